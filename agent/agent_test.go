@@ -2784,6 +2784,19 @@ func TestAgent_WriteVSCodeConfigs(t *testing.T) {
 	}, testutil.WaitShort, testutil.IntervalFast)
 }
 
+func TestAgent_AutoPortSharesWithoutRules(t *testing.T) {
+	t.Parallel()
+
+	// Without any port share rules the agent still reports an empty set,
+	// so that auto shares from earlier rules are removed.
+	//nolint:dogsled
+	_, client, _, _, _ := setupAgent(t, agentsdk.Manifest{}, 0)
+	require.Eventually(t, func() bool {
+		shares, sent := client.GetAutoPortShares()
+		return sent && len(shares) == 0
+	}, testutil.WaitShort, testutil.IntervalFast)
+}
+
 func TestAgent_DebugServer(t *testing.T) {
 	t.Parallel()
 
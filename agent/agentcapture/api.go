@@ -0,0 +1,121 @@
+package agentcapture
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/coder/coder/v2/coderd/httpapi"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+// Routes returns the HTTP handler for the capture API.
+func (m *Manager) Routes() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/", m.handleList)
+	r.Post("/", m.handleStart)
+	r.Route("/{port}", func(r chi.Router) {
+		r.Delete("/", m.handleDelete)
+		r.Post("/stop", m.handleStop)
+		r.Get("/har", m.handleHAR)
+	})
+	return r
+}
+
+func (m *Manager) handleList(rw http.ResponseWriter, r *http.Request) {
+	httpapi.Write(r.Context(), rw, http.StatusOK, m.List())
+}
+
+func (m *Manager) handleStart(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req codersdk.WorkspaceAgentHTTPCaptureRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+
+	capture, err := m.Start(req)
+	if errors.Is(err, ErrActive) {
+		httpapi.Write(ctx, rw, http.StatusConflict, codersdk.Response{
+			Message: fmt.Sprintf("Requests to port %d are already being captured.", req.Port),
+			Detail:  "Stop the active capture first.",
+		})
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Could not start capture.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	httpapi.Write(ctx, rw, http.StatusCreated, capture)
+}
+
+func (m *Manager) handleStop(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	port, ok := parsePort(rw, r)
+	if !ok {
+		return
+	}
+	capture, err := m.Stop(port)
+	if err != nil {
+		writeError(rw, r, err)
+		return
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, capture)
+}
+
+func (m *Manager) handleDelete(rw http.ResponseWriter, r *http.Request) {
+	port, ok := parsePort(rw, r)
+	if !ok {
+		return
+	}
+	if err := m.Delete(port); err != nil {
+		writeError(rw, r, err)
+		return
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+func (m *Manager) handleHAR(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	port, ok := parsePort(rw, r)
+	if !ok {
+		return
+	}
+	har, err := m.HAR(port)
+	if err != nil {
+		writeError(rw, r, err)
+		return
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, har)
+}
+
+func parsePort(rw http.ResponseWriter, r *http.Request) (uint16, bool) {
+	raw := chi.URLParam(r, "port")
+	port, err := strconv.ParseUint(raw, 10, 16)
+	if err != nil {
+		httpapi.Write(r.Context(), rw, http.StatusBadRequest, codersdk.Response{
+			Message: fmt.Sprintf("Invalid port %q.", raw),
+			Detail:  err.Error(),
+		})
+		return 0, false
+	}
+	return uint16(port), true
+}
+
+func writeError(rw http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, ErrNotFound) {
+		httpapi.Write(r.Context(), rw, http.StatusNotFound, codersdk.Response{
+			Message: "No capture found for this port.",
+		})
+		return
+	}
+	httpapi.Write(r.Context(), rw, http.StatusInternalServerError, codersdk.Response{
+		Message: "Internal error.",
+		Detail:  err.Error(),
+	})
+}
@@ -0,0 +1,478 @@
+// Package agentcapture records the HTTP requests to workspace apps. While a
+// capture is active the agent listens on the port of the app in the
+// tailnet, and proxies requests to the app to record them.
+package agentcapture
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/textproto"
+	"net/url"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"github.com/coder/coder/v2/codersdk"
+	"github.com/coder/coder/v2/codersdk/workspacesdk"
+	"github.com/coder/quartz"
+)
+
+const (
+	DefaultDuration     = 5 * time.Minute
+	MaxDuration         = time.Hour
+	DefaultMaxBodyBytes = 64 << 10
+	MaxBodyBytes        = 1 << 20
+	DefaultMaxEntries   = 500
+	MaxEntries          = 5000
+
+	// sniffTimeout is how long to wait for the first byte of a connection
+	// before passing it to the app unrecorded. Some protocols expect the
+	// server to speak first.
+	sniffTimeout = time.Second
+)
+
+var (
+	ErrNotFound = xerrors.New("no capture for this port")
+	ErrActive   = xerrors.New("a capture is already active for this port")
+)
+
+// Options configure a Manager.
+type Options struct {
+	// Listen listens on a port of the workspace agent in the tailnet.
+	Listen func(port uint16) (net.Listener, error)
+	// DialApp connects to the app on the given port. Defaults to dialing
+	// localhost.
+	DialApp func(ctx context.Context, port uint16) (net.Conn, error)
+	Clock   quartz.Clock
+}
+
+// Manager runs the captures of a workspace agent, one per port.
+type Manager struct {
+	logger slog.Logger
+	opts   Options
+
+	mu       sync.Mutex
+	closed   bool
+	captures map[uint16]*capture
+}
+
+// NewManager returns a Manager that records requests to the ports it
+// listens on with opts.Listen.
+func NewManager(logger slog.Logger, opts Options) *Manager {
+	if opts.Clock == nil {
+		opts.Clock = quartz.NewReal()
+	}
+	if opts.DialApp == nil {
+		opts.DialApp = func(ctx context.Context, port uint16) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "tcp", net.JoinHostPort("localhost", strconv.Itoa(int(port))))
+		}
+	}
+	return &Manager{
+		logger:   logger,
+		opts:     opts,
+		captures: map[uint16]*capture{},
+	}
+}
+
+// Start starts recording requests to a port. A stopped capture of the port
+// is discarded.
+func (m *Manager) Start(req codersdk.WorkspaceAgentHTTPCaptureRequest) (codersdk.WorkspaceAgentHTTPCapture, error) {
+	if req.Port < workspacesdk.AgentMinimumListeningPort {
+		return codersdk.WorkspaceAgentHTTPCapture{}, xerrors.Errorf("port %d is reserved by the agent", req.Port)
+	}
+	duration := time.Duration(req.DurationMillis) * time.Millisecond
+	switch {
+	case duration == 0:
+		duration = DefaultDuration
+	case duration < 0 || duration > MaxDuration:
+		return codersdk.WorkspaceAgentHTTPCapture{}, xerrors.Errorf("duration must be between 0 and %s", MaxDuration)
+	}
+	maxBodyBytes := req.MaxBodyBytes
+	switch {
+	case maxBodyBytes == 0:
+		maxBodyBytes = DefaultMaxBodyBytes
+	case maxBodyBytes < 0 || maxBodyBytes > MaxBodyBytes:
+		return codersdk.WorkspaceAgentHTTPCapture{}, xerrors.Errorf("max body bytes must be between 0 and %d", MaxBodyBytes)
+	}
+	maxEntries := req.MaxEntries
+	switch {
+	case maxEntries == 0:
+		maxEntries = DefaultMaxEntries
+	case maxEntries < 0 || maxEntries > MaxEntries:
+		return codersdk.WorkspaceAgentHTTPCapture{}, xerrors.Errorf("max entries must be between 0 and %d", MaxEntries)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return codersdk.WorkspaceAgentHTTPCapture{}, xerrors.New("agent is closing")
+	}
+	if prev, ok := m.captures[req.Port]; ok {
+		if prev.active() {
+			return codersdk.WorkspaceAgentHTTPCapture{}, ErrActive
+		}
+		delete(m.captures, req.Port)
+	}
+
+	ln, err := m.opts.Listen(req.Port)
+	if err != nil {
+		return codersdk.WorkspaceAgentHTTPCapture{}, xerrors.Errorf("listen on port %d: %w", req.Port, err)
+	}
+
+	now := m.opts.Clock.Now()
+	c := &capture{
+		logger:        m.logger.With(slog.F("port", req.Port)),
+		clock:         m.opts.Clock,
+		dial:          m.opts.DialApp,
+		port:          req.Port,
+		startedAt:     now,
+		endsAt:        now.Add(duration),
+		includeBodies: req.IncludeBodies,
+		maxBodyBytes:  maxBodyBytes,
+		maxEntries:    maxEntries,
+		redact:        redactions(req.RedactHeaders),
+		ln:            ln,
+		httpConns:     newConnListener(ln.Addr()),
+	}
+	c.proxy = c.newProxy()
+	c.server = &http.Server{
+		Handler:           c,
+		ReadHeaderTimeout: time.Minute,
+	}
+	c.mu.Lock()
+	c.timer = m.opts.Clock.AfterFunc(duration, c.stop, "agentcapture", "stop")
+	c.mu.Unlock()
+	go c.accept()
+	go func() {
+		_ = c.server.Serve(c.httpConns)
+	}()
+	m.captures[req.Port] = c
+	m.logger.Info(context.Background(), "started http capture",
+		slog.F("port", req.Port),
+		slog.F("duration", duration),
+		slog.F("include_bodies", req.IncludeBodies),
+	)
+	return c.describe(), nil
+}
+
+// Stop stops recording requests to a port, and keeps the recorded requests.
+func (m *Manager) Stop(port uint16) (codersdk.WorkspaceAgentHTTPCapture, error) {
+	m.mu.Lock()
+	c, ok := m.captures[port]
+	m.mu.Unlock()
+	if !ok {
+		return codersdk.WorkspaceAgentHTTPCapture{}, ErrNotFound
+	}
+	c.stop()
+	return c.describe(), nil
+}
+
+// Delete stops recording requests to a port and discards the recorded
+// requests.
+func (m *Manager) Delete(port uint16) error {
+	m.mu.Lock()
+	c, ok := m.captures[port]
+	delete(m.captures, port)
+	m.mu.Unlock()
+	if !ok {
+		return ErrNotFound
+	}
+	c.stop()
+	return nil
+}
+
+// List returns the captures ordered by port.
+func (m *Manager) List() []codersdk.WorkspaceAgentHTTPCapture {
+	m.mu.Lock()
+	captures := make([]*capture, 0, len(m.captures))
+	for _, c := range m.captures {
+		captures = append(captures, c)
+	}
+	m.mu.Unlock()
+
+	list := make([]codersdk.WorkspaceAgentHTTPCapture, 0, len(captures))
+	for _, c := range captures {
+		list = append(list, c.describe())
+	}
+	slices.SortFunc(list, func(a, b codersdk.WorkspaceAgentHTTPCapture) int {
+		return int(a.Port) - int(b.Port)
+	})
+	return list
+}
+
+// HAR returns the requests recorded for a port.
+func (m *Manager) HAR(port uint16) (codersdk.HAR, error) {
+	m.mu.Lock()
+	c, ok := m.captures[port]
+	m.mu.Unlock()
+	if !ok {
+		return codersdk.HAR{}, ErrNotFound
+	}
+	return c.har(), nil
+}
+
+// Close stops all captures.
+func (m *Manager) Close() error {
+	m.mu.Lock()
+	m.closed = true
+	captures := m.captures
+	m.captures = map[uint16]*capture{}
+	m.mu.Unlock()
+	for _, c := range captures {
+		c.stop()
+	}
+	return nil
+}
+
+type capture struct {
+	logger        slog.Logger
+	clock         quartz.Clock
+	dial          func(ctx context.Context, port uint16) (net.Conn, error)
+	port          uint16
+	startedAt     time.Time
+	endsAt        time.Time
+	includeBodies bool
+	maxBodyBytes  int64
+	maxEntries    int
+	redact        map[string]struct{}
+
+	ln        net.Listener
+	httpConns *connListener
+	server    *http.Server
+	proxy     *httputil.ReverseProxy
+	timer     *quartz.Timer
+
+	mu        sync.Mutex
+	stoppedAt *time.Time
+	entries   []codersdk.HAREntry
+	dropped   int
+	opaque    int
+}
+
+func (c *capture) active() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stoppedAt == nil
+}
+
+func (c *capture) stop() {
+	c.mu.Lock()
+	if c.stoppedAt != nil {
+		c.mu.Unlock()
+		return
+	}
+	now := c.clock.Now()
+	c.stoppedAt = &now
+	c.timer.Stop()
+	c.mu.Unlock()
+
+	// Closing the listener hands the port back to the app. Requests in
+	// flight are allowed to finish.
+	_ = c.ln.Close()
+	_ = c.httpConns.Close()
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := c.server.Shutdown(ctx); err != nil {
+			_ = c.server.Close()
+		}
+	}()
+	c.logger.Info(context.Background(), "stopped http capture")
+}
+
+func (c *capture) describe() codersdk.WorkspaceAgentHTTPCapture {
+	redact := make([]string, 0, len(c.redact))
+	for name := range c.redact {
+		redact = append(redact, name)
+	}
+	slices.Sort(redact)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return codersdk.WorkspaceAgentHTTPCapture{
+		Port:              c.port,
+		Active:            c.stoppedAt == nil,
+		StartedAt:         c.startedAt,
+		EndsAt:            c.endsAt,
+		StoppedAt:         c.stoppedAt,
+		IncludeBodies:     c.includeBodies,
+		MaxBodyBytes:      c.maxBodyBytes,
+		MaxEntries:        c.maxEntries,
+		RedactHeaders:     redact,
+		Entries:           len(c.entries),
+		Dropped:           c.dropped,
+		OpaqueConnections: c.opaque,
+	}
+}
+
+func (c *capture) record(entry codersdk.HAREntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= c.maxEntries {
+		c.entries = c.entries[1:]
+		c.dropped++
+	}
+	c.entries = append(c.entries, entry)
+}
+
+// accept sorts incoming connections. HTTP requests are recorded, anything
+// else is passed to the app as is.
+func (c *capture) accept() {
+	for {
+		conn, err := c.ln.Accept()
+		if err != nil {
+			return
+		}
+		go c.sniff(conn)
+	}
+}
+
+func (c *capture) sniff(conn net.Conn) {
+	br := bufio.NewReader(conn)
+	// Deadlines are wall clock times, so c.clock is not used here.
+	_ = conn.SetReadDeadline(time.Now().Add(sniffTimeout))
+	first, err := br.Peek(1)
+	_ = conn.SetReadDeadline(time.Time{})
+	sniffed := &bufferedConn{Conn: conn, r: br}
+	var netErr net.Error
+	switch {
+	case err == nil && first[0] >= 'A' && first[0] <= 'Z':
+		// HTTP/1 requests start with an upper case method.
+		if !c.httpConns.deliver(sniffed) {
+			_ = conn.Close()
+		}
+	case err == nil || (errors.As(err, &netErr) && netErr.Timeout()):
+		c.mu.Lock()
+		c.opaque++
+		c.mu.Unlock()
+		c.passthrough(sniffed)
+	default:
+		_ = conn.Close()
+	}
+}
+
+// passthrough copies bytes between conn and the app.
+func (c *capture) passthrough(conn net.Conn) {
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	app, err := c.dial(ctx, c.port)
+	cancel()
+	if err != nil {
+		c.logger.Debug(context.Background(), "dial app", slog.Error(err))
+		return
+	}
+	defer app.Close()
+	done := make(chan struct{}, 2)
+	go func() {
+		_, _ = io.Copy(app, conn)
+		done <- struct{}{}
+	}()
+	go func() {
+		_, _ = io.Copy(conn, app)
+		done <- struct{}{}
+	}()
+	<-done
+}
+
+func (c *capture) newProxy() *httputil.ReverseProxy {
+	target := &url.URL{Scheme: "http", Host: net.JoinHostPort("localhost", strconv.Itoa(int(c.port)))}
+	return &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(target)
+			// The app should not notice the capture.
+			pr.Out.Host = pr.In.Host
+		},
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return c.dial(ctx, c.port)
+			},
+			DisableCompression:  true,
+			MaxIdleConnsPerHost: 16,
+			IdleConnTimeout:     30 * time.Second,
+		},
+		// Stream responses, e.g. server-sent events, without buffering.
+		FlushInterval: -1,
+		ErrorHandler: func(rw http.ResponseWriter, r *http.Request, err error) {
+			c.logger.Debug(r.Context(), "proxy request to app", slog.Error(err))
+			rw.WriteHeader(http.StatusBadGateway)
+			_, _ = fmt.Fprintf(rw, "Failed to proxy request to the app on port %d: %s\n", c.port, err)
+		},
+	}
+}
+
+// redactions returns the canonical names of the headers to redact.
+func redactions(extra []string) map[string]struct{} {
+	names := map[string]struct{}{}
+	for _, name := range append(slices.Clone(codersdk.WorkspaceAgentHTTPCaptureRedactedHeaders), extra...) {
+		if name == "" {
+			continue
+		}
+		names[textproto.CanonicalMIMEHeaderKey(name)] = struct{}{}
+	}
+	return names
+}
+
+// connListener is a net.Listener for connections accepted elsewhere.
+type connListener struct {
+	addr   net.Addr
+	conns  chan net.Conn
+	once   sync.Once
+	closed chan struct{}
+}
+
+func newConnListener(addr net.Addr) *connListener {
+	return &connListener{
+		addr:   addr,
+		conns:  make(chan net.Conn),
+		closed: make(chan struct{}),
+	}
+}
+
+func (l *connListener) deliver(conn net.Conn) bool {
+	select {
+	case l.conns <- conn:
+		return true
+	case <-l.closed:
+		return false
+	}
+}
+
+func (l *connListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.closed:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *connListener) Close() error {
+	l.once.Do(func() { close(l.closed) })
+	return nil
+}
+
+func (l *connListener) Addr() net.Addr {
+	return l.addr
+}
+
+// bufferedConn reads the bytes that were peeked at before the rest of the
+// connection.
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
@@ -0,0 +1,307 @@
+package agentcapture_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/agent/agentcapture"
+	"github.com/coder/coder/v2/codersdk"
+	"github.com/coder/coder/v2/testutil"
+	"github.com/coder/quartz"
+)
+
+type fixture struct {
+	manager *agentcapture.Manager
+	clock   *quartz.Mock
+	port    uint16
+
+	mu     sync.Mutex
+	listen map[uint16]string
+}
+
+// newFixture runs an app that echoes request bodies, and a manager that
+// listens on a random local port in place of the tailnet.
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+
+	app := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		rw.Header().Set("Content-Type", "text/plain")
+		rw.Header().Set("Set-Cookie", "session=secret")
+		rw.Header().Set("X-Host", r.Host)
+		rw.WriteHeader(http.StatusTeapot)
+		_, _ = rw.Write([]byte("echo: " + string(body)))
+	}))
+	t.Cleanup(app.Close)
+	appURL, err := url.Parse(app.URL)
+	require.NoError(t, err)
+	appPort, err := strconv.ParseUint(appURL.Port(), 10, 16)
+	require.NoError(t, err)
+
+	f := &fixture{
+		clock:  quartz.NewMock(t),
+		port:   uint16(appPort),
+		listen: map[uint16]string{},
+	}
+	f.manager = agentcapture.NewManager(testutil.Logger(t), agentcapture.Options{
+		Listen: func(port uint16) (net.Listener, error) {
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				return nil, err
+			}
+			f.mu.Lock()
+			f.listen[port] = ln.Addr().String()
+			f.mu.Unlock()
+			return ln, nil
+		},
+		DialApp: func(ctx context.Context, _ uint16) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "tcp", appURL.Host)
+		},
+		Clock: f.clock,
+	})
+	t.Cleanup(func() { _ = f.manager.Close() })
+	return f
+}
+
+// waitEntries waits for the requests to be recorded, which happens after
+// the response is sent.
+func (f *fixture) waitEntries(t *testing.T, entries int) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		har, err := f.manager.HAR(f.port)
+		return err == nil && len(har.Log.Entries)+f.manager.List()[0].Dropped == entries
+	}, testutil.WaitShort, testutil.IntervalFast)
+}
+
+// addr is the address requests to the captured port are sent to.
+func (f *fixture) addr(port uint16) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.listen[port]
+}
+
+func TestCapture(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Record", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := testutil.Context(t, testutil.WaitShort)
+		f := newFixture(t)
+		capture, err := f.manager.Start(codersdk.WorkspaceAgentHTTPCaptureRequest{
+			Port:          f.port,
+			IncludeBodies: true,
+			RedactHeaders: []string{"x-api-key"},
+		})
+		require.NoError(t, err)
+		require.True(t, capture.Active)
+		require.Equal(t, agentcapture.DefaultDuration, capture.EndsAt.Sub(capture.StartedAt))
+		require.Contains(t, capture.RedactHeaders, "X-Api-Key")
+		require.Contains(t, capture.RedactHeaders, "Authorization")
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://"+f.addr(f.port)+"/hello?a=1", strings.NewReader("ping"))
+		require.NoError(t, err)
+		req.Host = "app.example.com"
+		req.Header.Set("Content-Type", "text/plain")
+		req.Header.Set("Authorization", "Bearer secret")
+		req.Header.Set("X-Api-Key", "secret")
+		req.Header.Set("Cookie", "session=secret")
+		req.Header.Set("X-Visible", "yes")
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		body, err := io.ReadAll(res.Body)
+		_ = res.Body.Close()
+		require.NoError(t, err)
+		require.Equal(t, http.StatusTeapot, res.StatusCode)
+		require.Equal(t, "echo: ping", string(body))
+		// The app sees the original host.
+		require.Equal(t, "app.example.com", res.Header.Get("X-Host"))
+
+		f.waitEntries(t, 1)
+		har, err := f.manager.HAR(f.port)
+		require.NoError(t, err)
+		require.Equal(t, "1.2", har.Log.Version)
+		require.Len(t, har.Log.Entries, 1)
+		entry := har.Log.Entries[0]
+		assert.Equal(t, http.MethodPost, entry.Request.Method)
+		assert.Equal(t, "http://app.example.com/hello?a=1", entry.Request.URL)
+		assert.Equal(t, []codersdk.HARNameValue{{Name: "a", Value: "1"}}, entry.Request.QueryString)
+		assert.Empty(t, entry.Request.Cookies)
+		require.NotNil(t, entry.Request.PostData)
+		assert.Equal(t, "ping", entry.Request.PostData.Text)
+		assert.EqualValues(t, 4, entry.Request.BodySize)
+
+		headers := map[string]string{}
+		for _, h := range entry.Request.Headers {
+			headers[h.Name] = h.Value
+		}
+		assert.Equal(t, codersdk.WorkspaceAgentHTTPCaptureRedacted, headers["Authorization"])
+		assert.Equal(t, codersdk.WorkspaceAgentHTTPCaptureRedacted, headers["X-Api-Key"])
+		assert.Equal(t, codersdk.WorkspaceAgentHTTPCaptureRedacted, headers["Cookie"])
+		assert.Equal(t, "yes", headers["X-Visible"])
+
+		assert.Equal(t, http.StatusTeapot, entry.Response.Status)
+		assert.Equal(t, "echo: ping", entry.Response.Content.Text)
+		assert.Empty(t, entry.Response.Content.Encoding)
+		assert.Empty(t, entry.Response.Cookies)
+		for _, h := range entry.Response.Headers {
+			if h.Name == "Set-Cookie" {
+				assert.Equal(t, codersdk.WorkspaceAgentHTTPCaptureRedacted, h.Value)
+			}
+		}
+	})
+
+	t.Run("NoBodies", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := testutil.Context(t, testutil.WaitShort)
+		f := newFixture(t)
+		_, err := f.manager.Start(codersdk.WorkspaceAgentHTTPCaptureRequest{Port: f.port})
+		require.NoError(t, err)
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://"+f.addr(f.port)+"/", strings.NewReader("ping"))
+		require.NoError(t, err)
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		_ = res.Body.Close()
+
+		f.waitEntries(t, 1)
+		har, err := f.manager.HAR(f.port)
+		require.NoError(t, err)
+		require.Len(t, har.Log.Entries, 1)
+		entry := har.Log.Entries[0]
+		assert.Nil(t, entry.Request.PostData)
+		assert.EqualValues(t, 4, entry.Request.BodySize)
+		assert.Empty(t, entry.Response.Content.Text)
+		assert.EqualValues(t, len("echo: ping"), entry.Response.Content.Size)
+	})
+
+	t.Run("MaxEntries", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := testutil.Context(t, testutil.WaitShort)
+		f := newFixture(t)
+		_, err := f.manager.Start(codersdk.WorkspaceAgentHTTPCaptureRequest{Port: f.port, MaxEntries: 2})
+		require.NoError(t, err)
+
+		for i := range 3 {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+f.addr(f.port)+"/"+strconv.Itoa(i), nil)
+			require.NoError(t, err)
+			res, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			_ = res.Body.Close()
+			f.waitEntries(t, i+1)
+		}
+
+		capture := f.manager.List()[0]
+		require.Equal(t, 2, capture.Entries)
+		require.Equal(t, 1, capture.Dropped)
+		har, err := f.manager.HAR(f.port)
+		require.NoError(t, err)
+		require.Len(t, har.Log.Entries, 2)
+		require.True(t, strings.HasSuffix(har.Log.Entries[0].Request.URL, "/1"))
+		require.Contains(t, har.Log.Comment, "1 older requests were dropped")
+	})
+
+	t.Run("Opaque", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := testutil.Context(t, testutil.WaitShort)
+		f := newFixture(t)
+		_, err := f.manager.Start(codersdk.WorkspaceAgentHTTPCaptureRequest{Port: f.port})
+		require.NoError(t, err)
+
+		// A connection that does not start like an HTTP request, e.g. a TLS
+		// client hello, is passed to the app as is.
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", f.addr(f.port))
+		require.NoError(t, err)
+		defer conn.Close()
+		_, err = conn.Write([]byte("\x16garbage\r\n\r\n"))
+		require.NoError(t, err)
+		// The app rejects it with a 400.
+		buf := make([]byte, 12)
+		_, err = io.ReadFull(conn, buf)
+		require.NoError(t, err)
+		require.Equal(t, "HTTP/1.1 400", string(buf))
+
+		require.Eventually(t, func() bool {
+			return f.manager.List()[0].OpaqueConnections == 1
+		}, testutil.WaitShort, testutil.IntervalFast)
+		har, err := f.manager.HAR(f.port)
+		require.NoError(t, err)
+		require.Empty(t, har.Log.Entries)
+	})
+
+	t.Run("StopAndDelete", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := testutil.Context(t, testutil.WaitShort)
+		f := newFixture(t)
+		_, err := f.manager.Start(codersdk.WorkspaceAgentHTTPCaptureRequest{Port: f.port})
+		require.NoError(t, err)
+
+		_, err = f.manager.Start(codersdk.WorkspaceAgentHTTPCaptureRequest{Port: f.port})
+		require.ErrorIs(t, err, agentcapture.ErrActive)
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+f.addr(f.port)+"/", nil)
+		require.NoError(t, err)
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		_ = res.Body.Close()
+		f.waitEntries(t, 1)
+
+		// The capture stops on its own once the duration has passed.
+		f.clock.Advance(agentcapture.DefaultDuration).MustWait(ctx)
+		capture := f.manager.List()[0]
+		require.False(t, capture.Active)
+		require.NotNil(t, capture.StoppedAt)
+		require.Equal(t, 1, capture.Entries)
+
+		// The listener is closed so the port is handed back to the app.
+		_, err = net.Dial("tcp", f.addr(f.port))
+		require.Error(t, err)
+
+		// The recording is kept until it is deleted or a new capture starts.
+		har, err := f.manager.HAR(f.port)
+		require.NoError(t, err)
+		require.Len(t, har.Log.Entries, 1)
+		_, err = f.manager.Stop(f.port)
+		require.NoError(t, err)
+
+		require.NoError(t, f.manager.Delete(f.port))
+		require.Empty(t, f.manager.List())
+		_, err = f.manager.HAR(f.port)
+		require.ErrorIs(t, err, agentcapture.ErrNotFound)
+		require.ErrorIs(t, f.manager.Delete(f.port), agentcapture.ErrNotFound)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		t.Parallel()
+
+		f := newFixture(t)
+		for _, req := range []codersdk.WorkspaceAgentHTTPCaptureRequest{
+			{Port: 1},
+			{Port: f.port, DurationMillis: (agentcapture.MaxDuration + time.Millisecond).Milliseconds()},
+			{Port: f.port, MaxBodyBytes: agentcapture.MaxBodyBytes + 1},
+			{Port: f.port, MaxEntries: -1},
+		} {
+			_, err := f.manager.Start(req)
+			require.Error(t, err, "%+v", req)
+		}
+		require.Empty(t, f.manager.List())
+	})
+}
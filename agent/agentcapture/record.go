@@ -0,0 +1,343 @@
+package agentcapture
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/v2/buildinfo"
+	"github.com/coder/coder/v2/codersdk"
+	"github.com/coder/quartz"
+)
+
+// ServeHTTP proxies a request to the app and records it.
+func (c *capture) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	start := c.clock.Now()
+	reqHeader := r.Header.Clone()
+
+	var reqBody *bodyRecorder
+	if r.Body != nil && r.Body != http.NoBody {
+		reqBody = &bodyRecorder{ReadCloser: r.Body, buf: newLimitedBuffer(c.bodyLimit())}
+		r.Body = reqBody
+	}
+	w := &responseRecorder{
+		ResponseWriter: rw,
+		clock:          c.clock,
+		body:           newLimitedBuffer(c.bodyLimit()),
+	}
+	c.proxy.ServeHTTP(w, r)
+	end := c.clock.Now()
+	if w.header == nil {
+		// The client went away before the app responded.
+		w.header = http.Header{}
+	}
+
+	c.record(c.entry(r, reqHeader, reqBody, w, start, end))
+}
+
+func (c *capture) bodyLimit() int64 {
+	if !c.includeBodies {
+		return 0
+	}
+	return c.maxBodyBytes
+}
+
+func (c *capture) entry(r *http.Request, header http.Header, body *bodyRecorder, w *responseRecorder, start, end time.Time) codersdk.HAREntry {
+	u := url.URL{Scheme: "http", Host: r.Host, Path: r.URL.Path, RawPath: r.URL.RawPath, RawQuery: r.URL.RawQuery}
+	if r.TLS != nil {
+		u.Scheme = "https"
+	}
+	header = header.Clone()
+	header.Set("Host", r.Host)
+
+	req := codersdk.HARRequest{
+		Method:      r.Method,
+		URL:         u.String(),
+		HTTPVersion: r.Proto,
+		Cookies:     c.cookies(header, (&http.Request{Header: header}).Cookies()),
+		Headers:     c.headers(header),
+		QueryString: queryString(r.URL.Query()),
+		HeadersSize: -1,
+		BodySize:    0,
+	}
+	if body != nil {
+		req.BodySize = body.buf.size
+		if c.includeBodies {
+			req.PostData = postData(header.Get("Content-Type"), body.buf)
+		}
+	}
+
+	wait := w.headerAt.Sub(start)
+	if w.headerAt.IsZero() {
+		wait = end.Sub(start)
+	}
+	res := codersdk.HARResponse{
+		Status:      w.status,
+		StatusText:  http.StatusText(w.status),
+		HTTPVersion: r.Proto,
+		Cookies:     c.cookies(w.header, (&http.Response{Header: w.header}).Cookies()),
+		Headers:     c.headers(w.header),
+		Content: codersdk.HARContent{
+			Size:     w.body.size,
+			MimeType: w.header.Get("Content-Type"),
+		},
+		RedirectURL: w.header.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    w.body.size,
+	}
+	if c.includeBodies {
+		res.Content.Text, res.Content.Encoding, res.Content.Comment = content(res.Content.MimeType, w.body)
+	}
+
+	entry := codersdk.HAREntry{
+		StartedDateTime: start,
+		Time:            millis(end.Sub(start)),
+		Request:         req,
+		Response:        res,
+		Timings: codersdk.HARTimings{
+			Send:    0,
+			Wait:    millis(wait),
+			Receive: millis(end.Sub(start) - wait),
+		},
+	}
+	if w.hijacked {
+		entry.Comment = "The connection was upgraded, messages after the upgrade are not recorded."
+	}
+	return entry
+}
+
+// headers returns the headers sorted by name, with redacted values
+// replaced.
+func (c *capture) headers(h http.Header) []codersdk.HARNameValue {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	headers := make([]codersdk.HARNameValue, 0, len(h))
+	for _, name := range names {
+		_, redacted := c.redact[http.CanonicalHeaderKey(name)]
+		for _, value := range h[name] {
+			if redacted {
+				value = codersdk.WorkspaceAgentHTTPCaptureRedacted
+			}
+			headers = append(headers, codersdk.HARNameValue{Name: name, Value: value})
+		}
+	}
+	return headers
+}
+
+// cookies lists the cookies, unless the header they are sent in is
+// redacted.
+func (c *capture) cookies(h http.Header, cookies []*http.Cookie) []codersdk.HARNameValue {
+	list := []codersdk.HARNameValue{}
+	for _, name := range []string{"Cookie", "Set-Cookie"} {
+		if _, redacted := c.redact[name]; redacted && len(h.Values(name)) > 0 {
+			return list
+		}
+	}
+	for _, cookie := range cookies {
+		list = append(list, codersdk.HARNameValue{Name: cookie.Name, Value: cookie.Value})
+	}
+	return list
+}
+
+func queryString(q url.Values) []codersdk.HARNameValue {
+	names := make([]string, 0, len(q))
+	for name := range q {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	list := make([]codersdk.HARNameValue, 0, len(q))
+	for _, name := range names {
+		for _, value := range q[name] {
+			list = append(list, codersdk.HARNameValue{Name: name, Value: value})
+		}
+	}
+	return list
+}
+
+func postData(mimeType string, body *limitedBuffer) *codersdk.HARPostData {
+	text, encoding, comment := content(mimeType, body)
+	if encoding != "" {
+		// HAR has no encoding for request bodies.
+		text = ""
+		comment = "The binary body is not recorded."
+	}
+	return &codersdk.HARPostData{MimeType: mimeType, Text: text, Comment: comment}
+}
+
+// content returns the recorded body as text, base64 encoded unless it is
+// valid UTF-8 text.
+func content(mimeType string, body *limitedBuffer) (text string, encoding string, comment string) {
+	data := body.buf.Bytes()
+	if body.truncated() {
+		comment = fmt.Sprintf("The body is truncated to %d of %d bytes.", len(data), body.size)
+	}
+	if isText(mimeType) && utf8.Valid(data) {
+		return string(data), "", comment
+	}
+	return base64.StdEncoding.EncodeToString(data), "base64", comment
+}
+
+func isText(mimeType string) bool {
+	if mimeType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		return false
+	}
+	switch {
+	case strings.HasPrefix(mediaType, "text/"),
+		strings.HasSuffix(mediaType, "+json"),
+		strings.HasSuffix(mediaType, "+xml"):
+		return true
+	}
+	switch mediaType {
+	case "application/json", "application/xml", "application/javascript",
+		"application/x-www-form-urlencoded", "application/graphql":
+		return true
+	}
+	return false
+}
+
+func millis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// har returns the recorded requests as an HTTP Archive.
+func (c *capture) har() codersdk.HAR {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	comment := fmt.Sprintf("Requests to port %d recorded by the Coder workspace agent from %s.", c.port, c.startedAt.Format(time.RFC3339))
+	if c.dropped > 0 {
+		comment += fmt.Sprintf(" %d older requests were dropped.", c.dropped)
+	}
+	if c.opaque > 0 {
+		comment += fmt.Sprintf(" %d connections were not HTTP, e.g. TLS, and were not recorded.", c.opaque)
+	}
+	return codersdk.HAR{
+		Log: codersdk.HARLog{
+			Version: "1.2",
+			Creator: codersdk.HARCreator{
+				Name:    "coder-agent",
+				Version: buildinfo.Version(),
+			},
+			Entries: slices.Clone(c.entries),
+			Comment: comment,
+		},
+	}
+}
+
+// limitedBuffer keeps the first max bytes written to it and counts the
+// rest.
+type limitedBuffer struct {
+	max  int64
+	buf  bytes.Buffer
+	size int64
+}
+
+func newLimitedBuffer(maxBytes int64) *limitedBuffer {
+	return &limitedBuffer{max: maxBytes}
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	b.size += int64(len(p))
+	if remaining := b.max - int64(b.buf.Len()); remaining > 0 {
+		_, _ = b.buf.Write(p[:min(int64(len(p)), remaining)])
+	}
+	return len(p), nil
+}
+
+func (b *limitedBuffer) truncated() bool {
+	return b.size > int64(b.buf.Len())
+}
+
+// bodyRecorder records the request body as it is read by the proxy.
+type bodyRecorder struct {
+	io.ReadCloser
+	buf *limitedBuffer
+}
+
+func (b *bodyRecorder) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	_, _ = b.buf.Write(p[:n])
+	return n, err
+}
+
+var (
+	_ http.ResponseWriter = (*responseRecorder)(nil)
+	_ http.Hijacker       = (*responseRecorder)(nil)
+	_ http.Flusher        = (*responseRecorder)(nil)
+)
+
+// responseRecorder records the response written by the proxy.
+type responseRecorder struct {
+	http.ResponseWriter
+	clock quartz.Clock
+
+	status   int
+	header   http.Header
+	headerAt time.Time
+	body     *limitedBuffer
+	hijacked bool
+}
+
+func (w *responseRecorder) WriteHeader(status int) {
+	// Informational responses are followed by the final response.
+	if w.header == nil && (status >= 200 || status == http.StatusSwitchingProtocols) {
+		w.snapshot(status)
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseRecorder) Write(p []byte) (int, error) {
+	if w.header == nil {
+		w.snapshot(http.StatusOK)
+	}
+	_, _ = w.body.Write(p)
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *responseRecorder) snapshot(status int) {
+	w.status = status
+	w.header = w.ResponseWriter.Header().Clone()
+	w.headerAt = w.clock.Now()
+}
+
+func (w *responseRecorder) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, xerrors.Errorf("%T is not a http.Hijacker", w.ResponseWriter)
+	}
+	// The proxy hijacks the connection to switch protocols, and writes the
+	// response itself.
+	if w.header == nil {
+		w.snapshot(http.StatusSwitchingProtocols)
+	}
+	w.hijacked = true
+	return hijacker.Hijack()
+}
+
+func (w *responseRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
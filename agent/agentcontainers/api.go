@@ -11,6 +11,7 @@ import (
 	"regexp"
 	"runtime"
 	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -28,8 +29,10 @@ import (
 	"github.com/coder/coder/v2/coderd/httpapi"
 	"github.com/coder/coder/v2/codersdk"
 	"github.com/coder/coder/v2/codersdk/agentsdk"
+	"github.com/coder/coder/v2/codersdk/wsjson"
 	"github.com/coder/coder/v2/provisioner"
 	"github.com/coder/quartz"
+	"github.com/coder/websocket"
 )
 
 const (
@@ -44,6 +47,10 @@ const (
 
 	maxAgentNameLength     = 64
 	maxAttemptsToNameAgent = 5
+
+	// maxBuildLogLines is the number of devcontainer build log lines
+	// retained per devcontainer for the logs endpoint.
+	maxBuildLogLines = 1000
 )
 
 // API is responsible for container-related operations in the agent.
@@ -86,6 +93,9 @@ type API struct {
 	injectedSubAgentProcs    map[string]subAgentProcess                     // By workspace folder.
 	usingWorkspaceFolderName map[string]bool                                // By workspace folder.
 	ignoredDevcontainers     map[string]bool                                // By workspace folder. Tracks three states (true, false and not checked).
+	buildLogs                map[string][]codersdk.WorkspaceAgentLog        // By workspace folder.
+	lastBuildLogID           int64
+	buildLogsUpdated         chan struct{} // Closed and replaced when build logs or build status change.
 	asyncWg                  sync.WaitGroup
 }
 
@@ -300,6 +310,8 @@ func NewAPI(logger slog.Logger, options ...Option) *API {
 		scriptLogger:                func(uuid.UUID) ScriptLogger { return noopScriptLogger{} },
 		injectedSubAgentProcs:       make(map[string]subAgentProcess),
 		usingWorkspaceFolderName:    make(map[string]bool),
+		buildLogs:                   make(map[string][]codersdk.WorkspaceAgentLog),
+		buildLogsUpdated:            make(chan struct{}),
 	}
 	// The ctx and logger must be set before applying options to avoid
 	// nil pointer dereference.
@@ -538,7 +550,11 @@ func (api *API) Routes() http.Handler {
 	// TODO(mafredri): Simplify this route as the previous /devcontainers
 	// /-route was dropped. We can drop the /devcontainers prefix here too.
 	r.Route("/devcontainers/{devcontainer}", func(r chi.Router) {
+		r.Delete("/", api.handleDevcontainerDelete)
+		r.Get("/logs", api.handleDevcontainerLogs)
 		r.Post("/recreate", api.handleDevcontainerRecreate)
+		r.Post("/start", api.handleDevcontainerStart)
+		r.Post("/stop", api.handleDevcontainerStop)
 	})
 
 	return r
@@ -663,6 +679,7 @@ func (api *API) processUpdatedContainersLocked(ctx context.Context, updated code
 			}
 
 			dc.Container = container
+			dc.Features = DevcontainerFeaturesFromLabels(container.Labels)
 			api.knownDevcontainers[dc.WorkspaceFolder] = dc
 			continue
 		}
@@ -675,6 +692,7 @@ func (api *API) processUpdatedContainersLocked(ctx context.Context, updated code
 			Status:          "",    // Updated later based on container state.
 			Dirty:           false, // Updated later based on config file changes.
 			Container:       container,
+			Features:        DevcontainerFeaturesFromLabels(container.Labels),
 		}
 
 		if configFile != "" {
@@ -706,6 +724,9 @@ func (api *API) processUpdatedContainersLocked(ctx context.Context, updated code
 		case dc.Status == codersdk.WorkspaceAgentDevcontainerStatusStarting:
 			continue // This state is handled by the recreation routine.
 
+		case devcontainerBusy(dc):
+			continue // This state is handled by the action in progress.
+
 		case dc.Status == codersdk.WorkspaceAgentDevcontainerStatusError && (dc.Container == nil || dc.Container.CreatedAt.Before(api.recreateErrorTimes[dc.WorkspaceFolder])):
 			continue // The devcontainer needs to be recreated.
 
@@ -921,16 +942,23 @@ func (api *API) handleDevcontainerRecreate(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	api.mu.Lock()
-
-	var dc codersdk.WorkspaceAgentDevcontainer
-	for _, knownDC := range api.knownDevcontainers {
-		if knownDC.ID.String() == devcontainerID {
-			dc = knownDC
-			break
+	var noCache bool
+	if v := r.URL.Query().Get("no_cache"); v != "" {
+		var err error
+		noCache, err = strconv.ParseBool(v)
+		if err != nil {
+			httpapi.Write(ctx, w, http.StatusBadRequest, codersdk.Response{
+				Message: "Invalid no_cache query parameter.",
+				Detail:  err.Error(),
+			})
+			return
 		}
 	}
-	if dc.ID == uuid.Nil {
+
+	api.mu.Lock()
+
+	dc, ok := api.devcontainerByIDLocked(devcontainerID)
+	if !ok {
 		api.mu.Unlock()
 
 		httpapi.Write(ctx, w, http.StatusNotFound, codersdk.Response{
@@ -948,6 +976,12 @@ func (api *API) handleDevcontainerRecreate(w http.ResponseWriter, r *http.Reques
 		})
 		return
 	}
+	if devcontainerBusy(dc) {
+		api.mu.Unlock()
+
+		writeDevcontainerBusy(ctx, w, dc)
+		return
+	}
 
 	// Update the status so that we don't try to recreate the
 	// devcontainer multiple times in parallel.
@@ -955,8 +989,12 @@ func (api *API) handleDevcontainerRecreate(w http.ResponseWriter, r *http.Reques
 	dc.Container = nil
 	dc.Error = ""
 	api.knownDevcontainers[dc.WorkspaceFolder] = dc
+	upOpts := []DevcontainerCLIUpOptions{WithRemoveExistingContainer()}
+	if noCache {
+		upOpts = append(upOpts, WithBuildNoCache())
+	}
 	go func() {
-		_ = api.CreateDevcontainer(dc.WorkspaceFolder, dc.ConfigPath, WithRemoveExistingContainer())
+		_ = api.CreateDevcontainer(dc.WorkspaceFolder, dc.ConfigPath, upOpts...)
 	}()
 
 	api.mu.Unlock()
@@ -967,6 +1005,370 @@ func (api *API) handleDevcontainerRecreate(w http.ResponseWriter, r *http.Reques
 	})
 }
 
+// devcontainerByIDLocked returns the known devcontainer with the given
+// ID. This method assumes that api.mu is held.
+func (api *API) devcontainerByIDLocked(id string) (codersdk.WorkspaceAgentDevcontainer, bool) {
+	for _, dc := range api.knownDevcontainers {
+		if dc.ID.String() == id {
+			return dc, true
+		}
+	}
+	return codersdk.WorkspaceAgentDevcontainer{}, false
+}
+
+// devcontainerBusy reports whether an action is in progress on the
+// devcontainer, during which no other action may start.
+func devcontainerBusy(dc codersdk.WorkspaceAgentDevcontainer) bool {
+	switch dc.Status {
+	case codersdk.WorkspaceAgentDevcontainerStatusStarting,
+		codersdk.WorkspaceAgentDevcontainerStatusStopping,
+		codersdk.WorkspaceAgentDevcontainerStatusDeleting:
+		return true
+	default:
+		return false
+	}
+}
+
+func writeDevcontainerBusy(ctx context.Context, w http.ResponseWriter, dc codersdk.WorkspaceAgentDevcontainer) {
+	if dc.Status == codersdk.WorkspaceAgentDevcontainerStatusStarting {
+		httpapi.Write(ctx, w, http.StatusConflict, codersdk.Response{
+			Message: "Devcontainer is being created",
+			Detail:  fmt.Sprintf("Devcontainer %q is currently being created, try again when it has finished.", dc.Name),
+		})
+		return
+	}
+	httpapi.Write(ctx, w, http.StatusConflict, codersdk.Response{
+		Message: "Devcontainer is busy",
+		Detail:  fmt.Sprintf("Devcontainer %q is currently %s, try again when it has finished.", dc.Name, dc.Status),
+	})
+}
+
+// devcontainerForAction looks up the devcontainer referenced by the
+// request and sets its status to the given transitional status, so that
+// no other action starts on it concurrently. It writes an error response
+// and returns false if the action cannot proceed. Otherwise, done must
+// be called once the action has finished to restore the previous status.
+func (api *API) devcontainerForAction(w http.ResponseWriter, r *http.Request, status codersdk.WorkspaceAgentDevcontainerStatus) (dc codersdk.WorkspaceAgentDevcontainer, done func(), ok bool) {
+	ctx := r.Context()
+	devcontainerID := chi.URLParam(r, "devcontainer")
+
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	dc, ok = api.devcontainerByIDLocked(devcontainerID)
+	if !ok {
+		httpapi.Write(ctx, w, http.StatusNotFound, codersdk.Response{
+			Message: "Devcontainer not found.",
+			Detail:  fmt.Sprintf("Could not find devcontainer with ID: %q", devcontainerID),
+		})
+		return dc, nil, false
+	}
+	if devcontainerBusy(dc) {
+		writeDevcontainerBusy(ctx, w, dc)
+		return dc, nil, false
+	}
+
+	previous := dc.Status
+	dc.Status = status
+	api.knownDevcontainers[dc.WorkspaceFolder] = dc
+	done = func() {
+		api.mu.Lock()
+		defer api.mu.Unlock()
+		// The devcontainer may have been forgotten or handed over to
+		// the creation routine in the meantime.
+		if current, ok := api.knownDevcontainers[dc.WorkspaceFolder]; ok && current.Status == status {
+			current.Status = previous
+			api.knownDevcontainers[dc.WorkspaceFolder] = current
+		}
+	}
+	return dc, done, true
+}
+
+// handleDevcontainerStop handles the HTTP request to stop the container
+// backing a devcontainer.
+func (api *API) handleDevcontainerStop(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	dc, done, ok := api.devcontainerForAction(w, r, codersdk.WorkspaceAgentDevcontainerStatusStopping)
+	if !ok {
+		return
+	}
+	if dc.Container == nil || !dc.Container.Running {
+		done()
+		httpapi.Write(ctx, w, http.StatusConflict, codersdk.Response{
+			Message: "Devcontainer is not running",
+			Detail:  fmt.Sprintf("Devcontainer %q has no running container to stop.", dc.Name),
+		})
+		return
+	}
+
+	stopCtx, cancel := context.WithTimeout(ctx, defaultOperationTimeout)
+	defer cancel()
+	err := api.ccli.Stop(stopCtx, dc.Container.ID)
+	done()
+	if err != nil {
+		httpapi.Write(ctx, w, http.StatusInternalServerError, codersdk.Response{
+			Message: "Could not stop devcontainer",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	if err := api.RefreshContainers(ctx); err != nil {
+		api.logger.Error(ctx, "refresh containers after devcontainer stop failed", slog.Error(err))
+	}
+
+	httpapi.Write(ctx, w, http.StatusOK, codersdk.Response{
+		Message: "Devcontainer stopped",
+		Detail:  fmt.Sprintf("Devcontainer %q has been stopped.", dc.Name),
+	})
+}
+
+// handleDevcontainerStart handles the HTTP request to start a stopped
+// devcontainer. If the devcontainer has no container, e.g. because it
+// was deleted, it is created instead.
+func (api *API) handleDevcontainerStart(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	dc, done, ok := api.devcontainerForAction(w, r, codersdk.WorkspaceAgentDevcontainerStatusStarting)
+	if !ok {
+		return
+	}
+	if dc.Container != nil && dc.Container.Running {
+		done()
+		httpapi.Write(ctx, w, http.StatusConflict, codersdk.Response{
+			Message: "Devcontainer is already running",
+			Detail:  fmt.Sprintf("Devcontainer %q is already running.", dc.Name),
+		})
+		return
+	}
+
+	if dc.Container == nil {
+		// The creation routine takes over the starting status and sets
+		// the status once the devcontainer has been created.
+		api.mu.Lock()
+		dc = api.knownDevcontainers[dc.WorkspaceFolder]
+		dc.Error = ""
+		api.knownDevcontainers[dc.WorkspaceFolder] = dc
+		go func() {
+			_ = api.CreateDevcontainer(dc.WorkspaceFolder, dc.ConfigPath)
+		}()
+		api.mu.Unlock()
+
+		httpapi.Write(ctx, w, http.StatusOK, codersdk.Response{
+			Message: "Devcontainer creation initiated",
+			Detail:  fmt.Sprintf("Devcontainer %q has no container, creation has started.", dc.Name),
+		})
+		return
+	}
+
+	startCtx, cancel := context.WithTimeout(ctx, defaultOperationTimeout)
+	defer cancel()
+	err := api.ccli.Start(startCtx, dc.Container.ID)
+	done()
+	if err != nil {
+		httpapi.Write(ctx, w, http.StatusInternalServerError, codersdk.Response{
+			Message: "Could not start devcontainer",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	if err := api.RefreshContainers(ctx); err != nil {
+		api.logger.Error(ctx, "refresh containers after devcontainer start failed", slog.Error(err))
+	}
+
+	httpapi.Write(ctx, w, http.StatusOK, codersdk.Response{
+		Message: "Devcontainer started",
+		Detail:  fmt.Sprintf("Devcontainer %q has been started.", dc.Name),
+	})
+}
+
+// handleDevcontainerDelete handles the HTTP request to remove the
+// container backing a devcontainer along with its subagent.
+func (api *API) handleDevcontainerDelete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	dc, done, ok := api.devcontainerForAction(w, r, codersdk.WorkspaceAgentDevcontainerStatusDeleting)
+	if !ok {
+		return
+	}
+
+	deleteCtx, cancel := context.WithTimeout(ctx, defaultOperationTimeout)
+	defer cancel()
+
+	if dc.Container != nil {
+		if err := api.ccli.Remove(deleteCtx, dc.Container.ID); err != nil {
+			done()
+			httpapi.Write(ctx, w, http.StatusInternalServerError, codersdk.Response{
+				Message: "Could not delete devcontainer",
+				Detail:  err.Error(),
+			})
+			return
+		}
+	}
+
+	done()
+
+	api.mu.Lock()
+	proc, injected := api.injectedSubAgentProcs[dc.WorkspaceFolder]
+	if injected {
+		proc.stop()
+		delete(api.injectedSubAgentProcs, dc.WorkspaceFolder)
+	}
+	// Devcontainers defined in the template remain known so that they
+	// can be started again, discovered ones are forgotten.
+	if !api.devcontainerNames[dc.Name] {
+		delete(api.knownDevcontainers, dc.WorkspaceFolder)
+		delete(api.buildLogs, dc.WorkspaceFolder)
+		api.notifyBuildLogsLocked()
+	}
+	api.mu.Unlock()
+
+	if injected && proc.agent.ID != uuid.Nil {
+		client := *api.subAgentClient.Load()
+		if err := client.Delete(deleteCtx, proc.agent.ID); err != nil {
+			api.logger.Error(ctx, "delete subagent for deleted devcontainer failed", slog.F("devcontainer_id", dc.ID), slog.Error(err))
+		}
+	}
+	if err := api.RefreshContainers(ctx); err != nil {
+		api.logger.Error(ctx, "refresh containers after devcontainer delete failed", slog.Error(err))
+	}
+
+	httpapi.Write(ctx, w, http.StatusOK, codersdk.Response{
+		Message: "Devcontainer deleted",
+		Detail:  fmt.Sprintf("Devcontainer %q has been deleted.", dc.Name),
+	})
+}
+
+// handleDevcontainerLogs handles the HTTP request to get the output of
+// the most recent build of a devcontainer. The after query parameter
+// can be used to only fetch logs newer than a previously seen log ID.
+// With the follow query parameter, the logs are streamed over a
+// websocket until the devcontainer is no longer being built.
+func (api *API) handleDevcontainerLogs(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	devcontainerID := chi.URLParam(r, "devcontainer")
+
+	var after int64
+	if v := r.URL.Query().Get("after"); v != "" {
+		var err error
+		after, err = strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			httpapi.Write(ctx, w, http.StatusBadRequest, codersdk.Response{
+				Message: "Invalid after query parameter.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+	}
+
+	logs, building, updated, ok := api.devcontainerBuildLogs(devcontainerID, after)
+	if !ok {
+		httpapi.Write(ctx, w, http.StatusNotFound, codersdk.Response{
+			Message: "Devcontainer not found.",
+			Detail:  fmt.Sprintf("Could not find devcontainer with ID: %q", devcontainerID),
+		})
+		return
+	}
+
+	if !r.URL.Query().Has("follow") {
+		httpapi.Write(ctx, w, http.StatusOK, codersdk.WorkspaceAgentDevcontainerLogsResponse{
+			Logs: logs,
+		})
+		return
+	}
+
+	conn, err := websocket.Accept(w, r, nil)
+	if err != nil {
+		httpapi.Write(ctx, w, http.StatusBadRequest, codersdk.Response{
+			Message: "Failed to accept websocket.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	encoder := wsjson.NewEncoder[[]codersdk.WorkspaceAgentLog](conn, websocket.MessageText)
+	defer encoder.Close(websocket.StatusNormalClosure)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go httpapi.HeartbeatClose(ctx, api.logger, cancel, conn)
+
+	for {
+		if len(logs) > 0 {
+			if err := encoder.Encode(logs); err != nil {
+				return
+			}
+			after = logs[len(logs)-1].ID
+		}
+		// The build logs are complete once the devcontainer is no
+		// longer being built.
+		if !building {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-api.ctx.Done():
+			return
+		case <-updated:
+		}
+		logs, building, updated, ok = api.devcontainerBuildLogs(devcontainerID, after)
+		if !ok {
+			return
+		}
+	}
+}
+
+// devcontainerBuildLogs returns the build logs of the devcontainer after
+// the given log ID, whether it is being built, and a channel that is
+// closed when either changes.
+func (api *API) devcontainerBuildLogs(devcontainerID string, after int64) (logs []codersdk.WorkspaceAgentLog, building bool, updated <-chan struct{}, ok bool) {
+	api.mu.RLock()
+	defer api.mu.RUnlock()
+
+	dc, ok := api.devcontainerByIDLocked(devcontainerID)
+	if !ok {
+		return nil, false, nil, false
+	}
+	logs = []codersdk.WorkspaceAgentLog{}
+	for _, l := range api.buildLogs[dc.WorkspaceFolder] {
+		if l.ID > after {
+			logs = append(logs, l)
+		}
+	}
+	return logs, dc.Status == codersdk.WorkspaceAgentDevcontainerStatusStarting, api.buildLogsUpdated, true
+}
+
+// appendBuildLogs records devcontainer build output so that it can be
+// served by the logs endpoint, retaining at most maxBuildLogLines.
+func (api *API) appendBuildLogs(workspaceFolder string, sourceID uuid.UUID, logs ...agentsdk.Log) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	buf := api.buildLogs[workspaceFolder]
+	for _, l := range logs {
+		api.lastBuildLogID++
+		buf = append(buf, codersdk.WorkspaceAgentLog{
+			ID:        api.lastBuildLogID,
+			CreatedAt: l.CreatedAt,
+			Output:    l.Output,
+			Level:     l.Level,
+			SourceID:  sourceID,
+		})
+	}
+	if len(buf) > maxBuildLogLines {
+		buf = slices.Clone(buf[len(buf)-maxBuildLogLines:])
+	}
+	api.buildLogs[workspaceFolder] = buf
+	api.notifyBuildLogsLocked()
+}
+
+// notifyBuildLogsLocked wakes the requests following build logs. The
+// lock must be held.
+func (api *API) notifyBuildLogsLocked() {
+	close(api.buildLogsUpdated)
+	api.buildLogsUpdated = make(chan struct{})
+}
+
 // createDevcontainer should run in its own goroutine and is responsible for
 // recreating a devcontainer based on the provided devcontainer configuration.
 // It updates the devcontainer status and logs the process. The configPath is
@@ -1004,6 +1406,9 @@ func (api *API) CreateDevcontainer(workspaceFolder, configPath string, opts ...D
 		logSourceID = agentsdk.ExternalLogSourceID
 	}
 
+	// Only keep the logs of the most recent build.
+	delete(api.buildLogs, dc.WorkspaceFolder)
+
 	api.asyncWg.Add(1)
 	defer api.asyncWg.Done()
 	api.mu.Unlock()
@@ -1022,9 +1427,13 @@ func (api *API) CreateDevcontainer(workspaceFolder, configPath string, opts ...D
 			logger.Error(flushCtx, "flush devcontainer logs failed during recreation", slog.Error(err))
 		}
 	}()
-	infoW := agentsdk.LogsWriter(ctx, scriptLogger.Send, logSourceID, codersdk.LogLevelInfo)
+	sendLogs := func(ctx context.Context, logs ...agentsdk.Log) error {
+		api.appendBuildLogs(dc.WorkspaceFolder, logSourceID, logs...)
+		return scriptLogger.Send(ctx, logs...)
+	}
+	infoW := agentsdk.LogsWriter(ctx, sendLogs, logSourceID, codersdk.LogLevelInfo)
 	defer infoW.Close()
-	errW := agentsdk.LogsWriter(ctx, scriptLogger.Send, logSourceID, codersdk.LogLevelError)
+	errW := agentsdk.LogsWriter(ctx, sendLogs, logSourceID, codersdk.LogLevelError)
 	defer errW.Close()
 
 	logger.Debug(ctx, "starting devcontainer recreation")
@@ -1046,6 +1455,7 @@ func (api *API) CreateDevcontainer(workspaceFolder, configPath string, opts ...D
 		dc.Error = err.Error()
 		api.knownDevcontainers[dc.WorkspaceFolder] = dc
 		api.recreateErrorTimes[dc.WorkspaceFolder] = api.clock.Now("agentcontainers", "recreate", "errorTimes")
+		api.notifyBuildLogsLocked()
 		api.mu.Unlock()
 
 		return xerrors.Errorf("start devcontainer: %w", err)
@@ -1070,6 +1480,7 @@ func (api *API) CreateDevcontainer(workspaceFolder, configPath string, opts ...D
 	dc.Error = ""
 	api.recreateSuccessTimes[dc.WorkspaceFolder] = api.clock.Now("agentcontainers", "recreate", "successTimes")
 	api.knownDevcontainers[dc.WorkspaceFolder] = dc
+	api.notifyBuildLogsLocked()
 	api.mu.Unlock()
 
 	// Ensure an immediate refresh to accurately reflect the
@@ -1,11 +1,26 @@
 package agentcontainers
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
 	"testing"
 
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"cdr.dev/slog"
+	"cdr.dev/slog/sloggers/slogtest"
+	"github.com/coder/coder/v2/agent/agentcontainers/watcher"
+	"github.com/coder/coder/v2/codersdk"
+	"github.com/coder/coder/v2/codersdk/agentsdk"
+	"github.com/coder/coder/v2/codersdk/wsjson"
 	"github.com/coder/coder/v2/provisioner"
+	"github.com/coder/coder/v2/testutil"
+	"github.com/coder/websocket"
 )
 
 func TestSafeAgentName(t *testing.T) {
@@ -356,3 +371,76 @@ func TestExpandedAgentName(t *testing.T) {
 		})
 	}
 }
+
+func TestDevcontainerLogsFollow(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Context(t, testutil.WaitShort)
+	logger := slogtest.Make(t, &slogtest.Options{IgnoreErrors: true}).Leveled(slog.LevelDebug)
+	dc := codersdk.WorkspaceAgentDevcontainer{
+		ID:              uuid.New(),
+		Name:            "test-devcontainer",
+		WorkspaceFolder: "/workspace/test",
+		ConfigPath:      "/workspace/test/.devcontainer/devcontainer.json",
+		Status:          codersdk.WorkspaceAgentDevcontainerStatusStarting,
+	}
+	api := NewAPI(logger,
+		WithWatcher(watcher.NewNoop()),
+		WithDevcontainers([]codersdk.WorkspaceAgentDevcontainer{dc}, nil),
+	)
+	defer api.Close()
+
+	r := chi.NewRouter()
+	r.Get("/devcontainers/{devcontainer}/logs", api.handleDevcontainerLogs)
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	sourceID := uuid.New()
+	api.appendBuildLogs(dc.WorkspaceFolder, sourceID, agentsdk.Log{Output: "first", Level: codersdk.LogLevelInfo})
+
+	conn, res, err := websocket.Dial(ctx, srv.URL+"/devcontainers/"+dc.ID.String()+"/logs?follow", nil)
+	require.NoError(t, err)
+	if res != nil && res.Body != nil {
+		defer res.Body.Close()
+	}
+	decoder := wsjson.NewDecoder[[]codersdk.WorkspaceAgentLog](conn, websocket.MessageText, logger)
+	defer decoder.Close()
+	logs := decoder.Chan()
+
+	// The logs so far are sent first, then new logs as they are appended.
+	got := testutil.RequireReceive(ctx, t, logs)
+	require.Len(t, got, 1)
+	require.Equal(t, "first", got[0].Output)
+	require.Equal(t, sourceID, got[0].SourceID)
+
+	api.appendBuildLogs(dc.WorkspaceFolder, sourceID, agentsdk.Log{Output: "second", Level: codersdk.LogLevelInfo})
+	got = testutil.RequireReceive(ctx, t, logs)
+	require.Len(t, got, 1)
+	require.Equal(t, "second", got[0].Output)
+
+	// Without follow, only the logs after the given ID are returned.
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/devcontainers/"+dc.ID.String()+"/logs?after="+strconv.FormatInt(got[0].ID-1, 10), nil)
+	require.NoError(t, err)
+	pageRes, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer pageRes.Body.Close()
+	var page codersdk.WorkspaceAgentDevcontainerLogsResponse
+	require.NoError(t, json.NewDecoder(pageRes.Body).Decode(&page))
+	require.Len(t, page.Logs, 1)
+	require.Equal(t, "second", page.Logs[0].Output)
+
+	// The stream ends once the devcontainer has been built.
+	api.mu.Lock()
+	dc = api.knownDevcontainers[dc.WorkspaceFolder]
+	dc.Status = codersdk.WorkspaceAgentDevcontainerStatusRunning
+	api.knownDevcontainers[dc.WorkspaceFolder] = dc
+	api.notifyBuildLogsLocked()
+	api.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for the log stream to end")
+	case _, ok := <-logs:
+		require.False(t, ok, "expected the log stream to end")
+	}
+}
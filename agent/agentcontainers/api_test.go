@@ -47,6 +47,10 @@ type fakeContainerCLI struct {
 	archErr    error
 	copyErr    error
 	execErr    error
+	stopErr    error
+	stopErrC   chan error // If set, receive the error to return instead of stopErr.
+	startErr   error
+	removeErr  error
 }
 
 func (f *fakeContainerCLI) List(_ context.Context) (codersdk.WorkspaceAgentListContainersResponse, error) {
@@ -65,6 +69,26 @@ func (f *fakeContainerCLI) ExecAs(ctx context.Context, name, user string, args .
 	return nil, f.execErr
 }
 
+func (f *fakeContainerCLI) Stop(ctx context.Context, _ string) error {
+	if f.stopErrC != nil {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-f.stopErrC:
+			return err
+		}
+	}
+	return f.stopErr
+}
+
+func (f *fakeContainerCLI) Start(_ context.Context, _ string) error {
+	return f.startErr
+}
+
+func (f *fakeContainerCLI) Remove(_ context.Context, _ string) error {
+	return f.removeErr
+}
+
 // fakeDevcontainerCLI implements the agentcontainers.DevcontainerCLI
 // interface for testing.
 type fakeDevcontainerCLI struct {
@@ -833,6 +857,261 @@ func TestAPI(t *testing.T) {
 		}
 	})
 
+	t.Run("Lifecycle", func(t *testing.T) {
+		t.Parallel()
+
+		devcontainerID := uuid.New()
+		workspaceFolder := "/workspace/test"
+		configPath := "/workspace/test/.devcontainer/devcontainer.json"
+
+		runningContainer := codersdk.WorkspaceAgentContainer{
+			ID:           "container-1",
+			FriendlyName: "test-container-1",
+			Running:      true,
+			Labels: map[string]string{
+				agentcontainers.DevcontainerLocalFolderLabel: workspaceFolder,
+				agentcontainers.DevcontainerConfigFileLabel:  configPath,
+				agentcontainers.DevcontainerMetadataLabel:    `[{"id":"ghcr.io/devcontainers/features/go:1"},{"remoteUser":"coder"}]`,
+			},
+		}
+		stoppedContainer := runningContainer
+		stoppedContainer.Running = false
+
+		type request struct {
+			method     string
+			path       string
+			wantStatus int
+			wantBody   string
+		}
+		tests := []struct {
+			name      string
+			container codersdk.WorkspaceAgentContainer
+			lister    *fakeContainerCLI
+			requests  []request
+		}{
+			{
+				name:      "Stop",
+				container: runningContainer,
+				lister:    &fakeContainerCLI{},
+				requests: []request{
+					{http.MethodPost, "/devcontainers/" + devcontainerID.String() + "/stop", http.StatusOK, "Devcontainer stopped"},
+					{http.MethodPost, "/devcontainers/" + uuid.NewString() + "/stop", http.StatusNotFound, "Devcontainer not found"},
+				},
+			},
+			{
+				name:      "StopError",
+				container: runningContainer,
+				lister:    &fakeContainerCLI{stopErr: xerrors.New("stop failed")},
+				requests: []request{
+					{http.MethodPost, "/devcontainers/" + devcontainerID.String() + "/stop", http.StatusInternalServerError, "stop failed"},
+				},
+			},
+			{
+				name:      "StopNotRunning",
+				container: stoppedContainer,
+				lister:    &fakeContainerCLI{},
+				requests: []request{
+					{http.MethodPost, "/devcontainers/" + devcontainerID.String() + "/stop", http.StatusConflict, "Devcontainer is not running"},
+				},
+			},
+			{
+				name:      "Start",
+				container: stoppedContainer,
+				lister:    &fakeContainerCLI{},
+				requests: []request{
+					{http.MethodPost, "/devcontainers/" + devcontainerID.String() + "/start", http.StatusOK, "Devcontainer started"},
+				},
+			},
+			{
+				name:      "StartAlreadyRunning",
+				container: runningContainer,
+				lister:    &fakeContainerCLI{},
+				requests: []request{
+					{http.MethodPost, "/devcontainers/" + devcontainerID.String() + "/start", http.StatusConflict, "Devcontainer is already running"},
+				},
+			},
+			{
+				name:      "Delete",
+				container: stoppedContainer,
+				lister:    &fakeContainerCLI{},
+				requests: []request{
+					{http.MethodDelete, "/devcontainers/" + devcontainerID.String(), http.StatusOK, "Devcontainer deleted"},
+				},
+			},
+			{
+				name:      "DeleteError",
+				container: stoppedContainer,
+				lister:    &fakeContainerCLI{removeErr: xerrors.New("remove failed")},
+				requests: []request{
+					{http.MethodDelete, "/devcontainers/" + devcontainerID.String(), http.StatusInternalServerError, "remove failed"},
+				},
+			},
+			{
+				name:      "Logs",
+				container: runningContainer,
+				lister:    &fakeContainerCLI{},
+				requests: []request{
+					{http.MethodGet, "/devcontainers/" + devcontainerID.String() + "/logs", http.StatusOK, `"logs": []`},
+					{http.MethodGet, "/devcontainers/" + devcontainerID.String() + "/logs?after=abc", http.StatusBadRequest, "Invalid after query parameter"},
+					{http.MethodGet, "/devcontainers/" + uuid.NewString() + "/logs", http.StatusNotFound, "Devcontainer not found"},
+				},
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				t.Parallel()
+
+				ctx := testutil.Context(t, testutil.WaitShort)
+				logger := slogtest.Make(t, &slogtest.Options{IgnoreErrors: true}).Leveled(slog.LevelDebug)
+				mClock := quartz.NewMock(t)
+				tickerTrap := mClock.Trap().TickerFunc("updaterLoop")
+
+				container := tt.container
+				tt.lister.containers = codersdk.WorkspaceAgentListContainersResponse{
+					Containers: []codersdk.WorkspaceAgentContainer{container},
+				}
+				tt.lister.arch = "<none>" // Unsupported architecture, don't inject subagent.
+
+				api := agentcontainers.NewAPI(
+					logger,
+					agentcontainers.WithClock(mClock),
+					agentcontainers.WithContainerCLI(tt.lister),
+					agentcontainers.WithDevcontainerCLI(&fakeDevcontainerCLI{}),
+					agentcontainers.WithWatcher(watcher.NewNoop()),
+					agentcontainers.WithDevcontainers([]codersdk.WorkspaceAgentDevcontainer{
+						{
+							ID:              devcontainerID,
+							Name:            "test-devcontainer",
+							WorkspaceFolder: workspaceFolder,
+							ConfigPath:      configPath,
+							Status:          codersdk.WorkspaceAgentDevcontainerStatusRunning,
+							Container:       &container,
+						},
+					}, nil),
+				)
+				api.Start()
+				defer api.Close()
+
+				r := chi.NewRouter()
+				r.Mount("/", api.Routes())
+
+				tickerTrap.MustWait(ctx).MustRelease(ctx)
+				tickerTrap.Close()
+
+				// The features applied to the container are reported
+				// from the devcontainer metadata label.
+				req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+				rec := httptest.NewRecorder()
+				r.ServeHTTP(rec, req)
+				require.Equal(t, http.StatusOK, rec.Code)
+				var resp codersdk.WorkspaceAgentListContainersResponse
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+				require.Len(t, resp.Devcontainers, 1)
+				require.Equal(t, []codersdk.WorkspaceAgentDevcontainerFeature{
+					{ID: "ghcr.io/devcontainers/features/go:1"},
+				}, resp.Devcontainers[0].Features)
+
+				for _, rr := range tt.requests {
+					req := httptest.NewRequest(rr.method, rr.path, nil).WithContext(ctx)
+					rec := httptest.NewRecorder()
+					r.ServeHTTP(rec, req)
+
+					require.Equal(t, rr.wantStatus, rec.Code, "status code mismatch for %s %s: %s", rr.method, rr.path, rec.Body.String())
+					assert.Contains(t, rec.Body.String(), rr.wantBody, "response body mismatch")
+				}
+			})
+		}
+
+		t.Run("ConcurrentActions", func(t *testing.T) {
+			t.Parallel()
+
+			ctx := testutil.Context(t, testutil.WaitShort)
+			logger := slogtest.Make(t, &slogtest.Options{IgnoreErrors: true}).Leveled(slog.LevelDebug)
+			mClock := quartz.NewMock(t)
+			tickerTrap := mClock.Trap().TickerFunc("updaterLoop")
+
+			container := runningContainer
+			lister := &fakeContainerCLI{
+				containers: codersdk.WorkspaceAgentListContainersResponse{
+					Containers: []codersdk.WorkspaceAgentContainer{container},
+				},
+				arch:     "<none>", // Unsupported architecture, don't inject subagent.
+				stopErrC: make(chan error),
+			}
+			api := agentcontainers.NewAPI(
+				logger,
+				agentcontainers.WithClock(mClock),
+				agentcontainers.WithContainerCLI(lister),
+				agentcontainers.WithDevcontainerCLI(&fakeDevcontainerCLI{}),
+				agentcontainers.WithWatcher(watcher.NewNoop()),
+				agentcontainers.WithDevcontainers([]codersdk.WorkspaceAgentDevcontainer{
+					{
+						ID:              devcontainerID,
+						Name:            "test-devcontainer",
+						WorkspaceFolder: workspaceFolder,
+						ConfigPath:      configPath,
+						Status:          codersdk.WorkspaceAgentDevcontainerStatusRunning,
+						Container:       &container,
+					},
+				}, nil),
+			)
+			api.Start()
+			defer api.Close()
+
+			r := chi.NewRouter()
+			r.Mount("/", api.Routes())
+
+			tickerTrap.MustWait(ctx).MustRelease(ctx)
+			tickerTrap.Close()
+
+			do := func(method, path string) *httptest.ResponseRecorder {
+				req := httptest.NewRequest(method, path, nil).WithContext(ctx)
+				rec := httptest.NewRecorder()
+				r.ServeHTTP(rec, req)
+				return rec
+			}
+			status := func() codersdk.WorkspaceAgentDevcontainerStatus {
+				var resp codersdk.WorkspaceAgentListContainersResponse
+				rec := do(http.MethodGet, "/")
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+				require.Len(t, resp.Devcontainers, 1)
+				return resp.Devcontainers[0].Status
+			}
+
+			// Block the stop in the container CLI.
+			stopped := make(chan *httptest.ResponseRecorder, 1)
+			go func() {
+				stopped <- do(http.MethodPost, "/devcontainers/"+devcontainerID.String()+"/stop")
+			}()
+			require.Eventually(t, func() bool {
+				return status() == codersdk.WorkspaceAgentDevcontainerStatusStopping
+			}, testutil.WaitShort, testutil.IntervalFast)
+
+			// No other action may start while the container is stopping.
+			for _, rr := range []struct {
+				method string
+				path   string
+			}{
+				{http.MethodPost, "/devcontainers/" + devcontainerID.String() + "/stop"},
+				{http.MethodPost, "/devcontainers/" + devcontainerID.String() + "/start"},
+				{http.MethodPost, "/devcontainers/" + devcontainerID.String() + "/recreate"},
+				{http.MethodDelete, "/devcontainers/" + devcontainerID.String()},
+			} {
+				rec := do(rr.method, rr.path)
+				require.Equal(t, http.StatusConflict, rec.Code, "status code mismatch for %s %s: %s", rr.method, rr.path, rec.Body.String())
+				assert.Contains(t, rec.Body.String(), "Devcontainer is busy")
+			}
+
+			testutil.RequireSend(ctx, t, lister.stopErrC, nil)
+			rec := testutil.RequireReceive(ctx, t, stopped)
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+			// The fake still lists the container as running.
+			require.Equal(t, codersdk.WorkspaceAgentDevcontainerStatusRunning, status())
+		})
+	})
+
 	t.Run("List devcontainers", func(t *testing.T) {
 		t.Parallel()
 
@@ -583,6 +583,33 @@ func (dcli *dockerCLI) ExecAs(ctx context.Context, containerName, uid string, ar
 	return stdout, nil
 }
 
+// Stop stops a running container.
+func (dcli *dockerCLI) Stop(ctx context.Context, containerName string) error {
+	_, stderr, err := runCmd(ctx, dcli.execer, "docker", "stop", containerName)
+	if err != nil {
+		return xerrors.Errorf("stop container %s: %w: %s", containerName, err, stderr)
+	}
+	return nil
+}
+
+// Start starts a stopped container.
+func (dcli *dockerCLI) Start(ctx context.Context, containerName string) error {
+	_, stderr, err := runCmd(ctx, dcli.execer, "docker", "start", containerName)
+	if err != nil {
+		return xerrors.Errorf("start container %s: %w: %s", containerName, err, stderr)
+	}
+	return nil
+}
+
+// Remove removes a container, stopping it first if it is running.
+func (dcli *dockerCLI) Remove(ctx context.Context, containerName string) error {
+	_, stderr, err := runCmd(ctx, dcli.execer, "docker", "rm", "--force", containerName)
+	if err != nil {
+		return xerrors.Errorf("remove container %s: %w: %s", containerName, err, stderr)
+	}
+	return nil
+}
+
 // runCmd is a helper function that runs a command with the given
 // arguments and returns the stdout and stderr output.
 func runCmd(ctx context.Context, execer agentexec.Execer, cmd string, args ...string) (stdout, stderr []byte, err error) {
@@ -2,6 +2,7 @@ package agentcontainers
 
 import (
 	"context"
+	"encoding/json"
 	"os"
 	"path/filepath"
 
@@ -21,10 +22,39 @@ const (
 	// DevcontainerIsTestRunLabel is set if the devcontainer is part of a test
 	// and should be excluded.
 	DevcontainerIsTestRunLabel = "devcontainer.is_test_run"
+	// DevcontainerMetadataLabel is the label that contains the merged
+	// image metadata, including applied features, as a JSON array.
+	DevcontainerMetadataLabel = "devcontainer.metadata"
 	// The default workspace folder inside the devcontainer.
 	DevcontainerDefaultContainerWorkspaceFolder = "/workspaces"
 )
 
+// DevcontainerFeaturesFromLabels returns the devcontainer features that
+// were applied to a container, as recorded by the devcontainer CLI in
+// the metadata label. Invalid or missing metadata yields no features.
+func DevcontainerFeaturesFromLabels(labels map[string]string) []codersdk.WorkspaceAgentDevcontainerFeature {
+	raw, ok := labels[DevcontainerMetadataLabel]
+	if !ok || raw == "" {
+		return nil
+	}
+	var entries []struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal([]byte(raw), &entries); err != nil {
+		return nil
+	}
+	var features []codersdk.WorkspaceAgentDevcontainerFeature
+	for _, e := range entries {
+		// Entries without an ID originate from the image or the
+		// devcontainer.json itself rather than from a feature.
+		if e.ID == "" {
+			continue
+		}
+		features = append(features, codersdk.WorkspaceAgentDevcontainerFeature{ID: e.ID})
+	}
+	return features
+}
+
 func ExtractDevcontainerScripts(
 	devcontainers []codersdk.WorkspaceAgentDevcontainer,
 	scripts []codersdk.WorkspaceAgentScript,
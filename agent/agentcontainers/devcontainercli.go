@@ -122,6 +122,14 @@ func WithRemoveExistingContainer() DevcontainerCLIUpOptions {
 	}
 }
 
+// WithBuildNoCache is an option to rebuild the devcontainer image
+// without using the build cache.
+func WithBuildNoCache() DevcontainerCLIUpOptions {
+	return func(o *devcontainerCLIUpConfig) {
+		o.args = append(o.args, "--build-no-cache")
+	}
+}
+
 // WithUpOutput sets additional stdout and stderr writers for logs
 // during Up operations.
 func WithUpOutput(stdout, stderr io.Writer) DevcontainerCLIUpOptions {
@@ -0,0 +1,140 @@
+package agentproc
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"cdr.dev/slog"
+	"github.com/coder/coder/v2/coderd/httpapi"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+// API serves the process inspection and control endpoints of the agent.
+type API struct {
+	logger  slog.Logger
+	lister  *Lister
+	selfPID int32
+}
+
+// NewAPI returns a new API that lists processes with lister.
+func NewAPI(logger slog.Logger, lister *Lister) *API {
+	return &API{
+		logger: logger,
+		lister: lister,
+		// #nosec G115 - PIDs always fit in an int32.
+		selfPID: int32(os.Getpid()),
+	}
+}
+
+// Routes returns the HTTP handler for the process API.
+func (api *API) Routes() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/", api.handleList)
+	r.Post("/{pid}/signal", api.handleSignal)
+	return r
+}
+
+func (api *API) handleList(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	order := codersdk.WorkspaceAgentProcessSortPID
+	if s := r.URL.Query().Get("sort"); s != "" {
+		order = codersdk.WorkspaceAgentProcessSort(s)
+		if !order.Valid() {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: fmt.Sprintf("Invalid sort order %q.", s),
+				Detail:  "Must be one of: cpu, memory, pid.",
+			})
+			return
+		}
+	}
+	limit := 0
+	if s := r.URL.Query().Get("limit"); s != "" {
+		var err error
+		limit, err = strconv.Atoi(s)
+		if err != nil || limit < 0 {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: fmt.Sprintf("Invalid limit %q.", s),
+				Detail:  "Must be a non-negative integer.",
+			})
+			return
+		}
+	}
+
+	procs, warnings, err := api.lister.List(ctx)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Could not list processes.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, codersdk.WorkspaceAgentProcessesResponse{
+		Processes: Top(procs, order, limit),
+		Warnings:  warnings,
+	})
+}
+
+func (api *API) handleSignal(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	pid, err := strconv.ParseInt(chi.URLParam(r, "pid"), 10, 32)
+	if err != nil || pid <= 0 {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Invalid process ID.",
+			Validations: []codersdk.ValidationError{
+				{Field: "pid", Detail: "Must be a positive integer."},
+			},
+		})
+		return
+	}
+
+	var req codersdk.WorkspaceAgentSignalProcessRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+	sig, err := ParseSignal(req.Signal)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Invalid signal.",
+			Validations: []codersdk.ValidationError{
+				{Field: "signal", Detail: err.Error()},
+			},
+		})
+		return
+	}
+
+	if int32(pid) == api.selfPID {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Refusing to signal the workspace agent.",
+			Detail:  "Restart the workspace to restart the agent.",
+		})
+		return
+	}
+
+	err = Signal(ctx, int32(pid), sig)
+	if errors.Is(err, ErrProcessNotFound) {
+		httpapi.Write(ctx, rw, http.StatusNotFound, codersdk.Response{
+			Message: fmt.Sprintf("Process %d not found.", pid),
+		})
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Could not signal process.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	api.logger.Info(ctx, "signaled process", slog.F("pid", pid), slog.F("signal", sig.String()))
+	httpapi.Write(ctx, rw, http.StatusOK, codersdk.Response{
+		Message: fmt.Sprintf("Sent %s to process %d.", sig, pid),
+	})
+}
@@ -0,0 +1,153 @@
+package agentproc_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/agent/agentproc"
+	"github.com/coder/coder/v2/codersdk"
+	"github.com/coder/coder/v2/testutil"
+)
+
+func TestAPI(t *testing.T) {
+	t.Parallel()
+
+	newAPI := func(t *testing.T) http.Handler {
+		t.Helper()
+		logger := testutil.Logger(t)
+		lister := agentproc.NewLister(logger, agentproc.WithSampleInterval(0))
+		return agentproc.NewAPI(logger, lister).Routes()
+	}
+
+	signal := func(t *testing.T, h http.Handler, pid any, sig string) *httptest.ResponseRecorder {
+		t.Helper()
+		body, err := json.Marshal(codersdk.WorkspaceAgentSignalProcessRequest{Signal: sig})
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/%v/signal", pid), bytes.NewReader(body))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("List", func(t *testing.T) {
+		t.Parallel()
+
+		h := newAPI(t)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?sort=pid", nil))
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var resp codersdk.WorkspaceAgentProcessesResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		var found bool
+		for i, p := range resp.Processes {
+			if i > 0 {
+				assert.Less(t, resp.Processes[i-1].PID, p.PID)
+			}
+			if p.PID == int32(os.Getpid()) {
+				found = true
+				assert.NotEmpty(t, p.Name)
+				assert.NotZero(t, p.MemoryRSS)
+				assert.NotNil(t, p.Ports)
+			}
+		}
+		require.True(t, found, "test process not listed")
+	})
+
+	t.Run("ListLimit", func(t *testing.T) {
+		t.Parallel()
+
+		h := newAPI(t)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?sort=memory&limit=1", nil))
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var resp codersdk.WorkspaceAgentProcessesResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		require.Len(t, resp.Processes, 1)
+	})
+
+	t.Run("ListInvalid", func(t *testing.T) {
+		t.Parallel()
+
+		h := newAPI(t)
+		for _, q := range []string{"sort=name", "limit=-1", "limit=abc"} {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?"+q, nil))
+			require.Equal(t, http.StatusBadRequest, rec.Code, q)
+		}
+	})
+
+	t.Run("Signal", func(t *testing.T) {
+		t.Parallel()
+		if runtime.GOOS == "windows" {
+			t.Skip("sleep is not available on Windows")
+		}
+
+		ctx := testutil.Context(t, testutil.WaitShort)
+		cmd := exec.CommandContext(ctx, "sleep", "30")
+		require.NoError(t, cmd.Start())
+
+		h := newAPI(t)
+		rec := signal(t, h, cmd.Process.Pid, "sigterm")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		err := cmd.Wait()
+		var exitErr *exec.ExitError
+		require.ErrorAs(t, err, &exitErr)
+		require.False(t, exitErr.Success())
+	})
+
+	t.Run("SignalInvalid", func(t *testing.T) {
+		t.Parallel()
+
+		h := newAPI(t)
+		require.Equal(t, http.StatusBadRequest, signal(t, h, "abc", "TERM").Code)
+		require.Equal(t, http.StatusBadRequest, signal(t, h, 0, "TERM").Code)
+		require.Equal(t, http.StatusBadRequest, signal(t, h, os.Getpid(), "NOPE").Code)
+		// The agent must not be able to kill itself.
+		require.Equal(t, http.StatusBadRequest, signal(t, h, os.Getpid(), "KILL").Code)
+	})
+}
+
+func TestTop(t *testing.T) {
+	t.Parallel()
+
+	procs := []codersdk.WorkspaceAgentProcess{
+		{PID: 3, CPUPercent: 10, MemoryRSS: 100},
+		{PID: 1, CPUPercent: 90, MemoryRSS: 10},
+		{PID: 2, CPUPercent: 10, MemoryRSS: 300},
+	}
+	pids := func(procs []codersdk.WorkspaceAgentProcess) []int32 {
+		var pids []int32
+		for _, p := range procs {
+			pids = append(pids, p.PID)
+		}
+		return pids
+	}
+
+	require.Equal(t, []int32{1, 2}, pids(agentproc.Top(procs, codersdk.WorkspaceAgentProcessSortCPU, 2)))
+	require.Equal(t, []int32{2, 3, 1}, pids(agentproc.Top(procs, codersdk.WorkspaceAgentProcessSortMemory, 0)))
+	require.Equal(t, []int32{1, 2, 3}, pids(agentproc.Top(procs, codersdk.WorkspaceAgentProcessSortPID, 5)))
+}
+
+func TestParseSignal(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range []string{"TERM", "sigterm", " SIGKILL ", "int"} {
+		_, err := agentproc.ParseSignal(name)
+		require.NoError(t, err, name)
+	}
+	_, err := agentproc.ParseSignal("SIGSEGV")
+	require.ErrorContains(t, err, "unsupported signal")
+}
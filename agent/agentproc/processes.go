@@ -0,0 +1,246 @@
+// Package agentproc inspects and controls the processes running alongside the
+// workspace agent.
+package agentproc
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/net"
+	"github.com/shirou/gopsutil/v4/process"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"github.com/coder/coder/v2/agent/proto"
+	"github.com/coder/coder/v2/codersdk"
+	"github.com/coder/quartz"
+)
+
+// DefaultSampleInterval is the interval over which CPU usage is measured.
+const DefaultSampleInterval = 500 * time.Millisecond
+
+// Lister lists the processes visible to the agent.
+type Lister struct {
+	logger         slog.Logger
+	clock          quartz.Clock
+	sampleInterval time.Duration
+}
+
+// Option is a functional option for Lister.
+type Option func(*Lister)
+
+// WithClock sets the quartz.Clock implementation to use.
+// This is primarily used for testing to control time.
+func WithClock(clock quartz.Clock) Option {
+	return func(l *Lister) {
+		l.clock = clock
+	}
+}
+
+// WithSampleInterval sets the interval over which CPU usage is measured.
+// An interval of zero reports the average CPU usage over the lifetime of
+// each process instead.
+func WithSampleInterval(d time.Duration) Option {
+	return func(l *Lister) {
+		l.sampleInterval = d
+	}
+}
+
+// NewLister returns a new process lister.
+func NewLister(logger slog.Logger, opts ...Option) *Lister {
+	l := &Lister{
+		logger:         logger,
+		clock:          quartz.NewReal(),
+		sampleInterval: DefaultSampleInterval,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// List returns the processes visible to the agent. Processes that exit while
+// they are being inspected are omitted. The returned warnings describe
+// information that could not be collected.
+func (l *Lister) List(ctx context.Context) ([]codersdk.WorkspaceAgentProcess, []string, error) {
+	procs, err := process.ProcessesWithContext(ctx)
+	if err != nil {
+		return nil, nil, xerrors.Errorf("list processes: %w", err)
+	}
+
+	var (
+		before  map[int32]float64
+		elapsed float64
+	)
+	if l.sampleInterval > 0 {
+		before = cpuTimes(ctx, procs)
+		started := l.clock.Now()
+		timer := l.clock.NewTimer(l.sampleInterval, "agentproc", "sample")
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, nil, ctx.Err()
+		case <-timer.C:
+		}
+		elapsed = l.clock.Since(started).Seconds()
+	}
+
+	var warnings []string
+	ports, err := listeningPorts(ctx)
+	if err != nil {
+		l.logger.Debug(ctx, "failed to list listening ports", slog.Error(err))
+		warnings = append(warnings, "Could not determine listening ports: "+err.Error())
+	}
+
+	result := make([]codersdk.WorkspaceAgentProcess, 0, len(procs))
+	for _, p := range procs {
+		proc, ok := inspect(ctx, p)
+		if !ok {
+			continue
+		}
+		if before != nil {
+			prev, ok := before[p.Pid]
+			if ok && elapsed > 0 {
+				if times, err := p.TimesWithContext(ctx); err == nil {
+					proc.CPUPercent = max(0, (times.User+times.System-prev)/elapsed*100)
+				}
+			}
+		} else if cpu, err := p.CPUPercentWithContext(ctx); err == nil {
+			proc.CPUPercent = cpu
+		}
+		proc.Ports = ports[p.Pid]
+		if proc.Ports == nil {
+			proc.Ports = []uint16{}
+		}
+		result = append(result, proc)
+	}
+	return result, warnings, nil
+}
+
+// FetchTopProcesses returns the n processes using the most memory. It
+// implements resourcesmonitor.ProcessesFetcher.
+func (l *Lister) FetchTopProcesses(ctx context.Context, n int) ([]*proto.PushResourcesMonitoringUsageRequest_Process, error) {
+	procs, _, err := l.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	top := Top(procs, codersdk.WorkspaceAgentProcessSortMemory, n)
+	result := make([]*proto.PushResourcesMonitoringUsageRequest_Process, 0, len(top))
+	for _, p := range top {
+		result = append(result, &proto.PushResourcesMonitoringUsageRequest_Process{
+			Pid:        p.PID,
+			Name:       p.Name,
+			Cmdline:    p.Cmdline,
+			CpuPercent: p.CPUPercent,
+			// #nosec G115 - RSS never exceeds the int64 range.
+			MemoryRss: int64(p.MemoryRSS),
+		})
+	}
+	return result, nil
+}
+
+// Sort orders processes in place, with the largest consumers first for the
+// cpu and memory orders.
+func Sort(procs []codersdk.WorkspaceAgentProcess, order codersdk.WorkspaceAgentProcessSort) {
+	slices.SortStableFunc(procs, func(a, b codersdk.WorkspaceAgentProcess) int {
+		switch order {
+		case codersdk.WorkspaceAgentProcessSortCPU:
+			if a.CPUPercent != b.CPUPercent {
+				if a.CPUPercent > b.CPUPercent {
+					return -1
+				}
+				return 1
+			}
+		case codersdk.WorkspaceAgentProcessSortMemory:
+			if a.MemoryRSS != b.MemoryRSS {
+				if a.MemoryRSS > b.MemoryRSS {
+					return -1
+				}
+				return 1
+			}
+		}
+		return int(a.PID - b.PID)
+	})
+}
+
+// Top returns the n largest consumers according to order. The input slice is
+// sorted in place.
+func Top(procs []codersdk.WorkspaceAgentProcess, order codersdk.WorkspaceAgentProcessSort, n int) []codersdk.WorkspaceAgentProcess {
+	Sort(procs, order)
+	if n > 0 && len(procs) > n {
+		procs = procs[:n]
+	}
+	return procs
+}
+
+func inspect(ctx context.Context, p *process.Process) (codersdk.WorkspaceAgentProcess, bool) {
+	// A process that has no name has most likely exited.
+	name, err := p.NameWithContext(ctx)
+	if err != nil {
+		return codersdk.WorkspaceAgentProcess{}, false
+	}
+	proc := codersdk.WorkspaceAgentProcess{
+		PID:  p.Pid,
+		Name: name,
+	}
+	// The remaining fields are best effort, some of them require elevated
+	// privileges for processes owned by other users.
+	if ppid, err := p.PpidWithContext(ctx); err == nil {
+		proc.PPID = ppid
+	}
+	if cmdline, err := p.CmdlineWithContext(ctx); err == nil {
+		proc.Cmdline = cmdline
+	}
+	if username, err := p.UsernameWithContext(ctx); err == nil {
+		proc.Username = username
+	}
+	if status, err := p.StatusWithContext(ctx); err == nil {
+		proc.Status = strings.Join(status, ",")
+	}
+	if mem, err := p.MemoryInfoWithContext(ctx); err == nil {
+		proc.MemoryRSS = mem.RSS
+	}
+	if memPercent, err := p.MemoryPercentWithContext(ctx); err == nil {
+		proc.MemoryPercent = memPercent
+	}
+	if created, err := p.CreateTimeWithContext(ctx); err == nil {
+		proc.CreatedAt = time.UnixMilli(created)
+	}
+	return proc, true
+}
+
+func cpuTimes(ctx context.Context, procs []*process.Process) map[int32]float64 {
+	times := make(map[int32]float64, len(procs))
+	for _, p := range procs {
+		t, err := p.TimesWithContext(ctx)
+		if err != nil {
+			continue
+		}
+		times[p.Pid] = t.User + t.System
+	}
+	return times
+}
+
+func listeningPorts(ctx context.Context) (map[int32][]uint16, error) {
+	conns, err := net.ConnectionsWithContext(ctx, "tcp")
+	if err != nil {
+		return nil, err
+	}
+	ports := make(map[int32][]uint16)
+	for _, conn := range conns {
+		if conn.Status != "LISTEN" || conn.Pid == 0 {
+			continue
+		}
+		// #nosec G115 - Ports are always within the uint16 range.
+		port := uint16(conn.Laddr.Port)
+		if !slices.Contains(ports[conn.Pid], port) {
+			ports[conn.Pid] = append(ports[conn.Pid], port)
+		}
+	}
+	for _, p := range ports {
+		slices.Sort(p)
+	}
+	return ports, nil
+}
@@ -0,0 +1,48 @@
+package agentproc
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+	"syscall"
+
+	"github.com/shirou/gopsutil/v4/process"
+	"golang.org/x/xerrors"
+)
+
+// ErrProcessNotFound is returned when signaling a process that does not exist.
+var ErrProcessNotFound = xerrors.New("process not found")
+
+// ParseSignal parses a signal name such as "TERM" or "SIGKILL". Only the
+// signals supported on the current platform are accepted.
+func ParseSignal(name string) (syscall.Signal, error) {
+	name = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(name)), "SIG")
+	sig, ok := signals[name]
+	if !ok {
+		return 0, xerrors.Errorf("unsupported signal %q, must be one of: %s", name, strings.Join(SupportedSignals(), ", "))
+	}
+	return sig, nil
+}
+
+// SupportedSignals returns the names of the signals supported on the current
+// platform.
+func SupportedSignals() []string {
+	return slices.Sorted(maps.Keys(signals))
+}
+
+// Signal sends sig to the process with the given PID.
+func Signal(ctx context.Context, pid int32, sig syscall.Signal) error {
+	p, err := process.NewProcessWithContext(ctx, pid)
+	if err != nil {
+		if errors.Is(err, process.ErrorProcessNotRunning) {
+			return ErrProcessNotFound
+		}
+		return xerrors.Errorf("find process %d: %w", pid, err)
+	}
+	if err := sendSignal(ctx, p, sig); err != nil {
+		return xerrors.Errorf("signal process %d: %w", pid, err)
+	}
+	return nil
+}
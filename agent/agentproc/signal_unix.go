@@ -0,0 +1,26 @@
+//go:build !windows
+
+package agentproc
+
+import (
+	"context"
+	"syscall"
+
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+var signals = map[string]syscall.Signal{
+	"HUP":  syscall.SIGHUP,
+	"INT":  syscall.SIGINT,
+	"QUIT": syscall.SIGQUIT,
+	"KILL": syscall.SIGKILL,
+	"USR1": syscall.SIGUSR1,
+	"USR2": syscall.SIGUSR2,
+	"TERM": syscall.SIGTERM,
+	"STOP": syscall.SIGSTOP,
+	"CONT": syscall.SIGCONT,
+}
+
+func sendSignal(ctx context.Context, p *process.Process, sig syscall.Signal) error {
+	return p.SendSignalWithContext(ctx, sig)
+}
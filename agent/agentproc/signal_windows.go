@@ -0,0 +1,23 @@
+package agentproc
+
+import (
+	"context"
+	"syscall"
+
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+// Windows has no signals, so only the ones that map to terminating the
+// process are supported.
+var signals = map[string]syscall.Signal{
+	"INT":  syscall.SIGINT,
+	"KILL": syscall.SIGKILL,
+	"TERM": syscall.SIGTERM,
+}
+
+func sendSignal(ctx context.Context, p *process.Process, sig syscall.Signal) error {
+	if sig == syscall.SIGKILL {
+		return p.KillWithContext(ctx)
+	}
+	return p.TerminateWithContext(ctx)
+}
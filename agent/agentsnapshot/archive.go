@@ -0,0 +1,461 @@
+package agentsnapshot
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+)
+
+// manifestName is the name of the first entry of every archive. It is not
+// an absolute path, so it never collides with a snapshotted file.
+const manifestName = ".coder-snapshot.json"
+
+// Manifest describes the state of the snapshotted directories at the time
+// of a snapshot. Incremental archives only contain the files that changed
+// since the parent snapshot, but their manifest lists every file.
+type Manifest struct {
+	Paths []string `json:"paths"`
+	// Files is keyed by absolute, slash separated path.
+	Files map[string]FileInfo `json:"files"`
+}
+
+// FileInfo is the metadata used to detect changes between snapshots.
+type FileInfo struct {
+	Mode    fs.FileMode `json:"mode"`
+	Size    int64       `json:"size,omitempty"`
+	ModTime time.Time   `json:"mod_time"`
+	Link    string      `json:"link,omitempty"`
+}
+
+// FileCount returns the number of entries in the manifest that are not
+// directories.
+func (m *Manifest) FileCount() int {
+	n := 0
+	for _, f := range m.Files {
+		if !f.Mode.IsDir() {
+			n++
+		}
+	}
+	return n
+}
+
+func (f FileInfo) unchanged(o FileInfo) bool {
+	return f.Mode == o.Mode && f.Size == o.Size && f.ModTime.Equal(o.ModTime) && f.Link == o.Link
+}
+
+// Excluded reports whether a path relative to a snapshotted directory is
+// excluded by patterns. Patterns containing a slash are matched against
+// the whole relative path, other patterns against every path element.
+func Excluded(patterns []string, rel string) bool {
+	rel = filepath.ToSlash(rel)
+	for _, pattern := range patterns {
+		if strings.Contains(pattern, "/") {
+			if ok, _ := path.Match(strings.Trim(pattern, "/"), rel); ok {
+				return true
+			}
+			continue
+		}
+		for _, elem := range strings.Split(rel, "/") {
+			if ok, _ := path.Match(pattern, elem); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type entry struct {
+	path string
+	name string
+	info fs.FileInfo
+	fi   FileInfo
+}
+
+// WriteArchive writes a gzip compressed tarball of paths to w, skipping
+// excluded files and paths that do not exist. If base is not nil, files
+// and symlinks that are unchanged since base are omitted from the archive.
+func WriteArchive(ctx context.Context, logger slog.Logger, w io.Writer, paths, exclude []string, base *Manifest) (*Manifest, error) {
+	manifest := &Manifest{
+		Paths: []string{},
+		Files: map[string]FileInfo{},
+	}
+	// The manifest is the first entry of the archive so that restores can
+	// validate entries before extracting them, which requires walking the
+	// directories before writing any file.
+	var entries []entry
+	for _, root := range paths {
+		root = filepath.Clean(root)
+		if _, err := os.Lstat(root); errors.Is(err, fs.ErrNotExist) {
+			logger.Debug(ctx, "skipping missing snapshot path", slog.F("path", root))
+			continue
+		}
+		manifest.Paths = append(manifest.Paths, filepath.ToSlash(root))
+
+		err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			if err != nil {
+				// Unreadable directories are skipped rather than failing
+				// the whole snapshot.
+				logger.Warn(ctx, "skipping unreadable path", slog.F("path", p), slog.Error(err))
+				if d != nil && d.IsDir() {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			rel, err := filepath.Rel(root, p)
+			if err != nil {
+				return err
+			}
+			if rel != "." && Excluded(exclude, rel) {
+				if d.IsDir() {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				logger.Warn(ctx, "skipping unreadable path", slog.F("path", p), slog.Error(err))
+				return nil
+			}
+			e, ok := newEntry(ctx, logger, p, info)
+			if !ok {
+				return nil
+			}
+			manifest.Files[e.name] = e.fi
+			// Directories are always written so that empty directories
+			// and permission changes are restored.
+			if base != nil && !e.fi.Mode.IsDir() {
+				if prev, ok := base.Files[e.name]; ok && prev.unchanged(e.fi) {
+					return nil
+				}
+			}
+			entries = append(entries, e)
+			return nil
+		})
+		if err != nil {
+			return nil, xerrors.Errorf("walk %q: %w", root, err)
+		}
+	}
+
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, xerrors.Errorf("marshal manifest: %w", err)
+	}
+	err = tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     manifestName,
+		Mode:     0o600,
+		Size:     int64(len(data)),
+		ModTime:  time.Now(),
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("write manifest header: %w", err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return nil, xerrors.Errorf("write manifest: %w", err)
+	}
+	for _, e := range entries {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if err := writeEntry(ctx, logger, tw, e); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, xerrors.Errorf("close tar writer: %w", err)
+	}
+	if err := gw.Close(); err != nil {
+		return nil, xerrors.Errorf("close gzip writer: %w", err)
+	}
+	return manifest, nil
+}
+
+func newEntry(ctx context.Context, logger slog.Logger, p string, info fs.FileInfo) (entry, bool) {
+	e := entry{
+		path: p,
+		name: filepath.ToSlash(p),
+		info: info,
+		fi: FileInfo{
+			Mode:    info.Mode(),
+			ModTime: info.ModTime().UTC(),
+		},
+	}
+	switch {
+	case info.Mode().IsDir():
+	case info.Mode().IsRegular():
+		e.fi.Size = info.Size()
+	case info.Mode()&fs.ModeSymlink != 0:
+		link, err := os.Readlink(p)
+		if err != nil {
+			logger.Warn(ctx, "skipping unreadable symlink", slog.F("path", p), slog.Error(err))
+			return entry{}, false
+		}
+		e.fi.Link = link
+	default:
+		// Sockets, devices and pipes cannot be restored meaningfully.
+		return entry{}, false
+	}
+	return e, true
+}
+
+func writeEntry(ctx context.Context, logger slog.Logger, tw *tar.Writer, e entry) error {
+	var f *os.File
+	if e.fi.Mode.IsRegular() {
+		var err error
+		f, err = os.Open(e.path)
+		if err != nil {
+			// The file is still listed in the manifest, restores report it
+			// as missing.
+			logger.Warn(ctx, "skipping unreadable file", slog.F("path", e.path), slog.Error(err))
+			return nil
+		}
+		defer f.Close()
+	}
+
+	hdr, err := tar.FileInfoHeader(e.info, e.fi.Link)
+	if err != nil {
+		return xerrors.Errorf("file info header %q: %w", e.path, err)
+	}
+	hdr.Name = strings.TrimPrefix(e.name, "/")
+	// Owners are not restored, the agent writes files as its own user.
+	hdr.Uid, hdr.Gid, hdr.Uname, hdr.Gname = 0, 0, "", ""
+	if err := tw.WriteHeader(hdr); err != nil {
+		return xerrors.Errorf("write header %q: %w", e.path, err)
+	}
+	if f == nil {
+		return nil
+	}
+	n, err := io.CopyN(tw, f, hdr.Size)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return xerrors.Errorf("copy %q: %w", e.path, err)
+	}
+	// The file was truncated after it was walked. Its modification time
+	// no longer matches the manifest, so the next snapshot includes it.
+	if n < hdr.Size {
+		if _, err := io.CopyN(tw, zeroReader{}, hdr.Size-n); err != nil {
+			return xerrors.Errorf("pad %q: %w", e.path, err)
+		}
+	}
+	return nil
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+// Opener opens a snapshot archive.
+type Opener func(ctx context.Context) (io.ReadCloser, error)
+
+// Restore extracts a chain of archives written by WriteArchive, given
+// newest first. The manifest of the newest archive determines which files
+// are restored, so files deleted before the newest snapshot are not
+// restored. Existing files are overwritten and other files are left
+// untouched. Restore returns the manifest of the newest archive and the
+// number of files that could not be restored.
+func Restore(ctx context.Context, logger slog.Logger, archives []Opener) (*Manifest, int, error) {
+	if len(archives) == 0 {
+		return nil, 0, xerrors.New("no archives to restore")
+	}
+
+	var (
+		final    *Manifest
+		restored = map[string]struct{}{}
+	)
+	for i, open := range archives {
+		rc, err := open(ctx)
+		if err != nil {
+			return nil, 0, xerrors.Errorf("open archive %d: %w", i, err)
+		}
+		m, err := extract(ctx, logger, rc, final, restored)
+		_ = rc.Close()
+		if err != nil {
+			return nil, 0, xerrors.Errorf("extract archive %d: %w", i, err)
+		}
+		if final == nil {
+			final = m
+		}
+	}
+
+	missing := 0
+	for name := range final.Files {
+		if _, ok := restored[name]; !ok {
+			missing++
+		}
+	}
+	return final, missing, nil
+}
+
+// extract extracts the archive in r and returns its manifest. Only entries
+// listed in final that have not been restored from a newer archive are
+// extracted. final is nil for the newest archive, which uses its own
+// manifest.
+func extract(ctx context.Context, logger slog.Logger, r io.Reader, final *Manifest, restored map[string]struct{}) (*Manifest, error) {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, xerrors.Errorf("open gzip reader: %w", err)
+	}
+	defer gr.Close()
+	tr := tar.NewReader(gr)
+
+	hdr, err := tr.Next()
+	if err != nil {
+		return nil, xerrors.Errorf("read manifest header: %w", err)
+	}
+	if hdr.Name != manifestName {
+		return nil, xerrors.Errorf("archive does not start with a manifest")
+	}
+	var manifest Manifest
+	if err := json.NewDecoder(tr).Decode(&manifest); err != nil {
+		return nil, xerrors.Errorf("decode manifest: %w", err)
+	}
+	if final == nil {
+		final = &manifest
+	}
+	roots := make([]string, 0, len(final.Paths))
+	for _, root := range final.Paths {
+		roots = append(roots, filepath.Clean(filepath.FromSlash(root)))
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, xerrors.Errorf("read tar header: %w", err)
+		}
+
+		name := path.Clean("/" + hdr.Name)
+		fi, ok := final.Files[name]
+		if !ok {
+			continue
+		}
+		if _, ok := restored[name]; ok {
+			continue
+		}
+		target := filepath.FromSlash(name)
+		root, ok := rootOf(roots, target)
+		if !ok {
+			return nil, xerrors.Errorf("archive entry %q is outside of the snapshot paths", hdr.Name)
+		}
+		if err := extractEntry(tr, hdr, fi, root, target); err != nil {
+			logger.Warn(ctx, "failed to restore file", slog.F("path", target), slog.Error(err))
+			continue
+		}
+		restored[name] = struct{}{}
+	}
+	return &manifest, nil
+}
+
+// rootOf returns the root that contains target.
+func rootOf(roots []string, target string) (string, bool) {
+	for _, root := range roots {
+		if target == root || strings.HasPrefix(target, root+string(filepath.Separator)) {
+			return root, true
+		}
+	}
+	return "", false
+}
+
+func extractEntry(r io.Reader, hdr *tar.Header, fi FileInfo, root, target string) error {
+	if target != root {
+		parent := filepath.Dir(target)
+		if err := os.MkdirAll(parent, 0o755); err != nil {
+			return xerrors.Errorf("create parent directory: %w", err)
+		}
+		// A symlink restored earlier must not redirect writes outside of
+		// the snapshotted directory.
+		resolvedRoot, err := filepath.EvalSymlinks(root)
+		if err != nil {
+			return xerrors.Errorf("resolve root: %w", err)
+		}
+		resolvedParent, err := filepath.EvalSymlinks(parent)
+		if err != nil {
+			return xerrors.Errorf("resolve parent directory: %w", err)
+		}
+		if _, ok := rootOf([]string{resolvedRoot}, resolvedParent); !ok {
+			return xerrors.Errorf("parent directory resolves outside of %q", root)
+		}
+	}
+
+	existing, err := os.Lstat(target)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	exists := err == nil
+
+	switch hdr.Typeflag {
+	case tar.TypeDir:
+		if exists && !existing.IsDir() {
+			if err := os.Remove(target); err != nil {
+				return err
+			}
+		}
+		if err := os.MkdirAll(target, fi.Mode.Perm()); err != nil {
+			return err
+		}
+		return os.Chmod(target, fi.Mode.Perm())
+	case tar.TypeReg:
+		if exists && existing.IsDir() {
+			return xerrors.New("a directory exists at the path of the file")
+		}
+		// Writing to a temporary file and renaming it replaces symlinks
+		// instead of writing through them.
+		f, err := os.CreateTemp(filepath.Dir(target), ".coder-restore-*")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(f.Name())
+		if _, err := io.Copy(f, r); err != nil {
+			_ = f.Close()
+			return err
+		}
+		if err := f.Chmod(fi.Mode.Perm()); err != nil {
+			_ = f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+		if err := os.Chtimes(f.Name(), fi.ModTime, fi.ModTime); err != nil {
+			return err
+		}
+		return os.Rename(f.Name(), target)
+	case tar.TypeSymlink:
+		if exists {
+			if existing.IsDir() {
+				return xerrors.New("a directory exists at the path of the symlink")
+			}
+			if err := os.Remove(target); err != nil {
+				return err
+			}
+		}
+		return os.Symlink(hdr.Linkname, target)
+	default:
+		return xerrors.Errorf("unsupported entry type %q", hdr.Typeflag)
+	}
+}
@@ -0,0 +1,220 @@
+package agentsnapshot_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/agent/agentsnapshot"
+	"github.com/coder/coder/v2/testutil"
+)
+
+func TestExcluded(t *testing.T) {
+	t.Parallel()
+
+	patterns := []string{"node_modules", "*.log", ".cache/go-build"}
+	for rel, want := range map[string]bool{
+		"node_modules":                 true,
+		"project/node_modules/foo.js":  true,
+		"project/server.log":           true,
+		".cache/go-build":              true,
+		".cache/pip":                   false,
+		"project/src/main.go":          false,
+		"project/node_modules_backup":  false,
+		"project/.cache/go-build/file": false,
+	} {
+		assert.Equal(t, want, agentsnapshot.Excluded(patterns, rel), rel)
+	}
+}
+
+func TestArchive(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("snapshots use unix paths")
+	}
+
+	writeFile := func(t *testing.T, path, content string, modTime time.Time) {
+		t.Helper()
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+	archive := func(t *testing.T, paths, exclude []string, base *agentsnapshot.Manifest) (*bytes.Buffer, *agentsnapshot.Manifest) {
+		t.Helper()
+		var buf bytes.Buffer
+		m, err := agentsnapshot.WriteArchive(context.Background(), testutil.Logger(t), &buf, paths, exclude, base)
+		require.NoError(t, err)
+		return &buf, m
+	}
+	opener := func(buf *bytes.Buffer) agentsnapshot.Opener {
+		data := buf.Bytes()
+		return func(context.Context) (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(data)), nil
+		}
+	}
+	entries := func(t *testing.T, buf *bytes.Buffer) []string {
+		t.Helper()
+		gr, err := gzip.NewReader(bytes.NewReader(buf.Bytes()))
+		require.NoError(t, err)
+		tr := tar.NewReader(gr)
+		var names []string
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			if hdr.Typeflag == tar.TypeReg {
+				names = append(names, hdr.Name)
+			}
+		}
+		return names
+	}
+
+	t.Run("Incremental", func(t *testing.T) {
+		t.Parallel()
+
+		home := filepath.Join(t.TempDir(), "home")
+		past := time.Now().Add(-time.Hour).Truncate(time.Second)
+		writeFile(t, filepath.Join(home, "a.txt"), "a", past)
+		writeFile(t, filepath.Join(home, "b.txt"), "b", past)
+		writeFile(t, filepath.Join(home, "deleted.txt"), "deleted", past)
+		writeFile(t, filepath.Join(home, "node_modules", "dep.js"), "dep", past)
+		require.NoError(t, os.Symlink("a.txt", filepath.Join(home, "link")))
+		require.NoError(t, os.Mkdir(filepath.Join(home, "empty"), 0o700))
+
+		full, m1 := archive(t, []string{home, filepath.Join(t.TempDir(), "missing")}, []string{"node_modules"}, nil)
+		require.Equal(t, []string{filepath.ToSlash(home)}, m1.Paths)
+		require.Equal(t, 4, m1.FileCount())
+		require.ElementsMatch(t, []string{
+			".coder-snapshot.json",
+			trimSlash(home) + "/a.txt",
+			trimSlash(home) + "/b.txt",
+			trimSlash(home) + "/deleted.txt",
+		}, entries(t, full))
+
+		writeFile(t, filepath.Join(home, "b.txt"), "b2", past.Add(time.Minute))
+		writeFile(t, filepath.Join(home, "c.txt"), "c", past)
+		require.NoError(t, os.Remove(filepath.Join(home, "deleted.txt")))
+
+		incr, m2 := archive(t, []string{home}, []string{"node_modules"}, m1)
+		require.Equal(t, 4, m2.FileCount())
+		require.ElementsMatch(t, []string{
+			".coder-snapshot.json",
+			trimSlash(home) + "/b.txt",
+			trimSlash(home) + "/c.txt",
+		}, entries(t, incr))
+
+		// Restore into a fresh home directory.
+		require.NoError(t, os.RemoveAll(home))
+		writeFile(t, filepath.Join(home, "a.txt"), "template", time.Now())
+		writeFile(t, filepath.Join(home, ".bashrc"), "template", time.Now())
+
+		restored, missing, err := agentsnapshot.Restore(context.Background(), testutil.Logger(t), []agentsnapshot.Opener{opener(incr), opener(full)})
+		require.NoError(t, err)
+		require.Zero(t, missing)
+		require.Equal(t, m2.Files, restored.Files)
+
+		for name, want := range map[string]string{
+			"a.txt":   "a",
+			"b.txt":   "b2",
+			"c.txt":   "c",
+			".bashrc": "template",
+		} {
+			got, err := os.ReadFile(filepath.Join(home, name))
+			require.NoError(t, err, name)
+			require.Equal(t, want, string(got), name)
+		}
+		require.NoFileExists(t, filepath.Join(home, "deleted.txt"))
+		require.NoDirExists(t, filepath.Join(home, "node_modules"))
+		require.DirExists(t, filepath.Join(home, "empty"))
+		link, err := os.Readlink(filepath.Join(home, "link"))
+		require.NoError(t, err)
+		require.Equal(t, "a.txt", link)
+		info, err := os.Stat(filepath.Join(home, "b.txt"))
+		require.NoError(t, err)
+		require.True(t, info.ModTime().Equal(past.Add(time.Minute)))
+
+		// Restored files keep their metadata, so nothing changed since the
+		// restored snapshot.
+		next, _ := archive(t, []string{home}, []string{"node_modules", ".bashrc"}, restored)
+		require.Equal(t, []string{".coder-snapshot.json"}, entries(t, next))
+	})
+
+	t.Run("OutsideRoot", func(t *testing.T) {
+		t.Parallel()
+
+		home := filepath.Join(t.TempDir(), "home")
+		outside := t.TempDir()
+		require.NoError(t, os.MkdirAll(home, 0o755))
+
+		// A symlink in the archive must not allow later entries to be
+		// written outside of the snapshot paths.
+		escape := malicious(t, home, map[string]agentsnapshot.FileInfo{
+			home + "/dir":      {Mode: fs.ModeSymlink | 0o777, Link: outside},
+			home + "/dir/file": {Mode: 0o600, Size: 4},
+		})
+		_, missing, err := agentsnapshot.Restore(context.Background(), testutil.Logger(t), []agentsnapshot.Opener{opener(escape)})
+		require.NoError(t, err)
+		require.Equal(t, 1, missing)
+		require.NoFileExists(t, filepath.Join(outside, "file"))
+
+		// Entries outside of the snapshot paths are rejected.
+		outsideEntry := malicious(t, home, map[string]agentsnapshot.FileInfo{
+			outside + "/file": {Mode: 0o600, Size: 4},
+		})
+		_, _, err = agentsnapshot.Restore(context.Background(), testutil.Logger(t), []agentsnapshot.Opener{opener(outsideEntry)})
+		require.ErrorContains(t, err, "outside of the snapshot paths")
+		require.NoFileExists(t, filepath.Join(outside, "file"))
+	})
+}
+
+// malicious returns an archive with the given entries, in order of their
+// paths, and a manifest that lists them.
+func malicious(t *testing.T, root string, files map[string]agentsnapshot.FileInfo) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	data, err := json.Marshal(agentsnapshot.Manifest{
+		Paths: []string{filepath.ToSlash(root)},
+		Files: files,
+	})
+	require.NoError(t, err)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: ".coder-snapshot.json", Mode: 0o600, Size: int64(len(data))}))
+	_, err = tw.Write(data)
+	require.NoError(t, err)
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		fi := files[name]
+		hdr := &tar.Header{Name: trimSlash(name), Mode: int64(fi.Mode.Perm()), Size: fi.Size, Typeflag: tar.TypeReg}
+		if fi.Link != "" {
+			hdr.Typeflag, hdr.Linkname = tar.TypeSymlink, fi.Link
+		}
+		require.NoError(t, tw.WriteHeader(hdr))
+		_, err = tw.Write(bytes.Repeat([]byte("x"), int(fi.Size)))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gw.Close())
+	return &buf
+}
+
+func trimSlash(p string) string {
+	return filepath.ToSlash(p)[1:]
+}
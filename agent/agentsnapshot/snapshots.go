@@ -0,0 +1,204 @@
+// Package agentsnapshot takes incremental snapshots of directories in a
+// workspace and restores them into a new build.
+package agentsnapshot
+
+import (
+	"context"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"github.com/coder/coder/v2/codersdk"
+	"github.com/coder/coder/v2/codersdk/agentsdk"
+	"github.com/coder/quartz"
+)
+
+// MaxIncrementalSnapshots is the number of incremental snapshots taken
+// after a full snapshot before the next full snapshot. It bounds the number
+// of archives needed to restore a snapshot.
+const MaxIncrementalSnapshots = 10
+
+// Client is the subset of the agent API used for snapshots.
+type Client interface {
+	UploadSnapshot(ctx context.Context, req agentsdk.UploadSnapshotRequest, archive io.Reader) (codersdk.WorkspaceSnapshot, error)
+	PendingSnapshotRestore(ctx context.Context) (agentsdk.PendingSnapshotRestore, error)
+	DownloadSnapshot(ctx context.Context, id uuid.UUID) (io.ReadCloser, error)
+	CompleteSnapshotRestore(ctx context.Context, req agentsdk.CompleteSnapshotRestoreRequest) error
+}
+
+// Options configure snapshots. Snapshots are disabled unless Client and
+// Paths are set.
+type Options struct {
+	Client Client
+	// Paths are the absolute paths of the directories to snapshot.
+	Paths []string
+	// Exclude are glob patterns of files and directories to skip, see
+	// Excluded.
+	Exclude []string
+	// Interval between scheduled snapshots. Zero disables scheduled
+	// snapshots, snapshots are then only taken when the agent stops.
+	Interval time.Duration
+	Clock    quartz.Clock
+}
+
+// Manager takes and restores snapshots.
+type Manager struct {
+	logger slog.Logger
+	opts   Options
+
+	// mu serializes snapshots and restores, and protects the fields
+	// below.
+	mu sync.Mutex
+	// base is the manifest of the last snapshot uploaded or restored,
+	// which the next snapshot is incremental to.
+	base   *Manifest
+	baseID uuid.UUID
+	// depth is the number of snapshots in the chain ending at base.
+	depth int
+}
+
+// New returns a Manager, or nil when snapshots are disabled.
+func New(logger slog.Logger, opts Options) *Manager {
+	if opts.Client == nil || len(opts.Paths) == 0 {
+		return nil
+	}
+	if opts.Clock == nil {
+		opts.Clock = quartz.NewReal()
+	}
+	return &Manager{
+		logger: logger,
+		opts:   opts,
+	}
+}
+
+// Run takes a snapshot every interval until ctx is done.
+func (m *Manager) Run(ctx context.Context) {
+	if m.opts.Interval <= 0 {
+		return
+	}
+	m.logger.Info(ctx, "scheduled snapshots enabled", slog.F("interval", m.opts.Interval), slog.F("paths", m.opts.Paths))
+	_ = m.opts.Clock.TickerFunc(ctx, m.opts.Interval, func() error {
+		if _, err := m.Snapshot(ctx, codersdk.WorkspaceSnapshotTriggerSchedule); err != nil && ctx.Err() == nil {
+			m.logger.Error(ctx, "scheduled snapshot failed", slog.Error(err))
+		}
+		return nil
+	}, "agentsnapshot", "schedule").Wait()
+}
+
+// Snapshot archives the configured paths and uploads the archive. The
+// snapshot is incremental to the previous snapshot, unless there is none
+// or the chain has reached MaxIncrementalSnapshots.
+func (m *Manager) Snapshot(ctx context.Context, trigger codersdk.WorkspaceSnapshotTrigger) (codersdk.WorkspaceSnapshot, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	base := m.base
+	if m.depth > MaxIncrementalSnapshots {
+		base = nil
+	}
+
+	f, err := os.CreateTemp("", "coder-snapshot-*.tar.gz")
+	if err != nil {
+		return codersdk.WorkspaceSnapshot{}, xerrors.Errorf("create temporary file: %w", err)
+	}
+	defer func() {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+	}()
+
+	start := m.opts.Clock.Now()
+	manifest, err := WriteArchive(ctx, m.logger, f, m.opts.Paths, m.opts.Exclude, base)
+	if err != nil {
+		return codersdk.WorkspaceSnapshot{}, xerrors.Errorf("write archive: %w", err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return codersdk.WorkspaceSnapshot{}, xerrors.Errorf("seek archive: %w", err)
+	}
+
+	req := agentsdk.UploadSnapshotRequest{
+		Trigger: trigger,
+		// #nosec G115 - The number of files always fits in an int32.
+		FileCount: int32(manifest.FileCount()),
+		Paths:     manifest.Paths,
+	}
+	if base != nil {
+		req.ParentID = &m.baseID
+	}
+	snapshot, err := m.opts.Client.UploadSnapshot(ctx, req, f)
+	if err != nil {
+		return codersdk.WorkspaceSnapshot{}, xerrors.Errorf("upload snapshot: %w", err)
+	}
+
+	m.logger.Info(ctx, "uploaded snapshot",
+		slog.F("snapshot_id", snapshot.ID),
+		slog.F("trigger", trigger),
+		slog.F("incremental", base != nil),
+		slog.F("size_bytes", snapshot.SizeBytes),
+		slog.F("file_count", snapshot.FileCount),
+		slog.F("duration", m.opts.Clock.Since(start)),
+	)
+	if base == nil {
+		m.depth = 0
+	}
+	m.base = manifest
+	m.baseID = snapshot.ID
+	m.depth++
+	return snapshot, nil
+}
+
+// Restore restores the snapshot requested for this build, if any. The
+// outcome is reported to coderd so that the restore is not attempted
+// again.
+func (m *Manager) Restore(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	pending, err := m.opts.Client.PendingSnapshotRestore(ctx)
+	if err != nil {
+		return xerrors.Errorf("get pending restore: %w", err)
+	}
+	if len(pending.Snapshots) == 0 {
+		return nil
+	}
+	snapshot := pending.Snapshots[len(pending.Snapshots)-1]
+	logger := m.logger.With(slog.F("snapshot_id", snapshot.ID), slog.F("chain_length", len(pending.Snapshots)))
+	logger.Info(ctx, "restoring snapshot")
+
+	archives := make([]Opener, 0, len(pending.Snapshots))
+	for i := len(pending.Snapshots) - 1; i >= 0; i-- {
+		id := pending.Snapshots[i].ID
+		archives = append(archives, func(ctx context.Context) (io.ReadCloser, error) {
+			return m.opts.Client.DownloadSnapshot(ctx, id)
+		})
+	}
+	start := m.opts.Clock.Now()
+	manifest, missing, restoreErr := Restore(ctx, logger, archives)
+
+	complete := agentsdk.CompleteSnapshotRestoreRequest{SnapshotID: snapshot.ID}
+	if restoreErr != nil {
+		complete.Error = restoreErr.Error()
+	}
+	if err := m.opts.Client.CompleteSnapshotRestore(ctx, complete); err != nil {
+		logger.Error(ctx, "failed to report snapshot restore", slog.Error(err))
+	}
+	if restoreErr != nil {
+		return xerrors.Errorf("restore snapshot %s: %w", snapshot.ID, restoreErr)
+	}
+
+	logger.Info(ctx, "restored snapshot",
+		slog.F("file_count", manifest.FileCount()),
+		slog.F("missing", missing),
+		slog.F("duration", m.opts.Clock.Since(start)),
+	)
+	// Restored files keep their modification times, so the next snapshot
+	// can be incremental to the restored one.
+	m.base = manifest
+	m.baseID = snapshot.ID
+	m.depth = len(pending.Snapshots)
+	return nil
+}
@@ -0,0 +1,149 @@
+package agentsnapshot_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/v2/agent/agentsnapshot"
+	"github.com/coder/coder/v2/codersdk"
+	"github.com/coder/coder/v2/codersdk/agentsdk"
+	"github.com/coder/coder/v2/testutil"
+	"github.com/coder/quartz"
+)
+
+type fakeClient struct {
+	mu        sync.Mutex
+	snapshots []codersdk.WorkspaceSnapshot
+	archives  map[uuid.UUID][]byte
+	pending   []codersdk.WorkspaceSnapshot
+	completed []agentsdk.CompleteSnapshotRestoreRequest
+}
+
+func (c *fakeClient) UploadSnapshot(_ context.Context, req agentsdk.UploadSnapshotRequest, archive io.Reader) (codersdk.WorkspaceSnapshot, error) {
+	data, err := io.ReadAll(archive)
+	if err != nil {
+		return codersdk.WorkspaceSnapshot{}, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	snapshot := codersdk.WorkspaceSnapshot{
+		ID:        uuid.New(),
+		ParentID:  req.ParentID,
+		Trigger:   req.Trigger,
+		SizeBytes: int64(len(data)),
+		FileCount: req.FileCount,
+		Paths:     req.Paths,
+	}
+	c.snapshots = append(c.snapshots, snapshot)
+	c.archives[snapshot.ID] = data
+	return snapshot, nil
+}
+
+func (c *fakeClient) PendingSnapshotRestore(context.Context) (agentsdk.PendingSnapshotRestore, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return agentsdk.PendingSnapshotRestore{Snapshots: c.pending}, nil
+}
+
+func (c *fakeClient) DownloadSnapshot(_ context.Context, id uuid.UUID) (io.ReadCloser, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data, ok := c.archives[id]
+	if !ok {
+		return nil, xerrors.Errorf("snapshot %s not found", id)
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (c *fakeClient) CompleteSnapshotRestore(_ context.Context, req agentsdk.CompleteSnapshotRestoreRequest) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.completed = append(c.completed, req)
+	c.pending = nil
+	return nil
+}
+
+func TestManager(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("snapshots use unix paths")
+	}
+
+	require.Nil(t, agentsnapshot.New(testutil.Logger(t), agentsnapshot.Options{Client: &fakeClient{}}))
+
+	ctx := testutil.Context(t, testutil.WaitShort)
+	home := t.TempDir()
+	client := &fakeClient{archives: map[uuid.UUID][]byte{}}
+	clock := quartz.NewMock(t)
+	trap := clock.Trap().TickerFunc("agentsnapshot", "schedule")
+	defer trap.Close()
+	m := agentsnapshot.New(testutil.Logger(t), agentsnapshot.Options{
+		Client:   client,
+		Paths:    []string{home},
+		Interval: time.Hour,
+		Clock:    clock,
+	})
+
+	require.NoError(t, os.WriteFile(filepath.Join(home, "a.txt"), []byte("a"), 0o600))
+	first, err := m.Snapshot(ctx, codersdk.WorkspaceSnapshotTriggerStop)
+	require.NoError(t, err)
+	require.Nil(t, first.ParentID)
+	require.Equal(t, int32(1), first.FileCount)
+
+	// Scheduled snapshots are incremental to the previous snapshot.
+	runCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		m.Run(runCtx)
+	}()
+	trap.MustWait(ctx).MustRelease(ctx)
+	require.NoError(t, os.WriteFile(filepath.Join(home, "b.txt"), []byte("b"), 0o600))
+	_, w := clock.AdvanceNext()
+	w.MustWait(ctx)
+	cancel()
+	<-done
+
+	require.Len(t, client.snapshots, 2)
+	second := client.snapshots[1]
+	require.Equal(t, codersdk.WorkspaceSnapshotTriggerSchedule, second.Trigger)
+	require.NotNil(t, second.ParentID)
+	require.Equal(t, first.ID, *second.ParentID)
+	require.Equal(t, int32(2), second.FileCount)
+
+	// Restore the chain into a new agent.
+	require.NoError(t, os.RemoveAll(home))
+	client.pending = client.snapshots
+	restorer := agentsnapshot.New(testutil.Logger(t), agentsnapshot.Options{
+		Client: client,
+		Paths:  []string{home},
+	})
+	require.NoError(t, restorer.Restore(ctx))
+	require.Equal(t, []agentsdk.CompleteSnapshotRestoreRequest{{SnapshotID: second.ID}}, client.completed)
+	for name, want := range map[string]string{"a.txt": "a", "b.txt": "b"} {
+		got, err := os.ReadFile(filepath.Join(home, name))
+		require.NoError(t, err)
+		require.Equal(t, want, string(got))
+	}
+
+	// Nothing is pending anymore, and the next snapshot continues the
+	// restored chain.
+	require.NoError(t, restorer.Restore(ctx))
+	require.Len(t, client.completed, 1)
+	third, err := restorer.Snapshot(ctx, codersdk.WorkspaceSnapshotTriggerStop)
+	require.NoError(t, err)
+	require.NotNil(t, third.ParentID)
+	require.Equal(t, second.ID, *third.ParentID)
+}
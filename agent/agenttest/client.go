@@ -98,8 +98,8 @@ func (c *Client) Close() {
 	c.derpMapOnce.Do(func() { close(c.derpMapUpdates) })
 }
 
-func (c *Client) ConnectRPC26(ctx context.Context) (
-	agentproto.DRPCAgentClient26, proto.DRPCTailnetClient26, error,
+func (c *Client) ConnectRPC27(ctx context.Context) (
+	agentproto.DRPCAgentClient27, proto.DRPCTailnetClient27, error,
 ) {
 	conn, lis := drpcsdk.MemTransportPipe()
 	c.LastWorkspaceAgent = func() {
@@ -163,6 +163,10 @@ func (c *Client) GetConnectionReports() []*agentproto.ReportConnectionRequest {
 	return c.fakeAgentAPI.GetConnectionReports()
 }
 
+func (c *Client) GetAutoPortShares() ([]*agentproto.UpdateAutoPortSharesRequest_Share, bool) {
+	return c.fakeAgentAPI.GetAutoPortShares()
+}
+
 func (c *Client) GetSubAgents() []*agentproto.SubAgent {
 	return c.fakeAgentAPI.GetSubAgents()
 }
@@ -197,6 +201,8 @@ type FakeAgentAPI struct {
 	subAgentDirs        map[uuid.UUID]string
 	subAgentDisplayApps map[uuid.UUID][]agentproto.CreateSubAgentRequest_DisplayApp
 	subAgentApps        map[uuid.UUID][]*agentproto.CreateSubAgentRequest_App
+	autoPortShares      []*agentproto.UpdateAutoPortSharesRequest_Share
+	autoPortSharesSent  bool
 
 	getAnnouncementBannersFunc              func() ([]codersdk.BannerConfig, error)
 	getResourcesMonitoringConfigurationFunc func() (*agentproto.GetResourcesMonitoringConfigurationResponse, error)
@@ -527,6 +533,23 @@ func (f *FakeAgentAPI) GetSubAgentApps(id uuid.UUID) ([]*agentproto.CreateSubAge
 	return apps, nil
 }
 
+func (f *FakeAgentAPI) UpdateAutoPortShares(_ context.Context, req *agentproto.UpdateAutoPortSharesRequest) (*agentproto.UpdateAutoPortSharesResponse, error) {
+	f.Lock()
+	f.autoPortShares = req.GetShares()
+	f.autoPortSharesSent = true
+	f.Unlock()
+
+	return &agentproto.UpdateAutoPortSharesResponse{}, nil
+}
+
+// GetAutoPortShares returns the most recently reported auto port shares,
+// and whether the agent has reported any.
+func (f *FakeAgentAPI) GetAutoPortShares() ([]*agentproto.UpdateAutoPortSharesRequest_Share, bool) {
+	f.Lock()
+	defer f.Unlock()
+	return slices.Clone(f.autoPortShares), f.autoPortSharesSent
+}
+
 func NewFakeAgentAPI(t testing.TB, logger slog.Logger, manifest *agentproto.Manifest, statsCh chan *agentproto.Stats) *FakeAgentAPI {
 	return &FakeAgentAPI{
 		t:           t,
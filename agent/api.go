@@ -7,6 +7,7 @@ import (
 
 	"github.com/go-chi/chi/v5"
 
+	"github.com/coder/coder/v2/agent/agentproc"
 	"github.com/coder/coder/v2/coderd/httpapi"
 	"github.com/coder/coder/v2/codersdk"
 )
@@ -19,22 +20,7 @@ func (a *agent) apiHandler() http.Handler {
 		})
 	})
 
-	// Make a copy to ensure the map is not modified after the handler is
-	// created.
-	cpy := make(map[int]string)
-	for k, b := range a.ignorePorts {
-		cpy[k] = b
-	}
-
-	cacheDuration := 1 * time.Second
-	if a.portCacheDuration > 0 {
-		cacheDuration = a.portCacheDuration
-	}
-
-	lp := &listeningPortsHandler{
-		ignorePorts:   cpy,
-		cacheDuration: cacheDuration,
-	}
+	lp := a.newListeningPortsHandler()
 
 	if a.containerAPI != nil {
 		r.Mount("/api/v0/containers", a.containerAPI.Routes())
@@ -50,6 +36,8 @@ func (a *agent) apiHandler() http.Handler {
 	promHandler := PrometheusMetricsHandler(a.prometheusRegistry, a.logger)
 
 	r.Get("/api/v0/listening-ports", lp.handler)
+	r.Mount("/api/v0/processes", agentproc.NewAPI(a.logger.Named("processes"), a.processLister).Routes())
+	r.Mount("/api/v0/http-captures", a.httpCaptures.Routes())
 	r.Get("/api/v0/netcheck", a.HandleNetcheck)
 	r.Post("/api/v0/list-directory", a.HandleLS)
 	r.Get("/debug/logs", a.HandleHTTPDebugLogs)
@@ -61,6 +49,25 @@ func (a *agent) apiHandler() http.Handler {
 	return r
 }
 
+func (a *agent) newListeningPortsHandler() *listeningPortsHandler {
+	// Make a copy to ensure the map is not modified after the handler is
+	// created.
+	cpy := make(map[int]string)
+	for k, b := range a.ignorePorts {
+		cpy[k] = b
+	}
+
+	cacheDuration := 1 * time.Second
+	if a.portCacheDuration > 0 {
+		cacheDuration = a.portCacheDuration
+	}
+
+	return &listeningPortsHandler{
+		ignorePorts:   cpy,
+		cacheDuration: cacheDuration,
+	}
+}
+
 type listeningPortsHandler struct {
 	ignorePorts   map[int]string
 	cacheDuration time.Duration
@@ -0,0 +1,116 @@
+package agent
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"github.com/coder/coder/v2/agent/proto"
+	"github.com/coder/coder/v2/codersdk"
+	"github.com/coder/coder/v2/codersdk/agentsdk"
+)
+
+// autoPortSharesInterval is how often the agent scans for listening ports
+// that match the port share rules of the template.
+const autoPortSharesInterval = 5 * time.Second
+
+// reportAutoPortShares returns a function that periodically evaluates the
+// port share rules from the manifest against the listening ports and reports
+// the ports to share whenever the set changes.
+func (a *agent) reportAutoPortShares(manifestOK *checkpoint) func(context.Context, proto.DRPCAgentClient27) error {
+	return func(ctx context.Context, aAPI proto.DRPCAgentClient27) error {
+		if err := manifestOK.wait(ctx); err != nil {
+			return xerrors.Errorf("no manifest: %w", err)
+		}
+		manifest := a.manifest.Load()
+		if len(manifest.PortShareRules) == 0 {
+			// Report an empty set so that auto shares created while the
+			// template still had rules are removed.
+			_, err := aAPI.UpdateAutoPortShares(ctx, &proto.UpdateAutoPortSharesRequest{})
+			if err != nil {
+				return xerrors.Errorf("clear auto port shares: %w", err)
+			}
+			return nil
+		}
+
+		logger := a.logger.Named("auto_port_shares")
+		lp := a.newListeningPortsHandler()
+
+		// lastShares is nil until the first report so that stale auto
+		// shares from a previous agent are cleaned up on startup.
+		var lastShares []*proto.UpdateAutoPortSharesRequest_Share
+		ticker := a.clock.TickerFunc(ctx, autoPortSharesInterval, func() error {
+			ports, err := lp.getListeningPorts()
+			if err != nil {
+				logger.Warn(ctx, "failed to scan listening ports", slog.Error(err))
+				return nil
+			}
+
+			shares, err := autoPortShares(manifest.PortShareRules, ports)
+			if err != nil {
+				logger.Error(ctx, "failed to evaluate port share rules", slog.Error(err))
+				return nil
+			}
+			if lastShares != nil && autoPortSharesEqual(lastShares, shares) {
+				return nil
+			}
+
+			_, err = aAPI.UpdateAutoPortShares(ctx, &proto.UpdateAutoPortSharesRequest{Shares: shares})
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				logger.Error(ctx, "failed to update auto port shares", slog.Error(err))
+				return nil
+			}
+			logger.Debug(ctx, "updated auto port shares", slog.F("shares", len(shares)))
+			lastShares = shares
+			return nil
+		}, "agent", "auto_port_shares")
+		err := ticker.Wait()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+}
+
+// autoPortShares returns the ports that should be shared according to the
+// first rule matching each listening port, ordered by port.
+func autoPortShares(rules []codersdk.TemplatePortShareRule, ports []codersdk.WorkspaceAgentListeningPort) ([]*proto.UpdateAutoPortSharesRequest_Share, error) {
+	shares := make([]*proto.UpdateAutoPortSharesRequest_Share, 0)
+	for _, port := range ports {
+		for _, rule := range rules {
+			if !rule.Matches(int32(port.Port)) {
+				continue
+			}
+			prule, err := agentsdk.ProtoFromPortShareRule(rule)
+			if err != nil {
+				return nil, err
+			}
+			shares = append(shares, &proto.UpdateAutoPortSharesRequest_Share{
+				Port:       int32(port.Port),
+				ShareLevel: prule.ShareLevel,
+				Protocol:   prule.Protocol,
+				Label:      prule.Label,
+			})
+			break
+		}
+	}
+	slices.SortFunc(shares, func(a, b *proto.UpdateAutoPortSharesRequest_Share) int {
+		return int(a.Port - b.Port)
+	})
+	return shares, nil
+}
+
+func autoPortSharesEqual(a, b []*proto.UpdateAutoPortSharesRequest_Share) bool {
+	return slices.EqualFunc(a, b, func(x, y *proto.UpdateAutoPortSharesRequest_Share) bool {
+		return x.Port == y.Port &&
+			x.ShareLevel == y.ShareLevel &&
+			x.Protocol == y.Protocol &&
+			x.Label == y.Label
+	})
+}
@@ -0,0 +1,51 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/agent/proto"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+func TestAutoPortShares(t *testing.T) {
+	t.Parallel()
+
+	rules := []codersdk.TemplatePortShareRule{
+		{
+			PortStart:  3000,
+			PortEnd:    3999,
+			ShareLevel: codersdk.WorkspaceAgentPortShareLevelOrganization,
+			Protocol:   codersdk.WorkspaceAgentPortShareProtocolHTTP,
+		},
+		{
+			PortStart:  8080,
+			PortEnd:    8080,
+			ShareLevel: codersdk.WorkspaceAgentPortShareLevelPublic,
+			Protocol:   codersdk.WorkspaceAgentPortShareProtocolHTTPS,
+			Label:      "docs",
+		},
+	}
+	ports := []codersdk.WorkspaceAgentListeningPort{
+		{Port: 8080, Network: "tcp"},
+		{Port: 5432, Network: "tcp"},
+		{Port: 3000, Network: "tcp"},
+		{Port: 3999, Network: "tcp"},
+	}
+
+	shares, err := autoPortShares(rules, ports)
+	require.NoError(t, err)
+	require.Equal(t, []*proto.UpdateAutoPortSharesRequest_Share{
+		{Port: 3000, ShareLevel: proto.WorkspaceApp_ORGANIZATION, Protocol: proto.PortShareRule_HTTP},
+		{Port: 3999, ShareLevel: proto.WorkspaceApp_ORGANIZATION, Protocol: proto.PortShareRule_HTTP},
+		{Port: 8080, ShareLevel: proto.WorkspaceApp_PUBLIC, Protocol: proto.PortShareRule_HTTPS, Label: "docs"},
+	}, shares)
+
+	require.True(t, autoPortSharesEqual(shares, shares))
+	require.False(t, autoPortSharesEqual(shares, shares[1:]))
+
+	shares, err = autoPortShares(rules, nil)
+	require.NoError(t, err)
+	require.Empty(t, shares)
+}
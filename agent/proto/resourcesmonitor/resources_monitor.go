@@ -9,27 +9,42 @@ import (
 	"github.com/coder/quartz"
 )
 
+// TopProcessesCount is the number of processes reported alongside the
+// datapoints when memory monitoring is enabled.
+const TopProcessesCount = 5
+
 type monitor struct {
 	logger           slog.Logger
 	clock            quartz.Clock
 	config           *proto.GetResourcesMonitoringConfigurationResponse
 	resourcesFetcher Fetcher
+	processesFetcher ProcessesFetcher
 	datapointsPusher datapointsPusher
 	queue            *Queue
 }
 
+// NewResourcesMonitor returns a monitor that periodically collects and pushes
+// resource usage. processesFetcher is optional, when set the processes using
+// the most memory are pushed alongside the datapoints.
+//
 //nolint:revive
-func NewResourcesMonitor(logger slog.Logger, clock quartz.Clock, config *proto.GetResourcesMonitoringConfigurationResponse, resourcesFetcher Fetcher, datapointsPusher datapointsPusher) *monitor {
+func NewResourcesMonitor(logger slog.Logger, clock quartz.Clock, config *proto.GetResourcesMonitoringConfigurationResponse, resourcesFetcher Fetcher, processesFetcher ProcessesFetcher, datapointsPusher datapointsPusher) *monitor {
 	return &monitor{
 		logger:           logger,
 		clock:            clock,
 		config:           config,
 		resourcesFetcher: resourcesFetcher,
+		processesFetcher: processesFetcher,
 		datapointsPusher: datapointsPusher,
 		queue:            NewQueue(int(config.Config.NumDatapoints)),
 	}
 }
 
+// ProcessesFetcher fetches the processes using the most memory.
+type ProcessesFetcher interface {
+	FetchTopProcesses(ctx context.Context, n int) ([]*proto.PushResourcesMonitoringUsageRequest_Process, error)
+}
+
 type datapointsPusher interface {
 	PushResourcesMonitoringUsage(ctx context.Context, req *proto.PushResourcesMonitoringUsageRequest) (*proto.PushResourcesMonitoringUsageResponse, error)
 }
@@ -74,9 +89,21 @@ func (m *monitor) Start(ctx context.Context) error {
 		m.queue.Push(datapoint)
 
 		if m.queue.IsFull() {
-			_, err := m.datapointsPusher.PushResourcesMonitoringUsage(ctx, &proto.PushResourcesMonitoringUsageRequest{
+			req := &proto.PushResourcesMonitoringUsageRequest{
 				Datapoints: m.queue.ItemsAsProto(),
-			})
+			}
+			if m.processesFetcher != nil && m.config.Memory != nil && m.config.Memory.Enabled {
+				// The top processes are only informational, so failing to
+				// fetch them must not prevent pushing the datapoints.
+				procs, err := m.processesFetcher.FetchTopProcesses(ctx, TopProcessesCount)
+				if err != nil {
+					m.logger.Warn(ctx, "failed to fetch top processes", slog.Error(err))
+				} else {
+					req.TopProcesses = procs
+				}
+			}
+
+			_, err := m.datapointsPusher.PushResourcesMonitoringUsage(ctx, req)
 			if err != nil {
 				// We don't want to stop the monitoring if we fail to push the datapoints
 				// to the server. We just log the error and continue.
@@ -216,7 +216,7 @@ func TestPushResourcesMonitoringWithConfig(t *testing.T) {
 				PushResourcesMonitoringUsageFunc: datapointsPusher,
 			}
 
-			monitor := resourcesmonitor.NewResourcesMonitor(logger, clk, tt.config, tt.fetcher, pusher)
+			monitor := resourcesmonitor.NewResourcesMonitor(logger, clk, tt.config, tt.fetcher, nil, pusher)
 			require.NoError(t, monitor.Start(ctx))
 
 			for i := 0; i < tt.numTicks; i++ {
@@ -232,3 +232,88 @@ func TestPushResourcesMonitoringWithConfig(t *testing.T) {
 		})
 	}
 }
+
+type processesFetcher struct {
+	procs []*proto.PushResourcesMonitoringUsageRequest_Process
+	err   error
+}
+
+func (f *processesFetcher) FetchTopProcesses(_ context.Context, n int) ([]*proto.PushResourcesMonitoringUsageRequest_Process, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.procs[:min(n, len(f.procs))], nil
+}
+
+func TestPushResourcesMonitoringTopProcesses(t *testing.T) {
+	t.Parallel()
+
+	procs := []*proto.PushResourcesMonitoringUsageRequest_Process{
+		{Pid: 10, Name: "node", MemoryRss: 4000},
+		{Pid: 20, Name: "go", MemoryRss: 2000},
+	}
+
+	tests := []struct {
+		name    string
+		memory  bool
+		fetcher *processesFetcher
+		want    []*proto.PushResourcesMonitoringUsageRequest_Process
+	}{
+		{
+			name:    "MemoryEnabled",
+			memory:  true,
+			fetcher: &processesFetcher{procs: procs},
+			want:    procs,
+		},
+		{
+			name:    "MemoryDisabled",
+			memory:  false,
+			fetcher: &processesFetcher{procs: procs},
+		},
+		{
+			// Failing to fetch processes must not prevent pushing datapoints.
+			name:    "FetchError",
+			memory:  true,
+			fetcher: &processesFetcher{err: assert.AnError},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var (
+				logger = slog.Make(sloghuman.Sink(os.Stdout))
+				clk    = quartz.NewMock(t)
+				pushed []*proto.PushResourcesMonitoringUsageRequest
+			)
+			pusher := &datapointsPusherMock{
+				PushResourcesMonitoringUsageFunc: func(_ context.Context, req *proto.PushResourcesMonitoringUsageRequest) (*proto.PushResourcesMonitoringUsageResponse, error) {
+					pushed = append(pushed, req)
+					return &proto.PushResourcesMonitoringUsageResponse{}, nil
+				},
+			}
+			config := &proto.GetResourcesMonitoringConfigurationResponse{
+				Config: &proto.GetResourcesMonitoringConfigurationResponse_Config{
+					NumDatapoints:             1,
+					CollectionIntervalSeconds: 1,
+				},
+				Memory: &proto.GetResourcesMonitoringConfigurationResponse_Memory{
+					Enabled: tt.memory,
+				},
+			}
+
+			monitor := resourcesmonitor.NewResourcesMonitor(logger, clk, config, &fetcher{totalMemory: 16000, usedMemory: 8000}, tt.fetcher, pusher)
+			require.NoError(t, monitor.Start(ctx))
+
+			_, waiter := clk.AdvanceNext()
+			require.NoError(t, waiter.Wait(ctx))
+
+			require.Len(t, pushed, 1)
+			require.Equal(t, tt.want, pushed[0].TopProcesses)
+		})
+	}
+}
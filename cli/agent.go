@@ -30,6 +30,7 @@ import (
 	"github.com/coder/coder/v2/agent"
 	"github.com/coder/coder/v2/agent/agentcontainers"
 	"github.com/coder/coder/v2/agent/agentexec"
+	"github.com/coder/coder/v2/agent/agentsnapshot"
 	"github.com/coder/coder/v2/agent/agentssh"
 	"github.com/coder/coder/v2/agent/reaper"
 	"github.com/coder/coder/v2/buildinfo"
@@ -56,6 +57,9 @@ func (r *RootCmd) workspaceAgent() *serpent.Command {
 		agentHeaderCommand  string
 		agentHeader         []string
 		devcontainers       bool
+		snapshotPaths       []string
+		snapshotExclude     []string
+		snapshotInterval    time.Duration
 	)
 	cmd := &serpent.Command{
 		Use:   "agent",
@@ -365,6 +369,12 @@ func (r *RootCmd) workspaceAgent() *serpent.Command {
 					DevcontainerAPIOptions: []agentcontainers.Option{
 						agentcontainers.WithSubAgentURL(r.agentURL.String()),
 					},
+					Snapshots: agentsnapshot.Options{
+						Client:   client,
+						Paths:    snapshotPaths,
+						Exclude:  snapshotExclude,
+						Interval: snapshotInterval,
+					},
 				})
 
 				promHandler := agent.PrometheusMetricsHandler(prometheusRegistry, logger)
@@ -510,6 +520,26 @@ func (r *RootCmd) workspaceAgent() *serpent.Command {
 			Description: "Allow the agent to automatically detect running devcontainers.",
 			Value:       serpent.BoolOf(&devcontainers),
 		},
+		{
+			Flag:        "snapshot-paths",
+			Env:         "CODER_AGENT_SNAPSHOT_PATHS",
+			Description: "Directories to snapshot when the agent stops and on the snapshot interval, e.g. ~. Snapshots can be restored into a new build with \"coder snapshot restore\". Snapshots are disabled when empty.",
+			Value:       serpent.StringArrayOf(&snapshotPaths),
+		},
+		{
+			Flag:        "snapshot-exclude",
+			Env:         "CODER_AGENT_SNAPSHOT_EXCLUDE",
+			Default:     "node_modules,.cache",
+			Description: "Glob patterns of files and directories to leave out of snapshots. Patterns containing a slash match paths relative to the snapshotted directory, other patterns match any file or directory name.",
+			Value:       serpent.StringArrayOf(&snapshotExclude),
+		},
+		{
+			Flag:        "snapshot-interval",
+			Env:         "CODER_AGENT_SNAPSHOT_INTERVAL",
+			Default:     "0",
+			Description: "How often to snapshot in addition to when the agent stops. Zero only snapshots when the agent stops.",
+			Value:       serpent.DurationOf(&snapshotInterval),
+		},
 	}
 
 	return cmd
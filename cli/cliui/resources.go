@@ -256,3 +256,40 @@ func renderPipe(idx, total int) string {
 	}
 	return pipeMid
 }
+
+// WorkspaceResourceChanges displays the changes an update of a workspace makes
+// to its resources. Persistent resources that would be replaced or deleted are
+// highlighted, since their data is lost.
+// ┌──────────────────────────────────────────────────────────────┐
+// │ Resource Changes                                             │
+// ├──────────────────────────────────────────────────────────────┤
+// │ RESOURCE                 ACTION     REASON                   │
+// │ docker_container.dev     update                              │
+// │ docker_volume.home       replace    name (persistent)        │
+// └──────────────────────────────────────────────────────────────┘
+func WorkspaceResourceChanges(writer io.Writer, changes []codersdk.WorkspaceResourceChange) error {
+	if len(changes) == 0 {
+		_, err := fmt.Fprintln(writer, "The update makes no changes to the resources of the workspace.")
+		return err
+	}
+
+	tableWriter := table.NewWriter()
+	tableWriter.SetTitle("Resource Changes")
+	tableWriter.SetStyle(table.StyleLight)
+	tableWriter.Style().Options.SeparateColumns = false
+	tableWriter.AppendHeader(table.Row{"Resource", "Action", "Reason"})
+	for _, change := range changes {
+		reason := strings.Join(change.ReplacePaths, ", ")
+		if change.Persistent {
+			reason = strings.TrimSpace(reason + " (persistent)")
+		}
+		action := string(change.Action)
+		if change.Destructive() {
+			action = pretty.Sprint(DefaultStyles.Error, action)
+			reason = pretty.Sprint(DefaultStyles.Error, reason)
+		}
+		tableWriter.AppendRow(table.Row{Bold(change.Address), action, reason})
+	}
+	_, err := fmt.Fprintln(writer, tableWriter.Render())
+	return err
+}
@@ -1,10 +1,12 @@
 package cliui_test
 
 import (
+	"bytes"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/coder/coder/v2/cli/cliui"
 	"github.com/coder/coder/v2/coderd/database/dbtime"
@@ -111,3 +113,31 @@ func TestWorkspaceResources(t *testing.T) {
 		<-done
 	})
 }
+
+func TestWorkspaceResourceChanges(t *testing.T) {
+	t.Parallel()
+
+	t.Run("None", func(t *testing.T) {
+		t.Parallel()
+		var out bytes.Buffer
+		require.NoError(t, cliui.WorkspaceResourceChanges(&out, nil))
+		require.Contains(t, out.String(), "no changes")
+	})
+
+	t.Run("Changes", func(t *testing.T) {
+		t.Parallel()
+		var out bytes.Buffer
+		err := cliui.WorkspaceResourceChanges(&out, []codersdk.WorkspaceResourceChange{{
+			Address: "docker_container.dev",
+			Action:  codersdk.WorkspaceResourceChangeActionUpdate,
+		}, {
+			Address:      "docker_volume.home",
+			Action:       codersdk.WorkspaceResourceChangeActionReplace,
+			ReplacePaths: []string{"name"},
+			Persistent:   true,
+		}})
+		require.NoError(t, err)
+		require.Contains(t, out.String(), "docker_container.dev")
+		require.Contains(t, out.String(), "name (persistent)")
+	})
+}
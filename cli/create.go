@@ -381,6 +381,11 @@ type prepWorkspaceBuildArgs struct {
 	RichParameters        []codersdk.WorkspaceBuildParameter
 	RichParameterFile     string
 	RichParameterDefaults []codersdk.WorkspaceBuildParameter
+
+	// UpdateWorkspaceID is the existing workspace the build updates to the
+	// template version. The dry-run previews the changes the update makes
+	// to its resources, which the user must confirm.
+	UpdateWorkspaceID uuid.UUID
 }
 
 // prepWorkspaceBuild will ensure a workspace build will succeed on the latest template version.
@@ -433,6 +438,7 @@ func prepWorkspaceBuild(inv *serpent.Invocation, client *codersdk.Client, args p
 	dryRun, err := client.CreateTemplateVersionDryRun(inv.Context(), templateVersion.ID, codersdk.CreateTemplateVersionDryRunRequest{
 		WorkspaceName:       args.NewWorkspaceName,
 		RichParameterValues: buildParameters,
+		WorkspaceID:         args.UpdateWorkspaceID,
 	})
 	if err != nil {
 		return nil, xerrors.Errorf("begin workspace dry-run: %w", err)
@@ -478,5 +484,46 @@ func prepWorkspaceBuild(inv *serpent.Invocation, client *codersdk.Client, args p
 		return nil, xerrors.Errorf("get resources: %w", err)
 	}
 
+	if args.UpdateWorkspaceID != uuid.Nil {
+		err = confirmWorkspaceUpdate(inv, client, templateVersion.ID, dryRun.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return buildParameters, nil
 }
+
+// confirmWorkspaceUpdate shows the resource changes of a dry-run that
+// previewed a workspace update, and asks the user to confirm them. Replacing
+// or deleting persistent resources defaults to not updating.
+func confirmWorkspaceUpdate(inv *serpent.Invocation, client *codersdk.Client, templateVersionID, dryRunID uuid.UUID) error {
+	preview, err := client.TemplateVersionDryRunResourceChanges(inv.Context(), templateVersionID, dryRunID)
+	if err != nil {
+		return xerrors.Errorf("get workspace update preview: %w", err)
+	}
+	err = cliui.WorkspaceResourceChanges(inv.Stdout, preview.Changes)
+	if err != nil {
+		return xerrors.Errorf("render resource changes: %w", err)
+	}
+	if len(preview.Changes) == 0 {
+		return nil
+	}
+
+	text := "Update workspace?"
+	defaultAnswer := cliui.ConfirmYes
+	for _, change := range preview.Changes {
+		if change.Destructive() {
+			cliui.Warn(inv.Stderr, "The update replaces or deletes persistent resources of the workspace. Their data will be lost.")
+			text = "Update workspace and lose the data of its persistent resources?"
+			defaultAnswer = cliui.ConfirmNo
+			break
+		}
+	}
+	_, err = cliui.Prompt(inv, cliui.PromptOptions{
+		Text:      text,
+		IsConfirm: true,
+		Default:   defaultAnswer,
+	})
+	return err
+}
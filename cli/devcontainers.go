@@ -0,0 +1,249 @@
+package cli
+
+import (
+	"fmt"
+
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/v2/cli/cliui"
+	"github.com/coder/coder/v2/codersdk"
+	"github.com/coder/serpent"
+)
+
+func (r *RootCmd) devcontainers() *serpent.Command {
+	cmd := &serpent.Command{
+		Use:     "devcontainers",
+		Short:   "Manage the dev containers of a workspace agent",
+		Aliases: []string{"devcontainer", "dc"},
+		Handler: func(inv *serpent.Invocation) error {
+			return inv.Command.HelpHandler(inv)
+		},
+		Children: []*serpent.Command{
+			r.devcontainersDelete(),
+			r.devcontainersList(),
+			r.devcontainersLogs(),
+			r.devcontainersRebuild(),
+			r.devcontainersStart(),
+			r.devcontainersStop(),
+		},
+	}
+	return cmd
+}
+
+func (r *RootCmd) devcontainersList() *serpent.Command {
+	type devcontainerRow struct {
+		Name            string                                    `json:"name" table:"name,default_sort"`
+		ID              string                                    `json:"id" table:"id"`
+		Status          codersdk.WorkspaceAgentDevcontainerStatus `json:"status" table:"status"`
+		Dirty           bool                                      `json:"dirty" table:"dirty"`
+		Container       string                                    `json:"container" table:"container"`
+		WorkspaceFolder string                                    `json:"workspace_folder" table:"workspace folder"`
+		Features        []string                                  `json:"features" table:"features"`
+	}
+
+	var (
+		client    = new(codersdk.Client)
+		formatter = cliui.NewOutputFormatter(
+			cliui.TableFormat([]devcontainerRow{}, []string{"name", "status", "dirty", "container", "workspace folder"}),
+			cliui.JSONFormat(),
+		)
+	)
+	cmd := &serpent.Command{
+		Use:     "list <workspace>[.<agent>]",
+		Short:   "List the dev containers of a workspace agent",
+		Aliases: []string{"ls"},
+		Middleware: serpent.Chain(
+			serpent.RequireNArgs(1),
+			r.InitClient(client),
+		),
+		Handler: func(inv *serpent.Invocation) error {
+			ctx := inv.Context()
+			_, agent, err := getWorkspaceAndAgent(ctx, inv, client, false, inv.Args[0])
+			if err != nil {
+				return err
+			}
+
+			resp, err := client.WorkspaceAgentListContainers(ctx, agent.ID, nil)
+			if err != nil {
+				return xerrors.Errorf("list dev containers: %w", err)
+			}
+			if len(resp.Devcontainers) == 0 {
+				_, _ = fmt.Fprintln(inv.Stdout, "No dev containers found")
+				return nil
+			}
+
+			rows := make([]devcontainerRow, 0, len(resp.Devcontainers))
+			for _, dc := range resp.Devcontainers {
+				row := devcontainerRow{
+					Name:            dc.Name,
+					ID:              dc.ID.String(),
+					Status:          dc.Status,
+					Dirty:           dc.Dirty,
+					WorkspaceFolder: dc.WorkspaceFolder,
+				}
+				if dc.Container != nil {
+					row.Container = dc.Container.FriendlyName
+				}
+				for _, f := range dc.Features {
+					row.Features = append(row.Features, f.ID)
+				}
+				rows = append(rows, row)
+			}
+
+			out, err := formatter.Format(ctx, rows)
+			if err != nil {
+				return xerrors.Errorf("format dev containers: %w", err)
+			}
+			_, _ = fmt.Fprintln(inv.Stdout, out)
+			return nil
+		},
+	}
+	formatter.AttachOptions(&cmd.Options)
+	return cmd
+}
+
+// devcontainerAction returns a command that runs fn against a single
+// dev container identified by name or ID.
+func (r *RootCmd) devcontainerAction(use, short, done string, fn func(inv *serpent.Invocation, client *codersdk.Client, agent codersdk.WorkspaceAgent, dc codersdk.WorkspaceAgentDevcontainer) error) *serpent.Command {
+	client := new(codersdk.Client)
+	cmd := &serpent.Command{
+		Use:   use + " <workspace>[.<agent>] <devcontainer>",
+		Short: short,
+		Middleware: serpent.Chain(
+			serpent.RequireNArgs(2),
+			r.InitClient(client),
+		),
+		Handler: func(inv *serpent.Invocation) error {
+			ctx := inv.Context()
+			_, agent, err := getWorkspaceAndAgent(ctx, inv, client, false, inv.Args[0])
+			if err != nil {
+				return err
+			}
+			dc, err := findDevcontainer(inv, client, agent, inv.Args[1])
+			if err != nil {
+				return err
+			}
+			if err := fn(inv, client, agent, dc); err != nil {
+				return err
+			}
+			if done != "" {
+				_, _ = fmt.Fprintf(inv.Stdout, "Dev container %s %s\n", cliui.Keyword(dc.Name), done)
+			}
+			return nil
+		},
+	}
+	return cmd
+}
+
+func (r *RootCmd) devcontainersStop() *serpent.Command {
+	return r.devcontainerAction("stop", "Stop a dev container", "has been stopped", func(inv *serpent.Invocation, client *codersdk.Client, agent codersdk.WorkspaceAgent, dc codersdk.WorkspaceAgentDevcontainer) error {
+		if _, err := client.WorkspaceAgentStopDevcontainer(inv.Context(), agent.ID, dc.ID.String()); err != nil {
+			return xerrors.Errorf("stop dev container: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *RootCmd) devcontainersStart() *serpent.Command {
+	return r.devcontainerAction("start", "Start a stopped dev container", "is starting", func(inv *serpent.Invocation, client *codersdk.Client, agent codersdk.WorkspaceAgent, dc codersdk.WorkspaceAgentDevcontainer) error {
+		if _, err := client.WorkspaceAgentStartDevcontainer(inv.Context(), agent.ID, dc.ID.String()); err != nil {
+			return xerrors.Errorf("start dev container: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *RootCmd) devcontainersDelete() *serpent.Command {
+	cmd := r.devcontainerAction("delete", "Delete the container of a dev container", "has been deleted", func(inv *serpent.Invocation, client *codersdk.Client, agent codersdk.WorkspaceAgent, dc codersdk.WorkspaceAgentDevcontainer) error {
+		if _, err := cliui.Prompt(inv, cliui.PromptOptions{
+			Text:      fmt.Sprintf("Delete the container of dev container %s?", cliui.Keyword(dc.Name)),
+			IsConfirm: true,
+			Default:   cliui.ConfirmNo,
+		}); err != nil {
+			return err
+		}
+		if _, err := client.WorkspaceAgentDeleteDevcontainer(inv.Context(), agent.ID, dc.ID.String()); err != nil {
+			return xerrors.Errorf("delete dev container: %w", err)
+		}
+		return nil
+	})
+	cmd.Options = serpent.OptionSet{cliui.SkipPromptOption()}
+	return cmd
+}
+
+func (r *RootCmd) devcontainersRebuild() *serpent.Command {
+	var noCache bool
+	cmd := r.devcontainerAction("rebuild", "Recreate a dev container, optionally without the build cache", "is being rebuilt", func(inv *serpent.Invocation, client *codersdk.Client, agent codersdk.WorkspaceAgent, dc codersdk.WorkspaceAgentDevcontainer) error {
+		var err error
+		if noCache {
+			_, err = client.WorkspaceAgentRebuildDevcontainer(inv.Context(), agent.ID, dc.ID.String())
+		} else {
+			_, err = client.WorkspaceAgentRecreateDevcontainer(inv.Context(), agent.ID, dc.ID.String())
+		}
+		if err != nil {
+			return xerrors.Errorf("rebuild dev container: %w", err)
+		}
+		return nil
+	})
+	cmd.Options = serpent.OptionSet{
+		{
+			Flag:        "no-cache",
+			Description: "Rebuild the dev container image without using the build cache.",
+			Value:       serpent.BoolOf(&noCache),
+		},
+	}
+	return cmd
+}
+
+func (r *RootCmd) devcontainersLogs() *serpent.Command {
+	var follow bool
+	cmd := r.devcontainerAction("logs", "Show the build logs of a dev container", "", func(inv *serpent.Invocation, client *codersdk.Client, agent codersdk.WorkspaceAgent, dc codersdk.WorkspaceAgentDevcontainer) error {
+		ctx := inv.Context()
+		// When following, the stream ends once the dev container is no
+		// longer being built.
+		logs, closer, err := client.WorkspaceAgentDevcontainerLogsAfter(ctx, agent.ID, dc.ID.String(), codersdk.WorkspaceAgentDevcontainerLogsOptions{
+			Follow: follow,
+		})
+		if err != nil {
+			return xerrors.Errorf("get dev container logs: %w", err)
+		}
+		defer closer.Close()
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case batch, ok := <-logs:
+				if !ok {
+					return nil
+				}
+				for _, log := range batch {
+					_, _ = fmt.Fprintln(inv.Stdout, log.Output)
+				}
+			}
+		}
+	})
+	cmd.Options = serpent.OptionSet{
+		{
+			Flag:          "follow",
+			FlagShorthand: "f",
+			Description:   "Follow the logs while the dev container is being built.",
+			Value:         serpent.BoolOf(&follow),
+		},
+	}
+	return cmd
+}
+
+// findDevcontainer returns the dev container of the agent matching the
+// given name or ID.
+func findDevcontainer(inv *serpent.Invocation, client *codersdk.Client, agent codersdk.WorkspaceAgent, nameOrID string) (codersdk.WorkspaceAgentDevcontainer, error) {
+	resp, err := client.WorkspaceAgentListContainers(inv.Context(), agent.ID, nil)
+	if err != nil {
+		return codersdk.WorkspaceAgentDevcontainer{}, xerrors.Errorf("list dev containers: %w", err)
+	}
+	for _, dc := range resp.Devcontainers {
+		if dc.ID.String() == nameOrID || dc.Name == nameOrID {
+			return dc, nil
+		}
+	}
+	return codersdk.WorkspaceAgentDevcontainer{}, xerrors.Errorf("dev container %q not found on agent %q", nameOrID, agent.Name)
+}
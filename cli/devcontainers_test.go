@@ -0,0 +1,174 @@
+package cli_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/coder/coder/v2/agent"
+	"github.com/coder/coder/v2/agent/agentcontainers"
+	"github.com/coder/coder/v2/agent/agentcontainers/acmock"
+	"github.com/coder/coder/v2/agent/agentcontainers/watcher"
+	"github.com/coder/coder/v2/agent/agenttest"
+	"github.com/coder/coder/v2/cli/clitest"
+	"github.com/coder/coder/v2/coderd/coderdtest"
+	"github.com/coder/coder/v2/coderd/database/dbtime"
+	"github.com/coder/coder/v2/codersdk"
+	"github.com/coder/coder/v2/testutil"
+)
+
+func TestDevcontainers(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS != "linux" {
+		t.Skip("DevContainers are only supported for agents on Linux")
+	}
+
+	const devcontainerName = "test-devcontainer"
+
+	// setup starts an agent with a single devcontainer backed by a
+	// container that is running or stopped, and waits for the agent to
+	// report the container.
+	setup := func(t *testing.T, running bool, setupMock func(mccli *acmock.MockContainerCLI, containerID string)) (*codersdk.Client, string) {
+		t.Helper()
+
+		workspaceFolder := t.TempDir()
+		configFile := filepath.Join(workspaceFolder, ".devcontainer", "devcontainer.json")
+		container := codersdk.WorkspaceAgentContainer{
+			ID:           uuid.NewString(),
+			CreatedAt:    dbtime.Now(),
+			FriendlyName: testutil.GetRandomName(t),
+			Image:        "busybox:latest",
+			Labels: map[string]string{
+				agentcontainers.DevcontainerLocalFolderLabel: workspaceFolder,
+				agentcontainers.DevcontainerConfigFileLabel:  configFile,
+			},
+			Running: running,
+			Status:  "running",
+		}
+		if !running {
+			container.Status = "exited"
+		}
+
+		ctrl := gomock.NewController(t)
+		mccli := acmock.NewMockContainerCLI(ctrl)
+		mdccli := acmock.NewMockDevcontainerCLI(ctrl)
+		mccli.EXPECT().List(gomock.Any()).Return(codersdk.WorkspaceAgentListContainersResponse{
+			Containers: []codersdk.WorkspaceAgentContainer{container},
+		}, nil).AnyTimes()
+		// DetectArchitecture always returns "<none>" for this test to disable agent injection.
+		mccli.EXPECT().DetectArchitecture(gomock.Any(), container.ID).Return("<none>", nil).AnyTimes()
+		mdccli.EXPECT().ReadConfig(gomock.Any(), workspaceFolder, configFile, gomock.Any()).Return(agentcontainers.DevcontainerConfig{}, nil).AnyTimes()
+		if setupMock != nil {
+			setupMock(mccli, container.ID)
+		}
+
+		client, workspace, agentToken := setupWorkspaceForAgent(t)
+		_ = agenttest.New(t, client.URL, agentToken, func(o *agent.Options) {
+			o.Devcontainers = true
+			o.DevcontainerAPIOptions = append(o.DevcontainerAPIOptions,
+				agentcontainers.WithContainerCLI(mccli),
+				agentcontainers.WithDevcontainerCLI(mdccli),
+				agentcontainers.WithWatcher(watcher.NewNoop()),
+				agentcontainers.WithDevcontainers([]codersdk.WorkspaceAgentDevcontainer{{
+					ID:              uuid.New(),
+					Name:            devcontainerName,
+					WorkspaceFolder: workspaceFolder,
+					ConfigPath:      configFile,
+				}}, nil),
+			)
+		})
+		resources := coderdtest.NewWorkspaceAgentWaiter(t, client, workspace.ID).Wait()
+		require.Len(t, resources, 1, "expected one resource")
+		require.Len(t, resources[0].Agents, 1, "expected one agent")
+		agentID := resources[0].Agents[0].ID
+
+		require.Eventually(t, func() bool {
+			resp, err := client.WorkspaceAgentListContainers(testutil.Context(t, testutil.WaitShort), agentID, nil)
+			if err != nil || len(resp.Devcontainers) != 1 {
+				return false
+			}
+			return resp.Devcontainers[0].Container != nil
+		}, testutil.WaitLong, testutil.IntervalFast)
+
+		return client, workspace.Name
+	}
+
+	run := func(t *testing.T, client *codersdk.Client, args ...string) (string, error) {
+		t.Helper()
+
+		inv, root := clitest.New(t, append([]string{"devcontainers"}, args...)...)
+		clitest.SetupConfig(t, client, root)
+		var buf bytes.Buffer
+		inv.Stdout = &buf
+		err := inv.WithContext(testutil.Context(t, testutil.WaitLong)).Run()
+		return buf.String(), err
+	}
+
+	t.Run("Stop", func(t *testing.T) {
+		t.Parallel()
+
+		client, workspaceName := setup(t, true, func(mccli *acmock.MockContainerCLI, containerID string) {
+			mccli.EXPECT().Stop(gomock.Any(), containerID).Return(nil).Times(1)
+		})
+
+		out, err := run(t, client, "stop", workspaceName, devcontainerName)
+		require.NoError(t, err)
+		assert.Contains(t, out, "has been stopped")
+	})
+
+	t.Run("Start", func(t *testing.T) {
+		t.Parallel()
+
+		client, workspaceName := setup(t, false, func(mccli *acmock.MockContainerCLI, containerID string) {
+			mccli.EXPECT().Start(gomock.Any(), containerID).Return(nil).Times(1)
+		})
+
+		out, err := run(t, client, "start", workspaceName, devcontainerName)
+		require.NoError(t, err)
+		assert.Contains(t, out, "is starting")
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		t.Parallel()
+
+		client, workspaceName := setup(t, true, func(mccli *acmock.MockContainerCLI, containerID string) {
+			mccli.EXPECT().Remove(gomock.Any(), containerID).Return(nil).Times(1)
+		})
+
+		out, err := run(t, client, "delete", workspaceName, devcontainerName, "--yes")
+		require.NoError(t, err)
+		assert.Contains(t, out, "has been deleted")
+	})
+
+	t.Run("Logs", func(t *testing.T) {
+		t.Parallel()
+
+		client, workspaceName := setup(t, true, nil)
+
+		// The devcontainer has not been built by the agent, so there
+		// are no logs and following them ends right away.
+		out, err := run(t, client, "logs", workspaceName, devcontainerName)
+		require.NoError(t, err)
+		assert.Empty(t, out)
+
+		out, err = run(t, client, "logs", workspaceName, devcontainerName, "--follow")
+		require.NoError(t, err)
+		assert.Empty(t, out)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		t.Parallel()
+
+		client, workspaceName := setup(t, true, nil)
+
+		_, err := run(t, client, "stop", workspaceName, "does-not-exist")
+		require.ErrorContains(t, err, `dev container "does-not-exist" not found`)
+	})
+}
@@ -0,0 +1,348 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/v2/cli/cliui"
+	"github.com/coder/coder/v2/codersdk"
+	"github.com/coder/serpent"
+)
+
+func (r *RootCmd) httpCapture() *serpent.Command {
+	cmd := &serpent.Command{
+		Use:   "http-capture",
+		Short: "Record the HTTP requests to a workspace app for debugging",
+		Long: "While a capture is active, the workspace agent proxies requests to the app's port " +
+			"and records them. " + fmt.Sprintf("Values of the %s headers are always redacted. ", strings.Join(codersdk.WorkspaceAgentHTTPCaptureRedactedHeaders, ", ")) +
+			"TLS connections are passed to the app without being recorded.\n\n" +
+			FormatExamples(
+				Example{
+					Description: "Record requests to port 8080, including bodies, for ten minutes",
+					Command:     "coder http-capture start my-workspace 8080 --include-bodies --duration 10m",
+				},
+				Example{
+					Description: "Save the recorded requests for a browser's developer tools",
+					Command:     "coder http-capture har my-workspace 8080 --output requests.har",
+				},
+			),
+		Handler: func(inv *serpent.Invocation) error {
+			return inv.Command.HelpHandler(inv)
+		},
+		Children: []*serpent.Command{
+			r.httpCaptureStart(),
+			r.httpCaptureStop(),
+			r.httpCaptureList(),
+			r.httpCaptureHAR(),
+		},
+	}
+	return cmd
+}
+
+func (r *RootCmd) httpCaptureStart() *serpent.Command {
+	var (
+		duration      time.Duration
+		includeBodies bool
+		maxBodySize   int64
+		maxEntries    int64
+		redact        []string
+		client        = new(codersdk.Client)
+	)
+	cmd := &serpent.Command{
+		Use:   "start <workspace>[.<agent>] <port|app>",
+		Short: "Start recording the requests to a port or app of a workspace",
+		Middleware: serpent.Chain(
+			serpent.RequireNArgs(2),
+			r.InitClient(client),
+		),
+		Options: serpent.OptionSet{
+			{
+				Flag:        "duration",
+				Description: "How long to record requests for, at most one hour.",
+				Default:     "5m",
+				Value:       serpent.DurationOf(&duration),
+			},
+			{
+				Flag:        "include-bodies",
+				Description: "Record request and response bodies.",
+				Value:       serpent.BoolOf(&includeBodies),
+			},
+			{
+				Flag:        "max-body-size",
+				Description: "Maximum number of bytes recorded per body, at most 1 MiB.",
+				Default:     "65536",
+				Value:       serpent.Int64Of(&maxBodySize),
+			},
+			{
+				Flag:        "max-entries",
+				Description: "Number of requests to keep. The oldest requests are dropped once it is reached.",
+				Default:     "500",
+				Value:       serpent.Int64Of(&maxEntries),
+			},
+			{
+				Flag:        "redact-header",
+				Description: "Additional header whose values are redacted, e.g. X-Api-Key. Can be repeated.",
+				Value:       serpent.StringArrayOf(&redact),
+			},
+		},
+		Handler: func(inv *serpent.Invocation) error {
+			ctx := inv.Context()
+			_, agent, err := getWorkspaceAndAgent(ctx, inv, client, false, inv.Args[0])
+			if err != nil {
+				return err
+			}
+			port, err := httpCapturePort(agent, inv.Args[1])
+			if err != nil {
+				return err
+			}
+
+			capture, err := client.StartWorkspaceAgentHTTPCapture(ctx, agent.ID, codersdk.WorkspaceAgentHTTPCaptureRequest{
+				Port:           port,
+				DurationMillis: duration.Milliseconds(),
+				IncludeBodies:  includeBodies,
+				MaxBodyBytes:   maxBodySize,
+				MaxEntries:     int(maxEntries),
+				RedactHeaders:  redact,
+			})
+			if err != nil {
+				return xerrors.Errorf("start capture: %w", err)
+			}
+			_, _ = fmt.Fprintf(inv.Stdout, "Recording requests to port %s until %s\n",
+				cliui.Keyword(strconv.Itoa(int(capture.Port))), capture.EndsAt.Local().Format(time.Kitchen))
+			_, _ = fmt.Fprintf(inv.Stdout, "Run %s to fetch them\n",
+				cliui.Code(fmt.Sprintf("coder http-capture har %s %d", inv.Args[0], capture.Port)))
+			return nil
+		},
+	}
+	return cmd
+}
+
+func (r *RootCmd) httpCaptureStop() *serpent.Command {
+	client := new(codersdk.Client)
+	cmd := &serpent.Command{
+		Use:   "stop <workspace>[.<agent>] <port|app>",
+		Short: "Stop recording the requests to a port or app of a workspace",
+		Long:  "The recorded requests are kept until the capture is deleted or a new capture of the port starts.",
+		Middleware: serpent.Chain(
+			serpent.RequireNArgs(2),
+			r.InitClient(client),
+		),
+		Handler: func(inv *serpent.Invocation) error {
+			ctx := inv.Context()
+			_, agent, err := getWorkspaceAndAgent(ctx, inv, client, false, inv.Args[0])
+			if err != nil {
+				return err
+			}
+			port, err := httpCapturePort(agent, inv.Args[1])
+			if err != nil {
+				return err
+			}
+
+			capture, err := client.StopWorkspaceAgentHTTPCapture(ctx, agent.ID, port)
+			if err != nil {
+				return xerrors.Errorf("stop capture: %w", err)
+			}
+			_, _ = fmt.Fprintf(inv.Stdout, "Stopped recording requests to port %s, %d requests were recorded\n",
+				cliui.Keyword(strconv.Itoa(int(capture.Port))), capture.Entries)
+			return nil
+		},
+	}
+	return cmd
+}
+
+func (r *RootCmd) httpCaptureList() *serpent.Command {
+	type captureRow struct {
+		Port     uint16 `json:"port" table:"port,default_sort"`
+		Status   string `json:"status" table:"status"`
+		Started  string `json:"started" table:"started"`
+		Ends     string `json:"ends" table:"ends"`
+		Bodies   bool   `json:"bodies" table:"bodies"`
+		Requests int    `json:"requests" table:"requests"`
+		Dropped  int    `json:"dropped" table:"dropped"`
+		Opaque   int    `json:"opaque" table:"opaque"`
+	}
+
+	var (
+		client    = new(codersdk.Client)
+		formatter = cliui.NewOutputFormatter(
+			cliui.ChangeFormatterData(
+				cliui.TableFormat([]captureRow{}, []string{"port", "status", "started", "ends", "bodies", "requests"}),
+				func(data any) (any, error) {
+					captures, ok := data.([]codersdk.WorkspaceAgentHTTPCapture)
+					if !ok {
+						return nil, xerrors.Errorf("expected []codersdk.WorkspaceAgentHTTPCapture, got %T", data)
+					}
+					rows := make([]captureRow, 0, len(captures))
+					for _, c := range captures {
+						status, ends := "active", c.EndsAt
+						if !c.Active {
+							status = "stopped"
+							if c.StoppedAt != nil {
+								ends = *c.StoppedAt
+							}
+						}
+						rows = append(rows, captureRow{
+							Port:     c.Port,
+							Status:   status,
+							Started:  humanize.Time(c.StartedAt),
+							Ends:     humanize.Time(ends),
+							Bodies:   c.IncludeBodies,
+							Requests: c.Entries,
+							Dropped:  c.Dropped,
+							Opaque:   c.OpaqueConnections,
+						})
+					}
+					return rows, nil
+				},
+			),
+			cliui.JSONFormat(),
+		)
+	)
+	cmd := &serpent.Command{
+		Use:     "list <workspace>[.<agent>]",
+		Short:   "List the HTTP captures of a workspace",
+		Aliases: []string{"ls"},
+		Middleware: serpent.Chain(
+			serpent.RequireNArgs(1),
+			r.InitClient(client),
+		),
+		Handler: func(inv *serpent.Invocation) error {
+			ctx := inv.Context()
+			_, agent, err := getWorkspaceAndAgent(ctx, inv, client, false, inv.Args[0])
+			if err != nil {
+				return err
+			}
+
+			captures, err := client.WorkspaceAgentHTTPCaptures(ctx, agent.ID)
+			if err != nil {
+				return xerrors.Errorf("list captures: %w", err)
+			}
+			if len(captures) == 0 && formatter.FormatID() == "table" {
+				cliui.Infof(inv.Stderr, "No HTTP captures for this workspace.")
+				return nil
+			}
+			out, err := formatter.Format(ctx, captures)
+			if err != nil {
+				return xerrors.Errorf("format captures: %w", err)
+			}
+			_, _ = fmt.Fprintln(inv.Stdout, out)
+			return nil
+		},
+	}
+	formatter.AttachOptions(&cmd.Options)
+	return cmd
+}
+
+func (r *RootCmd) httpCaptureHAR() *serpent.Command {
+	var (
+		output string
+		del    bool
+		client = new(codersdk.Client)
+	)
+	cmd := &serpent.Command{
+		Use:   "har <workspace>[.<agent>] <port|app>",
+		Short: "Write the requests recorded for a port or app as an HTTP Archive (HAR)",
+		Middleware: serpent.Chain(
+			serpent.RequireNArgs(2),
+			r.InitClient(client),
+		),
+		Options: serpent.OptionSet{
+			{
+				Flag:          "output",
+				FlagShorthand: "o",
+				Description:   "File to write the HAR to. Writes to stdout by default.",
+				Value:         serpent.StringOf(&output),
+			},
+			{
+				Flag:        "delete",
+				Description: "Stop the capture and discard the recorded requests once they are written.",
+				Value:       serpent.BoolOf(&del),
+			},
+		},
+		Handler: func(inv *serpent.Invocation) error {
+			ctx := inv.Context()
+			_, agent, err := getWorkspaceAndAgent(ctx, inv, client, false, inv.Args[0])
+			if err != nil {
+				return err
+			}
+			port, err := httpCapturePort(agent, inv.Args[1])
+			if err != nil {
+				return err
+			}
+
+			har, err := client.WorkspaceAgentHTTPCaptureHAR(ctx, agent.ID, port)
+			if err != nil {
+				return xerrors.Errorf("fetch capture: %w", err)
+			}
+
+			var w io.Writer = inv.Stdout
+			if output != "" && output != "-" {
+				f, err := os.Create(output)
+				if err != nil {
+					return xerrors.Errorf("create %s: %w", output, err)
+				}
+				defer f.Close()
+				w = f
+			}
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(har); err != nil {
+				return xerrors.Errorf("write HAR: %w", err)
+			}
+			if w != inv.Stdout {
+				_, _ = fmt.Fprintf(inv.Stderr, "Wrote %d requests to %s\n", len(har.Log.Entries), output)
+			}
+
+			if del {
+				if err := client.DeleteWorkspaceAgentHTTPCapture(ctx, agent.ID, port); err != nil {
+					return xerrors.Errorf("delete capture: %w", err)
+				}
+			}
+			return nil
+		},
+	}
+	return cmd
+}
+
+// httpCapturePort returns the port of an app of the agent, or the port
+// itself when arg is a number.
+func httpCapturePort(agent codersdk.WorkspaceAgent, arg string) (uint16, error) {
+	if port, err := strconv.ParseUint(arg, 10, 16); err == nil {
+		return uint16(port), nil
+	}
+	for _, app := range agent.Apps {
+		if app.Slug != arg {
+			continue
+		}
+		if app.URL == "" {
+			return 0, xerrors.Errorf("app %q is not served from a port of the workspace", arg)
+		}
+		u, err := url.Parse(app.URL)
+		if err != nil {
+			return 0, xerrors.Errorf("parse URL of app %q: %w", arg, err)
+		}
+		raw := u.Port()
+		switch {
+		case raw != "":
+		case u.Scheme == "https":
+			raw = "443"
+		default:
+			raw = "80"
+		}
+		port, err := strconv.ParseUint(raw, 10, 16)
+		if err != nil {
+			return 0, xerrors.Errorf("parse port of app %q: %w", arg, err)
+		}
+		return uint16(port), nil
+	}
+	return 0, xerrors.Errorf("%q is neither a port nor the slug of an app of agent %q", arg, agent.Name)
+}
@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/codersdk"
+)
+
+func TestHTTPCapturePort(t *testing.T) {
+	t.Parallel()
+
+	agent := codersdk.WorkspaceAgent{
+		Name: "main",
+		Apps: []codersdk.WorkspaceApp{
+			{Slug: "code-server", URL: "http://localhost:13337/?folder=/home/coder"},
+			{Slug: "web", URL: "http://localhost"},
+			{Slug: "secure", URL: "https://127.0.0.1"},
+			{Slug: "terminal", Command: "htop"},
+		},
+	}
+
+	for _, tc := range []struct {
+		arg  string
+		port uint16
+		err  string
+	}{
+		{arg: "8080", port: 8080},
+		{arg: "code-server", port: 13337},
+		{arg: "web", port: 80},
+		{arg: "secure", port: 443},
+		{arg: "terminal", err: "not served from a port"},
+		{arg: "missing", err: "neither a port nor the slug"},
+		{arg: "70000", err: "neither a port nor the slug"},
+	} {
+		port, err := httpCapturePort(agent, tc.arg)
+		if tc.err != "" {
+			require.ErrorContains(t, err, tc.err, tc.arg)
+			continue
+		}
+		require.NoError(t, err, tc.arg)
+		require.Equal(t, tc.port, port, tc.arg)
+	}
+}
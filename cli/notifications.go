@@ -2,11 +2,13 @@ package cli
 
 import (
 	"fmt"
+	"slices"
 
 	"golang.org/x/xerrors"
 
 	"github.com/coder/serpent"
 
+	"github.com/coder/coder/v2/cli/cliui"
 	"github.com/coder/coder/v2/codersdk"
 )
 
@@ -27,6 +29,10 @@ func (r *RootCmd) notifications() *serpent.Command {
 				Description: "Send a test notification. Administrators can use this to verify the notification target settings.",
 				Command:     "coder notifications test",
 			},
+			Example{
+				Description: "Receive notifications as Slack direct messages. Your Slack member ID is shown in your Slack profile.",
+				Command:     "coder notifications chat-identities link slack U012AB3CD",
+			},
 		),
 		Aliases: []string{"notification"},
 		Handler: func(inv *serpent.Invocation) error {
@@ -36,6 +42,7 @@ func (r *RootCmd) notifications() *serpent.Command {
 			r.pauseNotifications(),
 			r.resumeNotifications(),
 			r.testNotifications(),
+			r.chatIdentities(),
 		},
 	}
 	return cmd
@@ -109,3 +116,161 @@ func (r *RootCmd) testNotifications() *serpent.Command {
 	}
 	return cmd
 }
+
+func (r *RootCmd) chatIdentities() *serpent.Command {
+	cmd := &serpent.Command{
+		Use:   "chat-identities",
+		Short: "Manage the chat accounts that receive your notifications as direct messages",
+		Long: "Notifications sent by Slack, Matrix or Microsoft Teams are delivered to the linked account, " +
+			"or to the channel configured by the administrator if no account is linked. " +
+			"Slack and Matrix accounts must be verified with the code sent to them before they receive notifications.",
+		Aliases: []string{"chat-identity"},
+		Handler: func(inv *serpent.Invocation) error {
+			return inv.Command.HelpHandler(inv)
+		},
+		Children: []*serpent.Command{
+			r.listChatIdentities(),
+			r.linkChatIdentity(),
+			r.verifyChatIdentity(),
+			r.unlinkChatIdentity(),
+		},
+	}
+	return cmd
+}
+
+func (r *RootCmd) listChatIdentities() *serpent.Command {
+	var (
+		client    = new(codersdk.Client)
+		formatter = cliui.NewOutputFormatter(
+			cliui.TableFormat([]codersdk.UserChatIdentity{}, []string{"method", "identity", "verified", "updated at"}),
+			cliui.JSONFormat(),
+		)
+	)
+	cmd := &serpent.Command{
+		Use:     "list",
+		Short:   "List your linked chat accounts",
+		Aliases: []string{"ls"},
+		Middleware: serpent.Chain(
+			serpent.RequireNArgs(0),
+			r.InitClient(client),
+		),
+		Handler: func(inv *serpent.Invocation) error {
+			identities, err := client.UserChatIdentities(inv.Context(), codersdk.Me)
+			if err != nil {
+				return xerrors.Errorf("unable to list chat identities: %w", err)
+			}
+			if len(identities) == 0 && formatter.FormatID() == "table" {
+				cliui.Infof(inv.Stderr, "No chat accounts are linked.")
+				return nil
+			}
+
+			out, err := formatter.Format(inv.Context(), identities)
+			if err != nil {
+				return xerrors.Errorf("unable to format chat identities: %w", err)
+			}
+			_, _ = fmt.Fprintln(inv.Stdout, out)
+			return nil
+		},
+	}
+	formatter.AttachOptions(&cmd.Options)
+	return cmd
+}
+
+func (r *RootCmd) linkChatIdentity() *serpent.Command {
+	client := new(codersdk.Client)
+	cmd := &serpent.Command{
+		Use:   "link <slack|matrix|teams> <identity>",
+		Short: "Link a chat account to receive notifications as direct messages",
+		Long: "The identity is a Slack member ID, a Matrix user ID such as @alice:example.com, " +
+			"or a Microsoft Teams user principal name such as alice@example.com. " +
+			"Slack and Matrix accounts are sent a code in a direct message, verify it with " +
+			"\"coder notifications chat-identities verify\". Teams identities must match the email " +
+			"of your OIDC account, and notifications mention the linked user in the configured channel.",
+		Middleware: serpent.Chain(
+			serpent.RequireNArgs(2),
+			r.InitClient(client),
+		),
+		Handler: func(inv *serpent.Invocation) error {
+			method, err := parseChatNotificationMethod(inv.Args[0])
+			if err != nil {
+				return err
+			}
+			identity, err := client.UpdateUserChatIdentity(inv.Context(), codersdk.Me, method, codersdk.UpdateUserChatIdentityRequest{
+				Identity: inv.Args[1],
+			})
+			if err != nil {
+				return xerrors.Errorf("unable to link chat identity: %w", err)
+			}
+
+			if identity.Verified {
+				_, _ = fmt.Fprintf(inv.Stderr, "Linked %s account %s.\n", identity.Method, cliui.Keyword(identity.Identity))
+				return nil
+			}
+			_, _ = fmt.Fprintf(inv.Stderr, "Sent a verification code to %s account %s. Verify it with:\n\n\t%s\n",
+				identity.Method, cliui.Keyword(identity.Identity), cliui.Code(fmt.Sprintf("coder notifications chat-identities verify %s <code>", identity.Method)))
+			return nil
+		},
+	}
+	return cmd
+}
+
+func (r *RootCmd) verifyChatIdentity() *serpent.Command {
+	client := new(codersdk.Client)
+	cmd := &serpent.Command{
+		Use:   "verify <slack|matrix> <code>",
+		Short: "Verify a linked chat account with the code sent to it",
+		Middleware: serpent.Chain(
+			serpent.RequireNArgs(2),
+			r.InitClient(client),
+		),
+		Handler: func(inv *serpent.Invocation) error {
+			method, err := parseChatNotificationMethod(inv.Args[0])
+			if err != nil {
+				return err
+			}
+			identity, err := client.VerifyUserChatIdentity(inv.Context(), codersdk.Me, method, codersdk.VerifyUserChatIdentityRequest{
+				Code: inv.Args[1],
+			})
+			if err != nil {
+				return xerrors.Errorf("unable to verify chat identity: %w", err)
+			}
+
+			_, _ = fmt.Fprintf(inv.Stderr, "Verified %s account %s.\n", identity.Method, cliui.Keyword(identity.Identity))
+			return nil
+		},
+	}
+	return cmd
+}
+
+func (r *RootCmd) unlinkChatIdentity() *serpent.Command {
+	client := new(codersdk.Client)
+	cmd := &serpent.Command{
+		Use:   "unlink <slack|matrix|teams>",
+		Short: "Unlink a chat account",
+		Middleware: serpent.Chain(
+			serpent.RequireNArgs(1),
+			r.InitClient(client),
+		),
+		Handler: func(inv *serpent.Invocation) error {
+			method, err := parseChatNotificationMethod(inv.Args[0])
+			if err != nil {
+				return err
+			}
+			if err := client.DeleteUserChatIdentity(inv.Context(), codersdk.Me, method); err != nil {
+				return xerrors.Errorf("unable to unlink chat identity: %w", err)
+			}
+
+			_, _ = fmt.Fprintf(inv.Stderr, "Unlinked your %s account.\n", method)
+			return nil
+		},
+	}
+	return cmd
+}
+
+func parseChatNotificationMethod(arg string) (codersdk.ChatNotificationMethod, error) {
+	method := codersdk.ChatNotificationMethod(arg)
+	if !slices.Contains(codersdk.ChatNotificationMethods, method) {
+		return "", xerrors.Errorf("%q is not a chat notification method, expected one of %v", arg, codersdk.ChatNotificationMethods)
+	}
+	return method, nil
+}
@@ -5,14 +5,20 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/coder/serpent"
+
 	"github.com/coder/coder/v2/cli/clitest"
 	"github.com/coder/coder/v2/coderd/coderdtest"
 	"github.com/coder/coder/v2/coderd/notifications"
+	"github.com/coder/coder/v2/coderd/notifications/dispatch"
 	"github.com/coder/coder/v2/coderd/notifications/notificationstest"
 	"github.com/coder/coder/v2/codersdk"
 	"github.com/coder/coder/v2/testutil"
@@ -166,3 +172,58 @@ func TestNotificationsTest(t *testing.T) {
 		require.Len(t, sent, 0)
 	})
 }
+
+func TestNotificationsChatIdentities(t *testing.T) {
+	t.Parallel()
+
+	codes := make(chan string, 1)
+	slackServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg dispatch.SlackMessage
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		codes <- regexp.MustCompile(`code is ([a-z0-9]+)\.`).FindStringSubmatch(msg.Text)[1]
+		_ = json.NewEncoder(w).Encode(map[string]any{"ok": true})
+	}))
+	t.Cleanup(slackServer.Close)
+	slackURL, err := url.Parse(slackServer.URL)
+	require.NoError(t, err)
+
+	opts := createOpts(t)
+	opts.DeploymentValues.Notifications.Slack.BotToken = "xoxb-test"
+	opts.DeploymentValues.Notifications.Slack.APIURL = *serpent.URLOf(slackURL)
+	ownerClient := coderdtest.New(t, opts)
+	owner := coderdtest.CreateFirstUser(t, ownerClient)
+	member, _ := coderdtest.CreateAnotherUser(t, ownerClient, owner.OrganizationID)
+
+	ctx := testutil.Context(t, testutil.WaitLong)
+
+	inv, root := clitest.New(t, "notifications", "chat-identities", "link", "slack", "U012AB3CD")
+	clitest.SetupConfig(t, member, root)
+	require.NoError(t, inv.WithContext(ctx).Run())
+	code := testutil.TryReceive(ctx, t, codes)
+
+	inv, root = clitest.New(t, "notifications", "chat-identities", "link", "irc", "alice")
+	clitest.SetupConfig(t, member, root)
+	require.ErrorContains(t, inv.WithContext(ctx).Run(), "not a chat notification method")
+
+	inv, root = clitest.New(t, "notifications", "chat-identities", "verify", "slack", code)
+	clitest.SetupConfig(t, member, root)
+	require.NoError(t, inv.WithContext(ctx).Run())
+
+	buf := new(bytes.Buffer)
+	inv, root = clitest.New(t, "notifications", "chat-identities", "list", "--output", "json")
+	inv.Stdout = buf
+	clitest.SetupConfig(t, member, root)
+	require.NoError(t, inv.WithContext(ctx).Run())
+	var identities []codersdk.UserChatIdentity
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &identities))
+	require.Len(t, identities, 1)
+	require.Equal(t, "U012AB3CD", identities[0].Identity)
+	require.True(t, identities[0].Verified)
+
+	inv, root = clitest.New(t, "notifications", "chat-identities", "unlink", "slack")
+	clitest.SetupConfig(t, member, root)
+	require.NoError(t, inv.WithContext(ctx).Run())
+	identities, err = member.UserChatIdentities(ctx, codersdk.Me)
+	require.NoError(t, err)
+	require.Empty(t, identities)
+}
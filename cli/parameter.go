@@ -148,6 +148,10 @@ func parseParameterMapFile(parameterFile string) (map[string]string, error) {
 // buildFlags contains options relating to troubleshooting provisioner jobs.
 type buildFlags struct {
 	provisionerLogDebug bool
+	// previewUpdate previews the resource changes of an update to another
+	// template version and asks to confirm them. Only commands that can skip
+	// the prompt with --yes set it, since others may not have a terminal.
+	previewUpdate bool
 }
 
 func (bf *buildFlags) cliOptions() []serpent.Option {
@@ -0,0 +1,310 @@
+package cli
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/v2/cli/cliui"
+	"github.com/coder/coder/v2/codersdk"
+	"github.com/coder/serpent"
+)
+
+func (r *RootCmd) processes() *serpent.Command {
+	cmd := &serpent.Command{
+		Use:     "processes",
+		Short:   "Inspect and signal the processes running in a workspace",
+		Aliases: []string{"procs"},
+		Handler: func(inv *serpent.Invocation) error {
+			return inv.Command.HelpHandler(inv)
+		},
+		Children: []*serpent.Command{
+			r.processesList(),
+			r.processesSignal(),
+			r.processesTree(),
+		},
+	}
+	return cmd
+}
+
+// processSortOption returns the option used to order processes.
+func processSortOption(sort *string) serpent.Option {
+	return serpent.Option{
+		Flag:        "sort",
+		Description: "Order processes by cpu, memory or pid. The cpu and memory orders list the largest consumers first.",
+		Default:     string(codersdk.WorkspaceAgentProcessSortCPU),
+		Value: serpent.EnumOf(sort,
+			string(codersdk.WorkspaceAgentProcessSortCPU),
+			string(codersdk.WorkspaceAgentProcessSortMemory),
+			string(codersdk.WorkspaceAgentProcessSortPID),
+		),
+	}
+}
+
+func (r *RootCmd) processesList() *serpent.Command {
+	type processRow struct {
+		PID     int32  `json:"pid" table:"pid,nosort"`
+		PPID    int32  `json:"ppid" table:"ppid"`
+		User    string `json:"user" table:"user"`
+		CPU     string `json:"cpu" table:"cpu"`
+		Memory  string `json:"memory" table:"memory"`
+		Status  string `json:"status" table:"status"`
+		Ports   string `json:"ports" table:"ports"`
+		Name    string `json:"name" table:"name"`
+		Command string `json:"command" table:"command"`
+		Uptime  string `json:"uptime" table:"uptime"`
+	}
+
+	var (
+		sort      string
+		limit     int64
+		client    = new(codersdk.Client)
+		formatter = cliui.NewOutputFormatter(
+			cliui.ChangeFormatterData(
+				cliui.TableFormat([]processRow{}, []string{"pid", "user", "cpu", "memory", "ports", "command"}),
+				func(data any) (any, error) {
+					procs, ok := data.([]codersdk.WorkspaceAgentProcess)
+					if !ok {
+						return nil, xerrors.Errorf("expected []codersdk.WorkspaceAgentProcess, got %T", data)
+					}
+					rows := make([]processRow, 0, len(procs))
+					now := time.Now()
+					for _, p := range procs {
+						uptime := ""
+						if !p.CreatedAt.IsZero() {
+							uptime = durationDisplay(now.Sub(p.CreatedAt))
+						}
+						rows = append(rows, processRow{
+							PID:     p.PID,
+							PPID:    p.PPID,
+							User:    p.Username,
+							CPU:     fmt.Sprintf("%.1f%%", p.CPUPercent),
+							Memory:  humanize.IBytes(p.MemoryRSS),
+							Status:  p.Status,
+							Ports:   formatProcessPorts(p.Ports),
+							Name:    p.Name,
+							Command: processCommand(p),
+							Uptime:  uptime,
+						})
+					}
+					return rows, nil
+				},
+			),
+			cliui.JSONFormat(),
+		)
+	)
+	cmd := &serpent.Command{
+		Use:     "list <workspace>[.<agent>]",
+		Short:   "List the processes running in a workspace",
+		Aliases: []string{"ls", "ps"},
+		Long: FormatExamples(
+			Example{
+				Description: "Show the five processes using the most memory",
+				Command:     "coder processes list my-workspace --sort memory --limit 5",
+			},
+		),
+		Middleware: serpent.Chain(
+			serpent.RequireNArgs(1),
+			r.InitClient(client),
+		),
+		Options: serpent.OptionSet{
+			processSortOption(&sort),
+			{
+				Flag:          "limit",
+				FlagShorthand: "n",
+				Description:   "Maximum number of processes to show. Zero shows all processes.",
+				Default:       "0",
+				Value:         serpent.Int64Of(&limit),
+			},
+		},
+		Handler: func(inv *serpent.Invocation) error {
+			ctx := inv.Context()
+			_, agent, err := getWorkspaceAndAgent(ctx, inv, client, false, inv.Args[0])
+			if err != nil {
+				return err
+			}
+
+			resp, err := client.WorkspaceAgentProcesses(ctx, agent.ID, codersdk.WorkspaceAgentProcessesOptions{
+				Sort:  codersdk.WorkspaceAgentProcessSort(sort),
+				Limit: int(limit),
+			})
+			if err != nil {
+				return xerrors.Errorf("list processes: %w", err)
+			}
+			for _, warning := range resp.Warnings {
+				cliui.Warn(inv.Stderr, warning)
+			}
+
+			out, err := formatter.Format(ctx, resp.Processes)
+			if err != nil {
+				return xerrors.Errorf("format processes: %w", err)
+			}
+			_, _ = fmt.Fprintln(inv.Stdout, out)
+			return nil
+		},
+	}
+	formatter.AttachOptions(&cmd.Options)
+	return cmd
+}
+
+func (r *RootCmd) processesTree() *serpent.Command {
+	var (
+		sort   string
+		client = new(codersdk.Client)
+	)
+	cmd := &serpent.Command{
+		Use:   "tree <workspace>[.<agent>]",
+		Short: "Show the processes running in a workspace as a tree",
+		Middleware: serpent.Chain(
+			serpent.RequireNArgs(1),
+			r.InitClient(client),
+		),
+		Options: serpent.OptionSet{
+			processSortOption(&sort),
+		},
+		Handler: func(inv *serpent.Invocation) error {
+			ctx := inv.Context()
+			_, agent, err := getWorkspaceAndAgent(ctx, inv, client, false, inv.Args[0])
+			if err != nil {
+				return err
+			}
+
+			resp, err := client.WorkspaceAgentProcesses(ctx, agent.ID, codersdk.WorkspaceAgentProcessesOptions{
+				Sort: codersdk.WorkspaceAgentProcessSort(sort),
+			})
+			if err != nil {
+				return xerrors.Errorf("list processes: %w", err)
+			}
+			for _, warning := range resp.Warnings {
+				cliui.Warn(inv.Stderr, warning)
+			}
+
+			_, _ = fmt.Fprint(inv.Stdout, renderProcessTree(resp.Processes))
+			return nil
+		},
+	}
+	return cmd
+}
+
+func (r *RootCmd) processesSignal() *serpent.Command {
+	var (
+		signal string
+		client = new(codersdk.Client)
+	)
+	cmd := &serpent.Command{
+		Use:     "signal <workspace>[.<agent>] <pid>",
+		Short:   "Send a signal to a process running in a workspace",
+		Aliases: []string{"kill"},
+		Long: FormatExamples(
+			Example{
+				Description: "Forcefully stop a process",
+				Command:     "coder processes signal my-workspace 1234 --signal KILL",
+			},
+		),
+		Middleware: serpent.Chain(
+			serpent.RequireNArgs(2),
+			r.InitClient(client),
+		),
+		Options: serpent.OptionSet{
+			{
+				Flag:          "signal",
+				FlagShorthand: "s",
+				Description:   "The signal to send, e.g. TERM, KILL, INT or HUP. Only TERM, KILL and INT are supported on Windows.",
+				Default:       "TERM",
+				Value:         serpent.StringOf(&signal),
+			},
+			cliui.SkipPromptOption(),
+		},
+		Handler: func(inv *serpent.Invocation) error {
+			ctx := inv.Context()
+			pid, err := strconv.ParseInt(inv.Args[1], 10, 32)
+			if err != nil || pid <= 0 {
+				return xerrors.Errorf("invalid process ID %q", inv.Args[1])
+			}
+			_, agent, err := getWorkspaceAndAgent(ctx, inv, client, false, inv.Args[0])
+			if err != nil {
+				return err
+			}
+
+			if _, err := cliui.Prompt(inv, cliui.PromptOptions{
+				Text:      fmt.Sprintf("Send %s to process %s?", cliui.Keyword(strings.ToUpper(signal)), cliui.Keyword(inv.Args[1])),
+				IsConfirm: true,
+				Default:   cliui.ConfirmNo,
+			}); err != nil {
+				return err
+			}
+
+			err = client.WorkspaceAgentSignalProcess(ctx, agent.ID, int32(pid), codersdk.WorkspaceAgentSignalProcessRequest{
+				Signal: signal,
+			})
+			if err != nil {
+				return xerrors.Errorf("signal process: %w", err)
+			}
+			_, _ = fmt.Fprintf(inv.Stdout, "Sent %s to process %d\n", strings.ToUpper(signal), pid)
+			return nil
+		},
+	}
+	return cmd
+}
+
+// renderProcessTree renders processes as a tree rooted at the processes
+// whose parent is not listed. Siblings keep the order of procs.
+func renderProcessTree(procs []codersdk.WorkspaceAgentProcess) string {
+	known := make(map[int32]struct{}, len(procs))
+	for _, p := range procs {
+		known[p.PID] = struct{}{}
+	}
+	children := make(map[int32][]codersdk.WorkspaceAgentProcess)
+	var roots []codersdk.WorkspaceAgentProcess
+	for _, p := range procs {
+		if _, ok := known[p.PPID]; !ok || p.PPID == p.PID {
+			roots = append(roots, p)
+			continue
+		}
+		children[p.PPID] = append(children[p.PPID], p)
+	}
+
+	var sb strings.Builder
+	var walk func(p codersdk.WorkspaceAgentProcess, prefix, childPrefix string)
+	walk = func(p codersdk.WorkspaceAgentProcess, prefix, childPrefix string) {
+		_, _ = fmt.Fprintf(&sb, "%s%d %s (%.1f%% cpu, %s)", prefix, p.PID, processCommand(p), p.CPUPercent, humanize.IBytes(p.MemoryRSS))
+		if len(p.Ports) > 0 {
+			_, _ = fmt.Fprintf(&sb, " [%s]", formatProcessPorts(p.Ports))
+		}
+		sb.WriteString("\n")
+		kids := children[p.PID]
+		for i, c := range kids {
+			if i == len(kids)-1 {
+				walk(c, childPrefix+"└── ", childPrefix+"    ")
+			} else {
+				walk(c, childPrefix+"├── ", childPrefix+"│   ")
+			}
+		}
+	}
+	for _, root := range roots {
+		walk(root, "", "")
+	}
+	return sb.String()
+}
+
+// processCommand returns the command line of the process, falling back to
+// its name when the command line is not readable.
+func processCommand(p codersdk.WorkspaceAgentProcess) string {
+	if p.Cmdline != "" {
+		return p.Cmdline
+	}
+	return p.Name
+}
+
+func formatProcessPorts(ports []uint16) string {
+	strs := make([]string, 0, len(ports))
+	for _, port := range slices.Sorted(slices.Values(ports)) {
+		strs = append(strs, strconv.Itoa(int(port)))
+	}
+	return strings.Join(strs, ",")
+}
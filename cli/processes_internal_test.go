@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/codersdk"
+)
+
+func TestRenderProcessTree(t *testing.T) {
+	t.Parallel()
+
+	procs := []codersdk.WorkspaceAgentProcess{
+		{PID: 1, PPID: 0, Name: "init", MemoryRSS: 1024},
+		{PID: 20, PPID: 1, Name: "bash", Cmdline: "bash -l"},
+		{PID: 30, PPID: 20, Name: "node", Cmdline: "node server.js", CPUPercent: 42.5, Ports: []uint16{8080, 3000}},
+		{PID: 31, PPID: 20, Name: "sleep"},
+		{PID: 40, PPID: 1, Name: "sshd"},
+		// The parent of this process is not visible to the agent.
+		{PID: 50, PPID: 99, Name: "orphan"},
+	}
+
+	require.Equal(t, ""+
+		"1 init (0.0% cpu, 1.0 KiB)\n"+
+		"├── 20 bash -l (0.0% cpu, 0 B)\n"+
+		"│   ├── 30 node server.js (42.5% cpu, 0 B) [3000,8080]\n"+
+		"│   └── 31 sleep (0.0% cpu, 0 B)\n"+
+		"└── 40 sshd (0.0% cpu, 0 B)\n"+
+		"50 orphan (0.0% cpu, 0 B)\n",
+		renderProcessTree(procs))
+}
@@ -16,7 +16,7 @@ import (
 func (r *RootCmd) restart() *serpent.Command {
 	var (
 		parameterFlags workspaceParameterFlags
-		bflags         buildFlags
+		bflags         = buildFlags{previewUpdate: true}
 	)
 
 	client := new(codersdk.Client)
@@ -72,7 +72,11 @@ func (r *RootCmd) restart() *serpent.Command {
 			// workspaces with the active version.
 			if cerr, ok := codersdk.AsError(err); ok && cerr.StatusCode() == http.StatusForbidden {
 				_, _ = fmt.Fprintln(inv.Stdout, "Unable to restart the workspace with the template version from the last build. Policy may require you to restart with the current active template version.")
-				build, err = startWorkspace(inv, client, workspace, parameterFlags, bflags, WorkspaceUpdate)
+				// The workspace is already stopped, so don't ask to
+				// confirm the update halfway through the restart.
+				retryFlags := bflags
+				retryFlags.previewUpdate = false
+				build, err = startWorkspace(inv, client, workspace, parameterFlags, retryFlags, WorkspaceUpdate)
 				if err != nil {
 					return xerrors.Errorf("start workspace with active template version: %w", err)
 				}
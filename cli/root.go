@@ -110,14 +110,18 @@ func (r *RootCmd) CoreSubcommands() []*serpent.Command {
 		r.configSSH(),
 		r.create(),
 		r.deleteWorkspace(),
+		r.devcontainers(),
 		r.favorite(),
+		r.httpCapture(),
 		r.list(),
 		r.open(),
 		r.ping(),
+		r.processes(),
 		r.rename(),
 		r.restart(),
 		r.schedules(),
 		r.show(),
+		r.snapshots(),
 		r.speedtest(),
 		r.ssh(),
 		r.start(),
@@ -37,6 +37,7 @@ import (
 	embeddedpostgres "github.com/fergusstrange/embedded-postgres"
 	"github.com/google/go-github/v43/github"
 	"github.com/google/uuid"
+	goversion "github.com/hashicorp/go-version"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -63,6 +64,7 @@ import (
 
 	"github.com/coder/coder/v2/coderd/entitlements"
 	"github.com/coder/coder/v2/coderd/notifications/reports"
+	"github.com/coder/coder/v2/coderd/notifications/rules"
 	"github.com/coder/coder/v2/coderd/runtimeconfig"
 	"github.com/coder/coder/v2/coderd/webpush"
 	"github.com/coder/coder/v2/codersdk/drpcsdk"
@@ -83,6 +85,7 @@ import (
 	"github.com/coder/coder/v2/coderd/database/migrations"
 	"github.com/coder/coder/v2/coderd/database/pubsub"
 	"github.com/coder/coder/v2/coderd/devtunnel"
+	"github.com/coder/coder/v2/coderd/driftcheck"
 	"github.com/coder/coder/v2/coderd/externalauth"
 	"github.com/coder/coder/v2/coderd/gitsshkey"
 	"github.com/coder/coder/v2/coderd/httpmw"
@@ -92,6 +95,7 @@ import (
 	"github.com/coder/coder/v2/coderd/prometheusmetrics"
 	"github.com/coder/coder/v2/coderd/prometheusmetrics/insights"
 	"github.com/coder/coder/v2/coderd/promoauth"
+	"github.com/coder/coder/v2/coderd/provisionerqueue"
 	"github.com/coder/coder/v2/coderd/schedule"
 	"github.com/coder/coder/v2/coderd/telemetry"
 	"github.com/coder/coder/v2/coderd/tracing"
@@ -100,10 +104,12 @@ import (
 	"github.com/coder/coder/v2/coderd/util/slice"
 	stringutil "github.com/coder/coder/v2/coderd/util/strings"
 	"github.com/coder/coder/v2/coderd/workspaceapps/appurl"
+	"github.com/coder/coder/v2/coderd/workspacesnapshots"
 	"github.com/coder/coder/v2/coderd/workspacestats"
 	"github.com/coder/coder/v2/codersdk"
 	"github.com/coder/coder/v2/cryptorand"
 	"github.com/coder/coder/v2/provisioner/echo"
+	"github.com/coder/coder/v2/provisioner/external"
 	"github.com/coder/coder/v2/provisioner/terraform"
 	"github.com/coder/coder/v2/provisionerd"
 	"github.com/coder/coder/v2/provisionerd/proto"
@@ -242,6 +248,12 @@ func enablePrometheus(
 	}
 	afterCtx(ctx, closeWorkspacesFunc)
 
+	closeProvisionerQueueFunc, err := prometheusmetrics.ProvisionerQueue(ctx, options.Logger.Named("provisioner_queue_metrics"), options.PrometheusRegistry, options.Database, 0)
+	if err != nil {
+		return nil, xerrors.Errorf("register provisioner queue prometheus metric: %w", err)
+	}
+	afterCtx(ctx, closeProvisionerQueueFunc)
+
 	insightsMetricsCollector, err := insights.NewMetricsCollector(options.Database, options.Logger, 0, 0)
 	if err != nil {
 		return nil, xerrors.Errorf("unable to initialize insights metrics collector: %w", err)
@@ -818,6 +830,30 @@ func (r *RootCmd) Server(newAPI func(context.Context, *coderd.Options) (*coderd.
 				}
 			}
 
+			options.WorkspaceSnapshotMaxSize = vals.WorkspaceSnapshots.MaxSize.Value()
+			switch storage := vals.WorkspaceSnapshots.Storage.Value(); storage {
+			case string(database.WorkspaceSnapshotStorageDatabase):
+			case string(database.WorkspaceSnapshotStorageS3):
+				s3 := vals.WorkspaceSnapshots.S3
+				var endpoint *url.URL
+				if s3.Endpoint.String() != "" {
+					endpoint = s3.Endpoint.Value()
+				}
+				options.WorkspaceSnapshotStore, err = workspacesnapshots.NewS3Store(ctx, workspacesnapshots.S3Options{
+					Bucket:          s3.Bucket.Value(),
+					Region:          s3.Region.Value(),
+					Endpoint:        endpoint,
+					Prefix:          s3.Prefix.Value(),
+					AccessKeyID:     s3.AccessKeyID.Value(),
+					SecretAccessKey: s3.SecretAccessKey.Value(),
+				})
+				if err != nil {
+					return xerrors.Errorf("configure workspace snapshot storage: %w", err)
+				}
+			default:
+				return xerrors.Errorf("unknown workspace snapshot storage %q", storage)
+			}
+
 			githubOAuth2ConfigParams, err := getGithubOAuth2ConfigParams(ctx, options.Database, vals)
 			if err != nil {
 				return xerrors.Errorf("get github oauth2 config params: %w", err)
@@ -942,6 +978,26 @@ func (r *RootCmd) Server(newAPI func(context.Context, *coderd.Options) (*coderd.
 			notificationReportGenerator := reports.NewReportGenerator(ctx, logger.Named("notifications.report_generator"), options.Database, options.NotificationsEnqueuer, quartz.NewReal())
 			defer notificationReportGenerator.Close()
 
+			// Check running workspaces for resources changed outside of Coder.
+			driftCheckScheduler := driftcheck.NewScheduler(ctx, logger.Named("driftcheck"), options.Database, options.Pubsub, quartz.NewReal())
+			defer driftCheckScheduler.Close()
+
+			// Scale provisioner daemons to their queue with KEDA.
+			if vals.Provisioner.ScalerEnable {
+				scalerAddress := vals.Provisioner.ScalerAddress.String()
+				scalerListener, err := net.Listen("tcp", scalerAddress)
+				if err != nil {
+					return xerrors.Errorf("listen on provisioner scaler address %q: %w", scalerAddress, err)
+				}
+				scaler := provisionerqueue.NewScaler(logger.Named("provisioner_scaler"), options.Database, quartz.NewReal())
+				go func() {
+					err := scaler.Serve(ctx, scalerListener)
+					if err != nil {
+						logger.Error(ctx, "provisioner scaler stopped", slog.Error(err))
+					}
+				}()
+			}
+
 			// We use a separate coderAPICloser so the Enterprise API
 			// can have its own close functions. This is cleaner
 			// than abstracting the Coder API itself.
@@ -950,6 +1006,12 @@ func (r *RootCmd) Server(newAPI func(context.Context, *coderd.Options) (*coderd.
 				return xerrors.Errorf("create coder API: %w", err)
 			}
 
+			// Evaluate the notification rules defined by users. Rule owners are
+			// authorized with the API's authorizer, so must be initiated after
+			// Coder API.
+			notificationRulesEvaluator := rules.NewEvaluator(ctx, logger.Named("notifications.rules"), options.Database, coderAPI.Authorizer, options.NotificationsEnqueuer, quartz.NewReal())
+			defer notificationRulesEvaluator.Close()
+
 			if vals.Prometheus.Enable {
 				// Agent metrics require reference to the tailnet coordinator, so must be initiated after Coder API.
 				closeAgentsFunc, err := prometheusmetrics.Agents(ctx, logger, options.PrometheusRegistry, coderAPI.Database, &coderAPI.TailnetCoordinator, coderAPI.DERPMap, coderAPI.Options.AgentInactiveDisconnectTimeout, 0)
@@ -1035,7 +1097,21 @@ func (r *RootCmd) Server(newAPI func(context.Context, *coderd.Options) (*coderd.
 			defer shutdownConns()
 
 			// Ensures that old database entries are cleaned up over time!
-			purger := dbpurge.New(ctx, logger.Named("dbpurge"), options.Database, quartz.NewReal())
+			auditLogRetention, err := parseAuditLogRetention(vals.AuditLogRetention)
+			if err != nil {
+				return xerrors.Errorf("parse audit log retention: %w", err)
+			}
+			purger := dbpurge.New(ctx, logger.Named("dbpurge"), options.Database, quartz.NewReal(),
+				dbpurge.WithAuditLogRetention(auditLogRetention),
+				dbpurge.WithWorkspaceStateRetention(dbpurge.WorkspaceStateRetention{
+					Builds: int32(vals.WorkspaceStateHistory.Builds.Value()), //nolint:gosec // The number of builds to keep is small.
+					MaxAge: vals.WorkspaceStateHistory.MaxAge.Value(),
+				}),
+				dbpurge.WithWorkspaceSnapshotRetention(dbpurge.WorkspaceSnapshotRetention{
+					Chains: int32(vals.WorkspaceSnapshots.KeepChains.Value()), //nolint:gosec // The number of chains to keep is small.
+					Store:  options.WorkspaceSnapshotStore,
+				}),
+			)
 			defer purger.Close()
 
 			// Updates workspace usage
@@ -1426,6 +1502,9 @@ func newProvisionerDaemon(
 
 	// Populate the connector with the supported types.
 	connector := provisionerd.LocalProvisioners{}
+	// versionTags advertise the additional provisioner versions installed,
+	// so that jobs of templates pinned to them are routed here.
+	versionTags := map[string]string{}
 	for _, provisionerType := range provisionerTypes {
 		switch provisionerType {
 		case codersdk.ProvisionerTypeEcho:
@@ -1455,11 +1534,28 @@ func newProvisionerDaemon(
 				}
 			}()
 			connector[string(database.ProvisionerTypeEcho)] = sdkproto.NewDRPCProvisionerClient(echoClient)
-		case codersdk.ProvisionerTypeTerraform:
+		case codersdk.ProvisionerTypeTerraform, codersdk.ProvisionerTypeTofu:
+			distribution := terraform.Distribution(provisionerType)
 			tfDir := filepath.Join(cacheDir, "tf")
+			if distribution == terraform.DistributionTofu {
+				tfDir = filepath.Join(cacheDir, "tofu")
+			}
 			err = os.MkdirAll(tfDir, 0o700)
 			if err != nil {
-				return nil, xerrors.Errorf("mkdir terraform dir: %w", err)
+				return nil, xerrors.Errorf("mkdir %s dir: %w", provisionerType, err)
+			}
+
+			var versions []*goversion.Version
+			for _, raw := range cfg.Provisioner.DaemonVersions.Value() {
+				v, err := codersdk.ParseProvisionerBinaryVersion(raw)
+				if err != nil {
+					return nil, err
+				}
+				if v.Provisioner != provisionerType {
+					continue
+				}
+				versions = append(versions, goversion.Must(goversion.NewVersion(v.Version)))
+				versionTags[provisionersdk.ProvisionerVersionTag(string(v.Provisioner), v.Version)] = "true"
 			}
 
 			tracer := coderAPI.TracerProvider.Tracer(tracing.TracerName)
@@ -1482,8 +1578,10 @@ func newProvisionerDaemon(
 						Logger:        provisionerLogger,
 						WorkDirectory: workDir,
 					},
-					CachePath: tfDir,
-					Tracer:    tracer,
+					Distribution: distribution,
+					Versions:     versions,
+					CachePath:    tfDir,
+					Tracer:       tracer,
 				})
 				if err != nil && !xerrors.Is(err, context.Canceled) {
 					select {
@@ -1493,7 +1591,38 @@ func newProvisionerDaemon(
 				}
 			}()
 
-			connector[string(database.ProvisionerTypeTerraform)] = sdkproto.NewDRPCProvisionerClient(terraformClient)
+			connector[string(provisionerType)] = sdkproto.NewDRPCProvisionerClient(terraformClient)
+		case codersdk.ProvisionerTypeExternal:
+			externalClient, externalServer := drpcsdk.MemTransportPipe()
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-ctx.Done()
+				_ = externalClient.Close()
+				_ = externalServer.Close()
+			}()
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer cancel()
+
+				err := external.Serve(ctx, &external.ServeOptions{
+					ServeOptions: &provisionersdk.ServeOptions{
+						Listener:      externalServer,
+						Logger:        provisionerLogger,
+						WorkDirectory: workDir,
+					},
+					Command: cfg.Provisioner.ExternalCommand.Value(),
+				})
+				if err != nil && !xerrors.Is(err, context.Canceled) {
+					select {
+					case errCh <- err:
+					default:
+					}
+				}
+			}()
+
+			connector[string(database.ProvisionerTypeExternal)] = sdkproto.NewDRPCProvisionerClient(externalClient)
 		default:
 			return nil, xerrors.Errorf("unknown provisioner type %q", provisionerType)
 		}
@@ -1502,7 +1631,7 @@ func newProvisionerDaemon(
 	return provisionerd.New(func(dialCtx context.Context) (proto.DRPCProvisionerDaemonClient, error) {
 		// This debounces calls to listen every second. Read the comment
 		// in provisionerdserver.go to learn more!
-		return coderAPI.CreateInMemoryProvisionerDaemon(dialCtx, name, provisionerTypes)
+		return coderAPI.CreateInMemoryTaggedProvisionerDaemon(dialCtx, name, provisionerTypes, versionTags)
 	}, &provisionerd.Options{
 		Logger:              provisionerLogger,
 		UpdateInterval:      time.Second,
@@ -2795,3 +2924,16 @@ func getAndMigratePostgresDB(ctx context.Context, logger slog.Logger, postgresUR
 
 	return sqlDB, dbURL, nil
 }
+
+// parseAuditLogRetention validates the audit log retention configuration.
+func parseAuditLogRetention(cfg codersdk.AuditLogRetentionConfig) (dbpurge.AuditLogRetention, error) {
+	rules, err := dbpurge.ParseAuditLogRetentionRules(cfg.Rules.Value())
+	if err != nil {
+		return dbpurge.AuditLogRetention{}, err
+	}
+	return dbpurge.AuditLogRetention{
+		Default:         cfg.Default.Value(),
+		Rules:           rules,
+		RestoreDuration: cfg.RestoreDuration.Value(),
+	}, nil
+}
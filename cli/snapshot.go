@@ -0,0 +1,266 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/v2/cli/cliui"
+	"github.com/coder/coder/v2/codersdk"
+	"github.com/coder/serpent"
+)
+
+func (r *RootCmd) snapshots() *serpent.Command {
+	cmd := &serpent.Command{
+		Use:     "snapshots",
+		Short:   "List and restore snapshots of workspace directories",
+		Aliases: []string{"snapshot"},
+		Long: "Workspace agents started with --snapshot-paths upload a snapshot of those directories when the workspace " +
+			"stops, and periodically with --snapshot-interval. Restoring a snapshot replaces the directories when the " +
+			"workspace next starts.",
+		Handler: func(inv *serpent.Invocation) error {
+			return inv.Command.HelpHandler(inv)
+		},
+		Children: []*serpent.Command{
+			r.snapshotsList(),
+			r.snapshotsRestore(),
+			r.snapshotsCancel(),
+		},
+	}
+	return cmd
+}
+
+func (r *RootCmd) snapshotsList() *serpent.Command {
+	type snapshotRow struct {
+		ID        string    `json:"id" table:"id"`
+		CreatedAt time.Time `json:"created_at" table:"created at,default_sort"`
+		Agent     string    `json:"agent" table:"agent"`
+		Build     int32     `json:"build" table:"build"`
+		Trigger   string    `json:"trigger" table:"trigger"`
+		Kind      string    `json:"kind" table:"kind"`
+		Files     int32     `json:"files" table:"files"`
+		Size      string    `json:"size" table:"size"`
+		Paths     string    `json:"paths" table:"paths"`
+	}
+
+	var (
+		client    = new(codersdk.Client)
+		formatter = cliui.NewOutputFormatter(
+			cliui.ChangeFormatterData(
+				cliui.TableFormat([]snapshotRow{}, []string{"id", "created at", "agent", "trigger", "kind", "files", "size"}),
+				func(data any) (any, error) {
+					snapshots, ok := data.([]codersdk.WorkspaceSnapshot)
+					if !ok {
+						return nil, xerrors.Errorf("expected []codersdk.WorkspaceSnapshot, got %T", data)
+					}
+					rows := make([]snapshotRow, 0, len(snapshots))
+					for _, s := range snapshots {
+						kind := "full"
+						if s.ParentID != nil {
+							kind = "incremental"
+						}
+						rows = append(rows, snapshotRow{
+							ID:        s.ID.String(),
+							CreatedAt: s.CreatedAt,
+							Agent:     s.AgentName,
+							Build:     s.BuildNumber,
+							Trigger:   string(s.Trigger),
+							Kind:      kind,
+							Files:     s.FileCount,
+							Size:      humanize.IBytes(uint64(s.SizeBytes)),
+							Paths:     strings.Join(s.Paths, ", "),
+						})
+					}
+					return rows, nil
+				},
+			),
+			cliui.JSONFormat(),
+		)
+	)
+	cmd := &serpent.Command{
+		Use:     "list <workspace>",
+		Short:   "List the snapshots of a workspace, newest first",
+		Aliases: []string{"ls"},
+		Middleware: serpent.Chain(
+			serpent.RequireNArgs(1),
+			r.InitClient(client),
+		),
+		Handler: func(inv *serpent.Invocation) error {
+			ctx := inv.Context()
+			workspace, err := namedWorkspace(ctx, client, inv.Args[0])
+			if err != nil {
+				return err
+			}
+
+			snapshots, err := client.WorkspaceSnapshots(ctx, workspace.ID)
+			if err != nil {
+				return xerrors.Errorf("list snapshots: %w", err)
+			}
+			if len(snapshots) == 0 && formatter.FormatID() != cliui.JSONFormat().ID() {
+				cliui.Infof(inv.Stderr, "No snapshots found for workspace %s.", cliui.Keyword(workspace.Name))
+				return nil
+			}
+
+			out, err := formatter.Format(ctx, snapshots)
+			if err != nil {
+				return xerrors.Errorf("format snapshots: %w", err)
+			}
+			_, _ = fmt.Fprintln(inv.Stdout, out)
+			return nil
+		},
+	}
+	formatter.AttachOptions(&cmd.Options)
+	return cmd
+}
+
+func (r *RootCmd) snapshotsRestore() *serpent.Command {
+	var (
+		parameterFlags workspaceParameterFlags
+		bflags         buildFlags
+		noBuild        bool
+	)
+
+	client := new(codersdk.Client)
+	cmd := &serpent.Command{
+		Use:   "restore <workspace> <snapshot-id|latest>",
+		Short: "Restore a snapshot when the workspace next starts",
+		Long: "Running workspaces are restarted, and stopped workspaces are started, unless --no-build is set.\n\n" +
+			FormatExamples(
+				Example{
+					Description: "Restore the latest snapshot and restart the workspace",
+					Command:     "coder snapshots restore my-workspace latest",
+				},
+				Example{
+					Description: "Restore a snapshot the next time the workspace starts",
+					Command:     "coder snapshots restore my-workspace 2c4f5e3a-8a0c-4c1e-9b7f-0b8f3f0f4d1e --no-build",
+				},
+			),
+		Middleware: serpent.Chain(
+			serpent.RequireNArgs(2),
+			r.InitClient(client),
+		),
+		Options: serpent.OptionSet{
+			{
+				Flag:        "no-build",
+				Description: "Only schedule the restore, without starting a workspace build.",
+				Value:       serpent.BoolOf(&noBuild),
+			},
+			cliui.SkipPromptOption(),
+		},
+		Handler: func(inv *serpent.Invocation) error {
+			ctx := inv.Context()
+			workspace, err := namedWorkspace(ctx, client, inv.Args[0])
+			if err != nil {
+				return err
+			}
+
+			snapshots, err := client.WorkspaceSnapshots(ctx, workspace.ID)
+			if err != nil {
+				return xerrors.Errorf("list snapshots: %w", err)
+			}
+			snapshot, err := findSnapshot(snapshots, inv.Args[1])
+			if err != nil {
+				return err
+			}
+
+			_, err = cliui.Prompt(inv, cliui.PromptOptions{
+				Text: fmt.Sprintf("Replace %s in %s with the snapshot from %s?",
+					strings.Join(snapshot.Paths, ", "), cliui.Keyword(workspace.Name), cliui.Timestamp(snapshot.CreatedAt)),
+				IsConfirm: true,
+			})
+			if err != nil {
+				return err
+			}
+
+			if _, err := client.RestoreWorkspaceSnapshot(ctx, workspace.ID, snapshot.ID); err != nil {
+				return xerrors.Errorf("restore snapshot: %w", err)
+			}
+			if noBuild {
+				_, _ = fmt.Fprintf(inv.Stdout, "\nThe snapshot will be restored when the %s workspace next starts.\n", cliui.Keyword(workspace.Name))
+				return nil
+			}
+
+			if workspace.LatestBuild.Transition == codersdk.WorkspaceTransitionStart {
+				build, err := client.CreateWorkspaceBuild(ctx, workspace.ID, codersdk.CreateWorkspaceBuildRequest{
+					Transition: codersdk.WorkspaceTransitionStop,
+				})
+				if err != nil {
+					return xerrors.Errorf("stop workspace: %w", err)
+				}
+				if err := cliui.WorkspaceBuild(ctx, inv.Stdout, client, build.ID); err != nil {
+					return err
+				}
+			}
+
+			build, err := startWorkspace(inv, client, workspace, parameterFlags, bflags, WorkspaceStart)
+			if err != nil {
+				return err
+			}
+			if err := cliui.WorkspaceBuild(ctx, inv.Stdout, client, build.ID); err != nil {
+				return err
+			}
+
+			_, _ = fmt.Fprintf(inv.Stdout,
+				"\nThe %s workspace has been started, the agent restores the snapshot before running startup scripts.\n",
+				cliui.Keyword(workspace.Name),
+			)
+			return nil
+		},
+	}
+
+	cmd.Options = append(cmd.Options, parameterFlags.allOptions()...)
+	cmd.Options = append(cmd.Options, bflags.cliOptions()...)
+
+	return cmd
+}
+
+func (r *RootCmd) snapshotsCancel() *serpent.Command {
+	client := new(codersdk.Client)
+	cmd := &serpent.Command{
+		Use:   "cancel <workspace>",
+		Short: "Cancel a pending snapshot restore",
+		Middleware: serpent.Chain(
+			serpent.RequireNArgs(1),
+			r.InitClient(client),
+		),
+		Handler: func(inv *serpent.Invocation) error {
+			ctx := inv.Context()
+			workspace, err := namedWorkspace(ctx, client, inv.Args[0])
+			if err != nil {
+				return err
+			}
+
+			if err := client.CancelWorkspaceSnapshotRestore(ctx, workspace.ID); err != nil {
+				return xerrors.Errorf("cancel snapshot restore: %w", err)
+			}
+			_, _ = fmt.Fprintf(inv.Stdout, "No snapshot will be restored when the %s workspace next starts.\n", cliui.Keyword(workspace.Name))
+			return nil
+		},
+	}
+	return cmd
+}
+
+// findSnapshot returns the snapshot with the given ID, or the newest
+// snapshot for "latest". Snapshots are ordered newest first.
+func findSnapshot(snapshots []codersdk.WorkspaceSnapshot, arg string) (codersdk.WorkspaceSnapshot, error) {
+	if arg == "latest" {
+		if len(snapshots) == 0 {
+			return codersdk.WorkspaceSnapshot{}, xerrors.New("the workspace has no snapshots")
+		}
+		return snapshots[0], nil
+	}
+	id, err := uuid.Parse(arg)
+	if err != nil {
+		return codersdk.WorkspaceSnapshot{}, xerrors.Errorf("invalid snapshot ID %q: %w", arg, err)
+	}
+	for _, s := range snapshots {
+		if s.ID == id {
+			return s, nil
+		}
+	}
+	return codersdk.WorkspaceSnapshot{}, xerrors.Errorf("snapshot %s not found", id)
+}
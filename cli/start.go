@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/google/uuid"
 	"golang.org/x/xerrors"
 
 	"github.com/coder/coder/v2/cli/cliui"
@@ -16,7 +17,7 @@ import (
 func (r *RootCmd) start() *serpent.Command {
 	var (
 		parameterFlags workspaceParameterFlags
-		bflags         buildFlags
+		bflags         = buildFlags{previewUpdate: true}
 
 		noWait bool
 	)
@@ -117,10 +118,16 @@ func (r *RootCmd) start() *serpent.Command {
 func buildWorkspaceStartRequest(inv *serpent.Invocation, client *codersdk.Client, workspace codersdk.Workspace, parameterFlags workspaceParameterFlags, buildFlags buildFlags, action WorkspaceCLIAction) (codersdk.CreateWorkspaceBuildRequest, error) {
 	version := workspace.LatestBuild.TemplateVersionID
 
+	// Updates to another template version preview the changes they make to
+	// the resources of the workspace.
+	var updateWorkspaceID uuid.UUID
 	if workspace.AutomaticUpdates == codersdk.AutomaticUpdatesAlways || action == WorkspaceUpdate {
 		version = workspace.TemplateActiveVersionID
 		if version != workspace.LatestBuild.TemplateVersionID {
 			action = WorkspaceUpdate
+			if buildFlags.previewUpdate {
+				updateWorkspaceID = workspace.ID
+			}
 		}
 	}
 
@@ -156,6 +163,7 @@ func buildWorkspaceStartRequest(inv *serpent.Invocation, client *codersdk.Client
 		RichParameters:            cliRichParameters,
 		RichParameterFile:         parameterFlags.richParameterFile,
 		RichParameterDefaults:     cliRichParameterDefaults,
+		UpdateWorkspaceID:         updateWorkspaceID,
 	})
 	if err != nil {
 		return codersdk.CreateWorkspaceBuildRequest{}, err
@@ -174,6 +182,16 @@ func buildWorkspaceStartRequest(inv *serpent.Invocation, client *codersdk.Client
 }
 
 func startWorkspace(inv *serpent.Invocation, client *codersdk.Client, workspace codersdk.Workspace, parameterFlags workspaceParameterFlags, buildFlags buildFlags, action WorkspaceCLIAction) (codersdk.WorkspaceBuild, error) {
+	req, err := buildWorkspaceStartRequest(inv, client, workspace, parameterFlags, buildFlags, action)
+	if err != nil {
+		return codersdk.WorkspaceBuild{}, err
+	}
+	return createWorkspaceStartBuild(inv, client, workspace, req)
+}
+
+// createWorkspaceStartBuild starts a workspace with a prepared build request,
+// activating it first if it is dormant.
+func createWorkspaceStartBuild(inv *serpent.Invocation, client *codersdk.Client, workspace codersdk.Workspace, req codersdk.CreateWorkspaceBuildRequest) (codersdk.WorkspaceBuild, error) {
 	if workspace.DormantAt != nil {
 		_, _ = fmt.Fprintln(inv.Stdout, "Activating dormant workspace...")
 		err := client.UpdateWorkspaceDormancy(inv.Context(), workspace.ID, codersdk.UpdateWorkspaceDormancy{
@@ -183,10 +201,6 @@ func startWorkspace(inv *serpent.Invocation, client *codersdk.Client, workspace
 			return codersdk.WorkspaceBuild{}, xerrors.Errorf("activate workspace: %w", err)
 		}
 	}
-	req, err := buildWorkspaceStartRequest(inv, client, workspace, parameterFlags, buildFlags, action)
-	if err != nil {
-		return codersdk.WorkspaceBuild{}, err
-	}
 
 	build, err := client.CreateWorkspaceBuild(inv.Context(), workspace.ID, req)
 	if err != nil {
@@ -1,10 +1,18 @@
 package cli
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"sort"
 	"strconv"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"golang.org/x/xerrors"
 
 	"github.com/coder/coder/v2/cli/cliui"
 	"github.com/coder/coder/v2/codersdk"
@@ -21,6 +29,9 @@ func (r *RootCmd) state() *serpent.Command {
 		Children: []*serpent.Command{
 			r.statePull(),
 			r.statePush(),
+			r.stateList(),
+			r.stateDiff(),
+			r.stateRollback(),
 		},
 	}
 	return cmd
@@ -139,3 +150,341 @@ func (r *RootCmd) statePush() *serpent.Command {
 	}
 	return cmd
 }
+
+// workspaceBuildByNumber returns the build of a workspace with the given
+// number, or the latest build when the number is zero.
+func workspaceBuildByNumber(ctx context.Context, client *codersdk.Client, workspace codersdk.Workspace, buildNumber int64) (codersdk.WorkspaceBuild, error) {
+	if buildNumber == 0 {
+		return workspace.LatestBuild, nil
+	}
+	return client.WorkspaceBuildByUsernameAndWorkspaceNameAndBuildNumber(ctx, workspace.OwnerName, workspace.Name, strconv.FormatInt(buildNumber, 10))
+}
+
+func (r *RootCmd) stateList() *serpent.Command {
+	type stateRow struct {
+		Build      int32  `json:"build" table:"build,default_sort"`
+		Transition string `json:"transition" table:"transition"`
+		Size       string `json:"size" table:"size"`
+		Hash       string `json:"hash" table:"hash"`
+		Created    string `json:"created" table:"created"`
+	}
+
+	var (
+		client    = new(codersdk.Client)
+		formatter = cliui.NewOutputFormatter(
+			cliui.ChangeFormatterData(
+				cliui.TableFormat([]stateRow{}, []string{"build", "transition", "size", "hash", "created"}),
+				func(data any) (any, error) {
+					states, ok := data.([]codersdk.WorkspaceBuildState)
+					if !ok {
+						return nil, xerrors.Errorf("expected []codersdk.WorkspaceBuildState, got %T", data)
+					}
+					rows := make([]stateRow, 0, len(states))
+					for _, state := range states {
+						hash := state.Hash
+						if len(hash) > 12 {
+							hash = hash[:12]
+						}
+						rows = append(rows, stateRow{
+							Build:      state.BuildNumber,
+							Transition: string(state.Transition),
+							Size:       humanize.Bytes(uint64(state.Size)),
+							Hash:       hash,
+							Created:    humanize.Time(state.CreatedAt),
+						})
+					}
+					return rows, nil
+				},
+			),
+			cliui.JSONFormat(),
+		)
+	)
+	cmd := &serpent.Command{
+		Use:     "list <workspace>",
+		Short:   "List the Terraform states kept for the builds of a workspace.",
+		Aliases: []string{"ls"},
+		Middleware: serpent.Chain(
+			serpent.RequireNArgs(1),
+			r.InitClient(client),
+		),
+		Handler: func(inv *serpent.Invocation) error {
+			ctx := inv.Context()
+			workspace, err := namedWorkspace(ctx, client, inv.Args[0])
+			if err != nil {
+				return err
+			}
+
+			states, err := client.WorkspaceBuildStates(ctx, workspace.ID)
+			if err != nil {
+				return xerrors.Errorf("list states: %w", err)
+			}
+			if len(states) == 0 && formatter.FormatID() == "table" {
+				cliui.Infof(inv.Stderr, "No Terraform states are kept for this workspace.")
+				return nil
+			}
+
+			out, err := formatter.Format(ctx, states)
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintln(inv.Stdout, out)
+			return err
+		},
+	}
+	formatter.AttachOptions(&cmd.Options)
+	return cmd
+}
+
+func (r *RootCmd) stateDiff() *serpent.Command {
+	client := new(codersdk.Client)
+	cmd := &serpent.Command{
+		Use:   "diff <workspace> <build> [build]",
+		Short: "Show the resources that differ between the Terraform states of two builds.",
+		Long: "Compares the state of the first build with the state of the second build, " +
+			"which defaults to the latest build. Resources only in the first state are prefixed " +
+			"with -, resources only in the second with +, and changed resources with ~.",
+		Middleware: serpent.Chain(
+			serpent.RequireRangeArgs(2, 3),
+			r.InitClient(client),
+		),
+		Handler: func(inv *serpent.Invocation) error {
+			ctx := inv.Context()
+			workspace, err := namedWorkspace(ctx, client, inv.Args[0])
+			if err != nil {
+				return err
+			}
+
+			var states [2][]byte
+			for i := range states {
+				var buildNumber int64
+				if i+1 < len(inv.Args) {
+					buildNumber, err = strconv.ParseInt(inv.Args[i+1], 10, 64)
+					if err != nil || buildNumber < 1 {
+						return xerrors.Errorf("invalid build number %q", inv.Args[i+1])
+					}
+				}
+				build, err := workspaceBuildByNumber(ctx, client, workspace, buildNumber)
+				if err != nil {
+					return err
+				}
+				states[i], err = client.WorkspaceBuildState(ctx, build.ID)
+				if err != nil {
+					return xerrors.Errorf("get state of build %d: %w", build.BuildNumber, err)
+				}
+			}
+
+			changes, err := diffTerraformStates(states[0], states[1])
+			if err != nil {
+				return err
+			}
+			if len(changes) == 0 {
+				cliui.Infof(inv.Stderr, "The states are identical.")
+				return nil
+			}
+			for _, change := range changes {
+				_, _ = fmt.Fprintln(inv.Stdout, change)
+			}
+			return nil
+		},
+	}
+	return cmd
+}
+
+func (r *RootCmd) stateRollback() *serpent.Command {
+	var buildNumber int64
+	client := new(codersdk.Client)
+	cmd := &serpent.Command{
+		Use:   "rollback <workspace>",
+		Short: "Roll a workspace back to the Terraform state of an earlier build.",
+		Long: "Starts a build of the latest template version and transition of the workspace " +
+			"with the state of the given build.\n\n" +
+			FormatExamples(
+				Example{
+					Description: "Roll back to the state of build 7",
+					Command:     "coder state rollback my-workspace --build 7",
+				},
+			),
+		Middleware: serpent.Chain(
+			serpent.RequireNArgs(1),
+			r.InitClient(client),
+		),
+		Handler: func(inv *serpent.Invocation) error {
+			ctx := inv.Context()
+			if buildNumber < 1 {
+				return xerrors.New("the build to roll back to must be specified with --build")
+			}
+			workspace, err := namedWorkspace(ctx, client, inv.Args[0])
+			if err != nil {
+				return err
+			}
+			build, err := workspaceBuildByNumber(ctx, client, workspace, buildNumber)
+			if err != nil {
+				return err
+			}
+			state, err := client.WorkspaceBuildState(ctx, build.ID)
+			if err != nil {
+				return xerrors.Errorf("get state of build %d: %w", build.BuildNumber, err)
+			}
+			if len(state) == 0 {
+				return xerrors.Errorf("no state is kept for build %d", build.BuildNumber)
+			}
+
+			_, err = cliui.Prompt(inv, cliui.PromptOptions{
+				Text:      fmt.Sprintf("Replace the state of %s with the state of build %d?", workspace.Name, build.BuildNumber),
+				IsConfirm: true,
+				Default:   cliui.ConfirmNo,
+			})
+			if err != nil {
+				return err
+			}
+
+			latest := workspace.LatestBuild
+			build, err = client.CreateWorkspaceBuild(ctx, workspace.ID, codersdk.CreateWorkspaceBuildRequest{
+				TemplateVersionID: latest.TemplateVersionID,
+				Transition:        latest.Transition,
+				ProvisionerState:  state,
+			})
+			if err != nil {
+				return err
+			}
+			return cliui.WorkspaceBuild(ctx, inv.Stderr, client, build.ID)
+		},
+	}
+	cmd.Options = serpent.OptionSet{
+		{
+			Flag:          "build",
+			FlagShorthand: "b",
+			Description:   "The build whose state to roll back to.",
+			Value:         serpent.Int64Of(&buildNumber),
+		},
+		cliui.SkipPromptOption(),
+	}
+	return cmd
+}
+
+// terraformState is the subset of a Terraform state file that identifies
+// its resources.
+type terraformState struct {
+	Resources []struct {
+		Module    string `json:"module"`
+		Mode      string `json:"mode"`
+		Type      string `json:"type"`
+		Name      string `json:"name"`
+		Instances []struct {
+			IndexKey   json.RawMessage `json:"index_key"`
+			Attributes json.RawMessage `json:"attributes"`
+		} `json:"instances"`
+	} `json:"resources"`
+}
+
+// terraformStateInstances returns the attributes of each resource instance
+// of a Terraform state by its address.
+func terraformStateInstances(raw []byte) (map[string]json.RawMessage, error) {
+	instances := map[string]json.RawMessage{}
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return instances, nil
+	}
+	var state terraformState
+	err := json.Unmarshal(raw, &state)
+	if err != nil {
+		return nil, xerrors.Errorf("parse terraform state: %w", err)
+	}
+	for _, resource := range state.Resources {
+		address := resource.Type + "." + resource.Name
+		if resource.Mode == "data" {
+			address = "data." + address
+		}
+		if resource.Module != "" {
+			address = resource.Module + "." + address
+		}
+		for _, instance := range resource.Instances {
+			instanceAddress := address
+			if len(instance.IndexKey) > 0 {
+				instanceAddress += "[" + string(instance.IndexKey) + "]"
+			}
+			instances[instanceAddress] = instance.Attributes
+		}
+	}
+	return instances, nil
+}
+
+// diffTerraformStates lists the resource instances that were removed, added
+// or changed from one Terraform state to another, ordered by address.
+func diffTerraformStates(from, to []byte) ([]string, error) {
+	fromInstances, err := terraformStateInstances(from)
+	if err != nil {
+		return nil, err
+	}
+	toInstances, err := terraformStateInstances(to)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses := make([]string, 0, len(fromInstances)+len(toInstances))
+	for address := range fromInstances {
+		addresses = append(addresses, address)
+	}
+	for address := range toInstances {
+		if _, ok := fromInstances[address]; !ok {
+			addresses = append(addresses, address)
+		}
+	}
+	sort.Strings(addresses)
+
+	var changes []string
+	for _, address := range addresses {
+		fromAttributes, inFrom := fromInstances[address]
+		toAttributes, inTo := toInstances[address]
+		switch {
+		case !inTo:
+			changes = append(changes, "- "+address)
+		case !inFrom:
+			changes = append(changes, "+ "+address)
+		default:
+			changed, err := changedAttributes(fromAttributes, toAttributes)
+			if err != nil {
+				return nil, xerrors.Errorf("compare %s: %w", address, err)
+			}
+			if len(changed) > 0 {
+				changes = append(changes, fmt.Sprintf("~ %s (%s)", address, strings.Join(changed, ", ")))
+			}
+		}
+	}
+	return changes, nil
+}
+
+// changedAttributes returns the names of the top-level attributes that differ
+// between two attribute objects.
+func changedAttributes(from, to json.RawMessage) ([]string, error) {
+	var fromAttributes, toAttributes map[string]any
+	if len(from) > 0 {
+		if err := json.Unmarshal(from, &fromAttributes); err != nil {
+			return nil, err
+		}
+	}
+	if len(to) > 0 {
+		if err := json.Unmarshal(to, &toAttributes); err != nil {
+			return nil, err
+		}
+	}
+	var changed []string
+	for name, value := range fromAttributes {
+		other, ok := toAttributes[name]
+		if !ok || !jsonEqual(value, other) {
+			changed = append(changed, name)
+		}
+	}
+	for name := range toAttributes {
+		if _, ok := fromAttributes[name]; !ok {
+			changed = append(changed, name)
+		}
+	}
+	sort.Strings(changed)
+	return changed, nil
+}
+
+func jsonEqual(a, b any) bool {
+	aJSON, aErr := json.Marshal(a)
+	bJSON, bErr := json.Marshal(b)
+	return aErr == nil && bErr == nil && bytes.Equal(aJSON, bJSON)
+}
@@ -2,6 +2,7 @@ package cli_test
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -159,3 +160,45 @@ func TestStatePush(t *testing.T) {
 		require.NoError(t, err)
 	})
 }
+
+func TestStateRollback(t *testing.T) {
+	t.Parallel()
+	t.Run("OK", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		owner := coderdtest.CreateFirstUser(t, client)
+		templateAdmin, _ := coderdtest.CreateAnotherUser(t, client, owner.OrganizationID, rbac.RoleTemplateAdmin())
+		version := coderdtest.CreateTemplateVersion(t, client, owner.OrganizationID, &echo.Responses{
+			Parse:          echo.ParseComplete,
+			ProvisionApply: echo.ApplyComplete,
+		})
+		coderdtest.AwaitTemplateVersionJobCompleted(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, owner.OrganizationID, version.ID)
+		workspace := coderdtest.CreateWorkspace(t, templateAdmin, template.ID)
+		coderdtest.AwaitWorkspaceBuildJobCompleted(t, client, workspace.LatestBuild.ID)
+
+		inv, root := clitest.New(t, "state", "push", workspace.Name, "-")
+		clitest.SetupConfig(t, templateAdmin, root)
+		inv.Stdin = strings.NewReader("some magic state")
+		require.NoError(t, inv.Run())
+
+		inv, root = clitest.New(t, "state", "rollback", workspace.Name, "--build", "2", "--yes")
+		clitest.SetupConfig(t, templateAdmin, root)
+		require.NoError(t, inv.Run())
+
+		workspace, err := templateAdmin.Workspace(context.Background(), workspace.ID)
+		require.NoError(t, err)
+		require.EqualValues(t, 3, workspace.LatestBuild.BuildNumber)
+	})
+
+	t.Run("NoBuild", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		owner := coderdtest.CreateFirstUser(t, client)
+		templateAdmin, _ := coderdtest.CreateAnotherUser(t, client, owner.OrganizationID, rbac.RoleTemplateAdmin())
+		inv, root := clitest.New(t, "state", "rollback", "my-workspace", "--yes")
+		clitest.SetupConfig(t, templateAdmin, root)
+		err := inv.Run()
+		require.ErrorContains(t, err, "--build")
+	})
+}
@@ -34,6 +34,7 @@ func (r *RootCmd) templateEdit() *serpent.Command {
 		allowUserAutostop              bool
 		requireActiveVersion           bool
 		deprecationMessage             string
+		provisionerVersion             string
 		disableEveryone                bool
 		orgContext                     = NewOrganizationContext()
 	)
@@ -162,6 +163,11 @@ func (r *RootCmd) templateEdit() *serpent.Command {
 				deprecated = &deprecationMessage
 			}
 
+			var pinnedVersion *string
+			if userSetOption(inv, "provisioner-version") {
+				pinnedVersion = &provisionerVersion
+			}
+
 			var disableEveryoneGroup bool
 			if userSetOption(inv, "private") {
 				disableEveryoneGroup = disableEveryone
@@ -189,6 +195,7 @@ func (r *RootCmd) templateEdit() *serpent.Command {
 				AllowUserAutostop:              allowUserAutostop,
 				RequireActiveVersion:           requireActiveVersion,
 				DeprecationMessage:             deprecated,
+				ProvisionerVersion:             pinnedVersion,
 				DisableEveryoneGroupAccess:     disableEveryoneGroup,
 			}
 
@@ -295,6 +302,11 @@ func (r *RootCmd) templateEdit() *serpent.Command {
 			Value:       serpent.BoolOf(&requireActiveVersion),
 			Default:     "false",
 		},
+		{
+			Flag:        "provisioner-version",
+			Description: "Pin the version of the provisioner binary, e.g. 1.9.1, that jobs of the template run with. Only provisioner daemons that have the version installed run them. Pass an empty value to unpin.",
+			Value:       serpent.StringOf(&provisionerVersion),
+		},
 		{
 			Flag: "private",
 			Description: "Disable the default behavior of granting template access to the 'everyone' group. " +
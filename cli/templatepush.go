@@ -28,6 +28,7 @@ func (r *RootCmd) templatePush() *serpent.Command {
 	var (
 		versionName          string
 		provisioner          string
+		testProvisioner      string
 		workdir              string
 		variablesFile        string
 		commandLineVariables []string
@@ -81,6 +82,23 @@ func (r *RootCmd) templatePush() *serpent.Command {
 				createTemplate = true
 			}
 
+			// The provisioner of an existing template is kept unless it is
+			// changed explicitly.
+			provisionerType := codersdk.ProvisionerType(provisioner)
+			if testProvisioner != "" {
+				provisionerType = codersdk.ProvisionerType(testProvisioner)
+			}
+			if provisionerType == "" {
+				provisionerType = codersdk.ProvisionerTypeTerraform
+				if !createTemplate && template.Provisioner != "" {
+					provisionerType = template.Provisioner
+				}
+			}
+			err = codersdk.ProvisionerTypeValid(provisionerType)
+			if err != nil {
+				return err
+			}
+
 			var tags map[string]string
 			// Passing --provisioner-tag="-" allows the user to clear all provisioner tags.
 			if len(provisionerTags) == 1 && strings.TrimSpace(provisionerTags[0]) == "-" {
@@ -157,7 +175,7 @@ func (r *RootCmd) templatePush() *serpent.Command {
 				Message:            message,
 				Client:             client,
 				Organization:       organization,
-				Provisioner:        codersdk.ProvisionerType(provisioner),
+				Provisioner:        provisionerType,
 				FileID:             resp.ID,
 				ProvisionerTags:    tags,
 				UserVariableValues: userVariableValues,
@@ -207,11 +225,15 @@ func (r *RootCmd) templatePush() *serpent.Command {
 	}
 
 	cmd.Options = serpent.OptionSet{
+		{
+			Flag:        "provisioner",
+			Description: "The provisioner type to import the template with: terraform, tofu or external. Defaults to the provisioner type of the existing template, or terraform for new templates.",
+			Value:       serpent.StringOf(&provisioner),
+		},
 		{
 			Flag:        "test.provisioner",
 			Description: "Customize the provisioner backend.",
-			Default:     "terraform",
-			Value:       serpent.StringOf(&provisioner),
+			Value:       serpent.StringOf(&testProvisioner),
 			// This is for testing!
 			Hidden: true,
 		},
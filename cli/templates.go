@@ -32,6 +32,7 @@ func (r *RootCmd) templates() *serpent.Command {
 			r.templateInit(),
 			r.templateList(),
 			r.templatePush(),
+			r.templateTest(),
 			r.templateVersions(),
 			r.templateDelete(),
 			r.templatePull(),
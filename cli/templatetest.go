@@ -0,0 +1,717 @@
+//go:build !slim
+
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+	"gopkg.in/yaml.v3"
+
+	"github.com/coder/coder/v2/cli/cliui"
+	"github.com/coder/coder/v2/codersdk"
+	"github.com/coder/coder/v2/codersdk/drpcsdk"
+	"github.com/coder/coder/v2/provisioner/echo"
+	"github.com/coder/coder/v2/provisioner/terraform"
+	"github.com/coder/coder/v2/provisionersdk"
+	sdkproto "github.com/coder/coder/v2/provisionersdk/proto"
+	"github.com/coder/pretty"
+	"github.com/coder/serpent"
+)
+
+// templateTestFixture is a set of inputs to plan a template with. Fixtures
+// are read from YAML files in the fixtures directory, and the name of a
+// fixture is the name of its file without the extension.
+type templateTestFixture struct {
+	// Parameters are the values of rich parameters, by name.
+	Parameters map[string]string `yaml:"parameters"`
+	// Variables are the values of Terraform variables, by name. Variables
+	// that are omitted use their default value.
+	Variables map[string]string `yaml:"variables"`
+	// Transition is the workspace transition to plan: start, stop or
+	// delete. Defaults to start.
+	Transition codersdk.WorkspaceTransition `yaml:"transition"`
+	Workspace  struct {
+		Name        string   `yaml:"name"`
+		OwnerName   string   `yaml:"owner_name"`
+		OwnerEmail  string   `yaml:"owner_email"`
+		OwnerGroups []string `yaml:"owner_groups"`
+	} `yaml:"workspace"`
+	// ExpectError makes the fixture pass only when the plan fails with an
+	// error that contains it. Fixtures that expect an error have no golden
+	// file.
+	ExpectError string `yaml:"expect_error"`
+}
+
+// templateTestResult is the golden output of a fixture. It only holds what
+// is deterministic across plans, so it omits IDs and agent tokens.
+type templateTestResult struct {
+	WorkspaceTags         map[string]string       `json:"workspace_tags,omitempty"`
+	Parameters            []templateTestParameter `json:"parameters"`
+	Presets               []templateTestPreset    `json:"presets"`
+	Resources             []templateTestResource  `json:"resources"`
+	ExternalAuthProviders []string                `json:"external_auth_providers"`
+}
+
+type templateTestParameter struct {
+	Name                string                        `json:"name"`
+	DisplayName         string                        `json:"display_name,omitempty"`
+	Type                string                        `json:"type"`
+	FormType            string                        `json:"form_type"`
+	DefaultValue        string                        `json:"default_value"`
+	Required            bool                          `json:"required"`
+	Mutable             bool                          `json:"mutable"`
+	Ephemeral           bool                          `json:"ephemeral"`
+	Options             []templateTestParameterOption `json:"options,omitempty"`
+	ValidationRegex     string                        `json:"validation_regex,omitempty"`
+	ValidationMin       *int32                        `json:"validation_min,omitempty"`
+	ValidationMax       *int32                        `json:"validation_max,omitempty"`
+	ValidationMonotonic string                        `json:"validation_monotonic,omitempty"`
+}
+
+type templateTestParameterOption struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type templateTestPreset struct {
+	Name                string            `json:"name"`
+	Default             bool              `json:"default"`
+	Parameters          map[string]string `json:"parameters"`
+	PrebuiltInstances   int32             `json:"prebuilt_instances,omitempty"`
+	PrebuiltSchedulesTZ string            `json:"prebuilt_schedules_timezone,omitempty"`
+}
+
+type templateTestResource struct {
+	Type         string                         `json:"type"`
+	Name         string                         `json:"name"`
+	ModulePath   string                         `json:"module_path,omitempty"`
+	Hide         bool                           `json:"hide"`
+	Icon         string                         `json:"icon,omitempty"`
+	InstanceType string                         `json:"instance_type,omitempty"`
+	DailyCost    int32                          `json:"daily_cost"`
+	Metadata     []templateTestResourceMetadata `json:"metadata,omitempty"`
+	Agents       []templateTestAgent            `json:"agents,omitempty"`
+}
+
+type templateTestResourceMetadata struct {
+	Key       string `json:"key"`
+	Value     string `json:"value"`
+	Sensitive bool   `json:"sensitive"`
+}
+
+type templateTestAgent struct {
+	Name            string                      `json:"name"`
+	Auth            string                      `json:"auth"`
+	OperatingSystem string                      `json:"operating_system"`
+	Architecture    string                      `json:"architecture"`
+	Directory       string                      `json:"directory,omitempty"`
+	Env             map[string]string           `json:"env,omitempty"`
+	DisplayApps     *templateTestDisplayApps    `json:"display_apps,omitempty"`
+	Metadata        []templateTestAgentMetadata `json:"metadata,omitempty"`
+	Scripts         []templateTestScript        `json:"scripts,omitempty"`
+	Apps            []templateTestApp           `json:"apps,omitempty"`
+}
+
+type templateTestDisplayApps struct {
+	VSCode               bool `json:"vscode"`
+	VSCodeInsiders       bool `json:"vscode_insiders"`
+	WebTerminal          bool `json:"web_terminal"`
+	SSHHelper            bool `json:"ssh_helper"`
+	PortForwardingHelper bool `json:"port_forwarding_helper"`
+}
+
+type templateTestAgentMetadata struct {
+	Key         string `json:"key"`
+	DisplayName string `json:"display_name"`
+	Script      string `json:"script"`
+	Interval    int64  `json:"interval"`
+	Timeout     int64  `json:"timeout"`
+}
+
+type templateTestScript struct {
+	DisplayName      string `json:"display_name"`
+	Cron             string `json:"cron,omitempty"`
+	RunOnStart       bool   `json:"run_on_start"`
+	RunOnStop        bool   `json:"run_on_stop"`
+	StartBlocksLogin bool   `json:"start_blocks_login"`
+	TimeoutSeconds   int32  `json:"timeout_seconds"`
+}
+
+type templateTestApp struct {
+	Slug         string `json:"slug"`
+	DisplayName  string `json:"display_name"`
+	Command      string `json:"command,omitempty"`
+	URL          string `json:"url,omitempty"`
+	Icon         string `json:"icon,omitempty"`
+	Subdomain    bool   `json:"subdomain"`
+	SharingLevel string `json:"sharing_level"`
+	External     bool   `json:"external"`
+	Hidden       bool   `json:"hidden"`
+	OpenIn       string `json:"open_in"`
+	Group        string `json:"group,omitempty"`
+	Healthcheck  string `json:"healthcheck,omitempty"`
+}
+
+func (*RootCmd) templateTest() *serpent.Command {
+	var (
+		directory    string
+		fixturesDir  string
+		provisioner  string
+		cacheDir     string
+		updateGolden bool
+	)
+	cmd := &serpent.Command{
+		Use:   "test",
+		Short: "Test a template against fixtures of parameters without pushing it",
+		Long: "Runs parse and plan of the template in the current directory for each fixture in the fixtures " +
+			"directory, and compares the planned agents, apps, parameters, presets and metadata to the golden " +
+			"file of the fixture. Exits non-zero when any fixture fails.\n" + FormatExamples(
+			Example{
+				Description: "Test the template in the current directory",
+				Command:     "coder templates test",
+			},
+			Example{
+				Description: "Update the golden files after an intended change to the template",
+				Command:     "coder templates test --update-golden",
+			},
+		),
+		Middleware: serpent.Chain(
+			serpent.RequireNArgs(0),
+		),
+		Handler: func(inv *serpent.Invocation) error {
+			ctx, cancel := context.WithCancel(inv.Context())
+			defer cancel()
+
+			if fixturesDir == "" {
+				fixturesDir = filepath.Join(directory, ".coder", "tests")
+			}
+			fixtures, err := readTemplateTestFixtures(fixturesDir)
+			if err != nil {
+				return err
+			}
+			if len(fixtures) == 0 {
+				return xerrors.Errorf("no fixtures found in %q, add a <name>.yaml file for each set of inputs to test", fixturesDir)
+			}
+
+			var archive bytes.Buffer
+			err = provisionersdk.Tar(&archive, inv.Logger, directory, provisionersdk.TemplateArchiveLimit)
+			if err != nil {
+				return xerrors.Errorf("archive template: %w", err)
+			}
+			absDirectory, err := filepath.Abs(directory)
+			if err != nil {
+				return err
+			}
+
+			workDir, err := os.MkdirTemp("", "coder-templates-test")
+			if err != nil {
+				return err
+			}
+			defer os.RemoveAll(workDir)
+
+			client, err := serveTemplateTestProvisioner(ctx, inv, codersdk.ProvisionerType(provisioner), workDir, filepath.Join(cacheDir, "templates-test", provisioner))
+			if err != nil {
+				return err
+			}
+
+			var failed int
+			for _, name := range fixtures.names() {
+				goldenPath := filepath.Join(fixturesDir, name+".golden")
+				status, err := checkTemplateTestFixture(ctx, inv, client, archive.Bytes(), filepath.Base(absDirectory), fixtures[name], goldenPath, updateGolden)
+				switch {
+				case err != nil:
+					failed++
+					_, _ = fmt.Fprintf(inv.Stdout, "%s %s\n%s\n", pretty.Sprint(cliui.DefaultStyles.Error, "FAIL"), name, indentTemplateTestOutput(err.Error()))
+				case status == templateTestUpdated:
+					_, _ = fmt.Fprintf(inv.Stdout, "%s %s\n", pretty.Sprint(cliui.DefaultStyles.Warn, "UPDATED"), name)
+				default:
+					_, _ = fmt.Fprintf(inv.Stdout, "%s %s\n", pretty.Sprint(cliui.DefaultStyles.Keyword, "PASS"), name)
+				}
+			}
+
+			if failed > 0 {
+				return xerrors.Errorf("%d of %d fixtures failed", failed, len(fixtures))
+			}
+			_, _ = fmt.Fprintf(inv.Stdout, "\nAll %d fixtures passed.\n", len(fixtures))
+			return nil
+		},
+	}
+
+	cmd.Options = serpent.OptionSet{
+		{
+			Flag:          "directory",
+			FlagShorthand: "d",
+			Description:   "Specify the directory of the template to test.",
+			Default:       ".",
+			Value:         serpent.StringOf(&directory),
+		},
+		{
+			Flag:        "fixtures-dir",
+			Description: "Specify the directory of the fixture files. Defaults to .coder/tests in the template directory, which is not pushed with the template.",
+			Value:       serpent.StringOf(&fixturesDir),
+		},
+		{
+			Flag:        "provisioner",
+			Description: "The provisioner to plan the template with. The echo provisioner replays the mocked responses in the template directory instead of running Terraform.",
+			Default:     string(codersdk.ProvisionerTypeTerraform),
+			Value: serpent.EnumOf(&provisioner,
+				string(codersdk.ProvisionerTypeTerraform),
+				string(codersdk.ProvisionerTypeTofu),
+				string(codersdk.ProvisionerTypeEcho),
+			),
+		},
+		{
+			Flag:        "update-golden",
+			Description: "Write the results of the fixtures to their golden files instead of comparing them.",
+			Value:       serpent.BoolOf(&updateGolden),
+		},
+		{
+			Flag:          "cache-dir",
+			FlagShorthand: "c",
+			Env:           "CODER_CACHE_DIRECTORY",
+			Description:   "Directory to store cached providers and binaries in.",
+			Default:       codersdk.DefaultCacheDir(),
+			Value:         serpent.StringOf(&cacheDir),
+		},
+	}
+
+	return cmd
+}
+
+type templateTestFixtures map[string]templateTestFixture
+
+func (f templateTestFixtures) names() []string {
+	names := make([]string, 0, len(f))
+	for name := range f {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+type templateTestStatus int
+
+const (
+	templateTestPassed templateTestStatus = iota
+	templateTestUpdated
+)
+
+// checkTemplateTestFixture runs a fixture and compares its result to the
+// golden file, or writes the golden file if update is set. The returned
+// error describes why the fixture failed.
+func checkTemplateTestFixture(ctx context.Context, inv *serpent.Invocation, client sdkproto.DRPCProvisionerClient, archive []byte, templateName string, fixture templateTestFixture, goldenPath string, update bool) (templateTestStatus, error) {
+	result, err := runTemplateTestFixture(ctx, client, archive, templateName, fixture)
+	if fixture.ExpectError != "" {
+		switch {
+		case err == nil:
+			return 0, xerrors.Errorf("expected an error containing %q, but the plan succeeded", fixture.ExpectError)
+		case !strings.Contains(err.Error(), fixture.ExpectError):
+			return 0, xerrors.Errorf("expected an error containing %q: %w", fixture.ExpectError, err)
+		}
+		return templateTestPassed, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	got, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return 0, xerrors.Errorf("marshal result: %w", err)
+	}
+	got = append(got, '\n')
+
+	if update {
+		err = os.WriteFile(goldenPath, got, 0o600)
+		if err != nil {
+			return 0, xerrors.Errorf("write golden file: %w", err)
+		}
+		return templateTestUpdated, nil
+	}
+
+	want, err := os.ReadFile(goldenPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, xerrors.Errorf("golden file %s does not exist, run with --update-golden to create it", goldenPath)
+	}
+	if err != nil {
+		return 0, xerrors.Errorf("read golden file: %w", err)
+	}
+	if !bytes.Equal(want, got) {
+		diff, err := diffBytes(goldenPath, want, got, isTTYOut(inv))
+		if err != nil {
+			return 0, xerrors.Errorf("diff golden file: %w", err)
+		}
+		return 0, xerrors.Errorf("result does not match the golden file:\n%s", diff)
+	}
+	return templateTestPassed, nil
+}
+
+func readTemplateTestFixtures(dir string) (templateTestFixtures, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, xerrors.Errorf("read fixtures directory: %w", err)
+	}
+	fixtures := templateTestFixtures{}
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, xerrors.Errorf("read fixture: %w", err)
+		}
+		var fixture templateTestFixture
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(&fixture)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, xerrors.Errorf("parse fixture %q: %w", entry.Name(), err)
+		}
+		switch fixture.Transition {
+		case "", codersdk.WorkspaceTransitionStart, codersdk.WorkspaceTransitionStop, codersdk.WorkspaceTransitionDelete:
+		default:
+			return nil, xerrors.Errorf("fixture %q has invalid transition %q", entry.Name(), fixture.Transition)
+		}
+		fixtures[strings.TrimSuffix(entry.Name(), ext)] = fixture
+	}
+	return fixtures, nil
+}
+
+// serveTemplateTestProvisioner serves a provisioner in-process until the
+// context is canceled.
+func serveTemplateTestProvisioner(ctx context.Context, inv *serpent.Invocation, provisioner codersdk.ProvisionerType, workDir, cachePath string) (sdkproto.DRPCProvisionerClient, error) {
+	client, server := drpcsdk.MemTransportPipe()
+	go func() {
+		<-ctx.Done()
+		_ = client.Close()
+		_ = server.Close()
+	}()
+
+	serveOptions := &provisionersdk.ServeOptions{
+		Listener:      server,
+		Logger:        inv.Logger.Named(string(provisioner)),
+		WorkDirectory: workDir,
+	}
+	var serve func() error
+	switch provisioner {
+	case codersdk.ProvisionerTypeEcho:
+		serve = func() error {
+			return echo.Serve(ctx, serveOptions)
+		}
+	case codersdk.ProvisionerTypeTerraform, codersdk.ProvisionerTypeTofu:
+		err := os.MkdirAll(cachePath, 0o700)
+		if err != nil {
+			return nil, xerrors.Errorf("mkdir %q: %w", cachePath, err)
+		}
+		serve = func() error {
+			return terraform.Serve(ctx, &terraform.ServeOptions{
+				ServeOptions: serveOptions,
+				Distribution: terraform.Distribution(provisioner),
+				CachePath:    cachePath,
+			})
+		}
+	default:
+		return nil, xerrors.Errorf("unsupported provisioner %q", provisioner)
+	}
+	go func() {
+		err := serve()
+		if err != nil && !xerrors.Is(err, context.Canceled) {
+			cliui.Errorf(inv.Stderr, "Provisioner exited: %s\n", err)
+		}
+	}()
+	return sdkproto.NewDRPCProvisionerClient(client), nil
+}
+
+// runTemplateTestFixture parses and plans the template archive with the
+// inputs of a fixture in a new provisioner session.
+func runTemplateTestFixture(ctx context.Context, client sdkproto.DRPCProvisionerClient, archive []byte, templateName string, fixture templateTestFixture) (*templateTestResult, error) {
+	sess, err := client.Session(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("start provisioner session: %w", err)
+	}
+	defer sess.Close()
+
+	err = sess.Send(&sdkproto.Request{Type: &sdkproto.Request_Config{Config: &sdkproto.Config{
+		TemplateSourceArchive: archive,
+	}}})
+	if err != nil {
+		return nil, xerrors.Errorf("send config: %w", err)
+	}
+
+	err = sess.Send(&sdkproto.Request{Type: &sdkproto.Request_Parse{Parse: &sdkproto.ParseRequest{}}})
+	if err != nil {
+		return nil, xerrors.Errorf("send parse: %w", err)
+	}
+	var parse *sdkproto.ParseComplete
+	for parse == nil {
+		msg, err := sess.Recv()
+		if err != nil {
+			return nil, xerrors.Errorf("receive parse: %w", err)
+		}
+		parse = msg.GetParse()
+	}
+	if parse.Error != "" {
+		return nil, xerrors.Errorf("parse: %s", parse.Error)
+	}
+
+	variableValues := make([]*sdkproto.VariableValue, 0, len(parse.TemplateVariables))
+	for _, variable := range parse.TemplateVariables {
+		value, ok := fixture.Variables[variable.Name]
+		if !ok {
+			if variable.Required {
+				return nil, xerrors.Errorf("variable %q is required", variable.Name)
+			}
+			value = variable.DefaultValue
+		}
+		variableValues = append(variableValues, &sdkproto.VariableValue{
+			Name:      variable.Name,
+			Value:     value,
+			Sensitive: variable.Sensitive,
+		})
+	}
+	for name := range fixture.Variables {
+		if !slices.ContainsFunc(parse.TemplateVariables, func(v *sdkproto.TemplateVariable) bool { return v.Name == name }) {
+			return nil, xerrors.Errorf("variable %q is not defined by the template", name)
+		}
+	}
+
+	parameterValues := make([]*sdkproto.RichParameterValue, 0, len(fixture.Parameters))
+	for name, value := range fixture.Parameters {
+		parameterValues = append(parameterValues, &sdkproto.RichParameterValue{Name: name, Value: value})
+	}
+	sort.Slice(parameterValues, func(i, j int) bool { return parameterValues[i].Name < parameterValues[j].Name })
+
+	transition := sdkproto.WorkspaceTransition_START
+	switch fixture.Transition {
+	case codersdk.WorkspaceTransitionStop:
+		transition = sdkproto.WorkspaceTransition_STOP
+	case codersdk.WorkspaceTransitionDelete:
+		transition = sdkproto.WorkspaceTransition_DESTROY
+	}
+	metadata := &sdkproto.Metadata{
+		CoderUrl:             "https://coder.example.com",
+		WorkspaceTransition:  transition,
+		WorkspaceName:        valueOrDefault(fixture.Workspace.Name, "test"),
+		WorkspaceId:          uuid.Nil.String(),
+		WorkspaceOwner:       valueOrDefault(fixture.Workspace.OwnerName, "test-user"),
+		WorkspaceOwnerId:     uuid.Nil.String(),
+		WorkspaceOwnerEmail:  valueOrDefault(fixture.Workspace.OwnerEmail, "test-user@example.com"),
+		WorkspaceOwnerGroups: fixture.Workspace.OwnerGroups,
+		WorkspaceBuildId:     uuid.Nil.String(),
+		TemplateId:           uuid.Nil.String(),
+		TemplateName:         templateName,
+		TemplateVersion:      "test",
+	}
+
+	err = sess.Send(&sdkproto.Request{Type: &sdkproto.Request_Plan{Plan: &sdkproto.PlanRequest{
+		Metadata:            metadata,
+		RichParameterValues: parameterValues,
+		VariableValues:      variableValues,
+		OmitModuleFiles:     true,
+	}}})
+	if err != nil {
+		return nil, xerrors.Errorf("send plan: %w", err)
+	}
+	var plan *sdkproto.PlanComplete
+	for plan == nil {
+		msg, err := sess.Recv()
+		if err != nil {
+			return nil, xerrors.Errorf("receive plan: %w", err)
+		}
+		plan = msg.GetPlan()
+	}
+	if plan.Error != "" {
+		return nil, xerrors.Errorf("plan: %s", plan.Error)
+	}
+	for _, value := range parameterValues {
+		if !slices.ContainsFunc(plan.Parameters, func(p *sdkproto.RichParameter) bool { return p.Name == value.Name }) {
+			return nil, xerrors.Errorf("parameter %q is not defined by the template", value.Name)
+		}
+	}
+
+	return convertTemplateTestResult(parse, plan), nil
+}
+
+func valueOrDefault(value, def string) string {
+	if value == "" {
+		return def
+	}
+	return value
+}
+
+func convertTemplateTestResult(parse *sdkproto.ParseComplete, plan *sdkproto.PlanComplete) *templateTestResult {
+	result := &templateTestResult{
+		WorkspaceTags:         parse.WorkspaceTags,
+		Parameters:            []templateTestParameter{},
+		Presets:               []templateTestPreset{},
+		Resources:             []templateTestResource{},
+		ExternalAuthProviders: []string{},
+	}
+
+	parameters := slices.Clone(plan.Parameters)
+	sort.SliceStable(parameters, func(i, j int) bool { return parameters[i].Order < parameters[j].Order })
+	for _, p := range parameters {
+		parameter := templateTestParameter{
+			Name:                p.Name,
+			DisplayName:         p.DisplayName,
+			Type:                p.Type,
+			FormType:            strings.ToLower(p.FormType.String()),
+			DefaultValue:        p.DefaultValue,
+			Required:            p.Required,
+			Mutable:             p.Mutable,
+			Ephemeral:           p.Ephemeral,
+			ValidationRegex:     p.ValidationRegex,
+			ValidationMin:       p.ValidationMin,
+			ValidationMax:       p.ValidationMax,
+			ValidationMonotonic: p.ValidationMonotonic,
+		}
+		for _, o := range p.Options {
+			parameter.Options = append(parameter.Options, templateTestParameterOption{Name: o.Name, Value: o.Value})
+		}
+		result.Parameters = append(result.Parameters, parameter)
+	}
+
+	for _, p := range plan.Presets {
+		preset := templateTestPreset{
+			Name:       p.Name,
+			Default:    p.Default,
+			Parameters: map[string]string{},
+		}
+		for _, v := range p.Parameters {
+			preset.Parameters[v.Name] = v.Value
+		}
+		if p.Prebuild != nil {
+			preset.PrebuiltInstances = p.Prebuild.Instances
+			preset.PrebuiltSchedulesTZ = p.Prebuild.GetScheduling().GetTimezone()
+		}
+		result.Presets = append(result.Presets, preset)
+	}
+	sort.Slice(result.Presets, func(i, j int) bool { return result.Presets[i].Name < result.Presets[j].Name })
+
+	for _, r := range plan.Resources {
+		resource := templateTestResource{
+			Type:         r.Type,
+			Name:         r.Name,
+			ModulePath:   r.ModulePath,
+			Hide:         r.Hide,
+			Icon:         r.Icon,
+			InstanceType: r.InstanceType,
+			DailyCost:    r.DailyCost,
+		}
+		for _, m := range r.Metadata {
+			value := m.Value
+			if m.Sensitive {
+				// Sensitive values must not end up in golden files, which are
+				// usually committed next to the template.
+				value = "(sensitive)"
+			}
+			resource.Metadata = append(resource.Metadata, templateTestResourceMetadata{Key: m.Key, Value: value, Sensitive: m.Sensitive})
+		}
+		for _, a := range r.Agents {
+			resource.Agents = append(resource.Agents, convertTemplateTestAgent(a))
+		}
+		sort.Slice(resource.Agents, func(i, j int) bool { return resource.Agents[i].Name < resource.Agents[j].Name })
+		result.Resources = append(result.Resources, resource)
+	}
+	sort.SliceStable(result.Resources, func(i, j int) bool {
+		a, b := result.Resources[i], result.Resources[j]
+		if a.ModulePath != b.ModulePath {
+			return a.ModulePath < b.ModulePath
+		}
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		return a.Name < b.Name
+	})
+
+	for _, p := range plan.ExternalAuthProviders {
+		result.ExternalAuthProviders = append(result.ExternalAuthProviders, p.Id)
+	}
+	slices.Sort(result.ExternalAuthProviders)
+
+	return result
+}
+
+func convertTemplateTestAgent(a *sdkproto.Agent) templateTestAgent {
+	agent := templateTestAgent{
+		Name:            a.Name,
+		Auth:            "token",
+		OperatingSystem: a.OperatingSystem,
+		Architecture:    a.Architecture,
+		Directory:       a.Directory,
+		Env:             a.Env,
+	}
+	if _, ok := a.Auth.(*sdkproto.Agent_InstanceId); ok {
+		agent.Auth = "instance_id"
+	}
+	if d := a.DisplayApps; d != nil {
+		agent.DisplayApps = &templateTestDisplayApps{
+			VSCode:               d.Vscode,
+			VSCodeInsiders:       d.VscodeInsiders,
+			WebTerminal:          d.WebTerminal,
+			SSHHelper:            d.SshHelper,
+			PortForwardingHelper: d.PortForwardingHelper,
+		}
+	}
+	for _, m := range a.Metadata {
+		agent.Metadata = append(agent.Metadata, templateTestAgentMetadata{
+			Key:         m.Key,
+			DisplayName: m.DisplayName,
+			Script:      m.Script,
+			Interval:    m.Interval,
+			Timeout:     m.Timeout,
+		})
+	}
+	for _, s := range a.Scripts {
+		agent.Scripts = append(agent.Scripts, templateTestScript{
+			DisplayName:      s.DisplayName,
+			Cron:             s.Cron,
+			RunOnStart:       s.RunOnStart,
+			RunOnStop:        s.RunOnStop,
+			StartBlocksLogin: s.StartBlocksLogin,
+			TimeoutSeconds:   s.TimeoutSeconds,
+		})
+	}
+	sort.SliceStable(agent.Scripts, func(i, j int) bool { return agent.Scripts[i].DisplayName < agent.Scripts[j].DisplayName })
+	for _, app := range a.Apps {
+		converted := templateTestApp{
+			Slug:         app.Slug,
+			DisplayName:  app.DisplayName,
+			Command:      app.Command,
+			URL:          app.Url,
+			Icon:         app.Icon,
+			Subdomain:    app.Subdomain,
+			SharingLevel: strings.ToLower(app.SharingLevel.String()),
+			External:     app.External,
+			Hidden:       app.Hidden,
+			OpenIn:       strings.ToLower(app.OpenIn.String()),
+			Group:        app.Group,
+		}
+		if h := app.Healthcheck; h != nil && h.Url != "" {
+			converted.Healthcheck = fmt.Sprintf("%s every %ds, threshold %d", h.Url, h.Interval, h.Threshold)
+		}
+		agent.Apps = append(agent.Apps, converted)
+	}
+	sort.Slice(agent.Apps, func(i, j int) bool { return agent.Apps[i].Slug < agent.Apps[j].Slug })
+	return agent
+}
+
+func indentTemplateTestOutput(s string) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = "    " + line
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,21 @@
+//go:build slim
+
+package cli
+
+import "github.com/coder/serpent"
+
+func (*RootCmd) templateTest() *serpent.Command {
+	root := &serpent.Command{
+		Use:   "test",
+		Short: "Test a template against fixtures of parameters without pushing it",
+		// We accept RawArgs so all commands and flags are accepted.
+		RawArgs: true,
+		Hidden:  true,
+		Handler: func(inv *serpent.Invocation) error {
+			SlimUnsupported(inv.Stderr, "templates test")
+			return nil
+		},
+	}
+
+	return root
+}
@@ -0,0 +1,94 @@
+package cli_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/cli/clitest"
+	"github.com/coder/coder/v2/provisioner/echo"
+	"github.com/coder/coder/v2/provisionersdk/proto"
+)
+
+func TestTemplateTest(t *testing.T) {
+	t.Parallel()
+
+	source := clitest.CreateTemplateVersionSource(t, &echo.Responses{
+		Parse: echo.ParseComplete,
+		ProvisionPlan: []*proto.Response{{
+			Type: &proto.Response_Plan{Plan: &proto.PlanComplete{
+				Parameters: []*proto.RichParameter{{
+					Name:         "region",
+					Type:         "string",
+					DefaultValue: "eu-west",
+					Mutable:      true,
+				}},
+				Presets: []*proto.Preset{{
+					Name:       "US",
+					Parameters: []*proto.PresetParameter{{Name: "region", Value: "us-east"}},
+				}},
+				Resources: []*proto.Resource{{
+					Name: "dev",
+					Type: "docker_container",
+					Agents: []*proto.Agent{{
+						Id:              "3f6a2b7e-6a3c-4b7e-9d2a-0c6f1d8e4a11",
+						Name:            "main",
+						OperatingSystem: "linux",
+						Architecture:    "amd64",
+						Auth:            &proto.Agent_Token{Token: "secret"},
+						Apps: []*proto.App{{
+							Slug:        "code-server",
+							DisplayName: "code-server",
+							Url:         "http://localhost:8080",
+						}},
+					}},
+				}},
+			}},
+		}},
+	})
+	fixtures := filepath.Join(source, ".coder", "tests")
+	require.NoError(t, os.MkdirAll(fixtures, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(fixtures, "us.yaml"), []byte("parameters:\n  region: us-east\n"), 0o600))
+
+	run := func(t *testing.T, args ...string) (string, error) {
+		inv, _ := clitest.New(t, append([]string{"templates", "test", "--directory", source, "--provisioner", "echo"}, args...)...)
+		var out bytes.Buffer
+		inv.Stdout = &out
+		err := inv.Run()
+		return out.String(), err
+	}
+
+	// Without a golden file, the fixture fails.
+	out, err := run(t)
+	require.ErrorContains(t, err, "1 of 1 fixtures failed")
+	require.Contains(t, out, "run with --update-golden to create it")
+
+	out, err = run(t, "--update-golden")
+	require.NoError(t, err)
+	require.Contains(t, out, "UPDATED us")
+	golden, err := os.ReadFile(filepath.Join(fixtures, "us.golden"))
+	require.NoError(t, err)
+	require.Contains(t, string(golden), `"slug": "code-server"`)
+	require.NotContains(t, string(golden), "secret")
+	require.NotContains(t, string(golden), "3f6a2b7e")
+
+	out, err = run(t)
+	require.NoError(t, err)
+	require.Contains(t, out, "PASS us")
+
+	// A change to the result fails the fixture with a diff.
+	require.NoError(t, os.WriteFile(filepath.Join(fixtures, "us.golden"), bytes.Replace(golden, []byte("code-server"), []byte("vscode"), 1), 0o600))
+	out, err = run(t)
+	require.ErrorContains(t, err, "1 of 1 fixtures failed")
+	require.Contains(t, out, "FAIL us")
+	require.Contains(t, out, `"slug": "code-server"`)
+
+	// Parameters the template does not define fail the fixture.
+	require.NoError(t, os.WriteFile(filepath.Join(fixtures, "typo.yaml"), []byte("parameters:\n  regoin: us-east\nexpect_error: not defined by the template\n"), 0o600))
+	out, err = run(t, "--update-golden")
+	require.NoError(t, err)
+	require.Contains(t, out, "PASS typo")
+}
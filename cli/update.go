@@ -13,7 +13,7 @@ import (
 func (r *RootCmd) update() *serpent.Command {
 	var (
 		parameterFlags workspaceParameterFlags
-		bflags         buildFlags
+		bflags         = buildFlags{previewUpdate: true}
 	)
 	client := new(codersdk.Client)
 	cmd := &serpent.Command{
@@ -35,6 +35,13 @@ func (r *RootCmd) update() *serpent.Command {
 				return nil
 			}
 
+			// The update is previewed and confirmed against the current
+			// resources of the workspace, so prepare it before stopping.
+			req, err := buildWorkspaceStartRequest(inv, client, workspace, parameterFlags, bflags, WorkspaceUpdate)
+			if err != nil {
+				return err
+			}
+
 			// #17840: If the workspace is already running, we will stop it before
 			// updating. Simply performing a new start transition may not work if the
 			// template specifies ignore_changes.
@@ -49,7 +56,7 @@ func (r *RootCmd) update() *serpent.Command {
 				}
 			}
 
-			build, err := startWorkspace(inv, client, workspace, parameterFlags, bflags, WorkspaceUpdate)
+			build, err := createWorkspaceStartBuild(inv, client, workspace, req)
 			if err != nil {
 				return xerrors.Errorf("start workspace: %w", err)
 			}
@@ -72,5 +79,6 @@ func (r *RootCmd) update() *serpent.Command {
 
 	cmd.Options = append(cmd.Options, parameterFlags.allOptions()...)
 	cmd.Options = append(cmd.Options, bflags.cliOptions()...)
+	cmd.Options = append(cmd.Options, cliui.SkipPromptOption())
 	return cmd
 }
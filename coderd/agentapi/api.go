@@ -24,6 +24,7 @@ import (
 	"github.com/coder/coder/v2/coderd/database/pubsub"
 	"github.com/coder/coder/v2/coderd/externalauth"
 	"github.com/coder/coder/v2/coderd/notifications"
+	"github.com/coder/coder/v2/coderd/portsharing"
 	"github.com/coder/coder/v2/coderd/prometheusmetrics"
 	"github.com/coder/coder/v2/coderd/tracing"
 	"github.com/coder/coder/v2/coderd/workspacestats"
@@ -52,6 +53,7 @@ type API struct {
 	*ScriptsAPI
 	*AuditAPI
 	*SubAgentAPI
+	*PortSharesAPI
 	*tailnet.DRPCService
 
 	mu sync.Mutex
@@ -76,6 +78,7 @@ type Options struct {
 	TailnetCoordinator                *atomic.Pointer[tailnet.Coordinator]
 	StatsReporter                     *workspacestats.Reporter
 	AppearanceFetcher                 *atomic.Pointer[appearance.Fetcher]
+	PortSharer                        *atomic.Pointer[portsharing.PortSharer]
 	PublishWorkspaceUpdateFn          func(ctx context.Context, userID uuid.UUID, event wspubsub.WorkspaceEvent)
 	PublishWorkspaceAgentLogsUpdateFn func(ctx context.Context, workspaceAgentID uuid.UUID, msg agentsdk.LogsNotifyMessage)
 	NetworkTelemetryHandler           func(batch []*tailnetproto.TelemetryEvent)
@@ -205,6 +208,17 @@ func New(opts Options) *API {
 		Database:       opts.Database,
 	}
 
+	api.PortSharesAPI = &PortSharesAPI{
+		WorkspaceID:           opts.WorkspaceID,
+		AccessURL:             opts.AccessURL,
+		AppHostname:           opts.AppHostname,
+		AgentFn:               api.agent,
+		Log:                   opts.Log,
+		Database:              opts.Database,
+		PortSharer:            opts.PortSharer,
+		NotificationsEnqueuer: opts.NotificationsEnqueuer,
+	}
+
 	return api
 }
 
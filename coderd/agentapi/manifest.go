@@ -89,6 +89,16 @@ func (a *ManifestAPI) GetManifest(ctx context.Context, _ *agentproto.GetManifest
 		return nil, xerrors.Errorf("fetching workspace agent data: %w", err)
 	}
 
+	// Ports can only be shared through subdomain apps, so there is no point
+	// in sending port share rules when there is no app hostname.
+	var portShareRules []database.TemplatePortShareRule
+	if a.AppHostname != "" {
+		portShareRules, err = a.Database.GetTemplatePortShareRules(ctx, workspace.TemplateID)
+		if err != nil {
+			return nil, xerrors.Errorf("fetching template port share rules: %w", err)
+		}
+	}
+
 	appSlug := appurl.ApplicationURL{
 		AppSlugOrPort: "{{port}}",
 		AgentName:     workspaceAgent.Name,
@@ -135,11 +145,12 @@ func (a *ManifestAPI) GetManifest(ctx context.Context, _ *agentproto.GetManifest
 		DerpForceWebsockets:      a.DerpForceWebSockets,
 		ParentId:                 parentID,
 
-		DerpMap:       tailnet.DERPMapToProto(a.DerpMapFn()),
-		Scripts:       dbAgentScriptsToProto(scripts),
-		Apps:          apps,
-		Metadata:      dbAgentMetadataToProtoDescription(metadata),
-		Devcontainers: dbAgentDevcontainersToProto(devcontainers),
+		DerpMap:        tailnet.DERPMapToProto(a.DerpMapFn()),
+		Scripts:        dbAgentScriptsToProto(scripts),
+		Apps:           apps,
+		Metadata:       dbAgentMetadataToProtoDescription(metadata),
+		Devcontainers:  dbAgentDevcontainersToProto(devcontainers),
+		PortShareRules: dbPortShareRulesToProto(portShareRules),
 	}, nil
 }
 
@@ -252,3 +263,21 @@ func dbAgentDevcontainersToProto(devcontainers []database.WorkspaceAgentDevconta
 	}
 	return ret
 }
+
+func dbPortShareRulesToProto(rules []database.TemplatePortShareRule) []*agentproto.PortShareRule {
+	ret := make([]*agentproto.PortShareRule, len(rules))
+	for i, rule := range rules {
+		protocol := agentproto.PortShareRule_HTTP
+		if rule.Protocol == database.PortShareProtocolHttps {
+			protocol = agentproto.PortShareRule_HTTPS
+		}
+		ret[i] = &agentproto.PortShareRule{
+			PortStart:  rule.PortStart,
+			PortEnd:    rule.PortEnd,
+			ShareLevel: agentproto.WorkspaceApp_SharingLevel(agentproto.WorkspaceApp_SharingLevel_value[strings.ToUpper(string(rule.ShareLevel))]),
+			Protocol:   protocol,
+			Label:      rule.Label,
+		}
+	}
+	return ret
+}
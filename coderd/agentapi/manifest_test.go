@@ -50,6 +50,7 @@ func TestGetManifest(t *testing.T) {
 			OwnerID:       owner.ID,
 			OwnerUsername: owner.Username,
 			Name:          "cool-workspace",
+			TemplateID:    uuid.New(),
 		}
 		agent = database.WorkspaceAgent{
 			ID:   uuid.New(),
@@ -290,6 +291,40 @@ func TestGetManifest(t *testing.T) {
 				Timeout:     durationpb.New(time.Duration(metadata[1].Timeout)),
 			},
 		}
+		portShareRules = []database.TemplatePortShareRule{
+			{
+				ID:         uuid.New(),
+				TemplateID: workspace.TemplateID,
+				PortStart:  3000,
+				PortEnd:    3999,
+				ShareLevel: database.AppSharingLevelOrganization,
+				Protocol:   database.PortShareProtocolHttp,
+			},
+			{
+				ID:         uuid.New(),
+				TemplateID: workspace.TemplateID,
+				PortStart:  8080,
+				PortEnd:    8080,
+				ShareLevel: database.AppSharingLevelPublic,
+				Protocol:   database.PortShareProtocolHttps,
+				Label:      "docs",
+			},
+		}
+		protoPortShareRules = []*agentproto.PortShareRule{
+			{
+				PortStart:  3000,
+				PortEnd:    3999,
+				ShareLevel: agentproto.WorkspaceApp_ORGANIZATION,
+				Protocol:   agentproto.PortShareRule_HTTP,
+			},
+			{
+				PortStart:  8080,
+				PortEnd:    8080,
+				ShareLevel: agentproto.WorkspaceApp_PUBLIC,
+				Protocol:   agentproto.PortShareRule_HTTPS,
+				Label:      "docs",
+			},
+		}
 		protoDevcontainers = []*agentproto.WorkspaceAgentDevcontainer{
 			{
 				Id:              devcontainers[0].ID[:],
@@ -337,6 +372,7 @@ func TestGetManifest(t *testing.T) {
 		}).Return(metadata, nil)
 		mDB.EXPECT().GetWorkspaceAgentDevcontainersByAgentID(gomock.Any(), agent.ID).Return(devcontainers, nil)
 		mDB.EXPECT().GetWorkspaceByID(gomock.Any(), workspace.ID).Return(workspace, nil)
+		mDB.EXPECT().GetTemplatePortShareRules(gomock.Any(), workspace.TemplateID).Return(portShareRules, nil)
 
 		got, err := api.GetManifest(context.Background(), &agentproto.GetManifestRequest{})
 		require.NoError(t, err)
@@ -358,11 +394,12 @@ func TestGetManifest(t *testing.T) {
 			// tailnet.DERPMapToProto() is extensively tested elsewhere, so it's
 			// not necessary to manually recreate a big DERP map here like we
 			// did for apps and metadata.
-			DerpMap:       tailnet.DERPMapToProto(derpMapFn()),
-			Scripts:       protoScripts,
-			Apps:          protoApps,
-			Metadata:      protoMetadata,
-			Devcontainers: protoDevcontainers,
+			DerpMap:        tailnet.DERPMapToProto(derpMapFn()),
+			Scripts:        protoScripts,
+			Apps:           protoApps,
+			Metadata:       protoMetadata,
+			Devcontainers:  protoDevcontainers,
+			PortShareRules: protoPortShareRules,
 		}
 
 		// Log got and expected with spew.
@@ -404,6 +441,7 @@ func TestGetManifest(t *testing.T) {
 		}).Return([]database.WorkspaceAgentMetadatum{}, nil)
 		mDB.EXPECT().GetWorkspaceAgentDevcontainersByAgentID(gomock.Any(), childAgent.ID).Return([]database.WorkspaceAgentDevcontainer{}, nil)
 		mDB.EXPECT().GetWorkspaceByID(gomock.Any(), workspace.ID).Return(workspace, nil)
+		mDB.EXPECT().GetTemplatePortShareRules(gomock.Any(), workspace.TemplateID).Return([]database.TemplatePortShareRule{}, nil)
 
 		got, err := api.GetManifest(context.Background(), &agentproto.GetManifestRequest{})
 		require.NoError(t, err)
@@ -425,11 +463,12 @@ func TestGetManifest(t *testing.T) {
 			// tailnet.DERPMapToProto() is extensively tested elsewhere, so it's
 			// not necessary to manually recreate a big DERP map here like we
 			// did for apps and metadata.
-			DerpMap:       tailnet.DERPMapToProto(derpMapFn()),
-			Scripts:       []*agentproto.WorkspaceAgentScript{},
-			Apps:          []*agentproto.WorkspaceApp{},
-			Metadata:      []*agentproto.WorkspaceAgentMetadata_Description{},
-			Devcontainers: []*agentproto.WorkspaceAgentDevcontainer{},
+			DerpMap:        tailnet.DERPMapToProto(derpMapFn()),
+			Scripts:        []*agentproto.WorkspaceAgentScript{},
+			Apps:           []*agentproto.WorkspaceApp{},
+			Metadata:       []*agentproto.WorkspaceAgentMetadata_Description{},
+			Devcontainers:  []*agentproto.WorkspaceAgentDevcontainer{},
+			PortShareRules: []*agentproto.PortShareRule{},
 		}
 
 		require.Equal(t, expected, got)
@@ -487,11 +526,12 @@ func TestGetManifest(t *testing.T) {
 			// tailnet.DERPMapToProto() is extensively tested elsewhere, so it's
 			// not necessary to manually recreate a big DERP map here like we
 			// did for apps and metadata.
-			DerpMap:       tailnet.DERPMapToProto(derpMapFn()),
-			Scripts:       protoScripts,
-			Apps:          protoApps,
-			Metadata:      protoMetadata,
-			Devcontainers: protoDevcontainers,
+			DerpMap:        tailnet.DERPMapToProto(derpMapFn()),
+			Scripts:        protoScripts,
+			Apps:           protoApps,
+			Metadata:       protoMetadata,
+			Devcontainers:  protoDevcontainers,
+			PortShareRules: []*agentproto.PortShareRule{},
 		}
 
 		// Log got and expected with spew.
@@ -0,0 +1,191 @@
+package agentapi
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	agentproto "github.com/coder/coder/v2/agent/proto"
+	"github.com/coder/coder/v2/coderd/database"
+	"github.com/coder/coder/v2/coderd/database/dbauthz"
+	"github.com/coder/coder/v2/coderd/notifications"
+	"github.com/coder/coder/v2/coderd/portsharing"
+	"github.com/coder/coder/v2/coderd/workspaceapps/appurl"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+// PortSharesAPI reconciles the port shares created automatically by template
+// port share rules with the ports the agent reports as matching those rules.
+type PortSharesAPI struct {
+	WorkspaceID uuid.UUID
+	AccessURL   *url.URL
+	AppHostname string
+	AgentFn     func(context.Context) (database.WorkspaceAgent, error)
+
+	Log                   slog.Logger
+	Database              database.Store
+	PortSharer            *atomic.Pointer[portsharing.PortSharer]
+	NotificationsEnqueuer notifications.Enqueuer
+}
+
+func (a *PortSharesAPI) UpdateAutoPortShares(ctx context.Context, req *agentproto.UpdateAutoPortSharesRequest) (*agentproto.UpdateAutoPortSharesResponse, error) {
+	if a.AppHostname == "" {
+		// Ports can only be shared through subdomain apps.
+		return &agentproto.UpdateAutoPortSharesResponse{}, nil
+	}
+
+	workspaceAgent, err := a.AgentFn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	workspace, err := a.Database.GetWorkspaceByID(ctx, a.WorkspaceID)
+	if err != nil {
+		return nil, xerrors.Errorf("get workspace by id: %w", err)
+	}
+	template, err := a.Database.GetTemplateByID(ctx, workspace.TemplateID)
+	if err != nil {
+		return nil, xerrors.Errorf("get template by id: %w", err)
+	}
+	rules, err := a.Database.GetTemplatePortShareRules(ctx, template.ID)
+	if err != nil {
+		return nil, xerrors.Errorf("get template port share rules: %w", err)
+	}
+	shares, err := a.Database.ListWorkspaceAgentPortShares(ctx, workspace.ID)
+	if err != nil {
+		return nil, xerrors.Errorf("list workspace agent port shares: %w", err)
+	}
+
+	existing := make(map[int32]database.WorkspaceAgentPortShare)
+	for _, share := range shares {
+		if share.AgentName == workspaceAgent.Name {
+			existing[share.Port] = share
+		}
+	}
+
+	portSharer := portsharing.DefaultPortSharer
+	if a.PortSharer != nil {
+		portSharer = *a.PortSharer.Load()
+	}
+
+	desired := make(map[int32]struct{}, len(req.Shares))
+	for _, share := range req.Shares {
+		// The agent evaluates the rules, but we never trust it to pick the
+		// sharing level. Always use the rule stored for the template.
+		rule, ok := matchPortShareRule(rules, share.Port)
+		if !ok {
+			a.Log.Debug(ctx, "ignoring auto port share without matching rule", slog.F("port", share.Port))
+			continue
+		}
+		if err := portSharer.AuthorizedLevel(template, codersdk.WorkspaceAgentPortShareLevel(rule.ShareLevel)); err != nil {
+			a.Log.Debug(ctx, "ignoring auto port share above template max level",
+				slog.F("port", share.Port),
+				slog.F("share_level", rule.ShareLevel),
+				slog.Error(err),
+			)
+			continue
+		}
+		desired[share.Port] = struct{}{}
+
+		current, found := existing[share.Port]
+		if found && !current.AutoShared {
+			// Port shares created by the user always take precedence.
+			continue
+		}
+		if found && current.ShareLevel == rule.ShareLevel && current.Protocol == rule.Protocol && current.Label == rule.Label {
+			continue
+		}
+
+		_, err := a.Database.UpsertWorkspaceAgentPortShare(ctx, database.UpsertWorkspaceAgentPortShareParams{
+			WorkspaceID: workspace.ID,
+			AgentName:   workspaceAgent.Name,
+			Port:        share.Port,
+			ShareLevel:  rule.ShareLevel,
+			Protocol:    rule.Protocol,
+			AutoShared:  true,
+			Label:       rule.Label,
+		})
+		if err != nil {
+			return nil, xerrors.Errorf("upsert port share %d: %w", share.Port, err)
+		}
+		if found {
+			continue
+		}
+
+		if err := a.notifyPortShared(ctx, workspace, workspaceAgent, share.Port, rule); err != nil {
+			a.Log.Warn(ctx, "failed to notify owner of auto port share", slog.F("port", share.Port), slog.Error(err))
+		}
+	}
+
+	for port, share := range existing {
+		if !share.AutoShared {
+			continue
+		}
+		if _, ok := desired[port]; ok {
+			continue
+		}
+		err := a.Database.DeleteWorkspaceAgentPortShare(ctx, database.DeleteWorkspaceAgentPortShareParams{
+			WorkspaceID: workspace.ID,
+			AgentName:   workspaceAgent.Name,
+			Port:        port,
+		})
+		if err != nil {
+			return nil, xerrors.Errorf("delete port share %d: %w", port, err)
+		}
+	}
+
+	return &agentproto.UpdateAutoPortSharesResponse{}, nil
+}
+
+func (a *PortSharesAPI) notifyPortShared(ctx context.Context, workspace database.Workspace, workspaceAgent database.WorkspaceAgent, port int32, rule database.TemplatePortShareRule) error {
+	if a.NotificationsEnqueuer == nil {
+		return nil
+	}
+
+	slug := strconv.Itoa(int(port))
+	if rule.Protocol == database.PortShareProtocolHttps {
+		slug += "s"
+	}
+	portURL := vscodeProxyURI(appurl.ApplicationURL{
+		AppSlugOrPort: slug,
+		AgentName:     workspaceAgent.Name,
+		WorkspaceName: workspace.Name,
+		Username:      workspace.OwnerUsername,
+	}, a.AccessURL, a.AppHostname)
+
+	_, err := a.NotificationsEnqueuer.Enqueue(
+		// nolint:gocritic // We need to be able to send the notification.
+		dbauthz.AsNotifier(ctx),
+		workspace.OwnerID,
+		notifications.TemplateWorkspacePortShared,
+		map[string]string{
+			"workspace":   workspace.Name,
+			"agent":       workspaceAgent.Name,
+			"port":        strconv.Itoa(int(port)),
+			"share_level": string(rule.ShareLevel),
+			"label":       rule.Label,
+			"url":         portURL,
+		},
+		"agent-auto-port-share",
+		workspace.ID,
+		workspace.OwnerID,
+		workspace.OrganizationID,
+	)
+	if err != nil {
+		return xerrors.Errorf("enqueue notification: %w", err)
+	}
+	return nil
+}
+
+func matchPortShareRule(rules []database.TemplatePortShareRule, port int32) (database.TemplatePortShareRule, bool) {
+	for _, rule := range rules {
+		if port >= rule.PortStart && port <= rule.PortEnd {
+			return rule, true
+		}
+	}
+	return database.TemplatePortShareRule{}, false
+}
@@ -0,0 +1,242 @@
+package agentapi_test
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	agentproto "github.com/coder/coder/v2/agent/proto"
+	"github.com/coder/coder/v2/coderd/agentapi"
+	"github.com/coder/coder/v2/coderd/database"
+	"github.com/coder/coder/v2/coderd/database/dbgen"
+	"github.com/coder/coder/v2/coderd/database/dbtestutil"
+	"github.com/coder/coder/v2/coderd/notifications"
+	"github.com/coder/coder/v2/coderd/notifications/notificationstest"
+	"github.com/coder/coder/v2/testutil"
+)
+
+func TestUpdateAutoPortShares(t *testing.T) {
+	t.Parallel()
+
+	setup := func(t *testing.T) (*agentapi.PortSharesAPI, database.Store, database.WorkspaceTable, database.WorkspaceAgent, *notificationstest.FakeEnqueuer) {
+		t.Helper()
+
+		db, _ := dbtestutil.NewDB(t)
+		user := dbgen.User(t, db, database.User{})
+		org := dbgen.Organization(t, db, database.Organization{})
+		template := dbgen.Template(t, db, database.Template{
+			OrganizationID: org.ID,
+			CreatedBy:      user.ID,
+		})
+		templateVersion := dbgen.TemplateVersion(t, db, database.TemplateVersion{
+			TemplateID:     uuid.NullUUID{Valid: true, UUID: template.ID},
+			OrganizationID: org.ID,
+			CreatedBy:      user.ID,
+		})
+		workspace := dbgen.Workspace(t, db, database.WorkspaceTable{
+			OrganizationID: org.ID,
+			TemplateID:     template.ID,
+			OwnerID:        user.ID,
+		})
+		job := dbgen.ProvisionerJob(t, db, nil, database.ProvisionerJob{
+			Type: database.ProvisionerJobTypeWorkspaceBuild,
+		})
+		build := dbgen.WorkspaceBuild(t, db, database.WorkspaceBuild{
+			JobID:             job.ID,
+			WorkspaceID:       workspace.ID,
+			TemplateVersionID: templateVersion.ID,
+		})
+		resource := dbgen.WorkspaceResource(t, db, database.WorkspaceResource{
+			JobID: build.JobID,
+		})
+		agent := dbgen.WorkspaceAgent(t, db, database.WorkspaceAgent{
+			ResourceID: resource.ID,
+		})
+		_ = dbgen.TemplatePortShareRule(t, db, database.TemplatePortShareRule{
+			TemplateID: template.ID,
+			PortStart:  3000,
+			PortEnd:    3999,
+			ShareLevel: database.AppSharingLevelOrganization,
+		})
+		_ = dbgen.TemplatePortShareRule(t, db, database.TemplatePortShareRule{
+			TemplateID: template.ID,
+			PortStart:  8080,
+			PortEnd:    8080,
+			ShareLevel: database.AppSharingLevelPublic,
+			Protocol:   database.PortShareProtocolHttps,
+			Label:      "docs",
+		})
+
+		notifyEnq := &notificationstest.FakeEnqueuer{}
+		return &agentapi.PortSharesAPI{
+			WorkspaceID: workspace.ID,
+			AccessURL:   &url.URL{Scheme: "https", Host: "example.com"},
+			AppHostname: "*--apps.example.com",
+			AgentFn: func(context.Context) (database.WorkspaceAgent, error) {
+				return agent, nil
+			},
+			Log:                   testutil.Logger(t),
+			Database:              db,
+			NotificationsEnqueuer: notifyEnq,
+		}, db, workspace, agent, notifyEnq
+	}
+
+	t.Run("CreateAndNotify", func(t *testing.T) {
+		t.Parallel()
+
+		api, db, workspace, agent, notifyEnq := setup(t)
+		ctx := testutil.Context(t, testutil.WaitShort)
+
+		_, err := api.UpdateAutoPortShares(ctx, &agentproto.UpdateAutoPortSharesRequest{
+			Shares: []*agentproto.UpdateAutoPortSharesRequest_Share{
+				// The agent cannot escalate the sharing level, the rule wins.
+				{Port: 3000, ShareLevel: agentproto.WorkspaceApp_PUBLIC},
+				{Port: 8080, ShareLevel: agentproto.WorkspaceApp_PUBLIC, Protocol: agentproto.PortShareRule_HTTPS},
+				// No rule matches this port.
+				{Port: 5432, ShareLevel: agentproto.WorkspaceApp_PUBLIC},
+			},
+		})
+		require.NoError(t, err)
+
+		shares, err := db.ListWorkspaceAgentPortShares(ctx, workspace.ID)
+		require.NoError(t, err)
+		require.Len(t, shares, 2)
+		for _, share := range shares {
+			require.True(t, share.AutoShared)
+			switch share.Port {
+			case 3000:
+				require.Equal(t, database.AppSharingLevelOrganization, share.ShareLevel)
+				require.Empty(t, share.Label)
+			case 8080:
+				require.Equal(t, database.AppSharingLevelPublic, share.ShareLevel)
+				require.Equal(t, database.PortShareProtocolHttps, share.Protocol)
+				require.Equal(t, "docs", share.Label)
+			default:
+				t.Fatalf("unexpected port share %d", share.Port)
+			}
+		}
+
+		sent := notifyEnq.Sent(notificationstest.WithTemplateID(notifications.TemplateWorkspacePortShared))
+		require.Len(t, sent, 2)
+		for _, n := range sent {
+			require.Equal(t, workspace.OwnerID, n.UserID)
+			if n.Labels["port"] == "8080" {
+				require.Equal(t, "docs", n.Labels["label"])
+				require.True(t, strings.HasPrefix(n.Labels["url"], fmt.Sprintf("https://8080s--%s--%s--", agent.Name, workspace.Name)), n.Labels["url"])
+			}
+		}
+
+		// Reporting the same set again must not notify again.
+		notifyEnq.Clear()
+		_, err = api.UpdateAutoPortShares(ctx, &agentproto.UpdateAutoPortSharesRequest{
+			Shares: []*agentproto.UpdateAutoPortSharesRequest_Share{
+				{Port: 3000},
+				{Port: 8080},
+			},
+		})
+		require.NoError(t, err)
+		require.Empty(t, notifyEnq.Sent(notificationstest.WithTemplateID(notifications.TemplateWorkspacePortShared)))
+	})
+
+	t.Run("UpdateLabel", func(t *testing.T) {
+		t.Parallel()
+
+		api, db, workspace, agent, notifyEnq := setup(t)
+		ctx := testutil.Context(t, testutil.WaitShort)
+
+		// An auto share created before the rule had its current label.
+		_ = dbgen.WorkspaceAgentPortShare(t, db, database.WorkspaceAgentPortShare{
+			WorkspaceID: workspace.ID,
+			AgentName:   agent.Name,
+			Port:        8080,
+			ShareLevel:  database.AppSharingLevelPublic,
+			Protocol:    database.PortShareProtocolHttps,
+			AutoShared:  true,
+		})
+
+		_, err := api.UpdateAutoPortShares(ctx, &agentproto.UpdateAutoPortSharesRequest{
+			Shares: []*agentproto.UpdateAutoPortSharesRequest_Share{
+				{Port: 8080},
+			},
+		})
+		require.NoError(t, err)
+
+		share, err := db.GetWorkspaceAgentPortShare(ctx, database.GetWorkspaceAgentPortShareParams{
+			WorkspaceID: workspace.ID,
+			AgentName:   agent.Name,
+			Port:        8080,
+		})
+		require.NoError(t, err)
+		require.Equal(t, "docs", share.Label)
+		require.True(t, share.AutoShared)
+		// The port was already shared, so the owner is not notified again.
+		require.Empty(t, notifyEnq.Sent(notificationstest.WithTemplateID(notifications.TemplateWorkspacePortShared)))
+	})
+
+	t.Run("RulesRemoved", func(t *testing.T) {
+		t.Parallel()
+
+		api, db, workspace, _, _ := setup(t)
+		ctx := testutil.Context(t, testutil.WaitShort)
+
+		_, err := api.UpdateAutoPortShares(ctx, &agentproto.UpdateAutoPortSharesRequest{
+			Shares: []*agentproto.UpdateAutoPortSharesRequest_Share{
+				{Port: 3000},
+				{Port: 8080},
+			},
+		})
+		require.NoError(t, err)
+		shares, err := db.ListWorkspaceAgentPortShares(ctx, workspace.ID)
+		require.NoError(t, err)
+		require.Len(t, shares, 2)
+
+		// Once the template has no rules left, the agent reports an empty
+		// set and the shares it created must disappear.
+		err = db.DeleteTemplatePortShareRules(ctx, workspace.TemplateID)
+		require.NoError(t, err)
+		_, err = api.UpdateAutoPortShares(ctx, &agentproto.UpdateAutoPortSharesRequest{})
+		require.NoError(t, err)
+
+		shares, err = db.ListWorkspaceAgentPortShares(ctx, workspace.ID)
+		require.NoError(t, err)
+		require.Empty(t, shares)
+	})
+
+	t.Run("RemoveStale", func(t *testing.T) {
+		t.Parallel()
+
+		api, db, workspace, agent, _ := setup(t)
+		ctx := testutil.Context(t, testutil.WaitShort)
+
+		_ = dbgen.WorkspaceAgentPortShare(t, db, database.WorkspaceAgentPortShare{
+			WorkspaceID: workspace.ID,
+			AgentName:   agent.Name,
+			Port:        3001,
+			ShareLevel:  database.AppSharingLevelOrganization,
+			AutoShared:  true,
+		})
+		// Manual shares are never touched, even when a rule matches.
+		manual := dbgen.WorkspaceAgentPortShare(t, db, database.WorkspaceAgentPortShare{
+			WorkspaceID: workspace.ID,
+			AgentName:   agent.Name,
+			Port:        3002,
+			ShareLevel:  database.AppSharingLevelAuthenticated,
+		})
+
+		_, err := api.UpdateAutoPortShares(ctx, &agentproto.UpdateAutoPortSharesRequest{
+			Shares: []*agentproto.UpdateAutoPortSharesRequest_Share{
+				{Port: 3002},
+			},
+		})
+		require.NoError(t, err)
+
+		shares, err := db.ListWorkspaceAgentPortShares(ctx, workspace.ID)
+		require.NoError(t, err)
+		require.Equal(t, []database.WorkspaceAgentPortShare{manual}, shares)
+	})
+}
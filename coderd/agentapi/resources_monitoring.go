@@ -11,6 +11,7 @@ import (
 
 	"cdr.dev/slog"
 
+	"github.com/dustin/go-humanize"
 	"github.com/google/uuid"
 
 	"github.com/coder/coder/v2/agent/proto"
@@ -77,7 +78,7 @@ func (a *ResourcesMonitoringAPI) GetResourcesMonitoringConfiguration(ctx context
 func (a *ResourcesMonitoringAPI) PushResourcesMonitoringUsage(ctx context.Context, req *proto.PushResourcesMonitoringUsageRequest) (*proto.PushResourcesMonitoringUsageResponse, error) {
 	var err error
 
-	if memoryErr := a.monitorMemory(ctx, req.Datapoints); memoryErr != nil {
+	if memoryErr := a.monitorMemory(ctx, req.Datapoints, req.TopProcesses); memoryErr != nil {
 		err = errors.Join(err, xerrors.Errorf("monitor memory: %w", memoryErr))
 	}
 
@@ -88,7 +89,7 @@ func (a *ResourcesMonitoringAPI) PushResourcesMonitoringUsage(ctx context.Contex
 	return &proto.PushResourcesMonitoringUsageResponse{}, err
 }
 
-func (a *ResourcesMonitoringAPI) monitorMemory(ctx context.Context, datapoints []*proto.PushResourcesMonitoringUsageRequest_Datapoint) error {
+func (a *ResourcesMonitoringAPI) monitorMemory(ctx context.Context, datapoints []*proto.PushResourcesMonitoringUsageRequest_Datapoint, topProcesses []*proto.PushResourcesMonitoringUsageRequest_Process) error {
 	monitor, err := a.Database.FetchMemoryResourceMonitorsByAgentID(ctx, a.AgentID)
 	if err != nil {
 		// It is valid for an agent to not have a memory monitor, so we
@@ -146,6 +147,7 @@ func (a *ResourcesMonitoringAPI) monitorMemory(ctx context.Context, datapoints [
 			"threshold": fmt.Sprintf("%d%%", monitor.Threshold),
 		},
 		map[string]any{
+			"top_processes": topProcessesData(topProcesses),
 			// NOTE(DanielleMaywood):
 			// When notifications are enqueued, they are checked to be
 			// unique within a single day. This means that if we attempt
@@ -260,3 +262,20 @@ func (a *ResourcesMonitoringAPI) monitorVolumes(ctx context.Context, datapoints
 
 	return nil
 }
+
+// topProcessesData formats the processes reported by the agent for use in
+// notification templates.
+func topProcessesData(procs []*proto.PushResourcesMonitoringUsageRequest_Process) []map[string]any {
+	data := make([]map[string]any, 0, len(procs))
+	for _, p := range procs {
+		// #nosec G115 - Negative values are clamped to zero.
+		memory := uint64(max(p.MemoryRss, 0))
+		data = append(data, map[string]any{
+			"pid":    p.Pid,
+			"name":   p.Name,
+			"memory": humanize.IBytes(memory),
+			"cpu":    fmt.Sprintf("%.1f%%", p.CpuPercent),
+		})
+	}
+	return data
+}
@@ -321,6 +321,48 @@ func TestMemoryResourceMonitor(t *testing.T) {
 	}
 }
 
+func TestMemoryResourceMonitorTopProcesses(t *testing.T) {
+	t.Parallel()
+
+	api, _, clock, notifyEnq := resourceMonitorAPI(t)
+
+	datapoints := make([]*agentproto.PushResourcesMonitoringUsageRequest_Datapoint, 0, 10)
+	collectedAt := clock.Now()
+	for range 10 {
+		collectedAt = collectedAt.Add(15 * time.Second)
+		datapoints = append(datapoints, &agentproto.PushResourcesMonitoringUsageRequest_Datapoint{
+			CollectedAt: timestamppb.New(collectedAt),
+			Memory: &agentproto.PushResourcesMonitoringUsageRequest_Datapoint_MemoryUsage{
+				Used:  9,
+				Total: 10,
+			},
+		})
+	}
+
+	dbgen.WorkspaceAgentMemoryResourceMonitor(t, api.Database, database.WorkspaceAgentMemoryResourceMonitor{
+		AgentID:   api.AgentID,
+		State:     database.WorkspaceAgentMonitorStateOK,
+		Threshold: 80,
+	})
+
+	clock.Set(collectedAt)
+	_, err := api.PushResourcesMonitoringUsage(context.Background(), &agentproto.PushResourcesMonitoringUsageRequest{
+		Datapoints: datapoints,
+		TopProcesses: []*agentproto.PushResourcesMonitoringUsageRequest_Process{
+			{Pid: 42, Name: "node", CpuPercent: 12.34, MemoryRss: 2 << 30},
+			{Pid: 7, Name: "gopls", MemoryRss: 512 << 20},
+		},
+	})
+	require.NoError(t, err)
+
+	sent := notifyEnq.Sent(notificationstest.WithTemplateID(notifications.TemplateWorkspaceOutOfMemory))
+	require.Len(t, sent, 1)
+	require.Equal(t, []map[string]any{
+		{"pid": int32(42), "name": "node", "memory": "2.0 GiB", "cpu": "12.3%"},
+		{"pid": int32(7), "name": "gopls", "memory": "512 MiB", "cpu": "0.0%"},
+	}, sent[0].Data["top_processes"])
+}
+
 func TestMemoryResourceMonitorMissingData(t *testing.T) {
 	t.Parallel()
 
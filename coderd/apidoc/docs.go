@@ -17291,12 +17291,16 @@ const docTemplate = `{
                 "running",
                 "stopped",
                 "starting",
+                "stopping",
+                "deleting",
                 "error"
             ],
             "x-enum-varnames": [
                 "WorkspaceAgentDevcontainerStatusRunning",
                 "WorkspaceAgentDevcontainerStatusStopped",
                 "WorkspaceAgentDevcontainerStatusStarting",
+                "WorkspaceAgentDevcontainerStatusStopping",
+                "WorkspaceAgentDevcontainerStatusDeleting",
                 "WorkspaceAgentDevcontainerStatusError"
             ]
         },
@@ -17445,6 +17449,14 @@ const docTemplate = `{
                 "agent_name": {
                     "type": "string"
                 },
+                "auto_shared": {
+                    "description": "AutoShared is true when the port share was created by a template\nport share rule.",
+                    "type": "boolean"
+                },
+                "label": {
+                    "description": "Label is the label of the template port share rule that created\nthe port share, if any.",
+                    "type": "string"
+                },
                 "port": {
                     "type": "integer"
                 },
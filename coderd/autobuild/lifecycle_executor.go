@@ -256,6 +256,28 @@ func (e *Executor) runOnce(t time.Time) Stats {
 						}
 					}
 
+					// Holidays can be imported after next start at was computed,
+					// so if the autostart that's due falls on one it's skipped,
+					// and next start at moves to the next allowed autostart.
+					if ws.NextStartAt.Valid && ws.AutostartSchedule.Valid && !currentTick.Before(ws.NextStartAt.Time) {
+						due, allowed := schedule.NextAutostart(ws.NextStartAt.Time.Add(-time.Minute), ws.AutostartSchedule.String, templateSchedule)
+						if _, holiday := templateSchedule.Holidays.On(due); !allowed && holiday {
+							nextStartAt := sql.NullTime{}
+							next, err := schedule.NextAllowedAutostart(currentTick, ws.AutostartSchedule.String, templateSchedule)
+							if err == nil {
+								nextStartAt = sql.NullTime{Valid: true, Time: dbtime.Time(next.UTC())}
+							}
+							if err = tx.UpdateWorkspaceNextStartAt(e.ctx, database.UpdateWorkspaceNextStartAtParams{
+								ID:          wsID,
+								NextStartAt: nextStartAt,
+							}); err != nil {
+								return xerrors.Errorf("update workspace next start at: %w", err)
+							}
+							log.Info(e.ctx, "skipping autostart on holiday", slog.F("date", due.Format(time.DateOnly)))
+							ws.NextStartAt = nextStartAt
+						}
+					}
+
 					tmpl, err = tx.GetTemplateByID(e.ctx, ws.TemplateID)
 					if err != nil {
 						return xerrors.Errorf("get template by ID: %w", err)
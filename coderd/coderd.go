@@ -94,6 +94,7 @@ import (
 	"github.com/coder/coder/v2/coderd/util/slice"
 	"github.com/coder/coder/v2/coderd/workspaceapps"
 	"github.com/coder/coder/v2/coderd/workspaceapps/appurl"
+	"github.com/coder/coder/v2/coderd/workspacesnapshots"
 	"github.com/coder/coder/v2/coderd/workspacestats"
 	"github.com/coder/coder/v2/codersdk"
 	"github.com/coder/coder/v2/codersdk/healthsdk"
@@ -283,6 +284,11 @@ type Options struct {
 
 	// WebPushDispatcher is a way to send notifications over Web Push.
 	WebPushDispatcher webpush.Dispatcher
+
+	// WorkspaceSnapshotStore stores the archives uploaded by workspace
+	// agents. Defaults to the database.
+	WorkspaceSnapshotStore   workspacesnapshots.Store
+	WorkspaceSnapshotMaxSize int64
 }
 
 // @title Coder API
@@ -693,6 +699,12 @@ func New(options *Options) *API {
 	if options.HealthcheckTimeout == 0 {
 		options.HealthcheckTimeout = 30 * time.Second
 	}
+	if options.WorkspaceSnapshotStore == nil {
+		options.WorkspaceSnapshotStore = workspacesnapshots.NewDatabaseStore(options.Database)
+	}
+	if options.WorkspaceSnapshotMaxSize == 0 {
+		options.WorkspaceSnapshotMaxSize = DefaultWorkspaceSnapshotMaxSize
+	}
 	if options.HealthcheckRefresh == 0 {
 		options.HealthcheckRefresh = options.DeploymentValues.Healthcheck.Refresh.Value()
 	}
@@ -1077,6 +1089,13 @@ func New(options *Options) *API {
 			r.Get("/{fileID}", api.fileByID)
 			r.Post("/", api.postFile)
 		})
+		// The provider mirror authenticates provisioner jobs with their own
+		// tokens, since Terraform only sends a bearer token to mirrors.
+		r.Route("/provider-mirror", func(r chi.Router) {
+			r.Get("/providers/{hostname}/{namespace}/{type}/{file}", api.providerMirrorProvider)
+			r.Get("/archives/{archive}", api.providerMirrorArchive)
+			r.Get("/modules/{hash}", api.providerMirrorModuleFiles)
+		})
 		r.Route("/external-auth", func(r chi.Router) {
 			r.Use(
 				apiKeyMiddleware,
@@ -1147,11 +1166,27 @@ func New(options *Options) *API {
 				})
 				r.Route("/provisionerdaemons", func(r chi.Router) {
 					r.Get("/", api.provisionerDaemons)
+					r.Get("/queue", api.provisionerQueue)
 				})
 				r.Route("/provisionerjobs", func(r chi.Router) {
 					r.Get("/{job}", api.provisionerJob)
 					r.Get("/", api.provisionerJobs)
 				})
+				r.Route("/notifications/rules", func(r chi.Router) {
+					r.Get("/", api.notificationRules)
+					r.Post("/", api.postNotificationRule)
+					r.Put("/{notificationrule}", api.putNotificationRule)
+					r.Delete("/{notificationrule}", api.deleteNotificationRule)
+				})
+				r.Route("/plan-policies", func(r chi.Router) {
+					r.Get("/", api.planPolicies)
+					r.Post("/", api.postPlanPolicy)
+					r.Route("/{planpolicy}", func(r chi.Router) {
+						r.Get("/", api.planPolicy)
+						r.Patch("/", api.patchPlanPolicy)
+						r.Delete("/", api.deletePlanPolicy)
+					})
+				})
 			})
 		})
 		r.Route("/templates", func(r chi.Router) {
@@ -1168,6 +1203,8 @@ func New(options *Options) *API {
 				r.Get("/", api.template)
 				r.Delete("/", api.deleteTemplate)
 				r.Patch("/", api.patchTemplateMeta)
+				r.Get("/port-share-rules", api.templatePortShareRules)
+				r.Put("/port-share-rules", api.putTemplatePortShareRules)
 				r.Route("/versions", func(r chi.Router) {
 					r.Post("/archive", api.postArchiveTemplateVersions)
 					r.Get("/", api.templateVersionsByTemplate)
@@ -1201,6 +1238,7 @@ func New(options *Options) *API {
 				r.Post("/", api.postTemplateVersionDryRun)
 				r.Get("/{jobID}", api.templateVersionDryRun)
 				r.Get("/{jobID}/resources", api.templateVersionDryRunResources)
+				r.Get("/{jobID}/resource-changes", api.templateVersionDryRunResourceChanges)
 				r.Get("/{jobID}/logs", api.templateVersionDryRunLogs)
 				r.Get("/{jobID}/matched-provisioners", api.templateVersionDryRunMatchedProvisioners)
 				r.Patch("/{jobID}/cancel", api.patchTemplateVersionDryRunCancel)
@@ -1320,6 +1358,16 @@ func New(options *Options) *API {
 								r.Get("/", api.userNotificationPreferences)
 								r.Put("/", api.putUserNotificationPreferences)
 							})
+							r.Route("/chat-identities", func(r chi.Router) {
+								r.Get("/", api.userChatIdentities)
+								r.Put("/{method}", api.putUserChatIdentity)
+								r.Delete("/{method}", api.deleteUserChatIdentity)
+								r.Post("/{method}/verify", api.verifyUserChatIdentity)
+							})
+							r.Route("/delivery", func(r chi.Router) {
+								r.Get("/", api.userNotificationDeliverySettings)
+								r.Put("/", api.putUserNotificationDeliverySettings)
+							})
 						})
 						r.Route("/webpush", func(r chi.Router) {
 							r.Post("/subscription", api.postUserWebpushSubscription)
@@ -1353,6 +1401,12 @@ func New(options *Options) *API {
 				r.Get("/gitsshkey", api.agentGitSSHKey)
 				r.Post("/log-source", api.workspaceAgentPostLogSource)
 				r.Get("/reinit", api.workspaceAgentReinit)
+				r.Route("/snapshots", func(r chi.Router) {
+					r.Post("/", api.postWorkspaceAgentSnapshot)
+					r.Get("/restore", api.workspaceAgentPendingSnapshotRestore)
+					r.Post("/restore/complete", api.postWorkspaceAgentSnapshotRestoreComplete)
+					r.Get("/{snapshot}/archive", api.workspaceAgentSnapshotArchive)
+				})
 			})
 			r.Route("/{workspaceagent}", func(r chi.Router) {
 				r.Use(
@@ -1373,9 +1427,24 @@ func New(options *Options) *API {
 				r.Get("/startup-logs", api.workspaceAgentLogsDeprecated)
 				r.Get("/logs", api.workspaceAgentLogs)
 				r.Get("/listening-ports", api.workspaceAgentListeningPorts)
+				r.Get("/processes", api.workspaceAgentProcesses)
+				r.Post("/processes/{pid}/signal", api.workspaceAgentSignalProcess)
+				r.Route("/http-captures", func(r chi.Router) {
+					r.Get("/", api.workspaceAgentHTTPCaptures)
+					r.Post("/", api.postWorkspaceAgentHTTPCapture)
+					r.Delete("/{port}", api.deleteWorkspaceAgentHTTPCapture)
+					r.Post("/{port}/stop", api.postWorkspaceAgentHTTPCaptureStop)
+					r.Get("/{port}/har", api.workspaceAgentHTTPCaptureHAR)
+				})
 				r.Get("/connection", api.workspaceAgentConnection)
 				r.Get("/containers", api.workspaceAgentListContainers)
-				r.Post("/containers/devcontainers/{devcontainer}/recreate", api.workspaceAgentRecreateDevcontainer)
+				r.Route("/containers/devcontainers/{devcontainer}", func(r chi.Router) {
+					r.Delete("/", api.workspaceAgentDeleteDevcontainer)
+					r.Get("/logs", api.workspaceAgentDevcontainerLogs)
+					r.Post("/recreate", api.workspaceAgentRecreateDevcontainer)
+					r.Post("/start", api.workspaceAgentStartDevcontainer)
+					r.Post("/stop", api.workspaceAgentStopDevcontainer)
+				})
 				r.Get("/coordinate", api.workspaceAgentClientCoordinate)
 
 				// PTY is part of workspaceAppServer.
@@ -1417,6 +1486,16 @@ func New(options *Options) *API {
 					r.Delete("/", api.deleteWorkspaceAgentPortShare)
 				})
 				r.Get("/timings", api.workspaceTimings)
+				r.Get("/states", api.workspaceBuildStates)
+				r.Route("/drift", func(r chi.Router) {
+					r.Get("/", api.workspaceDriftCheck)
+					r.Post("/", api.postWorkspaceDriftCheck)
+				})
+				r.Route("/snapshots", func(r chi.Router) {
+					r.Get("/", api.workspaceSnapshots)
+					r.Post("/{snapshot}/restore", api.postWorkspaceSnapshotRestore)
+					r.Delete("/restore", api.deleteWorkspaceSnapshotRestore)
+				})
 			})
 		})
 		r.Route("/workspacebuilds/{workspacebuild}", func(r chi.Router) {
@@ -1459,6 +1538,7 @@ func New(options *Options) *API {
 			r.Get("/user-status-counts", api.insightsUserStatusCounts)
 			r.Get("/user-latency", api.insightsUserLatency)
 			r.Get("/templates", api.insightsTemplates)
+			r.Get("/costs", api.insightsCosts)
 		})
 		r.Route("/debug", func(r chi.Router) {
 			r.Use(
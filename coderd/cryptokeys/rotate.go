@@ -20,6 +20,9 @@ const (
 	WorkspaceAppsTokenDuration = time.Minute
 	OIDCConvertTokenDuration   = time.Minute * 5
 	TailnetResumeTokenDuration = time.Hour * 24
+	// AuditLogCheckpointDuration is how long a rotated audit log checkpoint
+	// key is retained, so that old checkpoints remain verifiable.
+	AuditLogCheckpointDuration = time.Hour * 24 * 365 * 7
 
 	// defaultRotationInterval is the default interval at which keys are checked for rotation.
 	defaultRotationInterval = time.Minute * 10
@@ -236,6 +239,8 @@ func generateNewSecret(feature database.CryptoKeyFeature) (string, error) {
 		return generateKey(64)
 	case database.CryptoKeyFeatureTailnetResume:
 		return generateKey(64)
+	case database.CryptoKeyFeatureAuditLogCheckpoint:
+		return generateKey(64)
 	}
 	return "", xerrors.Errorf("unknown feature: %s", feature)
 }
@@ -259,6 +264,8 @@ func tokenDuration(feature database.CryptoKeyFeature) time.Duration {
 		return OIDCConvertTokenDuration
 	case database.CryptoKeyFeatureTailnetResume:
 		return TailnetResumeTokenDuration
+	case database.CryptoKeyFeatureAuditLogCheckpoint:
+		return AuditLogCheckpointDuration
 	default:
 		return 0
 	}
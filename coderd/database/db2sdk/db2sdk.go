@@ -618,6 +618,28 @@ func ProvisionerDaemon(dbDaemon database.ProvisionerDaemon) codersdk.Provisioner
 	return result
 }
 
+func ProvisionerQueueStats(row database.GetProvisionerQueueStatsRow) codersdk.ProvisionerQueueStats {
+	var utilization float64
+	if row.Daemons > 0 {
+		utilization = float64(row.BusyDaemons) / float64(row.Daemons)
+	}
+	return codersdk.ProvisionerQueueStats{
+		OrganizationID:        row.OrganizationID,
+		Tags:                  row.Tags,
+		PendingJobs:           row.PendingJobs,
+		OldestPendingJobAgeMS: row.OldestPendingJobMs,
+		RunningJobs:           row.RunningJobs,
+		Daemons:               row.Daemons,
+		BusyDaemons:           row.BusyDaemons,
+		Utilization:           utilization,
+		WaitTime: codersdk.ProvisionerQueueWaitTime{
+			P50MS: row.WaitP50Ms,
+			P95MS: row.WaitP95Ms,
+			P99MS: row.WaitP99Ms,
+		},
+	}
+}
+
 func RecentProvisionerDaemons(now time.Time, staleInterval time.Duration, daemons []database.ProvisionerDaemon) []codersdk.ProvisionerDaemon {
 	results := []codersdk.ProvisionerDaemon{}
 
@@ -884,3 +906,18 @@ func PreviewParameterValidation(v *previewtypes.ParameterValidation) codersdk.Pr
 		Monotonic: v.Monotonic,
 	}
 }
+
+func AuditLogArchive(archive database.AuditLogArchive) codersdk.AuditLogArchive {
+	sdk := codersdk.AuditLogArchive{
+		ID:            archive.ID,
+		CreatedAt:     archive.CreatedAt,
+		FileName:      archive.FileName,
+		OldestTime:    archive.OldestTime,
+		NewestTime:    archive.NewestTime,
+		AuditLogCount: archive.AuditLogCount,
+	}
+	if archive.RestoredAt.Valid {
+		sdk.RestoredAt = &archive.RestoredAt.Time
+	}
+	return sdk
+}
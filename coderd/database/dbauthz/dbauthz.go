@@ -319,6 +319,8 @@ var (
 					rbac.ResourceInboxNotification.Type:   {policy.ActionCreate},
 					rbac.ResourceWebpushSubscription.Type: {policy.ActionCreate, policy.ActionRead, policy.ActionUpdate, policy.ActionDelete},
 					rbac.ResourceDeploymentConfig.Type:    {policy.ActionRead, policy.ActionUpdate}, // To read and upsert VAPID keys
+					// To send chat notifications to the identities users linked.
+					rbac.ResourceNotificationPreference.Type: {policy.ActionRead},
 				}),
 				Org:  map[string][]rbac.Permission{},
 				User: []rbac.Permission{},
@@ -1184,6 +1186,20 @@ func (q *querier) AcquireLock(ctx context.Context, id int64) error {
 	return q.db.AcquireLock(ctx, id)
 }
 
+func (q *querier) AcquireNotificationDigestMessages(ctx context.Context, arg database.AcquireNotificationDigestMessagesParams) ([]database.AcquireNotificationDigestMessagesRow, error) {
+	if err := q.authorizeContext(ctx, policy.ActionUpdate, rbac.ResourceNotificationMessage); err != nil {
+		return nil, err
+	}
+	return q.db.AcquireNotificationDigestMessages(ctx, arg)
+}
+
+func (q *querier) AcquireAuditLogExportCursor(ctx context.Context, arg database.AcquireAuditLogExportCursorParams) (database.AuditLogExportCursor, error) {
+	if err := q.authorizeContext(ctx, policy.ActionUpdate, rbac.ResourceSystem); err != nil {
+		return database.AuditLogExportCursor{}, err
+	}
+	return q.db.AcquireAuditLogExportCursor(ctx, arg)
+}
+
 func (q *querier) AcquireNotificationMessages(ctx context.Context, arg database.AcquireNotificationMessagesParams) ([]database.AcquireNotificationMessagesRow, error) {
 	if err := q.authorizeContext(ctx, policy.ActionUpdate, rbac.ResourceNotificationMessage); err != nil {
 		return nil, err
@@ -1241,6 +1257,13 @@ func (q *querier) BatchUpdateWorkspaceNextStartAt(ctx context.Context, arg datab
 	return q.db.BatchUpdateWorkspaceNextStartAt(ctx, arg)
 }
 
+func (q *querier) BulkDeferNotificationMessages(ctx context.Context, arg database.BulkDeferNotificationMessagesParams) (int64, error) {
+	if err := q.authorizeContext(ctx, policy.ActionUpdate, rbac.ResourceNotificationMessage); err != nil {
+		return 0, err
+	}
+	return q.db.BulkDeferNotificationMessages(ctx, arg)
+}
+
 func (q *querier) BulkMarkNotificationMessagesFailed(ctx context.Context, arg database.BulkMarkNotificationMessagesFailedParams) (int64, error) {
 	if err := q.authorizeContext(ctx, policy.ActionUpdate, rbac.ResourceNotificationMessage); err != nil {
 		return 0, err
@@ -1389,6 +1412,13 @@ func (q *querier) DeleteApplicationConnectAPIKeysByUserID(ctx context.Context, u
 	return q.db.DeleteApplicationConnectAPIKeysByUserID(ctx, userID)
 }
 
+func (q *querier) DeleteAuditLogsByIDs(ctx context.Context, ids []uuid.UUID) error {
+	if err := q.authorizeContext(ctx, policy.ActionDelete, rbac.ResourceSystem); err != nil {
+		return err
+	}
+	return q.db.DeleteAuditLogsByIDs(ctx, ids)
+}
+
 func (q *querier) DeleteCoordinator(ctx context.Context, id uuid.UUID) error {
 	if err := q.authorizeContext(ctx, policy.ActionDelete, rbac.ResourceTailnetCoordinator); err != nil {
 		return err
@@ -1448,6 +1478,17 @@ func (q *querier) DeleteLicense(ctx context.Context, id int32) (int32, error) {
 	return id, nil
 }
 
+func (q *querier) DeleteNotificationRule(ctx context.Context, id uuid.UUID) error {
+	rule, err := q.db.GetNotificationRuleByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := q.authorizeContext(ctx, policy.ActionUpdate, rbac.ResourceOrganization.WithID(rule.OrganizationID).InOrg(rule.OrganizationID)); err != nil {
+		return err
+	}
+	return q.db.DeleteNotificationRule(ctx, id)
+}
+
 func (q *querier) DeleteOAuth2ProviderAppByID(ctx context.Context, id uuid.UUID) error {
 	if err := q.authorizeContext(ctx, policy.ActionDelete, rbac.ResourceOauth2App); err != nil {
 		return err
@@ -1517,6 +1558,20 @@ func (q *querier) DeleteOldWorkspaceAgentStats(ctx context.Context) error {
 	return q.db.DeleteOldWorkspaceAgentStats(ctx)
 }
 
+func (q *querier) DeleteOldWorkspaceBuildStates(ctx context.Context, arg database.DeleteOldWorkspaceBuildStatesParams) (int64, error) {
+	if err := q.authorizeContext(ctx, policy.ActionDelete, rbac.ResourceSystem); err != nil {
+		return 0, err
+	}
+	return q.db.DeleteOldWorkspaceBuildStates(ctx, arg)
+}
+
+func (q *querier) DeleteOldWorkspaceSnapshots(ctx context.Context, keepChains int32) ([]database.DeleteOldWorkspaceSnapshotsRow, error) {
+	if err := q.authorizeContext(ctx, policy.ActionDelete, rbac.ResourceSystem); err != nil {
+		return nil, err
+	}
+	return q.db.DeleteOldWorkspaceSnapshots(ctx, keepChains)
+}
+
 func (q *querier) DeleteOrganizationMember(ctx context.Context, arg database.DeleteOrganizationMemberParams) error {
 	return deleteQ[database.OrganizationMember](q.log, q.auth, func(ctx context.Context, arg database.DeleteOrganizationMemberParams) (database.OrganizationMember, error) {
 		member, err := database.ExpectOne(q.OrganizationMembers(ctx, database.OrganizationMembersParams{
@@ -1531,6 +1586,10 @@ func (q *querier) DeleteOrganizationMember(ctx context.Context, arg database.Del
 	}, q.db.DeleteOrganizationMember)(ctx, arg)
 }
 
+func (q *querier) DeletePlanPolicyByID(ctx context.Context, id uuid.UUID) error {
+	return deleteQ(q.log, q.auth, q.db.GetPlanPolicyByID, q.db.DeletePlanPolicyByID)(ctx, id)
+}
+
 func (q *querier) DeleteProvisionerKey(ctx context.Context, id uuid.UUID) error {
 	return deleteQ(q.log, q.auth, q.db.GetProvisionerKeyByID, q.db.DeleteProvisionerKey)(ctx, id)
 }
@@ -1542,6 +1601,13 @@ func (q *querier) DeleteReplicasUpdatedBefore(ctx context.Context, updatedAt tim
 	return q.db.DeleteReplicasUpdatedBefore(ctx, updatedAt)
 }
 
+func (q *querier) DeleteRestoredAuditLogs(ctx context.Context, restoredBefore time.Time) error {
+	if err := q.authorizeContext(ctx, policy.ActionDelete, rbac.ResourceSystem); err != nil {
+		return err
+	}
+	return q.db.DeleteRestoredAuditLogs(ctx, restoredBefore)
+}
+
 func (q *querier) DeleteRuntimeConfig(ctx context.Context, key string) error {
 	if err := q.authorizeContext(ctx, policy.ActionDelete, rbac.ResourceSystem); err != nil {
 		return err
@@ -1584,6 +1650,27 @@ func (q *querier) DeleteTailnetTunnel(ctx context.Context, arg database.DeleteTa
 	return q.db.DeleteTailnetTunnel(ctx, arg)
 }
 
+func (q *querier) DeleteTemplatePortShareRules(ctx context.Context, templateID uuid.UUID) error {
+	template, err := q.db.GetTemplateByID(ctx, templateID)
+	if err != nil {
+		return err
+	}
+
+	// Port share rules are part of the template settings.
+	if err := q.authorizeContext(ctx, policy.ActionUpdate, template); err != nil {
+		return err
+	}
+
+	return q.db.DeleteTemplatePortShareRules(ctx, templateID)
+}
+
+func (q *querier) DeleteUserChatIdentity(ctx context.Context, arg database.DeleteUserChatIdentityParams) error {
+	if err := q.authorizeContext(ctx, policy.ActionUpdate, rbac.ResourceNotificationPreference.WithOwner(arg.UserID.String())); err != nil {
+		return err
+	}
+	return q.db.DeleteUserChatIdentity(ctx, arg)
+}
+
 func (q *querier) DeleteWebpushSubscriptionByUserIDAndEndpoint(ctx context.Context, arg database.DeleteWebpushSubscriptionByUserIDAndEndpointParams) error {
 	if err := q.authorizeContext(ctx, policy.ActionDelete, rbac.ResourceWebpushSubscription.WithOwner(arg.UserID.String())); err != nil {
 		return err
@@ -1625,6 +1712,20 @@ func (q *querier) DeleteWorkspaceAgentPortSharesByTemplate(ctx context.Context,
 	return q.db.DeleteWorkspaceAgentPortSharesByTemplate(ctx, templateID)
 }
 
+func (q *querier) DeleteWorkspaceSnapshotRestore(ctx context.Context, workspaceID uuid.UUID) error {
+	workspace, err := q.db.GetWorkspaceByID(ctx, workspaceID)
+	if err != nil {
+		return err
+	}
+
+	// completing a restore is more akin to updating the workspace.
+	if err := q.authorizeContext(ctx, policy.ActionUpdate, workspace); err != nil {
+		return err
+	}
+
+	return q.db.DeleteWorkspaceSnapshotRestore(ctx, workspaceID)
+}
+
 func (q *querier) DeleteWorkspaceSubAgentByID(ctx context.Context, id uuid.UUID) error {
 	workspace, err := q.db.GetWorkspaceByAgentID(ctx, id)
 	if err != nil {
@@ -1803,6 +1904,72 @@ func (q *querier) GetApplicationName(ctx context.Context) (string, error) {
 	return q.db.GetApplicationName(ctx)
 }
 
+func (q *querier) GetArchivedAuditLogIDs(ctx context.Context, ids []uuid.UUID) ([]uuid.UUID, error) {
+	// Archived audit logs are looked up by ID to verify the hash chain, across organizations.
+	if err := q.authorizeContext(ctx, policy.ActionRead, rbac.ResourceAuditLog); err != nil {
+		return nil, err
+	}
+	return q.db.GetArchivedAuditLogIDs(ctx, ids)
+}
+
+func (q *querier) GetAuditLogArchiveByID(ctx context.Context, id uuid.UUID) (database.AuditLogArchive, error) {
+	if err := q.authorizeContext(ctx, policy.ActionRead, rbac.ResourceAuditLog); err != nil {
+		return database.AuditLogArchive{}, err
+	}
+	return q.db.GetAuditLogArchiveByID(ctx, id)
+}
+
+func (q *querier) GetAuditLogArchives(ctx context.Context) ([]database.AuditLogArchive, error) {
+	if err := q.authorizeContext(ctx, policy.ActionRead, rbac.ResourceAuditLog); err != nil {
+		return nil, err
+	}
+	return q.db.GetAuditLogArchives(ctx)
+}
+
+func (q *querier) GetAuditLogCheckpoints(ctx context.Context, arg database.GetAuditLogCheckpointsParams) ([]database.AuditLogCheckpoint, error) {
+	if err := q.authorizeContext(ctx, policy.ActionRead, rbac.ResourceAuditLog); err != nil {
+		return nil, err
+	}
+	return q.db.GetAuditLogCheckpoints(ctx, arg)
+}
+
+func (q *querier) GetAuditLogExportCursor(ctx context.Context, sink string) (database.AuditLogExportCursor, error) {
+	if err := q.authorizeContext(ctx, policy.ActionRead, rbac.ResourceSystem); err != nil {
+		return database.AuditLogExportCursor{}, err
+	}
+	return q.db.GetAuditLogExportCursor(ctx, sink)
+}
+
+func (q *querier) GetAuditLogHashSequenceRange(ctx context.Context, arg database.GetAuditLogHashSequenceRangeParams) (database.GetAuditLogHashSequenceRangeRow, error) {
+	if err := q.authorizeContext(ctx, policy.ActionRead, rbac.ResourceAuditLog); err != nil {
+		return database.GetAuditLogHashSequenceRangeRow{}, err
+	}
+	return q.db.GetAuditLogHashSequenceRange(ctx, arg)
+}
+
+func (q *querier) GetAuditLogHashes(ctx context.Context, arg database.GetAuditLogHashesParams) ([]database.AuditLogHash, error) {
+	if err := q.authorizeContext(ctx, policy.ActionRead, rbac.ResourceAuditLog); err != nil {
+		return nil, err
+	}
+	return q.db.GetAuditLogHashes(ctx, arg)
+}
+
+func (q *querier) GetAuditLogsByIDs(ctx context.Context, ids []uuid.UUID) ([]database.AuditLog, error) {
+	// Audit logs are looked up by ID to verify the hash chain, across organizations.
+	if err := q.authorizeContext(ctx, policy.ActionRead, rbac.ResourceAuditLog); err != nil {
+		return nil, err
+	}
+	return q.db.GetAuditLogsByIDs(ctx, ids)
+}
+
+func (q *querier) GetAuditLogsForExport(ctx context.Context, arg database.GetAuditLogsForExportParams) ([]database.GetAuditLogsForExportRow, error) {
+	// Exports include the audit logs of every organization.
+	if err := q.authorizeContext(ctx, policy.ActionRead, rbac.ResourceAuditLog); err != nil {
+		return nil, err
+	}
+	return q.db.GetAuditLogsForExport(ctx, arg)
+}
+
 func (q *querier) GetAuditLogsOffset(ctx context.Context, arg database.GetAuditLogsOffsetParams) ([]database.GetAuditLogsOffsetRow, error) {
 	// Shortcut if the user is an owner. The SQL filter is noticeable,
 	// and this is an easy win for owners. Which is the common case.
@@ -1819,6 +1986,14 @@ func (q *querier) GetAuditLogsOffset(ctx context.Context, arg database.GetAuditL
 	return q.db.GetAuthorizedAuditLogsOffset(ctx, arg, prep)
 }
 
+func (q *querier) GetAuditLogsToChain(ctx context.Context, arg database.GetAuditLogsToChainParams) ([]database.AuditLog, error) {
+	// The hash chain includes the audit logs of every organization.
+	if err := q.authorizeContext(ctx, policy.ActionRead, rbac.ResourceAuditLog); err != nil {
+		return nil, err
+	}
+	return q.db.GetAuditLogsToChain(ctx, arg)
+}
+
 func (q *querier) GetAuthorizationUserRoles(ctx context.Context, userID uuid.UUID) (database.GetAuthorizationUserRolesRow, error) {
 	if err := q.authorizeContext(ctx, policy.ActionRead, rbac.ResourceSystem); err != nil {
 		return database.GetAuthorizationUserRolesRow{}, err
@@ -1908,6 +2083,20 @@ func (q *querier) GetEligibleProvisionerDaemonsByProvisionerJobIDs(ctx context.C
 	return fetchWithPostFilter(q.auth, policy.ActionRead, q.db.GetEligibleProvisionerDaemonsByProvisionerJobIDs)(ctx, provisionerJobIDs)
 }
 
+func (q *querier) GetEnabledNotificationRules(ctx context.Context) ([]database.NotificationRule, error) {
+	if err := q.authorizeContext(ctx, policy.ActionRead, rbac.ResourceSystem); err != nil {
+		return nil, err
+	}
+	return q.db.GetEnabledNotificationRules(ctx)
+}
+
+func (q *querier) GetExpiredAuditLogs(ctx context.Context, arg database.GetExpiredAuditLogsParams) ([]database.AuditLog, error) {
+	if err := q.authorizeContext(ctx, policy.ActionRead, rbac.ResourceAuditLog); err != nil {
+		return nil, err
+	}
+	return q.db.GetExpiredAuditLogs(ctx, arg)
+}
+
 func (q *querier) GetExternalAuthLink(ctx context.Context, arg database.GetExternalAuthLinkParams) (database.ExternalAuthLink, error) {
 	return fetchWithAction(q.log, q.auth, policy.ActionReadPersonal, q.db.GetExternalAuthLink)(ctx, arg)
 }
@@ -2048,6 +2237,20 @@ func (q *querier) GetLastUpdateCheck(ctx context.Context) (string, error) {
 	return q.db.GetLastUpdateCheck(ctx)
 }
 
+func (q *querier) GetLatestAuditLogCheckpoint(ctx context.Context) (database.AuditLogCheckpoint, error) {
+	if err := q.authorizeContext(ctx, policy.ActionRead, rbac.ResourceAuditLog); err != nil {
+		return database.AuditLogCheckpoint{}, err
+	}
+	return q.db.GetLatestAuditLogCheckpoint(ctx)
+}
+
+func (q *querier) GetLatestAuditLogHash(ctx context.Context) (database.AuditLogHash, error) {
+	if err := q.authorizeContext(ctx, policy.ActionRead, rbac.ResourceAuditLog); err != nil {
+		return database.AuditLogHash{}, err
+	}
+	return q.db.GetLatestAuditLogHash(ctx)
+}
+
 func (q *querier) GetLatestCryptoKeyByFeature(ctx context.Context, feature database.CryptoKeyFeature) (database.CryptoKey, error) {
 	if err := q.authorizeContext(ctx, policy.ActionRead, rbac.ResourceCryptoKey); err != nil {
 		return database.CryptoKey{}, err
@@ -2089,6 +2292,13 @@ func (q *querier) GetLatestWorkspaceBuildsByWorkspaceIDs(ctx context.Context, id
 	return q.db.GetLatestWorkspaceBuildsByWorkspaceIDs(ctx, ids)
 }
 
+func (q *querier) GetLatestWorkspaceDriftCheckByWorkspaceID(ctx context.Context, workspaceID uuid.UUID) (database.WorkspaceDriftCheck, error) {
+	if _, err := q.GetWorkspaceByID(ctx, workspaceID); err != nil {
+		return database.WorkspaceDriftCheck{}, err
+	}
+	return q.db.GetLatestWorkspaceDriftCheckByWorkspaceID(ctx, workspaceID)
+}
+
 func (q *querier) GetLicenseByID(ctx context.Context, id int32) (database.License, error) {
 	return fetch(q.log, q.auth, q.db.GetLicenseByID)(ctx, id)
 }
@@ -2119,6 +2329,24 @@ func (q *querier) GetNotificationReportGeneratorLogByTemplate(ctx context.Contex
 	return q.db.GetNotificationReportGeneratorLogByTemplate(ctx, arg)
 }
 
+func (q *querier) GetNotificationRuleByID(ctx context.Context, id uuid.UUID) (database.NotificationRule, error) {
+	rule, err := q.db.GetNotificationRuleByID(ctx, id)
+	if err != nil {
+		return database.NotificationRule{}, err
+	}
+	if err := q.authorizeContext(ctx, policy.ActionRead, rbac.ResourceOrganization.WithID(rule.OrganizationID).InOrg(rule.OrganizationID)); err != nil {
+		return database.NotificationRule{}, err
+	}
+	return rule, nil
+}
+
+func (q *querier) GetNotificationRulesByOrganizationID(ctx context.Context, organizationID uuid.UUID) ([]database.NotificationRule, error) {
+	if err := q.authorizeContext(ctx, policy.ActionRead, rbac.ResourceOrganization.WithID(organizationID).InOrg(organizationID)); err != nil {
+		return nil, err
+	}
+	return q.db.GetNotificationRulesByOrganizationID(ctx, organizationID)
+}
+
 func (q *querier) GetNotificationTemplateByID(ctx context.Context, id uuid.UUID) (database.NotificationTemplate, error) {
 	if err := q.authorizeContext(ctx, policy.ActionRead, rbac.ResourceNotificationTemplate); err != nil {
 		return database.NotificationTemplate{}, err
@@ -2238,6 +2466,13 @@ func (q *querier) GetOrganizationByName(ctx context.Context, name database.GetOr
 	return fetch(q.log, q.auth, q.db.GetOrganizationByName)(ctx, name)
 }
 
+func (q *querier) GetOrganizationHolidays(ctx context.Context, arg database.GetOrganizationHolidaysParams) ([]database.OrganizationHoliday, error) {
+	if err := q.authorizeContext(ctx, policy.ActionRead, rbac.ResourceOrganization.WithID(arg.OrganizationID).InOrg(arg.OrganizationID)); err != nil {
+		return nil, err
+	}
+	return q.db.GetOrganizationHolidays(ctx, arg)
+}
+
 func (q *querier) GetOrganizationIDsByMemberIDs(ctx context.Context, ids []uuid.UUID) ([]database.GetOrganizationIDsByMemberIDsRow, error) {
 	// TODO: This should be rewritten to return a list of database.OrganizationMember for consistent RBAC objects.
 	// Currently this row returns a list of org ids per user, which is challenging to check against the RBAC system.
@@ -2305,6 +2540,21 @@ func (q *querier) GetParameterSchemasByJobID(ctx context.Context, jobID uuid.UUI
 	return q.db.GetParameterSchemasByJobID(ctx, jobID)
 }
 
+func (q *querier) GetPlanPoliciesByOrganizationID(ctx context.Context, organizationID uuid.UUID) ([]database.PlanPolicy, error) {
+	if err := q.authorizeContext(ctx, policy.ActionRead, rbac.ResourceTemplate.InOrg(organizationID)); err != nil {
+		return nil, err
+	}
+	return q.db.GetPlanPoliciesByOrganizationID(ctx, organizationID)
+}
+
+func (q *querier) GetPlanPolicyByID(ctx context.Context, id uuid.UUID) (database.PlanPolicy, error) {
+	return fetch(q.log, q.auth, q.db.GetPlanPolicyByID)(ctx, id)
+}
+
+func (q *querier) GetPlanPolicyByOrganizationAndName(ctx context.Context, arg database.GetPlanPolicyByOrganizationAndNameParams) (database.PlanPolicy, error) {
+	return fetch(q.log, q.auth, q.db.GetPlanPolicyByOrganizationAndName)(ctx, arg)
+}
+
 func (q *querier) GetPrebuildMetrics(ctx context.Context) ([]database.GetPrebuildMetricsRow, error) {
 	// GetPrebuildMetrics returns metrics related to prebuilt workspaces,
 	// such as the number of created and failed prebuilt workspaces.
@@ -2314,6 +2564,13 @@ func (q *querier) GetPrebuildMetrics(ctx context.Context) ([]database.GetPrebuil
 	return q.db.GetPrebuildMetrics(ctx)
 }
 
+func (q *querier) GetPrebuildPoolHealth(ctx context.Context, since time.Time) ([]database.GetPrebuildPoolHealthRow, error) {
+	if err := q.authorizeContext(ctx, policy.ActionRead, rbac.ResourceSystem); err != nil {
+		return nil, err
+	}
+	return q.db.GetPrebuildPoolHealth(ctx, since)
+}
+
 func (q *querier) GetPrebuildsSettings(ctx context.Context) (string, error) {
 	return q.db.GetPrebuildsSettings(ctx)
 }
@@ -2401,6 +2658,27 @@ func (q *querier) GetPreviousTemplateVersion(ctx context.Context, arg database.G
 	return q.db.GetPreviousTemplateVersion(ctx, arg)
 }
 
+func (q *querier) GetProviderMirrorArchiveFileByHash(ctx context.Context, arg database.GetProviderMirrorArchiveFileByHashParams) (database.File, error) {
+	if err := q.authorizeContext(ctx, policy.ActionRead, rbac.ResourceSystem); err != nil {
+		return database.File{}, err
+	}
+	return q.db.GetProviderMirrorArchiveFileByHash(ctx, arg)
+}
+
+func (q *querier) GetProviderMirrorArchives(ctx context.Context, arg database.GetProviderMirrorArchivesParams) ([]database.GetProviderMirrorArchivesRow, error) {
+	if err := q.authorizeContext(ctx, policy.ActionRead, rbac.ResourceSystem); err != nil {
+		return nil, err
+	}
+	return q.db.GetProviderMirrorArchives(ctx, arg)
+}
+
+func (q *querier) GetProviderMirrorTokenByJobID(ctx context.Context, jobID uuid.UUID) (database.ProviderMirrorToken, error) {
+	if err := q.authorizeContext(ctx, policy.ActionRead, rbac.ResourceSystem); err != nil {
+		return database.ProviderMirrorToken{}, err
+	}
+	return q.db.GetProviderMirrorTokenByJobID(ctx, jobID)
+}
+
 func (q *querier) GetProvisionerDaemons(ctx context.Context) ([]database.ProvisionerDaemon, error) {
 	fetch := func(ctx context.Context, _ interface{}) ([]database.ProvisionerDaemon, error) {
 		return q.db.GetProvisionerDaemons(ctx)
@@ -2430,6 +2708,13 @@ func (q *querier) GetProvisionerJobByID(ctx context.Context, id uuid.UUID) (data
 		if err != nil {
 			return database.ProvisionerJob{}, xerrors.Errorf("fetch related workspace build: %w", err)
 		}
+	case database.ProvisionerJobTypeWorkspaceDriftCheck:
+		// Authorized call to get the drift check. If we can read the
+		// workspace, we can read the job.
+		_, err := q.GetWorkspaceDriftCheckByJobID(ctx, id)
+		if err != nil {
+			return database.ProvisionerJob{}, xerrors.Errorf("fetch related workspace drift check: %w", err)
+		}
 	case database.ProvisionerJobTypeTemplateVersionDryRun, database.ProvisionerJobTypeTemplateVersionImport:
 		// Authorized call to get template version.
 		_, err := authorizedTemplateVersionFromJob(ctx, q, job)
@@ -2522,6 +2807,10 @@ func (q *querier) GetProvisionerLogsAfterID(ctx context.Context, arg database.Ge
 	return q.db.GetProvisionerLogsAfterID(ctx, arg)
 }
 
+func (q *querier) GetProvisionerQueueStats(ctx context.Context, arg database.GetProvisionerQueueStatsParams) ([]database.GetProvisionerQueueStatsRow, error) {
+	return fetchWithPostFilter(q.auth, policy.ActionRead, q.db.GetProvisionerQueueStats)(ctx, arg)
+}
+
 func (q *querier) GetQuotaAllowanceForUser(ctx context.Context, params database.GetQuotaAllowanceForUserParams) (int64, error) {
 	err := q.authorizeContext(ctx, policy.ActionRead, rbac.ResourceUserObject(params.UserID))
 	if err != nil {
@@ -2631,6 +2920,17 @@ func (q *querier) GetTemplateAppInsightsByTemplate(ctx context.Context, arg data
 	return q.db.GetTemplateAppInsightsByTemplate(ctx, arg)
 }
 
+func (q *querier) GetTemplateAutostopWindows(ctx context.Context, templateID uuid.UUID) ([]database.TemplateAutostopWindow, error) {
+	template, err := q.db.GetTemplateByID(ctx, templateID)
+	if err != nil {
+		return nil, err
+	}
+	if err := q.authorizeContext(ctx, policy.ActionRead, template); err != nil {
+		return nil, err
+	}
+	return q.db.GetTemplateAutostopWindows(ctx, templateID)
+}
+
 // Only used by metrics cache.
 func (q *querier) GetTemplateAverageBuildTime(ctx context.Context, arg database.GetTemplateAverageBuildTimeParams) (database.GetTemplateAverageBuildTimeRow, error) {
 	if err := q.authorizeContext(ctx, policy.ActionRead, rbac.ResourceSystem); err != nil {
@@ -2684,6 +2984,19 @@ func (q *querier) GetTemplateParameterInsights(ctx context.Context, arg database
 	return q.db.GetTemplateParameterInsights(ctx, arg)
 }
 
+func (q *querier) GetTemplatePortShareRules(ctx context.Context, templateID uuid.UUID) ([]database.TemplatePortShareRule, error) {
+	template, err := q.db.GetTemplateByID(ctx, templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := q.authorizeContext(ctx, policy.ActionRead, template); err != nil {
+		return nil, err
+	}
+
+	return q.db.GetTemplatePortShareRules(ctx, templateID)
+}
+
 func (q *querier) GetTemplatePresetsWithPrebuilds(ctx context.Context, templateID uuid.NullUUID) ([]database.GetTemplatePresetsWithPrebuildsRow, error) {
 	// GetTemplatePresetsWithPrebuilds retrieves template versions with configured presets and prebuilds.
 	// Presets and prebuilds are part of the template, so if you can access templates - you can access them as well.
@@ -2700,6 +3013,13 @@ func (q *querier) GetTemplateUsageStats(ctx context.Context, arg database.GetTem
 	return q.db.GetTemplateUsageStats(ctx, arg)
 }
 
+func (q *querier) GetTemplateVersionAdoption(ctx context.Context) ([]database.GetTemplateVersionAdoptionRow, error) {
+	if err := q.authorizeContext(ctx, policy.ActionRead, rbac.ResourceSystem); err != nil {
+		return nil, err
+	}
+	return q.db.GetTemplateVersionAdoption(ctx)
+}
+
 func (q *querier) GetTemplateVersionByID(ctx context.Context, tvid uuid.UUID) (database.TemplateVersion, error) {
 	tv, err := q.db.GetTemplateVersionByID(ctx, tvid)
 	if err != nil {
@@ -2879,6 +3199,20 @@ func (q *querier) GetTemplatesWithFilter(ctx context.Context, arg database.GetTe
 	return q.db.GetAuthorizedTemplates(ctx, arg, prep)
 }
 
+func (q *querier) GetTopWorkspaceResourceConsumers(ctx context.Context, arg database.GetTopWorkspaceResourceConsumersParams) ([]database.GetTopWorkspaceResourceConsumersRow, error) {
+	if err := q.authorizeContext(ctx, policy.ActionRead, rbac.ResourceSystem); err != nil {
+		return nil, err
+	}
+	return q.db.GetTopWorkspaceResourceConsumers(ctx, arg)
+}
+
+func (q *querier) GetUnchainedAuditLogs(ctx context.Context, arg database.GetUnchainedAuditLogsParams) ([]database.GetUnchainedAuditLogsRow, error) {
+	if err := q.authorizeContext(ctx, policy.ActionRead, rbac.ResourceAuditLog); err != nil {
+		return nil, err
+	}
+	return q.db.GetUnchainedAuditLogs(ctx, arg)
+}
+
 func (q *querier) GetUnexpiredLicenses(ctx context.Context) ([]database.License, error) {
 	if err := q.authorizeContext(ctx, policy.ActionRead, rbac.ResourceSystem); err != nil {
 		return nil, err
@@ -2908,6 +3242,17 @@ func (q *querier) GetUserActivityInsights(ctx context.Context, arg database.GetU
 	return q.db.GetUserActivityInsights(ctx, arg)
 }
 
+func (q *querier) GetUserAutostopWindows(ctx context.Context, userID uuid.UUID) ([]database.UserAutostopWindow, error) {
+	u, err := q.db.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := q.authorizeContext(ctx, policy.ActionRead, u); err != nil {
+		return nil, err
+	}
+	return q.db.GetUserAutostopWindows(ctx, userID)
+}
+
 func (q *querier) GetUserByEmailOrUsername(ctx context.Context, arg database.GetUserByEmailOrUsernameParams) (database.User, error) {
 	return fetch(q.log, q.auth, q.db.GetUserByEmailOrUsername)(ctx, arg)
 }
@@ -2916,6 +3261,20 @@ func (q *querier) GetUserByID(ctx context.Context, id uuid.UUID) (database.User,
 	return fetch(q.log, q.auth, q.db.GetUserByID)(ctx, id)
 }
 
+func (q *querier) GetUserChatIdentities(ctx context.Context, userID uuid.UUID) ([]database.UserChatIdentity, error) {
+	if err := q.authorizeContext(ctx, policy.ActionRead, rbac.ResourceNotificationPreference.WithOwner(userID.String())); err != nil {
+		return nil, err
+	}
+	return q.db.GetUserChatIdentities(ctx, userID)
+}
+
+func (q *querier) GetUserChatIdentity(ctx context.Context, arg database.GetUserChatIdentityParams) (database.UserChatIdentity, error) {
+	if err := q.authorizeContext(ctx, policy.ActionRead, rbac.ResourceNotificationPreference.WithOwner(arg.UserID.String())); err != nil {
+		return database.UserChatIdentity{}, err
+	}
+	return q.db.GetUserChatIdentity(ctx, arg)
+}
+
 func (q *querier) GetUserCount(ctx context.Context, includeSystem bool) (int64, error) {
 	if err := q.authorizeContext(ctx, policy.ActionRead, rbac.ResourceSystem); err != nil {
 		return 0, err
@@ -2966,6 +3325,13 @@ func (q *querier) GetUserLinksByUserID(ctx context.Context, userID uuid.UUID) ([
 	return q.db.GetUserLinksByUserID(ctx, userID)
 }
 
+func (q *querier) GetUserNotificationDigestPreferences(ctx context.Context, userID uuid.UUID) ([]database.NotificationDigestPreference, error) {
+	if err := q.authorizeContext(ctx, policy.ActionRead, rbac.ResourceNotificationPreference.WithOwner(userID.String())); err != nil {
+		return nil, err
+	}
+	return q.db.GetUserNotificationDigestPreferences(ctx, userID)
+}
+
 func (q *querier) GetUserNotificationPreferences(ctx context.Context, userID uuid.UUID) ([]database.NotificationPreference, error) {
 	if err := q.authorizeContext(ctx, policy.ActionRead, rbac.ResourceNotificationPreference.WithOwner(userID.String())); err != nil {
 		return nil, err
@@ -2973,6 +3339,13 @@ func (q *querier) GetUserNotificationPreferences(ctx context.Context, userID uui
 	return q.db.GetUserNotificationPreferences(ctx, userID)
 }
 
+func (q *querier) GetUserNotificationSchedule(ctx context.Context, userID uuid.UUID) (database.UserNotificationSchedule, error) {
+	if err := q.authorizeContext(ctx, policy.ActionRead, rbac.ResourceNotificationPreference.WithOwner(userID.String())); err != nil {
+		return database.UserNotificationSchedule{}, err
+	}
+	return q.db.GetUserNotificationSchedule(ctx, userID)
+}
+
 func (q *querier) GetUserStatusCounts(ctx context.Context, arg database.GetUserStatusCountsParams) ([]database.GetUserStatusCountsRow, error) {
 	if err := q.authorizeContext(ctx, policy.ActionRead, rbac.ResourceUser); err != nil {
 		return nil, err
@@ -3289,6 +3662,13 @@ func (q *querier) GetWorkspaceBuildByWorkspaceIDAndBuildNumber(ctx context.Conte
 	return q.db.GetWorkspaceBuildByWorkspaceIDAndBuildNumber(ctx, arg)
 }
 
+func (q *querier) GetWorkspaceBuildEvents(ctx context.Context, arg database.GetWorkspaceBuildEventsParams) ([]database.GetWorkspaceBuildEventsRow, error) {
+	if err := q.authorizeContext(ctx, policy.ActionRead, rbac.ResourceSystem); err != nil {
+		return nil, err
+	}
+	return q.db.GetWorkspaceBuildEvents(ctx, arg)
+}
+
 func (q *querier) GetWorkspaceBuildParameters(ctx context.Context, workspaceBuildID uuid.UUID) ([]database.WorkspaceBuildParameter, error) {
 	// Authorized call to get the workspace build. If we can read the build,
 	// we can read the params.
@@ -3309,6 +3689,33 @@ func (q *querier) GetWorkspaceBuildParametersByBuildIDs(ctx context.Context, wor
 	return q.db.GetAuthorizedWorkspaceBuildParametersByBuildIDs(ctx, workspaceBuildIDs, prep)
 }
 
+func (q *querier) GetWorkspaceBuildStateByBuildID(ctx context.Context, workspaceBuildID uuid.UUID) (database.WorkspaceBuildState, error) {
+	state, err := q.db.GetWorkspaceBuildStateByBuildID(ctx, workspaceBuildID)
+	if err != nil {
+		return database.WorkspaceBuildState{}, err
+	}
+	// Authorized fetch, the caller additionally checks for permission to
+	// update the template, as it does for the state of a build.
+	if _, err := q.GetWorkspaceByID(ctx, state.WorkspaceID); err != nil {
+		return database.WorkspaceBuildState{}, err
+	}
+	return state, nil
+}
+
+func (q *querier) GetWorkspaceBuildStateKeyIDs(ctx context.Context) ([]database.GetWorkspaceBuildStateKeyIDsRow, error) {
+	if err := q.authorizeContext(ctx, policy.ActionRead, rbac.ResourceSystem); err != nil {
+		return nil, err
+	}
+	return q.db.GetWorkspaceBuildStateKeyIDs(ctx)
+}
+
+func (q *querier) GetWorkspaceBuildStatesByWorkspaceID(ctx context.Context, workspaceID uuid.UUID) ([]database.GetWorkspaceBuildStatesByWorkspaceIDRow, error) {
+	if _, err := q.GetWorkspaceByID(ctx, workspaceID); err != nil {
+		return nil, err
+	}
+	return q.db.GetWorkspaceBuildStatesByWorkspaceID(ctx, workspaceID)
+}
+
 func (q *querier) GetWorkspaceBuildStatsByTemplates(ctx context.Context, since time.Time) ([]database.GetWorkspaceBuildStatsByTemplatesRow, error) {
 	if err := q.authorizeContext(ctx, policy.ActionRead, rbac.ResourceSystem); err != nil {
 		return nil, err
@@ -3353,6 +3760,62 @@ func (q *querier) GetWorkspaceByWorkspaceAppID(ctx context.Context, workspaceApp
 	return fetch(q.log, q.auth, q.db.GetWorkspaceByWorkspaceAppID)(ctx, workspaceAppID)
 }
 
+func (q *querier) GetWorkspaceCostInsights(ctx context.Context, arg database.GetWorkspaceCostInsightsParams) ([]database.GetWorkspaceCostInsightsRow, error) {
+	// Used by insights endpoints. Need to check both for auditors and for regular users with template acl perms.
+	if err := q.authorizeContext(ctx, policy.ActionViewInsights, rbac.ResourceTemplate); err != nil {
+		for _, templateID := range arg.TemplateIDs {
+			template, err := q.db.GetTemplateByID(ctx, templateID)
+			if err != nil {
+				return nil, err
+			}
+
+			if err := q.authorizeContext(ctx, policy.ActionViewInsights, template); err != nil {
+				return nil, err
+			}
+		}
+		if len(arg.TemplateIDs) == 0 {
+			if err := q.authorizeContext(ctx, policy.ActionViewInsights, rbac.ResourceTemplate.All()); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return q.db.GetWorkspaceCostInsights(ctx, arg)
+}
+
+func (q *querier) GetWorkspaceCostInsightsByGroup(ctx context.Context, arg database.GetWorkspaceCostInsightsByGroupParams) ([]database.GetWorkspaceCostInsightsByGroupRow, error) {
+	// Used by insights endpoints. Need to check both for auditors and for regular users with template acl perms.
+	if err := q.authorizeContext(ctx, policy.ActionViewInsights, rbac.ResourceTemplate); err != nil {
+		for _, templateID := range arg.TemplateIDs {
+			template, err := q.db.GetTemplateByID(ctx, templateID)
+			if err != nil {
+				return nil, err
+			}
+
+			if err := q.authorizeContext(ctx, policy.ActionViewInsights, template); err != nil {
+				return nil, err
+			}
+		}
+		if len(arg.TemplateIDs) == 0 {
+			if err := q.authorizeContext(ctx, policy.ActionViewInsights, rbac.ResourceTemplate.All()); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return q.db.GetWorkspaceCostInsightsByGroup(ctx, arg)
+}
+
+func (q *querier) GetWorkspaceDriftCheckByJobID(ctx context.Context, jobID uuid.UUID) (database.WorkspaceDriftCheck, error) {
+	check, err := q.db.GetWorkspaceDriftCheckByJobID(ctx, jobID)
+	if err != nil {
+		return database.WorkspaceDriftCheck{}, err
+	}
+	// Authorized fetch, checks can be read by anyone who can read the workspace.
+	if _, err := q.GetWorkspaceByID(ctx, check.WorkspaceID); err != nil {
+		return database.WorkspaceDriftCheck{}, err
+	}
+	return check, nil
+}
+
 func (q *querier) GetWorkspaceModulesByJobID(ctx context.Context, jobID uuid.UUID) ([]database.WorkspaceModule, error) {
 	if err := q.authorizeContext(ctx, policy.ActionRead, rbac.ResourceSystem); err != nil {
 		return nil, err
@@ -3454,6 +3917,16 @@ func (q *querier) GetWorkspaceResourcesByJobID(ctx context.Context, jobID uuid.U
 			return nil, err
 		}
 		obj = workspace
+	case database.ProvisionerJobTypeWorkspaceDriftCheck:
+		check, err := q.db.GetWorkspaceDriftCheckByJobID(ctx, jobID)
+		if err != nil {
+			return nil, err
+		}
+		workspace, err := q.db.GetWorkspaceByID(ctx, check.WorkspaceID)
+		if err != nil {
+			return nil, err
+		}
+		obj = workspace
 	default:
 		return nil, xerrors.Errorf("unknown job type: %s", job.Type)
 	}
@@ -3481,6 +3954,46 @@ func (q *querier) GetWorkspaceResourcesCreatedAfter(ctx context.Context, created
 	return q.db.GetWorkspaceResourcesCreatedAfter(ctx, createdAt)
 }
 
+func (q *querier) GetWorkspaceSnapshotByID(ctx context.Context, id uuid.UUID) (database.WorkspaceSnapshot, error) {
+	snapshot, err := q.db.GetWorkspaceSnapshotByID(ctx, id)
+	if err != nil {
+		return database.WorkspaceSnapshot{}, err
+	}
+
+	// reading a snapshot is more akin to reading the workspace.
+	if _, err := q.GetWorkspaceByID(ctx, snapshot.WorkspaceID); err != nil {
+		return database.WorkspaceSnapshot{}, err
+	}
+
+	return snapshot, nil
+}
+
+func (q *querier) GetWorkspaceSnapshotChain(ctx context.Context, id uuid.UUID) ([]database.WorkspaceSnapshot, error) {
+	// Snapshots in a chain always belong to the same workspace, so
+	// authorizing the requested snapshot is enough.
+	if _, err := q.GetWorkspaceSnapshotByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return q.db.GetWorkspaceSnapshotChain(ctx, id)
+}
+
+func (q *querier) GetWorkspaceSnapshotRestoreByWorkspaceID(ctx context.Context, workspaceID uuid.UUID) (database.WorkspaceSnapshotRestore, error) {
+	if _, err := q.GetWorkspaceByID(ctx, workspaceID); err != nil {
+		return database.WorkspaceSnapshotRestore{}, err
+	}
+
+	return q.db.GetWorkspaceSnapshotRestoreByWorkspaceID(ctx, workspaceID)
+}
+
+func (q *querier) GetWorkspaceSnapshotsByWorkspaceID(ctx context.Context, workspaceID uuid.UUID) ([]database.WorkspaceSnapshot, error) {
+	if _, err := q.GetWorkspaceByID(ctx, workspaceID); err != nil {
+		return nil, err
+	}
+
+	return q.db.GetWorkspaceSnapshotsByWorkspaceID(ctx, workspaceID)
+}
+
 func (q *querier) GetWorkspaceUniqueOwnerCountByTemplateIDs(ctx context.Context, templateIDs []uuid.UUID) ([]database.GetWorkspaceUniqueOwnerCountByTemplateIDsRow, error) {
 	if err := q.authorizeContext(ctx, policy.ActionRead, rbac.ResourceSystem); err != nil {
 		return nil, err
@@ -3488,6 +4001,18 @@ func (q *querier) GetWorkspaceUniqueOwnerCountByTemplateIDs(ctx context.Context,
 	return q.db.GetWorkspaceUniqueOwnerCountByTemplateIDs(ctx, templateIDs)
 }
 
+func (q *querier) GetWorkspaceUpdatePreviewByJobID(ctx context.Context, jobID uuid.UUID) (database.WorkspaceUpdatePreview, error) {
+	preview, err := q.db.GetWorkspaceUpdatePreviewByJobID(ctx, jobID)
+	if err != nil {
+		return database.WorkspaceUpdatePreview{}, err
+	}
+	// Authorized fetch, previews can be read by anyone who can read the workspace.
+	if _, err := q.GetWorkspaceByID(ctx, preview.WorkspaceID); err != nil {
+		return database.WorkspaceUpdatePreview{}, err
+	}
+	return preview, nil
+}
+
 func (q *querier) GetWorkspaces(ctx context.Context, arg database.GetWorkspacesParams) ([]database.GetWorkspacesRow, error) {
 	prep, err := prepareSQLFilter(ctx, q.auth, policy.ActionRead, rbac.ResourceWorkspace.Type)
 	if err != nil {
@@ -3504,6 +4029,13 @@ func (q *querier) GetWorkspacesAndAgentsByOwnerID(ctx context.Context, ownerID u
 	return q.db.GetAuthorizedWorkspacesAndAgentsByOwnerID(ctx, ownerID, prep)
 }
 
+func (q *querier) GetWorkspacesApproachingDeletion(ctx context.Context, before time.Time) ([]database.GetWorkspacesApproachingDeletionRow, error) {
+	if err := q.authorizeContext(ctx, policy.ActionRead, rbac.ResourceSystem); err != nil {
+		return nil, err
+	}
+	return q.db.GetWorkspacesApproachingDeletion(ctx, before)
+}
+
 func (q *querier) GetWorkspacesByTemplateID(ctx context.Context, templateID uuid.UUID) ([]database.WorkspaceTable, error) {
 	if err := q.authorizeContext(ctx, policy.ActionRead, rbac.ResourceSystem); err != nil {
 		return nil, err
@@ -3511,6 +4043,13 @@ func (q *querier) GetWorkspacesByTemplateID(ctx context.Context, templateID uuid
 	return q.db.GetWorkspacesByTemplateID(ctx, templateID)
 }
 
+func (q *querier) GetWorkspacesDueForDriftCheck(ctx context.Context, now time.Time) ([]database.GetWorkspacesDueForDriftCheckRow, error) {
+	if err := q.authorizeContext(ctx, policy.ActionRead, rbac.ResourceSystem); err != nil {
+		return nil, err
+	}
+	return q.db.GetWorkspacesDueForDriftCheck(ctx, now)
+}
+
 func (q *querier) GetWorkspacesEligibleForTransition(ctx context.Context, now time.Time) ([]database.GetWorkspacesEligibleForTransitionRow, error) {
 	return q.db.GetWorkspacesEligibleForTransition(ctx, now)
 }
@@ -3535,6 +4074,34 @@ func (q *querier) InsertAuditLog(ctx context.Context, arg database.InsertAuditLo
 	return insert(q.log, q.auth, rbac.ResourceAuditLog, q.db.InsertAuditLog)(ctx, arg)
 }
 
+func (q *querier) InsertAuditLogArchive(ctx context.Context, arg database.InsertAuditLogArchiveParams) (database.AuditLogArchive, error) {
+	if err := q.authorizeContext(ctx, policy.ActionCreate, rbac.ResourceSystem); err != nil {
+		return database.AuditLogArchive{}, err
+	}
+	return q.db.InsertAuditLogArchive(ctx, arg)
+}
+
+func (q *querier) InsertAuditLogArchiveEntries(ctx context.Context, arg database.InsertAuditLogArchiveEntriesParams) error {
+	if err := q.authorizeContext(ctx, policy.ActionCreate, rbac.ResourceSystem); err != nil {
+		return err
+	}
+	return q.db.InsertAuditLogArchiveEntries(ctx, arg)
+}
+
+func (q *querier) InsertAuditLogCheckpoint(ctx context.Context, arg database.InsertAuditLogCheckpointParams) error {
+	if err := q.authorizeContext(ctx, policy.ActionCreate, rbac.ResourceSystem); err != nil {
+		return err
+	}
+	return q.db.InsertAuditLogCheckpoint(ctx, arg)
+}
+
+func (q *querier) InsertAuditLogHash(ctx context.Context, arg database.InsertAuditLogHashParams) error {
+	if err := q.authorizeContext(ctx, policy.ActionCreate, rbac.ResourceAuditLog); err != nil {
+		return err
+	}
+	return q.db.InsertAuditLogHash(ctx, arg)
+}
+
 func (q *querier) InsertCryptoKey(ctx context.Context, arg database.InsertCryptoKeyParams) (database.CryptoKey, error) {
 	if err := q.authorizeContext(ctx, policy.ActionCreate, rbac.ResourceCryptoKey); err != nil {
 		return database.CryptoKey{}, err
@@ -3637,6 +4204,13 @@ func (q *querier) InsertMissingGroups(ctx context.Context, arg database.InsertMi
 	return q.db.InsertMissingGroups(ctx, arg)
 }
 
+func (q *querier) InsertNotificationRule(ctx context.Context, arg database.InsertNotificationRuleParams) (database.NotificationRule, error) {
+	if err := q.authorizeContext(ctx, policy.ActionUpdate, rbac.ResourceOrganization.WithID(arg.OrganizationID).InOrg(arg.OrganizationID)); err != nil {
+		return database.NotificationRule{}, err
+	}
+	return q.db.InsertNotificationRule(ctx, arg)
+}
+
 func (q *querier) InsertOAuth2ProviderApp(ctx context.Context, arg database.InsertOAuth2ProviderAppParams) (database.OAuth2ProviderApp, error) {
 	if err := q.authorizeContext(ctx, policy.ActionCreate, rbac.ResourceOauth2App); err != nil {
 		return database.OAuth2ProviderApp{}, err
@@ -3688,6 +4262,10 @@ func (q *querier) InsertOrganizationMember(ctx context.Context, arg database.Ins
 	return insert(q.log, q.auth, obj, q.db.InsertOrganizationMember)(ctx, arg)
 }
 
+func (q *querier) InsertPlanPolicy(ctx context.Context, arg database.InsertPlanPolicyParams) (database.PlanPolicy, error) {
+	return insert(q.log, q.auth, rbac.ResourceTemplate.InOrg(arg.OrganizationID), q.db.InsertPlanPolicy)(ctx, arg)
+}
+
 func (q *querier) InsertPreset(ctx context.Context, arg database.InsertPresetParams) (database.TemplateVersionPreset, error) {
 	err := q.authorizeContext(ctx, policy.ActionUpdate, rbac.ResourceTemplate)
 	if err != nil {
@@ -3715,6 +4293,13 @@ func (q *querier) InsertPresetPrebuildSchedule(ctx context.Context, arg database
 	return q.db.InsertPresetPrebuildSchedule(ctx, arg)
 }
 
+func (q *querier) InsertProviderMirrorArchive(ctx context.Context, arg database.InsertProviderMirrorArchiveParams) (int64, error) {
+	if err := q.authorizeContext(ctx, policy.ActionCreate, rbac.ResourceSystem); err != nil {
+		return 0, err
+	}
+	return q.db.InsertProviderMirrorArchive(ctx, arg)
+}
+
 func (q *querier) InsertProvisionerJob(ctx context.Context, arg database.InsertProvisionerJobParams) (database.ProvisionerJob, error) {
 	// TODO: Remove this once we have a proper rbac check for provisioner jobs.
 	// Details in https://github.com/coder/coder/issues/16160
@@ -3760,6 +4345,20 @@ func (q *querier) InsertTemplate(ctx context.Context, arg database.InsertTemplat
 	return q.db.InsertTemplate(ctx, arg)
 }
 
+func (q *querier) InsertTemplatePortShareRule(ctx context.Context, arg database.InsertTemplatePortShareRuleParams) (database.TemplatePortShareRule, error) {
+	template, err := q.db.GetTemplateByID(ctx, arg.TemplateID)
+	if err != nil {
+		return database.TemplatePortShareRule{}, err
+	}
+
+	// Port share rules are part of the template settings.
+	if err := q.authorizeContext(ctx, policy.ActionUpdate, template); err != nil {
+		return database.TemplatePortShareRule{}, err
+	}
+
+	return q.db.InsertTemplatePortShareRule(ctx, arg)
+}
+
 func (q *querier) InsertTemplateVersion(ctx context.Context, arg database.InsertTemplateVersionParams) error {
 	if !arg.TemplateID.Valid {
 		// Making a new template version is the same permission as creating a new template.
@@ -4023,6 +4622,17 @@ func (q *querier) InsertWorkspaceBuildParameters(ctx context.Context, arg databa
 	return q.db.InsertWorkspaceBuildParameters(ctx, arg)
 }
 
+func (q *querier) InsertWorkspaceDriftCheck(ctx context.Context, arg database.InsertWorkspaceDriftCheckParams) (database.WorkspaceDriftCheck, error) {
+	workspace, err := q.db.GetWorkspaceByID(ctx, arg.WorkspaceID)
+	if err != nil {
+		return database.WorkspaceDriftCheck{}, err
+	}
+	if err := q.authorizeContext(ctx, policy.ActionUpdate, workspace); err != nil {
+		return database.WorkspaceDriftCheck{}, err
+	}
+	return q.db.InsertWorkspaceDriftCheck(ctx, arg)
+}
+
 func (q *querier) InsertWorkspaceModule(ctx context.Context, arg database.InsertWorkspaceModuleParams) (database.WorkspaceModule, error) {
 	if err := q.authorizeContext(ctx, policy.ActionCreate, rbac.ResourceSystem); err != nil {
 		return database.WorkspaceModule{}, err
@@ -4048,6 +4658,30 @@ func (q *querier) InsertWorkspaceResourceMetadata(ctx context.Context, arg datab
 	return q.db.InsertWorkspaceResourceMetadata(ctx, arg)
 }
 
+func (q *querier) InsertWorkspaceSnapshot(ctx context.Context, arg database.InsertWorkspaceSnapshotParams) (database.WorkspaceSnapshot, error) {
+	workspace, err := q.db.GetWorkspaceByID(ctx, arg.WorkspaceID)
+	if err != nil {
+		return database.WorkspaceSnapshot{}, err
+	}
+
+	if err := q.authorizeContext(ctx, policy.ActionUpdate, workspace); err != nil {
+		return database.WorkspaceSnapshot{}, err
+	}
+
+	return q.db.InsertWorkspaceSnapshot(ctx, arg)
+}
+
+func (q *querier) InsertWorkspaceUpdatePreview(ctx context.Context, arg database.InsertWorkspaceUpdatePreviewParams) (database.WorkspaceUpdatePreview, error) {
+	workspace, err := q.db.GetWorkspaceByID(ctx, arg.WorkspaceID)
+	if err != nil {
+		return database.WorkspaceUpdatePreview{}, err
+	}
+	if err := q.authorizeContext(ctx, policy.ActionUpdate, workspace); err != nil {
+		return database.WorkspaceUpdatePreview{}, err
+	}
+	return q.db.InsertWorkspaceUpdatePreview(ctx, arg)
+}
+
 func (q *querier) ListProvisionerKeysByOrganization(ctx context.Context, organizationID uuid.UUID) ([]database.ProvisionerKey, error) {
 	return fetchWithPostFilter(q.auth, policy.ActionRead, q.db.ListProvisionerKeysByOrganization)(ctx, organizationID)
 }
@@ -4192,6 +4826,21 @@ func (q *querier) UpdateAPIKeyByID(ctx context.Context, arg database.UpdateAPIKe
 	return update(q.log, q.auth, fetch, q.db.UpdateAPIKeyByID)(ctx, arg)
 }
 
+func (q *querier) UpdateAuditLogArchiveRestoredAt(ctx context.Context, arg database.UpdateAuditLogArchiveRestoredAtParams) error {
+	// Restoring an archive inserts its audit logs again.
+	if err := q.authorizeContext(ctx, policy.ActionCreate, rbac.ResourceAuditLog); err != nil {
+		return err
+	}
+	return q.db.UpdateAuditLogArchiveRestoredAt(ctx, arg)
+}
+
+func (q *querier) UpdateAuditLogExportCursor(ctx context.Context, arg database.UpdateAuditLogExportCursorParams) (int64, error) {
+	if err := q.authorizeContext(ctx, policy.ActionUpdate, rbac.ResourceSystem); err != nil {
+		return 0, err
+	}
+	return q.db.UpdateAuditLogExportCursor(ctx, arg)
+}
+
 func (q *querier) UpdateCryptoKeyDeletesAt(ctx context.Context, arg database.UpdateCryptoKeyDeletesAtParams) (database.CryptoKey, error) {
 	if err := q.authorizeContext(ctx, policy.ActionUpdate, rbac.ResourceCryptoKey); err != nil {
 		return database.CryptoKey{}, err
@@ -4310,6 +4959,24 @@ func (q *querier) UpdateMemoryResourceMonitor(ctx context.Context, arg database.
 	return q.db.UpdateMemoryResourceMonitor(ctx, arg)
 }
 
+func (q *querier) UpdateNotificationRule(ctx context.Context, arg database.UpdateNotificationRuleParams) (database.NotificationRule, error) {
+	rule, err := q.db.GetNotificationRuleByID(ctx, arg.ID)
+	if err != nil {
+		return database.NotificationRule{}, err
+	}
+	if err := q.authorizeContext(ctx, policy.ActionUpdate, rbac.ResourceOrganization.WithID(rule.OrganizationID).InOrg(rule.OrganizationID)); err != nil {
+		return database.NotificationRule{}, err
+	}
+	return q.db.UpdateNotificationRule(ctx, arg)
+}
+
+func (q *querier) UpdateNotificationRuleEvaluation(ctx context.Context, arg database.UpdateNotificationRuleEvaluationParams) error {
+	if err := q.authorizeContext(ctx, policy.ActionUpdate, rbac.ResourceSystem); err != nil {
+		return err
+	}
+	return q.db.UpdateNotificationRuleEvaluation(ctx, arg)
+}
+
 func (q *querier) UpdateNotificationTemplateMethodByID(ctx context.Context, arg database.UpdateNotificationTemplateMethodByIDParams) (database.NotificationTemplate, error) {
 	if err := q.authorizeContext(ctx, policy.ActionUpdate, rbac.ResourceNotificationTemplate); err != nil {
 		return database.NotificationTemplate{}, err
@@ -4348,6 +5015,20 @@ func (q *querier) UpdateOrganizationDeletedByID(ctx context.Context, arg databas
 	return deleteQ(q.log, q.auth, q.db.GetOrganizationByID, deleteF)(ctx, arg.ID)
 }
 
+func (q *querier) UpdateOrganizationHolidayCalendar(ctx context.Context, arg database.UpdateOrganizationHolidayCalendarParams) error {
+	if err := q.authorizeContext(ctx, policy.ActionUpdate, rbac.ResourceOrganization.WithID(arg.OrganizationID).InOrg(arg.OrganizationID)); err != nil {
+		return err
+	}
+	return q.db.UpdateOrganizationHolidayCalendar(ctx, arg)
+}
+
+func (q *querier) UpdatePlanPolicyByID(ctx context.Context, arg database.UpdatePlanPolicyByIDParams) (database.PlanPolicy, error) {
+	fetch := func(ctx context.Context, arg database.UpdatePlanPolicyByIDParams) (database.PlanPolicy, error) {
+		return q.db.GetPlanPolicyByID(ctx, arg.ID)
+	}
+	return updateWithReturn(q.log, q.auth, fetch, q.db.UpdatePlanPolicyByID)(ctx, arg)
+}
+
 func (q *querier) UpdatePresetPrebuildStatus(ctx context.Context, arg database.UpdatePresetPrebuildStatusParams) error {
 	preset, err := q.db.GetPresetByID(ctx, arg.PresetID)
 	if err != nil {
@@ -4418,6 +5099,21 @@ func (q *querier) UpdateProvisionerJobWithCancelByID(ctx context.Context, arg da
 			}
 		}
 
+		err = q.authorizeContext(ctx, policy.ActionUpdate, workspace)
+		if err != nil {
+			return err
+		}
+	case database.ProvisionerJobTypeWorkspaceDriftCheck:
+		// Drift checks never change the workspace, so anyone who can update
+		// the workspace can cancel them.
+		check, err := q.db.GetWorkspaceDriftCheckByJobID(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+		workspace, err := q.db.GetWorkspaceByID(ctx, check.WorkspaceID)
+		if err != nil {
+			return err
+		}
 		err = q.authorizeContext(ctx, policy.ActionUpdate, workspace)
 		if err != nil {
 			return err
@@ -4501,6 +5197,17 @@ func (q *querier) UpdateTemplateActiveVersionByID(ctx context.Context, arg datab
 	return update(q.log, q.auth, fetch, q.db.UpdateTemplateActiveVersionByID)(ctx, arg)
 }
 
+func (q *querier) UpdateTemplateAutostopWindows(ctx context.Context, arg database.UpdateTemplateAutostopWindowsParams) error {
+	template, err := q.db.GetTemplateByID(ctx, arg.TemplateID)
+	if err != nil {
+		return err
+	}
+	if err := q.authorizeContext(ctx, policy.ActionUpdate, template); err != nil {
+		return err
+	}
+	return q.db.UpdateTemplateAutostopWindows(ctx, arg)
+}
+
 // Deprecated: use SoftDeleteTemplateByID instead.
 func (q *querier) UpdateTemplateDeletedByID(ctx context.Context, arg database.UpdateTemplateDeletedByIDParams) error {
 	return q.SoftDeleteTemplateByID(ctx, arg.ID)
@@ -4616,6 +5323,17 @@ func (q *querier) UpdateTemplateWorkspacesLastUsedAt(ctx context.Context, arg da
 	return fetchAndExec(q.log, q.auth, policy.ActionUpdate, fetch, q.db.UpdateTemplateWorkspacesLastUsedAt)(ctx, arg)
 }
 
+func (q *querier) UpdateUserAutostopWindows(ctx context.Context, arg database.UpdateUserAutostopWindowsParams) error {
+	u, err := q.db.GetUserByID(ctx, arg.UserID)
+	if err != nil {
+		return err
+	}
+	if err := q.authorizeContext(ctx, policy.ActionUpdatePersonal, u); err != nil {
+		return err
+	}
+	return q.db.UpdateUserAutostopWindows(ctx, arg)
+}
+
 func (q *querier) UpdateUserDeletedByID(ctx context.Context, id uuid.UUID) error {
 	return deleteQ(q.log, q.auth, q.db.GetUserByID, q.db.UpdateUserDeletedByID)(ctx, id)
 }
@@ -4694,6 +5412,13 @@ func (q *querier) UpdateUserLoginType(ctx context.Context, arg database.UpdateUs
 	return q.db.UpdateUserLoginType(ctx, arg)
 }
 
+func (q *querier) UpdateUserNotificationDigestPreferences(ctx context.Context, arg database.UpdateUserNotificationDigestPreferencesParams) error {
+	if err := q.authorizeContext(ctx, policy.ActionUpdate, rbac.ResourceNotificationPreference.WithOwner(arg.UserID.String())); err != nil {
+		return err
+	}
+	return q.db.UpdateUserNotificationDigestPreferences(ctx, arg)
+}
+
 func (q *querier) UpdateUserNotificationPreferences(ctx context.Context, arg database.UpdateUserNotificationPreferencesParams) (int64, error) {
 	if err := q.authorizeContext(ctx, policy.ActionUpdate, rbac.ResourceNotificationPreference.WithOwner(arg.UserID.String())); err != nil {
 		return -1, err
@@ -4970,6 +5695,13 @@ func (q *querier) UpdateWorkspaceDormantDeletingAt(ctx context.Context, arg data
 	return updateWithReturn(q.log, q.auth, fetch, q.db.UpdateWorkspaceDormantDeletingAt)(ctx, arg)
 }
 
+func (q *querier) UpdateWorkspaceDriftCheckByJobID(ctx context.Context, arg database.UpdateWorkspaceDriftCheckByJobIDParams) (database.WorkspaceDriftCheck, error) {
+	if err := q.authorizeContext(ctx, policy.ActionUpdate, rbac.ResourceSystem); err != nil {
+		return database.WorkspaceDriftCheck{}, err
+	}
+	return q.db.UpdateWorkspaceDriftCheckByJobID(ctx, arg)
+}
+
 func (q *querier) UpdateWorkspaceLastUsedAt(ctx context.Context, arg database.UpdateWorkspaceLastUsedAtParams) error {
 	fetch := func(ctx context.Context, arg database.UpdateWorkspaceLastUsedAtParams) (database.Workspace, error) {
 		return q.db.GetWorkspaceByID(ctx, arg.ID)
@@ -5005,6 +5737,13 @@ func (q *querier) UpdateWorkspaceTTL(ctx context.Context, arg database.UpdateWor
 	return update(q.log, q.auth, fetch, q.db.UpdateWorkspaceTTL)(ctx, arg)
 }
 
+func (q *querier) UpdateWorkspaceUpdatePreviewByJobID(ctx context.Context, arg database.UpdateWorkspaceUpdatePreviewByJobIDParams) (database.WorkspaceUpdatePreview, error) {
+	if err := q.authorizeContext(ctx, policy.ActionUpdate, rbac.ResourceSystem); err != nil {
+		return database.WorkspaceUpdatePreview{}, err
+	}
+	return q.db.UpdateWorkspaceUpdatePreviewByJobID(ctx, arg)
+}
+
 func (q *querier) UpdateWorkspacesDormantDeletingAtByTemplateID(ctx context.Context, arg database.UpdateWorkspacesDormantDeletingAtByTemplateIDParams) ([]database.WorkspaceTable, error) {
 	template, err := q.db.GetTemplateByID(ctx, arg.TemplateID)
 	if err != nil {
@@ -5118,6 +5857,13 @@ func (q *querier) UpsertPrebuildsSettings(ctx context.Context, value string) err
 	return q.db.UpsertPrebuildsSettings(ctx, value)
 }
 
+func (q *querier) UpsertProviderMirrorToken(ctx context.Context, arg database.UpsertProviderMirrorTokenParams) error {
+	if err := q.authorizeContext(ctx, policy.ActionCreate, rbac.ResourceSystem); err != nil {
+		return err
+	}
+	return q.db.UpsertProviderMirrorToken(ctx, arg)
+}
+
 func (q *querier) UpsertProvisionerDaemon(ctx context.Context, arg database.UpsertProvisionerDaemonParams) (database.ProvisionerDaemon, error) {
 	res := rbac.ResourceProvisionerDaemon.InOrg(arg.OrganizationID)
 	if arg.Tags[provisionersdk.TagScope] == provisionersdk.ScopeUser {
@@ -5192,6 +5938,20 @@ func (q *querier) UpsertTemplateUsageStats(ctx context.Context) error {
 	return q.db.UpsertTemplateUsageStats(ctx)
 }
 
+func (q *querier) UpsertUserChatIdentity(ctx context.Context, arg database.UpsertUserChatIdentityParams) (database.UserChatIdentity, error) {
+	if err := q.authorizeContext(ctx, policy.ActionUpdate, rbac.ResourceNotificationPreference.WithOwner(arg.UserID.String())); err != nil {
+		return database.UserChatIdentity{}, err
+	}
+	return q.db.UpsertUserChatIdentity(ctx, arg)
+}
+
+func (q *querier) UpsertUserNotificationSchedule(ctx context.Context, arg database.UpsertUserNotificationScheduleParams) (database.UserNotificationSchedule, error) {
+	if err := q.authorizeContext(ctx, policy.ActionUpdate, rbac.ResourceNotificationPreference.WithOwner(arg.UserID.String())); err != nil {
+		return database.UserNotificationSchedule{}, err
+	}
+	return q.db.UpsertUserNotificationSchedule(ctx, arg)
+}
+
 func (q *querier) UpsertWebpushVAPIDKeys(ctx context.Context, arg database.UpsertWebpushVAPIDKeysParams) error {
 	if err := q.authorizeContext(ctx, policy.ActionUpdate, rbac.ResourceDeploymentConfig); err != nil {
 		return err
@@ -5237,6 +5997,41 @@ func (q *querier) UpsertWorkspaceAppAuditSession(ctx context.Context, arg databa
 	return q.db.UpsertWorkspaceAppAuditSession(ctx, arg)
 }
 
+func (q *querier) UpsertWorkspaceBuildState(ctx context.Context, arg database.UpsertWorkspaceBuildStateParams) error {
+	if err := q.authorizeContext(ctx, policy.ActionUpdate, rbac.ResourceSystem); err != nil {
+		return err
+	}
+	return q.db.UpsertWorkspaceBuildState(ctx, arg)
+}
+
+func (q *querier) UpsertWorkspaceCostUsage(ctx context.Context) error {
+	if err := q.authorizeContext(ctx, policy.ActionUpdate, rbac.ResourceSystem); err != nil {
+		return err
+	}
+	return q.db.UpsertWorkspaceCostUsage(ctx)
+}
+
+func (q *querier) UpsertWorkspaceSnapshotRestore(ctx context.Context, arg database.UpsertWorkspaceSnapshotRestoreParams) (database.WorkspaceSnapshotRestore, error) {
+	workspace, err := q.db.GetWorkspaceByID(ctx, arg.WorkspaceID)
+	if err != nil {
+		return database.WorkspaceSnapshotRestore{}, err
+	}
+
+	// restoring a snapshot overwrites files in the workspace.
+	if err := q.authorizeContext(ctx, policy.ActionUpdate, workspace); err != nil {
+		return database.WorkspaceSnapshotRestore{}, err
+	}
+
+	return q.db.UpsertWorkspaceSnapshotRestore(ctx, arg)
+}
+
+func (q *querier) VerifyUserChatIdentity(ctx context.Context, arg database.VerifyUserChatIdentityParams) (database.UserChatIdentity, error) {
+	if err := q.authorizeContext(ctx, policy.ActionUpdate, rbac.ResourceNotificationPreference.WithOwner(arg.UserID.String())); err != nil {
+		return database.UserChatIdentity{}, err
+	}
+	return q.db.VerifyUserChatIdentity(ctx, arg)
+}
+
 func (q *querier) GetAuthorizedTemplates(ctx context.Context, arg database.GetTemplatesWithFilterParams, _ rbac.PreparedAuthorized) ([]database.Template, error) {
 	// TODO Delete this function, all GetTemplates should be authorized. For now just call getTemplates on the authz querier.
 	return q.GetTemplatesWithFilter(ctx, arg)
@@ -2,6 +2,7 @@ package dbauthz_test
 
 import (
 	"context"
+	"crypto/sha256"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -337,6 +338,193 @@ func (s *MethodTestSuite) TestAuditLogs() {
 		_ = dbgen.AuditLog(s.T(), db, database.AuditLog{})
 		check.Args(database.CountAuditLogsParams{}, emptyPreparedAuthorized{}).Asserts(rbac.ResourceAuditLog, policy.ActionRead)
 	}))
+	s.Run("GetAuditLogsForExport", s.Subtest(func(db database.Store, check *expects) {
+		dbtestutil.DisableForeignKeysAndTriggers(s.T(), db)
+		_ = dbgen.AuditLog(s.T(), db, database.AuditLog{})
+		check.Args(database.GetAuditLogsForExportParams{
+			LimitOpt: 10,
+		}).Asserts(rbac.ResourceAuditLog, policy.ActionRead)
+	}))
+	s.Run("GetAuditLogExportCursor", s.Subtest(func(db database.Store, check *expects) {
+		_, err := db.AcquireAuditLogExportCursor(context.Background(), database.AcquireAuditLogExportCursorParams{
+			Sink:           "syslog",
+			Now:            dbtime.Now(),
+			LeasedBy:       uuid.New(),
+			LeaseExpiresAt: dbtime.Now().Add(time.Minute),
+		})
+		require.NoError(s.T(), err)
+		check.Args("syslog").Asserts(rbac.ResourceSystem, policy.ActionRead)
+	}))
+	s.Run("AcquireAuditLogExportCursor", s.Subtest(func(db database.Store, check *expects) {
+		check.Args(database.AcquireAuditLogExportCursorParams{
+			Sink:           "syslog",
+			Now:            dbtime.Now(),
+			LeasedBy:       uuid.New(),
+			LeaseExpiresAt: dbtime.Now().Add(time.Minute),
+		}).Asserts(rbac.ResourceSystem, policy.ActionUpdate)
+	}))
+	s.Run("UpdateAuditLogExportCursor", s.Subtest(func(db database.Store, check *expects) {
+		replicaID := uuid.New()
+		_, err := db.AcquireAuditLogExportCursor(context.Background(), database.AcquireAuditLogExportCursorParams{
+			Sink:           "syslog",
+			Now:            dbtime.Now(),
+			LeasedBy:       replicaID,
+			LeaseExpiresAt: dbtime.Now().Add(time.Minute),
+		})
+		require.NoError(s.T(), err)
+		check.Args(database.UpdateAuditLogExportCursorParams{
+			LastXactID:     1,
+			LastID:         uuid.New(),
+			UpdatedAt:      dbtime.Now(),
+			LeaseExpiresAt: dbtime.Now().Add(time.Minute),
+			Sink:           "syslog",
+			LeasedBy:       replicaID,
+		}).Asserts(rbac.ResourceSystem, policy.ActionUpdate).Returns(int64(1))
+	}))
+	s.Run("GetAuditLogsByIDs", s.Subtest(func(db database.Store, check *expects) {
+		dbtestutil.DisableForeignKeysAndTriggers(s.T(), db)
+		alog := dbgen.AuditLog(s.T(), db, database.AuditLog{})
+		check.Args([]uuid.UUID{alog.ID}).Asserts(rbac.ResourceAuditLog, policy.ActionRead).Returns([]database.AuditLog{alog})
+	}))
+	s.Run("InsertAuditLogHash", s.Subtest(func(db database.Store, check *expects) {
+		check.Args(database.InsertAuditLogHashParams{
+			Sequence:       1,
+			AuditLogID:     uuid.New(),
+			AuditLogTime:   dbtime.Now(),
+			AuditLogXactID: 1,
+			PreviousHash:   []byte{},
+			Hash:           []byte("hash"),
+		}).Asserts(rbac.ResourceAuditLog, policy.ActionCreate)
+	}))
+	s.Run("GetLatestAuditLogHash", s.Subtest(func(db database.Store, check *expects) {
+		hash := database.InsertAuditLogHashParams{
+			Sequence:       1,
+			AuditLogID:     uuid.New(),
+			AuditLogTime:   dbtime.Now(),
+			AuditLogXactID: 1,
+			PreviousHash:   []byte{},
+			Hash:           []byte("hash"),
+		}
+		require.NoError(s.T(), db.InsertAuditLogHash(context.Background(), hash))
+		check.Args().Asserts(rbac.ResourceAuditLog, policy.ActionRead).Returns(database.AuditLogHash(hash))
+	}))
+	s.Run("GetAuditLogHashSequenceRange", s.Subtest(func(db database.Store, check *expects) {
+		check.Args(database.GetAuditLogHashSequenceRangeParams{
+			FromTime: dbtime.Now().Add(-time.Hour),
+			ToTime:   dbtime.Now(),
+		}).Asserts(rbac.ResourceAuditLog, policy.ActionRead)
+	}))
+	s.Run("GetAuditLogHashes", s.Subtest(func(db database.Store, check *expects) {
+		check.Args(database.GetAuditLogHashesParams{
+			AfterSequence: 0,
+			LastSequence:  10,
+			LimitOpt:      10,
+		}).Asserts(rbac.ResourceAuditLog, policy.ActionRead)
+	}))
+	s.Run("GetUnchainedAuditLogs", s.Subtest(func(db database.Store, check *expects) {
+		check.Args(database.GetUnchainedAuditLogsParams{
+			FromTime:   dbtime.Now().Add(-time.Hour),
+			ToTime:     dbtime.Now(),
+			HeadXactID: 1,
+			HeadID:     uuid.New(),
+			LimitOpt:   10,
+		}).Asserts(rbac.ResourceAuditLog, policy.ActionRead)
+	}))
+	s.Run("GetAuditLogsToChain", s.Subtest(func(db database.Store, check *expects) {
+		dbtestutil.DisableForeignKeysAndTriggers(s.T(), db)
+		alog := dbgen.AuditLog(s.T(), db, database.AuditLog{})
+		check.Args(database.GetAuditLogsToChainParams{
+			LimitOpt: 10,
+		}).Asserts(rbac.ResourceAuditLog, policy.ActionRead).Returns([]database.AuditLog{alog})
+	}))
+	s.Run("InsertAuditLogCheckpoint", s.Subtest(func(db database.Store, check *expects) {
+		check.Args(database.InsertAuditLogCheckpointParams{
+			Sequence:    1,
+			Hash:        []byte("hash"),
+			KeySequence: 1,
+			Signature:   []byte("signature"),
+			CreatedAt:   dbtime.Now(),
+		}).Asserts(rbac.ResourceSystem, policy.ActionCreate)
+	}))
+	s.Run("GetLatestAuditLogCheckpoint", s.Subtest(func(db database.Store, check *expects) {
+		checkpoint := database.InsertAuditLogCheckpointParams{
+			Sequence:    1,
+			Hash:        []byte("hash"),
+			KeySequence: 1,
+			Signature:   []byte("signature"),
+			CreatedAt:   dbtime.Now(),
+		}
+		require.NoError(s.T(), db.InsertAuditLogCheckpoint(context.Background(), checkpoint))
+		check.Args().Asserts(rbac.ResourceAuditLog, policy.ActionRead).Returns(database.AuditLogCheckpoint(checkpoint))
+	}))
+	s.Run("GetAuditLogCheckpoints", s.Subtest(func(db database.Store, check *expects) {
+		check.Args(database.GetAuditLogCheckpointsParams{
+			FirstSequence: 1,
+			LastSequence:  10,
+		}).Asserts(rbac.ResourceAuditLog, policy.ActionRead)
+	}))
+	s.Run("GetExpiredAuditLogs", s.Subtest(func(db database.Store, check *expects) {
+		check.Args(database.GetExpiredAuditLogsParams{
+			MaxCutoff:         dbtime.Now(),
+			RuleResourceTypes: []string{""},
+			RuleActions:       []string{string(database.AuditActionConnect)},
+			RuleCutoffs:       []time.Time{dbtime.Now()},
+			LimitOpt:          10,
+		}).Asserts(rbac.ResourceAuditLog, policy.ActionRead)
+	}))
+	s.Run("InsertAuditLogArchive", s.Subtest(func(db database.Store, check *expects) {
+		file := dbgen.File(s.T(), db, database.File{})
+		check.Args(database.InsertAuditLogArchiveParams{
+			ID:            uuid.New(),
+			CreatedAt:     dbtime.Now(),
+			FileName:      "audit-logs.ndjson.gz",
+			FileID:        file.ID,
+			OldestTime:    dbtime.Now(),
+			NewestTime:    dbtime.Now(),
+			AuditLogCount: 1,
+			Sha256:        []byte("sha256"),
+		}).Asserts(rbac.ResourceSystem, policy.ActionCreate)
+	}))
+	s.Run("InsertAuditLogArchiveEntries", s.Subtest(func(db database.Store, check *expects) {
+		check.Args(database.InsertAuditLogArchiveEntriesParams{
+			AuditLogIDs: []uuid.UUID{uuid.New()},
+			ArchiveID:   uuid.New(),
+		}).Asserts(rbac.ResourceSystem, policy.ActionCreate)
+	}))
+	s.Run("DeleteAuditLogsByIDs", s.Subtest(func(db database.Store, check *expects) {
+		alog := dbgen.AuditLog(s.T(), db, database.AuditLog{})
+		check.Args([]uuid.UUID{alog.ID}).Asserts(rbac.ResourceSystem, policy.ActionDelete)
+	}))
+	s.Run("DeleteRestoredAuditLogs", s.Subtest(func(db database.Store, check *expects) {
+		check.Args(dbtime.Now()).Asserts(rbac.ResourceSystem, policy.ActionDelete)
+	}))
+	s.Run("GetAuditLogArchives", s.Subtest(func(db database.Store, check *expects) {
+		check.Args().Asserts(rbac.ResourceAuditLog, policy.ActionRead)
+	}))
+	s.Run("GetAuditLogArchiveByID", s.Subtest(func(db database.Store, check *expects) {
+		file := dbgen.File(s.T(), db, database.File{})
+		archive, err := db.InsertAuditLogArchive(context.Background(), database.InsertAuditLogArchiveParams{
+			ID:            uuid.New(),
+			CreatedAt:     dbtime.Now(),
+			FileName:      "audit-logs.ndjson.gz",
+			FileID:        file.ID,
+			OldestTime:    dbtime.Now(),
+			NewestTime:    dbtime.Now(),
+			AuditLogCount: 1,
+			Sha256:        []byte("sha256"),
+		})
+		require.NoError(s.T(), err)
+		check.Args(archive.ID).Asserts(rbac.ResourceAuditLog, policy.ActionRead).Returns(archive)
+	}))
+	s.Run("UpdateAuditLogArchiveRestoredAt", s.Subtest(func(db database.Store, check *expects) {
+		check.Args(database.UpdateAuditLogArchiveRestoredAtParams{
+			ID:         uuid.New(),
+			RestoredAt: sql.NullTime{Time: dbtime.Now(), Valid: true},
+		}).Asserts(rbac.ResourceAuditLog, policy.ActionCreate)
+	}))
+	s.Run("GetArchivedAuditLogIDs", s.Subtest(func(db database.Store, check *expects) {
+		check.Args([]uuid.UUID{uuid.New()}).Asserts(rbac.ResourceAuditLog, policy.ActionRead)
+	}))
 }
 
 func (s *MethodTestSuite) TestFile() {
@@ -836,6 +1024,24 @@ func (s *MethodTestSuite) TestOrganization() {
 		o := dbgen.Organization(s.T(), db, database.Organization{})
 		check.Args(o.ID).Asserts(o, policy.ActionRead).Returns(o)
 	}))
+	s.Run("GetOrganizationHolidays", s.Subtest(func(db database.Store, check *expects) {
+		o := dbgen.Organization(s.T(), db, database.Organization{})
+		check.Args(database.GetOrganizationHolidaysParams{
+			OrganizationID: o.ID,
+			After:          dbtime.Now(),
+		}).Asserts(o, policy.ActionRead)
+	}))
+	s.Run("UpdateOrganizationHolidayCalendar", s.Subtest(func(db database.Store, check *expects) {
+		o := dbgen.Organization(s.T(), db, database.Organization{})
+		check.Args(database.UpdateOrganizationHolidayCalendarParams{
+			OrganizationID: o.ID,
+			Calendar:       "holidays",
+			IDs:            []uuid.UUID{uuid.New()},
+			Dates:          []time.Time{time.Date(2026, time.December, 25, 0, 0, 0, 0, time.UTC)},
+			Names:          []string{"Christmas Day"},
+			CreatedAt:      dbtime.Now(),
+		}).Asserts(o, policy.ActionUpdate).Returns()
+	}))
 	s.Run("GetOrganizationResourceCountByID", s.Subtest(func(db database.Store, check *expects) {
 		u := dbgen.User(s.T(), db, database.User{})
 		o := dbgen.Organization(s.T(), db, database.Organization{})
@@ -1286,6 +1492,30 @@ func (s *MethodTestSuite) TestTemplate() {
 		t1 := dbgen.Template(s.T(), db, database.Template{})
 		check.Args(t1.ID).Asserts(t1, policy.ActionUpdate)
 	}))
+	s.Run("GetTemplateAutostopWindows", s.Subtest(func(db database.Store, check *expects) {
+		dbtestutil.DisableForeignKeysAndTriggers(s.T(), db)
+		t1 := dbgen.Template(s.T(), db, database.Template{})
+		err := db.UpdateTemplateAutostopWindows(context.Background(), database.UpdateTemplateAutostopWindowsParams{
+			TemplateID:  t1.ID,
+			Weekdays:    []int16{int16(time.Friday)},
+			StopMinutes: []int32{14 * 60},
+		})
+		require.NoError(s.T(), err)
+		check.Args(t1.ID).Asserts(t1, policy.ActionRead).Returns([]database.TemplateAutostopWindow{{
+			TemplateID: t1.ID,
+			Weekday:    int16(time.Friday),
+			StopMinute: 14 * 60,
+		}})
+	}))
+	s.Run("UpdateTemplateAutostopWindows", s.Subtest(func(db database.Store, check *expects) {
+		dbtestutil.DisableForeignKeysAndTriggers(s.T(), db)
+		t1 := dbgen.Template(s.T(), db, database.Template{})
+		check.Args(database.UpdateTemplateAutostopWindowsParams{
+			TemplateID:  t1.ID,
+			Weekdays:    []int16{int16(time.Friday)},
+			StopMinutes: []int32{14 * 60},
+		}).Asserts(t1, policy.ActionUpdate).Returns()
+	}))
 	s.Run("GetTemplateVersionByID", s.Subtest(func(db database.Store, check *expects) {
 		dbtestutil.DisableForeignKeysAndTriggers(s.T(), db)
 		t1 := dbgen.Template(s.T(), db, database.Template{})
@@ -1553,6 +1783,15 @@ func (s *MethodTestSuite) TestTemplate() {
 	s.Run("UpsertTemplateUsageStats", s.Subtest(func(db database.Store, check *expects) {
 		check.Asserts(rbac.ResourceSystem, policy.ActionUpdate)
 	}))
+	s.Run("GetWorkspaceCostInsights", s.Subtest(func(db database.Store, check *expects) {
+		check.Args(database.GetWorkspaceCostInsightsParams{}).Asserts(rbac.ResourceTemplate, policy.ActionViewInsights)
+	}))
+	s.Run("GetWorkspaceCostInsightsByGroup", s.Subtest(func(db database.Store, check *expects) {
+		check.Args(database.GetWorkspaceCostInsightsByGroupParams{}).Asserts(rbac.ResourceTemplate, policy.ActionViewInsights)
+	}))
+	s.Run("UpsertWorkspaceCostUsage", s.Subtest(func(db database.Store, check *expects) {
+		check.Asserts(rbac.ResourceSystem, policy.ActionUpdate)
+	}))
 }
 
 func (s *MethodTestSuite) TestUser() {
@@ -1651,6 +1890,28 @@ func (s *MethodTestSuite) TestUser() {
 			ID: u.ID,
 		}).Asserts(u, policy.ActionUpdatePersonal)
 	}))
+	s.Run("GetUserAutostopWindows", s.Subtest(func(db database.Store, check *expects) {
+		u := dbgen.User(s.T(), db, database.User{})
+		err := db.UpdateUserAutostopWindows(context.Background(), database.UpdateUserAutostopWindowsParams{
+			UserID:      u.ID,
+			Weekdays:    []int16{int16(time.Monday)},
+			StopMinutes: []int32{18 * 60},
+		})
+		require.NoError(s.T(), err)
+		check.Args(u.ID).Asserts(u, policy.ActionRead).Returns([]database.UserAutostopWindow{{
+			UserID:     u.ID,
+			Weekday:    int16(time.Monday),
+			StopMinute: 18 * 60,
+		}})
+	}))
+	s.Run("UpdateUserAutostopWindows", s.Subtest(func(db database.Store, check *expects) {
+		u := dbgen.User(s.T(), db, database.User{})
+		check.Args(database.UpdateUserAutostopWindowsParams{
+			UserID:      u.ID,
+			Weekdays:    []int16{int16(time.Monday)},
+			StopMinutes: []int32{18 * 60},
+		}).Asserts(u, policy.ActionUpdatePersonal).Returns()
+	}))
 	s.Run("UpdateUserLastSeenAt", s.Subtest(func(db database.Store, check *expects) {
 		u := dbgen.User(s.T(), db, database.User{})
 		check.Args(database.UpdateUserLastSeenAtParams{
@@ -3455,6 +3716,441 @@ func (s *MethodTestSuite) TestWorkspacePortSharing() {
 		_ = dbgen.WorkspaceAgentPortShare(s.T(), db, database.WorkspaceAgentPortShare{WorkspaceID: ws.ID})
 		check.Args(tpl.ID).Asserts(tpl, policy.ActionUpdate).Returns()
 	}))
+	s.Run("GetTemplatePortShareRules", s.Subtest(func(db database.Store, check *expects) {
+		u := dbgen.User(s.T(), db, database.User{})
+		org := dbgen.Organization(s.T(), db, database.Organization{})
+		tpl := dbgen.Template(s.T(), db, database.Template{
+			OrganizationID: org.ID,
+			CreatedBy:      u.ID,
+		})
+		rule := dbgen.TemplatePortShareRule(s.T(), db, database.TemplatePortShareRule{TemplateID: tpl.ID})
+		check.Args(tpl.ID).Asserts(tpl, policy.ActionRead).Returns([]database.TemplatePortShareRule{rule})
+	}))
+	s.Run("InsertTemplatePortShareRule", s.Subtest(func(db database.Store, check *expects) {
+		u := dbgen.User(s.T(), db, database.User{})
+		org := dbgen.Organization(s.T(), db, database.Organization{})
+		tpl := dbgen.Template(s.T(), db, database.Template{
+			OrganizationID: org.ID,
+			CreatedBy:      u.ID,
+		})
+		check.Args(database.InsertTemplatePortShareRuleParams{
+			ID:         uuid.New(),
+			TemplateID: tpl.ID,
+			PortStart:  8080,
+			PortEnd:    8080,
+			ShareLevel: database.AppSharingLevelPublic,
+			Protocol:   database.PortShareProtocolHttp,
+		}).Asserts(tpl, policy.ActionUpdate)
+	}))
+	s.Run("DeleteTemplatePortShareRules", s.Subtest(func(db database.Store, check *expects) {
+		u := dbgen.User(s.T(), db, database.User{})
+		org := dbgen.Organization(s.T(), db, database.Organization{})
+		tpl := dbgen.Template(s.T(), db, database.Template{
+			OrganizationID: org.ID,
+			CreatedBy:      u.ID,
+		})
+		_ = dbgen.TemplatePortShareRule(s.T(), db, database.TemplatePortShareRule{TemplateID: tpl.ID})
+		check.Args(tpl.ID).Asserts(tpl, policy.ActionUpdate).Returns()
+	}))
+}
+
+func (s *MethodTestSuite) TestWorkspaceSnapshots() {
+	setup := func(db database.Store) database.WorkspaceTable {
+		u := dbgen.User(s.T(), db, database.User{})
+		org := dbgen.Organization(s.T(), db, database.Organization{})
+		tpl := dbgen.Template(s.T(), db, database.Template{
+			OrganizationID: org.ID,
+			CreatedBy:      u.ID,
+		})
+		return dbgen.Workspace(s.T(), db, database.WorkspaceTable{
+			OwnerID:        u.ID,
+			OrganizationID: org.ID,
+			TemplateID:     tpl.ID,
+		})
+	}
+	s.Run("InsertWorkspaceSnapshot", s.Subtest(func(db database.Store, check *expects) {
+		ws := setup(db)
+		check.Args(database.InsertWorkspaceSnapshotParams{
+			ID:          uuid.New(),
+			WorkspaceID: ws.ID,
+			AgentName:   "main",
+			BuildNumber: 1,
+			Trigger:     database.WorkspaceSnapshotTriggerStop,
+			Storage:     database.WorkspaceSnapshotStorageDatabase,
+			Paths:       []string{"/home/coder"},
+		}).Asserts(ws, policy.ActionUpdate)
+	}))
+	s.Run("GetWorkspaceSnapshotByID", s.Subtest(func(db database.Store, check *expects) {
+		ws := setup(db)
+		snapshot := dbgen.WorkspaceSnapshot(s.T(), db, database.WorkspaceSnapshot{WorkspaceID: ws.ID})
+		check.Args(snapshot.ID).Asserts(ws, policy.ActionRead).Returns(snapshot)
+	}))
+	s.Run("GetWorkspaceSnapshotChain", s.Subtest(func(db database.Store, check *expects) {
+		ws := setup(db)
+		parent := dbgen.WorkspaceSnapshot(s.T(), db, database.WorkspaceSnapshot{WorkspaceID: ws.ID})
+		child := dbgen.WorkspaceSnapshot(s.T(), db, database.WorkspaceSnapshot{
+			WorkspaceID: ws.ID,
+			ParentID:    uuid.NullUUID{UUID: parent.ID, Valid: true},
+		})
+		check.Args(child.ID).Asserts(ws, policy.ActionRead).Returns([]database.WorkspaceSnapshot{parent, child})
+	}))
+	s.Run("GetWorkspaceSnapshotsByWorkspaceID", s.Subtest(func(db database.Store, check *expects) {
+		ws := setup(db)
+		snapshot := dbgen.WorkspaceSnapshot(s.T(), db, database.WorkspaceSnapshot{WorkspaceID: ws.ID})
+		check.Args(ws.ID).Asserts(ws, policy.ActionRead).Returns([]database.WorkspaceSnapshot{snapshot})
+	}))
+	s.Run("UpsertWorkspaceSnapshotRestore", s.Subtest(func(db database.Store, check *expects) {
+		ws := setup(db)
+		snapshot := dbgen.WorkspaceSnapshot(s.T(), db, database.WorkspaceSnapshot{WorkspaceID: ws.ID})
+		check.Args(database.UpsertWorkspaceSnapshotRestoreParams{
+			WorkspaceID:      ws.ID,
+			SnapshotID:       snapshot.ID,
+			AfterBuildNumber: 1,
+			RequestedBy:      ws.OwnerID,
+			RequestedAt:      dbtime.Now(),
+		}).Asserts(ws, policy.ActionUpdate)
+	}))
+	s.Run("GetWorkspaceSnapshotRestoreByWorkspaceID", s.Subtest(func(db database.Store, check *expects) {
+		ws := setup(db)
+		snapshot := dbgen.WorkspaceSnapshot(s.T(), db, database.WorkspaceSnapshot{WorkspaceID: ws.ID})
+		restore, err := db.UpsertWorkspaceSnapshotRestore(context.Background(), database.UpsertWorkspaceSnapshotRestoreParams{
+			WorkspaceID:      ws.ID,
+			SnapshotID:       snapshot.ID,
+			AfterBuildNumber: 1,
+			RequestedBy:      ws.OwnerID,
+			RequestedAt:      dbtime.Now(),
+		})
+		require.NoError(s.T(), err)
+		check.Args(ws.ID).Asserts(ws, policy.ActionRead).Returns(restore)
+	}))
+	s.Run("DeleteWorkspaceSnapshotRestore", s.Subtest(func(db database.Store, check *expects) {
+		ws := setup(db)
+		check.Args(ws.ID).Asserts(ws, policy.ActionUpdate).Returns()
+	}))
+	s.Run("DeleteOldWorkspaceSnapshots", s.Subtest(func(db database.Store, check *expects) {
+		check.Args(int32(3)).Asserts(rbac.ResourceSystem, policy.ActionDelete)
+	}))
+}
+
+func (s *MethodTestSuite) TestWorkspaceDriftChecks() {
+	type fixture struct {
+		workspace database.WorkspaceTable
+		build     database.WorkspaceBuild
+		job       database.ProvisionerJob
+	}
+	setup := func(db database.Store) fixture {
+		u := dbgen.User(s.T(), db, database.User{})
+		org := dbgen.Organization(s.T(), db, database.Organization{})
+		tpl := dbgen.Template(s.T(), db, database.Template{
+			OrganizationID: org.ID,
+			CreatedBy:      u.ID,
+		})
+		tv := dbgen.TemplateVersion(s.T(), db, database.TemplateVersion{
+			TemplateID:     uuid.NullUUID{UUID: tpl.ID, Valid: true},
+			OrganizationID: org.ID,
+			CreatedBy:      u.ID,
+		})
+		ws := dbgen.Workspace(s.T(), db, database.WorkspaceTable{
+			OwnerID:        u.ID,
+			OrganizationID: org.ID,
+			TemplateID:     tpl.ID,
+		})
+		buildJob := dbgen.ProvisionerJob(s.T(), db, nil, database.ProvisionerJob{
+			OrganizationID: org.ID,
+			Type:           database.ProvisionerJobTypeWorkspaceBuild,
+		})
+		build := dbgen.WorkspaceBuild(s.T(), db, database.WorkspaceBuild{
+			JobID:             buildJob.ID,
+			WorkspaceID:       ws.ID,
+			TemplateVersionID: tv.ID,
+		})
+		job := dbgen.ProvisionerJob(s.T(), db, nil, database.ProvisionerJob{
+			OrganizationID: org.ID,
+			Type:           database.ProvisionerJobTypeWorkspaceDriftCheck,
+		})
+		return fixture{workspace: ws, build: build, job: job}
+	}
+	s.Run("InsertWorkspaceDriftCheck", s.Subtest(func(db database.Store, check *expects) {
+		f := setup(db)
+		check.Args(database.InsertWorkspaceDriftCheckParams{
+			ID:               uuid.New(),
+			WorkspaceID:      f.workspace.ID,
+			WorkspaceBuildID: f.build.ID,
+			JobID:            f.job.ID,
+			CreatedAt:        dbtime.Now(),
+		}).Asserts(f.workspace, policy.ActionUpdate)
+	}))
+	s.Run("GetWorkspaceDriftCheckByJobID", s.Subtest(func(db database.Store, check *expects) {
+		f := setup(db)
+		dc := dbgen.WorkspaceDriftCheck(s.T(), db, database.WorkspaceDriftCheck{
+			WorkspaceID:      f.workspace.ID,
+			WorkspaceBuildID: f.build.ID,
+			JobID:            f.job.ID,
+		})
+		check.Args(f.job.ID).Asserts(f.workspace, policy.ActionRead).Returns(dc)
+	}))
+	s.Run("GetLatestWorkspaceDriftCheckByWorkspaceID", s.Subtest(func(db database.Store, check *expects) {
+		f := setup(db)
+		dc := dbgen.WorkspaceDriftCheck(s.T(), db, database.WorkspaceDriftCheck{
+			WorkspaceID:      f.workspace.ID,
+			WorkspaceBuildID: f.build.ID,
+			JobID:            f.job.ID,
+		})
+		check.Args(f.workspace.ID).Asserts(f.workspace, policy.ActionRead).Returns(dc)
+	}))
+	s.Run("UpdateWorkspaceDriftCheckByJobID", s.Subtest(func(db database.Store, check *expects) {
+		f := setup(db)
+		_ = dbgen.WorkspaceDriftCheck(s.T(), db, database.WorkspaceDriftCheck{
+			WorkspaceID:      f.workspace.ID,
+			WorkspaceBuildID: f.build.ID,
+			JobID:            f.job.ID,
+		})
+		check.Args(database.UpdateWorkspaceDriftCheckByJobIDParams{
+			JobID:       f.job.ID,
+			CompletedAt: sql.NullTime{Time: dbtime.Now(), Valid: true},
+		}).Asserts(rbac.ResourceSystem, policy.ActionUpdate)
+	}))
+	s.Run("GetWorkspacesDueForDriftCheck", s.Subtest(func(db database.Store, check *expects) {
+		check.Args(dbtime.Now()).Asserts(rbac.ResourceSystem, policy.ActionRead)
+	}))
+}
+
+func (s *MethodTestSuite) TestWorkspaceBuildStates() {
+	type fixture struct {
+		workspace database.WorkspaceTable
+		build     database.WorkspaceBuild
+	}
+	setup := func(db database.Store) fixture {
+		u := dbgen.User(s.T(), db, database.User{})
+		org := dbgen.Organization(s.T(), db, database.Organization{})
+		tpl := dbgen.Template(s.T(), db, database.Template{
+			OrganizationID: org.ID,
+			CreatedBy:      u.ID,
+		})
+		tv := dbgen.TemplateVersion(s.T(), db, database.TemplateVersion{
+			TemplateID:     uuid.NullUUID{UUID: tpl.ID, Valid: true},
+			OrganizationID: org.ID,
+			CreatedBy:      u.ID,
+		})
+		ws := dbgen.Workspace(s.T(), db, database.WorkspaceTable{
+			OwnerID:        u.ID,
+			OrganizationID: org.ID,
+			TemplateID:     tpl.ID,
+		})
+		job := dbgen.ProvisionerJob(s.T(), db, nil, database.ProvisionerJob{
+			OrganizationID: org.ID,
+			Type:           database.ProvisionerJobTypeWorkspaceBuild,
+		})
+		build := dbgen.WorkspaceBuild(s.T(), db, database.WorkspaceBuild{
+			JobID:             job.ID,
+			WorkspaceID:       ws.ID,
+			TemplateVersionID: tv.ID,
+		})
+		return fixture{workspace: ws, build: build}
+	}
+	upsert := func(db database.Store, f fixture) database.UpsertWorkspaceBuildStateParams {
+		arg := database.UpsertWorkspaceBuildStateParams{
+			WorkspaceBuildID: f.build.ID,
+			WorkspaceID:      f.workspace.ID,
+			State:            []byte("{}"),
+			Hash:             "44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a",
+			Size:             2,
+			CreatedAt:        dbtime.Now(),
+		}
+		require.NoError(s.T(), db.UpsertWorkspaceBuildState(context.Background(), arg))
+		return arg
+	}
+	s.Run("UpsertWorkspaceBuildState", s.Subtest(func(db database.Store, check *expects) {
+		f := setup(db)
+		check.Args(database.UpsertWorkspaceBuildStateParams{
+			WorkspaceBuildID: f.build.ID,
+			WorkspaceID:      f.workspace.ID,
+			State:            []byte("{}"),
+			CreatedAt:        dbtime.Now(),
+		}).Asserts(rbac.ResourceSystem, policy.ActionUpdate)
+	}))
+	s.Run("GetWorkspaceBuildStateByBuildID", s.Subtest(func(db database.Store, check *expects) {
+		f := setup(db)
+		_ = upsert(db, f)
+		check.Args(f.build.ID).Asserts(f.workspace, policy.ActionRead)
+	}))
+	s.Run("GetWorkspaceBuildStatesByWorkspaceID", s.Subtest(func(db database.Store, check *expects) {
+		f := setup(db)
+		_ = upsert(db, f)
+		check.Args(f.workspace.ID).Asserts(f.workspace, policy.ActionRead)
+	}))
+	s.Run("GetWorkspaceBuildStateKeyIDs", s.Subtest(func(db database.Store, check *expects) {
+		check.Args().Asserts(rbac.ResourceSystem, policy.ActionRead)
+	}))
+	s.Run("DeleteOldWorkspaceBuildStates", s.Subtest(func(db database.Store, check *expects) {
+		check.Args(database.DeleteOldWorkspaceBuildStatesParams{
+			KeepCount: 10,
+			Before:    dbtime.Now(),
+		}).Asserts(rbac.ResourceSystem, policy.ActionDelete)
+	}))
+}
+
+func (s *MethodTestSuite) TestPlanPolicies() {
+	s.Run("InsertPlanPolicy", s.Subtest(func(db database.Store, check *expects) {
+		org := dbgen.Organization(s.T(), db, database.Organization{})
+		check.Args(database.InsertPlanPolicyParams{
+			ID:             uuid.New(),
+			OrganizationID: org.ID,
+			Name:           "network",
+			Module:         "package coder.plan",
+			CreatedAt:      dbtime.Now(),
+			UpdatedAt:      dbtime.Now(),
+		}).Asserts(rbac.ResourceTemplate.InOrg(org.ID), policy.ActionCreate)
+	}))
+	s.Run("GetPlanPoliciesByOrganizationID", s.Subtest(func(db database.Store, check *expects) {
+		org := dbgen.Organization(s.T(), db, database.Organization{})
+		pp := dbgen.PlanPolicy(s.T(), db, database.PlanPolicy{OrganizationID: org.ID})
+		check.Args(org.ID).Asserts(rbac.ResourceTemplate.InOrg(org.ID), policy.ActionRead).Returns([]database.PlanPolicy{pp})
+	}))
+	s.Run("GetPlanPolicyByID", s.Subtest(func(db database.Store, check *expects) {
+		org := dbgen.Organization(s.T(), db, database.Organization{})
+		pp := dbgen.PlanPolicy(s.T(), db, database.PlanPolicy{OrganizationID: org.ID})
+		check.Args(pp.ID).Asserts(pp, policy.ActionRead).Returns(pp)
+	}))
+	s.Run("GetPlanPolicyByOrganizationAndName", s.Subtest(func(db database.Store, check *expects) {
+		org := dbgen.Organization(s.T(), db, database.Organization{})
+		pp := dbgen.PlanPolicy(s.T(), db, database.PlanPolicy{OrganizationID: org.ID})
+		check.Args(database.GetPlanPolicyByOrganizationAndNameParams{
+			OrganizationID: org.ID,
+			Name:           pp.Name,
+		}).Asserts(pp, policy.ActionRead).Returns(pp)
+	}))
+	s.Run("UpdatePlanPolicyByID", s.Subtest(func(db database.Store, check *expects) {
+		org := dbgen.Organization(s.T(), db, database.Organization{})
+		pp := dbgen.PlanPolicy(s.T(), db, database.PlanPolicy{OrganizationID: org.ID})
+		check.Args(database.UpdatePlanPolicyByIDParams{
+			ID:          pp.ID,
+			Description: "updated",
+			Module:      pp.Module,
+			UpdatedAt:   dbtime.Now(),
+		}).Asserts(pp, policy.ActionUpdate)
+	}))
+	s.Run("DeletePlanPolicyByID", s.Subtest(func(db database.Store, check *expects) {
+		org := dbgen.Organization(s.T(), db, database.Organization{})
+		pp := dbgen.PlanPolicy(s.T(), db, database.PlanPolicy{OrganizationID: org.ID})
+		check.Args(pp.ID).Asserts(pp, policy.ActionDelete).Returns()
+	}))
+}
+
+func (s *MethodTestSuite) TestWorkspaceUpdatePreviews() {
+	type fixture struct {
+		workspace database.WorkspaceTable
+		job       database.ProvisionerJob
+	}
+	setup := func(db database.Store) fixture {
+		u := dbgen.User(s.T(), db, database.User{})
+		org := dbgen.Organization(s.T(), db, database.Organization{})
+		tpl := dbgen.Template(s.T(), db, database.Template{
+			OrganizationID: org.ID,
+			CreatedBy:      u.ID,
+		})
+		ws := dbgen.Workspace(s.T(), db, database.WorkspaceTable{
+			OwnerID:        u.ID,
+			OrganizationID: org.ID,
+			TemplateID:     tpl.ID,
+		})
+		job := dbgen.ProvisionerJob(s.T(), db, nil, database.ProvisionerJob{
+			OrganizationID: org.ID,
+			Type:           database.ProvisionerJobTypeTemplateVersionDryRun,
+		})
+		return fixture{workspace: ws, job: job}
+	}
+	s.Run("InsertWorkspaceUpdatePreview", s.Subtest(func(db database.Store, check *expects) {
+		f := setup(db)
+		check.Args(database.InsertWorkspaceUpdatePreviewParams{
+			JobID:       f.job.ID,
+			WorkspaceID: f.workspace.ID,
+			CreatedAt:   dbtime.Now(),
+		}).Asserts(f.workspace, policy.ActionUpdate)
+	}))
+	s.Run("GetWorkspaceUpdatePreviewByJobID", s.Subtest(func(db database.Store, check *expects) {
+		f := setup(db)
+		preview := dbgen.WorkspaceUpdatePreview(s.T(), db, database.WorkspaceUpdatePreview{
+			JobID:       f.job.ID,
+			WorkspaceID: f.workspace.ID,
+		})
+		check.Args(f.job.ID).Asserts(f.workspace, policy.ActionRead).Returns(preview)
+	}))
+	s.Run("UpdateWorkspaceUpdatePreviewByJobID", s.Subtest(func(db database.Store, check *expects) {
+		f := setup(db)
+		_ = dbgen.WorkspaceUpdatePreview(s.T(), db, database.WorkspaceUpdatePreview{
+			JobID:       f.job.ID,
+			WorkspaceID: f.workspace.ID,
+		})
+		check.Args(database.UpdateWorkspaceUpdatePreviewByJobIDParams{
+			JobID:       f.job.ID,
+			CompletedAt: sql.NullTime{Time: dbtime.Now(), Valid: true},
+		}).Asserts(rbac.ResourceSystem, policy.ActionUpdate)
+	}))
+}
+
+func (s *MethodTestSuite) TestProviderMirror() {
+	s.Run("InsertProviderMirrorArchive", s.Subtest(func(db database.Store, check *expects) {
+		o := dbgen.Organization(s.T(), db, database.Organization{})
+		f := dbgen.File(s.T(), db, database.File{})
+		check.Args(database.InsertProviderMirrorArchiveParams{
+			OrganizationID: o.ID,
+			Hostname:       "registry.terraform.io",
+			Namespace:      "coder",
+			Type:           "coder",
+			Version:        "2.4.0",
+			Platform:       "linux_amd64",
+			Hash:           "h1:test",
+			FileID:         f.ID,
+			CreatedAt:      dbtime.Now(),
+		}).Asserts(rbac.ResourceSystem, policy.ActionCreate).Returns(int64(1))
+	}))
+	s.Run("GetProviderMirrorArchives", s.Subtest(func(db database.Store, check *expects) {
+		check.Args(database.GetProviderMirrorArchivesParams{
+			OrganizationID: uuid.New(),
+			Hostname:       "registry.terraform.io",
+			Namespace:      "coder",
+			Type:           "coder",
+		}).Asserts(rbac.ResourceSystem, policy.ActionRead)
+	}))
+	s.Run("GetProviderMirrorArchiveFileByHash", s.Subtest(func(db database.Store, check *expects) {
+		o := dbgen.Organization(s.T(), db, database.Organization{})
+		f := dbgen.File(s.T(), db, database.File{})
+		_, err := db.InsertProviderMirrorArchive(context.Background(), database.InsertProviderMirrorArchiveParams{
+			OrganizationID: o.ID,
+			Hostname:       "registry.terraform.io",
+			Namespace:      "coder",
+			Type:           "coder",
+			Version:        "2.4.0",
+			Platform:       "linux_amd64",
+			Hash:           "h1:test",
+			FileID:         f.ID,
+			CreatedAt:      dbtime.Now(),
+		})
+		require.NoError(s.T(), err)
+		check.Args(database.GetProviderMirrorArchiveFileByHashParams{
+			Hash:           f.Hash,
+			OrganizationID: o.ID,
+		}).Asserts(rbac.ResourceSystem, policy.ActionRead).Returns(f)
+	}))
+	s.Run("UpsertProviderMirrorToken", s.Subtest(func(db database.Store, check *expects) {
+		job := dbgen.ProvisionerJob(s.T(), db, nil, database.ProvisionerJob{})
+		check.Args(database.UpsertProviderMirrorTokenParams{
+			JobID:        job.ID,
+			HashedSecret: []byte("secret"),
+			CreatedAt:    dbtime.Now(),
+		}).Asserts(rbac.ResourceSystem, policy.ActionCreate)
+	}))
+	s.Run("GetProviderMirrorTokenByJobID", s.Subtest(func(db database.Store, check *expects) {
+		job := dbgen.ProvisionerJob(s.T(), db, nil, database.ProvisionerJob{})
+		err := db.UpsertProviderMirrorToken(context.Background(), database.UpsertProviderMirrorTokenParams{
+			JobID:        job.ID,
+			HashedSecret: []byte("secret"),
+			CreatedAt:    dbtime.Now(),
+		})
+		require.NoError(s.T(), err)
+		check.Args(job.ID).Asserts(rbac.ResourceSystem, policy.ActionRead)
+	}))
 }
 
 func (s *MethodTestSuite) TestProvisionerKeys() {
@@ -3587,6 +4283,7 @@ func (s *MethodTestSuite) TestExtraMethods() {
 			Provisioner:    database.ProvisionerTypeEcho,
 			StorageMethod:  database.ProvisionerStorageMethodFile,
 			Input:          json.RawMessage("{}"),
+			Priority:       database.ProvisionerJobPriorityInteractive,
 		})
 		s.NoError(err, "insert provisioner job")
 		d, err := db.UpsertProvisionerDaemon(context.Background(), database.UpsertProvisionerDaemonParams{
@@ -3656,6 +4353,24 @@ func (s *MethodTestSuite) TestExtraMethods() {
 			OrganizationID: org.ID,
 		}).Asserts(j1, policy.ActionRead, j2, policy.ActionRead).Returns(ds)
 	}))
+	s.Run("GetProvisionerQueueStats", s.Subtest(func(db database.Store, check *expects) {
+		org := dbgen.Organization(s.T(), db, database.Organization{})
+		_ = dbgen.ProvisionerDaemon(s.T(), db, database.ProvisionerDaemon{
+			OrganizationID: org.ID,
+			Tags: map[string]string{
+				provisionersdk.TagScope: provisionersdk.ScopeOrganization,
+			},
+		})
+		arg := database.GetProvisionerQueueStatsParams{
+			OrganizationID:  org.ID,
+			StaleIntervalMS: 24 * time.Hour.Milliseconds(),
+			StartedAfter:    dbtime.Now().Add(-time.Hour),
+		}
+		stats, err := db.GetProvisionerQueueStats(context.Background(), arg)
+		s.NoError(err, "get provisioner queue stats")
+		s.Require().Len(stats, 1)
+		check.Args(arg).Asserts(stats[0], policy.ActionRead).Returns(stats)
+	}))
 }
 
 func (s *MethodTestSuite) TestTailnetFunctions() {
@@ -4262,6 +4977,7 @@ func (s *MethodTestSuite) TestSystemFunctions() {
 			StorageMethod: database.ProvisionerStorageMethodFile,
 			Type:          database.ProvisionerJobTypeWorkspaceBuild,
 			Input:         json.RawMessage("{}"),
+			Priority:      database.ProvisionerJobPriorityInteractive,
 		}).Asserts( /* rbac.ResourceProvisionerJobs, policy.ActionCreate */ )
 	}))
 	s.Run("InsertProvisionerJobLogs", s.Subtest(func(db database.Store, check *expects) {
@@ -4533,6 +5249,23 @@ func (s *MethodTestSuite) TestSystemFunctions() {
 	s.Run("GetWorkspaceBuildStatsByTemplates", s.Subtest(func(db database.Store, check *expects) {
 		check.Args(dbtime.Now()).Asserts(rbac.ResourceSystem, policy.ActionRead)
 	}))
+	s.Run("GetWorkspacesApproachingDeletion", s.Subtest(func(db database.Store, check *expects) {
+		check.Args(dbtime.Now()).Asserts(rbac.ResourceSystem, policy.ActionRead)
+	}))
+	s.Run("GetTemplateVersionAdoption", s.Subtest(func(db database.Store, check *expects) {
+		check.Args().Asserts(rbac.ResourceSystem, policy.ActionRead)
+	}))
+	s.Run("GetTopWorkspaceResourceConsumers", s.Subtest(func(db database.Store, check *expects) {
+		check.Args(database.GetTopWorkspaceResourceConsumersParams{
+			Since:                dbtime.Now(),
+			LimitPerOrganization: 10,
+		}).Asserts(rbac.ResourceSystem, policy.ActionRead)
+	}))
+	s.Run("GetPrebuildPoolHealth", s.Subtest(func(db database.Store, check *expects) {
+		check.Args(dbtime.Now()).
+			Asserts(rbac.ResourceSystem, policy.ActionRead).
+			ErrorsWithInMemDB(dbmem.ErrUnimplemented)
+	}))
 	s.Run("UpsertNotificationReportGeneratorLog", s.Subtest(func(db database.Store, check *expects) {
 		check.Args(database.UpsertNotificationReportGeneratorLogParams{
 			NotificationTemplateID: uuid.New(),
@@ -4668,6 +5401,12 @@ func (s *MethodTestSuite) TestNotifications() {
 	s.Run("AcquireNotificationMessages", s.Subtest(func(_ database.Store, check *expects) {
 		check.Args(database.AcquireNotificationMessagesParams{}).Asserts(rbac.ResourceNotificationMessage, policy.ActionUpdate)
 	}))
+	s.Run("AcquireNotificationDigestMessages", s.Subtest(func(_ database.Store, check *expects) {
+		check.Args(database.AcquireNotificationDigestMessagesParams{}).Asserts(rbac.ResourceNotificationMessage, policy.ActionUpdate)
+	}))
+	s.Run("BulkDeferNotificationMessages", s.Subtest(func(_ database.Store, check *expects) {
+		check.Args(database.BulkDeferNotificationMessagesParams{}).Asserts(rbac.ResourceNotificationMessage, policy.ActionUpdate)
+	}))
 	s.Run("BulkMarkNotificationMessagesFailed", s.Subtest(func(_ database.Store, check *expects) {
 		check.Args(database.BulkMarkNotificationMessagesFailedParams{}).Asserts(rbac.ResourceNotificationMessage, policy.ActionUpdate)
 	}))
@@ -4768,6 +5507,87 @@ func (s *MethodTestSuite) TestNotifications() {
 		}).Asserts(rbac.ResourceNotificationPreference.WithOwner(user.ID.String()), policy.ActionUpdate)
 	}))
 
+	s.Run("GetUserChatIdentities", s.Subtest(func(db database.Store, check *expects) {
+		user := dbgen.User(s.T(), db, database.User{})
+		identity := dbgen.UserChatIdentity(s.T(), db, database.UserChatIdentity{UserID: user.ID})
+		check.Args(user.ID).
+			Asserts(rbac.ResourceNotificationPreference.WithOwner(user.ID.String()), policy.ActionRead).
+			Returns([]database.UserChatIdentity{identity})
+	}))
+	s.Run("GetUserChatIdentity", s.Subtest(func(db database.Store, check *expects) {
+		user := dbgen.User(s.T(), db, database.User{})
+		identity := dbgen.UserChatIdentity(s.T(), db, database.UserChatIdentity{UserID: user.ID})
+		check.Args(database.GetUserChatIdentityParams{UserID: user.ID, Method: identity.Method}).
+			Asserts(rbac.ResourceNotificationPreference.WithOwner(user.ID.String()), policy.ActionRead).
+			Returns(identity)
+	}))
+	s.Run("UpsertUserChatIdentity", s.Subtest(func(db database.Store, check *expects) {
+		user := dbgen.User(s.T(), db, database.User{})
+		check.Args(database.UpsertUserChatIdentityParams{
+			UserID:    user.ID,
+			Method:    database.NotificationMethodMatrix,
+			Identity:  "@alice:example.com",
+			UpdatedAt: dbtime.Now(),
+		}).Asserts(rbac.ResourceNotificationPreference.WithOwner(user.ID.String()), policy.ActionUpdate)
+	}))
+	s.Run("VerifyUserChatIdentity", s.Subtest(func(db database.Store, check *expects) {
+		user := dbgen.User(s.T(), db, database.User{})
+		now := dbtime.Now()
+		hash := sha256.Sum256([]byte("ABCD1234"))
+		_, err := db.UpsertUserChatIdentity(context.Background(), database.UpsertUserChatIdentityParams{
+			UserID:                user.ID,
+			Method:                database.NotificationMethodSlack,
+			Identity:              "U12345678",
+			UpdatedAt:             now,
+			VerificationCodeHash:  hash[:],
+			VerificationExpiresAt: sql.NullTime{Time: now.Add(time.Minute), Valid: true},
+		})
+		require.NoError(s.T(), err)
+		check.Args(database.VerifyUserChatIdentityParams{
+			UserID:               user.ID,
+			Method:               database.NotificationMethodSlack,
+			VerificationCodeHash: hash[:],
+			VerifiedAt:           now,
+		}).Asserts(rbac.ResourceNotificationPreference.WithOwner(user.ID.String()), policy.ActionUpdate)
+	}))
+	s.Run("DeleteUserChatIdentity", s.Subtest(func(db database.Store, check *expects) {
+		user := dbgen.User(s.T(), db, database.User{})
+		identity := dbgen.UserChatIdentity(s.T(), db, database.UserChatIdentity{UserID: user.ID})
+		check.Args(database.DeleteUserChatIdentityParams{UserID: user.ID, Method: identity.Method}).
+			Asserts(rbac.ResourceNotificationPreference.WithOwner(user.ID.String()), policy.ActionUpdate)
+	}))
+	s.Run("GetUserNotificationDigestPreferences", s.Subtest(func(db database.Store, check *expects) {
+		user := dbgen.User(s.T(), db, database.User{})
+		check.Args(user.ID).
+			Asserts(rbac.ResourceNotificationPreference.WithOwner(user.ID.String()), policy.ActionRead)
+	}))
+	s.Run("UpdateUserNotificationDigestPreferences", s.Subtest(func(db database.Store, check *expects) {
+		user := dbgen.User(s.T(), db, database.User{})
+		check.Args(database.UpdateUserNotificationDigestPreferencesParams{
+			UserID:                  user.ID,
+			NotificationTemplateIds: []uuid.UUID{notifications.TemplateWorkspaceManualBuildFailed},
+			DigestIntervals:         []database.NotificationDigestInterval{database.NotificationDigestIntervalHourly},
+		}).Asserts(rbac.ResourceNotificationPreference.WithOwner(user.ID.String()), policy.ActionUpdate)
+	}))
+	s.Run("GetUserNotificationSchedule", s.Subtest(func(db database.Store, check *expects) {
+		user := dbgen.User(s.T(), db, database.User{})
+		schedule := dbgen.UserNotificationSchedule(s.T(), db, database.UserNotificationSchedule{UserID: user.ID})
+		check.Args(user.ID).
+			Asserts(rbac.ResourceNotificationPreference.WithOwner(user.ID.String()), policy.ActionRead).
+			Returns(schedule)
+	}))
+	s.Run("UpsertUserNotificationSchedule", s.Subtest(func(db database.Store, check *expects) {
+		user := dbgen.User(s.T(), db, database.User{})
+		check.Args(database.UpsertUserNotificationScheduleParams{
+			UserID:          user.ID,
+			Timezone:        "Europe/Berlin",
+			QuietHoursStart: sql.NullInt16{Int16: 22 * 60, Valid: true},
+			QuietHoursEnd:   sql.NullInt16{Int16: 7 * 60, Valid: true},
+			DailyDigestTime: 9 * 60,
+			UpdatedAt:       dbtime.Now(),
+		}).Asserts(rbac.ResourceNotificationPreference.WithOwner(user.ID.String()), policy.ActionUpdate)
+	}))
+
 	s.Run("GetInboxNotificationsByUserID", s.Subtest(func(db database.Store, check *expects) {
 		u := dbgen.User(s.T(), db, database.User{})
 
@@ -4911,6 +5731,75 @@ func (s *MethodTestSuite) TestNotifications() {
 			ReadAt: sql.NullTime{Time: dbtestutil.NowInDefaultTimezone(), Valid: true},
 		}).Asserts(rbac.ResourceInboxNotification.WithOwner(u.ID.String()), policy.ActionUpdate)
 	}))
+
+	// Notification rules
+	s.Run("InsertNotificationRule", s.Subtest(func(db database.Store, check *expects) {
+		org := dbgen.Organization(s.T(), db, database.Organization{})
+		user := dbgen.User(s.T(), db, database.User{})
+		check.Args(database.InsertNotificationRuleParams{
+			ID:             uuid.New(),
+			OrganizationID: org.ID,
+			UserID:         user.ID,
+			Name:           "owner-assigned",
+			Source:         database.NotificationRuleSourceAuditLog,
+			Query:          "resource_type:organization_member action:write",
+			Threshold:      1,
+			Enabled:        true,
+			CreatedAt:      dbtime.Now(),
+		}).Asserts(org, policy.ActionUpdate)
+	}))
+	s.Run("GetNotificationRuleByID", s.Subtest(func(db database.Store, check *expects) {
+		org := dbgen.Organization(s.T(), db, database.Organization{})
+		user := dbgen.User(s.T(), db, database.User{})
+		rule := dbgen.NotificationRule(s.T(), db, database.NotificationRule{OrganizationID: org.ID, UserID: user.ID})
+		check.Args(rule.ID).Asserts(org, policy.ActionRead).Returns(rule)
+	}))
+	s.Run("GetNotificationRulesByOrganizationID", s.Subtest(func(db database.Store, check *expects) {
+		org := dbgen.Organization(s.T(), db, database.Organization{})
+		user := dbgen.User(s.T(), db, database.User{})
+		rule := dbgen.NotificationRule(s.T(), db, database.NotificationRule{OrganizationID: org.ID, UserID: user.ID})
+		check.Args(org.ID).Asserts(org, policy.ActionRead).Returns([]database.NotificationRule{rule})
+	}))
+	s.Run("UpdateNotificationRule", s.Subtest(func(db database.Store, check *expects) {
+		org := dbgen.Organization(s.T(), db, database.Organization{})
+		user := dbgen.User(s.T(), db, database.User{})
+		rule := dbgen.NotificationRule(s.T(), db, database.NotificationRule{OrganizationID: org.ID, UserID: user.ID})
+		check.Args(database.UpdateNotificationRuleParams{
+			ID:        rule.ID,
+			Name:      rule.Name,
+			Query:     "action:delete",
+			Threshold: 3,
+			Enabled:   true,
+			UpdatedAt: dbtime.Now(),
+		}).Asserts(org, policy.ActionUpdate)
+	}))
+	s.Run("DeleteNotificationRule", s.Subtest(func(db database.Store, check *expects) {
+		org := dbgen.Organization(s.T(), db, database.Organization{})
+		user := dbgen.User(s.T(), db, database.User{})
+		rule := dbgen.NotificationRule(s.T(), db, database.NotificationRule{OrganizationID: org.ID, UserID: user.ID})
+		check.Args(rule.ID).Asserts(org, policy.ActionUpdate)
+	}))
+	s.Run("GetEnabledNotificationRules", s.Subtest(func(_ database.Store, check *expects) {
+		check.Args().Asserts(rbac.ResourceSystem, policy.ActionRead)
+	}))
+	s.Run("UpdateNotificationRuleEvaluation", s.Subtest(func(db database.Store, check *expects) {
+		org := dbgen.Organization(s.T(), db, database.Organization{})
+		user := dbgen.User(s.T(), db, database.User{})
+		rule := dbgen.NotificationRule(s.T(), db, database.NotificationRule{OrganizationID: org.ID, UserID: user.ID})
+		check.Args(database.UpdateNotificationRuleEvaluationParams{
+			ID:              rule.ID,
+			LastEvaluatedAt: dbtime.Now(),
+		}).Asserts(rbac.ResourceSystem, policy.ActionUpdate)
+	}))
+	s.Run("GetWorkspaceBuildEvents", s.Subtest(func(db database.Store, check *expects) {
+		org := dbgen.Organization(s.T(), db, database.Organization{})
+		check.Args(database.GetWorkspaceBuildEventsParams{
+			OrganizationID:  org.ID,
+			CompletedAfter:  dbtime.Now().Add(-time.Hour),
+			CompletedBefore: dbtime.Now(),
+			LimitOpt:        10,
+		}).Asserts(rbac.ResourceSystem, policy.ActionRead)
+	}))
 }
 
 func (s *MethodTestSuite) TestPrebuilds() {
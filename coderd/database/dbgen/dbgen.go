@@ -151,11 +151,68 @@ func WorkspaceAgentPortShare(t testing.TB, db database.Store, orig database.Work
 		Port:        takeFirst(orig.Port, 8080),
 		ShareLevel:  takeFirst(orig.ShareLevel, database.AppSharingLevelPublic),
 		Protocol:    takeFirst(orig.Protocol, database.PortShareProtocolHttp),
+		AutoShared:  orig.AutoShared,
+		Label:       orig.Label,
 	})
 	require.NoError(t, err, "insert workspace agent")
 	return ps
 }
 
+func TemplatePortShareRule(t testing.TB, db database.Store, orig database.TemplatePortShareRule) database.TemplatePortShareRule {
+	rule, err := db.InsertTemplatePortShareRule(genCtx, database.InsertTemplatePortShareRuleParams{
+		ID:         takeFirst(orig.ID, uuid.New()),
+		TemplateID: takeFirst(orig.TemplateID, uuid.New()),
+		PortStart:  takeFirst(orig.PortStart, 3000),
+		PortEnd:    takeFirst(orig.PortEnd, orig.PortStart, 3999),
+		ShareLevel: takeFirst(orig.ShareLevel, database.AppSharingLevelOrganization),
+		Protocol:   takeFirst(orig.Protocol, database.PortShareProtocolHttp),
+		Label:      orig.Label,
+	})
+	require.NoError(t, err, "insert template port share rule")
+	return rule
+}
+
+func WorkspaceSnapshot(t testing.TB, db database.Store, orig database.WorkspaceSnapshot) database.WorkspaceSnapshot {
+	snapshot, err := db.InsertWorkspaceSnapshot(genCtx, database.InsertWorkspaceSnapshotParams{
+		ID:          takeFirst(orig.ID, uuid.New()),
+		WorkspaceID: takeFirst(orig.WorkspaceID, uuid.New()),
+		AgentName:   takeFirst(orig.AgentName, "main"),
+		BuildNumber: takeFirst(orig.BuildNumber, 1),
+		ParentID:    orig.ParentID,
+		Trigger:     takeFirst(orig.Trigger, database.WorkspaceSnapshotTriggerStop),
+		Storage:     takeFirst(orig.Storage, database.WorkspaceSnapshotStorageDatabase),
+		ObjectKey:   takeFirst(orig.ObjectKey, uuid.NewString()),
+		SizeBytes:   takeFirst(orig.SizeBytes, 1024),
+		FileCount:   takeFirst(orig.FileCount, 1),
+		Paths:       takeFirstSlice(orig.Paths, []string{"/home/coder"}),
+		CreatedAt:   takeFirst(orig.CreatedAt, dbtime.Now()),
+	})
+	require.NoError(t, err, "insert workspace snapshot")
+	return snapshot
+}
+
+func WorkspaceDriftCheck(t testing.TB, db database.Store, orig database.WorkspaceDriftCheck) database.WorkspaceDriftCheck {
+	check, err := db.InsertWorkspaceDriftCheck(genCtx, database.InsertWorkspaceDriftCheckParams{
+		ID:               takeFirst(orig.ID, uuid.New()),
+		WorkspaceID:      takeFirst(orig.WorkspaceID, uuid.New()),
+		WorkspaceBuildID: takeFirst(orig.WorkspaceBuildID, uuid.New()),
+		JobID:            takeFirst(orig.JobID, uuid.New()),
+		CreatedAt:        takeFirst(orig.CreatedAt, dbtime.Now()),
+	})
+	require.NoError(t, err, "insert workspace drift check")
+	return check
+}
+
+func WorkspaceUpdatePreview(t testing.TB, db database.Store, orig database.WorkspaceUpdatePreview) database.WorkspaceUpdatePreview {
+	preview, err := db.InsertWorkspaceUpdatePreview(genCtx, database.InsertWorkspaceUpdatePreviewParams{
+		JobID:       takeFirst(orig.JobID, uuid.New()),
+		WorkspaceID: takeFirst(orig.WorkspaceID, uuid.New()),
+		CreatedAt:   takeFirst(orig.CreatedAt, dbtime.Now()),
+	})
+	require.NoError(t, err, "insert workspace update preview")
+	return preview
+}
+
 func WorkspaceAgent(t testing.TB, db database.Store, orig database.WorkspaceAgent) database.WorkspaceAgent {
 	agt, err := db.InsertWorkspaceAgent(genCtx, database.InsertWorkspaceAgentParams{
 		ID:         takeFirst(orig.ID, uuid.New()),
@@ -547,6 +604,24 @@ func NotificationInbox(t testing.TB, db database.Store, orig database.InsertInbo
 	return notification
 }
 
+func NotificationRule(t testing.TB, db database.Store, orig database.NotificationRule) database.NotificationRule {
+	rule, err := db.InsertNotificationRule(genCtx, database.InsertNotificationRuleParams{
+		ID:             takeFirst(orig.ID, uuid.New()),
+		OrganizationID: takeFirst(orig.OrganizationID, uuid.New()),
+		UserID:         takeFirst(orig.UserID, uuid.New()),
+		Name:           takeFirst(orig.Name, testutil.GetRandomName(t)),
+		Source:         takeFirst(orig.Source, database.NotificationRuleSourceAuditLog),
+		Query:          takeFirst(orig.Query, "action:create"),
+		Method:         orig.Method,
+		Threshold:      takeFirst(orig.Threshold, 1),
+		WindowSeconds:  orig.WindowSeconds,
+		Enabled:        takeFirst(orig.Enabled, true),
+		CreatedAt:      takeFirst(orig.CreatedAt, dbtime.Now()),
+	})
+	require.NoError(t, err, "insert notification rule")
+	return rule
+}
+
 func WebpushSubscription(t testing.TB, db database.Store, orig database.InsertWebpushSubscriptionParams) database.WebpushSubscription {
 	subscription, err := db.InsertWebpushSubscription(genCtx, database.InsertWebpushSubscriptionParams{
 		CreatedAt:         takeFirst(orig.CreatedAt, dbtime.Now()),
@@ -559,6 +634,34 @@ func WebpushSubscription(t testing.TB, db database.Store, orig database.InsertWe
 	return subscription
 }
 
+func UserChatIdentity(t testing.TB, db database.Store, orig database.UserChatIdentity) database.UserChatIdentity {
+	identity, err := db.UpsertUserChatIdentity(genCtx, database.UpsertUserChatIdentityParams{
+		UserID:    takeFirst(orig.UserID, uuid.New()),
+		Method:    takeFirst(orig.Method, database.NotificationMethodSlack),
+		Identity:  takeFirst(orig.Identity, "U"+strings.ToUpper(uuid.NewString()[:8])),
+		UpdatedAt: takeFirst(orig.UpdatedAt, dbtime.Now()),
+		VerifiedAt: sql.NullTime{
+			Time:  takeFirst(orig.VerifiedAt.Time, dbtime.Now()),
+			Valid: true,
+		},
+	})
+	require.NoError(t, err, "upsert user chat identity")
+	return identity
+}
+
+func UserNotificationSchedule(t testing.TB, db database.Store, orig database.UserNotificationSchedule) database.UserNotificationSchedule {
+	schedule, err := db.UpsertUserNotificationSchedule(genCtx, database.UpsertUserNotificationScheduleParams{
+		UserID:          takeFirst(orig.UserID, uuid.New()),
+		Timezone:        takeFirst(orig.Timezone, "UTC"),
+		QuietHoursStart: orig.QuietHoursStart,
+		QuietHoursEnd:   orig.QuietHoursEnd,
+		DailyDigestTime: takeFirst(orig.DailyDigestTime, 9*60),
+		UpdatedAt:       takeFirst(orig.UpdatedAt, dbtime.Now()),
+	})
+	require.NoError(t, err, "upsert user notification schedule")
+	return schedule
+}
+
 func Group(t testing.TB, db database.Store, orig database.Group) database.Group {
 	t.Helper()
 
@@ -708,6 +811,8 @@ func ProvisionerJob(t testing.TB, db database.Store, ps pubsub.Pubsub, orig data
 		Input:          takeFirstSlice(orig.Input, []byte("{}")),
 		Tags:           tags,
 		TraceMetadata:  pqtype.NullRawMessage{},
+		Priority:       takeFirst(orig.Priority, database.ProvisionerJobPriorityInteractive),
+		TemplateID:     orig.TemplateID,
 	})
 	require.NoError(t, err, "insert job")
 	if ps != nil {
@@ -752,6 +857,20 @@ func ProvisionerJob(t testing.TB, db database.Store, ps pubsub.Pubsub, orig data
 	return job
 }
 
+func PlanPolicy(t testing.TB, db database.Store, orig database.PlanPolicy) database.PlanPolicy {
+	planPolicy, err := db.InsertPlanPolicy(genCtx, database.InsertPlanPolicyParams{
+		ID:             takeFirst(orig.ID, uuid.New()),
+		OrganizationID: takeFirst(orig.OrganizationID, uuid.New()),
+		Name:           takeFirst(orig.Name, testutil.GetRandomName(t)),
+		Description:    orig.Description,
+		Module:         takeFirst(orig.Module, "package coder.plan\n\ndeny contains msg if {\n\tfalse\n\tmsg := \"never\"\n}\n"),
+		CreatedAt:      takeFirst(orig.CreatedAt, dbtime.Now()),
+		UpdatedAt:      takeFirst(orig.UpdatedAt, dbtime.Now()),
+	})
+	require.NoError(t, err, "insert plan policy")
+	return planPolicy
+}
+
 func ProvisionerKey(t testing.TB, db database.Store, orig database.ProvisionerKey) database.ProvisionerKey {
 	key, err := db.InsertProvisionerKey(genCtx, database.InsertProvisionerKeyParams{
 		ID:             takeFirst(orig.ID, uuid.New()),
@@ -848,6 +967,7 @@ func WorkspaceResource(t testing.TB, db database.Store, orig database.WorkspaceR
 			String: takeFirst(orig.ModulePath.String, ""),
 			Valid:  takeFirst(orig.ModulePath.Valid, true),
 		},
+		HourlyCostMicros: takeFirst(orig.HourlyCostMicros, 0),
 	})
 	require.NoError(t, err, "insert resource")
 	return resource
@@ -1415,6 +1535,8 @@ func newCryptoKeySecret(feature database.CryptoKeyFeature) (string, error) {
 		return generateCryptoKey(64)
 	case database.CryptoKeyFeatureTailnetResume:
 		return generateCryptoKey(64)
+	case database.CryptoKeyFeatureAuditLogCheckpoint:
+		return generateCryptoKey(64)
 	}
 	return "", xerrors.Errorf("unknown feature: %s", feature)
 }
@@ -2,6 +2,7 @@ package dbmem
 
 import (
 	"bytes"
+	"cmp"
 	"context"
 	"database/sql"
 	"encoding/json"
@@ -215,6 +216,11 @@ type data struct {
 
 	// New tables
 	auditLogs                            []database.AuditLog
+	auditLogArchiveEntries               []database.AuditLogArchiveEntry
+	auditLogArchives                     []database.AuditLogArchive
+	auditLogCheckpoints                  []database.AuditLogCheckpoint
+	auditLogExportCursors                []database.AuditLogExportCursor
+	auditLogHashes                       []database.AuditLogHash
 	cryptoKeys                           []database.CryptoKey
 	dbcryptKeys                          []database.DBCryptKey
 	files                                []database.File
@@ -226,12 +232,17 @@ type data struct {
 	notificationMessages                 []database.NotificationMessage
 	notificationPreferences              []database.NotificationPreference
 	notificationReportGeneratorLogs      []database.NotificationReportGeneratorLog
+	notificationRules                    []database.NotificationRule
 	inboxNotifications                   []database.InboxNotification
 	oauth2ProviderApps                   []database.OAuth2ProviderApp
 	oauth2ProviderAppSecrets             []database.OAuth2ProviderAppSecret
 	oauth2ProviderAppCodes               []database.OAuth2ProviderAppCode
 	oauth2ProviderAppTokens              []database.OAuth2ProviderAppToken
+	organizationHolidays                 []database.OrganizationHoliday
 	parameterSchemas                     []database.ParameterSchema
+	planPolicies                         []database.PlanPolicy
+	providerMirrorArchives               []database.ProviderMirrorArchive
+	providerMirrorTokens                 []database.ProviderMirrorToken
 	provisionerDaemons                   []database.ProvisionerDaemon
 	provisionerJobLogs                   []database.ProvisionerJobLog
 	provisionerJobs                      []database.ProvisionerJob
@@ -243,8 +254,14 @@ type data struct {
 	templateVersionVariables             []database.TemplateVersionVariable
 	templateVersionWorkspaceTags         []database.TemplateVersionWorkspaceTag
 	templates                            []database.TemplateTable
+	templateAutostopWindows              []database.TemplateAutostopWindow
+	templatePortShareRules               []database.TemplatePortShareRule
 	templateUsageStats                   []database.TemplateUsageStat
+	userAutostopWindows                  []database.UserAutostopWindow
 	userConfigs                          []database.UserConfig
+	userChatIdentities                   []database.UserChatIdentity
+	userNotificationSchedules            []database.UserNotificationSchedule
+	notificationDigestPreferences        []database.NotificationDigestPreference
 	webpushSubscriptions                 []database.WebpushSubscription
 	workspaceAgents                      []database.WorkspaceAgent
 	workspaceAgentMetadata               []database.WorkspaceAgentMetadatum
@@ -264,9 +281,15 @@ type data struct {
 	workspaceAppStats                    []database.WorkspaceAppStat
 	workspaceBuilds                      []database.WorkspaceBuild
 	workspaceBuildParameters             []database.WorkspaceBuildParameter
+	workspaceBuildStates                 []database.WorkspaceBuildState
+	workspaceCostUsage                   []database.WorkspaceCostUsage
+	workspaceDriftChecks                 []database.WorkspaceDriftCheck
 	workspaceResourceMetadata            []database.WorkspaceResourceMetadatum
 	workspaceResources                   []database.WorkspaceResource
 	workspaceModules                     []database.WorkspaceModule
+	workspaceSnapshots                   []database.WorkspaceSnapshot
+	workspaceSnapshotRestores            []database.WorkspaceSnapshotRestore
+	workspaceUpdatePreviews              []database.WorkspaceUpdatePreview
 	workspaces                           []database.WorkspaceTable
 	workspaceProxies                     []database.WorkspaceProxy
 	customRoles                          []database.CustomRole
@@ -288,6 +311,7 @@ type data struct {
 	oauthSigningKey                  string
 	coordinatorResumeTokenSigningKey string
 	lastLicenseID                    int32
+	lastAuditLogXactID               int64
 	defaultProxyDisplayName          string
 	defaultProxyIconURL              string
 	webpushVAPIDPublicKey            string
@@ -1234,19 +1258,19 @@ func (q *FakeQuerier) getProvisionerJobsByIDsWithQueuePositionLockedTagBasedQueu
 		}
 	}
 
-	// Sort jobs per provisioner by CreatedAt
+	// Sort jobs per provisioner by priority, then by CreatedAt
 	for daemonID := range jobRanks {
-		sort.Slice(jobRanks[daemonID], func(i, j int) bool {
-			return jobRanks[daemonID][i].CreatedAt.Before(jobRanks[daemonID][j].CreatedAt)
-		})
+		slices.SortFunc(jobRanks[daemonID], compareProvisionerJobQueueOrder)
 	}
 
 	// Step 5: Compute queue position & max queue size across all provisioners
 	jobQueueStats := make(map[uuid.UUID]database.GetProvisionerJobsByIDsWithQueuePositionRow)
+	eligibleProvisioners := make(map[uuid.UUID]int64)
 	for _, jobs := range jobRanks {
 		queueSize := int64(len(jobs)) // Queue size per provisioner
 		for i, job := range jobs {
 			queuePosition := int64(i + 1)
+			eligibleProvisioners[job.ID]++
 
 			// If the job already exists, update only if this queuePosition is better
 			if existing, exists := jobQueueStats[job.ID]; exists {
@@ -1270,10 +1294,12 @@ func (q *FakeQuerier) getProvisionerJobsByIDsWithQueuePositionLockedTagBasedQueu
 	}
 
 	// Step 6: Compute the final results with minimal checks
+	averageDurationMs := q.averageProvisionerJobDurationMsNoLock()
 	var results []database.GetProvisionerJobsByIDsWithQueuePositionRow
 	for _, job := range filteredJobs {
 		// If the job has a computed rank, use it
 		if rank, found := jobQueueStats[job.ID]; found {
+			rank.EstimatedWaitMs = rank.QueuePosition * averageDurationMs / eligibleProvisioners[job.ID]
 			results = append(results, rank)
 		} else {
 			// Otherwise, return (0,0) for non-pending jobs and unranked pending jobs
@@ -1310,11 +1336,7 @@ func (q *FakeQuerier) getProvisionerJobsByIDsWithQueuePositionLockedGlobalQueue(
 	//		AND
 	//			error IS NULL
 	//	),
-	type pendingJobRow struct {
-		ID        uuid.UUID
-		CreatedAt time.Time
-	}
-	pendingJobs := make([]pendingJobRow, 0)
+	pendingJobs := make([]database.ProvisionerJob, 0)
 	for _, job := range q.provisionerJobs {
 		if job.StartedAt.Valid ||
 			job.CanceledAt.Valid ||
@@ -1322,23 +1344,17 @@ func (q *FakeQuerier) getProvisionerJobsByIDsWithQueuePositionLockedGlobalQueue(
 			job.Error.Valid {
 			continue
 		}
-		pendingJobs = append(pendingJobs, pendingJobRow{
-			ID:        job.ID,
-			CreatedAt: job.CreatedAt,
-		})
+		pendingJobs = append(pendingJobs, job)
 	}
 
 	//	queue_position AS (
 	//		SELECT
 	//			id,
-	//				ROW_NUMBER() OVER (ORDER BY created_at ASC) AS queue_position
+	//				ROW_NUMBER() OVER (ORDER BY priority DESC, created_at ASC) AS queue_position
 	//		FROM
 	//			pending_jobs
 	// 	),
-	slices.SortFunc(pendingJobs, func(a, b pendingJobRow) int {
-		c := a.CreatedAt.Compare(b.CreatedAt)
-		return c
-	})
+	slices.SortFunc(pendingJobs, compareProvisionerJobQueueOrder)
 
 	queuePosition := make(map[uuid.UUID]int64)
 	for idx, pj := range pendingJobs {
@@ -1349,6 +1365,7 @@ func (q *FakeQuerier) getProvisionerJobsByIDsWithQueuePositionLockedGlobalQueue(
 	//		SELECT COUNT(*) AS count FROM pending_jobs
 	//	),
 	queueSize := len(pendingJobs)
+	averageDurationMs := q.averageProvisionerJobDurationMsNoLock()
 
 	//	SELECT
 	//		sqlc.embed(pj),
@@ -1377,7 +1394,8 @@ func (q *FakeQuerier) getProvisionerJobsByIDsWithQueuePositionLockedGlobalQueue(
 			//	COALESCE(qp.queue_position, 0) AS queue_position,
 			QueuePosition: queuePosition[job.ID],
 			//	COALESCE(qs.count, 0) AS queue_size
-			QueueSize: int64(queueSize),
+			QueueSize:       int64(queueSize),
+			EstimatedWaitMs: queuePosition[job.ID] * averageDurationMs,
 		}
 		jobs = append(jobs, job)
 	}
@@ -1385,6 +1403,82 @@ func (q *FakeQuerier) getProvisionerJobsByIDsWithQueuePositionLockedGlobalQueue(
 	return jobs, nil
 }
 
+// provisionerJobPriorityRank orders priorities from the lowest to the highest,
+// like the provisioner_job_priority enum.
+func provisionerJobPriorityRank(priority database.ProvisionerJobPriority) int {
+	return slices.Index(database.AllProvisionerJobPriorityValues(), priority)
+}
+
+// compareProvisionerJobQueueOrder orders pending jobs by priority, then by
+// creation.
+func compareProvisionerJobQueueOrder(a, b database.ProvisionerJob) int {
+	if c := provisionerJobPriorityRank(b.Priority) - provisionerJobPriorityRank(a.Priority); c != 0 {
+		return c
+	}
+	return a.CreatedAt.Compare(b.CreatedAt)
+}
+
+// provisionerJobsAcquireOrderNoLock returns the indexes of the provisioner
+// jobs in the order AcquireProvisionerJob considers them: by priority, then by
+// the running jobs of their initiator and template, then by creation.
+func (q *FakeQuerier) provisionerJobsAcquireOrderNoLock() []int {
+	runningByInitiator := make(map[uuid.UUID]int)
+	runningByTemplate := make(map[uuid.UUID]int)
+	for _, job := range q.provisionerJobs {
+		if !job.StartedAt.Valid || job.CompletedAt.Valid {
+			continue
+		}
+		runningByInitiator[job.InitiatorID]++
+		if job.TemplateID.Valid {
+			runningByTemplate[job.TemplateID.UUID]++
+		}
+	}
+	runningTemplateJobs := func(job database.ProvisionerJob) int {
+		if !job.TemplateID.Valid {
+			return 0
+		}
+		return runningByTemplate[job.TemplateID.UUID]
+	}
+
+	indexes := make([]int, len(q.provisionerJobs))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	sort.SliceStable(indexes, func(i, j int) bool {
+		a, b := q.provisionerJobs[indexes[i]], q.provisionerJobs[indexes[j]]
+		if pa, pb := provisionerJobPriorityRank(a.Priority), provisionerJobPriorityRank(b.Priority); pa != pb {
+			return pa > pb
+		}
+		if ra, rb := runningByInitiator[a.InitiatorID], runningByInitiator[b.InitiatorID]; ra != rb {
+			return ra < rb
+		}
+		if ra, rb := runningTemplateJobs(a), runningTemplateJobs(b); ra != rb {
+			return ra < rb
+		}
+		return a.CreatedAt.Before(b.CreatedAt)
+	})
+	return indexes
+}
+
+// averageProvisionerJobDurationMsNoLock returns the average duration of the
+// jobs completed in the last day, to estimate the wait of pending jobs.
+func (q *FakeQuerier) averageProvisionerJobDurationMsNoLock() int64 {
+	var total time.Duration
+	var count int64
+	since := dbtime.Now().Add(-24 * time.Hour)
+	for _, job := range q.provisionerJobs {
+		if !job.StartedAt.Valid || !job.CompletedAt.Valid || job.CompletedAt.Time.Before(since) {
+			continue
+		}
+		total += job.CompletedAt.Time.Sub(job.StartedAt.Time)
+		count++
+	}
+	if count == 0 {
+		return 0
+	}
+	return total.Milliseconds() / count
+}
+
 // isDeprecated returns true if the template is deprecated.
 // A template is considered deprecated when it has a deprecation message.
 func isDeprecated(template database.Template) bool {
@@ -1402,12 +1496,64 @@ func (q *FakeQuerier) getWorkspaceBuildParametersNoLock(workspaceBuildID uuid.UU
 	return params, nil
 }
 
+func (q *FakeQuerier) getWorkspaceSnapshotByIDNoLock(_ context.Context, id uuid.UUID) (database.WorkspaceSnapshot, error) {
+	for _, snapshot := range q.workspaceSnapshots {
+		if snapshot.ID == id {
+			return snapshot, nil
+		}
+	}
+	return database.WorkspaceSnapshot{}, sql.ErrNoRows
+}
+
 func (*FakeQuerier) AcquireLock(_ context.Context, _ int64) error {
 	return xerrors.New("AcquireLock must only be called within a transaction")
 }
 
+func (*FakeQuerier) AcquireNotificationDigestMessages(_ context.Context, arg database.AcquireNotificationDigestMessagesParams) ([]database.AcquireNotificationDigestMessagesRow, error) {
+	err := validateDatabaseType(arg)
+	if err != nil {
+		return nil, err
+	}
+	// Messages are never batched, see BulkDeferNotificationMessages.
+	return nil, nil
+}
+
 // AcquireNotificationMessages implements the *basic* business logic, but is *not* exhaustive or meant to be 1:1 with
 // the real AcquireNotificationMessages query.
+func (q *FakeQuerier) AcquireAuditLogExportCursor(_ context.Context, arg database.AcquireAuditLogExportCursorParams) (database.AuditLogExportCursor, error) {
+	err := validateDatabaseType(arg)
+	if err != nil {
+		return database.AuditLogExportCursor{}, err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, cursor := range q.auditLogExportCursors {
+		if cursor.Sink != arg.Sink {
+			continue
+		}
+		if cursor.LeasedBy.Valid && cursor.LeasedBy.UUID != arg.LeasedBy && !cursor.LeaseExpiresAt.Time.Before(arg.Now) {
+			return database.AuditLogExportCursor{}, sql.ErrNoRows
+		}
+		cursor.LeasedBy = uuid.NullUUID{UUID: arg.LeasedBy, Valid: true}
+		cursor.LeaseExpiresAt = sql.NullTime{Time: arg.LeaseExpiresAt, Valid: true}
+		q.auditLogExportCursors[i] = cursor
+		return cursor, nil
+	}
+
+	cursor := database.AuditLogExportCursor{
+		Sink:           arg.Sink,
+		LastXactID:     q.lastAuditLogXactID + 1,
+		LastID:         uuid.Nil,
+		UpdatedAt:      arg.Now,
+		LeasedBy:       uuid.NullUUID{UUID: arg.LeasedBy, Valid: true},
+		LeaseExpiresAt: sql.NullTime{Time: arg.LeaseExpiresAt, Valid: true},
+	}
+	q.auditLogExportCursors = append(q.auditLogExportCursors, cursor)
+	return cursor, nil
+}
+
 func (q *FakeQuerier) AcquireNotificationMessages(_ context.Context, arg database.AcquireNotificationMessagesParams) ([]database.AcquireNotificationMessagesRow, error) {
 	err := validateDatabaseType(arg)
 	if err != nil {
@@ -1460,7 +1606,8 @@ func (q *FakeQuerier) AcquireProvisionerJob(_ context.Context, arg database.Acqu
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
 
-	for index, provisionerJob := range q.provisionerJobs {
+	for _, index := range q.provisionerJobsAcquireOrderNoLock() {
+		provisionerJob := q.provisionerJobs[index]
 		if provisionerJob.OrganizationID != arg.OrganizationID {
 			continue
 		}
@@ -1748,6 +1895,14 @@ func (q *FakeQuerier) BatchUpdateWorkspaceNextStartAt(_ context.Context, arg dat
 	return nil
 }
 
+func (*FakeQuerier) BulkDeferNotificationMessages(_ context.Context, arg database.BulkDeferNotificationMessagesParams) (int64, error) {
+	err := validateDatabaseType(arg)
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(arg.IDs)), nil
+}
+
 func (*FakeQuerier) BulkMarkNotificationMessagesFailed(_ context.Context, arg database.BulkMarkNotificationMessagesFailedParams) (int64, error) {
 	err := validateDatabaseType(arg)
 	if err != nil {
@@ -1916,6 +2071,16 @@ func (*FakeQuerier) DeleteCoordinator(context.Context, uuid.UUID) error {
 	return ErrUnimplemented
 }
 
+func (q *FakeQuerier) DeleteAuditLogsByIDs(_ context.Context, ids []uuid.UUID) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.auditLogs = slices.DeleteFunc(q.auditLogs, func(alog database.AuditLog) bool {
+		return slices.Contains(ids, alog.ID)
+	})
+	return nil
+}
+
 func (q *FakeQuerier) DeleteCryptoKey(_ context.Context, arg database.DeleteCryptoKeyParams) (database.CryptoKey, error) {
 	err := validateDatabaseType(arg)
 	if err != nil {
@@ -2044,6 +2209,16 @@ func (q *FakeQuerier) DeleteLicense(_ context.Context, id int32) (int32, error)
 	return 0, sql.ErrNoRows
 }
 
+func (q *FakeQuerier) DeleteNotificationRule(_ context.Context, id uuid.UUID) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.notificationRules = slices.DeleteFunc(q.notificationRules, func(rule database.NotificationRule) bool {
+		return rule.ID == id
+	})
+	return nil
+}
+
 func (q *FakeQuerier) DeleteOAuth2ProviderAppByID(_ context.Context, id uuid.UUID) error {
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
@@ -2375,6 +2550,115 @@ func (q *FakeQuerier) DeleteOldWorkspaceAgentStats(_ context.Context) error {
 	return nil
 }
 
+func (q *FakeQuerier) DeleteOldWorkspaceBuildStates(_ context.Context, arg database.DeleteOldWorkspaceBuildStatesParams) (int64, error) {
+	err := validateDatabaseType(arg)
+	if err != nil {
+		return 0, err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	byWorkspace := make(map[uuid.UUID][]database.WorkspaceBuildState)
+	for _, state := range q.workspaceBuildStates {
+		byWorkspace[state.WorkspaceID] = append(byWorkspace[state.WorkspaceID], state)
+	}
+	deleted := make(map[uuid.UUID]bool)
+	for _, states := range byWorkspace {
+		slices.SortFunc(states, func(a, b database.WorkspaceBuildState) int {
+			if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+				return c
+			}
+			return slice.Ascending(a.WorkspaceBuildID.String(), b.WorkspaceBuildID.String())
+		})
+		for idx, state := range states {
+			position := int32(idx + 1)
+			if position == 1 {
+				continue
+			}
+			if (arg.KeepCount > 0 && position > arg.KeepCount) || state.CreatedAt.Before(arg.Before) {
+				deleted[state.WorkspaceBuildID] = true
+			}
+		}
+	}
+	q.workspaceBuildStates = slices.DeleteFunc(q.workspaceBuildStates, func(state database.WorkspaceBuildState) bool {
+		return deleted[state.WorkspaceBuildID]
+	})
+	return int64(len(deleted)), nil
+}
+
+func (q *FakeQuerier) DeleteOldWorkspaceSnapshots(_ context.Context, keepChains int32) ([]database.DeleteOldWorkspaceSnapshotsRow, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	type agentKey struct {
+		workspaceID uuid.UUID
+		agentName   string
+	}
+	roots := make(map[agentKey][]database.WorkspaceSnapshot)
+	for _, snapshot := range q.workspaceSnapshots {
+		if snapshot.ParentID.Valid {
+			continue
+		}
+		key := agentKey{workspaceID: snapshot.WorkspaceID, agentName: snapshot.AgentName}
+		roots[key] = append(roots[key], snapshot)
+	}
+	expired := make(map[uuid.UUID]bool)
+	for _, snapshots := range roots {
+		slices.SortFunc(snapshots, func(a, b database.WorkspaceSnapshot) int {
+			if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+				return c
+			}
+			return slice.Ascending(a.ID.String(), b.ID.String())
+		})
+		for idx, snapshot := range snapshots {
+			if int32(idx) >= keepChains { //nolint:gosec // The number of snapshots is small.
+				expired[snapshot.ID] = true
+			}
+		}
+	}
+	// Expire the snapshots incremental to expired snapshots, until no more
+	// are found.
+	for found := true; found; {
+		found = false
+		for _, snapshot := range q.workspaceSnapshots {
+			if snapshot.ParentID.Valid && expired[snapshot.ParentID.UUID] && !expired[snapshot.ID] {
+				expired[snapshot.ID] = true
+				found = true
+			}
+		}
+	}
+
+	var deleted []database.DeleteOldWorkspaceSnapshotsRow
+	sharedFiles := make(map[string]bool)
+	for _, snapshot := range q.workspaceSnapshots {
+		if expired[snapshot.ID] {
+			deleted = append(deleted, database.DeleteOldWorkspaceSnapshotsRow{
+				Storage:   snapshot.Storage,
+				ObjectKey: snapshot.ObjectKey,
+			})
+		} else if snapshot.Storage == database.WorkspaceSnapshotStorageDatabase {
+			sharedFiles[snapshot.ObjectKey] = true
+		}
+	}
+	deletedFiles := make(map[string]bool)
+	for _, row := range deleted {
+		if row.Storage == database.WorkspaceSnapshotStorageDatabase && !sharedFiles[row.ObjectKey] {
+			deletedFiles[row.ObjectKey] = true
+		}
+	}
+	q.workspaceSnapshots = slices.DeleteFunc(q.workspaceSnapshots, func(snapshot database.WorkspaceSnapshot) bool {
+		return expired[snapshot.ID]
+	})
+	q.workspaceSnapshotRestores = slices.DeleteFunc(q.workspaceSnapshotRestores, func(restore database.WorkspaceSnapshotRestore) bool {
+		return expired[restore.SnapshotID]
+	})
+	q.files = slices.DeleteFunc(q.files, func(file database.File) bool {
+		return deletedFiles[file.ID.String()] && file.Mimetype == "application/gzip"
+	})
+	return deleted, nil
+}
+
 func (q *FakeQuerier) DeleteOrganizationMember(ctx context.Context, arg database.DeleteOrganizationMemberParams) error {
 	err := validateDatabaseType(arg)
 	if err != nil {
@@ -2406,6 +2690,19 @@ func (q *FakeQuerier) DeleteOrganizationMember(ctx context.Context, arg database
 	return nil
 }
 
+func (q *FakeQuerier) DeletePlanPolicyByID(_ context.Context, id uuid.UUID) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, planPolicy := range q.planPolicies {
+		if planPolicy.ID == id {
+			q.planPolicies = append(q.planPolicies[:i], q.planPolicies[i+1:]...)
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
 func (q *FakeQuerier) DeleteProvisionerKey(_ context.Context, id uuid.UUID) error {
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
@@ -2433,6 +2730,30 @@ func (q *FakeQuerier) DeleteReplicasUpdatedBefore(_ context.Context, before time
 	return nil
 }
 
+func (q *FakeQuerier) DeleteRestoredAuditLogs(_ context.Context, restoredBefore time.Time) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	expired := make(map[uuid.UUID]struct{})
+	for i, archive := range q.auditLogArchives {
+		if archive.RestoredAt.Valid && archive.RestoredAt.Time.Before(restoredBefore) {
+			expired[archive.ID] = struct{}{}
+			q.auditLogArchives[i].RestoredAt = sql.NullTime{}
+		}
+	}
+	deleted := make(map[uuid.UUID]struct{})
+	for _, entry := range q.auditLogArchiveEntries {
+		if _, ok := expired[entry.ArchiveID]; ok {
+			deleted[entry.AuditLogID] = struct{}{}
+		}
+	}
+	q.auditLogs = slices.DeleteFunc(q.auditLogs, func(alog database.AuditLog) bool {
+		_, ok := deleted[alog.ID]
+		return ok
+	})
+	return nil
+}
+
 func (q *FakeQuerier) DeleteRuntimeConfig(_ context.Context, key string) error {
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
@@ -2471,6 +2792,37 @@ func (*FakeQuerier) DeleteTailnetTunnel(_ context.Context, arg database.DeleteTa
 	return database.DeleteTailnetTunnelRow{}, ErrUnimplemented
 }
 
+func (q *FakeQuerier) DeleteTemplatePortShareRules(_ context.Context, templateID uuid.UUID) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	rules := make([]database.TemplatePortShareRule, 0, len(q.templatePortShareRules))
+	for _, rule := range q.templatePortShareRules {
+		if rule.TemplateID == templateID {
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	q.templatePortShareRules = rules
+
+	return nil
+}
+
+func (q *FakeQuerier) DeleteUserChatIdentity(_ context.Context, arg database.DeleteUserChatIdentityParams) error {
+	err := validateDatabaseType(arg)
+	if err != nil {
+		return err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.userChatIdentities = slices.DeleteFunc(q.userChatIdentities, func(identity database.UserChatIdentity) bool {
+		return identity.UserID == arg.UserID && identity.Method == arg.Method
+	})
+	return nil
+}
+
 func (q *FakeQuerier) DeleteWebpushSubscriptionByUserIDAndEndpoint(_ context.Context, arg database.DeleteWebpushSubscriptionByUserIDAndEndpointParams) error {
 	err := validateDatabaseType(arg)
 	if err != nil {
@@ -2546,6 +2898,19 @@ func (q *FakeQuerier) DeleteWorkspaceAgentPortSharesByTemplate(_ context.Context
 	return nil
 }
 
+func (q *FakeQuerier) DeleteWorkspaceSnapshotRestore(_ context.Context, workspaceID uuid.UUID) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, restore := range q.workspaceSnapshotRestores {
+		if restore.WorkspaceID == workspaceID {
+			q.workspaceSnapshotRestores = append(q.workspaceSnapshotRestores[:i], q.workspaceSnapshotRestores[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
 func (q *FakeQuerier) DeleteWorkspaceSubAgentByID(_ context.Context, id uuid.UUID) error {
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
@@ -2897,10 +3262,225 @@ func (q *FakeQuerier) GetApplicationName(_ context.Context) (string, error) {
 	return q.applicationName, nil
 }
 
+func (q *FakeQuerier) GetArchivedAuditLogIDs(_ context.Context, ids []uuid.UUID) ([]uuid.UUID, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	archived := make([]uuid.UUID, 0)
+	for _, entry := range q.auditLogArchiveEntries {
+		if slices.Contains(ids, entry.AuditLogID) {
+			archived = append(archived, entry.AuditLogID)
+		}
+	}
+	return archived, nil
+}
+
+func (q *FakeQuerier) GetAuditLogArchiveByID(_ context.Context, id uuid.UUID) (database.AuditLogArchive, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	for _, archive := range q.auditLogArchives {
+		if archive.ID == id {
+			return archive, nil
+		}
+	}
+	return database.AuditLogArchive{}, sql.ErrNoRows
+}
+
+func (q *FakeQuerier) GetAuditLogArchives(_ context.Context) ([]database.AuditLogArchive, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	archives := slices.Clone(q.auditLogArchives)
+	slices.SortFunc(archives, func(a, b database.AuditLogArchive) int {
+		if c := b.OldestTime.Compare(a.OldestTime); c != 0 {
+			return c
+		}
+		return slice.Ascending(a.ID.String(), b.ID.String())
+	})
+	return archives, nil
+}
+
+func (q *FakeQuerier) GetAuditLogCheckpoints(_ context.Context, arg database.GetAuditLogCheckpointsParams) ([]database.AuditLogCheckpoint, error) {
+	err := validateDatabaseType(arg)
+	if err != nil {
+		return nil, err
+	}
+
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	checkpoints := make([]database.AuditLogCheckpoint, 0)
+	for _, checkpoint := range q.auditLogCheckpoints {
+		if checkpoint.Sequence >= arg.FirstSequence && checkpoint.Sequence <= arg.LastSequence {
+			checkpoints = append(checkpoints, checkpoint)
+		}
+	}
+	slices.SortFunc(checkpoints, func(a, b database.AuditLogCheckpoint) int {
+		return cmp.Compare(a.Sequence, b.Sequence)
+	})
+	return checkpoints, nil
+}
+
+func (q *FakeQuerier) GetAuditLogExportCursor(_ context.Context, sink string) (database.AuditLogExportCursor, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	for _, cursor := range q.auditLogExportCursors {
+		if cursor.Sink == sink {
+			return cursor, nil
+		}
+	}
+	return database.AuditLogExportCursor{}, sql.ErrNoRows
+}
+
+func (q *FakeQuerier) GetAuditLogHashSequenceRange(_ context.Context, arg database.GetAuditLogHashSequenceRangeParams) (database.GetAuditLogHashSequenceRangeRow, error) {
+	err := validateDatabaseType(arg)
+	if err != nil {
+		return database.GetAuditLogHashSequenceRangeRow{}, err
+	}
+
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	var row database.GetAuditLogHashSequenceRangeRow
+	for _, hash := range q.auditLogHashes {
+		if hash.AuditLogTime.Before(arg.FromTime) || hash.AuditLogTime.After(arg.ToTime) {
+			continue
+		}
+		if row.FirstSequence == 0 || hash.Sequence < row.FirstSequence {
+			row.FirstSequence = hash.Sequence
+		}
+		if hash.Sequence > row.LastSequence {
+			row.LastSequence = hash.Sequence
+		}
+	}
+	return row, nil
+}
+
+func (q *FakeQuerier) GetAuditLogHashes(_ context.Context, arg database.GetAuditLogHashesParams) ([]database.AuditLogHash, error) {
+	err := validateDatabaseType(arg)
+	if err != nil {
+		return nil, err
+	}
+
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	hashes := make([]database.AuditLogHash, 0)
+	for _, hash := range q.auditLogHashes {
+		if hash.Sequence > arg.AfterSequence && hash.Sequence <= arg.LastSequence {
+			hashes = append(hashes, hash)
+		}
+	}
+	slices.SortFunc(hashes, func(a, b database.AuditLogHash) int {
+		return cmp.Compare(a.Sequence, b.Sequence)
+	})
+	if arg.LimitOpt > 0 && len(hashes) > int(arg.LimitOpt) {
+		hashes = hashes[:arg.LimitOpt]
+	}
+	return hashes, nil
+}
+
+func (q *FakeQuerier) GetAuditLogsByIDs(_ context.Context, ids []uuid.UUID) ([]database.AuditLog, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	logs := make([]database.AuditLog, 0)
+	for _, alog := range q.auditLogs {
+		if slices.Contains(ids, alog.ID) {
+			logs = append(logs, alog)
+		}
+	}
+	return logs, nil
+}
+
+func (q *FakeQuerier) GetAuditLogsForExport(_ context.Context, arg database.GetAuditLogsForExportParams) ([]database.GetAuditLogsForExportRow, error) {
+	err := validateDatabaseType(arg)
+	if err != nil {
+		return nil, err
+	}
+
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	logs := make([]database.AuditLog, 0)
+	for _, alog := range q.auditLogs {
+		if !alog.XactID.Valid || alog.XactID.Int64 < arg.AfterXactID {
+			continue
+		}
+		if alog.XactID.Int64 == arg.AfterXactID && slice.Ascending(alog.ID.String(), arg.AfterID.String()) <= 0 {
+			continue
+		}
+		logs = append(logs, alog)
+	}
+	slices.SortFunc(logs, func(a, b database.AuditLog) int {
+		if c := cmp.Compare(a.XactID.Int64, b.XactID.Int64); c != 0 {
+			return c
+		}
+		return slice.Ascending(a.ID.String(), b.ID.String())
+	})
+	if arg.LimitOpt > 0 && len(logs) > int(arg.LimitOpt) {
+		logs = logs[:arg.LimitOpt]
+	}
+
+	rows := make([]database.GetAuditLogsForExportRow, 0, len(logs))
+	for _, alog := range logs {
+		row := database.GetAuditLogsForExportRow{AuditLog: alog}
+		if user, err := q.getUserByIDNoLock(alog.UserID); err == nil {
+			row.UserUsername = user.Username
+			row.UserEmail = user.Email
+		}
+		if org, err := q.getOrganizationByIDNoLock(alog.OrganizationID); err == nil {
+			row.OrganizationName = org.Name
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
 func (q *FakeQuerier) GetAuditLogsOffset(ctx context.Context, arg database.GetAuditLogsOffsetParams) ([]database.GetAuditLogsOffsetRow, error) {
 	return q.GetAuthorizedAuditLogsOffset(ctx, arg, nil)
 }
 
+func (q *FakeQuerier) GetAuditLogsToChain(_ context.Context, arg database.GetAuditLogsToChainParams) ([]database.AuditLog, error) {
+	err := validateDatabaseType(arg)
+	if err != nil {
+		return nil, err
+	}
+
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	chained := make(map[uuid.UUID]struct{}, len(q.auditLogHashes))
+	for _, hash := range q.auditLogHashes {
+		chained[hash.AuditLogID] = struct{}{}
+	}
+	logs := make([]database.AuditLog, 0)
+	for _, alog := range q.auditLogs {
+		if !alog.XactID.Valid || alog.XactID.Int64 < arg.AfterXactID {
+			continue
+		}
+		if alog.XactID.Int64 == arg.AfterXactID && slice.Ascending(alog.ID.String(), arg.AfterID.String()) <= 0 {
+			continue
+		}
+		if _, ok := chained[alog.ID]; ok {
+			continue
+		}
+		logs = append(logs, alog)
+	}
+	slices.SortFunc(logs, func(a, b database.AuditLog) int {
+		if c := cmp.Compare(a.XactID.Int64, b.XactID.Int64); c != 0 {
+			return c
+		}
+		return slice.Ascending(a.ID.String(), b.ID.String())
+	})
+	if arg.LimitOpt > 0 && len(logs) > int(arg.LimitOpt) {
+		logs = logs[:arg.LimitOpt]
+	}
+	return logs, nil
+}
+
 func (q *FakeQuerier) GetAuthorizationUserRoles(_ context.Context, userID uuid.UUID) (database.GetAuthorizationUserRolesRow, error) {
 	q.mutex.RLock()
 	defer q.mutex.RUnlock()
@@ -3288,6 +3868,108 @@ func (q *FakeQuerier) GetEligibleProvisionerDaemonsByProvisionerJobIDs(_ context
 	return results, nil
 }
 
+func (q *FakeQuerier) GetEnabledNotificationRules(_ context.Context) ([]database.NotificationRule, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	rules := make([]database.NotificationRule, 0)
+	for _, rule := range q.notificationRules {
+		if rule.Enabled {
+			rules = append(rules, rule)
+		}
+	}
+	slices.SortFunc(rules, func(a, b database.NotificationRule) int {
+		if c := slice.Ascending(a.OrganizationID.String(), b.OrganizationID.String()); c != 0 {
+			return c
+		}
+		return slice.Ascending(a.Name, b.Name)
+	})
+	return rules, nil
+}
+
+func (q *FakeQuerier) GetExpiredAuditLogs(_ context.Context, arg database.GetExpiredAuditLogsParams) ([]database.AuditLog, error) {
+	err := validateDatabaseType(arg)
+	if err != nil {
+		return nil, err
+	}
+
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	archived := make(map[uuid.UUID]struct{}, len(q.auditLogArchiveEntries))
+	for _, entry := range q.auditLogArchiveEntries {
+		archived[entry.AuditLogID] = struct{}{}
+	}
+	// The most specific rule applies: one with a resource type over one
+	// with an action only.
+	cutoff := func(alog database.AuditLog) time.Time {
+		cutoff, specificity := arg.DefaultCutoff, -1
+		for i := range arg.RuleCutoffs {
+			resourceType, action := arg.RuleResourceTypes[i], arg.RuleActions[i]
+			if resourceType != "" && resourceType != string(alog.ResourceType) {
+				continue
+			}
+			if action != "" && action != string(alog.Action) {
+				continue
+			}
+			s := 0
+			if resourceType != "" {
+				s += 2
+			}
+			if action != "" {
+				s++
+			}
+			if s > specificity {
+				cutoff, specificity = arg.RuleCutoffs[i], s
+			}
+		}
+		return cutoff
+	}
+	// Audit logs which an active sink didn't export yet are kept.
+	unexported := func(alog database.AuditLog) bool {
+		if !alog.XactID.Valid {
+			return false
+		}
+		for _, cursor := range q.auditLogExportCursors {
+			if !cursor.LeaseExpiresAt.Valid || !cursor.LeaseExpiresAt.Time.After(arg.SinkActiveAfter) {
+				continue
+			}
+			if alog.XactID.Int64 > cursor.LastXactID ||
+				(alog.XactID.Int64 == cursor.LastXactID && slice.Ascending(alog.ID.String(), cursor.LastID.String()) > 0) {
+				return true
+			}
+		}
+		return false
+	}
+
+	logs := make([]database.AuditLog, 0)
+	for _, alog := range q.auditLogs {
+		if !alog.Time.Before(arg.MaxCutoff) {
+			continue
+		}
+		if _, ok := archived[alog.ID]; ok {
+			continue
+		}
+		if !alog.Time.Before(cutoff(alog)) {
+			continue
+		}
+		if unexported(alog) {
+			continue
+		}
+		logs = append(logs, alog)
+	}
+	slices.SortFunc(logs, func(a, b database.AuditLog) int {
+		if c := a.Time.Compare(b.Time); c != 0 {
+			return c
+		}
+		return slice.Ascending(a.ID.String(), b.ID.String())
+	})
+	if len(logs) > int(arg.LimitOpt) {
+		logs = logs[:arg.LimitOpt]
+	}
+	return logs, nil
+}
+
 func (q *FakeQuerier) GetExternalAuthLink(_ context.Context, arg database.GetExternalAuthLinkParams) (database.ExternalAuthLink, error) {
 	if err := validateDatabaseType(arg); err != nil {
 		return database.ExternalAuthLink{}, err
@@ -3761,6 +4443,38 @@ func (q *FakeQuerier) GetLastUpdateCheck(_ context.Context) (string, error) {
 	return string(q.lastUpdateCheck), nil
 }
 
+func (q *FakeQuerier) GetLatestAuditLogCheckpoint(_ context.Context) (database.AuditLogCheckpoint, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	var latest database.AuditLogCheckpoint
+	for _, checkpoint := range q.auditLogCheckpoints {
+		if checkpoint.Sequence > latest.Sequence {
+			latest = checkpoint
+		}
+	}
+	if latest.Sequence == 0 {
+		return database.AuditLogCheckpoint{}, sql.ErrNoRows
+	}
+	return latest, nil
+}
+
+func (q *FakeQuerier) GetLatestAuditLogHash(_ context.Context) (database.AuditLogHash, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	var latest database.AuditLogHash
+	for _, hash := range q.auditLogHashes {
+		if hash.Sequence > latest.Sequence {
+			latest = hash
+		}
+	}
+	if latest.Sequence == 0 {
+		return database.AuditLogHash{}, sql.ErrNoRows
+	}
+	return latest, nil
+}
+
 func (q *FakeQuerier) GetLatestCryptoKeyByFeature(_ context.Context, feature database.CryptoKeyFeature) (database.CryptoKey, error) {
 	q.mutex.RLock()
 	defer q.mutex.RUnlock()
@@ -3865,6 +4579,27 @@ func (q *FakeQuerier) GetLatestWorkspaceBuildsByWorkspaceIDs(_ context.Context,
 	return returnBuilds, nil
 }
 
+func (q *FakeQuerier) GetLatestWorkspaceDriftCheckByWorkspaceID(_ context.Context, workspaceID uuid.UUID) (database.WorkspaceDriftCheck, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	var latest database.WorkspaceDriftCheck
+	found := false
+	for _, check := range q.workspaceDriftChecks {
+		if check.WorkspaceID != workspaceID {
+			continue
+		}
+		if !found || check.CreatedAt.After(latest.CreatedAt) {
+			latest = check
+			found = true
+		}
+	}
+	if !found {
+		return database.WorkspaceDriftCheck{}, sql.ErrNoRows
+	}
+	return latest, nil
+}
+
 func (q *FakeQuerier) GetLicenseByID(_ context.Context, id int32) (database.License, error) {
 	q.mutex.RLock()
 	defer q.mutex.RUnlock()
@@ -3934,6 +4669,34 @@ func (q *FakeQuerier) GetNotificationReportGeneratorLogByTemplate(_ context.Cont
 	return database.NotificationReportGeneratorLog{}, sql.ErrNoRows
 }
 
+func (q *FakeQuerier) GetNotificationRuleByID(_ context.Context, id uuid.UUID) (database.NotificationRule, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	for _, rule := range q.notificationRules {
+		if rule.ID == id {
+			return rule, nil
+		}
+	}
+	return database.NotificationRule{}, sql.ErrNoRows
+}
+
+func (q *FakeQuerier) GetNotificationRulesByOrganizationID(_ context.Context, organizationID uuid.UUID) ([]database.NotificationRule, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	rules := make([]database.NotificationRule, 0)
+	for _, rule := range q.notificationRules {
+		if rule.OrganizationID == organizationID {
+			rules = append(rules, rule)
+		}
+	}
+	slices.SortFunc(rules, func(a, b database.NotificationRule) int {
+		return slice.Ascending(a.Name, b.Name)
+	})
+	return rules, nil
+}
+
 func (*FakeQuerier) GetNotificationTemplateByID(_ context.Context, _ uuid.UUID) (database.NotificationTemplate, error) {
 	// Not implementing this function because it relies on state in the database which is created with migrations.
 	// We could consider using code-generation to align the database state and dbmem, but it's not worth it right now.
@@ -4147,6 +4910,30 @@ func (q *FakeQuerier) GetOrganizationByName(_ context.Context, params database.G
 	return database.Organization{}, sql.ErrNoRows
 }
 
+func (q *FakeQuerier) GetOrganizationHolidays(_ context.Context, arg database.GetOrganizationHolidaysParams) ([]database.OrganizationHoliday, error) {
+	if err := validateDatabaseType(arg); err != nil {
+		return nil, err
+	}
+
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	after := arg.After.Truncate(24 * time.Hour)
+	holidays := make([]database.OrganizationHoliday, 0)
+	for _, holiday := range q.organizationHolidays {
+		if holiday.OrganizationID == arg.OrganizationID && !holiday.Date.Before(after) {
+			holidays = append(holidays, holiday)
+		}
+	}
+	slices.SortFunc(holidays, func(a, b database.OrganizationHoliday) int {
+		if c := a.Date.Compare(b.Date); c != 0 {
+			return c
+		}
+		return slice.Ascending(a.Calendar, b.Calendar)
+	})
+	return holidays, nil
+}
+
 func (q *FakeQuerier) GetOrganizationIDsByMemberIDs(_ context.Context, ids []uuid.UUID) ([]database.GetOrganizationIDsByMemberIDsRow, error) {
 	q.mutex.RLock()
 	defer q.mutex.RUnlock()
@@ -4262,28 +5049,76 @@ func (q *FakeQuerier) GetOrganizationsByUserID(_ context.Context, arg database.G
 	return organizations, nil
 }
 
-func (q *FakeQuerier) GetParameterSchemasByJobID(_ context.Context, jobID uuid.UUID) ([]database.ParameterSchema, error) {
+func (q *FakeQuerier) GetParameterSchemasByJobID(_ context.Context, jobID uuid.UUID) ([]database.ParameterSchema, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	parameters := make([]database.ParameterSchema, 0)
+	for _, parameterSchema := range q.parameterSchemas {
+		if parameterSchema.JobID != jobID {
+			continue
+		}
+		parameters = append(parameters, parameterSchema)
+	}
+	if len(parameters) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	sort.Slice(parameters, func(i, j int) bool {
+		return parameters[i].Index < parameters[j].Index
+	})
+	return parameters, nil
+}
+
+func (*FakeQuerier) GetPrebuildMetrics(_ context.Context) ([]database.GetPrebuildMetricsRow, error) {
+	return make([]database.GetPrebuildMetricsRow, 0), nil
+}
+
+func (*FakeQuerier) GetPrebuildPoolHealth(_ context.Context, _ time.Time) ([]database.GetPrebuildPoolHealthRow, error) {
+	return nil, ErrUnimplemented
+}
+
+func (q *FakeQuerier) GetPlanPoliciesByOrganizationID(_ context.Context, organizationID uuid.UUID) ([]database.PlanPolicy, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	planPolicies := make([]database.PlanPolicy, 0)
+	for _, planPolicy := range q.planPolicies {
+		if planPolicy.OrganizationID == organizationID {
+			planPolicies = append(planPolicies, planPolicy)
+		}
+	}
+	slices.SortFunc(planPolicies, func(a, b database.PlanPolicy) int {
+		return slice.Ascending(a.Name, b.Name)
+	})
+	return planPolicies, nil
+}
+
+func (q *FakeQuerier) GetPlanPolicyByID(_ context.Context, id uuid.UUID) (database.PlanPolicy, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	for _, planPolicy := range q.planPolicies {
+		if planPolicy.ID == id {
+			return planPolicy, nil
+		}
+	}
+	return database.PlanPolicy{}, sql.ErrNoRows
+}
+
+func (q *FakeQuerier) GetPlanPolicyByOrganizationAndName(_ context.Context, arg database.GetPlanPolicyByOrganizationAndNameParams) (database.PlanPolicy, error) {
+	if err := validateDatabaseType(arg); err != nil {
+		return database.PlanPolicy{}, err
+	}
+
 	q.mutex.RLock()
 	defer q.mutex.RUnlock()
 
-	parameters := make([]database.ParameterSchema, 0)
-	for _, parameterSchema := range q.parameterSchemas {
-		if parameterSchema.JobID != jobID {
-			continue
+	for _, planPolicy := range q.planPolicies {
+		if planPolicy.OrganizationID == arg.OrganizationID && planPolicy.Name == arg.Name {
+			return planPolicy, nil
 		}
-		parameters = append(parameters, parameterSchema)
-	}
-	if len(parameters) == 0 {
-		return nil, sql.ErrNoRows
 	}
-	sort.Slice(parameters, func(i, j int) bool {
-		return parameters[i].Index < parameters[j].Index
-	})
-	return parameters, nil
-}
-
-func (*FakeQuerier) GetPrebuildMetrics(_ context.Context) ([]database.GetPrebuildMetricsRow, error) {
-	return make([]database.GetPrebuildMetricsRow, 0), nil
+	return database.PlanPolicy{}, sql.ErrNoRows
 }
 
 func (q *FakeQuerier) GetPrebuildsSettings(_ context.Context) (string, error) {
@@ -4456,6 +5291,83 @@ func (q *FakeQuerier) GetPreviousTemplateVersion(_ context.Context, arg database
 	return previousTemplateVersions[0], nil
 }
 
+func (q *FakeQuerier) GetProviderMirrorArchiveFileByHash(_ context.Context, arg database.GetProviderMirrorArchiveFileByHashParams) (database.File, error) {
+	err := validateDatabaseType(arg)
+	if err != nil {
+		return database.File{}, err
+	}
+
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	for _, file := range q.files {
+		if file.Hash != arg.Hash {
+			continue
+		}
+		for _, archive := range q.providerMirrorArchives {
+			if archive.FileID == file.ID && archive.OrganizationID == arg.OrganizationID {
+				return file, nil
+			}
+		}
+	}
+	return database.File{}, sql.ErrNoRows
+}
+
+func (q *FakeQuerier) GetProviderMirrorArchives(_ context.Context, arg database.GetProviderMirrorArchivesParams) ([]database.GetProviderMirrorArchivesRow, error) {
+	err := validateDatabaseType(arg)
+	if err != nil {
+		return nil, err
+	}
+
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	var archives []database.GetProviderMirrorArchivesRow
+	for _, archive := range q.providerMirrorArchives {
+		if archive.OrganizationID != arg.OrganizationID || archive.Hostname != arg.Hostname ||
+			archive.Namespace != arg.Namespace || archive.Type != arg.Type {
+			continue
+		}
+		for _, file := range q.files {
+			if file.ID != archive.FileID {
+				continue
+			}
+			archives = append(archives, database.GetProviderMirrorArchivesRow{
+				OrganizationID: archive.OrganizationID,
+				Hostname:       archive.Hostname,
+				Namespace:      archive.Namespace,
+				Type:           archive.Type,
+				Version:        archive.Version,
+				Platform:       archive.Platform,
+				Hash:           archive.Hash,
+				FileID:         archive.FileID,
+				CreatedAt:      archive.CreatedAt,
+				FileHash:       file.Hash,
+			})
+			break
+		}
+	}
+	slices.SortFunc(archives, func(a, b database.GetProviderMirrorArchivesRow) int {
+		if c := strings.Compare(a.Version, b.Version); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Platform, b.Platform)
+	})
+	return archives, nil
+}
+
+func (q *FakeQuerier) GetProviderMirrorTokenByJobID(_ context.Context, jobID uuid.UUID) (database.ProviderMirrorToken, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	for _, token := range q.providerMirrorTokens {
+		if token.JobID == jobID {
+			return token, nil
+		}
+	}
+	return database.ProviderMirrorToken{}, sql.ErrNoRows
+}
+
 func (q *FakeQuerier) GetProvisionerDaemons(_ context.Context) ([]database.ProvisionerDaemon, error) {
 	q.mutex.RLock()
 	defer q.mutex.RUnlock()
@@ -4752,7 +5664,7 @@ func (q *FakeQuerier) GetProvisionerJobsByOrganizationAndStatusWithQueuePosition
 		queue_position AS (
 			SELECT
 				id,
-				ROW_NUMBER() OVER (ORDER BY created_at ASC) AS queue_position
+				ROW_NUMBER() OVER (ORDER BY priority DESC, created_at ASC) AS queue_position
 			FROM
 				pending_jobs
 		),
@@ -4795,6 +5707,7 @@ func (q *FakeQuerier) GetProvisionerJobsByOrganizationAndStatusWithQueuePosition
 		return nil, err
 	}
 
+	staleBefore := dbtime.Now().Add(-time.Duration(arg.StaleIntervalMS) * time.Millisecond)
 	var rows []database.GetProvisionerJobsByOrganizationAndStatusWithQueuePositionAndProvisionerRow
 	for _, rowQP := range rowsWithQueuePosition {
 		job := rowQP.ProvisionerJob
@@ -4813,9 +5726,10 @@ func (q *FakeQuerier) GetProvisionerJobsByOrganizationAndStatusWithQueuePosition
 		}
 
 		row := database.GetProvisionerJobsByOrganizationAndStatusWithQueuePositionAndProvisionerRow{
-			ProvisionerJob: rowQP.ProvisionerJob,
-			QueuePosition:  rowQP.QueuePosition,
-			QueueSize:      rowQP.QueueSize,
+			ProvisionerJob:  rowQP.ProvisionerJob,
+			QueuePosition:   rowQP.QueuePosition,
+			QueueSize:       rowQP.QueueSize,
+			EstimatedWaitMs: rowQP.EstimatedWaitMs,
 		}
 
 		// Start add metadata.
@@ -4872,9 +5786,14 @@ func (q *FakeQuerier) GetProvisionerJobsByOrganizationAndStatusWithQueuePosition
 			slices.SortFunc(availableWorkers, func(a, b database.ProvisionerDaemon) int {
 				return a.CreatedAt.Compare(b.CreatedAt)
 			})
+			var onlineWorkers int64
 			for _, worker := range availableWorkers {
 				row.AvailableWorkers = append(row.AvailableWorkers, worker.ID)
+				if worker.LastSeenAt.Valid && !worker.LastSeenAt.Time.Before(staleBefore) {
+					onlineWorkers++
+				}
 			}
+			row.EstimatedWaitMs /= max(onlineWorkers, 1)
 		}
 
 		// Add daemon name to provisioner job
@@ -4999,6 +5918,107 @@ func (q *FakeQuerier) GetProvisionerLogsAfterID(_ context.Context, arg database.
 	return logs, nil
 }
 
+func (q *FakeQuerier) GetProvisionerQueueStats(_ context.Context, arg database.GetProvisionerQueueStatsParams) ([]database.GetProvisionerQueueStatsRow, error) {
+	err := validateDatabaseType(arg)
+	if err != nil {
+		return nil, err
+	}
+
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	now := dbtime.Now()
+	inOrg := func(orgID uuid.UUID) bool {
+		return arg.OrganizationID == uuid.Nil || orgID == arg.OrganizationID
+	}
+	online := func(daemon database.ProvisionerDaemon) bool {
+		return daemon.LastSeenAt.Valid && !daemon.LastSeenAt.Time.Before(now.Add(-time.Duration(arg.StaleIntervalMS)*time.Millisecond))
+	}
+	runningJobs := func(daemonID uuid.UUID) int64 {
+		var count int64
+		for _, job := range q.provisionerJobs {
+			if job.WorkerID.Valid && job.WorkerID.UUID == daemonID && job.JobStatus == database.ProvisionerJobStatusRunning {
+				count++
+			}
+		}
+		return count
+	}
+
+	var rows []database.GetProvisionerQueueStatsRow
+	addTagSet := func(orgID uuid.UUID, tags database.StringMap) {
+		for _, row := range rows {
+			if row.OrganizationID == orgID && tagsEqual(row.Tags, tags) {
+				return
+			}
+		}
+		rows = append(rows, database.GetProvisionerQueueStatsRow{OrganizationID: orgID, Tags: tags})
+	}
+	for _, daemon := range q.provisionerDaemons {
+		if inOrg(daemon.OrganizationID) && online(daemon) {
+			addTagSet(daemon.OrganizationID, daemon.Tags)
+		}
+	}
+	for _, job := range q.provisionerJobs {
+		if inOrg(job.OrganizationID) && job.JobStatus == database.ProvisionerJobStatusPending {
+			addTagSet(job.OrganizationID, job.Tags)
+		}
+	}
+	if arg.OrganizationID != uuid.Nil && len(arg.Tags) > 0 {
+		addTagSet(arg.OrganizationID, arg.Tags)
+	}
+
+	for idx, row := range rows {
+		var oldestPending time.Time
+		var waits []float64
+		for _, job := range q.provisionerJobs {
+			if job.OrganizationID != row.OrganizationID || !provisionerTagsetContains(row.Tags, job.Tags) {
+				continue
+			}
+			if job.JobStatus == database.ProvisionerJobStatusPending {
+				row.PendingJobs++
+				if oldestPending.IsZero() || job.CreatedAt.Before(oldestPending) {
+					oldestPending = job.CreatedAt
+				}
+			}
+			if job.StartedAt.Valid && !job.StartedAt.Time.Before(arg.StartedAfter) {
+				waits = append(waits, float64(job.StartedAt.Time.Sub(job.CreatedAt).Milliseconds()))
+			}
+		}
+		if !oldestPending.IsZero() {
+			row.OldestPendingJobMs = now.Sub(oldestPending).Milliseconds()
+		}
+		for _, daemon := range q.provisionerDaemons {
+			if daemon.OrganizationID != row.OrganizationID || !tagsEqual(daemon.Tags, row.Tags) {
+				continue
+			}
+			jobs := runningJobs(daemon.ID)
+			row.RunningJobs += jobs
+			if online(daemon) {
+				row.Daemons++
+				if jobs > 0 {
+					row.BusyDaemons++
+				}
+			}
+		}
+		if len(waits) > 0 {
+			row.WaitP50Ms = int64(tryPercentileCont(waits, 50))
+			row.WaitP95Ms = int64(tryPercentileCont(waits, 95))
+			row.WaitP99Ms = int64(tryPercentileCont(waits, 99))
+		}
+		rows[idx] = row
+	}
+
+	slices.SortFunc(rows, func(a, b database.GetProvisionerQueueStatsRow) int {
+		if c := slice.Ascending(a.OrganizationID.String(), b.OrganizationID.String()); c != 0 {
+			return c
+		}
+		aTags, _ := json.Marshal(a.Tags)
+		bTags, _ := json.Marshal(b.Tags)
+		return slice.Ascending(string(aTags), string(bTags))
+	})
+	return rows, nil
+}
+
 func (q *FakeQuerier) GetQuotaAllowanceForUser(_ context.Context, params database.GetQuotaAllowanceForUserParams) (int64, error) {
 	q.mutex.RLock()
 	defer q.mutex.RUnlock()
@@ -5567,6 +6587,22 @@ func (q *FakeQuerier) GetTemplateAppInsightsByTemplate(ctx context.Context, arg
 	return result, nil
 }
 
+func (q *FakeQuerier) GetTemplateAutostopWindows(_ context.Context, templateID uuid.UUID) ([]database.TemplateAutostopWindow, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	windows := make([]database.TemplateAutostopWindow, 0)
+	for _, window := range q.templateAutostopWindows {
+		if window.TemplateID == templateID {
+			windows = append(windows, window)
+		}
+	}
+	slices.SortFunc(windows, func(a, b database.TemplateAutostopWindow) int {
+		return slice.Ascending(a.Weekday, b.Weekday)
+	})
+	return windows, nil
+}
+
 func (q *FakeQuerier) GetTemplateAverageBuildTime(ctx context.Context, arg database.GetTemplateAverageBuildTimeParams) (database.GetTemplateAverageBuildTimeRow, error) {
 	if err := validateDatabaseType(arg); err != nil {
 		return database.GetTemplateAverageBuildTimeRow{}, err
@@ -6142,6 +7178,23 @@ func (q *FakeQuerier) GetTemplateParameterInsights(ctx context.Context, arg data
 	return rows, nil
 }
 
+func (q *FakeQuerier) GetTemplatePortShareRules(_ context.Context, templateID uuid.UUID) ([]database.TemplatePortShareRule, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	rules := make([]database.TemplatePortShareRule, 0)
+	for _, rule := range q.templatePortShareRules {
+		if rule.TemplateID == templateID {
+			rules = append(rules, rule)
+		}
+	}
+	slices.SortFunc(rules, func(a, b database.TemplatePortShareRule) int {
+		return int(a.PortStart - b.PortStart)
+	})
+
+	return rules, nil
+}
+
 func (*FakeQuerier) GetTemplatePresetsWithPrebuilds(_ context.Context, _ uuid.NullUUID) ([]database.GetTemplatePresetsWithPrebuildsRow, error) {
 	return nil, ErrUnimplemented
 }
@@ -6174,6 +7227,76 @@ func (q *FakeQuerier) GetTemplateUsageStats(_ context.Context, arg database.GetT
 	return stats, nil
 }
 
+func (q *FakeQuerier) GetTemplateVersionAdoption(ctx context.Context) ([]database.GetTemplateVersionAdoptionRow, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	type key struct {
+		templateID uuid.UUID
+		versionID  uuid.UUID
+	}
+	adoption := map[key]database.GetTemplateVersionAdoptionRow{}
+	for _, w := range q.workspaces {
+		if w.Deleted || w.OwnerID == database.PrebuildsSystemUserID {
+			continue
+		}
+		t, err := q.getTemplateByIDNoLock(ctx, w.TemplateID)
+		if err != nil {
+			return nil, xerrors.Errorf("get template by ID: %w", err)
+		}
+		if t.Deleted {
+			continue
+		}
+		build, err := q.getLatestWorkspaceBuildByWorkspaceIDNoLock(ctx, w.ID)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, xerrors.Errorf("get latest workspace build: %w", err)
+		}
+		tv, err := q.getTemplateVersionByIDNoLock(ctx, build.TemplateVersionID)
+		if err != nil {
+			return nil, xerrors.Errorf("get template version by ID: %w", err)
+		}
+		activeVersion, err := q.getTemplateVersionByIDNoLock(ctx, t.ActiveVersionID)
+		if err != nil {
+			return nil, xerrors.Errorf("get active template version by ID: %w", err)
+		}
+
+		k := key{templateID: t.ID, versionID: tv.ID}
+		row, ok := adoption[k]
+		if !ok {
+			row = database.GetTemplateVersionAdoptionRow{
+				TemplateID:             t.ID,
+				TemplateName:           t.Name,
+				TemplateDisplayName:    t.DisplayName,
+				TemplateOrganizationID: t.OrganizationID,
+				TemplateVersionID:      tv.ID,
+				TemplateVersionName:    tv.Name,
+				Active:                 tv.ID == t.ActiveVersionID,
+				ActiveVersionName:      activeVersion.Name,
+			}
+		}
+		row.WorkspaceCount++
+		adoption[k] = row
+	}
+
+	rows := make([]database.GetTemplateVersionAdoptionRow, 0, len(adoption))
+	for _, row := range adoption {
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].TemplateName != rows[j].TemplateName {
+			return rows[i].TemplateName < rows[j].TemplateName
+		}
+		if rows[i].WorkspaceCount != rows[j].WorkspaceCount {
+			return rows[i].WorkspaceCount > rows[j].WorkspaceCount
+		}
+		return rows[i].TemplateVersionName < rows[j].TemplateVersionName
+	})
+	return rows, nil
+}
+
 func (q *FakeQuerier) GetTemplateVersionByID(ctx context.Context, templateVersionID uuid.UUID) (database.TemplateVersion, error) {
 	q.mutex.RLock()
 	defer q.mutex.RUnlock()
@@ -6405,6 +7528,119 @@ func (q *FakeQuerier) GetTemplatesWithFilter(ctx context.Context, arg database.G
 	return q.GetAuthorizedTemplates(ctx, arg, nil)
 }
 
+func (q *FakeQuerier) GetTopWorkspaceResourceConsumers(ctx context.Context, arg database.GetTopWorkspaceResourceConsumersParams) ([]database.GetTopWorkspaceResourceConsumersRow, error) {
+	err := validateDatabaseType(arg)
+	if err != nil {
+		return nil, err
+	}
+
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	usage := map[uuid.UUID]database.GetTopWorkspaceResourceConsumersRow{}
+	for _, stat := range q.workspaceAgentStats {
+		if stat.CreatedAt.Before(arg.Since) {
+			continue
+		}
+		row := usage[stat.WorkspaceID]
+		row.TransferredBytes += stat.RxBytes + stat.TxBytes
+		row.Sessions += stat.SessionCountVSCode + stat.SessionCountJetBrains + stat.SessionCountReconnectingPTY + stat.SessionCountSSH
+		usage[stat.WorkspaceID] = row
+	}
+
+	byOrganization := map[uuid.UUID][]database.GetTopWorkspaceResourceConsumersRow{}
+	for workspaceID, row := range usage {
+		if row.TransferredBytes <= 0 {
+			continue
+		}
+		w, err := q.getWorkspaceByIDNoLock(ctx, workspaceID)
+		if err != nil {
+			return nil, xerrors.Errorf("get workspace by ID: %w", err)
+		}
+		if w.Deleted || w.OwnerID == database.PrebuildsSystemUserID {
+			continue
+		}
+		org, err := q.getOrganizationByIDNoLock(w.OrganizationID)
+		if err != nil {
+			return nil, xerrors.Errorf("get organization by ID: %w", err)
+		}
+
+		row.WorkspaceID = w.ID
+		row.WorkspaceName = w.Name
+		row.OwnerUsername = w.OwnerUsername
+		row.TemplateName = w.TemplateName
+		row.TemplateDisplayName = w.TemplateDisplayName
+		row.OrganizationID = org.ID
+		row.OrganizationName = org.Name
+		row.OrganizationDisplayName = org.DisplayName
+		if build, err := q.getLatestWorkspaceBuildByWorkspaceIDNoLock(ctx, w.ID); err == nil {
+			row.DailyCost = build.DailyCost
+		}
+		byOrganization[org.ID] = append(byOrganization[org.ID], row)
+	}
+
+	rows := make([]database.GetTopWorkspaceResourceConsumersRow, 0)
+	for _, orgRows := range byOrganization {
+		sort.Slice(orgRows, func(i, j int) bool {
+			if orgRows[i].TransferredBytes != orgRows[j].TransferredBytes {
+				return orgRows[i].TransferredBytes > orgRows[j].TransferredBytes
+			}
+			return orgRows[i].WorkspaceName < orgRows[j].WorkspaceName
+		})
+		for i, row := range orgRows {
+			if int64(i) >= arg.LimitPerOrganization {
+				break
+			}
+			row.Position = int64(i) + 1
+			rows = append(rows, row)
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].OrganizationName != rows[j].OrganizationName {
+			return rows[i].OrganizationName < rows[j].OrganizationName
+		}
+		return rows[i].Position < rows[j].Position
+	})
+	return rows, nil
+}
+
+func (q *FakeQuerier) GetUnchainedAuditLogs(_ context.Context, arg database.GetUnchainedAuditLogsParams) ([]database.GetUnchainedAuditLogsRow, error) {
+	err := validateDatabaseType(arg)
+	if err != nil {
+		return nil, err
+	}
+
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	chained := make(map[uuid.UUID]struct{}, len(q.auditLogHashes))
+	for _, hash := range q.auditLogHashes {
+		chained[hash.AuditLogID] = struct{}{}
+	}
+	rows := make([]database.GetUnchainedAuditLogsRow, 0)
+	for _, alog := range q.auditLogs {
+		if alog.Time.Before(arg.FromTime) || alog.Time.After(arg.ToTime) {
+			continue
+		}
+		if alog.XactID.Valid && (alog.XactID.Int64 > arg.HeadXactID ||
+			(alog.XactID.Int64 == arg.HeadXactID && slice.Ascending(alog.ID.String(), arg.HeadID.String()) > 0)) {
+			// Not chained yet.
+			continue
+		}
+		if _, ok := chained[alog.ID]; ok {
+			continue
+		}
+		rows = append(rows, database.GetUnchainedAuditLogsRow{ID: alog.ID, Time: alog.Time})
+	}
+	slices.SortFunc(rows, func(a, b database.GetUnchainedAuditLogsRow) int {
+		return a.Time.Compare(b.Time)
+	})
+	if arg.LimitOpt > 0 && len(rows) > int(arg.LimitOpt) {
+		rows = rows[:arg.LimitOpt]
+	}
+	return rows, nil
+}
+
 func (q *FakeQuerier) GetUnexpiredLicenses(_ context.Context) ([]database.License, error) {
 	q.mutex.RLock()
 	defer q.mutex.RUnlock()
@@ -6566,6 +7802,22 @@ func (q *FakeQuerier) GetUserActivityInsights(_ context.Context, arg database.Ge
 	return rows, nil
 }
 
+func (q *FakeQuerier) GetUserAutostopWindows(_ context.Context, userID uuid.UUID) ([]database.UserAutostopWindow, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	windows := make([]database.UserAutostopWindow, 0)
+	for _, window := range q.userAutostopWindows {
+		if window.UserID == userID {
+			windows = append(windows, window)
+		}
+	}
+	slices.SortFunc(windows, func(a, b database.UserAutostopWindow) int {
+		return slice.Ascending(a.Weekday, b.Weekday)
+	})
+	return windows, nil
+}
+
 func (q *FakeQuerier) GetUserByEmailOrUsername(_ context.Context, arg database.GetUserByEmailOrUsernameParams) (database.User, error) {
 	if err := validateDatabaseType(arg); err != nil {
 		return database.User{}, err
@@ -6589,6 +7841,39 @@ func (q *FakeQuerier) GetUserByID(_ context.Context, id uuid.UUID) (database.Use
 	return q.getUserByIDNoLock(id)
 }
 
+func (q *FakeQuerier) GetUserChatIdentities(_ context.Context, userID uuid.UUID) ([]database.UserChatIdentity, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	identities := make([]database.UserChatIdentity, 0)
+	for _, identity := range q.userChatIdentities {
+		if identity.UserID == userID {
+			identities = append(identities, identity)
+		}
+	}
+	slices.SortFunc(identities, func(a, b database.UserChatIdentity) int {
+		return strings.Compare(string(a.Method), string(b.Method))
+	})
+	return identities, nil
+}
+
+func (q *FakeQuerier) GetUserChatIdentity(_ context.Context, arg database.GetUserChatIdentityParams) (database.UserChatIdentity, error) {
+	err := validateDatabaseType(arg)
+	if err != nil {
+		return database.UserChatIdentity{}, err
+	}
+
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	for _, identity := range q.userChatIdentities {
+		if identity.UserID == arg.UserID && identity.Method == arg.Method {
+			return identity, nil
+		}
+	}
+	return database.UserChatIdentity{}, sql.ErrNoRows
+}
+
 // nolint:revive // It's not a control flag, it's a filter.
 func (q *FakeQuerier) GetUserCount(_ context.Context, includeSystem bool) (int64, error) {
 	q.mutex.RLock()
@@ -6726,6 +8011,19 @@ func (q *FakeQuerier) GetUserLinksByUserID(_ context.Context, userID uuid.UUID)
 	return uls, nil
 }
 
+func (q *FakeQuerier) GetUserNotificationDigestPreferences(_ context.Context, userID uuid.UUID) ([]database.NotificationDigestPreference, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	out := make([]database.NotificationDigestPreference, 0)
+	for _, pref := range q.notificationDigestPreferences {
+		if pref.UserID == userID {
+			out = append(out, pref)
+		}
+	}
+	return out, nil
+}
+
 func (q *FakeQuerier) GetUserNotificationPreferences(_ context.Context, userID uuid.UUID) ([]database.NotificationPreference, error) {
 	q.mutex.RLock()
 	defer q.mutex.RUnlock()
@@ -6742,6 +8040,18 @@ func (q *FakeQuerier) GetUserNotificationPreferences(_ context.Context, userID u
 	return out, nil
 }
 
+func (q *FakeQuerier) GetUserNotificationSchedule(_ context.Context, userID uuid.UUID) (database.UserNotificationSchedule, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	for _, schedule := range q.userNotificationSchedules {
+		if schedule.UserID == userID {
+			return schedule, nil
+		}
+	}
+	return database.UserNotificationSchedule{}, sql.ErrNoRows
+}
+
 func (q *FakeQuerier) GetUserStatusCounts(_ context.Context, arg database.GetUserStatusCountsParams) ([]database.GetUserStatusCountsRow, error) {
 	q.mutex.RLock()
 	defer q.mutex.RUnlock()
@@ -7917,6 +9227,85 @@ func (q *FakeQuerier) GetWorkspaceBuildByWorkspaceIDAndBuildNumber(_ context.Con
 	return database.WorkspaceBuild{}, sql.ErrNoRows
 }
 
+func (q *FakeQuerier) GetWorkspaceBuildEvents(ctx context.Context, arg database.GetWorkspaceBuildEventsParams) ([]database.GetWorkspaceBuildEventsRow, error) {
+	err := validateDatabaseType(arg)
+	if err != nil {
+		return nil, err
+	}
+
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	rows := make([]database.GetWorkspaceBuildEventsRow, 0)
+	for _, wb := range q.workspaceBuilds {
+		job, err := q.getProvisionerJobByIDNoLock(ctx, wb.JobID)
+		if err != nil {
+			return nil, xerrors.Errorf("get provisioner job by ID: %w", err)
+		}
+		if !job.CompletedAt.Valid || !job.CompletedAt.Time.After(arg.CompletedAfter) || job.CompletedAt.Time.After(arg.CompletedBefore) {
+			continue
+		}
+		if arg.Transition != "" && string(wb.Transition) != arg.Transition {
+			continue
+		}
+		if arg.JobStatus != "" && string(job.JobStatus) != arg.JobStatus {
+			continue
+		}
+		if arg.BuildReason != "" && string(wb.Reason) != arg.BuildReason {
+			continue
+		}
+
+		w, err := q.getWorkspaceByIDNoLock(ctx, wb.WorkspaceID)
+		if err != nil {
+			return nil, xerrors.Errorf("get workspace by ID: %w", err)
+		}
+		if w.OrganizationID != arg.OrganizationID {
+			continue
+		}
+		if arg.WorkspaceName != "" && w.Name != strings.ToLower(arg.WorkspaceName) {
+			continue
+		}
+		owner, err := q.getUserByIDNoLock(w.OwnerID)
+		if err != nil {
+			return nil, xerrors.Errorf("get user by ID: %w", err)
+		}
+		if arg.OwnerUsername != "" && owner.Username != strings.ToLower(arg.OwnerUsername) {
+			continue
+		}
+		t, err := q.getTemplateByIDNoLock(ctx, w.TemplateID)
+		if err != nil {
+			return nil, xerrors.Errorf("get template by ID: %w", err)
+		}
+		if arg.TemplateName != "" && t.Name != strings.ToLower(arg.TemplateName) {
+			continue
+		}
+
+		rows = append(rows, database.GetWorkspaceBuildEventsRow{
+			ID:                     wb.ID,
+			BuildNumber:            wb.BuildNumber,
+			Transition:             wb.Transition,
+			Reason:                 wb.Reason,
+			JobStatus:              job.JobStatus,
+			CompletedAt:            job.CompletedAt.Time,
+			WorkspaceID:            w.ID,
+			WorkspaceName:          w.Name,
+			WorkspaceOwnerUsername: owner.Username,
+			TemplateName:           t.Name,
+		})
+	}
+
+	slices.SortFunc(rows, func(a, b database.GetWorkspaceBuildEventsRow) int {
+		return b.CompletedAt.Compare(a.CompletedAt)
+	})
+	for i := range rows {
+		rows[i].Count = int64(len(rows))
+	}
+	if arg.LimitOpt > 0 && len(rows) > int(arg.LimitOpt) {
+		rows = rows[:arg.LimitOpt]
+	}
+	return rows, nil
+}
+
 func (q *FakeQuerier) GetWorkspaceBuildParameters(_ context.Context, workspaceBuildID uuid.UUID) ([]database.WorkspaceBuildParameter, error) {
 	q.mutex.RLock()
 	defer q.mutex.RUnlock()
@@ -7929,6 +9318,64 @@ func (q *FakeQuerier) GetWorkspaceBuildParametersByBuildIDs(ctx context.Context,
 	return q.GetAuthorizedWorkspaceBuildParametersByBuildIDs(ctx, workspaceBuildIDs, nil)
 }
 
+func (q *FakeQuerier) GetWorkspaceBuildStateByBuildID(_ context.Context, workspaceBuildID uuid.UUID) (database.WorkspaceBuildState, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	for _, state := range q.workspaceBuildStates {
+		if state.WorkspaceBuildID == workspaceBuildID {
+			return state, nil
+		}
+	}
+	return database.WorkspaceBuildState{}, sql.ErrNoRows
+}
+
+func (q *FakeQuerier) GetWorkspaceBuildStateKeyIDs(_ context.Context) ([]database.GetWorkspaceBuildStateKeyIDsRow, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	rows := make([]database.GetWorkspaceBuildStateKeyIDsRow, 0, len(q.workspaceBuildStates))
+	for _, state := range q.workspaceBuildStates {
+		rows = append(rows, database.GetWorkspaceBuildStateKeyIDsRow{
+			WorkspaceBuildID: state.WorkspaceBuildID,
+			StateKeyID:       state.StateKeyID,
+		})
+	}
+	slices.SortFunc(rows, func(a, b database.GetWorkspaceBuildStateKeyIDsRow) int {
+		return slice.Ascending(a.WorkspaceBuildID.String(), b.WorkspaceBuildID.String())
+	})
+	return rows, nil
+}
+
+func (q *FakeQuerier) GetWorkspaceBuildStatesByWorkspaceID(_ context.Context, workspaceID uuid.UUID) ([]database.GetWorkspaceBuildStatesByWorkspaceIDRow, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	rows := make([]database.GetWorkspaceBuildStatesByWorkspaceIDRow, 0)
+	for _, state := range q.workspaceBuildStates {
+		if state.WorkspaceID != workspaceID {
+			continue
+		}
+		for _, build := range q.workspaceBuilds {
+			if build.ID != state.WorkspaceBuildID {
+				continue
+			}
+			rows = append(rows, database.GetWorkspaceBuildStatesByWorkspaceIDRow{
+				WorkspaceBuildID: state.WorkspaceBuildID,
+				BuildNumber:      build.BuildNumber,
+				Transition:       build.Transition,
+				Hash:             state.Hash,
+				Size:             state.Size,
+				CreatedAt:        state.CreatedAt,
+			})
+		}
+	}
+	slices.SortFunc(rows, func(a, b database.GetWorkspaceBuildStatesByWorkspaceIDRow) int {
+		return slice.Descending(a.BuildNumber, b.BuildNumber)
+	})
+	return rows, nil
+}
+
 func (q *FakeQuerier) GetWorkspaceBuildStatsByTemplates(ctx context.Context, since time.Time) ([]database.GetWorkspaceBuildStatsByTemplatesRow, error) {
 	q.mutex.RLock()
 	defer q.mutex.RUnlock()
@@ -8155,6 +9602,153 @@ func (q *FakeQuerier) GetWorkspaceByWorkspaceAppID(_ context.Context, workspaceA
 	return database.Workspace{}, sql.ErrNoRows
 }
 
+func (q *FakeQuerier) GetWorkspaceCostInsights(_ context.Context, arg database.GetWorkspaceCostInsightsParams) ([]database.GetWorkspaceCostInsightsRow, error) {
+	err := validateDatabaseType(arg)
+	if err != nil {
+		return nil, err
+	}
+
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	type key struct {
+		organizationID, templateID, userID uuid.UUID
+	}
+	rowsByKey := make(map[key]*database.GetWorkspaceCostInsightsRow)
+	var rows []*database.GetWorkspaceCostInsightsRow
+	for _, usage := range q.workspaceCostUsage {
+		if usage.StartTime.Before(arg.StartTime) || !usage.StartTime.Before(arg.EndTime) {
+			continue
+		}
+		if len(arg.TemplateIDs) > 0 && !slices.Contains(arg.TemplateIDs, usage.TemplateID) {
+			continue
+		}
+		k := key{usage.OrganizationID, usage.TemplateID, usage.UserID}
+		row, ok := rowsByKey[k]
+		if !ok {
+			organization, err := q.getOrganizationByIDNoLock(usage.OrganizationID)
+			if err != nil {
+				continue
+			}
+			template, err := q.getTemplateByIDNoLock(context.Background(), usage.TemplateID)
+			if err != nil {
+				continue
+			}
+			user, err := q.getUserByIDNoLock(usage.UserID)
+			if err != nil {
+				continue
+			}
+			row = &database.GetWorkspaceCostInsightsRow{
+				OrganizationID:   organization.ID,
+				OrganizationName: organization.Name,
+				TemplateID:       template.ID,
+				TemplateName:     template.Name,
+				UserID:           user.ID,
+				Username:         user.Username,
+			}
+			rowsByKey[k] = row
+			rows = append(rows, row)
+		}
+		row.UsageSeconds += int64(usage.UsageSeconds)
+		row.CostMicros += usage.CostMicros
+	}
+
+	slices.SortFunc(rows, func(a, b *database.GetWorkspaceCostInsightsRow) int {
+		if a.CostMicros != b.CostMicros {
+			return slice.Descending(a.CostMicros, b.CostMicros)
+		}
+		return slice.Descending(a.UsageSeconds, b.UsageSeconds)
+	})
+	result := make([]database.GetWorkspaceCostInsightsRow, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, *row)
+	}
+	return result, nil
+}
+
+func (q *FakeQuerier) GetWorkspaceCostInsightsByGroup(_ context.Context, arg database.GetWorkspaceCostInsightsByGroupParams) ([]database.GetWorkspaceCostInsightsByGroupRow, error) {
+	err := validateDatabaseType(arg)
+	if err != nil {
+		return nil, err
+	}
+
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	var rows []database.GetWorkspaceCostInsightsByGroupRow
+	for _, group := range q.groups {
+		members := make(map[uuid.UUID]struct{})
+		if q.isEveryoneGroup(group.ID) {
+			for _, member := range q.organizationMembers {
+				if member.OrganizationID == group.OrganizationID {
+					members[member.UserID] = struct{}{}
+				}
+			}
+		} else {
+			for _, member := range q.groupMembers {
+				if member.GroupID == group.ID {
+					members[member.UserID] = struct{}{}
+				}
+			}
+		}
+
+		organization, err := q.getOrganizationByIDNoLock(group.OrganizationID)
+		if err != nil {
+			continue
+		}
+		row := database.GetWorkspaceCostInsightsByGroupRow{
+			GroupID:          group.ID,
+			GroupName:        group.Name,
+			OrganizationID:   group.OrganizationID,
+			OrganizationName: organization.Name,
+		}
+		found := false
+		for _, usage := range q.workspaceCostUsage {
+			if usage.OrganizationID != group.OrganizationID {
+				continue
+			}
+			if _, ok := members[usage.UserID]; !ok {
+				continue
+			}
+			if user, err := q.getUserByIDNoLock(usage.UserID); err != nil || user.Deleted {
+				continue
+			}
+			if usage.StartTime.Before(arg.StartTime) || !usage.StartTime.Before(arg.EndTime) {
+				continue
+			}
+			if len(arg.TemplateIDs) > 0 && !slices.Contains(arg.TemplateIDs, usage.TemplateID) {
+				continue
+			}
+			found = true
+			row.UsageSeconds += int64(usage.UsageSeconds)
+			row.CostMicros += usage.CostMicros
+		}
+		if found {
+			rows = append(rows, row)
+		}
+	}
+
+	slices.SortFunc(rows, func(a, b database.GetWorkspaceCostInsightsByGroupRow) int {
+		if a.CostMicros != b.CostMicros {
+			return slice.Descending(a.CostMicros, b.CostMicros)
+		}
+		return slice.Descending(a.UsageSeconds, b.UsageSeconds)
+	})
+	return rows, nil
+}
+
+func (q *FakeQuerier) GetWorkspaceDriftCheckByJobID(_ context.Context, jobID uuid.UUID) (database.WorkspaceDriftCheck, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	for _, check := range q.workspaceDriftChecks {
+		if check.JobID == jobID {
+			return check, nil
+		}
+	}
+	return database.WorkspaceDriftCheck{}, sql.ErrNoRows
+}
+
 func (q *FakeQuerier) GetWorkspaceModulesByJobID(_ context.Context, jobID uuid.UUID) ([]database.WorkspaceModule, error) {
 	q.mutex.RLock()
 	defer q.mutex.RUnlock()
@@ -8345,6 +9939,61 @@ func (q *FakeQuerier) GetWorkspaceResourcesCreatedAfter(_ context.Context, after
 	return resources, nil
 }
 
+func (q *FakeQuerier) GetWorkspaceSnapshotByID(ctx context.Context, id uuid.UUID) (database.WorkspaceSnapshot, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	return q.getWorkspaceSnapshotByIDNoLock(ctx, id)
+}
+
+func (q *FakeQuerier) GetWorkspaceSnapshotChain(ctx context.Context, id uuid.UUID) ([]database.WorkspaceSnapshot, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	var chain []database.WorkspaceSnapshot
+	for next := (uuid.NullUUID{UUID: id, Valid: true}); next.Valid; {
+		snapshot, err := q.getWorkspaceSnapshotByIDNoLock(ctx, next.UUID)
+		if errors.Is(err, sql.ErrNoRows) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, snapshot)
+		next = snapshot.ParentID
+	}
+	slices.Reverse(chain)
+	return chain, nil
+}
+
+func (q *FakeQuerier) GetWorkspaceSnapshotRestoreByWorkspaceID(_ context.Context, workspaceID uuid.UUID) (database.WorkspaceSnapshotRestore, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	for _, restore := range q.workspaceSnapshotRestores {
+		if restore.WorkspaceID == workspaceID {
+			return restore, nil
+		}
+	}
+	return database.WorkspaceSnapshotRestore{}, sql.ErrNoRows
+}
+
+func (q *FakeQuerier) GetWorkspaceSnapshotsByWorkspaceID(_ context.Context, workspaceID uuid.UUID) ([]database.WorkspaceSnapshot, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	snapshots := make([]database.WorkspaceSnapshot, 0)
+	for _, snapshot := range q.workspaceSnapshots {
+		if snapshot.WorkspaceID == workspaceID {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+	slices.SortStableFunc(snapshots, func(a, b database.WorkspaceSnapshot) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+	return snapshots, nil
+}
+
 func (q *FakeQuerier) GetWorkspaceUniqueOwnerCountByTemplateIDs(_ context.Context, templateIds []uuid.UUID) ([]database.GetWorkspaceUniqueOwnerCountByTemplateIDsRow, error) {
 	q.mutex.RLock()
 	defer q.mutex.RUnlock()
@@ -8375,19 +10024,95 @@ func (q *FakeQuerier) GetWorkspaceUniqueOwnerCountByTemplateIDs(_ context.Contex
 	return resp, nil
 }
 
-func (q *FakeQuerier) GetWorkspaces(ctx context.Context, arg database.GetWorkspacesParams) ([]database.GetWorkspacesRow, error) {
-	if err := validateDatabaseType(arg); err != nil {
-		return nil, err
+func (q *FakeQuerier) GetWorkspaceUpdatePreviewByJobID(_ context.Context, jobID uuid.UUID) (database.WorkspaceUpdatePreview, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	for _, preview := range q.workspaceUpdatePreviews {
+		if preview.JobID == jobID {
+			return preview, nil
+		}
+	}
+	return database.WorkspaceUpdatePreview{}, sql.ErrNoRows
+}
+
+func (q *FakeQuerier) GetWorkspaces(ctx context.Context, arg database.GetWorkspacesParams) ([]database.GetWorkspacesRow, error) {
+	if err := validateDatabaseType(arg); err != nil {
+		return nil, err
+	}
+
+	// A nil auth filter means no auth filter.
+	workspaceRows, err := q.GetAuthorizedWorkspaces(ctx, arg, nil)
+	return workspaceRows, err
+}
+
+func (q *FakeQuerier) GetWorkspacesAndAgentsByOwnerID(ctx context.Context, ownerID uuid.UUID) ([]database.GetWorkspacesAndAgentsByOwnerIDRow, error) {
+	// No auth filter.
+	return q.GetAuthorizedWorkspacesAndAgentsByOwnerID(ctx, ownerID, nil)
+}
+
+func (q *FakeQuerier) GetWorkspacesApproachingDeletion(ctx context.Context, before time.Time) ([]database.GetWorkspacesApproachingDeletionRow, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	rows := make([]database.GetWorkspacesApproachingDeletionRow, 0)
+	for _, w := range q.workspaces {
+		if w.Deleted || w.OwnerID == database.PrebuildsSystemUserID {
+			continue
+		}
+		t, err := q.getTemplateByIDNoLock(ctx, w.TemplateID)
+		if err != nil {
+			return nil, xerrors.Errorf("get template by ID: %w", err)
+		}
+		if t.Deleted {
+			continue
+		}
+
+		switch {
+		case w.DormantAt.Valid:
+			if !w.DeletingAt.Valid || w.DeletingAt.Time.After(before) {
+				continue
+			}
+		case t.TimeTilDormant > 0:
+			if w.LastUsedAt.Add(time.Duration(t.TimeTilDormant)).After(before) {
+				continue
+			}
+		default:
+			continue
+		}
+
+		owner, err := q.getUserByIDNoLock(w.OwnerID)
+		if err != nil {
+			return nil, xerrors.Errorf("get user by ID: %w", err)
+		}
+		rows = append(rows, database.GetWorkspacesApproachingDeletionRow{
+			WorkspaceID:            w.ID,
+			WorkspaceName:          w.Name,
+			OwnerUsername:          owner.Username,
+			TemplateID:             t.ID,
+			TemplateName:           t.Name,
+			TemplateDisplayName:    t.DisplayName,
+			TemplateOrganizationID: t.OrganizationID,
+			TimeTilDormant:         t.TimeTilDormant,
+			LastUsedAt:             w.LastUsedAt,
+			DormantAt:              w.DormantAt,
+			DeletingAt:             w.DeletingAt,
+		})
 	}
 
-	// A nil auth filter means no auth filter.
-	workspaceRows, err := q.GetAuthorizedWorkspaces(ctx, arg, nil)
-	return workspaceRows, err
-}
-
-func (q *FakeQuerier) GetWorkspacesAndAgentsByOwnerID(ctx context.Context, ownerID uuid.UUID) ([]database.GetWorkspacesAndAgentsByOwnerIDRow, error) {
-	// No auth filter.
-	return q.GetAuthorizedWorkspacesAndAgentsByOwnerID(ctx, ownerID, nil)
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].TemplateName != rows[j].TemplateName {
+			return rows[i].TemplateName < rows[j].TemplateName
+		}
+		if rows[i].DeletingAt.Valid != rows[j].DeletingAt.Valid {
+			return rows[i].DeletingAt.Valid
+		}
+		if !rows[i].DeletingAt.Time.Equal(rows[j].DeletingAt.Time) {
+			return rows[i].DeletingAt.Time.Before(rows[j].DeletingAt.Time)
+		}
+		return rows[i].LastUsedAt.Before(rows[j].LastUsedAt)
+	})
+	return rows, nil
 }
 
 func (q *FakeQuerier) GetWorkspacesByTemplateID(_ context.Context, templateID uuid.UUID) ([]database.WorkspaceTable, error) {
@@ -8404,6 +10129,65 @@ func (q *FakeQuerier) GetWorkspacesByTemplateID(_ context.Context, templateID uu
 	return workspaces, nil
 }
 
+func (q *FakeQuerier) GetWorkspacesDueForDriftCheck(ctx context.Context, now time.Time) ([]database.GetWorkspacesDueForDriftCheckRow, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	rows := []database.GetWorkspacesDueForDriftCheckRow{}
+	for _, workspace := range q.workspaces {
+		if workspace.Deleted || workspace.DormantAt.Valid || workspace.OwnerID == database.PrebuildsSystemUserID {
+			continue
+		}
+		template, err := q.getTemplateByIDNoLock(ctx, workspace.TemplateID)
+		if err != nil {
+			return nil, xerrors.Errorf("get template by ID: %w", err)
+		}
+		if template.DriftCheckIntervalMs <= 0 {
+			continue
+		}
+		interval := time.Duration(template.DriftCheckIntervalMs) * time.Millisecond
+
+		build, err := q.getLatestWorkspaceBuildByWorkspaceIDNoLock(ctx, workspace.ID)
+		if err != nil {
+			return nil, xerrors.Errorf("get workspace build by ID: %w", err)
+		}
+		if build.Transition != database.WorkspaceTransitionStart {
+			continue
+		}
+		job, err := q.getProvisionerJobByIDNoLock(ctx, build.JobID)
+		if err != nil {
+			return nil, xerrors.Errorf("get provisioner job by ID: %w", err)
+		}
+		if job.JobStatus != database.ProvisionerJobStatusSucceeded || job.CompletedAt.Time.After(now.Add(-interval)) {
+			continue
+		}
+
+		recent := false
+		for _, check := range q.workspaceDriftChecks {
+			if check.WorkspaceID != workspace.ID {
+				continue
+			}
+			checkJob, err := q.getProvisionerJobByIDNoLock(ctx, check.JobID)
+			if err != nil {
+				return nil, xerrors.Errorf("get drift check job by ID: %w", err)
+			}
+			if !checkJob.CompletedAt.Valid || check.CreatedAt.After(now.Add(-interval)) {
+				recent = true
+				break
+			}
+		}
+		if recent {
+			continue
+		}
+
+		rows = append(rows, database.GetWorkspacesDueForDriftCheckRow{
+			WorkspaceID:      workspace.ID,
+			WorkspaceBuildID: build.ID,
+		})
+	}
+	return rows, nil
+}
+
 func (q *FakeQuerier) GetWorkspacesEligibleForTransition(ctx context.Context, now time.Time) ([]database.GetWorkspacesEligibleForTransitionRow, error) {
 	q.mutex.RLock()
 	defer q.mutex.RUnlock()
@@ -8579,7 +10363,27 @@ func (q *FakeQuerier) InsertAuditLog(_ context.Context, arg database.InsertAudit
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
 
-	alog := database.AuditLog(arg)
+	q.lastAuditLogXactID++
+	alog := database.AuditLog{
+		ID:               arg.ID,
+		Time:             arg.Time,
+		UserID:           arg.UserID,
+		OrganizationID:   arg.OrganizationID,
+		Ip:               arg.Ip,
+		UserAgent:        arg.UserAgent,
+		ResourceType:     arg.ResourceType,
+		ResourceID:       arg.ResourceID,
+		ResourceTarget:   arg.ResourceTarget,
+		Action:           arg.Action,
+		Diff:             arg.Diff,
+		StatusCode:       arg.StatusCode,
+		AdditionalFields: arg.AdditionalFields,
+		RequestID:        arg.RequestID,
+		ResourceIcon:     arg.ResourceIcon,
+		// The fake has no concurrent transactions, so every audit log is
+		// visible to the export as soon as it is inserted.
+		XactID: sql.NullInt64{Int64: q.lastAuditLogXactID, Valid: true},
+	}
 
 	q.auditLogs = append(q.auditLogs, alog)
 	slices.SortFunc(q.auditLogs, func(a, b database.AuditLog) int {
@@ -8594,6 +10398,93 @@ func (q *FakeQuerier) InsertAuditLog(_ context.Context, arg database.InsertAudit
 	return alog, nil
 }
 
+func (q *FakeQuerier) InsertAuditLogArchive(_ context.Context, arg database.InsertAuditLogArchiveParams) (database.AuditLogArchive, error) {
+	err := validateDatabaseType(arg)
+	if err != nil {
+		return database.AuditLogArchive{}, err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for _, archive := range q.auditLogArchives {
+		if archive.ID == arg.ID || archive.FileName == arg.FileName {
+			return database.AuditLogArchive{}, errUniqueConstraint
+		}
+	}
+	archive := database.AuditLogArchive{
+		ID:            arg.ID,
+		CreatedAt:     arg.CreatedAt,
+		FileName:      arg.FileName,
+		FileID:        arg.FileID,
+		OldestTime:    arg.OldestTime,
+		NewestTime:    arg.NewestTime,
+		AuditLogCount: arg.AuditLogCount,
+		Sha256:        arg.Sha256,
+	}
+	q.auditLogArchives = append(q.auditLogArchives, archive)
+	return archive, nil
+}
+
+func (q *FakeQuerier) InsertAuditLogArchiveEntries(_ context.Context, arg database.InsertAuditLogArchiveEntriesParams) error {
+	err := validateDatabaseType(arg)
+	if err != nil {
+		return err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for _, entry := range q.auditLogArchiveEntries {
+		if slices.Contains(arg.AuditLogIDs, entry.AuditLogID) {
+			return errUniqueConstraint
+		}
+	}
+	for _, id := range arg.AuditLogIDs {
+		q.auditLogArchiveEntries = append(q.auditLogArchiveEntries, database.AuditLogArchiveEntry{
+			AuditLogID: id,
+			ArchiveID:  arg.ArchiveID,
+		})
+	}
+	return nil
+}
+
+func (q *FakeQuerier) InsertAuditLogCheckpoint(_ context.Context, arg database.InsertAuditLogCheckpointParams) error {
+	err := validateDatabaseType(arg)
+	if err != nil {
+		return err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for _, checkpoint := range q.auditLogCheckpoints {
+		if checkpoint.Sequence == arg.Sequence {
+			return errUniqueConstraint
+		}
+	}
+	q.auditLogCheckpoints = append(q.auditLogCheckpoints, database.AuditLogCheckpoint(arg))
+	return nil
+}
+
+func (q *FakeQuerier) InsertAuditLogHash(_ context.Context, arg database.InsertAuditLogHashParams) error {
+	err := validateDatabaseType(arg)
+	if err != nil {
+		return err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for _, hash := range q.auditLogHashes {
+		if hash.Sequence == arg.Sequence || hash.AuditLogID == arg.AuditLogID {
+			return errUniqueConstraint
+		}
+	}
+	q.auditLogHashes = append(q.auditLogHashes, database.AuditLogHash(arg))
+	return nil
+}
+
 func (q *FakeQuerier) InsertCryptoKey(_ context.Context, arg database.InsertCryptoKeyParams) (database.CryptoKey, error) {
 	err := validateDatabaseType(arg)
 	if err != nil {
@@ -8917,6 +10808,40 @@ func (q *FakeQuerier) InsertMissingGroups(_ context.Context, arg database.Insert
 	return newGroups, nil
 }
 
+func (q *FakeQuerier) InsertNotificationRule(_ context.Context, arg database.InsertNotificationRuleParams) (database.NotificationRule, error) {
+	err := validateDatabaseType(arg)
+	if err != nil {
+		return database.NotificationRule{}, err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for _, rule := range q.notificationRules {
+		if rule.OrganizationID == arg.OrganizationID && rule.Name == arg.Name {
+			return database.NotificationRule{}, newUniqueConstraintError(database.UniqueNotificationRulesOrganizationIDNameKey)
+		}
+	}
+
+	rule := database.NotificationRule{
+		ID:              arg.ID,
+		OrganizationID:  arg.OrganizationID,
+		UserID:          arg.UserID,
+		Name:            arg.Name,
+		Source:          arg.Source,
+		Query:           arg.Query,
+		Method:          arg.Method,
+		Threshold:       arg.Threshold,
+		WindowSeconds:   arg.WindowSeconds,
+		Enabled:         arg.Enabled,
+		CreatedAt:       arg.CreatedAt,
+		UpdatedAt:       arg.CreatedAt,
+		LastEvaluatedAt: arg.CreatedAt,
+	}
+	q.notificationRules = append(q.notificationRules, rule)
+	return rule, nil
+}
+
 func (q *FakeQuerier) InsertOAuth2ProviderApp(_ context.Context, arg database.InsertOAuth2ProviderAppParams) (database.OAuth2ProviderApp, error) {
 	err := validateDatabaseType(arg)
 	if err != nil {
@@ -9094,6 +11019,33 @@ func (q *FakeQuerier) InsertOrganizationMember(_ context.Context, arg database.I
 	return organizationMember, nil
 }
 
+func (q *FakeQuerier) InsertPlanPolicy(_ context.Context, arg database.InsertPlanPolicyParams) (database.PlanPolicy, error) {
+	if err := validateDatabaseType(arg); err != nil {
+		return database.PlanPolicy{}, err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for _, planPolicy := range q.planPolicies {
+		if planPolicy.OrganizationID == arg.OrganizationID && planPolicy.Name == arg.Name {
+			return database.PlanPolicy{}, errUniqueConstraint
+		}
+	}
+	//nolint:gosimple // Database structs are kept separate from params.
+	planPolicy := database.PlanPolicy{
+		ID:             arg.ID,
+		OrganizationID: arg.OrganizationID,
+		Name:           arg.Name,
+		Description:    arg.Description,
+		Module:         arg.Module,
+		CreatedAt:      arg.CreatedAt,
+		UpdatedAt:      arg.UpdatedAt,
+	}
+	q.planPolicies = append(q.planPolicies, planPolicy)
+	return planPolicy, nil
+}
+
 func (q *FakeQuerier) InsertPreset(_ context.Context, arg database.InsertPresetParams) (database.TemplateVersionPreset, error) {
 	err := validateDatabaseType(arg)
 	if err != nil {
@@ -9164,6 +11116,36 @@ func (q *FakeQuerier) InsertPresetPrebuildSchedule(ctx context.Context, arg data
 	return presetPrebuildSchedule, nil
 }
 
+func (q *FakeQuerier) InsertProviderMirrorArchive(_ context.Context, arg database.InsertProviderMirrorArchiveParams) (int64, error) {
+	err := validateDatabaseType(arg)
+	if err != nil {
+		return 0, err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for _, existing := range q.providerMirrorArchives {
+		if existing.OrganizationID == arg.OrganizationID && existing.Hostname == arg.Hostname &&
+			existing.Namespace == arg.Namespace && existing.Type == arg.Type &&
+			existing.Version == arg.Version && existing.Platform == arg.Platform {
+			return 0, nil
+		}
+	}
+	q.providerMirrorArchives = append(q.providerMirrorArchives, database.ProviderMirrorArchive{
+		OrganizationID: arg.OrganizationID,
+		Hostname:       arg.Hostname,
+		Namespace:      arg.Namespace,
+		Type:           arg.Type,
+		Version:        arg.Version,
+		Platform:       arg.Platform,
+		Hash:           arg.Hash,
+		FileID:         arg.FileID,
+		CreatedAt:      arg.CreatedAt,
+	})
+	return 1, nil
+}
+
 func (q *FakeQuerier) InsertProvisionerJob(_ context.Context, arg database.InsertProvisionerJobParams) (database.ProvisionerJob, error) {
 	if err := validateDatabaseType(arg); err != nil {
 		return database.ProvisionerJob{}, err
@@ -9185,6 +11167,8 @@ func (q *FakeQuerier) InsertProvisionerJob(_ context.Context, arg database.Inser
 		Input:          arg.Input,
 		Tags:           maps.Clone(arg.Tags),
 		TraceMetadata:  arg.TraceMetadata,
+		Priority:       arg.Priority,
+		TemplateID:     arg.TemplateID,
 	}
 	job.JobStatus = provisionerJobStatus(job)
 	q.provisionerJobs = append(q.provisionerJobs, job)
@@ -9357,6 +11341,30 @@ func (q *FakeQuerier) InsertTemplate(_ context.Context, arg database.InsertTempl
 	return nil
 }
 
+func (q *FakeQuerier) InsertTemplatePortShareRule(_ context.Context, arg database.InsertTemplatePortShareRuleParams) (database.TemplatePortShareRule, error) {
+	err := validateDatabaseType(arg)
+	if err != nil {
+		return database.TemplatePortShareRule{}, err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	//nolint:gosimple // casts are not a simplification
+	rule := database.TemplatePortShareRule{
+		ID:         arg.ID,
+		TemplateID: arg.TemplateID,
+		PortStart:  arg.PortStart,
+		PortEnd:    arg.PortEnd,
+		ShareLevel: arg.ShareLevel,
+		Protocol:   arg.Protocol,
+		Label:      arg.Label,
+	}
+	q.templatePortShareRules = append(q.templatePortShareRules, rule)
+
+	return rule, nil
+}
+
 func (q *FakeQuerier) InsertTemplateVersion(_ context.Context, arg database.InsertTemplateVersionParams) error {
 	if err := validateDatabaseType(arg); err != nil {
 		return err
@@ -10042,6 +12050,31 @@ func (q *FakeQuerier) InsertWorkspaceBuildParameters(_ context.Context, arg data
 	return nil
 }
 
+func (q *FakeQuerier) InsertWorkspaceDriftCheck(_ context.Context, arg database.InsertWorkspaceDriftCheckParams) (database.WorkspaceDriftCheck, error) {
+	err := validateDatabaseType(arg)
+	if err != nil {
+		return database.WorkspaceDriftCheck{}, err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for _, check := range q.workspaceDriftChecks {
+		if check.JobID == arg.JobID {
+			return database.WorkspaceDriftCheck{}, errUniqueConstraint
+		}
+	}
+	check := database.WorkspaceDriftCheck{
+		ID:               arg.ID,
+		WorkspaceID:      arg.WorkspaceID,
+		WorkspaceBuildID: arg.WorkspaceBuildID,
+		JobID:            arg.JobID,
+		CreatedAt:        arg.CreatedAt,
+	}
+	q.workspaceDriftChecks = append(q.workspaceDriftChecks, check)
+	return check, nil
+}
+
 func (q *FakeQuerier) InsertWorkspaceModule(_ context.Context, arg database.InsertWorkspaceModuleParams) (database.WorkspaceModule, error) {
 	err := validateDatabaseType(arg)
 	if err != nil {
@@ -10097,16 +12130,17 @@ func (q *FakeQuerier) InsertWorkspaceResource(_ context.Context, arg database.In
 
 	//nolint:gosimple
 	resource := database.WorkspaceResource{
-		ID:         arg.ID,
-		CreatedAt:  arg.CreatedAt,
-		JobID:      arg.JobID,
-		Transition: arg.Transition,
-		Type:       arg.Type,
-		Name:       arg.Name,
-		Hide:       arg.Hide,
-		Icon:       arg.Icon,
-		DailyCost:  arg.DailyCost,
-		ModulePath: arg.ModulePath,
+		ID:               arg.ID,
+		CreatedAt:        arg.CreatedAt,
+		JobID:            arg.JobID,
+		Transition:       arg.Transition,
+		Type:             arg.Type,
+		Name:             arg.Name,
+		Hide:             arg.Hide,
+		Icon:             arg.Icon,
+		DailyCost:        arg.DailyCost,
+		ModulePath:       arg.ModulePath,
+		HourlyCostMicros: arg.HourlyCostMicros,
 	}
 	q.workspaceResources = append(q.workspaceResources, resource)
 	return resource, nil
@@ -10143,6 +12177,43 @@ func (q *FakeQuerier) InsertWorkspaceResourceMetadata(_ context.Context, arg dat
 	return metadata, nil
 }
 
+func (q *FakeQuerier) InsertWorkspaceSnapshot(_ context.Context, arg database.InsertWorkspaceSnapshotParams) (database.WorkspaceSnapshot, error) {
+	err := validateDatabaseType(arg)
+	if err != nil {
+		return database.WorkspaceSnapshot{}, err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	snapshot := database.WorkspaceSnapshot(arg)
+	q.workspaceSnapshots = append(q.workspaceSnapshots, snapshot)
+	return snapshot, nil
+}
+
+func (q *FakeQuerier) InsertWorkspaceUpdatePreview(_ context.Context, arg database.InsertWorkspaceUpdatePreviewParams) (database.WorkspaceUpdatePreview, error) {
+	err := validateDatabaseType(arg)
+	if err != nil {
+		return database.WorkspaceUpdatePreview{}, err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for _, preview := range q.workspaceUpdatePreviews {
+		if preview.JobID == arg.JobID {
+			return database.WorkspaceUpdatePreview{}, errUniqueConstraint
+		}
+	}
+	preview := database.WorkspaceUpdatePreview{
+		JobID:       arg.JobID,
+		WorkspaceID: arg.WorkspaceID,
+		CreatedAt:   arg.CreatedAt,
+	}
+	q.workspaceUpdatePreviews = append(q.workspaceUpdatePreviews, preview)
+	return preview, nil
+}
+
 func (q *FakeQuerier) ListProvisionerKeysByOrganization(_ context.Context, organizationID uuid.UUID) ([]database.ProvisionerKey, error) {
 	q.mutex.RLock()
 	defer q.mutex.RUnlock()
@@ -10550,6 +12621,47 @@ func (q *FakeQuerier) UpdateAPIKeyByID(_ context.Context, arg database.UpdateAPI
 	return sql.ErrNoRows
 }
 
+func (q *FakeQuerier) UpdateAuditLogArchiveRestoredAt(_ context.Context, arg database.UpdateAuditLogArchiveRestoredAtParams) error {
+	err := validateDatabaseType(arg)
+	if err != nil {
+		return err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, archive := range q.auditLogArchives {
+		if archive.ID == arg.ID {
+			q.auditLogArchives[i].RestoredAt = arg.RestoredAt
+			return nil
+		}
+	}
+	return nil
+}
+
+func (q *FakeQuerier) UpdateAuditLogExportCursor(_ context.Context, arg database.UpdateAuditLogExportCursorParams) (int64, error) {
+	err := validateDatabaseType(arg)
+	if err != nil {
+		return 0, err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, cursor := range q.auditLogExportCursors {
+		if cursor.Sink != arg.Sink || !cursor.LeasedBy.Valid || cursor.LeasedBy.UUID != arg.LeasedBy {
+			continue
+		}
+		cursor.LastXactID = arg.LastXactID
+		cursor.LastID = arg.LastID
+		cursor.UpdatedAt = arg.UpdatedAt
+		cursor.LeaseExpiresAt = sql.NullTime{Time: arg.LeaseExpiresAt, Valid: true}
+		q.auditLogExportCursors[i] = cursor
+		return 1, nil
+	}
+	return 0, nil
+}
+
 func (q *FakeQuerier) UpdateCryptoKeyDeletesAt(_ context.Context, arg database.UpdateCryptoKeyDeletesAtParams) (database.CryptoKey, error) {
 	err := validateDatabaseType(arg)
 	if err != nil {
@@ -10787,6 +12899,65 @@ func (q *FakeQuerier) UpdateMemoryResourceMonitor(_ context.Context, arg databas
 	return nil
 }
 
+func (q *FakeQuerier) UpdateNotificationRule(_ context.Context, arg database.UpdateNotificationRuleParams) (database.NotificationRule, error) {
+	err := validateDatabaseType(arg)
+	if err != nil {
+		return database.NotificationRule{}, err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, rule := range q.notificationRules {
+		if rule.ID != arg.ID {
+			continue
+		}
+		for _, other := range q.notificationRules {
+			if other.ID != rule.ID && other.OrganizationID == rule.OrganizationID && other.Name == arg.Name {
+				return database.NotificationRule{}, newUniqueConstraintError(database.UniqueNotificationRulesOrganizationIDNameKey)
+			}
+		}
+		if !rule.Enabled && arg.Enabled {
+			rule.LastEvaluatedAt = arg.UpdatedAt
+		}
+		rule.Name = arg.Name
+		rule.Query = arg.Query
+		rule.Method = arg.Method
+		rule.Threshold = arg.Threshold
+		rule.WindowSeconds = arg.WindowSeconds
+		rule.Enabled = arg.Enabled
+		rule.UpdatedAt = arg.UpdatedAt
+		q.notificationRules[i] = rule
+		return rule, nil
+	}
+	return database.NotificationRule{}, sql.ErrNoRows
+}
+
+func (q *FakeQuerier) UpdateNotificationRuleEvaluation(_ context.Context, arg database.UpdateNotificationRuleEvaluationParams) error {
+	err := validateDatabaseType(arg)
+	if err != nil {
+		return err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, rule := range q.notificationRules {
+		if rule.ID != arg.ID {
+			continue
+		}
+		rule.LastEvaluatedAt = arg.LastEvaluatedAt
+		if arg.LastTriggeredAt.Valid {
+			rule.LastTriggeredAt = arg.LastTriggeredAt
+		}
+		if arg.Enabled.Valid {
+			rule.Enabled = arg.Enabled.Bool
+		}
+		q.notificationRules[i] = rule
+	}
+	return nil
+}
+
 func (*FakeQuerier) UpdateNotificationTemplateMethodByID(_ context.Context, _ database.UpdateNotificationTemplateMethodByIDParams) (database.NotificationTemplate, error) {
 	// Not implementing this function because it relies on state in the database which is created with migrations.
 	// We could consider using code-generation to align the database state and dbmem, but it's not worth it right now.
@@ -10905,6 +13076,54 @@ func (q *FakeQuerier) UpdateOrganizationDeletedByID(_ context.Context, arg datab
 	return sql.ErrNoRows
 }
 
+func (q *FakeQuerier) UpdateOrganizationHolidayCalendar(_ context.Context, arg database.UpdateOrganizationHolidayCalendarParams) error {
+	if err := validateDatabaseType(arg); err != nil {
+		return err
+	}
+	if len(arg.IDs) != len(arg.Dates) || len(arg.IDs) != len(arg.Names) {
+		return xerrors.New("ids, dates and names must have the same length")
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.organizationHolidays = slices.DeleteFunc(q.organizationHolidays, func(holiday database.OrganizationHoliday) bool {
+		return holiday.OrganizationID == arg.OrganizationID && holiday.Calendar == arg.Calendar
+	})
+	for i, id := range arg.IDs {
+		q.organizationHolidays = append(q.organizationHolidays, database.OrganizationHoliday{
+			ID:             id,
+			OrganizationID: arg.OrganizationID,
+			Calendar:       arg.Calendar,
+			Date:           arg.Dates[i].Truncate(24 * time.Hour),
+			Name:           arg.Names[i],
+			CreatedAt:      arg.CreatedAt,
+		})
+	}
+	return nil
+}
+
+func (q *FakeQuerier) UpdatePlanPolicyByID(_ context.Context, arg database.UpdatePlanPolicyByIDParams) (database.PlanPolicy, error) {
+	if err := validateDatabaseType(arg); err != nil {
+		return database.PlanPolicy{}, err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, planPolicy := range q.planPolicies {
+		if planPolicy.ID != arg.ID {
+			continue
+		}
+		planPolicy.Description = arg.Description
+		planPolicy.Module = arg.Module
+		planPolicy.UpdatedAt = arg.UpdatedAt
+		q.planPolicies[i] = planPolicy
+		return planPolicy, nil
+	}
+	return database.PlanPolicy{}, sql.ErrNoRows
+}
+
 func (q *FakeQuerier) UpdatePresetPrebuildStatus(ctx context.Context, arg database.UpdatePresetPrebuildStatusParams) error {
 	err := validateDatabaseType(arg)
 	if err != nil {
@@ -11127,6 +13346,30 @@ func (q *FakeQuerier) UpdateTemplateActiveVersionByID(_ context.Context, arg dat
 	return sql.ErrNoRows
 }
 
+func (q *FakeQuerier) UpdateTemplateAutostopWindows(_ context.Context, arg database.UpdateTemplateAutostopWindowsParams) error {
+	if err := validateDatabaseType(arg); err != nil {
+		return err
+	}
+	if len(arg.Weekdays) != len(arg.StopMinutes) {
+		return xerrors.New("weekdays and stop minutes must have the same length")
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.templateAutostopWindows = slices.DeleteFunc(q.templateAutostopWindows, func(window database.TemplateAutostopWindow) bool {
+		return window.TemplateID == arg.TemplateID
+	})
+	for i, weekday := range arg.Weekdays {
+		q.templateAutostopWindows = append(q.templateAutostopWindows, database.TemplateAutostopWindow{
+			TemplateID: arg.TemplateID,
+			Weekday:    weekday,
+			StopMinute: arg.StopMinutes[i],
+		})
+	}
+	return nil
+}
+
 func (q *FakeQuerier) UpdateTemplateDeletedByID(_ context.Context, arg database.UpdateTemplateDeletedByIDParams) error {
 	if err := validateDatabaseType(arg); err != nil {
 		return err
@@ -11168,6 +13411,8 @@ func (q *FakeQuerier) UpdateTemplateMetaByID(_ context.Context, arg database.Upd
 		tpl.AllowUserCancelWorkspaceJobs = arg.AllowUserCancelWorkspaceJobs
 		tpl.MaxPortSharingLevel = arg.MaxPortSharingLevel
 		tpl.UseClassicParameterFlow = arg.UseClassicParameterFlow
+		tpl.DriftCheckIntervalMs = arg.DriftCheckIntervalMs
+		tpl.ProvisionerVersion = arg.ProvisionerVersion
 		q.templates[idx] = tpl
 		return nil
 	}
@@ -11307,6 +13552,30 @@ func (q *FakeQuerier) UpdateTemplateWorkspacesLastUsedAt(_ context.Context, arg
 	return nil
 }
 
+func (q *FakeQuerier) UpdateUserAutostopWindows(_ context.Context, arg database.UpdateUserAutostopWindowsParams) error {
+	if err := validateDatabaseType(arg); err != nil {
+		return err
+	}
+	if len(arg.Weekdays) != len(arg.StopMinutes) {
+		return xerrors.New("weekdays and stop minutes must have the same length")
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.userAutostopWindows = slices.DeleteFunc(q.userAutostopWindows, func(window database.UserAutostopWindow) bool {
+		return window.UserID == arg.UserID
+	})
+	for i, weekday := range arg.Weekdays {
+		q.userAutostopWindows = append(q.userAutostopWindows, database.UserAutostopWindow{
+			UserID:     arg.UserID,
+			Weekday:    weekday,
+			StopMinute: arg.StopMinutes[i],
+		})
+	}
+	return nil
+}
+
 func (q *FakeQuerier) UpdateUserDeletedByID(_ context.Context, id uuid.UUID) error {
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
@@ -11480,6 +13749,40 @@ func (q *FakeQuerier) UpdateUserLoginType(_ context.Context, arg database.Update
 	return database.User{}, sql.ErrNoRows
 }
 
+func (q *FakeQuerier) UpdateUserNotificationDigestPreferences(_ context.Context, arg database.UpdateUserNotificationDigestPreferencesParams) error {
+	err := validateDatabaseType(arg)
+	if err != nil {
+		return err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	now := dbtime.Now()
+	created := map[uuid.UUID]time.Time{}
+	q.notificationDigestPreferences = slices.DeleteFunc(q.notificationDigestPreferences, func(pref database.NotificationDigestPreference) bool {
+		if pref.UserID != arg.UserID {
+			return false
+		}
+		created[pref.NotificationTemplateID] = pref.CreatedAt
+		return true
+	})
+	for i, templateID := range arg.NotificationTemplateIds {
+		createdAt, ok := created[templateID]
+		if !ok {
+			createdAt = now
+		}
+		q.notificationDigestPreferences = append(q.notificationDigestPreferences, database.NotificationDigestPreference{
+			UserID:                 arg.UserID,
+			NotificationTemplateID: templateID,
+			DigestInterval:         arg.DigestIntervals[i],
+			CreatedAt:              createdAt,
+			UpdatedAt:              now,
+		})
+	}
+	return nil
+}
+
 func (q *FakeQuerier) UpdateUserNotificationPreferences(_ context.Context, arg database.UpdateUserNotificationPreferencesParams) (int64, error) {
 	err := validateDatabaseType(arg)
 	if err != nil {
@@ -12071,6 +14374,27 @@ func (q *FakeQuerier) UpdateWorkspaceDormantDeletingAt(_ context.Context, arg da
 	return database.WorkspaceTable{}, sql.ErrNoRows
 }
 
+func (q *FakeQuerier) UpdateWorkspaceDriftCheckByJobID(_ context.Context, arg database.UpdateWorkspaceDriftCheckByJobIDParams) (database.WorkspaceDriftCheck, error) {
+	err := validateDatabaseType(arg)
+	if err != nil {
+		return database.WorkspaceDriftCheck{}, err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, check := range q.workspaceDriftChecks {
+		if check.JobID != arg.JobID {
+			continue
+		}
+		check.CompletedAt = arg.CompletedAt
+		check.DriftedResources = arg.DriftedResources
+		q.workspaceDriftChecks[i] = check
+		return check, nil
+	}
+	return database.WorkspaceDriftCheck{}, sql.ErrNoRows
+}
+
 func (q *FakeQuerier) UpdateWorkspaceLastUsedAt(_ context.Context, arg database.UpdateWorkspaceLastUsedAtParams) error {
 	if err := validateDatabaseType(arg); err != nil {
 		return err
@@ -12174,6 +14498,27 @@ func (q *FakeQuerier) UpdateWorkspaceTTL(_ context.Context, arg database.UpdateW
 	return sql.ErrNoRows
 }
 
+func (q *FakeQuerier) UpdateWorkspaceUpdatePreviewByJobID(_ context.Context, arg database.UpdateWorkspaceUpdatePreviewByJobIDParams) (database.WorkspaceUpdatePreview, error) {
+	err := validateDatabaseType(arg)
+	if err != nil {
+		return database.WorkspaceUpdatePreview{}, err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, preview := range q.workspaceUpdatePreviews {
+		if preview.JobID != arg.JobID {
+			continue
+		}
+		preview.CompletedAt = arg.CompletedAt
+		preview.ResourceChanges = arg.ResourceChanges
+		q.workspaceUpdatePreviews[i] = preview
+		return preview, nil
+	}
+	return database.WorkspaceUpdatePreview{}, sql.ErrNoRows
+}
+
 func (q *FakeQuerier) UpdateWorkspacesDormantDeletingAtByTemplateID(_ context.Context, arg database.UpdateWorkspacesDormantDeletingAtByTemplateIDParams) ([]database.WorkspaceTable, error) {
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
@@ -12348,6 +14693,30 @@ func (q *FakeQuerier) UpsertPrebuildsSettings(_ context.Context, value string) e
 	return nil
 }
 
+func (q *FakeQuerier) UpsertProviderMirrorToken(_ context.Context, arg database.UpsertProviderMirrorTokenParams) error {
+	err := validateDatabaseType(arg)
+	if err != nil {
+		return err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	token := database.ProviderMirrorToken{
+		JobID:        arg.JobID,
+		HashedSecret: arg.HashedSecret,
+		CreatedAt:    arg.CreatedAt,
+	}
+	for i, existing := range q.providerMirrorTokens {
+		if existing.JobID == arg.JobID {
+			q.providerMirrorTokens[i] = token
+			return nil
+		}
+	}
+	q.providerMirrorTokens = append(q.providerMirrorTokens, token)
+	return nil
+}
+
 func (q *FakeQuerier) UpsertProvisionerDaemon(_ context.Context, arg database.UpsertProvisionerDaemonParams) (database.ProvisionerDaemon, error) {
 	if err := validateDatabaseType(arg); err != nil {
 		return database.ProvisionerDaemon{}, err
@@ -13060,6 +15429,67 @@ TemplateUsageStatsInsertLoop:
 	return nil
 }
 
+func (q *FakeQuerier) UpsertUserChatIdentity(_ context.Context, arg database.UpsertUserChatIdentityParams) (database.UserChatIdentity, error) {
+	err := validateDatabaseType(arg)
+	if err != nil {
+		return database.UserChatIdentity{}, err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, identity := range q.userChatIdentities {
+		if identity.UserID == arg.UserID && identity.Method == arg.Method {
+			identity.Identity = arg.Identity
+			identity.UpdatedAt = arg.UpdatedAt
+			identity.VerifiedAt = arg.VerifiedAt
+			identity.VerificationCodeHash = arg.VerificationCodeHash
+			identity.VerificationExpiresAt = arg.VerificationExpiresAt
+			q.userChatIdentities[i] = identity
+			return identity, nil
+		}
+	}
+	identity := database.UserChatIdentity{
+		UserID:                arg.UserID,
+		Method:                arg.Method,
+		Identity:              arg.Identity,
+		CreatedAt:             arg.UpdatedAt,
+		UpdatedAt:             arg.UpdatedAt,
+		VerifiedAt:            arg.VerifiedAt,
+		VerificationCodeHash:  arg.VerificationCodeHash,
+		VerificationExpiresAt: arg.VerificationExpiresAt,
+	}
+	q.userChatIdentities = append(q.userChatIdentities, identity)
+	return identity, nil
+}
+
+func (q *FakeQuerier) UpsertUserNotificationSchedule(_ context.Context, arg database.UpsertUserNotificationScheduleParams) (database.UserNotificationSchedule, error) {
+	err := validateDatabaseType(arg)
+	if err != nil {
+		return database.UserNotificationSchedule{}, err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	schedule := database.UserNotificationSchedule{
+		UserID:          arg.UserID,
+		Timezone:        arg.Timezone,
+		QuietHoursStart: arg.QuietHoursStart,
+		QuietHoursEnd:   arg.QuietHoursEnd,
+		DailyDigestTime: arg.DailyDigestTime,
+		UpdatedAt:       arg.UpdatedAt,
+	}
+	for i, existing := range q.userNotificationSchedules {
+		if existing.UserID == arg.UserID {
+			q.userNotificationSchedules[i] = schedule
+			return schedule, nil
+		}
+	}
+	q.userNotificationSchedules = append(q.userNotificationSchedules, schedule)
+	return schedule, nil
+}
+
 func (q *FakeQuerier) UpsertWebpushVAPIDKeys(_ context.Context, arg database.UpsertWebpushVAPIDKeysParams) error {
 	err := validateDatabaseType(arg)
 	if err != nil {
@@ -13087,6 +15517,8 @@ func (q *FakeQuerier) UpsertWorkspaceAgentPortShare(_ context.Context, arg datab
 		if share.WorkspaceID == arg.WorkspaceID && share.Port == arg.Port && share.AgentName == arg.AgentName {
 			share.ShareLevel = arg.ShareLevel
 			share.Protocol = arg.Protocol
+			share.AutoShared = arg.AutoShared
+			share.Label = arg.Label
 			q.workspaceAgentPortShares[i] = share
 			return share, nil
 		}
@@ -13099,6 +15531,8 @@ func (q *FakeQuerier) UpsertWorkspaceAgentPortShare(_ context.Context, arg datab
 		Port:        arg.Port,
 		ShareLevel:  arg.ShareLevel,
 		Protocol:    arg.Protocol,
+		AutoShared:  arg.AutoShared,
+		Label:       arg.Label,
 	}
 	q.workspaceAgentPortShares = append(q.workspaceAgentPortShares, psl)
 
@@ -13215,6 +15649,196 @@ func (q *FakeQuerier) UpsertWorkspaceAppAuditSession(_ context.Context, arg data
 	return true, nil
 }
 
+func (q *FakeQuerier) UpsertWorkspaceBuildState(_ context.Context, arg database.UpsertWorkspaceBuildStateParams) error {
+	err := validateDatabaseType(arg)
+	if err != nil {
+		return err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	state := database.WorkspaceBuildState{
+		WorkspaceBuildID: arg.WorkspaceBuildID,
+		WorkspaceID:      arg.WorkspaceID,
+		State:            arg.State,
+		StateKeyID:       arg.StateKeyID,
+		Hash:             arg.Hash,
+		Size:             arg.Size,
+		CreatedAt:        arg.CreatedAt,
+	}
+	for idx, existing := range q.workspaceBuildStates {
+		if existing.WorkspaceBuildID == arg.WorkspaceBuildID {
+			q.workspaceBuildStates[idx] = state
+			return nil
+		}
+	}
+	q.workspaceBuildStates = append(q.workspaceBuildStates, state)
+	return nil
+}
+
+func (q *FakeQuerier) UpsertWorkspaceCostUsage(_ context.Context) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	now := dbtime.Now()
+
+	// latest_start
+	var latestStart time.Time
+	for _, usage := range q.workspaceCostUsage {
+		if usage.StartTime.After(latestStart) {
+			latestStart = usage.StartTime
+		}
+	}
+	if latestStart.IsZero() {
+		for _, build := range q.workspaceBuilds {
+			if latestStart.IsZero() || build.CreatedAt.Before(latestStart) {
+				latestStart = build.CreatedAt
+			}
+		}
+		if latestStart.IsZero() {
+			return nil
+		}
+	} else {
+		latestStart = latestStart.Add(-time.Hour)
+	}
+	latestStart = latestStart.Truncate(time.Hour)
+
+	type key struct {
+		startTime   time.Time
+		workspaceID uuid.UUID
+	}
+	rollup := make(map[key]database.WorkspaceCostUsage)
+	for _, build := range q.workspaceBuilds {
+		if build.Transition != database.WorkspaceTransitionStart {
+			continue
+		}
+		job, err := q.getProvisionerJobByIDNoLock(context.Background(), build.JobID)
+		if err != nil || job.JobStatus != database.ProvisionerJobStatusSucceeded || !job.CompletedAt.Valid {
+			continue
+		}
+		workspace, err := q.getWorkspaceByIDNoLock(context.Background(), build.WorkspaceID)
+		if err != nil {
+			continue
+		}
+
+		// build_uptime
+		startedAt := job.CompletedAt.Time
+		stoppedAt := now
+		nextBuildNumber := int32(-1)
+		for _, next := range q.workspaceBuilds {
+			if next.WorkspaceID != build.WorkspaceID || next.BuildNumber <= build.BuildNumber {
+				continue
+			}
+			if nextBuildNumber == -1 || next.BuildNumber < nextBuildNumber {
+				nextBuildNumber = next.BuildNumber
+				stoppedAt = next.CreatedAt
+			}
+		}
+		if !stoppedAt.After(latestStart) {
+			continue
+		}
+		var hourlyCost int64
+		for _, resource := range q.workspaceResources {
+			if resource.JobID == build.JobID && resource.Transition == database.WorkspaceTransitionStart {
+				hourlyCost += resource.HourlyCostMicros
+			}
+		}
+
+		// hourly_uptime
+		from := startedAt
+		if from.Before(latestStart) {
+			from = latestStart
+		}
+		for hour := from.Truncate(time.Hour); hour.Before(stoppedAt); hour = hour.Add(time.Hour) {
+			start := startedAt
+			if start.Before(hour) {
+				start = hour
+			}
+			end := stoppedAt
+			if end.After(hour.Add(time.Hour)) {
+				end = hour.Add(time.Hour)
+			}
+			seconds := int64(end.Sub(start) / time.Second)
+			if seconds <= 0 {
+				continue
+			}
+			k := key{hour, workspace.ID}
+			usage := rollup[k]
+			usage.StartTime = hour
+			usage.WorkspaceID = workspace.ID
+			usage.TemplateID = workspace.TemplateID
+			usage.UserID = workspace.OwnerID
+			usage.OrganizationID = workspace.OrganizationID
+			// #nosec G115 - Safe conversion, usage is at most an hour
+			usage.UsageSeconds += int32(seconds)
+			usage.CostMicros += seconds * hourlyCost / 3600
+			rollup[k] = usage
+		}
+	}
+
+	for k, usage := range rollup {
+		i := slices.IndexFunc(q.workspaceCostUsage, func(existing database.WorkspaceCostUsage) bool {
+			return existing.StartTime.Equal(k.startTime) && existing.WorkspaceID == k.workspaceID
+		})
+		if i >= 0 {
+			q.workspaceCostUsage[i].UsageSeconds = usage.UsageSeconds
+			q.workspaceCostUsage[i].CostMicros = usage.CostMicros
+			continue
+		}
+		q.workspaceCostUsage = append(q.workspaceCostUsage, usage)
+	}
+	return nil
+}
+
+func (q *FakeQuerier) UpsertWorkspaceSnapshotRestore(_ context.Context, arg database.UpsertWorkspaceSnapshotRestoreParams) (database.WorkspaceSnapshotRestore, error) {
+	err := validateDatabaseType(arg)
+	if err != nil {
+		return database.WorkspaceSnapshotRestore{}, err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	restore := database.WorkspaceSnapshotRestore(arg)
+	for i, existing := range q.workspaceSnapshotRestores {
+		if existing.WorkspaceID == arg.WorkspaceID {
+			q.workspaceSnapshotRestores[i] = restore
+			return restore, nil
+		}
+	}
+	q.workspaceSnapshotRestores = append(q.workspaceSnapshotRestores, restore)
+	return restore, nil
+}
+
+func (q *FakeQuerier) VerifyUserChatIdentity(_ context.Context, arg database.VerifyUserChatIdentityParams) (database.UserChatIdentity, error) {
+	err := validateDatabaseType(arg)
+	if err != nil {
+		return database.UserChatIdentity{}, err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, identity := range q.userChatIdentities {
+		if identity.UserID != arg.UserID || identity.Method != arg.Method {
+			continue
+		}
+		if identity.VerificationCodeHash == nil || !bytes.Equal(identity.VerificationCodeHash, arg.VerificationCodeHash) {
+			return database.UserChatIdentity{}, sql.ErrNoRows
+		}
+		if !identity.VerificationExpiresAt.Valid || !identity.VerificationExpiresAt.Time.After(arg.VerifiedAt) {
+			return database.UserChatIdentity{}, sql.ErrNoRows
+		}
+		identity.VerifiedAt = sql.NullTime{Time: arg.VerifiedAt, Valid: true}
+		identity.VerificationCodeHash = nil
+		identity.VerificationExpiresAt = sql.NullTime{}
+		q.userChatIdentities[i] = identity
+		return identity, nil
+	}
+	return database.UserChatIdentity{}, sql.ErrNoRows
+}
+
 func (q *FakeQuerier) GetAuthorizedTemplates(ctx context.Context, arg database.GetTemplatesWithFilterParams, prepared rbac.PreparedAuthorized) ([]database.Template, error) {
 	if err := validateDatabaseType(arg); err != nil {
 		return nil, err
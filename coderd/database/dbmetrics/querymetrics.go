@@ -95,6 +95,20 @@ func (m queryMetricsStore) AcquireLock(ctx context.Context, pgAdvisoryXactLock i
 	return err
 }
 
+func (m queryMetricsStore) AcquireNotificationDigestMessages(ctx context.Context, arg database.AcquireNotificationDigestMessagesParams) ([]database.AcquireNotificationDigestMessagesRow, error) {
+	start := time.Now()
+	r0, r1 := m.s.AcquireNotificationDigestMessages(ctx, arg)
+	m.queryLatencies.WithLabelValues("AcquireNotificationDigestMessages").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
+func (m queryMetricsStore) AcquireAuditLogExportCursor(ctx context.Context, arg database.AcquireAuditLogExportCursorParams) (database.AuditLogExportCursor, error) {
+	start := time.Now()
+	r0, r1 := m.s.AcquireAuditLogExportCursor(ctx, arg)
+	m.queryLatencies.WithLabelValues("AcquireAuditLogExportCursor").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
 func (m queryMetricsStore) AcquireNotificationMessages(ctx context.Context, arg database.AcquireNotificationMessagesParams) ([]database.AcquireNotificationMessagesRow, error) {
 	start := time.Now()
 	r0, r1 := m.s.AcquireNotificationMessages(ctx, arg)
@@ -144,6 +158,13 @@ func (m queryMetricsStore) BatchUpdateWorkspaceNextStartAt(ctx context.Context,
 	return r0
 }
 
+func (m queryMetricsStore) BulkDeferNotificationMessages(ctx context.Context, arg database.BulkDeferNotificationMessagesParams) (int64, error) {
+	start := time.Now()
+	r0, r1 := m.s.BulkDeferNotificationMessages(ctx, arg)
+	m.queryLatencies.WithLabelValues("BulkDeferNotificationMessages").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
 func (m queryMetricsStore) BulkMarkNotificationMessagesFailed(ctx context.Context, arg database.BulkMarkNotificationMessagesFailedParams) (int64, error) {
 	start := time.Now()
 	r0, r1 := m.s.BulkMarkNotificationMessagesFailed(ctx, arg)
@@ -256,6 +277,13 @@ func (m queryMetricsStore) DeleteApplicationConnectAPIKeysByUserID(ctx context.C
 	return err
 }
 
+func (m queryMetricsStore) DeleteAuditLogsByIDs(ctx context.Context, ids []uuid.UUID) error {
+	start := time.Now()
+	r0 := m.s.DeleteAuditLogsByIDs(ctx, ids)
+	m.queryLatencies.WithLabelValues("DeleteAuditLogsByIDs").Observe(time.Since(start).Seconds())
+	return r0
+}
+
 func (m queryMetricsStore) DeleteCoordinator(ctx context.Context, id uuid.UUID) error {
 	start := time.Now()
 	r0 := m.s.DeleteCoordinator(ctx, id)
@@ -312,6 +340,13 @@ func (m queryMetricsStore) DeleteLicense(ctx context.Context, id int32) (int32,
 	return licenseID, err
 }
 
+func (m queryMetricsStore) DeleteNotificationRule(ctx context.Context, id uuid.UUID) error {
+	start := time.Now()
+	r0 := m.s.DeleteNotificationRule(ctx, id)
+	m.queryLatencies.WithLabelValues("DeleteNotificationRule").Observe(time.Since(start).Seconds())
+	return r0
+}
+
 func (m queryMetricsStore) DeleteOAuth2ProviderAppByID(ctx context.Context, id uuid.UUID) error {
 	start := time.Now()
 	r0 := m.s.DeleteOAuth2ProviderAppByID(ctx, id)
@@ -375,6 +410,20 @@ func (m queryMetricsStore) DeleteOldWorkspaceAgentStats(ctx context.Context) err
 	return err
 }
 
+func (m queryMetricsStore) DeleteOldWorkspaceBuildStates(ctx context.Context, arg database.DeleteOldWorkspaceBuildStatesParams) (int64, error) {
+	start := time.Now()
+	r0, r1 := m.s.DeleteOldWorkspaceBuildStates(ctx, arg)
+	m.queryLatencies.WithLabelValues("DeleteOldWorkspaceBuildStates").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
+func (m queryMetricsStore) DeleteOldWorkspaceSnapshots(ctx context.Context, keepChains int32) ([]database.DeleteOldWorkspaceSnapshotsRow, error) {
+	start := time.Now()
+	r0, r1 := m.s.DeleteOldWorkspaceSnapshots(ctx, keepChains)
+	m.queryLatencies.WithLabelValues("DeleteOldWorkspaceSnapshots").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
 func (m queryMetricsStore) DeleteOrganizationMember(ctx context.Context, arg database.DeleteOrganizationMemberParams) error {
 	start := time.Now()
 	r0 := m.s.DeleteOrganizationMember(ctx, arg)
@@ -382,6 +431,13 @@ func (m queryMetricsStore) DeleteOrganizationMember(ctx context.Context, arg dat
 	return r0
 }
 
+func (m queryMetricsStore) DeletePlanPolicyByID(ctx context.Context, id uuid.UUID) error {
+	start := time.Now()
+	r0 := m.s.DeletePlanPolicyByID(ctx, id)
+	m.queryLatencies.WithLabelValues("DeletePlanPolicyByID").Observe(time.Since(start).Seconds())
+	return r0
+}
+
 func (m queryMetricsStore) DeleteProvisionerKey(ctx context.Context, id uuid.UUID) error {
 	start := time.Now()
 	r0 := m.s.DeleteProvisionerKey(ctx, id)
@@ -396,6 +452,13 @@ func (m queryMetricsStore) DeleteReplicasUpdatedBefore(ctx context.Context, upda
 	return err
 }
 
+func (m queryMetricsStore) DeleteRestoredAuditLogs(ctx context.Context, restoredBefore time.Time) error {
+	start := time.Now()
+	r0 := m.s.DeleteRestoredAuditLogs(ctx, restoredBefore)
+	m.queryLatencies.WithLabelValues("DeleteRestoredAuditLogs").Observe(time.Since(start).Seconds())
+	return r0
+}
+
 func (m queryMetricsStore) DeleteRuntimeConfig(ctx context.Context, key string) error {
 	start := time.Now()
 	r0 := m.s.DeleteRuntimeConfig(ctx, key)
@@ -438,6 +501,20 @@ func (m queryMetricsStore) DeleteTailnetTunnel(ctx context.Context, arg database
 	return r0, r1
 }
 
+func (m queryMetricsStore) DeleteTemplatePortShareRules(ctx context.Context, templateID uuid.UUID) error {
+	start := time.Now()
+	r0 := m.s.DeleteTemplatePortShareRules(ctx, templateID)
+	m.queryLatencies.WithLabelValues("DeleteTemplatePortShareRules").Observe(time.Since(start).Seconds())
+	return r0
+}
+
+func (m queryMetricsStore) DeleteUserChatIdentity(ctx context.Context, arg database.DeleteUserChatIdentityParams) error {
+	start := time.Now()
+	r0 := m.s.DeleteUserChatIdentity(ctx, arg)
+	m.queryLatencies.WithLabelValues("DeleteUserChatIdentity").Observe(time.Since(start).Seconds())
+	return r0
+}
+
 func (m queryMetricsStore) DeleteWebpushSubscriptionByUserIDAndEndpoint(ctx context.Context, arg database.DeleteWebpushSubscriptionByUserIDAndEndpointParams) error {
 	start := time.Now()
 	r0 := m.s.DeleteWebpushSubscriptionByUserIDAndEndpoint(ctx, arg)
@@ -466,6 +543,13 @@ func (m queryMetricsStore) DeleteWorkspaceAgentPortSharesByTemplate(ctx context.
 	return r0
 }
 
+func (m queryMetricsStore) DeleteWorkspaceSnapshotRestore(ctx context.Context, workspaceID uuid.UUID) error {
+	start := time.Now()
+	r0 := m.s.DeleteWorkspaceSnapshotRestore(ctx, workspaceID)
+	m.queryLatencies.WithLabelValues("DeleteWorkspaceSnapshotRestore").Observe(time.Since(start).Seconds())
+	return r0
+}
+
 func (m queryMetricsStore) DeleteWorkspaceSubAgentByID(ctx context.Context, id uuid.UUID) error {
 	start := time.Now()
 	r0 := m.s.DeleteWorkspaceSubAgentByID(ctx, id)
@@ -634,6 +718,69 @@ func (m queryMetricsStore) GetApplicationName(ctx context.Context) (string, erro
 	return r0, r1
 }
 
+func (m queryMetricsStore) GetArchivedAuditLogIDs(ctx context.Context, ids []uuid.UUID) ([]uuid.UUID, error) {
+	start := time.Now()
+	r0, r1 := m.s.GetArchivedAuditLogIDs(ctx, ids)
+	m.queryLatencies.WithLabelValues("GetArchivedAuditLogIDs").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
+func (m queryMetricsStore) GetAuditLogArchiveByID(ctx context.Context, id uuid.UUID) (database.AuditLogArchive, error) {
+	start := time.Now()
+	r0, r1 := m.s.GetAuditLogArchiveByID(ctx, id)
+	m.queryLatencies.WithLabelValues("GetAuditLogArchiveByID").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
+func (m queryMetricsStore) GetAuditLogArchives(ctx context.Context) ([]database.AuditLogArchive, error) {
+	start := time.Now()
+	r0, r1 := m.s.GetAuditLogArchives(ctx)
+	m.queryLatencies.WithLabelValues("GetAuditLogArchives").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
+func (m queryMetricsStore) GetAuditLogCheckpoints(ctx context.Context, arg database.GetAuditLogCheckpointsParams) ([]database.AuditLogCheckpoint, error) {
+	start := time.Now()
+	r0, r1 := m.s.GetAuditLogCheckpoints(ctx, arg)
+	m.queryLatencies.WithLabelValues("GetAuditLogCheckpoints").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
+func (m queryMetricsStore) GetAuditLogExportCursor(ctx context.Context, sink string) (database.AuditLogExportCursor, error) {
+	start := time.Now()
+	r0, r1 := m.s.GetAuditLogExportCursor(ctx, sink)
+	m.queryLatencies.WithLabelValues("GetAuditLogExportCursor").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
+func (m queryMetricsStore) GetAuditLogHashSequenceRange(ctx context.Context, arg database.GetAuditLogHashSequenceRangeParams) (database.GetAuditLogHashSequenceRangeRow, error) {
+	start := time.Now()
+	r0, r1 := m.s.GetAuditLogHashSequenceRange(ctx, arg)
+	m.queryLatencies.WithLabelValues("GetAuditLogHashSequenceRange").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
+func (m queryMetricsStore) GetAuditLogHashes(ctx context.Context, arg database.GetAuditLogHashesParams) ([]database.AuditLogHash, error) {
+	start := time.Now()
+	r0, r1 := m.s.GetAuditLogHashes(ctx, arg)
+	m.queryLatencies.WithLabelValues("GetAuditLogHashes").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
+func (m queryMetricsStore) GetAuditLogsByIDs(ctx context.Context, ids []uuid.UUID) ([]database.AuditLog, error) {
+	start := time.Now()
+	r0, r1 := m.s.GetAuditLogsByIDs(ctx, ids)
+	m.queryLatencies.WithLabelValues("GetAuditLogsByIDs").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
+func (m queryMetricsStore) GetAuditLogsForExport(ctx context.Context, arg database.GetAuditLogsForExportParams) ([]database.GetAuditLogsForExportRow, error) {
+	start := time.Now()
+	r0, r1 := m.s.GetAuditLogsForExport(ctx, arg)
+	m.queryLatencies.WithLabelValues("GetAuditLogsForExport").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
 func (m queryMetricsStore) GetAuditLogsOffset(ctx context.Context, arg database.GetAuditLogsOffsetParams) ([]database.GetAuditLogsOffsetRow, error) {
 	start := time.Now()
 	rows, err := m.s.GetAuditLogsOffset(ctx, arg)
@@ -641,6 +788,13 @@ func (m queryMetricsStore) GetAuditLogsOffset(ctx context.Context, arg database.
 	return rows, err
 }
 
+func (m queryMetricsStore) GetAuditLogsToChain(ctx context.Context, arg database.GetAuditLogsToChainParams) ([]database.AuditLog, error) {
+	start := time.Now()
+	r0, r1 := m.s.GetAuditLogsToChain(ctx, arg)
+	m.queryLatencies.WithLabelValues("GetAuditLogsToChain").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
 func (m queryMetricsStore) GetAuthorizationUserRoles(ctx context.Context, userID uuid.UUID) (database.GetAuthorizationUserRolesRow, error) {
 	start := time.Now()
 	row, err := m.s.GetAuthorizationUserRoles(ctx, userID)
@@ -746,6 +900,20 @@ func (m queryMetricsStore) GetEligibleProvisionerDaemonsByProvisionerJobIDs(ctx
 	return r0, r1
 }
 
+func (m queryMetricsStore) GetEnabledNotificationRules(ctx context.Context) ([]database.NotificationRule, error) {
+	start := time.Now()
+	r0, r1 := m.s.GetEnabledNotificationRules(ctx)
+	m.queryLatencies.WithLabelValues("GetEnabledNotificationRules").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
+func (m queryMetricsStore) GetExpiredAuditLogs(ctx context.Context, arg database.GetExpiredAuditLogsParams) ([]database.AuditLog, error) {
+	start := time.Now()
+	r0, r1 := m.s.GetExpiredAuditLogs(ctx, arg)
+	m.queryLatencies.WithLabelValues("GetExpiredAuditLogs").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
 func (m queryMetricsStore) GetExternalAuthLink(ctx context.Context, arg database.GetExternalAuthLinkParams) (database.ExternalAuthLink, error) {
 	start := time.Now()
 	link, err := m.s.GetExternalAuthLink(ctx, arg)
@@ -879,6 +1047,20 @@ func (m queryMetricsStore) GetLastUpdateCheck(ctx context.Context) (string, erro
 	return version, err
 }
 
+func (m queryMetricsStore) GetLatestAuditLogCheckpoint(ctx context.Context) (database.AuditLogCheckpoint, error) {
+	start := time.Now()
+	r0, r1 := m.s.GetLatestAuditLogCheckpoint(ctx)
+	m.queryLatencies.WithLabelValues("GetLatestAuditLogCheckpoint").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
+func (m queryMetricsStore) GetLatestAuditLogHash(ctx context.Context) (database.AuditLogHash, error) {
+	start := time.Now()
+	r0, r1 := m.s.GetLatestAuditLogHash(ctx)
+	m.queryLatencies.WithLabelValues("GetLatestAuditLogHash").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
 func (m queryMetricsStore) GetLatestCryptoKeyByFeature(ctx context.Context, feature database.CryptoKeyFeature) (database.CryptoKey, error) {
 	start := time.Now()
 	r0, r1 := m.s.GetLatestCryptoKeyByFeature(ctx, feature)
@@ -914,6 +1096,13 @@ func (m queryMetricsStore) GetLatestWorkspaceBuildsByWorkspaceIDs(ctx context.Co
 	return builds, err
 }
 
+func (m queryMetricsStore) GetLatestWorkspaceDriftCheckByWorkspaceID(ctx context.Context, workspaceID uuid.UUID) (database.WorkspaceDriftCheck, error) {
+	start := time.Now()
+	r0, r1 := m.s.GetLatestWorkspaceDriftCheckByWorkspaceID(ctx, workspaceID)
+	m.queryLatencies.WithLabelValues("GetLatestWorkspaceDriftCheckByWorkspaceID").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
 func (m queryMetricsStore) GetLicenseByID(ctx context.Context, id int32) (database.License, error) {
 	start := time.Now()
 	license, err := m.s.GetLicenseByID(ctx, id)
@@ -949,6 +1138,20 @@ func (m queryMetricsStore) GetNotificationReportGeneratorLogByTemplate(ctx conte
 	return r0, r1
 }
 
+func (m queryMetricsStore) GetNotificationRuleByID(ctx context.Context, id uuid.UUID) (database.NotificationRule, error) {
+	start := time.Now()
+	r0, r1 := m.s.GetNotificationRuleByID(ctx, id)
+	m.queryLatencies.WithLabelValues("GetNotificationRuleByID").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
+func (m queryMetricsStore) GetNotificationRulesByOrganizationID(ctx context.Context, organizationID uuid.UUID) ([]database.NotificationRule, error) {
+	start := time.Now()
+	r0, r1 := m.s.GetNotificationRulesByOrganizationID(ctx, organizationID)
+	m.queryLatencies.WithLabelValues("GetNotificationRulesByOrganizationID").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
 func (m queryMetricsStore) GetNotificationTemplateByID(ctx context.Context, id uuid.UUID) (database.NotificationTemplate, error) {
 	start := time.Now()
 	r0, r1 := m.s.GetNotificationTemplateByID(ctx, id)
@@ -1068,6 +1271,13 @@ func (m queryMetricsStore) GetOrganizationByName(ctx context.Context, name datab
 	return organization, err
 }
 
+func (m queryMetricsStore) GetOrganizationHolidays(ctx context.Context, arg database.GetOrganizationHolidaysParams) ([]database.OrganizationHoliday, error) {
+	start := time.Now()
+	r0, r1 := m.s.GetOrganizationHolidays(ctx, arg)
+	m.queryLatencies.WithLabelValues("GetOrganizationHolidays").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
 func (m queryMetricsStore) GetOrganizationIDsByMemberIDs(ctx context.Context, ids []uuid.UUID) ([]database.GetOrganizationIDsByMemberIDsRow, error) {
 	start := time.Now()
 	organizations, err := m.s.GetOrganizationIDsByMemberIDs(ctx, ids)
@@ -1103,6 +1313,27 @@ func (m queryMetricsStore) GetParameterSchemasByJobID(ctx context.Context, jobID
 	return schemas, err
 }
 
+func (m queryMetricsStore) GetPlanPoliciesByOrganizationID(ctx context.Context, organizationID uuid.UUID) ([]database.PlanPolicy, error) {
+	start := time.Now()
+	r0, r1 := m.s.GetPlanPoliciesByOrganizationID(ctx, organizationID)
+	m.queryLatencies.WithLabelValues("GetPlanPoliciesByOrganizationID").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
+func (m queryMetricsStore) GetPlanPolicyByID(ctx context.Context, id uuid.UUID) (database.PlanPolicy, error) {
+	start := time.Now()
+	r0, r1 := m.s.GetPlanPolicyByID(ctx, id)
+	m.queryLatencies.WithLabelValues("GetPlanPolicyByID").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
+func (m queryMetricsStore) GetPlanPolicyByOrganizationAndName(ctx context.Context, arg database.GetPlanPolicyByOrganizationAndNameParams) (database.PlanPolicy, error) {
+	start := time.Now()
+	r0, r1 := m.s.GetPlanPolicyByOrganizationAndName(ctx, arg)
+	m.queryLatencies.WithLabelValues("GetPlanPolicyByOrganizationAndName").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
 func (m queryMetricsStore) GetPrebuildMetrics(ctx context.Context) ([]database.GetPrebuildMetricsRow, error) {
 	start := time.Now()
 	r0, r1 := m.s.GetPrebuildMetrics(ctx)
@@ -1110,6 +1341,13 @@ func (m queryMetricsStore) GetPrebuildMetrics(ctx context.Context) ([]database.G
 	return r0, r1
 }
 
+func (m queryMetricsStore) GetPrebuildPoolHealth(ctx context.Context, since time.Time) ([]database.GetPrebuildPoolHealthRow, error) {
+	start := time.Now()
+	r0, r1 := m.s.GetPrebuildPoolHealth(ctx, since)
+	m.queryLatencies.WithLabelValues("GetPrebuildPoolHealth").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
 func (m queryMetricsStore) GetPrebuildsSettings(ctx context.Context) (string, error) {
 	start := time.Now()
 	r0, r1 := m.s.GetPrebuildsSettings(ctx)
@@ -1173,6 +1411,27 @@ func (m queryMetricsStore) GetPreviousTemplateVersion(ctx context.Context, arg d
 	return version, err
 }
 
+func (m queryMetricsStore) GetProviderMirrorArchiveFileByHash(ctx context.Context, arg database.GetProviderMirrorArchiveFileByHashParams) (database.File, error) {
+	start := time.Now()
+	r0, r1 := m.s.GetProviderMirrorArchiveFileByHash(ctx, arg)
+	m.queryLatencies.WithLabelValues("GetProviderMirrorArchiveFileByHash").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
+func (m queryMetricsStore) GetProviderMirrorArchives(ctx context.Context, arg database.GetProviderMirrorArchivesParams) ([]database.GetProviderMirrorArchivesRow, error) {
+	start := time.Now()
+	r0, r1 := m.s.GetProviderMirrorArchives(ctx, arg)
+	m.queryLatencies.WithLabelValues("GetProviderMirrorArchives").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
+func (m queryMetricsStore) GetProviderMirrorTokenByJobID(ctx context.Context, jobID uuid.UUID) (database.ProviderMirrorToken, error) {
+	start := time.Now()
+	r0, r1 := m.s.GetProviderMirrorTokenByJobID(ctx, jobID)
+	m.queryLatencies.WithLabelValues("GetProviderMirrorTokenByJobID").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
 func (m queryMetricsStore) GetProvisionerDaemons(ctx context.Context) ([]database.ProvisionerDaemon, error) {
 	start := time.Now()
 	daemons, err := m.s.GetProvisionerDaemons(ctx)
@@ -1278,6 +1537,13 @@ func (m queryMetricsStore) GetProvisionerLogsAfterID(ctx context.Context, arg da
 	return logs, err
 }
 
+func (m queryMetricsStore) GetProvisionerQueueStats(ctx context.Context, arg database.GetProvisionerQueueStatsParams) ([]database.GetProvisionerQueueStatsRow, error) {
+	start := time.Now()
+	r0, r1 := m.s.GetProvisionerQueueStats(ctx, arg)
+	m.queryLatencies.WithLabelValues("GetProvisionerQueueStats").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
 func (m queryMetricsStore) GetQuotaAllowanceForUser(ctx context.Context, userID database.GetQuotaAllowanceForUserParams) (int64, error) {
 	start := time.Now()
 	allowance, err := m.s.GetQuotaAllowanceForUser(ctx, userID)
@@ -1383,6 +1649,13 @@ func (m queryMetricsStore) GetTemplateAppInsightsByTemplate(ctx context.Context,
 	return r0, r1
 }
 
+func (m queryMetricsStore) GetTemplateAutostopWindows(ctx context.Context, templateID uuid.UUID) ([]database.TemplateAutostopWindow, error) {
+	start := time.Now()
+	r0, r1 := m.s.GetTemplateAutostopWindows(ctx, templateID)
+	m.queryLatencies.WithLabelValues("GetTemplateAutostopWindows").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
 func (m queryMetricsStore) GetTemplateAverageBuildTime(ctx context.Context, arg database.GetTemplateAverageBuildTimeParams) (database.GetTemplateAverageBuildTimeRow, error) {
 	start := time.Now()
 	buildTime, err := m.s.GetTemplateAverageBuildTime(ctx, arg)
@@ -1439,6 +1712,13 @@ func (m queryMetricsStore) GetTemplateParameterInsights(ctx context.Context, arg
 	return r0, r1
 }
 
+func (m queryMetricsStore) GetTemplatePortShareRules(ctx context.Context, templateID uuid.UUID) ([]database.TemplatePortShareRule, error) {
+	start := time.Now()
+	r0, r1 := m.s.GetTemplatePortShareRules(ctx, templateID)
+	m.queryLatencies.WithLabelValues("GetTemplatePortShareRules").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
 func (m queryMetricsStore) GetTemplatePresetsWithPrebuilds(ctx context.Context, templateID uuid.NullUUID) ([]database.GetTemplatePresetsWithPrebuildsRow, error) {
 	start := time.Now()
 	r0, r1 := m.s.GetTemplatePresetsWithPrebuilds(ctx, templateID)
@@ -1453,6 +1733,13 @@ func (m queryMetricsStore) GetTemplateUsageStats(ctx context.Context, arg databa
 	return r0, r1
 }
 
+func (m queryMetricsStore) GetTemplateVersionAdoption(ctx context.Context) ([]database.GetTemplateVersionAdoptionRow, error) {
+	start := time.Now()
+	r0, r1 := m.s.GetTemplateVersionAdoption(ctx)
+	m.queryLatencies.WithLabelValues("GetTemplateVersionAdoption").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
 func (m queryMetricsStore) GetTemplateVersionByID(ctx context.Context, id uuid.UUID) (database.TemplateVersion, error) {
 	start := time.Now()
 	version, err := m.s.GetTemplateVersionByID(ctx, id)
@@ -1537,6 +1824,20 @@ func (m queryMetricsStore) GetTemplatesWithFilter(ctx context.Context, arg datab
 	return templates, err
 }
 
+func (m queryMetricsStore) GetTopWorkspaceResourceConsumers(ctx context.Context, arg database.GetTopWorkspaceResourceConsumersParams) ([]database.GetTopWorkspaceResourceConsumersRow, error) {
+	start := time.Now()
+	r0, r1 := m.s.GetTopWorkspaceResourceConsumers(ctx, arg)
+	m.queryLatencies.WithLabelValues("GetTopWorkspaceResourceConsumers").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
+func (m queryMetricsStore) GetUnchainedAuditLogs(ctx context.Context, arg database.GetUnchainedAuditLogsParams) ([]database.GetUnchainedAuditLogsRow, error) {
+	start := time.Now()
+	r0, r1 := m.s.GetUnchainedAuditLogs(ctx, arg)
+	m.queryLatencies.WithLabelValues("GetUnchainedAuditLogs").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
 func (m queryMetricsStore) GetUnexpiredLicenses(ctx context.Context) ([]database.License, error) {
 	start := time.Now()
 	licenses, err := m.s.GetUnexpiredLicenses(ctx)
@@ -1551,6 +1852,13 @@ func (m queryMetricsStore) GetUserActivityInsights(ctx context.Context, arg data
 	return r0, r1
 }
 
+func (m queryMetricsStore) GetUserAutostopWindows(ctx context.Context, userID uuid.UUID) ([]database.UserAutostopWindow, error) {
+	start := time.Now()
+	r0, r1 := m.s.GetUserAutostopWindows(ctx, userID)
+	m.queryLatencies.WithLabelValues("GetUserAutostopWindows").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
 func (m queryMetricsStore) GetUserByEmailOrUsername(ctx context.Context, arg database.GetUserByEmailOrUsernameParams) (database.User, error) {
 	start := time.Now()
 	user, err := m.s.GetUserByEmailOrUsername(ctx, arg)
@@ -1565,6 +1873,20 @@ func (m queryMetricsStore) GetUserByID(ctx context.Context, id uuid.UUID) (datab
 	return user, err
 }
 
+func (m queryMetricsStore) GetUserChatIdentities(ctx context.Context, userID uuid.UUID) ([]database.UserChatIdentity, error) {
+	start := time.Now()
+	r0, r1 := m.s.GetUserChatIdentities(ctx, userID)
+	m.queryLatencies.WithLabelValues("GetUserChatIdentities").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
+func (m queryMetricsStore) GetUserChatIdentity(ctx context.Context, arg database.GetUserChatIdentityParams) (database.UserChatIdentity, error) {
+	start := time.Now()
+	r0, r1 := m.s.GetUserChatIdentity(ctx, arg)
+	m.queryLatencies.WithLabelValues("GetUserChatIdentity").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
 func (m queryMetricsStore) GetUserCount(ctx context.Context, includeSystem bool) (int64, error) {
 	start := time.Now()
 	count, err := m.s.GetUserCount(ctx, includeSystem)
@@ -1600,6 +1922,13 @@ func (m queryMetricsStore) GetUserLinksByUserID(ctx context.Context, userID uuid
 	return r0, r1
 }
 
+func (m queryMetricsStore) GetUserNotificationDigestPreferences(ctx context.Context, userID uuid.UUID) ([]database.NotificationDigestPreference, error) {
+	start := time.Now()
+	r0, r1 := m.s.GetUserNotificationDigestPreferences(ctx, userID)
+	m.queryLatencies.WithLabelValues("GetUserNotificationDigestPreferences").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
 func (m queryMetricsStore) GetUserNotificationPreferences(ctx context.Context, userID uuid.UUID) ([]database.NotificationPreference, error) {
 	start := time.Now()
 	r0, r1 := m.s.GetUserNotificationPreferences(ctx, userID)
@@ -1607,6 +1936,13 @@ func (m queryMetricsStore) GetUserNotificationPreferences(ctx context.Context, u
 	return r0, r1
 }
 
+func (m queryMetricsStore) GetUserNotificationSchedule(ctx context.Context, userID uuid.UUID) (database.UserNotificationSchedule, error) {
+	start := time.Now()
+	r0, r1 := m.s.GetUserNotificationSchedule(ctx, userID)
+	m.queryLatencies.WithLabelValues("GetUserNotificationSchedule").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
 func (m queryMetricsStore) GetUserStatusCounts(ctx context.Context, arg database.GetUserStatusCountsParams) ([]database.GetUserStatusCountsRow, error) {
 	start := time.Now()
 	r0, r1 := m.s.GetUserStatusCounts(ctx, arg)
@@ -1859,6 +2195,13 @@ func (m queryMetricsStore) GetWorkspaceBuildByWorkspaceIDAndBuildNumber(ctx cont
 	return build, err
 }
 
+func (m queryMetricsStore) GetWorkspaceBuildEvents(ctx context.Context, arg database.GetWorkspaceBuildEventsParams) ([]database.GetWorkspaceBuildEventsRow, error) {
+	start := time.Now()
+	r0, r1 := m.s.GetWorkspaceBuildEvents(ctx, arg)
+	m.queryLatencies.WithLabelValues("GetWorkspaceBuildEvents").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
 func (m queryMetricsStore) GetWorkspaceBuildParameters(ctx context.Context, workspaceBuildID uuid.UUID) ([]database.WorkspaceBuildParameter, error) {
 	start := time.Now()
 	params, err := m.s.GetWorkspaceBuildParameters(ctx, workspaceBuildID)
@@ -1873,6 +2216,27 @@ func (m queryMetricsStore) GetWorkspaceBuildParametersByBuildIDs(ctx context.Con
 	return r0, r1
 }
 
+func (m queryMetricsStore) GetWorkspaceBuildStateByBuildID(ctx context.Context, workspaceBuildID uuid.UUID) (database.WorkspaceBuildState, error) {
+	start := time.Now()
+	r0, r1 := m.s.GetWorkspaceBuildStateByBuildID(ctx, workspaceBuildID)
+	m.queryLatencies.WithLabelValues("GetWorkspaceBuildStateByBuildID").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
+func (m queryMetricsStore) GetWorkspaceBuildStateKeyIDs(ctx context.Context) ([]database.GetWorkspaceBuildStateKeyIDsRow, error) {
+	start := time.Now()
+	r0, r1 := m.s.GetWorkspaceBuildStateKeyIDs(ctx)
+	m.queryLatencies.WithLabelValues("GetWorkspaceBuildStateKeyIDs").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
+func (m queryMetricsStore) GetWorkspaceBuildStatesByWorkspaceID(ctx context.Context, workspaceID uuid.UUID) ([]database.GetWorkspaceBuildStatesByWorkspaceIDRow, error) {
+	start := time.Now()
+	r0, r1 := m.s.GetWorkspaceBuildStatesByWorkspaceID(ctx, workspaceID)
+	m.queryLatencies.WithLabelValues("GetWorkspaceBuildStatesByWorkspaceID").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
 func (m queryMetricsStore) GetWorkspaceBuildStatsByTemplates(ctx context.Context, since time.Time) ([]database.GetWorkspaceBuildStatsByTemplatesRow, error) {
 	start := time.Now()
 	r0, r1 := m.s.GetWorkspaceBuildStatsByTemplates(ctx, since)
@@ -1929,6 +2293,27 @@ func (m queryMetricsStore) GetWorkspaceByWorkspaceAppID(ctx context.Context, wor
 	return workspace, err
 }
 
+func (m queryMetricsStore) GetWorkspaceCostInsights(ctx context.Context, arg database.GetWorkspaceCostInsightsParams) ([]database.GetWorkspaceCostInsightsRow, error) {
+	start := time.Now()
+	r0, r1 := m.s.GetWorkspaceCostInsights(ctx, arg)
+	m.queryLatencies.WithLabelValues("GetWorkspaceCostInsights").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
+func (m queryMetricsStore) GetWorkspaceCostInsightsByGroup(ctx context.Context, arg database.GetWorkspaceCostInsightsByGroupParams) ([]database.GetWorkspaceCostInsightsByGroupRow, error) {
+	start := time.Now()
+	r0, r1 := m.s.GetWorkspaceCostInsightsByGroup(ctx, arg)
+	m.queryLatencies.WithLabelValues("GetWorkspaceCostInsightsByGroup").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
+func (m queryMetricsStore) GetWorkspaceDriftCheckByJobID(ctx context.Context, jobID uuid.UUID) (database.WorkspaceDriftCheck, error) {
+	start := time.Now()
+	r0, r1 := m.s.GetWorkspaceDriftCheckByJobID(ctx, jobID)
+	m.queryLatencies.WithLabelValues("GetWorkspaceDriftCheckByJobID").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
 func (m queryMetricsStore) GetWorkspaceModulesByJobID(ctx context.Context, jobID uuid.UUID) ([]database.WorkspaceModule, error) {
 	start := time.Now()
 	r0, r1 := m.s.GetWorkspaceModulesByJobID(ctx, jobID)
@@ -2013,6 +2398,34 @@ func (m queryMetricsStore) GetWorkspaceResourcesCreatedAfter(ctx context.Context
 	return resources, err
 }
 
+func (m queryMetricsStore) GetWorkspaceSnapshotByID(ctx context.Context, id uuid.UUID) (database.WorkspaceSnapshot, error) {
+	start := time.Now()
+	r0, r1 := m.s.GetWorkspaceSnapshotByID(ctx, id)
+	m.queryLatencies.WithLabelValues("GetWorkspaceSnapshotByID").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
+func (m queryMetricsStore) GetWorkspaceSnapshotChain(ctx context.Context, id uuid.UUID) ([]database.WorkspaceSnapshot, error) {
+	start := time.Now()
+	r0, r1 := m.s.GetWorkspaceSnapshotChain(ctx, id)
+	m.queryLatencies.WithLabelValues("GetWorkspaceSnapshotChain").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
+func (m queryMetricsStore) GetWorkspaceSnapshotRestoreByWorkspaceID(ctx context.Context, workspaceID uuid.UUID) (database.WorkspaceSnapshotRestore, error) {
+	start := time.Now()
+	r0, r1 := m.s.GetWorkspaceSnapshotRestoreByWorkspaceID(ctx, workspaceID)
+	m.queryLatencies.WithLabelValues("GetWorkspaceSnapshotRestoreByWorkspaceID").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
+func (m queryMetricsStore) GetWorkspaceSnapshotsByWorkspaceID(ctx context.Context, workspaceID uuid.UUID) ([]database.WorkspaceSnapshot, error) {
+	start := time.Now()
+	r0, r1 := m.s.GetWorkspaceSnapshotsByWorkspaceID(ctx, workspaceID)
+	m.queryLatencies.WithLabelValues("GetWorkspaceSnapshotsByWorkspaceID").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
 func (m queryMetricsStore) GetWorkspaceUniqueOwnerCountByTemplateIDs(ctx context.Context, templateIds []uuid.UUID) ([]database.GetWorkspaceUniqueOwnerCountByTemplateIDsRow, error) {
 	start := time.Now()
 	r0, r1 := m.s.GetWorkspaceUniqueOwnerCountByTemplateIDs(ctx, templateIds)
@@ -2020,6 +2433,13 @@ func (m queryMetricsStore) GetWorkspaceUniqueOwnerCountByTemplateIDs(ctx context
 	return r0, r1
 }
 
+func (m queryMetricsStore) GetWorkspaceUpdatePreviewByJobID(ctx context.Context, jobID uuid.UUID) (database.WorkspaceUpdatePreview, error) {
+	start := time.Now()
+	r0, r1 := m.s.GetWorkspaceUpdatePreviewByJobID(ctx, jobID)
+	m.queryLatencies.WithLabelValues("GetWorkspaceUpdatePreviewByJobID").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
 func (m queryMetricsStore) GetWorkspaces(ctx context.Context, arg database.GetWorkspacesParams) ([]database.GetWorkspacesRow, error) {
 	start := time.Now()
 	workspaces, err := m.s.GetWorkspaces(ctx, arg)
@@ -2034,6 +2454,13 @@ func (m queryMetricsStore) GetWorkspacesAndAgentsByOwnerID(ctx context.Context,
 	return r0, r1
 }
 
+func (m queryMetricsStore) GetWorkspacesApproachingDeletion(ctx context.Context, before time.Time) ([]database.GetWorkspacesApproachingDeletionRow, error) {
+	start := time.Now()
+	r0, r1 := m.s.GetWorkspacesApproachingDeletion(ctx, before)
+	m.queryLatencies.WithLabelValues("GetWorkspacesApproachingDeletion").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
 func (m queryMetricsStore) GetWorkspacesByTemplateID(ctx context.Context, templateID uuid.UUID) ([]database.WorkspaceTable, error) {
 	start := time.Now()
 	r0, r1 := m.s.GetWorkspacesByTemplateID(ctx, templateID)
@@ -2041,6 +2468,13 @@ func (m queryMetricsStore) GetWorkspacesByTemplateID(ctx context.Context, templa
 	return r0, r1
 }
 
+func (m queryMetricsStore) GetWorkspacesDueForDriftCheck(ctx context.Context, now time.Time) ([]database.GetWorkspacesDueForDriftCheckRow, error) {
+	start := time.Now()
+	r0, r1 := m.s.GetWorkspacesDueForDriftCheck(ctx, now)
+	m.queryLatencies.WithLabelValues("GetWorkspacesDueForDriftCheck").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
 func (m queryMetricsStore) GetWorkspacesEligibleForTransition(ctx context.Context, now time.Time) ([]database.GetWorkspacesEligibleForTransitionRow, error) {
 	start := time.Now()
 	workspaces, err := m.s.GetWorkspacesEligibleForTransition(ctx, now)
@@ -2076,6 +2510,34 @@ func (m queryMetricsStore) InsertAuditLog(ctx context.Context, arg database.Inse
 	return log, err
 }
 
+func (m queryMetricsStore) InsertAuditLogArchive(ctx context.Context, arg database.InsertAuditLogArchiveParams) (database.AuditLogArchive, error) {
+	start := time.Now()
+	r0, r1 := m.s.InsertAuditLogArchive(ctx, arg)
+	m.queryLatencies.WithLabelValues("InsertAuditLogArchive").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
+func (m queryMetricsStore) InsertAuditLogArchiveEntries(ctx context.Context, arg database.InsertAuditLogArchiveEntriesParams) error {
+	start := time.Now()
+	r0 := m.s.InsertAuditLogArchiveEntries(ctx, arg)
+	m.queryLatencies.WithLabelValues("InsertAuditLogArchiveEntries").Observe(time.Since(start).Seconds())
+	return r0
+}
+
+func (m queryMetricsStore) InsertAuditLogCheckpoint(ctx context.Context, arg database.InsertAuditLogCheckpointParams) error {
+	start := time.Now()
+	r0 := m.s.InsertAuditLogCheckpoint(ctx, arg)
+	m.queryLatencies.WithLabelValues("InsertAuditLogCheckpoint").Observe(time.Since(start).Seconds())
+	return r0
+}
+
+func (m queryMetricsStore) InsertAuditLogHash(ctx context.Context, arg database.InsertAuditLogHashParams) error {
+	start := time.Now()
+	r0 := m.s.InsertAuditLogHash(ctx, arg)
+	m.queryLatencies.WithLabelValues("InsertAuditLogHash").Observe(time.Since(start).Seconds())
+	return r0
+}
+
 func (m queryMetricsStore) InsertCryptoKey(ctx context.Context, arg database.InsertCryptoKeyParams) (database.CryptoKey, error) {
 	start := time.Now()
 	key, err := m.s.InsertCryptoKey(ctx, arg)
@@ -2174,6 +2636,13 @@ func (m queryMetricsStore) InsertMissingGroups(ctx context.Context, arg database
 	return r0, r1
 }
 
+func (m queryMetricsStore) InsertNotificationRule(ctx context.Context, arg database.InsertNotificationRuleParams) (database.NotificationRule, error) {
+	start := time.Now()
+	r0, r1 := m.s.InsertNotificationRule(ctx, arg)
+	m.queryLatencies.WithLabelValues("InsertNotificationRule").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
 func (m queryMetricsStore) InsertOAuth2ProviderApp(ctx context.Context, arg database.InsertOAuth2ProviderAppParams) (database.OAuth2ProviderApp, error) {
 	start := time.Now()
 	r0, r1 := m.s.InsertOAuth2ProviderApp(ctx, arg)
@@ -2216,6 +2685,13 @@ func (m queryMetricsStore) InsertOrganizationMember(ctx context.Context, arg dat
 	return member, err
 }
 
+func (m queryMetricsStore) InsertPlanPolicy(ctx context.Context, arg database.InsertPlanPolicyParams) (database.PlanPolicy, error) {
+	start := time.Now()
+	r0, r1 := m.s.InsertPlanPolicy(ctx, arg)
+	m.queryLatencies.WithLabelValues("InsertPlanPolicy").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
 func (m queryMetricsStore) InsertPreset(ctx context.Context, arg database.InsertPresetParams) (database.TemplateVersionPreset, error) {
 	start := time.Now()
 	r0, r1 := m.s.InsertPreset(ctx, arg)
@@ -2237,6 +2713,13 @@ func (m queryMetricsStore) InsertPresetPrebuildSchedule(ctx context.Context, arg
 	return r0, r1
 }
 
+func (m queryMetricsStore) InsertProviderMirrorArchive(ctx context.Context, arg database.InsertProviderMirrorArchiveParams) (int64, error) {
+	start := time.Now()
+	r0, r1 := m.s.InsertProviderMirrorArchive(ctx, arg)
+	m.queryLatencies.WithLabelValues("InsertProviderMirrorArchive").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
 func (m queryMetricsStore) InsertProvisionerJob(ctx context.Context, arg database.InsertProvisionerJobParams) (database.ProvisionerJob, error) {
 	start := time.Now()
 	job, err := m.s.InsertProvisionerJob(ctx, arg)
@@ -2286,6 +2769,13 @@ func (m queryMetricsStore) InsertTemplate(ctx context.Context, arg database.Inse
 	return err
 }
 
+func (m queryMetricsStore) InsertTemplatePortShareRule(ctx context.Context, arg database.InsertTemplatePortShareRuleParams) (database.TemplatePortShareRule, error) {
+	start := time.Now()
+	r0, r1 := m.s.InsertTemplatePortShareRule(ctx, arg)
+	m.queryLatencies.WithLabelValues("InsertTemplatePortShareRule").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
 func (m queryMetricsStore) InsertTemplateVersion(ctx context.Context, arg database.InsertTemplateVersionParams) error {
 	start := time.Now()
 	err := m.s.InsertTemplateVersion(ctx, arg)
@@ -2454,6 +2944,13 @@ func (m queryMetricsStore) InsertWorkspaceBuildParameters(ctx context.Context, a
 	return err
 }
 
+func (m queryMetricsStore) InsertWorkspaceDriftCheck(ctx context.Context, arg database.InsertWorkspaceDriftCheckParams) (database.WorkspaceDriftCheck, error) {
+	start := time.Now()
+	r0, r1 := m.s.InsertWorkspaceDriftCheck(ctx, arg)
+	m.queryLatencies.WithLabelValues("InsertWorkspaceDriftCheck").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
 func (m queryMetricsStore) InsertWorkspaceModule(ctx context.Context, arg database.InsertWorkspaceModuleParams) (database.WorkspaceModule, error) {
 	start := time.Now()
 	r0, r1 := m.s.InsertWorkspaceModule(ctx, arg)
@@ -2482,6 +2979,20 @@ func (m queryMetricsStore) InsertWorkspaceResourceMetadata(ctx context.Context,
 	return metadata, err
 }
 
+func (m queryMetricsStore) InsertWorkspaceSnapshot(ctx context.Context, arg database.InsertWorkspaceSnapshotParams) (database.WorkspaceSnapshot, error) {
+	start := time.Now()
+	r0, r1 := m.s.InsertWorkspaceSnapshot(ctx, arg)
+	m.queryLatencies.WithLabelValues("InsertWorkspaceSnapshot").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
+func (m queryMetricsStore) InsertWorkspaceUpdatePreview(ctx context.Context, arg database.InsertWorkspaceUpdatePreviewParams) (database.WorkspaceUpdatePreview, error) {
+	start := time.Now()
+	r0, r1 := m.s.InsertWorkspaceUpdatePreview(ctx, arg)
+	m.queryLatencies.WithLabelValues("InsertWorkspaceUpdatePreview").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
 func (m queryMetricsStore) ListProvisionerKeysByOrganization(ctx context.Context, organizationID uuid.UUID) ([]database.ProvisionerKey, error) {
 	start := time.Now()
 	r0, r1 := m.s.ListProvisionerKeysByOrganization(ctx, organizationID)
@@ -2601,6 +3112,20 @@ func (m queryMetricsStore) UpdateAPIKeyByID(ctx context.Context, arg database.Up
 	return err
 }
 
+func (m queryMetricsStore) UpdateAuditLogArchiveRestoredAt(ctx context.Context, arg database.UpdateAuditLogArchiveRestoredAtParams) error {
+	start := time.Now()
+	r0 := m.s.UpdateAuditLogArchiveRestoredAt(ctx, arg)
+	m.queryLatencies.WithLabelValues("UpdateAuditLogArchiveRestoredAt").Observe(time.Since(start).Seconds())
+	return r0
+}
+
+func (m queryMetricsStore) UpdateAuditLogExportCursor(ctx context.Context, arg database.UpdateAuditLogExportCursorParams) (int64, error) {
+	start := time.Now()
+	r0, r1 := m.s.UpdateAuditLogExportCursor(ctx, arg)
+	m.queryLatencies.WithLabelValues("UpdateAuditLogExportCursor").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
 func (m queryMetricsStore) UpdateCryptoKeyDeletesAt(ctx context.Context, arg database.UpdateCryptoKeyDeletesAtParams) (database.CryptoKey, error) {
 	start := time.Now()
 	key, err := m.s.UpdateCryptoKeyDeletesAt(ctx, arg)
@@ -2671,6 +3196,20 @@ func (m queryMetricsStore) UpdateMemoryResourceMonitor(ctx context.Context, arg
 	return r0
 }
 
+func (m queryMetricsStore) UpdateNotificationRule(ctx context.Context, arg database.UpdateNotificationRuleParams) (database.NotificationRule, error) {
+	start := time.Now()
+	r0, r1 := m.s.UpdateNotificationRule(ctx, arg)
+	m.queryLatencies.WithLabelValues("UpdateNotificationRule").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
+func (m queryMetricsStore) UpdateNotificationRuleEvaluation(ctx context.Context, arg database.UpdateNotificationRuleEvaluationParams) error {
+	start := time.Now()
+	r0 := m.s.UpdateNotificationRuleEvaluation(ctx, arg)
+	m.queryLatencies.WithLabelValues("UpdateNotificationRuleEvaluation").Observe(time.Since(start).Seconds())
+	return r0
+}
+
 func (m queryMetricsStore) UpdateNotificationTemplateMethodByID(ctx context.Context, arg database.UpdateNotificationTemplateMethodByIDParams) (database.NotificationTemplate, error) {
 	start := time.Now()
 	r0, r1 := m.s.UpdateNotificationTemplateMethodByID(ctx, arg)
@@ -2706,6 +3245,20 @@ func (m queryMetricsStore) UpdateOrganizationDeletedByID(ctx context.Context, ar
 	return r0
 }
 
+func (m queryMetricsStore) UpdateOrganizationHolidayCalendar(ctx context.Context, arg database.UpdateOrganizationHolidayCalendarParams) error {
+	start := time.Now()
+	err := m.s.UpdateOrganizationHolidayCalendar(ctx, arg)
+	m.queryLatencies.WithLabelValues("UpdateOrganizationHolidayCalendar").Observe(time.Since(start).Seconds())
+	return err
+}
+
+func (m queryMetricsStore) UpdatePlanPolicyByID(ctx context.Context, arg database.UpdatePlanPolicyByIDParams) (database.PlanPolicy, error) {
+	start := time.Now()
+	r0, r1 := m.s.UpdatePlanPolicyByID(ctx, arg)
+	m.queryLatencies.WithLabelValues("UpdatePlanPolicyByID").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
 func (m queryMetricsStore) UpdatePresetPrebuildStatus(ctx context.Context, arg database.UpdatePresetPrebuildStatusParams) error {
 	start := time.Now()
 	r0 := m.s.UpdatePresetPrebuildStatus(ctx, arg)
@@ -2783,6 +3336,13 @@ func (m queryMetricsStore) UpdateTemplateActiveVersionByID(ctx context.Context,
 	return err
 }
 
+func (m queryMetricsStore) UpdateTemplateAutostopWindows(ctx context.Context, arg database.UpdateTemplateAutostopWindowsParams) error {
+	start := time.Now()
+	err := m.s.UpdateTemplateAutostopWindows(ctx, arg)
+	m.queryLatencies.WithLabelValues("UpdateTemplateAutostopWindows").Observe(time.Since(start).Seconds())
+	return err
+}
+
 func (m queryMetricsStore) UpdateTemplateDeletedByID(ctx context.Context, arg database.UpdateTemplateDeletedByIDParams) error {
 	start := time.Now()
 	err := m.s.UpdateTemplateDeletedByID(ctx, arg)
@@ -2839,6 +3399,13 @@ func (m queryMetricsStore) UpdateTemplateWorkspacesLastUsedAt(ctx context.Contex
 	return r0
 }
 
+func (m queryMetricsStore) UpdateUserAutostopWindows(ctx context.Context, arg database.UpdateUserAutostopWindowsParams) error {
+	start := time.Now()
+	err := m.s.UpdateUserAutostopWindows(ctx, arg)
+	m.queryLatencies.WithLabelValues("UpdateUserAutostopWindows").Observe(time.Since(start).Seconds())
+	return err
+}
+
 func (m queryMetricsStore) UpdateUserDeletedByID(ctx context.Context, id uuid.UUID) error {
 	start := time.Now()
 	r0 := m.s.UpdateUserDeletedByID(ctx, id)
@@ -2895,6 +3462,13 @@ func (m queryMetricsStore) UpdateUserLoginType(ctx context.Context, arg database
 	return r0, r1
 }
 
+func (m queryMetricsStore) UpdateUserNotificationDigestPreferences(ctx context.Context, arg database.UpdateUserNotificationDigestPreferencesParams) error {
+	start := time.Now()
+	r0 := m.s.UpdateUserNotificationDigestPreferences(ctx, arg)
+	m.queryLatencies.WithLabelValues("UpdateUserNotificationDigestPreferences").Observe(time.Since(start).Seconds())
+	return r0
+}
+
 func (m queryMetricsStore) UpdateUserNotificationPreferences(ctx context.Context, arg database.UpdateUserNotificationPreferencesParams) (int64, error) {
 	start := time.Now()
 	r0, r1 := m.s.UpdateUserNotificationPreferences(ctx, arg)
@@ -3056,6 +3630,13 @@ func (m queryMetricsStore) UpdateWorkspaceDormantDeletingAt(ctx context.Context,
 	return ws, r0
 }
 
+func (m queryMetricsStore) UpdateWorkspaceDriftCheckByJobID(ctx context.Context, arg database.UpdateWorkspaceDriftCheckByJobIDParams) (database.WorkspaceDriftCheck, error) {
+	start := time.Now()
+	r0, r1 := m.s.UpdateWorkspaceDriftCheckByJobID(ctx, arg)
+	m.queryLatencies.WithLabelValues("UpdateWorkspaceDriftCheckByJobID").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
 func (m queryMetricsStore) UpdateWorkspaceLastUsedAt(ctx context.Context, arg database.UpdateWorkspaceLastUsedAtParams) error {
 	start := time.Now()
 	err := m.s.UpdateWorkspaceLastUsedAt(ctx, arg)
@@ -3091,6 +3672,13 @@ func (m queryMetricsStore) UpdateWorkspaceTTL(ctx context.Context, arg database.
 	return r0
 }
 
+func (m queryMetricsStore) UpdateWorkspaceUpdatePreviewByJobID(ctx context.Context, arg database.UpdateWorkspaceUpdatePreviewByJobIDParams) (database.WorkspaceUpdatePreview, error) {
+	start := time.Now()
+	r0, r1 := m.s.UpdateWorkspaceUpdatePreviewByJobID(ctx, arg)
+	m.queryLatencies.WithLabelValues("UpdateWorkspaceUpdatePreviewByJobID").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
 func (m queryMetricsStore) UpdateWorkspacesDormantDeletingAtByTemplateID(ctx context.Context, arg database.UpdateWorkspacesDormantDeletingAtByTemplateIDParams) ([]database.WorkspaceTable, error) {
 	start := time.Now()
 	r0, r1 := m.s.UpdateWorkspacesDormantDeletingAtByTemplateID(ctx, arg)
@@ -3196,6 +3784,13 @@ func (m queryMetricsStore) UpsertPrebuildsSettings(ctx context.Context, value st
 	return r0
 }
 
+func (m queryMetricsStore) UpsertProviderMirrorToken(ctx context.Context, arg database.UpsertProviderMirrorTokenParams) error {
+	start := time.Now()
+	r0 := m.s.UpsertProviderMirrorToken(ctx, arg)
+	m.queryLatencies.WithLabelValues("UpsertProviderMirrorToken").Observe(time.Since(start).Seconds())
+	return r0
+}
+
 func (m queryMetricsStore) UpsertProvisionerDaemon(ctx context.Context, arg database.UpsertProvisionerDaemonParams) (database.ProvisionerDaemon, error) {
 	start := time.Now()
 	r0, r1 := m.s.UpsertProvisionerDaemon(ctx, arg)
@@ -3266,6 +3861,20 @@ func (m queryMetricsStore) UpsertTemplateUsageStats(ctx context.Context) error {
 	return r0
 }
 
+func (m queryMetricsStore) UpsertUserChatIdentity(ctx context.Context, arg database.UpsertUserChatIdentityParams) (database.UserChatIdentity, error) {
+	start := time.Now()
+	r0, r1 := m.s.UpsertUserChatIdentity(ctx, arg)
+	m.queryLatencies.WithLabelValues("UpsertUserChatIdentity").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
+func (m queryMetricsStore) UpsertUserNotificationSchedule(ctx context.Context, arg database.UpsertUserNotificationScheduleParams) (database.UserNotificationSchedule, error) {
+	start := time.Now()
+	r0, r1 := m.s.UpsertUserNotificationSchedule(ctx, arg)
+	m.queryLatencies.WithLabelValues("UpsertUserNotificationSchedule").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
 func (m queryMetricsStore) UpsertWebpushVAPIDKeys(ctx context.Context, arg database.UpsertWebpushVAPIDKeysParams) error {
 	start := time.Now()
 	r0 := m.s.UpsertWebpushVAPIDKeys(ctx, arg)
@@ -3294,6 +3903,34 @@ func (m queryMetricsStore) UpsertWorkspaceAppAuditSession(ctx context.Context, a
 	return r0, r1
 }
 
+func (m queryMetricsStore) UpsertWorkspaceBuildState(ctx context.Context, arg database.UpsertWorkspaceBuildStateParams) error {
+	start := time.Now()
+	r0 := m.s.UpsertWorkspaceBuildState(ctx, arg)
+	m.queryLatencies.WithLabelValues("UpsertWorkspaceBuildState").Observe(time.Since(start).Seconds())
+	return r0
+}
+
+func (m queryMetricsStore) UpsertWorkspaceCostUsage(ctx context.Context) error {
+	start := time.Now()
+	r0 := m.s.UpsertWorkspaceCostUsage(ctx)
+	m.queryLatencies.WithLabelValues("UpsertWorkspaceCostUsage").Observe(time.Since(start).Seconds())
+	return r0
+}
+
+func (m queryMetricsStore) UpsertWorkspaceSnapshotRestore(ctx context.Context, arg database.UpsertWorkspaceSnapshotRestoreParams) (database.WorkspaceSnapshotRestore, error) {
+	start := time.Now()
+	r0, r1 := m.s.UpsertWorkspaceSnapshotRestore(ctx, arg)
+	m.queryLatencies.WithLabelValues("UpsertWorkspaceSnapshotRestore").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
+func (m queryMetricsStore) VerifyUserChatIdentity(ctx context.Context, arg database.VerifyUserChatIdentityParams) (database.UserChatIdentity, error) {
+	start := time.Now()
+	r0, r1 := m.s.VerifyUserChatIdentity(ctx, arg)
+	m.queryLatencies.WithLabelValues("VerifyUserChatIdentity").Observe(time.Since(start).Seconds())
+	return r0, r1
+}
+
 func (m queryMetricsStore) GetAuthorizedTemplates(ctx context.Context, arg database.GetTemplatesWithFilterParams, prepared rbac.PreparedAuthorized) ([]database.Template, error) {
 	start := time.Now()
 	templates, err := m.s.GetAuthorizedTemplates(ctx, arg, prepared)
@@ -0,0 +1,321 @@
+package dbpurge
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/v2/coderd/database"
+	"github.com/coder/coder/v2/coderd/database/dbauthz"
+)
+
+const (
+	// auditLogArchiveBatchSize is the number of audit logs archived to a
+	// single file.
+	auditLogArchiveBatchSize = 10000
+	// maxAuditLogArchiveBatches bounds the archives written per purge, so a
+	// large backlog is worked through over several purges.
+	maxAuditLogArchiveBatches = 10
+	// defaultAuditLogRestoreDuration is how long restored audit logs are
+	// kept when no restore duration is configured.
+	defaultAuditLogRestoreDuration = 7 * 24 * time.Hour
+	// auditLogSinkInactiveAfter is how long after its lease expired a
+	// streaming sink is assumed to be unconfigured. Until then, audit logs
+	// it didn't export yet are not archived, so retention never deletes
+	// audit logs before they are streamed.
+	auditLogSinkInactiveAfter = 24 * time.Hour
+	// auditLogArchiveMimetype is the mimetype of archives in the files table.
+	auditLogArchiveMimetype = "application/gzip"
+)
+
+// ErrAuditLogArchiveRestored is returned when restoring an archive whose
+// audit logs are restored already.
+var ErrAuditLogArchiveRestored = xerrors.New("audit log archive is already restored")
+
+// AuditLogRetention configures how long audit logs are kept. Audit logs which
+// outlive their retention are archived to compressed files in the files
+// table, so any replica can restore them, then deleted.
+type AuditLogRetention struct {
+	// Default is the retention of audit logs no rule matches. Zero keeps
+	// them forever.
+	Default time.Duration
+	Rules   []AuditLogRetentionRule
+	// RestoreDuration is how long restored audit logs are kept before they
+	// are deleted again.
+	RestoreDuration time.Duration
+}
+
+// Enabled returns whether any audit logs expire.
+func (r AuditLogRetention) Enabled() bool {
+	if r.Default > 0 {
+		return true
+	}
+	for _, rule := range r.Rules {
+		if rule.Retention > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// AuditLogRetentionRule is the retention of the audit logs of an action,
+// optionally of a single resource type. An empty resource type or action
+// matches any.
+type AuditLogRetentionRule struct {
+	ResourceType database.ResourceType
+	Action       database.AuditAction
+	// Retention is how long matching audit logs are kept. Zero keeps them
+	// forever.
+	Retention time.Duration
+}
+
+// ParseAuditLogRetentionRules parses [resource_type:]action=duration rules,
+// where the action may be * to match every action of a resource type.
+func ParseAuditLogRetentionRules(values []string) ([]AuditLogRetentionRule, error) {
+	rules := make([]AuditLogRetentionRule, 0, len(values))
+	for _, value := range values {
+		key, duration, ok := strings.Cut(value, "=")
+		if !ok {
+			return nil, xerrors.Errorf("invalid audit log retention rule %q, expected [resource_type:]action=duration", value)
+		}
+		var rule AuditLogRetentionRule
+		action := key
+		if resourceType, rest, ok := strings.Cut(key, ":"); ok {
+			rule.ResourceType = database.ResourceType(resourceType)
+			if !rule.ResourceType.Valid() {
+				return nil, xerrors.Errorf("invalid resource type %q in audit log retention rule %q", resourceType, value)
+			}
+			action = rest
+		}
+		if action != "*" {
+			rule.Action = database.AuditAction(action)
+			if !rule.Action.Valid() {
+				return nil, xerrors.Errorf("invalid action %q in audit log retention rule %q", action, value)
+			}
+		} else if rule.ResourceType == "" {
+			return nil, xerrors.Errorf("audit log retention rule %q matches every audit log, set the default retention instead", value)
+		}
+		retention, err := time.ParseDuration(duration)
+		if err != nil {
+			return nil, xerrors.Errorf("parse duration of audit log retention rule %q: %w", value, err)
+		}
+		if retention < 0 {
+			return nil, xerrors.Errorf("negative duration in audit log retention rule %q", value)
+		}
+		rule.Retention = retention
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// expiredAuditLogsParams returns the query of the audit logs which expired
+// before now, and whether any can expire at all.
+func (r AuditLogRetention) expiredAuditLogsParams(now time.Time) (database.GetExpiredAuditLogsParams, bool) {
+	cutoff := func(retention time.Duration) time.Time {
+		if retention <= 0 {
+			// Nothing is older than the zero time, so it is kept forever.
+			return time.Time{}
+		}
+		return now.Add(-retention)
+	}
+	params := database.GetExpiredAuditLogsParams{
+		RuleResourceTypes: make([]string, 0, len(r.Rules)),
+		RuleActions:       make([]string, 0, len(r.Rules)),
+		RuleCutoffs:       make([]time.Time, 0, len(r.Rules)),
+		DefaultCutoff:     cutoff(r.Default),
+		SinkActiveAfter:   now.Add(-auditLogSinkInactiveAfter),
+		LimitOpt:          auditLogArchiveBatchSize,
+	}
+	params.MaxCutoff = params.DefaultCutoff
+	for _, rule := range r.Rules {
+		ruleCutoff := cutoff(rule.Retention)
+		params.RuleResourceTypes = append(params.RuleResourceTypes, string(rule.ResourceType))
+		params.RuleActions = append(params.RuleActions, string(rule.Action))
+		params.RuleCutoffs = append(params.RuleCutoffs, ruleCutoff)
+		if ruleCutoff.After(params.MaxCutoff) {
+			params.MaxCutoff = ruleCutoff
+		}
+	}
+	return params, !params.MaxCutoff.IsZero()
+}
+
+// archiveExpiredAuditLogs archives the audit logs which outlived their
+// retention, then deletes them. It returns the number of archives written.
+func archiveExpiredAuditLogs(ctx context.Context, tx database.Store, retention AuditLogRetention, now time.Time) (int, error) {
+	params, ok := retention.expiredAuditLogsParams(now)
+	if !ok {
+		return 0, nil
+	}
+
+	archives := 0
+	for range maxAuditLogArchiveBatches {
+		logs, err := tx.GetExpiredAuditLogs(ctx, params)
+		if err != nil {
+			return archives, xerrors.Errorf("get expired audit logs: %w", err)
+		}
+		if len(logs) == 0 {
+			break
+		}
+
+		data, err := encodeAuditLogArchive(logs)
+		if err != nil {
+			return archives, xerrors.Errorf("encode audit log archive: %w", err)
+		}
+		sum := sha256.Sum256(data)
+		file, err := tx.InsertFile(ctx, database.InsertFileParams{
+			ID:        uuid.New(),
+			Hash:      hex.EncodeToString(sum[:]),
+			CreatedBy: uuid.Nil,
+			CreatedAt: now,
+			Mimetype:  auditLogArchiveMimetype,
+			Data:      data,
+		})
+		if err != nil {
+			return archives, xerrors.Errorf("insert audit log archive file: %w", err)
+		}
+
+		id := uuid.New()
+		_, err = tx.InsertAuditLogArchive(ctx, database.InsertAuditLogArchiveParams{
+			ID:            id,
+			CreatedAt:     now,
+			FileName:      fmt.Sprintf("audit-logs-%s-%s.ndjson.gz", logs[0].Time.UTC().Format("20060102T150405Z"), id),
+			FileID:        file.ID,
+			OldestTime:    logs[0].Time,
+			NewestTime:    logs[len(logs)-1].Time,
+			AuditLogCount: int32(len(logs)), //nolint:gosec // Bounded by the batch size.
+			Sha256:        sum[:],
+		})
+		if err != nil {
+			return archives, xerrors.Errorf("insert audit log archive: %w", err)
+		}
+		ids := make([]uuid.UUID, 0, len(logs))
+		for _, alog := range logs {
+			ids = append(ids, alog.ID)
+		}
+		err = tx.InsertAuditLogArchiveEntries(ctx, database.InsertAuditLogArchiveEntriesParams{
+			AuditLogIDs: ids,
+			ArchiveID:   id,
+		})
+		if err != nil {
+			return archives, xerrors.Errorf("insert audit log archive entries: %w", err)
+		}
+		if err := tx.DeleteAuditLogsByIDs(ctx, ids); err != nil {
+			return archives, xerrors.Errorf("delete archived audit logs: %w", err)
+		}
+		archives++
+		if len(logs) < auditLogArchiveBatchSize {
+			break
+		}
+	}
+	return archives, nil
+}
+
+// encodeAuditLogArchive encodes audit logs as gzipped JSON, one object per
+// line.
+func encodeAuditLogArchive(logs []database.AuditLog) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	enc := json.NewEncoder(zw)
+	for _, alog := range logs {
+		if err := enc.Encode(alog); err != nil {
+			return nil, xerrors.Errorf("encode audit log %s: %w", alog.ID, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, xerrors.Errorf("close gzip writer: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// RestoreAuditLogArchive inserts the audit logs of an archive again, for
+// investigations. They are deleted again once the restore duration passed,
+// and stay in the archive.
+func RestoreAuditLogArchive(ctx context.Context, db database.Store, id uuid.UUID, now time.Time) (database.AuditLogArchive, error) {
+	archive, err := db.GetAuditLogArchiveByID(ctx, id)
+	if err != nil {
+		return database.AuditLogArchive{}, xerrors.Errorf("get audit log archive: %w", err)
+	}
+	if archive.RestoredAt.Valid {
+		return database.AuditLogArchive{}, ErrAuditLogArchiveRestored
+	}
+	// Archive files are owned by no user, access to the archive is
+	// authorized above.
+	//nolint:gocritic // The system reads the files of audit log archives.
+	file, err := db.GetFileByID(dbauthz.AsSystemRestricted(ctx), archive.FileID)
+	if err != nil {
+		return database.AuditLogArchive{}, xerrors.Errorf("get audit log archive file: %w", err)
+	}
+	logs, err := decodeAuditLogArchive(file.Data, archive.Sha256)
+	if err != nil {
+		return database.AuditLogArchive{}, xerrors.Errorf("read audit log archive %s: %w", archive.FileName, err)
+	}
+
+	err = db.InTx(func(tx database.Store) error {
+		// Check again in the transaction, in case the archive was restored
+		// concurrently.
+		archive, err = tx.GetAuditLogArchiveByID(ctx, id)
+		if err != nil {
+			return xerrors.Errorf("get audit log archive: %w", err)
+		}
+		if archive.RestoredAt.Valid {
+			return ErrAuditLogArchiveRestored
+		}
+		for _, alog := range logs {
+			if _, err := tx.InsertAuditLog(ctx, alog.InsertAuditLogParams()); err != nil {
+				return xerrors.Errorf("insert audit log %s: %w", alog.ID, err)
+			}
+		}
+		archive.RestoredAt = sql.NullTime{Time: now, Valid: true}
+		err = tx.UpdateAuditLogArchiveRestoredAt(ctx, database.UpdateAuditLogArchiveRestoredAtParams{
+			ID:         archive.ID,
+			RestoredAt: archive.RestoredAt,
+		})
+		if err != nil {
+			return xerrors.Errorf("update audit log archive: %w", err)
+		}
+		return nil
+	}, nil)
+	if err != nil {
+		return database.AuditLogArchive{}, err
+	}
+	return archive, nil
+}
+
+// decodeAuditLogArchive decodes the audit logs of an archive, after checking
+// the file matches the SHA-256 it was archived with.
+func decodeAuditLogArchive(data []byte, sum []byte) ([]database.AuditLog, error) {
+	if actual := sha256.Sum256(data); !bytes.Equal(actual[:], sum) {
+		return nil, xerrors.New("the file doesn't match the checksum it was archived with")
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, xerrors.Errorf("create gzip reader: %w", err)
+	}
+	defer zr.Close()
+	logs := make([]database.AuditLog, 0)
+	dec := json.NewDecoder(zr)
+	for {
+		var alog database.AuditLog
+		err := dec.Decode(&alog)
+		if xerrors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, xerrors.Errorf("decode audit log: %w", err)
+		}
+		logs = append(logs, alog)
+	}
+	return logs, nil
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/coder/coder/v2/coderd/database"
 	"github.com/coder/coder/v2/coderd/database/dbauthz"
 	"github.com/coder/coder/v2/coderd/database/dbtime"
+	"github.com/coder/coder/v2/coderd/workspacesnapshots"
 	"github.com/coder/quartz"
 )
 
@@ -20,13 +21,78 @@ const (
 	maxAgentLogAge = 7 * 24 * time.Hour
 )
 
+// Option configures the purging of the database.
+type Option func(*options)
+
+type options struct {
+	auditLogRetention          AuditLogRetention
+	workspaceStateRetention    WorkspaceStateRetention
+	workspaceSnapshotRetention WorkspaceSnapshotRetention
+}
+
+// WorkspaceStateRetention configures how many Terraform states of earlier
+// builds are kept per workspace. The newest state of a workspace is always
+// kept.
+type WorkspaceStateRetention struct {
+	// Builds is the number of states kept per workspace. Zero keeps any
+	// number of states.
+	Builds int32
+	// MaxAge is how long states are kept. Zero keeps them forever.
+	MaxAge time.Duration
+}
+
+// WorkspaceSnapshotRetention configures how many snapshot chains are kept per
+// workspace agent. A chain is a full snapshot and the snapshots incremental to
+// it.
+type WorkspaceSnapshotRetention struct {
+	// Chains is the number of chains kept per workspace agent. Zero keeps
+	// any number of chains.
+	Chains int32
+	// Store deletes the archives of deleted snapshots which are stored
+	// outside of the database.
+	Store workspacesnapshots.Store
+}
+
+// WithAuditLogRetention archives and deletes the audit logs which outlived
+// their retention. Audit logs are kept forever otherwise.
+func WithAuditLogRetention(retention AuditLogRetention) Option {
+	return func(o *options) {
+		o.auditLogRetention = retention
+	}
+}
+
+// WithWorkspaceStateRetention deletes the states of earlier workspace builds
+// which exceed the retention. All states are kept otherwise.
+func WithWorkspaceStateRetention(retention WorkspaceStateRetention) Option {
+	return func(o *options) {
+		o.workspaceStateRetention = retention
+	}
+}
+
+// WithWorkspaceSnapshotRetention deletes the snapshot chains of workspace
+// agents which exceed the retention. All snapshots are kept otherwise.
+func WithWorkspaceSnapshotRetention(retention WorkspaceSnapshotRetention) Option {
+	return func(o *options) {
+		o.workspaceSnapshotRetention = retention
+	}
+}
+
 // New creates a new periodically purging database instance.
 // It is the caller's responsibility to call Close on the returned instance.
 //
 // This is for cleaning up old, unused resources from the database that take up space.
-func New(ctx context.Context, logger slog.Logger, db database.Store, clk quartz.Clock) io.Closer {
+func New(ctx context.Context, logger slog.Logger, db database.Store, clk quartz.Clock, opts ...Option) io.Closer {
 	closed := make(chan struct{})
 
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	restoreDuration := o.auditLogRetention.RestoreDuration
+	if restoreDuration <= 0 {
+		restoreDuration = defaultAuditLogRestoreDuration
+	}
+
 	ctx, cancelFunc := context.WithCancel(ctx)
 	//nolint:gocritic // The system purges old db records without user input.
 	ctx = dbauthz.AsSystemRestricted(ctx)
@@ -35,6 +101,10 @@ func New(ctx context.Context, logger slog.Logger, db database.Store, clk quartz.
 	ticker := clk.NewTicker(delay)
 	doTick := func(start time.Time) {
 		defer ticker.Reset(delay)
+		var archives int
+		// Snapshot archives outside of the database are only deleted once
+		// the deletion of their snapshots is committed.
+		var snapshotArchives []database.DeleteOldWorkspaceSnapshotsRow
 		// Start a transaction to grab advisory lock, we don't want to run
 		// multiple purges at the same time (multiple replicas).
 		if err := db.InTx(func(tx database.Store) error {
@@ -62,6 +132,32 @@ func New(ctx context.Context, logger slog.Logger, db database.Store, clk quartz.
 			if err := tx.DeleteOldNotificationMessages(ctx); err != nil {
 				return xerrors.Errorf("failed to delete old notification messages: %w", err)
 			}
+			if retention := o.workspaceStateRetention; retention.Builds > 0 || retention.MaxAge > 0 {
+				var before time.Time
+				if retention.MaxAge > 0 {
+					before = start.Add(-retention.MaxAge)
+				}
+				if _, err := tx.DeleteOldWorkspaceBuildStates(ctx, database.DeleteOldWorkspaceBuildStatesParams{
+					KeepCount: retention.Builds,
+					Before:    before,
+				}); err != nil {
+					return xerrors.Errorf("failed to delete old workspace build states: %w", err)
+				}
+			}
+			if retention := o.workspaceSnapshotRetention; retention.Chains > 0 {
+				deleted, err := tx.DeleteOldWorkspaceSnapshots(ctx, retention.Chains)
+				if err != nil {
+					return xerrors.Errorf("failed to delete old workspace snapshots: %w", err)
+				}
+				snapshotArchives = deleted
+			}
+			if err := tx.DeleteRestoredAuditLogs(ctx, start.Add(-restoreDuration)); err != nil {
+				return xerrors.Errorf("failed to delete restored audit logs: %w", err)
+			}
+			archives, err = archiveExpiredAuditLogs(ctx, tx, o.auditLogRetention, start)
+			if err != nil {
+				return xerrors.Errorf("failed to archive expired audit logs: %w", err)
+			}
 
 			logger.Debug(ctx, "purged old database entries", slog.F("duration", clk.Since(start)))
 
@@ -70,6 +166,10 @@ func New(ctx context.Context, logger slog.Logger, db database.Store, clk quartz.
 			logger.Error(ctx, "failed to purge old database entries", slog.Error(err))
 			return
 		}
+		if archives > 0 {
+			logger.Info(ctx, "archived expired audit logs", slog.F("archives", archives))
+		}
+		deleteSnapshotArchives(ctx, logger, o.workspaceSnapshotRetention.Store, snapshotArchives)
 	}
 
 	go func() {
@@ -93,6 +193,27 @@ func New(ctx context.Context, logger slog.Logger, db database.Store, clk quartz.
 	}
 }
 
+// deleteSnapshotArchives deletes the archives of deleted snapshots which are
+// stored outside of the database. Archives which cannot be deleted are left
+// behind, as their snapshots are gone already.
+func deleteSnapshotArchives(ctx context.Context, logger slog.Logger, store workspacesnapshots.Store, deleted []database.DeleteOldWorkspaceSnapshotsRow) {
+	for _, archive := range deleted {
+		if archive.Storage == database.WorkspaceSnapshotStorageDatabase {
+			continue
+		}
+		if store == nil || store.Storage() != archive.Storage {
+			logger.Warn(ctx, "cannot delete archive of deleted workspace snapshot, its storage is not configured",
+				slog.F("storage", archive.Storage),
+				slog.F("key", archive.ObjectKey),
+			)
+			continue
+		}
+		if err := store.Delete(ctx, archive.ObjectKey); err != nil {
+			logger.Warn(ctx, "failed to delete archive of deleted workspace snapshot", slog.F("key", archive.ObjectKey), slog.Error(err))
+		}
+	}
+}
+
 type instance struct {
 	cancel context.CancelFunc
 	closed chan struct{}
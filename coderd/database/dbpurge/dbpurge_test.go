@@ -25,6 +25,7 @@ import (
 	"github.com/coder/coder/v2/coderd/database/dbrollup"
 	"github.com/coder/coder/v2/coderd/database/dbtestutil"
 	"github.com/coder/coder/v2/coderd/database/dbtime"
+	"github.com/coder/coder/v2/coderd/workspacesnapshots"
 	"github.com/coder/coder/v2/codersdk"
 	"github.com/coder/coder/v2/provisionerd/proto"
 	"github.com/coder/coder/v2/provisionersdk"
@@ -490,3 +491,270 @@ func containsProvisionerDaemon(daemons []database.ProvisionerDaemon, name string
 		return d.Name == name
 	})
 }
+
+//nolint:paralleltest // It uses LockIDDBPurge.
+func TestArchiveExpiredAuditLogs(t *testing.T) {
+	ctx := testutil.Context(t, testutil.WaitShort)
+	clk := quartz.NewMock(t)
+	now := dbtime.Now()
+	clk.Set(now).MustWait(ctx)
+
+	db, _ := dbtestutil.NewDB(t, dbtestutil.WithDumpOnFailure())
+	logger := slogtest.Make(t, &slogtest.Options{IgnoreErrors: true})
+
+	rules, err := dbpurge.ParseAuditLogRetentionRules([]string{"connect=720h", "organization_member:*=61320h"})
+	require.NoError(t, err)
+	retention := dbpurge.AuditLogRetention{
+		Default: 365 * 24 * time.Hour,
+		Rules:   rules,
+	}
+
+	// Given the following audit logs:
+	// A connection outlived the 30 day retention of connections.
+	expiredConnect := dbgen.AuditLog(t, db, database.AuditLog{
+		Time:   now.AddDate(0, 0, -40),
+		Action: database.AuditActionConnect,
+	})
+	// A connection is within the retention of connections.
+	connect := dbgen.AuditLog(t, db, database.AuditLog{
+		Time:   now.AddDate(0, 0, -10),
+		Action: database.AuditActionConnect,
+	})
+	// A role change is within the 7 year retention of organization members.
+	roleChange := dbgen.AuditLog(t, db, database.AuditLog{
+		Time:         now.AddDate(-2, 0, 0),
+		ResourceType: database.ResourceTypeOrganizationMember,
+		Action:       database.AuditActionWrite,
+	})
+	// A workspace change outlived the default retention.
+	expiredWrite := dbgen.AuditLog(t, db, database.AuditLog{
+		Time:         now.AddDate(-2, 0, 0),
+		ResourceType: database.ResourceTypeWorkspace,
+		Action:       database.AuditActionWrite,
+	})
+	// A workspace change outlived the default retention, but wasn't
+	// streamed to an active sink yet.
+	_, err = db.AcquireAuditLogExportCursor(ctx, database.AcquireAuditLogExportCursorParams{
+		Sink:           "s3",
+		Now:            now,
+		LeasedBy:       uuid.New(),
+		LeaseExpiresAt: now.Add(time.Minute),
+	})
+	require.NoError(t, err)
+	unexported := dbgen.AuditLog(t, db, database.AuditLog{
+		Time:         now.AddDate(-2, 0, 0),
+		ResourceType: database.ResourceTypeWorkspace,
+		Action:       database.AuditActionWrite,
+	})
+	ids := []uuid.UUID{expiredConnect.ID, connect.ID, roleChange.ID, expiredWrite.ID, unexported.ID}
+
+	// when dbpurge runs
+	done := awaitDoTick(ctx, t, clk)
+	closer := dbpurge.New(ctx, logger, db, clk, dbpurge.WithAuditLogRetention(retention))
+	defer closer.Close()
+	<-done // doTick() has now run.
+
+	// then the expired audit logs which were streamed were archived and
+	// deleted.
+	require.ElementsMatch(t, []uuid.UUID{connect.ID, roleChange.ID, unexported.ID}, auditLogIDs(ctx, t, db, ids))
+	archives, err := db.GetAuditLogArchives(ctx)
+	require.NoError(t, err)
+	require.Len(t, archives, 1)
+	archive := archives[0]
+	require.EqualValues(t, 2, archive.AuditLogCount)
+	require.True(t, archive.OldestTime.Equal(expiredWrite.Time))
+	file, err := db.GetFileByID(ctx, archive.FileID)
+	require.NoError(t, err)
+	require.Equal(t, "application/gzip", file.Mimetype)
+	archived, err := db.GetArchivedAuditLogIDs(ctx, ids)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []uuid.UUID{expiredConnect.ID, expiredWrite.ID}, archived)
+
+	// when the archive is restored
+	restored, err := dbpurge.RestoreAuditLogArchive(ctx, db, archive.ID, now)
+	require.NoError(t, err)
+	require.True(t, restored.RestoredAt.Valid)
+	require.ElementsMatch(t, ids, auditLogIDs(ctx, t, db, ids))
+	_, err = dbpurge.RestoreAuditLogArchive(ctx, db, archive.ID, now)
+	require.ErrorIs(t, err, dbpurge.ErrAuditLogArchiveRestored)
+
+	// then the restored audit logs are deleted again once the restore
+	// duration passed, and the archive can be restored again.
+	err = db.DeleteRestoredAuditLogs(ctx, now.Add(time.Second))
+	require.NoError(t, err)
+	require.ElementsMatch(t, []uuid.UUID{connect.ID, roleChange.ID, unexported.ID}, auditLogIDs(ctx, t, db, ids))
+	archive, err = db.GetAuditLogArchiveByID(ctx, archive.ID)
+	require.NoError(t, err)
+	require.False(t, archive.RestoredAt.Valid)
+}
+
+//nolint:paralleltest // It uses LockIDDBPurge.
+func TestDeleteOldWorkspaceBuildStates(t *testing.T) {
+	ctx := testutil.Context(t, testutil.WaitShort)
+	clk := quartz.NewMock(t)
+	now := dbtime.Now()
+	clk.Set(now).MustWait(ctx)
+
+	db, _ := dbtestutil.NewDB(t, dbtestutil.WithDumpOnFailure())
+	dbtestutil.DisableForeignKeysAndTriggers(t, db)
+	logger := slogtest.Make(t, &slogtest.Options{IgnoreErrors: true})
+
+	insertState := func(workspaceID uuid.UUID, buildNumber int32, age time.Duration) uuid.UUID {
+		build := dbgen.WorkspaceBuild(t, db, database.WorkspaceBuild{
+			WorkspaceID: workspaceID,
+			BuildNumber: buildNumber,
+		})
+		err := db.UpsertWorkspaceBuildState(ctx, database.UpsertWorkspaceBuildStateParams{
+			WorkspaceBuildID: build.ID,
+			WorkspaceID:      workspaceID,
+			State:            []byte("{}"),
+			CreatedAt:        now.Add(-age),
+		})
+		require.NoError(t, err)
+		return build.ID
+	}
+
+	// Given the following states:
+	workspace := uuid.New()
+	// A state outlived the maximum age.
+	expired := insertState(workspace, 1, 40*24*time.Hour)
+	// A state exceeds the number of states kept.
+	exceeded := insertState(workspace, 2, 20*24*time.Hour)
+	kept := insertState(workspace, 3, 10*24*time.Hour)
+	newest := insertState(workspace, 4, time.Hour)
+	// The only state of another workspace outlived the maximum age, but it
+	// is the newest state of its workspace.
+	otherWorkspace := uuid.New()
+	only := insertState(otherWorkspace, 1, 100*24*time.Hour)
+
+	// when dbpurge runs
+	done := awaitDoTick(ctx, t, clk)
+	closer := dbpurge.New(ctx, logger, db, clk, dbpurge.WithWorkspaceStateRetention(dbpurge.WorkspaceStateRetention{
+		Builds: 2,
+		MaxAge: 30 * 24 * time.Hour,
+	}))
+	defer closer.Close()
+	<-done // doTick() has now run.
+
+	// then the states beyond the retention were deleted.
+	for _, id := range []uuid.UUID{expired, exceeded} {
+		_, err := db.GetWorkspaceBuildStateByBuildID(ctx, id)
+		require.ErrorIs(t, err, sql.ErrNoRows)
+	}
+	for _, id := range []uuid.UUID{kept, newest, only} {
+		_, err := db.GetWorkspaceBuildStateByBuildID(ctx, id)
+		require.NoError(t, err)
+	}
+}
+
+func TestDeleteOldWorkspaceSnapshots(t *testing.T) {
+	ctx := testutil.Context(t, testutil.WaitShort)
+	clk := quartz.NewMock(t)
+	now := dbtime.Now()
+	clk.Set(now).MustWait(ctx)
+
+	db, _ := dbtestutil.NewDB(t, dbtestutil.WithDumpOnFailure())
+	dbtestutil.DisableForeignKeysAndTriggers(t, db)
+	logger := slogtest.Make(t, &slogtest.Options{IgnoreErrors: true})
+
+	workspace := uuid.New()
+	insertSnapshot := func(agentName string, parent *database.WorkspaceSnapshot, age time.Duration, storage database.WorkspaceSnapshotStorage, key string) database.WorkspaceSnapshot {
+		snapshot := database.WorkspaceSnapshot{
+			WorkspaceID: workspace,
+			AgentName:   agentName,
+			Storage:     storage,
+			ObjectKey:   key,
+			CreatedAt:   now.Add(-age),
+		}
+		if parent != nil {
+			snapshot.ParentID = uuid.NullUUID{UUID: parent.ID, Valid: true}
+		}
+		return dbgen.WorkspaceSnapshot(t, db, snapshot)
+	}
+	insertFile := func() string {
+		return dbgen.File(t, db, database.File{Mimetype: "application/gzip"}).ID.String()
+	}
+
+	// Given the following snapshot chains of an agent:
+	expiredFile := insertFile()
+	sharedFile := insertFile()
+	// The oldest chain exceeds the number of chains kept.
+	expiredFull := insertSnapshot("main", nil, 5*24*time.Hour, database.WorkspaceSnapshotStorageDatabase, expiredFile)
+	expiredIncrement := insertSnapshot("main", &expiredFull, 4*24*time.Hour, database.WorkspaceSnapshotStorageS3, "snapshots/expired.tar.gz")
+	// An archive in the database is shared with a snapshot which is kept.
+	expiredShared := insertSnapshot("main", &expiredIncrement, 4*24*time.Hour-time.Hour, database.WorkspaceSnapshotStorageDatabase, sharedFile)
+	keptFull := insertSnapshot("main", nil, 3*24*time.Hour, database.WorkspaceSnapshotStorageDatabase, insertFile())
+	keptIncrement := insertSnapshot("main", &keptFull, 2*24*time.Hour, database.WorkspaceSnapshotStorageS3, "snapshots/kept.tar.gz")
+	newest := insertSnapshot("main", nil, time.Hour, database.WorkspaceSnapshotStorageDatabase, sharedFile)
+	// The chains of other agents are counted separately.
+	otherAgent := insertSnapshot("other", nil, 10*24*time.Hour, database.WorkspaceSnapshotStorageDatabase, insertFile())
+
+	// when dbpurge runs
+	store := &fakeSnapshotStore{}
+	done := awaitDoTick(ctx, t, clk)
+	closer := dbpurge.New(ctx, logger, db, clk, dbpurge.WithWorkspaceSnapshotRetention(dbpurge.WorkspaceSnapshotRetention{
+		Chains: 2,
+		Store:  store,
+	}))
+	defer closer.Close()
+	<-done // doTick() has now run.
+
+	// then the oldest chain and its archives were deleted.
+	for _, snapshot := range []database.WorkspaceSnapshot{expiredFull, expiredIncrement, expiredShared} {
+		_, err := db.GetWorkspaceSnapshotByID(ctx, snapshot.ID)
+		require.ErrorIs(t, err, sql.ErrNoRows)
+	}
+	for _, snapshot := range []database.WorkspaceSnapshot{keptFull, keptIncrement, newest, otherAgent} {
+		_, err := db.GetWorkspaceSnapshotByID(ctx, snapshot.ID)
+		require.NoError(t, err)
+	}
+	_, err := db.GetFileByID(ctx, uuid.MustParse(expiredFile))
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = db.GetFileByID(ctx, uuid.MustParse(sharedFile))
+	require.NoError(t, err)
+	require.Equal(t, []string{"snapshots/expired.tar.gz"}, store.deleted)
+}
+
+// fakeSnapshotStore records the archives deleted from S3.
+type fakeSnapshotStore struct {
+	workspacesnapshots.Store
+	deleted []string
+}
+
+func (*fakeSnapshotStore) Storage() database.WorkspaceSnapshotStorage {
+	return database.WorkspaceSnapshotStorageS3
+}
+
+func (s *fakeSnapshotStore) Delete(_ context.Context, key string) error {
+	s.deleted = append(s.deleted, key)
+	return nil
+}
+
+func TestParseAuditLogRetentionRules(t *testing.T) {
+	t.Parallel()
+
+	rules, err := dbpurge.ParseAuditLogRetentionRules([]string{"connect=720h", "organization_member:*=61320h", "user:delete=0s"})
+	require.NoError(t, err)
+	require.Equal(t, []dbpurge.AuditLogRetentionRule{
+		{Action: database.AuditActionConnect, Retention: 720 * time.Hour},
+		{ResourceType: database.ResourceTypeOrganizationMember, Retention: 61320 * time.Hour},
+		{ResourceType: database.ResourceTypeUser, Action: database.AuditActionDelete},
+	}, rules)
+
+	for _, rule := range []string{"connect", "bogus=1h", "bogus:write=1h", "*=1h", "connect=1x", "connect=-1h"} {
+		_, err := dbpurge.ParseAuditLogRetentionRules([]string{rule})
+		require.Error(t, err, rule)
+	}
+}
+
+func auditLogIDs(ctx context.Context, t *testing.T, db database.Store, ids []uuid.UUID) []uuid.UUID {
+	t.Helper()
+
+	logs, err := db.GetAuditLogsByIDs(ctx, ids)
+	require.NoError(t, err)
+	found := make([]uuid.UUID, 0, len(logs))
+	for _, alog := range logs {
+		found = append(found, alog.ID)
+	}
+	return found
+}
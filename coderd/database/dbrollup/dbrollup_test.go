@@ -261,3 +261,95 @@ func TestRollupTemplateUsageStats(t *testing.T) {
 		},
 	}, stats[0])
 }
+
+func TestRollupWorkspaceCostUsage(t *testing.T) {
+	t.Parallel()
+
+	db, ps := dbtestutil.NewDB(t, dbtestutil.WithDumpOnFailure())
+	logger := slogtest.Make(t, &slogtest.Options{IgnoreErrors: true}).Leveled(slog.LevelDebug)
+
+	threeHoursAgo := dbtime.Now().Add(-3 * time.Hour).Truncate(time.Hour).UTC()
+
+	var (
+		org  = dbgen.Organization(t, db, database.Organization{})
+		user = dbgen.User(t, db, database.User{})
+		tpl  = dbgen.Template(t, db, database.Template{OrganizationID: org.ID, CreatedBy: user.ID})
+		ver  = dbgen.TemplateVersion(t, db, database.TemplateVersion{OrganizationID: org.ID, TemplateID: uuid.NullUUID{UUID: tpl.ID, Valid: true}, CreatedBy: user.ID})
+		ws   = dbgen.Workspace(t, db, database.WorkspaceTable{OrganizationID: org.ID, TemplateID: tpl.ID, OwnerID: user.ID})
+	)
+
+	// The workspace starts at half past the hour, and runs for an hour and a
+	// half until it is stopped.
+	startJob := dbgen.ProvisionerJob(t, db, ps, database.ProvisionerJob{
+		OrganizationID: org.ID,
+		CreatedAt:      threeHoursAgo,
+		StartedAt:      sql.NullTime{Time: threeHoursAgo, Valid: true},
+		CompletedAt:    sql.NullTime{Time: threeHoursAgo.Add(30 * time.Minute), Valid: true},
+	})
+	_ = dbgen.WorkspaceBuild(t, db, database.WorkspaceBuild{
+		WorkspaceID:       ws.ID,
+		JobID:             startJob.ID,
+		TemplateVersionID: ver.ID,
+		BuildNumber:       1,
+		Transition:        database.WorkspaceTransitionStart,
+		CreatedAt:         threeHoursAgo,
+	})
+	_ = dbgen.WorkspaceResource(t, db, database.WorkspaceResource{
+		JobID:            startJob.ID,
+		Transition:       database.WorkspaceTransitionStart,
+		HourlyCostMicros: 1_200_000,
+	})
+	_ = dbgen.WorkspaceResource(t, db, database.WorkspaceResource{
+		JobID:            startJob.ID,
+		Transition:       database.WorkspaceTransitionStart,
+		HourlyCostMicros: 800_000,
+	})
+	// Resources of the stop transition don't cost anything while running.
+	_ = dbgen.WorkspaceResource(t, db, database.WorkspaceResource{
+		JobID:            startJob.ID,
+		Transition:       database.WorkspaceTransitionStop,
+		HourlyCostMicros: 5_000_000,
+	})
+	stopJob := dbgen.ProvisionerJob(t, db, ps, database.ProvisionerJob{
+		OrganizationID: org.ID,
+		CreatedAt:      threeHoursAgo.Add(2 * time.Hour),
+	})
+	_ = dbgen.WorkspaceBuild(t, db, database.WorkspaceBuild{
+		WorkspaceID:       ws.ID,
+		JobID:             stopJob.ID,
+		TemplateVersionID: ver.ID,
+		BuildNumber:       2,
+		Transition:        database.WorkspaceTransitionStop,
+		CreatedAt:         threeHoursAgo.Add(2 * time.Hour),
+	})
+
+	// The data is already present, so we can rely on initial rollup to occur.
+	events := make(chan dbrollup.Event, 1)
+	rolluper := dbrollup.New(logger, db, dbrollup.WithInterval(250*time.Millisecond), dbrollup.WithEventChannel(events))
+	defer rolluper.Close()
+
+	<-events // Deplete init event, resume operation.
+
+	ctx := testutil.Context(t, testutil.WaitMedium)
+
+	select {
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for rollup to occur")
+	case ev := <-events:
+		require.True(t, ev.WorkspaceCostUsage, "expected workspace cost usage to be rolled up")
+	}
+
+	rows, err := db.GetWorkspaceCostInsights(ctx, database.GetWorkspaceCostInsightsParams{
+		StartTime: threeHoursAgo,
+		EndTime:   dbtime.Now(),
+	})
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, tpl.ID, rows[0].TemplateID)
+	require.Equal(t, user.ID, rows[0].UserID)
+	require.Equal(t, org.ID, rows[0].OrganizationID)
+	// Half an hour in the first bucket and a full hour in the second, at a
+	// cost of 2 per hour.
+	require.EqualValues(t, 90*60, rows[0].UsageSeconds)
+	require.EqualValues(t, 3_000_000, rows[0].CostMicros)
+}
@@ -56,7 +56,8 @@ CREATE TYPE crypto_key_feature AS ENUM (
     'workspace_apps_token',
     'workspace_apps_api_key',
     'oidc_convert',
-    'tailnet_resume'
+    'tailnet_resume',
+    'audit_log_checkpoint'
 );
 
 CREATE TYPE display_app AS ENUM (
@@ -114,13 +115,27 @@ CREATE TYPE notification_message_status AS ENUM (
     'permanent_failure',
     'temporary_failure',
     'unknown',
-    'inhibited'
+    'inhibited',
+    'batched'
+);
+
+CREATE TYPE notification_digest_interval AS ENUM (
+    'hourly',
+    'daily'
 );
 
 CREATE TYPE notification_method AS ENUM (
     'smtp',
     'webhook',
-    'inbox'
+    'inbox',
+    'slack',
+    'matrix',
+    'teams'
+);
+
+CREATE TYPE notification_rule_source AS ENUM (
+    'audit_log',
+    'workspace_build'
 );
 
 CREATE TYPE notification_template_kind AS ENUM (
@@ -184,6 +199,16 @@ CREATE TYPE provisioner_daemon_status AS ENUM (
 
 COMMENT ON TYPE provisioner_daemon_status IS 'The status of a provisioner daemon.';
 
+CREATE TYPE provisioner_job_priority AS ENUM (
+    'drift_check',
+    'template_import',
+    'prebuild',
+    'scheduled',
+    'interactive'
+);
+
+COMMENT ON TYPE provisioner_job_priority IS 'The priority class of a provisioner job. Pending jobs of a higher priority are acquired first. Values are ordered from the lowest to the highest priority.';
+
 CREATE TYPE provisioner_job_status AS ENUM (
     'pending',
     'running',
@@ -206,7 +231,8 @@ CREATE TYPE provisioner_job_timing_stage AS ENUM (
 CREATE TYPE provisioner_job_type AS ENUM (
     'template_version_import',
     'workspace_build',
-    'template_version_dry_run'
+    'template_version_dry_run',
+    'workspace_drift_check'
 );
 
 CREATE TYPE provisioner_storage_method AS ENUM (
@@ -215,7 +241,9 @@ CREATE TYPE provisioner_storage_method AS ENUM (
 
 CREATE TYPE provisioner_type AS ENUM (
     'echo',
-    'terraform'
+    'terraform',
+    'tofu',
+    'external'
 );
 
 CREATE TYPE resource_type AS ENUM (
@@ -329,6 +357,16 @@ CREATE TYPE workspace_app_status_state AS ENUM (
     'idle'
 );
 
+CREATE TYPE workspace_snapshot_storage AS ENUM (
+    'database',
+    's3'
+);
+
+CREATE TYPE workspace_snapshot_trigger AS ENUM (
+    'stop',
+    'schedule'
+);
+
 CREATE TYPE workspace_transition AS ENUM (
     'start',
     'stop',
@@ -805,6 +843,73 @@ CREATE TABLE api_keys (
 
 COMMENT ON COLUMN api_keys.hashed_secret IS 'hashed_secret contains a SHA256 hash of the key secret. This is considered a secret and MUST NOT be returned from the API as it is used for API key encryption in app proxying code.';
 
+CREATE TABLE audit_log_archive_entries (
+    audit_log_id uuid NOT NULL,
+    archive_id uuid NOT NULL
+);
+
+COMMENT ON TABLE audit_log_archive_entries IS 'The archive of each archived audit log, so the hash chain tells archived audit logs apart from deleted ones.';
+
+CREATE TABLE audit_log_archives (
+    id uuid NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    file_name text NOT NULL,
+    file_id uuid NOT NULL,
+    oldest_time timestamp with time zone NOT NULL,
+    newest_time timestamp with time zone NOT NULL,
+    audit_log_count integer NOT NULL,
+    sha256 bytea NOT NULL,
+    restored_at timestamp with time zone
+);
+
+COMMENT ON TABLE audit_log_archives IS 'Compressed files expired audit logs were archived to before they were deleted.';
+
+COMMENT ON COLUMN audit_log_archives.file_id IS 'The archive file in the files table, so it can be restored from any replica.';
+
+COMMENT ON COLUMN audit_log_archives.sha256 IS 'SHA-256 of the archive file, verified when it is restored.';
+
+COMMENT ON COLUMN audit_log_archives.restored_at IS 'When the audit logs of the archive were restored. Restored audit logs are deleted again after a while, without being archived again.';
+
+CREATE TABLE audit_log_checkpoints (
+    sequence bigint NOT NULL,
+    hash bytea NOT NULL,
+    key_sequence integer NOT NULL,
+    signature bytea NOT NULL,
+    created_at timestamp with time zone NOT NULL
+);
+
+COMMENT ON TABLE audit_log_checkpoints IS 'Signed heads of the audit log hash chain. Rewriting the chain before a checkpoint requires the signing key.';
+
+COMMENT ON COLUMN audit_log_checkpoints.key_sequence IS 'Sequence of the audit_log_checkpoint crypto key the signature was made with.';
+
+CREATE TABLE audit_log_export_cursors (
+    sink text NOT NULL,
+    last_xact_id bigint NOT NULL,
+    last_id uuid NOT NULL,
+    updated_at timestamp with time zone NOT NULL,
+    leased_by uuid,
+    lease_expires_at timestamp with time zone
+);
+
+COMMENT ON TABLE audit_log_export_cursors IS 'Position of each audit log streaming sink. Audit logs ordered after (last_xact_id, last_id) have not been delivered to the sink yet.';
+
+COMMENT ON COLUMN audit_log_export_cursors.leased_by IS 'Replica which delivers to the sink until the lease expires.';
+
+CREATE TABLE audit_log_hashes (
+    sequence bigint NOT NULL,
+    audit_log_id uuid NOT NULL,
+    audit_log_time timestamp with time zone NOT NULL,
+    audit_log_xact_id bigint NOT NULL,
+    previous_hash bytea NOT NULL,
+    hash bytea NOT NULL
+);
+
+COMMENT ON TABLE audit_log_hashes IS 'Hash chain over the audit logs in the order of the transactions which inserted them. Audit logs are not referenced with a foreign key, so their deletion is detected.';
+
+COMMENT ON COLUMN audit_log_hashes.audit_log_xact_id IS 'ID of the transaction which inserted the audit log. The chain is extended with the audit logs ordered after the last entry.';
+
+COMMENT ON COLUMN audit_log_hashes.hash IS 'SHA-256 of the previous hash and the audit log.';
+
 CREATE TABLE audit_logs (
     id uuid NOT NULL,
     "time" timestamp with time zone NOT NULL,
@@ -820,9 +925,12 @@ CREATE TABLE audit_logs (
     status_code integer NOT NULL,
     additional_fields jsonb NOT NULL,
     request_id uuid NOT NULL,
-    resource_icon text NOT NULL
+    resource_icon text NOT NULL,
+    xact_id bigint DEFAULT ((pg_current_xact_id())::text)::bigint
 );
 
+COMMENT ON COLUMN audit_logs.xact_id IS 'ID of the transaction which inserted the audit log. NULL for audit logs inserted before streaming was introduced.';
+
 CREATE TABLE crypto_keys (
     feature crypto_key_feature NOT NULL,
     sequence integer NOT NULL,
@@ -1046,6 +1154,16 @@ CREATE SEQUENCE licenses_id_seq
 
 ALTER SEQUENCE licenses_id_seq OWNED BY licenses.id;
 
+CREATE TABLE notification_digest_preferences (
+    user_id uuid NOT NULL,
+    notification_template_id uuid NOT NULL,
+    digest_interval notification_digest_interval NOT NULL,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+COMMENT ON TABLE notification_digest_preferences IS 'Notifications users chose to receive as a periodic digest rather than individually.';
+
 CREATE TABLE notification_messages (
     id uuid NOT NULL,
     notification_template_id uuid NOT NULL,
@@ -1082,6 +1200,39 @@ CREATE TABLE notification_report_generator_logs (
 
 COMMENT ON TABLE notification_report_generator_logs IS 'Log of generated reports for users.';
 
+CREATE TABLE notification_rules (
+    id uuid NOT NULL,
+    organization_id uuid NOT NULL,
+    user_id uuid NOT NULL,
+    name text NOT NULL,
+    source notification_rule_source NOT NULL,
+    query text NOT NULL,
+    method notification_method,
+    threshold integer DEFAULT 1 NOT NULL,
+    window_seconds integer DEFAULT 0 NOT NULL,
+    enabled boolean DEFAULT true NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    updated_at timestamp with time zone NOT NULL,
+    last_evaluated_at timestamp with time zone NOT NULL,
+    last_triggered_at timestamp with time zone,
+    CONSTRAINT notification_rules_threshold_check CHECK ((threshold > 0)),
+    CONSTRAINT notification_rules_window_seconds_check CHECK ((window_seconds >= 0))
+);
+
+COMMENT ON TABLE notification_rules IS 'User-defined notifications sent when audit log entries or workspace builds match a search query.';
+
+COMMENT ON COLUMN notification_rules.user_id IS 'The user who created the rule, notifications are sent to them.';
+
+COMMENT ON COLUMN notification_rules.query IS 'Search query in the syntax of the audit log or workspace build search, depending on the source.';
+
+COMMENT ON COLUMN notification_rules.method IS 'Dispatch method to send notifications with. NULL uses the method of the notification template, or the deployment default.';
+
+COMMENT ON COLUMN notification_rules.threshold IS 'Minimum number of matching events which trigger the rule.';
+
+COMMENT ON COLUMN notification_rules.window_seconds IS 'Period matching events are counted over. Once triggered, the rule is not triggered again until the window passed. 0 counts the events since the previous evaluation.';
+
+COMMENT ON COLUMN notification_rules.last_evaluated_at IS 'Events up to this time have been evaluated.';
+
 CREATE TABLE notification_templates (
     id uuid NOT NULL,
     name text NOT NULL,
@@ -1091,13 +1242,16 @@ CREATE TABLE notification_templates (
     "group" text,
     method notification_method,
     kind notification_template_kind DEFAULT 'system'::notification_template_kind NOT NULL,
-    enabled_by_default boolean DEFAULT true NOT NULL
+    enabled_by_default boolean DEFAULT true NOT NULL,
+    critical boolean DEFAULT false NOT NULL
 );
 
 COMMENT ON TABLE notification_templates IS 'Templates from which to create notification messages.';
 
 COMMENT ON COLUMN notification_templates.method IS 'NULL defers to the deployment-level method';
 
+COMMENT ON COLUMN notification_templates.critical IS 'Critical notifications are delivered immediately, they are neither batched into digests nor deferred by quiet hours.';
+
 CREATE TABLE oauth2_provider_app_codes (
     id uuid NOT NULL,
     created_at timestamp with time zone NOT NULL,
@@ -1169,6 +1323,19 @@ COMMENT ON COLUMN oauth2_provider_apps.client_type IS 'OAuth2 client type: confi
 
 COMMENT ON COLUMN oauth2_provider_apps.dynamically_registered IS 'Whether this app was created via dynamic client registration';
 
+CREATE TABLE organization_holidays (
+    id uuid NOT NULL,
+    organization_id uuid NOT NULL,
+    calendar text NOT NULL,
+    date date NOT NULL,
+    name text NOT NULL,
+    created_at timestamp with time zone NOT NULL
+);
+
+COMMENT ON TABLE organization_holidays IS 'Days on which workspaces of an organization are not autostarted, imported from iCalendar files.';
+
+COMMENT ON COLUMN organization_holidays.calendar IS 'Name of the calendar the holiday was imported from. Importing a calendar again replaces its holidays.';
+
 CREATE TABLE organizations (
     id uuid NOT NULL,
     name text NOT NULL,
@@ -1213,6 +1380,48 @@ CREATE TABLE parameter_values (
     destination_scheme parameter_destination_scheme NOT NULL
 );
 
+CREATE TABLE plan_policies (
+    id uuid NOT NULL,
+    organization_id uuid NOT NULL,
+    name text NOT NULL,
+    description text DEFAULT ''::text NOT NULL,
+    module text NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    updated_at timestamp with time zone NOT NULL
+);
+
+COMMENT ON TABLE plan_policies IS 'Rego policies evaluated against the Terraform plans of workspace builds of an organization before they are applied.';
+
+COMMENT ON COLUMN plan_policies.module IS 'Rego module of the policy. Messages of its deny rules fail the build, messages of its warn rules are written to the build logs.';
+
+CREATE TABLE provider_mirror_archives (
+    organization_id uuid NOT NULL,
+    hostname text NOT NULL,
+    namespace text NOT NULL,
+    type text NOT NULL,
+    version text NOT NULL,
+    platform text NOT NULL,
+    hash text NOT NULL,
+    file_id uuid NOT NULL,
+    created_at timestamp with time zone NOT NULL
+);
+
+COMMENT ON TABLE provider_mirror_archives IS 'Terraform provider packages installed by template imports, served to provisioner daemons by the provider network mirror.';
+
+COMMENT ON COLUMN provider_mirror_archives.organization_id IS 'The organization of the template imports that installed the package. Packages are only served to jobs of the same organization, so daemons of one organization cannot replace the providers of another.';
+
+COMMENT ON COLUMN provider_mirror_archives.hash IS 'The "h1:" package hash of the archive contents, as it appears in dependency lock files.';
+
+COMMENT ON COLUMN provider_mirror_archives.file_id IS 'The zip archive of the package. Files are content-addressed, so packages that are archived identically share a file.';
+
+CREATE TABLE provider_mirror_tokens (
+    job_id uuid NOT NULL,
+    hashed_secret bytea NOT NULL,
+    created_at timestamp with time zone NOT NULL
+);
+
+COMMENT ON TABLE provider_mirror_tokens IS 'Tokens that authenticate a provisioner job to the provider mirror while the job is running.';
+
 CREATE TABLE provisioner_daemons (
     id uuid NOT NULL,
     created_at timestamp with time zone NOT NULL,
@@ -1309,11 +1518,15 @@ CASE
         WHEN (started_at IS NULL) THEN 'pending'::provisioner_job_status
         ELSE 'running'::provisioner_job_status
     END
-END) STORED NOT NULL
+END) STORED NOT NULL,
+    priority provisioner_job_priority DEFAULT 'interactive'::provisioner_job_priority NOT NULL,
+    template_id uuid
 );
 
 COMMENT ON COLUMN provisioner_jobs.job_status IS 'Computed column to track the status of the job.';
 
+COMMENT ON COLUMN provisioner_jobs.template_id IS 'The template the job belongs to, if any. Used to share provisioner daemons fairly between templates.';
+
 CREATE TABLE provisioner_keys (
     id uuid NOT NULL,
     created_at timestamp with time zone NOT NULL,
@@ -1393,6 +1606,39 @@ CREATE TABLE telemetry_items (
     updated_at timestamp with time zone DEFAULT now() NOT NULL
 );
 
+CREATE TABLE template_autostop_windows (
+    template_id uuid NOT NULL,
+    weekday smallint NOT NULL,
+    stop_minute integer NOT NULL,
+    CONSTRAINT template_autostop_windows_stop_minute_check CHECK (((stop_minute >= 0) AND (stop_minute < 1440))),
+    CONSTRAINT template_autostop_windows_weekday_check CHECK (((weekday >= 0) AND (weekday <= 6)))
+);
+
+COMMENT ON TABLE template_autostop_windows IS 'The time of day workspaces of a template are stopped by, per day of the week.';
+
+COMMENT ON COLUMN template_autostop_windows.weekday IS 'Day of the week, where 0 is Sunday.';
+
+COMMENT ON COLUMN template_autostop_windows.stop_minute IS 'Minutes after midnight workspaces are stopped at, in the timezone of the quiet hours schedule of the workspace owner.';
+
+CREATE TABLE template_port_share_rules (
+    id uuid NOT NULL,
+    template_id uuid NOT NULL,
+    port_start integer NOT NULL,
+    port_end integer NOT NULL,
+    share_level app_sharing_level NOT NULL,
+    protocol port_share_protocol DEFAULT 'http'::port_share_protocol NOT NULL,
+    label text DEFAULT ''::text NOT NULL,
+    CONSTRAINT template_port_share_rules_port_range_check CHECK (((port_start >= 1) AND (port_end >= port_start) AND (port_end <= 65535)))
+);
+
+COMMENT ON TABLE template_port_share_rules IS 'Rules that automatically share listening ports of workspaces created from a template.';
+
+COMMENT ON COLUMN template_port_share_rules.port_start IS 'First port, inclusive, of the range matched by the rule.';
+
+COMMENT ON COLUMN template_port_share_rules.port_end IS 'Last port, inclusive, of the range matched by the rule.';
+
+COMMENT ON COLUMN template_port_share_rules.label IS 'Optional human readable label for ports matched by the rule.';
+
 CREATE TABLE template_usage_stats (
     start_time timestamp with time zone NOT NULL,
     end_time timestamp with time zone NOT NULL,
@@ -1639,7 +1885,9 @@ CREATE TABLE templates (
     deprecated text DEFAULT ''::text NOT NULL,
     activity_bump bigint DEFAULT '3600000000000'::bigint NOT NULL,
     max_port_sharing_level app_sharing_level DEFAULT 'owner'::app_sharing_level NOT NULL,
-    use_classic_parameter_flow boolean DEFAULT true NOT NULL
+    use_classic_parameter_flow boolean DEFAULT true NOT NULL,
+    drift_check_interval_ms bigint DEFAULT 0 NOT NULL,
+    provisioner_version text DEFAULT ''::text NOT NULL
 );
 
 COMMENT ON COLUMN templates.default_ttl IS 'The default duration for autostop for workspaces created from this template.';
@@ -1662,6 +1910,10 @@ COMMENT ON COLUMN templates.deprecated IS 'If set to a non empty string, the tem
 
 COMMENT ON COLUMN templates.use_classic_parameter_flow IS 'Determines whether to default to the dynamic parameter creation flow for this template or continue using the legacy classic parameter creation flow.This is a template wide setting, the template admin can revert to the classic flow if there are any issues. An escape hatch is required, as workspace creation is a core workflow and cannot break. This column will be removed when the dynamic parameter creation flow is stable.';
 
+COMMENT ON COLUMN templates.drift_check_interval_ms IS 'How often running workspaces of the template are checked for changes made outside of Coder. Zero disables drift checks.';
+
+COMMENT ON COLUMN templates.provisioner_version IS 'The version of the provisioner binary that jobs of the template must run with. Empty uses the default version of the provisioner daemon.';
+
 CREATE VIEW template_with_names AS
  SELECT templates.id,
     templates.created_at,
@@ -1692,6 +1944,8 @@ CREATE VIEW template_with_names AS
     templates.activity_bump,
     templates.max_port_sharing_level,
     templates.use_classic_parameter_flow,
+    templates.drift_check_interval_ms,
+    templates.provisioner_version,
     COALESCE(visible_users.avatar_url, ''::text) AS created_by_avatar_url,
     COALESCE(visible_users.username, ''::text) AS created_by_username,
     COALESCE(visible_users.name, ''::text) AS created_by_name,
@@ -1704,6 +1958,39 @@ CREATE VIEW template_with_names AS
 
 COMMENT ON VIEW template_with_names IS 'Joins in the display name information such as username, avatar, and organization name.';
 
+CREATE TABLE user_autostop_windows (
+    user_id uuid NOT NULL,
+    weekday smallint NOT NULL,
+    stop_minute integer NOT NULL,
+    CONSTRAINT user_autostop_windows_stop_minute_check CHECK (((stop_minute >= 0) AND (stop_minute < 1440))),
+    CONSTRAINT user_autostop_windows_weekday_check CHECK (((weekday >= 0) AND (weekday <= 6)))
+);
+
+COMMENT ON TABLE user_autostop_windows IS 'The time of day workspaces of a user are stopped by, per day of the week. Replaces the windows of templates which allow users to set their own autostop.';
+
+COMMENT ON COLUMN user_autostop_windows.weekday IS 'Day of the week, where 0 is Sunday.';
+
+COMMENT ON COLUMN user_autostop_windows.stop_minute IS 'Minutes after midnight workspaces are stopped at, in the timezone of the quiet hours schedule of the user.';
+
+CREATE TABLE user_chat_identities (
+    user_id uuid NOT NULL,
+    method notification_method NOT NULL,
+    identity text NOT NULL,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    verified_at timestamp with time zone,
+    verification_code_hash bytea,
+    verification_expires_at timestamp with time zone
+);
+
+COMMENT ON TABLE user_chat_identities IS 'Chat accounts users linked to receive notifications as direct messages.';
+
+COMMENT ON COLUMN user_chat_identities.identity IS 'The Slack member ID, Matrix user ID or Microsoft Teams user principal name of the user.';
+
+COMMENT ON COLUMN user_chat_identities.verified_at IS 'When the user proved they own the identity. Notifications are only delivered to verified identities.';
+
+COMMENT ON COLUMN user_chat_identities.verification_code_hash IS 'SHA-256 hash of the one-time code sent to the identity, until it is verified.';
+
 CREATE TABLE user_configs (
     user_id uuid NOT NULL,
     key character varying(256) NOT NULL,
@@ -1736,6 +2023,27 @@ COMMENT ON COLUMN user_links.oauth_refresh_token_key_id IS 'The ID of the key us
 
 COMMENT ON COLUMN user_links.claims IS 'Claims from the IDP for the linked user. Includes both id_token and userinfo claims. ';
 
+CREATE TABLE user_notification_schedules (
+    user_id uuid NOT NULL,
+    timezone text NOT NULL,
+    quiet_hours_start smallint,
+    quiet_hours_end smallint,
+    daily_digest_time smallint DEFAULT 540 NOT NULL,
+    updated_at timestamp with time zone NOT NULL,
+    CONSTRAINT user_notification_schedules_daily_digest_time_check CHECK (((daily_digest_time >= 0) AND (daily_digest_time <= 1439))),
+    CONSTRAINT user_notification_schedules_quiet_hours_check CHECK ((((quiet_hours_start IS NULL) AND (quiet_hours_end IS NULL)) OR (((quiet_hours_start >= 0) AND (quiet_hours_start <= 1439)) AND ((quiet_hours_end >= 0) AND (quiet_hours_end <= 1439)) AND (quiet_hours_start <> quiet_hours_end))))
+);
+
+COMMENT ON TABLE user_notification_schedules IS 'When users want to receive notifications, in their timezone.';
+
+COMMENT ON COLUMN user_notification_schedules.timezone IS 'IANA timezone the times are in.';
+
+COMMENT ON COLUMN user_notification_schedules.quiet_hours_start IS 'Minute of the day quiet hours start at, non-critical notifications are deferred until they end. NULL disables quiet hours.';
+
+COMMENT ON COLUMN user_notification_schedules.quiet_hours_end IS 'Minute of the day quiet hours end at, it is before the start when quiet hours span midnight.';
+
+COMMENT ON COLUMN user_notification_schedules.daily_digest_time IS 'Minute of the day daily digests are delivered at.';
+
 CREATE TABLE user_status_changes (
     id uuid DEFAULT gen_random_uuid() NOT NULL,
     user_id uuid NOT NULL,
@@ -1824,9 +2132,15 @@ CREATE TABLE workspace_agent_port_share (
     agent_name text NOT NULL,
     port integer NOT NULL,
     share_level app_sharing_level NOT NULL,
-    protocol port_share_protocol DEFAULT 'http'::port_share_protocol NOT NULL
+    protocol port_share_protocol DEFAULT 'http'::port_share_protocol NOT NULL,
+    auto_shared boolean DEFAULT false NOT NULL,
+    label text DEFAULT ''::text NOT NULL
 );
 
+COMMENT ON COLUMN workspace_agent_port_share.auto_shared IS 'Whether the port share was created automatically by a template port share rule.';
+
+COMMENT ON COLUMN workspace_agent_port_share.label IS 'Label of the template port share rule that created the port share, if any.';
+
 CREATE TABLE workspace_agent_script_timings (
     script_id uuid NOT NULL,
     started_at timestamp with time zone NOT NULL,
@@ -2081,6 +2395,24 @@ COMMENT ON COLUMN workspace_build_parameters.name IS 'Parameter name';
 
 COMMENT ON COLUMN workspace_build_parameters.value IS 'Parameter value';
 
+CREATE TABLE workspace_build_states (
+    workspace_build_id uuid NOT NULL,
+    workspace_id uuid NOT NULL,
+    state bytea NOT NULL,
+    state_key_id text,
+    hash text NOT NULL,
+    size bigint NOT NULL,
+    created_at timestamp with time zone NOT NULL
+);
+
+COMMENT ON TABLE workspace_build_states IS 'The Terraform state each workspace build ended with, kept to browse, diff and roll back to earlier states.';
+
+COMMENT ON COLUMN workspace_build_states.state_key_id IS 'The ID of the key used to encrypt the state. If this is NULL, the state is not encrypted';
+
+COMMENT ON COLUMN workspace_build_states.hash IS 'The hex encoded SHA256 hash of the unencrypted state, to compare states without decrypting them.';
+
+COMMENT ON COLUMN workspace_build_states.size IS 'The size of the unencrypted state in bytes.';
+
 CREATE TABLE workspace_builds (
     id uuid NOT NULL,
     created_at timestamp with time zone NOT NULL,
@@ -2175,6 +2507,38 @@ CREATE VIEW workspace_latest_builds AS
   WHERE (workspaces.deleted = false)
   ORDER BY workspaces.id;
 
+CREATE TABLE workspace_cost_usage (
+    start_time timestamp with time zone NOT NULL,
+    workspace_id uuid NOT NULL,
+    template_id uuid NOT NULL,
+    user_id uuid NOT NULL,
+    organization_id uuid NOT NULL,
+    usage_seconds integer NOT NULL,
+    cost_micros bigint NOT NULL
+);
+
+COMMENT ON TABLE workspace_cost_usage IS 'Records the uptime and cost of workspaces in hourly buckets, rolled up from workspace builds.';
+
+COMMENT ON COLUMN workspace_cost_usage.start_time IS 'Start time of the hour of usage.';
+
+COMMENT ON COLUMN workspace_cost_usage.usage_seconds IS 'Seconds the workspace was running during the hour.';
+
+COMMENT ON COLUMN workspace_cost_usage.cost_micros IS 'Cost of the running resources of the workspace during the hour, in millionths of the currency unit.';
+
+CREATE TABLE workspace_drift_checks (
+    id uuid NOT NULL,
+    workspace_id uuid NOT NULL,
+    workspace_build_id uuid NOT NULL,
+    job_id uuid NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    completed_at timestamp with time zone,
+    drifted_resources jsonb
+);
+
+COMMENT ON TABLE workspace_drift_checks IS 'Plans of running workspace builds, to find changes made to their resources outside of Coder.';
+
+COMMENT ON COLUMN workspace_drift_checks.drifted_resources IS 'The resources which changed outside of Coder, as reported by Terraform. NULL until the check completes.';
+
 CREATE TABLE workspace_modules (
     id uuid NOT NULL,
     job_id uuid NOT NULL,
@@ -2207,9 +2571,12 @@ CREATE TABLE workspace_resources (
     icon character varying(256) DEFAULT ''::character varying NOT NULL,
     instance_type character varying(256),
     daily_cost integer DEFAULT 0 NOT NULL,
-    module_path text
+    module_path text,
+    hourly_cost_micros bigint DEFAULT 0 NOT NULL
 );
 
+COMMENT ON COLUMN workspace_resources.hourly_cost_micros IS 'Cost per hour of the resource while the workspace is running, in millionths of the currency unit. Parsed from the hourly_cost metadata item of the resource.';
+
 CREATE VIEW workspace_prebuilds AS
  WITH all_prebuilds AS (
          SELECT w.id,
@@ -2306,6 +2673,51 @@ CREATE SEQUENCE workspace_resource_metadata_id_seq
 
 ALTER SEQUENCE workspace_resource_metadata_id_seq OWNED BY workspace_resource_metadata.id;
 
+CREATE TABLE workspace_snapshot_restores (
+    workspace_id uuid NOT NULL,
+    snapshot_id uuid NOT NULL,
+    after_build_number integer NOT NULL,
+    requested_by uuid NOT NULL,
+    requested_at timestamp with time zone NOT NULL
+);
+
+COMMENT ON TABLE workspace_snapshot_restores IS 'Pending snapshot restores. The agent of the first build after after_build_number restores the snapshot on startup.';
+
+CREATE TABLE workspace_snapshots (
+    id uuid NOT NULL,
+    workspace_id uuid NOT NULL,
+    agent_name text NOT NULL,
+    build_number integer NOT NULL,
+    parent_id uuid,
+    trigger workspace_snapshot_trigger NOT NULL,
+    storage workspace_snapshot_storage NOT NULL,
+    object_key text NOT NULL,
+    size_bytes bigint NOT NULL,
+    file_count integer NOT NULL,
+    paths text[] NOT NULL,
+    created_at timestamp with time zone NOT NULL
+);
+
+COMMENT ON TABLE workspace_snapshots IS 'Compressed archives of directories in a workspace, uploaded by the workspace agent.';
+
+COMMENT ON COLUMN workspace_snapshots.parent_id IS 'The snapshot this snapshot is incremental to. Restoring requires every snapshot in the chain. NULL for full snapshots.';
+
+COMMENT ON COLUMN workspace_snapshots.object_key IS 'The file ID for database storage or the object key for S3 storage.';
+
+COMMENT ON COLUMN workspace_snapshots.file_count IS 'The number of files in the workspace at the time of the snapshot, including files unchanged since the parent snapshot.';
+
+CREATE TABLE workspace_update_previews (
+    job_id uuid NOT NULL,
+    workspace_id uuid NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    completed_at timestamp with time zone,
+    resource_changes jsonb
+);
+
+COMMENT ON TABLE workspace_update_previews IS 'Template version dry-runs planned against the state of a workspace, to preview the changes an update of the workspace makes to its resources.';
+
+COMMENT ON COLUMN workspace_update_previews.resource_changes IS 'The resources the update would create, update, replace or delete. NULL until the dry-run completes.';
+
 CREATE VIEW workspaces_expanded AS
  SELECT workspaces.id,
     workspaces.created_at,
@@ -2359,6 +2771,21 @@ ALTER TABLE ONLY workspace_agent_stats
 ALTER TABLE ONLY api_keys
     ADD CONSTRAINT api_keys_pkey PRIMARY KEY (id);
 
+ALTER TABLE ONLY audit_log_archive_entries
+    ADD CONSTRAINT audit_log_archive_entries_pkey PRIMARY KEY (audit_log_id);
+
+ALTER TABLE ONLY audit_log_archives
+    ADD CONSTRAINT audit_log_archives_pkey PRIMARY KEY (id);
+
+ALTER TABLE ONLY audit_log_checkpoints
+    ADD CONSTRAINT audit_log_checkpoints_pkey PRIMARY KEY (sequence);
+
+ALTER TABLE ONLY audit_log_export_cursors
+    ADD CONSTRAINT audit_log_export_cursors_pkey PRIMARY KEY (sink);
+
+ALTER TABLE ONLY audit_log_hashes
+    ADD CONSTRAINT audit_log_hashes_pkey PRIMARY KEY (sequence);
+
 ALTER TABLE ONLY audit_logs
     ADD CONSTRAINT audit_logs_pkey PRIMARY KEY (id);
 
@@ -2410,6 +2837,9 @@ ALTER TABLE ONLY licenses
 ALTER TABLE ONLY licenses
     ADD CONSTRAINT licenses_pkey PRIMARY KEY (id);
 
+ALTER TABLE ONLY notification_digest_preferences
+    ADD CONSTRAINT notification_digest_preferences_pkey PRIMARY KEY (user_id, notification_template_id);
+
 ALTER TABLE ONLY notification_messages
     ADD CONSTRAINT notification_messages_pkey PRIMARY KEY (id);
 
@@ -2419,6 +2849,12 @@ ALTER TABLE ONLY notification_preferences
 ALTER TABLE ONLY notification_report_generator_logs
     ADD CONSTRAINT notification_report_generator_logs_pkey PRIMARY KEY (notification_template_id);
 
+ALTER TABLE ONLY notification_rules
+    ADD CONSTRAINT notification_rules_organization_id_name_key UNIQUE (organization_id, name);
+
+ALTER TABLE ONLY notification_rules
+    ADD CONSTRAINT notification_rules_pkey PRIMARY KEY (id);
+
 ALTER TABLE ONLY notification_templates
     ADD CONSTRAINT notification_templates_name_key UNIQUE (name);
 
@@ -2449,6 +2885,9 @@ ALTER TABLE ONLY oauth2_provider_apps
 ALTER TABLE ONLY oauth2_provider_apps
     ADD CONSTRAINT oauth2_provider_apps_pkey PRIMARY KEY (id);
 
+ALTER TABLE ONLY organization_holidays
+    ADD CONSTRAINT organization_holidays_pkey PRIMARY KEY (id);
+
 ALTER TABLE ONLY organization_members
     ADD CONSTRAINT organization_members_pkey PRIMARY KEY (organization_id, user_id);
 
@@ -2467,6 +2906,18 @@ ALTER TABLE ONLY parameter_values
 ALTER TABLE ONLY parameter_values
     ADD CONSTRAINT parameter_values_scope_id_name_key UNIQUE (scope_id, name);
 
+ALTER TABLE ONLY plan_policies
+    ADD CONSTRAINT plan_policies_organization_id_name_key UNIQUE (organization_id, name);
+
+ALTER TABLE ONLY plan_policies
+    ADD CONSTRAINT plan_policies_pkey PRIMARY KEY (id);
+
+ALTER TABLE ONLY provider_mirror_archives
+    ADD CONSTRAINT provider_mirror_archives_pkey PRIMARY KEY (organization_id, hostname, namespace, type, version, platform);
+
+ALTER TABLE ONLY provider_mirror_tokens
+    ADD CONSTRAINT provider_mirror_tokens_pkey PRIMARY KEY (job_id);
+
 ALTER TABLE ONLY provisioner_daemons
     ADD CONSTRAINT provisioner_daemons_pkey PRIMARY KEY (id);
 
@@ -2503,6 +2954,12 @@ ALTER TABLE ONLY tailnet_tunnels
 ALTER TABLE ONLY telemetry_items
     ADD CONSTRAINT telemetry_items_pkey PRIMARY KEY (key);
 
+ALTER TABLE ONLY template_autostop_windows
+    ADD CONSTRAINT template_autostop_windows_pkey PRIMARY KEY (template_id, weekday);
+
+ALTER TABLE ONLY template_port_share_rules
+    ADD CONSTRAINT template_port_share_rules_pkey PRIMARY KEY (id);
+
 ALTER TABLE ONLY template_usage_stats
     ADD CONSTRAINT template_usage_stats_pkey PRIMARY KEY (start_time, template_id, user_id);
 
@@ -2536,6 +2993,12 @@ ALTER TABLE ONLY template_versions
 ALTER TABLE ONLY templates
     ADD CONSTRAINT templates_pkey PRIMARY KEY (id);
 
+ALTER TABLE ONLY user_autostop_windows
+    ADD CONSTRAINT user_autostop_windows_pkey PRIMARY KEY (user_id, weekday);
+
+ALTER TABLE ONLY user_chat_identities
+    ADD CONSTRAINT user_chat_identities_pkey PRIMARY KEY (user_id, method);
+
 ALTER TABLE ONLY user_configs
     ADD CONSTRAINT user_configs_pkey PRIMARY KEY (user_id, key);
 
@@ -2545,6 +3008,9 @@ ALTER TABLE ONLY user_deleted
 ALTER TABLE ONLY user_links
     ADD CONSTRAINT user_links_pkey PRIMARY KEY (user_id, login_type);
 
+ALTER TABLE ONLY user_notification_schedules
+    ADD CONSTRAINT user_notification_schedules_pkey PRIMARY KEY (user_id);
+
 ALTER TABLE ONLY user_status_changes
     ADD CONSTRAINT user_status_changes_pkey PRIMARY KEY (id);
 
@@ -2608,6 +3074,9 @@ ALTER TABLE ONLY workspace_apps
 ALTER TABLE ONLY workspace_build_parameters
     ADD CONSTRAINT workspace_build_parameters_workspace_build_id_name_key UNIQUE (workspace_build_id, name);
 
+ALTER TABLE ONLY workspace_build_states
+    ADD CONSTRAINT workspace_build_states_pkey PRIMARY KEY (workspace_build_id);
+
 ALTER TABLE ONLY workspace_builds
     ADD CONSTRAINT workspace_builds_job_id_key UNIQUE (job_id);
 
@@ -2617,6 +3086,15 @@ ALTER TABLE ONLY workspace_builds
 ALTER TABLE ONLY workspace_builds
     ADD CONSTRAINT workspace_builds_workspace_id_build_number_key UNIQUE (workspace_id, build_number);
 
+ALTER TABLE ONLY workspace_cost_usage
+    ADD CONSTRAINT workspace_cost_usage_pkey PRIMARY KEY (start_time, workspace_id);
+
+ALTER TABLE ONLY workspace_drift_checks
+    ADD CONSTRAINT workspace_drift_checks_job_id_key UNIQUE (job_id);
+
+ALTER TABLE ONLY workspace_drift_checks
+    ADD CONSTRAINT workspace_drift_checks_pkey PRIMARY KEY (id);
+
 ALTER TABLE ONLY workspace_proxies
     ADD CONSTRAINT workspace_proxies_pkey PRIMARY KEY (id);
 
@@ -2632,6 +3110,15 @@ ALTER TABLE ONLY workspace_resource_metadata
 ALTER TABLE ONLY workspace_resources
     ADD CONSTRAINT workspace_resources_pkey PRIMARY KEY (id);
 
+ALTER TABLE ONLY workspace_snapshot_restores
+    ADD CONSTRAINT workspace_snapshot_restores_pkey PRIMARY KEY (workspace_id);
+
+ALTER TABLE ONLY workspace_snapshots
+    ADD CONSTRAINT workspace_snapshots_pkey PRIMARY KEY (id);
+
+ALTER TABLE ONLY workspace_update_previews
+    ADD CONSTRAINT workspace_update_previews_pkey PRIMARY KEY (job_id);
+
 ALTER TABLE ONLY workspaces
     ADD CONSTRAINT workspaces_pkey PRIMARY KEY (id);
 
@@ -2643,6 +3130,14 @@ CREATE UNIQUE INDEX idx_api_key_name ON api_keys USING btree (user_id, token_nam
 
 CREATE INDEX idx_api_keys_user ON api_keys USING btree (user_id);
 
+CREATE INDEX idx_audit_log_archive_entries_archive_id ON audit_log_archive_entries USING btree (archive_id);
+
+CREATE UNIQUE INDEX idx_audit_log_archives_file_name ON audit_log_archives USING btree (file_name);
+
+CREATE UNIQUE INDEX idx_audit_log_hashes_audit_log_id ON audit_log_hashes USING btree (audit_log_id);
+
+CREATE INDEX idx_audit_log_hashes_audit_log_time ON audit_log_hashes USING btree (audit_log_time);
+
 CREATE INDEX idx_audit_log_organization_id ON audit_logs USING btree (organization_id);
 
 CREATE INDEX idx_audit_log_resource_id ON audit_logs USING btree (resource_id);
@@ -2651,6 +3146,8 @@ CREATE INDEX idx_audit_log_user_id ON audit_logs USING btree (user_id);
 
 CREATE INDEX idx_audit_logs_time_desc ON audit_logs USING btree ("time" DESC);
 
+CREATE INDEX idx_audit_logs_xact_id ON audit_logs USING btree (xact_id, id) WHERE (xact_id IS NOT NULL);
+
 CREATE INDEX idx_custom_roles_id ON custom_roles USING btree (id);
 
 CREATE UNIQUE INDEX idx_custom_roles_name_lower ON custom_roles USING btree (lower(name));
@@ -2661,6 +3158,8 @@ CREATE INDEX idx_inbox_notifications_user_id_template_id_targets ON inbox_notifi
 
 CREATE INDEX idx_notification_messages_status ON notification_messages USING btree (status);
 
+CREATE INDEX idx_organization_holidays_organization_id_date ON organization_holidays USING btree (organization_id, date);
+
 CREATE INDEX idx_organization_member_organization_id_uuid ON organization_members USING btree (organization_id);
 
 CREATE INDEX idx_organization_member_user_id_uuid ON organization_members USING btree (user_id);
@@ -2701,14 +3200,26 @@ CREATE INDEX idx_workspace_app_statuses_workspace_id_created_at ON workspace_app
 
 CREATE UNIQUE INDEX notification_messages_dedupe_hash_idx ON notification_messages USING btree (dedupe_hash);
 
+CREATE INDEX notification_rules_enabled_idx ON notification_rules USING btree (enabled) WHERE enabled;
+
 CREATE UNIQUE INDEX organizations_single_default_org ON organizations USING btree (is_default) WHERE (is_default = true);
 
+CREATE INDEX provider_mirror_archives_file_id_idx ON provider_mirror_archives USING btree (file_id);
+
 CREATE INDEX provisioner_job_logs_id_job_id_idx ON provisioner_job_logs USING btree (job_id, id);
 
+CREATE INDEX provisioner_jobs_completed_at_idx ON provisioner_jobs USING btree (completed_at) WHERE (completed_at IS NOT NULL);
+
+CREATE INDEX provisioner_jobs_running_initiator_id_idx ON provisioner_jobs USING btree (initiator_id) WHERE ((started_at IS NOT NULL) AND (completed_at IS NULL));
+
+CREATE INDEX provisioner_jobs_running_template_id_idx ON provisioner_jobs USING btree (template_id) WHERE ((started_at IS NOT NULL) AND (completed_at IS NULL));
+
 CREATE INDEX provisioner_jobs_started_at_idx ON provisioner_jobs USING btree (started_at) WHERE (started_at IS NULL);
 
 CREATE UNIQUE INDEX provisioner_keys_organization_id_name_idx ON provisioner_keys USING btree (organization_id, lower((name)::text));
 
+CREATE INDEX template_port_share_rules_template_id_idx ON template_port_share_rules USING btree (template_id);
+
 CREATE INDEX template_usage_stats_start_time_idx ON template_usage_stats USING btree (start_time DESC);
 
 COMMENT ON INDEX template_usage_stats_start_time_idx IS 'Index for querying MAX(start_time).';
@@ -2749,6 +3260,14 @@ COMMENT ON INDEX workspace_app_audit_sessions_unique_index IS 'Unique index to e
 
 CREATE INDEX workspace_app_stats_workspace_id_idx ON workspace_app_stats USING btree (workspace_id);
 
+CREATE INDEX workspace_build_states_workspace_id_created_at_idx ON workspace_build_states USING btree (workspace_id, created_at DESC);
+
+CREATE INDEX workspace_cost_usage_start_time_idx ON workspace_cost_usage USING btree (start_time DESC);
+
+COMMENT ON INDEX workspace_cost_usage_start_time_idx IS 'Index for querying MAX(start_time).';
+
+CREATE INDEX workspace_drift_checks_workspace_id_created_at_idx ON workspace_drift_checks USING btree (workspace_id, created_at DESC);
+
 CREATE INDEX workspace_modules_created_at_idx ON workspace_modules USING btree (created_at);
 
 CREATE INDEX workspace_next_start_at_idx ON workspaces USING btree (next_start_at) WHERE (deleted = false);
@@ -2757,8 +3276,12 @@ CREATE UNIQUE INDEX workspace_proxies_lower_name_idx ON workspace_proxies USING
 
 CREATE INDEX workspace_resources_job_id_idx ON workspace_resources USING btree (job_id);
 
+CREATE INDEX workspace_snapshots_workspace_id_created_at_idx ON workspace_snapshots USING btree (workspace_id, created_at DESC);
+
 CREATE INDEX workspace_template_id_idx ON workspaces USING btree (template_id) WHERE (deleted = false);
 
+CREATE INDEX workspace_update_previews_workspace_id_idx ON workspace_update_previews USING btree (workspace_id);
+
 CREATE UNIQUE INDEX workspaces_owner_id_lower_idx ON workspaces USING btree (owner_id, lower((name)::text)) WHERE (deleted = false);
 
 CREATE OR REPLACE VIEW provisioner_job_stats AS
@@ -2858,6 +3381,12 @@ forward without requiring a migration to clean up historical data.';
 ALTER TABLE ONLY api_keys
     ADD CONSTRAINT api_keys_user_id_uuid_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
 
+ALTER TABLE ONLY audit_log_archive_entries
+    ADD CONSTRAINT audit_log_archive_entries_archive_id_fkey FOREIGN KEY (archive_id) REFERENCES audit_log_archives(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY audit_log_archives
+    ADD CONSTRAINT audit_log_archives_file_id_fkey FOREIGN KEY (file_id) REFERENCES files(id);
+
 ALTER TABLE ONLY crypto_keys
     ADD CONSTRAINT crypto_keys_secret_key_id_fkey FOREIGN KEY (secret_key_id) REFERENCES dbcrypt_keys(active_key_digest);
 
@@ -2894,6 +3423,12 @@ ALTER TABLE ONLY jfrog_xray_scans
 ALTER TABLE ONLY jfrog_xray_scans
     ADD CONSTRAINT jfrog_xray_scans_workspace_id_fkey FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE;
 
+ALTER TABLE ONLY notification_digest_preferences
+    ADD CONSTRAINT notification_digest_preferences_notification_template_id_fkey FOREIGN KEY (notification_template_id) REFERENCES notification_templates(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY notification_digest_preferences
+    ADD CONSTRAINT notification_digest_preferences_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
 ALTER TABLE ONLY notification_messages
     ADD CONSTRAINT notification_messages_notification_template_id_fkey FOREIGN KEY (notification_template_id) REFERENCES notification_templates(id) ON DELETE CASCADE;
 
@@ -2906,6 +3441,12 @@ ALTER TABLE ONLY notification_preferences
 ALTER TABLE ONLY notification_preferences
     ADD CONSTRAINT notification_preferences_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
 
+ALTER TABLE ONLY notification_rules
+    ADD CONSTRAINT notification_rules_organization_id_fkey FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY notification_rules
+    ADD CONSTRAINT notification_rules_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
 ALTER TABLE ONLY oauth2_provider_app_codes
     ADD CONSTRAINT oauth2_provider_app_codes_app_id_fkey FOREIGN KEY (app_id) REFERENCES oauth2_provider_apps(id) ON DELETE CASCADE;
 
@@ -2921,6 +3462,9 @@ ALTER TABLE ONLY oauth2_provider_app_tokens
 ALTER TABLE ONLY oauth2_provider_app_tokens
     ADD CONSTRAINT oauth2_provider_app_tokens_app_secret_id_fkey FOREIGN KEY (app_secret_id) REFERENCES oauth2_provider_app_secrets(id) ON DELETE CASCADE;
 
+ALTER TABLE ONLY organization_holidays
+    ADD CONSTRAINT organization_holidays_organization_id_fkey FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE;
+
 ALTER TABLE ONLY organization_members
     ADD CONSTRAINT organization_members_organization_id_uuid_fkey FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE;
 
@@ -2930,6 +3474,18 @@ ALTER TABLE ONLY organization_members
 ALTER TABLE ONLY parameter_schemas
     ADD CONSTRAINT parameter_schemas_job_id_fkey FOREIGN KEY (job_id) REFERENCES provisioner_jobs(id) ON DELETE CASCADE;
 
+ALTER TABLE ONLY plan_policies
+    ADD CONSTRAINT plan_policies_organization_id_fkey FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY provider_mirror_archives
+    ADD CONSTRAINT provider_mirror_archives_file_id_fkey FOREIGN KEY (file_id) REFERENCES files(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY provider_mirror_archives
+    ADD CONSTRAINT provider_mirror_archives_organization_id_fkey FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY provider_mirror_tokens
+    ADD CONSTRAINT provider_mirror_tokens_job_id_fkey FOREIGN KEY (job_id) REFERENCES provisioner_jobs(id) ON DELETE CASCADE;
+
 ALTER TABLE ONLY provisioner_daemons
     ADD CONSTRAINT provisioner_daemons_key_id_fkey FOREIGN KEY (key_id) REFERENCES provisioner_keys(id) ON DELETE CASCADE;
 
@@ -2963,6 +3519,12 @@ ALTER TABLE ONLY tailnet_peers
 ALTER TABLE ONLY tailnet_tunnels
     ADD CONSTRAINT tailnet_tunnels_coordinator_id_fkey FOREIGN KEY (coordinator_id) REFERENCES tailnet_coordinators(id) ON DELETE CASCADE;
 
+ALTER TABLE ONLY template_autostop_windows
+    ADD CONSTRAINT template_autostop_windows_template_id_fkey FOREIGN KEY (template_id) REFERENCES templates(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY template_port_share_rules
+    ADD CONSTRAINT template_port_share_rules_template_id_fkey FOREIGN KEY (template_id) REFERENCES templates(id) ON DELETE CASCADE;
+
 ALTER TABLE ONLY template_version_parameters
     ADD CONSTRAINT template_version_parameters_template_version_id_fkey FOREIGN KEY (template_version_id) REFERENCES template_versions(id) ON DELETE CASCADE;
 
@@ -3002,6 +3564,12 @@ ALTER TABLE ONLY templates
 ALTER TABLE ONLY templates
     ADD CONSTRAINT templates_organization_id_fkey FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE;
 
+ALTER TABLE ONLY user_autostop_windows
+    ADD CONSTRAINT user_autostop_windows_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY user_chat_identities
+    ADD CONSTRAINT user_chat_identities_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
 ALTER TABLE ONLY user_configs
     ADD CONSTRAINT user_configs_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
 
@@ -3017,6 +3585,9 @@ ALTER TABLE ONLY user_links
 ALTER TABLE ONLY user_links
     ADD CONSTRAINT user_links_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
 
+ALTER TABLE ONLY user_notification_schedules
+    ADD CONSTRAINT user_notification_schedules_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
 ALTER TABLE ONLY user_status_changes
     ADD CONSTRAINT user_status_changes_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id);
 
@@ -3083,6 +3654,15 @@ ALTER TABLE ONLY workspace_apps
 ALTER TABLE ONLY workspace_build_parameters
     ADD CONSTRAINT workspace_build_parameters_workspace_build_id_fkey FOREIGN KEY (workspace_build_id) REFERENCES workspace_builds(id) ON DELETE CASCADE;
 
+ALTER TABLE ONLY workspace_build_states
+    ADD CONSTRAINT workspace_build_states_state_key_id_fkey FOREIGN KEY (state_key_id) REFERENCES dbcrypt_keys(active_key_digest);
+
+ALTER TABLE ONLY workspace_build_states
+    ADD CONSTRAINT workspace_build_states_workspace_build_id_fkey FOREIGN KEY (workspace_build_id) REFERENCES workspace_builds(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY workspace_build_states
+    ADD CONSTRAINT workspace_build_states_workspace_id_fkey FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE;
+
 ALTER TABLE ONLY workspace_builds
     ADD CONSTRAINT workspace_builds_ai_task_sidebar_app_id_fkey FOREIGN KEY (ai_task_sidebar_app_id) REFERENCES workspace_apps(id);
 
@@ -3098,6 +3678,15 @@ ALTER TABLE ONLY workspace_builds
 ALTER TABLE ONLY workspace_builds
     ADD CONSTRAINT workspace_builds_workspace_id_fkey FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE;
 
+ALTER TABLE ONLY workspace_drift_checks
+    ADD CONSTRAINT workspace_drift_checks_job_id_fkey FOREIGN KEY (job_id) REFERENCES provisioner_jobs(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY workspace_drift_checks
+    ADD CONSTRAINT workspace_drift_checks_workspace_build_id_fkey FOREIGN KEY (workspace_build_id) REFERENCES workspace_builds(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY workspace_drift_checks
+    ADD CONSTRAINT workspace_drift_checks_workspace_id_fkey FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE;
+
 ALTER TABLE ONLY workspace_modules
     ADD CONSTRAINT workspace_modules_job_id_fkey FOREIGN KEY (job_id) REFERENCES provisioner_jobs(id) ON DELETE CASCADE;
 
@@ -3107,6 +3696,27 @@ ALTER TABLE ONLY workspace_resource_metadata
 ALTER TABLE ONLY workspace_resources
     ADD CONSTRAINT workspace_resources_job_id_fkey FOREIGN KEY (job_id) REFERENCES provisioner_jobs(id) ON DELETE CASCADE;
 
+ALTER TABLE ONLY workspace_snapshot_restores
+    ADD CONSTRAINT workspace_snapshot_restores_requested_by_fkey FOREIGN KEY (requested_by) REFERENCES users(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY workspace_snapshot_restores
+    ADD CONSTRAINT workspace_snapshot_restores_snapshot_id_fkey FOREIGN KEY (snapshot_id) REFERENCES workspace_snapshots(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY workspace_snapshot_restores
+    ADD CONSTRAINT workspace_snapshot_restores_workspace_id_fkey FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY workspace_snapshots
+    ADD CONSTRAINT workspace_snapshots_parent_id_fkey FOREIGN KEY (parent_id) REFERENCES workspace_snapshots(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY workspace_snapshots
+    ADD CONSTRAINT workspace_snapshots_workspace_id_fkey FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY workspace_update_previews
+    ADD CONSTRAINT workspace_update_previews_job_id_fkey FOREIGN KEY (job_id) REFERENCES provisioner_jobs(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY workspace_update_previews
+    ADD CONSTRAINT workspace_update_previews_workspace_id_fkey FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE;
+
 ALTER TABLE ONLY workspaces
     ADD CONSTRAINT workspaces_organization_id_fkey FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE RESTRICT;
 
@@ -13,6 +13,10 @@ const (
 	LockIDNotificationsReportGenerator
 	LockIDCryptoKeyRotation
 	LockIDReconcilePrebuilds
+	LockIDNotificationRulesEvaluator
+	LockIDAuditLogHashChain
+	LockIDAuditLogCheckpoint
+	LockIDWorkspaceDriftChecks
 )
 
 // GenLockID generates a unique and consistent lock ID from a given string.
@@ -0,0 +1,7 @@
+DELETE FROM notification_templates WHERE id = 'c6e2f0a5-5c8e-4e6b-9d4e-8c3b0f1a7d21';
+
+ALTER TABLE workspace_agent_port_share
+	DROP COLUMN IF EXISTS label,
+	DROP COLUMN IF EXISTS auto_shared;
+
+DROP TABLE IF EXISTS template_port_share_rules;
@@ -0,0 +1,50 @@
+CREATE TABLE template_port_share_rules (
+	id uuid NOT NULL PRIMARY KEY,
+	template_id uuid NOT NULL REFERENCES templates(id) ON DELETE CASCADE,
+	port_start integer NOT NULL,
+	port_end integer NOT NULL,
+	share_level app_sharing_level NOT NULL,
+	protocol port_share_protocol NOT NULL DEFAULT 'http'::port_share_protocol,
+	label text NOT NULL DEFAULT '',
+	CONSTRAINT template_port_share_rules_port_range_check CHECK (port_start >= 1 AND port_end >= port_start AND port_end <= 65535)
+);
+
+COMMENT ON TABLE template_port_share_rules IS 'Rules that automatically share listening ports of workspaces created from a template.';
+
+COMMENT ON COLUMN template_port_share_rules.port_start IS 'First port, inclusive, of the range matched by the rule.';
+
+COMMENT ON COLUMN template_port_share_rules.port_end IS 'Last port, inclusive, of the range matched by the rule.';
+
+COMMENT ON COLUMN template_port_share_rules.label IS 'Optional human readable label for ports matched by the rule.';
+
+CREATE INDEX template_port_share_rules_template_id_idx ON template_port_share_rules USING btree (template_id);
+
+ALTER TABLE workspace_agent_port_share
+	ADD COLUMN auto_shared boolean NOT NULL DEFAULT false,
+	ADD COLUMN label text NOT NULL DEFAULT '';
+
+COMMENT ON COLUMN workspace_agent_port_share.auto_shared IS 'Whether the port share was created automatically by a template port share rule.';
+
+COMMENT ON COLUMN workspace_agent_port_share.label IS 'Label of the template port share rule that created the port share, if any.';
+
+INSERT INTO notification_templates
+	(id, name, title_template, body_template, "group", actions)
+VALUES (
+	'c6e2f0a5-5c8e-4e6b-9d4e-8c3b0f1a7d21',
+	'Workspace Port Shared',
+	E'Port {{.Labels.port}} of workspace "{{.Labels.workspace}}" was shared',
+	E'Hi {{.UserName}},\n\n'||
+		E'Port **{{.Labels.port}}**{{ if .Labels.label }} ({{.Labels.label}}){{ end }} of agent **{{.Labels.agent}}** in workspace **{{.Labels.workspace}}** '||
+		E'was automatically shared with **{{.Labels.share_level}}** access by a template port sharing rule.',
+	'Workspace Events',
+	'[
+		{
+			"label": "Open port",
+			"url": "{{.Labels.url}}"
+		},
+		{
+			"label": "View workspace",
+			"url": "{{base_url}}/@{{.UserUsername}}/{{.Labels.workspace}}"
+		}
+	]'::jsonb
+);
@@ -0,0 +1,4 @@
+UPDATE notification_templates
+SET body_template = E'Hi {{.UserName}},\n\n'||
+	E'Your workspace **{{.Labels.workspace}}** has reached the memory usage threshold set at **{{.Labels.threshold}}**.'
+WHERE id = 'a9d027b4-ac49-4fb1-9f6d-45af15f64e7a';
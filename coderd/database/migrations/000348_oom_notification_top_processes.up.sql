@@ -0,0 +1,10 @@
+UPDATE notification_templates
+SET body_template = E'Hi {{.UserName}},\n\n'||
+	E'Your workspace **{{.Labels.workspace}}** has reached the memory usage threshold set at **{{.Labels.threshold}}**.'||
+	E'{{ if .Data.top_processes }}\n\n'||
+		E'The processes using the most memory are:\n\n'||
+		E'{{ range $process := .Data.top_processes }}'||
+			E'- **`{{$process.name}}`** (PID {{$process.pid}}) is using {{$process.memory}} of memory and {{$process.cpu}} CPU\n'||
+		E'{{ end }}'||
+	E'{{ end }}'
+WHERE id = 'a9d027b4-ac49-4fb1-9f6d-45af15f64e7a';
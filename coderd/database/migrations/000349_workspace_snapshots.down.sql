@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS workspace_snapshot_restores;
+
+DROP TABLE IF EXISTS workspace_snapshots;
+
+DROP TYPE IF EXISTS workspace_snapshot_storage;
+
+DROP TYPE IF EXISTS workspace_snapshot_trigger;
@@ -0,0 +1,44 @@
+CREATE TYPE workspace_snapshot_trigger AS ENUM (
+	'stop',
+	'schedule'
+);
+
+CREATE TYPE workspace_snapshot_storage AS ENUM (
+	'database',
+	's3'
+);
+
+CREATE TABLE workspace_snapshots (
+	id uuid NOT NULL PRIMARY KEY,
+	workspace_id uuid NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+	agent_name text NOT NULL,
+	build_number integer NOT NULL,
+	parent_id uuid REFERENCES workspace_snapshots(id) ON DELETE CASCADE,
+	trigger workspace_snapshot_trigger NOT NULL,
+	storage workspace_snapshot_storage NOT NULL,
+	object_key text NOT NULL,
+	size_bytes bigint NOT NULL,
+	file_count integer NOT NULL,
+	paths text[] NOT NULL,
+	created_at timestamp with time zone NOT NULL
+);
+
+COMMENT ON TABLE workspace_snapshots IS 'Compressed archives of directories in a workspace, uploaded by the workspace agent.';
+
+COMMENT ON COLUMN workspace_snapshots.parent_id IS 'The snapshot this snapshot is incremental to. Restoring requires every snapshot in the chain. NULL for full snapshots.';
+
+COMMENT ON COLUMN workspace_snapshots.object_key IS 'The file ID for database storage or the object key for S3 storage.';
+
+COMMENT ON COLUMN workspace_snapshots.file_count IS 'The number of files in the workspace at the time of the snapshot, including files unchanged since the parent snapshot.';
+
+CREATE INDEX workspace_snapshots_workspace_id_created_at_idx ON workspace_snapshots USING btree (workspace_id, created_at DESC);
+
+CREATE TABLE workspace_snapshot_restores (
+	workspace_id uuid NOT NULL PRIMARY KEY REFERENCES workspaces(id) ON DELETE CASCADE,
+	snapshot_id uuid NOT NULL REFERENCES workspace_snapshots(id) ON DELETE CASCADE,
+	after_build_number integer NOT NULL,
+	requested_by uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	requested_at timestamp with time zone NOT NULL
+);
+
+COMMENT ON TABLE workspace_snapshot_restores IS 'Pending snapshot restores. The agent of the first build after after_build_number restores the snapshot on startup.';
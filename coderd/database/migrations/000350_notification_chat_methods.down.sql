@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS user_chat_identities;
+
+-- Values cannot be removed from an enum, so the notification methods are left
+-- in place.
@@ -0,0 +1,25 @@
+-- New values cannot be used in the transaction that adds them, which is fine
+-- as nothing below refers to them.
+ALTER TYPE notification_method ADD VALUE IF NOT EXISTS 'slack';
+ALTER TYPE notification_method ADD VALUE IF NOT EXISTS 'matrix';
+ALTER TYPE notification_method ADD VALUE IF NOT EXISTS 'teams';
+
+CREATE TABLE user_chat_identities (
+	user_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	method notification_method NOT NULL,
+	identity text NOT NULL,
+	created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	updated_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	verified_at timestamp with time zone,
+	verification_code_hash bytea,
+	verification_expires_at timestamp with time zone,
+	PRIMARY KEY (user_id, method)
+);
+
+COMMENT ON TABLE user_chat_identities IS 'Chat accounts users linked to receive notifications as direct messages.';
+
+COMMENT ON COLUMN user_chat_identities.identity IS 'The Slack member ID, Matrix user ID or Microsoft Teams user principal name of the user.';
+
+COMMENT ON COLUMN user_chat_identities.verified_at IS 'When the user proved they own the identity. Notifications are only delivered to verified identities.';
+
+COMMENT ON COLUMN user_chat_identities.verification_code_hash IS 'SHA-256 hash of the one-time code sent to the identity, until it is verified.';
@@ -0,0 +1,17 @@
+DELETE FROM notification_templates WHERE id = '8d7c6a5e-3f1b-4c2d-9e8a-7b6f5d4c3e2a';
+
+DROP TABLE IF EXISTS user_notification_schedules;
+
+DROP TABLE IF EXISTS notification_digest_preferences;
+
+ALTER TABLE notification_templates
+	DROP COLUMN IF EXISTS critical;
+
+DROP TYPE IF EXISTS notification_digest_interval;
+
+-- Batched messages would be stuck, deliver them individually.
+UPDATE notification_messages
+SET status = 'pending'::notification_message_status
+WHERE status = 'batched'::notification_message_status;
+
+-- The batched value cannot be removed from notification_message_status.
@@ -0,0 +1,75 @@
+ALTER TYPE notification_message_status ADD VALUE IF NOT EXISTS 'batched';
+
+CREATE TYPE notification_digest_interval AS ENUM (
+	'hourly',
+	'daily'
+);
+
+ALTER TABLE notification_templates
+	ADD COLUMN critical boolean NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN notification_templates.critical IS 'Critical notifications are delivered immediately, they are neither batched into digests nor deferred by quiet hours.';
+
+UPDATE notification_templates SET critical = true WHERE id IN (
+	'62f86a30-2330-4b61-a26d-311ff3b608cf', -- One-time passcode
+	'c425f63e-716a-4bf4-ae24-78348f706c3f', -- Test notification
+	'6a2f0609-9b69-4d36-a989-9f5925b6cbff', -- Your account suspended
+	'1a6a6bea-ee0a-43e2-9e7c-eabdb53730e4', -- Your account activated
+	'a9d027b4-ac49-4fb1-9f6d-45af15f64e7a', -- Workspace out of memory
+	'f047f6a3-5713-40f7-85aa-0394cce9fa3a'  -- Workspace out of disk
+);
+
+CREATE TABLE notification_digest_preferences (
+	user_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	notification_template_id uuid NOT NULL REFERENCES notification_templates(id) ON DELETE CASCADE,
+	digest_interval notification_digest_interval NOT NULL,
+	created_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (user_id, notification_template_id)
+);
+
+COMMENT ON TABLE notification_digest_preferences IS 'Notifications users chose to receive as a periodic digest rather than individually.';
+
+CREATE TABLE user_notification_schedules (
+	user_id uuid NOT NULL PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+	timezone text NOT NULL,
+	quiet_hours_start smallint,
+	quiet_hours_end smallint,
+	daily_digest_time smallint NOT NULL DEFAULT 540,
+	updated_at timestamp with time zone NOT NULL,
+	CONSTRAINT user_notification_schedules_quiet_hours_check CHECK (
+		(quiet_hours_start IS NULL AND quiet_hours_end IS NULL)
+		OR (quiet_hours_start BETWEEN 0 AND 1439 AND quiet_hours_end BETWEEN 0 AND 1439 AND quiet_hours_start <> quiet_hours_end)
+	),
+	CONSTRAINT user_notification_schedules_daily_digest_time_check CHECK (daily_digest_time BETWEEN 0 AND 1439)
+);
+
+COMMENT ON TABLE user_notification_schedules IS 'When users want to receive notifications, in their timezone.';
+
+COMMENT ON COLUMN user_notification_schedules.timezone IS 'IANA timezone the times are in.';
+
+COMMENT ON COLUMN user_notification_schedules.quiet_hours_start IS 'Minute of the day quiet hours start at, non-critical notifications are deferred until they end. NULL disables quiet hours.';
+
+COMMENT ON COLUMN user_notification_schedules.quiet_hours_end IS 'Minute of the day quiet hours end at, it is before the start when quiet hours span midnight.';
+
+COMMENT ON COLUMN user_notification_schedules.daily_digest_time IS 'Minute of the day daily digests are delivered at.';
+
+INSERT INTO notification_templates
+	(id, name, title_template, body_template, "group", actions)
+VALUES (
+	'8d7c6a5e-3f1b-4c2d-9e8a-7b6f5d4c3e2a',
+	'Notification Digest',
+	E'{{.Data.count}} notifications since {{.Data.since}}',
+	E'Hi {{.UserName}},\n\n'||
+		E'Here is a summary of the notifications you chose to receive as a digest:\n\n'||
+		E'{{range $notification := .Data.notifications}}'||
+		E'* {{$notification.time}} · **{{$notification.name}}**: {{$notification.title}}\n'||
+		E'{{end}}',
+	'Notification Events',
+	'[
+		{
+			"label": "Manage notification settings",
+			"url": "{{base_url}}/settings/notifications"
+		}
+	]'::jsonb
+);
@@ -0,0 +1,13 @@
+DELETE FROM notification_templates WHERE id IN (
+	'e3b4f0c2-5a6d-4f1e-8b7c-2d9a0e1f3c45',
+	'7f2c9d4e-1b3a-4e6f-9c8d-5a0b1e2f3d67',
+	'a1d5e8f3-6c2b-4d9a-8e7f-0b3c4d5e6f78',
+	'c9e7a3b1-4d2f-4a8c-b6e5-1f0d2c3b4a89'
+);
+
+DELETE FROM notification_report_generator_logs WHERE notification_template_id IN (
+	'e3b4f0c2-5a6d-4f1e-8b7c-2d9a0e1f3c45',
+	'7f2c9d4e-1b3a-4e6f-9c8d-5a0b1e2f3d67',
+	'a1d5e8f3-6c2b-4d9a-8e7f-0b3c4d5e6f78',
+	'c9e7a3b1-4d2f-4a8c-b6e5-1f0d2c3b4a89'
+);
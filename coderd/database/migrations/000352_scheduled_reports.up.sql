@@ -0,0 +1,90 @@
+INSERT INTO notification_templates
+	(id, name, title_template, body_template, "group", actions)
+VALUES (
+	'e3b4f0c2-5a6d-4f1e-8b7c-2d9a0e1f3c45',
+	'Report: Workspaces Pending Deletion',
+	E'Workspaces pending deletion report',
+	E'Hi {{.UserName}},\n\n'||
+		E'The following workspaces will be deleted or marked as dormant over the next {{.Data.report_frequency}}:\n'||
+		E'{{range $template := .Data.templates}}\n'||
+		E'**{{$template.display_name}}**\n'||
+		E'{{range $workspace := $template.deleting}}\n'||
+		E'- [{{$workspace.owner_username}} / {{$workspace.name}}]({{base_url}}/@{{$workspace.owner_username}}/{{$workspace.name}}) is dormant and will be deleted on {{$workspace.deleting_at}}\n'||
+		E'{{- end}}'||
+		E'{{range $workspace := $template.dormant}}\n'||
+		E'- [{{$workspace.owner_username}} / {{$workspace.name}}]({{base_url}}/@{{$workspace.owner_username}}/{{$workspace.name}}) was last used on {{$workspace.last_used_at}} and will be marked as dormant on {{$workspace.dormant_at}}\n'||
+		E'{{- end}}\n'||
+		E'{{if $template.more}}- and {{$template.more}} more\n{{end}}'||
+		E'{{end}}\n'||
+		E'Workspace owners can keep their workspaces by activating them before then.',
+	'Template Events',
+	'[
+		{
+			"label": "View dormant workspaces",
+			"url": "{{base_url}}/workspaces?filter=dormant%3Atrue"
+		}
+	]'::jsonb
+), (
+	'7f2c9d4e-1b3a-4e6f-9c8d-5a0b1e2f3d67',
+	'Report: Template Version Adoption',
+	E'Template version adoption report',
+	E'Hi {{.UserName}},\n\n'||
+		E'The following templates have workspaces which are not on the active template version:\n'||
+		E'{{range $template := .Data.templates}}\n'||
+		E'**{{$template.display_name}}**: {{$template.outdated_workspaces}}/{{$template.total_workspaces}} workspaces are not on **{{$template.active_version}}**\n'||
+		E'{{range $version := $template.outdated_versions}}\n'||
+		E'- **{{$version.name}}**: {{$version.workspace_count}} workspace{{if gt $version.workspace_count 1.0}}s{{end}}\n'||
+		E'{{- end}}\n'||
+		E'{{end}}\n'||
+		E'Workspace owners can update their workspaces from the dashboard, or you can require the active version in the template settings.',
+	'Template Events',
+	'[
+		{
+			"label": "View outdated workspaces",
+			"url": "{{base_url}}/workspaces?filter=outdated%3Atrue"
+		}
+	]'::jsonb
+), (
+	'a1d5e8f3-6c2b-4d9a-8e7f-0b3c4d5e6f78',
+	'Report: Top Resource Consumers',
+	E'Top resource consumers report',
+	E'Hi {{.UserName}},\n\n'||
+		E'The following workspaces transferred the most data over the last {{.Data.report_frequency}}:\n'||
+		E'{{range $organization := .Data.organizations}}\n'||
+		E'**{{$organization.display_name}}**\n'||
+		E'{{range $workspace := $organization.workspaces}}\n'||
+		E'{{$workspace.position}}. [{{$workspace.owner_username}} / {{$workspace.name}}]({{base_url}}/@{{$workspace.owner_username}}/{{$workspace.name}}) ({{$workspace.template_display_name}}): '||
+		E'{{$workspace.transferred}} in {{$workspace.sessions}} session{{if ne $workspace.sessions 1.0}}s{{end}}'||
+		E'{{if $workspace.daily_cost}}, {{$workspace.daily_cost}} quota credits per day{{end}}\n'||
+		E'{{- end}}\n'||
+		E'{{end}}',
+	'Template Events',
+	'[
+		{
+			"label": "View insights",
+			"url": "{{base_url}}/insights"
+		}
+	]'::jsonb
+), (
+	'c9e7a3b1-4d2f-4a8c-b6e5-1f0d2c3b4a89',
+	'Report: Prebuild Pool Health',
+	E'Prebuild pool health report',
+	E'Hi {{.UserName}},\n\n'||
+		E'The following prebuilt workspace pools were unhealthy over the last {{.Data.report_frequency}}:\n'||
+		E'{{range $template := .Data.templates}}\n'||
+		E'**{{$template.display_name}}**\n'||
+		E'{{range $preset := $template.presets}}\n'||
+		E'- **{{$preset.name}}**: {{$preset.ready}}/{{$preset.desired}} prebuilt workspaces ready'||
+		E'{{if $preset.failed_builds}}, {{$preset.failed_builds}} failed build{{if gt $preset.failed_builds 1.0}}s{{end}}{{end}}'||
+		E'{{if $preset.hard_limited}}, prebuilds are paused after repeated failures{{end}}\n'||
+		E'{{- end}}\n'||
+		E'{{end}}\n'||
+		E'We recommend reviewing the failed builds and the template version to keep the pools filled.',
+	'Template Events',
+	'[
+		{
+			"label": "View templates",
+			"url": "{{base_url}}/templates"
+		}
+	]'::jsonb
+);
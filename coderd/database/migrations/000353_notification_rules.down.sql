@@ -0,0 +1,5 @@
+DELETE FROM notification_templates WHERE id = '5b9e8c1d-2f4a-4e3b-a6c7-d8e9f0a1b2c3';
+
+DROP TABLE IF EXISTS notification_rules;
+
+DROP TYPE IF EXISTS notification_rule_source;
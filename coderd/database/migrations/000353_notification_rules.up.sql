@@ -0,0 +1,62 @@
+CREATE TYPE notification_rule_source AS ENUM (
+	'audit_log',
+	'workspace_build'
+);
+
+CREATE TABLE notification_rules (
+	id uuid NOT NULL PRIMARY KEY,
+	organization_id uuid NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+	user_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	name text NOT NULL,
+	source notification_rule_source NOT NULL,
+	query text NOT NULL,
+	method notification_method,
+	threshold integer NOT NULL DEFAULT 1,
+	window_seconds integer NOT NULL DEFAULT 0,
+	enabled boolean NOT NULL DEFAULT true,
+	created_at timestamp with time zone NOT NULL,
+	updated_at timestamp with time zone NOT NULL,
+	last_evaluated_at timestamp with time zone NOT NULL,
+	last_triggered_at timestamp with time zone,
+	CONSTRAINT notification_rules_threshold_check CHECK (threshold > 0),
+	CONSTRAINT notification_rules_window_seconds_check CHECK (window_seconds >= 0),
+	CONSTRAINT notification_rules_organization_id_name_key UNIQUE (organization_id, name)
+);
+
+COMMENT ON TABLE notification_rules IS 'User-defined notifications sent when audit log entries or workspace builds match a search query.';
+
+COMMENT ON COLUMN notification_rules.user_id IS 'The user who created the rule, notifications are sent to them.';
+
+COMMENT ON COLUMN notification_rules.query IS 'Search query in the syntax of the audit log or workspace build search, depending on the source.';
+
+COMMENT ON COLUMN notification_rules.method IS 'Dispatch method to send notifications with. NULL uses the method of the notification template, or the deployment default.';
+
+COMMENT ON COLUMN notification_rules.threshold IS 'Minimum number of matching events which trigger the rule.';
+
+COMMENT ON COLUMN notification_rules.window_seconds IS 'Period matching events are counted over. Once triggered, the rule is not triggered again until the window passed. 0 counts the events since the previous evaluation.';
+
+COMMENT ON COLUMN notification_rules.last_evaluated_at IS 'Events up to this time have been evaluated.';
+
+CREATE INDEX notification_rules_enabled_idx ON notification_rules USING btree (enabled) WHERE enabled;
+
+INSERT INTO notification_templates
+	(id, name, title_template, body_template, "group", actions)
+VALUES (
+	'5b9e8c1d-2f4a-4e3b-a6c7-d8e9f0a1b2c3',
+	'Notification Rule Triggered',
+	E'{{.Labels.rule_name}}',
+	E'Hi {{.UserName}},\n\n'||
+		E'Your notification rule **{{.Labels.rule_name}}** matched {{.Data.count}} {{.Labels.source}} event{{if ne .Data.count 1.0}}s{{end}}'||
+		E'{{if .Data.window}} over the last {{.Data.window}}{{end}}:\n'||
+		E'{{range $event := .Data.events}}\n'||
+		E'* {{$event.time}} · {{$event.description}}'||
+		E'{{- end}}\n'||
+		E'{{if .Data.more}}\n…and {{.Data.more}} more.\n{{end}}',
+	'Notification Events',
+	'[
+		{
+			"label": "View events",
+			"url": "{{base_url}}{{.Labels.events_path}}"
+		}
+	]'::jsonb
+);
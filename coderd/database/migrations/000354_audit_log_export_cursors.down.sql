@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS audit_log_export_cursors;
+
+DROP INDEX IF EXISTS idx_audit_logs_xact_id;
+
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS xact_id;
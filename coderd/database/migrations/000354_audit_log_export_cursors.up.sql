@@ -0,0 +1,25 @@
+-- Audit logs are streamed in the order of the transactions which inserted
+-- them. Unlike their time, which is set before they are inserted, a
+-- transaction ID lower than that of the oldest running transaction can no
+-- longer appear. The default only applies to new audit logs, so existing
+-- rows are not rewritten.
+ALTER TABLE audit_logs ADD COLUMN xact_id bigint;
+ALTER TABLE audit_logs ALTER COLUMN xact_id SET DEFAULT (pg_current_xact_id()::text::bigint);
+
+COMMENT ON COLUMN audit_logs.xact_id IS 'ID of the transaction which inserted the audit log. NULL for audit logs inserted before streaming was introduced.';
+
+CREATE INDEX idx_audit_logs_xact_id ON audit_logs USING btree (xact_id, id) WHERE xact_id IS NOT NULL;
+
+CREATE TABLE audit_log_export_cursors (
+	sink text NOT NULL,
+	last_xact_id bigint NOT NULL,
+	last_id uuid NOT NULL,
+	updated_at timestamp with time zone NOT NULL,
+	leased_by uuid,
+	lease_expires_at timestamp with time zone,
+	PRIMARY KEY (sink)
+);
+
+COMMENT ON TABLE audit_log_export_cursors IS 'Position of each audit log streaming sink. Audit logs ordered after (last_xact_id, last_id) have not been delivered to the sink yet.';
+
+COMMENT ON COLUMN audit_log_export_cursors.leased_by IS 'Replica which delivers to the sink until the lease expires.';
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS audit_log_checkpoints;
+
+DROP TABLE IF EXISTS audit_log_hashes;
+
+DELETE FROM crypto_keys WHERE feature = 'audit_log_checkpoint';
+
+-- The audit_log_checkpoint value cannot be removed from crypto_key_feature.
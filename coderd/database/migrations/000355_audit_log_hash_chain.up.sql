@@ -0,0 +1,32 @@
+ALTER TYPE crypto_key_feature ADD VALUE IF NOT EXISTS 'audit_log_checkpoint';
+
+CREATE TABLE audit_log_hashes (
+	sequence bigint NOT NULL PRIMARY KEY,
+	audit_log_id uuid NOT NULL,
+	audit_log_time timestamp with time zone NOT NULL,
+	audit_log_xact_id bigint NOT NULL,
+	previous_hash bytea NOT NULL,
+	hash bytea NOT NULL
+);
+
+COMMENT ON TABLE audit_log_hashes IS 'Hash chain over the audit logs in the order of the transactions which inserted them. Audit logs are not referenced with a foreign key, so their deletion is detected.';
+
+COMMENT ON COLUMN audit_log_hashes.audit_log_xact_id IS 'ID of the transaction which inserted the audit log. The chain is extended with the audit logs ordered after the last entry.';
+
+COMMENT ON COLUMN audit_log_hashes.hash IS 'SHA-256 of the previous hash and the audit log.';
+
+CREATE UNIQUE INDEX idx_audit_log_hashes_audit_log_id ON audit_log_hashes USING btree (audit_log_id);
+
+CREATE INDEX idx_audit_log_hashes_audit_log_time ON audit_log_hashes USING btree (audit_log_time);
+
+CREATE TABLE audit_log_checkpoints (
+	sequence bigint NOT NULL PRIMARY KEY,
+	hash bytea NOT NULL,
+	key_sequence integer NOT NULL,
+	signature bytea NOT NULL,
+	created_at timestamp with time zone NOT NULL
+);
+
+COMMENT ON TABLE audit_log_checkpoints IS 'Signed heads of the audit log hash chain. Rewriting the chain before a checkpoint requires the signing key.';
+
+COMMENT ON COLUMN audit_log_checkpoints.key_sequence IS 'Sequence of the audit_log_checkpoint crypto key the signature was made with.';
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS audit_log_archive_entries;
+
+DROP TABLE IF EXISTS audit_log_archives;
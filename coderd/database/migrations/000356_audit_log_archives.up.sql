@@ -0,0 +1,30 @@
+CREATE TABLE audit_log_archives (
+	id uuid NOT NULL PRIMARY KEY,
+	created_at timestamp with time zone NOT NULL,
+	file_name text NOT NULL,
+	file_id uuid NOT NULL REFERENCES files (id),
+	oldest_time timestamp with time zone NOT NULL,
+	newest_time timestamp with time zone NOT NULL,
+	audit_log_count integer NOT NULL,
+	sha256 bytea NOT NULL,
+	restored_at timestamp with time zone
+);
+
+COMMENT ON TABLE audit_log_archives IS 'Compressed files expired audit logs were archived to before they were deleted.';
+
+COMMENT ON COLUMN audit_log_archives.file_id IS 'The archive file in the files table, so it can be restored from any replica.';
+
+COMMENT ON COLUMN audit_log_archives.sha256 IS 'SHA-256 of the archive file, verified when it is restored.';
+
+COMMENT ON COLUMN audit_log_archives.restored_at IS 'When the audit logs of the archive were restored. Restored audit logs are deleted again after a while, without being archived again.';
+
+CREATE UNIQUE INDEX idx_audit_log_archives_file_name ON audit_log_archives USING btree (file_name);
+
+CREATE TABLE audit_log_archive_entries (
+	audit_log_id uuid NOT NULL PRIMARY KEY,
+	archive_id uuid NOT NULL REFERENCES audit_log_archives (id) ON DELETE CASCADE
+);
+
+COMMENT ON TABLE audit_log_archive_entries IS 'The archive of each archived audit log, so the hash chain tells archived audit logs apart from deleted ones.';
+
+CREATE INDEX idx_audit_log_archive_entries_archive_id ON audit_log_archive_entries USING btree (archive_id);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS organization_holidays;
+
+DROP TABLE IF EXISTS user_autostop_windows;
+
+DROP TABLE IF EXISTS template_autostop_windows;
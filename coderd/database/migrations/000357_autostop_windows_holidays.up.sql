@@ -0,0 +1,40 @@
+CREATE TABLE template_autostop_windows (
+	template_id uuid NOT NULL REFERENCES templates (id) ON DELETE CASCADE,
+	weekday smallint NOT NULL CHECK (weekday >= 0 AND weekday <= 6),
+	stop_minute integer NOT NULL CHECK (stop_minute >= 0 AND stop_minute < 1440),
+	PRIMARY KEY (template_id, weekday)
+);
+
+COMMENT ON TABLE template_autostop_windows IS 'The time of day workspaces of a template are stopped by, per day of the week.';
+
+COMMENT ON COLUMN template_autostop_windows.weekday IS 'Day of the week, where 0 is Sunday.';
+
+COMMENT ON COLUMN template_autostop_windows.stop_minute IS 'Minutes after midnight workspaces are stopped at, in the timezone of the quiet hours schedule of the workspace owner.';
+
+CREATE TABLE user_autostop_windows (
+	user_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	weekday smallint NOT NULL CHECK (weekday >= 0 AND weekday <= 6),
+	stop_minute integer NOT NULL CHECK (stop_minute >= 0 AND stop_minute < 1440),
+	PRIMARY KEY (user_id, weekday)
+);
+
+COMMENT ON TABLE user_autostop_windows IS 'The time of day workspaces of a user are stopped by, per day of the week. Replaces the windows of templates which allow users to set their own autostop.';
+
+COMMENT ON COLUMN user_autostop_windows.weekday IS 'Day of the week, where 0 is Sunday.';
+
+COMMENT ON COLUMN user_autostop_windows.stop_minute IS 'Minutes after midnight workspaces are stopped at, in the timezone of the quiet hours schedule of the user.';
+
+CREATE TABLE organization_holidays (
+	id uuid NOT NULL PRIMARY KEY,
+	organization_id uuid NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+	calendar text NOT NULL,
+	date date NOT NULL,
+	name text NOT NULL,
+	created_at timestamp with time zone NOT NULL
+);
+
+COMMENT ON TABLE organization_holidays IS 'Days on which workspaces of an organization are not autostarted, imported from iCalendar files.';
+
+COMMENT ON COLUMN organization_holidays.calendar IS 'Name of the calendar the holiday was imported from. Importing a calendar again replaces its holidays.';
+
+CREATE INDEX idx_organization_holidays_organization_id_date ON organization_holidays USING btree (organization_id, date);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS workspace_cost_usage;
+
+ALTER TABLE workspace_resources DROP COLUMN IF EXISTS hourly_cost_micros;
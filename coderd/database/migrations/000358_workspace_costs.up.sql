@@ -0,0 +1,26 @@
+ALTER TABLE workspace_resources ADD COLUMN hourly_cost_micros bigint NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN workspace_resources.hourly_cost_micros IS 'Cost per hour of the resource while the workspace is running, in millionths of the currency unit. Parsed from the hourly_cost metadata item of the resource.';
+
+CREATE TABLE workspace_cost_usage (
+	start_time timestamp with time zone NOT NULL,
+	workspace_id uuid NOT NULL,
+	template_id uuid NOT NULL,
+	user_id uuid NOT NULL,
+	organization_id uuid NOT NULL,
+	usage_seconds integer NOT NULL,
+	cost_micros bigint NOT NULL,
+	PRIMARY KEY (start_time, workspace_id)
+);
+
+COMMENT ON TABLE workspace_cost_usage IS 'Records the uptime and cost of workspaces in hourly buckets, rolled up from workspace builds.';
+
+COMMENT ON COLUMN workspace_cost_usage.start_time IS 'Start time of the hour of usage.';
+
+COMMENT ON COLUMN workspace_cost_usage.usage_seconds IS 'Seconds the workspace was running during the hour.';
+
+COMMENT ON COLUMN workspace_cost_usage.cost_micros IS 'Cost of the running resources of the workspace during the hour, in millionths of the currency unit.';
+
+CREATE INDEX workspace_cost_usage_start_time_idx ON workspace_cost_usage USING btree (start_time DESC);
+
+COMMENT ON INDEX workspace_cost_usage_start_time_idx IS 'Index for querying MAX(start_time).';
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS provisioner_jobs_completed_at_idx;
+DROP INDEX IF EXISTS provisioner_jobs_running_template_id_idx;
+DROP INDEX IF EXISTS provisioner_jobs_running_initiator_id_idx;
+
+ALTER TABLE provisioner_jobs
+	DROP COLUMN template_id,
+	DROP COLUMN priority;
+
+DROP TYPE provisioner_job_priority;
@@ -0,0 +1,24 @@
+-- Priorities are declared from the lowest to the highest, so jobs can be
+-- ordered by priority.
+CREATE TYPE provisioner_job_priority AS ENUM (
+	'template_import',
+	'prebuild',
+	'scheduled',
+	'interactive'
+);
+
+COMMENT ON TYPE provisioner_job_priority IS 'The priority class of a provisioner job. Pending jobs of a higher priority are acquired first. Values are ordered from the lowest to the highest priority.';
+
+ALTER TABLE provisioner_jobs
+	ADD COLUMN priority provisioner_job_priority NOT NULL DEFAULT 'interactive',
+	ADD COLUMN template_id uuid;
+
+COMMENT ON COLUMN provisioner_jobs.template_id IS 'The template the job belongs to, if any. Used to share provisioner daemons fairly between templates.';
+
+-- Fair-share scheduling counts the running jobs of the initiator and template
+-- of every pending job.
+CREATE INDEX provisioner_jobs_running_initiator_id_idx ON provisioner_jobs USING btree (initiator_id) WHERE (started_at IS NOT NULL AND completed_at IS NULL);
+CREATE INDEX provisioner_jobs_running_template_id_idx ON provisioner_jobs USING btree (template_id) WHERE (started_at IS NOT NULL AND completed_at IS NULL);
+
+-- The wait of pending jobs is estimated from the jobs completed recently.
+CREATE INDEX provisioner_jobs_completed_at_idx ON provisioner_jobs USING btree (completed_at) WHERE (completed_at IS NOT NULL);
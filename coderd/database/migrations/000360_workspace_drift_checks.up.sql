@@ -0,0 +1,94 @@
+ALTER TYPE provisioner_job_type ADD VALUE IF NOT EXISTS 'workspace_drift_check';
+
+-- Scheduled drift checks only report changes, so they run after any other job.
+ALTER TYPE provisioner_job_priority ADD VALUE IF NOT EXISTS 'drift_check' BEFORE 'template_import';
+
+ALTER TABLE templates ADD COLUMN drift_check_interval_ms bigint NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN templates.drift_check_interval_ms IS 'How often running workspaces of the template are checked for changes made outside of Coder. Zero disables drift checks.';
+
+DROP VIEW template_with_names;
+
+CREATE VIEW template_with_names AS
+	SELECT templates.id,
+		templates.created_at,
+		templates.updated_at,
+		templates.organization_id,
+		templates.deleted,
+		templates.name,
+		templates.provisioner,
+		templates.active_version_id,
+		templates.description,
+		templates.default_ttl,
+		templates.created_by,
+		templates.icon,
+		templates.user_acl,
+		templates.group_acl,
+		templates.display_name,
+		templates.allow_user_cancel_workspace_jobs,
+		templates.allow_user_autostart,
+		templates.allow_user_autostop,
+		templates.failure_ttl,
+		templates.time_til_dormant,
+		templates.time_til_dormant_autodelete,
+		templates.autostop_requirement_days_of_week,
+		templates.autostop_requirement_weeks,
+		templates.autostart_block_days_of_week,
+		templates.require_active_version,
+		templates.deprecated,
+		templates.activity_bump,
+		templates.max_port_sharing_level,
+		templates.use_classic_parameter_flow,
+		templates.drift_check_interval_ms,
+		COALESCE(visible_users.avatar_url, ''::text) AS created_by_avatar_url,
+		COALESCE(visible_users.username, ''::text) AS created_by_username,
+		COALESCE(visible_users.name, ''::text) AS created_by_name,
+		COALESCE(organizations.name, ''::text) AS organization_name,
+		COALESCE(organizations.display_name, ''::text) AS organization_display_name,
+		COALESCE(organizations.icon, ''::text) AS organization_icon
+	FROM ((templates
+	  LEFT JOIN visible_users ON ((templates.created_by = visible_users.id)))
+	  LEFT JOIN organizations ON ((templates.organization_id = organizations.id)));
+
+COMMENT ON VIEW template_with_names IS 'Joins in the display name information such as username, avatar, and organization name.';
+
+CREATE TABLE workspace_drift_checks (
+	id uuid NOT NULL,
+	workspace_id uuid NOT NULL REFERENCES workspaces (id) ON DELETE CASCADE,
+	workspace_build_id uuid NOT NULL REFERENCES workspace_builds (id) ON DELETE CASCADE,
+	job_id uuid NOT NULL REFERENCES provisioner_jobs (id) ON DELETE CASCADE,
+	created_at timestamp with time zone NOT NULL,
+	completed_at timestamp with time zone,
+	drifted_resources jsonb,
+	PRIMARY KEY (id),
+	UNIQUE (job_id)
+);
+
+COMMENT ON TABLE workspace_drift_checks IS 'Plans of running workspace builds, to find changes made to their resources outside of Coder.';
+
+COMMENT ON COLUMN workspace_drift_checks.drifted_resources IS 'The resources which changed outside of Coder, as reported by Terraform. NULL until the check completes.';
+
+CREATE INDEX workspace_drift_checks_workspace_id_created_at_idx ON workspace_drift_checks (workspace_id, created_at DESC);
+
+INSERT INTO notification_templates
+	(id, name, title_template, body_template, "group", actions)
+VALUES ('0b7e3f5a-9c2d-4e8b-a1f6-3d5c7e9b2a40',
+		'Workspace Drift Detected',
+		E'Workspace "{{.Labels.name}}" has drifted',
+		$$
+Hi {{.UserName}},
+
+Resources of your workspace **{{.Labels.name}}** were changed outside of Coder:
+
+{{range $resource := .Data.resources -}}
+- _{{ $resource.address }}_ was {{ $resource.action }}
+{{end}}
+Rebuild the workspace to reconcile its resources with the template.
+$$,
+		'Workspace Events',
+		'[
+		{
+			"label": "View workspace",
+			"url": "{{base_url}}/@{{.UserUsername}}/{{.Labels.name}}"
+		}
+	]'::jsonb);
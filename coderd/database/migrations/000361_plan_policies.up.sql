@@ -0,0 +1,14 @@
+CREATE TABLE plan_policies (
+	id uuid NOT NULL PRIMARY KEY,
+	organization_id uuid NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+	name text NOT NULL,
+	description text NOT NULL DEFAULT '',
+	module text NOT NULL,
+	created_at timestamp with time zone NOT NULL,
+	updated_at timestamp with time zone NOT NULL,
+	UNIQUE (organization_id, name)
+);
+
+COMMENT ON TABLE plan_policies IS 'Rego policies evaluated against the Terraform plans of workspace builds of an organization before they are applied.';
+
+COMMENT ON COLUMN plan_policies.module IS 'Rego module of the policy. Messages of its deny rules fail the build, messages of its warn rules are written to the build logs.';
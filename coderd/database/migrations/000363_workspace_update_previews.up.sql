@@ -0,0 +1,14 @@
+CREATE TABLE workspace_update_previews (
+	job_id uuid NOT NULL REFERENCES provisioner_jobs (id) ON DELETE CASCADE,
+	workspace_id uuid NOT NULL REFERENCES workspaces (id) ON DELETE CASCADE,
+	created_at timestamp with time zone NOT NULL,
+	completed_at timestamp with time zone,
+	resource_changes jsonb,
+	PRIMARY KEY (job_id)
+);
+
+COMMENT ON TABLE workspace_update_previews IS 'Template version dry-runs planned against the state of a workspace, to preview the changes an update of the workspace makes to its resources.';
+
+COMMENT ON COLUMN workspace_update_previews.resource_changes IS 'The resources the update would create, update, replace or delete. NULL until the dry-run completes.';
+
+CREATE INDEX workspace_update_previews_workspace_id_idx ON workspace_update_previews (workspace_id);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS provider_mirror_tokens;
+
+DROP TABLE IF EXISTS provider_mirror_archives;
@@ -0,0 +1,31 @@
+CREATE TABLE provider_mirror_archives (
+	organization_id uuid NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+	hostname text NOT NULL,
+	namespace text NOT NULL,
+	type text NOT NULL,
+	version text NOT NULL,
+	platform text NOT NULL,
+	hash text NOT NULL,
+	file_id uuid NOT NULL REFERENCES files (id) ON DELETE CASCADE,
+	created_at timestamp with time zone NOT NULL,
+	PRIMARY KEY (organization_id, hostname, namespace, type, version, platform)
+);
+
+COMMENT ON TABLE provider_mirror_archives IS 'Terraform provider packages installed by template imports, served to provisioner daemons by the provider network mirror.';
+
+COMMENT ON COLUMN provider_mirror_archives.organization_id IS 'The organization of the template imports that installed the package. Packages are only served to jobs of the same organization, so daemons of one organization cannot replace the providers of another.';
+
+COMMENT ON COLUMN provider_mirror_archives.hash IS 'The "h1:" package hash of the archive contents, as it appears in dependency lock files.';
+
+COMMENT ON COLUMN provider_mirror_archives.file_id IS 'The zip archive of the package. Files are content-addressed, so packages that are archived identically share a file.';
+
+CREATE INDEX provider_mirror_archives_file_id_idx ON provider_mirror_archives (file_id);
+
+CREATE TABLE provider_mirror_tokens (
+	job_id uuid NOT NULL REFERENCES provisioner_jobs (id) ON DELETE CASCADE,
+	hashed_secret bytea NOT NULL,
+	created_at timestamp with time zone NOT NULL,
+	PRIMARY KEY (job_id)
+);
+
+COMMENT ON TABLE provider_mirror_tokens IS 'Tokens that authenticate a provisioner job to the provider mirror while the job is running.';
@@ -0,0 +1,20 @@
+CREATE TABLE workspace_build_states (
+	workspace_build_id uuid NOT NULL REFERENCES workspace_builds (id) ON DELETE CASCADE,
+	workspace_id uuid NOT NULL REFERENCES workspaces (id) ON DELETE CASCADE,
+	state bytea NOT NULL,
+	state_key_id text REFERENCES dbcrypt_keys (active_key_digest),
+	hash text NOT NULL,
+	size bigint NOT NULL,
+	created_at timestamp with time zone NOT NULL,
+	PRIMARY KEY (workspace_build_id)
+);
+
+COMMENT ON TABLE workspace_build_states IS 'The Terraform state each workspace build ended with, kept to browse, diff and roll back to earlier states.';
+
+COMMENT ON COLUMN workspace_build_states.state_key_id IS 'The ID of the key used to encrypt the state. If this is NULL, the state is not encrypted';
+
+COMMENT ON COLUMN workspace_build_states.hash IS 'The hex encoded SHA256 hash of the unencrypted state, to compare states without decrypting them.';
+
+COMMENT ON COLUMN workspace_build_states.size IS 'The size of the unencrypted state in bytes.';
+
+CREATE INDEX workspace_build_states_workspace_id_created_at_idx ON workspace_build_states (workspace_id, created_at DESC);
@@ -0,0 +1 @@
+-- The external value cannot be removed from provisioner_type.
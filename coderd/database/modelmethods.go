@@ -117,6 +117,28 @@ func (w AuditLog) RBACObject() rbac.Object {
 	return obj
 }
 
+// InsertAuditLogParams returns the parameters to insert the audit log. The ID
+// of the inserting transaction is assigned by the database.
+func (w AuditLog) InsertAuditLogParams() InsertAuditLogParams {
+	return InsertAuditLogParams{
+		ID:               w.ID,
+		Time:             w.Time,
+		UserID:           w.UserID,
+		OrganizationID:   w.OrganizationID,
+		Ip:               w.Ip,
+		UserAgent:        w.UserAgent,
+		ResourceType:     w.ResourceType,
+		ResourceID:       w.ResourceID,
+		ResourceTarget:   w.ResourceTarget,
+		Action:           w.Action,
+		Diff:             w.Diff,
+		StatusCode:       w.StatusCode,
+		AdditionalFields: w.AdditionalFields,
+		RequestID:        w.RequestID,
+		ResourceIcon:     w.ResourceIcon,
+	}
+}
+
 func (s APIKeyScope) ToRBAC() rbac.ScopeName {
 	switch s {
 	case APIKeyScopeAll:
@@ -331,6 +353,12 @@ func (p GetEligibleProvisionerDaemonsByProvisionerJobIDsRow) RBACObject() rbac.O
 	return p.ProvisionerDaemon.RBACObject()
 }
 
+// RBACObject for a plan policy is the templates of its organization, as the
+// policy applies to the builds of all of them. Template admins manage policies.
+func (p PlanPolicy) RBACObject() rbac.Object {
+	return rbac.ResourceTemplate.InOrg(p.OrganizationID)
+}
+
 // RBACObject for a provisioner key is the same as a provisioner daemon.
 // Keys == provisioners from a RBAC perspective.
 func (p ProvisionerKey) RBACObject() rbac.Object {
@@ -588,6 +616,10 @@ func (r GetProvisionerJobsByOrganizationAndStatusWithQueuePositionAndProvisioner
 	return r.ProvisionerJob.RBACObject()
 }
 
+func (r GetProvisionerQueueStatsRow) RBACObject() rbac.Object {
+	return rbac.ResourceProvisionerJobs.InOrg(r.OrganizationID)
+}
+
 func (m WorkspaceAgentMemoryResourceMonitor) Debounce(
 	by time.Duration,
 	now time.Time,
@@ -119,6 +119,8 @@ func (q *sqlQuerier) GetAuthorizedTemplates(ctx context.Context, arg GetTemplate
 			&i.ActivityBump,
 			&i.MaxPortSharingLevel,
 			&i.UseClassicParameterFlow,
+			&i.DriftCheckIntervalMs,
+			&i.ProvisionerVersion,
 			&i.CreatedByAvatarURL,
 			&i.CreatedByUsername,
 			&i.CreatedByName,
@@ -534,6 +536,7 @@ func (q *sqlQuerier) GetAuthorizedAuditLogsOffset(ctx context.Context, arg GetAu
 			&i.AuditLog.AdditionalFields,
 			&i.AuditLog.RequestID,
 			&i.AuditLog.ResourceIcon,
+			&i.AuditLog.XactID,
 			&i.UserUsername,
 			&i.UserName,
 			&i.UserEmail,
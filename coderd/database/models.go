@@ -422,6 +422,7 @@ const (
 	CryptoKeyFeatureWorkspaceAppsAPIKey CryptoKeyFeature = "workspace_apps_api_key"
 	CryptoKeyFeatureOIDCConvert         CryptoKeyFeature = "oidc_convert"
 	CryptoKeyFeatureTailnetResume       CryptoKeyFeature = "tailnet_resume"
+	CryptoKeyFeatureAuditLogCheckpoint  CryptoKeyFeature = "audit_log_checkpoint"
 )
 
 func (e *CryptoKeyFeature) Scan(src interface{}) error {
@@ -464,7 +465,8 @@ func (e CryptoKeyFeature) Valid() bool {
 	case CryptoKeyFeatureWorkspaceAppsToken,
 		CryptoKeyFeatureWorkspaceAppsAPIKey,
 		CryptoKeyFeatureOIDCConvert,
-		CryptoKeyFeatureTailnetResume:
+		CryptoKeyFeatureTailnetResume,
+		CryptoKeyFeatureAuditLogCheckpoint:
 		return true
 	}
 	return false
@@ -476,6 +478,7 @@ func AllCryptoKeyFeatureValues() []CryptoKeyFeature {
 		CryptoKeyFeatureWorkspaceAppsAPIKey,
 		CryptoKeyFeatureOIDCConvert,
 		CryptoKeyFeatureTailnetResume,
+		CryptoKeyFeatureAuditLogCheckpoint,
 	}
 }
 
@@ -861,6 +864,64 @@ func AllLoginTypeValues() []LoginType {
 	}
 }
 
+type NotificationDigestInterval string
+
+const (
+	NotificationDigestIntervalHourly NotificationDigestInterval = "hourly"
+	NotificationDigestIntervalDaily  NotificationDigestInterval = "daily"
+)
+
+func (e *NotificationDigestInterval) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = NotificationDigestInterval(s)
+	case string:
+		*e = NotificationDigestInterval(s)
+	default:
+		return fmt.Errorf("unsupported scan type for NotificationDigestInterval: %T", src)
+	}
+	return nil
+}
+
+type NullNotificationDigestInterval struct {
+	NotificationDigestInterval NotificationDigestInterval `json:"notification_digest_interval"`
+	Valid                      bool                       `json:"valid"` // Valid is true if NotificationDigestInterval is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullNotificationDigestInterval) Scan(value interface{}) error {
+	if value == nil {
+		ns.NotificationDigestInterval, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.NotificationDigestInterval.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullNotificationDigestInterval) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.NotificationDigestInterval), nil
+}
+
+func (e NotificationDigestInterval) Valid() bool {
+	switch e {
+	case NotificationDigestIntervalHourly,
+		NotificationDigestIntervalDaily:
+		return true
+	}
+	return false
+}
+
+func AllNotificationDigestIntervalValues() []NotificationDigestInterval {
+	return []NotificationDigestInterval{
+		NotificationDigestIntervalHourly,
+		NotificationDigestIntervalDaily,
+	}
+}
+
 type NotificationMessageStatus string
 
 const (
@@ -871,6 +932,7 @@ const (
 	NotificationMessageStatusTemporaryFailure NotificationMessageStatus = "temporary_failure"
 	NotificationMessageStatusUnknown          NotificationMessageStatus = "unknown"
 	NotificationMessageStatusInhibited        NotificationMessageStatus = "inhibited"
+	NotificationMessageStatusBatched          NotificationMessageStatus = "batched"
 )
 
 func (e *NotificationMessageStatus) Scan(src interface{}) error {
@@ -916,7 +978,8 @@ func (e NotificationMessageStatus) Valid() bool {
 		NotificationMessageStatusPermanentFailure,
 		NotificationMessageStatusTemporaryFailure,
 		NotificationMessageStatusUnknown,
-		NotificationMessageStatusInhibited:
+		NotificationMessageStatusInhibited,
+		NotificationMessageStatusBatched:
 		return true
 	}
 	return false
@@ -931,6 +994,7 @@ func AllNotificationMessageStatusValues() []NotificationMessageStatus {
 		NotificationMessageStatusTemporaryFailure,
 		NotificationMessageStatusUnknown,
 		NotificationMessageStatusInhibited,
+		NotificationMessageStatusBatched,
 	}
 }
 
@@ -940,6 +1004,9 @@ const (
 	NotificationMethodSmtp    NotificationMethod = "smtp"
 	NotificationMethodWebhook NotificationMethod = "webhook"
 	NotificationMethodInbox   NotificationMethod = "inbox"
+	NotificationMethodSlack   NotificationMethod = "slack"
+	NotificationMethodMatrix  NotificationMethod = "matrix"
+	NotificationMethodTeams   NotificationMethod = "teams"
 )
 
 func (e *NotificationMethod) Scan(src interface{}) error {
@@ -981,7 +1048,10 @@ func (e NotificationMethod) Valid() bool {
 	switch e {
 	case NotificationMethodSmtp,
 		NotificationMethodWebhook,
-		NotificationMethodInbox:
+		NotificationMethodInbox,
+		NotificationMethodSlack,
+		NotificationMethodMatrix,
+		NotificationMethodTeams:
 		return true
 	}
 	return false
@@ -992,6 +1062,67 @@ func AllNotificationMethodValues() []NotificationMethod {
 		NotificationMethodSmtp,
 		NotificationMethodWebhook,
 		NotificationMethodInbox,
+		NotificationMethodSlack,
+		NotificationMethodMatrix,
+		NotificationMethodTeams,
+	}
+}
+
+type NotificationRuleSource string
+
+const (
+	NotificationRuleSourceAuditLog       NotificationRuleSource = "audit_log"
+	NotificationRuleSourceWorkspaceBuild NotificationRuleSource = "workspace_build"
+)
+
+func (e *NotificationRuleSource) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = NotificationRuleSource(s)
+	case string:
+		*e = NotificationRuleSource(s)
+	default:
+		return fmt.Errorf("unsupported scan type for NotificationRuleSource: %T", src)
+	}
+	return nil
+}
+
+type NullNotificationRuleSource struct {
+	NotificationRuleSource NotificationRuleSource `json:"notification_rule_source"`
+	Valid                  bool                   `json:"valid"` // Valid is true if NotificationRuleSource is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullNotificationRuleSource) Scan(value interface{}) error {
+	if value == nil {
+		ns.NotificationRuleSource, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.NotificationRuleSource.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullNotificationRuleSource) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.NotificationRuleSource), nil
+}
+
+func (e NotificationRuleSource) Valid() bool {
+	switch e {
+	case NotificationRuleSourceAuditLog,
+		NotificationRuleSourceWorkspaceBuild:
+		return true
+	}
+	return false
+}
+
+func AllNotificationRuleSourceValues() []NotificationRuleSource {
+	return []NotificationRuleSource{
+		NotificationRuleSourceAuditLog,
+		NotificationRuleSourceWorkspaceBuild,
 	}
 }
 
@@ -1555,6 +1686,74 @@ func AllProvisionerDaemonStatusValues() []ProvisionerDaemonStatus {
 	}
 }
 
+// The priority class of a provisioner job. Pending jobs of a higher priority are acquired first. Values are ordered from the lowest to the highest priority.
+type ProvisionerJobPriority string
+
+const (
+	ProvisionerJobPriorityDriftCheck     ProvisionerJobPriority = "drift_check"
+	ProvisionerJobPriorityTemplateImport ProvisionerJobPriority = "template_import"
+	ProvisionerJobPriorityPrebuild       ProvisionerJobPriority = "prebuild"
+	ProvisionerJobPriorityScheduled      ProvisionerJobPriority = "scheduled"
+	ProvisionerJobPriorityInteractive    ProvisionerJobPriority = "interactive"
+)
+
+func (e *ProvisionerJobPriority) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = ProvisionerJobPriority(s)
+	case string:
+		*e = ProvisionerJobPriority(s)
+	default:
+		return fmt.Errorf("unsupported scan type for ProvisionerJobPriority: %T", src)
+	}
+	return nil
+}
+
+type NullProvisionerJobPriority struct {
+	ProvisionerJobPriority ProvisionerJobPriority `json:"provisioner_job_priority"`
+	Valid                  bool                   `json:"valid"` // Valid is true if ProvisionerJobPriority is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullProvisionerJobPriority) Scan(value interface{}) error {
+	if value == nil {
+		ns.ProvisionerJobPriority, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.ProvisionerJobPriority.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullProvisionerJobPriority) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.ProvisionerJobPriority), nil
+}
+
+func (e ProvisionerJobPriority) Valid() bool {
+	switch e {
+	case ProvisionerJobPriorityDriftCheck,
+		ProvisionerJobPriorityTemplateImport,
+		ProvisionerJobPriorityPrebuild,
+		ProvisionerJobPriorityScheduled,
+		ProvisionerJobPriorityInteractive:
+		return true
+	}
+	return false
+}
+
+func AllProvisionerJobPriorityValues() []ProvisionerJobPriority {
+	return []ProvisionerJobPriority{
+		ProvisionerJobPriorityDriftCheck,
+		ProvisionerJobPriorityTemplateImport,
+		ProvisionerJobPriorityPrebuild,
+		ProvisionerJobPriorityScheduled,
+		ProvisionerJobPriorityInteractive,
+	}
+}
+
 // Computed status of a provisioner job. Jobs could be stuck in a hung state, these states do not guarantee any transition to another state.
 type ProvisionerJobStatus string
 
@@ -1699,6 +1898,7 @@ const (
 	ProvisionerJobTypeTemplateVersionImport ProvisionerJobType = "template_version_import"
 	ProvisionerJobTypeWorkspaceBuild        ProvisionerJobType = "workspace_build"
 	ProvisionerJobTypeTemplateVersionDryRun ProvisionerJobType = "template_version_dry_run"
+	ProvisionerJobTypeWorkspaceDriftCheck   ProvisionerJobType = "workspace_drift_check"
 )
 
 func (e *ProvisionerJobType) Scan(src interface{}) error {
@@ -1740,7 +1940,8 @@ func (e ProvisionerJobType) Valid() bool {
 	switch e {
 	case ProvisionerJobTypeTemplateVersionImport,
 		ProvisionerJobTypeWorkspaceBuild,
-		ProvisionerJobTypeTemplateVersionDryRun:
+		ProvisionerJobTypeTemplateVersionDryRun,
+		ProvisionerJobTypeWorkspaceDriftCheck:
 		return true
 	}
 	return false
@@ -1751,6 +1952,7 @@ func AllProvisionerJobTypeValues() []ProvisionerJobType {
 		ProvisionerJobTypeTemplateVersionImport,
 		ProvisionerJobTypeWorkspaceBuild,
 		ProvisionerJobTypeTemplateVersionDryRun,
+		ProvisionerJobTypeWorkspaceDriftCheck,
 	}
 }
 
@@ -1814,6 +2016,8 @@ type ProvisionerType string
 const (
 	ProvisionerTypeEcho      ProvisionerType = "echo"
 	ProvisionerTypeTerraform ProvisionerType = "terraform"
+	ProvisionerTypeTofu      ProvisionerType = "tofu"
+	ProvisionerTypeExternal  ProvisionerType = "external"
 )
 
 func (e *ProvisionerType) Scan(src interface{}) error {
@@ -1854,7 +2058,9 @@ func (ns NullProvisionerType) Value() (driver.Value, error) {
 func (e ProvisionerType) Valid() bool {
 	switch e {
 	case ProvisionerTypeEcho,
-		ProvisionerTypeTerraform:
+		ProvisionerTypeTerraform,
+		ProvisionerTypeTofu,
+		ProvisionerTypeExternal:
 		return true
 	}
 	return false
@@ -1864,6 +2070,8 @@ func AllProvisionerTypeValues() []ProvisionerType {
 	return []ProvisionerType{
 		ProvisionerTypeEcho,
 		ProvisionerTypeTerraform,
+		ProvisionerTypeTofu,
+		ProvisionerTypeExternal,
 	}
 }
 
@@ -2689,6 +2897,122 @@ func AllWorkspaceAppStatusStateValues() []WorkspaceAppStatusState {
 	}
 }
 
+type WorkspaceSnapshotStorage string
+
+const (
+	WorkspaceSnapshotStorageDatabase WorkspaceSnapshotStorage = "database"
+	WorkspaceSnapshotStorageS3       WorkspaceSnapshotStorage = "s3"
+)
+
+func (e *WorkspaceSnapshotStorage) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = WorkspaceSnapshotStorage(s)
+	case string:
+		*e = WorkspaceSnapshotStorage(s)
+	default:
+		return fmt.Errorf("unsupported scan type for WorkspaceSnapshotStorage: %T", src)
+	}
+	return nil
+}
+
+type NullWorkspaceSnapshotStorage struct {
+	WorkspaceSnapshotStorage WorkspaceSnapshotStorage `json:"workspace_snapshot_storage"`
+	Valid                    bool                     `json:"valid"` // Valid is true if WorkspaceSnapshotStorage is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullWorkspaceSnapshotStorage) Scan(value interface{}) error {
+	if value == nil {
+		ns.WorkspaceSnapshotStorage, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.WorkspaceSnapshotStorage.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullWorkspaceSnapshotStorage) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.WorkspaceSnapshotStorage), nil
+}
+
+func (e WorkspaceSnapshotStorage) Valid() bool {
+	switch e {
+	case WorkspaceSnapshotStorageDatabase,
+		WorkspaceSnapshotStorageS3:
+		return true
+	}
+	return false
+}
+
+func AllWorkspaceSnapshotStorageValues() []WorkspaceSnapshotStorage {
+	return []WorkspaceSnapshotStorage{
+		WorkspaceSnapshotStorageDatabase,
+		WorkspaceSnapshotStorageS3,
+	}
+}
+
+type WorkspaceSnapshotTrigger string
+
+const (
+	WorkspaceSnapshotTriggerStop     WorkspaceSnapshotTrigger = "stop"
+	WorkspaceSnapshotTriggerSchedule WorkspaceSnapshotTrigger = "schedule"
+)
+
+func (e *WorkspaceSnapshotTrigger) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = WorkspaceSnapshotTrigger(s)
+	case string:
+		*e = WorkspaceSnapshotTrigger(s)
+	default:
+		return fmt.Errorf("unsupported scan type for WorkspaceSnapshotTrigger: %T", src)
+	}
+	return nil
+}
+
+type NullWorkspaceSnapshotTrigger struct {
+	WorkspaceSnapshotTrigger WorkspaceSnapshotTrigger `json:"workspace_snapshot_trigger"`
+	Valid                    bool                     `json:"valid"` // Valid is true if WorkspaceSnapshotTrigger is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullWorkspaceSnapshotTrigger) Scan(value interface{}) error {
+	if value == nil {
+		ns.WorkspaceSnapshotTrigger, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.WorkspaceSnapshotTrigger.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullWorkspaceSnapshotTrigger) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.WorkspaceSnapshotTrigger), nil
+}
+
+func (e WorkspaceSnapshotTrigger) Valid() bool {
+	switch e {
+	case WorkspaceSnapshotTriggerStop,
+		WorkspaceSnapshotTriggerSchedule:
+		return true
+	}
+	return false
+}
+
+func AllWorkspaceSnapshotTriggerValues() []WorkspaceSnapshotTrigger {
+	return []WorkspaceSnapshotTrigger{
+		WorkspaceSnapshotTriggerStop,
+		WorkspaceSnapshotTriggerSchedule,
+	}
+}
+
 type WorkspaceTransition string
 
 const (
@@ -2782,6 +3106,63 @@ type AuditLog struct {
 	AdditionalFields json.RawMessage `db:"additional_fields" json:"additional_fields"`
 	RequestID        uuid.UUID       `db:"request_id" json:"request_id"`
 	ResourceIcon     string          `db:"resource_icon" json:"resource_icon"`
+	// ID of the transaction which inserted the audit log. NULL for audit logs inserted before streaming was introduced.
+	XactID sql.NullInt64 `db:"xact_id" json:"xact_id"`
+}
+
+// Compressed files expired audit logs were archived to before they were deleted.
+type AuditLogArchive struct {
+	ID        uuid.UUID `db:"id" json:"id"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	FileName  string    `db:"file_name" json:"file_name"`
+	// The archive file in the files table, so it can be restored from any replica.
+	FileID        uuid.UUID `db:"file_id" json:"file_id"`
+	OldestTime    time.Time `db:"oldest_time" json:"oldest_time"`
+	NewestTime    time.Time `db:"newest_time" json:"newest_time"`
+	AuditLogCount int32     `db:"audit_log_count" json:"audit_log_count"`
+	// SHA-256 of the archive file, verified when it is restored.
+	Sha256 []byte `db:"sha256" json:"sha256"`
+	// When the audit logs of the archive were restored. Restored audit logs are deleted again after a while, without being archived again.
+	RestoredAt sql.NullTime `db:"restored_at" json:"restored_at"`
+}
+
+// The archive of each archived audit log, so the hash chain tells archived audit logs apart from deleted ones.
+type AuditLogArchiveEntry struct {
+	AuditLogID uuid.UUID `db:"audit_log_id" json:"audit_log_id"`
+	ArchiveID  uuid.UUID `db:"archive_id" json:"archive_id"`
+}
+
+// Signed heads of the audit log hash chain. Rewriting the chain before a checkpoint requires the signing key.
+type AuditLogCheckpoint struct {
+	Sequence int64  `db:"sequence" json:"sequence"`
+	Hash     []byte `db:"hash" json:"hash"`
+	// Sequence of the audit_log_checkpoint crypto key the signature was made with.
+	KeySequence int32     `db:"key_sequence" json:"key_sequence"`
+	Signature   []byte    `db:"signature" json:"signature"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+}
+
+// Position of each audit log streaming sink. Audit logs ordered after (last_time, last_id) have not been delivered to the sink yet.
+type AuditLogExportCursor struct {
+	Sink       string    `db:"sink" json:"sink"`
+	LastXactID int64     `db:"last_xact_id" json:"last_xact_id"`
+	LastID     uuid.UUID `db:"last_id" json:"last_id"`
+	UpdatedAt  time.Time `db:"updated_at" json:"updated_at"`
+	// Replica which delivers to the sink until the lease expires.
+	LeasedBy       uuid.NullUUID `db:"leased_by" json:"leased_by"`
+	LeaseExpiresAt sql.NullTime  `db:"lease_expires_at" json:"lease_expires_at"`
+}
+
+// Hash chain over the audit logs in the order of the transactions which inserted them. Audit logs are not referenced with a foreign key, so their deletion is detected.
+type AuditLogHash struct {
+	Sequence     int64     `db:"sequence" json:"sequence"`
+	AuditLogID   uuid.UUID `db:"audit_log_id" json:"audit_log_id"`
+	AuditLogTime time.Time `db:"audit_log_time" json:"audit_log_time"`
+	// ID of the transaction which inserted the audit log. The chain is extended with the audit logs ordered after the last entry.
+	AuditLogXactID int64  `db:"audit_log_xact_id" json:"audit_log_xact_id"`
+	PreviousHash   []byte `db:"previous_hash" json:"previous_hash"`
+	// SHA-256 of the previous hash and the audit log.
+	Hash []byte `db:"hash" json:"hash"`
 }
 
 type CryptoKey struct {
@@ -2927,6 +3308,15 @@ type License struct {
 	UUID uuid.UUID `db:"uuid" json:"uuid"`
 }
 
+// Notifications users chose to receive as a periodic digest rather than individually.
+type NotificationDigestPreference struct {
+	UserID                 uuid.UUID                  `db:"user_id" json:"user_id"`
+	NotificationTemplateID uuid.UUID                  `db:"notification_template_id" json:"notification_template_id"`
+	DigestInterval         NotificationDigestInterval `db:"digest_interval" json:"digest_interval"`
+	CreatedAt              time.Time                  `db:"created_at" json:"created_at"`
+	UpdatedAt              time.Time                  `db:"updated_at" json:"updated_at"`
+}
+
 type NotificationMessage struct {
 	ID                     uuid.UUID                 `db:"id" json:"id"`
 	NotificationTemplateID uuid.UUID                 `db:"notification_template_id" json:"notification_template_id"`
@@ -2961,6 +3351,30 @@ type NotificationReportGeneratorLog struct {
 	LastGeneratedAt        time.Time `db:"last_generated_at" json:"last_generated_at"`
 }
 
+// User-defined notifications sent when audit log entries or workspace builds match a search query.
+type NotificationRule struct {
+	ID             uuid.UUID `db:"id" json:"id"`
+	OrganizationID uuid.UUID `db:"organization_id" json:"organization_id"`
+	// The user who created the rule, notifications are sent to them.
+	UserID uuid.UUID              `db:"user_id" json:"user_id"`
+	Name   string                 `db:"name" json:"name"`
+	Source NotificationRuleSource `db:"source" json:"source"`
+	// Search query in the syntax of the audit log or workspace build search, depending on the source.
+	Query string `db:"query" json:"query"`
+	// Dispatch method to send notifications with. NULL uses the method of the notification template, or the deployment default.
+	Method NullNotificationMethod `db:"method" json:"method"`
+	// Minimum number of matching events which trigger the rule.
+	Threshold int32 `db:"threshold" json:"threshold"`
+	// Period matching events are counted over. Once triggered, the rule is not triggered again until the window passed. 0 counts the events since the previous evaluation.
+	WindowSeconds int32     `db:"window_seconds" json:"window_seconds"`
+	Enabled       bool      `db:"enabled" json:"enabled"`
+	CreatedAt     time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt     time.Time `db:"updated_at" json:"updated_at"`
+	// Events up to this time have been evaluated.
+	LastEvaluatedAt time.Time    `db:"last_evaluated_at" json:"last_evaluated_at"`
+	LastTriggeredAt sql.NullTime `db:"last_triggered_at" json:"last_triggered_at"`
+}
+
 // Templates from which to create notification messages.
 type NotificationTemplate struct {
 	ID            uuid.UUID      `db:"id" json:"id"`
@@ -2973,6 +3387,8 @@ type NotificationTemplate struct {
 	Method           NullNotificationMethod   `db:"method" json:"method"`
 	Kind             NotificationTemplateKind `db:"kind" json:"kind"`
 	EnabledByDefault bool                     `db:"enabled_by_default" json:"enabled_by_default"`
+	// Critical notifications are delivered immediately, they are neither batched into digests nor deferred by quiet hours.
+	Critical bool `db:"critical" json:"critical"`
 }
 
 // A table used to configure apps that can use Coder as an OAuth2 provider, the reverse of what we are calling external authentication.
@@ -3046,6 +3462,17 @@ type Organization struct {
 	Deleted     bool      `db:"deleted" json:"deleted"`
 }
 
+// Days on which workspaces of an organization are not autostarted, imported from iCalendar files.
+type OrganizationHoliday struct {
+	ID             uuid.UUID `db:"id" json:"id"`
+	OrganizationID uuid.UUID `db:"organization_id" json:"organization_id"`
+	// Name of the calendar the holiday was imported from. Importing a calendar again replaces its holidays.
+	Calendar  string    `db:"calendar" json:"calendar"`
+	Date      time.Time `db:"date" json:"date"`
+	Name      string    `db:"name" json:"name"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
 type OrganizationMember struct {
 	UserID         uuid.UUID `db:"user_id" json:"user_id"`
 	OrganizationID uuid.UUID `db:"organization_id" json:"organization_id"`
@@ -3086,6 +3513,41 @@ type ParameterValue struct {
 	DestinationScheme ParameterDestinationScheme `db:"destination_scheme" json:"destination_scheme"`
 }
 
+// Rego policies evaluated against the Terraform plans of workspace builds of an organization before they are applied.
+type PlanPolicy struct {
+	ID             uuid.UUID `db:"id" json:"id"`
+	OrganizationID uuid.UUID `db:"organization_id" json:"organization_id"`
+	Name           string    `db:"name" json:"name"`
+	Description    string    `db:"description" json:"description"`
+	// Rego module of the policy. Messages of its deny rules fail the build, messages of its warn rules are written to the build logs.
+	Module    string    `db:"module" json:"module"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// Terraform provider packages installed by template imports, served to provisioner daemons by the provider network mirror.
+type ProviderMirrorArchive struct {
+	// The organization of the template imports that installed the package. Packages are only served to jobs of the same organization, so daemons of one organization cannot replace the providers of another.
+	OrganizationID uuid.UUID `db:"organization_id" json:"organization_id"`
+	Hostname       string    `db:"hostname" json:"hostname"`
+	Namespace      string    `db:"namespace" json:"namespace"`
+	Type           string    `db:"type" json:"type"`
+	Version        string    `db:"version" json:"version"`
+	Platform       string    `db:"platform" json:"platform"`
+	// The "h1:" package hash of the archive contents, as it appears in dependency lock files.
+	Hash string `db:"hash" json:"hash"`
+	// The zip archive of the package. Files are content-addressed, so packages that are archived identically share a file.
+	FileID    uuid.UUID `db:"file_id" json:"file_id"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+// Tokens that authenticate a provisioner job to the provider mirror while the job is running.
+type ProviderMirrorToken struct {
+	JobID        uuid.UUID `db:"job_id" json:"job_id"`
+	HashedSecret []byte    `db:"hashed_secret" json:"hashed_secret"`
+	CreatedAt    time.Time `db:"created_at" json:"created_at"`
+}
+
 type ProvisionerDaemon struct {
 	ID           uuid.UUID         `db:"id" json:"id"`
 	CreatedAt    time.Time         `db:"created_at" json:"created_at"`
@@ -3121,7 +3583,10 @@ type ProvisionerJob struct {
 	ErrorCode      sql.NullString           `db:"error_code" json:"error_code"`
 	TraceMetadata  pqtype.NullRawMessage    `db:"trace_metadata" json:"trace_metadata"`
 	// Computed column to track the status of the job.
-	JobStatus ProvisionerJobStatus `db:"job_status" json:"job_status"`
+	JobStatus ProvisionerJobStatus   `db:"job_status" json:"job_status"`
+	Priority  ProvisionerJobPriority `db:"priority" json:"priority"`
+	// The template the job belongs to, if any. Used to share provisioner daemons fairly between templates.
+	TemplateID uuid.NullUUID `db:"template_id" json:"template_id"`
 }
 
 type ProvisionerJobLog struct {
@@ -3270,6 +3735,8 @@ type Template struct {
 	ActivityBump                  int64           `db:"activity_bump" json:"activity_bump"`
 	MaxPortSharingLevel           AppSharingLevel `db:"max_port_sharing_level" json:"max_port_sharing_level"`
 	UseClassicParameterFlow       bool            `db:"use_classic_parameter_flow" json:"use_classic_parameter_flow"`
+	DriftCheckIntervalMs          int64           `db:"drift_check_interval_ms" json:"drift_check_interval_ms"`
+	ProvisionerVersion            string          `db:"provisioner_version" json:"provisioner_version"`
 	CreatedByAvatarURL            string          `db:"created_by_avatar_url" json:"created_by_avatar_url"`
 	CreatedByUsername             string          `db:"created_by_username" json:"created_by_username"`
 	CreatedByName                 string          `db:"created_by_name" json:"created_by_name"`
@@ -3278,6 +3745,29 @@ type Template struct {
 	OrganizationIcon              string          `db:"organization_icon" json:"organization_icon"`
 }
 
+// Rules that automatically share listening ports of workspaces created from a template.
+// The time of day workspaces of a template are stopped by, per day of the week.
+type TemplateAutostopWindow struct {
+	TemplateID uuid.UUID `db:"template_id" json:"template_id"`
+	// Day of the week, where 0 is Sunday.
+	Weekday int16 `db:"weekday" json:"weekday"`
+	// Minutes after midnight workspaces are stopped at, in the timezone of the quiet hours schedule of the workspace owner.
+	StopMinute int32 `db:"stop_minute" json:"stop_minute"`
+}
+
+type TemplatePortShareRule struct {
+	ID         uuid.UUID `db:"id" json:"id"`
+	TemplateID uuid.UUID `db:"template_id" json:"template_id"`
+	// First port, inclusive, of the range matched by the rule.
+	PortStart int32 `db:"port_start" json:"port_start"`
+	// Last port, inclusive, of the range matched by the rule.
+	PortEnd    int32             `db:"port_end" json:"port_end"`
+	ShareLevel AppSharingLevel   `db:"share_level" json:"share_level"`
+	Protocol   PortShareProtocol `db:"protocol" json:"protocol"`
+	// Optional human readable label for ports matched by the rule.
+	Label string `db:"label" json:"label"`
+}
+
 type TemplateTable struct {
 	ID              uuid.UUID       `db:"id" json:"id"`
 	CreatedAt       time.Time       `db:"created_at" json:"created_at"`
@@ -3318,6 +3808,10 @@ type TemplateTable struct {
 	MaxPortSharingLevel AppSharingLevel `db:"max_port_sharing_level" json:"max_port_sharing_level"`
 	// Determines whether to default to the dynamic parameter creation flow for this template or continue using the legacy classic parameter creation flow.This is a template wide setting, the template admin can revert to the classic flow if there are any issues. An escape hatch is required, as workspace creation is a core workflow and cannot break. This column will be removed when the dynamic parameter creation flow is stable.
 	UseClassicParameterFlow bool `db:"use_classic_parameter_flow" json:"use_classic_parameter_flow"`
+	// How often running workspaces of the template are checked for changes made outside of Coder. Zero disables drift checks.
+	DriftCheckIntervalMs int64 `db:"drift_check_interval_ms" json:"drift_check_interval_ms"`
+	// The version of the provisioner binary that jobs of the template must run with. Empty uses the default version of the provisioner daemon.
+	ProvisionerVersion string `db:"provisioner_version" json:"provisioner_version"`
 }
 
 // Records aggregated usage statistics for templates/users. All usage is rounded up to the nearest minute.
@@ -3512,6 +4006,30 @@ type User struct {
 	IsSystem bool `db:"is_system" json:"is_system"`
 }
 
+// Chat accounts users linked to receive notifications as direct messages.
+// The time of day workspaces of a user are stopped by, per day of the week. Replaces the windows of templates which allow users to set their own autostop.
+type UserAutostopWindow struct {
+	UserID uuid.UUID `db:"user_id" json:"user_id"`
+	// Day of the week, where 0 is Sunday.
+	Weekday int16 `db:"weekday" json:"weekday"`
+	// Minutes after midnight workspaces are stopped at, in the timezone of the quiet hours schedule of the user.
+	StopMinute int32 `db:"stop_minute" json:"stop_minute"`
+}
+
+type UserChatIdentity struct {
+	UserID uuid.UUID          `db:"user_id" json:"user_id"`
+	Method NotificationMethod `db:"method" json:"method"`
+	// The Slack member ID, Matrix user ID or Microsoft Teams user principal name of the user.
+	Identity  string    `db:"identity" json:"identity"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+	// When the user proved they own the identity. Notifications are only delivered to verified identities.
+	VerifiedAt sql.NullTime `db:"verified_at" json:"verified_at"`
+	// SHA-256 hash of the one-time code sent to the identity, until it is verified.
+	VerificationCodeHash  []byte       `db:"verification_code_hash" json:"verification_code_hash"`
+	VerificationExpiresAt sql.NullTime `db:"verification_expires_at" json:"verification_expires_at"`
+}
+
 type UserConfig struct {
 	UserID uuid.UUID `db:"user_id" json:"user_id"`
 	Key    string    `db:"key" json:"key"`
@@ -3540,6 +4058,20 @@ type UserLink struct {
 	Claims UserLinkClaims `db:"claims" json:"claims"`
 }
 
+// When users want to receive notifications, in their timezone.
+type UserNotificationSchedule struct {
+	UserID uuid.UUID `db:"user_id" json:"user_id"`
+	// IANA timezone the times are in.
+	Timezone string `db:"timezone" json:"timezone"`
+	// Minute of the day quiet hours start at, non-critical notifications are deferred until they end. NULL disables quiet hours.
+	QuietHoursStart sql.NullInt16 `db:"quiet_hours_start" json:"quiet_hours_start"`
+	// Minute of the day quiet hours end at, it is before the start when quiet hours span midnight.
+	QuietHoursEnd sql.NullInt16 `db:"quiet_hours_end" json:"quiet_hours_end"`
+	// Minute of the day daily digests are delivered at.
+	DailyDigestTime int16     `db:"daily_digest_time" json:"daily_digest_time"`
+	UpdatedAt       time.Time `db:"updated_at" json:"updated_at"`
+}
+
 // Tracks the history of user status changes
 type UserStatusChange struct {
 	ID        uuid.UUID  `db:"id" json:"id"`
@@ -3709,6 +4241,10 @@ type WorkspaceAgentPortShare struct {
 	Port        int32             `db:"port" json:"port"`
 	ShareLevel  AppSharingLevel   `db:"share_level" json:"share_level"`
 	Protocol    PortShareProtocol `db:"protocol" json:"protocol"`
+	// Whether the port share was created automatically by a template port share rule.
+	AutoShared bool `db:"auto_shared" json:"auto_shared"`
+	// Label of the template port share rule that created the port share, if any.
+	Label string `db:"label" json:"label"`
 }
 
 type WorkspaceAgentScript struct {
@@ -3881,6 +4417,20 @@ type WorkspaceBuildParameter struct {
 	Value string `db:"value" json:"value"`
 }
 
+// The Terraform state each workspace build ended with, kept to browse, diff and roll back to earlier states.
+type WorkspaceBuildState struct {
+	WorkspaceBuildID uuid.UUID `db:"workspace_build_id" json:"workspace_build_id"`
+	WorkspaceID      uuid.UUID `db:"workspace_id" json:"workspace_id"`
+	State            []byte    `db:"state" json:"state"`
+	// The ID of the key used to encrypt the state. If this is NULL, the state is not encrypted
+	StateKeyID sql.NullString `db:"state_key_id" json:"state_key_id"`
+	// The hex encoded SHA256 hash of the unencrypted state, to compare states without decrypting them.
+	Hash string `db:"hash" json:"hash"`
+	// The size of the unencrypted state in bytes.
+	Size      int64     `db:"size" json:"size"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
 type WorkspaceBuildTable struct {
 	ID                      uuid.UUID           `db:"id" json:"id"`
 	CreatedAt               time.Time           `db:"created_at" json:"created_at"`
@@ -3912,6 +4462,32 @@ type WorkspaceLatestBuild struct {
 	JobStatus               ProvisionerJobStatus `db:"job_status" json:"job_status"`
 }
 
+// Records the uptime and cost of workspaces in hourly buckets, rolled up from workspace builds.
+type WorkspaceCostUsage struct {
+	// Start time of the hour of usage.
+	StartTime      time.Time `db:"start_time" json:"start_time"`
+	WorkspaceID    uuid.UUID `db:"workspace_id" json:"workspace_id"`
+	TemplateID     uuid.UUID `db:"template_id" json:"template_id"`
+	UserID         uuid.UUID `db:"user_id" json:"user_id"`
+	OrganizationID uuid.UUID `db:"organization_id" json:"organization_id"`
+	// Seconds the workspace was running during the hour.
+	UsageSeconds int32 `db:"usage_seconds" json:"usage_seconds"`
+	// Cost of the running resources of the workspace during the hour, in millionths of the currency unit.
+	CostMicros int64 `db:"cost_micros" json:"cost_micros"`
+}
+
+// Plans of running workspace builds, to find changes made to their resources outside of Coder.
+type WorkspaceDriftCheck struct {
+	ID               uuid.UUID    `db:"id" json:"id"`
+	WorkspaceID      uuid.UUID    `db:"workspace_id" json:"workspace_id"`
+	WorkspaceBuildID uuid.UUID    `db:"workspace_build_id" json:"workspace_build_id"`
+	JobID            uuid.UUID    `db:"job_id" json:"job_id"`
+	CreatedAt        time.Time    `db:"created_at" json:"created_at"`
+	CompletedAt      sql.NullTime `db:"completed_at" json:"completed_at"`
+	// The resources which changed outside of Coder, as reported by Terraform. NULL until the check completes.
+	DriftedResources pqtype.NullRawMessage `db:"drifted_resources" json:"drifted_resources"`
+}
+
 type WorkspaceModule struct {
 	ID         uuid.UUID           `db:"id" json:"id"`
 	JobID      uuid.UUID           `db:"job_id" json:"job_id"`
@@ -3976,6 +4552,8 @@ type WorkspaceResource struct {
 	InstanceType sql.NullString      `db:"instance_type" json:"instance_type"`
 	DailyCost    int32               `db:"daily_cost" json:"daily_cost"`
 	ModulePath   sql.NullString      `db:"module_path" json:"module_path"`
+	// Cost per hour of the resource while the workspace is running, in millionths of the currency unit. Parsed from the hourly_cost metadata item of the resource.
+	HourlyCostMicros int64 `db:"hourly_cost_micros" json:"hourly_cost_micros"`
 }
 
 type WorkspaceResourceMetadatum struct {
@@ -3986,6 +4564,44 @@ type WorkspaceResourceMetadatum struct {
 	ID                  int64          `db:"id" json:"id"`
 }
 
+// Compressed archives of directories in a workspace, uploaded by the workspace agent.
+type WorkspaceSnapshot struct {
+	ID          uuid.UUID `db:"id" json:"id"`
+	WorkspaceID uuid.UUID `db:"workspace_id" json:"workspace_id"`
+	AgentName   string    `db:"agent_name" json:"agent_name"`
+	BuildNumber int32     `db:"build_number" json:"build_number"`
+	// The snapshot this snapshot is incremental to. Restoring requires every snapshot in the chain. NULL for full snapshots.
+	ParentID uuid.NullUUID            `db:"parent_id" json:"parent_id"`
+	Trigger  WorkspaceSnapshotTrigger `db:"trigger" json:"trigger"`
+	Storage  WorkspaceSnapshotStorage `db:"storage" json:"storage"`
+	// The file ID for database storage or the object key for S3 storage.
+	ObjectKey string `db:"object_key" json:"object_key"`
+	SizeBytes int64  `db:"size_bytes" json:"size_bytes"`
+	// The number of files in the workspace at the time of the snapshot, including files unchanged since the parent snapshot.
+	FileCount int32     `db:"file_count" json:"file_count"`
+	Paths     []string  `db:"paths" json:"paths"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+// Pending snapshot restores. The agent of the first build after after_build_number restores the snapshot on startup.
+type WorkspaceSnapshotRestore struct {
+	WorkspaceID      uuid.UUID `db:"workspace_id" json:"workspace_id"`
+	SnapshotID       uuid.UUID `db:"snapshot_id" json:"snapshot_id"`
+	AfterBuildNumber int32     `db:"after_build_number" json:"after_build_number"`
+	RequestedBy      uuid.UUID `db:"requested_by" json:"requested_by"`
+	RequestedAt      time.Time `db:"requested_at" json:"requested_at"`
+}
+
+// Template version dry-runs planned against the state of a workspace, to preview the changes an update of the workspace makes to its resources.
+type WorkspaceUpdatePreview struct {
+	JobID       uuid.UUID    `db:"job_id" json:"job_id"`
+	WorkspaceID uuid.UUID    `db:"workspace_id" json:"workspace_id"`
+	CreatedAt   time.Time    `db:"created_at" json:"created_at"`
+	CompletedAt sql.NullTime `db:"completed_at" json:"completed_at"`
+	// The resources the update would create, update, replace or delete. NULL until the dry-run completes.
+	ResourceChanges pqtype.NullRawMessage `db:"resource_changes" json:"resource_changes"`
+}
+
 type WorkspaceTable struct {
 	ID                uuid.UUID        `db:"id" json:"id"`
 	CreatedAt         time.Time        `db:"created_at" json:"created_at"`
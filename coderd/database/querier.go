@@ -12,11 +12,19 @@ import (
 )
 
 type sqlcQuerier interface {
+	// AcquireAuditLogExportCursor leases the cursor of a streaming sink to a
+	// replica, unless another replica holds an unexpired lease. A new sink starts
+	// with the audit logs of the transactions which are still running.
+	AcquireAuditLogExportCursor(ctx context.Context, arg AcquireAuditLogExportCursorParams) (AuditLogExportCursor, error)
 	// Blocks until the lock is acquired.
 	//
 	// This must be called from within a transaction. The lock will be automatically
 	// released when the transaction ends.
 	AcquireLock(ctx context.Context, pgAdvisoryXactLock int64) error
+	// Acquires the lease for batched messages whose digest is due, grouped by recipient and method. If the lease expires
+	// before the digest is enqueued, the messages are acquired by AcquireNotificationMessages and batched again.
+	//
+	AcquireNotificationDigestMessages(ctx context.Context, arg AcquireNotificationDigestMessagesParams) ([]AcquireNotificationDigestMessagesRow, error)
 	// Acquires the lease for a given count of notification messages, to enable concurrent dequeuing and subsequent sending.
 	// Only rows that aren't already leased (or ones which are leased but have exceeded their lease period) are returned.
 	//
@@ -58,6 +66,10 @@ type sqlcQuerier interface {
 	ArchiveUnusedTemplateVersions(ctx context.Context, arg ArchiveUnusedTemplateVersionsParams) ([]uuid.UUID, error)
 	BatchUpdateWorkspaceLastUsedAt(ctx context.Context, arg BatchUpdateWorkspaceLastUsedAtParams) error
 	BatchUpdateWorkspaceNextStartAt(ctx context.Context, arg BatchUpdateWorkspaceNextStartAtParams) error
+	// Returns leased messages to the queue without counting a delivery attempt. Pending messages are delivered individually
+	// and batched messages in a digest once deliver_after has passed.
+	//
+	BulkDeferNotificationMessages(ctx context.Context, arg BulkDeferNotificationMessagesParams) (int64, error)
 	BulkMarkNotificationMessagesFailed(ctx context.Context, arg BulkMarkNotificationMessagesFailedParams) (int64, error)
 	BulkMarkNotificationMessagesSent(ctx context.Context, arg BulkMarkNotificationMessagesSentParams) (int64, error)
 	ClaimPrebuiltWorkspace(ctx context.Context, arg ClaimPrebuiltWorkspaceParams) (ClaimPrebuiltWorkspaceRow, error)
@@ -80,6 +92,7 @@ type sqlcQuerier interface {
 	// be recreated.
 	DeleteAllWebpushSubscriptions(ctx context.Context) error
 	DeleteApplicationConnectAPIKeysByUserID(ctx context.Context, userID uuid.UUID) error
+	DeleteAuditLogsByIDs(ctx context.Context, ids []uuid.UUID) error
 	DeleteCoordinator(ctx context.Context, id uuid.UUID) error
 	DeleteCryptoKey(ctx context.Context, arg DeleteCryptoKeyParams) (CryptoKey, error)
 	DeleteCustomRole(ctx context.Context, arg DeleteCustomRoleParams) error
@@ -88,6 +101,7 @@ type sqlcQuerier interface {
 	DeleteGroupByID(ctx context.Context, id uuid.UUID) error
 	DeleteGroupMemberFromGroup(ctx context.Context, arg DeleteGroupMemberFromGroupParams) error
 	DeleteLicense(ctx context.Context, id int32) (int32, error)
+	DeleteNotificationRule(ctx context.Context, id uuid.UUID) error
 	DeleteOAuth2ProviderAppByID(ctx context.Context, id uuid.UUID) error
 	DeleteOAuth2ProviderAppCodeByID(ctx context.Context, id uuid.UUID) error
 	DeleteOAuth2ProviderAppCodesByAppAndUserID(ctx context.Context, arg DeleteOAuth2ProviderAppCodesByAppAndUserIDParams) error
@@ -105,19 +119,37 @@ type sqlcQuerier interface {
 	// Logs can take up a lot of space, so it's important we clean up frequently.
 	DeleteOldWorkspaceAgentLogs(ctx context.Context, threshold time.Time) error
 	DeleteOldWorkspaceAgentStats(ctx context.Context) error
+	// DeleteOldWorkspaceBuildStates deletes the states of a workspace beyond the
+	// newest @keep_count, and the states created before @before. A @keep_count of
+	// zero keeps any number of states. The newest state of every workspace is
+	// always kept.
+	DeleteOldWorkspaceBuildStates(ctx context.Context, arg DeleteOldWorkspaceBuildStatesParams) (int64, error)
+	// Deletes all but the newest @keep_chains full snapshots of each workspace
+	// agent, along with the snapshots incremental to them. Archives stored in the
+	// database are deleted too, unless a remaining snapshot shares them. Returns
+	// the archives of the deleted snapshots.
+	DeleteOldWorkspaceSnapshots(ctx context.Context, keepChains int32) ([]DeleteOldWorkspaceSnapshotsRow, error)
 	DeleteOrganizationMember(ctx context.Context, arg DeleteOrganizationMemberParams) error
+	DeletePlanPolicyByID(ctx context.Context, id uuid.UUID) error
 	DeleteProvisionerKey(ctx context.Context, id uuid.UUID) error
 	DeleteReplicasUpdatedBefore(ctx context.Context, updatedAt time.Time) error
+	// DeleteRestoredAuditLogs deletes the audit logs of the archives restored
+	// before a time. They are still in their archive, so they can be restored
+	// again.
+	DeleteRestoredAuditLogs(ctx context.Context, restoredBefore time.Time) error
 	DeleteRuntimeConfig(ctx context.Context, key string) error
 	DeleteTailnetAgent(ctx context.Context, arg DeleteTailnetAgentParams) (DeleteTailnetAgentRow, error)
 	DeleteTailnetClient(ctx context.Context, arg DeleteTailnetClientParams) (DeleteTailnetClientRow, error)
 	DeleteTailnetClientSubscription(ctx context.Context, arg DeleteTailnetClientSubscriptionParams) error
 	DeleteTailnetPeer(ctx context.Context, arg DeleteTailnetPeerParams) (DeleteTailnetPeerRow, error)
 	DeleteTailnetTunnel(ctx context.Context, arg DeleteTailnetTunnelParams) (DeleteTailnetTunnelRow, error)
+	DeleteTemplatePortShareRules(ctx context.Context, templateID uuid.UUID) error
+	DeleteUserChatIdentity(ctx context.Context, arg DeleteUserChatIdentityParams) error
 	DeleteWebpushSubscriptionByUserIDAndEndpoint(ctx context.Context, arg DeleteWebpushSubscriptionByUserIDAndEndpointParams) error
 	DeleteWebpushSubscriptions(ctx context.Context, ids []uuid.UUID) error
 	DeleteWorkspaceAgentPortShare(ctx context.Context, arg DeleteWorkspaceAgentPortShareParams) error
 	DeleteWorkspaceAgentPortSharesByTemplate(ctx context.Context, templateID uuid.UUID) error
+	DeleteWorkspaceSnapshotRestore(ctx context.Context, workspaceID uuid.UUID) error
 	DeleteWorkspaceSubAgentByID(ctx context.Context, id uuid.UUID) error
 	// Disable foreign keys and triggers for all tables.
 	// Deprecated: disable foreign keys was created to aid in migrating off
@@ -148,9 +180,34 @@ type sqlcQuerier interface {
 	GetAnnouncementBanners(ctx context.Context) (string, error)
 	GetAppSecurityKey(ctx context.Context) (string, error)
 	GetApplicationName(ctx context.Context) (string, error)
+	// GetArchivedAuditLogIDs returns which of the given audit logs were archived.
+	GetArchivedAuditLogIDs(ctx context.Context, ids []uuid.UUID) ([]uuid.UUID, error)
+	GetAuditLogArchiveByID(ctx context.Context, id uuid.UUID) (AuditLogArchive, error)
+	GetAuditLogArchives(ctx context.Context) ([]AuditLogArchive, error)
+	// GetAuditLogCheckpoints returns the checkpoints in a sequence range, in order.
+	GetAuditLogCheckpoints(ctx context.Context, arg GetAuditLogCheckpointsParams) ([]AuditLogCheckpoint, error)
+	GetAuditLogExportCursor(ctx context.Context, sink string) (AuditLogExportCursor, error)
+	// GetAuditLogHashSequenceRange returns the first and last sequence of the hash
+	// chain entries of the audit logs in a time range. Both are 0 when there are
+	// none.
+	GetAuditLogHashSequenceRange(ctx context.Context, arg GetAuditLogHashSequenceRangeParams) (GetAuditLogHashSequenceRangeRow, error)
+	// GetAuditLogHashes returns the hash chain entries after a sequence, up to and
+	// including the last sequence, in order.
+	GetAuditLogHashes(ctx context.Context, arg GetAuditLogHashesParams) ([]AuditLogHash, error)
+	GetAuditLogsByIDs(ctx context.Context, ids []uuid.UUID) ([]AuditLog, error)
+	// GetAuditLogsForExport returns the audit logs ordered after the cursor of a
+	// streaming sink, in the order of the transactions which inserted them. Audit
+	// logs of transactions which may still be running are held back, so none can
+	// be inserted before the cursor once it advanced past them.
+	GetAuditLogsForExport(ctx context.Context, arg GetAuditLogsForExportParams) ([]GetAuditLogsForExportRow, error)
 	// GetAuditLogsBefore retrieves `row_limit` number of audit logs before the provided
 	// ID.
 	GetAuditLogsOffset(ctx context.Context, arg GetAuditLogsOffsetParams) ([]GetAuditLogsOffsetRow, error)
+	// GetAuditLogsToChain returns the audit logs ordered after the head of the
+	// hash chain, in the order of the transactions which inserted them. Audit logs
+	// of transactions which may still be running are held back, and audit logs
+	// which are chained already, such as restored ones, are skipped.
+	GetAuditLogsToChain(ctx context.Context, arg GetAuditLogsToChainParams) ([]AuditLog, error)
 	// This function returns roles for authorization purposes. Implied member roles
 	// are included.
 	GetAuthorizationUserRoles(ctx context.Context, userID uuid.UUID) (GetAuthorizationUserRolesRow, error)
@@ -168,6 +225,15 @@ type sqlcQuerier interface {
 	GetDeploymentWorkspaceAgentUsageStats(ctx context.Context, createdAt time.Time) (GetDeploymentWorkspaceAgentUsageStatsRow, error)
 	GetDeploymentWorkspaceStats(ctx context.Context) (GetDeploymentWorkspaceStatsRow, error)
 	GetEligibleProvisionerDaemonsByProvisionerJobIDs(ctx context.Context, provisionerJobIds []uuid.UUID) ([]GetEligibleProvisionerDaemonsByProvisionerJobIDsRow, error)
+	GetEnabledNotificationRules(ctx context.Context) ([]NotificationRule, error)
+	// GetExpiredAuditLogs returns the audit logs which outlived their retention
+	// and were not archived yet, oldest first. Retention rules are given as
+	// parallel arrays, where an empty resource type or action matches any. The
+	// most specific matching rule applies, and the default cutoff applies to audit
+	// logs no rule matches. max_cutoff is the latest of all cutoffs, so the scan
+	// is bounded by the time index. Audit logs which weren't delivered to a
+	// streaming sink leased after sink_active_after yet are kept.
+	GetExpiredAuditLogs(ctx context.Context, arg GetExpiredAuditLogsParams) ([]AuditLog, error)
 	GetExternalAuthLink(ctx context.Context, arg GetExternalAuthLinkParams) (ExternalAuthLink, error)
 	GetExternalAuthLinksByUserID(ctx context.Context, userID uuid.UUID) ([]ExternalAuthLink, error)
 	GetFailedWorkspaceBuildsByTemplateID(ctx context.Context, arg GetFailedWorkspaceBuildsByTemplateIDParams) ([]GetFailedWorkspaceBuildsByTemplateIDRow, error)
@@ -203,17 +269,22 @@ type sqlcQuerier interface {
 	// param limit_opt: The limit of notifications to fetch. If the limit is not specified, it defaults to 25
 	GetInboxNotificationsByUserID(ctx context.Context, arg GetInboxNotificationsByUserIDParams) ([]InboxNotification, error)
 	GetLastUpdateCheck(ctx context.Context) (string, error)
+	GetLatestAuditLogCheckpoint(ctx context.Context) (AuditLogCheckpoint, error)
+	GetLatestAuditLogHash(ctx context.Context) (AuditLogHash, error)
 	GetLatestCryptoKeyByFeature(ctx context.Context, feature CryptoKeyFeature) (CryptoKey, error)
 	GetLatestWorkspaceAppStatusesByWorkspaceIDs(ctx context.Context, ids []uuid.UUID) ([]WorkspaceAppStatus, error)
 	GetLatestWorkspaceBuildByWorkspaceID(ctx context.Context, workspaceID uuid.UUID) (WorkspaceBuild, error)
 	GetLatestWorkspaceBuilds(ctx context.Context) ([]WorkspaceBuild, error)
 	GetLatestWorkspaceBuildsByWorkspaceIDs(ctx context.Context, ids []uuid.UUID) ([]WorkspaceBuild, error)
+	GetLatestWorkspaceDriftCheckByWorkspaceID(ctx context.Context, workspaceID uuid.UUID) (WorkspaceDriftCheck, error)
 	GetLicenseByID(ctx context.Context, id int32) (License, error)
 	GetLicenses(ctx context.Context) ([]License, error)
 	GetLogoURL(ctx context.Context) (string, error)
 	GetNotificationMessagesByStatus(ctx context.Context, arg GetNotificationMessagesByStatusParams) ([]NotificationMessage, error)
 	// Fetch the notification report generator log indicating recent activity.
 	GetNotificationReportGeneratorLogByTemplate(ctx context.Context, templateID uuid.UUID) (NotificationReportGeneratorLog, error)
+	GetNotificationRuleByID(ctx context.Context, id uuid.UUID) (NotificationRule, error)
+	GetNotificationRulesByOrganizationID(ctx context.Context, organizationID uuid.UUID) ([]NotificationRule, error)
 	GetNotificationTemplateByID(ctx context.Context, id uuid.UUID) (NotificationTemplate, error)
 	GetNotificationTemplatesByKind(ctx context.Context, kind NotificationTemplateKind) ([]NotificationTemplate, error)
 	GetNotificationsSettings(ctx context.Context) (string, error)
@@ -231,12 +302,21 @@ type sqlcQuerier interface {
 	GetOAuthSigningKey(ctx context.Context) (string, error)
 	GetOrganizationByID(ctx context.Context, id uuid.UUID) (Organization, error)
 	GetOrganizationByName(ctx context.Context, arg GetOrganizationByNameParams) (Organization, error)
+	// Returns the holidays of an organization on or after a date.
+	GetOrganizationHolidays(ctx context.Context, arg GetOrganizationHolidaysParams) ([]OrganizationHoliday, error)
 	GetOrganizationIDsByMemberIDs(ctx context.Context, ids []uuid.UUID) ([]GetOrganizationIDsByMemberIDsRow, error)
 	GetOrganizationResourceCountByID(ctx context.Context, organizationID uuid.UUID) (GetOrganizationResourceCountByIDRow, error)
 	GetOrganizations(ctx context.Context, arg GetOrganizationsParams) ([]Organization, error)
 	GetOrganizationsByUserID(ctx context.Context, arg GetOrganizationsByUserIDParams) ([]Organization, error)
 	GetParameterSchemasByJobID(ctx context.Context, jobID uuid.UUID) ([]ParameterSchema, error)
+	GetPlanPoliciesByOrganizationID(ctx context.Context, organizationID uuid.UUID) ([]PlanPolicy, error)
+	GetPlanPolicyByID(ctx context.Context, id uuid.UUID) (PlanPolicy, error)
+	GetPlanPolicyByOrganizationAndName(ctx context.Context, arg GetPlanPolicyByOrganizationAndNameParams) (PlanPolicy, error)
 	GetPrebuildMetrics(ctx context.Context) ([]GetPrebuildMetricsRow, error)
+	// GetPrebuildPoolHealth reports, for each preset with a prebuild configuration on an active template version,
+	// how many prebuilt workspaces are running and ready compared to the desired instances, along with the number
+	// of prebuild builds which failed since the given time.
+	GetPrebuildPoolHealth(ctx context.Context, since time.Time) ([]GetPrebuildPoolHealthRow, error)
 	GetPrebuildsSettings(ctx context.Context) (string, error)
 	GetPresetByID(ctx context.Context, presetID uuid.UUID) (GetPresetByIDRow, error)
 	GetPresetByWorkspaceBuildID(ctx context.Context, workspaceBuildID uuid.UUID) (TemplateVersionPreset, error)
@@ -268,6 +348,14 @@ type sqlcQuerier interface {
 	GetPresetsBackoff(ctx context.Context, lookback time.Time) ([]GetPresetsBackoffRow, error)
 	GetPresetsByTemplateVersionID(ctx context.Context, templateVersionID uuid.UUID) ([]TemplateVersionPreset, error)
 	GetPreviousTemplateVersion(ctx context.Context, arg GetPreviousTemplateVersionParams) (TemplateVersion, error)
+	// GetProviderMirrorArchiveFileByHash only returns files that are archives of
+	// the provider mirror of the organization, so that the mirror never serves
+	// other files.
+	GetProviderMirrorArchiveFileByHash(ctx context.Context, arg GetProviderMirrorArchiveFileByHashParams) (File, error)
+	// GetProviderMirrorArchives returns the hash of the file of every archive, but
+	// not its data, as the archives can be large.
+	GetProviderMirrorArchives(ctx context.Context, arg GetProviderMirrorArchivesParams) ([]GetProviderMirrorArchivesRow, error)
+	GetProviderMirrorTokenByJobID(ctx context.Context, jobID uuid.UUID) (ProviderMirrorToken, error)
 	GetProvisionerDaemons(ctx context.Context) ([]ProvisionerDaemon, error)
 	GetProvisionerDaemonsByOrganization(ctx context.Context, arg GetProvisionerDaemonsByOrganizationParams) ([]ProvisionerDaemon, error)
 	// Current job information.
@@ -288,6 +376,11 @@ type sqlcQuerier interface {
 	GetProvisionerKeyByID(ctx context.Context, id uuid.UUID) (ProvisionerKey, error)
 	GetProvisionerKeyByName(ctx context.Context, arg GetProvisionerKeyByNameParams) (ProvisionerKey, error)
 	GetProvisionerLogsAfterID(ctx context.Context, arg GetProvisionerLogsAfterIDParams) ([]ProvisionerJobLog, error)
+	// Gets the autoscaling signals of each set of tags of the online provisioner
+	// daemons and pending jobs, and of the requested tags. Like
+	// AcquireProvisionerJob, pending jobs count towards every set of tags that
+	// contains their tags.
+	GetProvisionerQueueStats(ctx context.Context, arg GetProvisionerQueueStatsParams) ([]GetProvisionerQueueStatsRow, error)
 	GetQuotaAllowanceForUser(ctx context.Context, arg GetQuotaAllowanceForUserParams) (int64, error)
 	GetQuotaConsumedForUser(ctx context.Context, arg GetQuotaConsumedForUserParams) (int64, error)
 	GetReplicaByID(ctx context.Context, id uuid.UUID) (Replica, error)
@@ -308,6 +401,7 @@ type sqlcQuerier interface {
 	// GetTemplateAppInsightsByTemplate is used for Prometheus metrics. Keep
 	// in sync with GetTemplateAppInsights and UpsertTemplateUsageStats.
 	GetTemplateAppInsightsByTemplate(ctx context.Context, arg GetTemplateAppInsightsByTemplateParams) ([]GetTemplateAppInsightsByTemplateRow, error)
+	GetTemplateAutostopWindows(ctx context.Context, templateID uuid.UUID) ([]TemplateAutostopWindow, error)
 	GetTemplateAverageBuildTime(ctx context.Context, arg GetTemplateAverageBuildTimeParams) (GetTemplateAverageBuildTimeRow, error)
 	GetTemplateByID(ctx context.Context, id uuid.UUID) (Template, error)
 	GetTemplateByOrganizationAndName(ctx context.Context, arg GetTemplateByOrganizationAndNameParams) (Template, error)
@@ -335,11 +429,14 @@ type sqlcQuerier interface {
 	// created in the timeframe and return the aggregate usage counts of parameter
 	// values.
 	GetTemplateParameterInsights(ctx context.Context, arg GetTemplateParameterInsightsParams) ([]GetTemplateParameterInsightsRow, error)
+	GetTemplatePortShareRules(ctx context.Context, templateID uuid.UUID) ([]TemplatePortShareRule, error)
 	// GetTemplatePresetsWithPrebuilds retrieves template versions with configured presets and prebuilds.
 	// It also returns the number of desired instances for each preset.
 	// If template_id is specified, only template versions associated with that template will be returned.
 	GetTemplatePresetsWithPrebuilds(ctx context.Context, templateID uuid.NullUUID) ([]GetTemplatePresetsWithPrebuildsRow, error)
 	GetTemplateUsageStats(ctx context.Context, arg GetTemplateUsageStatsParams) ([]TemplateUsageStat, error)
+	// GetTemplateVersionAdoption counts the workspaces of every template by the template version of their latest build.
+	GetTemplateVersionAdoption(ctx context.Context) ([]GetTemplateVersionAdoptionRow, error)
 	GetTemplateVersionByID(ctx context.Context, id uuid.UUID) (TemplateVersion, error)
 	GetTemplateVersionByJobID(ctx context.Context, jobID uuid.UUID) (TemplateVersion, error)
 	GetTemplateVersionByTemplateIDAndName(ctx context.Context, arg GetTemplateVersionByTemplateIDAndNameParams) (TemplateVersion, error)
@@ -352,6 +449,12 @@ type sqlcQuerier interface {
 	GetTemplateVersionsCreatedAfter(ctx context.Context, createdAt time.Time) ([]TemplateVersion, error)
 	GetTemplates(ctx context.Context) ([]Template, error)
 	GetTemplatesWithFilter(ctx context.Context, arg GetTemplatesWithFilterParams) ([]Template, error)
+	// GetTopWorkspaceResourceConsumers returns the workspaces with the most network traffic since the given time,
+	// ranked per organization. The daily cost of the latest build is included as an indication of compute usage.
+	GetTopWorkspaceResourceConsumers(ctx context.Context, arg GetTopWorkspaceResourceConsumersParams) ([]GetTopWorkspaceResourceConsumersRow, error)
+	// GetUnchainedAuditLogs returns the audit logs in a time range which are not
+	// part of the hash chain, although they are ordered before its head.
+	GetUnchainedAuditLogs(ctx context.Context, arg GetUnchainedAuditLogsParams) ([]GetUnchainedAuditLogsRow, error)
 	GetUnexpiredLicenses(ctx context.Context) ([]License, error)
 	// GetUserActivityInsights returns the ranking with top active users.
 	// The result can be filtered on template_ids, meaning only user data
@@ -361,8 +464,11 @@ type sqlcQuerier interface {
 	// produces a bloated value if a user has used multiple templates
 	// simultaneously.
 	GetUserActivityInsights(ctx context.Context, arg GetUserActivityInsightsParams) ([]GetUserActivityInsightsRow, error)
+	GetUserAutostopWindows(ctx context.Context, userID uuid.UUID) ([]UserAutostopWindow, error)
 	GetUserByEmailOrUsername(ctx context.Context, arg GetUserByEmailOrUsernameParams) (User, error)
 	GetUserByID(ctx context.Context, id uuid.UUID) (User, error)
+	GetUserChatIdentities(ctx context.Context, userID uuid.UUID) ([]UserChatIdentity, error)
+	GetUserChatIdentity(ctx context.Context, arg GetUserChatIdentityParams) (UserChatIdentity, error)
 	GetUserCount(ctx context.Context, includeSystem bool) (int64, error)
 	// GetUserLatencyInsights returns the median and 95th percentile connection
 	// latency that users have experienced. The result can be filtered on
@@ -372,7 +478,9 @@ type sqlcQuerier interface {
 	GetUserLinkByLinkedID(ctx context.Context, linkedID string) (UserLink, error)
 	GetUserLinkByUserIDLoginType(ctx context.Context, arg GetUserLinkByUserIDLoginTypeParams) (UserLink, error)
 	GetUserLinksByUserID(ctx context.Context, userID uuid.UUID) ([]UserLink, error)
+	GetUserNotificationDigestPreferences(ctx context.Context, userID uuid.UUID) ([]NotificationDigestPreference, error)
 	GetUserNotificationPreferences(ctx context.Context, userID uuid.UUID) ([]NotificationPreference, error)
+	GetUserNotificationSchedule(ctx context.Context, userID uuid.UUID) (UserNotificationSchedule, error)
 	// GetUserStatusCounts returns the count of users in each status over time.
 	// The time range is inclusively defined by the start_time and end_time parameters.
 	//
@@ -426,8 +534,19 @@ type sqlcQuerier interface {
 	GetWorkspaceBuildByID(ctx context.Context, id uuid.UUID) (WorkspaceBuild, error)
 	GetWorkspaceBuildByJobID(ctx context.Context, jobID uuid.UUID) (WorkspaceBuild, error)
 	GetWorkspaceBuildByWorkspaceIDAndBuildNumber(ctx context.Context, arg GetWorkspaceBuildByWorkspaceIDAndBuildNumberParams) (WorkspaceBuild, error)
+	// GetWorkspaceBuildEvents returns workspace builds which completed in the given period, most recent first. The
+	// total number of matching builds is returned on every row, regardless of the limit.
+	GetWorkspaceBuildEvents(ctx context.Context, arg GetWorkspaceBuildEventsParams) ([]GetWorkspaceBuildEventsRow, error)
 	GetWorkspaceBuildParameters(ctx context.Context, workspaceBuildID uuid.UUID) ([]WorkspaceBuildParameter, error)
 	GetWorkspaceBuildParametersByBuildIDs(ctx context.Context, workspaceBuildIds []uuid.UUID) ([]WorkspaceBuildParameter, error)
+	GetWorkspaceBuildStateByBuildID(ctx context.Context, workspaceBuildID uuid.UUID) (WorkspaceBuildState, error)
+	// GetWorkspaceBuildStateKeyIDs returns the key every state is encrypted with,
+	// so that states can be re-encrypted when keys are rotated.
+	GetWorkspaceBuildStateKeyIDs(ctx context.Context) ([]GetWorkspaceBuildStateKeyIDsRow, error)
+	// GetWorkspaceBuildStatesByWorkspaceID lists the states kept for a workspace,
+	// newest first. The states themselves are left out, they are fetched one at a
+	// time.
+	GetWorkspaceBuildStatesByWorkspaceID(ctx context.Context, workspaceID uuid.UUID) ([]GetWorkspaceBuildStatesByWorkspaceIDRow, error)
 	GetWorkspaceBuildStatsByTemplates(ctx context.Context, since time.Time) ([]GetWorkspaceBuildStatsByTemplatesRow, error)
 	GetWorkspaceBuildsByWorkspaceID(ctx context.Context, arg GetWorkspaceBuildsByWorkspaceIDParams) ([]WorkspaceBuild, error)
 	GetWorkspaceBuildsCreatedAfter(ctx context.Context, createdAt time.Time) ([]WorkspaceBuild, error)
@@ -436,6 +555,14 @@ type sqlcQuerier interface {
 	GetWorkspaceByOwnerIDAndName(ctx context.Context, arg GetWorkspaceByOwnerIDAndNameParams) (Workspace, error)
 	GetWorkspaceByResourceID(ctx context.Context, resourceID uuid.UUID) (Workspace, error)
 	GetWorkspaceByWorkspaceAppID(ctx context.Context, workspaceAppID uuid.UUID) (Workspace, error)
+	// GetWorkspaceCostInsights returns the uptime and cost of workspaces between
+	// the start and end time, per organization, template and user.
+	GetWorkspaceCostInsights(ctx context.Context, arg GetWorkspaceCostInsightsParams) ([]GetWorkspaceCostInsightsRow, error)
+	// GetWorkspaceCostInsightsByGroup returns the uptime and cost of workspaces
+	// between the start and end time, per group of their owners. Users can be in
+	// many groups, so the same usage can count towards many groups.
+	GetWorkspaceCostInsightsByGroup(ctx context.Context, arg GetWorkspaceCostInsightsByGroupParams) ([]GetWorkspaceCostInsightsByGroupRow, error)
+	GetWorkspaceDriftCheckByJobID(ctx context.Context, jobID uuid.UUID) (WorkspaceDriftCheck, error)
 	GetWorkspaceModulesByJobID(ctx context.Context, jobID uuid.UUID) ([]WorkspaceModule, error)
 	GetWorkspaceModulesCreatedAfter(ctx context.Context, createdAt time.Time) ([]WorkspaceModule, error)
 	GetWorkspaceProxies(ctx context.Context) ([]WorkspaceProxy, error)
@@ -455,13 +582,26 @@ type sqlcQuerier interface {
 	GetWorkspaceResourcesByJobID(ctx context.Context, jobID uuid.UUID) ([]WorkspaceResource, error)
 	GetWorkspaceResourcesByJobIDs(ctx context.Context, ids []uuid.UUID) ([]WorkspaceResource, error)
 	GetWorkspaceResourcesCreatedAfter(ctx context.Context, createdAt time.Time) ([]WorkspaceResource, error)
+	GetWorkspaceSnapshotByID(ctx context.Context, id uuid.UUID) (WorkspaceSnapshot, error)
+	// Returns the snapshot and every snapshot it is incremental to, oldest first.
+	GetWorkspaceSnapshotChain(ctx context.Context, id uuid.UUID) ([]WorkspaceSnapshot, error)
+	GetWorkspaceSnapshotRestoreByWorkspaceID(ctx context.Context, workspaceID uuid.UUID) (WorkspaceSnapshotRestore, error)
+	GetWorkspaceSnapshotsByWorkspaceID(ctx context.Context, workspaceID uuid.UUID) ([]WorkspaceSnapshot, error)
 	GetWorkspaceUniqueOwnerCountByTemplateIDs(ctx context.Context, templateIds []uuid.UUID) ([]GetWorkspaceUniqueOwnerCountByTemplateIDsRow, error)
+	GetWorkspaceUpdatePreviewByJobID(ctx context.Context, jobID uuid.UUID) (WorkspaceUpdatePreview, error)
 	// build_params is used to filter by build parameters if present.
 	// It has to be a CTE because the set returning function 'unnest' cannot
 	// be used in a WHERE clause.
 	GetWorkspaces(ctx context.Context, arg GetWorkspacesParams) ([]GetWorkspacesRow, error)
 	GetWorkspacesAndAgentsByOwnerID(ctx context.Context, ownerID uuid.UUID) ([]GetWorkspacesAndAgentsByOwnerIDRow, error)
+	// GetWorkspacesApproachingDeletion returns dormant workspaces which will be deleted before the given time, and
+	// workspaces which will be marked as dormant before then. Prebuilt workspaces are excluded.
+	GetWorkspacesApproachingDeletion(ctx context.Context, before time.Time) ([]GetWorkspacesApproachingDeletionRow, error)
 	GetWorkspacesByTemplateID(ctx context.Context, templateID uuid.UUID) ([]WorkspaceTable, error)
+	// Returns the latest builds of running workspaces whose template checks for
+	// drift, when neither the build nor a drift check ran within the interval of
+	// the template.
+	GetWorkspacesDueForDriftCheck(ctx context.Context, now time.Time) ([]GetWorkspacesDueForDriftCheckRow, error)
 	GetWorkspacesEligibleForTransition(ctx context.Context, now time.Time) ([]GetWorkspacesEligibleForTransitionRow, error)
 	// Determines if the template versions table has any rows with has_ai_task = TRUE.
 	HasTemplateVersionsWithAITask(ctx context.Context) (bool, error)
@@ -471,6 +611,10 @@ type sqlcQuerier interface {
 	// every member of the org.
 	InsertAllUsersGroup(ctx context.Context, organizationID uuid.UUID) (Group, error)
 	InsertAuditLog(ctx context.Context, arg InsertAuditLogParams) (AuditLog, error)
+	InsertAuditLogArchive(ctx context.Context, arg InsertAuditLogArchiveParams) (AuditLogArchive, error)
+	InsertAuditLogArchiveEntries(ctx context.Context, arg InsertAuditLogArchiveEntriesParams) error
+	InsertAuditLogCheckpoint(ctx context.Context, arg InsertAuditLogCheckpointParams) error
+	InsertAuditLogHash(ctx context.Context, arg InsertAuditLogHashParams) error
 	InsertCryptoKey(ctx context.Context, arg InsertCryptoKeyParams) (CryptoKey, error)
 	InsertCustomRole(ctx context.Context, arg InsertCustomRoleParams) (CustomRole, error)
 	InsertDBCryptKey(ctx context.Context, arg InsertDBCryptKeyParams) error
@@ -489,15 +633,20 @@ type sqlcQuerier interface {
 	// values for avatar, display name, and quota allowance (all zero values).
 	// If the name conflicts, do nothing.
 	InsertMissingGroups(ctx context.Context, arg InsertMissingGroupsParams) ([]Group, error)
+	InsertNotificationRule(ctx context.Context, arg InsertNotificationRuleParams) (NotificationRule, error)
 	InsertOAuth2ProviderApp(ctx context.Context, arg InsertOAuth2ProviderAppParams) (OAuth2ProviderApp, error)
 	InsertOAuth2ProviderAppCode(ctx context.Context, arg InsertOAuth2ProviderAppCodeParams) (OAuth2ProviderAppCode, error)
 	InsertOAuth2ProviderAppSecret(ctx context.Context, arg InsertOAuth2ProviderAppSecretParams) (OAuth2ProviderAppSecret, error)
 	InsertOAuth2ProviderAppToken(ctx context.Context, arg InsertOAuth2ProviderAppTokenParams) (OAuth2ProviderAppToken, error)
 	InsertOrganization(ctx context.Context, arg InsertOrganizationParams) (Organization, error)
 	InsertOrganizationMember(ctx context.Context, arg InsertOrganizationMemberParams) (OrganizationMember, error)
+	InsertPlanPolicy(ctx context.Context, arg InsertPlanPolicyParams) (PlanPolicy, error)
 	InsertPreset(ctx context.Context, arg InsertPresetParams) (TemplateVersionPreset, error)
 	InsertPresetParameters(ctx context.Context, arg InsertPresetParametersParams) ([]TemplateVersionPresetParameter, error)
 	InsertPresetPrebuildSchedule(ctx context.Context, arg InsertPresetPrebuildScheduleParams) (TemplateVersionPresetPrebuildSchedule, error)
+	// InsertProviderMirrorArchive never replaces a package that is in the mirror
+	// already. It inserts no rows if the package exists.
+	InsertProviderMirrorArchive(ctx context.Context, arg InsertProviderMirrorArchiveParams) (int64, error)
 	InsertProvisionerJob(ctx context.Context, arg InsertProvisionerJobParams) (ProvisionerJob, error)
 	InsertProvisionerJobLogs(ctx context.Context, arg InsertProvisionerJobLogsParams) ([]ProvisionerJobLog, error)
 	InsertProvisionerJobTimings(ctx context.Context, arg InsertProvisionerJobTimingsParams) ([]ProvisionerJobTiming, error)
@@ -505,6 +654,7 @@ type sqlcQuerier interface {
 	InsertReplica(ctx context.Context, arg InsertReplicaParams) (Replica, error)
 	InsertTelemetryItemIfNotExists(ctx context.Context, arg InsertTelemetryItemIfNotExistsParams) error
 	InsertTemplate(ctx context.Context, arg InsertTemplateParams) error
+	InsertTemplatePortShareRule(ctx context.Context, arg InsertTemplatePortShareRuleParams) (TemplatePortShareRule, error)
 	InsertTemplateVersion(ctx context.Context, arg InsertTemplateVersionParams) error
 	InsertTemplateVersionParameter(ctx context.Context, arg InsertTemplateVersionParameterParams) (TemplateVersionParameter, error)
 	InsertTemplateVersionTerraformValuesByJobID(ctx context.Context, arg InsertTemplateVersionTerraformValuesByJobIDParams) error
@@ -532,10 +682,13 @@ type sqlcQuerier interface {
 	InsertWorkspaceAppStatus(ctx context.Context, arg InsertWorkspaceAppStatusParams) (WorkspaceAppStatus, error)
 	InsertWorkspaceBuild(ctx context.Context, arg InsertWorkspaceBuildParams) error
 	InsertWorkspaceBuildParameters(ctx context.Context, arg InsertWorkspaceBuildParametersParams) error
+	InsertWorkspaceDriftCheck(ctx context.Context, arg InsertWorkspaceDriftCheckParams) (WorkspaceDriftCheck, error)
 	InsertWorkspaceModule(ctx context.Context, arg InsertWorkspaceModuleParams) (WorkspaceModule, error)
 	InsertWorkspaceProxy(ctx context.Context, arg InsertWorkspaceProxyParams) (WorkspaceProxy, error)
 	InsertWorkspaceResource(ctx context.Context, arg InsertWorkspaceResourceParams) (WorkspaceResource, error)
 	InsertWorkspaceResourceMetadata(ctx context.Context, arg InsertWorkspaceResourceMetadataParams) ([]WorkspaceResourceMetadatum, error)
+	InsertWorkspaceSnapshot(ctx context.Context, arg InsertWorkspaceSnapshotParams) (WorkspaceSnapshot, error)
+	InsertWorkspaceUpdatePreview(ctx context.Context, arg InsertWorkspaceUpdatePreviewParams) (WorkspaceUpdatePreview, error)
 	ListProvisionerKeysByOrganization(ctx context.Context, organizationID uuid.UUID) ([]ProvisionerKey, error)
 	ListProvisionerKeysByOrganizationExcludeReserved(ctx context.Context, organizationID uuid.UUID) ([]ProvisionerKey, error)
 	ListWorkspaceAgentPortShares(ctx context.Context, workspaceID uuid.UUID) ([]WorkspaceAgentPortShare, error)
@@ -564,6 +717,10 @@ type sqlcQuerier interface {
 	UnarchiveTemplateVersion(ctx context.Context, arg UnarchiveTemplateVersionParams) error
 	UnfavoriteWorkspace(ctx context.Context, id uuid.UUID) error
 	UpdateAPIKeyByID(ctx context.Context, arg UpdateAPIKeyByIDParams) error
+	UpdateAuditLogArchiveRestoredAt(ctx context.Context, arg UpdateAuditLogArchiveRestoredAtParams) error
+	// UpdateAuditLogExportCursor advances the cursor of a streaming sink and
+	// extends the lease, if the replica still holds it.
+	UpdateAuditLogExportCursor(ctx context.Context, arg UpdateAuditLogExportCursorParams) (int64, error)
 	UpdateCryptoKeyDeletesAt(ctx context.Context, arg UpdateCryptoKeyDeletesAtParams) (CryptoKey, error)
 	UpdateCustomRole(ctx context.Context, arg UpdateCustomRoleParams) (CustomRole, error)
 	UpdateExternalAuthLink(ctx context.Context, arg UpdateExternalAuthLinkParams) (ExternalAuthLink, error)
@@ -574,11 +731,17 @@ type sqlcQuerier interface {
 	UpdateInboxNotificationReadStatus(ctx context.Context, arg UpdateInboxNotificationReadStatusParams) error
 	UpdateMemberRoles(ctx context.Context, arg UpdateMemberRolesParams) (OrganizationMember, error)
 	UpdateMemoryResourceMonitor(ctx context.Context, arg UpdateMemoryResourceMonitorParams) error
+	UpdateNotificationRule(ctx context.Context, arg UpdateNotificationRuleParams) (NotificationRule, error)
+	UpdateNotificationRuleEvaluation(ctx context.Context, arg UpdateNotificationRuleEvaluationParams) error
 	UpdateNotificationTemplateMethodByID(ctx context.Context, arg UpdateNotificationTemplateMethodByIDParams) (NotificationTemplate, error)
 	UpdateOAuth2ProviderAppByID(ctx context.Context, arg UpdateOAuth2ProviderAppByIDParams) (OAuth2ProviderApp, error)
 	UpdateOAuth2ProviderAppSecretByID(ctx context.Context, arg UpdateOAuth2ProviderAppSecretByIDParams) (OAuth2ProviderAppSecret, error)
 	UpdateOrganization(ctx context.Context, arg UpdateOrganizationParams) (Organization, error)
 	UpdateOrganizationDeletedByID(ctx context.Context, arg UpdateOrganizationDeletedByIDParams) error
+	// Replaces the holidays of a calendar of an organization. Passing no holidays
+	// removes the calendar.
+	UpdateOrganizationHolidayCalendar(ctx context.Context, arg UpdateOrganizationHolidayCalendarParams) error
+	UpdatePlanPolicyByID(ctx context.Context, arg UpdatePlanPolicyByIDParams) (PlanPolicy, error)
 	UpdatePresetPrebuildStatus(ctx context.Context, arg UpdatePresetPrebuildStatusParams) error
 	UpdateProvisionerDaemonLastSeenAt(ctx context.Context, arg UpdateProvisionerDaemonLastSeenAtParams) error
 	UpdateProvisionerJobByID(ctx context.Context, arg UpdateProvisionerJobByIDParams) error
@@ -590,6 +753,9 @@ type sqlcQuerier interface {
 	UpdateTemplateACLByID(ctx context.Context, arg UpdateTemplateACLByIDParams) error
 	UpdateTemplateAccessControlByID(ctx context.Context, arg UpdateTemplateAccessControlByIDParams) error
 	UpdateTemplateActiveVersionByID(ctx context.Context, arg UpdateTemplateActiveVersionByIDParams) error
+	// Replaces the autostop windows of a template. Weekdays not in the arguments
+	// no longer have a window.
+	UpdateTemplateAutostopWindows(ctx context.Context, arg UpdateTemplateAutostopWindowsParams) error
 	UpdateTemplateDeletedByID(ctx context.Context, arg UpdateTemplateDeletedByIDParams) error
 	UpdateTemplateMetaByID(ctx context.Context, arg UpdateTemplateMetaByIDParams) error
 	UpdateTemplateScheduleByID(ctx context.Context, arg UpdateTemplateScheduleByIDParams) error
@@ -598,6 +764,9 @@ type sqlcQuerier interface {
 	UpdateTemplateVersionDescriptionByJobID(ctx context.Context, arg UpdateTemplateVersionDescriptionByJobIDParams) error
 	UpdateTemplateVersionExternalAuthProvidersByJobID(ctx context.Context, arg UpdateTemplateVersionExternalAuthProvidersByJobIDParams) error
 	UpdateTemplateWorkspacesLastUsedAt(ctx context.Context, arg UpdateTemplateWorkspacesLastUsedAtParams) error
+	// Replaces the autostop windows of a user. Weekdays not in the arguments no
+	// longer have a window.
+	UpdateUserAutostopWindows(ctx context.Context, arg UpdateUserAutostopWindowsParams) error
 	UpdateUserDeletedByID(ctx context.Context, id uuid.UUID) error
 	UpdateUserGithubComUserID(ctx context.Context, arg UpdateUserGithubComUserIDParams) error
 	UpdateUserHashedOneTimePasscode(ctx context.Context, arg UpdateUserHashedOneTimePasscodeParams) error
@@ -606,6 +775,9 @@ type sqlcQuerier interface {
 	UpdateUserLink(ctx context.Context, arg UpdateUserLinkParams) (UserLink, error)
 	UpdateUserLinkedID(ctx context.Context, arg UpdateUserLinkedIDParams) (UserLink, error)
 	UpdateUserLoginType(ctx context.Context, arg UpdateUserLoginTypeParams) (User, error)
+	// Replaces the digest preferences of the user, templates which are not given
+	// are delivered individually again.
+	UpdateUserNotificationDigestPreferences(ctx context.Context, arg UpdateUserNotificationDigestPreferencesParams) error
 	UpdateUserNotificationPreferences(ctx context.Context, arg UpdateUserNotificationPreferencesParams) (int64, error)
 	UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) (User, error)
 	UpdateUserQuietHoursSchedule(ctx context.Context, arg UpdateUserQuietHoursScheduleParams) (User, error)
@@ -629,12 +801,14 @@ type sqlcQuerier interface {
 	UpdateWorkspaceBuildProvisionerStateByID(ctx context.Context, arg UpdateWorkspaceBuildProvisionerStateByIDParams) error
 	UpdateWorkspaceDeletedByID(ctx context.Context, arg UpdateWorkspaceDeletedByIDParams) error
 	UpdateWorkspaceDormantDeletingAt(ctx context.Context, arg UpdateWorkspaceDormantDeletingAtParams) (WorkspaceTable, error)
+	UpdateWorkspaceDriftCheckByJobID(ctx context.Context, arg UpdateWorkspaceDriftCheckByJobIDParams) (WorkspaceDriftCheck, error)
 	UpdateWorkspaceLastUsedAt(ctx context.Context, arg UpdateWorkspaceLastUsedAtParams) error
 	UpdateWorkspaceNextStartAt(ctx context.Context, arg UpdateWorkspaceNextStartAtParams) error
 	// This allows editing the properties of a workspace proxy.
 	UpdateWorkspaceProxy(ctx context.Context, arg UpdateWorkspaceProxyParams) (WorkspaceProxy, error)
 	UpdateWorkspaceProxyDeleted(ctx context.Context, arg UpdateWorkspaceProxyDeletedParams) error
 	UpdateWorkspaceTTL(ctx context.Context, arg UpdateWorkspaceTTLParams) error
+	UpdateWorkspaceUpdatePreviewByJobID(ctx context.Context, arg UpdateWorkspaceUpdatePreviewByJobIDParams) (WorkspaceUpdatePreview, error)
 	UpdateWorkspacesDormantDeletingAtByTemplateID(ctx context.Context, arg UpdateWorkspacesDormantDeletingAtByTemplateIDParams) ([]WorkspaceTable, error)
 	UpdateWorkspacesTTLByTemplateID(ctx context.Context, arg UpdateWorkspacesTTLByTemplateIDParams) error
 	UpsertAnnouncementBanners(ctx context.Context, value string) error
@@ -654,6 +828,7 @@ type sqlcQuerier interface {
 	UpsertOAuth2GithubDefaultEligible(ctx context.Context, eligible bool) error
 	UpsertOAuthSigningKey(ctx context.Context, value string) error
 	UpsertPrebuildsSettings(ctx context.Context, value string) error
+	UpsertProviderMirrorToken(ctx context.Context, arg UpsertProviderMirrorTokenParams) error
 	UpsertProvisionerDaemon(ctx context.Context, arg UpsertProvisionerDaemonParams) (ProvisionerDaemon, error)
 	UpsertRuntimeConfig(ctx context.Context, arg UpsertRuntimeConfigParams) error
 	UpsertTailnetAgent(ctx context.Context, arg UpsertTailnetAgentParams) (TailnetAgent, error)
@@ -668,6 +843,10 @@ type sqlcQuerier interface {
 	// used to store the data, and the minutes are summed for each user and template
 	// combination. The result is stored in the template_usage_stats table.
 	UpsertTemplateUsageStats(ctx context.Context) error
+	// Links the identity, replacing the verification of the previously linked
+	// identity.
+	UpsertUserChatIdentity(ctx context.Context, arg UpsertUserChatIdentityParams) (UserChatIdentity, error)
+	UpsertUserNotificationSchedule(ctx context.Context, arg UpsertUserNotificationScheduleParams) (UserNotificationSchedule, error)
 	UpsertWebpushVAPIDKeys(ctx context.Context, arg UpsertWebpushVAPIDKeysParams) error
 	UpsertWorkspaceAgentPortShare(ctx context.Context, arg UpsertWorkspaceAgentPortShareParams) (WorkspaceAgentPortShare, error)
 	UpsertWorkspaceApp(ctx context.Context, arg UpsertWorkspaceAppParams) (WorkspaceApp, error)
@@ -676,6 +855,16 @@ type sqlcQuerier interface {
 	// was started. This means that a new row was inserted (no previous session) or
 	// the updated_at is older than stale interval.
 	UpsertWorkspaceAppAuditSession(ctx context.Context, arg UpsertWorkspaceAppAuditSessionParams) (bool, error)
+	UpsertWorkspaceBuildState(ctx context.Context, arg UpsertWorkspaceBuildStateParams) error
+	// This query rolls up the uptime of workspaces into hourly buckets, and prices
+	// it with the hourly cost of the resources of the running build. A workspace
+	// is running from the completion of a successful start build until the next
+	// build of the workspace is created. The last bucket is recomputed on every
+	// run, as it may have been incomplete.
+	UpsertWorkspaceCostUsage(ctx context.Context) error
+	UpsertWorkspaceSnapshotRestore(ctx context.Context, arg UpsertWorkspaceSnapshotRestoreParams) (WorkspaceSnapshotRestore, error)
+	// Marks the identity as verified if the code matches and has not expired.
+	VerifyUserChatIdentity(ctx context.Context, arg VerifyUserChatIdentityParams) (UserChatIdentity, error)
 }
 
 var _ sqlcQuerier = (*sqlQuerier)(nil)
@@ -1319,6 +1319,142 @@ func TestQueuePosition(t *testing.T) {
 	}
 }
 
+func TestAcquireProvisionerJobPriority(t *testing.T) {
+	t.Parallel()
+
+	db, _ := dbtestutil.NewDB(t)
+	ctx := testutil.Context(t, testutil.WaitShort)
+	now := dbtime.Now()
+	org := dbgen.Organization(t, db, database.Organization{})
+	busyUser := uuid.New()
+	idleUser := uuid.New()
+
+	// The busy user already has a running job. It's inserted first, so
+	// dbgen acquires it rather than one of the pending jobs.
+	dbgen.ProvisionerJob(t, db, nil, database.ProvisionerJob{
+		OrganizationID: org.ID,
+		InitiatorID:    busyUser,
+		CreatedAt:      now.Add(-time.Hour),
+		StartedAt:      sql.NullTime{Time: now, Valid: true},
+		Tags:           database.StringMap{},
+	})
+
+	pending := func(initiatorID uuid.UUID, priority database.ProvisionerJobPriority, age time.Duration) database.ProvisionerJob {
+		return dbgen.ProvisionerJob(t, db, nil, database.ProvisionerJob{
+			OrganizationID: org.ID,
+			InitiatorID:    initiatorID,
+			CreatedAt:      now.Add(-age),
+			Priority:       priority,
+			Tags:           database.StringMap{},
+		})
+	}
+	driftJob := pending(idleUser, database.ProvisionerJobPriorityDriftCheck, 6*time.Minute)
+	importJob := pending(idleUser, database.ProvisionerJobPriorityTemplateImport, 5*time.Minute)
+	scheduledJob := pending(idleUser, database.ProvisionerJobPriorityScheduled, 4*time.Minute)
+	busyJob := pending(busyUser, database.ProvisionerJobPriorityInteractive, 3*time.Minute)
+	idleJob := pending(idleUser, database.ProvisionerJobPriorityInteractive, 2*time.Minute)
+
+	// The queue is ordered by priority, then by age.
+	queued, err := db.GetProvisionerJobsByIDsWithQueuePosition(ctx, database.GetProvisionerJobsByIDsWithQueuePositionParams{
+		IDs:             []uuid.UUID{driftJob.ID, importJob.ID, scheduledJob.ID, busyJob.ID, idleJob.ID},
+		StaleIntervalMS: provisionerdserver.StaleInterval.Milliseconds(),
+	})
+	require.NoError(t, err)
+	positions := make(map[uuid.UUID]int64)
+	for _, job := range queued {
+		positions[job.ProvisionerJob.ID] = job.QueuePosition
+	}
+	assert.Equal(t, map[uuid.UUID]int64{
+		busyJob.ID:      1,
+		idleJob.ID:      2,
+		scheduledJob.ID: 3,
+		importJob.ID:    4,
+		driftJob.ID:     5,
+	}, positions)
+
+	// Jobs of the same priority are acquired for the initiator with the
+	// fewest running jobs first.
+	var acquired []uuid.UUID
+	for range 5 {
+		job, err := db.AcquireProvisionerJob(ctx, database.AcquireProvisionerJobParams{
+			OrganizationID:  org.ID,
+			StartedAt:       sql.NullTime{Time: dbtime.Now(), Valid: true},
+			Types:           database.AllProvisionerTypeValues(),
+			WorkerID:        uuid.NullUUID{UUID: uuid.New(), Valid: true},
+			ProvisionerTags: json.RawMessage("{}"),
+		})
+		require.NoError(t, err)
+		acquired = append(acquired, job.ID)
+	}
+	assert.Equal(t, []uuid.UUID{idleJob.ID, busyJob.ID, scheduledJob.ID, importJob.ID, driftJob.ID}, acquired)
+}
+
+func TestProvisionerJobEstimatedWait(t *testing.T) {
+	t.Parallel()
+
+	db, _ := dbtestutil.NewDB(t)
+	ctx := testutil.Context(t, testutil.WaitShort)
+	now := dbtime.Now()
+	org := dbgen.Organization(t, db, database.Organization{})
+
+	// A job that took 10 seconds sets the average duration.
+	dbgen.ProvisionerJob(t, db, nil, database.ProvisionerJob{
+		OrganizationID: org.ID,
+		StartedAt:      sql.NullTime{Time: now.Add(-10 * time.Second), Valid: true},
+		CompletedAt:    sql.NullTime{Time: now, Valid: true},
+		Tags:           database.StringMap{},
+	})
+	pending := func(age time.Duration) database.ProvisionerJob {
+		return dbgen.ProvisionerJob(t, db, nil, database.ProvisionerJob{
+			OrganizationID: org.ID,
+			CreatedAt:      now.Add(-age),
+			Tags:           database.StringMap{},
+		})
+	}
+	first := pending(2 * time.Minute)
+	second := pending(time.Minute)
+
+	// Two online provisioners work through the queue, the stale one doesn't.
+	for range 2 {
+		dbgen.ProvisionerDaemon(t, db, database.ProvisionerDaemon{
+			OrganizationID: org.ID,
+			Tags:           database.StringMap{},
+		})
+	}
+	dbgen.ProvisionerDaemon(t, db, database.ProvisionerDaemon{
+		OrganizationID: org.ID,
+		Tags:           database.StringMap{},
+		LastSeenAt:     sql.NullTime{Time: now.Add(-time.Hour), Valid: true},
+	})
+	want := map[uuid.UUID]int64{
+		first.ID:  5000,
+		second.ID: 10000,
+	}
+
+	byIDs, err := db.GetProvisionerJobsByIDsWithQueuePosition(ctx, database.GetProvisionerJobsByIDsWithQueuePositionParams{
+		IDs:             []uuid.UUID{first.ID, second.ID},
+		StaleIntervalMS: provisionerdserver.StaleInterval.Milliseconds(),
+	})
+	require.NoError(t, err)
+	got := make(map[uuid.UUID]int64)
+	for _, job := range byIDs {
+		got[job.ProvisionerJob.ID] = job.EstimatedWaitMs
+	}
+	assert.Equal(t, want, got)
+
+	byOrg, err := db.GetProvisionerJobsByOrganizationAndStatusWithQueuePositionAndProvisioner(ctx, database.GetProvisionerJobsByOrganizationAndStatusWithQueuePositionAndProvisionerParams{
+		OrganizationID:  org.ID,
+		IDs:             []uuid.UUID{first.ID, second.ID},
+		StaleIntervalMS: provisionerdserver.StaleInterval.Milliseconds(),
+	})
+	require.NoError(t, err)
+	got = make(map[uuid.UUID]int64)
+	for _, job := range byOrg {
+		got[job.ProvisionerJob.ID] = job.EstimatedWaitMs
+	}
+	assert.Equal(t, want, got)
+}
+
 func TestUserLastSeenFilter(t *testing.T) {
 	t.Parallel()
 	if testing.Short() {
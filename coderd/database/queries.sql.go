@@ -441,6 +441,55 @@ func (q *sqlQuerier) UpdateAPIKeyByID(ctx context.Context, arg UpdateAPIKeyByIDP
 	return err
 }
 
+const acquireAuditLogExportCursor = `-- name: AcquireAuditLogExportCursor :one
+INSERT INTO audit_log_export_cursors (sink, last_xact_id, last_id, updated_at, leased_by, lease_expires_at)
+VALUES (
+	$1,
+	pg_snapshot_xmin(pg_current_snapshot()) :: text :: bigint,
+	'00000000-0000-0000-0000-000000000000' :: uuid,
+	$2 :: timestamptz,
+	$3 :: uuid,
+	$4 :: timestamptz
+)
+ON CONFLICT (sink) DO UPDATE SET
+	leased_by = EXCLUDED.leased_by,
+	lease_expires_at = EXCLUDED.lease_expires_at
+WHERE
+	audit_log_export_cursors.leased_by IS NULL
+	OR audit_log_export_cursors.leased_by = EXCLUDED.leased_by
+	OR audit_log_export_cursors.lease_expires_at < $2 :: timestamptz
+RETURNING sink, last_xact_id, last_id, updated_at, leased_by, lease_expires_at
+`
+
+type AcquireAuditLogExportCursorParams struct {
+	Sink           string    `db:"sink" json:"sink"`
+	Now            time.Time `db:"now" json:"now"`
+	LeasedBy       uuid.UUID `db:"leased_by" json:"leased_by"`
+	LeaseExpiresAt time.Time `db:"lease_expires_at" json:"lease_expires_at"`
+}
+
+// AcquireAuditLogExportCursor leases the cursor of a streaming sink to a
+// replica, unless another replica holds an unexpired lease. A new sink starts
+// with the audit logs of the transactions which are still running.
+func (q *sqlQuerier) AcquireAuditLogExportCursor(ctx context.Context, arg AcquireAuditLogExportCursorParams) (AuditLogExportCursor, error) {
+	row := q.db.QueryRowContext(ctx, acquireAuditLogExportCursor,
+		arg.Sink,
+		arg.Now,
+		arg.LeasedBy,
+		arg.LeaseExpiresAt,
+	)
+	var i AuditLogExportCursor
+	err := row.Scan(
+		&i.Sink,
+		&i.LastXactID,
+		&i.LastID,
+		&i.UpdatedAt,
+		&i.LeasedBy,
+		&i.LeaseExpiresAt,
+	)
+	return i, err
+}
+
 const countAuditLogs = `-- name: CountAuditLogs :one
 SELECT COUNT(*)
 FROM audit_logs
@@ -566,8 +615,408 @@ func (q *sqlQuerier) CountAuditLogs(ctx context.Context, arg CountAuditLogsParam
 	return count, err
 }
 
+const deleteAuditLogsByIDs = `-- name: DeleteAuditLogsByIDs :exec
+DELETE FROM audit_logs WHERE id = ANY($1 :: uuid[])
+`
+
+func (q *sqlQuerier) DeleteAuditLogsByIDs(ctx context.Context, ids []uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteAuditLogsByIDs, pq.Array(ids))
+	return err
+}
+
+const deleteRestoredAuditLogs = `-- name: DeleteRestoredAuditLogs :exec
+WITH expired AS (
+	UPDATE
+		audit_log_archives
+	SET
+		restored_at = NULL
+	WHERE
+		restored_at < $1 :: timestamptz
+	RETURNING
+		id
+)
+DELETE FROM
+	audit_logs
+WHERE
+	id IN (
+		SELECT
+			audit_log_id
+		FROM
+			audit_log_archive_entries
+		WHERE
+			archive_id IN (SELECT id FROM expired)
+	)
+`
+
+// DeleteRestoredAuditLogs deletes the audit logs of the archives restored
+// before a time. They are still in their archive, so they can be restored
+// again.
+func (q *sqlQuerier) DeleteRestoredAuditLogs(ctx context.Context, restoredBefore time.Time) error {
+	_, err := q.db.ExecContext(ctx, deleteRestoredAuditLogs, restoredBefore)
+	return err
+}
+
+const getArchivedAuditLogIDs = `-- name: GetArchivedAuditLogIDs :many
+SELECT audit_log_id FROM audit_log_archive_entries WHERE audit_log_id = ANY($1 :: uuid[])
+`
+
+// GetArchivedAuditLogIDs returns which of the given audit logs were archived.
+func (q *sqlQuerier) GetArchivedAuditLogIDs(ctx context.Context, ids []uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, getArchivedAuditLogIDs, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var audit_log_id uuid.UUID
+		if err := rows.Scan(&audit_log_id); err != nil {
+			return nil, err
+		}
+		items = append(items, audit_log_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAuditLogArchiveByID = `-- name: GetAuditLogArchiveByID :one
+SELECT id, created_at, file_name, file_id, oldest_time, newest_time, audit_log_count, sha256, restored_at FROM audit_log_archives WHERE id = $1
+`
+
+func (q *sqlQuerier) GetAuditLogArchiveByID(ctx context.Context, id uuid.UUID) (AuditLogArchive, error) {
+	row := q.db.QueryRowContext(ctx, getAuditLogArchiveByID, id)
+	var i AuditLogArchive
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.FileName,
+		&i.FileID,
+		&i.OldestTime,
+		&i.NewestTime,
+		&i.AuditLogCount,
+		&i.Sha256,
+		&i.RestoredAt,
+	)
+	return i, err
+}
+
+const getAuditLogArchives = `-- name: GetAuditLogArchives :many
+SELECT id, created_at, file_name, file_id, oldest_time, newest_time, audit_log_count, sha256, restored_at FROM audit_log_archives ORDER BY oldest_time DESC, id ASC
+`
+
+func (q *sqlQuerier) GetAuditLogArchives(ctx context.Context) ([]AuditLogArchive, error) {
+	rows, err := q.db.QueryContext(ctx, getAuditLogArchives)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AuditLogArchive
+	for rows.Next() {
+		var i AuditLogArchive
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.FileName,
+			&i.FileID,
+			&i.OldestTime,
+			&i.NewestTime,
+			&i.AuditLogCount,
+			&i.Sha256,
+			&i.RestoredAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAuditLogCheckpoints = `-- name: GetAuditLogCheckpoints :many
+SELECT
+	sequence, hash, key_sequence, signature, created_at
+FROM
+	audit_log_checkpoints
+WHERE
+	sequence >= $1 :: bigint
+	AND sequence <= $2 :: bigint
+ORDER BY
+	sequence ASC
+`
+
+type GetAuditLogCheckpointsParams struct {
+	FirstSequence int64 `db:"first_sequence" json:"first_sequence"`
+	LastSequence  int64 `db:"last_sequence" json:"last_sequence"`
+}
+
+// GetAuditLogCheckpoints returns the checkpoints in a sequence range, in order.
+func (q *sqlQuerier) GetAuditLogCheckpoints(ctx context.Context, arg GetAuditLogCheckpointsParams) ([]AuditLogCheckpoint, error) {
+	rows, err := q.db.QueryContext(ctx, getAuditLogCheckpoints, arg.FirstSequence, arg.LastSequence)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AuditLogCheckpoint
+	for rows.Next() {
+		var i AuditLogCheckpoint
+		if err := rows.Scan(
+			&i.Sequence,
+			&i.Hash,
+			&i.KeySequence,
+			&i.Signature,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAuditLogExportCursor = `-- name: GetAuditLogExportCursor :one
+SELECT sink, last_xact_id, last_id, updated_at, leased_by, lease_expires_at FROM audit_log_export_cursors WHERE sink = $1
+`
+
+func (q *sqlQuerier) GetAuditLogExportCursor(ctx context.Context, sink string) (AuditLogExportCursor, error) {
+	row := q.db.QueryRowContext(ctx, getAuditLogExportCursor, sink)
+	var i AuditLogExportCursor
+	err := row.Scan(
+		&i.Sink,
+		&i.LastXactID,
+		&i.LastID,
+		&i.UpdatedAt,
+		&i.LeasedBy,
+		&i.LeaseExpiresAt,
+	)
+	return i, err
+}
+
+const getAuditLogHashSequenceRange = `-- name: GetAuditLogHashSequenceRange :one
+SELECT
+	COALESCE(MIN(sequence), 0) :: bigint AS first_sequence,
+	COALESCE(MAX(sequence), 0) :: bigint AS last_sequence
+FROM
+	audit_log_hashes
+WHERE
+	audit_log_time >= $1 :: timestamptz
+	AND audit_log_time <= $2 :: timestamptz
+`
+
+type GetAuditLogHashSequenceRangeParams struct {
+	FromTime time.Time `db:"from_time" json:"from_time"`
+	ToTime   time.Time `db:"to_time" json:"to_time"`
+}
+
+type GetAuditLogHashSequenceRangeRow struct {
+	FirstSequence int64 `db:"first_sequence" json:"first_sequence"`
+	LastSequence  int64 `db:"last_sequence" json:"last_sequence"`
+}
+
+// GetAuditLogHashSequenceRange returns the first and last sequence of the hash
+// chain entries of the audit logs in a time range. Both are 0 when there are
+// none.
+func (q *sqlQuerier) GetAuditLogHashSequenceRange(ctx context.Context, arg GetAuditLogHashSequenceRangeParams) (GetAuditLogHashSequenceRangeRow, error) {
+	row := q.db.QueryRowContext(ctx, getAuditLogHashSequenceRange, arg.FromTime, arg.ToTime)
+	var i GetAuditLogHashSequenceRangeRow
+	err := row.Scan(&i.FirstSequence, &i.LastSequence)
+	return i, err
+}
+
+const getAuditLogHashes = `-- name: GetAuditLogHashes :many
+SELECT
+	sequence, audit_log_id, audit_log_time, audit_log_xact_id, previous_hash, hash
+FROM
+	audit_log_hashes
+WHERE
+	sequence > $1 :: bigint
+	AND sequence <= $2 :: bigint
+ORDER BY
+	sequence ASC
+LIMIT
+	NULLIF($3 :: int, 0)
+`
+
+type GetAuditLogHashesParams struct {
+	AfterSequence int64 `db:"after_sequence" json:"after_sequence"`
+	LastSequence  int64 `db:"last_sequence" json:"last_sequence"`
+	LimitOpt      int32 `db:"limit_opt" json:"limit_opt"`
+}
+
+// GetAuditLogHashes returns the hash chain entries after a sequence, up to and
+// including the last sequence, in order.
+func (q *sqlQuerier) GetAuditLogHashes(ctx context.Context, arg GetAuditLogHashesParams) ([]AuditLogHash, error) {
+	rows, err := q.db.QueryContext(ctx, getAuditLogHashes, arg.AfterSequence, arg.LastSequence, arg.LimitOpt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AuditLogHash
+	for rows.Next() {
+		var i AuditLogHash
+		if err := rows.Scan(
+			&i.Sequence,
+			&i.AuditLogID,
+			&i.AuditLogTime,
+			&i.AuditLogXactID,
+			&i.PreviousHash,
+			&i.Hash,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAuditLogsByIDs = `-- name: GetAuditLogsByIDs :many
+SELECT id, time, user_id, organization_id, ip, user_agent, resource_type, resource_id, resource_target, action, diff, status_code, additional_fields, request_id, resource_icon, xact_id FROM audit_logs WHERE id = ANY($1 :: uuid [ ])
+`
+
+func (q *sqlQuerier) GetAuditLogsByIDs(ctx context.Context, ids []uuid.UUID) ([]AuditLog, error) {
+	rows, err := q.db.QueryContext(ctx, getAuditLogsByIDs, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AuditLog
+	for rows.Next() {
+		var i AuditLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.Time,
+			&i.UserID,
+			&i.OrganizationID,
+			&i.Ip,
+			&i.UserAgent,
+			&i.ResourceType,
+			&i.ResourceID,
+			&i.ResourceTarget,
+			&i.Action,
+			&i.Diff,
+			&i.StatusCode,
+			&i.AdditionalFields,
+			&i.RequestID,
+			&i.ResourceIcon,
+			&i.XactID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAuditLogsForExport = `-- name: GetAuditLogsForExport :many
+SELECT
+	audit_logs.id, audit_logs.time, audit_logs.user_id, audit_logs.organization_id, audit_logs.ip, audit_logs.user_agent, audit_logs.resource_type, audit_logs.resource_id, audit_logs.resource_target, audit_logs.action, audit_logs.diff, audit_logs.status_code, audit_logs.additional_fields, audit_logs.request_id, audit_logs.resource_icon, audit_logs.xact_id,
+	COALESCE(users.username, '') AS user_username,
+	COALESCE(users.email, '') AS user_email,
+	COALESCE(organizations.name, '') AS organization_name
+FROM
+	audit_logs
+	LEFT JOIN users ON audit_logs.user_id = users.id
+	LEFT JOIN organizations ON audit_logs.organization_id = organizations.id
+WHERE
+	audit_logs.xact_id IS NOT NULL
+	AND (audit_logs.xact_id, audit_logs.id) > ($1 :: bigint, $2 :: uuid)
+	AND audit_logs.xact_id < pg_snapshot_xmin(pg_current_snapshot()) :: text :: bigint
+ORDER BY
+	audit_logs.xact_id ASC,
+	audit_logs.id ASC
+LIMIT
+	$3 :: int
+`
+
+type GetAuditLogsForExportParams struct {
+	AfterXactID int64     `db:"after_xact_id" json:"after_xact_id"`
+	AfterID     uuid.UUID `db:"after_id" json:"after_id"`
+	LimitOpt    int32     `db:"limit_opt" json:"limit_opt"`
+}
+
+type GetAuditLogsForExportRow struct {
+	AuditLog         AuditLog `db:"audit_log" json:"audit_log"`
+	UserUsername     string   `db:"user_username" json:"user_username"`
+	UserEmail        string   `db:"user_email" json:"user_email"`
+	OrganizationName string   `db:"organization_name" json:"organization_name"`
+}
+
+// GetAuditLogsForExport returns the audit logs ordered after the cursor of a
+// streaming sink, in the order of the transactions which inserted them. Audit
+// logs of transactions which may still be running are held back, so none can
+// be inserted before the cursor once it advanced past them.
+func (q *sqlQuerier) GetAuditLogsForExport(ctx context.Context, arg GetAuditLogsForExportParams) ([]GetAuditLogsForExportRow, error) {
+	rows, err := q.db.QueryContext(ctx, getAuditLogsForExport, arg.AfterXactID, arg.AfterID, arg.LimitOpt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetAuditLogsForExportRow
+	for rows.Next() {
+		var i GetAuditLogsForExportRow
+		if err := rows.Scan(
+			&i.AuditLog.ID,
+			&i.AuditLog.Time,
+			&i.AuditLog.UserID,
+			&i.AuditLog.OrganizationID,
+			&i.AuditLog.Ip,
+			&i.AuditLog.UserAgent,
+			&i.AuditLog.ResourceType,
+			&i.AuditLog.ResourceID,
+			&i.AuditLog.ResourceTarget,
+			&i.AuditLog.Action,
+			&i.AuditLog.Diff,
+			&i.AuditLog.StatusCode,
+			&i.AuditLog.AdditionalFields,
+			&i.AuditLog.RequestID,
+			&i.AuditLog.ResourceIcon,
+			&i.AuditLog.XactID,
+			&i.UserUsername,
+			&i.UserEmail,
+			&i.OrganizationName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getAuditLogsOffset = `-- name: GetAuditLogsOffset :many
-SELECT audit_logs.id, audit_logs.time, audit_logs.user_id, audit_logs.organization_id, audit_logs.ip, audit_logs.user_agent, audit_logs.resource_type, audit_logs.resource_id, audit_logs.resource_target, audit_logs.action, audit_logs.diff, audit_logs.status_code, audit_logs.additional_fields, audit_logs.request_id, audit_logs.resource_icon,
+SELECT audit_logs.id, audit_logs.time, audit_logs.user_id, audit_logs.organization_id, audit_logs.ip, audit_logs.user_agent, audit_logs.resource_type, audit_logs.resource_id, audit_logs.resource_target, audit_logs.action, audit_logs.diff, audit_logs.status_code, audit_logs.additional_fields, audit_logs.request_id, audit_logs.resource_icon, audit_logs.xact_id,
 	-- sqlc.embed(users) would be nice but it does not seem to play well with
 	-- left joins.
 	users.username AS user_username,
@@ -756,6 +1205,7 @@ func (q *sqlQuerier) GetAuditLogsOffset(ctx context.Context, arg GetAuditLogsOff
 			&i.AuditLog.AdditionalFields,
 			&i.AuditLog.RequestID,
 			&i.AuditLog.ResourceIcon,
+			&i.AuditLog.XactID,
 			&i.UserUsername,
 			&i.UserName,
 			&i.UserEmail,
@@ -785,15 +1235,294 @@ func (q *sqlQuerier) GetAuditLogsOffset(ctx context.Context, arg GetAuditLogsOff
 	return items, nil
 }
 
-const insertAuditLog = `-- name: InsertAuditLog :one
-INSERT INTO audit_logs (
-		id,
-		"time",
-		user_id,
-		organization_id,
-		ip,
-		user_agent,
-		resource_type,
+const getAuditLogsToChain = `-- name: GetAuditLogsToChain :many
+SELECT
+	id, time, user_id, organization_id, ip, user_agent, resource_type, resource_id, resource_target, action, diff, status_code, additional_fields, request_id, resource_icon, xact_id
+FROM
+	audit_logs
+WHERE
+	audit_logs.xact_id IS NOT NULL
+	AND (audit_logs.xact_id, audit_logs.id) > ($1 :: bigint, $2 :: uuid)
+	AND audit_logs.xact_id < pg_snapshot_xmin(pg_current_snapshot()) :: text :: bigint
+	AND NOT EXISTS (
+		SELECT 1 FROM audit_log_hashes WHERE audit_log_hashes.audit_log_id = audit_logs.id
+	)
+ORDER BY
+	audit_logs.xact_id ASC,
+	audit_logs.id ASC
+LIMIT
+	$3 :: int
+`
+
+type GetAuditLogsToChainParams struct {
+	AfterXactID int64     `db:"after_xact_id" json:"after_xact_id"`
+	AfterID     uuid.UUID `db:"after_id" json:"after_id"`
+	LimitOpt    int32     `db:"limit_opt" json:"limit_opt"`
+}
+
+// GetAuditLogsToChain returns the audit logs ordered after the head of the
+// hash chain, in the order of the transactions which inserted them. Audit logs
+// of transactions which may still be running are held back, and audit logs
+// which are chained already, such as restored ones, are skipped.
+func (q *sqlQuerier) GetAuditLogsToChain(ctx context.Context, arg GetAuditLogsToChainParams) ([]AuditLog, error) {
+	rows, err := q.db.QueryContext(ctx, getAuditLogsToChain, arg.AfterXactID, arg.AfterID, arg.LimitOpt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AuditLog
+	for rows.Next() {
+		var i AuditLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.Time,
+			&i.UserID,
+			&i.OrganizationID,
+			&i.Ip,
+			&i.UserAgent,
+			&i.ResourceType,
+			&i.ResourceID,
+			&i.ResourceTarget,
+			&i.Action,
+			&i.Diff,
+			&i.StatusCode,
+			&i.AdditionalFields,
+			&i.RequestID,
+			&i.ResourceIcon,
+			&i.XactID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getExpiredAuditLogs = `-- name: GetExpiredAuditLogs :many
+SELECT
+	id, time, user_id, organization_id, ip, user_agent, resource_type, resource_id, resource_target, action, diff, status_code, additional_fields, request_id, resource_icon, xact_id
+FROM
+	audit_logs
+WHERE
+	audit_logs.time < $1 :: timestamptz
+	AND NOT EXISTS (
+		SELECT 1 FROM audit_log_archive_entries WHERE audit_log_archive_entries.audit_log_id = audit_logs.id
+	)
+	AND audit_logs.time < COALESCE(
+		(
+			SELECT
+				rules.cutoff
+			FROM
+				unnest($2 :: text[], $3 :: text[], $4 :: timestamptz[]) AS rules(resource_type, action, cutoff)
+			WHERE
+				(rules.resource_type = '' OR rules.resource_type = audit_logs.resource_type :: text)
+				AND (rules.action = '' OR rules.action = audit_logs.action :: text)
+			ORDER BY
+				rules.resource_type != '' DESC,
+				rules.action != '' DESC
+			LIMIT 1
+		),
+		$5 :: timestamptz
+	)
+	AND (
+		audit_logs.xact_id IS NULL
+		OR NOT EXISTS (
+			SELECT
+				1
+			FROM
+				audit_log_export_cursors
+			WHERE
+				audit_log_export_cursors.lease_expires_at > $6 :: timestamptz
+				AND (audit_logs.xact_id, audit_logs.id) > (audit_log_export_cursors.last_xact_id, audit_log_export_cursors.last_id)
+		)
+	)
+ORDER BY
+	audit_logs.time ASC,
+	audit_logs.id ASC
+LIMIT
+	$7 :: int
+`
+
+type GetExpiredAuditLogsParams struct {
+	MaxCutoff         time.Time   `db:"max_cutoff" json:"max_cutoff"`
+	RuleResourceTypes []string    `db:"rule_resource_types" json:"rule_resource_types"`
+	RuleActions       []string    `db:"rule_actions" json:"rule_actions"`
+	RuleCutoffs       []time.Time `db:"rule_cutoffs" json:"rule_cutoffs"`
+	DefaultCutoff     time.Time   `db:"default_cutoff" json:"default_cutoff"`
+	SinkActiveAfter   time.Time   `db:"sink_active_after" json:"sink_active_after"`
+	LimitOpt          int32       `db:"limit_opt" json:"limit_opt"`
+}
+
+// GetExpiredAuditLogs returns the audit logs which outlived their retention
+// and were not archived yet, oldest first. Retention rules are given as
+// parallel arrays, where an empty resource type or action matches any. The
+// most specific matching rule applies, and the default cutoff applies to audit
+// logs no rule matches. max_cutoff is the latest of all cutoffs, so the scan
+// is bounded by the time index. Audit logs which weren't delivered to a
+// streaming sink leased after sink_active_after yet are kept.
+func (q *sqlQuerier) GetExpiredAuditLogs(ctx context.Context, arg GetExpiredAuditLogsParams) ([]AuditLog, error) {
+	rows, err := q.db.QueryContext(ctx, getExpiredAuditLogs,
+		arg.MaxCutoff,
+		pq.Array(arg.RuleResourceTypes),
+		pq.Array(arg.RuleActions),
+		pq.Array(arg.RuleCutoffs),
+		arg.DefaultCutoff,
+		arg.SinkActiveAfter,
+		arg.LimitOpt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AuditLog
+	for rows.Next() {
+		var i AuditLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.Time,
+			&i.UserID,
+			&i.OrganizationID,
+			&i.Ip,
+			&i.UserAgent,
+			&i.ResourceType,
+			&i.ResourceID,
+			&i.ResourceTarget,
+			&i.Action,
+			&i.Diff,
+			&i.StatusCode,
+			&i.AdditionalFields,
+			&i.RequestID,
+			&i.ResourceIcon,
+			&i.XactID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLatestAuditLogCheckpoint = `-- name: GetLatestAuditLogCheckpoint :one
+SELECT sequence, hash, key_sequence, signature, created_at FROM audit_log_checkpoints ORDER BY sequence DESC LIMIT 1
+`
+
+func (q *sqlQuerier) GetLatestAuditLogCheckpoint(ctx context.Context) (AuditLogCheckpoint, error) {
+	row := q.db.QueryRowContext(ctx, getLatestAuditLogCheckpoint)
+	var i AuditLogCheckpoint
+	err := row.Scan(
+		&i.Sequence,
+		&i.Hash,
+		&i.KeySequence,
+		&i.Signature,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getLatestAuditLogHash = `-- name: GetLatestAuditLogHash :one
+SELECT sequence, audit_log_id, audit_log_time, audit_log_xact_id, previous_hash, hash FROM audit_log_hashes ORDER BY sequence DESC LIMIT 1
+`
+
+func (q *sqlQuerier) GetLatestAuditLogHash(ctx context.Context) (AuditLogHash, error) {
+	row := q.db.QueryRowContext(ctx, getLatestAuditLogHash)
+	var i AuditLogHash
+	err := row.Scan(
+		&i.Sequence,
+		&i.AuditLogID,
+		&i.AuditLogTime,
+		&i.AuditLogXactID,
+		&i.PreviousHash,
+		&i.Hash,
+	)
+	return i, err
+}
+
+const getUnchainedAuditLogs = `-- name: GetUnchainedAuditLogs :many
+SELECT
+	id, time
+FROM
+	audit_logs
+WHERE
+	time >= $1 :: timestamptz
+	AND time <= $2 :: timestamptz
+	AND (xact_id IS NULL OR (xact_id, id) <= ($3 :: bigint, $4 :: uuid))
+	AND NOT EXISTS (
+		SELECT 1 FROM audit_log_hashes WHERE audit_log_hashes.audit_log_id = audit_logs.id
+	)
+ORDER BY
+	time ASC
+LIMIT
+	NULLIF($5 :: int, 0)
+`
+
+type GetUnchainedAuditLogsParams struct {
+	FromTime   time.Time `db:"from_time" json:"from_time"`
+	ToTime     time.Time `db:"to_time" json:"to_time"`
+	HeadXactID int64     `db:"head_xact_id" json:"head_xact_id"`
+	HeadID     uuid.UUID `db:"head_id" json:"head_id"`
+	LimitOpt   int32     `db:"limit_opt" json:"limit_opt"`
+}
+
+type GetUnchainedAuditLogsRow struct {
+	ID   uuid.UUID `db:"id" json:"id"`
+	Time time.Time `db:"time" json:"time"`
+}
+
+// GetUnchainedAuditLogs returns the audit logs in a time range which are not
+// part of the hash chain, although they are ordered before its head.
+func (q *sqlQuerier) GetUnchainedAuditLogs(ctx context.Context, arg GetUnchainedAuditLogsParams) ([]GetUnchainedAuditLogsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getUnchainedAuditLogs,
+		arg.FromTime,
+		arg.ToTime,
+		arg.HeadXactID,
+		arg.HeadID,
+		arg.LimitOpt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUnchainedAuditLogsRow
+	for rows.Next() {
+		var i GetUnchainedAuditLogsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Time,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertAuditLog = `-- name: InsertAuditLog :one
+INSERT INTO audit_logs (
+		id,
+		"time",
+		user_id,
+		organization_id,
+		ip,
+		user_agent,
+		resource_type,
 		resource_id,
 		resource_target,
 		action,
@@ -820,7 +1549,7 @@ VALUES (
 		$14,
 		$15
 	)
-RETURNING id, time, user_id, organization_id, ip, user_agent, resource_type, resource_id, resource_target, action, diff, status_code, additional_fields, request_id, resource_icon
+RETURNING id, time, user_id, organization_id, ip, user_agent, resource_type, resource_id, resource_target, action, diff, status_code, additional_fields, request_id, resource_icon, xact_id
 `
 
 type InsertAuditLogParams struct {
@@ -876,79 +1605,378 @@ func (q *sqlQuerier) InsertAuditLog(ctx context.Context, arg InsertAuditLogParam
 		&i.AdditionalFields,
 		&i.RequestID,
 		&i.ResourceIcon,
+		&i.XactID,
 	)
 	return i, err
 }
 
-const deleteCryptoKey = `-- name: DeleteCryptoKey :one
-UPDATE crypto_keys
-SET secret = NULL, secret_key_id = NULL
-WHERE feature = $1 AND sequence = $2 RETURNING feature, sequence, secret, secret_key_id, starts_at, deletes_at
+const insertAuditLogArchive = `-- name: InsertAuditLogArchive :one
+INSERT INTO audit_log_archives (id, created_at, file_name, file_id, oldest_time, newest_time, audit_log_count, sha256)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING id, created_at, file_name, file_id, oldest_time, newest_time, audit_log_count, sha256, restored_at
 `
 
-type DeleteCryptoKeyParams struct {
-	Feature  CryptoKeyFeature `db:"feature" json:"feature"`
-	Sequence int32            `db:"sequence" json:"sequence"`
+type InsertAuditLogArchiveParams struct {
+	ID            uuid.UUID `db:"id" json:"id"`
+	CreatedAt     time.Time `db:"created_at" json:"created_at"`
+	FileName      string    `db:"file_name" json:"file_name"`
+	FileID        uuid.UUID `db:"file_id" json:"file_id"`
+	OldestTime    time.Time `db:"oldest_time" json:"oldest_time"`
+	NewestTime    time.Time `db:"newest_time" json:"newest_time"`
+	AuditLogCount int32     `db:"audit_log_count" json:"audit_log_count"`
+	Sha256        []byte    `db:"sha256" json:"sha256"`
 }
 
-func (q *sqlQuerier) DeleteCryptoKey(ctx context.Context, arg DeleteCryptoKeyParams) (CryptoKey, error) {
-	row := q.db.QueryRowContext(ctx, deleteCryptoKey, arg.Feature, arg.Sequence)
-	var i CryptoKey
+func (q *sqlQuerier) InsertAuditLogArchive(ctx context.Context, arg InsertAuditLogArchiveParams) (AuditLogArchive, error) {
+	row := q.db.QueryRowContext(ctx, insertAuditLogArchive,
+		arg.ID,
+		arg.CreatedAt,
+		arg.FileName,
+		arg.FileID,
+		arg.OldestTime,
+		arg.NewestTime,
+		arg.AuditLogCount,
+		arg.Sha256,
+	)
+	var i AuditLogArchive
 	err := row.Scan(
-		&i.Feature,
-		&i.Sequence,
-		&i.Secret,
-		&i.SecretKeyID,
-		&i.StartsAt,
-		&i.DeletesAt,
+		&i.ID,
+		&i.CreatedAt,
+		&i.FileName,
+		&i.FileID,
+		&i.OldestTime,
+		&i.NewestTime,
+		&i.AuditLogCount,
+		&i.Sha256,
+		&i.RestoredAt,
 	)
 	return i, err
 }
 
-const getCryptoKeyByFeatureAndSequence = `-- name: GetCryptoKeyByFeatureAndSequence :one
-SELECT feature, sequence, secret, secret_key_id, starts_at, deletes_at
-FROM crypto_keys
-WHERE feature = $1
-  AND sequence = $2
-  AND secret IS NOT NULL
+const insertAuditLogArchiveEntries = `-- name: InsertAuditLogArchiveEntries :exec
+INSERT INTO audit_log_archive_entries (audit_log_id, archive_id)
+SELECT unnest($1 :: uuid[]), $2 :: uuid
 `
 
-type GetCryptoKeyByFeatureAndSequenceParams struct {
-	Feature  CryptoKeyFeature `db:"feature" json:"feature"`
-	Sequence int32            `db:"sequence" json:"sequence"`
+type InsertAuditLogArchiveEntriesParams struct {
+	AuditLogIDs []uuid.UUID `db:"audit_log_ids" json:"audit_log_ids"`
+	ArchiveID   uuid.UUID   `db:"archive_id" json:"archive_id"`
 }
 
-func (q *sqlQuerier) GetCryptoKeyByFeatureAndSequence(ctx context.Context, arg GetCryptoKeyByFeatureAndSequenceParams) (CryptoKey, error) {
-	row := q.db.QueryRowContext(ctx, getCryptoKeyByFeatureAndSequence, arg.Feature, arg.Sequence)
-	var i CryptoKey
-	err := row.Scan(
-		&i.Feature,
-		&i.Sequence,
-		&i.Secret,
-		&i.SecretKeyID,
-		&i.StartsAt,
-		&i.DeletesAt,
+func (q *sqlQuerier) InsertAuditLogArchiveEntries(ctx context.Context, arg InsertAuditLogArchiveEntriesParams) error {
+	_, err := q.db.ExecContext(ctx, insertAuditLogArchiveEntries, pq.Array(arg.AuditLogIDs), arg.ArchiveID)
+	return err
+}
+
+const insertAuditLogCheckpoint = `-- name: InsertAuditLogCheckpoint :exec
+INSERT INTO audit_log_checkpoints (sequence, hash, key_sequence, signature, created_at)
+VALUES ($1, $2, $3, $4, $5)
+`
+
+type InsertAuditLogCheckpointParams struct {
+	Sequence    int64     `db:"sequence" json:"sequence"`
+	Hash        []byte    `db:"hash" json:"hash"`
+	KeySequence int32     `db:"key_sequence" json:"key_sequence"`
+	Signature   []byte    `db:"signature" json:"signature"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+}
+
+func (q *sqlQuerier) InsertAuditLogCheckpoint(ctx context.Context, arg InsertAuditLogCheckpointParams) error {
+	_, err := q.db.ExecContext(ctx, insertAuditLogCheckpoint,
+		arg.Sequence,
+		arg.Hash,
+		arg.KeySequence,
+		arg.Signature,
+		arg.CreatedAt,
 	)
-	return i, err
+	return err
 }
 
-const getCryptoKeys = `-- name: GetCryptoKeys :many
-SELECT feature, sequence, secret, secret_key_id, starts_at, deletes_at
-FROM crypto_keys
-WHERE secret IS NOT NULL
+const insertAuditLogHash = `-- name: InsertAuditLogHash :exec
+INSERT INTO audit_log_hashes (sequence, audit_log_id, audit_log_time, audit_log_xact_id, previous_hash, hash)
+VALUES ($1, $2, $3, $4, $5, $6)
 `
 
-func (q *sqlQuerier) GetCryptoKeys(ctx context.Context) ([]CryptoKey, error) {
-	rows, err := q.db.QueryContext(ctx, getCryptoKeys)
+type InsertAuditLogHashParams struct {
+	Sequence       int64     `db:"sequence" json:"sequence"`
+	AuditLogID     uuid.UUID `db:"audit_log_id" json:"audit_log_id"`
+	AuditLogTime   time.Time `db:"audit_log_time" json:"audit_log_time"`
+	AuditLogXactID int64     `db:"audit_log_xact_id" json:"audit_log_xact_id"`
+	PreviousHash   []byte    `db:"previous_hash" json:"previous_hash"`
+	Hash           []byte    `db:"hash" json:"hash"`
+}
+
+func (q *sqlQuerier) InsertAuditLogHash(ctx context.Context, arg InsertAuditLogHashParams) error {
+	_, err := q.db.ExecContext(ctx, insertAuditLogHash,
+		arg.Sequence,
+		arg.AuditLogID,
+		arg.AuditLogTime,
+		arg.AuditLogXactID,
+		arg.PreviousHash,
+		arg.Hash,
+	)
+	return err
+}
+
+const updateAuditLogArchiveRestoredAt = `-- name: UpdateAuditLogArchiveRestoredAt :exec
+UPDATE audit_log_archives SET restored_at = $1 WHERE id = $2
+`
+
+type UpdateAuditLogArchiveRestoredAtParams struct {
+	RestoredAt sql.NullTime `db:"restored_at" json:"restored_at"`
+	ID         uuid.UUID    `db:"id" json:"id"`
+}
+
+func (q *sqlQuerier) UpdateAuditLogArchiveRestoredAt(ctx context.Context, arg UpdateAuditLogArchiveRestoredAtParams) error {
+	_, err := q.db.ExecContext(ctx, updateAuditLogArchiveRestoredAt, arg.RestoredAt, arg.ID)
+	return err
+}
+
+const updateAuditLogExportCursor = `-- name: UpdateAuditLogExportCursor :execrows
+UPDATE audit_log_export_cursors
+SET
+	last_xact_id = $1,
+	last_id = $2,
+	updated_at = $3,
+	lease_expires_at = $4 :: timestamptz
+WHERE
+	sink = $5
+	AND leased_by = $6 :: uuid
+`
+
+type UpdateAuditLogExportCursorParams struct {
+	LastXactID     int64     `db:"last_xact_id" json:"last_xact_id"`
+	LastID         uuid.UUID `db:"last_id" json:"last_id"`
+	UpdatedAt      time.Time `db:"updated_at" json:"updated_at"`
+	LeaseExpiresAt time.Time `db:"lease_expires_at" json:"lease_expires_at"`
+	Sink           string    `db:"sink" json:"sink"`
+	LeasedBy       uuid.UUID `db:"leased_by" json:"leased_by"`
+}
+
+// UpdateAuditLogExportCursor advances the cursor of a streaming sink and
+// extends the lease, if the replica still holds it.
+func (q *sqlQuerier) UpdateAuditLogExportCursor(ctx context.Context, arg UpdateAuditLogExportCursorParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateAuditLogExportCursor,
+		arg.LastXactID,
+		arg.LastID,
+		arg.UpdatedAt,
+		arg.LeaseExpiresAt,
+		arg.Sink,
+		arg.LeasedBy,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getTemplateAutostopWindows = `-- name: GetTemplateAutostopWindows :many
+SELECT
+	template_id, weekday, stop_minute
+FROM
+	template_autostop_windows
+WHERE
+	template_id = $1
+ORDER BY
+	weekday ASC
+`
+
+func (q *sqlQuerier) GetTemplateAutostopWindows(ctx context.Context, templateID uuid.UUID) ([]TemplateAutostopWindow, error) {
+	rows, err := q.db.QueryContext(ctx, getTemplateAutostopWindows, templateID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []CryptoKey
+	var items []TemplateAutostopWindow
 	for rows.Next() {
-		var i CryptoKey
+		var i TemplateAutostopWindow
 		if err := rows.Scan(
-			&i.Feature,
+			&i.TemplateID,
+			&i.Weekday,
+			&i.StopMinute,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserAutostopWindows = `-- name: GetUserAutostopWindows :many
+SELECT
+	user_id, weekday, stop_minute
+FROM
+	user_autostop_windows
+WHERE
+	user_id = $1
+ORDER BY
+	weekday ASC
+`
+
+func (q *sqlQuerier) GetUserAutostopWindows(ctx context.Context, userID uuid.UUID) ([]UserAutostopWindow, error) {
+	rows, err := q.db.QueryContext(ctx, getUserAutostopWindows, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UserAutostopWindow
+	for rows.Next() {
+		var i UserAutostopWindow
+		if err := rows.Scan(
+			&i.UserID,
+			&i.Weekday,
+			&i.StopMinute,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateTemplateAutostopWindows = `-- name: UpdateTemplateAutostopWindows :exec
+WITH deleted AS (
+	DELETE FROM
+		template_autostop_windows
+	WHERE
+		template_id = $1
+		AND NOT (weekday = ANY($2 :: smallint[]))
+)
+INSERT INTO
+	template_autostop_windows (template_id, weekday, stop_minute)
+SELECT
+	$1,
+	unnest($2 :: smallint[]),
+	unnest($3 :: integer[])
+ON CONFLICT (template_id, weekday) DO UPDATE SET
+	stop_minute = EXCLUDED.stop_minute
+`
+
+type UpdateTemplateAutostopWindowsParams struct {
+	TemplateID  uuid.UUID `db:"template_id" json:"template_id"`
+	Weekdays    []int16   `db:"weekdays" json:"weekdays"`
+	StopMinutes []int32   `db:"stop_minutes" json:"stop_minutes"`
+}
+
+// Replaces the autostop windows of a template. Weekdays not in the arguments
+// no longer have a window.
+func (q *sqlQuerier) UpdateTemplateAutostopWindows(ctx context.Context, arg UpdateTemplateAutostopWindowsParams) error {
+	_, err := q.db.ExecContext(ctx, updateTemplateAutostopWindows, arg.TemplateID, pq.Array(arg.Weekdays), pq.Array(arg.StopMinutes))
+	return err
+}
+
+const updateUserAutostopWindows = `-- name: UpdateUserAutostopWindows :exec
+WITH deleted AS (
+	DELETE FROM
+		user_autostop_windows
+	WHERE
+		user_id = $1
+		AND NOT (weekday = ANY($2 :: smallint[]))
+)
+INSERT INTO
+	user_autostop_windows (user_id, weekday, stop_minute)
+SELECT
+	$1,
+	unnest($2 :: smallint[]),
+	unnest($3 :: integer[])
+ON CONFLICT (user_id, weekday) DO UPDATE SET
+	stop_minute = EXCLUDED.stop_minute
+`
+
+type UpdateUserAutostopWindowsParams struct {
+	UserID      uuid.UUID `db:"user_id" json:"user_id"`
+	Weekdays    []int16   `db:"weekdays" json:"weekdays"`
+	StopMinutes []int32   `db:"stop_minutes" json:"stop_minutes"`
+}
+
+// Replaces the autostop windows of a user. Weekdays not in the arguments no
+// longer have a window.
+func (q *sqlQuerier) UpdateUserAutostopWindows(ctx context.Context, arg UpdateUserAutostopWindowsParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserAutostopWindows, arg.UserID, pq.Array(arg.Weekdays), pq.Array(arg.StopMinutes))
+	return err
+}
+
+const deleteCryptoKey = `-- name: DeleteCryptoKey :one
+UPDATE crypto_keys
+SET secret = NULL, secret_key_id = NULL
+WHERE feature = $1 AND sequence = $2 RETURNING feature, sequence, secret, secret_key_id, starts_at, deletes_at
+`
+
+type DeleteCryptoKeyParams struct {
+	Feature  CryptoKeyFeature `db:"feature" json:"feature"`
+	Sequence int32            `db:"sequence" json:"sequence"`
+}
+
+func (q *sqlQuerier) DeleteCryptoKey(ctx context.Context, arg DeleteCryptoKeyParams) (CryptoKey, error) {
+	row := q.db.QueryRowContext(ctx, deleteCryptoKey, arg.Feature, arg.Sequence)
+	var i CryptoKey
+	err := row.Scan(
+		&i.Feature,
+		&i.Sequence,
+		&i.Secret,
+		&i.SecretKeyID,
+		&i.StartsAt,
+		&i.DeletesAt,
+	)
+	return i, err
+}
+
+const getCryptoKeyByFeatureAndSequence = `-- name: GetCryptoKeyByFeatureAndSequence :one
+SELECT feature, sequence, secret, secret_key_id, starts_at, deletes_at
+FROM crypto_keys
+WHERE feature = $1
+  AND sequence = $2
+  AND secret IS NOT NULL
+`
+
+type GetCryptoKeyByFeatureAndSequenceParams struct {
+	Feature  CryptoKeyFeature `db:"feature" json:"feature"`
+	Sequence int32            `db:"sequence" json:"sequence"`
+}
+
+func (q *sqlQuerier) GetCryptoKeyByFeatureAndSequence(ctx context.Context, arg GetCryptoKeyByFeatureAndSequenceParams) (CryptoKey, error) {
+	row := q.db.QueryRowContext(ctx, getCryptoKeyByFeatureAndSequence, arg.Feature, arg.Sequence)
+	var i CryptoKey
+	err := row.Scan(
+		&i.Feature,
+		&i.Sequence,
+		&i.Secret,
+		&i.SecretKeyID,
+		&i.StartsAt,
+		&i.DeletesAt,
+	)
+	return i, err
+}
+
+const getCryptoKeys = `-- name: GetCryptoKeys :many
+SELECT feature, sequence, secret, secret_key_id, starts_at, deletes_at
+FROM crypto_keys
+WHERE secret IS NOT NULL
+`
+
+func (q *sqlQuerier) GetCryptoKeys(ctx context.Context) ([]CryptoKey, error) {
+	rows, err := q.db.QueryContext(ctx, getCryptoKeys)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CryptoKey
+	for rows.Next() {
+		var i CryptoKey
+		if err := rows.Scan(
+			&i.Feature,
 			&i.Sequence,
 			&i.Secret,
 			&i.SecretKeyID,
@@ -3857,6 +4885,88 @@ func (q *sqlQuerier) TryAcquireLock(ctx context.Context, pgTryAdvisoryXactLock i
 	return pg_try_advisory_xact_lock, err
 }
 
+const acquireNotificationDigestMessages = `-- name: AcquireNotificationDigestMessages :many
+WITH acquired AS (
+    UPDATE
+        notification_messages
+            SET updated_at = NOW(),
+                status = 'leased'::notification_message_status,
+                status_reason = 'Leased for a digest by notifier ' || $1::uuid,
+                leased_until = NOW() + CONCAT($2::int, ' seconds')::interval
+            WHERE id IN (SELECT nm.id
+                         FROM notification_messages AS nm
+                         WHERE nm.status = 'batched'::notification_message_status
+                           AND nm.next_retry_after <= NOW()
+                         ORDER BY nm.user_id, nm.method, nm.created_at
+                             FOR UPDATE OF nm
+                                 SKIP LOCKED
+                         LIMIT $3)
+            RETURNING id, notification_template_id, user_id, method, status, status_reason, created_by, payload, attempt_count, targets, created_at, updated_at, leased_until, next_retry_after, queued_seconds, dedupe_hash)
+SELECT nm.id,
+       nm.user_id,
+       nm.method,
+       nm.payload,
+       nm.created_at,
+       nt.name AS template_name,
+       nt.title_template,
+       uns.timezone
+FROM acquired nm
+         JOIN notification_templates nt ON nm.notification_template_id = nt.id
+         LEFT JOIN user_notification_schedules AS uns ON uns.user_id = nm.user_id
+ORDER BY nm.user_id, nm.method, nm.created_at
+`
+
+type AcquireNotificationDigestMessagesParams struct {
+	NotifierID   uuid.UUID `db:"notifier_id" json:"notifier_id"`
+	LeaseSeconds int32     `db:"lease_seconds" json:"lease_seconds"`
+	Count        int32     `db:"count" json:"count"`
+}
+
+type AcquireNotificationDigestMessagesRow struct {
+	ID            uuid.UUID          `db:"id" json:"id"`
+	UserID        uuid.UUID          `db:"user_id" json:"user_id"`
+	Method        NotificationMethod `db:"method" json:"method"`
+	Payload       json.RawMessage    `db:"payload" json:"payload"`
+	CreatedAt     time.Time          `db:"created_at" json:"created_at"`
+	TemplateName  string             `db:"template_name" json:"template_name"`
+	TitleTemplate string             `db:"title_template" json:"title_template"`
+	Timezone      sql.NullString     `db:"timezone" json:"timezone"`
+}
+
+// Acquires the lease for batched messages whose digest is due, grouped by recipient and method. If the lease expires
+// before the digest is enqueued, the messages are acquired by AcquireNotificationMessages and batched again.
+func (q *sqlQuerier) AcquireNotificationDigestMessages(ctx context.Context, arg AcquireNotificationDigestMessagesParams) ([]AcquireNotificationDigestMessagesRow, error) {
+	rows, err := q.db.QueryContext(ctx, acquireNotificationDigestMessages, arg.NotifierID, arg.LeaseSeconds, arg.Count)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AcquireNotificationDigestMessagesRow
+	for rows.Next() {
+		var i AcquireNotificationDigestMessagesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Method,
+			&i.Payload,
+			&i.CreatedAt,
+			&i.TemplateName,
+			&i.TitleTemplate,
+			&i.Timezone,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const acquireNotificationMessages = `-- name: AcquireNotificationMessages :many
 WITH acquired AS (
     UPDATE
@@ -3910,31 +5020,53 @@ SELECT
     nt.id                                                                 AS template_id,
     nt.title_template,
     nt.body_template,
+    nt.critical,
     -- preferences
-    (CASE WHEN np.disabled IS NULL THEN false ELSE np.disabled END)::bool AS disabled
+    (CASE WHEN np.disabled IS NULL THEN false ELSE np.disabled END)::bool AS disabled,
+    ndp.digest_interval,
+    -- messages are delivered individually if the user disabled digests
+    (CASE WHEN dnp.disabled IS NULL THEN false ELSE dnp.disabled END)::bool AS digest_disabled,
+    -- schedule
+    uns.timezone,
+    uns.quiet_hours_start,
+    uns.quiet_hours_end,
+    uns.daily_digest_time
 FROM acquired nm
          JOIN notification_templates nt ON nm.notification_template_id = nt.id
          LEFT JOIN notification_preferences AS np
                    ON (np.user_id = nm.user_id AND np.notification_template_id = nm.notification_template_id)
+         LEFT JOIN notification_digest_preferences AS ndp
+                   ON (ndp.user_id = nm.user_id AND ndp.notification_template_id = nm.notification_template_id)
+         LEFT JOIN notification_preferences AS dnp
+                   ON (dnp.user_id = nm.user_id AND dnp.notification_template_id = $5::uuid)
+         LEFT JOIN user_notification_schedules AS uns ON uns.user_id = nm.user_id
 `
 
 type AcquireNotificationMessagesParams struct {
-	NotifierID      uuid.UUID `db:"notifier_id" json:"notifier_id"`
-	LeaseSeconds    int32     `db:"lease_seconds" json:"lease_seconds"`
-	MaxAttemptCount int32     `db:"max_attempt_count" json:"max_attempt_count"`
-	Count           int32     `db:"count" json:"count"`
+	NotifierID       uuid.UUID `db:"notifier_id" json:"notifier_id"`
+	LeaseSeconds     int32     `db:"lease_seconds" json:"lease_seconds"`
+	MaxAttemptCount  int32     `db:"max_attempt_count" json:"max_attempt_count"`
+	Count            int32     `db:"count" json:"count"`
+	DigestTemplateID uuid.UUID `db:"digest_template_id" json:"digest_template_id"`
 }
 
 type AcquireNotificationMessagesRow struct {
-	ID            uuid.UUID          `db:"id" json:"id"`
-	Payload       json.RawMessage    `db:"payload" json:"payload"`
-	Method        NotificationMethod `db:"method" json:"method"`
-	AttemptCount  int32              `db:"attempt_count" json:"attempt_count"`
-	QueuedSeconds float64            `db:"queued_seconds" json:"queued_seconds"`
-	TemplateID    uuid.UUID          `db:"template_id" json:"template_id"`
-	TitleTemplate string             `db:"title_template" json:"title_template"`
-	BodyTemplate  string             `db:"body_template" json:"body_template"`
-	Disabled      bool               `db:"disabled" json:"disabled"`
+	ID              uuid.UUID                      `db:"id" json:"id"`
+	Payload         json.RawMessage                `db:"payload" json:"payload"`
+	Method          NotificationMethod             `db:"method" json:"method"`
+	AttemptCount    int32                          `db:"attempt_count" json:"attempt_count"`
+	QueuedSeconds   float64                        `db:"queued_seconds" json:"queued_seconds"`
+	TemplateID      uuid.UUID                      `db:"template_id" json:"template_id"`
+	TitleTemplate   string                         `db:"title_template" json:"title_template"`
+	BodyTemplate    string                         `db:"body_template" json:"body_template"`
+	Critical        bool                           `db:"critical" json:"critical"`
+	Disabled        bool                           `db:"disabled" json:"disabled"`
+	DigestInterval  NullNotificationDigestInterval `db:"digest_interval" json:"digest_interval"`
+	DigestDisabled  bool                           `db:"digest_disabled" json:"digest_disabled"`
+	Timezone        sql.NullString                 `db:"timezone" json:"timezone"`
+	QuietHoursStart sql.NullInt16                  `db:"quiet_hours_start" json:"quiet_hours_start"`
+	QuietHoursEnd   sql.NullInt16                  `db:"quiet_hours_end" json:"quiet_hours_end"`
+	DailyDigestTime sql.NullInt16                  `db:"daily_digest_time" json:"daily_digest_time"`
 }
 
 // Acquires the lease for a given count of notification messages, to enable concurrent dequeuing and subsequent sending.
@@ -3953,6 +5085,7 @@ func (q *sqlQuerier) AcquireNotificationMessages(ctx context.Context, arg Acquir
 		arg.LeaseSeconds,
 		arg.MaxAttemptCount,
 		arg.Count,
+		arg.DigestTemplateID,
 	)
 	if err != nil {
 		return nil, err
@@ -3970,7 +5103,14 @@ func (q *sqlQuerier) AcquireNotificationMessages(ctx context.Context, arg Acquir
 			&i.TemplateID,
 			&i.TitleTemplate,
 			&i.BodyTemplate,
+			&i.Critical,
 			&i.Disabled,
+			&i.DigestInterval,
+			&i.DigestDisabled,
+			&i.Timezone,
+			&i.QuietHoursStart,
+			&i.QuietHoursEnd,
+			&i.DailyDigestTime,
 		); err != nil {
 			return nil, err
 		}
@@ -3985,6 +5125,42 @@ func (q *sqlQuerier) AcquireNotificationMessages(ctx context.Context, arg Acquir
 	return items, nil
 }
 
+const bulkDeferNotificationMessages = `-- name: BulkDeferNotificationMessages :execrows
+UPDATE notification_messages
+SET updated_at       = NOW(),
+    status           = subquery.status,
+    status_reason    = subquery.status_reason,
+    leased_until     = NULL,
+    next_retry_after = subquery.deliver_after
+FROM (SELECT UNNEST($1::uuid[])                             AS id,
+             UNNEST($2::notification_message_status[]) AS status,
+             UNNEST($3::text[])                  AS status_reason,
+             UNNEST($4::timestamptz[])           AS deliver_after) AS subquery
+WHERE notification_messages.id = subquery.id
+`
+
+type BulkDeferNotificationMessagesParams struct {
+	IDs           []uuid.UUID                 `db:"ids" json:"ids"`
+	Statuses      []NotificationMessageStatus `db:"statuses" json:"statuses"`
+	StatusReasons []string                    `db:"status_reasons" json:"status_reasons"`
+	DeliverAfters []time.Time                 `db:"deliver_afters" json:"deliver_afters"`
+}
+
+// Returns leased messages to the queue without counting a delivery attempt. Pending messages are delivered individually
+// and batched messages in a digest once deliver_after has passed.
+func (q *sqlQuerier) BulkDeferNotificationMessages(ctx context.Context, arg BulkDeferNotificationMessagesParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, bulkDeferNotificationMessages,
+		pq.Array(arg.IDs),
+		pq.Array(arg.Statuses),
+		pq.Array(arg.StatusReasons),
+		pq.Array(arg.DeliverAfters),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const bulkMarkNotificationMessagesFailed = `-- name: BulkMarkNotificationMessagesFailed :execrows
 UPDATE notification_messages
 SET queued_seconds   = 0,
@@ -4070,6 +5246,15 @@ func (q *sqlQuerier) DeleteAllWebpushSubscriptions(ctx context.Context) error {
 	return err
 }
 
+const deleteNotificationRule = `-- name: DeleteNotificationRule :exec
+DELETE FROM notification_rules WHERE id = $1::uuid
+`
+
+func (q *sqlQuerier) DeleteNotificationRule(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteNotificationRule, id)
+	return err
+}
+
 const deleteOldNotificationMessages = `-- name: DeleteOldNotificationMessages :exec
 DELETE
 FROM notification_messages
@@ -4085,6 +5270,22 @@ func (q *sqlQuerier) DeleteOldNotificationMessages(ctx context.Context) error {
 	return err
 }
 
+const deleteUserChatIdentity = `-- name: DeleteUserChatIdentity :exec
+DELETE FROM user_chat_identities
+WHERE user_id = $1::uuid
+  AND method = $2::notification_method
+`
+
+type DeleteUserChatIdentityParams struct {
+	UserID uuid.UUID          `db:"user_id" json:"user_id"`
+	Method NotificationMethod `db:"method" json:"method"`
+}
+
+func (q *sqlQuerier) DeleteUserChatIdentity(ctx context.Context, arg DeleteUserChatIdentityParams) error {
+	_, err := q.db.ExecContext(ctx, deleteUserChatIdentity, arg.UserID, arg.Method)
+	return err
+}
+
 const deleteWebpushSubscriptionByUserIDAndEndpoint = `-- name: DeleteWebpushSubscriptionByUserIDAndEndpoint :exec
 DELETE FROM webpush_subscriptions
 WHERE user_id = $1 AND endpoint = $2
@@ -4195,6 +5396,48 @@ func (q *sqlQuerier) FetchNewMessageMetadata(ctx context.Context, arg FetchNewMe
 	return i, err
 }
 
+const getEnabledNotificationRules = `-- name: GetEnabledNotificationRules :many
+SELECT id, organization_id, user_id, name, source, query, method, threshold, window_seconds, enabled, created_at, updated_at, last_evaluated_at, last_triggered_at FROM notification_rules WHERE enabled ORDER BY organization_id, name
+`
+
+func (q *sqlQuerier) GetEnabledNotificationRules(ctx context.Context) ([]NotificationRule, error) {
+	rows, err := q.db.QueryContext(ctx, getEnabledNotificationRules)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []NotificationRule
+	for rows.Next() {
+		var i NotificationRule
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrganizationID,
+			&i.UserID,
+			&i.Name,
+			&i.Source,
+			&i.Query,
+			&i.Method,
+			&i.Threshold,
+			&i.WindowSeconds,
+			&i.Enabled,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.LastEvaluatedAt,
+			&i.LastTriggeredAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getNotificationMessagesByStatus = `-- name: GetNotificationMessagesByStatus :many
 SELECT id, notification_template_id, user_id, method, status, status_reason, created_by, payload, attempt_count, targets, created_at, updated_at, leased_until, next_retry_after, queued_seconds, dedupe_hash
 FROM notification_messages
@@ -4264,8 +5507,76 @@ func (q *sqlQuerier) GetNotificationReportGeneratorLogByTemplate(ctx context.Con
 	return i, err
 }
 
+const getNotificationRuleByID = `-- name: GetNotificationRuleByID :one
+SELECT id, organization_id, user_id, name, source, query, method, threshold, window_seconds, enabled, created_at, updated_at, last_evaluated_at, last_triggered_at FROM notification_rules WHERE id = $1::uuid
+`
+
+func (q *sqlQuerier) GetNotificationRuleByID(ctx context.Context, id uuid.UUID) (NotificationRule, error) {
+	row := q.db.QueryRowContext(ctx, getNotificationRuleByID, id)
+	var i NotificationRule
+	err := row.Scan(
+		&i.ID,
+		&i.OrganizationID,
+		&i.UserID,
+		&i.Name,
+		&i.Source,
+		&i.Query,
+		&i.Method,
+		&i.Threshold,
+		&i.WindowSeconds,
+		&i.Enabled,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.LastEvaluatedAt,
+		&i.LastTriggeredAt,
+	)
+	return i, err
+}
+
+const getNotificationRulesByOrganizationID = `-- name: GetNotificationRulesByOrganizationID :many
+SELECT id, organization_id, user_id, name, source, query, method, threshold, window_seconds, enabled, created_at, updated_at, last_evaluated_at, last_triggered_at FROM notification_rules WHERE organization_id = $1::uuid ORDER BY name ASC
+`
+
+func (q *sqlQuerier) GetNotificationRulesByOrganizationID(ctx context.Context, organizationID uuid.UUID) ([]NotificationRule, error) {
+	rows, err := q.db.QueryContext(ctx, getNotificationRulesByOrganizationID, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []NotificationRule
+	for rows.Next() {
+		var i NotificationRule
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrganizationID,
+			&i.UserID,
+			&i.Name,
+			&i.Source,
+			&i.Query,
+			&i.Method,
+			&i.Threshold,
+			&i.WindowSeconds,
+			&i.Enabled,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.LastEvaluatedAt,
+			&i.LastTriggeredAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getNotificationTemplateByID = `-- name: GetNotificationTemplateByID :one
-SELECT id, name, title_template, body_template, actions, "group", method, kind, enabled_by_default
+SELECT id, name, title_template, body_template, actions, "group", method, kind, enabled_by_default, critical
 FROM notification_templates
 WHERE id = $1::uuid
 `
@@ -4283,12 +5594,13 @@ func (q *sqlQuerier) GetNotificationTemplateByID(ctx context.Context, id uuid.UU
 		&i.Method,
 		&i.Kind,
 		&i.EnabledByDefault,
+		&i.Critical,
 	)
 	return i, err
 }
 
 const getNotificationTemplatesByKind = `-- name: GetNotificationTemplatesByKind :many
-SELECT id, name, title_template, body_template, actions, "group", method, kind, enabled_by_default
+SELECT id, name, title_template, body_template, actions, "group", method, kind, enabled_by_default, critical
 FROM notification_templates
 WHERE kind = $1::notification_template_kind
 ORDER BY name ASC
@@ -4313,6 +5625,109 @@ func (q *sqlQuerier) GetNotificationTemplatesByKind(ctx context.Context, kind No
 			&i.Method,
 			&i.Kind,
 			&i.EnabledByDefault,
+			&i.Critical,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserChatIdentities = `-- name: GetUserChatIdentities :many
+SELECT user_id, method, identity, created_at, updated_at, verified_at, verification_code_hash, verification_expires_at
+FROM user_chat_identities
+WHERE user_id = $1::uuid
+ORDER BY method::text
+`
+
+func (q *sqlQuerier) GetUserChatIdentities(ctx context.Context, userID uuid.UUID) ([]UserChatIdentity, error) {
+	rows, err := q.db.QueryContext(ctx, getUserChatIdentities, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UserChatIdentity
+	for rows.Next() {
+		var i UserChatIdentity
+		if err := rows.Scan(
+			&i.UserID,
+			&i.Method,
+			&i.Identity,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.VerifiedAt,
+			&i.VerificationCodeHash,
+			&i.VerificationExpiresAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserChatIdentity = `-- name: GetUserChatIdentity :one
+SELECT user_id, method, identity, created_at, updated_at, verified_at, verification_code_hash, verification_expires_at
+FROM user_chat_identities
+WHERE user_id = $1::uuid
+  AND method = $2::notification_method
+`
+
+type GetUserChatIdentityParams struct {
+	UserID uuid.UUID          `db:"user_id" json:"user_id"`
+	Method NotificationMethod `db:"method" json:"method"`
+}
+
+func (q *sqlQuerier) GetUserChatIdentity(ctx context.Context, arg GetUserChatIdentityParams) (UserChatIdentity, error) {
+	row := q.db.QueryRowContext(ctx, getUserChatIdentity, arg.UserID, arg.Method)
+	var i UserChatIdentity
+	err := row.Scan(
+		&i.UserID,
+		&i.Method,
+		&i.Identity,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.VerifiedAt,
+		&i.VerificationCodeHash,
+		&i.VerificationExpiresAt,
+	)
+	return i, err
+}
+
+const getUserNotificationDigestPreferences = `-- name: GetUserNotificationDigestPreferences :many
+SELECT user_id, notification_template_id, digest_interval, created_at, updated_at
+FROM notification_digest_preferences
+WHERE user_id = $1::uuid
+`
+
+func (q *sqlQuerier) GetUserNotificationDigestPreferences(ctx context.Context, userID uuid.UUID) ([]NotificationDigestPreference, error) {
+	rows, err := q.db.QueryContext(ctx, getUserNotificationDigestPreferences, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []NotificationDigestPreference
+	for rows.Next() {
+		var i NotificationDigestPreference
+		if err := rows.Scan(
+			&i.UserID,
+			&i.NotificationTemplateID,
+			&i.DigestInterval,
+			&i.CreatedAt,
+			&i.UpdatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -4362,6 +5777,26 @@ func (q *sqlQuerier) GetUserNotificationPreferences(ctx context.Context, userID
 	return items, nil
 }
 
+const getUserNotificationSchedule = `-- name: GetUserNotificationSchedule :one
+SELECT user_id, timezone, quiet_hours_start, quiet_hours_end, daily_digest_time, updated_at
+FROM user_notification_schedules
+WHERE user_id = $1::uuid
+`
+
+func (q *sqlQuerier) GetUserNotificationSchedule(ctx context.Context, userID uuid.UUID) (UserNotificationSchedule, error) {
+	row := q.db.QueryRowContext(ctx, getUserNotificationSchedule, userID)
+	var i UserNotificationSchedule
+	err := row.Scan(
+		&i.UserID,
+		&i.Timezone,
+		&i.QuietHoursStart,
+		&i.QuietHoursEnd,
+		&i.DailyDigestTime,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const getWebpushSubscriptionsByUserID = `-- name: GetWebpushSubscriptionsByUserID :many
 SELECT id, user_id, created_at, endpoint, endpoint_p256dh_key, endpoint_auth_key
 FROM webpush_subscriptions
@@ -4398,6 +5833,87 @@ func (q *sqlQuerier) GetWebpushSubscriptionsByUserID(ctx context.Context, userID
 	return items, nil
 }
 
+const insertNotificationRule = `-- name: InsertNotificationRule :one
+INSERT INTO notification_rules (
+	id,
+	organization_id,
+	user_id,
+	name,
+	source,
+	query,
+	method,
+	threshold,
+	window_seconds,
+	enabled,
+	created_at,
+	updated_at,
+	last_evaluated_at
+) VALUES (
+	$1,
+	$2,
+	$3,
+	$4,
+	$5,
+	$6,
+	$7,
+	$8,
+	$9,
+	$10,
+	$11,
+	$11,
+	$11
+)
+RETURNING id, organization_id, user_id, name, source, query, method, threshold, window_seconds, enabled, created_at, updated_at, last_evaluated_at, last_triggered_at
+`
+
+type InsertNotificationRuleParams struct {
+	ID             uuid.UUID              `db:"id" json:"id"`
+	OrganizationID uuid.UUID              `db:"organization_id" json:"organization_id"`
+	UserID         uuid.UUID              `db:"user_id" json:"user_id"`
+	Name           string                 `db:"name" json:"name"`
+	Source         NotificationRuleSource `db:"source" json:"source"`
+	Query          string                 `db:"query" json:"query"`
+	Method         NullNotificationMethod `db:"method" json:"method"`
+	Threshold      int32                  `db:"threshold" json:"threshold"`
+	WindowSeconds  int32                  `db:"window_seconds" json:"window_seconds"`
+	Enabled        bool                   `db:"enabled" json:"enabled"`
+	CreatedAt      time.Time              `db:"created_at" json:"created_at"`
+}
+
+func (q *sqlQuerier) InsertNotificationRule(ctx context.Context, arg InsertNotificationRuleParams) (NotificationRule, error) {
+	row := q.db.QueryRowContext(ctx, insertNotificationRule,
+		arg.ID,
+		arg.OrganizationID,
+		arg.UserID,
+		arg.Name,
+		arg.Source,
+		arg.Query,
+		arg.Method,
+		arg.Threshold,
+		arg.WindowSeconds,
+		arg.Enabled,
+		arg.CreatedAt,
+	)
+	var i NotificationRule
+	err := row.Scan(
+		&i.ID,
+		&i.OrganizationID,
+		&i.UserID,
+		&i.Name,
+		&i.Source,
+		&i.Query,
+		&i.Method,
+		&i.Threshold,
+		&i.WindowSeconds,
+		&i.Enabled,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.LastEvaluatedAt,
+		&i.LastTriggeredAt,
+	)
+	return i, err
+}
+
 const insertWebpushSubscription = `-- name: InsertWebpushSubscription :one
 INSERT INTO webpush_subscriptions (user_id, created_at, endpoint, endpoint_p256dh_key, endpoint_auth_key)
 VALUES ($1, $2, $3, $4, $5)
@@ -4432,11 +5948,95 @@ func (q *sqlQuerier) InsertWebpushSubscription(ctx context.Context, arg InsertWe
 	return i, err
 }
 
+const updateNotificationRule = `-- name: UpdateNotificationRule :one
+UPDATE notification_rules
+SET
+	name = $1,
+	query = $2,
+	method = $3,
+	threshold = $4,
+	window_seconds = $5,
+	enabled = $6,
+	updated_at = $7,
+	-- Events which happened while a rule was disabled are not evaluated once it is enabled again.
+	last_evaluated_at = CASE WHEN NOT enabled AND $6::boolean THEN $7 ELSE last_evaluated_at END
+WHERE id = $8
+RETURNING id, organization_id, user_id, name, source, query, method, threshold, window_seconds, enabled, created_at, updated_at, last_evaluated_at, last_triggered_at
+`
+
+type UpdateNotificationRuleParams struct {
+	Name          string                 `db:"name" json:"name"`
+	Query         string                 `db:"query" json:"query"`
+	Method        NullNotificationMethod `db:"method" json:"method"`
+	Threshold     int32                  `db:"threshold" json:"threshold"`
+	WindowSeconds int32                  `db:"window_seconds" json:"window_seconds"`
+	Enabled       bool                   `db:"enabled" json:"enabled"`
+	UpdatedAt     time.Time              `db:"updated_at" json:"updated_at"`
+	ID            uuid.UUID              `db:"id" json:"id"`
+}
+
+func (q *sqlQuerier) UpdateNotificationRule(ctx context.Context, arg UpdateNotificationRuleParams) (NotificationRule, error) {
+	row := q.db.QueryRowContext(ctx, updateNotificationRule,
+		arg.Name,
+		arg.Query,
+		arg.Method,
+		arg.Threshold,
+		arg.WindowSeconds,
+		arg.Enabled,
+		arg.UpdatedAt,
+		arg.ID,
+	)
+	var i NotificationRule
+	err := row.Scan(
+		&i.ID,
+		&i.OrganizationID,
+		&i.UserID,
+		&i.Name,
+		&i.Source,
+		&i.Query,
+		&i.Method,
+		&i.Threshold,
+		&i.WindowSeconds,
+		&i.Enabled,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.LastEvaluatedAt,
+		&i.LastTriggeredAt,
+	)
+	return i, err
+}
+
+const updateNotificationRuleEvaluation = `-- name: UpdateNotificationRuleEvaluation :exec
+UPDATE notification_rules
+SET
+	last_evaluated_at = $1,
+	last_triggered_at = COALESCE($2, last_triggered_at),
+	enabled = COALESCE($3, enabled)
+WHERE id = $4
+`
+
+type UpdateNotificationRuleEvaluationParams struct {
+	LastEvaluatedAt time.Time    `db:"last_evaluated_at" json:"last_evaluated_at"`
+	LastTriggeredAt sql.NullTime `db:"last_triggered_at" json:"last_triggered_at"`
+	Enabled         sql.NullBool `db:"enabled" json:"enabled"`
+	ID              uuid.UUID    `db:"id" json:"id"`
+}
+
+func (q *sqlQuerier) UpdateNotificationRuleEvaluation(ctx context.Context, arg UpdateNotificationRuleEvaluationParams) error {
+	_, err := q.db.ExecContext(ctx, updateNotificationRuleEvaluation,
+		arg.LastEvaluatedAt,
+		arg.LastTriggeredAt,
+		arg.Enabled,
+		arg.ID,
+	)
+	return err
+}
+
 const updateNotificationTemplateMethodByID = `-- name: UpdateNotificationTemplateMethodByID :one
 UPDATE notification_templates
 SET method = $1::notification_method
 WHERE id = $2::uuid
-RETURNING id, name, title_template, body_template, actions, "group", method, kind, enabled_by_default
+RETURNING id, name, title_template, body_template, actions, "group", method, kind, enabled_by_default, critical
 `
 
 type UpdateNotificationTemplateMethodByIDParams struct {
@@ -4457,10 +6057,39 @@ func (q *sqlQuerier) UpdateNotificationTemplateMethodByID(ctx context.Context, a
 		&i.Method,
 		&i.Kind,
 		&i.EnabledByDefault,
+		&i.Critical,
 	)
 	return i, err
 }
 
+const updateUserNotificationDigestPreferences = `-- name: UpdateUserNotificationDigestPreferences :exec
+WITH new_values AS (SELECT UNNEST($2::uuid[])                      AS notification_template_id,
+                           UNNEST($3::notification_digest_interval[]) AS digest_interval),
+     deleted AS (DELETE FROM notification_digest_preferences
+                 WHERE notification_digest_preferences.user_id = $1::uuid
+                   AND notification_digest_preferences.notification_template_id NOT IN (SELECT notification_template_id FROM new_values))
+INSERT
+INTO notification_digest_preferences (user_id, notification_template_id, digest_interval)
+SELECT $1::uuid, new_values.notification_template_id, new_values.digest_interval
+FROM new_values
+ON CONFLICT (user_id, notification_template_id) DO UPDATE
+    SET digest_interval = EXCLUDED.digest_interval,
+        updated_at      = CURRENT_TIMESTAMP
+`
+
+type UpdateUserNotificationDigestPreferencesParams struct {
+	UserID                  uuid.UUID                    `db:"user_id" json:"user_id"`
+	NotificationTemplateIds []uuid.UUID                  `db:"notification_template_ids" json:"notification_template_ids"`
+	DigestIntervals         []NotificationDigestInterval `db:"digest_intervals" json:"digest_intervals"`
+}
+
+// Replaces the digest preferences of the user, templates which are not given
+// are delivered individually again.
+func (q *sqlQuerier) UpdateUserNotificationDigestPreferences(ctx context.Context, arg UpdateUserNotificationDigestPreferencesParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserNotificationDigestPreferences, arg.UserID, pq.Array(arg.NotificationTemplateIds), pq.Array(arg.DigestIntervals))
+	return err
+}
+
 const updateUserNotificationPreferences = `-- name: UpdateUserNotificationPreferences :execrows
 INSERT
 INTO notification_preferences (user_id, notification_template_id, disabled)
@@ -4503,42 +6132,174 @@ func (q *sqlQuerier) UpsertNotificationReportGeneratorLog(ctx context.Context, a
 	return err
 }
 
-const countUnreadInboxNotificationsByUserID = `-- name: CountUnreadInboxNotificationsByUserID :one
-SELECT COUNT(*) FROM inbox_notifications WHERE user_id = $1 AND read_at IS NULL
-`
-
-func (q *sqlQuerier) CountUnreadInboxNotificationsByUserID(ctx context.Context, userID uuid.UUID) (int64, error) {
-	row := q.db.QueryRowContext(ctx, countUnreadInboxNotificationsByUserID, userID)
-	var count int64
-	err := row.Scan(&count)
-	return count, err
+const upsertUserChatIdentity = `-- name: UpsertUserChatIdentity :one
+INSERT INTO user_chat_identities (user_id, method, identity, created_at, updated_at, verified_at, verification_code_hash, verification_expires_at)
+VALUES ($1::uuid, $2::notification_method, $3::text, $4::timestamptz, $4::timestamptz,
+	$5::timestamptz, $6::bytea, $7::timestamptz)
+ON CONFLICT (user_id, method) DO UPDATE
+SET identity = EXCLUDED.identity,
+	updated_at = EXCLUDED.updated_at,
+	verified_at = EXCLUDED.verified_at,
+	verification_code_hash = EXCLUDED.verification_code_hash,
+	verification_expires_at = EXCLUDED.verification_expires_at
+RETURNING user_id, method, identity, created_at, updated_at, verified_at, verification_code_hash, verification_expires_at
+`
+
+type UpsertUserChatIdentityParams struct {
+	UserID                uuid.UUID          `db:"user_id" json:"user_id"`
+	Method                NotificationMethod `db:"method" json:"method"`
+	Identity              string             `db:"identity" json:"identity"`
+	UpdatedAt             time.Time          `db:"updated_at" json:"updated_at"`
+	VerifiedAt            sql.NullTime       `db:"verified_at" json:"verified_at"`
+	VerificationCodeHash  []byte             `db:"verification_code_hash" json:"verification_code_hash"`
+	VerificationExpiresAt sql.NullTime       `db:"verification_expires_at" json:"verification_expires_at"`
+}
+
+// Links the identity, replacing the verification of the previously linked
+// identity.
+func (q *sqlQuerier) UpsertUserChatIdentity(ctx context.Context, arg UpsertUserChatIdentityParams) (UserChatIdentity, error) {
+	row := q.db.QueryRowContext(ctx, upsertUserChatIdentity,
+		arg.UserID,
+		arg.Method,
+		arg.Identity,
+		arg.UpdatedAt,
+		arg.VerifiedAt,
+		arg.VerificationCodeHash,
+		arg.VerificationExpiresAt,
+	)
+	var i UserChatIdentity
+	err := row.Scan(
+		&i.UserID,
+		&i.Method,
+		&i.Identity,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.VerifiedAt,
+		&i.VerificationCodeHash,
+		&i.VerificationExpiresAt,
+	)
+	return i, err
 }
 
-const getFilteredInboxNotificationsByUserID = `-- name: GetFilteredInboxNotificationsByUserID :many
-SELECT id, user_id, template_id, targets, title, content, icon, actions, read_at, created_at FROM inbox_notifications WHERE
-	user_id = $1 AND
-	($2::UUID[] IS NULL OR template_id = ANY($2::UUID[])) AND
-	($3::UUID[] IS NULL OR targets @> $3::UUID[]) AND
-	($4::inbox_notification_read_status = 'all' OR ($4::inbox_notification_read_status = 'unread' AND read_at IS NULL) OR ($4::inbox_notification_read_status = 'read' AND read_at IS NOT NULL)) AND
-	($5::TIMESTAMPTZ = '0001-01-01 00:00:00Z' OR created_at < $5::TIMESTAMPTZ)
-	ORDER BY created_at DESC
-	LIMIT (COALESCE(NULLIF($6 :: INT, 0), 25))
+const upsertUserNotificationSchedule = `-- name: UpsertUserNotificationSchedule :one
+INSERT INTO user_notification_schedules (user_id, timezone, quiet_hours_start, quiet_hours_end, daily_digest_time, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (user_id) DO UPDATE
+    SET timezone          = EXCLUDED.timezone,
+        quiet_hours_start = EXCLUDED.quiet_hours_start,
+        quiet_hours_end   = EXCLUDED.quiet_hours_end,
+        daily_digest_time = EXCLUDED.daily_digest_time,
+        updated_at        = EXCLUDED.updated_at
+RETURNING user_id, timezone, quiet_hours_start, quiet_hours_end, daily_digest_time, updated_at
 `
 
-type GetFilteredInboxNotificationsByUserIDParams struct {
-	UserID       uuid.UUID                   `db:"user_id" json:"user_id"`
-	Templates    []uuid.UUID                 `db:"templates" json:"templates"`
-	Targets      []uuid.UUID                 `db:"targets" json:"targets"`
-	ReadStatus   InboxNotificationReadStatus `db:"read_status" json:"read_status"`
-	CreatedAtOpt time.Time                   `db:"created_at_opt" json:"created_at_opt"`
-	LimitOpt     int32                       `db:"limit_opt" json:"limit_opt"`
+type UpsertUserNotificationScheduleParams struct {
+	UserID          uuid.UUID     `db:"user_id" json:"user_id"`
+	Timezone        string        `db:"timezone" json:"timezone"`
+	QuietHoursStart sql.NullInt16 `db:"quiet_hours_start" json:"quiet_hours_start"`
+	QuietHoursEnd   sql.NullInt16 `db:"quiet_hours_end" json:"quiet_hours_end"`
+	DailyDigestTime int16         `db:"daily_digest_time" json:"daily_digest_time"`
+	UpdatedAt       time.Time     `db:"updated_at" json:"updated_at"`
 }
 
-// Fetches inbox notifications for a user filtered by templates and targets
-// param user_id: The user ID
-// param templates: The template IDs to filter by - the template_id = ANY(@templates::UUID[]) condition checks if the template_id is in the @templates array
-// param targets: The target IDs to filter by - the targets @> COALESCE(@targets, ARRAY[]::UUID[]) condition checks if the targets array (from the DB) contains all the elements in the @targets array
-// param read_status: The read status to filter by - can be any of 'ALL', 'UNREAD', 'READ'
+func (q *sqlQuerier) UpsertUserNotificationSchedule(ctx context.Context, arg UpsertUserNotificationScheduleParams) (UserNotificationSchedule, error) {
+	row := q.db.QueryRowContext(ctx, upsertUserNotificationSchedule,
+		arg.UserID,
+		arg.Timezone,
+		arg.QuietHoursStart,
+		arg.QuietHoursEnd,
+		arg.DailyDigestTime,
+		arg.UpdatedAt,
+	)
+	var i UserNotificationSchedule
+	err := row.Scan(
+		&i.UserID,
+		&i.Timezone,
+		&i.QuietHoursStart,
+		&i.QuietHoursEnd,
+		&i.DailyDigestTime,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const verifyUserChatIdentity = `-- name: VerifyUserChatIdentity :one
+UPDATE user_chat_identities
+SET verified_at = $1::timestamptz,
+	verification_code_hash = NULL,
+	verification_expires_at = NULL
+WHERE user_id = $2::uuid
+  AND method = $3::notification_method
+  AND verification_code_hash = $4::bytea
+  AND verification_expires_at > $1::timestamptz
+RETURNING user_id, method, identity, created_at, updated_at, verified_at, verification_code_hash, verification_expires_at
+`
+
+type VerifyUserChatIdentityParams struct {
+	VerifiedAt           time.Time          `db:"verified_at" json:"verified_at"`
+	UserID               uuid.UUID          `db:"user_id" json:"user_id"`
+	Method               NotificationMethod `db:"method" json:"method"`
+	VerificationCodeHash []byte             `db:"verification_code_hash" json:"verification_code_hash"`
+}
+
+// Marks the identity as verified if the code matches and has not expired.
+func (q *sqlQuerier) VerifyUserChatIdentity(ctx context.Context, arg VerifyUserChatIdentityParams) (UserChatIdentity, error) {
+	row := q.db.QueryRowContext(ctx, verifyUserChatIdentity,
+		arg.VerifiedAt,
+		arg.UserID,
+		arg.Method,
+		arg.VerificationCodeHash,
+	)
+	var i UserChatIdentity
+	err := row.Scan(
+		&i.UserID,
+		&i.Method,
+		&i.Identity,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.VerifiedAt,
+		&i.VerificationCodeHash,
+		&i.VerificationExpiresAt,
+	)
+	return i, err
+}
+
+const countUnreadInboxNotificationsByUserID = `-- name: CountUnreadInboxNotificationsByUserID :one
+SELECT COUNT(*) FROM inbox_notifications WHERE user_id = $1 AND read_at IS NULL
+`
+
+func (q *sqlQuerier) CountUnreadInboxNotificationsByUserID(ctx context.Context, userID uuid.UUID) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countUnreadInboxNotificationsByUserID, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const getFilteredInboxNotificationsByUserID = `-- name: GetFilteredInboxNotificationsByUserID :many
+SELECT id, user_id, template_id, targets, title, content, icon, actions, read_at, created_at FROM inbox_notifications WHERE
+	user_id = $1 AND
+	($2::UUID[] IS NULL OR template_id = ANY($2::UUID[])) AND
+	($3::UUID[] IS NULL OR targets @> $3::UUID[]) AND
+	($4::inbox_notification_read_status = 'all' OR ($4::inbox_notification_read_status = 'unread' AND read_at IS NULL) OR ($4::inbox_notification_read_status = 'read' AND read_at IS NOT NULL)) AND
+	($5::TIMESTAMPTZ = '0001-01-01 00:00:00Z' OR created_at < $5::TIMESTAMPTZ)
+	ORDER BY created_at DESC
+	LIMIT (COALESCE(NULLIF($6 :: INT, 0), 25))
+`
+
+type GetFilteredInboxNotificationsByUserIDParams struct {
+	UserID       uuid.UUID                   `db:"user_id" json:"user_id"`
+	Templates    []uuid.UUID                 `db:"templates" json:"templates"`
+	Targets      []uuid.UUID                 `db:"targets" json:"targets"`
+	ReadStatus   InboxNotificationReadStatus `db:"read_status" json:"read_status"`
+	CreatedAtOpt time.Time                   `db:"created_at_opt" json:"created_at_opt"`
+	LimitOpt     int32                       `db:"limit_opt" json:"limit_opt"`
+}
+
+// Fetches inbox notifications for a user filtered by templates and targets
+// param user_id: The user ID
+// param templates: The template IDs to filter by - the template_id = ANY(@templates::UUID[]) condition checks if the template_id is in the @templates array
+// param targets: The target IDs to filter by - the targets @> COALESCE(@targets, ARRAY[]::UUID[]) condition checks if the targets array (from the DB) contains all the elements in the @targets array
+// param read_status: The read status to filter by - can be any of 'ALL', 'UNREAD', 'READ'
 // param created_at_opt: The created_at timestamp to filter by. This parameter is usd for pagination - it fetches notifications created before the specified timestamp if it is not the zero value
 // param limit_opt: The limit of notifications to fetch. If the limit is not specified, it defaults to 25
 func (q *sqlQuerier) GetFilteredInboxNotificationsByUserID(ctx context.Context, arg GetFilteredInboxNotificationsByUserIDParams) ([]InboxNotification, error) {
@@ -5410,6 +7171,97 @@ func (q *sqlQuerier) UpdateOAuth2ProviderAppSecretByID(ctx context.Context, arg
 	return i, err
 }
 
+const getOrganizationHolidays = `-- name: GetOrganizationHolidays :many
+SELECT
+	id, organization_id, calendar, date, name, created_at
+FROM
+	organization_holidays
+WHERE
+	organization_id = $1
+	AND date >= $2 :: date
+ORDER BY
+	date ASC,
+	calendar ASC
+`
+
+type GetOrganizationHolidaysParams struct {
+	OrganizationID uuid.UUID `db:"organization_id" json:"organization_id"`
+	After          time.Time `db:"after" json:"after"`
+}
+
+// Returns the holidays of an organization on or after a date.
+func (q *sqlQuerier) GetOrganizationHolidays(ctx context.Context, arg GetOrganizationHolidaysParams) ([]OrganizationHoliday, error) {
+	rows, err := q.db.QueryContext(ctx, getOrganizationHolidays, arg.OrganizationID, arg.After)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []OrganizationHoliday
+	for rows.Next() {
+		var i OrganizationHoliday
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrganizationID,
+			&i.Calendar,
+			&i.Date,
+			&i.Name,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateOrganizationHolidayCalendar = `-- name: UpdateOrganizationHolidayCalendar :exec
+WITH deleted AS (
+	DELETE FROM
+		organization_holidays
+	WHERE
+		organization_id = $1
+		AND calendar = $2
+)
+INSERT INTO
+	organization_holidays (id, organization_id, calendar, date, name, created_at)
+SELECT
+	unnest($3 :: uuid[]),
+	$1,
+	$2,
+	unnest($4 :: date[]),
+	unnest($5 :: text[]),
+	$6 :: timestamptz
+`
+
+type UpdateOrganizationHolidayCalendarParams struct {
+	OrganizationID uuid.UUID   `db:"organization_id" json:"organization_id"`
+	Calendar       string      `db:"calendar" json:"calendar"`
+	IDs            []uuid.UUID `db:"ids" json:"ids"`
+	Dates          []time.Time `db:"dates" json:"dates"`
+	Names          []string    `db:"names" json:"names"`
+	CreatedAt      time.Time   `db:"created_at" json:"created_at"`
+}
+
+// Replaces the holidays of a calendar of an organization. Passing no holidays
+// removes the calendar.
+func (q *sqlQuerier) UpdateOrganizationHolidayCalendar(ctx context.Context, arg UpdateOrganizationHolidayCalendarParams) error {
+	_, err := q.db.ExecContext(ctx, updateOrganizationHolidayCalendar,
+		arg.OrganizationID,
+		arg.Calendar,
+		pq.Array(arg.IDs),
+		pq.Array(arg.Dates),
+		pq.Array(arg.Names),
+		arg.CreatedAt,
+	)
+	return err
+}
+
 const deleteOrganizationMember = `-- name: DeleteOrganizationMember :exec
 DELETE
 	FROM
@@ -6132,6 +7984,194 @@ func (q *sqlQuerier) GetParameterSchemasByJobID(ctx context.Context, jobID uuid.
 	return items, nil
 }
 
+const deletePlanPolicyByID = `-- name: DeletePlanPolicyByID :exec
+DELETE FROM
+	plan_policies
+WHERE
+	id = $1
+`
+
+func (q *sqlQuerier) DeletePlanPolicyByID(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deletePlanPolicyByID, id)
+	return err
+}
+
+const getPlanPoliciesByOrganizationID = `-- name: GetPlanPoliciesByOrganizationID :many
+SELECT
+	id, organization_id, name, description, module, created_at, updated_at
+FROM
+	plan_policies
+WHERE
+	organization_id = $1
+ORDER BY
+	name ASC
+`
+
+func (q *sqlQuerier) GetPlanPoliciesByOrganizationID(ctx context.Context, organizationID uuid.UUID) ([]PlanPolicy, error) {
+	rows, err := q.db.QueryContext(ctx, getPlanPoliciesByOrganizationID, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PlanPolicy
+	for rows.Next() {
+		var i PlanPolicy
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrganizationID,
+			&i.Name,
+			&i.Description,
+			&i.Module,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPlanPolicyByID = `-- name: GetPlanPolicyByID :one
+SELECT
+	id, organization_id, name, description, module, created_at, updated_at
+FROM
+	plan_policies
+WHERE
+	id = $1
+`
+
+func (q *sqlQuerier) GetPlanPolicyByID(ctx context.Context, id uuid.UUID) (PlanPolicy, error) {
+	row := q.db.QueryRowContext(ctx, getPlanPolicyByID, id)
+	var i PlanPolicy
+	err := row.Scan(
+		&i.ID,
+		&i.OrganizationID,
+		&i.Name,
+		&i.Description,
+		&i.Module,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getPlanPolicyByOrganizationAndName = `-- name: GetPlanPolicyByOrganizationAndName :one
+SELECT
+	id, organization_id, name, description, module, created_at, updated_at
+FROM
+	plan_policies
+WHERE
+	organization_id = $1
+	AND name = $2
+`
+
+type GetPlanPolicyByOrganizationAndNameParams struct {
+	OrganizationID uuid.UUID `db:"organization_id" json:"organization_id"`
+	Name           string    `db:"name" json:"name"`
+}
+
+func (q *sqlQuerier) GetPlanPolicyByOrganizationAndName(ctx context.Context, arg GetPlanPolicyByOrganizationAndNameParams) (PlanPolicy, error) {
+	row := q.db.QueryRowContext(ctx, getPlanPolicyByOrganizationAndName, arg.OrganizationID, arg.Name)
+	var i PlanPolicy
+	err := row.Scan(
+		&i.ID,
+		&i.OrganizationID,
+		&i.Name,
+		&i.Description,
+		&i.Module,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const insertPlanPolicy = `-- name: InsertPlanPolicy :one
+INSERT INTO
+	plan_policies (id, organization_id, name, description, module, created_at, updated_at)
+VALUES
+	($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, organization_id, name, description, module, created_at, updated_at
+`
+
+type InsertPlanPolicyParams struct {
+	ID             uuid.UUID `db:"id" json:"id"`
+	OrganizationID uuid.UUID `db:"organization_id" json:"organization_id"`
+	Name           string    `db:"name" json:"name"`
+	Description    string    `db:"description" json:"description"`
+	Module         string    `db:"module" json:"module"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time `db:"updated_at" json:"updated_at"`
+}
+
+func (q *sqlQuerier) InsertPlanPolicy(ctx context.Context, arg InsertPlanPolicyParams) (PlanPolicy, error) {
+	row := q.db.QueryRowContext(ctx, insertPlanPolicy,
+		arg.ID,
+		arg.OrganizationID,
+		arg.Name,
+		arg.Description,
+		arg.Module,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+	)
+	var i PlanPolicy
+	err := row.Scan(
+		&i.ID,
+		&i.OrganizationID,
+		&i.Name,
+		&i.Description,
+		&i.Module,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const updatePlanPolicyByID = `-- name: UpdatePlanPolicyByID :one
+UPDATE
+	plan_policies
+SET
+	description = $1,
+	module = $2,
+	updated_at = $3
+WHERE
+	id = $4
+RETURNING id, organization_id, name, description, module, created_at, updated_at
+`
+
+type UpdatePlanPolicyByIDParams struct {
+	Description string    `db:"description" json:"description"`
+	Module      string    `db:"module" json:"module"`
+	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+	ID          uuid.UUID `db:"id" json:"id"`
+}
+
+func (q *sqlQuerier) UpdatePlanPolicyByID(ctx context.Context, arg UpdatePlanPolicyByIDParams) (PlanPolicy, error) {
+	row := q.db.QueryRowContext(ctx, updatePlanPolicyByID,
+		arg.Description,
+		arg.Module,
+		arg.UpdatedAt,
+		arg.ID,
+	)
+	var i PlanPolicy
+	err := row.Scan(
+		&i.ID,
+		&i.OrganizationID,
+		&i.Name,
+		&i.Description,
+		&i.Module,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const claimPrebuiltWorkspace = `-- name: ClaimPrebuiltWorkspace :one
 UPDATE workspaces w
 SET owner_id   = $1::uuid,
@@ -6288,13 +8328,97 @@ func (q *sqlQuerier) GetPrebuildMetrics(ctx context.Context) ([]GetPrebuildMetri
 	return items, nil
 }
 
-const getPresetsAtFailureLimit = `-- name: GetPresetsAtFailureLimit :many
-WITH filtered_builds AS (
-	-- Only select builds which are for prebuild creations
-	SELECT wlb.template_version_id, wlb.created_at, tvp.id AS preset_id, wlb.job_status, tvp.desired_instances
-	FROM template_version_presets tvp
-			INNER JOIN workspace_latest_builds wlb ON wlb.template_version_preset_id = tvp.id
-			INNER JOIN workspaces w ON wlb.workspace_id = w.id
+const getPrebuildPoolHealth = `-- name: GetPrebuildPoolHealth :many
+SELECT
+		t.id                          AS template_id,
+		t.name                        AS template_name,
+		t.display_name                AS template_display_name,
+		t.organization_id             AS template_organization_id,
+		tvp.id                        AS preset_id,
+		tvp.name                      AS preset_name,
+		tvp.desired_instances::int    AS desired_instances,
+		tvp.prebuild_status,
+		COUNT(p.id)::int              AS running,
+		COUNT(p.id) FILTER (WHERE p.ready)::int AS ready,
+		(
+			SELECT COUNT(*)
+			FROM workspace_prebuild_builds wpb
+					INNER JOIN provisioner_jobs pj ON pj.id = wpb.job_id
+			WHERE wpb.template_version_preset_id = tvp.id
+				AND pj.job_status = 'failed'::provisioner_job_status
+				AND pj.created_at >= $1::timestamptz
+		)::int                        AS failed_builds
+FROM templates t
+		INNER JOIN template_version_presets tvp ON tvp.template_version_id = t.active_version_id
+		LEFT JOIN workspace_latest_builds b ON b.template_version_preset_id = tvp.id
+			AND b.transition = 'start'::workspace_transition
+			AND b.job_status = 'succeeded'::provisioner_job_status
+		LEFT JOIN workspace_prebuilds p ON p.id = b.workspace_id AND p.current_preset_id = tvp.id
+WHERE tvp.desired_instances IS NOT NULL -- Consider only presets that have a prebuild configuration.
+	AND NOT t.deleted
+GROUP BY t.id, tvp.id
+ORDER BY t.name, tvp.name
+`
+
+type GetPrebuildPoolHealthRow struct {
+	TemplateID             uuid.UUID      `db:"template_id" json:"template_id"`
+	TemplateName           string         `db:"template_name" json:"template_name"`
+	TemplateDisplayName    string         `db:"template_display_name" json:"template_display_name"`
+	TemplateOrganizationID uuid.UUID      `db:"template_organization_id" json:"template_organization_id"`
+	PresetID               uuid.UUID      `db:"preset_id" json:"preset_id"`
+	PresetName             string         `db:"preset_name" json:"preset_name"`
+	DesiredInstances       int32          `db:"desired_instances" json:"desired_instances"`
+	PrebuildStatus         PrebuildStatus `db:"prebuild_status" json:"prebuild_status"`
+	Running                int32          `db:"running" json:"running"`
+	Ready                  int32          `db:"ready" json:"ready"`
+	FailedBuilds           int32          `db:"failed_builds" json:"failed_builds"`
+}
+
+// GetPrebuildPoolHealth reports, for each preset with a prebuild configuration on an active template version,
+// how many prebuilt workspaces are running and ready compared to the desired instances, along with the number
+// of prebuild builds which failed since the given time.
+func (q *sqlQuerier) GetPrebuildPoolHealth(ctx context.Context, since time.Time) ([]GetPrebuildPoolHealthRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPrebuildPoolHealth, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPrebuildPoolHealthRow
+	for rows.Next() {
+		var i GetPrebuildPoolHealthRow
+		if err := rows.Scan(
+			&i.TemplateID,
+			&i.TemplateName,
+			&i.TemplateDisplayName,
+			&i.TemplateOrganizationID,
+			&i.PresetID,
+			&i.PresetName,
+			&i.DesiredInstances,
+			&i.PrebuildStatus,
+			&i.Running,
+			&i.Ready,
+			&i.FailedBuilds,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPresetsAtFailureLimit = `-- name: GetPresetsAtFailureLimit :many
+WITH filtered_builds AS (
+	-- Only select builds which are for prebuild creations
+	SELECT wlb.template_version_id, wlb.created_at, tvp.id AS preset_id, wlb.job_status, tvp.desired_instances
+	FROM template_version_presets tvp
+			INNER JOIN workspace_latest_builds wlb ON wlb.template_version_preset_id = tvp.id
+			INNER JOIN workspaces w ON wlb.workspace_id = w.id
 			INNER JOIN template_versions tv ON wlb.template_version_id = tv.id
 			INNER JOIN templates t ON tv.template_id = t.id AND t.active_version_id = tv.id
 	WHERE tvp.desired_instances IS NOT NULL -- Consider only presets that have a prebuild configuration.
@@ -6963,6 +9087,203 @@ func (q *sqlQuerier) UpdatePresetPrebuildStatus(ctx context.Context, arg UpdateP
 	return err
 }
 
+const getProviderMirrorArchiveFileByHash = `-- name: GetProviderMirrorArchiveFileByHash :one
+SELECT
+	files.hash, files.created_at, files.created_by, files.mimetype, files.data, files.id
+FROM
+	files
+WHERE
+	files.hash = $1
+	AND EXISTS (
+		SELECT
+			1
+		FROM
+			provider_mirror_archives
+		WHERE
+			provider_mirror_archives.file_id = files.id
+			AND provider_mirror_archives.organization_id = $2
+	)
+LIMIT
+	1
+`
+
+type GetProviderMirrorArchiveFileByHashParams struct {
+	Hash           string    `db:"hash" json:"hash"`
+	OrganizationID uuid.UUID `db:"organization_id" json:"organization_id"`
+}
+
+// GetProviderMirrorArchiveFileByHash only returns files that are archives of
+// the provider mirror of the organization, so that the mirror never serves
+// other files.
+func (q *sqlQuerier) GetProviderMirrorArchiveFileByHash(ctx context.Context, arg GetProviderMirrorArchiveFileByHashParams) (File, error) {
+	row := q.db.QueryRowContext(ctx, getProviderMirrorArchiveFileByHash, arg.Hash, arg.OrganizationID)
+	var i File
+	err := row.Scan(
+		&i.Hash,
+		&i.CreatedAt,
+		&i.CreatedBy,
+		&i.Mimetype,
+		&i.Data,
+		&i.ID,
+	)
+	return i, err
+}
+
+const getProviderMirrorArchives = `-- name: GetProviderMirrorArchives :many
+SELECT
+	provider_mirror_archives.organization_id, provider_mirror_archives.hostname, provider_mirror_archives.namespace, provider_mirror_archives.type, provider_mirror_archives.version, provider_mirror_archives.platform, provider_mirror_archives.hash, provider_mirror_archives.file_id, provider_mirror_archives.created_at,
+	files.hash AS file_hash
+FROM
+	provider_mirror_archives
+JOIN
+	files ON files.id = provider_mirror_archives.file_id
+WHERE
+	provider_mirror_archives.organization_id = $1
+	AND provider_mirror_archives.hostname = $2
+	AND provider_mirror_archives.namespace = $3
+	AND provider_mirror_archives.type = $4
+ORDER BY
+	provider_mirror_archives.version, provider_mirror_archives.platform
+`
+
+type GetProviderMirrorArchivesParams struct {
+	OrganizationID uuid.UUID `db:"organization_id" json:"organization_id"`
+	Hostname       string    `db:"hostname" json:"hostname"`
+	Namespace      string    `db:"namespace" json:"namespace"`
+	Type           string    `db:"type" json:"type"`
+}
+
+type GetProviderMirrorArchivesRow struct {
+	OrganizationID uuid.UUID `db:"organization_id" json:"organization_id"`
+	Hostname       string    `db:"hostname" json:"hostname"`
+	Namespace      string    `db:"namespace" json:"namespace"`
+	Type           string    `db:"type" json:"type"`
+	Version        string    `db:"version" json:"version"`
+	Platform       string    `db:"platform" json:"platform"`
+	Hash           string    `db:"hash" json:"hash"`
+	FileID         uuid.UUID `db:"file_id" json:"file_id"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+	FileHash       string    `db:"file_hash" json:"file_hash"`
+}
+
+// GetProviderMirrorArchives returns the hash of the file of every archive, but
+// not its data, as the archives can be large.
+func (q *sqlQuerier) GetProviderMirrorArchives(ctx context.Context, arg GetProviderMirrorArchivesParams) ([]GetProviderMirrorArchivesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getProviderMirrorArchives,
+		arg.OrganizationID,
+		arg.Hostname,
+		arg.Namespace,
+		arg.Type,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetProviderMirrorArchivesRow
+	for rows.Next() {
+		var i GetProviderMirrorArchivesRow
+		if err := rows.Scan(
+			&i.OrganizationID,
+			&i.Hostname,
+			&i.Namespace,
+			&i.Type,
+			&i.Version,
+			&i.Platform,
+			&i.Hash,
+			&i.FileID,
+			&i.CreatedAt,
+			&i.FileHash,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getProviderMirrorTokenByJobID = `-- name: GetProviderMirrorTokenByJobID :one
+SELECT
+	job_id, hashed_secret, created_at
+FROM
+	provider_mirror_tokens
+WHERE
+	job_id = $1
+`
+
+func (q *sqlQuerier) GetProviderMirrorTokenByJobID(ctx context.Context, jobID uuid.UUID) (ProviderMirrorToken, error) {
+	row := q.db.QueryRowContext(ctx, getProviderMirrorTokenByJobID, jobID)
+	var i ProviderMirrorToken
+	err := row.Scan(&i.JobID, &i.HashedSecret, &i.CreatedAt)
+	return i, err
+}
+
+const insertProviderMirrorArchive = `-- name: InsertProviderMirrorArchive :execrows
+INSERT INTO
+	provider_mirror_archives (organization_id, hostname, namespace, type, version, platform, hash, file_id, created_at)
+VALUES
+	($1, $2, $3, $4, $5, $6, $7, $8, $9)
+ON CONFLICT (organization_id, hostname, namespace, type, version, platform) DO NOTHING
+`
+
+type InsertProviderMirrorArchiveParams struct {
+	OrganizationID uuid.UUID `db:"organization_id" json:"organization_id"`
+	Hostname       string    `db:"hostname" json:"hostname"`
+	Namespace      string    `db:"namespace" json:"namespace"`
+	Type           string    `db:"type" json:"type"`
+	Version        string    `db:"version" json:"version"`
+	Platform       string    `db:"platform" json:"platform"`
+	Hash           string    `db:"hash" json:"hash"`
+	FileID         uuid.UUID `db:"file_id" json:"file_id"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+}
+
+// InsertProviderMirrorArchive never replaces a package that is in the mirror
+// already. It inserts no rows if the package exists.
+func (q *sqlQuerier) InsertProviderMirrorArchive(ctx context.Context, arg InsertProviderMirrorArchiveParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, insertProviderMirrorArchive,
+		arg.OrganizationID,
+		arg.Hostname,
+		arg.Namespace,
+		arg.Type,
+		arg.Version,
+		arg.Platform,
+		arg.Hash,
+		arg.FileID,
+		arg.CreatedAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const upsertProviderMirrorToken = `-- name: UpsertProviderMirrorToken :exec
+INSERT INTO
+	provider_mirror_tokens (job_id, hashed_secret, created_at)
+VALUES
+	($1, $2, $3)
+ON CONFLICT (job_id) DO UPDATE SET
+	hashed_secret = EXCLUDED.hashed_secret,
+	created_at = EXCLUDED.created_at
+`
+
+type UpsertProviderMirrorTokenParams struct {
+	JobID        uuid.UUID `db:"job_id" json:"job_id"`
+	HashedSecret []byte    `db:"hashed_secret" json:"hashed_secret"`
+	CreatedAt    time.Time `db:"created_at" json:"created_at"`
+}
+
+func (q *sqlQuerier) UpsertProviderMirrorToken(ctx context.Context, arg UpsertProviderMirrorTokenParams) error {
+	_, err := q.db.ExecContext(ctx, upsertProviderMirrorToken, arg.JobID, arg.HashedSecret, arg.CreatedAt)
+	return err
+}
+
 const deleteOldProvisionerDaemons = `-- name: DeleteOldProvisionerDaemons :exec
 DELETE FROM provisioner_daemons WHERE (
 	(created_at < (NOW() - INTERVAL '7 days') AND last_seen_at IS NULL) OR
@@ -7519,12 +9840,37 @@ WHERE
 			-- they are aliases and the code that calls this query already relies on a different type
 			AND provisioner_tagset_contains($5 :: jsonb, potential_job.tags :: jsonb)
 		ORDER BY
+			-- Jobs of a higher priority class are acquired first.
+			potential_job.priority DESC,
+			-- Share the provisioner daemons fairly within a priority class, so
+			-- a single user or template can't monopolize them. Jobs whose
+			-- initiator, then template, has the fewest running jobs go first.
+			(
+				SELECT
+					COUNT(*)
+				FROM
+					provisioner_jobs AS running_job
+				WHERE
+					running_job.initiator_id = potential_job.initiator_id
+					AND running_job.started_at IS NOT NULL
+					AND running_job.completed_at IS NULL
+			) ASC,
+			(
+				SELECT
+					COUNT(*)
+				FROM
+					provisioner_jobs AS running_job
+				WHERE
+					running_job.template_id = potential_job.template_id
+					AND running_job.started_at IS NOT NULL
+					AND running_job.completed_at IS NULL
+			) ASC,
 			potential_job.created_at
 		FOR UPDATE
 		SKIP LOCKED
 		LIMIT
 			1
-	) RETURNING id, created_at, updated_at, started_at, canceled_at, completed_at, error, organization_id, initiator_id, provisioner, storage_method, type, input, worker_id, file_id, tags, error_code, trace_metadata, job_status
+	) RETURNING id, created_at, updated_at, started_at, canceled_at, completed_at, error, organization_id, initiator_id, provisioner, storage_method, type, input, worker_id, file_id, tags, error_code, trace_metadata, job_status, priority, template_id
 `
 
 type AcquireProvisionerJobParams struct {
@@ -7570,13 +9916,15 @@ func (q *sqlQuerier) AcquireProvisionerJob(ctx context.Context, arg AcquireProvi
 		&i.ErrorCode,
 		&i.TraceMetadata,
 		&i.JobStatus,
+		&i.Priority,
+		&i.TemplateID,
 	)
 	return i, err
 }
 
 const getProvisionerJobByID = `-- name: GetProvisionerJobByID :one
 SELECT
-	id, created_at, updated_at, started_at, canceled_at, completed_at, error, organization_id, initiator_id, provisioner, storage_method, type, input, worker_id, file_id, tags, error_code, trace_metadata, job_status
+	id, created_at, updated_at, started_at, canceled_at, completed_at, error, organization_id, initiator_id, provisioner, storage_method, type, input, worker_id, file_id, tags, error_code, trace_metadata, job_status, priority, template_id
 FROM
 	provisioner_jobs
 WHERE
@@ -7606,13 +9954,15 @@ func (q *sqlQuerier) GetProvisionerJobByID(ctx context.Context, id uuid.UUID) (P
 		&i.ErrorCode,
 		&i.TraceMetadata,
 		&i.JobStatus,
+		&i.Priority,
+		&i.TemplateID,
 	)
 	return i, err
 }
 
 const getProvisionerJobByIDForUpdate = `-- name: GetProvisionerJobByIDForUpdate :one
 SELECT
-	id, created_at, updated_at, started_at, canceled_at, completed_at, error, organization_id, initiator_id, provisioner, storage_method, type, input, worker_id, file_id, tags, error_code, trace_metadata, job_status
+	id, created_at, updated_at, started_at, canceled_at, completed_at, error, organization_id, initiator_id, provisioner, storage_method, type, input, worker_id, file_id, tags, error_code, trace_metadata, job_status, priority, template_id
 FROM
 	provisioner_jobs
 WHERE
@@ -7646,6 +9996,8 @@ func (q *sqlQuerier) GetProvisionerJobByIDForUpdate(ctx context.Context, id uuid
 		&i.ErrorCode,
 		&i.TraceMetadata,
 		&i.JobStatus,
+		&i.Priority,
+		&i.TemplateID,
 	)
 	return i, err
 }
@@ -7689,7 +10041,7 @@ func (q *sqlQuerier) GetProvisionerJobTimingsByJobID(ctx context.Context, jobID
 
 const getProvisionerJobsByIDs = `-- name: GetProvisionerJobsByIDs :many
 SELECT
-	id, created_at, updated_at, started_at, canceled_at, completed_at, error, organization_id, initiator_id, provisioner, storage_method, type, input, worker_id, file_id, tags, error_code, trace_metadata, job_status
+	id, created_at, updated_at, started_at, canceled_at, completed_at, error, organization_id, initiator_id, provisioner, storage_method, type, input, worker_id, file_id, tags, error_code, trace_metadata, job_status, priority, template_id
 FROM
 	provisioner_jobs
 WHERE
@@ -7725,6 +10077,8 @@ func (q *sqlQuerier) GetProvisionerJobsByIDs(ctx context.Context, ids []uuid.UUI
 			&i.ErrorCode,
 			&i.TraceMetadata,
 			&i.JobStatus,
+			&i.Priority,
+			&i.TemplateID,
 		); err != nil {
 			return nil, err
 		}
@@ -7752,7 +10106,7 @@ WITH filtered_provisioner_jobs AS (
 pending_jobs AS (
 	-- Step 2: Extract only pending jobs
 	SELECT
-		id, created_at, tags
+		id, created_at, priority, tags
 	FROM
 		provisioner_jobs
 	WHERE
@@ -7767,8 +10121,11 @@ ranked_jobs AS (
 	SELECT
 		pj.id,
 		pj.created_at,
-		ROW_NUMBER() OVER (PARTITION BY opd.id ORDER BY pj.created_at ASC) AS queue_position,
-		COUNT(*) OVER (PARTITION BY opd.id) AS queue_size
+		-- See AcquireProvisionerJob, jobs of a higher priority are acquired
+		-- first.
+		ROW_NUMBER() OVER (PARTITION BY opd.id ORDER BY pj.priority DESC, pj.created_at ASC) AS queue_position,
+		COUNT(*) OVER (PARTITION BY opd.id) AS queue_size,
+		opd.id AS provisioner_daemon_id
 	FROM
 		pending_jobs pj
 			INNER JOIN online_provisioner_daemons opd
@@ -7780,21 +10137,37 @@ final_jobs AS (
 		fpj.id,
 		fpj.created_at,
 		COALESCE(MIN(rj.queue_position), 0) :: BIGINT AS queue_position, -- Best queue position across provisioners
-		COALESCE(MAX(rj.queue_size), 0) :: BIGINT AS queue_size -- Max queue size across provisioners
+		COALESCE(MAX(rj.queue_size), 0) :: BIGINT AS queue_size, -- Max queue size across provisioners
+		COUNT(rj.provisioner_daemon_id) :: BIGINT AS eligible_provisioners -- Online provisioners that can acquire the job
 	FROM
 		filtered_provisioner_jobs fpj -- Use the pre-filtered dataset instead of full provisioner_jobs
 			LEFT JOIN ranked_jobs rj
 					ON fpj.id = rj.id -- Join with the ranking jobs CTE to assign a rank to each specified provisioner job.
 	GROUP BY
 		fpj.id, fpj.created_at
+),
+average_duration AS (
+	-- Step 5: Average duration of the jobs completed in the last day, to
+	-- estimate the wait of pending jobs
+	SELECT
+		COALESCE(AVG(EXTRACT(EPOCH FROM (completed_at - started_at)) * 1000), 0) :: BIGINT AS ms
+	FROM
+		provisioner_jobs
+	WHERE
+		completed_at >= NOW() - INTERVAL '1 day'
+		AND started_at IS NOT NULL
 )
 SELECT
-	-- Step 5: Final SELECT with INNER JOIN provisioner_jobs
+	-- Step 6: Final SELECT with INNER JOIN provisioner_jobs
 	fj.id,
 	fj.created_at,
-	pj.id, pj.created_at, pj.updated_at, pj.started_at, pj.canceled_at, pj.completed_at, pj.error, pj.organization_id, pj.initiator_id, pj.provisioner, pj.storage_method, pj.type, pj.input, pj.worker_id, pj.file_id, pj.tags, pj.error_code, pj.trace_metadata, pj.job_status,
+	pj.id, pj.created_at, pj.updated_at, pj.started_at, pj.canceled_at, pj.completed_at, pj.error, pj.organization_id, pj.initiator_id, pj.provisioner, pj.storage_method, pj.type, pj.input, pj.worker_id, pj.file_id, pj.tags, pj.error_code, pj.trace_metadata, pj.job_status, pj.priority, pj.template_id,
 	fj.queue_position,
-	fj.queue_size
+	fj.queue_size,
+	-- Only computed for pending jobs, the average is not needed otherwise. The
+	-- eligible provisioners work through the queue in parallel. Jobs with a
+	-- queue position have at least one.
+	(CASE WHEN fj.queue_position > 0 THEN fj.queue_position * (SELECT ms FROM average_duration) / fj.eligible_provisioners ELSE 0 END) :: BIGINT AS estimated_wait_ms
 FROM
 	final_jobs fj
 		INNER JOIN provisioner_jobs pj
@@ -7810,11 +10183,12 @@ type GetProvisionerJobsByIDsWithQueuePositionParams struct {
 }
 
 type GetProvisionerJobsByIDsWithQueuePositionRow struct {
-	ID             uuid.UUID      `db:"id" json:"id"`
-	CreatedAt      time.Time      `db:"created_at" json:"created_at"`
-	ProvisionerJob ProvisionerJob `db:"provisioner_job" json:"provisioner_job"`
-	QueuePosition  int64          `db:"queue_position" json:"queue_position"`
-	QueueSize      int64          `db:"queue_size" json:"queue_size"`
+	ID              uuid.UUID      `db:"id" json:"id"`
+	CreatedAt       time.Time      `db:"created_at" json:"created_at"`
+	ProvisionerJob  ProvisionerJob `db:"provisioner_job" json:"provisioner_job"`
+	QueuePosition   int64          `db:"queue_position" json:"queue_position"`
+	QueueSize       int64          `db:"queue_size" json:"queue_size"`
+	EstimatedWaitMs int64          `db:"estimated_wait_ms" json:"estimated_wait_ms"`
 }
 
 func (q *sqlQuerier) GetProvisionerJobsByIDsWithQueuePosition(ctx context.Context, arg GetProvisionerJobsByIDsWithQueuePositionParams) ([]GetProvisionerJobsByIDsWithQueuePositionRow, error) {
@@ -7848,8 +10222,11 @@ func (q *sqlQuerier) GetProvisionerJobsByIDsWithQueuePosition(ctx context.Contex
 			&i.ProvisionerJob.ErrorCode,
 			&i.ProvisionerJob.TraceMetadata,
 			&i.ProvisionerJob.JobStatus,
+			&i.ProvisionerJob.Priority,
+			&i.ProvisionerJob.TemplateID,
 			&i.QueuePosition,
 			&i.QueueSize,
+			&i.EstimatedWaitMs,
 		); err != nil {
 			return nil, err
 		}
@@ -7867,7 +10244,7 @@ func (q *sqlQuerier) GetProvisionerJobsByIDsWithQueuePosition(ctx context.Contex
 const getProvisionerJobsByOrganizationAndStatusWithQueuePositionAndProvisioner = `-- name: GetProvisionerJobsByOrganizationAndStatusWithQueuePositionAndProvisioner :many
 WITH pending_jobs AS (
     SELECT
-        id, created_at
+        id, created_at, priority
     FROM
         provisioner_jobs
     WHERE
@@ -7882,17 +10259,44 @@ WITH pending_jobs AS (
 queue_position AS (
     SELECT
         id,
-        ROW_NUMBER() OVER (ORDER BY created_at ASC) AS queue_position
+        ROW_NUMBER() OVER (ORDER BY priority DESC, created_at ASC) AS queue_position
     FROM
         pending_jobs
 ),
 queue_size AS (
 	SELECT COUNT(*) AS count FROM pending_jobs
+),
+average_duration AS (
+	-- See GetProvisionerJobsByIDsWithQueuePosition.
+	SELECT
+		COALESCE(AVG(EXTRACT(EPOCH FROM (completed_at - started_at)) * 1000), 0) :: BIGINT AS ms
+	FROM
+		provisioner_jobs
+	WHERE
+		completed_at >= NOW() - INTERVAL '1 day'
+		AND started_at IS NOT NULL
+),
+online_provisioner_daemons AS (
+	SELECT organization_id, provisioners, tags FROM provisioner_daemons pd
+	WHERE pd.last_seen_at IS NOT NULL AND pd.last_seen_at >= (NOW() - ($1::bigint || ' ms')::interval)
 )
 SELECT
-	pj.id, pj.created_at, pj.updated_at, pj.started_at, pj.canceled_at, pj.completed_at, pj.error, pj.organization_id, pj.initiator_id, pj.provisioner, pj.storage_method, pj.type, pj.input, pj.worker_id, pj.file_id, pj.tags, pj.error_code, pj.trace_metadata, pj.job_status,
+	pj.id, pj.created_at, pj.updated_at, pj.started_at, pj.canceled_at, pj.completed_at, pj.error, pj.organization_id, pj.initiator_id, pj.provisioner, pj.storage_method, pj.type, pj.input, pj.worker_id, pj.file_id, pj.tags, pj.error_code, pj.trace_metadata, pj.job_status, pj.priority, pj.template_id,
     COALESCE(qp.queue_position, 0) AS queue_position,
     COALESCE(qs.count, 0) AS queue_size,
+	-- The online provisioners that can acquire the job work through the queue
+	-- in parallel.
+	(CASE WHEN qp.queue_position > 0 THEN qp.queue_position * (SELECT ms FROM average_duration) / GREATEST((
+		SELECT
+			COUNT(*)
+		FROM
+			online_provisioner_daemons opd
+		WHERE
+			-- See AcquireProvisionerJob.
+			pj.organization_id = opd.organization_id
+			AND pj.provisioner = ANY(opd.provisioners)
+			AND provisioner_tagset_contains(opd.tags, pj.tags)
+	), 1) ELSE 0 END) :: BIGINT AS estimated_wait_ms,
 	-- Use subquery to utilize ORDER BY in array_agg since it cannot be
 	-- combined with FILTER.
 	(
@@ -7946,10 +10350,10 @@ LEFT JOIN
 	-- Join to get the daemon name corresponding to the job's worker_id
 	provisioner_daemons pd ON pd.id = pj.worker_id
 WHERE
-	pj.organization_id = $1::uuid
-	AND (COALESCE(array_length($2::uuid[], 1), 0) = 0 OR pj.id = ANY($2::uuid[]))
-	AND (COALESCE(array_length($3::provisioner_job_status[], 1), 0) = 0 OR pj.job_status = ANY($3::provisioner_job_status[]))
-	AND ($4::tagset = 'null'::tagset OR provisioner_tagset_contains(pj.tags::tagset, $4::tagset))
+	pj.organization_id = $2::uuid
+	AND (COALESCE(array_length($3::uuid[], 1), 0) = 0 OR pj.id = ANY($3::uuid[]))
+	AND (COALESCE(array_length($4::provisioner_job_status[], 1), 0) = 0 OR pj.job_status = ANY($4::provisioner_job_status[]))
+	AND ($5::tagset = 'null'::tagset OR provisioner_tagset_contains(pj.tags::tagset, $5::tagset))
 GROUP BY
 	pj.id,
 	qp.queue_position,
@@ -7965,21 +10369,23 @@ GROUP BY
 ORDER BY
 	pj.created_at DESC
 LIMIT
-	$5::int
+	$6::int
 `
 
 type GetProvisionerJobsByOrganizationAndStatusWithQueuePositionAndProvisionerParams struct {
-	OrganizationID uuid.UUID              `db:"organization_id" json:"organization_id"`
-	IDs            []uuid.UUID            `db:"ids" json:"ids"`
-	Status         []ProvisionerJobStatus `db:"status" json:"status"`
-	Tags           StringMap              `db:"tags" json:"tags"`
-	Limit          sql.NullInt32          `db:"limit" json:"limit"`
+	StaleIntervalMS int64                  `db:"stale_interval_ms" json:"stale_interval_ms"`
+	OrganizationID  uuid.UUID              `db:"organization_id" json:"organization_id"`
+	IDs             []uuid.UUID            `db:"ids" json:"ids"`
+	Status          []ProvisionerJobStatus `db:"status" json:"status"`
+	Tags            StringMap              `db:"tags" json:"tags"`
+	Limit           sql.NullInt32          `db:"limit" json:"limit"`
 }
 
 type GetProvisionerJobsByOrganizationAndStatusWithQueuePositionAndProvisionerRow struct {
 	ProvisionerJob      ProvisionerJob `db:"provisioner_job" json:"provisioner_job"`
 	QueuePosition       int64          `db:"queue_position" json:"queue_position"`
 	QueueSize           int64          `db:"queue_size" json:"queue_size"`
+	EstimatedWaitMs     int64          `db:"estimated_wait_ms" json:"estimated_wait_ms"`
 	AvailableWorkers    []uuid.UUID    `db:"available_workers" json:"available_workers"`
 	TemplateVersionName string         `db:"template_version_name" json:"template_version_name"`
 	TemplateID          uuid.NullUUID  `db:"template_id" json:"template_id"`
@@ -7993,6 +10399,7 @@ type GetProvisionerJobsByOrganizationAndStatusWithQueuePositionAndProvisionerRow
 
 func (q *sqlQuerier) GetProvisionerJobsByOrganizationAndStatusWithQueuePositionAndProvisioner(ctx context.Context, arg GetProvisionerJobsByOrganizationAndStatusWithQueuePositionAndProvisionerParams) ([]GetProvisionerJobsByOrganizationAndStatusWithQueuePositionAndProvisionerRow, error) {
 	rows, err := q.db.QueryContext(ctx, getProvisionerJobsByOrganizationAndStatusWithQueuePositionAndProvisioner,
+		arg.StaleIntervalMS,
 		arg.OrganizationID,
 		pq.Array(arg.IDs),
 		pq.Array(arg.Status),
@@ -8026,8 +10433,11 @@ func (q *sqlQuerier) GetProvisionerJobsByOrganizationAndStatusWithQueuePositionA
 			&i.ProvisionerJob.ErrorCode,
 			&i.ProvisionerJob.TraceMetadata,
 			&i.ProvisionerJob.JobStatus,
+			&i.ProvisionerJob.Priority,
+			&i.ProvisionerJob.TemplateID,
 			&i.QueuePosition,
 			&i.QueueSize,
+			&i.EstimatedWaitMs,
 			pq.Array(&i.AvailableWorkers),
 			&i.TemplateVersionName,
 			&i.TemplateID,
@@ -8052,7 +10462,7 @@ func (q *sqlQuerier) GetProvisionerJobsByOrganizationAndStatusWithQueuePositionA
 }
 
 const getProvisionerJobsCreatedAfter = `-- name: GetProvisionerJobsCreatedAfter :many
-SELECT id, created_at, updated_at, started_at, canceled_at, completed_at, error, organization_id, initiator_id, provisioner, storage_method, type, input, worker_id, file_id, tags, error_code, trace_metadata, job_status FROM provisioner_jobs WHERE created_at > $1
+SELECT id, created_at, updated_at, started_at, canceled_at, completed_at, error, organization_id, initiator_id, provisioner, storage_method, type, input, worker_id, file_id, tags, error_code, trace_metadata, job_status, priority, template_id FROM provisioner_jobs WHERE created_at > $1
 `
 
 func (q *sqlQuerier) GetProvisionerJobsCreatedAfter(ctx context.Context, createdAt time.Time) ([]ProvisionerJob, error) {
@@ -8084,6 +10494,8 @@ func (q *sqlQuerier) GetProvisionerJobsCreatedAfter(ctx context.Context, created
 			&i.ErrorCode,
 			&i.TraceMetadata,
 			&i.JobStatus,
+			&i.Priority,
+			&i.TemplateID,
 		); err != nil {
 			return nil, err
 		}
@@ -8100,7 +10512,7 @@ func (q *sqlQuerier) GetProvisionerJobsCreatedAfter(ctx context.Context, created
 
 const getProvisionerJobsToBeReaped = `-- name: GetProvisionerJobsToBeReaped :many
 SELECT
-	id, created_at, updated_at, started_at, canceled_at, completed_at, error, organization_id, initiator_id, provisioner, storage_method, type, input, worker_id, file_id, tags, error_code, trace_metadata, job_status
+	id, created_at, updated_at, started_at, canceled_at, completed_at, error, organization_id, initiator_id, provisioner, storage_method, type, input, worker_id, file_id, tags, error_code, trace_metadata, job_status, priority, template_id
 FROM
 	provisioner_jobs
 WHERE
@@ -8157,6 +10569,8 @@ func (q *sqlQuerier) GetProvisionerJobsToBeReaped(ctx context.Context, arg GetPr
 			&i.ErrorCode,
 			&i.TraceMetadata,
 			&i.JobStatus,
+			&i.Priority,
+			&i.TemplateID,
 		); err != nil {
 			return nil, err
 		}
@@ -8171,67 +10585,254 @@ func (q *sqlQuerier) GetProvisionerJobsToBeReaped(ctx context.Context, arg GetPr
 	return items, nil
 }
 
-const insertProvisionerJob = `-- name: InsertProvisionerJob :one
-INSERT INTO
-	provisioner_jobs (
-		id,
-		created_at,
-		updated_at,
-		organization_id,
-		initiator_id,
-		provisioner,
-		storage_method,
-		file_id,
-		"type",
-		"input",
-		tags,
-		trace_metadata
-	)
-VALUES
-	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING id, created_at, updated_at, started_at, canceled_at, completed_at, error, organization_id, initiator_id, provisioner, storage_method, type, input, worker_id, file_id, tags, error_code, trace_metadata, job_status
-`
-
-type InsertProvisionerJobParams struct {
-	ID             uuid.UUID                `db:"id" json:"id"`
-	CreatedAt      time.Time                `db:"created_at" json:"created_at"`
-	UpdatedAt      time.Time                `db:"updated_at" json:"updated_at"`
-	OrganizationID uuid.UUID                `db:"organization_id" json:"organization_id"`
-	InitiatorID    uuid.UUID                `db:"initiator_id" json:"initiator_id"`
-	Provisioner    ProvisionerType          `db:"provisioner" json:"provisioner"`
-	StorageMethod  ProvisionerStorageMethod `db:"storage_method" json:"storage_method"`
-	FileID         uuid.UUID                `db:"file_id" json:"file_id"`
-	Type           ProvisionerJobType       `db:"type" json:"type"`
-	Input          json.RawMessage          `db:"input" json:"input"`
-	Tags           StringMap                `db:"tags" json:"tags"`
-	TraceMetadata  pqtype.NullRawMessage    `db:"trace_metadata" json:"trace_metadata"`
-}
-
-func (q *sqlQuerier) InsertProvisionerJob(ctx context.Context, arg InsertProvisionerJobParams) (ProvisionerJob, error) {
-	row := q.db.QueryRowContext(ctx, insertProvisionerJob,
-		arg.ID,
-		arg.CreatedAt,
-		arg.UpdatedAt,
-		arg.OrganizationID,
-		arg.InitiatorID,
-		arg.Provisioner,
-		arg.StorageMethod,
-		arg.FileID,
-		arg.Type,
-		arg.Input,
-		arg.Tags,
-		arg.TraceMetadata,
-	)
-	var i ProvisionerJob
-	err := row.Scan(
-		&i.ID,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-		&i.StartedAt,
-		&i.CanceledAt,
-		&i.CompletedAt,
-		&i.Error,
-		&i.OrganizationID,
-		&i.InitiatorID,
+const getProvisionerQueueStats = `-- name: GetProvisionerQueueStats :many
+WITH online_daemons AS (
+	SELECT
+		id, organization_id, tags
+	FROM
+		provisioner_daemons
+	WHERE
+		last_seen_at IS NOT NULL
+		AND last_seen_at >= (NOW() - ($1::bigint || ' ms')::interval)
+		AND CASE
+			WHEN $2 :: uuid != '00000000-0000-0000-0000-000000000000'::uuid THEN organization_id = $2
+			ELSE true
+		END
+),
+pending_jobs AS (
+	SELECT
+		organization_id, tags, created_at
+	FROM
+		provisioner_jobs
+	WHERE
+		job_status = 'pending'
+		AND CASE
+			WHEN $2 :: uuid != '00000000-0000-0000-0000-000000000000'::uuid THEN organization_id = $2
+			ELSE true
+		END
+),
+started_jobs AS (
+	SELECT
+		organization_id, tags, EXTRACT(EPOCH FROM (started_at - created_at)) * 1000 AS wait_ms
+	FROM
+		provisioner_jobs
+	WHERE
+		started_at >= $3
+		AND CASE
+			WHEN $2 :: uuid != '00000000-0000-0000-0000-000000000000'::uuid THEN organization_id = $2
+			ELSE true
+		END
+),
+tag_sets AS (
+	SELECT organization_id, tags FROM online_daemons
+	UNION
+	SELECT organization_id, tags FROM pending_jobs
+	UNION
+	-- The requested tags, e.g. of daemons that were scaled to zero.
+	SELECT $2 :: uuid, $4 :: tagset
+	WHERE
+		$2 :: uuid != '00000000-0000-0000-0000-000000000000'::uuid
+		AND $4 :: tagset != '{}' :: tagset
+)
+SELECT
+	ts.organization_id,
+	ts.tags :: tagset AS tags,
+	(
+		SELECT
+			COUNT(*)
+		FROM
+			pending_jobs pj
+		WHERE
+			pj.organization_id = ts.organization_id
+			AND provisioner_tagset_contains(ts.tags :: tagset, pj.tags :: tagset)
+	) :: BIGINT AS pending_jobs,
+	(
+		SELECT
+			COALESCE(EXTRACT(EPOCH FROM (NOW() - MIN(pj.created_at))) * 1000, 0)
+		FROM
+			pending_jobs pj
+		WHERE
+			pj.organization_id = ts.organization_id
+			AND provisioner_tagset_contains(ts.tags :: tagset, pj.tags :: tagset)
+	) :: BIGINT AS oldest_pending_job_ms,
+	-- Jobs run by any daemon with the tags, even one that went stale.
+	(
+		SELECT
+			COUNT(*)
+		FROM
+			provisioner_jobs rj
+			INNER JOIN provisioner_daemons pd ON pd.id = rj.worker_id
+		WHERE
+			rj.job_status = 'running'
+			AND pd.organization_id = ts.organization_id
+			AND pd.tags = ts.tags
+	) :: BIGINT AS running_jobs,
+	(
+		SELECT
+			COUNT(*)
+		FROM
+			online_daemons od
+		WHERE
+			od.organization_id = ts.organization_id
+			AND od.tags = ts.tags
+	) :: BIGINT AS daemons,
+	(
+		SELECT
+			COUNT(*)
+		FROM
+			online_daemons od
+		WHERE
+			od.organization_id = ts.organization_id
+			AND od.tags = ts.tags
+			AND EXISTS (
+				SELECT 1 FROM provisioner_jobs rj WHERE rj.worker_id = od.id AND rj.job_status = 'running'
+			)
+	) :: BIGINT AS busy_daemons,
+	COALESCE(w.p50, 0) :: BIGINT AS wait_p50_ms,
+	COALESCE(w.p95, 0) :: BIGINT AS wait_p95_ms,
+	COALESCE(w.p99, 0) :: BIGINT AS wait_p99_ms
+FROM
+	tag_sets ts
+LEFT JOIN LATERAL (
+	SELECT
+		percentile_cont(0.5) WITHIN GROUP (ORDER BY sj.wait_ms) AS p50,
+		percentile_cont(0.95) WITHIN GROUP (ORDER BY sj.wait_ms) AS p95,
+		percentile_cont(0.99) WITHIN GROUP (ORDER BY sj.wait_ms) AS p99
+	FROM
+		started_jobs sj
+	WHERE
+		sj.organization_id = ts.organization_id
+		AND provisioner_tagset_contains(ts.tags :: tagset, sj.tags :: tagset)
+) w ON TRUE
+ORDER BY
+	ts.organization_id, ts.tags :: text
+`
+
+type GetProvisionerQueueStatsParams struct {
+	StaleIntervalMS int64     `db:"stale_interval_ms" json:"stale_interval_ms"`
+	OrganizationID  uuid.UUID `db:"organization_id" json:"organization_id"`
+	StartedAfter    time.Time `db:"started_after" json:"started_after"`
+	Tags            StringMap `db:"tags" json:"tags"`
+}
+
+type GetProvisionerQueueStatsRow struct {
+	OrganizationID     uuid.UUID `db:"organization_id" json:"organization_id"`
+	Tags               StringMap `db:"tags" json:"tags"`
+	PendingJobs        int64     `db:"pending_jobs" json:"pending_jobs"`
+	OldestPendingJobMs int64     `db:"oldest_pending_job_ms" json:"oldest_pending_job_ms"`
+	RunningJobs        int64     `db:"running_jobs" json:"running_jobs"`
+	Daemons            int64     `db:"daemons" json:"daemons"`
+	BusyDaemons        int64     `db:"busy_daemons" json:"busy_daemons"`
+	WaitP50Ms          int64     `db:"wait_p50_ms" json:"wait_p50_ms"`
+	WaitP95Ms          int64     `db:"wait_p95_ms" json:"wait_p95_ms"`
+	WaitP99Ms          int64     `db:"wait_p99_ms" json:"wait_p99_ms"`
+}
+
+// Gets the autoscaling signals of each set of tags of the online provisioner
+// daemons and pending jobs, and of the requested tags. Like
+// AcquireProvisionerJob, pending jobs count towards every set of tags that
+// contains their tags.
+func (q *sqlQuerier) GetProvisionerQueueStats(ctx context.Context, arg GetProvisionerQueueStatsParams) ([]GetProvisionerQueueStatsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getProvisionerQueueStats, arg.StaleIntervalMS, arg.OrganizationID, arg.StartedAfter, arg.Tags)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetProvisionerQueueStatsRow
+	for rows.Next() {
+		var i GetProvisionerQueueStatsRow
+		if err := rows.Scan(
+			&i.OrganizationID,
+			&i.Tags,
+			&i.PendingJobs,
+			&i.OldestPendingJobMs,
+			&i.RunningJobs,
+			&i.Daemons,
+			&i.BusyDaemons,
+			&i.WaitP50Ms,
+			&i.WaitP95Ms,
+			&i.WaitP99Ms,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertProvisionerJob = `-- name: InsertProvisionerJob :one
+INSERT INTO
+	provisioner_jobs (
+		id,
+		created_at,
+		updated_at,
+		organization_id,
+		initiator_id,
+		provisioner,
+		storage_method,
+		file_id,
+		"type",
+		"input",
+		tags,
+		trace_metadata,
+		priority,
+		template_id
+	)
+VALUES
+	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) RETURNING id, created_at, updated_at, started_at, canceled_at, completed_at, error, organization_id, initiator_id, provisioner, storage_method, type, input, worker_id, file_id, tags, error_code, trace_metadata, job_status, priority, template_id
+`
+
+type InsertProvisionerJobParams struct {
+	ID             uuid.UUID                `db:"id" json:"id"`
+	CreatedAt      time.Time                `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time                `db:"updated_at" json:"updated_at"`
+	OrganizationID uuid.UUID                `db:"organization_id" json:"organization_id"`
+	InitiatorID    uuid.UUID                `db:"initiator_id" json:"initiator_id"`
+	Provisioner    ProvisionerType          `db:"provisioner" json:"provisioner"`
+	StorageMethod  ProvisionerStorageMethod `db:"storage_method" json:"storage_method"`
+	FileID         uuid.UUID                `db:"file_id" json:"file_id"`
+	Type           ProvisionerJobType       `db:"type" json:"type"`
+	Input          json.RawMessage          `db:"input" json:"input"`
+	Tags           StringMap                `db:"tags" json:"tags"`
+	TraceMetadata  pqtype.NullRawMessage    `db:"trace_metadata" json:"trace_metadata"`
+	Priority       ProvisionerJobPriority   `db:"priority" json:"priority"`
+	TemplateID     uuid.NullUUID            `db:"template_id" json:"template_id"`
+}
+
+func (q *sqlQuerier) InsertProvisionerJob(ctx context.Context, arg InsertProvisionerJobParams) (ProvisionerJob, error) {
+	row := q.db.QueryRowContext(ctx, insertProvisionerJob,
+		arg.ID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.OrganizationID,
+		arg.InitiatorID,
+		arg.Provisioner,
+		arg.StorageMethod,
+		arg.FileID,
+		arg.Type,
+		arg.Input,
+		arg.Tags,
+		arg.TraceMetadata,
+		arg.Priority,
+		arg.TemplateID,
+	)
+	var i ProvisionerJob
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.StartedAt,
+		&i.CanceledAt,
+		&i.CompletedAt,
+		&i.Error,
+		&i.OrganizationID,
+		&i.InitiatorID,
 		&i.Provisioner,
 		&i.StorageMethod,
 		&i.Type,
@@ -8242,6 +10843,8 @@ func (q *sqlQuerier) InsertProvisionerJob(ctx context.Context, arg InsertProvisi
 		&i.ErrorCode,
 		&i.TraceMetadata,
 		&i.JobStatus,
+		&i.Priority,
+		&i.TemplateID,
 	)
 	return i, err
 }
@@ -10687,6 +13290,116 @@ func (q *sqlQuerier) UpsertTelemetryItem(ctx context.Context, arg UpsertTelemetr
 	return err
 }
 
+const deleteTemplatePortShareRules = `-- name: DeleteTemplatePortShareRules :exec
+DELETE FROM
+	template_port_share_rules
+WHERE
+	template_id = $1
+`
+
+func (q *sqlQuerier) DeleteTemplatePortShareRules(ctx context.Context, templateID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteTemplatePortShareRules, templateID)
+	return err
+}
+
+const getTemplatePortShareRules = `-- name: GetTemplatePortShareRules :many
+SELECT
+	id, template_id, port_start, port_end, share_level, protocol, label
+FROM
+	template_port_share_rules
+WHERE
+	template_id = $1
+ORDER BY
+	port_start ASC
+`
+
+func (q *sqlQuerier) GetTemplatePortShareRules(ctx context.Context, templateID uuid.UUID) ([]TemplatePortShareRule, error) {
+	rows, err := q.db.QueryContext(ctx, getTemplatePortShareRules, templateID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TemplatePortShareRule
+	for rows.Next() {
+		var i TemplatePortShareRule
+		if err := rows.Scan(
+			&i.ID,
+			&i.TemplateID,
+			&i.PortStart,
+			&i.PortEnd,
+			&i.ShareLevel,
+			&i.Protocol,
+			&i.Label,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertTemplatePortShareRule = `-- name: InsertTemplatePortShareRule :one
+INSERT INTO
+	template_port_share_rules (
+		id,
+		template_id,
+		port_start,
+		port_end,
+		share_level,
+		protocol,
+		label
+	)
+VALUES (
+	$1,
+	$2,
+	$3,
+	$4,
+	$5,
+	$6,
+	$7
+)
+RETURNING id, template_id, port_start, port_end, share_level, protocol, label
+`
+
+type InsertTemplatePortShareRuleParams struct {
+	ID         uuid.UUID         `db:"id" json:"id"`
+	TemplateID uuid.UUID         `db:"template_id" json:"template_id"`
+	PortStart  int32             `db:"port_start" json:"port_start"`
+	PortEnd    int32             `db:"port_end" json:"port_end"`
+	ShareLevel AppSharingLevel   `db:"share_level" json:"share_level"`
+	Protocol   PortShareProtocol `db:"protocol" json:"protocol"`
+	Label      string            `db:"label" json:"label"`
+}
+
+func (q *sqlQuerier) InsertTemplatePortShareRule(ctx context.Context, arg InsertTemplatePortShareRuleParams) (TemplatePortShareRule, error) {
+	row := q.db.QueryRowContext(ctx, insertTemplatePortShareRule,
+		arg.ID,
+		arg.TemplateID,
+		arg.PortStart,
+		arg.PortEnd,
+		arg.ShareLevel,
+		arg.Protocol,
+		arg.Label,
+	)
+	var i TemplatePortShareRule
+	err := row.Scan(
+		&i.ID,
+		&i.TemplateID,
+		&i.PortStart,
+		&i.PortEnd,
+		&i.ShareLevel,
+		&i.Protocol,
+		&i.Label,
+	)
+	return i, err
+}
+
 const getTemplateAverageBuildTime = `-- name: GetTemplateAverageBuildTime :one
 WITH build_times AS (
 SELECT
@@ -10749,7 +13462,7 @@ func (q *sqlQuerier) GetTemplateAverageBuildTime(ctx context.Context, arg GetTem
 
 const getTemplateByID = `-- name: GetTemplateByID :one
 SELECT
-	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name, allow_user_cancel_workspace_jobs, allow_user_autostart, allow_user_autostop, failure_ttl, time_til_dormant, time_til_dormant_autodelete, autostop_requirement_days_of_week, autostop_requirement_weeks, autostart_block_days_of_week, require_active_version, deprecated, activity_bump, max_port_sharing_level, use_classic_parameter_flow, created_by_avatar_url, created_by_username, created_by_name, organization_name, organization_display_name, organization_icon
+	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name, allow_user_cancel_workspace_jobs, allow_user_autostart, allow_user_autostop, failure_ttl, time_til_dormant, time_til_dormant_autodelete, autostop_requirement_days_of_week, autostop_requirement_weeks, autostart_block_days_of_week, require_active_version, deprecated, activity_bump, max_port_sharing_level, use_classic_parameter_flow, drift_check_interval_ms, provisioner_version, created_by_avatar_url, created_by_username, created_by_name, organization_name, organization_display_name, organization_icon
 FROM
 	template_with_names
 WHERE
@@ -10791,6 +13504,8 @@ func (q *sqlQuerier) GetTemplateByID(ctx context.Context, id uuid.UUID) (Templat
 		&i.ActivityBump,
 		&i.MaxPortSharingLevel,
 		&i.UseClassicParameterFlow,
+		&i.DriftCheckIntervalMs,
+		&i.ProvisionerVersion,
 		&i.CreatedByAvatarURL,
 		&i.CreatedByUsername,
 		&i.CreatedByName,
@@ -10803,7 +13518,7 @@ func (q *sqlQuerier) GetTemplateByID(ctx context.Context, id uuid.UUID) (Templat
 
 const getTemplateByOrganizationAndName = `-- name: GetTemplateByOrganizationAndName :one
 SELECT
-	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name, allow_user_cancel_workspace_jobs, allow_user_autostart, allow_user_autostop, failure_ttl, time_til_dormant, time_til_dormant_autodelete, autostop_requirement_days_of_week, autostop_requirement_weeks, autostart_block_days_of_week, require_active_version, deprecated, activity_bump, max_port_sharing_level, use_classic_parameter_flow, created_by_avatar_url, created_by_username, created_by_name, organization_name, organization_display_name, organization_icon
+	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name, allow_user_cancel_workspace_jobs, allow_user_autostart, allow_user_autostop, failure_ttl, time_til_dormant, time_til_dormant_autodelete, autostop_requirement_days_of_week, autostop_requirement_weeks, autostart_block_days_of_week, require_active_version, deprecated, activity_bump, max_port_sharing_level, use_classic_parameter_flow, drift_check_interval_ms, provisioner_version, created_by_avatar_url, created_by_username, created_by_name, organization_name, organization_display_name, organization_icon
 FROM
 	template_with_names AS templates
 WHERE
@@ -10853,6 +13568,8 @@ func (q *sqlQuerier) GetTemplateByOrganizationAndName(ctx context.Context, arg G
 		&i.ActivityBump,
 		&i.MaxPortSharingLevel,
 		&i.UseClassicParameterFlow,
+		&i.DriftCheckIntervalMs,
+		&i.ProvisionerVersion,
 		&i.CreatedByAvatarURL,
 		&i.CreatedByUsername,
 		&i.CreatedByName,
@@ -10863,33 +13580,110 @@ func (q *sqlQuerier) GetTemplateByOrganizationAndName(ctx context.Context, arg G
 	return i, err
 }
 
-const getTemplates = `-- name: GetTemplates :many
-SELECT id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name, allow_user_cancel_workspace_jobs, allow_user_autostart, allow_user_autostop, failure_ttl, time_til_dormant, time_til_dormant_autodelete, autostop_requirement_days_of_week, autostop_requirement_weeks, autostart_block_days_of_week, require_active_version, deprecated, activity_bump, max_port_sharing_level, use_classic_parameter_flow, created_by_avatar_url, created_by_username, created_by_name, organization_name, organization_display_name, organization_icon FROM template_with_names AS templates
-ORDER BY (name, id) ASC
+const getTemplateVersionAdoption = `-- name: GetTemplateVersionAdoption :many
+SELECT
+	t.id AS template_id,
+	t.name AS template_name,
+	t.display_name AS template_display_name,
+	t.organization_id AS template_organization_id,
+	tv.id AS template_version_id,
+	tv.name AS template_version_name,
+	tv.id = t.active_version_id AS active,
+	atv.name AS active_version_name,
+	COUNT(w.id)::int AS workspace_count
+FROM
+	workspaces w
+JOIN
+	workspace_latest_builds wlb ON wlb.workspace_id = w.id
+JOIN
+	templates t ON t.id = w.template_id
+JOIN
+	template_versions tv ON tv.id = wlb.template_version_id
+JOIN
+	template_versions atv ON atv.id = t.active_version_id
+WHERE
+	NOT w.deleted
+	AND NOT t.deleted
+	AND w.owner_id != 'c42fdf75-3097-471c-8c33-fb52454d81c0'::uuid -- Exclude prebuilt workspaces.
+GROUP BY
+	t.id, tv.id, atv.id
+ORDER BY
+	t.name ASC, workspace_count DESC, tv.name ASC
 `
 
-func (q *sqlQuerier) GetTemplates(ctx context.Context) ([]Template, error) {
-	rows, err := q.db.QueryContext(ctx, getTemplates)
+type GetTemplateVersionAdoptionRow struct {
+	TemplateID             uuid.UUID `db:"template_id" json:"template_id"`
+	TemplateName           string    `db:"template_name" json:"template_name"`
+	TemplateDisplayName    string    `db:"template_display_name" json:"template_display_name"`
+	TemplateOrganizationID uuid.UUID `db:"template_organization_id" json:"template_organization_id"`
+	TemplateVersionID      uuid.UUID `db:"template_version_id" json:"template_version_id"`
+	TemplateVersionName    string    `db:"template_version_name" json:"template_version_name"`
+	Active                 bool      `db:"active" json:"active"`
+	ActiveVersionName      string    `db:"active_version_name" json:"active_version_name"`
+	WorkspaceCount         int32     `db:"workspace_count" json:"workspace_count"`
+}
+
+// GetTemplateVersionAdoption counts the workspaces of every template by the template version of their latest build.
+func (q *sqlQuerier) GetTemplateVersionAdoption(ctx context.Context) ([]GetTemplateVersionAdoptionRow, error) {
+	rows, err := q.db.QueryContext(ctx, getTemplateVersionAdoption)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Template
+	var items []GetTemplateVersionAdoptionRow
 	for rows.Next() {
-		var i Template
+		var i GetTemplateVersionAdoptionRow
 		if err := rows.Scan(
-			&i.ID,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-			&i.OrganizationID,
-			&i.Deleted,
-			&i.Name,
-			&i.Provisioner,
-			&i.ActiveVersionID,
-			&i.Description,
-			&i.DefaultTTL,
-			&i.CreatedBy,
-			&i.Icon,
+			&i.TemplateID,
+			&i.TemplateName,
+			&i.TemplateDisplayName,
+			&i.TemplateOrganizationID,
+			&i.TemplateVersionID,
+			&i.TemplateVersionName,
+			&i.Active,
+			&i.ActiveVersionName,
+			&i.WorkspaceCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTemplates = `-- name: GetTemplates :many
+SELECT id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name, allow_user_cancel_workspace_jobs, allow_user_autostart, allow_user_autostop, failure_ttl, time_til_dormant, time_til_dormant_autodelete, autostop_requirement_days_of_week, autostop_requirement_weeks, autostart_block_days_of_week, require_active_version, deprecated, activity_bump, max_port_sharing_level, use_classic_parameter_flow, drift_check_interval_ms, provisioner_version, created_by_avatar_url, created_by_username, created_by_name, organization_name, organization_display_name, organization_icon FROM template_with_names AS templates
+ORDER BY (name, id) ASC
+`
+
+func (q *sqlQuerier) GetTemplates(ctx context.Context) ([]Template, error) {
+	rows, err := q.db.QueryContext(ctx, getTemplates)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Template
+	for rows.Next() {
+		var i Template
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.OrganizationID,
+			&i.Deleted,
+			&i.Name,
+			&i.Provisioner,
+			&i.ActiveVersionID,
+			&i.Description,
+			&i.DefaultTTL,
+			&i.CreatedBy,
+			&i.Icon,
 			&i.UserACL,
 			&i.GroupACL,
 			&i.DisplayName,
@@ -10907,6 +13701,8 @@ func (q *sqlQuerier) GetTemplates(ctx context.Context) ([]Template, error) {
 			&i.ActivityBump,
 			&i.MaxPortSharingLevel,
 			&i.UseClassicParameterFlow,
+			&i.DriftCheckIntervalMs,
+			&i.ProvisionerVersion,
 			&i.CreatedByAvatarURL,
 			&i.CreatedByUsername,
 			&i.CreatedByName,
@@ -10929,7 +13725,7 @@ func (q *sqlQuerier) GetTemplates(ctx context.Context) ([]Template, error) {
 
 const getTemplatesWithFilter = `-- name: GetTemplatesWithFilter :many
 SELECT
-	t.id, t.created_at, t.updated_at, t.organization_id, t.deleted, t.name, t.provisioner, t.active_version_id, t.description, t.default_ttl, t.created_by, t.icon, t.user_acl, t.group_acl, t.display_name, t.allow_user_cancel_workspace_jobs, t.allow_user_autostart, t.allow_user_autostop, t.failure_ttl, t.time_til_dormant, t.time_til_dormant_autodelete, t.autostop_requirement_days_of_week, t.autostop_requirement_weeks, t.autostart_block_days_of_week, t.require_active_version, t.deprecated, t.activity_bump, t.max_port_sharing_level, t.use_classic_parameter_flow, t.created_by_avatar_url, t.created_by_username, t.created_by_name, t.organization_name, t.organization_display_name, t.organization_icon
+	t.id, t.created_at, t.updated_at, t.organization_id, t.deleted, t.name, t.provisioner, t.active_version_id, t.description, t.default_ttl, t.created_by, t.icon, t.user_acl, t.group_acl, t.display_name, t.allow_user_cancel_workspace_jobs, t.allow_user_autostart, t.allow_user_autostop, t.failure_ttl, t.time_til_dormant, t.time_til_dormant_autodelete, t.autostop_requirement_days_of_week, t.autostop_requirement_weeks, t.autostart_block_days_of_week, t.require_active_version, t.deprecated, t.activity_bump, t.max_port_sharing_level, t.use_classic_parameter_flow, t.drift_check_interval_ms, t.provisioner_version, t.created_by_avatar_url, t.created_by_username, t.created_by_name, t.organization_name, t.organization_display_name, t.organization_icon
 FROM
 	template_with_names AS t
 LEFT JOIN
@@ -11040,6 +13836,8 @@ func (q *sqlQuerier) GetTemplatesWithFilter(ctx context.Context, arg GetTemplate
 			&i.ActivityBump,
 			&i.MaxPortSharingLevel,
 			&i.UseClassicParameterFlow,
+			&i.DriftCheckIntervalMs,
+			&i.ProvisionerVersion,
 			&i.CreatedByAvatarURL,
 			&i.CreatedByUsername,
 			&i.CreatedByName,
@@ -11221,7 +14019,9 @@ SET
 	allow_user_cancel_workspace_jobs = $7,
 	group_acl = $8,
 	max_port_sharing_level = $9,
-	use_classic_parameter_flow = $10
+	use_classic_parameter_flow = $10,
+	drift_check_interval_ms = $11,
+	provisioner_version = $12
 WHERE
 	id = $1
 `
@@ -11237,6 +14037,8 @@ type UpdateTemplateMetaByIDParams struct {
 	GroupACL                     TemplateACL     `db:"group_acl" json:"group_acl"`
 	MaxPortSharingLevel          AppSharingLevel `db:"max_port_sharing_level" json:"max_port_sharing_level"`
 	UseClassicParameterFlow      bool            `db:"use_classic_parameter_flow" json:"use_classic_parameter_flow"`
+	DriftCheckIntervalMs         int64           `db:"drift_check_interval_ms" json:"drift_check_interval_ms"`
+	ProvisionerVersion           string          `db:"provisioner_version" json:"provisioner_version"`
 }
 
 func (q *sqlQuerier) UpdateTemplateMetaByID(ctx context.Context, arg UpdateTemplateMetaByIDParams) error {
@@ -11251,6 +14053,8 @@ func (q *sqlQuerier) UpdateTemplateMetaByID(ctx context.Context, arg UpdateTempl
 		arg.GroupACL,
 		arg.MaxPortSharingLevel,
 		arg.UseClassicParameterFlow,
+		arg.DriftCheckIntervalMs,
+		arg.ProvisionerVersion,
 	)
 	return err
 }
@@ -13840,7 +16644,7 @@ func (q *sqlQuerier) DeleteWorkspaceAgentPortSharesByTemplate(ctx context.Contex
 
 const getWorkspaceAgentPortShare = `-- name: GetWorkspaceAgentPortShare :one
 SELECT
-	workspace_id, agent_name, port, share_level, protocol
+	workspace_id, agent_name, port, share_level, protocol, auto_shared, label
 FROM
 	workspace_agent_port_share
 WHERE
@@ -13864,13 +16668,15 @@ func (q *sqlQuerier) GetWorkspaceAgentPortShare(ctx context.Context, arg GetWork
 		&i.Port,
 		&i.ShareLevel,
 		&i.Protocol,
+		&i.AutoShared,
+		&i.Label,
 	)
 	return i, err
 }
 
 const listWorkspaceAgentPortShares = `-- name: ListWorkspaceAgentPortShares :many
 SELECT
-	workspace_id, agent_name, port, share_level, protocol
+	workspace_id, agent_name, port, share_level, protocol, auto_shared, label
 FROM
 	workspace_agent_port_share
 WHERE
@@ -13892,6 +16698,8 @@ func (q *sqlQuerier) ListWorkspaceAgentPortShares(ctx context.Context, workspace
 			&i.Port,
 			&i.ShareLevel,
 			&i.Protocol,
+			&i.AutoShared,
+			&i.Label,
 		); err != nil {
 			return nil, err
 		}
@@ -13935,14 +16743,18 @@ INSERT INTO
 		agent_name,
 		port,
 		share_level,
-		protocol
+		protocol,
+		auto_shared,
+		label
 	)
 VALUES (
 	$1,
 	$2,
 	$3,
 	$4,
-	$5
+	$5,
+	$6,
+	$7
 )
 ON CONFLICT (
 	workspace_id,
@@ -13951,8 +16763,10 @@ ON CONFLICT (
 )
 DO UPDATE SET
 	share_level = $4,
-	protocol = $5
-RETURNING workspace_id, agent_name, port, share_level, protocol
+	protocol = $5,
+	auto_shared = $6,
+	label = $7
+RETURNING workspace_id, agent_name, port, share_level, protocol, auto_shared, label
 `
 
 type UpsertWorkspaceAgentPortShareParams struct {
@@ -13961,6 +16775,8 @@ type UpsertWorkspaceAgentPortShareParams struct {
 	Port        int32             `db:"port" json:"port"`
 	ShareLevel  AppSharingLevel   `db:"share_level" json:"share_level"`
 	Protocol    PortShareProtocol `db:"protocol" json:"protocol"`
+	AutoShared  bool              `db:"auto_shared" json:"auto_shared"`
+	Label       string            `db:"label" json:"label"`
 }
 
 func (q *sqlQuerier) UpsertWorkspaceAgentPortShare(ctx context.Context, arg UpsertWorkspaceAgentPortShareParams) (WorkspaceAgentPortShare, error) {
@@ -13970,6 +16786,8 @@ func (q *sqlQuerier) UpsertWorkspaceAgentPortShare(ctx context.Context, arg Upse
 		arg.Port,
 		arg.ShareLevel,
 		arg.Protocol,
+		arg.AutoShared,
+		arg.Label,
 	)
 	var i WorkspaceAgentPortShare
 	err := row.Scan(
@@ -13978,6 +16796,8 @@ func (q *sqlQuerier) UpsertWorkspaceAgentPortShare(ctx context.Context, arg Upse
 		&i.Port,
 		&i.ShareLevel,
 		&i.Protocol,
+		&i.AutoShared,
+		&i.Label,
 	)
 	return i, err
 }
@@ -15883,6 +18703,109 @@ func (q *sqlQuerier) GetTemplateDAUs(ctx context.Context, arg GetTemplateDAUsPar
 	return items, nil
 }
 
+const getTopWorkspaceResourceConsumers = `-- name: GetTopWorkspaceResourceConsumers :many
+WITH workspace_usage AS (
+	SELECT
+		workspace_id,
+		SUM(rx_bytes + tx_bytes)::bigint AS transferred_bytes,
+		SUM(session_count_vscode + session_count_jetbrains + session_count_reconnecting_pty + session_count_ssh)::bigint AS sessions
+	FROM workspace_agent_stats
+	WHERE created_at >= $2::timestamptz
+	GROUP BY workspace_id
+), ranked AS (
+	SELECT
+		w.id AS workspace_id,
+		w.name AS workspace_name,
+		u.username AS owner_username,
+		t.name AS template_name,
+		t.display_name AS template_display_name,
+		o.id AS organization_id,
+		o.name AS organization_name,
+		o.display_name AS organization_display_name,
+		workspace_usage.transferred_bytes,
+		workspace_usage.sessions,
+		COALESCE(lb.daily_cost, 0)::int AS daily_cost,
+		ROW_NUMBER() OVER (PARTITION BY o.id ORDER BY workspace_usage.transferred_bytes DESC, w.name ASC) AS position
+	FROM workspace_usage
+	JOIN workspaces w ON w.id = workspace_usage.workspace_id
+	JOIN users u ON u.id = w.owner_id
+	JOIN templates t ON t.id = w.template_id
+	JOIN organizations o ON o.id = w.organization_id
+	LEFT JOIN LATERAL (
+		SELECT daily_cost
+		FROM workspace_builds
+		WHERE workspace_builds.workspace_id = w.id
+		ORDER BY build_number DESC
+		LIMIT 1
+	) lb ON TRUE
+	WHERE NOT w.deleted
+		AND w.owner_id != 'c42fdf75-3097-471c-8c33-fb52454d81c0'::uuid -- Exclude prebuilt workspaces.
+		AND workspace_usage.transferred_bytes > 0
+)
+SELECT workspace_id, workspace_name, owner_username, template_name, template_display_name, organization_id, organization_name, organization_display_name, transferred_bytes, sessions, daily_cost, position
+FROM ranked
+WHERE position <= $1::bigint
+ORDER BY organization_name, position
+`
+
+type GetTopWorkspaceResourceConsumersParams struct {
+	LimitPerOrganization int64     `db:"limit_per_organization" json:"limit_per_organization"`
+	Since                time.Time `db:"since" json:"since"`
+}
+
+type GetTopWorkspaceResourceConsumersRow struct {
+	WorkspaceID             uuid.UUID `db:"workspace_id" json:"workspace_id"`
+	WorkspaceName           string    `db:"workspace_name" json:"workspace_name"`
+	OwnerUsername           string    `db:"owner_username" json:"owner_username"`
+	TemplateName            string    `db:"template_name" json:"template_name"`
+	TemplateDisplayName     string    `db:"template_display_name" json:"template_display_name"`
+	OrganizationID          uuid.UUID `db:"organization_id" json:"organization_id"`
+	OrganizationName        string    `db:"organization_name" json:"organization_name"`
+	OrganizationDisplayName string    `db:"organization_display_name" json:"organization_display_name"`
+	TransferredBytes        int64     `db:"transferred_bytes" json:"transferred_bytes"`
+	Sessions                int64     `db:"sessions" json:"sessions"`
+	DailyCost               int32     `db:"daily_cost" json:"daily_cost"`
+	Position                int64     `db:"position" json:"position"`
+}
+
+// GetTopWorkspaceResourceConsumers returns the workspaces with the most network traffic since the given time,
+// ranked per organization. The daily cost of the latest build is included as an indication of compute usage.
+func (q *sqlQuerier) GetTopWorkspaceResourceConsumers(ctx context.Context, arg GetTopWorkspaceResourceConsumersParams) ([]GetTopWorkspaceResourceConsumersRow, error) {
+	rows, err := q.db.QueryContext(ctx, getTopWorkspaceResourceConsumers, arg.LimitPerOrganization, arg.Since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTopWorkspaceResourceConsumersRow
+	for rows.Next() {
+		var i GetTopWorkspaceResourceConsumersRow
+		if err := rows.Scan(
+			&i.WorkspaceID,
+			&i.WorkspaceName,
+			&i.OwnerUsername,
+			&i.TemplateName,
+			&i.TemplateDisplayName,
+			&i.OrganizationID,
+			&i.OrganizationName,
+			&i.OrganizationDisplayName,
+			&i.TransferredBytes,
+			&i.Sessions,
+			&i.DailyCost,
+			&i.Position,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getWorkspaceAgentStats = `-- name: GetWorkspaceAgentStats :many
 WITH agent_stats AS (
 	SELECT
@@ -17556,6 +20479,138 @@ func (q *sqlQuerier) GetWorkspaceBuildByWorkspaceIDAndBuildNumber(ctx context.Co
 	return i, err
 }
 
+const getWorkspaceBuildEvents = `-- name: GetWorkspaceBuildEvents :many
+SELECT
+	wb.id,
+	wb.build_number,
+	wb.transition,
+	wb.reason,
+	pj.job_status,
+	pj.completed_at::timestamptz AS completed_at,
+	w.id AS workspace_id,
+	w.name AS workspace_name,
+	u.username AS workspace_owner_username,
+	t.name AS template_name,
+	COUNT(*) OVER () AS count
+FROM
+	workspace_builds AS wb
+JOIN
+	workspaces AS w ON wb.workspace_id = w.id
+JOIN
+	users AS u ON w.owner_id = u.id
+JOIN
+	templates AS t ON w.template_id = t.id
+JOIN
+	provisioner_jobs AS pj ON wb.job_id = pj.id
+WHERE
+	w.organization_id = $1
+	AND pj.completed_at > $2 :: timestamptz
+	AND pj.completed_at <= $3 :: timestamptz
+	AND CASE
+		WHEN $4 :: text != '' THEN t.name = LOWER($4)
+		ELSE true
+	END
+	AND CASE
+		WHEN $5 :: text != '' THEN w.name = LOWER($5)
+		ELSE true
+	END
+	AND CASE
+		WHEN $6 :: text != '' THEN u.username = LOWER($6)
+		ELSE true
+	END
+	AND CASE
+		WHEN $7 :: text != '' THEN wb.transition = $7 :: workspace_transition
+		ELSE true
+	END
+	AND CASE
+		WHEN $8 :: text != '' THEN pj.job_status = $8 :: provisioner_job_status
+		ELSE true
+	END
+	AND CASE
+		WHEN $9 :: text != '' THEN wb.reason = $9 :: build_reason
+		ELSE true
+	END
+ORDER BY
+	pj.completed_at DESC
+LIMIT
+	$10
+`
+
+type GetWorkspaceBuildEventsParams struct {
+	OrganizationID  uuid.UUID `db:"organization_id" json:"organization_id"`
+	CompletedAfter  time.Time `db:"completed_after" json:"completed_after"`
+	CompletedBefore time.Time `db:"completed_before" json:"completed_before"`
+	TemplateName    string    `db:"template_name" json:"template_name"`
+	WorkspaceName   string    `db:"workspace_name" json:"workspace_name"`
+	OwnerUsername   string    `db:"owner_username" json:"owner_username"`
+	Transition      string    `db:"transition" json:"transition"`
+	JobStatus       string    `db:"job_status" json:"job_status"`
+	BuildReason     string    `db:"build_reason" json:"build_reason"`
+	LimitOpt        int32     `db:"limit_opt" json:"limit_opt"`
+}
+
+type GetWorkspaceBuildEventsRow struct {
+	ID                     uuid.UUID            `db:"id" json:"id"`
+	BuildNumber            int32                `db:"build_number" json:"build_number"`
+	Transition             WorkspaceTransition  `db:"transition" json:"transition"`
+	Reason                 BuildReason          `db:"reason" json:"reason"`
+	JobStatus              ProvisionerJobStatus `db:"job_status" json:"job_status"`
+	CompletedAt            time.Time            `db:"completed_at" json:"completed_at"`
+	WorkspaceID            uuid.UUID            `db:"workspace_id" json:"workspace_id"`
+	WorkspaceName          string               `db:"workspace_name" json:"workspace_name"`
+	WorkspaceOwnerUsername string               `db:"workspace_owner_username" json:"workspace_owner_username"`
+	TemplateName           string               `db:"template_name" json:"template_name"`
+	Count                  int64                `db:"count" json:"count"`
+}
+
+// GetWorkspaceBuildEvents returns workspace builds which completed in the given period, most recent first. The
+// total number of matching builds is returned on every row, regardless of the limit.
+func (q *sqlQuerier) GetWorkspaceBuildEvents(ctx context.Context, arg GetWorkspaceBuildEventsParams) ([]GetWorkspaceBuildEventsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getWorkspaceBuildEvents,
+		arg.OrganizationID,
+		arg.CompletedAfter,
+		arg.CompletedBefore,
+		arg.TemplateName,
+		arg.WorkspaceName,
+		arg.OwnerUsername,
+		arg.Transition,
+		arg.JobStatus,
+		arg.BuildReason,
+		arg.LimitOpt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetWorkspaceBuildEventsRow
+	for rows.Next() {
+		var i GetWorkspaceBuildEventsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.BuildNumber,
+			&i.Transition,
+			&i.Reason,
+			&i.JobStatus,
+			&i.CompletedAt,
+			&i.WorkspaceID,
+			&i.WorkspaceName,
+			&i.WorkspaceOwnerUsername,
+			&i.TemplateName,
+			&i.Count,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getWorkspaceBuildStatsByTemplates = `-- name: GetWorkspaceBuildStatsByTemplates :many
 SELECT
     w.template_id,
@@ -17915,33 +20970,98 @@ func (q *sqlQuerier) UpdateWorkspaceBuildProvisionerStateByID(ctx context.Contex
 	return err
 }
 
-const getWorkspaceModulesByJobID = `-- name: GetWorkspaceModulesByJobID :many
+const deleteOldWorkspaceBuildStates = `-- name: DeleteOldWorkspaceBuildStates :execrows
+DELETE FROM
+	workspace_build_states
+WHERE
+	workspace_build_id IN (
+		SELECT
+			ranked.workspace_build_id
+		FROM (
+			SELECT
+				workspace_build_id,
+				created_at,
+				row_number() OVER (PARTITION BY workspace_id ORDER BY created_at DESC, workspace_build_id) AS position
+			FROM
+				workspace_build_states
+		) AS ranked
+		WHERE
+			ranked.position > 1
+			AND (
+				($1::int > 0 AND ranked.position > $1::int)
+				OR ranked.created_at < $2::timestamptz
+			)
+	)
+`
+
+type DeleteOldWorkspaceBuildStatesParams struct {
+	KeepCount int32     `db:"keep_count" json:"keep_count"`
+	Before    time.Time `db:"before" json:"before"`
+}
+
+// DeleteOldWorkspaceBuildStates deletes the states of a workspace beyond the
+// newest @keep_count, and the states created before @before. A @keep_count of
+// zero keeps any number of states. The newest state of every workspace is
+// always kept.
+func (q *sqlQuerier) DeleteOldWorkspaceBuildStates(ctx context.Context, arg DeleteOldWorkspaceBuildStatesParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteOldWorkspaceBuildStates, arg.KeepCount, arg.Before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getWorkspaceBuildStateByBuildID = `-- name: GetWorkspaceBuildStateByBuildID :one
 SELECT
-	id, job_id, transition, source, version, key, created_at
+	workspace_build_id, workspace_id, state, state_key_id, hash, size, created_at
 FROM
-	workspace_modules
+	workspace_build_states
 WHERE
-	job_id = $1
+	workspace_build_id = $1
 `
 
-func (q *sqlQuerier) GetWorkspaceModulesByJobID(ctx context.Context, jobID uuid.UUID) ([]WorkspaceModule, error) {
-	rows, err := q.db.QueryContext(ctx, getWorkspaceModulesByJobID, jobID)
+func (q *sqlQuerier) GetWorkspaceBuildStateByBuildID(ctx context.Context, workspaceBuildID uuid.UUID) (WorkspaceBuildState, error) {
+	row := q.db.QueryRowContext(ctx, getWorkspaceBuildStateByBuildID, workspaceBuildID)
+	var i WorkspaceBuildState
+	err := row.Scan(
+		&i.WorkspaceBuildID,
+		&i.WorkspaceID,
+		&i.State,
+		&i.StateKeyID,
+		&i.Hash,
+		&i.Size,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getWorkspaceBuildStateKeyIDs = `-- name: GetWorkspaceBuildStateKeyIDs :many
+SELECT
+	workspace_build_id,
+	state_key_id
+FROM
+	workspace_build_states
+ORDER BY
+	workspace_build_id
+`
+
+type GetWorkspaceBuildStateKeyIDsRow struct {
+	WorkspaceBuildID uuid.UUID      `db:"workspace_build_id" json:"workspace_build_id"`
+	StateKeyID       sql.NullString `db:"state_key_id" json:"state_key_id"`
+}
+
+// GetWorkspaceBuildStateKeyIDs returns the key every state is encrypted with,
+// so that states can be re-encrypted when keys are rotated.
+func (q *sqlQuerier) GetWorkspaceBuildStateKeyIDs(ctx context.Context) ([]GetWorkspaceBuildStateKeyIDsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getWorkspaceBuildStateKeyIDs)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []WorkspaceModule
+	var items []GetWorkspaceBuildStateKeyIDsRow
 	for rows.Next() {
-		var i WorkspaceModule
-		if err := rows.Scan(
-			&i.ID,
-			&i.JobID,
-			&i.Transition,
-			&i.Source,
-			&i.Version,
-			&i.Key,
-			&i.CreatedAt,
-		); err != nil {
+		var i GetWorkspaceBuildStateKeyIDsRow
+		if err := rows.Scan(&i.WorkspaceBuildID, &i.StateKeyID); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -17955,26 +21075,51 @@ func (q *sqlQuerier) GetWorkspaceModulesByJobID(ctx context.Context, jobID uuid.
 	return items, nil
 }
 
-const getWorkspaceModulesCreatedAfter = `-- name: GetWorkspaceModulesCreatedAfter :many
-SELECT id, job_id, transition, source, version, key, created_at FROM workspace_modules WHERE created_at > $1
+const getWorkspaceBuildStatesByWorkspaceID = `-- name: GetWorkspaceBuildStatesByWorkspaceID :many
+SELECT
+	workspace_build_states.workspace_build_id,
+	workspace_builds.build_number,
+	workspace_builds.transition,
+	workspace_build_states.hash,
+	workspace_build_states.size,
+	workspace_build_states.created_at
+FROM
+	workspace_build_states
+JOIN
+	workspace_builds ON workspace_builds.id = workspace_build_states.workspace_build_id
+WHERE
+	workspace_build_states.workspace_id = $1
+ORDER BY
+	workspace_builds.build_number DESC
 `
 
-func (q *sqlQuerier) GetWorkspaceModulesCreatedAfter(ctx context.Context, createdAt time.Time) ([]WorkspaceModule, error) {
-	rows, err := q.db.QueryContext(ctx, getWorkspaceModulesCreatedAfter, createdAt)
+type GetWorkspaceBuildStatesByWorkspaceIDRow struct {
+	WorkspaceBuildID uuid.UUID           `db:"workspace_build_id" json:"workspace_build_id"`
+	BuildNumber      int32               `db:"build_number" json:"build_number"`
+	Transition       WorkspaceTransition `db:"transition" json:"transition"`
+	Hash             string              `db:"hash" json:"hash"`
+	Size             int64               `db:"size" json:"size"`
+	CreatedAt        time.Time           `db:"created_at" json:"created_at"`
+}
+
+// GetWorkspaceBuildStatesByWorkspaceID lists the states kept for a workspace,
+// newest first. The states themselves are left out, they are fetched one at a
+// time.
+func (q *sqlQuerier) GetWorkspaceBuildStatesByWorkspaceID(ctx context.Context, workspaceID uuid.UUID) ([]GetWorkspaceBuildStatesByWorkspaceIDRow, error) {
+	rows, err := q.db.QueryContext(ctx, getWorkspaceBuildStatesByWorkspaceID, workspaceID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []WorkspaceModule
+	var items []GetWorkspaceBuildStatesByWorkspaceIDRow
 	for rows.Next() {
-		var i WorkspaceModule
+		var i GetWorkspaceBuildStatesByWorkspaceIDRow
 		if err := rows.Scan(
-			&i.ID,
-			&i.JobID,
+			&i.WorkspaceBuildID,
+			&i.BuildNumber,
 			&i.Transition,
-			&i.Source,
-			&i.Version,
-			&i.Key,
+			&i.Hash,
+			&i.Size,
 			&i.CreatedAt,
 		); err != nil {
 			return nil, err
@@ -17990,98 +21135,113 @@ func (q *sqlQuerier) GetWorkspaceModulesCreatedAfter(ctx context.Context, create
 	return items, nil
 }
 
-const insertWorkspaceModule = `-- name: InsertWorkspaceModule :one
+const upsertWorkspaceBuildState = `-- name: UpsertWorkspaceBuildState :exec
 INSERT INTO
-	workspace_modules (id, job_id, transition, source, version, key, created_at)
+	workspace_build_states (workspace_build_id, workspace_id, state, state_key_id, hash, size, created_at)
 VALUES
-	($1, $2, $3, $4, $5, $6, $7) RETURNING id, job_id, transition, source, version, key, created_at
-`
-
-type InsertWorkspaceModuleParams struct {
-	ID         uuid.UUID           `db:"id" json:"id"`
-	JobID      uuid.UUID           `db:"job_id" json:"job_id"`
-	Transition WorkspaceTransition `db:"transition" json:"transition"`
-	Source     string              `db:"source" json:"source"`
-	Version    string              `db:"version" json:"version"`
-	Key        string              `db:"key" json:"key"`
-	CreatedAt  time.Time           `db:"created_at" json:"created_at"`
-}
-
-func (q *sqlQuerier) InsertWorkspaceModule(ctx context.Context, arg InsertWorkspaceModuleParams) (WorkspaceModule, error) {
-	row := q.db.QueryRowContext(ctx, insertWorkspaceModule,
-		arg.ID,
-		arg.JobID,
-		arg.Transition,
-		arg.Source,
-		arg.Version,
-		arg.Key,
+	($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (workspace_build_id) DO UPDATE SET
+	state = EXCLUDED.state,
+	state_key_id = EXCLUDED.state_key_id,
+	hash = EXCLUDED.hash,
+	size = EXCLUDED.size,
+	created_at = EXCLUDED.created_at
+`
+
+type UpsertWorkspaceBuildStateParams struct {
+	WorkspaceBuildID uuid.UUID      `db:"workspace_build_id" json:"workspace_build_id"`
+	WorkspaceID      uuid.UUID      `db:"workspace_id" json:"workspace_id"`
+	State            []byte         `db:"state" json:"state"`
+	StateKeyID       sql.NullString `db:"state_key_id" json:"state_key_id"`
+	Hash             string         `db:"hash" json:"hash"`
+	Size             int64          `db:"size" json:"size"`
+	CreatedAt        time.Time      `db:"created_at" json:"created_at"`
+}
+
+func (q *sqlQuerier) UpsertWorkspaceBuildState(ctx context.Context, arg UpsertWorkspaceBuildStateParams) error {
+	_, err := q.db.ExecContext(ctx, upsertWorkspaceBuildState,
+		arg.WorkspaceBuildID,
+		arg.WorkspaceID,
+		arg.State,
+		arg.StateKeyID,
+		arg.Hash,
+		arg.Size,
 		arg.CreatedAt,
 	)
-	var i WorkspaceModule
-	err := row.Scan(
-		&i.ID,
-		&i.JobID,
-		&i.Transition,
-		&i.Source,
-		&i.Version,
-		&i.Key,
-		&i.CreatedAt,
-	)
-	return i, err
+	return err
 }
 
-const getWorkspaceResourceByID = `-- name: GetWorkspaceResourceByID :one
+const getWorkspaceCostInsights = `-- name: GetWorkspaceCostInsights :many
 SELECT
-	id, created_at, job_id, transition, type, name, hide, icon, instance_type, daily_cost, module_path
+	wcu.organization_id,
+	o.name AS organization_name,
+	wcu.template_id,
+	t.name AS template_name,
+	wcu.user_id,
+	u.username,
+	SUM(wcu.usage_seconds)::bigint AS usage_seconds,
+	SUM(wcu.cost_micros)::bigint AS cost_micros
 FROM
-	workspace_resources
+	workspace_cost_usage AS wcu
+JOIN
+	organizations AS o
+ON
+	o.id = wcu.organization_id
+JOIN
+	templates AS t
+ON
+	t.id = wcu.template_id
+JOIN
+	users AS u
+ON
+	u.id = wcu.user_id
 WHERE
-	id = $1
+	wcu.start_time >= $1::timestamptz
+	AND wcu.start_time < $2::timestamptz
+	AND CASE WHEN COALESCE(array_length($3::uuid[], 1), 0) > 0 THEN wcu.template_id = ANY($3::uuid[]) ELSE TRUE END
+GROUP BY
+	wcu.organization_id, o.name, wcu.template_id, t.name, wcu.user_id, u.username
+ORDER BY
+	cost_micros DESC, usage_seconds DESC;
 `
 
-func (q *sqlQuerier) GetWorkspaceResourceByID(ctx context.Context, id uuid.UUID) (WorkspaceResource, error) {
-	row := q.db.QueryRowContext(ctx, getWorkspaceResourceByID, id)
-	var i WorkspaceResource
-	err := row.Scan(
-		&i.ID,
-		&i.CreatedAt,
-		&i.JobID,
-		&i.Transition,
-		&i.Type,
-		&i.Name,
-		&i.Hide,
-		&i.Icon,
-		&i.InstanceType,
-		&i.DailyCost,
-		&i.ModulePath,
-	)
-	return i, err
+type GetWorkspaceCostInsightsParams struct {
+	StartTime   time.Time   `db:"start_time" json:"start_time"`
+	EndTime     time.Time   `db:"end_time" json:"end_time"`
+	TemplateIDs []uuid.UUID `db:"template_ids" json:"template_ids"`
 }
 
-const getWorkspaceResourceMetadataByResourceIDs = `-- name: GetWorkspaceResourceMetadataByResourceIDs :many
-SELECT
-	workspace_resource_id, key, value, sensitive, id
-FROM
-	workspace_resource_metadata
-WHERE
-	workspace_resource_id = ANY($1 :: uuid [ ]) ORDER BY id ASC
-`
+type GetWorkspaceCostInsightsRow struct {
+	OrganizationID   uuid.UUID `db:"organization_id" json:"organization_id"`
+	OrganizationName string    `db:"organization_name" json:"organization_name"`
+	TemplateID       uuid.UUID `db:"template_id" json:"template_id"`
+	TemplateName     string    `db:"template_name" json:"template_name"`
+	UserID           uuid.UUID `db:"user_id" json:"user_id"`
+	Username         string    `db:"username" json:"username"`
+	UsageSeconds     int64     `db:"usage_seconds" json:"usage_seconds"`
+	CostMicros       int64     `db:"cost_micros" json:"cost_micros"`
+}
 
-func (q *sqlQuerier) GetWorkspaceResourceMetadataByResourceIDs(ctx context.Context, ids []uuid.UUID) ([]WorkspaceResourceMetadatum, error) {
-	rows, err := q.db.QueryContext(ctx, getWorkspaceResourceMetadataByResourceIDs, pq.Array(ids))
+// GetWorkspaceCostInsights returns the uptime and cost of workspaces between
+// the start and end time, per organization, template and user.
+func (q *sqlQuerier) GetWorkspaceCostInsights(ctx context.Context, arg GetWorkspaceCostInsightsParams) ([]GetWorkspaceCostInsightsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getWorkspaceCostInsights, arg.StartTime, arg.EndTime, pq.Array(arg.TemplateIDs))
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []WorkspaceResourceMetadatum
+	var items []GetWorkspaceCostInsightsRow
 	for rows.Next() {
-		var i WorkspaceResourceMetadatum
+		var i GetWorkspaceCostInsightsRow
 		if err := rows.Scan(
-			&i.WorkspaceResourceID,
-			&i.Key,
-			&i.Value,
-			&i.Sensitive,
-			&i.ID,
+			&i.OrganizationID,
+			&i.OrganizationName,
+			&i.TemplateID,
+			&i.TemplateName,
+			&i.UserID,
+			&i.Username,
+			&i.UsageSeconds,
+			&i.CostMicros,
 		); err != nil {
 			return nil, err
 		}
@@ -18096,27 +21256,69 @@ func (q *sqlQuerier) GetWorkspaceResourceMetadataByResourceIDs(ctx context.Conte
 	return items, nil
 }
 
-const getWorkspaceResourceMetadataCreatedAfter = `-- name: GetWorkspaceResourceMetadataCreatedAfter :many
-SELECT workspace_resource_id, key, value, sensitive, id FROM workspace_resource_metadata WHERE workspace_resource_id = ANY(
-	SELECT id FROM workspace_resources WHERE created_at > $1
-)
+const getWorkspaceCostInsightsByGroup = `-- name: GetWorkspaceCostInsightsByGroup :many
+SELECT
+	gme.group_id,
+	gme.group_name,
+	gme.organization_id,
+	o.name AS organization_name,
+	SUM(wcu.usage_seconds)::bigint AS usage_seconds,
+	SUM(wcu.cost_micros)::bigint AS cost_micros
+FROM
+	workspace_cost_usage AS wcu
+JOIN
+	group_members_expanded AS gme
+ON
+	gme.user_id = wcu.user_id
+	AND gme.organization_id = wcu.organization_id
+JOIN
+	organizations AS o
+ON
+	o.id = gme.organization_id
+WHERE
+	wcu.start_time >= $1::timestamptz
+	AND wcu.start_time < $2::timestamptz
+	AND CASE WHEN COALESCE(array_length($3::uuid[], 1), 0) > 0 THEN wcu.template_id = ANY($3::uuid[]) ELSE TRUE END
+GROUP BY
+	gme.group_id, gme.group_name, gme.organization_id, o.name
+ORDER BY
+	cost_micros DESC, usage_seconds DESC;
 `
 
-func (q *sqlQuerier) GetWorkspaceResourceMetadataCreatedAfter(ctx context.Context, createdAt time.Time) ([]WorkspaceResourceMetadatum, error) {
-	rows, err := q.db.QueryContext(ctx, getWorkspaceResourceMetadataCreatedAfter, createdAt)
+type GetWorkspaceCostInsightsByGroupParams struct {
+	StartTime   time.Time   `db:"start_time" json:"start_time"`
+	EndTime     time.Time   `db:"end_time" json:"end_time"`
+	TemplateIDs []uuid.UUID `db:"template_ids" json:"template_ids"`
+}
+
+type GetWorkspaceCostInsightsByGroupRow struct {
+	GroupID          uuid.UUID `db:"group_id" json:"group_id"`
+	GroupName        string    `db:"group_name" json:"group_name"`
+	OrganizationID   uuid.UUID `db:"organization_id" json:"organization_id"`
+	OrganizationName string    `db:"organization_name" json:"organization_name"`
+	UsageSeconds     int64     `db:"usage_seconds" json:"usage_seconds"`
+	CostMicros       int64     `db:"cost_micros" json:"cost_micros"`
+}
+
+// GetWorkspaceCostInsightsByGroup returns the uptime and cost of workspaces
+// between the start and end time, per group of their owners. Users can be in
+// many groups, so the same usage can count towards many groups.
+func (q *sqlQuerier) GetWorkspaceCostInsightsByGroup(ctx context.Context, arg GetWorkspaceCostInsightsByGroupParams) ([]GetWorkspaceCostInsightsByGroupRow, error) {
+	rows, err := q.db.QueryContext(ctx, getWorkspaceCostInsightsByGroup, arg.StartTime, arg.EndTime, pq.Array(arg.TemplateIDs))
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []WorkspaceResourceMetadatum
+	var items []GetWorkspaceCostInsightsByGroupRow
 	for rows.Next() {
-		var i WorkspaceResourceMetadatum
+		var i GetWorkspaceCostInsightsByGroupRow
 		if err := rows.Scan(
-			&i.WorkspaceResourceID,
-			&i.Key,
-			&i.Value,
-			&i.Sensitive,
-			&i.ID,
+			&i.GroupID,
+			&i.GroupName,
+			&i.OrganizationID,
+			&i.OrganizationName,
+			&i.UsageSeconds,
+			&i.CostMicros,
 		); err != nil {
 			return nil, err
 		}
@@ -18131,37 +21333,232 @@ func (q *sqlQuerier) GetWorkspaceResourceMetadataCreatedAfter(ctx context.Contex
 	return items, nil
 }
 
-const getWorkspaceResourcesByJobID = `-- name: GetWorkspaceResourcesByJobID :many
+const upsertWorkspaceCostUsage = `-- name: UpsertWorkspaceCostUsage :exec
+WITH
+	latest_start AS (
+		SELECT
+			date_trunc('hour', COALESCE(
+				MAX(start_time) - '1 hour'::interval,
+				-- Fallback when there is no cost usage yet.
+				(SELECT MIN(created_at) FROM workspace_builds)
+			)) AS t
+		FROM
+			workspace_cost_usage
+	),
+	build_uptime AS (
+		SELECT
+			wb.workspace_id,
+			w.template_id,
+			w.owner_id AS user_id,
+			w.organization_id,
+			pj.completed_at AS started_at,
+			COALESCE(next_build.created_at, NOW()) AS stopped_at,
+			(
+				SELECT
+					COALESCE(SUM(wr.hourly_cost_micros), 0)
+				FROM
+					workspace_resources AS wr
+				WHERE
+					wr.job_id = wb.job_id
+					AND wr.transition = 'start'
+			)::bigint AS hourly_cost_micros
+		FROM
+			workspace_builds AS wb
+		JOIN
+			provisioner_jobs AS pj
+		ON
+			pj.id = wb.job_id
+		JOIN
+			workspaces AS w
+		ON
+			w.id = wb.workspace_id
+		LEFT JOIN LATERAL (
+			SELECT
+				nb.created_at
+			FROM
+				workspace_builds AS nb
+			WHERE
+				nb.workspace_id = wb.workspace_id
+				AND nb.build_number > wb.build_number
+			ORDER BY
+				nb.build_number ASC
+			LIMIT 1
+		) AS next_build ON TRUE
+		WHERE
+			wb.transition = 'start'
+			AND pj.job_status = 'succeeded'
+			AND COALESCE(next_build.created_at, NOW()) > (SELECT t FROM latest_start)
+	),
+	hourly_uptime AS (
+		SELECT
+			s.hour_bucket AS start_time,
+			bu.workspace_id,
+			bu.template_id,
+			bu.user_id,
+			bu.organization_id,
+			EXTRACT(EPOCH FROM
+				LEAST(bu.stopped_at, s.hour_bucket + '1 hour'::interval)
+				- GREATEST(bu.started_at, s.hour_bucket)
+			)::bigint AS usage_seconds,
+			bu.hourly_cost_micros
+		FROM
+			build_uptime AS bu
+		-- Generate a series of hour buckets for each build.
+		CROSS JOIN
+			generate_series(
+				date_trunc('hour', GREATEST(bu.started_at, (SELECT t FROM latest_start))),
+				-- Subtract 1 μs to avoid creating an extra series.
+				date_trunc('hour', bu.stopped_at - '1 microsecond'::interval),
+				'1 hour'::interval
+			) AS s(hour_bucket)
+	)
+INSERT INTO workspace_cost_usage (
+	start_time,
+	workspace_id,
+	template_id,
+	user_id,
+	organization_id,
+	usage_seconds,
+	cost_micros
+)
 SELECT
-	id, created_at, job_id, transition, type, name, hide, icon, instance_type, daily_cost, module_path
+	start_time,
+	workspace_id,
+	template_id,
+	user_id,
+	organization_id,
+	SUM(usage_seconds) AS usage_seconds,
+	SUM(usage_seconds * hourly_cost_micros / 3600) AS cost_micros
 FROM
-	workspace_resources
+	hourly_uptime
+WHERE
+	usage_seconds > 0
+GROUP BY
+	start_time, workspace_id, template_id, user_id, organization_id
+ON CONFLICT
+	(start_time, workspace_id)
+DO UPDATE
+SET
+	usage_seconds = EXCLUDED.usage_seconds,
+	cost_micros = EXCLUDED.cost_micros;
+`
+
+// This query rolls up the uptime of workspaces into hourly buckets, and prices
+// it with the hourly cost of the resources of the running build. A workspace
+// is running from the completion of a successful start build until the next
+// build of the workspace is created. The last bucket is recomputed on every
+// run, as it may have been incomplete.
+func (q *sqlQuerier) UpsertWorkspaceCostUsage(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, upsertWorkspaceCostUsage)
+	return err
+}
+
+const getLatestWorkspaceDriftCheckByWorkspaceID = `-- name: GetLatestWorkspaceDriftCheckByWorkspaceID :one
+SELECT
+	id, workspace_id, workspace_build_id, job_id, created_at, completed_at, drifted_resources
+FROM
+	workspace_drift_checks
+WHERE
+	workspace_id = $1
+ORDER BY
+	created_at DESC
+LIMIT
+	1
+`
+
+func (q *sqlQuerier) GetLatestWorkspaceDriftCheckByWorkspaceID(ctx context.Context, workspaceID uuid.UUID) (WorkspaceDriftCheck, error) {
+	row := q.db.QueryRowContext(ctx, getLatestWorkspaceDriftCheckByWorkspaceID, workspaceID)
+	var i WorkspaceDriftCheck
+	err := row.Scan(
+		&i.ID,
+		&i.WorkspaceID,
+		&i.WorkspaceBuildID,
+		&i.JobID,
+		&i.CreatedAt,
+		&i.CompletedAt,
+		&i.DriftedResources,
+	)
+	return i, err
+}
+
+const getWorkspaceDriftCheckByJobID = `-- name: GetWorkspaceDriftCheckByJobID :one
+SELECT
+	id, workspace_id, workspace_build_id, job_id, created_at, completed_at, drifted_resources
+FROM
+	workspace_drift_checks
 WHERE
 	job_id = $1
 `
 
-func (q *sqlQuerier) GetWorkspaceResourcesByJobID(ctx context.Context, jobID uuid.UUID) ([]WorkspaceResource, error) {
-	rows, err := q.db.QueryContext(ctx, getWorkspaceResourcesByJobID, jobID)
+func (q *sqlQuerier) GetWorkspaceDriftCheckByJobID(ctx context.Context, jobID uuid.UUID) (WorkspaceDriftCheck, error) {
+	row := q.db.QueryRowContext(ctx, getWorkspaceDriftCheckByJobID, jobID)
+	var i WorkspaceDriftCheck
+	err := row.Scan(
+		&i.ID,
+		&i.WorkspaceID,
+		&i.WorkspaceBuildID,
+		&i.JobID,
+		&i.CreatedAt,
+		&i.CompletedAt,
+		&i.DriftedResources,
+	)
+	return i, err
+}
+
+const getWorkspacesDueForDriftCheck = `-- name: GetWorkspacesDueForDriftCheck :many
+SELECT
+	workspaces.id AS workspace_id,
+	workspace_latest_builds.id AS workspace_build_id
+FROM
+	workspaces
+JOIN
+	templates ON templates.id = workspaces.template_id
+JOIN
+	workspace_latest_builds ON workspace_latest_builds.workspace_id = workspaces.id
+JOIN
+	provisioner_jobs ON provisioner_jobs.id = workspace_latest_builds.job_id
+WHERE
+	NOT workspaces.deleted
+	AND workspaces.dormant_at IS NULL
+	AND workspaces.owner_id != 'c42fdf75-3097-471c-8c33-fb52454d81c0'::uuid -- Exclude prebuilt workspaces.
+	AND templates.drift_check_interval_ms > 0
+	AND workspace_latest_builds.transition = 'start'::workspace_transition
+	AND workspace_latest_builds.job_status = 'succeeded'::provisioner_job_status
+	AND provisioner_jobs.completed_at <= $1::timestamptz - templates.drift_check_interval_ms * INTERVAL '1 millisecond'
+	AND NOT EXISTS (
+		SELECT
+			1
+		FROM
+			workspace_drift_checks
+		JOIN
+			provisioner_jobs drift_check_jobs ON drift_check_jobs.id = workspace_drift_checks.job_id
+		WHERE
+			workspace_drift_checks.workspace_id = workspaces.id
+			AND (
+				drift_check_jobs.completed_at IS NULL
+				OR workspace_drift_checks.created_at > $1::timestamptz - templates.drift_check_interval_ms * INTERVAL '1 millisecond'
+			)
+	)
+`
+
+type GetWorkspacesDueForDriftCheckRow struct {
+	WorkspaceID      uuid.UUID `db:"workspace_id" json:"workspace_id"`
+	WorkspaceBuildID uuid.UUID `db:"workspace_build_id" json:"workspace_build_id"`
+}
+
+// Returns the latest builds of running workspaces whose template checks for
+// drift, when neither the build nor a drift check ran within the interval of
+// the template.
+func (q *sqlQuerier) GetWorkspacesDueForDriftCheck(ctx context.Context, now time.Time) ([]GetWorkspacesDueForDriftCheckRow, error) {
+	rows, err := q.db.QueryContext(ctx, getWorkspacesDueForDriftCheck, now)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []WorkspaceResource
+	var items []GetWorkspacesDueForDriftCheckRow
 	for rows.Next() {
-		var i WorkspaceResource
-		if err := rows.Scan(
-			&i.ID,
-			&i.CreatedAt,
-			&i.JobID,
-			&i.Transition,
-			&i.Type,
-			&i.Name,
-			&i.Hide,
-			&i.Icon,
-			&i.InstanceType,
-			&i.DailyCost,
-			&i.ModulePath,
-		); err != nil {
+		var i GetWorkspacesDueForDriftCheckRow
+		if err := rows.Scan(&i.WorkspaceID, &i.WorkspaceBuildID); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -18175,36 +21572,101 @@ func (q *sqlQuerier) GetWorkspaceResourcesByJobID(ctx context.Context, jobID uui
 	return items, nil
 }
 
-const getWorkspaceResourcesByJobIDs = `-- name: GetWorkspaceResourcesByJobIDs :many
-SELECT
-	id, created_at, job_id, transition, type, name, hide, icon, instance_type, daily_cost, module_path
-FROM
-	workspace_resources
-WHERE
-	job_id = ANY($1 :: uuid [ ])
+const insertWorkspaceDriftCheck = `-- name: InsertWorkspaceDriftCheck :one
+INSERT INTO
+	workspace_drift_checks (id, workspace_id, workspace_build_id, job_id, created_at)
+VALUES
+	($1, $2, $3, $4, $5)
+RETURNING id, workspace_id, workspace_build_id, job_id, created_at, completed_at, drifted_resources
 `
 
-func (q *sqlQuerier) GetWorkspaceResourcesByJobIDs(ctx context.Context, ids []uuid.UUID) ([]WorkspaceResource, error) {
-	rows, err := q.db.QueryContext(ctx, getWorkspaceResourcesByJobIDs, pq.Array(ids))
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []WorkspaceResource
-	for rows.Next() {
-		var i WorkspaceResource
-		if err := rows.Scan(
-			&i.ID,
-			&i.CreatedAt,
-			&i.JobID,
-			&i.Transition,
-			&i.Type,
-			&i.Name,
-			&i.Hide,
-			&i.Icon,
-			&i.InstanceType,
-			&i.DailyCost,
-			&i.ModulePath,
+type InsertWorkspaceDriftCheckParams struct {
+	ID               uuid.UUID `db:"id" json:"id"`
+	WorkspaceID      uuid.UUID `db:"workspace_id" json:"workspace_id"`
+	WorkspaceBuildID uuid.UUID `db:"workspace_build_id" json:"workspace_build_id"`
+	JobID            uuid.UUID `db:"job_id" json:"job_id"`
+	CreatedAt        time.Time `db:"created_at" json:"created_at"`
+}
+
+func (q *sqlQuerier) InsertWorkspaceDriftCheck(ctx context.Context, arg InsertWorkspaceDriftCheckParams) (WorkspaceDriftCheck, error) {
+	row := q.db.QueryRowContext(ctx, insertWorkspaceDriftCheck,
+		arg.ID,
+		arg.WorkspaceID,
+		arg.WorkspaceBuildID,
+		arg.JobID,
+		arg.CreatedAt,
+	)
+	var i WorkspaceDriftCheck
+	err := row.Scan(
+		&i.ID,
+		&i.WorkspaceID,
+		&i.WorkspaceBuildID,
+		&i.JobID,
+		&i.CreatedAt,
+		&i.CompletedAt,
+		&i.DriftedResources,
+	)
+	return i, err
+}
+
+const updateWorkspaceDriftCheckByJobID = `-- name: UpdateWorkspaceDriftCheckByJobID :one
+UPDATE
+	workspace_drift_checks
+SET
+	completed_at = $1,
+	drifted_resources = $2
+WHERE
+	job_id = $3
+RETURNING id, workspace_id, workspace_build_id, job_id, created_at, completed_at, drifted_resources
+`
+
+type UpdateWorkspaceDriftCheckByJobIDParams struct {
+	CompletedAt      sql.NullTime          `db:"completed_at" json:"completed_at"`
+	DriftedResources pqtype.NullRawMessage `db:"drifted_resources" json:"drifted_resources"`
+	JobID            uuid.UUID             `db:"job_id" json:"job_id"`
+}
+
+func (q *sqlQuerier) UpdateWorkspaceDriftCheckByJobID(ctx context.Context, arg UpdateWorkspaceDriftCheckByJobIDParams) (WorkspaceDriftCheck, error) {
+	row := q.db.QueryRowContext(ctx, updateWorkspaceDriftCheckByJobID, arg.CompletedAt, arg.DriftedResources, arg.JobID)
+	var i WorkspaceDriftCheck
+	err := row.Scan(
+		&i.ID,
+		&i.WorkspaceID,
+		&i.WorkspaceBuildID,
+		&i.JobID,
+		&i.CreatedAt,
+		&i.CompletedAt,
+		&i.DriftedResources,
+	)
+	return i, err
+}
+
+const getWorkspaceModulesByJobID = `-- name: GetWorkspaceModulesByJobID :many
+SELECT
+	id, job_id, transition, source, version, key, created_at
+FROM
+	workspace_modules
+WHERE
+	job_id = $1
+`
+
+func (q *sqlQuerier) GetWorkspaceModulesByJobID(ctx context.Context, jobID uuid.UUID) ([]WorkspaceModule, error) {
+	rows, err := q.db.QueryContext(ctx, getWorkspaceModulesByJobID, jobID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WorkspaceModule
+	for rows.Next() {
+		var i WorkspaceModule
+		if err := rows.Scan(
+			&i.ID,
+			&i.JobID,
+			&i.Transition,
+			&i.Source,
+			&i.Version,
+			&i.Key,
+			&i.CreatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -18219,31 +21681,27 @@ func (q *sqlQuerier) GetWorkspaceResourcesByJobIDs(ctx context.Context, ids []uu
 	return items, nil
 }
 
-const getWorkspaceResourcesCreatedAfter = `-- name: GetWorkspaceResourcesCreatedAfter :many
-SELECT id, created_at, job_id, transition, type, name, hide, icon, instance_type, daily_cost, module_path FROM workspace_resources WHERE created_at > $1
+const getWorkspaceModulesCreatedAfter = `-- name: GetWorkspaceModulesCreatedAfter :many
+SELECT id, job_id, transition, source, version, key, created_at FROM workspace_modules WHERE created_at > $1
 `
 
-func (q *sqlQuerier) GetWorkspaceResourcesCreatedAfter(ctx context.Context, createdAt time.Time) ([]WorkspaceResource, error) {
-	rows, err := q.db.QueryContext(ctx, getWorkspaceResourcesCreatedAfter, createdAt)
+func (q *sqlQuerier) GetWorkspaceModulesCreatedAfter(ctx context.Context, createdAt time.Time) ([]WorkspaceModule, error) {
+	rows, err := q.db.QueryContext(ctx, getWorkspaceModulesCreatedAfter, createdAt)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []WorkspaceResource
+	var items []WorkspaceModule
 	for rows.Next() {
-		var i WorkspaceResource
+		var i WorkspaceModule
 		if err := rows.Scan(
 			&i.ID,
-			&i.CreatedAt,
 			&i.JobID,
 			&i.Transition,
-			&i.Type,
-			&i.Name,
-			&i.Hide,
-			&i.Icon,
-			&i.InstanceType,
-			&i.DailyCost,
-			&i.ModulePath,
+			&i.Source,
+			&i.Version,
+			&i.Key,
+			&i.CreatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -18258,41 +21716,57 @@ func (q *sqlQuerier) GetWorkspaceResourcesCreatedAfter(ctx context.Context, crea
 	return items, nil
 }
 
-const insertWorkspaceResource = `-- name: InsertWorkspaceResource :one
+const insertWorkspaceModule = `-- name: InsertWorkspaceModule :one
 INSERT INTO
-	workspace_resources (id, created_at, job_id, transition, type, name, hide, icon, instance_type, daily_cost, module_path)
+	workspace_modules (id, job_id, transition, source, version, key, created_at)
 VALUES
-	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id, created_at, job_id, transition, type, name, hide, icon, instance_type, daily_cost, module_path
+	($1, $2, $3, $4, $5, $6, $7) RETURNING id, job_id, transition, source, version, key, created_at
 `
 
-type InsertWorkspaceResourceParams struct {
-	ID           uuid.UUID           `db:"id" json:"id"`
-	CreatedAt    time.Time           `db:"created_at" json:"created_at"`
-	JobID        uuid.UUID           `db:"job_id" json:"job_id"`
-	Transition   WorkspaceTransition `db:"transition" json:"transition"`
-	Type         string              `db:"type" json:"type"`
-	Name         string              `db:"name" json:"name"`
-	Hide         bool                `db:"hide" json:"hide"`
-	Icon         string              `db:"icon" json:"icon"`
-	InstanceType sql.NullString      `db:"instance_type" json:"instance_type"`
-	DailyCost    int32               `db:"daily_cost" json:"daily_cost"`
-	ModulePath   sql.NullString      `db:"module_path" json:"module_path"`
+type InsertWorkspaceModuleParams struct {
+	ID         uuid.UUID           `db:"id" json:"id"`
+	JobID      uuid.UUID           `db:"job_id" json:"job_id"`
+	Transition WorkspaceTransition `db:"transition" json:"transition"`
+	Source     string              `db:"source" json:"source"`
+	Version    string              `db:"version" json:"version"`
+	Key        string              `db:"key" json:"key"`
+	CreatedAt  time.Time           `db:"created_at" json:"created_at"`
 }
 
-func (q *sqlQuerier) InsertWorkspaceResource(ctx context.Context, arg InsertWorkspaceResourceParams) (WorkspaceResource, error) {
-	row := q.db.QueryRowContext(ctx, insertWorkspaceResource,
+func (q *sqlQuerier) InsertWorkspaceModule(ctx context.Context, arg InsertWorkspaceModuleParams) (WorkspaceModule, error) {
+	row := q.db.QueryRowContext(ctx, insertWorkspaceModule,
 		arg.ID,
-		arg.CreatedAt,
 		arg.JobID,
 		arg.Transition,
-		arg.Type,
-		arg.Name,
-		arg.Hide,
-		arg.Icon,
-		arg.InstanceType,
-		arg.DailyCost,
-		arg.ModulePath,
+		arg.Source,
+		arg.Version,
+		arg.Key,
+		arg.CreatedAt,
+	)
+	var i WorkspaceModule
+	err := row.Scan(
+		&i.ID,
+		&i.JobID,
+		&i.Transition,
+		&i.Source,
+		&i.Version,
+		&i.Key,
+		&i.CreatedAt,
 	)
+	return i, err
+}
+
+const getWorkspaceResourceByID = `-- name: GetWorkspaceResourceByID :one
+SELECT
+	id, created_at, job_id, transition, type, name, hide, icon, instance_type, daily_cost, module_path, hourly_cost_micros
+FROM
+	workspace_resources
+WHERE
+	id = $1
+`
+
+func (q *sqlQuerier) GetWorkspaceResourceByID(ctx context.Context, id uuid.UUID) (WorkspaceResource, error) {
+	row := q.db.QueryRowContext(ctx, getWorkspaceResourceByID, id)
 	var i WorkspaceResource
 	err := row.Scan(
 		&i.ID,
@@ -18306,34 +21780,22 @@ func (q *sqlQuerier) InsertWorkspaceResource(ctx context.Context, arg InsertWork
 		&i.InstanceType,
 		&i.DailyCost,
 		&i.ModulePath,
+		&i.HourlyCostMicros,
 	)
 	return i, err
 }
 
-const insertWorkspaceResourceMetadata = `-- name: InsertWorkspaceResourceMetadata :many
-INSERT INTO
-	workspace_resource_metadata
+const getWorkspaceResourceMetadataByResourceIDs = `-- name: GetWorkspaceResourceMetadataByResourceIDs :many
 SELECT
-	$1 :: uuid AS workspace_resource_id,
-	unnest($2 :: text [ ]) AS key,
-	unnest($3 :: text [ ]) AS value,
-	unnest($4 :: boolean [ ]) AS sensitive RETURNING workspace_resource_id, key, value, sensitive, id
+	workspace_resource_id, key, value, sensitive, id
+FROM
+	workspace_resource_metadata
+WHERE
+	workspace_resource_id = ANY($1 :: uuid [ ]) ORDER BY id ASC
 `
 
-type InsertWorkspaceResourceMetadataParams struct {
-	WorkspaceResourceID uuid.UUID `db:"workspace_resource_id" json:"workspace_resource_id"`
-	Key                 []string  `db:"key" json:"key"`
-	Value               []string  `db:"value" json:"value"`
-	Sensitive           []bool    `db:"sensitive" json:"sensitive"`
-}
-
-func (q *sqlQuerier) InsertWorkspaceResourceMetadata(ctx context.Context, arg InsertWorkspaceResourceMetadataParams) ([]WorkspaceResourceMetadatum, error) {
-	rows, err := q.db.QueryContext(ctx, insertWorkspaceResourceMetadata,
-		arg.WorkspaceResourceID,
-		pq.Array(arg.Key),
-		pq.Array(arg.Value),
-		pq.Array(arg.Sensitive),
-	)
+func (q *sqlQuerier) GetWorkspaceResourceMetadataByResourceIDs(ctx context.Context, ids []uuid.UUID) ([]WorkspaceResourceMetadatum, error) {
+	rows, err := q.db.QueryContext(ctx, getWorkspaceResourceMetadataByResourceIDs, pq.Array(ids))
 	if err != nil {
 		return nil, err
 	}
@@ -18361,63 +21823,337 @@ func (q *sqlQuerier) InsertWorkspaceResourceMetadata(ctx context.Context, arg In
 	return items, nil
 }
 
-const batchUpdateWorkspaceLastUsedAt = `-- name: BatchUpdateWorkspaceLastUsedAt :exec
-UPDATE
-	workspaces
-SET
-	last_used_at = $1
-WHERE
-	id = ANY($2 :: uuid[])
-AND
-  -- Do not overwrite with older data
-  last_used_at < $1
+const getWorkspaceResourceMetadataCreatedAfter = `-- name: GetWorkspaceResourceMetadataCreatedAfter :many
+SELECT workspace_resource_id, key, value, sensitive, id FROM workspace_resource_metadata WHERE workspace_resource_id = ANY(
+	SELECT id FROM workspace_resources WHERE created_at > $1
+)
 `
 
-type BatchUpdateWorkspaceLastUsedAtParams struct {
-	LastUsedAt time.Time   `db:"last_used_at" json:"last_used_at"`
-	IDs        []uuid.UUID `db:"ids" json:"ids"`
-}
-
-func (q *sqlQuerier) BatchUpdateWorkspaceLastUsedAt(ctx context.Context, arg BatchUpdateWorkspaceLastUsedAtParams) error {
-	_, err := q.db.ExecContext(ctx, batchUpdateWorkspaceLastUsedAt, arg.LastUsedAt, pq.Array(arg.IDs))
-	return err
+func (q *sqlQuerier) GetWorkspaceResourceMetadataCreatedAfter(ctx context.Context, createdAt time.Time) ([]WorkspaceResourceMetadatum, error) {
+	rows, err := q.db.QueryContext(ctx, getWorkspaceResourceMetadataCreatedAfter, createdAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WorkspaceResourceMetadatum
+	for rows.Next() {
+		var i WorkspaceResourceMetadatum
+		if err := rows.Scan(
+			&i.WorkspaceResourceID,
+			&i.Key,
+			&i.Value,
+			&i.Sensitive,
+			&i.ID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const batchUpdateWorkspaceNextStartAt = `-- name: BatchUpdateWorkspaceNextStartAt :exec
-UPDATE
-	workspaces
-SET
-	next_start_at = CASE
-		WHEN batch.next_start_at = '0001-01-01 00:00:00+00'::timestamptz THEN NULL
-		ELSE batch.next_start_at
-	END
-FROM (
-	SELECT
-		unnest($1::uuid[]) AS id,
-		unnest($2::timestamptz[]) AS next_start_at
-) AS batch
+const getWorkspaceResourcesByJobID = `-- name: GetWorkspaceResourcesByJobID :many
+SELECT
+	id, created_at, job_id, transition, type, name, hide, icon, instance_type, daily_cost, module_path, hourly_cost_micros
+FROM
+	workspace_resources
 WHERE
-	workspaces.id = batch.id
-`
-
-type BatchUpdateWorkspaceNextStartAtParams struct {
-	IDs          []uuid.UUID `db:"ids" json:"ids"`
-	NextStartAts []time.Time `db:"next_start_ats" json:"next_start_ats"`
-}
-
-func (q *sqlQuerier) BatchUpdateWorkspaceNextStartAt(ctx context.Context, arg BatchUpdateWorkspaceNextStartAtParams) error {
-	_, err := q.db.ExecContext(ctx, batchUpdateWorkspaceNextStartAt, pq.Array(arg.IDs), pq.Array(arg.NextStartAts))
-	return err
-}
-
-const favoriteWorkspace = `-- name: FavoriteWorkspace :exec
-UPDATE workspaces SET favorite = true WHERE id = $1
+	job_id = $1
 `
 
-func (q *sqlQuerier) FavoriteWorkspace(ctx context.Context, id uuid.UUID) error {
-	_, err := q.db.ExecContext(ctx, favoriteWorkspace, id)
-	return err
-}
+func (q *sqlQuerier) GetWorkspaceResourcesByJobID(ctx context.Context, jobID uuid.UUID) ([]WorkspaceResource, error) {
+	rows, err := q.db.QueryContext(ctx, getWorkspaceResourcesByJobID, jobID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WorkspaceResource
+	for rows.Next() {
+		var i WorkspaceResource
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.JobID,
+			&i.Transition,
+			&i.Type,
+			&i.Name,
+			&i.Hide,
+			&i.Icon,
+			&i.InstanceType,
+			&i.DailyCost,
+			&i.ModulePath,
+			&i.HourlyCostMicros,
+			&i.HourlyCostMicros,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getWorkspaceResourcesByJobIDs = `-- name: GetWorkspaceResourcesByJobIDs :many
+SELECT
+	id, created_at, job_id, transition, type, name, hide, icon, instance_type, daily_cost, module_path, hourly_cost_micros
+FROM
+	workspace_resources
+WHERE
+	job_id = ANY($1 :: uuid [ ])
+`
+
+func (q *sqlQuerier) GetWorkspaceResourcesByJobIDs(ctx context.Context, ids []uuid.UUID) ([]WorkspaceResource, error) {
+	rows, err := q.db.QueryContext(ctx, getWorkspaceResourcesByJobIDs, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WorkspaceResource
+	for rows.Next() {
+		var i WorkspaceResource
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.JobID,
+			&i.Transition,
+			&i.Type,
+			&i.Name,
+			&i.Hide,
+			&i.Icon,
+			&i.InstanceType,
+			&i.DailyCost,
+			&i.ModulePath,
+			&i.HourlyCostMicros,
+			&i.HourlyCostMicros,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getWorkspaceResourcesCreatedAfter = `-- name: GetWorkspaceResourcesCreatedAfter :many
+SELECT id, created_at, job_id, transition, type, name, hide, icon, instance_type, daily_cost, module_path, hourly_cost_micros FROM workspace_resources WHERE created_at > $1
+`
+
+func (q *sqlQuerier) GetWorkspaceResourcesCreatedAfter(ctx context.Context, createdAt time.Time) ([]WorkspaceResource, error) {
+	rows, err := q.db.QueryContext(ctx, getWorkspaceResourcesCreatedAfter, createdAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WorkspaceResource
+	for rows.Next() {
+		var i WorkspaceResource
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.JobID,
+			&i.Transition,
+			&i.Type,
+			&i.Name,
+			&i.Hide,
+			&i.Icon,
+			&i.InstanceType,
+			&i.DailyCost,
+			&i.ModulePath,
+			&i.HourlyCostMicros,
+			&i.HourlyCostMicros,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertWorkspaceResource = `-- name: InsertWorkspaceResource :one
+INSERT INTO
+	workspace_resources (id, created_at, job_id, transition, type, name, hide, icon, instance_type, daily_cost, module_path, hourly_cost_micros)
+VALUES
+	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING id, created_at, job_id, transition, type, name, hide, icon, instance_type, daily_cost, module_path, hourly_cost_micros
+`
+
+type InsertWorkspaceResourceParams struct {
+	ID               uuid.UUID           `db:"id" json:"id"`
+	CreatedAt        time.Time           `db:"created_at" json:"created_at"`
+	JobID            uuid.UUID           `db:"job_id" json:"job_id"`
+	Transition       WorkspaceTransition `db:"transition" json:"transition"`
+	Type             string              `db:"type" json:"type"`
+	Name             string              `db:"name" json:"name"`
+	Hide             bool                `db:"hide" json:"hide"`
+	Icon             string              `db:"icon" json:"icon"`
+	InstanceType     sql.NullString      `db:"instance_type" json:"instance_type"`
+	DailyCost        int32               `db:"daily_cost" json:"daily_cost"`
+	ModulePath       sql.NullString      `db:"module_path" json:"module_path"`
+	HourlyCostMicros int64               `db:"hourly_cost_micros" json:"hourly_cost_micros"`
+}
+
+func (q *sqlQuerier) InsertWorkspaceResource(ctx context.Context, arg InsertWorkspaceResourceParams) (WorkspaceResource, error) {
+	row := q.db.QueryRowContext(ctx, insertWorkspaceResource,
+		arg.ID,
+		arg.CreatedAt,
+		arg.JobID,
+		arg.Transition,
+		arg.Type,
+		arg.Name,
+		arg.Hide,
+		arg.Icon,
+		arg.InstanceType,
+		arg.DailyCost,
+		arg.ModulePath,
+		arg.HourlyCostMicros,
+	)
+	var i WorkspaceResource
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.JobID,
+		&i.Transition,
+		&i.Type,
+		&i.Name,
+		&i.Hide,
+		&i.Icon,
+		&i.InstanceType,
+		&i.DailyCost,
+		&i.ModulePath,
+		&i.HourlyCostMicros,
+	)
+	return i, err
+}
+
+const insertWorkspaceResourceMetadata = `-- name: InsertWorkspaceResourceMetadata :many
+INSERT INTO
+	workspace_resource_metadata
+SELECT
+	$1 :: uuid AS workspace_resource_id,
+	unnest($2 :: text [ ]) AS key,
+	unnest($3 :: text [ ]) AS value,
+	unnest($4 :: boolean [ ]) AS sensitive RETURNING workspace_resource_id, key, value, sensitive, id
+`
+
+type InsertWorkspaceResourceMetadataParams struct {
+	WorkspaceResourceID uuid.UUID `db:"workspace_resource_id" json:"workspace_resource_id"`
+	Key                 []string  `db:"key" json:"key"`
+	Value               []string  `db:"value" json:"value"`
+	Sensitive           []bool    `db:"sensitive" json:"sensitive"`
+}
+
+func (q *sqlQuerier) InsertWorkspaceResourceMetadata(ctx context.Context, arg InsertWorkspaceResourceMetadataParams) ([]WorkspaceResourceMetadatum, error) {
+	rows, err := q.db.QueryContext(ctx, insertWorkspaceResourceMetadata,
+		arg.WorkspaceResourceID,
+		pq.Array(arg.Key),
+		pq.Array(arg.Value),
+		pq.Array(arg.Sensitive),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WorkspaceResourceMetadatum
+	for rows.Next() {
+		var i WorkspaceResourceMetadatum
+		if err := rows.Scan(
+			&i.WorkspaceResourceID,
+			&i.Key,
+			&i.Value,
+			&i.Sensitive,
+			&i.ID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const batchUpdateWorkspaceLastUsedAt = `-- name: BatchUpdateWorkspaceLastUsedAt :exec
+UPDATE
+	workspaces
+SET
+	last_used_at = $1
+WHERE
+	id = ANY($2 :: uuid[])
+AND
+  -- Do not overwrite with older data
+  last_used_at < $1
+`
+
+type BatchUpdateWorkspaceLastUsedAtParams struct {
+	LastUsedAt time.Time   `db:"last_used_at" json:"last_used_at"`
+	IDs        []uuid.UUID `db:"ids" json:"ids"`
+}
+
+func (q *sqlQuerier) BatchUpdateWorkspaceLastUsedAt(ctx context.Context, arg BatchUpdateWorkspaceLastUsedAtParams) error {
+	_, err := q.db.ExecContext(ctx, batchUpdateWorkspaceLastUsedAt, arg.LastUsedAt, pq.Array(arg.IDs))
+	return err
+}
+
+const batchUpdateWorkspaceNextStartAt = `-- name: BatchUpdateWorkspaceNextStartAt :exec
+UPDATE
+	workspaces
+SET
+	next_start_at = CASE
+		WHEN batch.next_start_at = '0001-01-01 00:00:00+00'::timestamptz THEN NULL
+		ELSE batch.next_start_at
+	END
+FROM (
+	SELECT
+		unnest($1::uuid[]) AS id,
+		unnest($2::timestamptz[]) AS next_start_at
+) AS batch
+WHERE
+	workspaces.id = batch.id
+`
+
+type BatchUpdateWorkspaceNextStartAtParams struct {
+	IDs          []uuid.UUID `db:"ids" json:"ids"`
+	NextStartAts []time.Time `db:"next_start_ats" json:"next_start_ats"`
+}
+
+func (q *sqlQuerier) BatchUpdateWorkspaceNextStartAt(ctx context.Context, arg BatchUpdateWorkspaceNextStartAtParams) error {
+	_, err := q.db.ExecContext(ctx, batchUpdateWorkspaceNextStartAt, pq.Array(arg.IDs), pq.Array(arg.NextStartAts))
+	return err
+}
+
+const favoriteWorkspace = `-- name: FavoriteWorkspace :exec
+UPDATE workspaces SET favorite = true WHERE id = $1
+`
+
+func (q *sqlQuerier) FavoriteWorkspace(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, favoriteWorkspace, id)
+	return err
+}
 
 const getDeploymentWorkspaceStats = `-- name: GetDeploymentWorkspaceStats :one
 WITH workspaces_with_jobs AS (
@@ -18892,7 +22628,7 @@ LEFT JOIN LATERAL (
 ) latest_build ON TRUE
 LEFT JOIN LATERAL (
 	SELECT
-		id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name, allow_user_cancel_workspace_jobs, allow_user_autostart, allow_user_autostop, failure_ttl, time_til_dormant, time_til_dormant_autodelete, autostop_requirement_days_of_week, autostop_requirement_weeks, autostart_block_days_of_week, require_active_version, deprecated, activity_bump, max_port_sharing_level, use_classic_parameter_flow
+		id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name, allow_user_cancel_workspace_jobs, allow_user_autostart, allow_user_autostop, failure_ttl, time_til_dormant, time_til_dormant_autodelete, autostop_requirement_days_of_week, autostop_requirement_weeks, autostart_block_days_of_week, require_active_version, deprecated, activity_bump, max_port_sharing_level, use_classic_parameter_flow, drift_check_interval_ms, provisioner_version
 	FROM
 		templates
 	WHERE
@@ -19421,6 +23157,95 @@ func (q *sqlQuerier) GetWorkspacesAndAgentsByOwnerID(ctx context.Context, ownerI
 	return items, nil
 }
 
+const getWorkspacesApproachingDeletion = `-- name: GetWorkspacesApproachingDeletion :many
+SELECT
+	w.id AS workspace_id,
+	w.name AS workspace_name,
+	u.username AS owner_username,
+	t.id AS template_id,
+	t.name AS template_name,
+	t.display_name AS template_display_name,
+	t.organization_id AS template_organization_id,
+	t.time_til_dormant,
+	w.last_used_at,
+	w.dormant_at,
+	w.deleting_at
+FROM
+	workspaces w
+JOIN
+	users u ON u.id = w.owner_id
+JOIN
+	templates t ON t.id = w.template_id
+WHERE
+	NOT w.deleted
+	AND NOT t.deleted
+	AND w.owner_id != 'c42fdf75-3097-471c-8c33-fb52454d81c0'::uuid
+	AND (
+		(
+			w.dormant_at IS NOT NULL
+			AND w.deleting_at IS NOT NULL
+			AND w.deleting_at <= $1::timestamptz
+		) OR (
+			w.dormant_at IS NULL
+			AND t.time_til_dormant > 0
+			AND w.last_used_at + (INTERVAL '1 millisecond' * (t.time_til_dormant / 1000000)) <= $1::timestamptz
+		)
+	)
+ORDER BY
+	t.name ASC, w.deleting_at ASC NULLS LAST, w.last_used_at ASC
+`
+
+type GetWorkspacesApproachingDeletionRow struct {
+	WorkspaceID            uuid.UUID    `db:"workspace_id" json:"workspace_id"`
+	WorkspaceName          string       `db:"workspace_name" json:"workspace_name"`
+	OwnerUsername          string       `db:"owner_username" json:"owner_username"`
+	TemplateID             uuid.UUID    `db:"template_id" json:"template_id"`
+	TemplateName           string       `db:"template_name" json:"template_name"`
+	TemplateDisplayName    string       `db:"template_display_name" json:"template_display_name"`
+	TemplateOrganizationID uuid.UUID    `db:"template_organization_id" json:"template_organization_id"`
+	TimeTilDormant         int64        `db:"time_til_dormant" json:"time_til_dormant"`
+	LastUsedAt             time.Time    `db:"last_used_at" json:"last_used_at"`
+	DormantAt              sql.NullTime `db:"dormant_at" json:"dormant_at"`
+	DeletingAt             sql.NullTime `db:"deleting_at" json:"deleting_at"`
+}
+
+// GetWorkspacesApproachingDeletion returns dormant workspaces which will be deleted before the given time, and
+// workspaces which will be marked as dormant before then. Prebuilt workspaces are excluded.
+func (q *sqlQuerier) GetWorkspacesApproachingDeletion(ctx context.Context, before time.Time) ([]GetWorkspacesApproachingDeletionRow, error) {
+	rows, err := q.db.QueryContext(ctx, getWorkspacesApproachingDeletion, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetWorkspacesApproachingDeletionRow
+	for rows.Next() {
+		var i GetWorkspacesApproachingDeletionRow
+		if err := rows.Scan(
+			&i.WorkspaceID,
+			&i.WorkspaceName,
+			&i.OwnerUsername,
+			&i.TemplateID,
+			&i.TemplateName,
+			&i.TemplateDisplayName,
+			&i.TemplateOrganizationID,
+			&i.TimeTilDormant,
+			&i.LastUsedAt,
+			&i.DormantAt,
+			&i.DeletingAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getWorkspacesByTemplateID = `-- name: GetWorkspacesByTemplateID :many
 SELECT id, created_at, updated_at, owner_id, organization_id, template_id, deleted, name, autostart_schedule, ttl, last_used_at, dormant_at, deleting_at, automatic_updates, favorite, next_start_at FROM workspaces WHERE template_id = $1 AND deleted = false
 `
@@ -19990,51 +23815,330 @@ func (q *sqlQuerier) UpdateWorkspacesDormantDeletingAtByTemplateID(ctx context.C
 	return items, nil
 }
 
-const updateWorkspacesTTLByTemplateID = `-- name: UpdateWorkspacesTTLByTemplateID :exec
-UPDATE
-		workspaces
-SET
-		ttl = $2
+const updateWorkspacesTTLByTemplateID = `-- name: UpdateWorkspacesTTLByTemplateID :exec
+UPDATE
+		workspaces
+SET
+		ttl = $2
+WHERE
+		template_id = $1
+`
+
+type UpdateWorkspacesTTLByTemplateIDParams struct {
+	TemplateID uuid.UUID     `db:"template_id" json:"template_id"`
+	Ttl        sql.NullInt64 `db:"ttl" json:"ttl"`
+}
+
+func (q *sqlQuerier) UpdateWorkspacesTTLByTemplateID(ctx context.Context, arg UpdateWorkspacesTTLByTemplateIDParams) error {
+	_, err := q.db.ExecContext(ctx, updateWorkspacesTTLByTemplateID, arg.TemplateID, arg.Ttl)
+	return err
+}
+
+const getWorkspaceAgentScriptsByAgentIDs = `-- name: GetWorkspaceAgentScriptsByAgentIDs :many
+SELECT workspace_agent_id, log_source_id, log_path, created_at, script, cron, start_blocks_login, run_on_start, run_on_stop, timeout_seconds, display_name, id FROM workspace_agent_scripts WHERE workspace_agent_id = ANY($1 :: uuid [ ])
+`
+
+func (q *sqlQuerier) GetWorkspaceAgentScriptsByAgentIDs(ctx context.Context, ids []uuid.UUID) ([]WorkspaceAgentScript, error) {
+	rows, err := q.db.QueryContext(ctx, getWorkspaceAgentScriptsByAgentIDs, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WorkspaceAgentScript
+	for rows.Next() {
+		var i WorkspaceAgentScript
+		if err := rows.Scan(
+			&i.WorkspaceAgentID,
+			&i.LogSourceID,
+			&i.LogPath,
+			&i.CreatedAt,
+			&i.Script,
+			&i.Cron,
+			&i.StartBlocksLogin,
+			&i.RunOnStart,
+			&i.RunOnStop,
+			&i.TimeoutSeconds,
+			&i.DisplayName,
+			&i.ID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertWorkspaceAgentScripts = `-- name: InsertWorkspaceAgentScripts :many
+INSERT INTO
+	workspace_agent_scripts (workspace_agent_id, created_at, log_source_id, log_path, script, cron, start_blocks_login, run_on_start, run_on_stop, timeout_seconds, display_name, id)
+SELECT
+	$1 :: uuid AS workspace_agent_id,
+	$2 :: timestamptz AS created_at,
+	unnest($3 :: uuid [ ]) AS log_source_id,
+	unnest($4 :: text [ ]) AS log_path,
+	unnest($5 :: text [ ]) AS script,
+	unnest($6 :: text [ ]) AS cron,
+	unnest($7 :: boolean [ ]) AS start_blocks_login,
+	unnest($8 :: boolean [ ]) AS run_on_start,
+	unnest($9 :: boolean [ ]) AS run_on_stop,
+	unnest($10 :: integer [ ]) AS timeout_seconds,
+	unnest($11 :: text [ ]) AS display_name,
+	unnest($12 :: uuid [ ]) AS id
+RETURNING workspace_agent_scripts.workspace_agent_id, workspace_agent_scripts.log_source_id, workspace_agent_scripts.log_path, workspace_agent_scripts.created_at, workspace_agent_scripts.script, workspace_agent_scripts.cron, workspace_agent_scripts.start_blocks_login, workspace_agent_scripts.run_on_start, workspace_agent_scripts.run_on_stop, workspace_agent_scripts.timeout_seconds, workspace_agent_scripts.display_name, workspace_agent_scripts.id
+`
+
+type InsertWorkspaceAgentScriptsParams struct {
+	WorkspaceAgentID uuid.UUID   `db:"workspace_agent_id" json:"workspace_agent_id"`
+	CreatedAt        time.Time   `db:"created_at" json:"created_at"`
+	LogSourceID      []uuid.UUID `db:"log_source_id" json:"log_source_id"`
+	LogPath          []string    `db:"log_path" json:"log_path"`
+	Script           []string    `db:"script" json:"script"`
+	Cron             []string    `db:"cron" json:"cron"`
+	StartBlocksLogin []bool      `db:"start_blocks_login" json:"start_blocks_login"`
+	RunOnStart       []bool      `db:"run_on_start" json:"run_on_start"`
+	RunOnStop        []bool      `db:"run_on_stop" json:"run_on_stop"`
+	TimeoutSeconds   []int32     `db:"timeout_seconds" json:"timeout_seconds"`
+	DisplayName      []string    `db:"display_name" json:"display_name"`
+	ID               []uuid.UUID `db:"id" json:"id"`
+}
+
+func (q *sqlQuerier) InsertWorkspaceAgentScripts(ctx context.Context, arg InsertWorkspaceAgentScriptsParams) ([]WorkspaceAgentScript, error) {
+	rows, err := q.db.QueryContext(ctx, insertWorkspaceAgentScripts,
+		arg.WorkspaceAgentID,
+		arg.CreatedAt,
+		pq.Array(arg.LogSourceID),
+		pq.Array(arg.LogPath),
+		pq.Array(arg.Script),
+		pq.Array(arg.Cron),
+		pq.Array(arg.StartBlocksLogin),
+		pq.Array(arg.RunOnStart),
+		pq.Array(arg.RunOnStop),
+		pq.Array(arg.TimeoutSeconds),
+		pq.Array(arg.DisplayName),
+		pq.Array(arg.ID),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WorkspaceAgentScript
+	for rows.Next() {
+		var i WorkspaceAgentScript
+		if err := rows.Scan(
+			&i.WorkspaceAgentID,
+			&i.LogSourceID,
+			&i.LogPath,
+			&i.CreatedAt,
+			&i.Script,
+			&i.Cron,
+			&i.StartBlocksLogin,
+			&i.RunOnStart,
+			&i.RunOnStop,
+			&i.TimeoutSeconds,
+			&i.DisplayName,
+			&i.ID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteOldWorkspaceSnapshots = `-- name: DeleteOldWorkspaceSnapshots :many
+WITH RECURSIVE expired AS (
+	SELECT
+		ranked.id
+	FROM (
+		SELECT
+			id,
+			row_number() OVER (PARTITION BY workspace_id, agent_name ORDER BY created_at DESC, id) AS position
+		FROM
+			workspace_snapshots
+		WHERE
+			parent_id IS NULL
+	) AS ranked
+	WHERE
+		ranked.position > $1 :: int
+	UNION ALL
+	SELECT
+		workspace_snapshots.id
+	FROM
+		workspace_snapshots
+	JOIN
+		expired ON workspace_snapshots.parent_id = expired.id
+),
+deleted AS (
+	DELETE FROM
+		workspace_snapshots
+	WHERE
+		id IN (SELECT expired.id FROM expired)
+	RETURNING
+		storage, object_key
+),
+deleted_files AS (
+	DELETE FROM
+		files
+	WHERE
+		files.id :: text IN (SELECT deleted.object_key FROM deleted WHERE deleted.storage = 'database')
+		-- Snapshot archives are deduplicated by content, so a file with
+		-- identical contents may have been uploaded for something else.
+		AND files.mimetype = 'application/gzip'
+		AND NOT EXISTS (
+			SELECT
+				1
+			FROM
+				workspace_snapshots
+			WHERE
+				workspace_snapshots.storage = 'database'
+				AND workspace_snapshots.object_key = files.id :: text
+				AND workspace_snapshots.id NOT IN (SELECT expired.id FROM expired)
+		)
+)
+SELECT
+	storage, object_key
+FROM
+	deleted
+`
+
+type DeleteOldWorkspaceSnapshotsRow struct {
+	Storage   WorkspaceSnapshotStorage `db:"storage" json:"storage"`
+	ObjectKey string                   `db:"object_key" json:"object_key"`
+}
+
+// Deletes all but the newest @keep_chains full snapshots of each workspace
+// agent, along with the snapshots incremental to them. Archives stored in the
+// database are deleted too, unless a remaining snapshot shares them. Returns
+// the archives of the deleted snapshots.
+func (q *sqlQuerier) DeleteOldWorkspaceSnapshots(ctx context.Context, keepChains int32) ([]DeleteOldWorkspaceSnapshotsRow, error) {
+	rows, err := q.db.QueryContext(ctx, deleteOldWorkspaceSnapshots, keepChains)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []DeleteOldWorkspaceSnapshotsRow
+	for rows.Next() {
+		var i DeleteOldWorkspaceSnapshotsRow
+		if err := rows.Scan(&i.Storage, &i.ObjectKey); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteWorkspaceSnapshotRestore = `-- name: DeleteWorkspaceSnapshotRestore :exec
+DELETE FROM
+	workspace_snapshot_restores
 WHERE
-		template_id = $1
+	workspace_id = $1
 `
 
-type UpdateWorkspacesTTLByTemplateIDParams struct {
-	TemplateID uuid.UUID     `db:"template_id" json:"template_id"`
-	Ttl        sql.NullInt64 `db:"ttl" json:"ttl"`
+func (q *sqlQuerier) DeleteWorkspaceSnapshotRestore(ctx context.Context, workspaceID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteWorkspaceSnapshotRestore, workspaceID)
+	return err
 }
 
-func (q *sqlQuerier) UpdateWorkspacesTTLByTemplateID(ctx context.Context, arg UpdateWorkspacesTTLByTemplateIDParams) error {
-	_, err := q.db.ExecContext(ctx, updateWorkspacesTTLByTemplateID, arg.TemplateID, arg.Ttl)
-	return err
+const getWorkspaceSnapshotByID = `-- name: GetWorkspaceSnapshotByID :one
+SELECT
+	id, workspace_id, agent_name, build_number, parent_id, trigger, storage, object_key, size_bytes, file_count, paths, created_at
+FROM
+	workspace_snapshots
+WHERE
+	id = $1
+`
+
+func (q *sqlQuerier) GetWorkspaceSnapshotByID(ctx context.Context, id uuid.UUID) (WorkspaceSnapshot, error) {
+	row := q.db.QueryRowContext(ctx, getWorkspaceSnapshotByID, id)
+	var i WorkspaceSnapshot
+	err := row.Scan(
+		&i.ID,
+		&i.WorkspaceID,
+		&i.AgentName,
+		&i.BuildNumber,
+		&i.ParentID,
+		&i.Trigger,
+		&i.Storage,
+		&i.ObjectKey,
+		&i.SizeBytes,
+		&i.FileCount,
+		pq.Array(&i.Paths),
+		&i.CreatedAt,
+	)
+	return i, err
 }
 
-const getWorkspaceAgentScriptsByAgentIDs = `-- name: GetWorkspaceAgentScriptsByAgentIDs :many
-SELECT workspace_agent_id, log_source_id, log_path, created_at, script, cron, start_blocks_login, run_on_start, run_on_stop, timeout_seconds, display_name, id FROM workspace_agent_scripts WHERE workspace_agent_id = ANY($1 :: uuid [ ])
+const getWorkspaceSnapshotChain = `-- name: GetWorkspaceSnapshotChain :many
+WITH RECURSIVE chain AS (
+	SELECT
+		workspace_snapshots.id,
+		workspace_snapshots.parent_id,
+		0 AS depth
+	FROM
+		workspace_snapshots
+	WHERE
+		workspace_snapshots.id = $1 :: uuid
+	UNION ALL
+	SELECT
+		parent.id,
+		parent.parent_id,
+		chain.depth + 1
+	FROM
+		workspace_snapshots parent
+	JOIN
+		chain ON parent.id = chain.parent_id
+)
+SELECT
+	workspace_snapshots.id, workspace_snapshots.workspace_id, workspace_snapshots.agent_name, workspace_snapshots.build_number, workspace_snapshots.parent_id, workspace_snapshots.trigger, workspace_snapshots.storage, workspace_snapshots.object_key, workspace_snapshots.size_bytes, workspace_snapshots.file_count, workspace_snapshots.paths, workspace_snapshots.created_at
+FROM
+	workspace_snapshots
+JOIN
+	chain ON chain.id = workspace_snapshots.id
+ORDER BY
+	chain.depth DESC
 `
 
-func (q *sqlQuerier) GetWorkspaceAgentScriptsByAgentIDs(ctx context.Context, ids []uuid.UUID) ([]WorkspaceAgentScript, error) {
-	rows, err := q.db.QueryContext(ctx, getWorkspaceAgentScriptsByAgentIDs, pq.Array(ids))
+// Returns the snapshot and every snapshot it is incremental to, oldest first.
+func (q *sqlQuerier) GetWorkspaceSnapshotChain(ctx context.Context, id uuid.UUID) ([]WorkspaceSnapshot, error) {
+	rows, err := q.db.QueryContext(ctx, getWorkspaceSnapshotChain, id)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []WorkspaceAgentScript
+	var items []WorkspaceSnapshot
 	for rows.Next() {
-		var i WorkspaceAgentScript
+		var i WorkspaceSnapshot
 		if err := rows.Scan(
-			&i.WorkspaceAgentID,
-			&i.LogSourceID,
-			&i.LogPath,
-			&i.CreatedAt,
-			&i.Script,
-			&i.Cron,
-			&i.StartBlocksLogin,
-			&i.RunOnStart,
-			&i.RunOnStop,
-			&i.TimeoutSeconds,
-			&i.DisplayName,
 			&i.ID,
+			&i.WorkspaceID,
+			&i.AgentName,
+			&i.BuildNumber,
+			&i.ParentID,
+			&i.Trigger,
+			&i.Storage,
+			&i.ObjectKey,
+			&i.SizeBytes,
+			&i.FileCount,
+			pq.Array(&i.Paths),
+			&i.CreatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -20049,75 +24153,61 @@ func (q *sqlQuerier) GetWorkspaceAgentScriptsByAgentIDs(ctx context.Context, ids
 	return items, nil
 }
 
-const insertWorkspaceAgentScripts = `-- name: InsertWorkspaceAgentScripts :many
-INSERT INTO
-	workspace_agent_scripts (workspace_agent_id, created_at, log_source_id, log_path, script, cron, start_blocks_login, run_on_start, run_on_stop, timeout_seconds, display_name, id)
+const getWorkspaceSnapshotRestoreByWorkspaceID = `-- name: GetWorkspaceSnapshotRestoreByWorkspaceID :one
 SELECT
-	$1 :: uuid AS workspace_agent_id,
-	$2 :: timestamptz AS created_at,
-	unnest($3 :: uuid [ ]) AS log_source_id,
-	unnest($4 :: text [ ]) AS log_path,
-	unnest($5 :: text [ ]) AS script,
-	unnest($6 :: text [ ]) AS cron,
-	unnest($7 :: boolean [ ]) AS start_blocks_login,
-	unnest($8 :: boolean [ ]) AS run_on_start,
-	unnest($9 :: boolean [ ]) AS run_on_stop,
-	unnest($10 :: integer [ ]) AS timeout_seconds,
-	unnest($11 :: text [ ]) AS display_name,
-	unnest($12 :: uuid [ ]) AS id
-RETURNING workspace_agent_scripts.workspace_agent_id, workspace_agent_scripts.log_source_id, workspace_agent_scripts.log_path, workspace_agent_scripts.created_at, workspace_agent_scripts.script, workspace_agent_scripts.cron, workspace_agent_scripts.start_blocks_login, workspace_agent_scripts.run_on_start, workspace_agent_scripts.run_on_stop, workspace_agent_scripts.timeout_seconds, workspace_agent_scripts.display_name, workspace_agent_scripts.id
+	workspace_id, snapshot_id, after_build_number, requested_by, requested_at
+FROM
+	workspace_snapshot_restores
+WHERE
+	workspace_id = $1
 `
 
-type InsertWorkspaceAgentScriptsParams struct {
-	WorkspaceAgentID uuid.UUID   `db:"workspace_agent_id" json:"workspace_agent_id"`
-	CreatedAt        time.Time   `db:"created_at" json:"created_at"`
-	LogSourceID      []uuid.UUID `db:"log_source_id" json:"log_source_id"`
-	LogPath          []string    `db:"log_path" json:"log_path"`
-	Script           []string    `db:"script" json:"script"`
-	Cron             []string    `db:"cron" json:"cron"`
-	StartBlocksLogin []bool      `db:"start_blocks_login" json:"start_blocks_login"`
-	RunOnStart       []bool      `db:"run_on_start" json:"run_on_start"`
-	RunOnStop        []bool      `db:"run_on_stop" json:"run_on_stop"`
-	TimeoutSeconds   []int32     `db:"timeout_seconds" json:"timeout_seconds"`
-	DisplayName      []string    `db:"display_name" json:"display_name"`
-	ID               []uuid.UUID `db:"id" json:"id"`
+func (q *sqlQuerier) GetWorkspaceSnapshotRestoreByWorkspaceID(ctx context.Context, workspaceID uuid.UUID) (WorkspaceSnapshotRestore, error) {
+	row := q.db.QueryRowContext(ctx, getWorkspaceSnapshotRestoreByWorkspaceID, workspaceID)
+	var i WorkspaceSnapshotRestore
+	err := row.Scan(
+		&i.WorkspaceID,
+		&i.SnapshotID,
+		&i.AfterBuildNumber,
+		&i.RequestedBy,
+		&i.RequestedAt,
+	)
+	return i, err
 }
 
-func (q *sqlQuerier) InsertWorkspaceAgentScripts(ctx context.Context, arg InsertWorkspaceAgentScriptsParams) ([]WorkspaceAgentScript, error) {
-	rows, err := q.db.QueryContext(ctx, insertWorkspaceAgentScripts,
-		arg.WorkspaceAgentID,
-		arg.CreatedAt,
-		pq.Array(arg.LogSourceID),
-		pq.Array(arg.LogPath),
-		pq.Array(arg.Script),
-		pq.Array(arg.Cron),
-		pq.Array(arg.StartBlocksLogin),
-		pq.Array(arg.RunOnStart),
-		pq.Array(arg.RunOnStop),
-		pq.Array(arg.TimeoutSeconds),
-		pq.Array(arg.DisplayName),
-		pq.Array(arg.ID),
-	)
+const getWorkspaceSnapshotsByWorkspaceID = `-- name: GetWorkspaceSnapshotsByWorkspaceID :many
+SELECT
+	id, workspace_id, agent_name, build_number, parent_id, trigger, storage, object_key, size_bytes, file_count, paths, created_at
+FROM
+	workspace_snapshots
+WHERE
+	workspace_id = $1
+ORDER BY
+	created_at DESC
+`
+
+func (q *sqlQuerier) GetWorkspaceSnapshotsByWorkspaceID(ctx context.Context, workspaceID uuid.UUID) ([]WorkspaceSnapshot, error) {
+	rows, err := q.db.QueryContext(ctx, getWorkspaceSnapshotsByWorkspaceID, workspaceID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []WorkspaceAgentScript
+	var items []WorkspaceSnapshot
 	for rows.Next() {
-		var i WorkspaceAgentScript
+		var i WorkspaceSnapshot
 		if err := rows.Scan(
-			&i.WorkspaceAgentID,
-			&i.LogSourceID,
-			&i.LogPath,
-			&i.CreatedAt,
-			&i.Script,
-			&i.Cron,
-			&i.StartBlocksLogin,
-			&i.RunOnStart,
-			&i.RunOnStop,
-			&i.TimeoutSeconds,
-			&i.DisplayName,
 			&i.ID,
+			&i.WorkspaceID,
+			&i.AgentName,
+			&i.BuildNumber,
+			&i.ParentID,
+			&i.Trigger,
+			&i.Storage,
+			&i.ObjectKey,
+			&i.SizeBytes,
+			&i.FileCount,
+			pq.Array(&i.Paths),
+			&i.CreatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -20131,3 +24221,214 @@ func (q *sqlQuerier) InsertWorkspaceAgentScripts(ctx context.Context, arg Insert
 	}
 	return items, nil
 }
+
+const insertWorkspaceSnapshot = `-- name: InsertWorkspaceSnapshot :one
+INSERT INTO
+	workspace_snapshots (
+		id,
+		workspace_id,
+		agent_name,
+		build_number,
+		parent_id,
+		trigger,
+		storage,
+		object_key,
+		size_bytes,
+		file_count,
+		paths,
+		created_at
+	)
+VALUES (
+	$1,
+	$2,
+	$3,
+	$4,
+	$5,
+	$6,
+	$7,
+	$8,
+	$9,
+	$10,
+	$11,
+	$12
+)
+RETURNING id, workspace_id, agent_name, build_number, parent_id, trigger, storage, object_key, size_bytes, file_count, paths, created_at
+`
+
+type InsertWorkspaceSnapshotParams struct {
+	ID          uuid.UUID                `db:"id" json:"id"`
+	WorkspaceID uuid.UUID                `db:"workspace_id" json:"workspace_id"`
+	AgentName   string                   `db:"agent_name" json:"agent_name"`
+	BuildNumber int32                    `db:"build_number" json:"build_number"`
+	ParentID    uuid.NullUUID            `db:"parent_id" json:"parent_id"`
+	Trigger     WorkspaceSnapshotTrigger `db:"trigger" json:"trigger"`
+	Storage     WorkspaceSnapshotStorage `db:"storage" json:"storage"`
+	ObjectKey   string                   `db:"object_key" json:"object_key"`
+	SizeBytes   int64                    `db:"size_bytes" json:"size_bytes"`
+	FileCount   int32                    `db:"file_count" json:"file_count"`
+	Paths       []string                 `db:"paths" json:"paths"`
+	CreatedAt   time.Time                `db:"created_at" json:"created_at"`
+}
+
+func (q *sqlQuerier) InsertWorkspaceSnapshot(ctx context.Context, arg InsertWorkspaceSnapshotParams) (WorkspaceSnapshot, error) {
+	row := q.db.QueryRowContext(ctx, insertWorkspaceSnapshot,
+		arg.ID,
+		arg.WorkspaceID,
+		arg.AgentName,
+		arg.BuildNumber,
+		arg.ParentID,
+		arg.Trigger,
+		arg.Storage,
+		arg.ObjectKey,
+		arg.SizeBytes,
+		arg.FileCount,
+		pq.Array(arg.Paths),
+		arg.CreatedAt,
+	)
+	var i WorkspaceSnapshot
+	err := row.Scan(
+		&i.ID,
+		&i.WorkspaceID,
+		&i.AgentName,
+		&i.BuildNumber,
+		&i.ParentID,
+		&i.Trigger,
+		&i.Storage,
+		&i.ObjectKey,
+		&i.SizeBytes,
+		&i.FileCount,
+		pq.Array(&i.Paths),
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const upsertWorkspaceSnapshotRestore = `-- name: UpsertWorkspaceSnapshotRestore :one
+INSERT INTO
+	workspace_snapshot_restores (
+		workspace_id,
+		snapshot_id,
+		after_build_number,
+		requested_by,
+		requested_at
+	)
+VALUES (
+	$1,
+	$2,
+	$3,
+	$4,
+	$5
+)
+ON CONFLICT (workspace_id) DO UPDATE SET
+	snapshot_id = EXCLUDED.snapshot_id,
+	after_build_number = EXCLUDED.after_build_number,
+	requested_by = EXCLUDED.requested_by,
+	requested_at = EXCLUDED.requested_at
+RETURNING workspace_id, snapshot_id, after_build_number, requested_by, requested_at
+`
+
+type UpsertWorkspaceSnapshotRestoreParams struct {
+	WorkspaceID      uuid.UUID `db:"workspace_id" json:"workspace_id"`
+	SnapshotID       uuid.UUID `db:"snapshot_id" json:"snapshot_id"`
+	AfterBuildNumber int32     `db:"after_build_number" json:"after_build_number"`
+	RequestedBy      uuid.UUID `db:"requested_by" json:"requested_by"`
+	RequestedAt      time.Time `db:"requested_at" json:"requested_at"`
+}
+
+func (q *sqlQuerier) UpsertWorkspaceSnapshotRestore(ctx context.Context, arg UpsertWorkspaceSnapshotRestoreParams) (WorkspaceSnapshotRestore, error) {
+	row := q.db.QueryRowContext(ctx, upsertWorkspaceSnapshotRestore,
+		arg.WorkspaceID,
+		arg.SnapshotID,
+		arg.AfterBuildNumber,
+		arg.RequestedBy,
+		arg.RequestedAt,
+	)
+	var i WorkspaceSnapshotRestore
+	err := row.Scan(
+		&i.WorkspaceID,
+		&i.SnapshotID,
+		&i.AfterBuildNumber,
+		&i.RequestedBy,
+		&i.RequestedAt,
+	)
+	return i, err
+}
+
+const getWorkspaceUpdatePreviewByJobID = `-- name: GetWorkspaceUpdatePreviewByJobID :one
+SELECT
+	job_id, workspace_id, created_at, completed_at, resource_changes
+FROM
+	workspace_update_previews
+WHERE
+	job_id = $1
+`
+
+func (q *sqlQuerier) GetWorkspaceUpdatePreviewByJobID(ctx context.Context, jobID uuid.UUID) (WorkspaceUpdatePreview, error) {
+	row := q.db.QueryRowContext(ctx, getWorkspaceUpdatePreviewByJobID, jobID)
+	var i WorkspaceUpdatePreview
+	err := row.Scan(
+		&i.JobID,
+		&i.WorkspaceID,
+		&i.CreatedAt,
+		&i.CompletedAt,
+		&i.ResourceChanges,
+	)
+	return i, err
+}
+
+const insertWorkspaceUpdatePreview = `-- name: InsertWorkspaceUpdatePreview :one
+INSERT INTO
+	workspace_update_previews (job_id, workspace_id, created_at)
+VALUES
+	($1, $2, $3)
+RETURNING job_id, workspace_id, created_at, completed_at, resource_changes
+`
+
+type InsertWorkspaceUpdatePreviewParams struct {
+	JobID       uuid.UUID `db:"job_id" json:"job_id"`
+	WorkspaceID uuid.UUID `db:"workspace_id" json:"workspace_id"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+}
+
+func (q *sqlQuerier) InsertWorkspaceUpdatePreview(ctx context.Context, arg InsertWorkspaceUpdatePreviewParams) (WorkspaceUpdatePreview, error) {
+	row := q.db.QueryRowContext(ctx, insertWorkspaceUpdatePreview, arg.JobID, arg.WorkspaceID, arg.CreatedAt)
+	var i WorkspaceUpdatePreview
+	err := row.Scan(
+		&i.JobID,
+		&i.WorkspaceID,
+		&i.CreatedAt,
+		&i.CompletedAt,
+		&i.ResourceChanges,
+	)
+	return i, err
+}
+
+const updateWorkspaceUpdatePreviewByJobID = `-- name: UpdateWorkspaceUpdatePreviewByJobID :one
+UPDATE
+	workspace_update_previews
+SET
+	completed_at = $1,
+	resource_changes = $2
+WHERE
+	job_id = $3
+RETURNING job_id, workspace_id, created_at, completed_at, resource_changes
+`
+
+type UpdateWorkspaceUpdatePreviewByJobIDParams struct {
+	CompletedAt     sql.NullTime          `db:"completed_at" json:"completed_at"`
+	ResourceChanges pqtype.NullRawMessage `db:"resource_changes" json:"resource_changes"`
+	JobID           uuid.UUID             `db:"job_id" json:"job_id"`
+}
+
+func (q *sqlQuerier) UpdateWorkspaceUpdatePreviewByJobID(ctx context.Context, arg UpdateWorkspaceUpdatePreviewByJobIDParams) (WorkspaceUpdatePreview, error) {
+	row := q.db.QueryRowContext(ctx, updateWorkspaceUpdatePreviewByJobID, arg.CompletedAt, arg.ResourceChanges, arg.JobID)
+	var i WorkspaceUpdatePreview
+	err := row.Scan(
+		&i.JobID,
+		&i.WorkspaceID,
+		&i.CreatedAt,
+		&i.CompletedAt,
+		&i.ResourceChanges,
+	)
+	return i, err
+}
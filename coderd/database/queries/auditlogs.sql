@@ -237,3 +237,265 @@ WHERE
 	-- Authorize Filter clause will be injected below in CountAuthorizedAuditLogs
 	-- @authorize_filter
 ;
+
+-- name: GetAuditLogsForExport :many
+-- GetAuditLogsForExport returns the audit logs ordered after the cursor of a
+-- streaming sink, in the order of the transactions which inserted them. Audit
+-- logs of transactions which may still be running are held back, so none can
+-- be inserted before the cursor once it advanced past them.
+SELECT
+	sqlc.embed(audit_logs),
+	COALESCE(users.username, '') AS user_username,
+	COALESCE(users.email, '') AS user_email,
+	COALESCE(organizations.name, '') AS organization_name
+FROM
+	audit_logs
+	LEFT JOIN users ON audit_logs.user_id = users.id
+	LEFT JOIN organizations ON audit_logs.organization_id = organizations.id
+WHERE
+	audit_logs.xact_id IS NOT NULL
+	AND (audit_logs.xact_id, audit_logs.id) > (@after_xact_id :: bigint, @after_id :: uuid)
+	AND audit_logs.xact_id < pg_snapshot_xmin(pg_current_snapshot()) :: text :: bigint
+ORDER BY
+	audit_logs.xact_id ASC,
+	audit_logs.id ASC
+LIMIT
+	@limit_opt :: int;
+
+-- name: GetAuditLogExportCursor :one
+SELECT * FROM audit_log_export_cursors WHERE sink = @sink;
+
+-- name: AcquireAuditLogExportCursor :one
+-- AcquireAuditLogExportCursor leases the cursor of a streaming sink to a
+-- replica, unless another replica holds an unexpired lease. A new sink starts
+-- with the audit logs of the transactions which are still running.
+INSERT INTO audit_log_export_cursors (sink, last_xact_id, last_id, updated_at, leased_by, lease_expires_at)
+VALUES (
+	@sink,
+	pg_snapshot_xmin(pg_current_snapshot()) :: text :: bigint,
+	'00000000-0000-0000-0000-000000000000' :: uuid,
+	@now :: timestamptz,
+	@leased_by :: uuid,
+	@lease_expires_at :: timestamptz
+)
+ON CONFLICT (sink) DO UPDATE SET
+	leased_by = EXCLUDED.leased_by,
+	lease_expires_at = EXCLUDED.lease_expires_at
+WHERE
+	audit_log_export_cursors.leased_by IS NULL
+	OR audit_log_export_cursors.leased_by = EXCLUDED.leased_by
+	OR audit_log_export_cursors.lease_expires_at < @now :: timestamptz
+RETURNING *;
+
+-- name: UpdateAuditLogExportCursor :execrows
+-- UpdateAuditLogExportCursor advances the cursor of a streaming sink and
+-- extends the lease, if the replica still holds it.
+UPDATE audit_log_export_cursors
+SET
+	last_xact_id = @last_xact_id,
+	last_id = @last_id,
+	updated_at = @updated_at,
+	lease_expires_at = @lease_expires_at :: timestamptz
+WHERE
+	sink = @sink
+	AND leased_by = @leased_by :: uuid;
+
+-- name: GetAuditLogsByIDs :many
+SELECT * FROM audit_logs WHERE id = ANY(@ids :: uuid [ ]);
+
+-- name: GetLatestAuditLogHash :one
+SELECT * FROM audit_log_hashes ORDER BY sequence DESC LIMIT 1;
+
+-- name: InsertAuditLogHash :exec
+INSERT INTO audit_log_hashes (sequence, audit_log_id, audit_log_time, audit_log_xact_id, previous_hash, hash)
+VALUES (@sequence, @audit_log_id, @audit_log_time, @audit_log_xact_id, @previous_hash, @hash);
+
+-- name: GetAuditLogsToChain :many
+-- GetAuditLogsToChain returns the audit logs ordered after the head of the
+-- hash chain, in the order of the transactions which inserted them. Audit logs
+-- of transactions which may still be running are held back, and audit logs
+-- which are chained already, such as restored ones, are skipped.
+SELECT
+	*
+FROM
+	audit_logs
+WHERE
+	audit_logs.xact_id IS NOT NULL
+	AND (audit_logs.xact_id, audit_logs.id) > (@after_xact_id :: bigint, @after_id :: uuid)
+	AND audit_logs.xact_id < pg_snapshot_xmin(pg_current_snapshot()) :: text :: bigint
+	AND NOT EXISTS (
+		SELECT 1 FROM audit_log_hashes WHERE audit_log_hashes.audit_log_id = audit_logs.id
+	)
+ORDER BY
+	audit_logs.xact_id ASC,
+	audit_logs.id ASC
+LIMIT
+	@limit_opt :: int;
+
+-- name: GetAuditLogHashSequenceRange :one
+-- GetAuditLogHashSequenceRange returns the first and last sequence of the hash
+-- chain entries of the audit logs in a time range. Both are 0 when there are
+-- none.
+SELECT
+	COALESCE(MIN(sequence), 0) :: bigint AS first_sequence,
+	COALESCE(MAX(sequence), 0) :: bigint AS last_sequence
+FROM
+	audit_log_hashes
+WHERE
+	audit_log_time >= @from_time :: timestamptz
+	AND audit_log_time <= @to_time :: timestamptz;
+
+-- name: GetAuditLogHashes :many
+-- GetAuditLogHashes returns the hash chain entries after a sequence, up to and
+-- including the last sequence, in order.
+SELECT
+	*
+FROM
+	audit_log_hashes
+WHERE
+	sequence > @after_sequence :: bigint
+	AND sequence <= @last_sequence :: bigint
+ORDER BY
+	sequence ASC
+LIMIT
+	NULLIF(@limit_opt :: int, 0);
+
+-- name: GetUnchainedAuditLogs :many
+-- GetUnchainedAuditLogs returns the audit logs in a time range which are not
+-- part of the hash chain, although they are ordered before its head.
+SELECT
+	id, time
+FROM
+	audit_logs
+WHERE
+	time >= @from_time :: timestamptz
+	AND time <= @to_time :: timestamptz
+	AND (xact_id IS NULL OR (xact_id, id) <= (@head_xact_id :: bigint, @head_id :: uuid))
+	AND NOT EXISTS (
+		SELECT 1 FROM audit_log_hashes WHERE audit_log_hashes.audit_log_id = audit_logs.id
+	)
+ORDER BY
+	time ASC
+LIMIT
+	NULLIF(@limit_opt :: int, 0);
+
+-- name: InsertAuditLogCheckpoint :exec
+INSERT INTO audit_log_checkpoints (sequence, hash, key_sequence, signature, created_at)
+VALUES (@sequence, @hash, @key_sequence, @signature, @created_at);
+
+-- name: GetLatestAuditLogCheckpoint :one
+SELECT * FROM audit_log_checkpoints ORDER BY sequence DESC LIMIT 1;
+
+-- name: GetAuditLogCheckpoints :many
+-- GetAuditLogCheckpoints returns the checkpoints in a sequence range, in order.
+SELECT
+	*
+FROM
+	audit_log_checkpoints
+WHERE
+	sequence >= @first_sequence :: bigint
+	AND sequence <= @last_sequence :: bigint
+ORDER BY
+	sequence ASC;
+
+-- name: GetExpiredAuditLogs :many
+-- GetExpiredAuditLogs returns the audit logs which outlived their retention
+-- and were not archived yet, oldest first. Retention rules are given as
+-- parallel arrays, where an empty resource type or action matches any. The
+-- most specific matching rule applies, and the default cutoff applies to audit
+-- logs no rule matches. max_cutoff is the latest of all cutoffs, so the scan
+-- is bounded by the time index. Audit logs which weren't delivered to a
+-- streaming sink leased after sink_active_after yet are kept.
+SELECT
+	*
+FROM
+	audit_logs
+WHERE
+	audit_logs.time < @max_cutoff :: timestamptz
+	AND NOT EXISTS (
+		SELECT 1 FROM audit_log_archive_entries WHERE audit_log_archive_entries.audit_log_id = audit_logs.id
+	)
+	AND audit_logs.time < COALESCE(
+		(
+			SELECT
+				rules.cutoff
+			FROM
+				unnest(@rule_resource_types :: text[], @rule_actions :: text[], @rule_cutoffs :: timestamptz[]) AS rules(resource_type, action, cutoff)
+			WHERE
+				(rules.resource_type = '' OR rules.resource_type = audit_logs.resource_type :: text)
+				AND (rules.action = '' OR rules.action = audit_logs.action :: text)
+			ORDER BY
+				rules.resource_type != '' DESC,
+				rules.action != '' DESC
+			LIMIT 1
+		),
+		@default_cutoff :: timestamptz
+	)
+	AND (
+		audit_logs.xact_id IS NULL
+		OR NOT EXISTS (
+			SELECT
+				1
+			FROM
+				audit_log_export_cursors
+			WHERE
+				audit_log_export_cursors.lease_expires_at > @sink_active_after :: timestamptz
+				AND (audit_logs.xact_id, audit_logs.id) > (audit_log_export_cursors.last_xact_id, audit_log_export_cursors.last_id)
+		)
+	)
+ORDER BY
+	audit_logs.time ASC,
+	audit_logs.id ASC
+LIMIT
+	@limit_opt :: int;
+
+-- name: InsertAuditLogArchive :one
+INSERT INTO audit_log_archives (id, created_at, file_name, file_id, oldest_time, newest_time, audit_log_count, sha256)
+VALUES (@id, @created_at, @file_name, @file_id, @oldest_time, @newest_time, @audit_log_count, @sha256)
+RETURNING *;
+
+-- name: InsertAuditLogArchiveEntries :exec
+INSERT INTO audit_log_archive_entries (audit_log_id, archive_id)
+SELECT unnest(@audit_log_ids :: uuid[]), @archive_id :: uuid;
+
+-- name: DeleteAuditLogsByIDs :exec
+DELETE FROM audit_logs WHERE id = ANY(@ids :: uuid[]);
+
+-- name: GetAuditLogArchives :many
+SELECT * FROM audit_log_archives ORDER BY oldest_time DESC, id ASC;
+
+-- name: GetAuditLogArchiveByID :one
+SELECT * FROM audit_log_archives WHERE id = @id;
+
+-- name: UpdateAuditLogArchiveRestoredAt :exec
+UPDATE audit_log_archives SET restored_at = @restored_at WHERE id = @id;
+
+-- name: GetArchivedAuditLogIDs :many
+-- GetArchivedAuditLogIDs returns which of the given audit logs were archived.
+SELECT audit_log_id FROM audit_log_archive_entries WHERE audit_log_id = ANY(@ids :: uuid[]);
+
+-- name: DeleteRestoredAuditLogs :exec
+-- DeleteRestoredAuditLogs deletes the audit logs of the archives restored
+-- before a time. They are still in their archive, so they can be restored
+-- again.
+WITH expired AS (
+	UPDATE
+		audit_log_archives
+	SET
+		restored_at = NULL
+	WHERE
+		restored_at < @restored_before :: timestamptz
+	RETURNING
+		id
+)
+DELETE FROM
+	audit_logs
+WHERE
+	id IN (
+		SELECT
+			audit_log_id
+		FROM
+			audit_log_archive_entries
+		WHERE
+			archive_id IN (SELECT id FROM expired)
+	);
@@ -0,0 +1,57 @@
+-- name: GetTemplateAutostopWindows :many
+SELECT
+	*
+FROM
+	template_autostop_windows
+WHERE
+	template_id = $1
+ORDER BY
+	weekday ASC;
+
+-- name: UpdateTemplateAutostopWindows :exec
+-- Replaces the autostop windows of a template. Weekdays not in the arguments
+-- no longer have a window.
+WITH deleted AS (
+	DELETE FROM
+		template_autostop_windows
+	WHERE
+		template_id = @template_id
+		AND NOT (weekday = ANY(@weekdays :: smallint[]))
+)
+INSERT INTO
+	template_autostop_windows (template_id, weekday, stop_minute)
+SELECT
+	@template_id,
+	unnest(@weekdays :: smallint[]),
+	unnest(@stop_minutes :: integer[])
+ON CONFLICT (template_id, weekday) DO UPDATE SET
+	stop_minute = EXCLUDED.stop_minute;
+
+-- name: GetUserAutostopWindows :many
+SELECT
+	*
+FROM
+	user_autostop_windows
+WHERE
+	user_id = $1
+ORDER BY
+	weekday ASC;
+
+-- name: UpdateUserAutostopWindows :exec
+-- Replaces the autostop windows of a user. Weekdays not in the arguments no
+-- longer have a window.
+WITH deleted AS (
+	DELETE FROM
+		user_autostop_windows
+	WHERE
+		user_id = @user_id
+		AND NOT (weekday = ANY(@weekdays :: smallint[]))
+)
+INSERT INTO
+	user_autostop_windows (user_id, weekday, stop_minute)
+SELECT
+	@user_id,
+	unnest(@weekdays :: smallint[]),
+	unnest(@stop_minutes :: integer[])
+ON CONFLICT (user_id, weekday) DO UPDATE SET
+	stop_minute = EXCLUDED.stop_minute;
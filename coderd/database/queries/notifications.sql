@@ -88,12 +88,75 @@ SELECT
     nt.id                                                                 AS template_id,
     nt.title_template,
     nt.body_template,
+    nt.critical,
     -- preferences
-    (CASE WHEN np.disabled IS NULL THEN false ELSE np.disabled END)::bool AS disabled
+    (CASE WHEN np.disabled IS NULL THEN false ELSE np.disabled END)::bool AS disabled,
+    ndp.digest_interval,
+    -- messages are delivered individually if the user disabled digests
+    (CASE WHEN dnp.disabled IS NULL THEN false ELSE dnp.disabled END)::bool AS digest_disabled,
+    -- schedule
+    uns.timezone,
+    uns.quiet_hours_start,
+    uns.quiet_hours_end,
+    uns.daily_digest_time
 FROM acquired nm
          JOIN notification_templates nt ON nm.notification_template_id = nt.id
          LEFT JOIN notification_preferences AS np
-                   ON (np.user_id = nm.user_id AND np.notification_template_id = nm.notification_template_id);
+                   ON (np.user_id = nm.user_id AND np.notification_template_id = nm.notification_template_id)
+         LEFT JOIN notification_digest_preferences AS ndp
+                   ON (ndp.user_id = nm.user_id AND ndp.notification_template_id = nm.notification_template_id)
+         LEFT JOIN notification_preferences AS dnp
+                   ON (dnp.user_id = nm.user_id AND dnp.notification_template_id = @digest_template_id::uuid)
+         LEFT JOIN user_notification_schedules AS uns ON uns.user_id = nm.user_id;
+
+-- Returns leased messages to the queue without counting a delivery attempt. Pending messages are delivered individually
+-- and batched messages in a digest once deliver_after has passed.
+--
+-- name: BulkDeferNotificationMessages :execrows
+UPDATE notification_messages
+SET updated_at       = NOW(),
+    status           = subquery.status,
+    status_reason    = subquery.status_reason,
+    leased_until     = NULL,
+    next_retry_after = subquery.deliver_after
+FROM (SELECT UNNEST(@ids::uuid[])                             AS id,
+             UNNEST(@statuses::notification_message_status[]) AS status,
+             UNNEST(@status_reasons::text[])                  AS status_reason,
+             UNNEST(@deliver_afters::timestamptz[])           AS deliver_after) AS subquery
+WHERE notification_messages.id = subquery.id;
+
+-- Acquires the lease for batched messages whose digest is due, grouped by recipient and method. If the lease expires
+-- before the digest is enqueued, the messages are acquired by AcquireNotificationMessages and batched again.
+--
+-- name: AcquireNotificationDigestMessages :many
+WITH acquired AS (
+    UPDATE
+        notification_messages
+            SET updated_at = NOW(),
+                status = 'leased'::notification_message_status,
+                status_reason = 'Leased for a digest by notifier ' || sqlc.arg('notifier_id')::uuid,
+                leased_until = NOW() + CONCAT(sqlc.arg('lease_seconds')::int, ' seconds')::interval
+            WHERE id IN (SELECT nm.id
+                         FROM notification_messages AS nm
+                         WHERE nm.status = 'batched'::notification_message_status
+                           AND nm.next_retry_after <= NOW()
+                         ORDER BY nm.user_id, nm.method, nm.created_at
+                             FOR UPDATE OF nm
+                                 SKIP LOCKED
+                         LIMIT sqlc.arg('count'))
+            RETURNING *)
+SELECT nm.id,
+       nm.user_id,
+       nm.method,
+       nm.payload,
+       nm.created_at,
+       nt.name AS template_name,
+       nt.title_template,
+       uns.timezone
+FROM acquired nm
+         JOIN notification_templates nt ON nm.notification_template_id = nt.id
+         LEFT JOIN user_notification_schedules AS uns ON uns.user_id = nm.user_id
+ORDER BY nm.user_id, nm.method, nm.created_at;
 
 -- name: BulkMarkNotificationMessagesFailed :execrows
 UPDATE notification_messages
@@ -214,3 +277,150 @@ WHERE user_id = @user_id AND endpoint = @endpoint;
 -- keypair will no longer be valid and all existing subscriptions will need to
 -- be recreated.
 TRUNCATE TABLE webpush_subscriptions;
+
+-- name: GetUserChatIdentities :many
+SELECT *
+FROM user_chat_identities
+WHERE user_id = @user_id::uuid
+ORDER BY method::text;
+
+-- name: GetUserChatIdentity :one
+SELECT *
+FROM user_chat_identities
+WHERE user_id = @user_id::uuid
+  AND method = @method::notification_method;
+
+-- name: UpsertUserChatIdentity :one
+-- Links the identity, replacing the verification of the previously linked
+-- identity.
+INSERT INTO user_chat_identities (user_id, method, identity, created_at, updated_at, verified_at, verification_code_hash, verification_expires_at)
+VALUES (@user_id::uuid, @method::notification_method, @identity::text, @updated_at::timestamptz, @updated_at::timestamptz,
+	sqlc.narg('verified_at')::timestamptz, sqlc.narg('verification_code_hash')::bytea, sqlc.narg('verification_expires_at')::timestamptz)
+ON CONFLICT (user_id, method) DO UPDATE
+SET identity = EXCLUDED.identity,
+	updated_at = EXCLUDED.updated_at,
+	verified_at = EXCLUDED.verified_at,
+	verification_code_hash = EXCLUDED.verification_code_hash,
+	verification_expires_at = EXCLUDED.verification_expires_at
+RETURNING *;
+
+-- name: VerifyUserChatIdentity :one
+-- Marks the identity as verified if the code matches and has not expired.
+UPDATE user_chat_identities
+SET verified_at = @verified_at::timestamptz,
+	verification_code_hash = NULL,
+	verification_expires_at = NULL
+WHERE user_id = @user_id::uuid
+  AND method = @method::notification_method
+  AND verification_code_hash = @verification_code_hash::bytea
+  AND verification_expires_at > @verified_at::timestamptz
+RETURNING *;
+
+-- name: DeleteUserChatIdentity :exec
+DELETE FROM user_chat_identities
+WHERE user_id = @user_id::uuid
+  AND method = @method::notification_method;
+
+-- name: GetUserNotificationDigestPreferences :many
+SELECT *
+FROM notification_digest_preferences
+WHERE user_id = @user_id::uuid;
+
+-- name: UpdateUserNotificationDigestPreferences :exec
+-- Replaces the digest preferences of the user, templates which are not given
+-- are delivered individually again.
+WITH new_values AS (SELECT UNNEST(@notification_template_ids::uuid[])                      AS notification_template_id,
+                           UNNEST(@digest_intervals::notification_digest_interval[]) AS digest_interval),
+     deleted AS (DELETE FROM notification_digest_preferences
+                 WHERE notification_digest_preferences.user_id = @user_id::uuid
+                   AND notification_digest_preferences.notification_template_id NOT IN (SELECT notification_template_id FROM new_values))
+INSERT
+INTO notification_digest_preferences (user_id, notification_template_id, digest_interval)
+SELECT @user_id::uuid, new_values.notification_template_id, new_values.digest_interval
+FROM new_values
+ON CONFLICT (user_id, notification_template_id) DO UPDATE
+    SET digest_interval = EXCLUDED.digest_interval,
+        updated_at      = CURRENT_TIMESTAMP;
+
+-- name: GetUserNotificationSchedule :one
+SELECT *
+FROM user_notification_schedules
+WHERE user_id = @user_id::uuid;
+
+-- name: UpsertUserNotificationSchedule :one
+INSERT INTO user_notification_schedules (user_id, timezone, quiet_hours_start, quiet_hours_end, daily_digest_time, updated_at)
+VALUES (@user_id, @timezone, sqlc.narg('quiet_hours_start'), sqlc.narg('quiet_hours_end'), @daily_digest_time, @updated_at)
+ON CONFLICT (user_id) DO UPDATE
+    SET timezone          = EXCLUDED.timezone,
+        quiet_hours_start = EXCLUDED.quiet_hours_start,
+        quiet_hours_end   = EXCLUDED.quiet_hours_end,
+        daily_digest_time = EXCLUDED.daily_digest_time,
+        updated_at        = EXCLUDED.updated_at
+RETURNING *;
+
+-- name: InsertNotificationRule :one
+INSERT INTO notification_rules (
+	id,
+	organization_id,
+	user_id,
+	name,
+	source,
+	query,
+	method,
+	threshold,
+	window_seconds,
+	enabled,
+	created_at,
+	updated_at,
+	last_evaluated_at
+) VALUES (
+	@id,
+	@organization_id,
+	@user_id,
+	@name,
+	@source,
+	@query,
+	@method,
+	@threshold,
+	@window_seconds,
+	@enabled,
+	@created_at,
+	@created_at,
+	@created_at
+)
+RETURNING *;
+
+-- name: GetNotificationRuleByID :one
+SELECT * FROM notification_rules WHERE id = @id::uuid;
+
+-- name: GetNotificationRulesByOrganizationID :many
+SELECT * FROM notification_rules WHERE organization_id = @organization_id::uuid ORDER BY name ASC;
+
+-- name: GetEnabledNotificationRules :many
+SELECT * FROM notification_rules WHERE enabled ORDER BY organization_id, name;
+
+-- name: UpdateNotificationRule :one
+UPDATE notification_rules
+SET
+	name = @name,
+	query = @query,
+	method = @method,
+	threshold = @threshold,
+	window_seconds = @window_seconds,
+	enabled = @enabled,
+	updated_at = @updated_at,
+	-- Events which happened while a rule was disabled are not evaluated once it is enabled again.
+	last_evaluated_at = CASE WHEN NOT enabled AND @enabled::boolean THEN @updated_at ELSE last_evaluated_at END
+WHERE id = @id
+RETURNING *;
+
+-- name: DeleteNotificationRule :exec
+DELETE FROM notification_rules WHERE id = @id::uuid;
+
+-- name: UpdateNotificationRuleEvaluation :exec
+UPDATE notification_rules
+SET
+	last_evaluated_at = @last_evaluated_at,
+	last_triggered_at = COALESCE(sqlc.narg('last_triggered_at'), last_triggered_at),
+	enabled = COALESCE(sqlc.narg('enabled'), enabled)
+WHERE id = @id;
@@ -0,0 +1,32 @@
+-- name: GetOrganizationHolidays :many
+-- Returns the holidays of an organization on or after a date.
+SELECT
+	*
+FROM
+	organization_holidays
+WHERE
+	organization_id = @organization_id
+	AND date >= @after :: date
+ORDER BY
+	date ASC,
+	calendar ASC;
+
+-- name: UpdateOrganizationHolidayCalendar :exec
+-- Replaces the holidays of a calendar of an organization. Passing no holidays
+-- removes the calendar.
+WITH deleted AS (
+	DELETE FROM
+		organization_holidays
+	WHERE
+		organization_id = @organization_id
+		AND calendar = @calendar
+)
+INSERT INTO
+	organization_holidays (id, organization_id, calendar, date, name, created_at)
+SELECT
+	unnest(@ids :: uuid[]),
+	@organization_id,
+	@calendar,
+	unnest(@dates :: date[]),
+	unnest(@names :: text[]),
+	@created_at :: timestamptz;
@@ -0,0 +1,50 @@
+-- name: GetPlanPoliciesByOrganizationID :many
+SELECT
+	*
+FROM
+	plan_policies
+WHERE
+	organization_id = @organization_id
+ORDER BY
+	name ASC;
+
+-- name: GetPlanPolicyByID :one
+SELECT
+	*
+FROM
+	plan_policies
+WHERE
+	id = @id;
+
+-- name: GetPlanPolicyByOrganizationAndName :one
+SELECT
+	*
+FROM
+	plan_policies
+WHERE
+	organization_id = @organization_id
+	AND name = @name;
+
+-- name: InsertPlanPolicy :one
+INSERT INTO
+	plan_policies (id, organization_id, name, description, module, created_at, updated_at)
+VALUES
+	(@id, @organization_id, @name, @description, @module, @created_at, @updated_at)
+RETURNING *;
+
+-- name: UpdatePlanPolicyByID :one
+UPDATE
+	plan_policies
+SET
+	description = @description,
+	module = @module,
+	updated_at = @updated_at
+WHERE
+	id = @id
+RETURNING *;
+
+-- name: DeletePlanPolicyByID :exec
+DELETE FROM
+	plan_policies
+WHERE
+	id = @id;
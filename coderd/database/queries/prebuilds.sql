@@ -188,3 +188,37 @@ INNER JOIN organizations o ON o.id = w.organization_id
 WHERE NOT t.deleted AND wpb.build_number = 1
 GROUP BY t.name, tvp.name, o.name
 ORDER BY t.name, tvp.name, o.name;
+
+-- GetPrebuildPoolHealth reports, for each preset with a prebuild configuration on an active template version,
+-- how many prebuilt workspaces are running and ready compared to the desired instances, along with the number
+-- of prebuild builds which failed since the given time.
+-- name: GetPrebuildPoolHealth :many
+SELECT
+		t.id                          AS template_id,
+		t.name                        AS template_name,
+		t.display_name                AS template_display_name,
+		t.organization_id             AS template_organization_id,
+		tvp.id                        AS preset_id,
+		tvp.name                      AS preset_name,
+		tvp.desired_instances::int    AS desired_instances,
+		tvp.prebuild_status,
+		COUNT(p.id)::int              AS running,
+		COUNT(p.id) FILTER (WHERE p.ready)::int AS ready,
+		(
+			SELECT COUNT(*)
+			FROM workspace_prebuild_builds wpb
+					INNER JOIN provisioner_jobs pj ON pj.id = wpb.job_id
+			WHERE wpb.template_version_preset_id = tvp.id
+				AND pj.job_status = 'failed'::provisioner_job_status
+				AND pj.created_at >= @since::timestamptz
+		)::int                        AS failed_builds
+FROM templates t
+		INNER JOIN template_version_presets tvp ON tvp.template_version_id = t.active_version_id
+		LEFT JOIN workspace_latest_builds b ON b.template_version_preset_id = tvp.id
+			AND b.transition = 'start'::workspace_transition
+			AND b.job_status = 'succeeded'::provisioner_job_status
+		LEFT JOIN workspace_prebuilds p ON p.id = b.workspace_id AND p.current_preset_id = tvp.id
+WHERE tvp.desired_instances IS NOT NULL -- Consider only presets that have a prebuild configuration.
+	AND NOT t.deleted
+GROUP BY t.id, tvp.id
+ORDER BY t.name, tvp.name;
@@ -0,0 +1,65 @@
+-- name: InsertProviderMirrorArchive :execrows
+-- InsertProviderMirrorArchive never replaces a package that is in the mirror
+-- already. It inserts no rows if the package exists.
+INSERT INTO
+	provider_mirror_archives (organization_id, hostname, namespace, type, version, platform, hash, file_id, created_at)
+VALUES
+	(@organization_id, @hostname, @namespace, @type, @version, @platform, @hash, @file_id, @created_at)
+ON CONFLICT (organization_id, hostname, namespace, type, version, platform) DO NOTHING;
+
+-- name: GetProviderMirrorArchives :many
+-- GetProviderMirrorArchives returns the hash of the file of every archive, but
+-- not its data, as the archives can be large.
+SELECT
+	provider_mirror_archives.*,
+	files.hash AS file_hash
+FROM
+	provider_mirror_archives
+JOIN
+	files ON files.id = provider_mirror_archives.file_id
+WHERE
+	provider_mirror_archives.organization_id = @organization_id
+	AND provider_mirror_archives.hostname = @hostname
+	AND provider_mirror_archives.namespace = @namespace
+	AND provider_mirror_archives.type = @type
+ORDER BY
+	provider_mirror_archives.version, provider_mirror_archives.platform;
+
+-- name: GetProviderMirrorArchiveFileByHash :one
+-- GetProviderMirrorArchiveFileByHash only returns files that are archives of
+-- the provider mirror of the organization, so that the mirror never serves
+-- other files.
+SELECT
+	files.*
+FROM
+	files
+WHERE
+	files.hash = @hash
+	AND EXISTS (
+		SELECT
+			1
+		FROM
+			provider_mirror_archives
+		WHERE
+			provider_mirror_archives.file_id = files.id
+			AND provider_mirror_archives.organization_id = @organization_id
+	)
+LIMIT
+	1;
+
+-- name: UpsertProviderMirrorToken :exec
+INSERT INTO
+	provider_mirror_tokens (job_id, hashed_secret, created_at)
+VALUES
+	(@job_id, @hashed_secret, @created_at)
+ON CONFLICT (job_id) DO UPDATE SET
+	hashed_secret = EXCLUDED.hashed_secret,
+	created_at = EXCLUDED.created_at;
+
+-- name: GetProviderMirrorTokenByJobID :one
+SELECT
+	*
+FROM
+	provider_mirror_tokens
+WHERE
+	job_id = @job_id;
@@ -26,6 +26,31 @@ WHERE
 			-- they are aliases and the code that calls this query already relies on a different type
 			AND provisioner_tagset_contains(@provisioner_tags :: jsonb, potential_job.tags :: jsonb)
 		ORDER BY
+			-- Jobs of a higher priority class are acquired first.
+			potential_job.priority DESC,
+			-- Share the provisioner daemons fairly within a priority class, so
+			-- a single user or template can't monopolize them. Jobs whose
+			-- initiator, then template, has the fewest running jobs go first.
+			(
+				SELECT
+					COUNT(*)
+				FROM
+					provisioner_jobs AS running_job
+				WHERE
+					running_job.initiator_id = potential_job.initiator_id
+					AND running_job.started_at IS NOT NULL
+					AND running_job.completed_at IS NULL
+			) ASC,
+			(
+				SELECT
+					COUNT(*)
+				FROM
+					provisioner_jobs AS running_job
+				WHERE
+					running_job.template_id = potential_job.template_id
+					AND running_job.started_at IS NOT NULL
+					AND running_job.completed_at IS NULL
+			) ASC,
 			potential_job.created_at
 		FOR UPDATE
 		SKIP LOCKED
@@ -74,7 +99,7 @@ WITH filtered_provisioner_jobs AS (
 pending_jobs AS (
 	-- Step 2: Extract only pending jobs
 	SELECT
-		id, created_at, tags
+		id, created_at, priority, tags
 	FROM
 		provisioner_jobs
 	WHERE
@@ -89,8 +114,11 @@ ranked_jobs AS (
 	SELECT
 		pj.id,
 		pj.created_at,
-		ROW_NUMBER() OVER (PARTITION BY opd.id ORDER BY pj.created_at ASC) AS queue_position,
-		COUNT(*) OVER (PARTITION BY opd.id) AS queue_size
+		-- See AcquireProvisionerJob, jobs of a higher priority are acquired
+		-- first.
+		ROW_NUMBER() OVER (PARTITION BY opd.id ORDER BY pj.priority DESC, pj.created_at ASC) AS queue_position,
+		COUNT(*) OVER (PARTITION BY opd.id) AS queue_size,
+		opd.id AS provisioner_daemon_id
 	FROM
 		pending_jobs pj
 			INNER JOIN online_provisioner_daemons opd
@@ -102,21 +130,37 @@ final_jobs AS (
 		fpj.id,
 		fpj.created_at,
 		COALESCE(MIN(rj.queue_position), 0) :: BIGINT AS queue_position, -- Best queue position across provisioners
-		COALESCE(MAX(rj.queue_size), 0) :: BIGINT AS queue_size -- Max queue size across provisioners
+		COALESCE(MAX(rj.queue_size), 0) :: BIGINT AS queue_size, -- Max queue size across provisioners
+		COUNT(rj.provisioner_daemon_id) :: BIGINT AS eligible_provisioners -- Online provisioners that can acquire the job
 	FROM
 		filtered_provisioner_jobs fpj -- Use the pre-filtered dataset instead of full provisioner_jobs
 			LEFT JOIN ranked_jobs rj
 					ON fpj.id = rj.id -- Join with the ranking jobs CTE to assign a rank to each specified provisioner job.
 	GROUP BY
 		fpj.id, fpj.created_at
+),
+average_duration AS (
+	-- Step 5: Average duration of the jobs completed in the last day, to
+	-- estimate the wait of pending jobs
+	SELECT
+		COALESCE(AVG(EXTRACT(EPOCH FROM (completed_at - started_at)) * 1000), 0) :: BIGINT AS ms
+	FROM
+		provisioner_jobs
+	WHERE
+		completed_at >= NOW() - INTERVAL '1 day'
+		AND started_at IS NOT NULL
 )
 SELECT
-	-- Step 5: Final SELECT with INNER JOIN provisioner_jobs
+	-- Step 6: Final SELECT with INNER JOIN provisioner_jobs
 	fj.id,
 	fj.created_at,
 	sqlc.embed(pj),
 	fj.queue_position,
-	fj.queue_size
+	fj.queue_size,
+	-- Only computed for pending jobs, the average is not needed otherwise. The
+	-- eligible provisioners work through the queue in parallel. Jobs with a
+	-- queue position have at least one.
+	(CASE WHEN fj.queue_position > 0 THEN fj.queue_position * (SELECT ms FROM average_duration) / fj.eligible_provisioners ELSE 0 END) :: BIGINT AS estimated_wait_ms
 FROM
 	final_jobs fj
 		INNER JOIN provisioner_jobs pj
@@ -128,7 +172,7 @@ ORDER BY
 -- name: GetProvisionerJobsByOrganizationAndStatusWithQueuePositionAndProvisioner :many
 WITH pending_jobs AS (
     SELECT
-        id, created_at
+        id, created_at, priority
     FROM
         provisioner_jobs
     WHERE
@@ -143,17 +187,44 @@ WITH pending_jobs AS (
 queue_position AS (
     SELECT
         id,
-        ROW_NUMBER() OVER (ORDER BY created_at ASC) AS queue_position
+        ROW_NUMBER() OVER (ORDER BY priority DESC, created_at ASC) AS queue_position
     FROM
         pending_jobs
 ),
 queue_size AS (
 	SELECT COUNT(*) AS count FROM pending_jobs
+),
+average_duration AS (
+	-- See GetProvisionerJobsByIDsWithQueuePosition.
+	SELECT
+		COALESCE(AVG(EXTRACT(EPOCH FROM (completed_at - started_at)) * 1000), 0) :: BIGINT AS ms
+	FROM
+		provisioner_jobs
+	WHERE
+		completed_at >= NOW() - INTERVAL '1 day'
+		AND started_at IS NOT NULL
+),
+online_provisioner_daemons AS (
+	SELECT organization_id, provisioners, tags FROM provisioner_daemons pd
+	WHERE pd.last_seen_at IS NOT NULL AND pd.last_seen_at >= (NOW() - (@stale_interval_ms::bigint || ' ms')::interval)
 )
 SELECT
 	sqlc.embed(pj),
     COALESCE(qp.queue_position, 0) AS queue_position,
     COALESCE(qs.count, 0) AS queue_size,
+	-- The online provisioners that can acquire the job work through the queue
+	-- in parallel.
+	(CASE WHEN qp.queue_position > 0 THEN qp.queue_position * (SELECT ms FROM average_duration) / GREATEST((
+		SELECT
+			COUNT(*)
+		FROM
+			online_provisioner_daemons opd
+		WHERE
+			-- See AcquireProvisionerJob.
+			pj.organization_id = opd.organization_id
+			AND pj.provisioner = ANY(opd.provisioners)
+			AND provisioner_tagset_contains(opd.tags, pj.tags)
+	), 1) ELSE 0 END) :: BIGINT AS estimated_wait_ms,
 	-- Use subquery to utilize ORDER BY in array_agg since it cannot be
 	-- combined with FILTER.
 	(
@@ -231,6 +302,132 @@ LIMIT
 -- name: GetProvisionerJobsCreatedAfter :many
 SELECT * FROM provisioner_jobs WHERE created_at > $1;
 
+-- name: GetProvisionerQueueStats :many
+-- Gets the autoscaling signals of each set of tags of the online provisioner
+-- daemons and pending jobs, and of the requested tags. Like
+-- AcquireProvisionerJob, pending jobs count towards every set of tags that
+-- contains their tags.
+WITH online_daemons AS (
+	SELECT
+		id, organization_id, tags
+	FROM
+		provisioner_daemons
+	WHERE
+		last_seen_at IS NOT NULL
+		AND last_seen_at >= (NOW() - (@stale_interval_ms::bigint || ' ms')::interval)
+		AND CASE
+			WHEN @organization_id :: uuid != '00000000-0000-0000-0000-000000000000'::uuid THEN organization_id = @organization_id
+			ELSE true
+		END
+),
+pending_jobs AS (
+	SELECT
+		organization_id, tags, created_at
+	FROM
+		provisioner_jobs
+	WHERE
+		job_status = 'pending'
+		AND CASE
+			WHEN @organization_id :: uuid != '00000000-0000-0000-0000-000000000000'::uuid THEN organization_id = @organization_id
+			ELSE true
+		END
+),
+started_jobs AS (
+	SELECT
+		organization_id, tags, EXTRACT(EPOCH FROM (started_at - created_at)) * 1000 AS wait_ms
+	FROM
+		provisioner_jobs
+	WHERE
+		started_at >= @started_after
+		AND CASE
+			WHEN @organization_id :: uuid != '00000000-0000-0000-0000-000000000000'::uuid THEN organization_id = @organization_id
+			ELSE true
+		END
+),
+tag_sets AS (
+	SELECT organization_id, tags FROM online_daemons
+	UNION
+	SELECT organization_id, tags FROM pending_jobs
+	UNION
+	-- The requested tags, e.g. of daemons that were scaled to zero.
+	SELECT @organization_id :: uuid, @tags :: tagset
+	WHERE
+		@organization_id :: uuid != '00000000-0000-0000-0000-000000000000'::uuid
+		AND @tags :: tagset != '{}' :: tagset
+)
+SELECT
+	ts.organization_id,
+	ts.tags :: tagset AS tags,
+	(
+		SELECT
+			COUNT(*)
+		FROM
+			pending_jobs pj
+		WHERE
+			pj.organization_id = ts.organization_id
+			AND provisioner_tagset_contains(ts.tags :: tagset, pj.tags :: tagset)
+	) :: BIGINT AS pending_jobs,
+	(
+		SELECT
+			COALESCE(EXTRACT(EPOCH FROM (NOW() - MIN(pj.created_at))) * 1000, 0)
+		FROM
+			pending_jobs pj
+		WHERE
+			pj.organization_id = ts.organization_id
+			AND provisioner_tagset_contains(ts.tags :: tagset, pj.tags :: tagset)
+	) :: BIGINT AS oldest_pending_job_ms,
+	-- Jobs run by any daemon with the tags, even one that went stale.
+	(
+		SELECT
+			COUNT(*)
+		FROM
+			provisioner_jobs rj
+			INNER JOIN provisioner_daemons pd ON pd.id = rj.worker_id
+		WHERE
+			rj.job_status = 'running'
+			AND pd.organization_id = ts.organization_id
+			AND pd.tags = ts.tags
+	) :: BIGINT AS running_jobs,
+	(
+		SELECT
+			COUNT(*)
+		FROM
+			online_daemons od
+		WHERE
+			od.organization_id = ts.organization_id
+			AND od.tags = ts.tags
+	) :: BIGINT AS daemons,
+	(
+		SELECT
+			COUNT(*)
+		FROM
+			online_daemons od
+		WHERE
+			od.organization_id = ts.organization_id
+			AND od.tags = ts.tags
+			AND EXISTS (
+				SELECT 1 FROM provisioner_jobs rj WHERE rj.worker_id = od.id AND rj.job_status = 'running'
+			)
+	) :: BIGINT AS busy_daemons,
+	COALESCE(w.p50, 0) :: BIGINT AS wait_p50_ms,
+	COALESCE(w.p95, 0) :: BIGINT AS wait_p95_ms,
+	COALESCE(w.p99, 0) :: BIGINT AS wait_p99_ms
+FROM
+	tag_sets ts
+LEFT JOIN LATERAL (
+	SELECT
+		percentile_cont(0.5) WITHIN GROUP (ORDER BY sj.wait_ms) AS p50,
+		percentile_cont(0.95) WITHIN GROUP (ORDER BY sj.wait_ms) AS p95,
+		percentile_cont(0.99) WITHIN GROUP (ORDER BY sj.wait_ms) AS p99
+	FROM
+		started_jobs sj
+	WHERE
+		sj.organization_id = ts.organization_id
+		AND provisioner_tagset_contains(ts.tags :: tagset, sj.tags :: tagset)
+) w ON TRUE
+ORDER BY
+	ts.organization_id, ts.tags :: text;
+
 -- name: InsertProvisionerJob :one
 INSERT INTO
 	provisioner_jobs (
@@ -245,10 +442,12 @@ INSERT INTO
 		"type",
 		"input",
 		tags,
-		trace_metadata
+		trace_metadata,
+		priority,
+		template_id
 	)
 VALUES
-	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING *;
+	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) RETURNING *;
 
 -- name: UpdateProvisionerJobByID :exec
 UPDATE
@@ -0,0 +1,37 @@
+-- name: GetTemplatePortShareRules :many
+SELECT
+	*
+FROM
+	template_port_share_rules
+WHERE
+	template_id = $1
+ORDER BY
+	port_start ASC;
+
+-- name: InsertTemplatePortShareRule :one
+INSERT INTO
+	template_port_share_rules (
+		id,
+		template_id,
+		port_start,
+		port_end,
+		share_level,
+		protocol,
+		label
+	)
+VALUES (
+	$1,
+	$2,
+	$3,
+	$4,
+	$5,
+	$6,
+	$7
+)
+RETURNING *;
+
+-- name: DeleteTemplatePortShareRules :exec
+DELETE FROM
+	template_port_share_rules
+WHERE
+	template_id = $1;
@@ -134,7 +134,9 @@ SET
 	allow_user_cancel_workspace_jobs = $7,
 	group_acl = $8,
 	max_port_sharing_level = $9,
-	use_classic_parameter_flow = $10
+	use_classic_parameter_flow = $10,
+	drift_check_interval_ms = $11,
+	provisioner_version = $12
 WHERE
 	id = $1
 ;
@@ -209,3 +211,34 @@ SET
 WHERE
 	id = $1
 ;
+
+-- name: GetTemplateVersionAdoption :many
+-- GetTemplateVersionAdoption counts the workspaces of every template by the template version of their latest build.
+SELECT
+	t.id AS template_id,
+	t.name AS template_name,
+	t.display_name AS template_display_name,
+	t.organization_id AS template_organization_id,
+	tv.id AS template_version_id,
+	tv.name AS template_version_name,
+	tv.id = t.active_version_id AS active,
+	atv.name AS active_version_name,
+	COUNT(w.id)::int AS workspace_count
+FROM
+	workspaces w
+JOIN
+	workspace_latest_builds wlb ON wlb.workspace_id = w.id
+JOIN
+	templates t ON t.id = w.template_id
+JOIN
+	template_versions tv ON tv.id = wlb.template_version_id
+JOIN
+	template_versions atv ON atv.id = t.active_version_id
+WHERE
+	NOT w.deleted
+	AND NOT t.deleted
+	AND w.owner_id != 'c42fdf75-3097-471c-8c33-fb52454d81c0'::uuid -- Exclude prebuilt workspaces.
+GROUP BY
+	t.id, tv.id, atv.id
+ORDER BY
+	t.name ASC, workspace_count DESC, tv.name ASC;
@@ -31,14 +31,18 @@ INSERT INTO
 		agent_name,
 		port,
 		share_level,
-		protocol
+		protocol,
+		auto_shared,
+		label
 	)
 VALUES (
 	$1,
 	$2,
 	$3,
 	$4,
-	$5
+	$5,
+	$6,
+	$7
 )
 ON CONFLICT (
 	workspace_id,
@@ -47,7 +51,9 @@ ON CONFLICT (
 )
 DO UPDATE SET
 	share_level = $4,
-	protocol = $5
+	protocol = $5,
+	auto_shared = $6,
+	label = $7
 RETURNING *;
 
 -- name: ReduceWorkspaceAgentShareLevelToAuthenticatedByTemplate :exec
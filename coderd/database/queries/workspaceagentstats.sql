@@ -379,3 +379,49 @@ JOIN
 	workspaces
 ON
 	workspaces.id = agent_stats.workspace_id;
+
+-- name: GetTopWorkspaceResourceConsumers :many
+-- GetTopWorkspaceResourceConsumers returns the workspaces with the most network traffic since the given time,
+-- ranked per organization. The daily cost of the latest build is included as an indication of compute usage.
+WITH workspace_usage AS (
+	SELECT
+		workspace_id,
+		SUM(rx_bytes + tx_bytes)::bigint AS transferred_bytes,
+		SUM(session_count_vscode + session_count_jetbrains + session_count_reconnecting_pty + session_count_ssh)::bigint AS sessions
+	FROM workspace_agent_stats
+	WHERE created_at >= @since::timestamptz
+	GROUP BY workspace_id
+), ranked AS (
+	SELECT
+		w.id AS workspace_id,
+		w.name AS workspace_name,
+		u.username AS owner_username,
+		t.name AS template_name,
+		t.display_name AS template_display_name,
+		o.id AS organization_id,
+		o.name AS organization_name,
+		o.display_name AS organization_display_name,
+		workspace_usage.transferred_bytes,
+		workspace_usage.sessions,
+		COALESCE(lb.daily_cost, 0)::int AS daily_cost,
+		ROW_NUMBER() OVER (PARTITION BY o.id ORDER BY workspace_usage.transferred_bytes DESC, w.name ASC) AS position
+	FROM workspace_usage
+	JOIN workspaces w ON w.id = workspace_usage.workspace_id
+	JOIN users u ON u.id = w.owner_id
+	JOIN templates t ON t.id = w.template_id
+	JOIN organizations o ON o.id = w.organization_id
+	LEFT JOIN LATERAL (
+		SELECT daily_cost
+		FROM workspace_builds
+		WHERE workspace_builds.workspace_id = w.id
+		ORDER BY build_number DESC
+		LIMIT 1
+	) lb ON TRUE
+	WHERE NOT w.deleted
+		AND w.owner_id != 'c42fdf75-3097-471c-8c33-fb52454d81c0'::uuid -- Exclude prebuilt workspaces.
+		AND workspace_usage.transferred_bytes > 0
+)
+SELECT *
+FROM ranked
+WHERE position <= @limit_per_organization::bigint
+ORDER BY organization_name, position;
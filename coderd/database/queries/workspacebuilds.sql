@@ -253,3 +253,61 @@ WHERE
 	AND pj.job_status = 'failed'
 ORDER BY
 	tv.name ASC, wb.build_number DESC;
+
+-- name: GetWorkspaceBuildEvents :many
+-- GetWorkspaceBuildEvents returns workspace builds which completed in the given period, most recent first. The
+-- total number of matching builds is returned on every row, regardless of the limit.
+SELECT
+	wb.id,
+	wb.build_number,
+	wb.transition,
+	wb.reason,
+	pj.job_status,
+	pj.completed_at::timestamptz AS completed_at,
+	w.id AS workspace_id,
+	w.name AS workspace_name,
+	u.username AS workspace_owner_username,
+	t.name AS template_name,
+	COUNT(*) OVER () AS count
+FROM
+	workspace_builds AS wb
+JOIN
+	workspaces AS w ON wb.workspace_id = w.id
+JOIN
+	users AS u ON w.owner_id = u.id
+JOIN
+	templates AS t ON w.template_id = t.id
+JOIN
+	provisioner_jobs AS pj ON wb.job_id = pj.id
+WHERE
+	w.organization_id = @organization_id
+	AND pj.completed_at > @completed_after :: timestamptz
+	AND pj.completed_at <= @completed_before :: timestamptz
+	AND CASE
+		WHEN @template_name :: text != '' THEN t.name = LOWER(@template_name)
+		ELSE true
+	END
+	AND CASE
+		WHEN @workspace_name :: text != '' THEN w.name = LOWER(@workspace_name)
+		ELSE true
+	END
+	AND CASE
+		WHEN @owner_username :: text != '' THEN u.username = LOWER(@owner_username)
+		ELSE true
+	END
+	AND CASE
+		WHEN @transition :: text != '' THEN wb.transition = @transition :: workspace_transition
+		ELSE true
+	END
+	AND CASE
+		WHEN @job_status :: text != '' THEN pj.job_status = @job_status :: provisioner_job_status
+		ELSE true
+	END
+	AND CASE
+		WHEN @build_reason :: text != '' THEN wb.reason = @build_reason :: build_reason
+		ELSE true
+	END
+ORDER BY
+	pj.completed_at DESC
+LIMIT
+	@limit_opt;
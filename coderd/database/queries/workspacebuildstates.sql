@@ -0,0 +1,77 @@
+-- name: UpsertWorkspaceBuildState :exec
+INSERT INTO
+	workspace_build_states (workspace_build_id, workspace_id, state, state_key_id, hash, size, created_at)
+VALUES
+	(@workspace_build_id, @workspace_id, @state, @state_key_id, @hash, @size, @created_at)
+ON CONFLICT (workspace_build_id) DO UPDATE SET
+	state = EXCLUDED.state,
+	state_key_id = EXCLUDED.state_key_id,
+	hash = EXCLUDED.hash,
+	size = EXCLUDED.size,
+	created_at = EXCLUDED.created_at;
+
+-- name: GetWorkspaceBuildStateByBuildID :one
+SELECT
+	*
+FROM
+	workspace_build_states
+WHERE
+	workspace_build_id = @workspace_build_id;
+
+-- name: GetWorkspaceBuildStatesByWorkspaceID :many
+-- GetWorkspaceBuildStatesByWorkspaceID lists the states kept for a workspace,
+-- newest first. The states themselves are left out, they are fetched one at a
+-- time.
+SELECT
+	workspace_build_states.workspace_build_id,
+	workspace_builds.build_number,
+	workspace_builds.transition,
+	workspace_build_states.hash,
+	workspace_build_states.size,
+	workspace_build_states.created_at
+FROM
+	workspace_build_states
+JOIN
+	workspace_builds ON workspace_builds.id = workspace_build_states.workspace_build_id
+WHERE
+	workspace_build_states.workspace_id = @workspace_id
+ORDER BY
+	workspace_builds.build_number DESC;
+
+-- name: GetWorkspaceBuildStateKeyIDs :many
+-- GetWorkspaceBuildStateKeyIDs returns the key every state is encrypted with,
+-- so that states can be re-encrypted when keys are rotated.
+SELECT
+	workspace_build_id,
+	state_key_id
+FROM
+	workspace_build_states
+ORDER BY
+	workspace_build_id;
+
+-- name: DeleteOldWorkspaceBuildStates :execrows
+-- DeleteOldWorkspaceBuildStates deletes the states of a workspace beyond the
+-- newest @keep_count, and the states created before @before. A @keep_count of
+-- zero keeps any number of states. The newest state of every workspace is
+-- always kept.
+DELETE FROM
+	workspace_build_states
+WHERE
+	workspace_build_id IN (
+		SELECT
+			ranked.workspace_build_id
+		FROM (
+			SELECT
+				workspace_build_id,
+				created_at,
+				row_number() OVER (PARTITION BY workspace_id ORDER BY created_at DESC, workspace_build_id) AS position
+			FROM
+				workspace_build_states
+		) AS ranked
+		WHERE
+			ranked.position > 1
+			AND (
+				(@keep_count::int > 0 AND ranked.position > @keep_count::int)
+				OR ranked.created_at < @before::timestamptz
+			)
+	);
@@ -0,0 +1,179 @@
+-- name: UpsertWorkspaceCostUsage :exec
+-- This query rolls up the uptime of workspaces into hourly buckets, and prices
+-- it with the hourly cost of the resources of the running build. A workspace
+-- is running from the completion of a successful start build until the next
+-- build of the workspace is created. The last bucket is recomputed on every
+-- run, as it may have been incomplete.
+WITH
+	latest_start AS (
+		SELECT
+			date_trunc('hour', COALESCE(
+				MAX(start_time) - '1 hour'::interval,
+				-- Fallback when there is no cost usage yet.
+				(SELECT MIN(created_at) FROM workspace_builds)
+			)) AS t
+		FROM
+			workspace_cost_usage
+	),
+	build_uptime AS (
+		SELECT
+			wb.workspace_id,
+			w.template_id,
+			w.owner_id AS user_id,
+			w.organization_id,
+			pj.completed_at AS started_at,
+			COALESCE(next_build.created_at, NOW()) AS stopped_at,
+			(
+				SELECT
+					COALESCE(SUM(wr.hourly_cost_micros), 0)
+				FROM
+					workspace_resources AS wr
+				WHERE
+					wr.job_id = wb.job_id
+					AND wr.transition = 'start'
+			)::bigint AS hourly_cost_micros
+		FROM
+			workspace_builds AS wb
+		JOIN
+			provisioner_jobs AS pj
+		ON
+			pj.id = wb.job_id
+		JOIN
+			workspaces AS w
+		ON
+			w.id = wb.workspace_id
+		LEFT JOIN LATERAL (
+			SELECT
+				nb.created_at
+			FROM
+				workspace_builds AS nb
+			WHERE
+				nb.workspace_id = wb.workspace_id
+				AND nb.build_number > wb.build_number
+			ORDER BY
+				nb.build_number ASC
+			LIMIT 1
+		) AS next_build ON TRUE
+		WHERE
+			wb.transition = 'start'
+			AND pj.job_status = 'succeeded'
+			AND COALESCE(next_build.created_at, NOW()) > (SELECT t FROM latest_start)
+	),
+	hourly_uptime AS (
+		SELECT
+			s.hour_bucket AS start_time,
+			bu.workspace_id,
+			bu.template_id,
+			bu.user_id,
+			bu.organization_id,
+			EXTRACT(EPOCH FROM
+				LEAST(bu.stopped_at, s.hour_bucket + '1 hour'::interval)
+				- GREATEST(bu.started_at, s.hour_bucket)
+			)::bigint AS usage_seconds,
+			bu.hourly_cost_micros
+		FROM
+			build_uptime AS bu
+		-- Generate a series of hour buckets for each build.
+		CROSS JOIN
+			generate_series(
+				date_trunc('hour', GREATEST(bu.started_at, (SELECT t FROM latest_start))),
+				-- Subtract 1 μs to avoid creating an extra series.
+				date_trunc('hour', bu.stopped_at - '1 microsecond'::interval),
+				'1 hour'::interval
+			) AS s(hour_bucket)
+	)
+INSERT INTO workspace_cost_usage (
+	start_time,
+	workspace_id,
+	template_id,
+	user_id,
+	organization_id,
+	usage_seconds,
+	cost_micros
+)
+SELECT
+	start_time,
+	workspace_id,
+	template_id,
+	user_id,
+	organization_id,
+	SUM(usage_seconds) AS usage_seconds,
+	SUM(usage_seconds * hourly_cost_micros / 3600) AS cost_micros
+FROM
+	hourly_uptime
+WHERE
+	usage_seconds > 0
+GROUP BY
+	start_time, workspace_id, template_id, user_id, organization_id
+ON CONFLICT
+	(start_time, workspace_id)
+DO UPDATE
+SET
+	usage_seconds = EXCLUDED.usage_seconds,
+	cost_micros = EXCLUDED.cost_micros;
+
+-- name: GetWorkspaceCostInsights :many
+-- GetWorkspaceCostInsights returns the uptime and cost of workspaces between
+-- the start and end time, per organization, template and user.
+SELECT
+	wcu.organization_id,
+	o.name AS organization_name,
+	wcu.template_id,
+	t.name AS template_name,
+	wcu.user_id,
+	u.username,
+	SUM(wcu.usage_seconds)::bigint AS usage_seconds,
+	SUM(wcu.cost_micros)::bigint AS cost_micros
+FROM
+	workspace_cost_usage AS wcu
+JOIN
+	organizations AS o
+ON
+	o.id = wcu.organization_id
+JOIN
+	templates AS t
+ON
+	t.id = wcu.template_id
+JOIN
+	users AS u
+ON
+	u.id = wcu.user_id
+WHERE
+	wcu.start_time >= @start_time::timestamptz
+	AND wcu.start_time < @end_time::timestamptz
+	AND CASE WHEN COALESCE(array_length(@template_ids::uuid[], 1), 0) > 0 THEN wcu.template_id = ANY(@template_ids::uuid[]) ELSE TRUE END
+GROUP BY
+	wcu.organization_id, o.name, wcu.template_id, t.name, wcu.user_id, u.username
+ORDER BY
+	cost_micros DESC, usage_seconds DESC;
+
+-- name: GetWorkspaceCostInsightsByGroup :many
+-- GetWorkspaceCostInsightsByGroup returns the uptime and cost of workspaces
+-- between the start and end time, per group of their owners. Users can be in
+-- many groups, so the same usage can count towards many groups.
+SELECT
+	gme.group_id,
+	gme.group_name,
+	gme.organization_id,
+	o.name AS organization_name,
+	SUM(wcu.usage_seconds)::bigint AS usage_seconds,
+	SUM(wcu.cost_micros)::bigint AS cost_micros
+FROM
+	workspace_cost_usage AS wcu
+JOIN
+	group_members_expanded AS gme
+ON
+	gme.user_id = wcu.user_id
+	AND gme.organization_id = wcu.organization_id
+JOIN
+	organizations AS o
+ON
+	o.id = gme.organization_id
+WHERE
+	wcu.start_time >= @start_time::timestamptz
+	AND wcu.start_time < @end_time::timestamptz
+	AND CASE WHEN COALESCE(array_length(@template_ids::uuid[], 1), 0) > 0 THEN wcu.template_id = ANY(@template_ids::uuid[]) ELSE TRUE END
+GROUP BY
+	gme.group_id, gme.group_name, gme.organization_id, o.name
+ORDER BY
+	cost_micros DESC, usage_seconds DESC;
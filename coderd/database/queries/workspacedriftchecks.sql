@@ -0,0 +1,74 @@
+-- name: InsertWorkspaceDriftCheck :one
+INSERT INTO
+	workspace_drift_checks (id, workspace_id, workspace_build_id, job_id, created_at)
+VALUES
+	(@id, @workspace_id, @workspace_build_id, @job_id, @created_at)
+RETURNING *;
+
+-- name: GetWorkspaceDriftCheckByJobID :one
+SELECT
+	*
+FROM
+	workspace_drift_checks
+WHERE
+	job_id = @job_id;
+
+-- name: GetLatestWorkspaceDriftCheckByWorkspaceID :one
+SELECT
+	*
+FROM
+	workspace_drift_checks
+WHERE
+	workspace_id = @workspace_id
+ORDER BY
+	created_at DESC
+LIMIT
+	1;
+
+-- name: UpdateWorkspaceDriftCheckByJobID :one
+UPDATE
+	workspace_drift_checks
+SET
+	completed_at = @completed_at,
+	drifted_resources = @drifted_resources
+WHERE
+	job_id = @job_id
+RETURNING *;
+
+-- name: GetWorkspacesDueForDriftCheck :many
+-- Returns the latest builds of running workspaces whose template checks for
+-- drift, when neither the build nor a drift check ran within the interval of
+-- the template.
+SELECT
+	workspaces.id AS workspace_id,
+	workspace_latest_builds.id AS workspace_build_id
+FROM
+	workspaces
+JOIN
+	templates ON templates.id = workspaces.template_id
+JOIN
+	workspace_latest_builds ON workspace_latest_builds.workspace_id = workspaces.id
+JOIN
+	provisioner_jobs ON provisioner_jobs.id = workspace_latest_builds.job_id
+WHERE
+	NOT workspaces.deleted
+	AND workspaces.dormant_at IS NULL
+	AND workspaces.owner_id != 'c42fdf75-3097-471c-8c33-fb52454d81c0'::uuid -- Exclude prebuilt workspaces.
+	AND templates.drift_check_interval_ms > 0
+	AND workspace_latest_builds.transition = 'start'::workspace_transition
+	AND workspace_latest_builds.job_status = 'succeeded'::provisioner_job_status
+	AND provisioner_jobs.completed_at <= @now::timestamptz - templates.drift_check_interval_ms * INTERVAL '1 millisecond'
+	AND NOT EXISTS (
+		SELECT
+			1
+		FROM
+			workspace_drift_checks
+		JOIN
+			provisioner_jobs drift_check_jobs ON drift_check_jobs.id = workspace_drift_checks.job_id
+		WHERE
+			workspace_drift_checks.workspace_id = workspaces.id
+			AND (
+				drift_check_jobs.completed_at IS NULL
+				OR workspace_drift_checks.created_at > @now::timestamptz - templates.drift_check_interval_ms * INTERVAL '1 millisecond'
+			)
+	);
@@ -866,3 +866,42 @@ GROUP BY workspaces.id, workspaces.name, latest_build.job_status, latest_build.j
 
 -- name: GetWorkspacesByTemplateID :many
 SELECT * FROM workspaces WHERE template_id = $1 AND deleted = false;
+
+-- name: GetWorkspacesApproachingDeletion :many
+-- GetWorkspacesApproachingDeletion returns dormant workspaces which will be deleted before the given time, and
+-- workspaces which will be marked as dormant before then. Prebuilt workspaces are excluded.
+SELECT
+	w.id AS workspace_id,
+	w.name AS workspace_name,
+	u.username AS owner_username,
+	t.id AS template_id,
+	t.name AS template_name,
+	t.display_name AS template_display_name,
+	t.organization_id AS template_organization_id,
+	t.time_til_dormant,
+	w.last_used_at,
+	w.dormant_at,
+	w.deleting_at
+FROM
+	workspaces w
+JOIN
+	users u ON u.id = w.owner_id
+JOIN
+	templates t ON t.id = w.template_id
+WHERE
+	NOT w.deleted
+	AND NOT t.deleted
+	AND w.owner_id != 'c42fdf75-3097-471c-8c33-fb52454d81c0'::uuid
+	AND (
+		(
+			w.dormant_at IS NOT NULL
+			AND w.deleting_at IS NOT NULL
+			AND w.deleting_at <= @before::timestamptz
+		) OR (
+			w.dormant_at IS NULL
+			AND t.time_til_dormant > 0
+			AND w.last_used_at + (INTERVAL '1 millisecond' * (t.time_til_dormant / 1000000)) <= @before::timestamptz
+		)
+	)
+ORDER BY
+	t.name ASC, w.deleting_at ASC NULLS LAST, w.last_used_at ASC;
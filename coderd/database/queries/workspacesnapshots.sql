@@ -0,0 +1,175 @@
+-- name: InsertWorkspaceSnapshot :one
+INSERT INTO
+	workspace_snapshots (
+		id,
+		workspace_id,
+		agent_name,
+		build_number,
+		parent_id,
+		trigger,
+		storage,
+		object_key,
+		size_bytes,
+		file_count,
+		paths,
+		created_at
+	)
+VALUES (
+	$1,
+	$2,
+	$3,
+	$4,
+	$5,
+	$6,
+	$7,
+	$8,
+	$9,
+	$10,
+	$11,
+	$12
+)
+RETURNING *;
+
+-- name: GetWorkspaceSnapshotByID :one
+SELECT
+	*
+FROM
+	workspace_snapshots
+WHERE
+	id = $1;
+
+-- name: GetWorkspaceSnapshotsByWorkspaceID :many
+SELECT
+	*
+FROM
+	workspace_snapshots
+WHERE
+	workspace_id = $1
+ORDER BY
+	created_at DESC;
+
+-- name: GetWorkspaceSnapshotChain :many
+-- Returns the snapshot and every snapshot it is incremental to, oldest first.
+WITH RECURSIVE chain AS (
+	SELECT
+		workspace_snapshots.id,
+		workspace_snapshots.parent_id,
+		0 AS depth
+	FROM
+		workspace_snapshots
+	WHERE
+		workspace_snapshots.id = @id :: uuid
+	UNION ALL
+	SELECT
+		parent.id,
+		parent.parent_id,
+		chain.depth + 1
+	FROM
+		workspace_snapshots parent
+	JOIN
+		chain ON parent.id = chain.parent_id
+)
+SELECT
+	workspace_snapshots.*
+FROM
+	workspace_snapshots
+JOIN
+	chain ON chain.id = workspace_snapshots.id
+ORDER BY
+	chain.depth DESC;
+
+-- name: UpsertWorkspaceSnapshotRestore :one
+INSERT INTO
+	workspace_snapshot_restores (
+		workspace_id,
+		snapshot_id,
+		after_build_number,
+		requested_by,
+		requested_at
+	)
+VALUES (
+	$1,
+	$2,
+	$3,
+	$4,
+	$5
+)
+ON CONFLICT (workspace_id) DO UPDATE SET
+	snapshot_id = EXCLUDED.snapshot_id,
+	after_build_number = EXCLUDED.after_build_number,
+	requested_by = EXCLUDED.requested_by,
+	requested_at = EXCLUDED.requested_at
+RETURNING *;
+
+-- name: GetWorkspaceSnapshotRestoreByWorkspaceID :one
+SELECT
+	*
+FROM
+	workspace_snapshot_restores
+WHERE
+	workspace_id = $1;
+
+-- name: DeleteWorkspaceSnapshotRestore :exec
+DELETE FROM
+	workspace_snapshot_restores
+WHERE
+	workspace_id = $1;
+
+-- name: DeleteOldWorkspaceSnapshots :many
+-- Deletes all but the newest @keep_chains full snapshots of each workspace
+-- agent, along with the snapshots incremental to them. Archives stored in the
+-- database are deleted too, unless a remaining snapshot shares them. Returns
+-- the archives of the deleted snapshots.
+WITH RECURSIVE expired AS (
+	SELECT
+		ranked.id
+	FROM (
+		SELECT
+			id,
+			row_number() OVER (PARTITION BY workspace_id, agent_name ORDER BY created_at DESC, id) AS position
+		FROM
+			workspace_snapshots
+		WHERE
+			parent_id IS NULL
+	) AS ranked
+	WHERE
+		ranked.position > @keep_chains :: int
+	UNION ALL
+	SELECT
+		workspace_snapshots.id
+	FROM
+		workspace_snapshots
+	JOIN
+		expired ON workspace_snapshots.parent_id = expired.id
+),
+deleted AS (
+	DELETE FROM
+		workspace_snapshots
+	WHERE
+		id IN (SELECT expired.id FROM expired)
+	RETURNING
+		storage, object_key
+),
+deleted_files AS (
+	DELETE FROM
+		files
+	WHERE
+		files.id :: text IN (SELECT deleted.object_key FROM deleted WHERE deleted.storage = 'database')
+		-- Snapshot archives are deduplicated by content, so a file with
+		-- identical contents may have been uploaded for something else.
+		AND files.mimetype = 'application/gzip'
+		AND NOT EXISTS (
+			SELECT
+				1
+			FROM
+				workspace_snapshots
+			WHERE
+				workspace_snapshots.storage = 'database'
+				AND workspace_snapshots.object_key = files.id :: text
+				AND workspace_snapshots.id NOT IN (SELECT expired.id FROM expired)
+		)
+)
+SELECT
+	storage, object_key
+FROM
+	deleted;
@@ -0,0 +1,24 @@
+-- name: InsertWorkspaceUpdatePreview :one
+INSERT INTO
+	workspace_update_previews (job_id, workspace_id, created_at)
+VALUES
+	(@job_id, @workspace_id, @created_at)
+RETURNING *;
+
+-- name: GetWorkspaceUpdatePreviewByJobID :one
+SELECT
+	*
+FROM
+	workspace_update_previews
+WHERE
+	job_id = @job_id;
+
+-- name: UpdateWorkspaceUpdatePreviewByJobID :one
+UPDATE
+	workspace_update_previews
+SET
+	completed_at = @completed_at,
+	resource_changes = @resource_changes
+WHERE
+	job_id = @job_id
+RETURNING *;
@@ -8,6 +8,11 @@ type UniqueConstraint string
 const (
 	UniqueAgentStatsPkey                                      UniqueConstraint = "agent_stats_pkey"                                                // ALTER TABLE ONLY workspace_agent_stats ADD CONSTRAINT agent_stats_pkey PRIMARY KEY (id);
 	UniqueAPIKeysPkey                                         UniqueConstraint = "api_keys_pkey"                                                   // ALTER TABLE ONLY api_keys ADD CONSTRAINT api_keys_pkey PRIMARY KEY (id);
+	UniqueAuditLogArchiveEntriesPkey                          UniqueConstraint = "audit_log_archive_entries_pkey"                                  // ALTER TABLE ONLY audit_log_archive_entries ADD CONSTRAINT audit_log_archive_entries_pkey PRIMARY KEY (audit_log_id);
+	UniqueAuditLogArchivesPkey                                UniqueConstraint = "audit_log_archives_pkey"                                         // ALTER TABLE ONLY audit_log_archives ADD CONSTRAINT audit_log_archives_pkey PRIMARY KEY (id);
+	UniqueAuditLogCheckpointsPkey                             UniqueConstraint = "audit_log_checkpoints_pkey"                                      // ALTER TABLE ONLY audit_log_checkpoints ADD CONSTRAINT audit_log_checkpoints_pkey PRIMARY KEY (sequence);
+	UniqueAuditLogExportCursorsPkey                           UniqueConstraint = "audit_log_export_cursors_pkey"                                   // ALTER TABLE ONLY audit_log_export_cursors ADD CONSTRAINT audit_log_export_cursors_pkey PRIMARY KEY (sink);
+	UniqueAuditLogHashesPkey                                  UniqueConstraint = "audit_log_hashes_pkey"                                           // ALTER TABLE ONLY audit_log_hashes ADD CONSTRAINT audit_log_hashes_pkey PRIMARY KEY (sequence);
 	UniqueAuditLogsPkey                                       UniqueConstraint = "audit_logs_pkey"                                                 // ALTER TABLE ONLY audit_logs ADD CONSTRAINT audit_logs_pkey PRIMARY KEY (id);
 	UniqueCryptoKeysPkey                                      UniqueConstraint = "crypto_keys_pkey"                                                // ALTER TABLE ONLY crypto_keys ADD CONSTRAINT crypto_keys_pkey PRIMARY KEY (feature, sequence);
 	UniqueCustomRolesUniqueKey                                UniqueConstraint = "custom_roles_unique_key"                                         // ALTER TABLE ONLY custom_roles ADD CONSTRAINT custom_roles_unique_key UNIQUE (name, organization_id);
@@ -25,9 +30,12 @@ const (
 	UniqueJfrogXrayScansPkey                                  UniqueConstraint = "jfrog_xray_scans_pkey"                                           // ALTER TABLE ONLY jfrog_xray_scans ADD CONSTRAINT jfrog_xray_scans_pkey PRIMARY KEY (agent_id, workspace_id);
 	UniqueLicensesJWTKey                                      UniqueConstraint = "licenses_jwt_key"                                                // ALTER TABLE ONLY licenses ADD CONSTRAINT licenses_jwt_key UNIQUE (jwt);
 	UniqueLicensesPkey                                        UniqueConstraint = "licenses_pkey"                                                   // ALTER TABLE ONLY licenses ADD CONSTRAINT licenses_pkey PRIMARY KEY (id);
+	UniqueNotificationDigestPreferencesPkey                   UniqueConstraint = "notification_digest_preferences_pkey"                            // ALTER TABLE ONLY notification_digest_preferences ADD CONSTRAINT notification_digest_preferences_pkey PRIMARY KEY (user_id, notification_template_id);
 	UniqueNotificationMessagesPkey                            UniqueConstraint = "notification_messages_pkey"                                      // ALTER TABLE ONLY notification_messages ADD CONSTRAINT notification_messages_pkey PRIMARY KEY (id);
 	UniqueNotificationPreferencesPkey                         UniqueConstraint = "notification_preferences_pkey"                                   // ALTER TABLE ONLY notification_preferences ADD CONSTRAINT notification_preferences_pkey PRIMARY KEY (user_id, notification_template_id);
 	UniqueNotificationReportGeneratorLogsPkey                 UniqueConstraint = "notification_report_generator_logs_pkey"                         // ALTER TABLE ONLY notification_report_generator_logs ADD CONSTRAINT notification_report_generator_logs_pkey PRIMARY KEY (notification_template_id);
+	UniqueNotificationRulesOrganizationIDNameKey              UniqueConstraint = "notification_rules_organization_id_name_key"                     // ALTER TABLE ONLY notification_rules ADD CONSTRAINT notification_rules_organization_id_name_key UNIQUE (organization_id, name);
+	UniqueNotificationRulesPkey                               UniqueConstraint = "notification_rules_pkey"                                         // ALTER TABLE ONLY notification_rules ADD CONSTRAINT notification_rules_pkey PRIMARY KEY (id);
 	UniqueNotificationTemplatesNameKey                        UniqueConstraint = "notification_templates_name_key"                                 // ALTER TABLE ONLY notification_templates ADD CONSTRAINT notification_templates_name_key UNIQUE (name);
 	UniqueNotificationTemplatesPkey                           UniqueConstraint = "notification_templates_pkey"                                     // ALTER TABLE ONLY notification_templates ADD CONSTRAINT notification_templates_pkey PRIMARY KEY (id);
 	UniqueOauth2ProviderAppCodesPkey                          UniqueConstraint = "oauth2_provider_app_codes_pkey"                                  // ALTER TABLE ONLY oauth2_provider_app_codes ADD CONSTRAINT oauth2_provider_app_codes_pkey PRIMARY KEY (id);
@@ -38,12 +46,17 @@ const (
 	UniqueOauth2ProviderAppTokensPkey                         UniqueConstraint = "oauth2_provider_app_tokens_pkey"                                 // ALTER TABLE ONLY oauth2_provider_app_tokens ADD CONSTRAINT oauth2_provider_app_tokens_pkey PRIMARY KEY (id);
 	UniqueOauth2ProviderAppsNameKey                           UniqueConstraint = "oauth2_provider_apps_name_key"                                   // ALTER TABLE ONLY oauth2_provider_apps ADD CONSTRAINT oauth2_provider_apps_name_key UNIQUE (name);
 	UniqueOauth2ProviderAppsPkey                              UniqueConstraint = "oauth2_provider_apps_pkey"                                       // ALTER TABLE ONLY oauth2_provider_apps ADD CONSTRAINT oauth2_provider_apps_pkey PRIMARY KEY (id);
+	UniqueOrganizationHolidaysPkey                            UniqueConstraint = "organization_holidays_pkey"                                      // ALTER TABLE ONLY organization_holidays ADD CONSTRAINT organization_holidays_pkey PRIMARY KEY (id);
 	UniqueOrganizationMembersPkey                             UniqueConstraint = "organization_members_pkey"                                       // ALTER TABLE ONLY organization_members ADD CONSTRAINT organization_members_pkey PRIMARY KEY (organization_id, user_id);
 	UniqueOrganizationsPkey                                   UniqueConstraint = "organizations_pkey"                                              // ALTER TABLE ONLY organizations ADD CONSTRAINT organizations_pkey PRIMARY KEY (id);
 	UniqueParameterSchemasJobIDNameKey                        UniqueConstraint = "parameter_schemas_job_id_name_key"                               // ALTER TABLE ONLY parameter_schemas ADD CONSTRAINT parameter_schemas_job_id_name_key UNIQUE (job_id, name);
 	UniqueParameterSchemasPkey                                UniqueConstraint = "parameter_schemas_pkey"                                          // ALTER TABLE ONLY parameter_schemas ADD CONSTRAINT parameter_schemas_pkey PRIMARY KEY (id);
 	UniqueParameterValuesPkey                                 UniqueConstraint = "parameter_values_pkey"                                           // ALTER TABLE ONLY parameter_values ADD CONSTRAINT parameter_values_pkey PRIMARY KEY (id);
 	UniqueParameterValuesScopeIDNameKey                       UniqueConstraint = "parameter_values_scope_id_name_key"                              // ALTER TABLE ONLY parameter_values ADD CONSTRAINT parameter_values_scope_id_name_key UNIQUE (scope_id, name);
+	UniquePlanPoliciesOrganizationIDNameKey                   UniqueConstraint = "plan_policies_organization_id_name_key"                          // ALTER TABLE ONLY plan_policies ADD CONSTRAINT plan_policies_organization_id_name_key UNIQUE (organization_id, name);
+	UniquePlanPoliciesPkey                                    UniqueConstraint = "plan_policies_pkey"                                              // ALTER TABLE ONLY plan_policies ADD CONSTRAINT plan_policies_pkey PRIMARY KEY (id);
+	UniqueProviderMirrorArchivesPkey                          UniqueConstraint = "provider_mirror_archives_pkey"                                   // ALTER TABLE ONLY provider_mirror_archives ADD CONSTRAINT provider_mirror_archives_pkey PRIMARY KEY (organization_id, hostname, namespace, type, version, platform);
+	UniqueProviderMirrorTokensPkey                            UniqueConstraint = "provider_mirror_tokens_pkey"                                     // ALTER TABLE ONLY provider_mirror_tokens ADD CONSTRAINT provider_mirror_tokens_pkey PRIMARY KEY (job_id);
 	UniqueProvisionerDaemonsPkey                              UniqueConstraint = "provisioner_daemons_pkey"                                        // ALTER TABLE ONLY provisioner_daemons ADD CONSTRAINT provisioner_daemons_pkey PRIMARY KEY (id);
 	UniqueProvisionerJobLogsPkey                              UniqueConstraint = "provisioner_job_logs_pkey"                                       // ALTER TABLE ONLY provisioner_job_logs ADD CONSTRAINT provisioner_job_logs_pkey PRIMARY KEY (id);
 	UniqueProvisionerJobsPkey                                 UniqueConstraint = "provisioner_jobs_pkey"                                           // ALTER TABLE ONLY provisioner_jobs ADD CONSTRAINT provisioner_jobs_pkey PRIMARY KEY (id);
@@ -56,6 +69,8 @@ const (
 	UniqueTailnetPeersPkey                                    UniqueConstraint = "tailnet_peers_pkey"                                              // ALTER TABLE ONLY tailnet_peers ADD CONSTRAINT tailnet_peers_pkey PRIMARY KEY (id, coordinator_id);
 	UniqueTailnetTunnelsPkey                                  UniqueConstraint = "tailnet_tunnels_pkey"                                            // ALTER TABLE ONLY tailnet_tunnels ADD CONSTRAINT tailnet_tunnels_pkey PRIMARY KEY (coordinator_id, src_id, dst_id);
 	UniqueTelemetryItemsPkey                                  UniqueConstraint = "telemetry_items_pkey"                                            // ALTER TABLE ONLY telemetry_items ADD CONSTRAINT telemetry_items_pkey PRIMARY KEY (key);
+	UniqueTemplateAutostopWindowsPkey                         UniqueConstraint = "template_autostop_windows_pkey"                                  // ALTER TABLE ONLY template_autostop_windows ADD CONSTRAINT template_autostop_windows_pkey PRIMARY KEY (template_id, weekday);
+	UniqueTemplatePortShareRulesPkey                          UniqueConstraint = "template_port_share_rules_pkey"                                  // ALTER TABLE ONLY template_port_share_rules ADD CONSTRAINT template_port_share_rules_pkey PRIMARY KEY (id);
 	UniqueTemplateUsageStatsPkey                              UniqueConstraint = "template_usage_stats_pkey"                                       // ALTER TABLE ONLY template_usage_stats ADD CONSTRAINT template_usage_stats_pkey PRIMARY KEY (start_time, template_id, user_id);
 	UniqueTemplateVersionParametersTemplateVersionIDNameKey   UniqueConstraint = "template_version_parameters_template_version_id_name_key"        // ALTER TABLE ONLY template_version_parameters ADD CONSTRAINT template_version_parameters_template_version_id_name_key UNIQUE (template_version_id, name);
 	UniqueTemplateVersionPresetParametersPkey                 UniqueConstraint = "template_version_preset_parameters_pkey"                         // ALTER TABLE ONLY template_version_preset_parameters ADD CONSTRAINT template_version_preset_parameters_pkey PRIMARY KEY (id);
@@ -67,9 +82,12 @@ const (
 	UniqueTemplateVersionsPkey                                UniqueConstraint = "template_versions_pkey"                                          // ALTER TABLE ONLY template_versions ADD CONSTRAINT template_versions_pkey PRIMARY KEY (id);
 	UniqueTemplateVersionsTemplateIDNameKey                   UniqueConstraint = "template_versions_template_id_name_key"                          // ALTER TABLE ONLY template_versions ADD CONSTRAINT template_versions_template_id_name_key UNIQUE (template_id, name);
 	UniqueTemplatesPkey                                       UniqueConstraint = "templates_pkey"                                                  // ALTER TABLE ONLY templates ADD CONSTRAINT templates_pkey PRIMARY KEY (id);
+	UniqueUserAutostopWindowsPkey                             UniqueConstraint = "user_autostop_windows_pkey"                                      // ALTER TABLE ONLY user_autostop_windows ADD CONSTRAINT user_autostop_windows_pkey PRIMARY KEY (user_id, weekday);
+	UniqueUserChatIdentitiesPkey                              UniqueConstraint = "user_chat_identities_pkey"                                       // ALTER TABLE ONLY user_chat_identities ADD CONSTRAINT user_chat_identities_pkey PRIMARY KEY (user_id, method);
 	UniqueUserConfigsPkey                                     UniqueConstraint = "user_configs_pkey"                                               // ALTER TABLE ONLY user_configs ADD CONSTRAINT user_configs_pkey PRIMARY KEY (user_id, key);
 	UniqueUserDeletedPkey                                     UniqueConstraint = "user_deleted_pkey"                                               // ALTER TABLE ONLY user_deleted ADD CONSTRAINT user_deleted_pkey PRIMARY KEY (id);
 	UniqueUserLinksPkey                                       UniqueConstraint = "user_links_pkey"                                                 // ALTER TABLE ONLY user_links ADD CONSTRAINT user_links_pkey PRIMARY KEY (user_id, login_type);
+	UniqueUserNotificationSchedulesPkey                       UniqueConstraint = "user_notification_schedules_pkey"                                // ALTER TABLE ONLY user_notification_schedules ADD CONSTRAINT user_notification_schedules_pkey PRIMARY KEY (user_id);
 	UniqueUserStatusChangesPkey                               UniqueConstraint = "user_status_changes_pkey"                                        // ALTER TABLE ONLY user_status_changes ADD CONSTRAINT user_status_changes_pkey PRIMARY KEY (id);
 	UniqueUsersPkey                                           UniqueConstraint = "users_pkey"                                                      // ALTER TABLE ONLY users ADD CONSTRAINT users_pkey PRIMARY KEY (id);
 	UniqueWebpushSubscriptionsPkey                            UniqueConstraint = "webpush_subscriptions_pkey"                                      // ALTER TABLE ONLY webpush_subscriptions ADD CONSTRAINT webpush_subscriptions_pkey PRIMARY KEY (id);
@@ -91,16 +109,25 @@ const (
 	UniqueWorkspaceAppsAgentIDSlugIndex                       UniqueConstraint = "workspace_apps_agent_id_slug_idx"                                // ALTER TABLE ONLY workspace_apps ADD CONSTRAINT workspace_apps_agent_id_slug_idx UNIQUE (agent_id, slug);
 	UniqueWorkspaceAppsPkey                                   UniqueConstraint = "workspace_apps_pkey"                                             // ALTER TABLE ONLY workspace_apps ADD CONSTRAINT workspace_apps_pkey PRIMARY KEY (id);
 	UniqueWorkspaceBuildParametersWorkspaceBuildIDNameKey     UniqueConstraint = "workspace_build_parameters_workspace_build_id_name_key"          // ALTER TABLE ONLY workspace_build_parameters ADD CONSTRAINT workspace_build_parameters_workspace_build_id_name_key UNIQUE (workspace_build_id, name);
+	UniqueWorkspaceBuildStatesPkey                            UniqueConstraint = "workspace_build_states_pkey"                                     // ALTER TABLE ONLY workspace_build_states ADD CONSTRAINT workspace_build_states_pkey PRIMARY KEY (workspace_build_id);
 	UniqueWorkspaceBuildsJobIDKey                             UniqueConstraint = "workspace_builds_job_id_key"                                     // ALTER TABLE ONLY workspace_builds ADD CONSTRAINT workspace_builds_job_id_key UNIQUE (job_id);
 	UniqueWorkspaceBuildsPkey                                 UniqueConstraint = "workspace_builds_pkey"                                           // ALTER TABLE ONLY workspace_builds ADD CONSTRAINT workspace_builds_pkey PRIMARY KEY (id);
 	UniqueWorkspaceBuildsWorkspaceIDBuildNumberKey            UniqueConstraint = "workspace_builds_workspace_id_build_number_key"                  // ALTER TABLE ONLY workspace_builds ADD CONSTRAINT workspace_builds_workspace_id_build_number_key UNIQUE (workspace_id, build_number);
+	UniqueWorkspaceCostUsagePkey                              UniqueConstraint = "workspace_cost_usage_pkey"                                       // ALTER TABLE ONLY workspace_cost_usage ADD CONSTRAINT workspace_cost_usage_pkey PRIMARY KEY (start_time, workspace_id);
+	UniqueWorkspaceDriftChecksJobIDKey                        UniqueConstraint = "workspace_drift_checks_job_id_key"                               // ALTER TABLE ONLY workspace_drift_checks ADD CONSTRAINT workspace_drift_checks_job_id_key UNIQUE (job_id);
+	UniqueWorkspaceDriftChecksPkey                            UniqueConstraint = "workspace_drift_checks_pkey"                                     // ALTER TABLE ONLY workspace_drift_checks ADD CONSTRAINT workspace_drift_checks_pkey PRIMARY KEY (id);
 	UniqueWorkspaceProxiesPkey                                UniqueConstraint = "workspace_proxies_pkey"                                          // ALTER TABLE ONLY workspace_proxies ADD CONSTRAINT workspace_proxies_pkey PRIMARY KEY (id);
 	UniqueWorkspaceProxiesRegionIDUnique                      UniqueConstraint = "workspace_proxies_region_id_unique"                              // ALTER TABLE ONLY workspace_proxies ADD CONSTRAINT workspace_proxies_region_id_unique UNIQUE (region_id);
 	UniqueWorkspaceResourceMetadataName                       UniqueConstraint = "workspace_resource_metadata_name"                                // ALTER TABLE ONLY workspace_resource_metadata ADD CONSTRAINT workspace_resource_metadata_name UNIQUE (workspace_resource_id, key);
 	UniqueWorkspaceResourceMetadataPkey                       UniqueConstraint = "workspace_resource_metadata_pkey"                                // ALTER TABLE ONLY workspace_resource_metadata ADD CONSTRAINT workspace_resource_metadata_pkey PRIMARY KEY (id);
 	UniqueWorkspaceResourcesPkey                              UniqueConstraint = "workspace_resources_pkey"                                        // ALTER TABLE ONLY workspace_resources ADD CONSTRAINT workspace_resources_pkey PRIMARY KEY (id);
+	UniqueWorkspaceSnapshotRestoresPkey                       UniqueConstraint = "workspace_snapshot_restores_pkey"                                // ALTER TABLE ONLY workspace_snapshot_restores ADD CONSTRAINT workspace_snapshot_restores_pkey PRIMARY KEY (workspace_id);
+	UniqueWorkspaceSnapshotsPkey                              UniqueConstraint = "workspace_snapshots_pkey"                                        // ALTER TABLE ONLY workspace_snapshots ADD CONSTRAINT workspace_snapshots_pkey PRIMARY KEY (id);
+	UniqueWorkspaceUpdatePreviewsPkey                         UniqueConstraint = "workspace_update_previews_pkey"                                  // ALTER TABLE ONLY workspace_update_previews ADD CONSTRAINT workspace_update_previews_pkey PRIMARY KEY (job_id);
 	UniqueWorkspacesPkey                                      UniqueConstraint = "workspaces_pkey"                                                 // ALTER TABLE ONLY workspaces ADD CONSTRAINT workspaces_pkey PRIMARY KEY (id);
 	UniqueIndexAPIKeyName                                     UniqueConstraint = "idx_api_key_name"                                                // CREATE UNIQUE INDEX idx_api_key_name ON api_keys USING btree (user_id, token_name) WHERE (login_type = 'token'::login_type);
+	UniqueIndexAuditLogArchivesFileName                       UniqueConstraint = "idx_audit_log_archives_file_name"                                // CREATE UNIQUE INDEX idx_audit_log_archives_file_name ON audit_log_archives USING btree (file_name);
+	UniqueIndexAuditLogHashesAuditLogID                       UniqueConstraint = "idx_audit_log_hashes_audit_log_id"                               // CREATE UNIQUE INDEX idx_audit_log_hashes_audit_log_id ON audit_log_hashes USING btree (audit_log_id);
 	UniqueIndexCustomRolesNameLower                           UniqueConstraint = "idx_custom_roles_name_lower"                                     // CREATE UNIQUE INDEX idx_custom_roles_name_lower ON custom_roles USING btree (lower(name));
 	UniqueIndexOrganizationNameLower                          UniqueConstraint = "idx_organization_name_lower"                                     // CREATE UNIQUE INDEX idx_organization_name_lower ON organizations USING btree (lower(name)) WHERE (deleted = false);
 	UniqueIndexProvisionerDaemonsOrgNameOwnerKey              UniqueConstraint = "idx_provisioner_daemons_org_name_owner_key"                      // CREATE UNIQUE INDEX idx_provisioner_daemons_org_name_owner_key ON provisioner_daemons USING btree (organization_id, name, lower(COALESCE((tags ->> 'owner'::text), ''::text)));
@@ -0,0 +1,201 @@
+// Package driftcheck periodically plans running workspaces to find resources
+// that were changed outside of Coder.
+package driftcheck
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"github.com/coder/quartz"
+
+	"github.com/coder/coder/v2/coderd/database"
+	"github.com/coder/coder/v2/coderd/database/dbauthz"
+	"github.com/coder/coder/v2/coderd/database/dbtime"
+	"github.com/coder/coder/v2/coderd/database/provisionerjobs"
+	"github.com/coder/coder/v2/coderd/database/pubsub"
+	"github.com/coder/coder/v2/coderd/provisionerdserver"
+)
+
+// interval is how often the scheduler looks for workspaces that are due for
+// a drift check. The check interval itself is configured per template.
+const interval = time.Minute
+
+// NewScheduler starts a scheduler that enqueues drift checks for running
+// workspaces whose template has drift checks enabled.
+func NewScheduler(ctx context.Context, logger slog.Logger, db database.Store, ps pubsub.Pubsub, clk quartz.Clock) io.Closer {
+	closed := make(chan struct{})
+
+	ctx, cancelFunc := context.WithCancel(ctx)
+	//nolint:gocritic // The system checks workspaces for drift without direct user input.
+	ctx = dbauthz.AsSystemRestricted(ctx)
+
+	ticker := clk.NewTicker(interval)
+	ticker.Stop()
+	doTick := func(now time.Time) {
+		defer ticker.Reset(interval)
+
+		var jobs []database.ProvisionerJob
+		err := db.InTx(func(tx database.Store) error {
+			// Only one replica should enqueue checks, or workspaces would be
+			// checked once per replica.
+			ok, err := tx.TryAcquireLock(ctx, database.LockIDWorkspaceDriftChecks)
+			if err != nil {
+				return xerrors.Errorf("acquire drift check lock: %w", err)
+			}
+			if !ok {
+				logger.Debug(ctx, "unable to acquire lock for drift checks, skipping")
+				return nil
+			}
+
+			due, err := tx.GetWorkspacesDueForDriftCheck(ctx, now)
+			if err != nil {
+				return xerrors.Errorf("get workspaces due for drift check: %w", err)
+			}
+			for _, row := range due {
+				_, job, err := enqueue(ctx, tx, row.WorkspaceID, row.WorkspaceBuildID, database.ProvisionerJobPriorityDriftCheck, now)
+				if err != nil {
+					logger.Error(ctx, "failed to enqueue drift check",
+						slog.F("workspace_id", row.WorkspaceID), slog.Error(err))
+					continue
+				}
+				jobs = append(jobs, job)
+			}
+			return nil
+		}, nil)
+		if err != nil {
+			logger.Error(ctx, "failed to enqueue drift checks", slog.Error(err))
+			return
+		}
+		postJobs(ctx, logger, ps, jobs)
+		if len(jobs) > 0 {
+			logger.Info(ctx, "enqueued drift checks", slog.F("count", len(jobs)))
+		}
+	}
+
+	go func() {
+		defer close(closed)
+		defer ticker.Stop()
+		// Force an initial tick.
+		doTick(dbtime.Time(clk.Now()).UTC())
+		for {
+			select {
+			case <-ctx.Done():
+				logger.Debug(ctx, "closing drift check scheduler")
+				return
+			case tick := <-ticker.C:
+				ticker.Stop()
+
+				doTick(dbtime.Time(tick).UTC())
+			}
+		}
+	}()
+	return &scheduler{
+		cancel: cancelFunc,
+		closed: closed,
+	}
+}
+
+type scheduler struct {
+	cancel context.CancelFunc
+	closed chan struct{}
+}
+
+func (s *scheduler) Close() error {
+	s.cancel()
+	<-s.closed
+	return nil
+}
+
+// Enqueue inserts a drift check of a workspace build and posts its job to
+// the provisioner daemons. The build must be the latest build of the
+// workspace, and must have started it.
+func Enqueue(ctx context.Context, logger slog.Logger, db database.Store, ps pubsub.Pubsub, workspaceID, buildID uuid.UUID) (database.WorkspaceDriftCheck, database.ProvisionerJob, error) {
+	var (
+		check database.WorkspaceDriftCheck
+		job   database.ProvisionerJob
+	)
+	err := db.InTx(func(tx database.Store) error {
+		var err error
+		// The user is waiting for the result of a check they requested.
+		check, job, err = enqueue(ctx, tx, workspaceID, buildID, database.ProvisionerJobPriorityInteractive, dbtime.Now())
+		return err
+	}, nil)
+	if err != nil {
+		return database.WorkspaceDriftCheck{}, database.ProvisionerJob{}, err
+	}
+	postJobs(ctx, logger, ps, []database.ProvisionerJob{job})
+	return check, job, nil
+}
+
+func enqueue(ctx context.Context, db database.Store, workspaceID, buildID uuid.UUID, priority database.ProvisionerJobPriority, now time.Time) (database.WorkspaceDriftCheck, database.ProvisionerJob, error) {
+	build, err := db.GetWorkspaceBuildByID(ctx, buildID)
+	if err != nil {
+		return database.WorkspaceDriftCheck{}, database.ProvisionerJob{}, xerrors.Errorf("get workspace build: %w", err)
+	}
+	buildJob, err := db.GetProvisionerJobByID(ctx, build.JobID)
+	if err != nil {
+		return database.WorkspaceDriftCheck{}, database.ProvisionerJob{}, xerrors.Errorf("get workspace build job: %w", err)
+	}
+	workspace, err := db.GetWorkspaceByID(ctx, workspaceID)
+	if err != nil {
+		return database.WorkspaceDriftCheck{}, database.ProvisionerJob{}, xerrors.Errorf("get workspace: %w", err)
+	}
+
+	// The check plans with the same input as the build, so that the
+	// provisioner daemon loads the state and parameters of the build.
+	input, err := json.Marshal(provisionerdserver.WorkspaceProvisionJob{
+		WorkspaceBuildID: build.ID,
+	})
+	if err != nil {
+		return database.WorkspaceDriftCheck{}, database.ProvisionerJob{}, xerrors.Errorf("marshal job input: %w", err)
+	}
+	job, err := db.InsertProvisionerJob(ctx, database.InsertProvisionerJobParams{
+		ID:             uuid.New(),
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		OrganizationID: buildJob.OrganizationID,
+		InitiatorID:    workspace.OwnerID,
+		Provisioner:    buildJob.Provisioner,
+		StorageMethod:  buildJob.StorageMethod,
+		FileID:         buildJob.FileID,
+		Type:           database.ProvisionerJobTypeWorkspaceDriftCheck,
+		Input:          input,
+		// Run on the same provisioners as the build, which have access to
+		// the infrastructure of the workspace.
+		Tags:       buildJob.Tags,
+		Priority:   priority,
+		TemplateID: buildJob.TemplateID,
+	})
+	if err != nil {
+		return database.WorkspaceDriftCheck{}, database.ProvisionerJob{}, xerrors.Errorf("insert provisioner job: %w", err)
+	}
+	check, err := db.InsertWorkspaceDriftCheck(ctx, database.InsertWorkspaceDriftCheckParams{
+		ID:               uuid.New(),
+		WorkspaceID:      workspace.ID,
+		WorkspaceBuildID: build.ID,
+		JobID:            job.ID,
+		CreatedAt:        now,
+	})
+	if err != nil {
+		return database.WorkspaceDriftCheck{}, database.ProvisionerJob{}, xerrors.Errorf("insert workspace drift check: %w", err)
+	}
+	return check, job, nil
+}
+
+// postJobs notifies provisioner daemons of new jobs. Jobs are posted after
+// the transaction commits, so that daemons can acquire them.
+func postJobs(ctx context.Context, logger slog.Logger, ps pubsub.Pubsub, jobs []database.ProvisionerJob) {
+	for _, job := range jobs {
+		if err := provisionerjobs.PostJob(ps, job); err != nil {
+			// Daemons poll for jobs, so the check still runs.
+			logger.Error(ctx, "failed to post provisioner job to pubsub",
+				slog.F("job_id", job.ID), slog.Error(err))
+		}
+	}
+}
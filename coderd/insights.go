@@ -3,9 +3,11 @@ package coderd
 import (
 	"context"
 	"database/sql"
+	"encoding/csv"
 	"fmt"
 	"net/http"
 	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -13,6 +15,8 @@ import (
 	"golang.org/x/sync/errgroup"
 	"golang.org/x/xerrors"
 
+	"cdr.dev/slog"
+
 	"github.com/coder/coder/v2/coderd/database"
 	"github.com/coder/coder/v2/coderd/database/db2sdk"
 	"github.com/coder/coder/v2/coderd/database/dbtime"
@@ -355,6 +359,182 @@ func (api *API) insightsUserStatusCounts(rw http.ResponseWriter, r *http.Request
 	httpapi.Write(ctx, rw, http.StatusOK, resp)
 }
 
+// @Summary Get insights about workspace costs
+// @Description Returns the uptime and cost of workspaces, from the hourly cost
+// @Description of their resources while running, rolled up by user, group,
+// @Description organization or template.
+// @ID get-insights-about-workspace-costs
+// @Security CoderSessionToken
+// @Produce json,text/csv
+// @Tags Insights
+// @Param start_time query string true "Start time" format(date-time)
+// @Param end_time query string true "End time" format(date-time)
+// @Param group_by query string false "Group by" enums(user,group,organization,template)
+// @Param template_ids query []string false "Template IDs" collectionFormat(csv)
+// @Param format query string false "Response format" enums(json,csv)
+// @Success 200 {object} codersdk.CostInsightsResponse
+// @Router /insights/costs [get]
+func (api *API) insightsCosts(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	p := httpapi.NewQueryParamParser().
+		RequiredNotEmpty("start_time").
+		RequiredNotEmpty("end_time")
+	vals := r.URL.Query()
+	var (
+		// The QueryParamParser does not preserve timezone, so we need
+		// to parse the time ourselves.
+		startTimeString = p.String(vals, "", "start_time")
+		endTimeString   = p.String(vals, "", "end_time")
+		groupBy         = codersdk.CostInsightsGroupBy(p.String(vals, string(codersdk.CostInsightsGroupByUser), "group_by"))
+		format          = p.String(vals, "json", "format")
+		templateIDs     = p.UUIDs(vals, []uuid.UUID{}, "template_ids")
+	)
+	p.ErrorExcessParams(vals)
+	switch groupBy {
+	case codersdk.CostInsightsGroupByUser, codersdk.CostInsightsGroupByGroup,
+		codersdk.CostInsightsGroupByOrganization, codersdk.CostInsightsGroupByTemplate:
+	default:
+		p.Errors = append(p.Errors, codersdk.ValidationError{
+			Field:  "group_by",
+			Detail: fmt.Sprintf("must be one of %q, %q, %q or %q", codersdk.CostInsightsGroupByUser, codersdk.CostInsightsGroupByGroup, codersdk.CostInsightsGroupByOrganization, codersdk.CostInsightsGroupByTemplate),
+		})
+	}
+	if format != "json" && format != "csv" {
+		p.Errors = append(p.Errors, codersdk.ValidationError{
+			Field:  "format",
+			Detail: `must be "json" or "csv"`,
+		})
+	}
+	if len(p.Errors) > 0 {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message:     "Query parameters have invalid values.",
+			Validations: p.Errors,
+		})
+		return
+	}
+
+	startTime, endTime, ok := parseInsightsStartAndEndTime(ctx, rw, time.Now(), startTimeString, endTimeString)
+	if !ok {
+		return
+	}
+
+	report := codersdk.CostInsightsReport{
+		StartTime:   startTime,
+		EndTime:     endTime,
+		TemplateIDs: templateIDs,
+		GroupBy:     groupBy,
+	}
+	var err error
+	if groupBy == codersdk.CostInsightsGroupByGroup {
+		var rows []database.GetWorkspaceCostInsightsByGroupRow
+		rows, err = api.Database.GetWorkspaceCostInsightsByGroup(ctx, database.GetWorkspaceCostInsightsByGroupParams{
+			StartTime:   startTime,
+			EndTime:     endTime,
+			TemplateIDs: templateIDs,
+		})
+		report.Entries = convertGroupCostInsights(rows)
+	} else {
+		var rows []database.GetWorkspaceCostInsightsRow
+		rows, err = api.Database.GetWorkspaceCostInsights(ctx, database.GetWorkspaceCostInsightsParams{
+			StartTime:   startTime,
+			EndTime:     endTime,
+			TemplateIDs: templateIDs,
+		})
+		report.Entries = convertCostInsights(groupBy, rows)
+	}
+	if err != nil {
+		if httpapi.Is404Error(err) {
+			httpapi.ResourceNotFound(rw)
+			return
+		}
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace costs.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	if format == "csv" {
+		writeCostInsightsCSV(ctx, rw, api.Logger, report)
+		return
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, codersdk.CostInsightsResponse{Report: report})
+}
+
+// convertCostInsights rolls up the cost of workspaces per organization,
+// template and user into entries of the given dimension.
+func convertCostInsights(groupBy codersdk.CostInsightsGroupBy, rows []database.GetWorkspaceCostInsightsRow) []codersdk.CostInsightsEntry {
+	entries := []codersdk.CostInsightsEntry{}
+	indexByID := make(map[uuid.UUID]int)
+	costMicros := make(map[uuid.UUID]int64)
+	for _, row := range rows {
+		var entry codersdk.CostInsightsEntry
+		switch groupBy {
+		case codersdk.CostInsightsGroupByOrganization:
+			entry.ID, entry.Name = row.OrganizationID, row.OrganizationName
+		case codersdk.CostInsightsGroupByTemplate:
+			entry.ID, entry.Name, entry.OrganizationName = row.TemplateID, row.TemplateName, row.OrganizationName
+		default:
+			entry.ID, entry.Name = row.UserID, row.Username
+		}
+		i, ok := indexByID[entry.ID]
+		if !ok {
+			i = len(entries)
+			indexByID[entry.ID] = i
+			entries = append(entries, entry)
+		}
+		entries[i].UsageSeconds += row.UsageSeconds
+		costMicros[entry.ID] += row.CostMicros
+	}
+	for i := range entries {
+		entries[i].Cost = float64(costMicros[entries[i].ID]) / 1e6
+	}
+	slices.SortStableFunc(entries, func(a, b codersdk.CostInsightsEntry) int {
+		if a.Cost != b.Cost {
+			return slice.Descending(a.Cost, b.Cost)
+		}
+		return slice.Descending(a.UsageSeconds, b.UsageSeconds)
+	})
+	return entries
+}
+
+func convertGroupCostInsights(rows []database.GetWorkspaceCostInsightsByGroupRow) []codersdk.CostInsightsEntry {
+	entries := make([]codersdk.CostInsightsEntry, 0, len(rows))
+	for _, row := range rows {
+		entries = append(entries, codersdk.CostInsightsEntry{
+			ID:               row.GroupID,
+			Name:             row.GroupName,
+			OrganizationName: row.OrganizationName,
+			UsageSeconds:     row.UsageSeconds,
+			Cost:             float64(row.CostMicros) / 1e6,
+		})
+	}
+	return entries
+}
+
+func writeCostInsightsCSV(ctx context.Context, rw http.ResponseWriter, logger slog.Logger, report codersdk.CostInsightsReport) {
+	rw.Header().Set("Content-Type", "text/csv")
+	rw.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="costs-by-%s-%s-%s.csv"`,
+		report.GroupBy, report.StartTime.Format(time.DateOnly), report.EndTime.Format(time.DateOnly)))
+	rw.WriteHeader(http.StatusOK)
+
+	w := csv.NewWriter(rw)
+	records := [][]string{{string(report.GroupBy) + "_id", string(report.GroupBy), "organization", "usage_hours", "cost"}}
+	for _, entry := range report.Entries {
+		records = append(records, []string{
+			entry.ID.String(),
+			entry.Name,
+			entry.OrganizationName,
+			strconv.FormatFloat(float64(entry.UsageSeconds)/3600, 'f', 2, 64),
+			strconv.FormatFloat(entry.Cost, 'f', 6, 64),
+		})
+	}
+	if err := w.WriteAll(records); err != nil {
+		logger.Debug(ctx, "write cost insights csv", slog.Error(err))
+	}
+}
+
 // @Summary Get insights about templates
 // @ID get-insights-about-templates
 // @Security CoderSessionToken
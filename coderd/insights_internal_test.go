@@ -6,9 +6,11 @@ import (
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/coder/coder/v2/coderd/database"
 	"github.com/coder/coder/v2/codersdk"
 )
 
@@ -339,3 +341,61 @@ func stripTime(t time.Time) time.Time {
 	y, m, d := t.Date()
 	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
 }
+
+func Test_convertCostInsights(t *testing.T) {
+	t.Parallel()
+
+	var (
+		orgID  = uuid.New()
+		tplA   = uuid.New()
+		tplB   = uuid.New()
+		alice  = uuid.New()
+		bob    = uuid.New()
+		newRow = func(templateID uuid.UUID, templateName string, userID uuid.UUID, username string, seconds, micros int64) database.GetWorkspaceCostInsightsRow {
+			return database.GetWorkspaceCostInsightsRow{
+				OrganizationID:   orgID,
+				OrganizationName: "acme",
+				TemplateID:       templateID,
+				TemplateName:     templateName,
+				UserID:           userID,
+				Username:         username,
+				UsageSeconds:     seconds,
+				CostMicros:       micros,
+			}
+		}
+		rows = []database.GetWorkspaceCostInsightsRow{
+			newRow(tplA, "docker", alice, "alice", 3600, 500_000),
+			newRow(tplB, "gpu", alice, "alice", 1800, 2_000_000),
+			newRow(tplA, "docker", bob, "bob", 7200, 1_000_000),
+		}
+	)
+
+	t.Run("User", func(t *testing.T) {
+		t.Parallel()
+		require.Equal(t, []codersdk.CostInsightsEntry{
+			{ID: alice, Name: "alice", UsageSeconds: 5400, Cost: 2.5},
+			{ID: bob, Name: "bob", UsageSeconds: 7200, Cost: 1},
+		}, convertCostInsights(codersdk.CostInsightsGroupByUser, rows))
+	})
+
+	t.Run("Template", func(t *testing.T) {
+		t.Parallel()
+		require.Equal(t, []codersdk.CostInsightsEntry{
+			{ID: tplB, Name: "gpu", OrganizationName: "acme", UsageSeconds: 1800, Cost: 2},
+			{ID: tplA, Name: "docker", OrganizationName: "acme", UsageSeconds: 10800, Cost: 1.5},
+		}, convertCostInsights(codersdk.CostInsightsGroupByTemplate, rows))
+	})
+
+	t.Run("Organization", func(t *testing.T) {
+		t.Parallel()
+		require.Equal(t, []codersdk.CostInsightsEntry{
+			{ID: orgID, Name: "acme", UsageSeconds: 12600, Cost: 3.5},
+		}, convertCostInsights(codersdk.CostInsightsGroupByOrganization, rows))
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		t.Parallel()
+		require.Empty(t, convertCostInsights(codersdk.CostInsightsGroupByUser, nil))
+		require.NotNil(t, convertCostInsights(codersdk.CostInsightsGroupByUser, nil))
+	})
+}
@@ -0,0 +1,294 @@
+package coderd
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/coder/coder/v2/coderd/database"
+	"github.com/coder/coder/v2/coderd/database/dbtime"
+	"github.com/coder/coder/v2/coderd/httpapi"
+	"github.com/coder/coder/v2/coderd/httpmw"
+	"github.com/coder/coder/v2/coderd/notifications/rules"
+	"github.com/coder/coder/v2/coderd/rbac"
+	"github.com/coder/coder/v2/coderd/rbac/policy"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+// @Summary Get notification rules
+// @ID get-notification-rules
+// @Security CoderSessionToken
+// @Produce json
+// @Tags Notifications
+// @Param organization path string true "Organization ID" format(uuid)
+// @Success 200 {array} codersdk.NotificationRule
+// @Router /organizations/{organization}/notifications/rules [get]
+func (api *API) notificationRules(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx = r.Context()
+		org = httpmw.OrganizationParam(r)
+	)
+
+	dbRules, err := api.Database.GetNotificationRulesByOrganizationID(ctx, org.ID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Failed to fetch notification rules.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	out := make([]codersdk.NotificationRule, 0, len(dbRules))
+	for _, rule := range dbRules {
+		out = append(out, convertNotificationRule(rule))
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, out)
+}
+
+// @Summary Create notification rule
+// @ID create-notification-rule
+// @Security CoderSessionToken
+// @Accept json
+// @Produce json
+// @Tags Notifications
+// @Param organization path string true "Organization ID" format(uuid)
+// @Param request body codersdk.CreateNotificationRuleRequest true "Notification rule"
+// @Success 201 {object} codersdk.NotificationRule
+// @Router /organizations/{organization}/notifications/rules [post]
+func (api *API) postNotificationRule(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx    = r.Context()
+		org    = httpmw.OrganizationParam(r)
+		apiKey = httpmw.APIKey(r)
+	)
+
+	var req codersdk.CreateNotificationRuleRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+	source := database.NotificationRuleSource(req.Source)
+	if !source.Valid() {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Invalid notification rule source.",
+			Validations: []codersdk.ValidationError{{
+				Field:  "source",
+				Detail: fmt.Sprintf("%q is not a valid source", req.Source),
+			}},
+		})
+		return
+	}
+	method, ok := api.validateNotificationRule(rw, r, org.ID, source, req.Query, req.Method, req.Threshold, req.WindowSeconds)
+	if !ok {
+		return
+	}
+
+	rule, err := api.Database.InsertNotificationRule(ctx, database.InsertNotificationRuleParams{
+		ID:             uuid.New(),
+		OrganizationID: org.ID,
+		UserID:         apiKey.UserID,
+		Name:           req.Name,
+		Source:         source,
+		Query:          req.Query,
+		Method:         method,
+		Threshold:      max(req.Threshold, 1),
+		WindowSeconds:  req.WindowSeconds,
+		Enabled:        !req.Disabled,
+		CreatedAt:      dbtime.Now(),
+	})
+	if database.IsUniqueViolation(err, database.UniqueNotificationRulesOrganizationIDNameKey) {
+		httpapi.Write(ctx, rw, http.StatusConflict, codersdk.Response{
+			Message: fmt.Sprintf("A notification rule named %q already exists.", req.Name),
+		})
+		return
+	}
+	if httpapi.IsUnauthorizedError(err) {
+		httpapi.Forbidden(rw)
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Failed to create notification rule.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusCreated, convertNotificationRule(rule))
+}
+
+// @Summary Update notification rule
+// @ID update-notification-rule
+// @Security CoderSessionToken
+// @Accept json
+// @Produce json
+// @Tags Notifications
+// @Param organization path string true "Organization ID" format(uuid)
+// @Param notificationrule path string true "Notification rule ID" format(uuid)
+// @Param request body codersdk.UpdateNotificationRuleRequest true "Notification rule"
+// @Success 200 {object} codersdk.NotificationRule
+// @Router /organizations/{organization}/notifications/rules/{notificationrule} [put]
+func (api *API) putNotificationRule(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	rule, ok := api.notificationRuleParam(rw, r)
+	if !ok {
+		return
+	}
+	var req codersdk.UpdateNotificationRuleRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+	method, ok := api.validateNotificationRule(rw, r, rule.OrganizationID, rule.Source, req.Query, req.Method, req.Threshold, req.WindowSeconds)
+	if !ok {
+		return
+	}
+
+	rule, err := api.Database.UpdateNotificationRule(ctx, database.UpdateNotificationRuleParams{
+		ID:            rule.ID,
+		Name:          req.Name,
+		Query:         req.Query,
+		Method:        method,
+		Threshold:     max(req.Threshold, 1),
+		WindowSeconds: req.WindowSeconds,
+		Enabled:       req.Enabled,
+		UpdatedAt:     dbtime.Now(),
+	})
+	if database.IsUniqueViolation(err, database.UniqueNotificationRulesOrganizationIDNameKey) {
+		httpapi.Write(ctx, rw, http.StatusConflict, codersdk.Response{
+			Message: fmt.Sprintf("A notification rule named %q already exists.", req.Name),
+		})
+		return
+	}
+	if httpapi.IsUnauthorizedError(err) {
+		httpapi.Forbidden(rw)
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Failed to update notification rule.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, convertNotificationRule(rule))
+}
+
+// @Summary Delete notification rule
+// @ID delete-notification-rule
+// @Security CoderSessionToken
+// @Tags Notifications
+// @Param organization path string true "Organization ID" format(uuid)
+// @Param notificationrule path string true "Notification rule ID" format(uuid)
+// @Success 204
+// @Router /organizations/{organization}/notifications/rules/{notificationrule} [delete]
+func (api *API) deleteNotificationRule(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	rule, ok := api.notificationRuleParam(rw, r)
+	if !ok {
+		return
+	}
+	err := api.Database.DeleteNotificationRule(ctx, rule.ID)
+	if httpapi.IsUnauthorizedError(err) {
+		httpapi.Forbidden(rw)
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Failed to delete notification rule.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// notificationRuleParam fetches the notification rule in the URL, which must belong to the organization in the URL.
+func (api *API) notificationRuleParam(rw http.ResponseWriter, r *http.Request) (database.NotificationRule, bool) {
+	var (
+		ctx = r.Context()
+		org = httpmw.OrganizationParam(r)
+	)
+
+	ruleID, ok := httpmw.ParseUUIDParam(rw, r, "notificationrule")
+	if !ok {
+		return database.NotificationRule{}, false
+	}
+	rule, err := api.Database.GetNotificationRuleByID(ctx, ruleID)
+	if httpapi.Is404Error(err) || (err == nil && rule.OrganizationID != org.ID) {
+		httpapi.ResourceNotFound(rw)
+		return database.NotificationRule{}, false
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Failed to fetch notification rule.",
+			Detail:  err.Error(),
+		})
+		return database.NotificationRule{}, false
+	}
+	return rule, true
+}
+
+// validateNotificationRule validates the query and delivery settings of a rule, and returns its dispatch method.
+// Audit log rules can only be managed by users who can read the audit logs of the organization, since the
+// notifications describe the matching entries.
+func (api *API) validateNotificationRule(rw http.ResponseWriter, r *http.Request, organizationID uuid.UUID, source database.NotificationRuleSource, query, method string, threshold, windowSeconds int32) (database.NullNotificationMethod, bool) {
+	ctx := r.Context()
+
+	if source == database.NotificationRuleSourceAuditLog && !api.Authorize(r, policy.ActionRead, rbac.ResourceAuditLog.InOrg(organizationID)) {
+		httpapi.Forbidden(rw)
+		return database.NullNotificationMethod{}, false
+	}
+
+	validations := rules.ValidateQuery(ctx, api.Database, source, query)
+	if threshold < 0 {
+		validations = append(validations, codersdk.ValidationError{Field: "threshold", Detail: "must be at least 1"})
+	}
+	if windowSeconds < 0 {
+		validations = append(validations, codersdk.ValidationError{Field: "window_seconds", Detail: "must not be negative"})
+	}
+	var nm database.NullNotificationMethod
+	if method != "" {
+		err := nm.Scan(method)
+		switch {
+		case err != nil || !nm.NotificationMethod.Valid() || nm.NotificationMethod == database.NotificationMethodInbox:
+			validations = append(validations, codersdk.ValidationError{Field: "method", Detail: fmt.Sprintf("%q is not a valid method", method)})
+		case !api.chatNotificationMethodConfigured(nm.NotificationMethod):
+			validations = append(validations, codersdk.ValidationError{Field: "method", Detail: fmt.Sprintf("%q is not configured on this deployment", method)})
+		}
+	}
+	if len(validations) > 0 {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message:     "Invalid notification rule.",
+			Validations: validations,
+		})
+		return database.NullNotificationMethod{}, false
+	}
+	return nm, true
+}
+
+func convertNotificationRule(rule database.NotificationRule) codersdk.NotificationRule {
+	out := codersdk.NotificationRule{
+		ID:             rule.ID,
+		OrganizationID: rule.OrganizationID,
+		UserID:         rule.UserID,
+		Name:           rule.Name,
+		Source:         codersdk.NotificationRuleSource(rule.Source),
+		Query:          rule.Query,
+		Threshold:      rule.Threshold,
+		WindowSeconds:  rule.WindowSeconds,
+		Enabled:        rule.Enabled,
+		CreatedAt:      rule.CreatedAt,
+		UpdatedAt:      rule.UpdatedAt,
+	}
+	if rule.Method.Valid {
+		out.Method = string(rule.Method.NotificationMethod)
+	}
+	if rule.LastTriggeredAt.Valid {
+		out.LastTriggeredAt = &rule.LastTriggeredAt.Time
+	}
+	return out
+}
@@ -2,22 +2,35 @@ package coderd
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	"golang.org/x/xerrors"
 
 	"cdr.dev/slog"
 
 	"github.com/coder/coder/v2/coderd/audit"
 	"github.com/coder/coder/v2/coderd/database"
 	"github.com/coder/coder/v2/coderd/database/dbauthz"
+	"github.com/coder/coder/v2/coderd/database/dbtime"
 	"github.com/coder/coder/v2/coderd/httpapi"
 	"github.com/coder/coder/v2/coderd/httpmw"
 	"github.com/coder/coder/v2/coderd/notifications"
+	"github.com/coder/coder/v2/coderd/notifications/dispatch"
 	"github.com/coder/coder/v2/coderd/rbac"
 	"github.com/coder/coder/v2/coderd/rbac/policy"
 	"github.com/coder/coder/v2/codersdk"
+	"github.com/coder/coder/v2/cryptorand"
 )
 
 // @Summary Get notifications settings
@@ -162,6 +175,11 @@ func (api *API) notificationDispatchMethods(rw http.ResponseWriter, r *http.Requ
 		if nm == database.NotificationMethodInbox {
 			continue
 		}
+		// Chat methods need credentials, unlike SMTP and webhooks which
+		// fail with a descriptive error, so only offer the configured ones.
+		if !api.chatNotificationMethodConfigured(nm) {
+			continue
+		}
 		methods = append(methods, string(nm))
 	}
 
@@ -323,6 +341,443 @@ func (api *API) putUserNotificationPreferences(rw http.ResponseWriter, r *http.R
 	httpapi.Write(ctx, rw, http.StatusOK, out)
 }
 
+func (api *API) chatNotificationMethodConfigured(method database.NotificationMethod) bool {
+	cfg := api.DeploymentValues.Notifications
+	switch method {
+	case database.NotificationMethodSlack:
+		return cfg.Slack.BotToken.String() != ""
+	case database.NotificationMethodMatrix:
+		return cfg.Matrix.Homeserver.String() != "" && cfg.Matrix.AccessToken.String() != ""
+	case database.NotificationMethodTeams:
+		return cfg.Teams.WebhookURL.String() != ""
+	default:
+		return true
+	}
+}
+
+// @Summary Get user chat identities
+// @ID get-user-chat-identities
+// @Security CoderSessionToken
+// @Produce json
+// @Tags Notifications
+// @Param user path string true "User ID, name, or me"
+// @Success 200 {array} codersdk.UserChatIdentity
+// @Router /users/{user}/notifications/chat-identities [get]
+func (api *API) userChatIdentities(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx  = r.Context()
+		user = httpmw.UserParam(r)
+	)
+
+	identities, err := api.Database.GetUserChatIdentities(ctx, user.ID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Failed to retrieve user chat identities.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	out := make([]codersdk.UserChatIdentity, 0, len(identities))
+	for _, identity := range identities {
+		out = append(out, convertUserChatIdentity(identity))
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, out)
+}
+
+// @Summary Update user chat identity
+// @ID update-user-chat-identity
+// @Security CoderSessionToken
+// @Accept json
+// @Produce json
+// @Tags Notifications
+// @Param user path string true "User ID, name, or me"
+// @Param method path string true "Chat notification method" Enums(slack,matrix,teams)
+// @Param request body codersdk.UpdateUserChatIdentityRequest true "Chat identity"
+// @Success 200 {object} codersdk.UserChatIdentity
+// @Router /users/{user}/notifications/chat-identities/{method} [put]
+func (api *API) putUserChatIdentity(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx  = r.Context()
+		user = httpmw.UserParam(r)
+	)
+
+	method, ok := parseChatNotificationMethod(rw, r)
+	if !ok {
+		return
+	}
+	var req codersdk.UpdateUserChatIdentityRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+	if err := method.ValidateIdentity(req.Identity); err != nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Invalid chat identity.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	now := dbtime.Now()
+	params := database.UpsertUserChatIdentityParams{
+		UserID:    user.ID,
+		Method:    database.NotificationMethod(method),
+		Identity:  req.Identity,
+		UpdatedAt: now,
+	}
+	var code string
+	switch method {
+	case codersdk.ChatNotificationMethodTeams:
+		// Teams webhooks cannot send direct messages, so a code cannot be
+		// delivered. The identity must instead be the email the identity
+		// provider asserted for the user.
+		if user.LoginType != database.LoginTypeOIDC || !strings.EqualFold(user.Email, req.Identity) {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: "Microsoft Teams identities must match the email of your OIDC account.",
+			})
+			return
+		}
+		params.VerifiedAt = sql.NullTime{Time: now, Valid: true}
+	default:
+		var err error
+		code, err = cryptorand.StringCharset(cryptorand.Human, chatVerificationCodeLength)
+		if err != nil {
+			httpapi.InternalServerError(rw, err)
+			return
+		}
+		hash := sha256.Sum256([]byte(code))
+		params.VerificationCodeHash = hash[:]
+		params.VerificationExpiresAt = sql.NullTime{Time: now.Add(chatVerificationCodeLifetime), Valid: true}
+	}
+
+	if code != "" {
+		err := api.sendChatVerificationCode(ctx, method, req.Identity, code)
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusBadGateway, codersdk.Response{
+				Message: "Failed to send the verification code.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+	}
+
+	identity, err := api.Database.UpsertUserChatIdentity(ctx, params)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Failed to update user chat identity.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, convertUserChatIdentity(identity))
+}
+
+// @Summary Verify user chat identity
+// @ID verify-user-chat-identity
+// @Security CoderSessionToken
+// @Accept json
+// @Produce json
+// @Tags Notifications
+// @Param user path string true "User ID, name, or me"
+// @Param method path string true "Chat notification method" Enums(slack,matrix)
+// @Param request body codersdk.VerifyUserChatIdentityRequest true "Verification code"
+// @Success 200 {object} codersdk.UserChatIdentity
+// @Router /users/{user}/notifications/chat-identities/{method}/verify [post]
+func (api *API) verifyUserChatIdentity(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx  = r.Context()
+		user = httpmw.UserParam(r)
+	)
+
+	method, ok := parseChatNotificationMethod(rw, r)
+	if !ok {
+		return
+	}
+	var req codersdk.VerifyUserChatIdentityRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+
+	hash := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(req.Code))))
+	identity, err := api.Database.VerifyUserChatIdentity(ctx, database.VerifyUserChatIdentityParams{
+		UserID:               user.ID,
+		Method:               database.NotificationMethod(method),
+		VerificationCodeHash: hash[:],
+		VerifiedAt:           dbtime.Now(),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Invalid or expired verification code.",
+			Detail:  "Link the identity again to receive a new code.",
+		})
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Failed to verify user chat identity.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, convertUserChatIdentity(identity))
+}
+
+const (
+	chatVerificationCodeLength   = 8
+	chatVerificationCodeLifetime = 15 * time.Minute
+)
+
+// sendChatVerificationCode sends the code to identity in a direct message,
+// so only its owner can verify it.
+func (api *API) sendChatVerificationCode(ctx context.Context, method codersdk.ChatNotificationMethod, identity, code string) error {
+	cfg := api.DeploymentValues.Notifications
+	switch method {
+	case codersdk.ChatNotificationMethodSlack:
+		return dispatch.NewSlackHandler(cfg.Slack, api.Database, api.Logger.Named("slack")).SendVerificationCode(ctx, identity, code)
+	case codersdk.ChatNotificationMethodMatrix:
+		return dispatch.NewMatrixHandler(cfg.Matrix, api.Database, api.Logger.Named("matrix")).SendVerificationCode(ctx, identity, code)
+	default:
+		return xerrors.Errorf("%s cannot send direct messages", method)
+	}
+}
+
+// @Summary Delete user chat identity
+// @ID delete-user-chat-identity
+// @Security CoderSessionToken
+// @Tags Notifications
+// @Param user path string true "User ID, name, or me"
+// @Param method path string true "Chat notification method" Enums(slack,matrix,teams)
+// @Success 204
+// @Router /users/{user}/notifications/chat-identities/{method} [delete]
+func (api *API) deleteUserChatIdentity(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx  = r.Context()
+		user = httpmw.UserParam(r)
+	)
+
+	method, ok := parseChatNotificationMethod(rw, r)
+	if !ok {
+		return
+	}
+	err := api.Database.DeleteUserChatIdentity(ctx, database.DeleteUserChatIdentityParams{
+		UserID: user.ID,
+		Method: database.NotificationMethod(method),
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Failed to delete user chat identity.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Get user notification delivery settings
+// @ID get-user-notification-delivery-settings
+// @Security CoderSessionToken
+// @Produce json
+// @Tags Notifications
+// @Param user path string true "User ID, name, or me"
+// @Success 200 {object} codersdk.NotificationDeliverySettings
+// @Router /users/{user}/notifications/delivery [get]
+func (api *API) userNotificationDeliverySettings(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx  = r.Context()
+		user = httpmw.UserParam(r)
+	)
+
+	schedule, err := api.Database.GetUserNotificationSchedule(ctx, user.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Failed to retrieve user notification schedule.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	digests, err := api.Database.GetUserNotificationDigestPreferences(ctx, user.ID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Failed to retrieve user notification digest preferences.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, convertNotificationDeliverySettings(schedule, digests))
+}
+
+// @Summary Update user notification delivery settings
+// @ID update-user-notification-delivery-settings
+// @Security CoderSessionToken
+// @Accept json
+// @Produce json
+// @Tags Notifications
+// @Param user path string true "User ID, name, or me"
+// @Param request body codersdk.NotificationDeliverySettings true "Delivery settings"
+// @Success 200 {object} codersdk.NotificationDeliverySettings
+// @Router /users/{user}/notifications/delivery [put]
+func (api *API) putUserNotificationDeliverySettings(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx  = r.Context()
+		user = httpmw.UserParam(r)
+	)
+
+	var req codersdk.NotificationDeliverySettings
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+
+	scheduleParams := database.UpsertUserNotificationScheduleParams{
+		UserID:          user.ID,
+		Timezone:        req.Timezone,
+		DailyDigestTime: 9 * 60,
+		UpdatedAt:       dbtime.Now(),
+	}
+	var validationErrs []codersdk.ValidationError
+	if scheduleParams.Timezone == "" {
+		scheduleParams.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(scheduleParams.Timezone); err != nil {
+		validationErrs = append(validationErrs, codersdk.ValidationError{Field: "timezone", Detail: err.Error()})
+	}
+	if req.DailyDigestTime != "" {
+		minute, err := parseTimeOfDay(req.DailyDigestTime)
+		if err != nil {
+			validationErrs = append(validationErrs, codersdk.ValidationError{Field: "daily_digest_time", Detail: err.Error()})
+		}
+		scheduleParams.DailyDigestTime = minute
+	}
+	if req.QuietHours != nil {
+		start, startErr := parseTimeOfDay(req.QuietHours.Start)
+		if startErr != nil {
+			validationErrs = append(validationErrs, codersdk.ValidationError{Field: "quiet_hours.start", Detail: startErr.Error()})
+		}
+		end, endErr := parseTimeOfDay(req.QuietHours.End)
+		if endErr != nil {
+			validationErrs = append(validationErrs, codersdk.ValidationError{Field: "quiet_hours.end", Detail: endErr.Error()})
+		}
+		if startErr == nil && endErr == nil && start == end {
+			validationErrs = append(validationErrs, codersdk.ValidationError{Field: "quiet_hours", Detail: "quiet hours must not start and end at the same time"})
+		}
+		scheduleParams.QuietHoursStart = sql.NullInt16{Int16: start, Valid: true}
+		scheduleParams.QuietHoursEnd = sql.NullInt16{Int16: end, Valid: true}
+	}
+
+	// Only templates which are not critical can be batched into digests.
+	templates, err := api.Database.GetNotificationTemplatesByKind(ctx, database.NotificationTemplateKindSystem)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Failed to retrieve notification templates.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	digestParams := database.UpdateUserNotificationDigestPreferencesParams{
+		UserID:                  user.ID,
+		NotificationTemplateIds: make([]uuid.UUID, 0, len(req.Digests)),
+		DigestIntervals:         make([]database.NotificationDigestInterval, 0, len(req.Digests)),
+	}
+	for tmplID, interval := range req.Digests {
+		field := "digests." + tmplID
+		id, err := uuid.Parse(tmplID)
+		if err != nil {
+			validationErrs = append(validationErrs, codersdk.ValidationError{Field: field, Detail: "Unable to parse notification template UUID."})
+			continue
+		}
+		idx := slices.IndexFunc(templates, func(tmpl database.NotificationTemplate) bool { return tmpl.ID == id })
+		switch {
+		case idx < 0:
+			validationErrs = append(validationErrs, codersdk.ValidationError{Field: field, Detail: "Notification template not found."})
+			continue
+		case templates[idx].Critical || id == notifications.TemplateNotificationDigest:
+			validationErrs = append(validationErrs, codersdk.ValidationError{Field: field, Detail: fmt.Sprintf("%q notifications cannot be batched into digests.", templates[idx].Name)})
+			continue
+		}
+		dbInterval := database.NotificationDigestInterval(interval)
+		if !dbInterval.Valid() {
+			validationErrs = append(validationErrs, codersdk.ValidationError{Field: field, Detail: fmt.Sprintf("%q is not a digest interval.", interval)})
+			continue
+		}
+		digestParams.NotificationTemplateIds = append(digestParams.NotificationTemplateIds, id)
+		digestParams.DigestIntervals = append(digestParams.DigestIntervals, dbInterval)
+	}
+	if len(validationErrs) > 0 {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message:     "Invalid notification delivery settings.",
+			Validations: validationErrs,
+		})
+		return
+	}
+
+	var (
+		schedule database.UserNotificationSchedule
+		digests  []database.NotificationDigestPreference
+	)
+	err = api.Database.InTx(func(tx database.Store) error {
+		var err error
+		schedule, err = tx.UpsertUserNotificationSchedule(ctx, scheduleParams)
+		if err != nil {
+			return xerrors.Errorf("upsert schedule: %w", err)
+		}
+		if err := tx.UpdateUserNotificationDigestPreferences(ctx, digestParams); err != nil {
+			return xerrors.Errorf("update digest preferences: %w", err)
+		}
+		digests, err = tx.GetUserNotificationDigestPreferences(ctx, user.ID)
+		if err != nil {
+			return xerrors.Errorf("get digest preferences: %w", err)
+		}
+		return nil
+	}, nil)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Failed to update user notification delivery settings.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, convertNotificationDeliverySettings(schedule, digests))
+}
+
+// parseTimeOfDay parses an HH:MM time of day into minutes after midnight.
+func parseTimeOfDay(s string) (int16, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, xerrors.Errorf("%q is not a time of day in the HH:MM format", s)
+	}
+	// #nosec G115 - Safe conversion, there are 1440 minutes in a day
+	return int16(t.Hour()*60 + t.Minute()), nil
+}
+
+func formatTimeOfDay(minute int16) string {
+	return fmt.Sprintf("%02d:%02d", minute/60, minute%60)
+}
+
+func parseChatNotificationMethod(rw http.ResponseWriter, r *http.Request) (codersdk.ChatNotificationMethod, bool) {
+	method := codersdk.ChatNotificationMethod(chi.URLParam(r, "method"))
+	if !slices.Contains(codersdk.ChatNotificationMethods, method) {
+		httpapi.Write(r.Context(), rw, http.StatusBadRequest, codersdk.Response{
+			Message: fmt.Sprintf("%q is not a chat notification method.", method),
+			Detail:  fmt.Sprintf("Valid methods are %v.", codersdk.ChatNotificationMethods),
+		})
+		return "", false
+	}
+	return method, true
+}
+
+func convertUserChatIdentity(identity database.UserChatIdentity) codersdk.UserChatIdentity {
+	return codersdk.UserChatIdentity{
+		Method:    codersdk.ChatNotificationMethod(identity.Method),
+		Identity:  identity.Identity,
+		Verified:  identity.VerifiedAt.Valid,
+		UpdatedAt: identity.UpdatedAt,
+	}
+}
+
 func convertNotificationTemplates(in []database.NotificationTemplate) (out []codersdk.NotificationTemplate) {
 	for _, tmpl := range in {
 		out = append(out, codersdk.NotificationTemplate{
@@ -335,6 +790,7 @@ func convertNotificationTemplates(in []database.NotificationTemplate) (out []cod
 			Method:           string(tmpl.Method.NotificationMethod),
 			Kind:             string(tmpl.Kind),
 			EnabledByDefault: tmpl.EnabledByDefault,
+			Critical:         tmpl.Critical,
 		})
 	}
 
@@ -352,3 +808,27 @@ func convertNotificationPreferences(in []database.NotificationPreference) (out [
 
 	return out
 }
+
+// convertNotificationDeliverySettings converts the schedule and digest
+// preferences of a user. A zero schedule yields the defaults.
+func convertNotificationDeliverySettings(schedule database.UserNotificationSchedule, digests []database.NotificationDigestPreference) codersdk.NotificationDeliverySettings {
+	out := codersdk.NotificationDeliverySettings{
+		Timezone:        schedule.Timezone,
+		DailyDigestTime: formatTimeOfDay(schedule.DailyDigestTime),
+		Digests:         make(map[string]codersdk.NotificationDigestInterval, len(digests)),
+	}
+	if schedule.UserID == uuid.Nil {
+		out.Timezone = "UTC"
+		out.DailyDigestTime = formatTimeOfDay(9 * 60)
+	}
+	if schedule.QuietHoursStart.Valid && schedule.QuietHoursEnd.Valid {
+		out.QuietHours = &codersdk.NotificationQuietHours{
+			Start: formatTimeOfDay(schedule.QuietHoursStart.Int16),
+			End:   formatTimeOfDay(schedule.QuietHoursEnd.Int16),
+		}
+	}
+	for _, digest := range digests {
+		out.Digests[digest.NotificationTemplateID.String()] = codersdk.NotificationDigestInterval(digest.DigestInterval)
+	}
+	return out
+}
@@ -0,0 +1,177 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+
+	"github.com/coder/coder/v2/coderd/database"
+	"github.com/coder/coder/v2/coderd/database/dbtime"
+	"github.com/coder/coder/v2/coderd/notifications/render"
+	"github.com/coder/coder/v2/coderd/notifications/types"
+)
+
+// digestBatchSize is the maximum number of batched messages acquired per fetch interval. Messages of a recipient which
+// do not fit are summarized in a separate digest on the next interval.
+const digestBatchSize = 500
+
+// processDigests summarizes batched messages which are due into a single digest message per recipient and method.
+// The digest is enqueued like any other notification, and the summarized messages are marked as sent. If the user
+// disabled digests, the messages are returned to the queue to be delivered individually instead.
+func (n *notifier) processDigests(ctx context.Context) error {
+	rows, err := n.store.AcquireNotificationDigestMessages(ctx, database.AcquireNotificationDigestMessagesParams{
+		NotifierID:   n.id,
+		LeaseSeconds: int32(n.cfg.LeasePeriod.Value().Seconds()),
+		Count:        digestBatchSize,
+	})
+	if err != nil {
+		return xerrors.Errorf("acquire digest messages: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	helpers, err := n.fetchHelpers(ctx)
+	if err != nil {
+		return decorateHelpersError{err}
+	}
+
+	// Rows are ordered by recipient and method.
+	for start := 0; start < len(rows); {
+		end := start + 1
+		for end < len(rows) && rows[end].UserID == rows[start].UserID && rows[end].Method == rows[start].Method {
+			end++
+		}
+		group := rows[start:end]
+		start = end
+
+		logger := n.log.With(slog.F("user_id", group[0].UserID), slog.F("method", group[0].Method), slog.F("count", len(group)))
+		err := n.enqueueDigest(ctx, group, helpers)
+		switch {
+		case xerrors.Is(err, ErrCannotEnqueueDisabledNotification):
+			logger.Debug(ctx, "digest disabled by user, delivering messages individually")
+			err := n.undigest(ctx, group)
+			if err != nil {
+				// The leases expire and the messages are evaluated again.
+				logger.Error(ctx, "failed to return digest messages to the queue", slog.Error(err))
+			}
+		case err != nil:
+			// The leases expire and the messages are batched again.
+			logger.Error(ctx, "failed to enqueue digest", slog.Error(err))
+		default:
+			logger.Debug(ctx, "enqueued digest")
+		}
+	}
+	return nil
+}
+
+// undigest returns batched messages to the queue as pending, so that they are delivered individually.
+func (n *notifier) undigest(ctx context.Context, rows []database.AcquireNotificationDigestMessagesRow) error {
+	now := dbtime.Time(n.clock.Now().UTC())
+	params := database.BulkDeferNotificationMessagesParams{
+		IDs:           make([]uuid.UUID, 0, len(rows)),
+		Statuses:      make([]database.NotificationMessageStatus, 0, len(rows)),
+		StatusReasons: make([]string, 0, len(rows)),
+		DeliverAfters: make([]time.Time, 0, len(rows)),
+	}
+	for _, row := range rows {
+		params.IDs = append(params.IDs, row.ID)
+		params.Statuses = append(params.Statuses, database.NotificationMessageStatusPending)
+		params.StatusReasons = append(params.StatusReasons, "digest disabled by user")
+		params.DeliverAfters = append(params.DeliverAfters, now)
+	}
+	_, err := n.store.BulkDeferNotificationMessages(ctx, params)
+	if err != nil {
+		return xerrors.Errorf("defer messages: %w", err)
+	}
+	return nil
+}
+
+func (n *notifier) enqueueDigest(ctx context.Context, rows []database.AcquireNotificationDigestMessagesRow, helpers template.FuncMap) error {
+	var (
+		userID = rows[0].UserID
+		method = rows[0].Method
+		loc    = scheduleLocation(rows[0].Timezone)
+		items  = make([]map[string]any, 0, len(rows))
+		ids    = make([]uuid.UUID, 0, len(rows))
+		since  = rows[0].CreatedAt
+	)
+	for _, row := range rows {
+		title := row.TemplateName
+		var payload types.MessagePayload
+		if err := json.Unmarshal(row.Payload, &payload); err == nil {
+			if rendered, err := render.GoTemplate(row.TitleTemplate, payload, helpers); err == nil {
+				title = rendered
+			}
+		}
+		if row.CreatedAt.Before(since) {
+			since = row.CreatedAt
+		}
+		items = append(items, map[string]any{
+			"name":  row.TemplateName,
+			"title": title,
+			"time":  row.CreatedAt.In(loc).Format("Jan 2 15:04"),
+		})
+		ids = append(ids, row.ID)
+	}
+
+	metadata, err := n.store.FetchNewMessageMetadata(ctx, database.FetchNewMessageMetadataParams{
+		UserID:                 userID,
+		NotificationTemplateID: TemplateNotificationDigest,
+	})
+	if err != nil {
+		return xerrors.Errorf("new message metadata: %w", err)
+	}
+	payload, err := buildPayload(metadata, nil, map[string]any{
+		"count":         len(items),
+		"since":         since.In(loc).Format("Jan 2 15:04 MST"),
+		"notifications": items,
+	}, nil, helpers)
+	if err != nil {
+		return xerrors.Errorf("build payload: %w", err)
+	}
+	input, err := json.Marshal(payload)
+	if err != nil {
+		return xerrors.Errorf("encode payload: %w", err)
+	}
+
+	now := dbtime.Time(n.clock.Now().UTC())
+	return n.store.InTx(func(tx database.Store) error {
+		err := tx.EnqueueNotificationMessage(ctx, database.EnqueueNotificationMessageParams{
+			ID:                     uuid.New(),
+			UserID:                 userID,
+			NotificationTemplateID: TemplateNotificationDigest,
+			Method:                 method,
+			Payload:                input,
+			CreatedBy:              "notifier",
+			CreatedAt:              now,
+		})
+		if err != nil {
+			// See StoreEnqueuer.EnqueueWithData.
+			if strings.Contains(err.Error(), ErrCannotEnqueueDisabledNotification.Error()) {
+				return ErrCannotEnqueueDisabledNotification
+			}
+			return xerrors.Errorf("enqueue digest: %w", err)
+		}
+
+		sentAts := make([]time.Time, len(ids))
+		for i := range sentAts {
+			sentAts[i] = now
+		}
+		_, err = tx.BulkMarkNotificationMessagesSent(ctx, database.BulkMarkNotificationMessagesSentParams{
+			IDs:     ids,
+			SentAts: sentAts,
+		})
+		if err != nil {
+			return xerrors.Errorf("mark digest messages sent: %w", err)
+		}
+		return nil
+	}, nil)
+}
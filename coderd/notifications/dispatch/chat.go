@@ -0,0 +1,129 @@
+package dispatch
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/v2/coderd/database"
+	"github.com/coder/coder/v2/coderd/notifications/types"
+)
+
+// ChatIdentityStore looks up the chat accounts users linked to receive
+// notifications as direct messages.
+type ChatIdentityStore interface {
+	GetUserChatIdentity(ctx context.Context, arg database.GetUserChatIdentityParams) (database.UserChatIdentity, error)
+}
+
+// chatIdentity returns the account the recipient linked for method, or an
+// empty string if they have not linked and verified one. Unverified
+// identities are ignored, as anyone could claim them.
+func chatIdentity(ctx context.Context, store ChatIdentityStore, payload types.MessagePayload, method database.NotificationMethod) (string, error) {
+	userID, err := uuid.Parse(payload.UserID)
+	if err != nil {
+		return "", xerrors.Errorf("parse user ID: %w", err)
+	}
+	identity, err := store.GetUserChatIdentity(ctx, database.GetUserChatIdentityParams{
+		UserID: userID,
+		Method: method,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", xerrors.Errorf("get chat identity: %w", err)
+	}
+	if !identity.VerifiedAt.Valid {
+		return "", nil
+	}
+	return identity.Identity, nil
+}
+
+// VerificationMessage is the direct message that carries the one-time code
+// users enter to prove they own the chat account they linked.
+func VerificationMessage(code string) string {
+	return fmt.Sprintf("Your Coder verification code is %s. If you did not link this account to Coder, ignore this message.", code)
+}
+
+// chatRequest sends a JSON request to a chat platform and decodes the JSON
+// response into out, if it is not nil. Rate limits and server errors are
+// retryable, other errors are not as they are caused by the configuration
+// or the message.
+func chatRequest(ctx context.Context, cl *http.Client, method, url string, header http.Header, in, out any) (retryable bool, err error) {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return false, xerrors.Errorf("marshal request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	// Outer context has a deadline (see CODER_NOTIFICATIONS_DISPATCH_TIMEOUT).
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return false, xerrors.Errorf("create HTTP request: %w", err)
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	}
+
+	resp, err := cl.Do(req)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return true, xerrors.Errorf("request timeout: %w", err)
+		}
+		return true, xerrors.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		// Grab the start of the body, it usually explains the error.
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		retryable := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		return retryable, &chatStatusError{statusCode: resp.StatusCode, body: string(bytes.TrimSpace(respBody))}
+	}
+	if out == nil {
+		return false, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return true, xerrors.Errorf("decode response: %w", err)
+	}
+	return false, nil
+}
+
+// chatStatusError is returned for non-2xx responses of chat platforms.
+type chatStatusError struct {
+	statusCode int
+	body       string
+}
+
+func (e *chatStatusError) Error() string {
+	return fmt.Sprintf("non-2xx response (%d): %s", e.statusCode, e.body)
+}
+
+func isChatStatus(err error, statusCode int) bool {
+	var statusErr *chatStatusError
+	return errors.As(err, &statusErr) && statusErr.statusCode == statusCode
+}
+
+// truncate shortens s to at most n runes, as chat platforms reject
+// messages with overly long fields.
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
@@ -0,0 +1,201 @@
+package dispatch
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+
+	"github.com/coder/coder/v2/coderd/database"
+	"github.com/coder/coder/v2/coderd/notifications/types"
+	markdown "github.com/coder/coder/v2/coderd/render"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+// MatrixHandler dispatches notification messages to Matrix rooms with the
+// client-server API. Users who linked a Matrix account receive messages in
+// a direct chat with the notification account, the configured room receives
+// all other messages.
+//
+// Direct chats are tracked in the m.direct account data of the notification
+// account, as Matrix clients do, so they are reused across restarts and
+// replicas.
+type MatrixHandler struct {
+	cfg   codersdk.NotificationsMatrixConfig
+	store ChatIdentityStore
+	log   slog.Logger
+
+	cl *http.Client
+
+	mu     sync.Mutex // Protects following.
+	self   string
+	direct map[string]string
+}
+
+// MatrixMessage is the content of an m.room.message event, see
+// https://spec.matrix.org/v1.10/client-server-api/#mroommessage.
+type MatrixMessage struct {
+	MsgType       string `json:"msgtype"`
+	Body          string `json:"body"`
+	Format        string `json:"format,omitempty"`
+	FormattedBody string `json:"formatted_body,omitempty"`
+}
+
+func NewMatrixHandler(cfg codersdk.NotificationsMatrixConfig, store ChatIdentityStore, log slog.Logger) *MatrixHandler {
+	return &MatrixHandler{cfg: cfg, store: store, log: log, cl: &http.Client{}, direct: map[string]string{}}
+}
+
+func (m *MatrixHandler) Dispatcher(payload types.MessagePayload, titleMarkdown, bodyMarkdown string, _ template.FuncMap) (DeliveryFunc, error) {
+	if m.cfg.Homeserver.String() == "" || m.cfg.AccessToken.String() == "" {
+		return nil, xerrors.New("matrix homeserver or access token not defined")
+	}
+
+	title, err := markdown.PlaintextFromMarkdown(titleMarkdown)
+	if err != nil {
+		return nil, xerrors.Errorf("render title: %w", err)
+	}
+	body, err := markdown.PlaintextFromMarkdown(bodyMarkdown)
+	if err != nil {
+		return nil, xerrors.Errorf("render body: %w", err)
+	}
+
+	var plain, formatted strings.Builder
+	_, _ = fmt.Fprintf(&plain, "%s\n\n%s", title, body)
+	_, _ = fmt.Fprintf(&formatted, "<h4>%s</h4>\n%s", html.EscapeString(title), markdown.HTMLFromMarkdown(bodyMarkdown))
+	if len(payload.Actions) > 0 {
+		plain.WriteString("\n")
+		formatted.WriteString("\n<p>")
+		for i, action := range payload.Actions {
+			_, _ = fmt.Fprintf(&plain, "\n%s: %s", action.Label, action.URL)
+			if i > 0 {
+				formatted.WriteString(" · ")
+			}
+			_, _ = fmt.Fprintf(&formatted, `<a href="%s">%s</a>`, html.EscapeString(action.URL), html.EscapeString(action.Label))
+		}
+		formatted.WriteString("</p>")
+	}
+
+	// Notices are meant for bots, clients do not notify for them unless the
+	// room is set to notify for all messages, which direct chats are.
+	msg := MatrixMessage{
+		MsgType:       "m.notice",
+		Body:          plain.String(),
+		Format:        "org.matrix.custom.html",
+		FormattedBody: formatted.String(),
+	}
+	return m.dispatch(payload, msg), nil
+}
+
+func (m *MatrixHandler) dispatch(payload types.MessagePayload, msg MatrixMessage) DeliveryFunc {
+	return func(ctx context.Context, msgID uuid.UUID) (retryable bool, err error) {
+		identity, err := chatIdentity(ctx, m.store, payload, database.NotificationMethodMatrix)
+		if err != nil {
+			return true, err
+		}
+		room := m.cfg.Room.String()
+		if identity != "" {
+			room, retryable, err = m.directRoom(ctx, identity)
+			if err != nil {
+				return retryable, xerrors.Errorf("find direct chat with %s: %w", identity, err)
+			}
+		}
+		if room == "" {
+			return false, xerrors.New("user has not linked a Matrix account and no Matrix room is configured")
+		}
+
+		// The message ID is the transaction ID, so the homeserver ignores
+		// retries of messages it has already received.
+		retryable, err = m.request(ctx, http.MethodPut,
+			fmt.Sprintf("/rooms/%s/send/m.room.message/%s", url.PathEscape(room), msgID), msg, nil)
+		if err != nil {
+			m.log.Warn(ctx, "unsuccessful delivery", slog.Error(err), slog.F("msg_id", msgID))
+			return retryable, err
+		}
+		return false, nil
+	}
+}
+
+// SendVerificationCode sends the code to the Matrix user in a direct chat,
+// so only the owner of the account can enter it.
+func (m *MatrixHandler) SendVerificationCode(ctx context.Context, identity, code string) error {
+	if m.cfg.Homeserver.String() == "" || m.cfg.AccessToken.String() == "" {
+		return xerrors.New("matrix homeserver or access token not defined")
+	}
+	room, _, err := m.directRoom(ctx, identity)
+	if err != nil {
+		return xerrors.Errorf("find direct chat with %s: %w", identity, err)
+	}
+	_, err = m.request(ctx, http.MethodPut,
+		fmt.Sprintf("/rooms/%s/send/m.room.message/%s", url.PathEscape(room), uuid.New()), MatrixMessage{
+			MsgType: "m.text",
+			Body:    VerificationMessage(code),
+		}, nil)
+	return err
+}
+
+// directRoom returns the direct chat with user, creating one if there is
+// none.
+func (m *MatrixHandler) directRoom(ctx context.Context, user string) (room string, retryable bool, err error) {
+	// Hold the lock throughout so that concurrent messages to the same user
+	// do not create several rooms.
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if room, ok := m.direct[user]; ok {
+		return room, false, nil
+	}
+
+	if m.self == "" {
+		var whoami struct {
+			UserID string `json:"user_id"`
+		}
+		if retryable, err := m.request(ctx, http.MethodGet, "/account/whoami", nil, &whoami); err != nil {
+			return "", retryable, xerrors.Errorf("get own user ID: %w", err)
+		}
+		m.self = whoami.UserID
+	}
+
+	direct := map[string][]string{}
+	path := fmt.Sprintf("/user/%s/account_data/m.direct", url.PathEscape(m.self))
+	retryable, err = m.request(ctx, http.MethodGet, path, nil, &direct)
+	// The account data does not exist until the first direct chat.
+	if err != nil && !isChatStatus(err, http.StatusNotFound) {
+		return "", retryable, xerrors.Errorf("get direct chats: %w", err)
+	}
+	if rooms := direct[user]; len(rooms) > 0 {
+		m.direct[user] = rooms[len(rooms)-1]
+		return m.direct[user], false, nil
+	}
+
+	var created struct {
+		RoomID string `json:"room_id"`
+	}
+	if retryable, err := m.request(ctx, http.MethodPost, "/createRoom", map[string]any{
+		"is_direct": true,
+		"invite":    []string{user},
+		"preset":    "trusted_private_chat",
+	}, &created); err != nil {
+		return "", retryable, xerrors.Errorf("create direct chat: %w", err)
+	}
+	direct[user] = append(direct[user], created.RoomID)
+	if retryable, err := m.request(ctx, http.MethodPut, path, direct, nil); err != nil {
+		// The room exists, but would be created again next time.
+		return "", retryable, xerrors.Errorf("save direct chat: %w", err)
+	}
+	m.direct[user] = created.RoomID
+	return created.RoomID, false, nil
+}
+
+func (m *MatrixHandler) request(ctx context.Context, method, path string, in, out any) (bool, error) {
+	return chatRequest(ctx, m.cl, method, strings.TrimSuffix(m.cfg.Homeserver.String(), "/")+"/_matrix/client/v3"+path, http.Header{
+		"Authorization": {"Bearer " + m.cfg.AccessToken.String()},
+	}, in, out)
+}
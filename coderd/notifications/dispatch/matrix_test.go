@@ -0,0 +1,197 @@
+package dispatch_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/sloggers/slogtest"
+	"github.com/coder/serpent"
+
+	"github.com/coder/coder/v2/coderd/database"
+	"github.com/coder/coder/v2/coderd/notifications/dispatch"
+	"github.com/coder/coder/v2/coderd/notifications/types"
+	"github.com/coder/coder/v2/codersdk"
+	"github.com/coder/coder/v2/testutil"
+)
+
+// fakeHomeserver implements the parts of the Matrix client-server API used
+// by the Matrix handler.
+type fakeHomeserver struct {
+	t *testing.T
+
+	mu       sync.Mutex
+	direct   map[string][]string
+	created  int
+	messages map[string][]dispatch.MatrixMessage
+	txns     map[string]bool
+}
+
+func newFakeHomeserver(t *testing.T) *fakeHomeserver {
+	return &fakeHomeserver{t: t, messages: map[string][]dispatch.MatrixMessage{}, txns: map[string]bool{}}
+}
+
+func (f *fakeHomeserver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	assert.Equal(f.t, "Bearer syt_test", r.Header.Get("Authorization"))
+	path := strings.TrimPrefix(r.URL.EscapedPath(), "/_matrix/client/v3")
+	switch {
+	case path == "/account/whoami":
+		_ = json.NewEncoder(w).Encode(map[string]string{"user_id": "@coder:example.com"})
+	case path == "/user/@coder:example.com/account_data/m.direct" && r.Method == http.MethodGet:
+		if f.direct == nil {
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(map[string]string{"errcode": "M_NOT_FOUND"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(f.direct)
+	case path == "/user/@coder:example.com/account_data/m.direct" && r.Method == http.MethodPut:
+		assert.NoError(f.t, json.NewDecoder(r.Body).Decode(&f.direct))
+		_, _ = w.Write([]byte("{}"))
+	case path == "/createRoom":
+		var req struct {
+			IsDirect bool     `json:"is_direct"`
+			Invite   []string `json:"invite"`
+		}
+		assert.NoError(f.t, json.NewDecoder(r.Body).Decode(&req))
+		assert.True(f.t, req.IsDirect)
+		f.created++
+		_ = json.NewEncoder(w).Encode(map[string]string{"room_id": "!direct" + req.Invite[0]})
+	case strings.HasPrefix(path, "/rooms/") && r.Method == http.MethodPut:
+		parts := strings.Split(strings.TrimPrefix(path, "/rooms/"), "/")
+		assert.Len(f.t, parts, 4)
+		room, err := url.PathUnescape(parts[0])
+		assert.NoError(f.t, err)
+		// Transaction IDs make sends idempotent.
+		if !f.txns[parts[3]] {
+			f.txns[parts[3]] = true
+			var msg dispatch.MatrixMessage
+			assert.NoError(f.t, json.NewDecoder(r.Body).Decode(&msg))
+			f.messages[room] = append(f.messages[room], msg)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"event_id": "$" + parts[3]})
+	default:
+		f.t.Errorf("unexpected request %s %s", r.Method, path)
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestMatrix(t *testing.T) {
+	t.Parallel()
+
+	const (
+		titleMarkdown = "Workspace **dev** stopped"
+		bodyMarkdown  = "Your workspace was *stopped*."
+	)
+
+	logger := slogtest.Make(t, &slogtest.Options{IgnoreErrors: true}).Leveled(slog.LevelDebug)
+
+	setup := func(t *testing.T, room string, identities map[database.NotificationMethod]string) (*fakeHomeserver, func() *dispatch.MatrixHandler, types.MessagePayload) {
+		homeserver := newFakeHomeserver(t)
+		server := httptest.NewServer(homeserver)
+		t.Cleanup(server.Close)
+		u, err := url.Parse(server.URL)
+		require.NoError(t, err)
+
+		userID := uuid.New()
+		payload := types.MessagePayload{
+			Version:          "1.2",
+			NotificationName: "test",
+			UserID:           userID.String(),
+			Actions:          []types.TemplateAction{{Label: "View workspace", URL: "https://coder.com/@alice/dev"}},
+		}
+		newHandler := func() *dispatch.MatrixHandler {
+			return dispatch.NewMatrixHandler(codersdk.NotificationsMatrixConfig{
+				Homeserver:  *serpent.URLOf(u),
+				AccessToken: "syt_test",
+				Room:        serpent.String(room),
+			}, fakeChatIdentities{userID: userID, identities: identities}, logger)
+		}
+		return homeserver, newHandler, payload
+	}
+
+	t.Run("Room", func(t *testing.T) {
+		t.Parallel()
+		ctx := testutil.Context(t, testutil.WaitLong)
+		homeserver, newHandler, payload := setup(t, "!ops:example.com", nil)
+
+		deliveryFn, err := newHandler().Dispatcher(payload, titleMarkdown, bodyMarkdown, helpers())
+		require.NoError(t, err)
+		msgID := uuid.New()
+		retryable, err := deliveryFn(ctx, msgID)
+		require.NoError(t, err)
+		require.False(t, retryable)
+		// A retry of the same message is ignored by the homeserver.
+		_, err = deliveryFn(ctx, msgID)
+		require.NoError(t, err)
+
+		require.Len(t, homeserver.messages["!ops:example.com"], 1)
+		msg := homeserver.messages["!ops:example.com"][0]
+		require.Equal(t, "m.notice", msg.MsgType)
+		require.Contains(t, msg.Body, "Workspace dev stopped")
+		require.Contains(t, msg.Body, "View workspace: https://coder.com/@alice/dev")
+		require.Contains(t, msg.FormattedBody, "<h4>Workspace dev stopped</h4>")
+		require.Contains(t, msg.FormattedBody, `<a href="https://coder.com/@alice/dev">View workspace</a>`)
+		require.Zero(t, homeserver.created)
+	})
+
+	t.Run("DirectChat", func(t *testing.T) {
+		t.Parallel()
+		ctx := testutil.Context(t, testutil.WaitLong)
+		homeserver, newHandler, payload := setup(t, "!ops:example.com", map[database.NotificationMethod]string{
+			database.NotificationMethodMatrix: "@alice:example.com",
+		})
+
+		deliveryFn, err := newHandler().Dispatcher(payload, titleMarkdown, bodyMarkdown, helpers())
+		require.NoError(t, err)
+		for range 2 {
+			_, err = deliveryFn(ctx, uuid.New())
+			require.NoError(t, err)
+		}
+		require.Equal(t, 1, homeserver.created)
+		require.Equal(t, map[string][]string{"@alice:example.com": {"!direct@alice:example.com"}}, homeserver.direct)
+
+		// A new handler, as after a restart, reuses the direct chat.
+		deliveryFn, err = newHandler().Dispatcher(payload, titleMarkdown, bodyMarkdown, helpers())
+		require.NoError(t, err)
+		_, err = deliveryFn(ctx, uuid.New())
+		require.NoError(t, err)
+		require.Equal(t, 1, homeserver.created)
+		require.Len(t, homeserver.messages["!direct@alice:example.com"], 3)
+		require.Empty(t, homeserver.messages["!ops:example.com"])
+	})
+
+	t.Run("VerificationCode", func(t *testing.T) {
+		t.Parallel()
+		ctx := testutil.Context(t, testutil.WaitLong)
+		homeserver, newHandler, _ := setup(t, "", nil)
+
+		require.NoError(t, newHandler().SendVerificationCode(ctx, "@alice:example.com", "ABCD1234"))
+		require.Equal(t, 1, homeserver.created)
+		require.Len(t, homeserver.messages["!direct@alice:example.com"], 1)
+		require.Contains(t, homeserver.messages["!direct@alice:example.com"][0].Body, "ABCD1234")
+	})
+
+	t.Run("NoRecipient", func(t *testing.T) {
+		t.Parallel()
+		ctx := testutil.Context(t, testutil.WaitLong)
+		_, newHandler, payload := setup(t, "", nil)
+
+		deliveryFn, err := newHandler().Dispatcher(payload, titleMarkdown, bodyMarkdown, helpers())
+		require.NoError(t, err)
+		retryable, err := deliveryFn(ctx, uuid.New())
+		require.ErrorContains(t, err, "no Matrix room is configured")
+		require.False(t, retryable)
+	})
+}
@@ -0,0 +1,195 @@
+package dispatch
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+
+	"github.com/coder/coder/v2/coderd/database"
+	"github.com/coder/coder/v2/coderd/notifications/types"
+	markdown "github.com/coder/coder/v2/coderd/render"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+// SlackHandler dispatches notification messages to Slack with the
+// chat.postMessage API. Users who linked a Slack account receive a direct
+// message from the app, the configured channel receives all other messages.
+type SlackHandler struct {
+	cfg   codersdk.NotificationsSlackConfig
+	store ChatIdentityStore
+	log   slog.Logger
+
+	cl *http.Client
+}
+
+// SlackMessage is a chat.postMessage request, see
+// https://api.slack.com/methods/chat.postMessage.
+type SlackMessage struct {
+	Channel string `json:"channel"`
+	// Text is shown in notifications, the blocks are shown in Slack.
+	Text        string       `json:"text"`
+	Blocks      []SlackBlock `json:"blocks"`
+	UnfurlLinks bool         `json:"unfurl_links"`
+}
+
+// SlackBlock is a Block Kit layout block.
+type SlackBlock struct {
+	Type     string         `json:"type"`
+	Text     *SlackText     `json:"text,omitempty"`
+	Elements []SlackElement `json:"elements,omitempty"`
+}
+
+type SlackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// SlackElement is a Block Kit button linking to a URL.
+type SlackElement struct {
+	Type     string    `json:"type"`
+	Text     SlackText `json:"text"`
+	URL      string    `json:"url"`
+	ActionID string    `json:"action_id"`
+}
+
+type slackResponse struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error"`
+}
+
+// Limits of Block Kit, see https://api.slack.com/reference/block-kit/blocks.
+const (
+	slackHeaderMaxLength  = 150
+	slackSectionMaxLength = 3000
+	slackButtonMaxLength  = 75
+	slackMaxButtons       = 25
+)
+
+func NewSlackHandler(cfg codersdk.NotificationsSlackConfig, store ChatIdentityStore, log slog.Logger) *SlackHandler {
+	return &SlackHandler{cfg: cfg, store: store, log: log, cl: &http.Client{}}
+}
+
+func (s *SlackHandler) Dispatcher(payload types.MessagePayload, titleMarkdown, bodyMarkdown string, _ template.FuncMap) (DeliveryFunc, error) {
+	if s.cfg.BotToken.String() == "" {
+		return nil, xerrors.New("slack bot token not defined")
+	}
+
+	title, err := markdown.PlaintextFromMarkdown(titleMarkdown)
+	if err != nil {
+		return nil, xerrors.Errorf("render title: %w", err)
+	}
+
+	msg := SlackMessage{
+		Text: title,
+		Blocks: []SlackBlock{
+			{Type: "header", Text: &SlackText{Type: "plain_text", Text: truncate(title, slackHeaderMaxLength)}},
+			{Type: "section", Text: &SlackText{Type: "mrkdwn", Text: truncate(SlackMrkdwn(bodyMarkdown), slackSectionMaxLength)}},
+		},
+	}
+	if len(payload.Actions) > 0 {
+		actions := SlackBlock{Type: "actions"}
+		for i, action := range payload.Actions {
+			if i == slackMaxButtons {
+				break
+			}
+			actions.Elements = append(actions.Elements, SlackElement{
+				Type:     "button",
+				Text:     SlackText{Type: "plain_text", Text: truncate(action.Label, slackButtonMaxLength)},
+				URL:      action.URL,
+				ActionID: uuid.NewString(),
+			})
+		}
+		msg.Blocks = append(msg.Blocks, actions)
+	}
+
+	return s.dispatch(payload, msg), nil
+}
+
+func (s *SlackHandler) dispatch(payload types.MessagePayload, msg SlackMessage) DeliveryFunc {
+	return func(ctx context.Context, msgID uuid.UUID) (retryable bool, err error) {
+		identity, err := chatIdentity(ctx, s.store, payload, database.NotificationMethodSlack)
+		if err != nil {
+			return true, err
+		}
+		// Posting to a member ID sends a direct message from the app.
+		msg := msg
+		msg.Channel = identity
+		if msg.Channel == "" {
+			msg.Channel = s.cfg.Channel.String()
+		}
+		if msg.Channel == "" {
+			return false, xerrors.New("user has not linked a Slack account and no Slack channel is configured")
+		}
+
+		retryable, err = s.post(ctx, msg)
+		if err != nil {
+			s.log.Warn(ctx, "unsuccessful delivery", slog.Error(err), slog.F("msg_id", msgID))
+			return retryable, err
+		}
+		return false, nil
+	}
+}
+
+// SendVerificationCode sends the code to the Slack member as a direct
+// message, so only the owner of the account can enter it.
+func (s *SlackHandler) SendVerificationCode(ctx context.Context, identity, code string) error {
+	if s.cfg.BotToken.String() == "" {
+		return xerrors.New("slack bot token not defined")
+	}
+	text := VerificationMessage(code)
+	_, err := s.post(ctx, SlackMessage{
+		Channel: identity,
+		Text:    text,
+		Blocks: []SlackBlock{
+			{Type: "section", Text: &SlackText{Type: "plain_text", Text: text}},
+		},
+	})
+	return err
+}
+
+func (s *SlackHandler) post(ctx context.Context, msg SlackMessage) (retryable bool, err error) {
+	var resp slackResponse
+	retryable, err = chatRequest(ctx, s.cl, http.MethodPost, strings.TrimSuffix(s.cfg.APIURL.String(), "/")+"/chat.postMessage", http.Header{
+		"Authorization": {"Bearer " + s.cfg.BotToken.String()},
+	}, msg, &resp)
+	if err != nil {
+		return retryable, err
+	}
+	// Slack reports most errors with a 200 response.
+	if !resp.OK {
+		switch resp.Error {
+		case "ratelimited", "internal_error", "fatal_error", "service_unavailable", "request_timeout":
+			return true, xerrors.Errorf("slack error: %s", resp.Error)
+		}
+		return false, xerrors.Errorf("slack error: %s", resp.Error)
+	}
+	return false, nil
+}
+
+var (
+	markdownLinkRegex   = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	markdownBoldRegex   = regexp.MustCompile(`\*\*([^*]+)\*\*|__([^_]+)__`)
+	markdownHeaderRegex = regexp.MustCompile(`(?m)^#{1,6}\s+(.+)$`)
+	markdownBulletRegex = regexp.MustCompile(`(?m)^(\s*)[-*]\s+`)
+)
+
+// SlackMrkdwn converts the Markdown of notification templates to Slack's
+// mrkdwn, see https://api.slack.com/reference/surfaces/formatting.
+func SlackMrkdwn(md string) string {
+	text := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(strings.TrimSpace(md))
+	// Bullets first, as their asterisks would be taken for bold text.
+	text = markdownBulletRegex.ReplaceAllString(text, "${1}• ")
+	text = markdownLinkRegex.ReplaceAllString(text, "<$2|$1>")
+	text = markdownBoldRegex.ReplaceAllStringFunc(text, func(m string) string {
+		return "*" + m[2:len(m)-2] + "*"
+	})
+	text = markdownHeaderRegex.ReplaceAllString(text, "*$1*")
+	return text
+}
@@ -0,0 +1,189 @@
+package dispatch_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/sloggers/slogtest"
+	"github.com/coder/serpent"
+
+	"github.com/coder/coder/v2/coderd/database"
+	"github.com/coder/coder/v2/coderd/notifications/dispatch"
+	"github.com/coder/coder/v2/coderd/notifications/types"
+	"github.com/coder/coder/v2/codersdk"
+	"github.com/coder/coder/v2/testutil"
+)
+
+func TestSlack(t *testing.T) {
+	t.Parallel()
+
+	const (
+		titleMarkdown = "Workspace **dev** stopped"
+		bodyMarkdown  = "Your workspace [dev](https://coder.com/@alice/dev) was stopped."
+		botToken      = "xoxb-test"
+	)
+
+	userID := uuid.New()
+	msgPayload := types.MessagePayload{
+		Version:          "1.2",
+		NotificationName: "test",
+		UserID:           userID.String(),
+		Actions: []types.TemplateAction{
+			{Label: "View workspace", URL: "https://coder.com/@alice/dev"},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		channel    string
+		identities map[database.NotificationMethod]string
+		unverified bool
+		response   func(w http.ResponseWriter)
+
+		expectChannel   string
+		expectRetryable bool
+		expectErr       string
+	}{
+		{
+			name:          "direct message",
+			channel:       "#coder",
+			identities:    map[database.NotificationMethod]string{database.NotificationMethodSlack: "U012AB3CD"},
+			expectChannel: "U012AB3CD",
+		},
+		{
+			name:          "channel fallback",
+			channel:       "#coder",
+			expectChannel: "#coder",
+		},
+		{
+			name:          "unverified identity",
+			channel:       "#coder",
+			identities:    map[database.NotificationMethod]string{database.NotificationMethodSlack: "U012AB3CD"},
+			unverified:    true,
+			expectChannel: "#coder",
+		},
+		{
+			name:      "no recipient",
+			expectErr: "no Slack channel is configured",
+		},
+		{
+			name:          "slack error",
+			channel:       "#coder",
+			expectChannel: "#coder",
+			response: func(w http.ResponseWriter) {
+				_ = json.NewEncoder(w).Encode(map[string]any{"ok": false, "error": "channel_not_found"})
+			},
+			expectErr: "channel_not_found",
+		},
+		{
+			name:          "rate limited",
+			channel:       "#coder",
+			expectChannel: "#coder",
+			response: func(w http.ResponseWriter) {
+				w.WriteHeader(http.StatusTooManyRequests)
+			},
+			expectRetryable: true,
+			expectErr:       "non-2xx response (429)",
+		},
+	}
+
+	logger := slogtest.Make(t, &slogtest.Options{IgnoreErrors: true}).Leveled(slog.LevelDebug)
+
+	// nolint:paralleltest // Irrelevant as of Go v1.22
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctx := testutil.Context(t, testutil.WaitLong)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/chat.postMessage", r.URL.Path)
+				assert.Equal(t, "Bearer "+botToken, r.Header.Get("Authorization"))
+
+				var msg dispatch.SlackMessage
+				assert.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+				assert.Equal(t, tc.expectChannel, msg.Channel)
+				assert.Equal(t, "Workspace dev stopped", msg.Text)
+				if assert.Len(t, msg.Blocks, 3) {
+					assert.Equal(t, "header", msg.Blocks[0].Type)
+					assert.Equal(t, "Your workspace <https://coder.com/@alice/dev|dev> was stopped.", msg.Blocks[1].Text.Text)
+					if assert.Len(t, msg.Blocks[2].Elements, 1) {
+						assert.Equal(t, "https://coder.com/@alice/dev", msg.Blocks[2].Elements[0].URL)
+					}
+				}
+
+				if tc.response != nil {
+					tc.response(w)
+					return
+				}
+				_ = json.NewEncoder(w).Encode(map[string]any{"ok": true})
+			}))
+			defer server.Close()
+			apiURL, err := url.Parse(server.URL)
+			require.NoError(t, err)
+
+			handler := dispatch.NewSlackHandler(codersdk.NotificationsSlackConfig{
+				BotToken: serpent.String(botToken),
+				Channel:  serpent.String(tc.channel),
+				APIURL:   *serpent.URLOf(apiURL),
+			}, fakeChatIdentities{userID: userID, identities: tc.identities, unverified: tc.unverified}, logger.With(slog.F("test", tc.name)))
+			deliveryFn, err := handler.Dispatcher(msgPayload, titleMarkdown, bodyMarkdown, helpers())
+			require.NoError(t, err)
+
+			retryable, err := deliveryFn(ctx, uuid.New())
+			if tc.expectErr == "" {
+				require.NoError(t, err)
+				require.False(t, retryable)
+				return
+			}
+			require.ErrorContains(t, err, tc.expectErr)
+			require.Equal(t, tc.expectRetryable, retryable)
+		})
+	}
+}
+
+func TestSlackVerificationCode(t *testing.T) {
+	t.Parallel()
+	ctx := testutil.Context(t, testutil.WaitLong)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg dispatch.SlackMessage
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		assert.Equal(t, "U012AB3CD", msg.Channel)
+		assert.Contains(t, msg.Text, "ABCD1234")
+		_ = json.NewEncoder(w).Encode(map[string]any{"ok": true})
+	}))
+	defer server.Close()
+	apiURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	handler := dispatch.NewSlackHandler(codersdk.NotificationsSlackConfig{
+		BotToken: "xoxb-test",
+		APIURL:   *serpent.URLOf(apiURL),
+	}, fakeChatIdentities{}, slogtest.Make(t, nil))
+	require.NoError(t, handler.SendVerificationCode(ctx, "U012AB3CD", "ABCD1234"))
+}
+
+func TestSlackMrkdwn(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		in, out string
+	}{
+		{in: "plain text", out: "plain text"},
+		{in: "**bold** and __bold__", out: "*bold* and *bold*"},
+		{in: "[docs](https://coder.com/docs)", out: "<https://coder.com/docs|docs>"},
+		{in: "- one\n* two", out: "• one\n• two"},
+		{in: "## Header", out: "*Header*"},
+		{in: "a < b & c", out: "a &lt; b &amp; c"},
+	} {
+		require.Equal(t, tc.out, dispatch.SlackMrkdwn(tc.in), tc.in)
+	}
+}
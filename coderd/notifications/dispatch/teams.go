@@ -0,0 +1,161 @@
+package dispatch
+
+import (
+	"context"
+	"html"
+	"net/http"
+	"text/template"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+
+	"github.com/coder/coder/v2/coderd/database"
+	"github.com/coder/coder/v2/coderd/notifications/types"
+	markdown "github.com/coder/coder/v2/coderd/render"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+// TeamsHandler dispatches notification messages to a Microsoft Teams channel
+// as Adaptive Cards, through an incoming webhook or a Workflows webhook.
+// Webhooks cannot send direct messages, so users who linked a Teams account
+// are mentioned in the channel instead.
+type TeamsHandler struct {
+	cfg   codersdk.NotificationsTeamsConfig
+	store ChatIdentityStore
+	log   slog.Logger
+
+	cl *http.Client
+}
+
+// TeamsMessage is the message posted to a Teams webhook, carrying a single
+// Adaptive Card.
+type TeamsMessage struct {
+	Type        string            `json:"type"`
+	Attachments []TeamsAttachment `json:"attachments"`
+}
+
+type TeamsAttachment struct {
+	ContentType string    `json:"contentType"`
+	Content     TeamsCard `json:"content"`
+}
+
+// TeamsCard is an Adaptive Card, see https://adaptivecards.io/explorer/.
+type TeamsCard struct {
+	Schema  string          `json:"$schema"`
+	Type    string          `json:"type"`
+	Version string          `json:"version"`
+	Body    []TeamsElement  `json:"body"`
+	Actions []TeamsAction   `json:"actions,omitempty"`
+	MSTeams *TeamsCardProps `json:"msteams,omitempty"`
+}
+
+type TeamsElement struct {
+	Type   string `json:"type"`
+	Text   string `json:"text"`
+	Weight string `json:"weight,omitempty"`
+	Size   string `json:"size,omitempty"`
+	Wrap   bool   `json:"wrap"`
+}
+
+type TeamsAction struct {
+	Type  string `json:"type"`
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+// TeamsCardProps holds the Teams specific properties of a card.
+type TeamsCardProps struct {
+	Width    string         `json:"width,omitempty"`
+	Entities []TeamsMention `json:"entities,omitempty"`
+}
+
+// TeamsMention resolves the <at> tags of a card to users, see
+// https://learn.microsoft.com/en-us/microsoftteams/platform/task-modules-and-cards/cards/cards-format#mention-support-within-adaptive-cards.
+type TeamsMention struct {
+	Type      string             `json:"type"`
+	Text      string             `json:"text"`
+	Mentioned TeamsMentionTarget `json:"mentioned"`
+}
+
+type TeamsMentionTarget struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+func NewTeamsHandler(cfg codersdk.NotificationsTeamsConfig, store ChatIdentityStore, log slog.Logger) *TeamsHandler {
+	return &TeamsHandler{cfg: cfg, store: store, log: log, cl: &http.Client{}}
+}
+
+func (t *TeamsHandler) Dispatcher(payload types.MessagePayload, titleMarkdown, bodyMarkdown string, _ template.FuncMap) (DeliveryFunc, error) {
+	if t.cfg.WebhookURL.String() == "" {
+		return nil, xerrors.New("teams webhook URL not defined")
+	}
+
+	title, err := markdown.PlaintextFromMarkdown(titleMarkdown)
+	if err != nil {
+		return nil, xerrors.Errorf("render title: %w", err)
+	}
+
+	// TextBlocks support a subset of Markdown, which covers what the
+	// notification templates use.
+	card := TeamsCard{
+		Schema:  "http://adaptivecards.io/schemas/adaptive-card.json",
+		Type:    "AdaptiveCard",
+		Version: "1.4",
+		Body: []TeamsElement{
+			{Type: "TextBlock", Text: title, Weight: "Bolder", Size: "Medium", Wrap: true},
+			{Type: "TextBlock", Text: bodyMarkdown, Wrap: true},
+		},
+		MSTeams: &TeamsCardProps{Width: "Full"},
+	}
+	for _, action := range payload.Actions {
+		card.Actions = append(card.Actions, TeamsAction{Type: "Action.OpenUrl", Title: action.Label, URL: action.URL})
+	}
+
+	return t.dispatch(payload, card), nil
+}
+
+func (t *TeamsHandler) dispatch(payload types.MessagePayload, card TeamsCard) DeliveryFunc {
+	return func(ctx context.Context, msgID uuid.UUID) (retryable bool, err error) {
+		identity, err := chatIdentity(ctx, t.store, payload, database.NotificationMethodTeams)
+		if err != nil {
+			return true, err
+		}
+		card := card
+		if identity != "" {
+			name := payload.UserName
+			if name == "" {
+				name = payload.UserUsername
+			}
+			mention := "<at>" + html.EscapeString(name) + "</at>"
+			props := *card.MSTeams
+			props.Entities = []TeamsMention{{
+				Type:      "mention",
+				Text:      mention,
+				Mentioned: TeamsMentionTarget{ID: identity, Name: name},
+			}}
+			card.MSTeams = &props
+			card.Body = append([]TeamsElement{{Type: "TextBlock", Text: mention, Wrap: true}}, card.Body...)
+		}
+
+		msg := TeamsMessage{
+			Type: "message",
+			Attachments: []TeamsAttachment{{
+				ContentType: "application/vnd.microsoft.card.adaptive",
+				Content:     card,
+			}},
+		}
+		// Workflows webhooks respond with 202 Accepted, incoming webhooks
+		// with 200 OK.
+		retryable, err = chatRequest(ctx, t.cl, http.MethodPost, t.cfg.WebhookURL.String(), http.Header{
+			"X-Message-Id": {msgID.String()},
+		}, msg, nil)
+		if err != nil {
+			t.log.Warn(ctx, "unsuccessful delivery", slog.Error(err), slog.F("msg_id", msgID))
+			return retryable, err
+		}
+		return false, nil
+	}
+}
@@ -0,0 +1,130 @@
+package dispatch_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/sloggers/slogtest"
+	"github.com/coder/serpent"
+
+	"github.com/coder/coder/v2/coderd/database"
+	"github.com/coder/coder/v2/coderd/notifications/dispatch"
+	"github.com/coder/coder/v2/coderd/notifications/types"
+	"github.com/coder/coder/v2/codersdk"
+	"github.com/coder/coder/v2/testutil"
+)
+
+func TestTeams(t *testing.T) {
+	t.Parallel()
+
+	const (
+		titleMarkdown = "Workspace **dev** stopped"
+		bodyMarkdown  = "Your workspace was **stopped**."
+	)
+
+	userID := uuid.New()
+	msgPayload := types.MessagePayload{
+		Version:          "1.2",
+		NotificationName: "test",
+		UserID:           userID.String(),
+		UserName:         "Alice Liddell",
+		UserUsername:     "alice",
+		Actions: []types.TemplateAction{
+			{Label: "View workspace", URL: "https://coder.com/@alice/dev"},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		identities map[database.NotificationMethod]string
+		status     int
+
+		expectMention   string
+		expectRetryable bool
+		expectErr       string
+	}{
+		{
+			name:   "channel",
+			status: http.StatusOK,
+		},
+		{
+			name:          "mention",
+			identities:    map[database.NotificationMethod]string{database.NotificationMethodTeams: "alice@example.com"},
+			status:        http.StatusAccepted,
+			expectMention: "alice@example.com",
+		},
+		{
+			name:      "bad request",
+			status:    http.StatusBadRequest,
+			expectErr: "non-2xx response (400)",
+		},
+		{
+			name:            "server error",
+			status:          http.StatusBadGateway,
+			expectRetryable: true,
+			expectErr:       "non-2xx response (502)",
+		},
+	}
+
+	logger := slogtest.Make(t, &slogtest.Options{IgnoreErrors: true}).Leveled(slog.LevelDebug)
+
+	// nolint:paralleltest // Irrelevant as of Go v1.22
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctx := testutil.Context(t, testutil.WaitLong)
+			msgID := uuid.New()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, msgID.String(), r.Header.Get("X-Message-Id"))
+
+				var msg dispatch.TeamsMessage
+				assert.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+				assert.Equal(t, "message", msg.Type)
+				if assert.Len(t, msg.Attachments, 1) {
+					card := msg.Attachments[0].Content
+					assert.Equal(t, "AdaptiveCard", card.Type)
+					assert.Equal(t, []dispatch.TeamsAction{{Type: "Action.OpenUrl", Title: "View workspace", URL: "https://coder.com/@alice/dev"}}, card.Actions)
+					if tc.expectMention == "" {
+						assert.Empty(t, card.MSTeams.Entities)
+						if assert.Len(t, card.Body, 2) {
+							assert.Equal(t, "Workspace dev stopped", card.Body[0].Text)
+							assert.Equal(t, bodyMarkdown, card.Body[1].Text)
+						}
+					} else if assert.Len(t, card.MSTeams.Entities, 1) && assert.Len(t, card.Body, 3) {
+						assert.Equal(t, "<at>Alice Liddell</at>", card.Body[0].Text)
+						assert.Equal(t, card.Body[0].Text, card.MSTeams.Entities[0].Text)
+						assert.Equal(t, tc.expectMention, card.MSTeams.Entities[0].Mentioned.ID)
+					}
+				}
+				w.WriteHeader(tc.status)
+			}))
+			defer server.Close()
+			webhookURL, err := url.Parse(server.URL)
+			require.NoError(t, err)
+
+			handler := dispatch.NewTeamsHandler(codersdk.NotificationsTeamsConfig{
+				WebhookURL: *serpent.URLOf(webhookURL),
+			}, fakeChatIdentities{userID: userID, identities: tc.identities}, logger.With(slog.F("test", tc.name)))
+			deliveryFn, err := handler.Dispatcher(msgPayload, titleMarkdown, bodyMarkdown, helpers())
+			require.NoError(t, err)
+
+			retryable, err := deliveryFn(ctx, msgID)
+			if tc.expectErr == "" {
+				require.NoError(t, err)
+				require.False(t, retryable)
+				return
+			}
+			require.ErrorContains(t, err, tc.expectErr)
+			require.Equal(t, tc.expectRetryable, retryable)
+		})
+	}
+}
@@ -1,5 +1,15 @@
 package dispatch_test
 
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/coder/coder/v2/coderd/database"
+)
+
 func helpers() map[string]any {
 	return map[string]any{
 		"base_url":     func() string { return "http://test.com" },
@@ -8,3 +18,25 @@ func helpers() map[string]any {
 		"app_name":     func() string { return "Coder" },
 	}
 }
+
+// fakeChatIdentities is a dispatch.ChatIdentityStore holding the chat
+// identities of a single user.
+type fakeChatIdentities struct {
+	userID     uuid.UUID
+	identities map[database.NotificationMethod]string
+	// unverified identities have not been confirmed with a code yet.
+	unverified bool
+}
+
+func (f fakeChatIdentities) GetUserChatIdentity(_ context.Context, arg database.GetUserChatIdentityParams) (database.UserChatIdentity, error) {
+	identity, ok := f.identities[arg.Method]
+	if !ok || arg.UserID != f.userID {
+		return database.UserChatIdentity{}, sql.ErrNoRows
+	}
+	return database.UserChatIdentity{
+		UserID:     arg.UserID,
+		Method:     arg.Method,
+		Identity:   identity,
+		VerifiedAt: sql.NullTime{Time: time.Now(), Valid: !f.unverified},
+	}, nil
+}
@@ -80,6 +80,16 @@ func (s *StoreEnqueuer) Enqueue(ctx context.Context, userID, templateID uuid.UUI
 // Enqueue queues a notification message for later delivery.
 // Messages will be dequeued by a notifier later and dispatched.
 func (s *StoreEnqueuer) EnqueueWithData(ctx context.Context, userID, templateID uuid.UUID, labels map[string]string, data map[string]any, createdBy string, targets ...uuid.UUID) ([]uuid.UUID, error) {
+	return s.enqueue(ctx, database.NullNotificationMethod{}, userID, templateID, labels, data, createdBy, targets...)
+}
+
+// EnqueueWithMethod queues a notification message for delivery with the given dispatch method, instead of the method
+// configured on the template or the deployment default. The message is still delivered to the inbox, if enabled.
+func (s *StoreEnqueuer) EnqueueWithMethod(ctx context.Context, method database.NotificationMethod, userID, templateID uuid.UUID, labels map[string]string, data map[string]any, createdBy string, targets ...uuid.UUID) ([]uuid.UUID, error) {
+	return s.enqueue(ctx, database.NullNotificationMethod{NotificationMethod: method, Valid: true}, userID, templateID, labels, data, createdBy, targets...)
+}
+
+func (s *StoreEnqueuer) enqueue(ctx context.Context, method database.NullNotificationMethod, userID, templateID uuid.UUID, labels map[string]string, data map[string]any, createdBy string, targets ...uuid.UUID) ([]uuid.UUID, error) {
 	metadata, err := s.store.FetchNewMessageMetadata(ctx, database.FetchNewMessageMetadataParams{
 		UserID:                 userID,
 		NotificationTemplateID: templateID,
@@ -89,7 +99,7 @@ func (s *StoreEnqueuer) EnqueueWithData(ctx context.Context, userID, templateID
 		return nil, xerrors.Errorf("new message metadata: %w", err)
 	}
 
-	payload, err := s.buildPayload(metadata, labels, data, targets)
+	payload, err := buildPayload(metadata, labels, data, targets, s.helpers)
 	if err != nil {
 		s.log.Warn(ctx, "failed to build payload", slog.F("template_id", templateID), slog.F("user_id", userID), slog.Error(err))
 		return nil, xerrors.Errorf("enqueue notification (payload build): %w", err)
@@ -101,9 +111,12 @@ func (s *StoreEnqueuer) EnqueueWithData(ctx context.Context, userID, templateID
 	}
 
 	methods := []database.NotificationMethod{}
-	if metadata.CustomMethod.Valid {
+	switch {
+	case method.Valid:
+		methods = append(methods, method.NotificationMethod)
+	case metadata.CustomMethod.Valid:
 		methods = append(methods, metadata.CustomMethod.NotificationMethod)
-	} else if s.defaultEnabled {
+	case s.defaultEnabled:
 		methods = append(methods, s.defaultMethod)
 	}
 
@@ -165,7 +178,7 @@ func (s *StoreEnqueuer) EnqueueWithData(ctx context.Context, userID, templateID
 // buildPayload creates the payload that the notification will for variable substitution and/or routing.
 // The payload contains information about the recipient, the event that triggered the notification, and any subsequent
 // actions which can be taken by the recipient.
-func (s *StoreEnqueuer) buildPayload(metadata database.FetchNewMessageMetadataRow, labels map[string]string, data map[string]any, targets []uuid.UUID) (*types.MessagePayload, error) {
+func buildPayload(metadata database.FetchNewMessageMetadataRow, labels map[string]string, data map[string]any, targets []uuid.UUID, helpers template.FuncMap) (*types.MessagePayload, error) {
 	payload := types.MessagePayload{
 		Version: "1.2",
 
@@ -185,7 +198,7 @@ func (s *StoreEnqueuer) buildPayload(metadata database.FetchNewMessageMetadataRo
 	}
 
 	// Execute any templates in actions.
-	out, err := render.GoTemplate(string(metadata.Actions), payload, s.helpers)
+	out, err := render.GoTemplate(string(metadata.Actions), payload, helpers)
 	if err != nil {
 		return nil, xerrors.Errorf("render actions: %w", err)
 	}
@@ -216,3 +229,8 @@ func (*NoopEnqueuer) EnqueueWithData(context.Context, uuid.UUID, uuid.UUID, map[
 	// nolint:nilnil // irrelevant.
 	return nil, nil
 }
+
+func (*NoopEnqueuer) EnqueueWithMethod(context.Context, database.NotificationMethod, uuid.UUID, uuid.UUID, map[string]string, map[string]any, string, ...uuid.UUID) ([]uuid.UUID, error) {
+	// nolint:nilnil // irrelevant.
+	return nil, nil
+}
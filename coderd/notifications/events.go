@@ -18,6 +18,8 @@ var (
 	TemplateWorkspaceManualBuildFailed = uuid.MustParse("2faeee0f-26cb-4e96-821c-85ccb9f71513")
 	TemplateWorkspaceOutOfMemory       = uuid.MustParse("a9d027b4-ac49-4fb1-9f6d-45af15f64e7a")
 	TemplateWorkspaceOutOfDisk         = uuid.MustParse("f047f6a3-5713-40f7-85aa-0394cce9fa3a")
+	TemplateWorkspacePortShared        = uuid.MustParse("c6e2f0a5-5c8e-4e6b-9d4e-8c3b0f1a7d21")
+	TemplateWorkspaceDriftDetected     = uuid.MustParse("0b7e3f5a-9c2d-4e8b-a1f6-3d5c7e9b2a40")
 )
 
 // Account-related events.
@@ -40,6 +42,11 @@ var (
 
 	TemplateWorkspaceBuildsFailedReport = uuid.MustParse("34a20db2-e9cc-4a93-b0e4-8569699d7a00")
 	TemplateWorkspaceResourceReplaced   = uuid.MustParse("89d9745a-816e-4695-a17f-3d0a229e2b8d")
+
+	TemplateWorkspacesPendingDeletionReport = uuid.MustParse("e3b4f0c2-5a6d-4f1e-8b7c-2d9a0e1f3c45")
+	TemplateVersionAdoptionReport           = uuid.MustParse("7f2c9d4e-1b3a-4e6f-9c8d-5a0b1e2f3d67")
+	TemplateTopResourceConsumersReport      = uuid.MustParse("a1d5e8f3-6c2b-4d9a-8e7f-0b3c4d5e6f78")
+	TemplatePrebuildPoolHealthReport        = uuid.MustParse("c9e7a3b1-4d2f-4a8c-b6e5-1f0d2c3b4a89")
 )
 
 // Prebuilds-related events
@@ -49,5 +56,7 @@ var (
 
 // Notification-related events.
 var (
-	TemplateTestNotification = uuid.MustParse("c425f63e-716a-4bf4-ae24-78348f706c3f")
+	TemplateTestNotification          = uuid.MustParse("c425f63e-716a-4bf4-ae24-78348f706c3f")
+	TemplateNotificationDigest        = uuid.MustParse("8d7c6a5e-3f1b-4c2d-9e8a-7b6f5d4c3e2a")
+	TemplateNotificationRuleTriggered = uuid.MustParse("5b9e8c1d-2f4a-4e3b-a6c7-d8e9f0a1b2c3")
 )
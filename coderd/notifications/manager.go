@@ -127,6 +127,9 @@ func defaultHandlers(cfg codersdk.NotificationsConfig, log slog.Logger, store St
 		database.NotificationMethodSmtp:    dispatch.NewSMTPHandler(cfg.SMTP, log.Named("dispatcher.smtp")),
 		database.NotificationMethodWebhook: dispatch.NewWebhookHandler(cfg.Webhook, log.Named("dispatcher.webhook")),
 		database.NotificationMethodInbox:   dispatch.NewInboxHandler(log.Named("dispatcher.inbox"), store, ps),
+		database.NotificationMethodSlack:   dispatch.NewSlackHandler(cfg.Slack, store, log.Named("dispatcher.slack")),
+		database.NotificationMethodMatrix:  dispatch.NewMatrixHandler(cfg.Matrix, store, log.Named("dispatcher.matrix")),
+		database.NotificationMethodTeams:   dispatch.NewTeamsHandler(cfg.Teams, store, log.Named("dispatcher.teams")),
 	}
 }
 
@@ -3,6 +3,7 @@ package notifications_test
 import (
 	"bytes"
 	"context"
+	"database/sql"
 	_ "embed"
 	"encoding/json"
 	"flag"
@@ -1169,6 +1170,35 @@ func TestNotificationTemplates_Golden(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "TemplateWorkspaceOutOfMemory_TopProcesses",
+			id:   notifications.TemplateWorkspaceOutOfMemory,
+			payload: types.MessagePayload{
+				UserName:     "Bobby",
+				UserEmail:    "bobby@coder.com",
+				UserUsername: "bobby",
+				Labels: map[string]string{
+					"workspace": "bobby-workspace",
+					"threshold": "90%",
+				},
+				Data: map[string]any{
+					"top_processes": []map[string]any{
+						{
+							"pid":    42,
+							"name":   "node",
+							"memory": "2.0 GiB",
+							"cpu":    "12.3%",
+						},
+						{
+							"pid":    7,
+							"name":   "gopls",
+							"memory": "512 MiB",
+							"cpu":    "0.0%",
+						},
+					},
+				},
+			},
+		},
 		{
 			name: "TemplateWorkspaceOutOfDisk",
 			id:   notifications.TemplateWorkspaceOutOfDisk,
@@ -1217,6 +1247,47 @@ func TestNotificationTemplates_Golden(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "TemplateWorkspacePortShared",
+			id:   notifications.TemplateWorkspacePortShared,
+			payload: types.MessagePayload{
+				UserName:     "Bobby",
+				UserEmail:    "bobby@coder.com",
+				UserUsername: "bobby",
+				Labels: map[string]string{
+					"workspace":   "bobby-workspace",
+					"agent":       "main",
+					"port":        "8080",
+					"share_level": "public",
+					"label":       "docs",
+					"url":         "https://8080--main--bobby-workspace--bobby.apps.coder.com",
+				},
+			},
+		},
+		{
+			name: "TemplateWorkspaceDriftDetected",
+			id:   notifications.TemplateWorkspaceDriftDetected,
+			payload: types.MessagePayload{
+				UserName:     "Bobby",
+				UserEmail:    "bobby@coder.com",
+				UserUsername: "bobby",
+				Labels: map[string]string{
+					"name": "bobby-workspace",
+				},
+				Data: map[string]any{
+					"resources": []map[string]any{
+						{
+							"address": "docker_container.workspace[0]",
+							"action":  "changed",
+						},
+						{
+							"address": "docker_volume.home_volume",
+							"action":  "deleted",
+						},
+					},
+				},
+			},
+		},
 		{
 			name: "TemplateTestNotification",
 			id:   notifications.TemplateTestNotification,
@@ -1227,6 +1298,154 @@ func TestNotificationTemplates_Golden(t *testing.T) {
 				Labels:       map[string]string{},
 			},
 		},
+		{
+			name: "TemplateWorkspacesPendingDeletionReport",
+			id:   notifications.TemplateWorkspacesPendingDeletionReport,
+			payload: types.MessagePayload{
+				UserName:     "Bobby",
+				UserEmail:    "bobby@coder.com",
+				UserUsername: "bobby",
+				Labels:       map[string]string{},
+				Data: map[string]any{
+					"report_frequency": "week",
+					"templates": []map[string]any{
+						{
+							"name":         "bobby-first-template",
+							"display_name": "Bobby First Template",
+							"deleting": []map[string]any{
+								{"name": "workspace-1", "owner_username": "mtojek", "deleting_at": "Jan 18, 2024"},
+							},
+							"dormant": []map[string]any{
+								{"name": "my-workspace-3", "owner_username": "johndoe", "last_used_at": "Dec 12, 2023", "dormant_at": "Jan 11, 2024"},
+							},
+							"more": 0.0,
+						},
+						{
+							"name":         "bobby-second-template",
+							"display_name": "Bobby Second Template",
+							"deleting":     []map[string]any{},
+							"dormant": []map[string]any{
+								{"name": "workwork", "owner_username": "jack", "last_used_at": "Dec 20, 2023", "dormant_at": "Jan 19, 2024"},
+							},
+							"more": 3.0,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "TemplateVersionAdoptionReport",
+			id:   notifications.TemplateVersionAdoptionReport,
+			payload: types.MessagePayload{
+				UserName:     "Bobby",
+				UserEmail:    "bobby@coder.com",
+				UserUsername: "bobby",
+				Labels:       map[string]string{},
+				Data: map[string]any{
+					"report_frequency": "week",
+					"templates": []map[string]any{
+						{
+							"name":                "bobby-first-template",
+							"display_name":        "Bobby First Template",
+							"active_version":      "bobby-template-version-3",
+							"total_workspaces":    10.0,
+							"outdated_workspaces": 4.0,
+							"outdated_versions": []map[string]any{
+								{"name": "bobby-template-version-2", "workspace_count": 3.0},
+								{"name": "bobby-template-version-1", "workspace_count": 1.0},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "TemplateTopResourceConsumersReport",
+			id:   notifications.TemplateTopResourceConsumersReport,
+			payload: types.MessagePayload{
+				UserName:     "Bobby",
+				UserEmail:    "bobby@coder.com",
+				UserUsername: "bobby",
+				Labels:       map[string]string{},
+				Data: map[string]any{
+					"report_frequency": "week",
+					"organizations": []map[string]any{
+						{
+							"name":         "coder",
+							"display_name": "Coder",
+							"workspaces": []map[string]any{
+								{"position": 1.0, "name": "workspace-1", "owner_username": "mtojek", "template_display_name": "Bobby First Template", "transferred": "12 GB", "sessions": 14.0, "daily_cost": 10.0},
+								{"position": 2.0, "name": "workwork", "owner_username": "jack", "template_display_name": "Bobby Second Template", "transferred": "850 MB", "sessions": 1.0, "daily_cost": 0.0},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "TemplatePrebuildPoolHealthReport",
+			id:   notifications.TemplatePrebuildPoolHealthReport,
+			payload: types.MessagePayload{
+				UserName:     "Bobby",
+				UserEmail:    "bobby@coder.com",
+				UserUsername: "bobby",
+				Labels:       map[string]string{},
+				Data: map[string]any{
+					"report_frequency": "day",
+					"templates": []map[string]any{
+						{
+							"name":         "bobby-first-template",
+							"display_name": "Bobby First Template",
+							"presets": []map[string]any{
+								{"name": "particle-accelerator", "desired": 5.0, "running": 3.0, "ready": 2.0, "failed_builds": 4.0, "hard_limited": false},
+								{"name": "collider", "desired": 2.0, "running": 0.0, "ready": 0.0, "failed_builds": 1.0, "hard_limited": true},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "TemplateNotificationDigest",
+			id:   notifications.TemplateNotificationDigest,
+			payload: types.MessagePayload{
+				UserName:     "Bobby",
+				UserEmail:    "bobby@coder.com",
+				UserUsername: "bobby",
+				Labels:       map[string]string{},
+				Data: map[string]any{
+					"count": 2,
+					"since": "Jan 15 09:00 UTC",
+					"notifications": []map[string]any{
+						{"name": "Workspace Deleted", "title": `Workspace "bobby-workspace" deleted`, "time": "Jan 15 09:00"},
+						{"name": "Workspace Deleted", "title": `Workspace "bobby-other-workspace" deleted`, "time": "Jan 15 09:30"},
+					},
+				},
+			},
+		},
+		{
+			name: "TemplateNotificationRuleTriggered",
+			id:   notifications.TemplateNotificationRuleTriggered,
+			payload: types.MessagePayload{
+				UserName:     "Bobby",
+				UserEmail:    "bobby@coder.com",
+				UserUsername: "bobby",
+				Labels: map[string]string{
+					"rule_name":   "Failed docker starts",
+					"source":      "workspace build",
+					"events_path": "/workspaces",
+				},
+				Data: map[string]any{
+					"count":  4.0,
+					"window": "1 hour",
+					"events": []map[string]any{
+						{"time": "2024-01-15 09:30:00 UTC", "description": "bobby/bobby-workspace #4 start failed (template docker, reason initiator)"},
+						{"time": "2024-01-15 09:20:00 UTC", "description": "bobby/bobby-workspace #3 start failed (template docker, reason initiator)"},
+					},
+					"more": 2.0,
+				},
+			},
+		},
 		{
 			name: "TemplateWorkspaceResourceReplaced",
 			id:   notifications.TemplateWorkspaceResourceReplaced,
@@ -1951,6 +2170,258 @@ func TestNotificationDuplicates(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestNotificationDigest(t *testing.T) {
+	t.Parallel()
+
+	// SETUP
+	if !dbtestutil.WillUsePostgres() {
+		t.Skip("This test requires postgres; it relies on business-logic only implemented in the database")
+	}
+
+	// nolint:gocritic // Unit test.
+	ctx := dbauthz.AsNotifier(testutil.Context(t, testutil.WaitLong))
+	store, pubsub := dbtestutil.NewDB(t)
+	logger := testutil.Logger(t)
+
+	const method = database.NotificationMethodWebhook
+	cfg := defaultNotificationsConfig(method)
+	cfg.Inbox.Enabled = false
+	handler := &chanHandler{calls: make(chan dispatchCall)}
+
+	// The clock is set in the past, so that the digest is due as soon as the messages are batched.
+	mClock := quartz.NewMock(t)
+	mClock.Set(time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC))
+	syncTrap := mClock.Trap().NewTicker("Manager", "storeSync")
+	defer syncTrap.Close()
+	fetchTrap := mClock.Trap().TickerFunc("notifier", "fetchInterval")
+	defer fetchTrap.Close()
+
+	mgr, err := notifications.NewManager(cfg, store, pubsub, defaultHelpers(), createMetrics(),
+		logger.Named("manager"), notifications.WithTestClock(mClock))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, mgr.Stop(ctx))
+	})
+	mgr.WithHandlers(map[database.NotificationMethod]notifications.Handler{method: handler})
+	enq, err := notifications.NewStoreEnqueuer(cfg, store, defaultHelpers(), logger.Named("enqueuer"), mClock)
+	require.NoError(t, err)
+
+	// GIVEN: a user who wants workspace deletions in an hourly digest
+	user := createSampleUser(t, store)
+	err = store.UpdateUserNotificationDigestPreferences(ctx, database.UpdateUserNotificationDigestPreferencesParams{
+		UserID:                  user.ID,
+		NotificationTemplateIds: []uuid.UUID{notifications.TemplateWorkspaceDeleted},
+		DigestIntervals:         []database.NotificationDigestInterval{database.NotificationDigestIntervalHourly},
+	})
+	require.NoError(t, err)
+
+	for _, workspace := range []string{"bobby-workspace", "bobby-other-workspace"} {
+		_, err = enq.Enqueue(ctx, user.ID, notifications.TemplateWorkspaceDeleted,
+			map[string]string{"name": workspace, "reason": "autodeleted due to dormancy", "initiator": "autobuild"}, "test")
+		require.NoError(t, err)
+	}
+
+	mgr.Run(ctx)
+	syncTrap.MustWait(ctx).MustRelease(ctx)
+	fetchTrap.MustWait(ctx).MustRelease(ctx)
+
+	// WHEN: the messages are fetched, they are batched and summarized in a digest
+	mClock.Advance(cfg.FetchInterval.Value()).MustWait(ctx)
+
+	sent, err := store.GetNotificationMessagesByStatus(ctx, database.GetNotificationMessagesByStatusParams{
+		Status: database.NotificationMessageStatusSent,
+		Limit:  10,
+	})
+	require.NoError(t, err)
+	require.Len(t, sent, 2)
+
+	// THEN: the digest is delivered on the next fetch
+	w := mClock.Advance(cfg.FetchInterval.Value())
+	call := testutil.TryReceive(ctx, t, handler.calls)
+	require.Equal(t, notifications.TemplateNotificationDigest.String(), call.payload.NotificationTemplateID)
+	require.Equal(t, "2 notifications since Jan 15 09:00 UTC", call.title)
+	require.Contains(t, call.body, `Workspace "bobby-workspace" deleted`)
+	require.Contains(t, call.body, `Workspace "bobby-other-workspace" deleted`)
+	testutil.RequireSend(ctx, t, call.result, dispatchResult{})
+	w.MustWait(ctx)
+}
+
+func TestNotificationDigestDisabled(t *testing.T) {
+	t.Parallel()
+
+	// SETUP
+	if !dbtestutil.WillUsePostgres() {
+		t.Skip("This test requires postgres; it relies on business-logic only implemented in the database")
+	}
+
+	// nolint:gocritic // Unit test.
+	ctx := dbauthz.AsNotifier(testutil.Context(t, testutil.WaitLong))
+	store, pubsub := dbtestutil.NewDB(t)
+	logger := testutil.Logger(t)
+
+	const method = database.NotificationMethodWebhook
+	cfg := defaultNotificationsConfig(method)
+	cfg.Inbox.Enabled = false
+	handler := &chanHandler{calls: make(chan dispatchCall)}
+
+	mClock := quartz.NewMock(t)
+	mClock.Set(time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC))
+	syncTrap := mClock.Trap().NewTicker("Manager", "storeSync")
+	defer syncTrap.Close()
+	fetchTrap := mClock.Trap().TickerFunc("notifier", "fetchInterval")
+	defer fetchTrap.Close()
+
+	mgr, err := notifications.NewManager(cfg, store, pubsub, defaultHelpers(), createMetrics(),
+		logger.Named("manager"), notifications.WithTestClock(mClock))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, mgr.Stop(ctx))
+	})
+	mgr.WithHandlers(map[database.NotificationMethod]notifications.Handler{method: handler})
+	enq, err := notifications.NewStoreEnqueuer(cfg, store, defaultHelpers(), logger.Named("enqueuer"), mClock)
+	require.NoError(t, err)
+
+	// GIVEN: a user who wants workspace deletions in an hourly digest, with messages batched into a digest which is due
+	user := createSampleUser(t, store)
+	err = store.UpdateUserNotificationDigestPreferences(ctx, database.UpdateUserNotificationDigestPreferencesParams{
+		UserID:                  user.ID,
+		NotificationTemplateIds: []uuid.UUID{notifications.TemplateWorkspaceDeleted},
+		DigestIntervals:         []database.NotificationDigestInterval{database.NotificationDigestIntervalHourly},
+	})
+	require.NoError(t, err)
+	for _, workspace := range []string{"bobby-workspace", "bobby-other-workspace"} {
+		_, err = enq.Enqueue(ctx, user.ID, notifications.TemplateWorkspaceDeleted,
+			map[string]string{"name": workspace, "reason": "autodeleted due to dormancy", "initiator": "autobuild"}, "test")
+		require.NoError(t, err)
+	}
+	msgs, err := store.AcquireNotificationMessages(ctx, database.AcquireNotificationMessagesParams{
+		NotifierID:       uuid.New(),
+		LeaseSeconds:     60,
+		MaxAttemptCount:  5,
+		Count:            10,
+		DigestTemplateID: notifications.TemplateNotificationDigest,
+	})
+	require.NoError(t, err)
+	require.Len(t, msgs, 2)
+	_, err = store.BulkDeferNotificationMessages(ctx, database.BulkDeferNotificationMessagesParams{
+		IDs:           []uuid.UUID{msgs[0].ID, msgs[1].ID},
+		Statuses:      []database.NotificationMessageStatus{database.NotificationMessageStatusBatched, database.NotificationMessageStatusBatched},
+		StatusReasons: []string{"batched into hourly digest", "batched into hourly digest"},
+		DeliverAfters: []time.Time{mClock.Now(), mClock.Now()},
+	})
+	require.NoError(t, err)
+
+	// GIVEN: the user disables digests afterward
+	_, err = store.UpdateUserNotificationPreferences(ctx, database.UpdateUserNotificationPreferencesParams{
+		UserID:                  user.ID,
+		NotificationTemplateIds: []uuid.UUID{notifications.TemplateNotificationDigest},
+		Disableds:               []bool{true},
+	})
+	require.NoError(t, err)
+
+	mgr.Run(ctx)
+	syncTrap.MustWait(ctx).MustRelease(ctx)
+	fetchTrap.MustWait(ctx).MustRelease(ctx)
+
+	// WHEN: the digest is due, the batched messages are returned to the queue instead of being dropped
+	mClock.Advance(cfg.FetchInterval.Value()).MustWait(ctx)
+
+	pending, err := store.GetNotificationMessagesByStatus(ctx, database.GetNotificationMessagesByStatusParams{
+		Status: database.NotificationMessageStatusPending,
+		Limit:  10,
+	})
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+
+	// THEN: the messages are delivered individually on the next fetch
+	w := mClock.Advance(cfg.FetchInterval.Value())
+	var titles []string
+	for range 2 {
+		call := testutil.TryReceive(ctx, t, handler.calls)
+		require.Equal(t, notifications.TemplateWorkspaceDeleted.String(), call.payload.NotificationTemplateID)
+		titles = append(titles, call.title)
+		testutil.RequireSend(ctx, t, call.result, dispatchResult{})
+	}
+	require.ElementsMatch(t, []string{`Workspace "bobby-workspace" deleted`, `Workspace "bobby-other-workspace" deleted`}, titles)
+	w.MustWait(ctx)
+}
+
+func TestQuietHours(t *testing.T) {
+	t.Parallel()
+
+	// SETUP
+	if !dbtestutil.WillUsePostgres() {
+		t.Skip("This test requires postgres; it relies on business-logic only implemented in the database")
+	}
+
+	// nolint:gocritic // Unit test.
+	ctx := dbauthz.AsNotifier(testutil.Context(t, testutil.WaitLong))
+	store, pubsub := dbtestutil.NewDB(t)
+	logger := testutil.Logger(t)
+
+	const method = database.NotificationMethodWebhook
+	cfg := defaultNotificationsConfig(method)
+	cfg.Inbox.Enabled = false
+	handler := &chanHandler{calls: make(chan dispatchCall)}
+
+	now := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+	mClock := quartz.NewMock(t)
+	mClock.Set(now)
+	syncTrap := mClock.Trap().NewTicker("Manager", "storeSync")
+	defer syncTrap.Close()
+	fetchTrap := mClock.Trap().TickerFunc("notifier", "fetchInterval")
+	defer fetchTrap.Close()
+
+	mgr, err := notifications.NewManager(cfg, store, pubsub, defaultHelpers(), createMetrics(),
+		logger.Named("manager"), notifications.WithTestClock(mClock))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, mgr.Stop(ctx))
+	})
+	mgr.WithHandlers(map[database.NotificationMethod]notifications.Handler{method: handler})
+	enq, err := notifications.NewStoreEnqueuer(cfg, store, defaultHelpers(), logger.Named("enqueuer"), mClock)
+	require.NoError(t, err)
+
+	// GIVEN: a user whose quiet hours are 08:00 to 10:00 in Berlin (07:00 to 09:00 UTC)
+	user := createSampleUser(t, store)
+	dbgen.UserNotificationSchedule(t, store, database.UserNotificationSchedule{
+		UserID:          user.ID,
+		Timezone:        "Europe/Berlin",
+		QuietHoursStart: sql.NullInt16{Int16: 8 * 60, Valid: true},
+		QuietHoursEnd:   sql.NullInt16{Int16: 10 * 60, Valid: true},
+	})
+
+	// WHEN: a regular and a critical notification are enqueued during quiet hours
+	deferred, err := enq.Enqueue(ctx, user.ID, notifications.TemplateWorkspaceDeleted,
+		map[string]string{"name": "bobby-workspace", "reason": "autodeleted due to dormancy", "initiator": "autobuild"}, "test")
+	require.NoError(t, err)
+	_, err = enq.Enqueue(ctx, user.ID, notifications.TemplateTestNotification, map[string]string{}, "test")
+	require.NoError(t, err)
+
+	mgr.Run(ctx)
+	syncTrap.MustWait(ctx).MustRelease(ctx)
+	fetchTrap.MustWait(ctx).MustRelease(ctx)
+
+	// THEN: only the critical notification is delivered
+	w := mClock.Advance(cfg.FetchInterval.Value())
+	call := testutil.TryReceive(ctx, t, handler.calls)
+	require.Equal(t, notifications.TemplateTestNotification.String(), call.payload.NotificationTemplateID)
+	testutil.RequireSend(ctx, t, call.result, dispatchResult{})
+	w.MustWait(ctx)
+
+	// and the other is held until the quiet hours end.
+	pending, err := store.GetNotificationMessagesByStatus(ctx, database.GetNotificationMessagesByStatusParams{
+		Status: database.NotificationMessageStatusPending,
+		Limit:  10,
+	})
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	require.Equal(t, deferred[0], pending[0].ID)
+	require.Equal(t, "deferred by quiet hours", pending[0].StatusReason.String)
+	require.True(t, pending[0].NextRetryAfter.Valid)
+	require.True(t, now.Add(time.Hour).Equal(pending[0].NextRetryAfter.Time), pending[0].NextRetryAfter.Time)
+}
+
 func TestNotificationMethodCannotDefaultToInbox(t *testing.T) {
 	t.Parallel()
 
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"sync"
 	"text/template"
+	"time"
 
 	"github.com/google/uuid"
 	"golang.org/x/sync/errgroup"
@@ -114,6 +115,11 @@ func (n *notifier) run(success chan<- dispatchResult, failure chan<- dispatchRes
 			if err != nil {
 				n.log.Error(n.outerCtx, "failed to process messages", slog.Error(err))
 			}
+
+			err = n.processDigests(n.outerCtx)
+			if err != nil {
+				n.log.Error(n.outerCtx, "failed to process digests", slog.Error(err))
+			}
 		}
 		// we don't return any errors because we don't want to kill the loop because of them.
 		return nil
@@ -169,7 +175,11 @@ func (n *notifier) process(ctx context.Context, success chan<- dispatchResult, f
 		return nil
 	}
 
-	var eg errgroup.Group
+	var (
+		eg       errgroup.Group
+		deferred database.BulkDeferNotificationMessagesParams
+		now      = n.clock.Now()
+	)
 	for _, msg := range msgs {
 		// If a notification template has been disabled by the user after a notification was enqueued, mark it as inhibited
 		if msg.Disabled {
@@ -177,6 +187,16 @@ func (n *notifier) process(ctx context.Context, success chan<- dispatchResult, f
 			continue
 		}
 
+		// Messages which the user wants in a digest, or which arrive during their quiet hours, are returned to the
+		// queue until they are due.
+		if status, reason, deliverAfter, ok := n.deferral(msg, now); ok {
+			deferred.IDs = append(deferred.IDs, msg.ID)
+			deferred.Statuses = append(deferred.Statuses, status)
+			deferred.StatusReasons = append(deferred.StatusReasons, reason)
+			deferred.DeliverAfters = append(deferred.DeliverAfters, deliverAfter)
+			continue
+		}
+
 		// A message failing to be prepared correctly should not affect other messages.
 		deliverFn, err := n.prepare(ctx, msg)
 		if err != nil {
@@ -196,6 +216,15 @@ func (n *notifier) process(ctx context.Context, success chan<- dispatchResult, f
 		})
 	}
 
+	if len(deferred.IDs) > 0 {
+		// If this fails, the leases expire and the messages are evaluated again.
+		if _, err := n.store.BulkDeferNotificationMessages(ctx, deferred); err != nil {
+			n.log.Error(ctx, "failed to defer messages", slog.F("count", len(deferred.IDs)), slog.Error(err))
+		} else {
+			n.log.Debug(ctx, "deferred messages", slog.F("count", len(deferred.IDs)))
+		}
+	}
+
 	if err = eg.Wait(); err != nil {
 		n.log.Debug(ctx, "dispatch failed", slog.Error(err))
 		return xerrors.Errorf("dispatch failed: %w", err)
@@ -205,6 +234,26 @@ func (n *notifier) process(ctx context.Context, success chan<- dispatchResult, f
 	return nil
 }
 
+// deferral determines whether a message should be held back rather than delivered now, and until when.
+// Critical notifications and Coder Inbox messages are always delivered immediately. Messages are not batched if the user
+// disabled the digest they would be summarized in.
+func (n *notifier) deferral(msg database.AcquireNotificationMessagesRow, now time.Time) (database.NotificationMessageStatus, string, time.Time, bool) {
+	if msg.Critical || msg.Method == database.NotificationMethodInbox {
+		return "", "", time.Time{}, false
+	}
+
+	sched := newDeliverySchedule(msg.Timezone, msg.QuietHoursStart, msg.QuietHoursEnd, msg.DailyDigestTime)
+	if msg.DigestInterval.Valid && !msg.DigestDisabled && msg.TemplateID != TemplateNotificationDigest {
+		interval := msg.DigestInterval.NotificationDigestInterval
+		return database.NotificationMessageStatusBatched, fmt.Sprintf("batched into %s digest", interval),
+			sched.nextDigest(now, interval), true
+	}
+	if until, ok := sched.quietUntil(now); ok {
+		return database.NotificationMessageStatusPending, "deferred by quiet hours", until, true
+	}
+	return "", "", time.Time{}, false
+}
+
 // fetch retrieves messages from the queue by "acquiring a lease" whereby this notifier is the exclusive handler of these
 // messages until they are dispatched - or until the lease expires (in exceptional cases).
 func (n *notifier) fetch(ctx context.Context) ([]database.AcquireNotificationMessagesRow, error) {
@@ -212,9 +261,10 @@ func (n *notifier) fetch(ctx context.Context) ([]database.AcquireNotificationMes
 		// #nosec G115 - Safe conversion for lease count which is expected to be within int32 range
 		Count: int32(n.cfg.LeaseCount),
 		// #nosec G115 - Safe conversion for max send attempts which is expected to be within int32 range
-		MaxAttemptCount: int32(n.cfg.MaxSendAttempts),
-		NotifierID:      n.id,
-		LeaseSeconds:    int32(n.cfg.LeasePeriod.Value().Seconds()),
+		MaxAttemptCount:  int32(n.cfg.MaxSendAttempts),
+		NotifierID:       n.id,
+		LeaseSeconds:     int32(n.cfg.LeasePeriod.Value().Seconds()),
+		DigestTemplateID: TemplateNotificationDigest,
 	})
 	if err != nil {
 		return nil, xerrors.Errorf("acquire messages: %w", err)
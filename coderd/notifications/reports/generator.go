@@ -50,9 +50,11 @@ func NewReportGenerator(ctx context.Context, logger slog.Logger, db database.Sto
 				return nil
 			}
 
-			err = reportFailedWorkspaceBuilds(ctx, logger, tx, enqueuer, clk)
-			if err != nil {
-				return xerrors.Errorf("unable to generate reports with failed workspace builds: %w", err)
+			for _, report := range scheduledReports {
+				err = runScheduledReport(ctx, logger, tx, enqueuer, clk, report)
+				if err != nil {
+					return xerrors.Errorf("unable to generate %q report: %w", report.name, err)
+				}
 			}
 
 			logger.Info(ctx, "report generator finished", slog.F("duration", clk.Since(start)))
@@ -98,31 +100,40 @@ func (i *reportGenerator) Close() error {
 	return nil
 }
 
-const (
-	failedWorkspaceBuildsReportFrequency      = 7 * 24 * time.Hour
-	failedWorkspaceBuildsReportFrequencyLabel = "week"
-)
+// scheduledReport is a periodic report sent to template admins. Each report is a notification template, so
+// recipients can opt out of it in their notification preferences.
+type scheduledReport struct {
+	name       string
+	templateID uuid.UUID
+	frequency  time.Duration
+	generate   func(ctx context.Context, logger slog.Logger, db database.Store, enqueuer notifications.Enqueuer, now time.Time) error
+}
 
-type adminReport struct {
-	stats        database.GetWorkspaceBuildStatsByTemplatesRow
-	failedBuilds []database.GetFailedWorkspaceBuildsByTemplateIDRow
+// scheduledReports are run by the report generator in this order.
+var scheduledReports = []scheduledReport{
+	failedWorkspaceBuildsReport,
+	workspacesPendingDeletionReport,
+	templateVersionAdoptionReport,
+	topResourceConsumersReport,
+	prebuildPoolHealthReport,
 }
 
-func reportFailedWorkspaceBuilds(ctx context.Context, logger slog.Logger, db database.Store, enqueuer notifications.Enqueuer, clk quartz.Clock) error {
+// runScheduledReport generates the report if it has not been generated within its frequency.
+func runScheduledReport(ctx context.Context, logger slog.Logger, db database.Store, enqueuer notifications.Enqueuer, clk quartz.Clock, report scheduledReport) error {
 	now := clk.Now()
-	since := now.Add(-failedWorkspaceBuildsReportFrequency)
+	logger = logger.With(slog.F("report", report.name), slog.F("notification_template_id", report.templateID))
 
 	// Firstly, check if this is the first run of the job ever
-	reportLog, err := db.GetNotificationReportGeneratorLogByTemplate(ctx, notifications.TemplateWorkspaceBuildsFailedReport)
+	reportLog, err := db.GetNotificationReportGeneratorLogByTemplate(ctx, report.templateID)
 	if err != nil && !xerrors.Is(err, sql.ErrNoRows) {
 		return xerrors.Errorf("unable to read report generator log: %w", err)
 	}
 	if xerrors.Is(err, sql.ErrNoRows) {
-		// First run? Check-in the job, and get back after one week.
-		logger.Info(ctx, "report generator is executing the job for the first time", slog.F("notification_template_id", notifications.TemplateWorkspaceBuildsFailedReport))
+		// First run? Check-in the job, and get back after the report frequency.
+		logger.Info(ctx, "report generator is executing the job for the first time")
 
 		err = db.UpsertNotificationReportGeneratorLog(ctx, database.UpsertNotificationReportGeneratorLogParams{
-			NotificationTemplateID: notifications.TemplateWorkspaceBuildsFailedReport,
+			NotificationTemplateID: report.templateID,
 			LastGeneratedAt:        dbtime.Time(now).UTC(),
 		})
 		if err != nil {
@@ -132,11 +143,72 @@ func reportFailedWorkspaceBuilds(ctx context.Context, logger slog.Logger, db dat
 	}
 
 	// Secondly, check if the job has not been running recently
-	if !reportLog.LastGeneratedAt.IsZero() && reportLog.LastGeneratedAt.Add(failedWorkspaceBuildsReportFrequency).After(now) {
+	if !reportLog.LastGeneratedAt.IsZero() && reportLog.LastGeneratedAt.Add(report.frequency).After(now) {
 		return nil // reports sent recently, no need to send them now
 	}
 
-	// Thirdly, fetch workspace build stats by templates
+	// Thirdly, generate and enqueue the report
+	if err := report.generate(ctx, logger, db, enqueuer, now); err != nil {
+		return err
+	}
+
+	if xerrors.Is(ctx.Err(), context.Canceled) {
+		logger.Error(ctx, "report generator job is canceled")
+		return ctx.Err()
+	}
+
+	// Lastly, update the timestamp in the generator log.
+	err = db.UpsertNotificationReportGeneratorLog(ctx, database.UpsertNotificationReportGeneratorLogParams{
+		NotificationTemplateID: report.templateID,
+		LastGeneratedAt:        dbtime.Time(now).UTC(),
+	})
+	if err != nil {
+		return xerrors.Errorf("unable to update report generator logs: %w", err)
+	}
+	return nil
+}
+
+// enqueueReport sends a report to a single recipient.
+func enqueueReport(ctx context.Context, logger slog.Logger, enqueuer notifications.Enqueuer, userID, templateID uuid.UUID, data map[string]any, targets []uuid.UUID) {
+	_, err := enqueuer.EnqueueWithData(ctx, userID, templateID,
+		map[string]string{},
+		data,
+		"report_generator",
+		slice.Unique(targets)...,
+	)
+	switch {
+	case xerrors.Is(err, notifications.ErrCannotEnqueueDisabledNotification):
+		logger.Debug(ctx, "report disabled by recipient", slog.F("user_id", userID))
+	case err != nil:
+		logger.Warn(ctx, "failed to send a report", slog.F("user_id", userID), slog.Error(err))
+	}
+}
+
+const (
+	failedWorkspaceBuildsReportFrequency      = 7 * 24 * time.Hour
+	failedWorkspaceBuildsReportFrequencyLabel = "week"
+)
+
+var failedWorkspaceBuildsReport = scheduledReport{
+	name:       "failed workspace builds",
+	templateID: notifications.TemplateWorkspaceBuildsFailedReport,
+	frequency:  failedWorkspaceBuildsReportFrequency,
+	generate:   generateFailedWorkspaceBuildsReport,
+}
+
+type adminReport struct {
+	stats        database.GetWorkspaceBuildStatsByTemplatesRow
+	failedBuilds []database.GetFailedWorkspaceBuildsByTemplateIDRow
+}
+
+func reportFailedWorkspaceBuilds(ctx context.Context, logger slog.Logger, db database.Store, enqueuer notifications.Enqueuer, clk quartz.Clock) error {
+	return runScheduledReport(ctx, logger, db, enqueuer, clk, failedWorkspaceBuildsReport)
+}
+
+func generateFailedWorkspaceBuildsReport(ctx context.Context, logger slog.Logger, db database.Store, enqueuer notifications.Enqueuer, now time.Time) error {
+	since := now.Add(-failedWorkspaceBuildsReportFrequency)
+
+	// Fetch workspace build stats by templates
 	templateStatsRows, err := db.GetWorkspaceBuildStatsByTemplates(ctx, dbtime.Time(since).UTC())
 	if err != nil {
 		return xerrors.Errorf("unable to fetch failed workspace builds: %w", err)
@@ -158,7 +230,7 @@ func reportFailedWorkspaceBuilds(ctx context.Context, logger slog.Logger, db dat
 		}
 
 		// Fetch template admins with org access to the templates
-		templateAdmins, err := findTemplateAdmins(ctx, db, stats)
+		templateAdmins, err := findTemplateAdmins(ctx, db, stats.TemplateOrganizationID)
 		if err != nil {
 			logger.Error(ctx, "unable to find template admins for template", slog.F("template_id", stats.TemplateID), slog.Error(err))
 			continue
@@ -200,28 +272,7 @@ func reportFailedWorkspaceBuilds(ctx context.Context, logger slog.Logger, db dat
 			targets = append(targets, report.stats.TemplateID, report.stats.TemplateOrganizationID)
 		}
 
-		if _, err := enqueuer.EnqueueWithData(ctx, templateAdmin, notifications.TemplateWorkspaceBuildsFailedReport,
-			map[string]string{},
-			reportData,
-			"report_generator",
-			slice.Unique(targets)...,
-		); err != nil {
-			logger.Warn(ctx, "failed to send a report with failed workspace builds", slog.Error(err))
-		}
-	}
-
-	if xerrors.Is(ctx.Err(), context.Canceled) {
-		logger.Error(ctx, "report generator job is canceled")
-		return ctx.Err()
-	}
-
-	// Lastly, update the timestamp in the generator log.
-	err = db.UpsertNotificationReportGeneratorLog(ctx, database.UpsertNotificationReportGeneratorLogParams{
-		NotificationTemplateID: notifications.TemplateWorkspaceBuildsFailedReport,
-		LastGeneratedAt:        dbtime.Time(now).UTC(),
-	})
-	if err != nil {
-		return xerrors.Errorf("unable to update report generator logs: %w", err)
+		enqueueReport(ctx, logger, enqueuer, templateAdmin, notifications.TemplateWorkspaceBuildsFailedReport, reportData, targets)
 	}
 	return nil
 }
@@ -296,7 +347,8 @@ func buildDataForReportFailedWorkspaceBuilds(reports []adminReport) map[string]a
 	}
 }
 
-func findTemplateAdmins(ctx context.Context, db database.Store, stats database.GetWorkspaceBuildStatsByTemplatesRow) ([]database.GetUsersRow, error) {
+// findTemplateAdmins returns the template admins who are members of the organization, sorted by username.
+func findTemplateAdmins(ctx context.Context, db database.Store, organizationID uuid.UUID) ([]database.GetUsersRow, error) {
 	users, err := db.GetUsers(ctx, database.GetUsersParams{
 		RbacRole: []string{codersdk.RoleTemplateAdmin},
 	})
@@ -322,7 +374,7 @@ func findTemplateAdmins(ctx context.Context, db database.Store, stats database.G
 	}
 
 	for _, entry := range orgIDsByMemberIDs {
-		if slices.Contains(entry.OrganizationIDs, stats.TemplateOrganizationID) {
+		if slices.Contains(entry.OrganizationIDs, organizationID) {
 			templateAdmins = append(templateAdmins, usersByIDs[entry.UserID])
 		}
 	}
@@ -0,0 +1,335 @@
+package reports
+
+import (
+	"context"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+
+	"github.com/coder/coder/v2/coderd/database"
+	"github.com/coder/coder/v2/coderd/database/dbtime"
+	"github.com/coder/coder/v2/coderd/notifications"
+)
+
+const (
+	weeklyReportFrequency      = 7 * 24 * time.Hour
+	weeklyReportFrequencyLabel = "week"
+	dailyReportFrequency       = 24 * time.Hour
+	dailyReportFrequencyLabel  = "day"
+
+	// reportDateFormat is used for dates in reports, which are always in UTC.
+	reportDateFormat = "Jan 2, 2006"
+
+	workspacesLimitPerTemplate            = 10
+	resourceConsumersLimitPerOrganization = 10
+)
+
+var (
+	workspacesPendingDeletionReport = scheduledReport{
+		name:       "workspaces pending deletion",
+		templateID: notifications.TemplateWorkspacesPendingDeletionReport,
+		frequency:  weeklyReportFrequency,
+		generate:   generateWorkspacesPendingDeletionReport,
+	}
+	templateVersionAdoptionReport = scheduledReport{
+		name:       "template version adoption",
+		templateID: notifications.TemplateVersionAdoptionReport,
+		frequency:  weeklyReportFrequency,
+		generate:   generateTemplateVersionAdoptionReport,
+	}
+	topResourceConsumersReport = scheduledReport{
+		name:       "top resource consumers",
+		templateID: notifications.TemplateTopResourceConsumersReport,
+		frequency:  weeklyReportFrequency,
+		generate:   generateTopResourceConsumersReport,
+	}
+	prebuildPoolHealthReport = scheduledReport{
+		name:       "prebuild pool health",
+		templateID: notifications.TemplatePrebuildPoolHealthReport,
+		frequency:  dailyReportFrequency,
+		generate:   generatePrebuildPoolHealthReport,
+	}
+)
+
+// reportSection is the part of a report about a single template or organization. Template admins receive the
+// sections of the organizations they are members of.
+type reportSection struct {
+	organizationID uuid.UUID
+	targets        []uuid.UUID
+	data           map[string]any
+}
+
+// sendReportToTemplateAdmins enqueues a single report per template admin, with the sections relevant to them listed
+// under key.
+func sendReportToTemplateAdmins(ctx context.Context, logger slog.Logger, db database.Store, enqueuer notifications.Enqueuer, templateID uuid.UUID, frequencyLabel, key string, sections []reportSection) {
+	var (
+		adminsByOrganization = map[uuid.UUID][]database.GetUsersRow{}
+		recipients           []uuid.UUID
+		recipientSections    = map[uuid.UUID][]map[string]any{}
+		recipientTargets     = map[uuid.UUID][]uuid.UUID{}
+	)
+	for _, section := range sections {
+		admins, ok := adminsByOrganization[section.organizationID]
+		if !ok {
+			var err error
+			admins, err = findTemplateAdmins(ctx, db, section.organizationID)
+			if err != nil {
+				logger.Error(ctx, "unable to find template admins for organization", slog.F("organization_id", section.organizationID), slog.Error(err))
+			}
+			adminsByOrganization[section.organizationID] = admins
+		}
+
+		for _, admin := range admins {
+			if _, ok := recipientSections[admin.ID]; !ok {
+				recipients = append(recipients, admin.ID)
+			}
+			recipientSections[admin.ID] = append(recipientSections[admin.ID], section.data)
+			recipientTargets[admin.ID] = append(recipientTargets[admin.ID], section.targets...)
+		}
+	}
+
+	for _, recipient := range recipients {
+		if ctx.Err() != nil {
+			logger.Debug(ctx, "context is canceled, quitting", slog.Error(ctx.Err()))
+			return
+		}
+
+		enqueueReport(ctx, logger, enqueuer, recipient, templateID, map[string]any{
+			"report_frequency": frequencyLabel,
+			key:                recipientSections[recipient],
+		}, recipientTargets[recipient])
+	}
+}
+
+func templateDisplayName(name, displayName string) string {
+	if displayName == "" {
+		return name
+	}
+	return displayName
+}
+
+// generateWorkspacesPendingDeletionReport reports dormant workspaces which will be deleted, and workspaces which will
+// become dormant, before the next report.
+func generateWorkspacesPendingDeletionReport(ctx context.Context, logger slog.Logger, db database.Store, enqueuer notifications.Enqueuer, now time.Time) error {
+	rows, err := db.GetWorkspacesApproachingDeletion(ctx, dbtime.Time(now.Add(weeklyReportFrequency)).UTC())
+	if err != nil {
+		return xerrors.Errorf("unable to fetch workspaces approaching deletion: %w", err)
+	}
+
+	var (
+		templateIDs []uuid.UUID
+		rowsByID    = map[uuid.UUID][]database.GetWorkspacesApproachingDeletionRow{}
+	)
+	for _, row := range rows {
+		if _, ok := rowsByID[row.TemplateID]; !ok {
+			templateIDs = append(templateIDs, row.TemplateID)
+		}
+		rowsByID[row.TemplateID] = append(rowsByID[row.TemplateID], row)
+	}
+
+	sections := make([]reportSection, 0, len(templateIDs))
+	for _, templateID := range templateIDs {
+		templateRows := rowsByID[templateID]
+		deleting := []map[string]any{}
+		dormant := []map[string]any{}
+		for i, row := range templateRows {
+			if i == workspacesLimitPerTemplate {
+				break
+			}
+			if row.DormantAt.Valid {
+				deleting = append(deleting, map[string]any{
+					"name":           row.WorkspaceName,
+					"owner_username": row.OwnerUsername,
+					"deleting_at":    row.DeletingAt.Time.UTC().Format(reportDateFormat),
+				})
+				continue
+			}
+			dormant = append(dormant, map[string]any{
+				"name":           row.WorkspaceName,
+				"owner_username": row.OwnerUsername,
+				"last_used_at":   row.LastUsedAt.UTC().Format(reportDateFormat),
+				"dormant_at":     row.LastUsedAt.Add(time.Duration(row.TimeTilDormant)).UTC().Format(reportDateFormat),
+			})
+		}
+
+		first := templateRows[0]
+		sections = append(sections, reportSection{
+			organizationID: first.TemplateOrganizationID,
+			targets:        []uuid.UUID{first.TemplateID, first.TemplateOrganizationID},
+			data: map[string]any{
+				"name":         first.TemplateName,
+				"display_name": templateDisplayName(first.TemplateName, first.TemplateDisplayName),
+				"deleting":     deleting,
+				"dormant":      dormant,
+				"more":         max(len(templateRows)-workspacesLimitPerTemplate, 0),
+			},
+		})
+	}
+
+	sendReportToTemplateAdmins(ctx, logger, db, enqueuer, notifications.TemplateWorkspacesPendingDeletionReport, weeklyReportFrequencyLabel, "templates", sections)
+	return nil
+}
+
+// generateTemplateVersionAdoptionReport reports templates with workspaces whose latest build does not use the active
+// template version.
+func generateTemplateVersionAdoptionReport(ctx context.Context, logger slog.Logger, db database.Store, enqueuer notifications.Enqueuer, _ time.Time) error {
+	rows, err := db.GetTemplateVersionAdoption(ctx)
+	if err != nil {
+		return xerrors.Errorf("unable to fetch template version adoption: %w", err)
+	}
+
+	var (
+		templateIDs []uuid.UUID
+		rowsByID    = map[uuid.UUID][]database.GetTemplateVersionAdoptionRow{}
+	)
+	for _, row := range rows {
+		if _, ok := rowsByID[row.TemplateID]; !ok {
+			templateIDs = append(templateIDs, row.TemplateID)
+		}
+		rowsByID[row.TemplateID] = append(rowsByID[row.TemplateID], row)
+	}
+
+	sections := make([]reportSection, 0, len(templateIDs))
+	for _, templateID := range templateIDs {
+		var (
+			total, outdated  int32
+			outdatedVersions = []map[string]any{}
+			templateRows     = rowsByID[templateID]
+		)
+		for _, row := range templateRows {
+			total += row.WorkspaceCount
+			if row.Active {
+				continue
+			}
+			outdated += row.WorkspaceCount
+			outdatedVersions = append(outdatedVersions, map[string]any{
+				"name":            row.TemplateVersionName,
+				"workspace_count": row.WorkspaceCount,
+			})
+		}
+		if outdated == 0 {
+			continue
+		}
+
+		first := templateRows[0]
+		sections = append(sections, reportSection{
+			organizationID: first.TemplateOrganizationID,
+			targets:        []uuid.UUID{first.TemplateID, first.TemplateOrganizationID},
+			data: map[string]any{
+				"name":                first.TemplateName,
+				"display_name":        templateDisplayName(first.TemplateName, first.TemplateDisplayName),
+				"active_version":      first.ActiveVersionName,
+				"total_workspaces":    total,
+				"outdated_workspaces": outdated,
+				"outdated_versions":   outdatedVersions,
+			},
+		})
+	}
+
+	sendReportToTemplateAdmins(ctx, logger, db, enqueuer, notifications.TemplateVersionAdoptionReport, weeklyReportFrequencyLabel, "templates", sections)
+	return nil
+}
+
+// generateTopResourceConsumersReport reports the workspaces of each organization which transferred the most data
+// since the previous report.
+func generateTopResourceConsumersReport(ctx context.Context, logger slog.Logger, db database.Store, enqueuer notifications.Enqueuer, now time.Time) error {
+	rows, err := db.GetTopWorkspaceResourceConsumers(ctx, database.GetTopWorkspaceResourceConsumersParams{
+		Since:                dbtime.Time(now.Add(-weeklyReportFrequency)).UTC(),
+		LimitPerOrganization: resourceConsumersLimitPerOrganization,
+	})
+	if err != nil {
+		return xerrors.Errorf("unable to fetch top workspace resource consumers: %w", err)
+	}
+
+	// Rows are ordered by organization and position.
+	var sections []reportSection
+	for _, row := range rows {
+		if len(sections) == 0 || sections[len(sections)-1].organizationID != row.OrganizationID {
+			displayName := row.OrganizationDisplayName
+			if displayName == "" {
+				displayName = row.OrganizationName
+			}
+			sections = append(sections, reportSection{
+				organizationID: row.OrganizationID,
+				targets:        []uuid.UUID{row.OrganizationID},
+				data: map[string]any{
+					"name":         row.OrganizationName,
+					"display_name": displayName,
+					"workspaces":   []map[string]any{},
+				},
+			})
+		}
+
+		section := sections[len(sections)-1]
+		//nolint:errorlint,forcetypeassert // only this function prepares the notification model
+		section.data["workspaces"] = append(section.data["workspaces"].([]map[string]any), map[string]any{
+			"position":              row.Position,
+			"name":                  row.WorkspaceName,
+			"owner_username":        row.OwnerUsername,
+			"template_display_name": templateDisplayName(row.TemplateName, row.TemplateDisplayName),
+			// #nosec G115 - Safe conversion as the sum of transferred bytes is positive
+			"transferred": humanize.Bytes(uint64(row.TransferredBytes)),
+			"sessions":    row.Sessions,
+			"daily_cost":  row.DailyCost,
+		})
+	}
+
+	sendReportToTemplateAdmins(ctx, logger, db, enqueuer, notifications.TemplateTopResourceConsumersReport, weeklyReportFrequencyLabel, "organizations", sections)
+	return nil
+}
+
+// generatePrebuildPoolHealthReport reports presets whose prebuilt workspace pool is not filled, or whose prebuilds
+// failed since the previous report.
+func generatePrebuildPoolHealthReport(ctx context.Context, logger slog.Logger, db database.Store, enqueuer notifications.Enqueuer, now time.Time) error {
+	rows, err := db.GetPrebuildPoolHealth(ctx, dbtime.Time(now.Add(-dailyReportFrequency)).UTC())
+	if err != nil {
+		return xerrors.Errorf("unable to fetch prebuild pool health: %w", err)
+	}
+
+	var (
+		templateIDs []uuid.UUID
+		sectionByID = map[uuid.UUID]reportSection{}
+	)
+	for _, row := range rows {
+		hardLimited := row.PrebuildStatus == database.PrebuildStatusHardLimited
+		if row.Ready >= row.DesiredInstances && row.FailedBuilds == 0 && !hardLimited {
+			continue
+		}
+
+		section, ok := sectionByID[row.TemplateID]
+		if !ok {
+			templateIDs = append(templateIDs, row.TemplateID)
+			section = reportSection{
+				organizationID: row.TemplateOrganizationID,
+				targets:        []uuid.UUID{row.TemplateID, row.TemplateOrganizationID},
+				data: map[string]any{
+					"name":         row.TemplateName,
+					"display_name": templateDisplayName(row.TemplateName, row.TemplateDisplayName),
+					"presets":      []map[string]any{},
+				},
+			}
+		}
+		//nolint:errorlint,forcetypeassert // only this function prepares the notification model
+		section.data["presets"] = append(section.data["presets"].([]map[string]any), map[string]any{
+			"name":          row.PresetName,
+			"desired":       row.DesiredInstances,
+			"running":       row.Running,
+			"ready":         row.Ready,
+			"failed_builds": row.FailedBuilds,
+			"hard_limited":  hardLimited,
+		})
+		sectionByID[row.TemplateID] = section
+	}
+
+	sections := make([]reportSection, 0, len(templateIDs))
+	for _, templateID := range templateIDs {
+		sections = append(sections, sectionByID[templateID])
+	}
+
+	sendReportToTemplateAdmins(ctx, logger, db, enqueuer, notifications.TemplatePrebuildPoolHealthReport, dailyReportFrequencyLabel, "templates", sections)
+	return nil
+}
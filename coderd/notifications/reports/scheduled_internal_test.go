@@ -0,0 +1,310 @@
+package reports
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/coderd/database"
+	"github.com/coder/coder/v2/coderd/database/dbgen"
+	"github.com/coder/coder/v2/coderd/database/dbtestutil"
+	"github.com/coder/coder/v2/coderd/notifications"
+	"github.com/coder/coder/v2/coderd/rbac"
+)
+
+func TestReportWorkspacesPendingDeletion(t *testing.T) {
+	t.Parallel()
+
+	// Setup
+	ctx, logger, db, _, notifEnq, clk := setup(t)
+
+	// Given
+	org := dbgen.Organization(t, db, database.Organization{})
+	templateAdmin := dbgen.User(t, db, database.User{Username: "template-admin-1", RBACRoles: []string{rbac.RoleTemplateAdmin().Name}})
+	_ = dbgen.OrganizationMember(t, db, database.OrganizationMember{UserID: templateAdmin.ID, OrganizationID: org.ID})
+	user := dbgen.User(t, db, database.User{})
+	_ = dbgen.OrganizationMember(t, db, database.OrganizationMember{UserID: user.ID, OrganizationID: org.ID})
+
+	t1 := dbgen.Template(t, db, database.Template{Name: "template-1", DisplayName: "First Template", CreatedBy: templateAdmin.ID, OrganizationID: org.ID})
+	err := db.UpdateTemplateScheduleByID(ctx, database.UpdateTemplateScheduleByIDParams{
+		ID:                       t1.ID,
+		UpdatedAt:                clk.Now(),
+		TimeTilDormant:           int64(30 * dayDuration),
+		TimeTilDormantAutoDelete: int64(28 * dayDuration),
+	})
+	require.NoError(t, err)
+	// Workspaces of templates without dormancy are never reported.
+	t2 := dbgen.Template(t, db, database.Template{Name: "template-2", CreatedBy: templateAdmin.ID, OrganizationID: org.ID})
+
+	// When: first run
+	notifEnq.Clear()
+	err = runScheduledReport(ctx, logger, db, notifEnq, clk, workspacesPendingDeletionReport)
+
+	// Then: the job is only checked in
+	require.NoError(t, err)
+	require.Empty(t, notifEnq.Sent())
+
+	// Given: one week later
+	clk.Advance(weeklyReportFrequency + time.Minute)
+	now := clk.Now()
+
+	// Becomes dormant in 5 days.
+	becomingDormant := dbgen.Workspace(t, db, database.WorkspaceTable{Name: "becoming-dormant", TemplateID: t1.ID, OwnerID: user.ID, OrganizationID: org.ID, LastUsedAt: now.Add(-25 * dayDuration)})
+	// Becomes dormant in 29 days.
+	_ = dbgen.Workspace(t, db, database.WorkspaceTable{Name: "active", TemplateID: t1.ID, OwnerID: user.ID, OrganizationID: org.ID, LastUsedAt: now.Add(-dayDuration)})
+	// Dormant for 25 days, deleted in 3 days.
+	deleting := dbgen.Workspace(t, db, database.WorkspaceTable{Name: "deleting", TemplateID: t1.ID, OwnerID: user.ID, OrganizationID: org.ID, LastUsedAt: now.Add(-55 * dayDuration)})
+	_, err = db.UpdateWorkspaceDormantDeletingAt(ctx, database.UpdateWorkspaceDormantDeletingAtParams{
+		ID:        deleting.ID,
+		DormantAt: sql.NullTime{Time: now.Add(-25 * dayDuration), Valid: true},
+	})
+	require.NoError(t, err)
+	_ = dbgen.Workspace(t, db, database.WorkspaceTable{TemplateID: t2.ID, OwnerID: user.ID, OrganizationID: org.ID, LastUsedAt: now.Add(-100 * dayDuration)})
+
+	// When
+	notifEnq.Clear()
+	err = runScheduledReport(ctx, logger, authedDB(t, db, logger), notifEnq, clk, workspacesPendingDeletionReport)
+
+	// Then
+	require.NoError(t, err)
+	sent := notifEnq.Sent()
+	require.Len(t, sent, 1)
+	require.Equal(t, templateAdmin.ID, sent[0].UserID)
+	require.Equal(t, notifications.TemplateWorkspacesPendingDeletionReport, sent[0].TemplateID)
+	require.Equal(t, "week", sent[0].Data["report_frequency"])
+	require.Equal(t, []map[string]any{
+		{
+			"name":         t1.Name,
+			"display_name": t1.DisplayName,
+			"deleting": []map[string]any{
+				{"name": deleting.Name, "owner_username": user.Username, "deleting_at": now.Add(3 * dayDuration).UTC().Format(reportDateFormat)},
+			},
+			"dormant": []map[string]any{
+				{"name": becomingDormant.Name, "owner_username": user.Username, "last_used_at": now.Add(-25 * dayDuration).UTC().Format(reportDateFormat), "dormant_at": now.Add(5 * dayDuration).UTC().Format(reportDateFormat)},
+			},
+			"more": 0,
+		},
+	}, sent[0].Data["templates"])
+
+	// Given: one day later
+	clk.Advance(dayDuration)
+
+	// When
+	notifEnq.Clear()
+	err = runScheduledReport(ctx, logger, authedDB(t, db, logger), notifEnq, clk, workspacesPendingDeletionReport)
+
+	// Then: no report as it is too early
+	require.NoError(t, err)
+	require.Empty(t, notifEnq.Sent())
+}
+
+func TestReportTemplateVersionAdoption(t *testing.T) {
+	t.Parallel()
+
+	// Setup
+	ctx, logger, db, ps, notifEnq, clk := setup(t)
+
+	// Given
+	org := dbgen.Organization(t, db, database.Organization{})
+	templateAdmin := dbgen.User(t, db, database.User{Username: "template-admin-1", RBACRoles: []string{rbac.RoleTemplateAdmin().Name}})
+	_ = dbgen.OrganizationMember(t, db, database.OrganizationMember{UserID: templateAdmin.ID, OrganizationID: org.ID})
+	user := dbgen.User(t, db, database.User{})
+	_ = dbgen.OrganizationMember(t, db, database.OrganizationMember{UserID: user.ID, OrganizationID: org.ID})
+
+	t1 := dbgen.Template(t, db, database.Template{Name: "template-1", DisplayName: "First Template", CreatedBy: templateAdmin.ID, OrganizationID: org.ID})
+	t1v1 := dbgen.TemplateVersion(t, db, database.TemplateVersion{Name: "template-1-version-1", CreatedBy: templateAdmin.ID, OrganizationID: org.ID, TemplateID: uuid.NullUUID{UUID: t1.ID, Valid: true}, JobID: uuid.New()})
+	t1v2 := dbgen.TemplateVersion(t, db, database.TemplateVersion{Name: "template-1-version-2", CreatedBy: templateAdmin.ID, OrganizationID: org.ID, TemplateID: uuid.NullUUID{UUID: t1.ID, Valid: true}, JobID: uuid.New()})
+	t2 := dbgen.Template(t, db, database.Template{Name: "template-2", CreatedBy: templateAdmin.ID, OrganizationID: org.ID})
+	t2v1 := dbgen.TemplateVersion(t, db, database.TemplateVersion{Name: "template-2-version-1", CreatedBy: templateAdmin.ID, OrganizationID: org.ID, TemplateID: uuid.NullUUID{UUID: t2.ID, Valid: true}, JobID: uuid.New()})
+	for _, tc := range []struct{ templateID, versionID uuid.UUID }{{t1.ID, t1v2.ID}, {t2.ID, t2v1.ID}} {
+		require.NoError(t, db.UpdateTemplateActiveVersionByID(ctx, database.UpdateTemplateActiveVersionByIDParams{
+			ID:              tc.templateID,
+			ActiveVersionID: tc.versionID,
+			UpdatedAt:       clk.Now(),
+		}))
+	}
+
+	build := func(templateID, versionID uuid.UUID) {
+		w := dbgen.Workspace(t, db, database.WorkspaceTable{TemplateID: templateID, OwnerID: user.ID, OrganizationID: org.ID})
+		pj := dbgen.ProvisionerJob(t, db, ps, database.ProvisionerJob{OrganizationID: org.ID, CompletedAt: sql.NullTime{Time: clk.Now(), Valid: true}})
+		_ = dbgen.WorkspaceBuild(t, db, database.WorkspaceBuild{WorkspaceID: w.ID, BuildNumber: 1, TemplateVersionID: versionID, JobID: pj.ID, Transition: database.WorkspaceTransitionStart, Reason: database.BuildReasonInitiator})
+	}
+	build(t1.ID, t1v1.ID)
+	build(t1.ID, t1v1.ID)
+	build(t1.ID, t1v2.ID)
+	// All workspaces of the second template are up to date.
+	build(t2.ID, t2v1.ID)
+
+	// When: first run
+	notifEnq.Clear()
+	err := runScheduledReport(ctx, logger, db, notifEnq, clk, templateVersionAdoptionReport)
+
+	// Then: the job is only checked in
+	require.NoError(t, err)
+	require.Empty(t, notifEnq.Sent())
+
+	// Given: one week later
+	clk.Advance(weeklyReportFrequency + time.Minute)
+
+	// When
+	notifEnq.Clear()
+	err = runScheduledReport(ctx, logger, authedDB(t, db, logger), notifEnq, clk, templateVersionAdoptionReport)
+
+	// Then
+	require.NoError(t, err)
+	sent := notifEnq.Sent()
+	require.Len(t, sent, 1)
+	require.Equal(t, templateAdmin.ID, sent[0].UserID)
+	require.Equal(t, notifications.TemplateVersionAdoptionReport, sent[0].TemplateID)
+	require.Equal(t, []map[string]any{
+		{
+			"name":                t1.Name,
+			"display_name":        t1.DisplayName,
+			"active_version":      t1v2.Name,
+			"total_workspaces":    int32(3),
+			"outdated_workspaces": int32(2),
+			"outdated_versions": []map[string]any{
+				{"name": t1v1.Name, "workspace_count": int32(2)},
+			},
+		},
+	}, sent[0].Data["templates"])
+}
+
+func TestReportTopResourceConsumers(t *testing.T) {
+	t.Parallel()
+
+	// Setup
+	ctx, logger, db, ps, notifEnq, clk := setup(t)
+
+	// Given
+	org := dbgen.Organization(t, db, database.Organization{})
+	templateAdmin := dbgen.User(t, db, database.User{Username: "template-admin-1", RBACRoles: []string{rbac.RoleTemplateAdmin().Name}})
+	_ = dbgen.OrganizationMember(t, db, database.OrganizationMember{UserID: templateAdmin.ID, OrganizationID: org.ID})
+	// Template admin in some other org, they should not receive any notification
+	_ = dbgen.User(t, db, database.User{Username: "template-admin-2", RBACRoles: []string{rbac.RoleTemplateAdmin().Name}})
+	user := dbgen.User(t, db, database.User{})
+	_ = dbgen.OrganizationMember(t, db, database.OrganizationMember{UserID: user.ID, OrganizationID: org.ID})
+
+	t1 := dbgen.Template(t, db, database.Template{Name: "template-1", DisplayName: "First Template", CreatedBy: templateAdmin.ID, OrganizationID: org.ID})
+	t1v1 := dbgen.TemplateVersion(t, db, database.TemplateVersion{CreatedBy: templateAdmin.ID, OrganizationID: org.ID, TemplateID: uuid.NullUUID{UUID: t1.ID, Valid: true}, JobID: uuid.New()})
+	w1 := dbgen.Workspace(t, db, database.WorkspaceTable{Name: "small", TemplateID: t1.ID, OwnerID: user.ID, OrganizationID: org.ID})
+	w2 := dbgen.Workspace(t, db, database.WorkspaceTable{Name: "large", TemplateID: t1.ID, OwnerID: user.ID, OrganizationID: org.ID})
+	w3 := dbgen.Workspace(t, db, database.WorkspaceTable{Name: "idle", TemplateID: t1.ID, OwnerID: user.ID, OrganizationID: org.ID})
+	pj := dbgen.ProvisionerJob(t, db, ps, database.ProvisionerJob{OrganizationID: org.ID, CompletedAt: sql.NullTime{Time: clk.Now(), Valid: true}})
+	_ = dbgen.WorkspaceBuild(t, db, database.WorkspaceBuild{WorkspaceID: w2.ID, BuildNumber: 1, TemplateVersionID: t1v1.ID, JobID: pj.ID, DailyCost: 10, Transition: database.WorkspaceTransitionStart, Reason: database.BuildReasonInitiator})
+
+	// When: first run
+	notifEnq.Clear()
+	err := runScheduledReport(ctx, logger, db, notifEnq, clk, topResourceConsumersReport)
+
+	// Then: the job is only checked in
+	require.NoError(t, err)
+	require.Empty(t, notifEnq.Sent())
+
+	// Given: one week later, with stats since then
+	clk.Advance(weeklyReportFrequency + time.Minute)
+	now := clk.Now()
+
+	stat := func(workspaceID uuid.UUID, createdAt time.Time, bytes, sessions int64) {
+		_ = dbgen.WorkspaceAgentStat(t, db, database.WorkspaceAgentStat{
+			CreatedAt:       createdAt,
+			UserID:          user.ID,
+			TemplateID:      t1.ID,
+			WorkspaceID:     workspaceID,
+			RxBytes:         bytes / 2,
+			TxBytes:         bytes / 2,
+			SessionCountSSH: sessions,
+		})
+	}
+	stat(w1.ID, now.Add(-dayDuration), 1000, 1)
+	stat(w1.ID, now.Add(-2*dayDuration), 1000, 1)
+	stat(w2.ID, now.Add(-dayDuration), 5_000_000, 1)
+	// Stats from before the report period are ignored.
+	stat(w3.ID, now.Add(-8*dayDuration), 10_000_000, 1)
+
+	// When
+	notifEnq.Clear()
+	err = runScheduledReport(ctx, logger, authedDB(t, db, logger), notifEnq, clk, topResourceConsumersReport)
+
+	// Then
+	require.NoError(t, err)
+	sent := notifEnq.Sent()
+	require.Len(t, sent, 1)
+	require.Equal(t, templateAdmin.ID, sent[0].UserID)
+	require.Equal(t, notifications.TemplateTopResourceConsumersReport, sent[0].TemplateID)
+	require.Equal(t, "week", sent[0].Data["report_frequency"])
+	require.Equal(t, []map[string]any{
+		{
+			"name":         org.Name,
+			"display_name": org.DisplayName,
+			"workspaces": []map[string]any{
+				{"position": int64(1), "name": w2.Name, "owner_username": user.Username, "template_display_name": t1.DisplayName, "transferred": humanize.Bytes(5_000_000), "sessions": int64(1), "daily_cost": int32(10)},
+				{"position": int64(2), "name": w1.Name, "owner_username": user.Username, "template_display_name": t1.DisplayName, "transferred": humanize.Bytes(2000), "sessions": int64(2), "daily_cost": int32(0)},
+			},
+		},
+	}, sent[0].Data["organizations"])
+}
+
+func TestReportPrebuildPoolHealth(t *testing.T) {
+	t.Parallel()
+
+	if !dbtestutil.WillUsePostgres() {
+		t.Skip("This test requires postgres")
+	}
+
+	// Setup
+	ctx, logger, db, _, notifEnq, clk := setup(t)
+
+	// Given
+	org := dbgen.Organization(t, db, database.Organization{})
+	templateAdmin := dbgen.User(t, db, database.User{Username: "template-admin-1", RBACRoles: []string{rbac.RoleTemplateAdmin().Name}})
+	_ = dbgen.OrganizationMember(t, db, database.OrganizationMember{UserID: templateAdmin.ID, OrganizationID: org.ID})
+
+	t1 := dbgen.Template(t, db, database.Template{Name: "template-1", DisplayName: "First Template", CreatedBy: templateAdmin.ID, OrganizationID: org.ID})
+	t1v1 := dbgen.TemplateVersion(t, db, database.TemplateVersion{CreatedBy: templateAdmin.ID, OrganizationID: org.ID, TemplateID: uuid.NullUUID{UUID: t1.ID, Valid: true}, JobID: uuid.New()})
+	require.NoError(t, db.UpdateTemplateActiveVersionByID(ctx, database.UpdateTemplateActiveVersionByIDParams{
+		ID:              t1.ID,
+		ActiveVersionID: t1v1.ID,
+		UpdatedAt:       clk.Now(),
+	}))
+	// No prebuilt workspaces are running for this preset.
+	unhealthy := dbgen.Preset(t, db, database.InsertPresetParams{Name: "unhealthy", TemplateVersionID: t1v1.ID, DesiredInstances: sql.NullInt32{Int32: 2, Valid: true}})
+	// Nothing is desired from this preset, so its pool is always filled.
+	_ = dbgen.Preset(t, db, database.InsertPresetParams{Name: "empty", TemplateVersionID: t1v1.ID, DesiredInstances: sql.NullInt32{Int32: 0, Valid: true}})
+
+	// When: first run
+	notifEnq.Clear()
+	err := runScheduledReport(ctx, logger, db, notifEnq, clk, prebuildPoolHealthReport)
+
+	// Then: the job is only checked in
+	require.NoError(t, err)
+	require.Empty(t, notifEnq.Sent())
+
+	// Given: one day later
+	clk.Advance(dailyReportFrequency + time.Minute)
+
+	// When
+	notifEnq.Clear()
+	err = runScheduledReport(ctx, logger, authedDB(t, db, logger), notifEnq, clk, prebuildPoolHealthReport)
+
+	// Then
+	require.NoError(t, err)
+	sent := notifEnq.Sent()
+	require.Len(t, sent, 1)
+	require.Equal(t, templateAdmin.ID, sent[0].UserID)
+	require.Equal(t, notifications.TemplatePrebuildPoolHealthReport, sent[0].TemplateID)
+	require.Equal(t, "day", sent[0].Data["report_frequency"])
+	require.Equal(t, []map[string]any{
+		{
+			"name":         t1.Name,
+			"display_name": t1.DisplayName,
+			"presets": []map[string]any{
+				{"name": unhealthy.Name, "desired": int32(2), "running": int32(0), "ready": int32(0), "failed_builds": int32(0), "hard_limited": false},
+			},
+		},
+	}, sent[0].Data["templates"])
+}
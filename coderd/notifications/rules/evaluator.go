@@ -0,0 +1,342 @@
+package rules
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"net/url"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"github.com/coder/quartz"
+
+	"github.com/coder/coder/v2/coderd/database"
+	"github.com/coder/coder/v2/coderd/database/dbauthz"
+	"github.com/coder/coder/v2/coderd/database/dbtime"
+	"github.com/coder/coder/v2/coderd/httpmw"
+	"github.com/coder/coder/v2/coderd/notifications"
+	"github.com/coder/coder/v2/coderd/rbac"
+	"github.com/coder/coder/v2/coderd/rbac/policy"
+	"github.com/coder/coder/v2/coderd/searchquery"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+const (
+	interval = time.Minute
+
+	// maxEvents is the number of matching events listed in a notification.
+	maxEvents = 10
+
+	eventTimeFormat = "2006-01-02 15:04:05 MST"
+)
+
+// NewEvaluator periodically evaluates the enabled notification rules, and notifies their owners of the rules
+// which are triggered. The authorizer checks that the owners of audit log rules can still read the audit logs
+// the rules match.
+func NewEvaluator(ctx context.Context, logger slog.Logger, db database.Store, authorizer rbac.Authorizer, enqueuer notifications.Enqueuer, clk quartz.Clock) io.Closer {
+	closed := make(chan struct{})
+
+	ctx, cancelFunc := context.WithCancel(ctx)
+	//nolint:gocritic // The system evaluates notification rules without direct user input.
+	ctx = dbauthz.AsSystemRestricted(ctx)
+
+	ticker := clk.NewTicker(interval)
+	ticker.Stop()
+	doTick := func(now time.Time) {
+		defer ticker.Reset(interval)
+		// Start a transaction to grab advisory lock, we don't want to evaluate rules at the same time (multiple replicas).
+		if err := db.InTx(func(tx database.Store) error {
+			ok, err := tx.TryAcquireLock(ctx, database.LockIDNotificationRulesEvaluator)
+			if err != nil {
+				return xerrors.Errorf("failed to acquire notification rules evaluator lock: %w", err)
+			}
+			if !ok {
+				logger.Debug(ctx, "unable to acquire lock for evaluating notification rules, skipping")
+				return nil
+			}
+
+			rules, err := tx.GetEnabledNotificationRules(ctx)
+			if err != nil {
+				return xerrors.Errorf("get enabled notification rules: %w", err)
+			}
+			for _, rule := range rules {
+				if err := evaluateRule(ctx, logger, tx, authorizer, enqueuer, rule, now); err != nil {
+					return xerrors.Errorf("evaluate notification rule %q: %w", rule.ID, err)
+				}
+			}
+			return nil
+		}, nil); err != nil {
+			logger.Error(ctx, "failed to evaluate notification rules", slog.Error(err))
+		}
+	}
+
+	go func() {
+		defer close(closed)
+		defer ticker.Stop()
+		// Force an initial tick.
+		doTick(dbtime.Time(clk.Now()).UTC())
+		for {
+			select {
+			case <-ctx.Done():
+				logger.Debug(ctx, "closing notification rules evaluator")
+				return
+			case tick := <-ticker.C:
+				ticker.Stop()
+
+				doTick(dbtime.Time(tick).UTC())
+			}
+		}
+	}()
+	return &evaluator{
+		cancel: cancelFunc,
+		closed: closed,
+	}
+}
+
+type evaluator struct {
+	cancel context.CancelFunc
+	closed chan struct{}
+}
+
+func (e *evaluator) Close() error {
+	e.cancel()
+	<-e.closed
+	return nil
+}
+
+// ValidateQuery returns the errors of a notification rule query for the given source.
+func ValidateQuery(ctx context.Context, db database.Store, source database.NotificationRuleSource, query string) []codersdk.ValidationError {
+	switch source {
+	case database.NotificationRuleSourceAuditLog:
+		_, _, errs := searchquery.AuditLogs(ctx, db, query)
+		return errs
+	case database.NotificationRuleSourceWorkspaceBuild:
+		_, errs := searchquery.WorkspaceBuildEvents(query)
+		return errs
+	default:
+		return []codersdk.ValidationError{{Field: "source", Detail: fmt.Sprintf("unknown source %q", source)}}
+	}
+}
+
+// matchedEvents are the events matching a rule in the evaluated period.
+type matchedEvents struct {
+	count  int64
+	events []map[string]any
+	// path is the page of the dashboard which lists the events.
+	path string
+}
+
+// evaluateRule counts the events matching the rule and notifies its owner when they reach the threshold.
+//
+// Rules without a window count the events since the previous evaluation. Rules with a window count the events
+// within the window, and are not triggered again until the window passed since they were last triggered.
+//
+// Audit log rules are disabled once their owner can no longer read the audit logs of the organization, as the
+// notifications describe the matching entries.
+func evaluateRule(ctx context.Context, logger slog.Logger, db database.Store, authorizer rbac.Authorizer, enqueuer notifications.Enqueuer, rule database.NotificationRule, now time.Time) error {
+	logger = logger.With(slog.F("rule_id", rule.ID), slog.F("rule_name", rule.Name))
+
+	// Audit logs are read as the rule owner.
+	ownerCtx := ctx
+	if rule.Source == database.NotificationRuleSourceAuditLog {
+		owner, allowed, err := auditLogReader(ctx, db, authorizer, rule)
+		if err != nil {
+			return err
+		}
+		ownerCtx = dbauthz.As(ctx, owner)
+		if !allowed {
+			logger.Warn(ctx, "owner of notification rule can no longer read audit logs, disabling rule", slog.F("user_id", rule.UserID))
+			return db.UpdateNotificationRuleEvaluation(ctx, database.UpdateNotificationRuleEvaluationParams{
+				ID:              rule.ID,
+				LastEvaluatedAt: now,
+				Enabled:         sql.NullBool{Bool: false, Valid: true},
+			})
+		}
+	}
+
+	window := time.Duration(rule.WindowSeconds) * time.Second
+	since := rule.LastEvaluatedAt
+	if window > 0 {
+		since = now.Add(-window)
+	}
+
+	update := database.UpdateNotificationRuleEvaluationParams{
+		ID:              rule.ID,
+		LastEvaluatedAt: now,
+	}
+
+	var (
+		matched matchedEvents
+		errs    []codersdk.ValidationError
+		err     error
+	)
+	switch rule.Source {
+	case database.NotificationRuleSourceAuditLog:
+		matched, errs, err = matchAuditLogs(ownerCtx, db, rule, since, now)
+	case database.NotificationRuleSourceWorkspaceBuild:
+		matched, errs, err = matchWorkspaceBuilds(ctx, db, rule, since, now)
+	default:
+		err = xerrors.Errorf("unknown source %q", rule.Source)
+	}
+	if err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		// The query was valid when the rule was saved, but may refer to an organization which no longer exists.
+		logger.Warn(ctx, "notification rule has an invalid query, skipping", slog.F("errors", errs))
+		return db.UpdateNotificationRuleEvaluation(ctx, update)
+	}
+
+	triggered := matched.count > 0 && matched.count >= int64(rule.Threshold)
+	if window > 0 && rule.LastTriggeredAt.Valid && rule.LastTriggeredAt.Time.After(since) {
+		triggered = false
+	}
+	if triggered {
+		if err := notify(ctx, logger, enqueuer, rule, matched, window); err != nil {
+			return err
+		}
+		update.LastTriggeredAt = sql.NullTime{Time: now, Valid: true}
+	}
+
+	return db.UpdateNotificationRuleEvaluation(ctx, update)
+}
+
+// auditLogReader returns the subject of the rule owner, and whether they can read the audit logs of the rule's
+// organization. Their current roles are checked, rather than those they had when the rule was saved.
+func auditLogReader(ctx context.Context, db database.Store, authorizer rbac.Authorizer, rule database.NotificationRule) (rbac.Subject, bool, error) {
+	subject, _, err := httpmw.UserRBACSubject(ctx, db, rule.UserID, rbac.ScopeAll)
+	if err != nil {
+		return rbac.Subject{}, false, xerrors.Errorf("get roles of rule owner: %w", err)
+	}
+	err = authorizer.Authorize(ctx, subject, policy.ActionRead, rbac.ResourceAuditLog.InOrg(rule.OrganizationID))
+	return subject, err == nil, nil
+}
+
+func matchAuditLogs(ctx context.Context, db database.Store, rule database.NotificationRule, since, now time.Time) (matchedEvents, []codersdk.ValidationError, error) {
+	filter, countFilter, errs := searchquery.AuditLogs(ctx, db, rule.Query)
+	if len(errs) > 0 {
+		return matchedEvents{}, errs, nil
+	}
+
+	// Rules only match the audit logs of their own organization. The dates of the query are replaced by the evaluated
+	// period, which excludes its start.
+	filter.OrganizationID = rule.OrganizationID
+	filter.DateFrom = since.Add(time.Microsecond)
+	filter.DateTo = now
+	filter.LimitOpt = maxEvents
+	countFilter.OrganizationID = filter.OrganizationID
+	countFilter.DateFrom = filter.DateFrom
+	countFilter.DateTo = filter.DateTo
+
+	count, err := db.CountAuditLogs(ctx, countFilter)
+	if err != nil {
+		return matchedEvents{}, nil, xerrors.Errorf("count audit logs: %w", err)
+	}
+	matched := matchedEvents{
+		count: count,
+		path:  "/audit?filter=" + url.QueryEscape(rule.Query),
+	}
+	if count == 0 {
+		return matched, nil, nil
+	}
+
+	logs, err := db.GetAuditLogsOffset(ctx, filter)
+	if err != nil {
+		return matchedEvents{}, nil, xerrors.Errorf("get audit logs: %w", err)
+	}
+	for _, log := range logs {
+		user := "System"
+		if log.UserUsername.Valid {
+			user = log.UserUsername.String
+		}
+		matched.events = append(matched.events, map[string]any{
+			"time":        log.AuditLog.Time.UTC().Format(eventTimeFormat),
+			"description": fmt.Sprintf("%s: %s %s %s", user, log.AuditLog.Action, log.AuditLog.ResourceType, log.AuditLog.ResourceTarget),
+		})
+	}
+	return matched, nil, nil
+}
+
+func matchWorkspaceBuilds(ctx context.Context, db database.Store, rule database.NotificationRule, since, now time.Time) (matchedEvents, []codersdk.ValidationError, error) {
+	filter, errs := searchquery.WorkspaceBuildEvents(rule.Query)
+	if len(errs) > 0 {
+		return matchedEvents{}, errs, nil
+	}
+
+	filter.OrganizationID = rule.OrganizationID
+	filter.CompletedAfter = since
+	filter.CompletedBefore = now
+	filter.LimitOpt = maxEvents
+
+	builds, err := db.GetWorkspaceBuildEvents(ctx, filter)
+	if err != nil {
+		return matchedEvents{}, nil, xerrors.Errorf("get workspace build events: %w", err)
+	}
+	matched := matchedEvents{path: "/workspaces"}
+	for _, build := range builds {
+		matched.count = build.Count
+		matched.events = append(matched.events, map[string]any{
+			"time": build.CompletedAt.UTC().Format(eventTimeFormat),
+			"description": fmt.Sprintf("%s/%s #%d %s %s (template %s, reason %s)",
+				build.WorkspaceOwnerUsername, build.WorkspaceName, build.BuildNumber, build.Transition, build.JobStatus, build.TemplateName, build.Reason),
+		})
+	}
+	return matched, nil, nil
+}
+
+func notify(ctx context.Context, logger slog.Logger, enqueuer notifications.Enqueuer, rule database.NotificationRule, matched matchedEvents, window time.Duration) error {
+	source := "audit log"
+	if rule.Source == database.NotificationRuleSourceWorkspaceBuild {
+		source = "workspace build"
+	}
+	labels := map[string]string{
+		"rule_name":   rule.Name,
+		"source":      source,
+		"events_path": matched.path,
+	}
+	data := map[string]any{
+		"count":  matched.count,
+		"events": matched.events,
+	}
+	if window > 0 {
+		data["window"] = formatWindow(window)
+	}
+	if more := matched.count - int64(len(matched.events)); more > 0 {
+		data["more"] = more
+	}
+
+	var err error
+	if methodEnqueuer, ok := enqueuer.(notifications.MethodEnqueuer); ok && rule.Method.Valid {
+		_, err = methodEnqueuer.EnqueueWithMethod(ctx, rule.Method.NotificationMethod, rule.UserID, notifications.TemplateNotificationRuleTriggered, labels, data, "notification-rules")
+	} else {
+		_, err = enqueuer.EnqueueWithData(ctx, rule.UserID, notifications.TemplateNotificationRuleTriggered, labels, data, "notification-rules")
+	}
+	if err != nil {
+		if xerrors.Is(err, notifications.ErrCannotEnqueueDisabledNotification) || xerrors.Is(err, notifications.ErrDuplicate) {
+			logger.Debug(ctx, "notification rule was not delivered", slog.Error(err))
+			return nil
+		}
+		return xerrors.Errorf("enqueue notification: %w", err)
+	}
+	return nil
+}
+
+// formatWindow formats a window in the largest unit which divides it, e.g. "2 hours".
+func formatWindow(window time.Duration) string {
+	value, unit := int64(window/time.Second), "second"
+	switch {
+	case window%(24*time.Hour) == 0:
+		value, unit = int64(window/(24*time.Hour)), "day"
+	case window%time.Hour == 0:
+		value, unit = int64(window/time.Hour), "hour"
+	case window%time.Minute == 0:
+		value, unit = int64(window/time.Minute), "minute"
+	}
+	if value == 1 {
+		return fmt.Sprintf("%d %s", value, unit)
+	}
+	return fmt.Sprintf("%d %ss", value, unit)
+}
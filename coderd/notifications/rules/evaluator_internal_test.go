@@ -0,0 +1,208 @@
+package rules
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+
+	"cdr.dev/slog/sloggers/slogtest"
+
+	"github.com/coder/coder/v2/coderd/database"
+	"github.com/coder/coder/v2/coderd/database/dbauthz"
+	"github.com/coder/coder/v2/coderd/database/dbgen"
+	"github.com/coder/coder/v2/coderd/database/dbtestutil"
+	"github.com/coder/coder/v2/coderd/database/dbtime"
+	"github.com/coder/coder/v2/coderd/notifications"
+	"github.com/coder/coder/v2/coderd/notifications/notificationstest"
+	"github.com/coder/coder/v2/coderd/rbac"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+func TestEvaluateAuditLogRule(t *testing.T) {
+	t.Parallel()
+
+	// nolint:gocritic // The evaluator runs as system.
+	ctx := dbauthz.AsSystemRestricted(context.Background())
+	logger := slogtest.Make(t, &slogtest.Options{})
+	db, _ := dbtestutil.NewDB(t)
+	notifEnq := &notificationstest.FakeEnqueuer{}
+	authorizer := rbac.NewStrictCachingAuthorizer(prometheus.NewRegistry())
+	now := dbtime.Now()
+
+	// Given: a rule matching role assignments in an organization
+	org := dbgen.Organization(t, db, database.Organization{})
+	otherOrg := dbgen.Organization(t, db, database.Organization{})
+	owner := dbgen.User(t, db, database.User{RBACRoles: []string{codersdk.RoleAuditor}})
+	admin := dbgen.User(t, db, database.User{Username: "admin"})
+	rule := dbgen.NotificationRule(t, db, database.NotificationRule{
+		OrganizationID: org.ID,
+		UserID:         owner.ID,
+		Name:           "role-assignments",
+		Source:         database.NotificationRuleSourceAuditLog,
+		Query:          "resource_type:organization_member action:write",
+		CreatedAt:      now.Add(-2 * time.Minute),
+	})
+
+	_ = dbgen.AuditLog(t, db, database.AuditLog{OrganizationID: org.ID, UserID: admin.ID, Time: now.Add(-time.Minute), ResourceType: database.ResourceTypeOrganizationMember, Action: database.AuditActionWrite, ResourceTarget: "bob"})
+	// Neither of these match.
+	_ = dbgen.AuditLog(t, db, database.AuditLog{OrganizationID: otherOrg.ID, UserID: admin.ID, Time: now.Add(-time.Minute), ResourceType: database.ResourceTypeOrganizationMember, Action: database.AuditActionWrite})
+	_ = dbgen.AuditLog(t, db, database.AuditLog{OrganizationID: org.ID, UserID: admin.ID, Time: now.Add(-time.Minute), ResourceType: database.ResourceTypeTemplate, Action: database.AuditActionWrite})
+
+	// When
+	err := evaluateRule(ctx, logger, db, authorizer, notifEnq, rule, now)
+
+	// Then
+	require.NoError(t, err)
+	sent := notifEnq.Sent()
+	require.Len(t, sent, 1)
+	require.Equal(t, owner.ID, sent[0].UserID)
+	require.Equal(t, notifications.TemplateNotificationRuleTriggered, sent[0].TemplateID)
+	require.Equal(t, "role-assignments", sent[0].Labels["rule_name"])
+	require.Equal(t, "audit log", sent[0].Labels["source"])
+	require.Equal(t, "/audit?filter=resource_type%3Aorganization_member+action%3Awrite", sent[0].Labels["events_path"])
+	require.EqualValues(t, 1, sent[0].Data["count"])
+	require.Equal(t, []map[string]any{
+		{"time": now.Add(-time.Minute).UTC().Format(eventTimeFormat), "description": "admin: write organization_member bob"},
+	}, sent[0].Data["events"])
+
+	rule, err = db.GetNotificationRuleByID(ctx, rule.ID)
+	require.NoError(t, err)
+	require.WithinDuration(t, now, rule.LastEvaluatedAt, time.Second)
+	require.True(t, rule.LastTriggeredAt.Valid)
+
+	// When: evaluated again without new events
+	notifEnq.Clear()
+	err = evaluateRule(ctx, logger, db, authorizer, notifEnq, rule, now.Add(time.Minute))
+
+	// Then: events are not notified twice
+	require.NoError(t, err)
+	require.Empty(t, notifEnq.Sent())
+}
+
+func TestEvaluateAuditLogRuleOwnerLostRoles(t *testing.T) {
+	t.Parallel()
+
+	// nolint:gocritic // The evaluator runs as system.
+	ctx := dbauthz.AsSystemRestricted(context.Background())
+	logger := slogtest.Make(t, &slogtest.Options{IgnoreErrors: true})
+	db, _ := dbtestutil.NewDB(t)
+	notifEnq := &notificationstest.FakeEnqueuer{}
+	authorizer := rbac.NewStrictCachingAuthorizer(prometheus.NewRegistry())
+	now := dbtime.Now()
+
+	// Given: a rule whose owner is no longer allowed to read audit logs
+	org := dbgen.Organization(t, db, database.Organization{})
+	owner := dbgen.User(t, db, database.User{})
+	rule := dbgen.NotificationRule(t, db, database.NotificationRule{
+		OrganizationID: org.ID,
+		UserID:         owner.ID,
+		Source:         database.NotificationRuleSourceAuditLog,
+		Query:          "action:write",
+		CreatedAt:      now.Add(-2 * time.Minute),
+	})
+	_ = dbgen.AuditLog(t, db, database.AuditLog{OrganizationID: org.ID, Time: now.Add(-time.Minute), Action: database.AuditActionWrite})
+
+	// When
+	err := evaluateRule(ctx, logger, db, authorizer, notifEnq, rule, now)
+
+	// Then: the rule is disabled without notifying the owner
+	require.NoError(t, err)
+	require.Empty(t, notifEnq.Sent())
+	rule, err = db.GetNotificationRuleByID(ctx, rule.ID)
+	require.NoError(t, err)
+	require.False(t, rule.Enabled)
+}
+
+func TestEvaluateWorkspaceBuildRule(t *testing.T) {
+	t.Parallel()
+
+	// nolint:gocritic // The evaluator runs as system.
+	ctx := dbauthz.AsSystemRestricted(context.Background())
+	logger := slogtest.Make(t, &slogtest.Options{})
+	db, ps := dbtestutil.NewDB(t)
+	notifEnq := &notificationstest.FakeEnqueuer{}
+	authorizer := rbac.NewStrictCachingAuthorizer(prometheus.NewRegistry())
+	now := dbtime.Now()
+
+	// Given: a rule matching more than 3 failed starts of a template within an hour
+	org := dbgen.Organization(t, db, database.Organization{})
+	user := dbgen.User(t, db, database.User{Username: "alice"})
+	tmpl := dbgen.Template(t, db, database.Template{Name: "docker", CreatedBy: user.ID, OrganizationID: org.ID})
+	tv := dbgen.TemplateVersion(t, db, database.TemplateVersion{CreatedBy: user.ID, OrganizationID: org.ID, TemplateID: uuid.NullUUID{UUID: tmpl.ID, Valid: true}, JobID: uuid.New()})
+	ws := dbgen.Workspace(t, db, database.WorkspaceTable{Name: "dev", TemplateID: tmpl.ID, OwnerID: user.ID, OrganizationID: org.ID})
+	rule := dbgen.NotificationRule(t, db, database.NotificationRule{
+		OrganizationID: org.ID,
+		UserID:         user.ID,
+		Source:         database.NotificationRuleSourceWorkspaceBuild,
+		Query:          "template:docker transition:start status:failed",
+		Threshold:      3,
+		WindowSeconds:  int32(time.Hour / time.Second),
+		CreatedAt:      now.Add(-2 * time.Hour),
+	})
+
+	build := func(number int32, completedAt time.Time, transition database.WorkspaceTransition, jobError string) {
+		job := dbgen.ProvisionerJob(t, db, ps, database.ProvisionerJob{
+			OrganizationID: org.ID,
+			CompletedAt:    sql.NullTime{Time: completedAt, Valid: true},
+			Error:          sql.NullString{String: jobError, Valid: jobError != ""},
+		})
+		_ = dbgen.WorkspaceBuild(t, db, database.WorkspaceBuild{WorkspaceID: ws.ID, BuildNumber: number, TemplateVersionID: tv.ID, JobID: job.ID, Transition: transition, Reason: database.BuildReasonInitiator})
+	}
+	// Outside of the window.
+	build(1, now.Add(-90*time.Minute), database.WorkspaceTransitionStart, "failed")
+	build(2, now.Add(-50*time.Minute), database.WorkspaceTransitionStart, "failed")
+	build(3, now.Add(-40*time.Minute), database.WorkspaceTransitionStop, "failed")
+	build(4, now.Add(-30*time.Minute), database.WorkspaceTransitionStart, "failed")
+
+	// When: only two failed starts are in the window
+	err := evaluateRule(ctx, logger, db, authorizer, notifEnq, rule, now)
+
+	// Then
+	require.NoError(t, err)
+	require.Empty(t, notifEnq.Sent())
+
+	// Given: a third failed start
+	build(5, now.Add(time.Minute), database.WorkspaceTransitionStart, "failed")
+	rule, err = db.GetNotificationRuleByID(ctx, rule.ID)
+	require.NoError(t, err)
+
+	// When
+	err = evaluateRule(ctx, logger, db, authorizer, notifEnq, rule, now.Add(2*time.Minute))
+
+	// Then
+	require.NoError(t, err)
+	sent := notifEnq.Sent()
+	require.Len(t, sent, 1)
+	require.Equal(t, user.ID, sent[0].UserID)
+	require.Equal(t, "workspace build", sent[0].Labels["source"])
+	require.Equal(t, "1 hour", sent[0].Data["window"])
+	require.EqualValues(t, 3, sent[0].Data["count"])
+	require.Len(t, sent[0].Data["events"], 3)
+
+	// Given: a fourth failed start within the window
+	build(6, now.Add(3*time.Minute), database.WorkspaceTransitionStart, "failed")
+	rule, err = db.GetNotificationRuleByID(ctx, rule.ID)
+	require.NoError(t, err)
+
+	// When
+	notifEnq.Clear()
+	err = evaluateRule(ctx, logger, db, authorizer, notifEnq, rule, now.Add(4*time.Minute))
+
+	// Then: the rule is not triggered again until the window passed
+	require.NoError(t, err)
+	require.Empty(t, notifEnq.Sent())
+}
+
+func TestFormatWindow(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "1 hour", formatWindow(time.Hour))
+	require.Equal(t, "90 minutes", formatWindow(90*time.Minute))
+	require.Equal(t, "2 days", formatWindow(48*time.Hour))
+	require.Equal(t, "45 seconds", formatWindow(45*time.Second))
+}
@@ -0,0 +1,93 @@
+package notifications
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/coder/coder/v2/coderd/database"
+)
+
+// deliverySchedule describes when a user wants to receive notifications, in their own timezone.
+type deliverySchedule struct {
+	loc *time.Location
+
+	// quietStart and quietEnd are minutes after midnight; the window wraps past midnight when quietStart > quietEnd.
+	quietHours bool
+	quietStart int
+	quietEnd   int
+
+	// dailyDigest is the time of day, in minutes after midnight, at which daily digests are delivered.
+	dailyDigest int
+}
+
+// newDeliverySchedule builds a deliverySchedule from the nullable user_notification_schedules columns.
+// Users without a schedule get UTC, no quiet hours and a daily digest at 09:00.
+func newDeliverySchedule(timezone sql.NullString, quietStart, quietEnd, dailyDigest sql.NullInt16) deliverySchedule {
+	sched := deliverySchedule{loc: scheduleLocation(timezone), dailyDigest: 9 * 60}
+	if quietStart.Valid && quietEnd.Valid && quietStart.Int16 != quietEnd.Int16 {
+		sched.quietHours = true
+		sched.quietStart = int(quietStart.Int16)
+		sched.quietEnd = int(quietEnd.Int16)
+	}
+	if dailyDigest.Valid {
+		sched.dailyDigest = int(dailyDigest.Int16)
+	}
+	return sched
+}
+
+// scheduleLocation loads the timezone of a user's schedule, falling back to UTC.
+func scheduleLocation(timezone sql.NullString) *time.Location {
+	if timezone.Valid {
+		if loc, err := time.LoadLocation(timezone.String); err == nil {
+			return loc
+		}
+	}
+	return time.UTC
+}
+
+// at returns the given minute of the day of t, in the schedule's timezone.
+func (s deliverySchedule) at(t time.Time, dayOffset, minute int) time.Time {
+	y, m, d := t.In(s.loc).Date()
+	return time.Date(y, m, d+dayOffset, minute/60, minute%60, 0, 0, s.loc)
+}
+
+// quietUntil returns the end of the quiet hours window which now falls in, if any.
+func (s deliverySchedule) quietUntil(now time.Time) (time.Time, bool) {
+	if !s.quietHours {
+		return time.Time{}, false
+	}
+
+	local := now.In(s.loc)
+	minute := local.Hour()*60 + local.Minute()
+	switch {
+	case s.quietStart < s.quietEnd && minute >= s.quietStart && minute < s.quietEnd:
+		return s.at(now, 0, s.quietEnd), true
+	case s.quietStart > s.quietEnd && minute >= s.quietStart:
+		return s.at(now, 1, s.quietEnd), true
+	case s.quietStart > s.quietEnd && minute < s.quietEnd:
+		return s.at(now, 0, s.quietEnd), true
+	default:
+		return time.Time{}, false
+	}
+}
+
+// nextDigest returns when the next digest of the given interval is due after now. Digests which would fall in quiet
+// hours are held until the quiet hours end.
+func (s deliverySchedule) nextDigest(now time.Time, interval database.NotificationDigestInterval) time.Time {
+	var next time.Time
+	switch interval {
+	case database.NotificationDigestIntervalHourly:
+		local := now.In(s.loc)
+		next = time.Date(local.Year(), local.Month(), local.Day(), local.Hour()+1, 0, 0, 0, s.loc)
+	default:
+		next = s.at(now, 0, s.dailyDigest)
+		if !next.After(now) {
+			next = s.at(now, 1, s.dailyDigest)
+		}
+	}
+
+	if until, ok := s.quietUntil(next); ok {
+		return until
+	}
+	return next
+}
@@ -0,0 +1,93 @@
+package notifications
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/coderd/database"
+)
+
+func TestDeliverySchedule(t *testing.T) {
+	t.Parallel()
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	minutes := func(hour, minute int) sql.NullInt16 {
+		// #nosec G115 - Safe conversion for minutes of the day
+		return sql.NullInt16{Int16: int16(hour*60 + minute), Valid: true}
+	}
+	inBerlin := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2025, month, day, hour, minute, 0, 0, berlin)
+	}
+
+	t.Run("QuietHours", func(t *testing.T) {
+		t.Parallel()
+
+		overnight := newDeliverySchedule(sql.NullString{String: "Europe/Berlin", Valid: true}, minutes(22, 0), minutes(7, 30), sql.NullInt16{})
+		daytime := newDeliverySchedule(sql.NullString{String: "Europe/Berlin", Valid: true}, minutes(12, 0), minutes(13, 0), sql.NullInt16{})
+		none := newDeliverySchedule(sql.NullString{}, sql.NullInt16{}, sql.NullInt16{}, sql.NullInt16{})
+
+		for _, tc := range []struct {
+			name   string
+			sched  deliverySchedule
+			now    time.Time
+			quiet  bool
+			expect time.Time
+		}{
+			{name: "BeforeOvernight", sched: overnight, now: inBerlin(time.June, 10, 21, 59)},
+			{name: "OvernightEvening", sched: overnight, now: inBerlin(time.June, 10, 22, 0), quiet: true, expect: inBerlin(time.June, 11, 7, 30)},
+			{name: "OvernightMorning", sched: overnight, now: inBerlin(time.June, 11, 3, 0), quiet: true, expect: inBerlin(time.June, 11, 7, 30)},
+			{name: "AfterOvernight", sched: overnight, now: inBerlin(time.June, 11, 7, 30)},
+			// The last Sunday of March is 23 hours long in Berlin.
+			{name: "OvernightDST", sched: overnight, now: inBerlin(time.March, 29, 23, 0), quiet: true, expect: inBerlin(time.March, 30, 7, 30)},
+			{name: "Daytime", sched: daytime, now: inBerlin(time.June, 10, 12, 15), quiet: true, expect: inBerlin(time.June, 10, 13, 0)},
+			{name: "OutsideDaytime", sched: daytime, now: inBerlin(time.June, 10, 22, 0)},
+			{name: "None", sched: none, now: inBerlin(time.June, 10, 23, 0)},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				// Times are compared in UTC, as the notifier sees them.
+				until, quiet := tc.sched.quietUntil(tc.now.UTC())
+				require.Equal(t, tc.quiet, quiet)
+				if tc.quiet {
+					require.True(t, tc.expect.Equal(until), "expected %s, got %s", tc.expect, until)
+				}
+			})
+		}
+	})
+
+	t.Run("NextDigest", func(t *testing.T) {
+		t.Parallel()
+
+		sched := newDeliverySchedule(sql.NullString{String: "Europe/Berlin", Valid: true}, minutes(22, 0), minutes(7, 30), minutes(18, 0))
+		utc := newDeliverySchedule(sql.NullString{}, sql.NullInt16{}, sql.NullInt16{}, sql.NullInt16{})
+		kolkata := newDeliverySchedule(sql.NullString{String: "Asia/Kolkata", Valid: true}, sql.NullInt16{}, sql.NullInt16{}, sql.NullInt16{})
+
+		for _, tc := range []struct {
+			name     string
+			sched    deliverySchedule
+			now      time.Time
+			interval database.NotificationDigestInterval
+			expect   time.Time
+		}{
+			{name: "Hourly", sched: sched, now: inBerlin(time.June, 10, 14, 20), interval: database.NotificationDigestIntervalHourly, expect: inBerlin(time.June, 10, 15, 0)},
+			{name: "HourlyInQuietHours", sched: sched, now: inBerlin(time.June, 10, 21, 20), interval: database.NotificationDigestIntervalHourly, expect: inBerlin(time.June, 11, 7, 30)},
+			{name: "HourlyHalfHourOffset", sched: kolkata, now: time.Date(2025, time.June, 10, 10, 0, 0, 0, time.UTC), interval: database.NotificationDigestIntervalHourly, expect: time.Date(2025, time.June, 10, 10, 30, 0, 0, time.UTC)},
+			{name: "DailyToday", sched: sched, now: inBerlin(time.June, 10, 14, 20), interval: database.NotificationDigestIntervalDaily, expect: inBerlin(time.June, 10, 18, 0)},
+			{name: "DailyTomorrow", sched: sched, now: inBerlin(time.June, 10, 18, 0), interval: database.NotificationDigestIntervalDaily, expect: inBerlin(time.June, 11, 18, 0)},
+			{name: "DailyDefault", sched: utc, now: time.Date(2025, time.June, 10, 8, 0, 0, 0, time.UTC), interval: database.NotificationDigestIntervalDaily, expect: time.Date(2025, time.June, 10, 9, 0, 0, 0, time.UTC)},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				next := tc.sched.nextDigest(tc.now.UTC(), tc.interval)
+				require.True(t, tc.expect.Equal(next), "expected %s, got %s", tc.expect, next)
+			})
+		}
+	})
+}
@@ -19,6 +19,8 @@ type Store interface {
 	AcquireNotificationMessages(ctx context.Context, params database.AcquireNotificationMessagesParams) ([]database.AcquireNotificationMessagesRow, error)
 	BulkMarkNotificationMessagesSent(ctx context.Context, arg database.BulkMarkNotificationMessagesSentParams) (int64, error)
 	BulkMarkNotificationMessagesFailed(ctx context.Context, arg database.BulkMarkNotificationMessagesFailedParams) (int64, error)
+	BulkDeferNotificationMessages(ctx context.Context, arg database.BulkDeferNotificationMessagesParams) (int64, error)
+	AcquireNotificationDigestMessages(ctx context.Context, arg database.AcquireNotificationDigestMessagesParams) ([]database.AcquireNotificationDigestMessagesRow, error)
 	EnqueueNotificationMessage(ctx context.Context, arg database.EnqueueNotificationMessageParams) error
 	FetchNewMessageMetadata(ctx context.Context, arg database.FetchNewMessageMetadataParams) (database.FetchNewMessageMetadataRow, error)
 	GetNotificationMessagesByStatus(ctx context.Context, arg database.GetNotificationMessagesByStatusParams) ([]database.NotificationMessage, error)
@@ -27,6 +29,10 @@ type Store interface {
 	GetLogoURL(ctx context.Context) (string, error)
 
 	InsertInboxNotification(ctx context.Context, arg database.InsertInboxNotificationParams) (database.InboxNotification, error)
+	GetUserChatIdentity(ctx context.Context, arg database.GetUserChatIdentityParams) (database.UserChatIdentity, error)
+
+	// InTx is used to enqueue a digest and mark the messages it summarizes as sent atomically.
+	InTx(func(database.Store) error, *database.TxOptions) error
 }
 
 // Handler is responsible for preparing and delivering a notification by a given method.
@@ -40,3 +46,9 @@ type Enqueuer interface {
 	Enqueue(ctx context.Context, userID, templateID uuid.UUID, labels map[string]string, createdBy string, targets ...uuid.UUID) ([]uuid.UUID, error)
 	EnqueueWithData(ctx context.Context, userID, templateID uuid.UUID, labels map[string]string, data map[string]any, createdBy string, targets ...uuid.UUID) ([]uuid.UUID, error)
 }
+
+// MethodEnqueuer is an Enqueuer which can override the dispatch method of a message.
+type MethodEnqueuer interface {
+	Enqueuer
+	EnqueueWithMethod(ctx context.Context, method database.NotificationMethod, userID, templateID uuid.UUID, labels map[string]string, data map[string]any, createdBy string, targets ...uuid.UUID) ([]uuid.UUID, error)
+}
@@ -1,17 +1,26 @@
 package coderd_test
 
 import (
+	"encoding/json"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
 	"slices"
+	"strings"
 	"testing"
 
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/coder/serpent"
 
 	"github.com/coder/coder/v2/coderd/coderdtest"
 	"github.com/coder/coder/v2/coderd/database"
+	"github.com/coder/coder/v2/coderd/database/dbtestutil"
 	"github.com/coder/coder/v2/coderd/notifications"
+	"github.com/coder/coder/v2/coderd/notifications/dispatch"
 	"github.com/coder/coder/v2/coderd/notifications/notificationstest"
 	"github.com/coder/coder/v2/codersdk"
 	"github.com/coder/coder/v2/testutil"
@@ -276,33 +285,47 @@ func TestNotificationDispatchMethods(t *testing.T) {
 	defaultOpts := createOpts(t)
 	webhookOpts := createOpts(t)
 	webhookOpts.DeploymentValues.Notifications.Method = serpent.String(database.NotificationMethodWebhook)
+	slackOpts := createOpts(t)
+	slackOpts.DeploymentValues.Notifications.Method = serpent.String(database.NotificationMethodSlack)
+	slackOpts.DeploymentValues.Notifications.Slack.BotToken = "xoxb-test"
+
+	// Chat methods are only available once configured.
+	var baseMethods []string
+	for _, nm := range database.AllNotificationMethodValues() {
+		switch nm {
+		case database.NotificationMethodInbox, database.NotificationMethodSlack,
+			database.NotificationMethodMatrix, database.NotificationMethodTeams:
+			continue
+		}
+		baseMethods = append(baseMethods, string(nm))
+	}
 
 	tests := []struct {
 		name            string
 		opts            *coderdtest.Options
+		expectedMethods []string
 		expectedDefault string
 	}{
 		{
 			name:            "default",
 			opts:            defaultOpts,
+			expectedMethods: baseMethods,
 			expectedDefault: string(database.NotificationMethodSmtp),
 		},
 		{
 			name:            "non-default",
 			opts:            webhookOpts,
+			expectedMethods: baseMethods,
 			expectedDefault: string(database.NotificationMethodWebhook),
 		},
+		{
+			name:            "chat",
+			opts:            slackOpts,
+			expectedMethods: append(slices.Clone(baseMethods), string(database.NotificationMethodSlack)),
+			expectedDefault: string(database.NotificationMethodSlack),
+		},
 	}
 
-	var allMethods []string
-	for _, nm := range database.AllNotificationMethodValues() {
-		if nm == database.NotificationMethodInbox {
-			continue
-		}
-		allMethods = append(allMethods, string(nm))
-	}
-	slices.Sort(allMethods)
-
 	// nolint:paralleltest // Not since Go v1.22.
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
@@ -316,12 +339,178 @@ func TestNotificationDispatchMethods(t *testing.T) {
 			require.NoError(t, err)
 
 			slices.Sort(resp.AvailableNotificationMethods)
-			require.EqualValues(t, resp.AvailableNotificationMethods, allMethods)
+			expectedMethods := slices.Clone(tc.expectedMethods)
+			slices.Sort(expectedMethods)
+			require.EqualValues(t, expectedMethods, resp.AvailableNotificationMethods)
 			require.Equal(t, tc.expectedDefault, resp.DefaultNotificationMethod)
 		})
 	}
 }
 
+func TestUserChatIdentities(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Context(t, testutil.WaitLong)
+	codes := make(chan string, 4)
+	slackServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg dispatch.SlackMessage
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		codes <- msg.Channel + " " + verificationCodeRegex.FindStringSubmatch(msg.Text)[1]
+		_ = json.NewEncoder(w).Encode(map[string]any{"ok": true})
+	}))
+	t.Cleanup(slackServer.Close)
+	slackURL, err := url.Parse(slackServer.URL)
+	require.NoError(t, err)
+
+	opts := createOpts(t)
+	opts.DeploymentValues.Notifications.Slack.BotToken = "xoxb-test"
+	opts.DeploymentValues.Notifications.Slack.APIURL = *serpent.URLOf(slackURL)
+	api := coderdtest.New(t, opts)
+	firstUser := coderdtest.CreateFirstUser(t, api)
+	member, memberUser := coderdtest.CreateAnotherUser(t, api, firstUser.OrganizationID)
+
+	identities, err := member.UserChatIdentities(ctx, codersdk.Me)
+	require.NoError(t, err)
+	require.Empty(t, identities)
+
+	// Identities are validated for the platform.
+	_, err = member.UpdateUserChatIdentity(ctx, codersdk.Me, codersdk.ChatNotificationMethodSlack, codersdk.UpdateUserChatIdentityRequest{Identity: "alice"})
+	var sdkErr *codersdk.Error
+	require.ErrorAs(t, err, &sdkErr)
+	require.Equal(t, http.StatusBadRequest, sdkErr.StatusCode())
+	_, err = member.UpdateUserChatIdentity(ctx, codersdk.Me, "irc", codersdk.UpdateUserChatIdentityRequest{Identity: "alice"})
+	require.ErrorAs(t, err, &sdkErr)
+	require.Equal(t, http.StatusBadRequest, sdkErr.StatusCode())
+
+	// Linked identities are unverified until the code sent to them is
+	// entered.
+	slack, err := member.UpdateUserChatIdentity(ctx, codersdk.Me, codersdk.ChatNotificationMethodSlack, codersdk.UpdateUserChatIdentityRequest{Identity: "U012AB3CD"})
+	require.NoError(t, err)
+	require.Equal(t, "U012AB3CD", slack.Identity)
+	require.False(t, slack.Verified)
+	sent := testutil.TryReceive(ctx, t, codes)
+	require.True(t, strings.HasPrefix(sent, "U012AB3CD "))
+
+	_, err = member.VerifyUserChatIdentity(ctx, codersdk.Me, codersdk.ChatNotificationMethodSlack, codersdk.VerifyUserChatIdentityRequest{Code: "wrong"})
+	require.ErrorAs(t, err, &sdkErr)
+	require.Equal(t, http.StatusBadRequest, sdkErr.StatusCode())
+	slack, err = member.VerifyUserChatIdentity(ctx, codersdk.Me, codersdk.ChatNotificationMethodSlack, codersdk.VerifyUserChatIdentityRequest{
+		Code: strings.TrimPrefix(sent, "U012AB3CD "),
+	})
+	require.NoError(t, err)
+	require.True(t, slack.Verified)
+
+	// Linking another account replaces the previous one, and has to be
+	// verified again.
+	slack, err = member.UpdateUserChatIdentity(ctx, codersdk.Me, codersdk.ChatNotificationMethodSlack, codersdk.UpdateUserChatIdentityRequest{Identity: "W0123ABCD"})
+	require.NoError(t, err)
+	require.Equal(t, "W0123ABCD", slack.Identity)
+	require.False(t, slack.Verified)
+	sent = testutil.TryReceive(ctx, t, codes)
+	require.True(t, strings.HasPrefix(sent, "W0123ABCD "))
+
+	// Codes cannot be sent without the platform configured.
+	_, err = member.UpdateUserChatIdentity(ctx, codersdk.Me, codersdk.ChatNotificationMethodMatrix, codersdk.UpdateUserChatIdentityRequest{Identity: "@alice:example.com"})
+	require.ErrorAs(t, err, &sdkErr)
+	require.Equal(t, http.StatusBadGateway, sdkErr.StatusCode())
+
+	// Teams identities must match the email of an OIDC account.
+	_, err = member.UpdateUserChatIdentity(ctx, codersdk.Me, codersdk.ChatNotificationMethodTeams, codersdk.UpdateUserChatIdentityRequest{Identity: memberUser.Email})
+	require.ErrorAs(t, err, &sdkErr)
+	require.Equal(t, http.StatusBadRequest, sdkErr.StatusCode())
+
+	identities, err = member.UserChatIdentities(ctx, codersdk.Me)
+	require.NoError(t, err)
+	require.Len(t, identities, 1)
+	require.Equal(t, "W0123ABCD", identities[0].Identity)
+
+	// Admins can see the identities of other users, members cannot.
+	identities, err = api.UserChatIdentities(ctx, memberUser.ID.String())
+	require.NoError(t, err)
+	require.Len(t, identities, 1)
+	_, err = member.UserChatIdentities(ctx, firstUser.UserID.String())
+	require.Error(t, err)
+
+	err = member.DeleteUserChatIdentity(ctx, codersdk.Me, codersdk.ChatNotificationMethodSlack)
+	require.NoError(t, err)
+	identities, err = member.UserChatIdentities(ctx, codersdk.Me)
+	require.NoError(t, err)
+	require.Empty(t, identities)
+}
+
+var verificationCodeRegex = regexp.MustCompile(`code is ([a-z0-9]+)\.`)
+
+func TestUserNotificationDeliverySettings(t *testing.T) {
+	t.Parallel()
+
+	// Digest preferences are validated against the notification templates.
+	if !dbtestutil.WillUsePostgres() {
+		t.Skip("This test requires postgres; it relies on read from and writing to the notification_templates table")
+	}
+
+	ctx := testutil.Context(t, testutil.WaitLong)
+	api := coderdtest.New(t, createOpts(t))
+	firstUser := coderdtest.CreateFirstUser(t, api)
+	member, memberUser := coderdtest.CreateAnotherUser(t, api, firstUser.OrganizationID)
+
+	settings, err := member.UserNotificationDeliverySettings(ctx, codersdk.Me)
+	require.NoError(t, err)
+	require.Equal(t, codersdk.NotificationDeliverySettings{
+		Timezone:        "UTC",
+		DailyDigestTime: "09:00",
+		Digests:         map[string]codersdk.NotificationDigestInterval{},
+	}, settings)
+
+	var sdkErr *codersdk.Error
+	for name, req := range map[string]codersdk.NotificationDeliverySettings{
+		"Timezone":        {Timezone: "Mars/Olympus_Mons"},
+		"DailyDigestTime": {DailyDigestTime: "9am"},
+		"QuietHours":      {QuietHours: &codersdk.NotificationQuietHours{Start: "22:00", End: "22:00"}},
+		"Template":        {Digests: map[string]codersdk.NotificationDigestInterval{uuid.NewString(): codersdk.NotificationDigestIntervalDaily}},
+		"Critical": {Digests: map[string]codersdk.NotificationDigestInterval{
+			notifications.TemplateUserRequestedOneTimePasscode.String(): codersdk.NotificationDigestIntervalDaily,
+		}},
+		"Interval": {Digests: map[string]codersdk.NotificationDigestInterval{
+			notifications.TemplateWorkspaceManualBuildFailed.String(): "weekly",
+		}},
+	} {
+		_, err = member.UpdateUserNotificationDeliverySettings(ctx, codersdk.Me, req)
+		require.ErrorAs(t, err, &sdkErr, name)
+		require.Equal(t, http.StatusBadRequest, sdkErr.StatusCode(), name)
+	}
+
+	want := codersdk.NotificationDeliverySettings{
+		Timezone:        "Europe/Berlin",
+		QuietHours:      &codersdk.NotificationQuietHours{Start: "22:00", End: "07:30"},
+		DailyDigestTime: "18:00",
+		Digests: map[string]codersdk.NotificationDigestInterval{
+			notifications.TemplateWorkspaceManualBuildFailed.String(): codersdk.NotificationDigestIntervalHourly,
+			notifications.TemplateWorkspaceDeleted.String():           codersdk.NotificationDigestIntervalDaily,
+		},
+	}
+	settings, err = member.UpdateUserNotificationDeliverySettings(ctx, codersdk.Me, want)
+	require.NoError(t, err)
+	require.Equal(t, want, settings)
+
+	// Settings are replaced as a whole.
+	want = codersdk.NotificationDeliverySettings{
+		Timezone:        "America/New_York",
+		DailyDigestTime: "09:00",
+		Digests: map[string]codersdk.NotificationDigestInterval{
+			notifications.TemplateWorkspaceDeleted.String(): codersdk.NotificationDigestIntervalHourly,
+		},
+	}
+	_, err = member.UpdateUserNotificationDeliverySettings(ctx, codersdk.Me, want)
+	require.NoError(t, err)
+	settings, err = api.UserNotificationDeliverySettings(ctx, memberUser.ID.String())
+	require.NoError(t, err)
+	require.Equal(t, want, settings)
+
+	// Members cannot see the settings of other users.
+	_, err = member.UserNotificationDeliverySettings(ctx, firstUser.UserID.String())
+	require.Error(t, err)
+}
+
 func TestNotificationTest(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,265 @@
+package coderd
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/coder/coder/v2/coderd/database"
+	"github.com/coder/coder/v2/coderd/database/dbtime"
+	"github.com/coder/coder/v2/coderd/httpapi"
+	"github.com/coder/coder/v2/coderd/httpmw"
+	"github.com/coder/coder/v2/coderd/planpolicy"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+// @Summary Get plan policies
+// @ID get-plan-policies
+// @Security CoderSessionToken
+// @Produce json
+// @Tags Templates
+// @Param organization path string true "Organization ID" format(uuid)
+// @Success 200 {array} codersdk.PlanPolicy
+// @Router /organizations/{organization}/plan-policies [get]
+func (api *API) planPolicies(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx = r.Context()
+		org = httpmw.OrganizationParam(r)
+	)
+
+	dbPolicies, err := api.Database.GetPlanPoliciesByOrganizationID(ctx, org.ID)
+	if httpapi.IsUnauthorizedError(err) {
+		httpapi.Forbidden(rw)
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Failed to fetch plan policies.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	out := make([]codersdk.PlanPolicy, 0, len(dbPolicies))
+	for _, planPolicy := range dbPolicies {
+		out = append(out, convertPlanPolicy(planPolicy))
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, out)
+}
+
+// @Summary Get plan policy
+// @ID get-plan-policy
+// @Security CoderSessionToken
+// @Produce json
+// @Tags Templates
+// @Param organization path string true "Organization ID" format(uuid)
+// @Param planpolicy path string true "Plan policy name"
+// @Success 200 {object} codersdk.PlanPolicy
+// @Router /organizations/{organization}/plan-policies/{planpolicy} [get]
+func (api *API) planPolicy(rw http.ResponseWriter, r *http.Request) {
+	planPolicy, ok := api.planPolicyParam(rw, r)
+	if !ok {
+		return
+	}
+	httpapi.Write(r.Context(), rw, http.StatusOK, convertPlanPolicy(planPolicy))
+}
+
+// @Summary Create plan policy
+// @Description Plan policies are Rego modules evaluated against the Terraform
+// @Description plans of workspace builds of the organization, before they are
+// @Description applied. Messages of `deny` rules fail the build, and messages of
+// @Description `warn` rules are written to the build logs.
+// @ID create-plan-policy
+// @Security CoderSessionToken
+// @Accept json
+// @Produce json
+// @Tags Templates
+// @Param organization path string true "Organization ID" format(uuid)
+// @Param request body codersdk.CreatePlanPolicyRequest true "Plan policy"
+// @Success 201 {object} codersdk.PlanPolicy
+// @Router /organizations/{organization}/plan-policies [post]
+func (api *API) postPlanPolicy(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx = r.Context()
+		org = httpmw.OrganizationParam(r)
+	)
+
+	var req codersdk.CreatePlanPolicyRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+	if !validatePlanPolicy(rw, r, req.Name, req.Module) {
+		return
+	}
+
+	now := dbtime.Now()
+	planPolicy, err := api.Database.InsertPlanPolicy(ctx, database.InsertPlanPolicyParams{
+		ID:             uuid.New(),
+		OrganizationID: org.ID,
+		Name:           req.Name,
+		Description:    req.Description,
+		Module:         req.Module,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	})
+	if database.IsUniqueViolation(err, database.UniquePlanPoliciesOrganizationIDNameKey) {
+		httpapi.Write(ctx, rw, http.StatusConflict, codersdk.Response{
+			Message: fmt.Sprintf("A plan policy named %q already exists.", req.Name),
+		})
+		return
+	}
+	if httpapi.IsUnauthorizedError(err) {
+		httpapi.Forbidden(rw)
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Failed to create plan policy.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusCreated, convertPlanPolicy(planPolicy))
+}
+
+// @Summary Update plan policy
+// @ID update-plan-policy
+// @Security CoderSessionToken
+// @Accept json
+// @Produce json
+// @Tags Templates
+// @Param organization path string true "Organization ID" format(uuid)
+// @Param planpolicy path string true "Plan policy name"
+// @Param request body codersdk.UpdatePlanPolicyRequest true "Plan policy"
+// @Success 200 {object} codersdk.PlanPolicy
+// @Router /organizations/{organization}/plan-policies/{planpolicy} [patch]
+func (api *API) patchPlanPolicy(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	planPolicy, ok := api.planPolicyParam(rw, r)
+	if !ok {
+		return
+	}
+	var req codersdk.UpdatePlanPolicyRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+	params := database.UpdatePlanPolicyByIDParams{
+		ID:          planPolicy.ID,
+		Description: planPolicy.Description,
+		Module:      planPolicy.Module,
+		UpdatedAt:   dbtime.Now(),
+	}
+	if req.Description != nil {
+		params.Description = *req.Description
+	}
+	if req.Module != nil {
+		if !validatePlanPolicy(rw, r, planPolicy.Name, *req.Module) {
+			return
+		}
+		params.Module = *req.Module
+	}
+
+	planPolicy, err := api.Database.UpdatePlanPolicyByID(ctx, params)
+	if httpapi.IsUnauthorizedError(err) {
+		httpapi.Forbidden(rw)
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Failed to update plan policy.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, convertPlanPolicy(planPolicy))
+}
+
+// @Summary Delete plan policy
+// @ID delete-plan-policy
+// @Security CoderSessionToken
+// @Tags Templates
+// @Param organization path string true "Organization ID" format(uuid)
+// @Param planpolicy path string true "Plan policy name"
+// @Success 204
+// @Router /organizations/{organization}/plan-policies/{planpolicy} [delete]
+func (api *API) deletePlanPolicy(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	planPolicy, ok := api.planPolicyParam(rw, r)
+	if !ok {
+		return
+	}
+	err := api.Database.DeletePlanPolicyByID(ctx, planPolicy.ID)
+	if httpapi.IsUnauthorizedError(err) {
+		httpapi.Forbidden(rw)
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Failed to delete plan policy.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// planPolicyParam fetches the plan policy named in the URL from the organization in the URL.
+func (api *API) planPolicyParam(rw http.ResponseWriter, r *http.Request) (database.PlanPolicy, bool) {
+	var (
+		ctx = r.Context()
+		org = httpmw.OrganizationParam(r)
+	)
+
+	planPolicy, err := api.Database.GetPlanPolicyByOrganizationAndName(ctx, database.GetPlanPolicyByOrganizationAndNameParams{
+		OrganizationID: org.ID,
+		Name:           chi.URLParam(r, "planpolicy"),
+	})
+	if httpapi.Is404Error(err) {
+		httpapi.ResourceNotFound(rw)
+		return database.PlanPolicy{}, false
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Failed to fetch plan policy.",
+			Detail:  err.Error(),
+		})
+		return database.PlanPolicy{}, false
+	}
+	return planPolicy, true
+}
+
+// validatePlanPolicy rejects modules that would fail to evaluate, so that a
+// broken policy is reported when it is saved rather than by failing builds.
+func validatePlanPolicy(rw http.ResponseWriter, r *http.Request, name, module string) bool {
+	err := planpolicy.Validate(planpolicy.Policy{Name: name, Module: module})
+	if err != nil {
+		httpapi.Write(r.Context(), rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Invalid plan policy module.",
+			Validations: []codersdk.ValidationError{{
+				Field:  "module",
+				Detail: err.Error(),
+			}},
+		})
+		return false
+	}
+	return true
+}
+
+func convertPlanPolicy(planPolicy database.PlanPolicy) codersdk.PlanPolicy {
+	return codersdk.PlanPolicy{
+		ID:             planPolicy.ID,
+		OrganizationID: planPolicy.OrganizationID,
+		Name:           planPolicy.Name,
+		Description:    planPolicy.Description,
+		Module:         planPolicy.Module,
+		CreatedAt:      planPolicy.CreatedAt,
+		UpdatedAt:      planPolicy.UpdatedAt,
+	}
+}
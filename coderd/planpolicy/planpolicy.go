@@ -0,0 +1,225 @@
+// Package planpolicy evaluates Rego policies against the Terraform plans of
+// workspace builds, so that disallowed changes are rejected before they are
+// applied.
+//
+// A policy is a Rego module whose package may declare `deny` and `warn`
+// rules, each a set of messages. The JSON plan of the build is the input:
+//
+//	package coder.plan
+//
+//	deny contains msg if {
+//		some change in input.resource_changes
+//		change.type == "aws_eip"
+//		msg := sprintf("%s: public IPs are not allowed", [change.address])
+//	}
+package planpolicy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/open-policy-agent/opa/v1/ast"
+	"github.com/open-policy-agent/opa/v1/rego"
+	"golang.org/x/xerrors"
+)
+
+const (
+	// RuleDeny names the rules whose messages fail the build.
+	RuleDeny = "deny"
+	// RuleWarn names the rules whose messages are written to the build logs.
+	RuleWarn = "warn"
+
+	// evalTimeout bounds the evaluation of a single policy.
+	evalTimeout = 30 * time.Second
+)
+
+// disallowedBuiltins are the built-in functions policies cannot call. Policies
+// run on provisioner daemons, which hold cloud credentials, so they must not
+// read the environment or reach the network.
+var disallowedBuiltins = map[string]struct{}{
+	"http.send":          {},
+	"net.lookup_ip_addr": {},
+	"opa.runtime":        {},
+}
+
+var capabilities = func() *ast.Capabilities {
+	c := ast.CapabilitiesForThisVersion()
+	c.Builtins = slices.DeleteFunc(c.Builtins, func(b *ast.Builtin) bool {
+		_, ok := disallowedBuiltins[b.Name]
+		return ok
+	})
+	// An empty list, unlike nil, allows no hosts at all.
+	c.AllowNet = []string{}
+	return c
+}()
+
+// Policy is a Rego module evaluated against plans.
+type Policy struct {
+	Name   string
+	Module string
+}
+
+// Message is a message of a deny or warn rule of a policy.
+type Message struct {
+	Policy  string
+	Message string
+}
+
+func (m Message) String() string {
+	return fmt.Sprintf("%s: %s", m.Policy, m.Message)
+}
+
+// Result is the outcome of evaluating policies against a plan.
+type Result struct {
+	Denials  []Message
+	Warnings []Message
+}
+
+// Validate parses and compiles the module of a policy. Policies are validated
+// when they are saved, so that a broken policy is not discovered by a build.
+func Validate(policy Policy) error {
+	module, err := parse(policy)
+	if err != nil {
+		return err
+	}
+	compiler := ast.NewCompiler().WithCapabilities(capabilities)
+	compiler.Compile(map[string]*ast.Module{policy.Name: module})
+	if compiler.Failed() {
+		return xerrors.Errorf("compile policy: %w", compiler.Errors)
+	}
+
+	hasRule := slices.ContainsFunc(module.Rules, func(rule *ast.Rule) bool {
+		name := ruleName(rule)
+		return name == RuleDeny || name == RuleWarn
+	})
+	if !hasRule {
+		return xerrors.Errorf("policy declares neither %q nor %q rules", RuleDeny, RuleWarn)
+	}
+	return nil
+}
+
+// Evaluate evaluates policies against a Terraform JSON plan. An error is
+// returned if any policy could not be evaluated, in which case the plan must
+// be treated as denied.
+func Evaluate(ctx context.Context, policies []Policy, planJSON []byte) (Result, error) {
+	result := Result{
+		Denials:  []Message{},
+		Warnings: []Message{},
+	}
+	if len(policies) == 0 {
+		return result, nil
+	}
+
+	input, err := ast.ValueFromReader(bytes.NewReader(planJSON))
+	if err != nil {
+		return Result{}, xerrors.Errorf("parse plan: %w", err)
+	}
+	for _, policy := range policies {
+		document, err := evaluate(ctx, policy, input)
+		if err != nil {
+			return Result{}, xerrors.Errorf("evaluate policy %q: %w", policy.Name, err)
+		}
+		result.Denials = append(result.Denials, messages(policy.Name, document[RuleDeny])...)
+		result.Warnings = append(result.Warnings, messages(policy.Name, document[RuleWarn])...)
+	}
+	return result, nil
+}
+
+func evaluate(ctx context.Context, policy Policy, input ast.Value) (map[string]any, error) {
+	module, err := parse(policy)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, evalTimeout)
+	defer cancel()
+
+	// Each policy is queried for its own package, so that messages are
+	// attributed to the policy they came from.
+	resultSet, err := rego.New(
+		rego.Query(module.Package.Path.String()),
+		rego.ParsedModule(module),
+		rego.ParsedInput(input),
+		rego.Capabilities(capabilities),
+		rego.StrictBuiltinErrors(true),
+	).Eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(resultSet) == 0 || len(resultSet[0].Expressions) == 0 {
+		// The package has no rules that apply to the plan.
+		return map[string]any{}, nil
+	}
+	document, ok := resultSet[0].Expressions[0].Value.(map[string]any)
+	if !ok {
+		return nil, xerrors.Errorf("unexpected document type %T", resultSet[0].Expressions[0].Value)
+	}
+	return document, nil
+}
+
+func parse(policy Policy) (*ast.Module, error) {
+	module, err := ast.ParseModuleWithOpts(policy.Name+".rego", policy.Module, ast.ParserOptions{
+		Capabilities: capabilities,
+		RegoVersion:  ast.RegoV1,
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("parse policy: %w", err)
+	}
+	if module == nil {
+		return nil, xerrors.New("policy is empty")
+	}
+	return module, nil
+}
+
+func ruleName(rule *ast.Rule) string {
+	ref := rule.Head.Ref()
+	if len(ref) == 0 {
+		return ""
+	}
+	name, ok := ref[0].Value.(ast.Var)
+	if !ok {
+		return ""
+	}
+	return string(name)
+}
+
+// messages converts the value of a deny or warn rule to messages. Rules
+// usually produce strings, but objects with a "msg" key, as used by conftest,
+// are accepted too.
+func messages(policyName string, value any) []Message {
+	values, ok := value.([]any)
+	if !ok {
+		return nil
+	}
+	converted := make([]Message, 0, len(values))
+	for _, v := range values {
+		var message string
+		switch v := v.(type) {
+		case string:
+			message = v
+		case map[string]any:
+			if msg, ok := v["msg"].(string); ok {
+				message = msg
+				break
+			}
+			data, _ := json.Marshal(v)
+			message = string(data)
+		default:
+			data, _ := json.Marshal(v)
+			message = string(data)
+		}
+		converted = append(converted, Message{
+			Policy:  policyName,
+			Message: strings.TrimSpace(message),
+		})
+	}
+	slices.SortFunc(converted, func(a, b Message) int {
+		return strings.Compare(a.Message, b.Message)
+	})
+	return converted
+}
@@ -0,0 +1,190 @@
+package planpolicy_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/coderd/planpolicy"
+)
+
+const plan = `{
+	"format_version": "1.2",
+	"resource_changes": [
+		{
+			"address": "aws_eip.public",
+			"type": "aws_eip",
+			"name": "public",
+			"change": {"actions": ["create"], "after": {"domain": "vpc"}}
+		},
+		{
+			"address": "aws_instance.dev",
+			"type": "aws_instance",
+			"name": "dev",
+			"change": {"actions": ["create"], "after": {"instance_type": "m5.8xlarge"}}
+		}
+	]
+}`
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name   string
+		module string
+		err    string
+	}{
+		{
+			name: "Valid",
+			module: `package coder.plan
+
+deny contains msg if {
+	some change in input.resource_changes
+	change.type == "aws_eip"
+	msg := "no public IPs"
+}`,
+		},
+		{
+			name: "OnlyWarn",
+			module: `package coder.plan
+
+warn contains "always" if true`,
+		},
+		{
+			name:   "SyntaxError",
+			module: `package coder.plan deny contains`,
+			err:    "parse policy",
+		},
+		{
+			name:   "Empty",
+			module: ``,
+			err:    "parse policy",
+		},
+		{
+			name: "NoRules",
+			module: `package coder.plan
+
+allow := true`,
+			err: "neither",
+		},
+		{
+			name: "Network",
+			module: `package coder.plan
+
+deny contains msg if {
+	resp := http.send({"method": "GET", "url": "https://example.com"})
+	msg := resp.body
+}`,
+			err: "http.send",
+		},
+		{
+			name: "Environment",
+			module: `package coder.plan
+
+deny contains msg if {
+	msg := opa.runtime().env.AWS_SECRET_ACCESS_KEY
+}`,
+			err: "opa.runtime",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := planpolicy.Validate(planpolicy.Policy{Name: "test", Module: tc.module})
+			if tc.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	t.Parallel()
+
+	t.Run("NoPolicies", func(t *testing.T) {
+		t.Parallel()
+
+		result, err := planpolicy.Evaluate(context.Background(), nil, []byte(plan))
+		require.NoError(t, err)
+		require.Empty(t, result.Denials)
+		require.Empty(t, result.Warnings)
+	})
+
+	t.Run("DenyAndWarn", func(t *testing.T) {
+		t.Parallel()
+
+		result, err := planpolicy.Evaluate(context.Background(), []planpolicy.Policy{
+			{
+				Name: "network",
+				Module: `package coder.network
+
+deny contains msg if {
+	some change in input.resource_changes
+	change.type == "aws_eip"
+	msg := sprintf("%s: public IPs are not allowed", [change.address])
+}`,
+			},
+			{
+				Name: "sizes",
+				Module: `package coder.sizes
+
+large := {"m5.8xlarge", "m5.16xlarge"}
+
+warn contains {"msg": msg} if {
+	some change in input.resource_changes
+	change.type == "aws_instance"
+	large[change.change.after.instance_type]
+	msg := sprintf("%s: %s is expensive", [change.address, change.change.after.instance_type])
+}`,
+			},
+		}, []byte(plan))
+		require.NoError(t, err)
+		require.Equal(t, []planpolicy.Message{{
+			Policy:  "network",
+			Message: "aws_eip.public: public IPs are not allowed",
+		}}, result.Denials)
+		require.Equal(t, []planpolicy.Message{{
+			Policy:  "sizes",
+			Message: "aws_instance.dev: m5.8xlarge is expensive",
+		}}, result.Warnings)
+	})
+
+	t.Run("Compliant", func(t *testing.T) {
+		t.Parallel()
+
+		result, err := planpolicy.Evaluate(context.Background(), []planpolicy.Policy{{
+			Name: "privileged",
+			Module: `package coder.plan
+
+deny contains msg if {
+	some change in input.resource_changes
+	change.type == "kubernetes_pod"
+	msg := "no pods"
+}`,
+		}}, []byte(plan))
+		require.NoError(t, err)
+		require.Empty(t, result.Denials)
+		require.Empty(t, result.Warnings)
+	})
+
+	t.Run("RuntimeError", func(t *testing.T) {
+		t.Parallel()
+
+		// Conflicting values of a complete rule fail evaluation, which must
+		// not be mistaken for a compliant plan.
+		_, err := planpolicy.Evaluate(context.Background(), []planpolicy.Policy{{
+			Name: "broken",
+			Module: `package coder.plan
+
+kind := change.type if {
+	some change in input.resource_changes
+}
+
+deny contains kind if false`,
+		}}, []byte(plan))
+		require.ErrorContains(t, err, `evaluate policy "broken"`)
+	})
+}
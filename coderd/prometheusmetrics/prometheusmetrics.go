@@ -21,6 +21,7 @@ import (
 	"github.com/coder/coder/v2/coderd/database"
 	"github.com/coder/coder/v2/coderd/database/dbauthz"
 	"github.com/coder/coder/v2/coderd/database/dbtime"
+	"github.com/coder/coder/v2/coderd/provisionerqueue"
 	"github.com/coder/coder/v2/codersdk"
 	"github.com/coder/coder/v2/tailnet"
 	"github.com/coder/quartz"
@@ -252,6 +253,116 @@ func Workspaces(ctx context.Context, logger slog.Logger, registerer prometheus.R
 	}, nil
 }
 
+// ProvisionerQueue tracks the autoscaling signals of the provisioner daemons
+// of each organization by set of tags.
+func ProvisionerQueue(ctx context.Context, logger slog.Logger, registerer prometheus.Registerer, db database.Store, duration time.Duration) (func(), error) {
+	if duration == 0 {
+		duration = defaultRefreshRate
+	}
+
+	labels := []string{"organization_name", "tags"}
+	newGauge := func(name, help string, extraLabels ...string) (*prometheus.GaugeVec, error) {
+		gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "coderd",
+			Subsystem: "provisioner_queue",
+			Name:      name,
+			Help:      help,
+		}, append(labels, extraLabels...))
+		if err := registerer.Register(gauge); err != nil {
+			return nil, xerrors.Errorf("register %s gauge: %w", name, err)
+		}
+		return gauge, nil
+	}
+	pendingJobs, err := newGauge("pending_jobs", "The number of pending jobs that provisioner daemons with the tags can acquire.")
+	if err != nil {
+		return nil, err
+	}
+	oldestPendingJob, err := newGauge("oldest_pending_job_age_seconds", "How long the oldest pending job that provisioner daemons with the tags can acquire has been waiting.")
+	if err != nil {
+		return nil, err
+	}
+	runningJobs, err := newGauge("running_jobs", "The number of jobs running on provisioner daemons with the tags.")
+	if err != nil {
+		return nil, err
+	}
+	daemons, err := newGauge("daemons", "The number of online provisioner daemons with the tags.")
+	if err != nil {
+		return nil, err
+	}
+	busyDaemons, err := newGauge("busy_daemons", "The number of online provisioner daemons with the tags that are running a job.")
+	if err != nil {
+		return nil, err
+	}
+	utilization, err := newGauge("daemon_utilization", "The fraction of the online provisioner daemons with the tags that are running a job.")
+	if err != nil {
+		return nil, err
+	}
+	waitTime, err := newGauge("wait_seconds", "Percentiles of how long the jobs that provisioner daemons with the tags can acquire waited to start, over the last hour.", "quantile")
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancelFunc := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	update := func() {
+		//nolint:gocritic // This is a system service that reports the
+		// queues of all organizations.
+		ctx := dbauthz.AsSystemRestricted(ctx)
+		stats, err := provisionerqueue.Stats(ctx, db, uuid.Nil, nil)
+		if err != nil {
+			logger.Warn(ctx, "failed to load provisioner queue stats", slog.Error(err))
+			return
+		}
+		organizations, err := db.GetOrganizations(ctx, database.GetOrganizationsParams{})
+		if err != nil {
+			logger.Warn(ctx, "failed to load organizations", slog.Error(err))
+			return
+		}
+		organizationNames := make(map[uuid.UUID]string, len(organizations))
+		for _, organization := range organizations {
+			organizationNames[organization.ID] = organization.Name
+		}
+
+		for _, gauge := range []*prometheus.GaugeVec{pendingJobs, oldestPendingJob, runningJobs, daemons, busyDaemons, utilization, waitTime} {
+			gauge.Reset()
+		}
+		for _, s := range stats {
+			values := []string{organizationNames[s.OrganizationID], codersdk.ProvisionerKeyTags(s.Tags).String()}
+			pendingJobs.WithLabelValues(values...).Set(float64(s.PendingJobs))
+			oldestPendingJob.WithLabelValues(values...).Set(float64(s.OldestPendingJobAgeMS) / 1000)
+			runningJobs.WithLabelValues(values...).Set(float64(s.RunningJobs))
+			daemons.WithLabelValues(values...).Set(float64(s.Daemons))
+			busyDaemons.WithLabelValues(values...).Set(float64(s.BusyDaemons))
+			utilization.WithLabelValues(values...).Set(s.Utilization)
+			waitTime.WithLabelValues(append(values, "0.5")...).Set(float64(s.WaitTime.P50MS) / 1000)
+			waitTime.WithLabelValues(append(values, "0.95")...).Set(float64(s.WaitTime.P95MS) / 1000)
+			waitTime.WithLabelValues(append(values, "0.99")...).Set(float64(s.WaitTime.P99MS) / 1000)
+		}
+	}
+
+	// Use time.Nanosecond to force an initial tick. It will be reset to the
+	// correct duration after executing once.
+	ticker := time.NewTicker(time.Nanosecond)
+	go func() {
+		defer close(done)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				update()
+				ticker.Reset(duration)
+			}
+		}
+	}()
+	return func() {
+		cancelFunc()
+		<-done
+	}, nil
+}
+
 // Agents tracks the total number of workspaces with labels on status.
 func Agents(ctx context.Context, logger slog.Logger, registerer prometheus.Registerer, db database.Store, coordinator *atomic.Pointer[tailnet.Coordinator], derpMapFn func() *tailcfg.DERPMap, agentInactiveDisconnectTimeout, duration time.Duration) (func(), error) {
 	if duration == 0 {
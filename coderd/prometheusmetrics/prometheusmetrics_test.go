@@ -832,6 +832,7 @@ func insertRunning(t *testing.T, db database.Store, u database.User, org databas
 		Type:           database.ProvisionerJobTypeWorkspaceBuild,
 		Input:          json.RawMessage("{}"),
 		OrganizationID: org.ID,
+		Priority:       database.ProvisionerJobPriorityInteractive,
 	})
 	require.NoError(t, err)
 	err = db.InsertWorkspaceBuild(context.Background(), database.InsertWorkspaceBuildParams{
@@ -0,0 +1,227 @@
+package coderd
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/coder/coder/v2/coderd/database"
+	"github.com/coder/coder/v2/coderd/database/dbauthz"
+	"github.com/coder/coder/v2/coderd/httpapi"
+	"github.com/coder/coder/v2/coderd/provisionerdserver"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+// providerMirrorVersions is the response of the "List Available Versions"
+// endpoint of the Terraform provider network mirror protocol.
+type providerMirrorVersions struct {
+	Versions map[string]struct{} `json:"versions"`
+}
+
+// providerMirrorInstallation is the response of the "List Available
+// Installation Packages" endpoint of the Terraform provider network mirror
+// protocol.
+type providerMirrorInstallation struct {
+	Archives map[string]providerMirrorArchive `json:"archives"`
+}
+
+type providerMirrorArchive struct {
+	URL    string   `json:"url"`
+	Hashes []string `json:"hashes"`
+}
+
+// providerMirrorJob authenticates a request to the provider mirror with the
+// token of a running provisioner job.
+func (api *API) providerMirrorJob(rw http.ResponseWriter, r *http.Request) (database.ProvisionerJob, bool) {
+	ctx := r.Context()
+	if !api.DeploymentValues.Provisioner.ProviderMirror.Value() {
+		httpapi.ResourceNotFound(rw)
+		return database.ProvisionerJob{}, false
+	}
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		httpapi.Write(ctx, rw, http.StatusUnauthorized, codersdk.Response{
+			Message: "A provider mirror token is required.",
+		})
+		return database.ProvisionerJob{}, false
+	}
+	job, err := provisionerdserver.VerifyProviderMirrorToken(ctx, api.Database, token)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusUnauthorized, codersdk.Response{
+			Message: "Invalid provider mirror token.",
+			Detail:  err.Error(),
+		})
+		return database.ProvisionerJob{}, false
+	}
+	return job, true
+}
+
+// @Summary Get provider versions or packages from the provider mirror
+// @ID get-provider-mirror-provider
+// @Produce json
+// @Tags Provisioning
+// @Param hostname path string true "Provider hostname"
+// @Param namespace path string true "Provider namespace"
+// @Param type path string true "Provider type"
+// @Param file path string true "index.json or <version>.json"
+// @Success 200
+// @Router /provider-mirror/providers/{hostname}/{namespace}/{type}/{file} [get]
+// @x-apidocgen {"skip": true}
+func (api *API) providerMirrorProvider(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	job, ok := api.providerMirrorJob(rw, r)
+	if !ok {
+		return
+	}
+
+	file := chi.URLParam(r, "file")
+	if !strings.HasSuffix(file, ".json") {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	//nolint:gocritic // The provider mirror is shared by the provisioner jobs of an organization.
+	archives, err := api.Database.GetProviderMirrorArchives(dbauthz.AsProvisionerd(ctx), database.GetProviderMirrorArchivesParams{
+		OrganizationID: job.OrganizationID,
+		Hostname:       chi.URLParam(r, "hostname"),
+		Namespace:      chi.URLParam(r, "namespace"),
+		Type:           chi.URLParam(r, "type"),
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching provider archives.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	if file == "index.json" {
+		versions := providerMirrorVersions{Versions: map[string]struct{}{}}
+		for _, archive := range archives {
+			versions.Versions[archive.Version] = struct{}{}
+		}
+		httpapi.Write(ctx, rw, http.StatusOK, versions)
+		return
+	}
+
+	version := strings.TrimSuffix(file, ".json")
+	installation := providerMirrorInstallation{Archives: map[string]providerMirrorArchive{}}
+	for _, archive := range archives {
+		if archive.Version != version {
+			continue
+		}
+		installation.Archives[archive.Platform] = providerMirrorArchive{
+			URL:    api.AccessURL.JoinPath("/api/v2/provider-mirror/archives", archive.FileHash+".zip").String(),
+			Hashes: []string{archive.Hash},
+		}
+	}
+	if len(installation.Archives) == 0 {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, installation)
+}
+
+// @Summary Get provider archive from the provider mirror
+// @ID get-provider-mirror-archive
+// @Produce application/zip
+// @Tags Provisioning
+// @Param archive path string true "SHA256 hash of the archive, with a .zip suffix"
+// @Success 200
+// @Router /provider-mirror/archives/{archive} [get]
+// @x-apidocgen {"skip": true}
+func (api *API) providerMirrorArchive(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	job, ok := api.providerMirrorJob(rw, r)
+	if !ok {
+		return
+	}
+
+	hash, ok := strings.CutSuffix(chi.URLParam(r, "archive"), ".zip")
+	if !ok {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+	//nolint:gocritic // The provider mirror is shared by the provisioner jobs of an organization.
+	file, err := api.Database.GetProviderMirrorArchiveFileByHash(dbauthz.AsProvisionerd(ctx), database.GetProviderMirrorArchiveFileByHashParams{
+		Hash:           hash,
+		OrganizationID: job.OrganizationID,
+	})
+	if httpapi.Is404Error(err) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching provider archive.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	rw.Header().Set("Content-Type", zipMimeType)
+	rw.WriteHeader(http.StatusOK)
+	_, _ = rw.Write(file.Data)
+}
+
+// @Summary Get cached module files of the template version of a job
+// @ID get-provider-mirror-module-files
+// @Produce application/x-tar
+// @Tags Provisioning
+// @Param hash path string true "SHA256 hash of the module files"
+// @Success 200
+// @Router /provider-mirror/modules/{hash} [get]
+// @x-apidocgen {"skip": true}
+func (api *API) providerMirrorModuleFiles(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	job, ok := api.providerMirrorJob(rw, r)
+	if !ok {
+		return
+	}
+
+	// Jobs may only download the modules of the template version they run.
+	//nolint:gocritic // The job was authenticated by its provider mirror token.
+	ctx = dbauthz.AsProvisionerd(ctx)
+	templateVersionID, err := provisionerdserver.ProviderMirrorTemplateVersionID(ctx, api.Database, job)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching template version of job.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	terraformValues, err := api.Database.GetTemplateVersionTerraformValues(ctx, templateVersionID)
+	if httpapi.Is404Error(err) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching template version terraform values.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	if !terraformValues.CachedModuleFiles.Valid {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+	file, err := api.Database.GetFileByID(ctx, terraformValues.CachedModuleFiles.UUID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching module files.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	if file.Hash != chi.URLParam(r, "hash") {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	rw.Header().Set("Content-Type", file.Mimetype)
+	rw.WriteHeader(http.StatusOK)
+	_, _ = rw.Write(file.Data)
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/coder/coder/v2/coderd/httpapi"
 	"github.com/coder/coder/v2/coderd/httpmw"
 	"github.com/coder/coder/v2/coderd/provisionerdserver"
+	"github.com/coder/coder/v2/coderd/provisionerqueue"
 	"github.com/coder/coder/v2/coderd/rbac"
 	"github.com/coder/coder/v2/coderd/rbac/policy"
 	"github.com/coder/coder/v2/coderd/util/ptr"
@@ -103,3 +104,60 @@ func (api *API) provisionerDaemons(rw http.ResponseWriter, r *http.Request) {
 		return pd
 	}))
 }
+
+// @Summary Get provisioner queue stats
+// @ID get-provisioner-queue-stats
+// @Security CoderSessionToken
+// @Produce json
+// @Tags Provisioning
+// @Param organization path string true "Organization ID" format(uuid)
+// @Param tags query object false "Provisioner tags of the daemons to get the stats of (JSON of the form {'tag1':'value1','tag2':'value2'})"
+// @Success 200 {array} codersdk.ProvisionerQueueStats
+// @Router /organizations/{organization}/provisionerdaemons/queue [get]
+func (api *API) provisionerQueue(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx = r.Context()
+		org = httpmw.OrganizationParam(r)
+	)
+
+	// Like provisioner daemons, the stats are about provisioner jobs.
+	if !api.Authorize(r, policy.ActionRead, rbac.ResourceProvisionerJobs.InOrg(org.ID)) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	qp := r.URL.Query()
+	p := httpapi.NewQueryParamParser()
+	tags := p.JSONStringMap(qp, database.StringMap{}, "tags")
+	p.ErrorExcessParams(qp)
+	if len(p.Errors) > 0 {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message:     "Invalid query parameters.",
+			Validations: p.Errors,
+		})
+		return
+	}
+
+	if len(tags) > 0 {
+		stats, err := provisionerqueue.StatsOfTags(ctx, api.Database, org.ID, tags)
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+				Message: "Internal error fetching provisioner queue stats.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+		httpapi.Write(ctx, rw, http.StatusOK, []codersdk.ProvisionerQueueStats{stats})
+		return
+	}
+
+	stats, err := provisionerqueue.Stats(ctx, api.Database, org.ID, nil)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching provisioner queue stats.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, stats)
+}
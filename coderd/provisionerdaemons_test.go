@@ -252,3 +252,82 @@ func TestProvisionerDaemons(t *testing.T) {
 		require.Len(t, daemons, 0)
 	})
 }
+
+func TestProvisionerQueue(t *testing.T) {
+	t.Parallel()
+
+	db, ps := dbtestutil.NewDB(t)
+	client, _, coderdAPI := coderdtest.NewWithAPI(t, &coderdtest.Options{
+		IncludeProvisionerDaemon: false,
+		Database:                 db,
+		Pubsub:                   ps,
+	})
+	owner := coderdtest.CreateFirstUser(t, client)
+	templateAdminClient, _ := coderdtest.CreateAnotherUser(t, client, owner.OrganizationID, rbac.ScopedRoleOrgTemplateAdmin(owner.OrganizationID))
+	memberClient, _ := coderdtest.CreateAnotherUser(t, client, owner.OrganizationID)
+
+	gpuTags := database.StringMap{"owner": "", "scope": "organization", "pool": "gpu"}
+	orgTags := database.StringMap{"owner": "", "scope": "organization"}
+
+	// A busy daemon of the GPU pool.
+	daemon := dbgen.ProvisionerDaemon(t, db, database.ProvisionerDaemon{
+		OrganizationID: owner.OrganizationID,
+		LastSeenAt:     sql.NullTime{Time: coderdAPI.Clock.Now().Add(time.Hour), Valid: true}, // Stale interval can't be adjusted, keep online.
+		Tags:           gpuTags,
+	})
+	dbgen.ProvisionerJob(t, db, nil, database.ProvisionerJob{
+		OrganizationID: owner.OrganizationID,
+		WorkerID:       uuid.NullUUID{UUID: daemon.ID, Valid: true},
+		StartedAt:      sql.NullTime{Time: coderdAPI.Clock.Now(), Valid: true},
+		Tags:           gpuTags,
+	})
+	// A pending job that any daemon of the organization can acquire.
+	dbgen.ProvisionerJob(t, db, nil, database.ProvisionerJob{
+		OrganizationID: owner.OrganizationID,
+		Tags:           orgTags,
+	})
+
+	t.Run("All", func(t *testing.T) {
+		t.Parallel()
+		ctx := testutil.Context(t, testutil.WaitMedium)
+		stats, err := templateAdminClient.OrganizationProvisionerQueue(ctx, owner.OrganizationID, nil)
+		require.NoError(t, err)
+		require.Len(t, stats, 2)
+		byTags := make(map[string]codersdk.ProvisionerQueueStats)
+		for _, s := range stats {
+			byTags[codersdk.ProvisionerKeyTags(s.Tags).String()] = s
+		}
+
+		gpu := byTags[codersdk.ProvisionerKeyTags(gpuTags).String()]
+		require.EqualValues(t, 1, gpu.PendingJobs)
+		require.EqualValues(t, 1, gpu.RunningJobs)
+		require.EqualValues(t, 1, gpu.Daemons)
+		require.EqualValues(t, 1, gpu.BusyDaemons)
+		require.Equal(t, 1.0, gpu.Utilization)
+		require.EqualValues(t, 2, gpu.DesiredDaemons())
+
+		org := byTags[codersdk.ProvisionerKeyTags(orgTags).String()]
+		require.EqualValues(t, 1, org.PendingJobs)
+		require.EqualValues(t, 0, org.Daemons)
+		require.EqualValues(t, 1, org.DesiredDaemons())
+	})
+
+	t.Run("Tags", func(t *testing.T) {
+		t.Parallel()
+		ctx := testutil.Context(t, testutil.WaitMedium)
+		// Daemons of the pool aren't online, but can acquire the pending job.
+		stats, err := templateAdminClient.OrganizationProvisionerQueue(ctx, owner.OrganizationID, map[string]string{"pool": "cpu"})
+		require.NoError(t, err)
+		require.Len(t, stats, 1)
+		require.Equal(t, map[string]string{"owner": "", "scope": "organization", "pool": "cpu"}, stats[0].Tags)
+		require.EqualValues(t, 1, stats[0].PendingJobs)
+		require.EqualValues(t, 0, stats[0].Daemons)
+	})
+
+	t.Run("MemberDenied", func(t *testing.T) {
+		t.Parallel()
+		ctx := testutil.Context(t, testutil.WaitMedium)
+		_, err := memberClient.OrganizationProvisionerQueue(ctx, owner.OrganizationID, nil)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,163 @@
+package provisionerdserver
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/v2/apiversion"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+// ProvisionerVersionSupportsDriftChecks returns whether a provisioner daemon
+// of the given API version understands drift check jobs. Older daemons would
+// fail to run them.
+func ProvisionerVersionSupportsDriftChecks(version string) bool {
+	major, minor, err := apiversion.Parse(version)
+	if err != nil {
+		return false
+	}
+	return major > 1 || (major == 1 && minor >= 8)
+}
+
+// ParseResourceDrift returns the resources of a Terraform JSON plan that
+// changed outside of Terraform since the state was last written. Drift is read
+// from the refresh of the plan, so the changes the plan itself would make,
+// like a new session token, are never reported as drift.
+func ParseResourceDrift(planJSON []byte) ([]codersdk.WorkspaceDriftedResource, error) {
+	resources := []codersdk.WorkspaceDriftedResource{}
+	if len(planJSON) == 0 {
+		return resources, nil
+	}
+	var plan tfjson.Plan
+	if err := json.Unmarshal(planJSON, &plan); err != nil {
+		return nil, xerrors.Errorf("unmarshal plan: %w", err)
+	}
+
+	for _, change := range plan.ResourceDrift {
+		if change == nil || change.Change == nil {
+			continue
+		}
+		// Data sources are read on every plan, a changed data source is not
+		// drift of the workspace.
+		if change.Mode == tfjson.DataResourceMode {
+			continue
+		}
+
+		var action codersdk.WorkspaceDriftAction
+		switch {
+		case change.Change.Actions.Delete():
+			action = codersdk.WorkspaceDriftActionDeleted
+		case change.Change.Actions.Update():
+			action = codersdk.WorkspaceDriftActionChanged
+		default:
+			continue
+		}
+
+		resource := codersdk.WorkspaceDriftedResource{
+			Address:    change.Address,
+			Type:       change.Type,
+			Name:       change.Name,
+			ModulePath: change.ModuleAddress,
+			Action:     action,
+			Attributes: []codersdk.WorkspaceDriftedAttribute{},
+		}
+		if action == codersdk.WorkspaceDriftActionChanged {
+			resource.Attributes = driftedAttributes(change.Change)
+			if len(resource.Attributes) == 0 {
+				// Only computed attributes changed, which is not
+				// interesting to the owner.
+				continue
+			}
+		}
+		resources = append(resources, resource)
+	}
+
+	sort.Slice(resources, func(i, j int) bool {
+		return resources[i].Address < resources[j].Address
+	})
+	return resources, nil
+}
+
+// driftedAttributes diffs the before and after values of a change. Nested
+// objects are walked so that a single changed label is reported rather than
+// every label.
+func driftedAttributes(change *tfjson.Change) []codersdk.WorkspaceDriftedAttribute {
+	before, _ := change.Before.(map[string]any)
+	after, _ := change.After.(map[string]any)
+	beforeSensitive, _ := change.BeforeSensitive.(map[string]any)
+	afterSensitive, _ := change.AfterSensitive.(map[string]any)
+
+	var attributes []codersdk.WorkspaceDriftedAttribute
+	var walk func(path []string, before, after map[string]any, beforeSensitive, afterSensitive map[string]any)
+	walk = func(path []string, before, after map[string]any, beforeSensitive, afterSensitive map[string]any) {
+		keys := make([]string, 0, len(before)+len(after))
+		for key := range before {
+			keys = append(keys, key)
+		}
+		for key := range after {
+			if _, ok := before[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		slices.Sort(keys)
+
+		for _, key := range keys {
+			b, a := before[key], after[key]
+			if reflect.DeepEqual(b, a) {
+				continue
+			}
+			attrPath := append(slices.Clone(path), key)
+			sensitive := containsSensitive(beforeSensitive[key]) || containsSensitive(afterSensitive[key])
+
+			bMap, bIsMap := b.(map[string]any)
+			aMap, aIsMap := a.(map[string]any)
+			if bIsMap && aIsMap && !sensitive {
+				bs, _ := beforeSensitive[key].(map[string]any)
+				as, _ := afterSensitive[key].(map[string]any)
+				walk(attrPath, bMap, aMap, bs, as)
+				continue
+			}
+
+			attribute := codersdk.WorkspaceDriftedAttribute{
+				Path:      strings.Join(attrPath, "."),
+				Sensitive: sensitive,
+			}
+			if !sensitive {
+				attribute.Before = b
+				attribute.After = a
+			}
+			attributes = append(attributes, attribute)
+		}
+	}
+	walk(nil, before, after, beforeSensitive, afterSensitive)
+	return attributes
+}
+
+// containsSensitive reports whether a value of a sensitivity mask marks
+// anything as sensitive. Terraform masks mirror the shape of the value, with
+// true in place of sensitive values.
+func containsSensitive(mask any) bool {
+	switch v := mask.(type) {
+	case bool:
+		return v
+	case map[string]any:
+		for _, child := range v {
+			if containsSensitive(child) {
+				return true
+			}
+		}
+	case []any:
+		for _, child := range v {
+			if containsSensitive(child) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package provisionerdserver_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/coderd/provisionerdserver"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+func TestParseResourceDrift(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Empty", func(t *testing.T) {
+		t.Parallel()
+
+		resources, err := provisionerdserver.ParseResourceDrift(nil)
+		require.NoError(t, err)
+		require.Empty(t, resources)
+	})
+
+	t.Run("Drift", func(t *testing.T) {
+		t.Parallel()
+
+		plan, err := json.Marshal(tfjson.Plan{
+			FormatVersion: "1.2",
+			ResourceDrift: []*tfjson.ResourceChange{
+				{
+					Address: "docker_volume.home",
+					Mode:    tfjson.ManagedResourceMode,
+					Type:    "docker_volume",
+					Name:    "home",
+					Change: &tfjson.Change{
+						Actions: tfjson.Actions{tfjson.ActionDelete},
+						Before:  map[string]any{"name": "home"},
+					},
+				},
+				{
+					Address:       "module.vm.aws_instance.dev",
+					ModuleAddress: "module.vm",
+					Mode:          tfjson.ManagedResourceMode,
+					Type:          "aws_instance",
+					Name:          "dev",
+					Change: &tfjson.Change{
+						Actions: tfjson.Actions{tfjson.ActionUpdate},
+						Before: map[string]any{
+							"instance_type": "t3.micro",
+							"tags":          map[string]any{"owner": "alice", "team": "dev"},
+							"user_data":     "old",
+							"ami":           "ami-1",
+						},
+						After: map[string]any{
+							"instance_type": "t3.large",
+							"tags":          map[string]any{"owner": "bob", "team": "dev"},
+							"user_data":     "new",
+							"ami":           "ami-1",
+						},
+						BeforeSensitive: map[string]any{"user_data": true},
+						AfterSensitive:  map[string]any{"user_data": true},
+					},
+				},
+				{
+					// Only the values that changed count as drift.
+					Address: "docker_container.workspace",
+					Mode:    tfjson.ManagedResourceMode,
+					Type:    "docker_container",
+					Name:    "workspace",
+					Change: &tfjson.Change{
+						Actions: tfjson.Actions{tfjson.ActionUpdate},
+						Before:  map[string]any{"image": "ubuntu"},
+						After:   map[string]any{"image": "ubuntu"},
+					},
+				},
+				{
+					// Data sources are read on every plan.
+					Address: "data.coder_workspace.me",
+					Mode:    tfjson.DataResourceMode,
+					Type:    "coder_workspace",
+					Name:    "me",
+					Change: &tfjson.Change{
+						Actions: tfjson.Actions{tfjson.ActionUpdate},
+						Before:  map[string]any{"transition": "stop"},
+						After:   map[string]any{"transition": "start"},
+					},
+				},
+			},
+		})
+		require.NoError(t, err)
+
+		resources, err := provisionerdserver.ParseResourceDrift(plan)
+		require.NoError(t, err)
+		require.Equal(t, []codersdk.WorkspaceDriftedResource{
+			{
+				Address:    "docker_volume.home",
+				Type:       "docker_volume",
+				Name:       "home",
+				Action:     codersdk.WorkspaceDriftActionDeleted,
+				Attributes: []codersdk.WorkspaceDriftedAttribute{},
+			},
+			{
+				Address:    "module.vm.aws_instance.dev",
+				Type:       "aws_instance",
+				Name:       "dev",
+				ModulePath: "module.vm",
+				Action:     codersdk.WorkspaceDriftActionChanged,
+				Attributes: []codersdk.WorkspaceDriftedAttribute{
+					{Path: "instance_type", Before: "t3.micro", After: "t3.large"},
+					{Path: "tags.owner", Before: "alice", After: "bob"},
+					{Path: "user_data", Sensitive: true},
+				},
+			},
+		}, resources)
+	})
+}
+
+func TestProvisionerVersionSupportsDriftChecks(t *testing.T) {
+	t.Parallel()
+
+	require.False(t, provisionerdserver.ProvisionerVersionSupportsDriftChecks("1.7"))
+	require.False(t, provisionerdserver.ProvisionerVersionSupportsDriftChecks("invalid"))
+	require.True(t, provisionerdserver.ProvisionerVersionSupportsDriftChecks("1.8"))
+	require.True(t, provisionerdserver.ProvisionerVersionSupportsDriftChecks("2.0"))
+}
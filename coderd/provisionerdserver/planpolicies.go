@@ -0,0 +1,29 @@
+package provisionerdserver
+
+import (
+	"github.com/coder/coder/v2/apiversion"
+	"github.com/coder/coder/v2/coderd/database"
+	"github.com/coder/coder/v2/provisionerd/proto"
+)
+
+// ProvisionerVersionSupportsPlanPolicies returns whether a provisioner daemon
+// of the given API version evaluates plan policies. Older daemons would apply
+// builds without enforcing them.
+func ProvisionerVersionSupportsPlanPolicies(version string) bool {
+	major, minor, err := apiversion.Parse(version)
+	if err != nil {
+		return false
+	}
+	return major > 1 || (major == 1 && minor >= 9)
+}
+
+func convertPlanPolicies(policies []database.PlanPolicy) []*proto.PlanPolicy {
+	converted := make([]*proto.PlanPolicy, 0, len(policies))
+	for _, policy := range policies {
+		converted = append(converted, &proto.PlanPolicy{
+			Name:   policy.Name,
+			Module: policy.Module,
+		})
+	}
+	return converted
+}
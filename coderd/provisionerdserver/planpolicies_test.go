@@ -0,0 +1,18 @@
+package provisionerdserver_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/coderd/provisionerdserver"
+)
+
+func TestProvisionerVersionSupportsPlanPolicies(t *testing.T) {
+	t.Parallel()
+
+	require.False(t, provisionerdserver.ProvisionerVersionSupportsPlanPolicies("1.8"))
+	require.False(t, provisionerdserver.ProvisionerVersionSupportsPlanPolicies("invalid"))
+	require.True(t, provisionerdserver.ProvisionerVersionSupportsPlanPolicies("1.9"))
+	require.True(t, provisionerdserver.ProvisionerVersionSupportsPlanPolicies("2.0"))
+}
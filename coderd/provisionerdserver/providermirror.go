@@ -0,0 +1,278 @@
+package provisionerdserver
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/mod/sumdb/dirhash"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/v2/apiversion"
+	"github.com/coder/coder/v2/coderd/database"
+	"github.com/coder/coder/v2/coderd/database/dbauthz"
+	"github.com/coder/coder/v2/coderd/database/dbtime"
+	"github.com/coder/coder/v2/cryptorand"
+	"github.com/coder/coder/v2/provisionerd/proto"
+	sdkproto "github.com/coder/coder/v2/provisionersdk/proto"
+)
+
+// ProvisionerVersionSupportsProviderMirror returns whether a provisioner
+// daemon of the given API version installs providers from the provider mirror
+// and uploads the providers of template imports to it.
+func ProvisionerVersionSupportsProviderMirror(version string) bool {
+	major, minor, err := apiversion.Parse(version)
+	if err != nil {
+		return false
+	}
+	return major > 1 || (major == 1 && minor >= 12)
+}
+
+// NewProviderMirrorToken generates a token that authenticates a provisioner
+// job to the provider mirror. Only the hash of the secret is stored.
+func NewProviderMirrorToken(jobID uuid.UUID) (token string, hashedSecret []byte, err error) {
+	secret, err := cryptorand.String(32)
+	if err != nil {
+		return "", nil, xerrors.Errorf("generate secret: %w", err)
+	}
+	hashed := sha256.Sum256([]byte(secret))
+	return jobID.String() + "." + secret, hashed[:], nil
+}
+
+// VerifyProviderMirrorToken returns the job a provider mirror token belongs
+// to. Tokens are only valid while their job is running.
+func VerifyProviderMirrorToken(ctx context.Context, db database.Store, token string) (database.ProvisionerJob, error) {
+	rawJobID, secret, ok := strings.Cut(token, ".")
+	if !ok {
+		return database.ProvisionerJob{}, xerrors.New("malformed provider mirror token")
+	}
+	jobID, err := uuid.Parse(rawJobID)
+	if err != nil {
+		return database.ProvisionerJob{}, xerrors.Errorf("parse job id: %w", err)
+	}
+
+	//nolint:gocritic // Tokens are verified before anything is known about the caller.
+	ctx = dbauthz.AsProvisionerd(ctx)
+	stored, err := db.GetProviderMirrorTokenByJobID(ctx, jobID)
+	if err != nil {
+		return database.ProvisionerJob{}, xerrors.Errorf("get provider mirror token: %w", err)
+	}
+	hashed := sha256.Sum256([]byte(secret))
+	if subtle.ConstantTimeCompare(hashed[:], stored.HashedSecret) != 1 {
+		return database.ProvisionerJob{}, xerrors.New("invalid provider mirror token")
+	}
+	job, err := db.GetProvisionerJobByID(ctx, jobID)
+	if err != nil {
+		return database.ProvisionerJob{}, xerrors.Errorf("get provisioner job: %w", err)
+	}
+	if !job.StartedAt.Valid || job.CompletedAt.Valid {
+		return database.ProvisionerJob{}, xerrors.New("provider mirror token of a job that is not running")
+	}
+	return job, nil
+}
+
+// ProviderMirrorTemplateVersionID returns the template version whose cached
+// modules the job installs from the mirror. Template imports populate the
+// module cache themselves, so they have none.
+func ProviderMirrorTemplateVersionID(ctx context.Context, db database.Store, job database.ProvisionerJob) (uuid.UUID, error) {
+	switch job.Type {
+	case database.ProvisionerJobTypeWorkspaceBuild, database.ProvisionerJobTypeWorkspaceDriftCheck:
+		var input WorkspaceProvisionJob
+		err := json.Unmarshal(job.Input, &input)
+		if err != nil {
+			return uuid.Nil, xerrors.Errorf("unmarshal job input: %w", err)
+		}
+		build, err := db.GetWorkspaceBuildByID(ctx, input.WorkspaceBuildID)
+		if err != nil {
+			return uuid.Nil, xerrors.Errorf("get workspace build: %w", err)
+		}
+		return build.TemplateVersionID, nil
+	case database.ProvisionerJobTypeTemplateVersionDryRun:
+		var input TemplateVersionDryRunJob
+		err := json.Unmarshal(job.Input, &input)
+		if err != nil {
+			return uuid.Nil, xerrors.Errorf("unmarshal job input: %w", err)
+		}
+		return input.TemplateVersionID, nil
+	default:
+		return uuid.Nil, nil
+	}
+}
+
+// providerMirrorURL returns the base URL of the provider mirror, or nil if
+// the mirror is disabled. Terraform only installs from mirrors over HTTPS.
+func (s *server) providerMirrorURL() *url.URL {
+	if s.DeploymentValues == nil || !s.DeploymentValues.Provisioner.ProviderMirror.Value() {
+		return nil
+	}
+	if s.AccessURL == nil || s.AccessURL.Scheme != "https" {
+		return nil
+	}
+	return s.AccessURL.JoinPath("/api/v2/provider-mirror")
+}
+
+// acquireProviderMirror points the acquired job at the provider mirror, and
+// at the modules cached by the import of its template version.
+func (s *server) acquireProviderMirror(ctx context.Context, job database.ProvisionerJob, protoJob *proto.AcquiredJob) error {
+	mirrorURL := s.providerMirrorURL()
+	if mirrorURL == nil || !ProvisionerVersionSupportsProviderMirror(s.apiVersion) {
+		return nil
+	}
+
+	var metadata *sdkproto.Metadata
+	switch typed := protoJob.Type.(type) {
+	case *proto.AcquiredJob_WorkspaceBuild_:
+		metadata = typed.WorkspaceBuild.GetMetadata()
+	case *proto.AcquiredJob_WorkspaceDriftCheck:
+		metadata = typed.WorkspaceDriftCheck.GetMetadata()
+	case *proto.AcquiredJob_TemplateDryRun_:
+		metadata = typed.TemplateDryRun.GetMetadata()
+	case *proto.AcquiredJob_TemplateImport_:
+		metadata = typed.TemplateImport.GetMetadata()
+	}
+	if metadata == nil {
+		return nil
+	}
+
+	token, hashedSecret, err := NewProviderMirrorToken(job.ID)
+	if err != nil {
+		return xerrors.Errorf("generate provider mirror token: %w", err)
+	}
+	err = s.Database.UpsertProviderMirrorToken(ctx, database.UpsertProviderMirrorTokenParams{
+		JobID:        job.ID,
+		HashedSecret: hashedSecret,
+		CreatedAt:    dbtime.Now(),
+	})
+	if err != nil {
+		return xerrors.Errorf("insert provider mirror token: %w", err)
+	}
+	// Terraform expects the URL of a network mirror to end with a slash.
+	metadata.ProviderMirrorUrl = mirrorURL.JoinPath("providers").String() + "/"
+	metadata.ProviderMirrorToken = token
+
+	templateVersionID, err := ProviderMirrorTemplateVersionID(ctx, s.Database, job)
+	if err != nil {
+		return err
+	}
+	if templateVersionID == uuid.Nil {
+		return nil
+	}
+	terraformValues, err := s.Database.GetTemplateVersionTerraformValues(ctx, templateVersionID)
+	if xerrors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return xerrors.Errorf("get template version terraform values: %w", err)
+	}
+	if !terraformValues.CachedModuleFiles.Valid {
+		return nil
+	}
+	file, err := s.Database.GetFileByID(ctx, terraformValues.CachedModuleFiles.UUID)
+	if err != nil {
+		return xerrors.Errorf("get cached module files: %w", err)
+	}
+	metadata.ModuleFilesUrl = mirrorURL.JoinPath("modules", file.Hash).String()
+	return nil
+}
+
+// completeProviderMirror adds the provider packages installed by a template
+// import to the provider mirror of its organization. The packages were
+// uploaded before the job completed, and are referenced by their hash. The
+// "h1:" hash served with a package is computed from the uploaded archive
+// rather than trusted from the daemon, and packages in the mirror are never
+// replaced, so a daemon cannot change the providers other jobs install.
+func (s *server) completeProviderMirror(ctx context.Context, db database.Store, organizationID uuid.UUID, archives []*sdkproto.ProviderArchive, now time.Time) error {
+	if s.providerMirrorURL() == nil {
+		return nil
+	}
+	for _, archive := range archives {
+		if len(archive.DataHash) == 0 {
+			continue
+		}
+		//nolint:gocritic // Acting as provisioner
+		file, err := db.GetFileByHashAndCreator(dbauthz.AsProvisionerd(ctx), database.GetFileByHashAndCreatorParams{
+			Hash:      hex.EncodeToString(archive.DataHash),
+			CreatedBy: uuid.Nil,
+		})
+		if err != nil {
+			return xerrors.Errorf("get provider archive %s, it should have been uploaded: %w", providerArchiveName(archive), err)
+		}
+		hash, err := providerArchiveHash(file.Data)
+		if err != nil {
+			return xerrors.Errorf("hash provider archive %s: %w", providerArchiveName(archive), err)
+		}
+		if hash != archive.Hash {
+			return xerrors.Errorf("provider archive %s has hash %s, but the provisioner reported %s", providerArchiveName(archive), hash, archive.Hash)
+		}
+		inserted, err := db.InsertProviderMirrorArchive(ctx, database.InsertProviderMirrorArchiveParams{
+			OrganizationID: organizationID,
+			Hostname:       archive.Hostname,
+			Namespace:      archive.Namespace,
+			Type:           archive.Type,
+			Version:        archive.Version,
+			Platform:       archive.Platform,
+			Hash:           hash,
+			FileID:         file.ID,
+			CreatedAt:      now,
+		})
+		if err != nil {
+			return xerrors.Errorf("insert provider archive %s: %w", providerArchiveName(archive), err)
+		}
+		if inserted > 0 {
+			continue
+		}
+		// The package is in the mirror already. It must be the same package,
+		// or either the mirror or the daemon installed a different one.
+		existing, err := db.GetProviderMirrorArchives(ctx, database.GetProviderMirrorArchivesParams{
+			OrganizationID: organizationID,
+			Hostname:       archive.Hostname,
+			Namespace:      archive.Namespace,
+			Type:           archive.Type,
+		})
+		if err != nil {
+			return xerrors.Errorf("get provider archives %s: %w", providerArchiveName(archive), err)
+		}
+		for _, mirrored := range existing {
+			if mirrored.Version == archive.Version && mirrored.Platform == archive.Platform && mirrored.Hash != hash {
+				return xerrors.Errorf("provider archive %s has hash %s, but the provider mirror has %s", providerArchiveName(archive), hash, mirrored.Hash)
+			}
+		}
+	}
+	return nil
+}
+
+// providerArchiveHash computes the "h1:" hash of the contents of a zip
+// archive of a provider package, as Terraform does for dependency lock files.
+func providerArchiveHash(data []byte) (string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", xerrors.Errorf("open archive: %w", err)
+	}
+	files := make([]string, 0, len(zr.File))
+	byName := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		if _, ok := byName[f.Name]; ok {
+			return "", xerrors.Errorf("archive contains %q twice", f.Name)
+		}
+		files = append(files, f.Name)
+		byName[f.Name] = f
+	}
+	return dirhash.Hash1(files, func(name string) (io.ReadCloser, error) {
+		return byName[name].Open()
+	})
+}
+
+func providerArchiveName(archive *sdkproto.ProviderArchive) string {
+	return fmt.Sprintf("%s/%s/%s %s (%s)", archive.Hostname, archive.Namespace, archive.Type, archive.Version, archive.Platform)
+}
@@ -0,0 +1,64 @@
+package provisionerdserver
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/mod/sumdb/dirhash"
+)
+
+func TestProviderArchiveHash(t *testing.T) {
+	t.Parallel()
+
+	files := map[string]string{
+		"terraform-provider-coder_v2.4.0": "binary",
+		"LICENSE":                         "MPL-2.0",
+		"docs/README.md":                  "readme",
+	}
+	archive := func(t *testing.T, names ...string) []byte {
+		var b bytes.Buffer
+		w := zip.NewWriter(&b)
+		for _, name := range names {
+			fw, err := w.Create(name)
+			require.NoError(t, err)
+			_, err = fw.Write([]byte(files[name]))
+			require.NoError(t, err)
+		}
+		require.NoError(t, w.Close())
+		return b.Bytes()
+	}
+
+	t.Run("MatchesPackageDirectory", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		for name, content := range files {
+			require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o700))
+			require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+		}
+		want, err := dirhash.HashDir(dir, "", dirhash.Hash1)
+		require.NoError(t, err)
+
+		got, err := providerArchiveHash(archive(t, "terraform-provider-coder_v2.4.0", "LICENSE", "docs/README.md"))
+		require.NoError(t, err)
+		require.Equal(t, want, got)
+	})
+
+	t.Run("DuplicateFile", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := providerArchiveHash(archive(t, "LICENSE", "LICENSE"))
+		require.ErrorContains(t, err, "twice")
+	})
+
+	t.Run("NotAnArchive", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := providerArchiveHash([]byte("not a zip"))
+		require.Error(t, err)
+	})
+}
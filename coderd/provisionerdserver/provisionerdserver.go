@@ -59,6 +59,7 @@ import (
 
 const (
 	tarMimeType = "application/x-tar"
+	zipMimeType = "application/zip"
 )
 
 const (
@@ -465,10 +466,21 @@ func (s *server) acquireProtoJob(ctx context.Context, job database.ProvisionerJo
 		Provisioner:   string(job.Provisioner),
 		UserName:      user.Username,
 		TraceMetadata: jobTraceMetadata,
+		// Jobs of templates that pin a version of the provisioner binary
+		// carry its tag, so they are only acquired by daemons that have it.
+		ProvisionerVersion: provisionersdk.ProvisionerVersionFromTags(string(job.Provisioner), job.Tags),
 	}
 
 	switch job.Type {
-	case database.ProvisionerJobTypeWorkspaceBuild:
+	case database.ProvisionerJobTypeWorkspaceBuild, database.ProvisionerJobTypeWorkspaceDriftCheck:
+		// Drift checks plan against the state of an existing build, so they
+		// are acquired exactly like the build itself. They must not have side
+		// effects on the running workspace though.
+		isDriftCheck := job.Type == database.ProvisionerJobTypeWorkspaceDriftCheck
+		if isDriftCheck && !ProvisionerVersionSupportsDriftChecks(s.apiVersion) {
+			return nil, failJob(fmt.Sprintf("provisioner daemon version %q is too old to check workspaces for drift", s.apiVersion))
+		}
+
 		var input WorkspaceProvisionJob
 		err = json.Unmarshal(job.Input, &input)
 		if err != nil {
@@ -519,16 +531,18 @@ func (s *server) acquireProtoJob(ctx context.Context, job database.ProvisionerJo
 			ownerGroupNames = append(ownerGroupNames, group.Group.Name)
 		}
 
-		msg, err := json.Marshal(wspubsub.WorkspaceEvent{
-			Kind:        wspubsub.WorkspaceEventKindStateChange,
-			WorkspaceID: workspace.ID,
-		})
-		if err != nil {
-			return nil, failJob(fmt.Sprintf("marshal workspace update event: %s", err))
-		}
-		err = s.Pubsub.Publish(wspubsub.WorkspaceEventChannel(workspace.OwnerID), msg)
-		if err != nil {
-			return nil, failJob(fmt.Sprintf("publish workspace update: %s", err))
+		if !isDriftCheck {
+			msg, err := json.Marshal(wspubsub.WorkspaceEvent{
+				Kind:        wspubsub.WorkspaceEventKindStateChange,
+				WorkspaceID: workspace.ID,
+			})
+			if err != nil {
+				return nil, failJob(fmt.Sprintf("marshal workspace update event: %s", err))
+			}
+			err = s.Pubsub.Publish(wspubsub.WorkspaceEventChannel(workspace.OwnerID), msg)
+			if err != nil {
+				return nil, failJob(fmt.Sprintf("publish workspace update: %s", err))
+			}
 		}
 
 		var workspaceOwnerOIDCAccessToken string
@@ -541,17 +555,22 @@ func (s *server) acquireProtoJob(ctx context.Context, job database.ProvisionerJo
 			}
 		}
 
+		// Drift checks leave the session token of the running workspace
+		// alone. Drift is read from the refreshed state, so the plan does not
+		// need a valid token.
 		var sessionToken string
-		switch workspaceBuild.Transition {
-		case database.WorkspaceTransitionStart:
-			sessionToken, err = s.regenerateSessionToken(ctx, owner, workspace)
-			if err != nil {
-				return nil, failJob(fmt.Sprintf("regenerate session token: %s", err))
-			}
-		case database.WorkspaceTransitionStop, database.WorkspaceTransitionDelete:
-			err = deleteSessionToken(ctx, s.Database, workspace)
-			if err != nil {
-				return nil, failJob(fmt.Sprintf("delete session token: %s", err))
+		if !isDriftCheck {
+			switch workspaceBuild.Transition {
+			case database.WorkspaceTransitionStart:
+				sessionToken, err = s.regenerateSessionToken(ctx, owner, workspace)
+				if err != nil {
+					return nil, failJob(fmt.Sprintf("regenerate session token: %s", err))
+				}
+			case database.WorkspaceTransitionStop, database.WorkspaceTransitionDelete:
+				err = deleteSessionToken(ctx, s.Database, workspace)
+				if err != nil {
+					return nil, failJob(fmt.Sprintf("delete session token: %s", err))
+				}
 			}
 		}
 
@@ -682,40 +701,63 @@ func (s *server) acquireProtoJob(ctx context.Context, job database.ProvisionerJo
 			}
 		}
 
-		protoJob.Type = &proto.AcquiredJob_WorkspaceBuild_{
-			WorkspaceBuild: &proto.AcquiredJob_WorkspaceBuild{
-				WorkspaceBuildId:        workspaceBuild.ID.String(),
-				WorkspaceName:           workspace.Name,
-				State:                   workspaceBuild.ProvisionerState,
-				RichParameterValues:     convertRichParameterValues(workspaceBuildParameters),
-				PreviousParameterValues: convertRichParameterValues(lastWorkspaceBuildParameters),
-				VariableValues:          asVariableValues(templateVariables),
-				ExternalAuthProviders:   externalAuthProviders,
-				Metadata: &sdkproto.Metadata{
-					CoderUrl:                      s.AccessURL.String(),
-					WorkspaceTransition:           transition,
-					WorkspaceName:                 workspace.Name,
-					WorkspaceOwner:                owner.Username,
-					WorkspaceOwnerEmail:           owner.Email,
-					WorkspaceOwnerName:            owner.Name,
-					WorkspaceOwnerGroups:          ownerGroupNames,
-					WorkspaceOwnerOidcAccessToken: workspaceOwnerOIDCAccessToken,
-					WorkspaceId:                   workspace.ID.String(),
-					WorkspaceOwnerId:              owner.ID.String(),
-					TemplateId:                    template.ID.String(),
-					TemplateName:                  template.Name,
-					TemplateVersion:               templateVersion.Name,
-					WorkspaceOwnerSessionToken:    sessionToken,
-					WorkspaceOwnerSshPublicKey:    ownerSSHPublicKey,
-					WorkspaceOwnerSshPrivateKey:   ownerSSHPrivateKey,
-					WorkspaceBuildId:              workspaceBuild.ID.String(),
-					WorkspaceOwnerLoginType:       string(owner.LoginType),
-					WorkspaceOwnerRbacRoles:       ownerRbacRoles,
-					RunningAgentAuthTokens:        runningAgentAuthTokens,
-					PrebuiltWorkspaceBuildStage:   input.PrebuiltWorkspaceBuildStage,
-				},
-				LogLevel: input.LogLevel,
+		// Drift checks are never applied, and a policy must never keep a
+		// workspace from being deleted, so only other builds are held to the
+		// plan policies of the organization.
+		var planPolicies []*proto.PlanPolicy
+		if !isDriftCheck && transition != sdkproto.WorkspaceTransition_DESTROY {
+			policies, err := s.Database.GetPlanPoliciesByOrganizationID(ctx, job.OrganizationID)
+			if err != nil {
+				return nil, failJob(fmt.Sprintf("get plan policies: %s", err))
+			}
+			if len(policies) > 0 && !ProvisionerVersionSupportsPlanPolicies(s.apiVersion) {
+				return nil, failJob(fmt.Sprintf("provisioner daemon version %q is too old to enforce the plan policies of the organization", s.apiVersion))
+			}
+			planPolicies = convertPlanPolicies(policies)
+		}
+
+		workspaceBuildJob := &proto.AcquiredJob_WorkspaceBuild{
+			WorkspaceBuildId:        workspaceBuild.ID.String(),
+			WorkspaceName:           workspace.Name,
+			State:                   workspaceBuild.ProvisionerState,
+			RichParameterValues:     convertRichParameterValues(workspaceBuildParameters),
+			PreviousParameterValues: convertRichParameterValues(lastWorkspaceBuildParameters),
+			VariableValues:          asVariableValues(templateVariables),
+			ExternalAuthProviders:   externalAuthProviders,
+			Metadata: &sdkproto.Metadata{
+				CoderUrl:                      s.AccessURL.String(),
+				WorkspaceTransition:           transition,
+				WorkspaceName:                 workspace.Name,
+				WorkspaceOwner:                owner.Username,
+				WorkspaceOwnerEmail:           owner.Email,
+				WorkspaceOwnerName:            owner.Name,
+				WorkspaceOwnerGroups:          ownerGroupNames,
+				WorkspaceOwnerOidcAccessToken: workspaceOwnerOIDCAccessToken,
+				WorkspaceId:                   workspace.ID.String(),
+				WorkspaceOwnerId:              owner.ID.String(),
+				TemplateId:                    template.ID.String(),
+				TemplateName:                  template.Name,
+				TemplateVersion:               templateVersion.Name,
+				WorkspaceOwnerSessionToken:    sessionToken,
+				WorkspaceOwnerSshPublicKey:    ownerSSHPublicKey,
+				WorkspaceOwnerSshPrivateKey:   ownerSSHPrivateKey,
+				WorkspaceBuildId:              workspaceBuild.ID.String(),
+				WorkspaceOwnerLoginType:       string(owner.LoginType),
+				WorkspaceOwnerRbacRoles:       ownerRbacRoles,
+				RunningAgentAuthTokens:        runningAgentAuthTokens,
+				PrebuiltWorkspaceBuildStage:   input.PrebuiltWorkspaceBuildStage,
 			},
+			LogLevel:     input.LogLevel,
+			PlanPolicies: planPolicies,
+		}
+		if isDriftCheck {
+			protoJob.Type = &proto.AcquiredJob_WorkspaceDriftCheck{
+				WorkspaceDriftCheck: workspaceBuildJob,
+			}
+		} else {
+			protoJob.Type = &proto.AcquiredJob_WorkspaceBuild_{
+				WorkspaceBuild: workspaceBuildJob,
+			}
 		}
 	case database.ProvisionerJobTypeTemplateVersionDryRun:
 		var input TemplateVersionDryRunJob
@@ -733,19 +775,29 @@ func (s *server) acquireProtoJob(ctx context.Context, job database.ProvisionerJo
 			return nil, failJob(fmt.Sprintf("get template version variables: %s", err))
 		}
 
-		protoJob.Type = &proto.AcquiredJob_TemplateDryRun_{
-			TemplateDryRun: &proto.AcquiredJob_TemplateDryRun{
-				RichParameterValues: convertRichParameterValues(input.RichParameterValues),
-				VariableValues:      asVariableValues(templateVariables),
-				Metadata: &sdkproto.Metadata{
-					CoderUrl:      s.AccessURL.String(),
-					WorkspaceName: input.WorkspaceName,
-					// There is no owner for a template import, but we can assume
-					// the "Everyone" group as a placeholder.
-					WorkspaceOwnerGroups: []string{database.EveryoneGroup},
-				},
+		dryRun := &proto.AcquiredJob_TemplateDryRun{
+			RichParameterValues: convertRichParameterValues(input.RichParameterValues),
+			VariableValues:      asVariableValues(templateVariables),
+			Metadata: &sdkproto.Metadata{
+				CoderUrl:      s.AccessURL.String(),
+				WorkspaceName: input.WorkspaceName,
+				// There is no owner for a template import, but we can assume
+				// the "Everyone" group as a placeholder.
+				WorkspaceOwnerGroups: []string{database.EveryoneGroup},
 			},
 		}
+		if input.WorkspaceID != uuid.Nil {
+			if !ProvisionerVersionSupportsUpdatePreviews(s.apiVersion) {
+				return nil, failJob(fmt.Sprintf("provisioner daemon version %q is too old to preview workspace updates", s.apiVersion))
+			}
+			err = s.acquireWorkspaceUpdatePreview(ctx, input, templateVersion, dryRun)
+			if err != nil {
+				return nil, failJob(err.Error())
+			}
+		}
+		protoJob.Type = &proto.AcquiredJob_TemplateDryRun_{
+			TemplateDryRun: dryRun,
+		}
 	case database.ProvisionerJobTypeTemplateVersionImport:
 		var input TemplateVersionImportJob
 		err = json.Unmarshal(job.Input, &input)
@@ -770,6 +822,10 @@ func (s *server) acquireProtoJob(ctx context.Context, job database.ProvisionerJo
 			},
 		}
 	}
+	err = s.acquireProviderMirror(ctx, job, protoJob)
+	if err != nil {
+		return nil, failJob(fmt.Sprintf("acquire provider mirror: %s", err))
+	}
 	switch job.StorageMethod {
 	case database.ProvisionerStorageMethodFile:
 		file, err := s.Database.GetFileByID(ctx, job.FileID)
@@ -1118,6 +1174,10 @@ func (s *server) FailJob(ctx context.Context, failJob *proto.FailedJob) (*proto.
 				if err != nil {
 					return xerrors.Errorf("update workspace build state: %w", err)
 				}
+				err = insertWorkspaceBuildState(ctx, db, build, jobType.WorkspaceBuild.State, s.timeNow())
+				if err != nil {
+					return xerrors.Errorf("insert workspace build state history: %w", err)
+				}
 				err = db.UpdateWorkspaceBuildDeadlineByID(ctx, database.UpdateWorkspaceBuildDeadlineByIDParams{
 					ID:          input.WorkspaceBuildID,
 					UpdatedAt:   s.timeNow(),
@@ -1149,6 +1209,16 @@ func (s *server) FailJob(ctx context.Context, failJob *proto.FailedJob) (*proto.
 			return nil, xerrors.Errorf("publish workspace update: %w", err)
 		}
 	case *proto.FailedJob_TemplateImport_:
+	case *proto.FailedJob_WorkspaceDriftCheck_:
+		// The error is stored on the job. The check is completed without
+		// results, so that it is not mistaken for a pending one.
+		_, err = s.Database.UpdateWorkspaceDriftCheckByJobID(ctx, database.UpdateWorkspaceDriftCheckByJobIDParams{
+			JobID:       jobID,
+			CompletedAt: job.CompletedAt,
+		})
+		if err != nil {
+			return nil, xerrors.Errorf("update workspace drift check: %w", err)
+		}
 	}
 
 	// if failed job is a workspace build, audit the outcome
@@ -1396,6 +1466,15 @@ UploadFileStream:
 			Mimetype:  tarMimeType,
 			Data:      fileData,
 		}
+	case sdkproto.DataUploadType_UPLOAD_TYPE_PROVIDER_ARCHIVE:
+		insert = database.InsertFileParams{
+			ID:        uuid.New(),
+			Hash:      hash,
+			CreatedAt: dbtime.Now(),
+			CreatedBy: uuid.Nil,
+			Mimetype:  zipMimeType,
+			Data:      fileData,
+		}
 	default:
 		return xerrors.Errorf("unsupported file upload type: %s", file.Type)
 	}
@@ -1460,6 +1539,11 @@ func (s *server) CompleteJob(ctx context.Context, completed *proto.CompletedJob)
 		if err != nil {
 			return nil, err
 		}
+	case *proto.CompletedJob_WorkspaceDriftCheck_:
+		err = s.completeWorkspaceDriftCheckJob(ctx, jobID, jobType)
+		if err != nil {
+			return nil, err
+		}
 	default:
 		if completed.Type == nil {
 			return nil, xerrors.Errorf("type payload must be provided")
@@ -1742,6 +1826,11 @@ func (s *server) completeTemplateImportJob(ctx context.Context, job database.Pro
 			}
 		}
 
+		err = s.completeProviderMirror(ctx, db, job.OrganizationID, jobType.TemplateImport.ProviderArchives, now)
+		if err != nil {
+			return xerrors.Errorf("complete provider mirror: %w", err)
+		}
+
 		// Mark job as completed
 		err = db.UpdateProvisionerJobWithCompleteByID(ctx, database.UpdateProvisionerJobWithCompleteByIDParams{
 			ID:        jobID,
@@ -1849,6 +1938,10 @@ func (s *server) completeWorkspaceBuildJob(ctx context.Context, job database.Pro
 		if err != nil {
 			return xerrors.Errorf("update workspace build provisioner state: %w", err)
 		}
+		err = insertWorkspaceBuildState(ctx, db, workspaceBuild, jobType.WorkspaceBuild.State, now)
+		if err != nil {
+			return xerrors.Errorf("insert workspace build state history: %w", err)
+		}
 		err = db.UpdateWorkspaceBuildDeadlineByID(ctx, database.UpdateWorkspaceBuildDeadlineByIDParams{
 			ID:          workspaceBuild.ID,
 			Deadline:    autoStop.Deadline,
@@ -2145,8 +2238,13 @@ func (s *server) completeTemplateDryRunJob(ctx context.Context, job database.Pro
 			}
 		}
 
+		err := s.completeWorkspaceUpdatePreview(ctx, db, jobID, jobType.TemplateDryRun, now)
+		if err != nil {
+			return xerrors.Errorf("complete workspace update preview: %w", err)
+		}
+
 		// Mark job as complete
-		err := db.UpdateProvisionerJobWithCompleteByID(ctx, database.UpdateProvisionerJobWithCompleteByIDParams{
+		err = db.UpdateProvisionerJobWithCompleteByID(ctx, database.UpdateProvisionerJobWithCompleteByIDParams{
 			ID:        jobID,
 			UpdatedAt: now,
 			CompletedAt: sql.NullTime{
@@ -2165,6 +2263,214 @@ func (s *server) completeTemplateDryRunJob(ctx context.Context, job database.Pro
 	}, nil) // End of transaction
 }
 
+// completeWorkspaceUpdatePreview stores the resource changes of a dry-run
+// that previewed a workspace update. Other dry-runs have no preview.
+func (*server) completeWorkspaceUpdatePreview(ctx context.Context, db database.Store, jobID uuid.UUID, dryRun *proto.CompletedJob_TemplateDryRun, now time.Time) error {
+	preview, err := db.GetWorkspaceUpdatePreviewByJobID(ctx, jobID)
+	if xerrors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return xerrors.Errorf("get workspace update preview: %w", err)
+	}
+
+	// Resources that outlive a stop of the workspace are persistent, like
+	// the volume of its home directory.
+	build, err := db.GetLatestWorkspaceBuildByWorkspaceID(ctx, preview.WorkspaceID)
+	if err != nil {
+		return xerrors.Errorf("get latest workspace build: %w", err)
+	}
+	templateVersion, err := db.GetTemplateVersionByID(ctx, build.TemplateVersionID)
+	if err != nil {
+		return xerrors.Errorf("get template version: %w", err)
+	}
+	resources, err := db.GetWorkspaceResourcesByJobID(ctx, templateVersion.JobID)
+	if err != nil {
+		return xerrors.Errorf("get template version resources: %w", err)
+	}
+
+	changes, err := ParseResourceChanges(dryRun.Plan, dryRun.ResourceReplacements, resources)
+	if err != nil {
+		return xerrors.Errorf("parse resource changes: %w", err)
+	}
+	resourceChanges, err := json.Marshal(changes)
+	if err != nil {
+		return xerrors.Errorf("marshal resource changes: %w", err)
+	}
+	_, err = db.UpdateWorkspaceUpdatePreviewByJobID(ctx, database.UpdateWorkspaceUpdatePreviewByJobIDParams{
+		JobID: jobID,
+		CompletedAt: sql.NullTime{
+			Time:  now,
+			Valid: true,
+		},
+		ResourceChanges: pqtype.NullRawMessage{
+			RawMessage: resourceChanges,
+			Valid:      true,
+		},
+	})
+	if err != nil {
+		return xerrors.Errorf("update workspace update preview: %w", err)
+	}
+	return nil
+}
+
+// acquireWorkspaceUpdatePreview plans a dry-run against the state of the
+// latest build of the workspace it previews the update of. Parameters the
+// dry-run doesn't set keep their value from the latest build, like they would
+// in the update.
+func (s *server) acquireWorkspaceUpdatePreview(ctx context.Context, input TemplateVersionDryRunJob, templateVersion database.TemplateVersion, dryRun *proto.AcquiredJob_TemplateDryRun) error {
+	workspace, err := s.Database.GetWorkspaceByID(ctx, input.WorkspaceID)
+	if err != nil {
+		return xerrors.Errorf("get workspace: %w", err)
+	}
+	build, err := s.Database.GetLatestWorkspaceBuildByWorkspaceID(ctx, workspace.ID)
+	if err != nil {
+		return xerrors.Errorf("get latest workspace build: %w", err)
+	}
+	previousParameters, err := s.Database.GetWorkspaceBuildParameters(ctx, build.ID)
+	if err != nil {
+		return xerrors.Errorf("get workspace build parameters: %w", err)
+	}
+	owner, err := s.Database.GetUserByID(ctx, workspace.OwnerID)
+	if err != nil {
+		return xerrors.Errorf("get owner: %w", err)
+	}
+	template, err := s.Database.GetTemplateByID(ctx, workspace.TemplateID)
+	if err != nil {
+		return xerrors.Errorf("get template: %w", err)
+	}
+
+	values := make(map[string]string, len(previousParameters)+len(input.RichParameterValues))
+	for _, param := range previousParameters {
+		values[param.Name] = param.Value
+	}
+	for _, param := range input.RichParameterValues {
+		values[param.Name] = param.Value
+	}
+	parameters := make([]database.WorkspaceBuildParameter, 0, len(values))
+	for name, value := range values {
+		parameters = append(parameters, database.WorkspaceBuildParameter{Name: name, Value: value})
+	}
+	slices.SortFunc(parameters, func(a, b database.WorkspaceBuildParameter) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	dryRun.RichParameterValues = convertRichParameterValues(parameters)
+	dryRun.Metadata.WorkspaceId = workspace.ID.String()
+	dryRun.Metadata.WorkspaceName = workspace.Name
+	dryRun.Metadata.WorkspaceOwner = owner.Username
+	dryRun.Metadata.WorkspaceOwnerId = owner.ID.String()
+	dryRun.Metadata.WorkspaceOwnerEmail = owner.Email
+	dryRun.Metadata.WorkspaceOwnerName = owner.Name
+	dryRun.Metadata.TemplateId = template.ID.String()
+	dryRun.Metadata.TemplateName = template.Name
+	dryRun.Metadata.TemplateVersion = templateVersion.Name
+	dryRun.WorkspaceUpdate = &proto.AcquiredJob_TemplateDryRun_WorkspaceUpdate{
+		State:                   build.ProvisionerState,
+		PreviousParameterValues: convertRichParameterValues(previousParameters),
+	}
+	return nil
+}
+
+// completeWorkspaceDriftCheckJob stores the resources that drifted according to
+// the plan of a drift check, and notifies the workspace owner if any did.
+func (s *server) completeWorkspaceDriftCheckJob(ctx context.Context, jobID uuid.UUID, jobType *proto.CompletedJob_WorkspaceDriftCheck_) error {
+	resources, err := ParseResourceDrift(jobType.WorkspaceDriftCheck.Plan)
+	if err != nil {
+		return xerrors.Errorf("parse resource drift: %w", err)
+	}
+	driftedResources, err := json.Marshal(resources)
+	if err != nil {
+		return xerrors.Errorf("marshal drifted resources: %w", err)
+	}
+
+	var check database.WorkspaceDriftCheck
+	err = s.Database.InTx(func(db database.Store) error {
+		now := s.timeNow()
+		check, err = db.UpdateWorkspaceDriftCheckByJobID(ctx, database.UpdateWorkspaceDriftCheckByJobIDParams{
+			JobID: jobID,
+			CompletedAt: sql.NullTime{
+				Time:  now,
+				Valid: true,
+			},
+			DriftedResources: pqtype.NullRawMessage{
+				RawMessage: driftedResources,
+				Valid:      true,
+			},
+		})
+		if err != nil {
+			return xerrors.Errorf("update workspace drift check: %w", err)
+		}
+
+		err = db.UpdateProvisionerJobWithCompleteByID(ctx, database.UpdateProvisionerJobWithCompleteByIDParams{
+			ID:        jobID,
+			UpdatedAt: now,
+			CompletedAt: sql.NullTime{
+				Time:  now,
+				Valid: true,
+			},
+			Error:     sql.NullString{},
+			ErrorCode: sql.NullString{},
+		})
+		if err != nil {
+			return xerrors.Errorf("update provisioner job: %w", err)
+		}
+		return nil
+	}, nil)
+	if err != nil {
+		return err
+	}
+	s.Logger.Debug(ctx, "marked workspace drift check job as completed",
+		slog.F("job_id", jobID), slog.F("drifted_resources", len(resources)))
+
+	if len(resources) == 0 {
+		return nil
+	}
+
+	workspace, err := s.Database.GetWorkspaceByID(ctx, check.WorkspaceID)
+	if err != nil {
+		s.Logger.Warn(ctx, "failed to get workspace for drift notification", slog.Error(err))
+		return nil
+	}
+	s.notifyWorkspaceDriftDetected(ctx, workspace, resources)
+
+	msg, err := json.Marshal(wspubsub.WorkspaceEvent{
+		Kind:        wspubsub.WorkspaceEventKindStateChange,
+		WorkspaceID: workspace.ID,
+	})
+	if err != nil {
+		return xerrors.Errorf("marshal workspace update event: %s", err)
+	}
+	err = s.Pubsub.Publish(wspubsub.WorkspaceEventChannel(workspace.OwnerID), msg)
+	if err != nil {
+		return xerrors.Errorf("publish workspace update: %w", err)
+	}
+	return nil
+}
+
+func (s *server) notifyWorkspaceDriftDetected(ctx context.Context, workspace database.Workspace, resources []codersdk.WorkspaceDriftedResource) {
+	data := make([]map[string]any, 0, len(resources))
+	for _, resource := range resources {
+		data = append(data, map[string]any{
+			"address": resource.Address,
+			"action":  string(resource.Action),
+		})
+	}
+
+	if _, err := s.NotificationsEnqueuer.EnqueueWithData(ctx, workspace.OwnerID, notifications.TemplateWorkspaceDriftDetected,
+		map[string]string{
+			"name": workspace.Name,
+		},
+		map[string]any{
+			"resources": data,
+		}, "provisionerdserver",
+		// Associate this notification with all the related entities.
+		workspace.ID, workspace.OwnerID, workspace.TemplateID, workspace.OrganizationID,
+	); err != nil {
+		s.Logger.Warn(ctx, "failed to notify of workspace drift", slog.Error(err))
+	}
+}
+
 func (s *server) notifyWorkspaceDeleted(ctx context.Context, workspace database.Workspace, build database.WorkspaceBuild) {
 	var reason string
 	initiator := build.InitiatorByUsername
@@ -2315,6 +2621,18 @@ func InsertWorkspacePresetAndParameters(ctx context.Context, db database.Store,
 }
 
 func InsertWorkspaceResource(ctx context.Context, db database.Store, jobID uuid.UUID, transition database.WorkspaceTransition, protoResource *sdkproto.Resource, snapshot *telemetry.Snapshot) error {
+	var hourlyCost int64
+	for _, metadatum := range protoResource.Metadata {
+		if metadatum.IsNull || metadatum.Key != provisionersdk.HourlyCostMetadataKey {
+			continue
+		}
+		cost, err := provisionersdk.ParseHourlyCost(metadatum.Value)
+		if err != nil {
+			return xerrors.Errorf("resource %q: %w", protoResource.Name, err)
+		}
+		hourlyCost = cost
+	}
+
 	resource, err := db.InsertWorkspaceResource(ctx, database.InsertWorkspaceResourceParams{
 		ID:         uuid.New(),
 		CreatedAt:  dbtime.Now(),
@@ -2334,6 +2652,7 @@ func InsertWorkspaceResource(ctx context.Context, db database.Store, jobID uuid.
 			// empty string is root module
 			Valid: true,
 		},
+		HourlyCostMicros: hourlyCost,
 	})
 	if err != nil {
 		return xerrors.Errorf("insert provisioner job resource %q: %w", protoResource.Name, err)
@@ -2909,6 +3228,9 @@ type TemplateVersionDryRunJob struct {
 	TemplateVersionID   uuid.UUID                          `json:"template_version_id"`
 	WorkspaceName       string                             `json:"workspace_name"`
 	RichParameterValues []database.WorkspaceBuildParameter `json:"rich_parameter_values"`
+	// WorkspaceID is set for dry-runs that preview an update of the
+	// workspace to the template version.
+	WorkspaceID uuid.UUID `json:"workspace_id,omitempty"`
 }
 
 func asVariableValues(templateVariables []database.TemplateVersionVariable) []*sdkproto.VariableValue {
@@ -2972,3 +3294,20 @@ func convertDisplayApps(apps *sdkproto.DisplayApps) []database.DisplayApp {
 	}
 	return dapps
 }
+
+// insertWorkspaceBuildState keeps the state a workspace build ended with, so
+// that earlier states can be browsed and rolled back to after later builds.
+func insertWorkspaceBuildState(ctx context.Context, db database.Store, build database.WorkspaceBuild, state []byte, now time.Time) error {
+	if len(state) == 0 {
+		return nil
+	}
+	hash := sha256.Sum256(state)
+	return db.UpsertWorkspaceBuildState(ctx, database.UpsertWorkspaceBuildStateParams{
+		WorkspaceBuildID: build.ID,
+		WorkspaceID:      build.WorkspaceID,
+		State:            state,
+		Hash:             hex.EncodeToString(hash[:]),
+		Size:             int64(len(state)),
+		CreatedAt:        now,
+	})
+}
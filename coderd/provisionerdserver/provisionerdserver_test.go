@@ -165,6 +165,7 @@ func TestAcquireJob(t *testing.T) {
 				Type:           database.ProvisionerJobTypeTemplateVersionDryRun,
 				Input:          json.RawMessage("{}"),
 				Tags:           pd.Tags,
+				Priority:       database.ProvisionerJobPriorityInteractive,
 			})
 			require.NoError(t, err)
 			_, err = tc.acquire(ctx, srv)
@@ -682,6 +683,7 @@ func TestUpdateJob(t *testing.T) {
 			Type:          database.ProvisionerJobTypeTemplateVersionDryRun,
 			Input:         json.RawMessage("{}"),
 			Tags:          pd.Tags,
+			Priority:      database.ProvisionerJobPriorityInteractive,
 		})
 		require.NoError(t, err)
 		_, err = srv.UpdateJob(ctx, &proto.UpdateJobRequest{
@@ -700,6 +702,7 @@ func TestUpdateJob(t *testing.T) {
 			Type:          database.ProvisionerJobTypeTemplateVersionDryRun,
 			Input:         json.RawMessage("{}"),
 			Tags:          pd.Tags,
+			Priority:      database.ProvisionerJobPriorityInteractive,
 		})
 		require.NoError(t, err)
 		_, err = db.AcquireProvisionerJob(ctx, database.AcquireProvisionerJobParams{
@@ -729,6 +732,7 @@ func TestUpdateJob(t *testing.T) {
 			StorageMethod: database.ProvisionerStorageMethodFile,
 			Input:         json.RawMessage("{}"),
 			Tags:          tags,
+			Priority:      database.ProvisionerJobPriorityInteractive,
 		})
 		require.NoError(t, err)
 		_, err = db.AcquireProvisionerJob(ctx, database.AcquireProvisionerJobParams{
@@ -957,6 +961,7 @@ func TestFailJob(t *testing.T) {
 			Type:          database.ProvisionerJobTypeTemplateVersionImport,
 			Input:         json.RawMessage("{}"),
 			Tags:          pd.Tags,
+			Priority:      database.ProvisionerJobPriorityInteractive,
 		})
 		require.NoError(t, err)
 		_, err = db.AcquireProvisionerJob(ctx, database.AcquireProvisionerJobParams{
@@ -987,6 +992,7 @@ func TestFailJob(t *testing.T) {
 			StorageMethod: database.ProvisionerStorageMethodFile,
 			Input:         json.RawMessage("{}"),
 			Tags:          pd.Tags,
+			Priority:      database.ProvisionerJobPriorityInteractive,
 		})
 		require.NoError(t, err)
 		_, err = db.AcquireProvisionerJob(ctx, database.AcquireProvisionerJobParams{
@@ -1053,6 +1059,7 @@ func TestFailJob(t *testing.T) {
 			Type:          database.ProvisionerJobTypeWorkspaceBuild,
 			StorageMethod: database.ProvisionerStorageMethodFile,
 			Tags:          pd.Tags,
+			Priority:      database.ProvisionerJobPriorityInteractive,
 		})
 		require.NoError(t, err)
 		err = db.InsertWorkspaceBuild(ctx, database.InsertWorkspaceBuildParams{
@@ -1152,6 +1159,7 @@ func TestCompleteJob(t *testing.T) {
 			OrganizationID: pd.OrganizationID,
 			Input:          json.RawMessage("{}"),
 			Tags:           pd.Tags,
+			Priority:       database.ProvisionerJobPriorityInteractive,
 		})
 		require.NoError(t, err)
 		_, err = db.AcquireProvisionerJob(ctx, database.AcquireProvisionerJobParams{
@@ -1197,6 +1205,7 @@ func TestCompleteJob(t *testing.T) {
 				StorageMethod:  database.ProvisionerStorageMethodFile,
 				Type:           database.ProvisionerJobTypeTemplateVersionImport,
 				Tags:           pd.Tags,
+				Priority:       database.ProvisionerJobPriorityInteractive,
 			})
 			require.NoError(t, err)
 			_, err = db.AcquireProvisionerJob(ctx, database.AcquireProvisionerJobParams{
@@ -1248,6 +1257,7 @@ func TestCompleteJob(t *testing.T) {
 				StorageMethod: database.ProvisionerStorageMethodFile,
 				Input:         json.RawMessage("{}"),
 				Tags:          pd.Tags,
+				Priority:      database.ProvisionerJobPriorityInteractive,
 			})
 			require.NoError(t, err)
 			_, err = db.AcquireProvisionerJob(ctx, database.AcquireProvisionerJobParams{
@@ -1466,6 +1476,7 @@ func TestCompleteJob(t *testing.T) {
 			Type:           database.ProvisionerJobTypeWorkspaceBuild,
 			OrganizationID: pd.OrganizationID,
 			Tags:           pd.Tags,
+			Priority:       database.ProvisionerJobPriorityInteractive,
 		})
 		require.NoError(t, err)
 		_, err = db.AcquireProvisionerJob(ctx, database.AcquireProvisionerJobParams{
@@ -1523,6 +1534,7 @@ func TestCompleteJob(t *testing.T) {
 			Type:           database.ProvisionerJobTypeWorkspaceBuild,
 			OrganizationID: pd.OrganizationID,
 			Tags:           pd.Tags,
+			Priority:       database.ProvisionerJobPriorityInteractive,
 		})
 		require.NoError(t, err)
 		_, err = db.AcquireProvisionerJob(ctx, database.AcquireProvisionerJobParams{
@@ -1587,6 +1599,7 @@ func TestCompleteJob(t *testing.T) {
 			StorageMethod:  database.ProvisionerStorageMethodFile,
 			Type:           database.ProvisionerJobTypeWorkspaceBuild,
 			Tags:           pd.Tags,
+			Priority:       database.ProvisionerJobPriorityInteractive,
 		})
 		require.NoError(t, err)
 		_, err = db.AcquireProvisionerJob(ctx, database.AcquireProvisionerJobParams{
@@ -1910,6 +1923,7 @@ func TestCompleteJob(t *testing.T) {
 			StorageMethod: database.ProvisionerStorageMethodFile,
 			Input:         json.RawMessage("{}"),
 			Tags:          pd.Tags,
+			Priority:      database.ProvisionerJobPriorityInteractive,
 		})
 		require.NoError(t, err)
 		_, err = db.AcquireProvisionerJob(ctx, database.AcquireProvisionerJobParams{
@@ -2001,8 +2015,9 @@ func TestCompleteJob(t *testing.T) {
 					Transition: database.WorkspaceTransitionStart,
 				}},
 				provisionerJobParams: database.InsertProvisionerJobParams{
-					Type:  database.ProvisionerJobTypeTemplateVersionDryRun,
-					Input: json.RawMessage("{}"),
+					Type:     database.ProvisionerJobTypeTemplateVersionDryRun,
+					Input:    json.RawMessage("{}"),
+					Priority: database.ProvisionerJobPriorityInteractive,
 				},
 			},
 			{
@@ -2043,6 +2058,7 @@ func TestCompleteJob(t *testing.T) {
 					Input: must(json.Marshal(provisionerdserver.TemplateVersionImportJob{
 						TemplateVersionID: templateVersionID,
 					})),
+					Priority: database.ProvisionerJobPriorityInteractive,
 				},
 				expectedResources: []database.WorkspaceResource{{
 					Name: "something",
@@ -2125,6 +2141,7 @@ func TestCompleteJob(t *testing.T) {
 					Input: must(json.Marshal(provisionerdserver.WorkspaceProvisionJob{
 						WorkspaceBuildID: workspaceBuildID,
 					})),
+					Priority: database.ProvisionerJobPriorityInteractive,
 				},
 			},
 		}
@@ -2278,6 +2295,7 @@ func TestCompleteJob(t *testing.T) {
 					StorageMethod:  database.ProvisionerStorageMethodFile,
 					Type:           database.ProvisionerJobTypeWorkspaceBuild,
 					Tags:           pd.Tags,
+					Priority:       database.ProvisionerJobPriorityInteractive,
 				})
 				require.NoError(t, err)
 
@@ -2503,6 +2521,7 @@ func TestCompleteJob(t *testing.T) {
 						StorageMethod: database.ProvisionerStorageMethodFile,
 						Type:          database.ProvisionerJobTypeTemplateVersionImport,
 						Tags:          pd.Tags,
+						Priority:      database.ProvisionerJobPriorityInteractive,
 					})
 					require.NoError(t, err)
 
@@ -2622,6 +2641,7 @@ func TestCompleteJob(t *testing.T) {
 						StorageMethod: database.ProvisionerStorageMethodFile,
 						Type:          database.ProvisionerJobTypeWorkspaceBuild,
 						Tags:          pd.Tags,
+						Priority:      database.ProvisionerJobPriorityInteractive,
 					})
 					require.NoError(t, err)
 
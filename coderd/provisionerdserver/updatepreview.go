@@ -0,0 +1,99 @@
+package provisionerdserver
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/v2/apiversion"
+	"github.com/coder/coder/v2/coderd/database"
+	"github.com/coder/coder/v2/codersdk"
+	sdkproto "github.com/coder/coder/v2/provisionersdk/proto"
+)
+
+// ProvisionerVersionSupportsUpdatePreviews returns whether a provisioner
+// daemon of the given API version plans dry-runs against the state of a
+// workspace. Older daemons would plan them from scratch.
+func ProvisionerVersionSupportsUpdatePreviews(version string) bool {
+	major, minor, err := apiversion.Parse(version)
+	if err != nil {
+		return false
+	}
+	return major > 1 || (major == 1 && minor >= 11)
+}
+
+// ParseResourceChanges returns the resources that a Terraform JSON plan of a
+// workspace update creates, updates, replaces or deletes. Resources of the
+// Coder provider are left out, since the agent is replaced on every build.
+//
+// persistentResources are the resources of the current template version that
+// outlive a stop of the workspace. Changes to them are marked as persistent.
+func ParseResourceChanges(planJSON []byte, replacements []*sdkproto.ResourceReplacement, persistentResources []database.WorkspaceResource) ([]codersdk.WorkspaceResourceChange, error) {
+	changes := []codersdk.WorkspaceResourceChange{}
+	if len(planJSON) == 0 {
+		return changes, nil
+	}
+	var plan tfjson.Plan
+	if err := json.Unmarshal(planJSON, &plan); err != nil {
+		return nil, xerrors.Errorf("unmarshal plan: %w", err)
+	}
+
+	replacePaths := make(map[string][]string, len(replacements))
+	for _, replacement := range replacements {
+		replacePaths[replacement.GetResource()] = replacement.GetPaths()
+	}
+	persistent := make(map[string]struct{}, len(persistentResources))
+	for _, resource := range persistentResources {
+		if resource.Transition != database.WorkspaceTransitionStop {
+			continue
+		}
+		persistent[resourceKey(resource.ModulePath.String, resource.Type, resource.Name)] = struct{}{}
+	}
+
+	for _, change := range plan.ResourceChanges {
+		if change == nil || change.Change == nil {
+			continue
+		}
+		if change.Mode == tfjson.DataResourceMode || strings.HasPrefix(change.Type, "coder_") {
+			continue
+		}
+
+		var action codersdk.WorkspaceResourceChangeAction
+		switch actions := change.Change.Actions; {
+		case actions.Replace():
+			action = codersdk.WorkspaceResourceChangeActionReplace
+		case actions.Create():
+			action = codersdk.WorkspaceResourceChangeActionCreate
+		case actions.Update():
+			action = codersdk.WorkspaceResourceChangeActionUpdate
+		case actions.Delete():
+			action = codersdk.WorkspaceResourceChangeActionDelete
+		default:
+			// No-ops and reads don't change the workspace.
+			continue
+		}
+
+		_, isPersistent := persistent[resourceKey(change.ModuleAddress, change.Type, change.Name)]
+		changes = append(changes, codersdk.WorkspaceResourceChange{
+			Address:      change.Address,
+			Type:         change.Type,
+			Name:         change.Name,
+			ModulePath:   change.ModuleAddress,
+			Action:       action,
+			ReplacePaths: replacePaths[change.Address],
+			Persistent:   isPersistent,
+		})
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Address < changes[j].Address
+	})
+	return changes, nil
+}
+
+func resourceKey(modulePath, resourceType, name string) string {
+	return modulePath + "/" + resourceType + "." + name
+}
@@ -0,0 +1,99 @@
+package provisionerdserver_test
+
+import (
+	"database/sql"
+	"encoding/json"
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/coderd/database"
+	"github.com/coder/coder/v2/coderd/provisionerdserver"
+	"github.com/coder/coder/v2/codersdk"
+	sdkproto "github.com/coder/coder/v2/provisionersdk/proto"
+)
+
+func TestParseResourceChanges(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Empty", func(t *testing.T) {
+		t.Parallel()
+
+		changes, err := provisionerdserver.ParseResourceChanges(nil, nil, nil)
+		require.NoError(t, err)
+		require.Empty(t, changes)
+	})
+
+	t.Run("Changes", func(t *testing.T) {
+		t.Parallel()
+
+		change := func(address, module, typ, name string, actions ...tfjson.Action) *tfjson.ResourceChange {
+			return &tfjson.ResourceChange{
+				Address:       address,
+				ModuleAddress: module,
+				Mode:          tfjson.ManagedResourceMode,
+				Type:          typ,
+				Name:          name,
+				Change:        &tfjson.Change{Actions: actions},
+			}
+		}
+		read := change("data.coder_workspace.me", "", "coder_workspace", "me", tfjson.ActionRead)
+		read.Mode = tfjson.DataResourceMode
+		plan, err := json.Marshal(tfjson.Plan{
+			FormatVersion: "1.2",
+			ResourceChanges: []*tfjson.ResourceChange{
+				read,
+				change("coder_agent.main", "", "coder_agent", "main", tfjson.ActionDelete, tfjson.ActionCreate),
+				change("docker_volume.home", "", "docker_volume", "home", tfjson.ActionDelete, tfjson.ActionCreate),
+				change("docker_container.dev[0]", "", "docker_container", "dev", tfjson.ActionUpdate),
+				change("module.cache.docker_volume.cache", "module.cache", "docker_volume", "cache", tfjson.ActionDelete),
+				change("docker_network.dev", "", "docker_network", "dev", tfjson.ActionCreate),
+				change("docker_image.dev", "", "docker_image", "dev", tfjson.ActionNoop),
+			},
+		})
+		require.NoError(t, err)
+
+		changes, err := provisionerdserver.ParseResourceChanges(plan, []*sdkproto.ResourceReplacement{{
+			Resource: "docker_volume.home",
+			Paths:    []string{"name"},
+		}}, []database.WorkspaceResource{
+			{Transition: database.WorkspaceTransitionStop, Type: "docker_volume", Name: "home"},
+			{Transition: database.WorkspaceTransitionStop, Type: "docker_volume", Name: "cache", ModulePath: sql.NullString{String: "module.cache", Valid: true}},
+			{Transition: database.WorkspaceTransitionStart, Type: "docker_container", Name: "dev"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, []codersdk.WorkspaceResourceChange{
+			{
+				Address: "docker_container.dev[0]",
+				Type:    "docker_container",
+				Name:    "dev",
+				Action:  codersdk.WorkspaceResourceChangeActionUpdate,
+			},
+			{
+				Address: "docker_network.dev",
+				Type:    "docker_network",
+				Name:    "dev",
+				Action:  codersdk.WorkspaceResourceChangeActionCreate,
+			},
+			{
+				Address:      "docker_volume.home",
+				Type:         "docker_volume",
+				Name:         "home",
+				Action:       codersdk.WorkspaceResourceChangeActionReplace,
+				ReplacePaths: []string{"name"},
+				Persistent:   true,
+			},
+			{
+				Address:    "module.cache.docker_volume.cache",
+				Type:       "docker_volume",
+				Name:       "cache",
+				ModulePath: "module.cache",
+				Action:     codersdk.WorkspaceResourceChangeActionDelete,
+				Persistent: true,
+			},
+		}, changes)
+		require.True(t, changes[2].Destructive())
+		require.False(t, changes[0].Destructive())
+	})
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/coder/coder/v2/coderd/httpapi"
 	"github.com/coder/coder/v2/coderd/httpmw"
 	"github.com/coder/coder/v2/coderd/httpmw/loggermw"
+	"github.com/coder/coder/v2/coderd/provisionerdserver"
 	"github.com/coder/coder/v2/coderd/rbac"
 	"github.com/coder/coder/v2/coderd/rbac/policy"
 	"github.com/coder/coder/v2/coderd/util/slice"
@@ -120,11 +121,12 @@ func (api *API) handleAuthAndFetchProvisionerJobs(rw http.ResponseWriter, r *htt
 	}
 
 	jobs, err := api.Database.GetProvisionerJobsByOrganizationAndStatusWithQueuePositionAndProvisioner(ctx, database.GetProvisionerJobsByOrganizationAndStatusWithQueuePositionAndProvisionerParams{
-		OrganizationID: org.ID,
-		Status:         slice.StringEnums[database.ProvisionerJobStatus](status),
-		Limit:          sql.NullInt32{Int32: limit, Valid: limit > 0},
-		IDs:            ids,
-		Tags:           tags,
+		OrganizationID:  org.ID,
+		Status:          slice.StringEnums[database.ProvisionerJobStatus](status),
+		Limit:           sql.NullInt32{Int32: limit, Valid: limit > 0},
+		IDs:             ids,
+		Tags:            tags,
+		StaleIntervalMS: provisionerdserver.StaleInterval.Milliseconds(),
 	})
 	if err != nil {
 		if httpapi.Is404Error(err) {
@@ -353,16 +355,18 @@ func convertProvisionerJobLog(provisionerJobLog database.ProvisionerJobLog) code
 func convertProvisionerJob(pj database.GetProvisionerJobsByIDsWithQueuePositionRow) codersdk.ProvisionerJob {
 	provisionerJob := pj.ProvisionerJob
 	job := codersdk.ProvisionerJob{
-		ID:             provisionerJob.ID,
-		OrganizationID: provisionerJob.OrganizationID,
-		CreatedAt:      provisionerJob.CreatedAt,
-		Type:           codersdk.ProvisionerJobType(provisionerJob.Type),
-		Error:          provisionerJob.Error.String,
-		ErrorCode:      codersdk.JobErrorCode(provisionerJob.ErrorCode.String),
-		FileID:         provisionerJob.FileID,
-		Tags:           provisionerJob.Tags,
-		QueuePosition:  int(pj.QueuePosition),
-		QueueSize:      int(pj.QueueSize),
+		ID:                  provisionerJob.ID,
+		OrganizationID:      provisionerJob.OrganizationID,
+		CreatedAt:           provisionerJob.CreatedAt,
+		Type:                codersdk.ProvisionerJobType(provisionerJob.Type),
+		Error:               provisionerJob.Error.String,
+		ErrorCode:           codersdk.JobErrorCode(provisionerJob.ErrorCode.String),
+		FileID:              provisionerJob.FileID,
+		Tags:                provisionerJob.Tags,
+		QueuePosition:       int(pj.QueuePosition),
+		QueueSize:           int(pj.QueueSize),
+		Priority:            codersdk.ProvisionerJobPriority(provisionerJob.Priority),
+		EstimatedWaitMillis: pj.EstimatedWaitMs,
 	}
 	// Applying values optional to the struct.
 	if provisionerJob.StartedAt.Valid {
@@ -391,9 +395,10 @@ func convertProvisionerJob(pj database.GetProvisionerJobsByIDsWithQueuePositionR
 
 func convertProvisionerJobWithQueuePosition(pj database.GetProvisionerJobsByOrganizationAndStatusWithQueuePositionAndProvisionerRow) codersdk.ProvisionerJob {
 	job := convertProvisionerJob(database.GetProvisionerJobsByIDsWithQueuePositionRow{
-		ProvisionerJob: pj.ProvisionerJob,
-		QueuePosition:  pj.QueuePosition,
-		QueueSize:      pj.QueueSize,
+		ProvisionerJob:  pj.ProvisionerJob,
+		QueuePosition:   pj.QueuePosition,
+		QueueSize:       pj.QueueSize,
+		EstimatedWaitMs: pj.EstimatedWaitMs,
 	})
 	job.WorkerName = pj.WorkerName
 	job.AvailableWorkers = pj.AvailableWorkers
@@ -0,0 +1,66 @@
+// Package provisionerqueue reports the autoscaling signals of provisioner
+// daemons by set of tags: the depth of their queue, how long jobs wait for
+// them, and how busy they are.
+package provisionerqueue
+
+import (
+	"context"
+	"maps"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/v2/coderd/database"
+	"github.com/coder/coder/v2/coderd/database/db2sdk"
+	"github.com/coder/coder/v2/coderd/database/dbtime"
+	"github.com/coder/coder/v2/coderd/provisionerdserver"
+	"github.com/coder/coder/v2/codersdk"
+	"github.com/coder/coder/v2/provisionersdk"
+)
+
+// WaitWindow is how far back the wait time of started jobs is measured.
+const WaitWindow = time.Hour
+
+// Stats returns the stats of the provisioner daemons of an organization by
+// set of tags, or of all organizations if the organization ID is nil. The
+// stats of the tags are included, if any, even when no daemon with them is
+// online.
+func Stats(ctx context.Context, db database.Store, organizationID uuid.UUID, tags map[string]string) ([]codersdk.ProvisionerQueueStats, error) {
+	var tagset database.StringMap
+	if len(tags) > 0 {
+		tagset = Tags(tags)
+	}
+	rows, err := db.GetProvisionerQueueStats(ctx, database.GetProvisionerQueueStatsParams{
+		StaleIntervalMS: provisionerdserver.StaleInterval.Milliseconds(),
+		OrganizationID:  organizationID,
+		StartedAfter:    dbtime.Now().Add(-WaitWindow),
+		Tags:            tagset,
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("get provisioner queue stats: %w", err)
+	}
+	return db2sdk.List(rows, db2sdk.ProvisionerQueueStats), nil
+}
+
+// StatsOfTags returns the stats of the provisioner daemons of an
+// organization with the tags.
+func StatsOfTags(ctx context.Context, db database.Store, organizationID uuid.UUID, tags map[string]string) (codersdk.ProvisionerQueueStats, error) {
+	tags = Tags(tags)
+	stats, err := Stats(ctx, db, organizationID, tags)
+	if err != nil {
+		return codersdk.ProvisionerQueueStats{}, err
+	}
+	for _, s := range stats {
+		if maps.Equal(s.Tags, tags) {
+			return s, nil
+		}
+	}
+	return codersdk.ProvisionerQueueStats{}, xerrors.Errorf("no provisioner queue stats of tags %s", codersdk.ProvisionerKeyTags(tags))
+}
+
+// Tags returns the tags of provisioner daemons started with the tags, which
+// are organization scoped unless the scope is set.
+func Tags(tags map[string]string) map[string]string {
+	return provisionersdk.MutateTags(uuid.Nil, tags)
+}
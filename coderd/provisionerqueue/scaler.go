@@ -0,0 +1,198 @@
+package provisionerqueue
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"cdr.dev/slog"
+
+	"github.com/coder/coder/v2/coderd/database"
+	"github.com/coder/coder/v2/coderd/database/dbauthz"
+	"github.com/coder/coder/v2/coderd/provisionerqueue/externalscaler"
+	"github.com/coder/coder/v2/codersdk"
+	"github.com/coder/quartz"
+)
+
+const (
+	// MetricName is the name of the metric of the external scaler, the
+	// number of jobs for the daemons with the tags of the scaled object.
+	MetricName = "coder_provisioner_jobs"
+
+	// MetadataOrganization is the name or ID of the organization of the
+	// daemons. Defaults to the default organization.
+	MetadataOrganization = "organization"
+	// MetadataTags is a JSON object of the tags of the daemons, as passed
+	// to "coder provisioner start".
+	MetadataTags = "tags"
+	// MetadataJobsPerDaemon is the number of jobs per daemon to scale for.
+	// Defaults to 1, as a daemon runs a single job at a time.
+	MetadataJobsPerDaemon = "jobsPerDaemon"
+
+	// PollInterval is how often StreamIsActive checks whether the daemons
+	// should be active.
+	PollInterval = 5 * time.Second
+)
+
+// Scaler is a KEDA external scaler, which scales the provisioner daemons
+// with a set of tags to the number of pending and running jobs they can run.
+// See: https://keda.sh/docs/latest/concepts/external-scalers/
+type Scaler struct {
+	externalscaler.UnimplementedExternalScalerServer
+
+	db     database.Store
+	logger slog.Logger
+	clock  quartz.Clock
+}
+
+func NewScaler(logger slog.Logger, db database.Store, clk quartz.Clock) *Scaler {
+	return &Scaler{
+		db:     db,
+		logger: logger,
+		clock:  clk,
+	}
+}
+
+// Serve serves the external scaler over gRPC on the listener until the
+// context is canceled.
+func (s *Scaler) Serve(ctx context.Context, listener net.Listener) error {
+	server := grpc.NewServer()
+	externalscaler.RegisterExternalScalerServer(server, s)
+	go func() {
+		<-ctx.Done()
+		server.GracefulStop()
+	}()
+	err := server.Serve(listener)
+	if err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		return xerrors.Errorf("serve external scaler: %w", err)
+	}
+	return nil
+}
+
+func (s *Scaler) IsActive(ctx context.Context, ref *externalscaler.ScaledObjectRef) (*externalscaler.IsActiveResponse, error) {
+	stats, err := s.stats(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	return &externalscaler.IsActiveResponse{Result: stats.DesiredDaemons() > 0}, nil
+}
+
+func (s *Scaler) StreamIsActive(ref *externalscaler.ScaledObjectRef, stream externalscaler.ExternalScaler_StreamIsActiveServer) error {
+	ctx := stream.Context()
+	ticker := s.clock.NewTicker(PollInterval, "Scaler", "StreamIsActive")
+	defer ticker.Stop()
+	var active *bool
+	for {
+		stats, err := s.stats(ctx, ref)
+		if err != nil {
+			return err
+		}
+		// Only changes are pushed, KEDA polls the metrics of active
+		// objects itself.
+		if result := stats.DesiredDaemons() > 0; active == nil || *active != result {
+			err = stream.Send(&externalscaler.IsActiveResponse{Result: result})
+			if err != nil {
+				return err
+			}
+			active = &result
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scaler) GetMetricSpec(_ context.Context, ref *externalscaler.ScaledObjectRef) (*externalscaler.GetMetricSpecResponse, error) {
+	target, err := jobsPerDaemon(ref)
+	if err != nil {
+		return nil, err
+	}
+	return &externalscaler.GetMetricSpecResponse{
+		MetricSpecs: []*externalscaler.MetricSpec{{
+			MetricName: MetricName,
+			TargetSize: target,
+		}},
+	}, nil
+}
+
+func (s *Scaler) GetMetrics(ctx context.Context, req *externalscaler.GetMetricsRequest) (*externalscaler.GetMetricsResponse, error) {
+	stats, err := s.stats(ctx, req.GetScaledObjectRef())
+	if err != nil {
+		return nil, err
+	}
+	return &externalscaler.GetMetricsResponse{
+		MetricValues: []*externalscaler.MetricValue{{
+			MetricName:  MetricName,
+			MetricValue: stats.DesiredDaemons(),
+		}},
+	}, nil
+}
+
+// stats returns the stats of the daemons of the scaled object.
+func (s *Scaler) stats(ctx context.Context, ref *externalscaler.ScaledObjectRef) (codersdk.ProvisionerQueueStats, error) {
+	metadata := ref.GetScalerMetadata()
+	var tags map[string]string
+	if raw := metadata[MetadataTags]; raw != "" {
+		err := json.Unmarshal([]byte(raw), &tags)
+		if err != nil {
+			return codersdk.ProvisionerQueueStats{}, status.Errorf(codes.InvalidArgument, "%q must be a JSON object of strings: %s", MetadataTags, err)
+		}
+	}
+
+	//nolint:gocritic // The scaler only reads the stats of the queue.
+	ctx = dbauthz.AsSystemRestricted(ctx)
+	organization, err := s.organization(ctx, metadata[MetadataOrganization])
+	if errors.Is(err, sql.ErrNoRows) {
+		return codersdk.ProvisionerQueueStats{}, status.Errorf(codes.NotFound, "organization %q not found", metadata[MetadataOrganization])
+	}
+	if err != nil {
+		s.logger.Error(ctx, "get organization of scaled object", slog.F("name", ref.GetName()), slog.Error(err))
+		return codersdk.ProvisionerQueueStats{}, status.Error(codes.Internal, err.Error())
+	}
+	stats, err := StatsOfTags(ctx, s.db, organization.ID, tags)
+	if err != nil {
+		s.logger.Error(ctx, "get provisioner queue stats of scaled object", slog.F("name", ref.GetName()), slog.Error(err))
+		return codersdk.ProvisionerQueueStats{}, status.Error(codes.Internal, err.Error())
+	}
+	return stats, nil
+}
+
+// organization returns the organization by name or ID, like the
+// organization path parameter of the API.
+func (s *Scaler) organization(ctx context.Context, nameOrID string) (database.Organization, error) {
+	if nameOrID == "" || nameOrID == codersdk.DefaultOrganization {
+		return s.db.GetDefaultOrganization(ctx)
+	}
+	id, err := uuid.Parse(nameOrID)
+	if err == nil {
+		return s.db.GetOrganizationByID(ctx, id)
+	}
+	return s.db.GetOrganizationByName(ctx, database.GetOrganizationByNameParams{
+		Name:    nameOrID,
+		Deleted: false,
+	})
+}
+
+func jobsPerDaemon(ref *externalscaler.ScaledObjectRef) (int64, error) {
+	raw := ref.GetScalerMetadata()[MetadataJobsPerDaemon]
+	if raw == "" {
+		return 1, nil
+	}
+	jobs, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || jobs < 1 {
+		return 0, status.Errorf(codes.InvalidArgument, "%q must be a positive integer", MetadataJobsPerDaemon)
+	}
+	return jobs, nil
+}
@@ -0,0 +1,317 @@
+package provisionerqueue_test
+
+import (
+	"context"
+	"database/sql"
+	"net"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"cdr.dev/slog"
+	"github.com/coder/coder/v2/coderd/coderdtest"
+	"github.com/coder/coder/v2/coderd/database"
+	"github.com/coder/coder/v2/coderd/database/dbauthz"
+	"github.com/coder/coder/v2/coderd/database/dbgen"
+	"github.com/coder/coder/v2/coderd/database/dbtestutil"
+	"github.com/coder/coder/v2/coderd/database/dbtime"
+	"github.com/coder/coder/v2/coderd/provisionerqueue"
+	"github.com/coder/coder/v2/coderd/provisionerqueue/externalscaler"
+	"github.com/coder/coder/v2/coderd/rbac"
+	"github.com/coder/coder/v2/testutil"
+	"github.com/coder/quartz"
+)
+
+func TestMain(m *testing.M) {
+	goleak.VerifyTestMain(m, testutil.GoleakOptions...)
+}
+
+func TestScalerMetadata(t *testing.T) {
+	t.Parallel()
+
+	db, _ := dbtestutil.NewDB(t)
+	logger := testutil.Logger(t)
+	client := setupScaler(t, wrapDBAuthz(db, logger), quartz.NewReal())
+	org := dbgen.Organization(t, db, database.Organization{})
+
+	for _, tc := range []struct {
+		name     string
+		metadata map[string]string
+		// statsCode is the code of the calls that read the stats of the
+		// daemons, which depend on the organization and tags.
+		statsCode codes.Code
+		// specCode is the code of the metric spec, which depends on the
+		// jobs per daemon.
+		specCode codes.Code
+		target   int64
+	}{
+		{
+			name:     "Defaults",
+			metadata: map[string]string{},
+			target:   1,
+		},
+		{
+			name: "OrganizationByName",
+			metadata: map[string]string{
+				provisionerqueue.MetadataOrganization:  org.Name,
+				provisionerqueue.MetadataTags:          `{"kind": "k8s"}`,
+				provisionerqueue.MetadataJobsPerDaemon: "4",
+			},
+			target: 4,
+		},
+		{
+			name: "OrganizationByID",
+			metadata: map[string]string{
+				provisionerqueue.MetadataOrganization: org.ID.String(),
+			},
+			target: 1,
+		},
+		{
+			name: "UnknownOrganization",
+			metadata: map[string]string{
+				provisionerqueue.MetadataOrganization: "does-not-exist",
+			},
+			statsCode: codes.NotFound,
+			target:    1,
+		},
+		{
+			name: "TagsNotJSON",
+			metadata: map[string]string{
+				provisionerqueue.MetadataTags: "kind=k8s",
+			},
+			statsCode: codes.InvalidArgument,
+			target:    1,
+		},
+		{
+			name: "TagsNotStrings",
+			metadata: map[string]string{
+				provisionerqueue.MetadataTags: `{"replicas": 3}`,
+			},
+			statsCode: codes.InvalidArgument,
+			target:    1,
+		},
+		{
+			name: "JobsPerDaemonNotInteger",
+			metadata: map[string]string{
+				provisionerqueue.MetadataJobsPerDaemon: "many",
+			},
+			specCode: codes.InvalidArgument,
+		},
+		{
+			name: "JobsPerDaemonZero",
+			metadata: map[string]string{
+				provisionerqueue.MetadataJobsPerDaemon: "0",
+			},
+			specCode: codes.InvalidArgument,
+		},
+		{
+			name: "JobsPerDaemonNegative",
+			metadata: map[string]string{
+				provisionerqueue.MetadataJobsPerDaemon: "-1",
+			},
+			specCode: codes.InvalidArgument,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ctx := testutil.Context(t, testutil.WaitMedium)
+			ref := &externalscaler.ScaledObjectRef{
+				Name:           "coder-provisioner",
+				Namespace:      "coder",
+				ScalerMetadata: tc.metadata,
+			}
+
+			_, err := client.IsActive(ctx, ref)
+			require.Equal(t, tc.statsCode, status.Code(err), err)
+			_, err = client.GetMetrics(ctx, &externalscaler.GetMetricsRequest{
+				ScaledObjectRef: ref,
+				MetricName:      provisionerqueue.MetricName,
+			})
+			require.Equal(t, tc.statsCode, status.Code(err), err)
+
+			spec, err := client.GetMetricSpec(ctx, ref)
+			require.Equal(t, tc.specCode, status.Code(err), err)
+			if tc.specCode != codes.OK {
+				return
+			}
+			require.Len(t, spec.MetricSpecs, 1)
+			require.Equal(t, provisionerqueue.MetricName, spec.MetricSpecs[0].MetricName)
+			require.Equal(t, tc.target, spec.MetricSpecs[0].TargetSize)
+		})
+	}
+}
+
+func TestScalerScaledToZero(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Context(t, testutil.WaitLong)
+	db, _ := dbtestutil.NewDB(t)
+	logger := testutil.Logger(t)
+	client := setupScaler(t, wrapDBAuthz(db, logger), quartz.NewReal())
+	ref := &externalscaler.ScaledObjectRef{
+		Name:      "coder-provisioner",
+		Namespace: "coder",
+		ScalerMetadata: map[string]string{
+			provisionerqueue.MetadataTags: `{"kind": "k8s"}`,
+		},
+	}
+	requireJobs := func(t *testing.T, jobs int64) {
+		t.Helper()
+		active, err := client.IsActive(ctx, ref)
+		require.NoError(t, err)
+		require.Equal(t, jobs > 0, active.Result)
+		metrics, err := client.GetMetrics(ctx, &externalscaler.GetMetricsRequest{
+			ScaledObjectRef: ref,
+			MetricName:      provisionerqueue.MetricName,
+		})
+		require.NoError(t, err)
+		require.Len(t, metrics.MetricValues, 1)
+		require.Equal(t, provisionerqueue.MetricName, metrics.MetricValues[0].MetricName)
+		require.Equal(t, jobs, metrics.MetricValues[0].MetricValue)
+	}
+
+	// No daemon with the tags is online, which must not fail the scaler
+	// while the deployment is scaled to zero.
+	requireJobs(t, 0)
+
+	// Jobs that the daemons can't acquire don't scale them up.
+	dbgen.ProvisionerJob(t, db, nil, database.ProvisionerJob{
+		Tags: database.StringMap{"owner": "", "scope": "organization", "kind": "docker"},
+	})
+	requireJobs(t, 0)
+
+	// Both jobs with the tags of the daemons and jobs with a subset of
+	// them do.
+	dbgen.ProvisionerJob(t, db, nil, database.ProvisionerJob{
+		Tags: database.StringMap{"owner": "", "scope": "organization", "kind": "k8s"},
+	})
+	dbgen.ProvisionerJob(t, db, nil, database.ProvisionerJob{
+		Tags: database.StringMap{"owner": "", "scope": "organization"},
+	})
+	requireJobs(t, 2)
+}
+
+func TestScalerStreamIsActive(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Context(t, testutil.WaitLong)
+	db, _ := dbtestutil.NewDB(t)
+	logger := testutil.Logger(t)
+	mClock := quartz.NewMock(t)
+	trap := mClock.Trap().NewTicker("Scaler", "StreamIsActive")
+	defer trap.Close()
+	store := &statsSignaller{Store: wrapDBAuthz(db, logger), read: make(chan struct{})}
+	client := setupScaler(t, store, mClock)
+
+	stream, err := client.StreamIsActive(ctx, &externalscaler.ScaledObjectRef{
+		Name:      "coder-provisioner",
+		Namespace: "coder",
+		ScalerMetadata: map[string]string{
+			provisionerqueue.MetadataTags: `{"kind": "k8s"}`,
+		},
+	})
+	require.NoError(t, err)
+	trap.MustWait(ctx).MustRelease(ctx)
+
+	// poll waits for the scaler to read the stats on the next tick.
+	poll := func() {
+		mClock.Advance(provisionerqueue.PollInterval).MustWait(ctx)
+		testutil.RequireReceive(ctx, t, store.read)
+	}
+	requireActive := func(want bool) {
+		t.Helper()
+		resp, err := stream.Recv()
+		require.NoError(t, err)
+		require.Equal(t, want, resp.Result)
+	}
+
+	// The initial state is always pushed.
+	testutil.RequireReceive(ctx, t, store.read)
+	requireActive(false)
+
+	// Polls that don't change the state push nothing, so the next
+	// response is the change to active.
+	poll()
+	poll()
+	job := dbgen.ProvisionerJob(t, db, nil, database.ProvisionerJob{
+		Tags: database.StringMap{"owner": "", "scope": "organization", "kind": "k8s"},
+	})
+	poll()
+	requireActive(true)
+
+	poll()
+	err = db.UpdateProvisionerJobWithCancelByID(ctx, database.UpdateProvisionerJobWithCancelByIDParams{
+		ID:          job.ID,
+		CanceledAt:  sql.NullTime{Time: dbtime.Now(), Valid: true},
+		CompletedAt: sql.NullTime{Time: dbtime.Now(), Valid: true},
+	})
+	require.NoError(t, err)
+	poll()
+	requireActive(false)
+}
+
+// setupScaler serves a scaler on an in-memory listener and returns a gRPC
+// client of it.
+func setupScaler(t *testing.T, db database.Store, clock quartz.Clock) externalscaler.ExternalScalerClient {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	listener := bufconn.Listen(1 << 20)
+	scaler := provisionerqueue.NewScaler(testutil.Logger(t), db, clock)
+	served := make(chan error, 1)
+	go func() {
+		served <- scaler.Serve(ctx, listener)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		assert.NoError(t, <-served)
+	})
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+	return externalscaler.NewExternalScalerClient(conn)
+}
+
+// wrapDBAuthz adds our Authorization/RBAC around the given database store, to
+// ensure the scaler has the right permissions to read the queue.
+func wrapDBAuthz(db database.Store, logger slog.Logger) database.Store {
+	return dbauthz.New(
+		db,
+		rbac.NewStrictCachingAuthorizer(prometheus.NewRegistry()),
+		logger,
+		coderdtest.AccessControlStorePointer(),
+	)
+}
+
+// statsSignaller signals every time the scaler reads the stats of the
+// queue.
+type statsSignaller struct {
+	database.Store
+
+	read chan struct{}
+}
+
+func (s *statsSignaller) GetProvisionerQueueStats(ctx context.Context, arg database.GetProvisionerQueueStatsParams) ([]database.GetProvisionerQueueStatsRow, error) {
+	rows, err := s.Store.GetProvisionerQueueStats(ctx, arg)
+	select {
+	case s.read <- struct{}{}:
+	case <-ctx.Done():
+	}
+	return rows, err
+}
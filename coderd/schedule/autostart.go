@@ -27,8 +27,12 @@ func NextAutostart(at time.Time, wsSchedule string, templateSchedule TemplateSch
 	// forbidden day, do not allow the auto start. We use the time location of the
 	// schedule to determine the weekday. So if "Saturday" is disallowed, the
 	// definition of "Saturday" depends on the location of the schedule.
+	// Holidays are matched against the date in the same location.
 	zonedTransition := nextTransition.In(sched.Location())
 	allowed := templateSchedule.AutostartRequirement.DaysMap()[zonedTransition.Weekday()]
+	if _, holiday := templateSchedule.Holidays.On(zonedTransition); holiday {
+		allowed = false
+	}
 
 	return zonedTransition, allowed
 }
@@ -38,7 +42,9 @@ func NextAllowedAutostart(at time.Time, wsSchedule string, templateSchedule Temp
 
 	// Our cron schedules work on a weekly basis, so to ensure we've exhausted all
 	// possible autostart times we need to check up to 7 days worth of autostarts.
-	for next.Sub(at) < 7*24*time.Hour {
+	// Every holiday can push the next allowed autostart out by up to a week.
+	limit := time.Duration(len(templateSchedule.Holidays)+1) * 7 * 24 * time.Hour
+	for next.Sub(at) < limit {
 		var valid bool
 		next, valid = NextAutostart(next, wsSchedule, templateSchedule)
 		if valid {
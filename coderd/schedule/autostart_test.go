@@ -38,4 +38,31 @@ func TestNextAllowedAutostart(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, time.Date(2024, time.January, 8, 9, 0, 0, 0, time.UTC), next)
 	})
+	t.Run("SkipsHolidays", func(t *testing.T) {
+		t.Parallel()
+
+		// 1st January 2024 is a Monday
+		at := time.Date(2024, time.January, 1, 10, 0, 0, 0, time.UTC)
+		//  Monday-Friday 9:00AM in New York
+		sched := "CRON_TZ=America/New_York 00 09 * * 1-5"
+		opts := schedule.TemplateScheduleOptions{
+			AutostartRequirement: schedule.TemplateAutostartRequirement{
+				DaysOfWeek: 0b01111111,
+			},
+			Holidays: schedule.Holidays{
+				"2024-01-02": "Day after New Year's Day",
+				"2024-01-03": "Another day off",
+			},
+		}
+		newYork, err := time.LoadLocation("America/New_York")
+		require.NoError(t, err)
+
+		next, allowed := schedule.NextAutostart(at, sched, opts)
+		require.False(t, allowed)
+		require.Equal(t, time.Date(2024, time.January, 2, 9, 0, 0, 0, newYork), next)
+
+		next, err = schedule.NextAllowedAutostart(at, sched, opts)
+		require.NoError(t, err)
+		require.Equal(t, time.Date(2024, time.January, 4, 9, 0, 0, 0, newYork), next)
+	})
 }
@@ -232,6 +232,37 @@ func CalculateAutostop(ctx context.Context, params CalculateAutostopParams) (Aut
 		}
 	}
 
+	// Autostop windows stop the workspace at a time of day which can differ per
+	// day of the week, e.g. at the end of working hours. The user's windows
+	// replace the template's if the template allows users to set their own
+	// autostop.
+	if len(templateSchedule.AutostopWindows) > 0 || templateSchedule.UserAutostopEnabled {
+		userQuietHoursSchedule, err := params.UserQuietHoursScheduleStore.Get(ctx, db, workspace.OwnerID)
+		if err != nil {
+			return autostop, xerrors.Errorf("get user quiet hours schedule options: %w", err)
+		}
+
+		windows := templateSchedule.AutostopWindows
+		if templateSchedule.UserAutostopEnabled && len(userQuietHoursSchedule.AutostopWindows) > 0 {
+			windows = userQuietHoursSchedule.AutostopWindows
+		}
+		if len(windows) > 0 {
+			// Windows are in the timezone of the user's quiet hours schedule,
+			// as it's the only timezone we know of the user.
+			loc := time.UTC
+			if userQuietHoursSchedule.Schedule != nil {
+				loc = userQuietHoursSchedule.Schedule.Location()
+			}
+
+			// Skip windows which are too close to now, the workspace is
+			// stopped at the window after instead.
+			stop := windows.NextStop(now.In(loc).Add(autostopWindowLeeway))
+			if !stop.IsZero() && (autostop.MaxDeadline.IsZero() || stop.Before(autostop.MaxDeadline)) {
+				autostop.MaxDeadline = stop
+			}
+		}
+	}
+
 	// If the workspace doesn't have a deadline or the max deadline is sooner
 	// than the workspace deadline, use the max deadline as the actual deadline.
 	if !autostop.MaxDeadline.IsZero() && (autostop.Deadline.IsZero() || autostop.MaxDeadline.Before(autostop.Deadline)) {
@@ -86,6 +86,8 @@ func TestCalculateAutoStop(t *testing.T) {
 		templateDefaultTTL          time.Duration
 		templateAutostopRequirement schedule.TemplateAutostopRequirement
 		userQuietHoursSchedule      string
+		templateAutostopWindows     schedule.AutostopWindows
+		userAutostopWindows         schedule.AutostopWindows
 		// workspaceTTL is usually copied from the template's TTL when the
 		// workspace is made, so it takes precedence unless
 		// templateAllowAutostop is false.
@@ -483,6 +485,86 @@ func TestCalculateAutoStop(t *testing.T) {
 			expectedMaxDeadline: time.Date(pastDateNight.Year(), pastDateNight.Month(), pastDateNight.Day()+1, 11, 0, 0, 0, chicago),
 			errContains:         "",
 		},
+		{
+			name:                  "AutostopWindow",
+			now:                   wednesdayMidnightUTC.Add(9 * time.Hour),
+			templateAllowAutostop: false,
+			templateAutostopWindows: schedule.AutostopWindows{
+				time.Wednesday: 18 * time.Hour,
+				time.Friday:    14 * time.Hour,
+			},
+			expectedMaxDeadline: wednesdayMidnightUTC.Add(18 * time.Hour),
+		},
+		{
+			name:                  "AutostopWindowWithTTL",
+			now:                   wednesdayMidnightUTC.Add(9 * time.Hour),
+			templateAllowAutostop: true,
+			workspaceTTL:          2 * time.Hour,
+			templateAutostopWindows: schedule.AutostopWindows{
+				time.Wednesday: 18 * time.Hour,
+			},
+			expectedDeadline:    wednesdayMidnightUTC.Add(11 * time.Hour),
+			expectedMaxDeadline: wednesdayMidnightUTC.Add(18 * time.Hour),
+		},
+		{
+			// Thursday has no window, so the next one is on Friday.
+			name:                  "AutostopWindowPassed",
+			now:                   wednesdayMidnightUTC.Add(19 * time.Hour),
+			templateAllowAutostop: false,
+			templateAutostopWindows: schedule.AutostopWindows{
+				time.Wednesday: 18 * time.Hour,
+				time.Friday:    14 * time.Hour,
+			},
+			expectedMaxDeadline: wednesdayMidnightUTC.AddDate(0, 0, 2).Add(14 * time.Hour),
+		},
+		{
+			// Started too close to today's window, so the next one is used.
+			name:                  "AutostopWindowLeeway",
+			now:                   wednesdayMidnightUTC.Add(17*time.Hour + 45*time.Minute),
+			templateAllowAutostop: false,
+			templateAutostopWindows: schedule.AutostopWindows{
+				time.Wednesday: 18 * time.Hour,
+				time.Friday:    14 * time.Hour,
+			},
+			expectedMaxDeadline: wednesdayMidnightUTC.AddDate(0, 0, 2).Add(14 * time.Hour),
+		},
+		{
+			name:                  "AutostopWindowUser",
+			now:                   wednesdayMidnightUTC.Add(9 * time.Hour),
+			templateAllowAutostop: true,
+			templateAutostopWindows: schedule.AutostopWindows{
+				time.Wednesday: 18 * time.Hour,
+			},
+			userAutostopWindows: schedule.AutostopWindows{
+				time.Wednesday: 16 * time.Hour,
+			},
+			expectedMaxDeadline: wednesdayMidnightUTC.Add(16 * time.Hour),
+		},
+		{
+			// The template doesn't allow users to set their own autostop, so
+			// the user's windows are ignored.
+			name:                  "AutostopWindowUserNotAllowed",
+			now:                   wednesdayMidnightUTC.Add(9 * time.Hour),
+			templateAllowAutostop: false,
+			templateAutostopWindows: schedule.AutostopWindows{
+				time.Wednesday: 18 * time.Hour,
+			},
+			userAutostopWindows: schedule.AutostopWindows{
+				time.Wednesday: 16 * time.Hour,
+			},
+			expectedMaxDeadline: wednesdayMidnightUTC.Add(18 * time.Hour),
+		},
+		{
+			// Windows are in the timezone of the user's quiet hours schedule.
+			name:                   "AutostopWindowQuietHoursTimezone",
+			now:                    fridayEveningSydney.Add(-12 * time.Hour),
+			templateAllowAutostop:  false,
+			userQuietHoursSchedule: sydneyQuietHours,
+			templateAutostopWindows: schedule.AutostopWindows{
+				time.Friday: 14 * time.Hour,
+			},
+			expectedMaxDeadline: fridayEveningSydney.Add(-8 * time.Hour),
+		},
 	}
 
 	for _, c := range cases {
@@ -500,6 +582,7 @@ func TestCalculateAutoStop(t *testing.T) {
 						DefaultTTL:           c.templateDefaultTTL,
 						AutostopRequirement:  c.templateAutostopRequirement,
 						AutostartRequirement: c.templateAutoStart,
+						AutostopWindows:      c.templateAutostopWindows,
 					}, nil
 				},
 			}
@@ -508,7 +591,8 @@ func TestCalculateAutoStop(t *testing.T) {
 				GetFn: func(_ context.Context, _ database.Store, _ uuid.UUID) (schedule.UserQuietHoursScheduleOptions, error) {
 					if c.userQuietHoursSchedule == "" {
 						return schedule.UserQuietHoursScheduleOptions{
-							Schedule: nil,
+							Schedule:        nil,
+							AutostopWindows: c.userAutostopWindows,
 						}, nil
 					}
 
@@ -518,8 +602,9 @@ func TestCalculateAutoStop(t *testing.T) {
 					}
 
 					return schedule.UserQuietHoursScheduleOptions{
-						Schedule: sched,
-						UserSet:  false,
+						Schedule:        sched,
+						UserSet:         false,
+						AutostopWindows: c.userAutostopWindows,
 					}, nil
 				},
 			}
@@ -0,0 +1,82 @@
+package schedule
+
+import (
+	"sort"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// autostopWindowLeeway is the duration of time before an autostop window where
+// we skip the window and fall back to the next one. This avoids workspaces
+// started just before the end of the working day being stopped right away.
+const autostopWindowLeeway = 30 * time.Minute
+
+// AutostopWindows maps days of the week to the time of day workspaces must be
+// stopped at, as an offset from midnight. Workspaces aren't stopped on days
+// without a window.
+//
+// E.g. {Monday: 18h, Friday: 14h} stops workspaces at 18:00 on Mondays and at
+// 14:00 on Fridays.
+type AutostopWindows map[time.Weekday]time.Duration
+
+// Verify returns an error if a window is not a whole minute within its day.
+func (w AutostopWindows) Verify() error {
+	for day, stop := range w {
+		if day < time.Sunday || day > time.Saturday {
+			return xerrors.Errorf("invalid autostop window day of the week %d", day)
+		}
+		if stop < 0 || stop >= 24*time.Hour {
+			return xerrors.Errorf("autostop window of %s must be within the day, got %s", day, stop)
+		}
+		if stop%time.Minute != 0 {
+			return xerrors.Errorf("autostop window of %s must be a whole minute, got %s", day, stop)
+		}
+	}
+	return nil
+}
+
+// Columns returns the days of the week and the minutes after midnight of the
+// windows, sorted by day, as they are stored in the database.
+func (w AutostopWindows) Columns() (weekdays []int16, stopMinutes []int32) {
+	days := make([]time.Weekday, 0, len(w))
+	for day := range w {
+		days = append(days, day)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i] < days[j] })
+
+	weekdays = make([]int16, 0, len(days))
+	stopMinutes = make([]int32, 0, len(days))
+	for _, day := range days {
+		// #nosec G115 - Safe conversion, days of the week range from 0-6
+		weekdays = append(weekdays, int16(day))
+		// #nosec G115 - Safe conversion, windows are within a day
+		stopMinutes = append(stopMinutes, int32(w[day]/time.Minute))
+	}
+	return weekdays, stopMinutes
+}
+
+// NextStop returns the first window after t, in the location of t. The zero
+// time is returned if there are no windows.
+func (w AutostopWindows) NextStop(t time.Time) time.Time {
+	if len(w) == 0 {
+		return time.Time{}
+	}
+
+	day := truncateMidnight(t)
+	// Check today and every day of the next week, as today's window may have
+	// passed already.
+	for i := 0; i <= len(DaysOfWeek); i++ {
+		if stop, ok := w[day.Weekday()]; ok {
+			// Use time.Date rather than adding the offset to midnight, so the
+			// window is at the same wall clock time on daylight savings days.
+			yy, mm, dd := day.Date()
+			next := time.Date(yy, mm, dd, int(stop/time.Hour), int(stop%time.Hour/time.Minute), 0, 0, t.Location())
+			if next.After(t) {
+				return next
+			}
+		}
+		day = nextDayMidnight(day)
+	}
+	return time.Time{}
+}
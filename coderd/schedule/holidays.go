@@ -0,0 +1,257 @@
+package schedule
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// maxHolidayDays bounds the days a single calendar event can span, so a
+// mistyped end date doesn't block autostart for years.
+const maxHolidayDays = 31
+
+// Holidays are the dates workspaces are not autostarted on, keyed by the date
+// formatted as time.DateOnly, with the name of the holiday.
+type Holidays map[string]string
+
+// On returns the name of the holiday on the date of t, in the location of t.
+func (h Holidays) On(t time.Time) (string, bool) {
+	name, ok := h[t.Format(time.DateOnly)]
+	return name, ok
+}
+
+// Holiday is a day imported from a calendar.
+type Holiday struct {
+	// Date is midnight UTC of the day of the holiday.
+	Date time.Time
+	Name string
+}
+
+// ParseICalendar returns the holidays of the events of an iCalendar (RFC 5545)
+// file. Each day an event spans is a holiday. Events which recur yearly are
+// repeated until the given time, other recurrences are not supported.
+//
+// The dates are taken as written in the file, regardless of their timezone.
+func ParseICalendar(r io.Reader, until time.Time) ([]Holiday, error) {
+	lines, err := unfoldICalendarLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		holidays []Holiday
+		event    map[string]icalProperty
+	)
+	for i, line := range lines {
+		prop, err := parseICalendarProperty(line)
+		if err != nil {
+			return nil, xerrors.Errorf("line %d: %w", i+1, err)
+		}
+		switch {
+		case prop.name == "BEGIN" && strings.EqualFold(prop.value, "VEVENT"):
+			event = map[string]icalProperty{}
+		case prop.name == "END" && strings.EqualFold(prop.value, "VEVENT"):
+			if event == nil {
+				return nil, xerrors.Errorf("line %d: END:VEVENT without BEGIN:VEVENT", i+1)
+			}
+			days, err := icalEventHolidays(event, until)
+			if err != nil {
+				return nil, xerrors.Errorf("event ending on line %d: %w", i+1, err)
+			}
+			holidays = append(holidays, days...)
+			event = nil
+		case event != nil:
+			// Only the first occurrence of a property is used, nested
+			// components such as alarms come after the event's own
+			// properties.
+			if _, ok := event[prop.name]; !ok {
+				event[prop.name] = prop
+			}
+		}
+	}
+	if event != nil {
+		return nil, xerrors.New("unterminated VEVENT")
+	}
+	return holidays, nil
+}
+
+type icalProperty struct {
+	name  string
+	value string
+}
+
+// unfoldICalendarLines joins lines continued with a leading space or tab.
+func unfoldICalendarLines(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(line) > 0 && (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, xerrors.Errorf("read calendar: %w", err)
+	}
+	return lines, nil
+}
+
+// parseICalendarProperty parses a NAME;PARAM=VALUE:VALUE content line.
+func parseICalendarProperty(line string) (icalProperty, error) {
+	// The value starts at the first colon which isn't in a quoted parameter
+	// value.
+	quoted := false
+	sep := -1
+	for i, c := range line {
+		if c == '"' {
+			quoted = !quoted
+		}
+		if c == ':' && !quoted {
+			sep = i
+			break
+		}
+	}
+	if sep < 0 {
+		return icalProperty{}, xerrors.Errorf("invalid content line %q", line)
+	}
+
+	// Parameters such as the timezone of dates are ignored.
+	name, _, _ := strings.Cut(line[:sep], ";")
+	return icalProperty{
+		name:  strings.ToUpper(name),
+		value: line[sep+1:],
+	}, nil
+}
+
+// icalEventHolidays returns a holiday for each day of an event.
+func icalEventHolidays(event map[string]icalProperty, until time.Time) ([]Holiday, error) {
+	if status, ok := event["STATUS"]; ok && strings.EqualFold(status.value, "CANCELLED") {
+		return nil, nil
+	}
+	start, ok := event["DTSTART"]
+	if !ok {
+		return nil, xerrors.New("missing DTSTART")
+	}
+	startDate, _, err := parseICalendarDate(start.value)
+	if err != nil {
+		return nil, xerrors.Errorf("parse DTSTART: %w", err)
+	}
+
+	// Events last a day unless they have an end. The end of all-day events is
+	// exclusive, timed events last until the day they end on unless they end
+	// at midnight.
+	days := 1
+	if end, ok := event["DTEND"]; ok {
+		endDate, allDay, err := parseICalendarDate(end.value)
+		if err != nil {
+			return nil, xerrors.Errorf("parse DTEND: %w", err)
+		}
+		days = int(endDate.Sub(startDate) / (24 * time.Hour))
+		if !allDay && !strings.HasPrefix(end.value[8:], "T000000") {
+			days++
+		}
+	}
+	if days < 1 {
+		days = 1
+	}
+	if days > maxHolidayDays {
+		return nil, xerrors.Errorf("event spans %d days, more than the maximum of %d", days, maxHolidayDays)
+	}
+
+	name := unescapeICalendarText(event["SUMMARY"].value)
+	years := 1
+	if rrule, ok := event["RRULE"]; ok {
+		years, err = icalYearlyOccurrences(rrule.value, startDate, until)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var holidays []Holiday
+	for year := 0; year < years; year++ {
+		occurrence := startDate.AddDate(year, 0, 0)
+		for day := 0; day < days; day++ {
+			holidays = append(holidays, Holiday{
+				Date: occurrence.AddDate(0, 0, day),
+				Name: name,
+			})
+		}
+	}
+	return holidays, nil
+}
+
+// icalYearlyOccurrences returns how many times a yearly recurring event
+// occurs from its start until the given time.
+func icalYearlyOccurrences(rrule string, start, until time.Time) (int, error) {
+	count := -1
+	for _, part := range strings.Split(rrule, ";") {
+		key, value, _ := strings.Cut(part, "=")
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			if !strings.EqualFold(value, "YEARLY") {
+				return 0, xerrors.Errorf("unsupported recurrence %q, only yearly events are supported", rrule)
+			}
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return 0, xerrors.Errorf("invalid recurrence count %q", value)
+			}
+			count = n
+		case "UNTIL":
+			date, _, err := parseICalendarDate(value)
+			if err != nil {
+				return 0, xerrors.Errorf("parse recurrence until: %w", err)
+			}
+			if date.Before(until) {
+				until = date
+			}
+		case "INTERVAL":
+			if value != "1" {
+				return 0, xerrors.Errorf("unsupported recurrence interval %q", value)
+			}
+		case "BYMONTH", "BYMONTHDAY", "WKST":
+			// These repeat the date of the start, which is what we do anyway.
+		default:
+			return 0, xerrors.Errorf("unsupported recurrence %q", rrule)
+		}
+	}
+
+	years := 0
+	for start.AddDate(years, 0, 0).Before(until) || start.AddDate(years, 0, 0).Equal(until) {
+		years++
+		if years == count {
+			break
+		}
+	}
+	if years == 0 {
+		years = 1
+	}
+	return years, nil
+}
+
+// parseICalendarDate parses a DATE or DATE-TIME value, and returns midnight UTC
+// of its day and whether it was a DATE.
+func parseICalendarDate(value string) (time.Time, bool, error) {
+	if len(value) < 8 {
+		return time.Time{}, false, xerrors.Errorf("invalid date %q", value)
+	}
+	date, err := time.Parse("20060102", value[:8])
+	if err != nil {
+		return time.Time{}, false, xerrors.Errorf("invalid date %q: %w", value, err)
+	}
+	return date, len(value) == 8, nil
+}
+
+func unescapeICalendarText(value string) string {
+	return strings.NewReplacer(`\n`, " ", `\N`, " ", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(value)
+}
@@ -0,0 +1,192 @@
+package schedule_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/coderd/schedule"
+)
+
+func TestParseICalendar(t *testing.T) {
+	t.Parallel()
+
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	until := date(2026, time.December, 31)
+
+	testCases := []struct {
+		name          string
+		events        string
+		expected      []schedule.Holiday
+		errorContains string
+	}{
+		{
+			name: "AllDay",
+			events: `BEGIN:VEVENT
+DTSTART;VALUE=DATE:20250704
+DTEND;VALUE=DATE:20250705
+SUMMARY:Independence Day
+END:VEVENT`,
+			expected: []schedule.Holiday{
+				{Date: date(2025, time.July, 4), Name: "Independence Day"},
+			},
+		},
+		{
+			name: "MultipleDays",
+			events: `BEGIN:VEVENT
+DTSTART;VALUE=DATE:20251224
+DTEND;VALUE=DATE:20251227
+SUMMARY:Winter break\, part 1
+END:VEVENT`,
+			expected: []schedule.Holiday{
+				{Date: date(2025, time.December, 24), Name: "Winter break, part 1"},
+				{Date: date(2025, time.December, 25), Name: "Winter break, part 1"},
+				{Date: date(2025, time.December, 26), Name: "Winter break, part 1"},
+			},
+		},
+		{
+			name: "Timed",
+			events: `BEGIN:VEVENT
+DTSTART;TZID=Europe/Berlin:20251231T120000
+DTEND;TZID=Europe/Berlin:20260101T120000
+SUMMARY:New Year
+END:VEVENT
+BEGIN:VEVENT
+DTSTART:20260501T000000Z
+DTEND:20260502T000000Z
+SUMMARY:Labour Day
+END:VEVENT`,
+			expected: []schedule.Holiday{
+				{Date: date(2025, time.December, 31), Name: "New Year"},
+				{Date: date(2026, time.January, 1), Name: "New Year"},
+				{Date: date(2026, time.May, 1), Name: "Labour Day"},
+			},
+		},
+		{
+			name: "Yearly",
+			events: `BEGIN:VEVENT
+DTSTART;VALUE=DATE:20241225
+RRULE:FREQ=YEARLY;BYMONTH=12;BYMONTHDAY=25
+SUMMARY:Christmas
+END:VEVENT`,
+			expected: []schedule.Holiday{
+				{Date: date(2024, time.December, 25), Name: "Christmas"},
+				{Date: date(2025, time.December, 25), Name: "Christmas"},
+				{Date: date(2026, time.December, 25), Name: "Christmas"},
+			},
+		},
+		{
+			name: "YearlyCount",
+			events: `BEGIN:VEVENT
+DTSTART;VALUE=DATE:20241225
+RRULE:FREQ=YEARLY;COUNT=2
+SUMMARY:Christmas
+END:VEVENT`,
+			expected: []schedule.Holiday{
+				{Date: date(2024, time.December, 25), Name: "Christmas"},
+				{Date: date(2025, time.December, 25), Name: "Christmas"},
+			},
+		},
+		{
+			name: "FoldedAndCancelled",
+			events: "BEGIN:VEVENT\r\nDTSTART;VALUE=DATE:20250101\r\nSUMMARY:New \r\n Year's Day\r\nEND:VEVENT\r\n" +
+				"BEGIN:VEVENT\r\nDTSTART;VALUE=DATE:20250102\r\nSTATUS:CANCELLED\r\nSUMMARY:Moved\r\nEND:VEVENT\r\n",
+			expected: []schedule.Holiday{
+				{Date: date(2025, time.January, 1), Name: "New Year's Day"},
+			},
+		},
+		{
+			name: "UnsupportedRecurrence",
+			events: `BEGIN:VEVENT
+DTSTART;VALUE=DATE:20250101
+RRULE:FREQ=WEEKLY
+END:VEVENT`,
+			errorContains: "only yearly events are supported",
+		},
+		{
+			name: "TooLong",
+			events: `BEGIN:VEVENT
+DTSTART;VALUE=DATE:20250101
+DTEND;VALUE=DATE:20260101
+END:VEVENT`,
+			errorContains: "more than the maximum",
+		},
+		{
+			name: "Unterminated",
+			events: `BEGIN:VEVENT
+DTSTART;VALUE=DATE:20250101`,
+			errorContains: "unterminated VEVENT",
+		},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			ics := "BEGIN:VCALENDAR\nVERSION:2.0\n" + c.events + "\nEND:VCALENDAR\n"
+			holidays, err := schedule.ParseICalendar(strings.NewReader(ics), until)
+			if c.errorContains != "" {
+				require.ErrorContains(t, err, c.errorContains)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.expected, holidays)
+		})
+	}
+}
+
+func TestAutostopWindowsNextStop(t *testing.T) {
+	t.Parallel()
+
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	windows := schedule.AutostopWindows{
+		time.Monday:   18 * time.Hour,
+		time.Thursday: 18 * time.Hour,
+		time.Friday:   14*time.Hour + 30*time.Minute,
+	}
+	require.NoError(t, windows.Verify())
+
+	// 3rd March 2025 is a Monday.
+	for _, c := range []struct {
+		name     string
+		at       time.Time
+		expected time.Time
+	}{
+		{
+			name:     "SameDay",
+			at:       time.Date(2025, time.March, 3, 9, 0, 0, 0, newYork),
+			expected: time.Date(2025, time.March, 3, 18, 0, 0, 0, newYork),
+		},
+		{
+			name:     "SkipsDaysWithoutWindow",
+			at:       time.Date(2025, time.March, 3, 19, 0, 0, 0, newYork),
+			expected: time.Date(2025, time.March, 6, 18, 0, 0, 0, newYork),
+		},
+		{
+			name:     "AtWindow",
+			at:       time.Date(2025, time.March, 7, 14, 30, 0, 0, newYork),
+			expected: time.Date(2025, time.March, 10, 18, 0, 0, 0, newYork),
+		},
+		{
+			// Daylight savings starts on 9th March 2025 in New York.
+			name:     "DaylightSavings",
+			at:       time.Date(2025, time.March, 8, 9, 0, 0, 0, newYork),
+			expected: time.Date(2025, time.March, 10, 18, 0, 0, 0, newYork),
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, c.expected, windows.NextStop(c.at))
+		})
+	}
+
+	require.True(t, schedule.AutostopWindows{}.NextStop(time.Now()).IsZero())
+	require.Error(t, schedule.AutostopWindows{time.Monday: 24 * time.Hour}.Verify())
+	require.Error(t, schedule.AutostopWindows{time.Monday: 90 * time.Second}.Verify())
+}
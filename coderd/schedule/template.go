@@ -128,6 +128,13 @@ type TemplateScheduleOptions struct {
 	AutostopRequirement TemplateAutostopRequirement
 	// AutostartRequirement dictates when the workspace can be auto started.
 	AutostartRequirement TemplateAutostartRequirement
+	// AutostopWindows dictates the time of day the workspace must be stopped
+	// by, per day of the week. If UserAutostopEnabled, the windows of the
+	// workspace owner are used instead, if they have any.
+	AutostopWindows AutostopWindows
+	// Holidays are the days of the template's organization on which the
+	// workspace is not auto started. Holidays are not set by Set.
+	Holidays Holidays
 	// FailureTTL dictates the duration after which failed workspaces will be
 	// stopped automatically.
 	FailureTTL time.Duration
@@ -183,7 +190,8 @@ func (*agplTemplateScheduleStore) Get(ctx context.Context, db database.Store, te
 		DefaultTTL:           time.Duration(tpl.DefaultTTL),
 		ActivityBump:         time.Duration(tpl.ActivityBump),
 		// Disregard the values in the database, since AutostopRequirement,
-		// FailureTTL, TimeTilDormant, and TimeTilDormantAutoDelete are enterprise features.
+		// AutostopWindows, Holidays, FailureTTL, TimeTilDormant, and
+		// TimeTilDormantAutoDelete are enterprise features.
 		AutostartRequirement: TemplateAutostartRequirement{
 			// Default to allowing all days for AGPL
 			DaysOfWeek: 0b01111111,
@@ -29,6 +29,10 @@ type UserQuietHoursScheduleOptions struct {
 	// false, the user cannot set a custom schedule and the default schedule
 	// will always be used.
 	UserCanSet bool
+	// AutostopWindows are the user's own autostop windows, which replace the
+	// template's windows if the template allows users to set their own
+	// autostop. Windows are in the location of Schedule.
+	AutostopWindows AutostopWindows
 }
 
 type UserQuietHoursScheduleStore interface {
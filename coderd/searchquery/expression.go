@@ -0,0 +1,400 @@
+package searchquery
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/xerrors"
+)
+
+const (
+	// maxExpressionTerms bounds the terms of a query, as every term of a
+	// boolean query may be a database query.
+	maxExpressionTerms = 16
+	// maxExpressionDepth bounds the nesting of parentheses and negations.
+	maxExpressionDepth = 8
+)
+
+type expressionOp int
+
+const (
+	expressionTerm expressionOp = iota
+	expressionRegex
+	expressionAnd
+	expressionOr
+	expressionNot
+)
+
+// expression is a parsed search query. Terms are `key:value` elements, which
+// are combined with AND, OR and NOT, e.g.
+//
+//	(template:docker OR template:k8s) -status:stopped
+type expression struct {
+	op expressionOp
+	// term is the text of the term, as understood by searchTerms, for
+	// expressionTerm.
+	term string
+	// regex matches names for expressionRegex.
+	regex *regexp.Regexp
+	// column is the 1-based position of the term in the query.
+	column   int
+	children []*expression
+}
+
+// terms returns the terms and regular expressions of the expression.
+func (e *expression) terms() []*expression {
+	switch e.op {
+	case expressionTerm, expressionRegex:
+		return []*expression{e}
+	default:
+		var terms []*expression
+		for _, child := range e.children {
+			terms = append(terms, child.terms()...)
+		}
+		return terms
+	}
+}
+
+// conjunction splits the expression into the plain terms that are ANDed at the
+// top level, and the remaining expressions. A query without boolean operators
+// only has plain terms.
+func (e *expression) conjunction() (terms []*expression, rest []*expression) {
+	switch e.op {
+	case expressionTerm:
+		return []*expression{e}, nil
+	case expressionAnd:
+		for _, child := range e.children {
+			childTerms, childRest := child.conjunction()
+			terms = append(terms, childTerms...)
+			rest = append(rest, childRest...)
+		}
+		return terms, rest
+	default:
+		return nil, []*expression{e}
+	}
+}
+
+type tokenKind int
+
+const (
+	tokenTerm tokenKind = iota
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenOpen
+	tokenClose
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	// column is the 1-based position of the token in the query.
+	column int
+}
+
+// expressionError is an error at a position of a query.
+type expressionError struct {
+	column int
+	detail string
+}
+
+func (e expressionError) Error() string {
+	return fmt.Sprintf("%s (at column %d)", e.detail, e.column)
+}
+
+func errorAt(column int, format string, args ...any) error {
+	return expressionError{column: column, detail: fmt.Sprintf(format, args...)}
+}
+
+// tokenize splits a query into terms, parentheses and the AND, OR and NOT
+// operators. Operators must be uppercase, so they don't clash with names. A
+// '-' at the start of a term negates it. Quoted strings and regular
+// expressions are kept within a single term.
+func tokenize(query string) ([]token, error) {
+	var (
+		tokens []token
+		runes  = []rune(query)
+	)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+			continue
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenOpen, text: "(", column: i + 1})
+			i++
+			continue
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenClose, text: ")", column: i + 1})
+			i++
+			continue
+		case r == '-':
+			tokens = append(tokens, token{kind: tokenNot, text: "-", column: i + 1})
+			i++
+			continue
+		}
+
+		start := i
+		var word strings.Builder
+		for i < len(runes) {
+			r = runes[i]
+			if unicode.IsSpace(r) || r == '(' || r == ')' {
+				break
+			}
+			switch {
+			case r == '"':
+				end := indexRune(runes, i+1, '"')
+				if end < 0 {
+					return nil, errorAt(i+1, "unterminated quote")
+				}
+				_, _ = word.WriteString(string(runes[i : end+1]))
+				i = end + 1
+			case r == '/' && (word.Len() == 0 || strings.HasSuffix(word.String(), ":")):
+				// A regular expression, which may contain spaces and
+				// parentheses.
+				end := i + 1
+				for ; end < len(runes); end++ {
+					if runes[end] == '\\' {
+						end++
+						continue
+					}
+					if runes[end] == '/' {
+						break
+					}
+				}
+				if end >= len(runes) {
+					return nil, errorAt(i+1, "unterminated regular expression")
+				}
+				_, _ = word.WriteString(string(runes[i : end+1]))
+				i = end + 1
+			default:
+				_, _ = word.WriteRune(r)
+				i++
+			}
+		}
+
+		tok := token{kind: tokenTerm, text: word.String(), column: start + 1}
+		switch tok.text {
+		case "AND":
+			tok.kind = tokenAnd
+		case "OR":
+			tok.kind = tokenOr
+		case "NOT":
+			tok.kind = tokenNot
+		}
+		tokens = append(tokens, tok)
+	}
+	return tokens, nil
+}
+
+func indexRune(runes []rune, from int, r rune) int {
+	for i := from; i < len(runes); i++ {
+		if runes[i] == r {
+			return i
+		}
+	}
+	return -1
+}
+
+// parseExpression parses a query with the grammar:
+//
+//	or    = and { "OR" and }
+//	and   = unary { [ "AND" ] unary }
+//	unary = ( "NOT" | "-" ) unary | "(" or ")" | term
+//
+// Terms next to each other are ANDed, like in queries without operators.
+func parseExpression(query string) (*expression, error) {
+	tokens, err := tokenize(query)
+	if err != nil {
+		return nil, err
+	}
+	p := &expressionParser{tokens: tokens}
+	expr, err := p.or(0)
+	if err != nil {
+		return nil, err
+	}
+	if tok, ok := p.peek(); ok {
+		return nil, errorAt(tok.column, "unexpected %q", tok.text)
+	}
+	if len(expr.terms()) > maxExpressionTerms {
+		return nil, xerrors.Errorf("query can contain at most %d terms", maxExpressionTerms)
+	}
+	return expr, nil
+}
+
+type expressionParser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *expressionParser) peek() (token, bool) {
+	if p.pos >= len(p.tokens) {
+		return token{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+// end is the column after the last token, for errors at the end of a query.
+func (p *expressionParser) end() int {
+	if len(p.tokens) == 0 {
+		return 1
+	}
+	last := p.tokens[len(p.tokens)-1]
+	return last.column + len([]rune(last.text))
+}
+
+func (p *expressionParser) or(depth int) (*expression, error) {
+	left, err := p.and(depth)
+	if err != nil {
+		return nil, err
+	}
+	expr := left
+	for {
+		tok, ok := p.peek()
+		if !ok || tok.kind != tokenOr {
+			return expr, nil
+		}
+		p.pos++
+		right, err := p.and(depth)
+		if err != nil {
+			return nil, err
+		}
+		if expr.op != expressionOr {
+			expr = &expression{op: expressionOr, column: left.column, children: []*expression{left}}
+		}
+		expr.children = append(expr.children, right)
+	}
+}
+
+func (p *expressionParser) and(depth int) (*expression, error) {
+	left, err := p.unary(depth)
+	if err != nil {
+		return nil, err
+	}
+	expr := left
+	for {
+		tok, ok := p.peek()
+		if !ok || tok.kind == tokenOr || tok.kind == tokenClose {
+			return expr, nil
+		}
+		if tok.kind == tokenAnd {
+			p.pos++
+		}
+		right, err := p.unary(depth)
+		if err != nil {
+			return nil, err
+		}
+		if expr.op != expressionAnd {
+			expr = &expression{op: expressionAnd, column: left.column, children: []*expression{left}}
+		}
+		expr.children = append(expr.children, right)
+	}
+}
+
+func (p *expressionParser) unary(depth int) (*expression, error) {
+	tok, ok := p.peek()
+	if !ok {
+		return nil, errorAt(p.end(), "expected a search term")
+	}
+	if depth > maxExpressionDepth {
+		return nil, errorAt(tok.column, "query can nest at most %d levels", maxExpressionDepth)
+	}
+	p.pos++
+	switch tok.kind {
+	case tokenNot:
+		child, err := p.unary(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return &expression{op: expressionNot, column: tok.column, children: []*expression{child}}, nil
+	case tokenOpen:
+		expr, err := p.or(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		closing, ok := p.peek()
+		if !ok {
+			return nil, errorAt(tok.column, "unclosed %q", tok.text)
+		}
+		if closing.kind != tokenClose {
+			return nil, errorAt(closing.column, "unexpected %q", closing.text)
+		}
+		p.pos++
+		return expr, nil
+	case tokenTerm:
+		return parseTerm(tok)
+	default:
+		return nil, errorAt(tok.column, "unexpected %q", tok.text)
+	}
+}
+
+// nameRegexTermRegex matches terms with a regular expression on names, e.g.
+// `name:/^dev-/`.
+var nameRegexTermRegex = regexp.MustCompile(`(?i)^"?name"?:/`)
+
+// comparisonRegex matches timestamp comparisons, e.g. `last_used>2024-01-01`.
+var comparisonRegex = regexp.MustCompile(`^([a-zA-Z_-]+)(>=|<=|>|<)(.*)$`)
+
+// comparisonKeys maps the keys that can be compared to the keys of the
+// earliest and latest timestamps, which are inclusive.
+var comparisonKeys = map[string][2]string{
+	"last_used": {"last_used_after", "last_used_before"},
+}
+
+// parseTerm converts comparisons and regular expressions of a term. Other
+// terms are validated later on, by the parser of the query.
+func parseTerm(tok token) (*expression, error) {
+	text := tok.text
+	if strings.HasPrefix(text, "/") || nameRegexTermRegex.MatchString(text) {
+		pattern := text[strings.Index(text, "/")+1:]
+		if !strings.HasSuffix(pattern, "/") {
+			return nil, errorAt(tok.column, "regular expression %q must end with '/'", text)
+		}
+		pattern = strings.TrimSuffix(pattern, "/")
+		// Names are lowercase, but the pattern isn't lowercased, so escapes
+		// like \D keep their meaning.
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			return nil, errorAt(tok.column, "invalid regular expression %q: %s", pattern, err.Error())
+		}
+		return &expression{op: expressionRegex, regex: re, column: tok.column}, nil
+	}
+
+	if match := comparisonRegex.FindStringSubmatch(text); match != nil {
+		key := strings.ToLower(match[1])
+		keys, ok := comparisonKeys[key]
+		if !ok {
+			return nil, errorAt(tok.column, "%q cannot be compared, only last_used can", key)
+		}
+		value, err := parseComparisonTime(strings.Trim(match[3], `"`))
+		if err != nil {
+			return nil, errorAt(tok.column, "%q must be a date (2006-01-02) or a timestamp (%s)", match[3], time.RFC3339)
+		}
+		key = keys[0]
+		if strings.HasPrefix(match[2], "<") {
+			key = keys[1]
+		}
+		// Timestamps are stored with microsecond precision, so a strict
+		// bound is the inclusive bound a microsecond further.
+		switch match[2] {
+		case ">":
+			value = value.Add(time.Microsecond)
+		case "<":
+			value = value.Add(-time.Microsecond)
+		}
+		// Quote the timestamp, as it contains ':'.
+		text = fmt.Sprintf("%s:%q", key, value.UTC().Format(time.RFC3339Nano))
+	}
+	return &expression{op: expressionTerm, term: text, column: tok.column}, nil
+}
+
+func parseComparisonTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339Nano, strings.ToUpper(value))
+}
@@ -0,0 +1,244 @@
+package searchquery
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// format prints an expression in prefix notation for comparisons.
+func (e *expression) format() string {
+	switch e.op {
+	case expressionTerm:
+		return e.term
+	case expressionRegex:
+		return "/" + e.regex.String() + "/"
+	case expressionNot:
+		return "(not " + e.children[0].format() + ")"
+	default:
+		op := "and"
+		if e.op == expressionOr {
+			op = "or"
+		}
+		parts := make([]string, 0, len(e.children))
+		for _, child := range e.children {
+			parts = append(parts, child.format())
+		}
+		return "(" + op + " " + strings.Join(parts, " ") + ")"
+	}
+}
+
+func TestParseExpression(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		Name     string
+		Query    string
+		Expected string
+		// ExpectedTerms and ExpectedRest are the number of terms and
+		// expressions of the top level conjunction.
+		ExpectedTerms int
+		ExpectedRest  int
+	}{
+		{
+			Name:          "Term",
+			Query:         "owner:alice",
+			Expected:      "owner:alice",
+			ExpectedTerms: 1,
+		},
+		{
+			Name:          "Implicit",
+			Query:         `name:foo template:"my template"`,
+			Expected:      `(and name:foo template:"my template")`,
+			ExpectedTerms: 2,
+		},
+		{
+			Name:          "ExplicitAnd",
+			Query:         "owner:alice AND status:running",
+			Expected:      "(and owner:alice status:running)",
+			ExpectedTerms: 2,
+		},
+		{
+			Name:         "Or",
+			Query:        "template:docker OR template:k8s",
+			Expected:     "(or template:docker template:k8s)",
+			ExpectedRest: 1,
+		},
+		{
+			Name:          "Precedence",
+			Query:         "owner:alice template:docker OR template:k8s status:running",
+			Expected:      "(or (and owner:alice template:docker) (and template:k8s status:running))",
+			ExpectedRest:  1,
+			ExpectedTerms: 0,
+		},
+		{
+			Name:          "Grouping",
+			Query:         "owner:me (template:docker OR template:k8s)",
+			Expected:      "(and owner:me (or template:docker template:k8s))",
+			ExpectedTerms: 1,
+			ExpectedRest:  1,
+		},
+		{
+			Name:          "Negation",
+			Query:         "-status:stopped NOT dormant:true owner:alice",
+			Expected:      "(and (not status:stopped) (not dormant:true) owner:alice)",
+			ExpectedTerms: 1,
+			ExpectedRest:  2,
+		},
+		{
+			Name:          "NegatedGroup",
+			Query:         "-(owner:alice OR owner:bob)",
+			Expected:      "(not (or owner:alice owner:bob))",
+			ExpectedTerms: 0,
+			ExpectedRest:  1,
+		},
+		{
+			Name:          "LowercaseOperatorsAreTerms",
+			Query:         "foo or bar",
+			Expected:      "(and foo or bar)",
+			ExpectedTerms: 3,
+		},
+		{
+			Name:          "HyphenatedTerm",
+			Query:         "has-agent:connected workspace-name",
+			Expected:      "(and has-agent:connected workspace-name)",
+			ExpectedTerms: 2,
+		},
+		{
+			Name:          "QuotedParentheses",
+			Query:         `param:"region=eu (west)"`,
+			Expected:      `param:"region=eu (west)"`,
+			ExpectedTerms: 1,
+		},
+		{
+			Name:         "Regex",
+			Query:        "name:/^dev-(a|b)$/",
+			Expected:     "/(?i)^dev-(a|b)$/",
+			ExpectedRest: 1,
+		},
+		{
+			Name:         "BareRegex",
+			Query:        `/\d+ x/`,
+			Expected:     `/(?i)\d+ x/`,
+			ExpectedRest: 1,
+		},
+		{
+			Name:          "ComparisonDate",
+			Query:         "last_used>=2024-01-02",
+			Expected:      `last_used_after:"2024-01-02T00:00:00Z"`,
+			ExpectedTerms: 1,
+		},
+		{
+			Name:          "ComparisonStrict",
+			Query:         "last_used>2024-01-02",
+			Expected:      `last_used_after:"2024-01-02T00:00:00.000001Z"`,
+			ExpectedTerms: 1,
+		},
+		{
+			Name:          "ComparisonStrictBefore",
+			Query:         "last_used<2024-01-02",
+			Expected:      `last_used_before:"2024-01-01T23:59:59.999999Z"`,
+			ExpectedTerms: 1,
+		},
+		{
+			Name:          "ComparisonTimestamp",
+			Query:         "last_used<=2024-01-02T03:04:05+01:00",
+			Expected:      `last_used_before:"2024-01-02T02:04:05Z"`,
+			ExpectedTerms: 1,
+		},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(t *testing.T) {
+			t.Parallel()
+			expr, err := parseExpression(c.Query)
+			require.NoError(t, err)
+			assert.Equal(t, c.Expected, expr.format())
+			terms, rest := expr.conjunction()
+			assert.Len(t, terms, c.ExpectedTerms, "terms")
+			assert.Len(t, rest, c.ExpectedRest, "rest")
+		})
+	}
+}
+
+func TestParseExpressionErrors(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		Name                  string
+		Query                 string
+		ExpectedErrorContains string
+	}{
+		{
+			Name:                  "Unclosed",
+			Query:                 "owner:alice (template:docker",
+			ExpectedErrorContains: `unclosed "(" (at column 13)`,
+		},
+		{
+			Name:                  "UnexpectedClose",
+			Query:                 "owner:alice)",
+			ExpectedErrorContains: `unexpected ")" (at column 12)`,
+		},
+		{
+			Name:                  "DanglingOr",
+			Query:                 "owner:alice OR",
+			ExpectedErrorContains: "expected a search term (at column 15)",
+		},
+		{
+			Name:                  "DoubleOperator",
+			Query:                 "owner:alice OR AND owner:bob",
+			ExpectedErrorContains: `unexpected "AND" (at column 16)`,
+		},
+		{
+			Name:                  "EmptyGroup",
+			Query:                 "()",
+			ExpectedErrorContains: `unexpected ")" (at column 2)`,
+		},
+		{
+			Name:                  "UnterminatedQuote",
+			Query:                 `name:foo template:"docker`,
+			ExpectedErrorContains: "unterminated quote (at column 19)",
+		},
+		{
+			Name:                  "UnterminatedRegex",
+			Query:                 "name:/foo",
+			ExpectedErrorContains: "unterminated regular expression (at column 6)",
+		},
+		{
+			Name:                  "InvalidRegex",
+			Query:                 "owner:alice name:/(/",
+			ExpectedErrorContains: "invalid regular expression \"(\"",
+		},
+		{
+			Name:                  "ComparisonKey",
+			Query:                 "created>2024-01-01",
+			ExpectedErrorContains: `"created" cannot be compared`,
+		},
+		{
+			Name:                  "ComparisonValue",
+			Query:                 "last_used>yesterday",
+			ExpectedErrorContains: `"yesterday" must be a date`,
+		},
+		{
+			Name:                  "TooManyTerms",
+			Query:                 strings.Repeat("a OR ", maxExpressionTerms) + "a",
+			ExpectedErrorContains: "at most 16 terms",
+		},
+		{
+			Name:                  "TooDeep",
+			Query:                 strings.Repeat("(", maxExpressionDepth+2) + "a" + strings.Repeat(")", maxExpressionDepth+2),
+			ExpectedErrorContains: "at most 8 levels",
+		},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(t *testing.T) {
+			t.Parallel()
+			_, err := parseExpression(c.Query)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), c.ExpectedErrorContains)
+		})
+	}
+}
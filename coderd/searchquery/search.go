@@ -127,6 +127,63 @@ func Workspaces(ctx context.Context, db database.Store, query string, page coder
 		return filter, nil
 	}
 
+	expr, err := parseExpression(query)
+	if err != nil {
+		return filter, []codersdk.ValidationError{{Field: "q", Detail: err.Error()}}
+	}
+	// Validate every term on its own first, so errors point at the term. Terms
+	// within boolean operators are limited to the workspaces the query is
+	// evaluated on, so they aren't paginated.
+	all := database.GetWorkspacesParams{
+		AgentInactiveDisconnectTimeoutSeconds: filter.AgentInactiveDisconnectTimeoutSeconds,
+	}
+	termFilters := make(map[*expression]database.GetWorkspacesParams)
+	for _, term := range expr.terms() {
+		if term.op != expressionTerm {
+			continue
+		}
+		termFilter, errs := workspaceFilter(ctx, db, all, term.term)
+		if len(errs) > 0 {
+			for i := range errs {
+				errs[i].Detail = fmt.Sprintf("Query element %q (at column %d): %s", term.term, term.column, errs[i].Detail)
+			}
+			return filter, errs
+		}
+		termFilters[term] = termFilter
+	}
+
+	// Terms that are ANDed at the top level are filtered in the database like
+	// queries without operators. The remaining expressions are evaluated to
+	// the IDs of the workspaces matching them.
+	terms, rest := expr.conjunction()
+	texts := make([]string, 0, len(terms))
+	for _, term := range terms {
+		texts = append(texts, term.term)
+	}
+	filter, errs := workspaceFilter(ctx, db, filter, strings.Join(texts, " "))
+	if len(errs) > 0 || len(rest) == 0 {
+		return filter, errs
+	}
+
+	search, err := newWorkspaceSearch(ctx, db, termFilters, filter)
+	if err != nil {
+		return filter, []codersdk.ValidationError{{Field: "q", Detail: err.Error()}}
+	}
+	ids, err := search.match(ctx, &expression{op: expressionAnd, children: rest})
+	if err != nil {
+		return filter, []codersdk.ValidationError{{Field: "q", Detail: err.Error()}}
+	}
+	filter.WorkspaceIds = search.restrict(filter.WorkspaceIds, ids)
+	return filter, nil
+}
+
+// workspaceFilter adds the terms of a query without boolean operators to the
+// filter.
+func workspaceFilter(ctx context.Context, db database.Store, filter database.GetWorkspacesParams, query string) (database.GetWorkspacesParams, []codersdk.ValidationError) {
+	if query == "" {
+		return filter, nil
+	}
+
 	// Always lowercase for all searches.
 	query = strings.ToLower(query)
 	values, errors := searchTerms(query, func(term string, values url.Values) error {
@@ -233,6 +290,42 @@ func Templates(ctx context.Context, db database.Store, query string) (database.G
 	return filter, parser.Errors
 }
 
+// WorkspaceBuildEvents parses a query over completed workspace builds. The organization and period are not part of the
+// query, they are set by the caller.
+//
+// Supported query parameters:
+//
+//   - workspace: string (workspace name, the default for terms without a key)
+//   - owner: string (username)
+//   - template: string (template name)
+//   - transition: string (enum)
+//   - status: string (enum, the status of the provisioner job)
+//   - build_reason: string (enum)
+func WorkspaceBuildEvents(query string) (database.GetWorkspaceBuildEventsParams, []codersdk.ValidationError) {
+	// Always lowercase for all searches.
+	query = strings.ToLower(query)
+	values, errors := searchTerms(query, func(term string, values url.Values) error {
+		values.Add("workspace", term)
+		return nil
+	})
+	if len(errors) > 0 {
+		return database.GetWorkspaceBuildEventsParams{}, errors
+	}
+
+	parser := httpapi.NewQueryParamParser()
+	filter := database.GetWorkspaceBuildEventsParams{
+		WorkspaceName: parser.String(values, "", "workspace"),
+		OwnerUsername: parser.String(values, "", "owner"),
+		TemplateName:  parser.String(values, "", "template"),
+		Transition:    string(httpapi.ParseCustom(parser, values, "", "transition", httpapi.ParseEnum[database.WorkspaceTransition])),
+		JobStatus:     string(httpapi.ParseCustom(parser, values, "", "status", httpapi.ParseEnum[database.ProvisionerJobStatus])),
+		BuildReason:   string(httpapi.ParseCustom(parser, values, "", "build_reason", httpapi.ParseEnum[database.BuildReason])),
+	}
+
+	parser.ErrorExcessParams(values)
+	return filter, parser.Errors
+}
+
 func searchTerms(query string, defaultKey func(term string, values url.Values) error) (url.Values, []codersdk.ValidationError) {
 	searchValues := make(url.Values)
 
@@ -252,8 +252,42 @@ func TestSearchWorkspace(t *testing.T) {
 				},
 			},
 		},
+		{
+			Name:  "ExplicitAnd",
+			Query: "owner:alice AND (template:docker name:dev)",
+			Expected: database.GetWorkspacesParams{
+				OwnerUsername: "alice",
+				TemplateName:  "docker",
+				Name:          "dev",
+			},
+		},
+		{
+			Name:  "LastUsedComparison",
+			Query: "last_used>2024-01-02 last_used<2024-02-03T04:05:06Z",
+			Expected: database.GetWorkspacesParams{
+				LastUsedAfter:  time.Date(2024, 1, 2, 0, 0, 0, int(time.Microsecond), time.UTC),
+				LastUsedBefore: time.Date(2024, 2, 3, 4, 5, 5, int(time.Second-time.Microsecond), time.UTC),
+			},
+		},
+		{
+			Name:  "OrNoMatches",
+			Query: "owner:alice OR owner:bob",
+			Expected: database.GetWorkspacesParams{
+				WorkspaceIds: []uuid.UUID{uuid.Nil},
+			},
+		},
 
 		// Failures
+		{
+			Name:                  "UnclosedParenthesis",
+			Query:                 "owner:alice (template:docker OR template:k8s",
+			ExpectedErrorContains: `unclosed "(" (at column 13)`,
+		},
+		{
+			Name:                  "InvalidTermInOr",
+			Query:                 "owner:alice OR foo:bar",
+			ExpectedErrorContains: `Query element "foo:bar" (at column 16): "foo" is not a valid query param`,
+		},
 		{
 			Name:                  "ParamExcessValue",
 			Query:                 "param:foo=bar=baz",
@@ -337,6 +371,119 @@ func TestSearchWorkspace(t *testing.T) {
 	})
 }
 
+func TestSearchWorkspaceExpression(t *testing.T) {
+	t.Parallel()
+
+	db := dbmem.New()
+	org := dbgen.Organization(t, db, database.Organization{})
+	alice := dbgen.User(t, db, database.User{Username: "alice"})
+	bob := dbgen.User(t, db, database.User{Username: "bob"})
+	docker := dbgen.Template(t, db, database.Template{Name: "docker", OrganizationID: org.ID, CreatedBy: alice.ID})
+	k8s := dbgen.Template(t, db, database.Template{Name: "k8s", OrganizationID: org.ID, CreatedBy: alice.ID})
+	workspace := func(owner database.User, template database.Template, name string) uuid.UUID {
+		return dbgen.Workspace(t, db, database.WorkspaceTable{
+			OwnerID:        owner.ID,
+			OrganizationID: org.ID,
+			TemplateID:     template.ID,
+			Name:           name,
+		}).ID
+	}
+	aliceDev := workspace(alice, docker, "dev-alice")
+	aliceProd := workspace(alice, k8s, "prod-alice")
+	bobDev := workspace(bob, docker, "dev-bob")
+	bobProd := workspace(bob, k8s, "prod-bob")
+
+	testCases := []struct {
+		Name     string
+		Query    string
+		Expected []uuid.UUID
+	}{
+		{
+			Name:     "Or",
+			Query:    "template:k8s OR name:dev-bob",
+			Expected: []uuid.UUID{aliceProd, bobDev, bobProd},
+		},
+		{
+			Name:     "SameKeyInOr",
+			Query:    "name:dev-alice OR name:prod-bob",
+			Expected: []uuid.UUID{aliceDev, bobProd},
+		},
+		{
+			Name:     "Not",
+			Query:    "-owner:alice",
+			Expected: []uuid.UUID{bobDev, bobProd},
+		},
+		{
+			Name:     "NotGroup",
+			Query:    "NOT (owner:alice OR template:docker)",
+			Expected: []uuid.UUID{bobProd},
+		},
+		{
+			Name:     "Regex",
+			Query:    "name:/^dev-/",
+			Expected: []uuid.UUID{aliceDev, bobDev},
+		},
+		{
+			Name:     "RegexAndTerm",
+			Query:    "owner:bob /^(dev|prod)-/",
+			Expected: []uuid.UUID{bobDev, bobProd},
+		},
+		{
+			Name:     "NotAndTerm",
+			Query:    "owner:alice -template:docker",
+			Expected: []uuid.UUID{aliceProd},
+		},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(t *testing.T) {
+			t.Parallel()
+			filter, errs := searchquery.Workspaces(context.Background(), db, c.Query, codersdk.Pagination{}, 0)
+			require.Empty(t, errs)
+			rows, err := db.GetWorkspaces(context.Background(), filter)
+			require.NoError(t, err)
+			ids := make([]uuid.UUID, 0, len(rows))
+			for _, row := range rows {
+				ids = append(ids, row.ID)
+			}
+			require.ElementsMatch(t, c.Expected, ids)
+		})
+	}
+}
+
+func TestSearchWorkspaceExpressionLimit(t *testing.T) {
+	t.Parallel()
+
+	db := dbmem.New()
+	org := dbgen.Organization(t, db, database.Organization{})
+	alice := dbgen.User(t, db, database.User{Username: "alice"})
+	bob := dbgen.User(t, db, database.User{Username: "bob"})
+	template := dbgen.Template(t, db, database.Template{OrganizationID: org.ID, CreatedBy: alice.ID})
+	for i := 0; i <= 1000; i++ {
+		dbgen.Workspace(t, db, database.WorkspaceTable{
+			OwnerID:        alice.ID,
+			OrganizationID: org.ID,
+			TemplateID:     template.ID,
+		})
+	}
+	bobDev := dbgen.Workspace(t, db, database.WorkspaceTable{
+		OwnerID:        bob.ID,
+		OrganizationID: org.ID,
+		TemplateID:     template.ID,
+		Name:           "dev",
+	})
+
+	// Too many workspaces are left to evaluate the expression on.
+	_, errs := searchquery.Workspaces(context.Background(), db, "-name:dev", codersdk.Pagination{}, 0)
+	require.Len(t, errs, 1)
+	require.Contains(t, errs[0].Detail, "can search at most 1000 workspaces")
+
+	// Terms outside of boolean operators narrow the workspaces down.
+	filter, errs := searchquery.Workspaces(context.Background(), db, "owner:bob /^dev$/", codersdk.Pagination{}, 0)
+	require.Empty(t, errs)
+	require.Equal(t, []uuid.UUID{bobDev.ID}, filter.WorkspaceIds)
+}
+
 func TestSearchAudit(t *testing.T) {
 	t.Parallel()
 	testCases := []struct {
@@ -648,3 +795,71 @@ func TestSearchTemplates(t *testing.T) {
 		})
 	}
 }
+
+func TestSearchWorkspaceBuildEvents(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		Name                  string
+		Query                 string
+		Expected              database.GetWorkspaceBuildEventsParams
+		ExpectedErrorContains string
+	}{
+		{
+			Name:     "Empty",
+			Query:    "",
+			Expected: database.GetWorkspaceBuildEventsParams{},
+		},
+		{
+			Name:  "OnlyWorkspace",
+			Query: "My-Workspace",
+			Expected: database.GetWorkspaceBuildEventsParams{
+				WorkspaceName: "my-workspace",
+			},
+		},
+		{
+			Name:  "FailedStarts",
+			Query: "template:docker transition:start status:failed",
+			Expected: database.GetWorkspaceBuildEventsParams{
+				TemplateName: "docker",
+				Transition:   string(database.WorkspaceTransitionStart),
+				JobStatus:    string(database.ProvisionerJobStatusFailed),
+			},
+		},
+		{
+			Name:  "OwnerAndReason",
+			Query: "owner:Alice build_reason:autostop",
+			Expected: database.GetWorkspaceBuildEventsParams{
+				OwnerUsername: "alice",
+				BuildReason:   string(database.BuildReasonAutostop),
+			},
+		},
+		{
+			Name:                  "InvalidTransition",
+			Query:                 "transition:restart",
+			ExpectedErrorContains: "transition",
+		},
+		{
+			Name:                  "UnknownKey",
+			Query:                 "action:create",
+			ExpectedErrorContains: "action",
+		},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(t *testing.T) {
+			t.Parallel()
+			values, errs := searchquery.WorkspaceBuildEvents(c.Query)
+			if c.ExpectedErrorContains != "" {
+				require.True(t, len(errs) > 0, "expect some errors")
+				var s strings.Builder
+				for _, err := range errs {
+					_, _ = s.WriteString(fmt.Sprintf("%s: %s\n", err.Field, err.Detail))
+				}
+				require.Contains(t, s.String(), c.ExpectedErrorContains)
+			} else {
+				require.Len(t, errs, 0, "expected no error")
+				require.Equal(t, c.Expected, values, "expected values")
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package searchquery
+
+import (
+	"context"
+	"sort"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/v2/coderd/database"
+	"github.com/coder/coder/v2/coderd/database/dbauthz"
+)
+
+// maxSearchedWorkspaces bounds the workspaces that boolean operators and
+// regular expressions are evaluated on, as they are matched in memory.
+const maxSearchedWorkspaces = 1000
+
+// workspaceSearch evaluates boolean workspace queries. They are evaluated on
+// the workspaces matching the terms outside of boolean operators. Every term
+// is a query for the IDs of those workspaces matching it, which are combined
+// in memory.
+type workspaceSearch struct {
+	db database.Store
+	// filters are the filters of the terms of the query.
+	filters map[*expression]database.GetWorkspacesParams
+	// workspaces are the workspaces the query is evaluated on.
+	workspaces []database.GetWorkspacesRow
+	ids        []uuid.UUID
+}
+
+type workspaceIDs map[uuid.UUID]struct{}
+
+// newWorkspaceSearch fetches the workspaces matching scope, which boolean
+// expressions are evaluated on. Queries scoping more than
+// maxSearchedWorkspaces workspaces are rejected.
+func newWorkspaceSearch(ctx context.Context, db database.Store, filters map[*expression]database.GetWorkspacesParams, scope database.GetWorkspacesParams) (*workspaceSearch, error) {
+	scope.Offset = 0
+	scope.Limit = maxSearchedWorkspaces + 1
+	rows, err := getWorkspaces(ctx, db, scope)
+	if err != nil {
+		return nil, xerrors.Errorf("get workspaces: %w", err)
+	}
+	if len(rows) > maxSearchedWorkspaces {
+		return nil, xerrors.Errorf("boolean operators and regular expressions can search at most %d workspaces, narrow the query down with terms outside of them, e.g. owner:me", maxSearchedWorkspaces)
+	}
+	ids := make([]uuid.UUID, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.ID)
+	}
+	return &workspaceSearch{
+		db:         db,
+		filters:    filters,
+		workspaces: rows,
+		ids:        ids,
+	}, nil
+}
+
+func (s *workspaceSearch) match(ctx context.Context, e *expression) (workspaceIDs, error) {
+	switch e.op {
+	case expressionTerm:
+		filter, ok := s.filters[e]
+		if !ok {
+			return nil, xerrors.Errorf("term %q was not parsed", e.term)
+		}
+		filter.WorkspaceIds = s.within(filter.WorkspaceIds)
+		if len(filter.WorkspaceIds) == 0 {
+			return workspaceIDs{}, nil
+		}
+		rows, err := getWorkspaces(ctx, s.db, filter)
+		if err != nil {
+			return nil, xerrors.Errorf("get workspaces matching %q: %w", e.term, err)
+		}
+		ids := make(workspaceIDs, len(rows))
+		for _, row := range rows {
+			ids[row.ID] = struct{}{}
+		}
+		return ids, nil
+	case expressionRegex:
+		ids := make(workspaceIDs)
+		for _, row := range s.workspaces {
+			if e.regex.MatchString(row.Name) {
+				ids[row.ID] = struct{}{}
+			}
+		}
+		return ids, nil
+	case expressionNot:
+		matched, err := s.match(ctx, e.children[0])
+		if err != nil {
+			return nil, err
+		}
+		ids := make(workspaceIDs)
+		for _, id := range s.ids {
+			if _, ok := matched[id]; !ok {
+				ids[id] = struct{}{}
+			}
+		}
+		return ids, nil
+	case expressionAnd:
+		var ids workspaceIDs
+		for i, child := range e.children {
+			matched, err := s.match(ctx, child)
+			if err != nil {
+				return nil, err
+			}
+			if i == 0 {
+				ids = matched
+				continue
+			}
+			for id := range ids {
+				if _, ok := matched[id]; !ok {
+					delete(ids, id)
+				}
+			}
+			if len(ids) == 0 {
+				break
+			}
+		}
+		return ids, nil
+	case expressionOr:
+		ids := make(workspaceIDs)
+		for _, child := range e.children {
+			matched, err := s.match(ctx, child)
+			if err != nil {
+				return nil, err
+			}
+			for id := range matched {
+				ids[id] = struct{}{}
+			}
+		}
+		return ids, nil
+	default:
+		return nil, xerrors.Errorf("unknown expression %d", e.op)
+	}
+}
+
+// within returns the IDs of the searched workspaces, limited to the IDs of a
+// term if it has any.
+func (s *workspaceSearch) within(ids []uuid.UUID) []uuid.UUID {
+	if len(ids) == 0 {
+		return s.ids
+	}
+	allowed := make(workspaceIDs, len(ids))
+	for _, id := range ids {
+		allowed[id] = struct{}{}
+	}
+	within := make([]uuid.UUID, 0, len(ids))
+	for _, id := range s.ids {
+		if _, ok := allowed[id]; ok {
+			within = append(within, id)
+		}
+	}
+	return within
+}
+
+// getWorkspaces resolves the "me" owner of a filter to the actor before
+// querying it. The caller resolves it for the filter it gets, but boolean
+// expressions are evaluated before that.
+func getWorkspaces(ctx context.Context, db database.Store, filter database.GetWorkspacesParams) ([]database.GetWorkspacesRow, error) {
+	if filter.OwnerUsername == "me" {
+		actor, ok := dbauthz.ActorFromContext(ctx)
+		if !ok {
+			return nil, xerrors.New("owner \"me\" requires an authenticated user")
+		}
+		ownerID, err := uuid.Parse(actor.ID)
+		if err != nil {
+			return nil, xerrors.Errorf("parse actor ID: %w", err)
+		}
+		filter.OwnerID = ownerID
+		filter.OwnerUsername = ""
+	}
+	return db.GetWorkspaces(ctx, filter)
+}
+
+// restrict returns the workspace IDs to filter on, given the IDs of the `id`
+// terms and the IDs matching the boolean expressions. An empty list doesn't
+// filter, so the nil UUID is returned when nothing matches.
+func (*workspaceSearch) restrict(filtered []uuid.UUID, matched workspaceIDs) []uuid.UUID {
+	if len(filtered) > 0 {
+		allowed := make(workspaceIDs, len(filtered))
+		for _, id := range filtered {
+			allowed[id] = struct{}{}
+		}
+		for id := range matched {
+			if _, ok := allowed[id]; !ok {
+				delete(matched, id)
+			}
+		}
+	}
+	if len(matched) == 0 {
+		return []uuid.UUID{uuid.Nil}
+	}
+	ids := make([]uuid.UUID, 0, len(matched))
+	for id := range matched {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i].String() < ids[j].String() })
+	return ids
+}
@@ -715,6 +715,38 @@ func (api *API) patchTemplateMeta(rw http.ResponseWriter, r *http.Request) {
 	if req.TimeTilDormantAutoDeleteMillis < 0 || (req.TimeTilDormantAutoDeleteMillis > 0 && req.TimeTilDormantAutoDeleteMillis < minTTL) {
 		validErrs = append(validErrs, codersdk.ValidationError{Field: "time_til_dormant_autodelete_ms", Detail: "Value must be at least one minute."})
 	}
+	// Defaults to the existing. Every check plans the workspace, so they
+	// cannot run more often than every five minutes.
+	const minDriftCheckInterval = 1000 * 60 * 5
+	driftCheckInterval := template.DriftCheckIntervalMs
+	if req.DriftCheckIntervalMillis != nil {
+		driftCheckInterval = *req.DriftCheckIntervalMillis
+	}
+	if driftCheckInterval < 0 || (driftCheckInterval > 0 && driftCheckInterval < minDriftCheckInterval) {
+		validErrs = append(validErrs, codersdk.ValidationError{Field: "drift_check_interval_ms", Detail: "Value must be at least five minutes."})
+	}
+	// Defaults to the existing. Versions are stored in canonical form, as
+	// they are matched against the tags of provisioner daemons.
+	provisionerVersion := template.ProvisionerVersion
+	if req.ProvisionerVersion != nil {
+		provisionerVersion = *req.ProvisionerVersion
+		if provisionerVersion != "" {
+			switch template.Provisioner {
+			case database.ProvisionerTypeTerraform, database.ProvisionerTypeTofu:
+				provisionerVersion, err = codersdk.CanonicalProvisionerVersion(provisionerVersion)
+				if err != nil {
+					validErrs = append(validErrs, codersdk.ValidationError{Field: "provisioner_version", Detail: err.Error()})
+				}
+			default:
+				validErrs = append(validErrs, codersdk.ValidationError{Field: "provisioner_version", Detail: fmt.Sprintf("The %s provisioner does not support versions.", template.Provisioner)})
+			}
+		}
+	}
+	// Dynamic parameters are evaluated from the Terraform files of the
+	// template, which external templates don't have.
+	if req.UseClassicParameterFlow != nil && !*req.UseClassicParameterFlow && template.Provisioner == database.ProvisionerTypeExternal {
+		validErrs = append(validErrs, codersdk.ValidationError{Field: "use_classic_parameter_flow", Detail: "The external provisioner only supports the classic parameter flow."})
+	}
 	maxPortShareLevel := template.MaxPortSharingLevel
 	if req.MaxPortShareLevel != nil && *req.MaxPortShareLevel != portSharer.ConvertMaxLevel(template.MaxPortSharingLevel) {
 		err := portSharer.ValidateTemplateMaxLevel(*req.MaxPortShareLevel)
@@ -759,6 +791,8 @@ func (api *API) patchTemplateMeta(rw http.ResponseWriter, r *http.Request) {
 			req.RequireActiveVersion == template.RequireActiveVersion &&
 			(deprecationMessage == template.Deprecated) &&
 			(classicTemplateFlow == template.UseClassicParameterFlow) &&
+			driftCheckInterval == template.DriftCheckIntervalMs &&
+			provisionerVersion == template.ProvisionerVersion &&
 			maxPortShareLevel == template.MaxPortSharingLevel {
 			return nil
 		}
@@ -801,6 +835,8 @@ func (api *API) patchTemplateMeta(rw http.ResponseWriter, r *http.Request) {
 			GroupACL:                     groupACL,
 			MaxPortSharingLevel:          maxPortShareLevel,
 			UseClassicParameterFlow:      classicTemplateFlow,
+			DriftCheckIntervalMs:         driftCheckInterval,
+			ProvisionerVersion:           provisionerVersion,
 		})
 		if err != nil {
 			return xerrors.Errorf("update template metadata: %w", err)
@@ -1079,11 +1115,13 @@ func (api *API) convertTemplate(
 			DaysOfWeek: codersdk.BitmapToWeekdays(template.AutostartAllowedDays()),
 		},
 		// These values depend on entitlements and come from the templateAccessControl
-		RequireActiveVersion:    templateAccessControl.RequireActiveVersion,
-		Deprecated:              templateAccessControl.IsDeprecated(),
-		DeprecationMessage:      templateAccessControl.Deprecated,
-		MaxPortShareLevel:       maxPortShareLevel,
-		UseClassicParameterFlow: template.UseClassicParameterFlow,
+		RequireActiveVersion:     templateAccessControl.RequireActiveVersion,
+		Deprecated:               templateAccessControl.IsDeprecated(),
+		DeprecationMessage:       templateAccessControl.Deprecated,
+		MaxPortShareLevel:        maxPortShareLevel,
+		UseClassicParameterFlow:  template.UseClassicParameterFlow,
+		DriftCheckIntervalMillis: template.DriftCheckIntervalMs,
+		ProvisionerVersion:       template.ProvisionerVersion,
 	}
 }
 
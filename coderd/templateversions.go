@@ -493,6 +493,39 @@ func (api *API) postTemplateVersionDryRun(rw http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	// Dry-runs of a workspace preview its update to the template version, so
+	// they need the same permissions as the update itself.
+	var workspace database.Workspace
+	if req.WorkspaceID != uuid.Nil {
+		workspace, err = api.Database.GetWorkspaceByID(ctx, req.WorkspaceID)
+		if httpapi.Is404Error(err) {
+			httpapi.Write(ctx, rw, http.StatusNotFound, codersdk.Response{
+				Message: fmt.Sprintf("Workspace %q not found.", req.WorkspaceID),
+			})
+			return
+		}
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+				Message: "Internal error fetching workspace.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+		if !api.Authorize(r, policy.ActionUpdate, workspace) {
+			httpapi.ResourceNotFound(rw)
+			return
+		}
+		if !templateVersion.TemplateID.Valid || workspace.TemplateID != templateVersion.TemplateID.UUID {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: "Template version does not belong to the template of the workspace.",
+			})
+			return
+		}
+		if req.WorkspaceName == "" {
+			req.WorkspaceName = workspace.Name
+		}
+	}
+
 	richParameterValues := make([]database.WorkspaceBuildParameter, len(req.RichParameterValues))
 	for i, v := range req.RichParameterValues {
 		richParameterValues[i] = database.WorkspaceBuildParameter{
@@ -508,6 +541,7 @@ func (api *API) postTemplateVersionDryRun(rw http.ResponseWriter, r *http.Reques
 		TemplateVersionID:   templateVersion.ID,
 		WorkspaceName:       req.WorkspaceName,
 		RichParameterValues: richParameterValues,
+		WorkspaceID:         req.WorkspaceID,
 	})
 	if err != nil {
 		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
@@ -528,24 +562,46 @@ func (api *API) postTemplateVersionDryRun(rw http.ResponseWriter, r *http.Reques
 
 	// Create a dry-run job
 	jobID := uuid.New()
-	provisionerJob, err := api.Database.InsertProvisionerJob(ctx, database.InsertProvisionerJobParams{
-		ID:             jobID,
-		CreatedAt:      dbtime.Now(),
-		UpdatedAt:      dbtime.Now(),
-		OrganizationID: templateVersion.OrganizationID,
-		InitiatorID:    apiKey.UserID,
-		Provisioner:    job.Provisioner,
-		StorageMethod:  job.StorageMethod,
-		FileID:         job.FileID,
-		Type:           database.ProvisionerJobTypeTemplateVersionDryRun,
-		Input:          input,
-		// Copy tags from the previous run.
-		Tags: job.Tags,
-		TraceMetadata: pqtype.NullRawMessage{
-			Valid:      true,
-			RawMessage: metadataRaw,
-		},
-	})
+	var provisionerJob database.ProvisionerJob
+	err = api.Database.InTx(func(tx database.Store) error {
+		now := dbtime.Now()
+		provisionerJob, err = tx.InsertProvisionerJob(ctx, database.InsertProvisionerJobParams{
+			ID:             jobID,
+			CreatedAt:      now,
+			UpdatedAt:      now,
+			OrganizationID: templateVersion.OrganizationID,
+			InitiatorID:    apiKey.UserID,
+			Provisioner:    job.Provisioner,
+			StorageMethod:  job.StorageMethod,
+			FileID:         job.FileID,
+			Type:           database.ProvisionerJobTypeTemplateVersionDryRun,
+			Input:          input,
+			// Copy tags from the previous run.
+			Tags: job.Tags,
+			TraceMetadata: pqtype.NullRawMessage{
+				Valid:      true,
+				RawMessage: metadataRaw,
+			},
+			// The user is waiting for the results of the dry-run.
+			Priority:   database.ProvisionerJobPriorityInteractive,
+			TemplateID: templateVersion.TemplateID,
+		})
+		if err != nil {
+			return xerrors.Errorf("insert provisioner job: %w", err)
+		}
+		if req.WorkspaceID == uuid.Nil {
+			return nil
+		}
+		_, err = tx.InsertWorkspaceUpdatePreview(ctx, database.InsertWorkspaceUpdatePreviewParams{
+			JobID:       jobID,
+			WorkspaceID: workspace.ID,
+			CreatedAt:   now,
+		})
+		if err != nil {
+			return xerrors.Errorf("insert workspace update preview: %w", err)
+		}
+		return nil
+	}, nil)
 	if err != nil {
 		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
 			Message: "Internal error inserting provisioner job.",
@@ -639,6 +695,66 @@ func (api *API) templateVersionDryRunResources(rw http.ResponseWriter, r *http.R
 	api.provisionerJobResources(rw, r, job.ProvisionerJob)
 }
 
+// @Summary Get template version dry-run resource changes by job ID
+// @ID get-template-version-dry-run-resource-changes-by-job-id
+// @Security CoderSessionToken
+// @Produce json
+// @Tags Templates
+// @Param templateversion path string true "Template version ID" format(uuid)
+// @Param jobID path string true "Job ID" format(uuid)
+// @Success 200 {object} codersdk.WorkspaceUpdatePreview
+// @Router /templateversions/{templateversion}/dry-run/{jobID}/resource-changes [get]
+func (api *API) templateVersionDryRunResourceChanges(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	job, ok := api.fetchTemplateVersionDryRunJob(rw, r)
+	if !ok {
+		return
+	}
+
+	preview, err := api.Database.GetWorkspaceUpdatePreviewByJobID(ctx, job.ProvisionerJob.ID)
+	if httpapi.Is404Error(err) {
+		httpapi.Write(ctx, rw, http.StatusNotFound, codersdk.Response{
+			Message: "Dry-run did not preview a workspace update.",
+		})
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace update preview.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	apiPreview, err := convertWorkspaceUpdatePreview(preview)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error converting workspace update preview.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, apiPreview)
+}
+
+func convertWorkspaceUpdatePreview(preview database.WorkspaceUpdatePreview) (codersdk.WorkspaceUpdatePreview, error) {
+	apiPreview := codersdk.WorkspaceUpdatePreview{
+		WorkspaceID: preview.WorkspaceID,
+		CreatedAt:   preview.CreatedAt,
+		Changes:     []codersdk.WorkspaceResourceChange{},
+	}
+	if preview.CompletedAt.Valid {
+		apiPreview.CompletedAt = &preview.CompletedAt.Time
+	}
+	if preview.ResourceChanges.Valid {
+		err := json.Unmarshal(preview.ResourceChanges.RawMessage, &apiPreview.Changes)
+		if err != nil {
+			return codersdk.WorkspaceUpdatePreview{}, xerrors.Errorf("unmarshal resource changes: %w", err)
+		}
+	}
+	return apiPreview, nil
+}
+
 // @Summary Get template version dry-run logs by job ID
 // @ID get-template-version-dry-run-logs-by-job-id
 // @Security CoderSessionToken
@@ -1464,8 +1580,11 @@ func (api *API) postTemplateVersionsByOrganization(rw http.ResponseWriter, r *ht
 		return
 	}
 
+	// The provisioner version pinned by the template, if the version is
+	// created for one.
+	var provisionerVersion string
 	if req.TemplateID != uuid.Nil {
-		_, err := api.Database.GetTemplateByID(ctx, req.TemplateID)
+		template, err := api.Database.GetTemplateByID(ctx, req.TemplateID)
 		if httpapi.Is404Error(err) {
 			httpapi.Write(ctx, rw, http.StatusNotFound, codersdk.Response{
 				Message: "Template does not exist.",
@@ -1479,6 +1598,9 @@ func (api *API) postTemplateVersionsByOrganization(rw http.ResponseWriter, r *ht
 			})
 			return
 		}
+		if string(template.Provisioner) == string(req.Provisioner) {
+			provisionerVersion = template.ProvisionerVersion
+		}
 	}
 
 	if req.ExampleID != "" && req.FileID != uuid.Nil {
@@ -1619,6 +1741,10 @@ func (api *API) postTemplateVersionsByOrganization(rw http.ResponseWriter, r *ht
 	// User-specified tags in the request will take precedence over tags parsed from `coder_workspace_tags`
 	// data sources defined in the template file.
 	tags := provisionersdk.MutateTags(apiKey.UserID, parsedTags, req.ProvisionerTags)
+	// Provisioner version tags are managed by Coder, and replaced by the one
+	// of the version the template pins. Tags reused from a previous version
+	// must not route the import to daemons of an outdated version.
+	tags = provisionersdk.WithProvisionerVersion(tags, string(req.Provisioner), provisionerVersion)
 
 	var templateVersion database.TemplateVersion
 	var provisionerJob database.ProvisionerJob
@@ -1664,6 +1790,11 @@ func (api *API) postTemplateVersionsByOrganization(rw http.ResponseWriter, r *ht
 				Valid:      true,
 				RawMessage: traceMetadataRaw,
 			},
+			Priority: database.ProvisionerJobPriorityTemplateImport,
+			TemplateID: uuid.NullUUID{
+				UUID:  req.TemplateID,
+				Valid: req.TemplateID != uuid.Nil,
+			},
 		})
 		if err != nil {
 			httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
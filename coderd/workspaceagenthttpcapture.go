@@ -0,0 +1,195 @@
+package coderd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/coder/coder/v2/coderd/httpapi"
+	"github.com/coder/coder/v2/coderd/httpmw"
+	"github.com/coder/coder/v2/coderd/rbac/policy"
+	"github.com/coder/coder/v2/codersdk"
+	"github.com/coder/coder/v2/codersdk/workspacesdk"
+)
+
+// @Summary List HTTP captures for workspace agent
+// @ID list-http-captures-for-workspace-agent
+// @Security CoderSessionToken
+// @Produce json
+// @Tags Agents
+// @Param workspaceagent path string true "Workspace agent ID" format(uuid)
+// @Success 200 {array} codersdk.WorkspaceAgentHTTPCapture
+// @Router /workspaceagents/{workspaceagent}/http-captures [get]
+func (api *API) workspaceAgentHTTPCaptures(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	agentConn, release, ok := api.workspaceAgentHTTPCaptureConn(rw, r)
+	if !ok {
+		return
+	}
+	defer release()
+
+	captures, err := agentConn.HTTPCaptures(ctx)
+	if err != nil {
+		writeWorkspaceAgentHTTPCaptureError(ctx, rw, "listing HTTP captures", err)
+		return
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, captures)
+}
+
+// @Summary Start HTTP capture for workspace agent
+// @ID start-http-capture-for-workspace-agent
+// @Security CoderSessionToken
+// @Accept json
+// @Produce json
+// @Tags Agents
+// @Param workspaceagent path string true "Workspace agent ID" format(uuid)
+// @Param request body codersdk.WorkspaceAgentHTTPCaptureRequest true "Capture request"
+// @Success 201 {object} codersdk.WorkspaceAgentHTTPCapture
+// @Router /workspaceagents/{workspaceagent}/http-captures [post]
+func (api *API) postWorkspaceAgentHTTPCapture(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req codersdk.WorkspaceAgentHTTPCaptureRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+
+	agentConn, release, ok := api.workspaceAgentHTTPCaptureConn(rw, r)
+	if !ok {
+		return
+	}
+	defer release()
+
+	capture, err := agentConn.StartHTTPCapture(ctx, req)
+	if err != nil {
+		writeWorkspaceAgentHTTPCaptureError(ctx, rw, "starting HTTP capture", err)
+		return
+	}
+	httpapi.Write(ctx, rw, http.StatusCreated, capture)
+}
+
+// @Summary Stop HTTP capture for workspace agent
+// @ID stop-http-capture-for-workspace-agent
+// @Security CoderSessionToken
+// @Produce json
+// @Tags Agents
+// @Param workspaceagent path string true "Workspace agent ID" format(uuid)
+// @Param port path int true "Port"
+// @Success 200 {object} codersdk.WorkspaceAgentHTTPCapture
+// @Router /workspaceagents/{workspaceagent}/http-captures/{port}/stop [post]
+func (api *API) postWorkspaceAgentHTTPCaptureStop(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	port, ok := parseWorkspaceAgentHTTPCapturePort(rw, r)
+	if !ok {
+		return
+	}
+	agentConn, release, ok := api.workspaceAgentHTTPCaptureConn(rw, r)
+	if !ok {
+		return
+	}
+	defer release()
+
+	capture, err := agentConn.StopHTTPCapture(ctx, port)
+	if err != nil {
+		writeWorkspaceAgentHTTPCaptureError(ctx, rw, "stopping HTTP capture", err)
+		return
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, capture)
+}
+
+// @Summary Delete HTTP capture for workspace agent
+// @ID delete-http-capture-for-workspace-agent
+// @Security CoderSessionToken
+// @Tags Agents
+// @Param workspaceagent path string true "Workspace agent ID" format(uuid)
+// @Param port path int true "Port"
+// @Success 204
+// @Router /workspaceagents/{workspaceagent}/http-captures/{port} [delete]
+func (api *API) deleteWorkspaceAgentHTTPCapture(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	port, ok := parseWorkspaceAgentHTTPCapturePort(rw, r)
+	if !ok {
+		return
+	}
+	agentConn, release, ok := api.workspaceAgentHTTPCaptureConn(rw, r)
+	if !ok {
+		return
+	}
+	defer release()
+
+	if err := agentConn.DeleteHTTPCapture(ctx, port); err != nil {
+		writeWorkspaceAgentHTTPCaptureError(ctx, rw, "deleting HTTP capture", err)
+		return
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Get HTTP capture as HAR for workspace agent
+// @ID get-http-capture-as-har-for-workspace-agent
+// @Security CoderSessionToken
+// @Produce json
+// @Tags Agents
+// @Param workspaceagent path string true "Workspace agent ID" format(uuid)
+// @Param port path int true "Port"
+// @Success 200 {object} codersdk.HAR
+// @Router /workspaceagents/{workspaceagent}/http-captures/{port}/har [get]
+func (api *API) workspaceAgentHTTPCaptureHAR(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	port, ok := parseWorkspaceAgentHTTPCapturePort(rw, r)
+	if !ok {
+		return
+	}
+	agentConn, release, ok := api.workspaceAgentHTTPCaptureConn(rw, r)
+	if !ok {
+		return
+	}
+	defer release()
+
+	har, err := agentConn.HTTPCaptureHAR(ctx, port)
+	if err != nil {
+		writeWorkspaceAgentHTTPCaptureError(ctx, rw, "fetching HTTP capture", err)
+		return
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, har)
+}
+
+// workspaceAgentHTTPCaptureConn dials the workspace agent after checking
+// that the user may see the traffic of the workspace. Captured requests
+// can contain credentials, so this requires the same permission as
+// connecting over SSH.
+func (api *API) workspaceAgentHTTPCaptureConn(rw http.ResponseWriter, r *http.Request) (*workspacesdk.AgentConn, func(), bool) {
+	if !api.Authorize(r, policy.ActionSSH, httpmw.WorkspaceParam(r)) {
+		httpapi.ResourceNotFound(rw)
+		return nil, nil, false
+	}
+	return api.workspaceAgentConn(rw, r)
+}
+
+func parseWorkspaceAgentHTTPCapturePort(rw http.ResponseWriter, r *http.Request) (uint16, bool) {
+	port, err := strconv.ParseUint(chi.URLParam(r, "port"), 10, 16)
+	if err != nil || port == 0 {
+		httpapi.Write(r.Context(), rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Invalid port.",
+			Validations: []codersdk.ValidationError{
+				{Field: "port", Detail: "Must be an integer between 1 and 65535."},
+			},
+		})
+		return 0, false
+	}
+	return uint16(port), true
+}
+
+// writeWorkspaceAgentHTTPCaptureError passes through errors returned by
+// the agent.
+func writeWorkspaceAgentHTTPCaptureError(ctx context.Context, rw http.ResponseWriter, action string, err error) {
+	if cerr, ok := codersdk.AsError(err); ok {
+		httpapi.Write(ctx, rw, cerr.StatusCode(), cerr.Response)
+		return
+	}
+	httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+		Message: fmt.Sprintf("Internal error %s.", action),
+		Detail:  err.Error(),
+	})
+}
@@ -3,9 +3,12 @@ package coderd
 import (
 	"database/sql"
 	"errors"
+	"fmt"
 	"net/http"
 	"slices"
 
+	"github.com/google/uuid"
+
 	"github.com/coder/coder/v2/coderd/database"
 	"github.com/coder/coder/v2/coderd/httpapi"
 	"github.com/coder/coder/v2/coderd/httpmw"
@@ -182,6 +185,145 @@ func (api *API) deleteWorkspaceAgentPortShare(rw http.ResponseWriter, r *http.Re
 	rw.WriteHeader(http.StatusOK)
 }
 
+// @Summary Get template port share rules
+// @ID get-template-port-share-rules
+// @Security CoderSessionToken
+// @Produce json
+// @Tags PortSharing
+// @Param template path string true "Template ID" format(uuid)
+// @Success 200 {object} codersdk.TemplatePortShareRules
+// @Router /templates/{template}/port-share-rules [get]
+func (api *API) templatePortShareRules(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	template := httpmw.TemplateParam(r)
+
+	rules, err := api.Database.GetTemplatePortShareRules(ctx, template.ID)
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, codersdk.TemplatePortShareRules{
+		Rules: convertTemplatePortShareRules(rules),
+	})
+}
+
+// @Summary Update template port share rules
+// @ID update-template-port-share-rules
+// @Security CoderSessionToken
+// @Accept json
+// @Produce json
+// @Tags PortSharing
+// @Param template path string true "Template ID" format(uuid)
+// @Param request body codersdk.UpdateTemplatePortShareRulesRequest true "Update port share rules request"
+// @Success 200 {object} codersdk.TemplatePortShareRules
+// @Router /templates/{template}/port-share-rules [put]
+func (api *API) putTemplatePortShareRules(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	template := httpmw.TemplateParam(r)
+	portSharer := *api.PortSharer.Load()
+	var req codersdk.UpdateTemplatePortShareRulesRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+
+	var validations []codersdk.ValidationError
+	for i, rule := range req.Rules {
+		field := fmt.Sprintf("rules[%d]", i)
+		if rule.PortStart < 9 || rule.PortEnd > 65535 || rule.PortStart > rule.PortEnd {
+			validations = append(validations, codersdk.ValidationError{
+				Field:  field + ".port_start",
+				Detail: "Port range must be between 9 and 65535 and start must not be after end.",
+			})
+		}
+		if !rule.ShareLevel.ValidPortShareLevel() {
+			validations = append(validations, codersdk.ValidationError{
+				Field:  field + ".share_level",
+				Detail: "Port sharing level not allowed.",
+			})
+		} else if err := portSharer.AuthorizedLevel(template, rule.ShareLevel); err != nil {
+			validations = append(validations, codersdk.ValidationError{
+				Field:  field + ".share_level",
+				Detail: err.Error(),
+			})
+		}
+		if !rule.Protocol.ValidPortProtocol() {
+			validations = append(validations, codersdk.ValidationError{
+				Field:  field + ".protocol",
+				Detail: "Port protocol not allowed.",
+			})
+		}
+		for j, other := range req.Rules[:i] {
+			if rule.PortStart <= other.PortEnd && other.PortStart <= rule.PortEnd {
+				validations = append(validations, codersdk.ValidationError{
+					Field:  field + ".port_start",
+					Detail: fmt.Sprintf("Port range overlaps with rules[%d].", j),
+				})
+			}
+		}
+	}
+	if len(validations) > 0 {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message:     "Invalid port share rules.",
+			Validations: validations,
+		})
+		return
+	}
+
+	var rules []database.TemplatePortShareRule
+	err := api.Database.InTx(func(tx database.Store) error {
+		err := tx.DeleteTemplatePortShareRules(ctx, template.ID)
+		if err != nil {
+			return err
+		}
+		for _, rule := range req.Rules {
+			inserted, err := tx.InsertTemplatePortShareRule(ctx, database.InsertTemplatePortShareRuleParams{
+				ID:         uuid.New(),
+				TemplateID: template.ID,
+				PortStart:  rule.PortStart,
+				PortEnd:    rule.PortEnd,
+				ShareLevel: database.AppSharingLevel(rule.ShareLevel),
+				Protocol:   database.PortShareProtocol(rule.Protocol),
+				Label:      rule.Label,
+			})
+			if err != nil {
+				return err
+			}
+			rules = append(rules, inserted)
+		}
+		return nil
+	}, nil)
+	if httpapi.Is404Error(err) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, codersdk.TemplatePortShareRules{
+		Rules: convertTemplatePortShareRules(rules),
+	})
+}
+
+func convertTemplatePortShareRules(rules []database.TemplatePortShareRule) []codersdk.TemplatePortShareRule {
+	converted := []codersdk.TemplatePortShareRule{}
+	for _, rule := range rules {
+		converted = append(converted, codersdk.TemplatePortShareRule{
+			PortStart:  rule.PortStart,
+			PortEnd:    rule.PortEnd,
+			ShareLevel: codersdk.WorkspaceAgentPortShareLevel(rule.ShareLevel),
+			Protocol:   codersdk.WorkspaceAgentPortShareProtocol(rule.Protocol),
+			Label:      rule.Label,
+		})
+	}
+	slices.SortFunc(converted, func(i, j codersdk.TemplatePortShareRule) int {
+		return (int)(i.PortStart - j.PortStart)
+	})
+	return converted
+}
+
 func convertPortShares(shares []database.WorkspaceAgentPortShare) []codersdk.WorkspaceAgentPortShare {
 	converted := []codersdk.WorkspaceAgentPortShare{}
 	for _, share := range shares {
@@ -200,5 +342,7 @@ func convertPortShare(share database.WorkspaceAgentPortShare) codersdk.Workspace
 		Port:        share.Port,
 		ShareLevel:  codersdk.WorkspaceAgentPortShareLevel(share.ShareLevel),
 		Protocol:    codersdk.WorkspaceAgentPortShareProtocol(share.Protocol),
+		AutoShared:  share.AutoShared,
+		Label:       share.Label,
 	}
 }
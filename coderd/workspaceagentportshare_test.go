@@ -218,3 +218,62 @@ func TestDeleteWorkspaceAgentPortShare(t *testing.T) {
 	})
 	require.Error(t, err)
 }
+
+func TestTemplatePortShareRules(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+	client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+	owner := coderdtest.CreateFirstUser(t, client)
+	version := coderdtest.CreateTemplateVersion(t, client, owner.OrganizationID, nil)
+	coderdtest.AwaitTemplateVersionJobCompleted(t, client, version.ID)
+	template := coderdtest.CreateTemplate(t, client, owner.OrganizationID, version.ID)
+
+	rules, err := client.TemplatePortShareRules(ctx, template.ID)
+	require.NoError(t, err)
+	require.Empty(t, rules.Rules)
+
+	// overlapping ranges should fail
+	_, err = client.UpdateTemplatePortShareRules(ctx, template.ID, codersdk.UpdateTemplatePortShareRulesRequest{
+		Rules: []codersdk.TemplatePortShareRule{
+			{PortStart: 3000, PortEnd: 3999, ShareLevel: codersdk.WorkspaceAgentPortShareLevelOrganization, Protocol: codersdk.WorkspaceAgentPortShareProtocolHTTP},
+			{PortStart: 3500, PortEnd: 3500, ShareLevel: codersdk.WorkspaceAgentPortShareLevelPublic, Protocol: codersdk.WorkspaceAgentPortShareProtocolHTTP},
+		},
+	})
+	require.Error(t, err)
+
+	// owner level should fail
+	_, err = client.UpdateTemplatePortShareRules(ctx, template.ID, codersdk.UpdateTemplatePortShareRulesRequest{
+		Rules: []codersdk.TemplatePortShareRule{
+			{PortStart: 8080, PortEnd: 8080, ShareLevel: codersdk.WorkspaceAgentPortShareLevelOwner, Protocol: codersdk.WorkspaceAgentPortShareProtocolHTTP},
+		},
+	})
+	require.Error(t, err)
+
+	// inverted range should fail
+	_, err = client.UpdateTemplatePortShareRules(ctx, template.ID, codersdk.UpdateTemplatePortShareRulesRequest{
+		Rules: []codersdk.TemplatePortShareRule{
+			{PortStart: 9000, PortEnd: 8000, ShareLevel: codersdk.WorkspaceAgentPortShareLevelPublic, Protocol: codersdk.WorkspaceAgentPortShareProtocolHTTP},
+		},
+	})
+	require.Error(t, err)
+
+	want := []codersdk.TemplatePortShareRule{
+		{PortStart: 3000, PortEnd: 3999, ShareLevel: codersdk.WorkspaceAgentPortShareLevelOrganization, Protocol: codersdk.WorkspaceAgentPortShareProtocolHTTP},
+		{PortStart: 8080, PortEnd: 8080, ShareLevel: codersdk.WorkspaceAgentPortShareLevelPublic, Protocol: codersdk.WorkspaceAgentPortShareProtocolHTTPS, Label: "docs"},
+	}
+	rules, err = client.UpdateTemplatePortShareRules(ctx, template.ID, codersdk.UpdateTemplatePortShareRulesRequest{
+		Rules: []codersdk.TemplatePortShareRule{want[1], want[0]},
+	})
+	require.NoError(t, err)
+	require.Equal(t, want, rules.Rules)
+
+	rules, err = client.TemplatePortShareRules(ctx, template.ID)
+	require.NoError(t, err)
+	require.Equal(t, want, rules.Rules)
+
+	// updating replaces all rules
+	rules, err = client.UpdateTemplatePortShareRules(ctx, template.ID, codersdk.UpdateTemplatePortShareRulesRequest{})
+	require.NoError(t, err)
+	require.Empty(t, rules.Rules)
+}
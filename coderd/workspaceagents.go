@@ -899,18 +899,142 @@ func (api *API) workspaceAgentListContainers(rw http.ResponseWriter, r *http.Req
 	httpapi.Write(ctx, rw, http.StatusOK, cts)
 }
 
-// @Summary Recreate devcontainer for workspace agent
-// @ID recreate-devcontainer-for-workspace-agent
+// @Summary Get processes for workspace agent
+// @ID get-processes-for-workspace-agent
 // @Security CoderSessionToken
+// @Produce json
 // @Tags Agents
+// @Param workspaceagent path string true "Workspace agent ID" format(uuid)
+// @Param sort query string false "Sort order" Enums(cpu,memory,pid)
+// @Param limit query int false "Maximum number of processes"
+// @Success 200 {object} codersdk.WorkspaceAgentProcessesResponse
+// @Router /workspaceagents/{workspaceagent}/processes [get]
+func (api *API) workspaceAgentProcesses(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Command lines and environments of processes often contain secrets,
+	// so require the same permission as connecting over SSH.
+	workspace := httpmw.WorkspaceParam(r)
+	if !api.Authorize(r, policy.ActionSSH, workspace) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	var (
+		parser = httpapi.NewQueryParamParser()
+		opts   = codersdk.WorkspaceAgentProcessesOptions{
+			Sort:  codersdk.WorkspaceAgentProcessSort(parser.String(r.URL.Query(), "", "sort")),
+			Limit: int(parser.PositiveInt32(r.URL.Query(), 0, "limit")),
+		}
+	)
+	if opts.Sort != "" && !opts.Sort.Valid() {
+		parser.Errors = append(parser.Errors, codersdk.ValidationError{
+			Field:  "sort",
+			Detail: fmt.Sprintf("Invalid sort order %q, must be one of: cpu, memory, pid.", opts.Sort),
+		})
+	}
+	if len(parser.Errors) > 0 {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message:     "Invalid query parameters.",
+			Validations: parser.Errors,
+		})
+		return
+	}
+
+	agentConn, release, ok := api.workspaceAgentConn(rw, r)
+	if !ok {
+		return
+	}
+	defer release()
+
+	// Listing processes samples CPU usage, so allow it a little longer
+	// than an unreachable agent would take to time out.
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	procs, err := agentConn.ListProcesses(ctx, opts)
+	if err != nil {
+		if cerr, ok := codersdk.AsError(err); ok {
+			httpapi.Write(ctx, rw, cerr.StatusCode(), cerr.Response)
+			return
+		}
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching processes.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, procs)
+}
+
+// @Summary Signal process for workspace agent
+// @ID signal-process-for-workspace-agent
+// @Security CoderSessionToken
+// @Accept json
 // @Produce json
+// @Tags Agents
 // @Param workspaceagent path string true "Workspace agent ID" format(uuid)
-// @Param devcontainer path string true "Devcontainer ID"
-// @Success 202 {object} codersdk.Response
-// @Router /workspaceagents/{workspaceagent}/containers/devcontainers/{devcontainer}/recreate [post]
-func (api *API) workspaceAgentRecreateDevcontainer(rw http.ResponseWriter, r *http.Request) {
+// @Param pid path int true "Process ID"
+// @Param request body codersdk.WorkspaceAgentSignalProcessRequest true "Signal request"
+// @Success 200 {object} codersdk.Response
+// @Router /workspaceagents/{workspaceagent}/processes/{pid}/signal [post]
+func (api *API) workspaceAgentSignalProcess(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Signaling processes is equivalent to running commands in the
+	// workspace, so require the same permission as connecting over SSH.
+	workspace := httpmw.WorkspaceParam(r)
+	if !api.Authorize(r, policy.ActionSSH, workspace) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	pid, err := strconv.ParseInt(chi.URLParam(r, "pid"), 10, 32)
+	if err != nil || pid <= 0 {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Invalid process ID.",
+			Validations: []codersdk.ValidationError{
+				{Field: "pid", Detail: "Must be a positive integer."},
+			},
+		})
+		return
+	}
+
+	var req codersdk.WorkspaceAgentSignalProcessRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+
+	agentConn, release, ok := api.workspaceAgentConn(rw, r)
+	if !ok {
+		return
+	}
+	defer release()
+
+	err = agentConn.SignalProcess(ctx, int32(pid), req)
+	if err != nil {
+		if cerr, ok := codersdk.AsError(err); ok {
+			httpapi.Write(ctx, rw, cerr.StatusCode(), cerr.Response)
+			return
+		}
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error signaling process.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, codersdk.Response{
+		Message: fmt.Sprintf("Sent %s to process %d.", req.Signal, pid),
+	})
+}
+
+// workspaceAgentDevcontainerConn validates the devcontainer request and
+// dials the workspace agent. It writes an error response and returns
+// false if the agent cannot be reached.
+func (api *API) workspaceAgentDevcontainerConn(rw http.ResponseWriter, r *http.Request) (string, *workspacesdk.AgentConn, func(), bool) {
 	ctx := r.Context()
-	workspaceAgent := httpmw.WorkspaceAgentParam(r)
 
 	devcontainer := chi.URLParam(r, "devcontainer")
 	if devcontainer == "" {
@@ -920,9 +1044,23 @@ func (api *API) workspaceAgentRecreateDevcontainer(rw http.ResponseWriter, r *ht
 				{Field: "devcontainer", Detail: "Devcontainer ID is required."},
 			},
 		})
-		return
+		return "", nil, nil, false
 	}
 
+	agentConn, release, ok := api.workspaceAgentConn(rw, r)
+	if !ok {
+		return "", nil, nil, false
+	}
+	return devcontainer, agentConn, release, true
+}
+
+// workspaceAgentConn dials the workspace agent from the request. It writes
+// an error response and returns false if the agent is not connected or
+// cannot be reached.
+func (api *API) workspaceAgentConn(rw http.ResponseWriter, r *http.Request) (*workspacesdk.AgentConn, func(), bool) {
+	ctx := r.Context()
+	workspaceAgent := httpmw.WorkspaceAgentParam(r)
+
 	apiAgent, err := db2sdk.WorkspaceAgent(
 		api.DERPMap(),
 		*api.TailnetCoordinator.Load(),
@@ -938,13 +1076,13 @@ func (api *API) workspaceAgentRecreateDevcontainer(rw http.ResponseWriter, r *ht
 			Message: "Internal error reading workspace agent.",
 			Detail:  err.Error(),
 		})
-		return
+		return nil, nil, false
 	}
 	if apiAgent.Status != codersdk.WorkspaceAgentConnected {
 		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
 			Message: fmt.Sprintf("Agent state is %q, it must be in the %q state.", apiAgent.Status, codersdk.WorkspaceAgentConnected),
 		})
-		return
+		return nil, nil, false
 	}
 
 	// If the agent is unreachable, the request will hang. Assume that if we
@@ -957,32 +1095,285 @@ func (api *API) workspaceAgentRecreateDevcontainer(rw http.ResponseWriter, r *ht
 			Message: "Internal error dialing workspace agent.",
 			Detail:  err.Error(),
 		})
+		return nil, nil, false
+	}
+	return agentConn, release, true
+}
+
+// authorizeWorkspaceAgentDevcontainer checks that the user may control the
+// devcontainers of the workspace, which requires the same permission as
+// connecting to it over SSH.
+func (api *API) authorizeWorkspaceAgentDevcontainer(rw http.ResponseWriter, r *http.Request) bool {
+	if !api.Authorize(r, policy.ActionSSH, httpmw.WorkspaceParam(r)) {
+		httpapi.ResourceNotFound(rw)
+		return false
+	}
+	return true
+}
+
+// writeWorkspaceAgentDevcontainerError writes the error returned by the
+// agent for a devcontainer operation, passing through agent responses.
+func writeWorkspaceAgentDevcontainerError(ctx context.Context, rw http.ResponseWriter, action string, err error) {
+	if errors.Is(err, context.Canceled) {
+		httpapi.Write(ctx, rw, http.StatusRequestTimeout, codersdk.Response{
+			Message: fmt.Sprintf("Failed to %s devcontainer from agent.", action),
+			Detail:  "Request timed out.",
+		})
+		return
+	}
+	// If the agent returns a codersdk.Error, we can return that directly.
+	if cerr, ok := codersdk.AsError(err); ok {
+		httpapi.Write(ctx, rw, cerr.StatusCode(), cerr.Response)
+		return
+	}
+	httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+		Message: fmt.Sprintf("Internal error trying to %s devcontainer.", action),
+		Detail:  err.Error(),
+	})
+}
+
+// @Summary Recreate devcontainer for workspace agent
+// @ID recreate-devcontainer-for-workspace-agent
+// @Security CoderSessionToken
+// @Tags Agents
+// @Produce json
+// @Param workspaceagent path string true "Workspace agent ID" format(uuid)
+// @Param devcontainer path string true "Devcontainer ID"
+// @Param no_cache query bool false "Rebuild the image without the build cache"
+// @Success 202 {object} codersdk.Response
+// @Router /workspaceagents/{workspaceagent}/containers/devcontainers/{devcontainer}/recreate [post]
+func (api *API) workspaceAgentRecreateDevcontainer(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if !api.authorizeWorkspaceAgentDevcontainer(rw, r) {
+		return
+	}
+
+	noCache := false
+	if v := r.URL.Query().Get("no_cache"); v != "" {
+		var err error
+		noCache, err = strconv.ParseBool(v)
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: "Invalid no_cache query parameter.",
+				Validations: []codersdk.ValidationError{
+					{Field: "no_cache", Detail: err.Error()},
+				},
+			})
+			return
+		}
+	}
+
+	devcontainer, agentConn, release, ok := api.workspaceAgentDevcontainerConn(rw, r)
+	if !ok {
 		return
 	}
 	defer release()
 
-	m, err := agentConn.RecreateDevcontainer(ctx, devcontainer)
+	var (
+		m   codersdk.Response
+		err error
+	)
+	if noCache {
+		m, err = agentConn.RebuildDevcontainer(ctx, devcontainer)
+	} else {
+		m, err = agentConn.RecreateDevcontainer(ctx, devcontainer)
+	}
 	if err != nil {
-		if errors.Is(err, context.Canceled) {
-			httpapi.Write(ctx, rw, http.StatusRequestTimeout, codersdk.Response{
-				Message: "Failed to recreate devcontainer from agent.",
-				Detail:  "Request timed out.",
+		writeWorkspaceAgentDevcontainerError(ctx, rw, "recreate", err)
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusAccepted, m)
+}
+
+// @Summary Stop devcontainer for workspace agent
+// @ID stop-devcontainer-for-workspace-agent
+// @Security CoderSessionToken
+// @Tags Agents
+// @Produce json
+// @Param workspaceagent path string true "Workspace agent ID" format(uuid)
+// @Param devcontainer path string true "Devcontainer ID"
+// @Success 200 {object} codersdk.Response
+// @Router /workspaceagents/{workspaceagent}/containers/devcontainers/{devcontainer}/stop [post]
+func (api *API) workspaceAgentStopDevcontainer(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if !api.authorizeWorkspaceAgentDevcontainer(rw, r) {
+		return
+	}
+
+	devcontainer, agentConn, release, ok := api.workspaceAgentDevcontainerConn(rw, r)
+	if !ok {
+		return
+	}
+	defer release()
+
+	m, err := agentConn.StopDevcontainer(ctx, devcontainer)
+	if err != nil {
+		writeWorkspaceAgentDevcontainerError(ctx, rw, "stop", err)
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, m)
+}
+
+// @Summary Start devcontainer for workspace agent
+// @ID start-devcontainer-for-workspace-agent
+// @Security CoderSessionToken
+// @Tags Agents
+// @Produce json
+// @Param workspaceagent path string true "Workspace agent ID" format(uuid)
+// @Param devcontainer path string true "Devcontainer ID"
+// @Success 200 {object} codersdk.Response
+// @Router /workspaceagents/{workspaceagent}/containers/devcontainers/{devcontainer}/start [post]
+func (api *API) workspaceAgentStartDevcontainer(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if !api.authorizeWorkspaceAgentDevcontainer(rw, r) {
+		return
+	}
+
+	devcontainer, agentConn, release, ok := api.workspaceAgentDevcontainerConn(rw, r)
+	if !ok {
+		return
+	}
+	defer release()
+
+	m, err := agentConn.StartDevcontainer(ctx, devcontainer)
+	if err != nil {
+		writeWorkspaceAgentDevcontainerError(ctx, rw, "start", err)
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, m)
+}
+
+// @Summary Delete devcontainer for workspace agent
+// @ID delete-devcontainer-for-workspace-agent
+// @Security CoderSessionToken
+// @Tags Agents
+// @Produce json
+// @Param workspaceagent path string true "Workspace agent ID" format(uuid)
+// @Param devcontainer path string true "Devcontainer ID"
+// @Success 200 {object} codersdk.Response
+// @Router /workspaceagents/{workspaceagent}/containers/devcontainers/{devcontainer} [delete]
+func (api *API) workspaceAgentDeleteDevcontainer(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if !api.authorizeWorkspaceAgentDevcontainer(rw, r) {
+		return
+	}
+
+	devcontainer, agentConn, release, ok := api.workspaceAgentDevcontainerConn(rw, r)
+	if !ok {
+		return
+	}
+	defer release()
+
+	m, err := agentConn.DeleteDevcontainer(ctx, devcontainer)
+	if err != nil {
+		writeWorkspaceAgentDevcontainerError(ctx, rw, "delete", err)
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, m)
+}
+
+// @Summary Get devcontainer build logs for workspace agent
+// @ID get-devcontainer-build-logs-for-workspace-agent
+// @Security CoderSessionToken
+// @Tags Agents
+// @Produce json
+// @Param workspaceagent path string true "Workspace agent ID" format(uuid)
+// @Param devcontainer path string true "Devcontainer ID"
+// @Param after query int false "Only return logs after this log ID"
+// @Param follow query bool false "Follow log stream"
+// @Success 200 {object} codersdk.WorkspaceAgentDevcontainerLogsResponse
+// @Router /workspaceagents/{workspaceagent}/containers/devcontainers/{devcontainer}/logs [get]
+func (api *API) workspaceAgentDevcontainerLogs(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if !api.authorizeWorkspaceAgentDevcontainer(rw, r) {
+		return
+	}
+
+	var after int64
+	if v := r.URL.Query().Get("after"); v != "" {
+		var err error
+		after, err = strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: "Invalid after query parameter.",
+				Validations: []codersdk.ValidationError{
+					{Field: "after", Detail: err.Error()},
+				},
 			})
 			return
 		}
-		// If the agent returns a codersdk.Error, we can return that directly.
-		if cerr, ok := codersdk.AsError(err); ok {
-			httpapi.Write(ctx, rw, cerr.StatusCode(), cerr.Response)
+	}
+
+	devcontainer, agentConn, release, ok := api.workspaceAgentDevcontainerConn(rw, r)
+	if !ok {
+		return
+	}
+	defer release()
+
+	if !r.URL.Query().Has("follow") {
+		logs, err := agentConn.DevcontainerLogs(ctx, devcontainer, after)
+		if err != nil {
+			writeWorkspaceAgentDevcontainerError(ctx, rw, "get logs for", err)
 			return
 		}
-		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
-			Message: "Internal error recreating devcontainer.",
+		httpapi.Write(ctx, rw, http.StatusOK, logs)
+		return
+	}
+
+	logCh, closer, err := agentConn.WatchDevcontainerLogs(ctx, devcontainer, after)
+	if err != nil {
+		writeWorkspaceAgentDevcontainerError(ctx, rw, "watch logs for", err)
+		return
+	}
+	defer closer.Close()
+
+	api.WebsocketWaitMutex.Lock()
+	api.WebsocketWaitGroup.Add(1)
+	api.WebsocketWaitMutex.Unlock()
+	defer api.WebsocketWaitGroup.Done()
+
+	conn, err := websocket.Accept(rw, r, nil)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Failed to accept websocket.",
 			Detail:  err.Error(),
 		})
 		return
 	}
+	encoder := wsjson.NewEncoder[[]codersdk.WorkspaceAgentLog](conn, websocket.MessageText)
+	defer encoder.Close(websocket.StatusNormalClosure)
 
-	httpapi.Write(ctx, rw, http.StatusAccepted, m)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go httpapi.HeartbeatClose(ctx, api.Logger, cancel, conn)
+
+	// Log the request immediately instead of after it completes.
+	if rl := loggermw.RequestLoggerFromContext(ctx); rl != nil {
+		rl.WriteLog(ctx, http.StatusAccepted)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case logs, ok := <-logCh:
+			if !ok {
+				return
+			}
+			if err := encoder.Encode(logs); err != nil {
+				return
+			}
+		}
+	}
 }
 
 // @Summary Get connection info for workspace agent
@@ -7,9 +7,13 @@ import (
 	"maps"
 	"net"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -1190,6 +1194,176 @@ func TestWorkspaceAgentListeningPorts(t *testing.T) {
 	})
 }
 
+func TestWorkspaceAgentProcesses(t *testing.T) {
+	t.Parallel()
+
+	client, db := coderdtest.NewWithDatabase(t, nil)
+	user := coderdtest.CreateFirstUser(t, client)
+	r := dbfake.WorkspaceBuild(t, db, database.WorkspaceTable{
+		OrganizationID: user.OrganizationID,
+		OwnerID:        user.UserID,
+	}).WithAgent().Do()
+	_ = agenttest.New(t, client.URL, r.AgentToken)
+	resources := coderdtest.AwaitWorkspaceAgents(t, client, r.Workspace.ID)
+	agentID := resources[0].Agents[0].ID
+
+	t.Run("List", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := testutil.Context(t, testutil.WaitLong)
+		resp, err := client.WorkspaceAgentProcesses(ctx, agentID, codersdk.WorkspaceAgentProcessesOptions{
+			Sort: codersdk.WorkspaceAgentProcessSortPID,
+		})
+		require.NoError(t, err)
+		// The agent runs in the test process.
+		require.True(t, slices.ContainsFunc(resp.Processes, func(p codersdk.WorkspaceAgentProcess) bool {
+			return p.PID == int32(os.Getpid())
+		}))
+
+		resp, err = client.WorkspaceAgentProcesses(ctx, agentID, codersdk.WorkspaceAgentProcessesOptions{
+			Sort:  codersdk.WorkspaceAgentProcessSortMemory,
+			Limit: 1,
+		})
+		require.NoError(t, err)
+		require.Len(t, resp.Processes, 1)
+	})
+
+	t.Run("ListWithoutSSH", func(t *testing.T) {
+		t.Parallel()
+
+		// A user who can read the workspace but not connect to it must not
+		// see the command lines of its processes.
+		readOnlyClient, _ := coderdtest.CreateAnotherUser(t, client, user.OrganizationID, rbac.RoleTemplateAdmin())
+		ctx := testutil.Context(t, testutil.WaitLong)
+		_, err := readOnlyClient.WorkspaceAgentProcesses(ctx, agentID, codersdk.WorkspaceAgentProcessesOptions{})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+	})
+
+	t.Run("InvalidSort", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := testutil.Context(t, testutil.WaitLong)
+		_, err := client.WorkspaceAgentProcesses(ctx, agentID, codersdk.WorkspaceAgentProcessesOptions{
+			Sort: "name",
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+	})
+
+	t.Run("Signal", func(t *testing.T) {
+		t.Parallel()
+		if runtime.GOOS == "windows" {
+			t.Skip("sleep is not available on Windows")
+		}
+
+		ctx := testutil.Context(t, testutil.WaitLong)
+		cmd := exec.CommandContext(ctx, "sleep", "60")
+		require.NoError(t, cmd.Start())
+
+		err := client.WorkspaceAgentSignalProcess(ctx, agentID, int32(cmd.Process.Pid), codersdk.WorkspaceAgentSignalProcessRequest{
+			Signal: "TERM",
+		})
+		require.NoError(t, err)
+		require.Error(t, cmd.Wait())
+	})
+
+	t.Run("SignalAgent", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := testutil.Context(t, testutil.WaitLong)
+		err := client.WorkspaceAgentSignalProcess(ctx, agentID, int32(os.Getpid()), codersdk.WorkspaceAgentSignalProcessRequest{
+			Signal: "KILL",
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+	})
+}
+
+func TestWorkspaceAgentHTTPCaptures(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Context(t, testutil.WaitLong)
+	ownerClient, db := coderdtest.NewWithDatabase(t, nil)
+	owner := coderdtest.CreateFirstUser(t, ownerClient)
+	client, user := coderdtest.CreateAnotherUser(t, ownerClient, owner.OrganizationID)
+	r := dbfake.WorkspaceBuild(t, db, database.WorkspaceTable{
+		OrganizationID: owner.OrganizationID,
+		OwnerID:        user.ID,
+	}).WithAgent().Do()
+	_ = agenttest.New(t, client.URL, r.AgentToken)
+	resources := coderdtest.AwaitWorkspaceAgents(t, client, r.Workspace.ID)
+	agentID := resources[0].Agents[0].ID
+
+	app := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		_, _ = rw.Write([]byte("hello"))
+	}))
+	defer app.Close()
+	appURL, err := url.Parse(app.URL)
+	require.NoError(t, err)
+	port, err := strconv.ParseUint(appURL.Port(), 10, 16)
+	require.NoError(t, err)
+
+	capture, err := client.StartWorkspaceAgentHTTPCapture(ctx, agentID, codersdk.WorkspaceAgentHTTPCaptureRequest{
+		Port:          uint16(port),
+		IncludeBodies: true,
+	})
+	require.NoError(t, err)
+	require.True(t, capture.Active)
+
+	// Starting a second capture of the same port conflicts.
+	_, err = client.StartWorkspaceAgentHTTPCapture(ctx, agentID, codersdk.WorkspaceAgentHTTPCaptureRequest{Port: uint16(port)})
+	var apiErr *codersdk.Error
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusConflict, apiErr.StatusCode())
+
+	// Requests to the app over the tailnet are recorded.
+	conn, err := workspacesdk.New(client).DialAgent(ctx, agentID, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	httpClient := &http.Client{
+		Transport: &http.Transport{
+			DialContext: conn.DialContext,
+		},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("http://%s/", net.JoinHostPort(tailnet.TailscaleServicePrefix.AddrFromUUID(agentID).String(), appURL.Port())), nil)
+	require.NoError(t, err)
+	res, err := httpClient.Do(req)
+	require.NoError(t, err)
+	_ = res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	var har codersdk.HAR
+	require.Eventually(t, func() bool {
+		har, err = client.WorkspaceAgentHTTPCaptureHAR(ctx, agentID, uint16(port))
+		return err == nil && len(har.Log.Entries) == 1
+	}, testutil.WaitLong, testutil.IntervalFast)
+	require.Equal(t, "hello", har.Log.Entries[0].Response.Content.Text)
+
+	captures, err := client.WorkspaceAgentHTTPCaptures(ctx, agentID)
+	require.NoError(t, err)
+	require.Len(t, captures, 1)
+	require.Equal(t, 1, captures[0].Entries)
+
+	// Other users cannot see the traffic of the workspace.
+	otherClient, _ := coderdtest.CreateAnotherUser(t, ownerClient, owner.OrganizationID)
+	_, err = otherClient.WorkspaceAgentHTTPCaptureHAR(ctx, agentID, uint16(port))
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+
+	capture, err = client.StopWorkspaceAgentHTTPCapture(ctx, agentID, uint16(port))
+	require.NoError(t, err)
+	require.False(t, capture.Active)
+
+	require.NoError(t, client.DeleteWorkspaceAgentHTTPCapture(ctx, agentID, uint16(port)))
+	_, err = client.WorkspaceAgentHTTPCaptureHAR(ctx, agentID, uint16(port))
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+}
+
 func TestWorkspaceAgentContainers(t *testing.T) {
 	t.Parallel()
 
@@ -1507,6 +1681,227 @@ func TestWorkspaceAgentRecreateDevcontainer(t *testing.T) {
 	})
 }
 
+func TestWorkspaceAgentDevcontainerActions(t *testing.T) {
+	t.Parallel()
+
+	var (
+		workspaceFolder = t.TempDir()
+		configFile      = filepath.Join(workspaceFolder, ".devcontainer", "devcontainer.json")
+		devcontainerID  = uuid.New()
+	)
+
+	newContainer := func(running bool) codersdk.WorkspaceAgentContainer {
+		status := "running"
+		if !running {
+			status = "exited"
+		}
+		return codersdk.WorkspaceAgentContainer{
+			ID:           uuid.NewString(),
+			CreatedAt:    dbtime.Now(),
+			FriendlyName: testutil.GetRandomName(t),
+			Image:        "busybox:latest",
+			Labels: map[string]string{
+				agentcontainers.DevcontainerLocalFolderLabel: workspaceFolder,
+				agentcontainers.DevcontainerConfigFileLabel:  configFile,
+			},
+			Running: running,
+			Status:  status,
+		}
+	}
+
+	// setup starts an agent with a single devcontainer backed by the
+	// given container and returns the owner client, a client that can
+	// read but not connect to the workspace, and the agent ID.
+	setup := func(t *testing.T, container codersdk.WorkspaceAgentContainer, setupMock func(mccli *acmock.MockContainerCLI, mdccli *acmock.MockDevcontainerCLI)) (*codersdk.Client, *codersdk.Client, uuid.UUID) {
+		ctrl := gomock.NewController(t)
+		mccli := acmock.NewMockContainerCLI(ctrl)
+		mdccli := acmock.NewMockDevcontainerCLI(ctrl)
+		mccli.EXPECT().List(gomock.Any()).Return(codersdk.WorkspaceAgentListContainersResponse{
+			Containers: []codersdk.WorkspaceAgentContainer{container},
+		}, nil).AnyTimes()
+		// DetectArchitecture always returns "<none>" for this test to disable agent injection.
+		mccli.EXPECT().DetectArchitecture(gomock.Any(), container.ID).Return("<none>", nil).AnyTimes()
+		mdccli.EXPECT().ReadConfig(gomock.Any(), workspaceFolder, configFile, gomock.Any()).Return(agentcontainers.DevcontainerConfig{}, nil).AnyTimes()
+		if setupMock != nil {
+			setupMock(mccli, mdccli)
+		}
+
+		logger := slogtest.Make(t, &slogtest.Options{IgnoreErrors: true}).Leveled(slog.LevelDebug)
+		client, db := coderdtest.NewWithDatabase(t, &coderdtest.Options{
+			Logger: &logger,
+		})
+		user := coderdtest.CreateFirstUser(t, client)
+		readOnlyClient, _ := coderdtest.CreateAnotherUser(t, client, user.OrganizationID, rbac.RoleTemplateAdmin())
+		r := dbfake.WorkspaceBuild(t, db, database.WorkspaceTable{
+			OrganizationID: user.OrganizationID,
+			OwnerID:        user.UserID,
+		}).WithAgent().Do()
+
+		_ = agenttest.New(t, client.URL, r.AgentToken, func(o *agent.Options) {
+			o.Logger = logger.Named("agent")
+			o.Devcontainers = true
+			o.DevcontainerAPIOptions = []agentcontainers.Option{
+				agentcontainers.WithContainerCLI(mccli),
+				agentcontainers.WithDevcontainerCLI(mdccli),
+				agentcontainers.WithWatcher(watcher.NewNoop()),
+				agentcontainers.WithDevcontainers([]codersdk.WorkspaceAgentDevcontainer{{
+					ID:              devcontainerID,
+					Name:            "test-devcontainer",
+					WorkspaceFolder: workspaceFolder,
+					ConfigPath:      configFile,
+				}}, nil),
+			}
+		})
+		resources := coderdtest.NewWorkspaceAgentWaiter(t, client, r.Workspace.ID).Wait()
+		require.Len(t, resources, 1, "expected one resource")
+		require.Len(t, resources[0].Agents, 1, "expected one agent")
+		agentID := resources[0].Agents[0].ID
+
+		// Wait for the agent to associate the container with the
+		// devcontainer.
+		require.Eventually(t, func() bool {
+			resp, err := client.WorkspaceAgentListContainers(testutil.Context(t, testutil.WaitShort), agentID, nil)
+			if err != nil || len(resp.Devcontainers) != 1 {
+				return false
+			}
+			return resp.Devcontainers[0].Container != nil
+		}, testutil.WaitLong, testutil.IntervalFast)
+
+		return client, readOnlyClient, agentID
+	}
+
+	requireStatus := func(t *testing.T, err error, status int) {
+		t.Helper()
+		cerr, ok := codersdk.AsError(err)
+		require.True(t, ok, "expected error to be a coder error")
+		require.Equal(t, status, cerr.StatusCode())
+	}
+
+	for _, tc := range []struct {
+		name      string
+		running   bool
+		setupMock func(mccli *acmock.MockContainerCLI, mdccli *acmock.MockDevcontainerCLI, containerID string)
+		do        func(ctx context.Context, client *codersdk.Client, agentID uuid.UUID) error
+	}{
+		{
+			name:    "Recreate",
+			running: true,
+			setupMock: func(_ *acmock.MockContainerCLI, mdccli *acmock.MockDevcontainerCLI, _ string) {
+				mdccli.EXPECT().Up(gomock.Any(), workspaceFolder, configFile, gomock.Any()).Return("someid", nil).Times(1)
+			},
+			do: func(ctx context.Context, client *codersdk.Client, agentID uuid.UUID) error {
+				_, err := client.WorkspaceAgentRecreateDevcontainer(ctx, agentID, devcontainerID.String())
+				return err
+			},
+		},
+		{
+			name:    "Rebuild",
+			running: true,
+			setupMock: func(_ *acmock.MockContainerCLI, mdccli *acmock.MockDevcontainerCLI, _ string) {
+				mdccli.EXPECT().Up(gomock.Any(), workspaceFolder, configFile, gomock.Any()).Return("someid", nil).Times(1)
+			},
+			do: func(ctx context.Context, client *codersdk.Client, agentID uuid.UUID) error {
+				_, err := client.WorkspaceAgentRebuildDevcontainer(ctx, agentID, devcontainerID.String())
+				return err
+			},
+		},
+		{
+			name:    "Stop",
+			running: true,
+			setupMock: func(mccli *acmock.MockContainerCLI, _ *acmock.MockDevcontainerCLI, containerID string) {
+				mccli.EXPECT().Stop(gomock.Any(), containerID).Return(nil).Times(1)
+			},
+			do: func(ctx context.Context, client *codersdk.Client, agentID uuid.UUID) error {
+				_, err := client.WorkspaceAgentStopDevcontainer(ctx, agentID, devcontainerID.String())
+				return err
+			},
+		},
+		{
+			name:    "Start",
+			running: false,
+			setupMock: func(mccli *acmock.MockContainerCLI, _ *acmock.MockDevcontainerCLI, containerID string) {
+				mccli.EXPECT().Start(gomock.Any(), containerID).Return(nil).Times(1)
+			},
+			do: func(ctx context.Context, client *codersdk.Client, agentID uuid.UUID) error {
+				_, err := client.WorkspaceAgentStartDevcontainer(ctx, agentID, devcontainerID.String())
+				return err
+			},
+		},
+		{
+			name:    "Delete",
+			running: true,
+			setupMock: func(mccli *acmock.MockContainerCLI, _ *acmock.MockDevcontainerCLI, containerID string) {
+				mccli.EXPECT().Remove(gomock.Any(), containerID).Return(nil).Times(1)
+			},
+			do: func(ctx context.Context, client *codersdk.Client, agentID uuid.UUID) error {
+				_, err := client.WorkspaceAgentDeleteDevcontainer(ctx, agentID, devcontainerID.String())
+				return err
+			},
+		},
+		{
+			name:    "Logs",
+			running: true,
+			do: func(ctx context.Context, client *codersdk.Client, agentID uuid.UUID) error {
+				_, err := client.WorkspaceAgentDevcontainerLogs(ctx, agentID, devcontainerID.String(), 0)
+				return err
+			},
+		},
+		{
+			name:    "LogsFollow",
+			running: true,
+			do: func(ctx context.Context, client *codersdk.Client, agentID uuid.UUID) error {
+				logs, closer, err := client.WorkspaceAgentDevcontainerLogsAfter(ctx, agentID, devcontainerID.String(), codersdk.WorkspaceAgentDevcontainerLogsOptions{Follow: true})
+				if err != nil {
+					return err
+				}
+				defer closer.Close()
+				// The devcontainer is not being built, so the stream
+				// ends right away.
+				for {
+					select {
+					case <-ctx.Done():
+						return ctx.Err()
+					case _, ok := <-logs:
+						if !ok {
+							return nil
+						}
+					}
+				}
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			container := newContainer(tc.running)
+			client, readOnlyClient, agentID := setup(t, container, func(mccli *acmock.MockContainerCLI, mdccli *acmock.MockDevcontainerCLI) {
+				if tc.setupMock != nil {
+					tc.setupMock(mccli, mdccli, container.ID)
+				}
+			})
+			ctx := testutil.Context(t, testutil.WaitLong)
+
+			// A user who can read the workspace but not connect to it
+			// must not control its devcontainers.
+			err := tc.do(ctx, readOnlyClient, agentID)
+			requireStatus(t, err, http.StatusNotFound)
+
+			err = tc.do(ctx, client, agentID)
+			require.NoError(t, err)
+		})
+	}
+
+	t.Run("NotFound", func(t *testing.T) {
+		t.Parallel()
+
+		client, _, agentID := setup(t, newContainer(true), nil)
+		ctx := testutil.Context(t, testutil.WaitLong)
+
+		_, err := client.WorkspaceAgentStopDevcontainer(ctx, agentID, uuid.NewString())
+		requireStatus(t, err, http.StatusNotFound)
+	})
+}
+
 func TestWorkspaceAgentAppHealth(t *testing.T) {
 	t.Parallel()
 	client, db := coderdtest.NewWithDatabase(t, nil)
@@ -2623,7 +3018,7 @@ func requireGetManifest(ctx context.Context, t testing.TB, aAPI agentproto.DRPCA
 }
 
 func postStartup(ctx context.Context, t testing.TB, client agent.Client, startup *agentproto.Startup) error {
-	aAPI, _, err := client.ConnectRPC26(ctx)
+	aAPI, _, err := client.ConnectRPC27(ctx)
 	require.NoError(t, err)
 	defer func() {
 		cErr := aAPI.DRPCConn().Close()
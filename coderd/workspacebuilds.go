@@ -760,9 +760,71 @@ func (api *API) workspaceBuildState(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	state := workspaceBuild.ProvisionerState
+	if len(state) == 0 {
+		// The state of earlier builds may only be kept in the state history.
+		stored, err := api.Database.GetWorkspaceBuildStateByBuildID(ctx, workspaceBuild.ID)
+		if err != nil && !httpapi.Is404Error(err) {
+			httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+				Message: "Internal error fetching workspace build state.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+		state = stored.State
+	}
+
 	rw.Header().Set("Content-Type", "application/json")
 	rw.WriteHeader(http.StatusOK)
-	_, _ = rw.Write(workspaceBuild.ProvisionerState)
+	_, _ = rw.Write(state)
+}
+
+// @Summary Get Terraform state history of workspace
+// @ID get-terraform-state-history-of-workspace
+// @Security CoderSessionToken
+// @Produce json
+// @Tags Builds
+// @Param workspace path string true "Workspace ID" format(uuid)
+// @Success 200 {array} codersdk.WorkspaceBuildState
+// @Router /workspaces/{workspace}/states [get]
+func (api *API) workspaceBuildStates(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	workspace := httpmw.WorkspaceParam(r)
+	template, err := api.Database.GetTemplateByID(ctx, workspace.TemplateID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Failed to get template",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	// Like the state itself, its history requires update permissions on the
+	// template.
+	if !api.Authorize(r, policy.ActionUpdate, template.RBACObject()) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	states, err := api.Database.GetWorkspaceBuildStatesByWorkspaceID(ctx, workspace.ID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace build states.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, db2sdk.List(states, func(state database.GetWorkspaceBuildStatesByWorkspaceIDRow) codersdk.WorkspaceBuildState {
+		return codersdk.WorkspaceBuildState{
+			WorkspaceBuildID: state.WorkspaceBuildID,
+			BuildNumber:      state.BuildNumber,
+			Transition:       codersdk.WorkspaceTransition(state.Transition),
+			Hash:             state.Hash,
+			Size:             state.Size,
+			CreatedAt:        state.CreatedAt,
+		}
+	}))
 }
 
 // @Summary Get workspace build timings by ID
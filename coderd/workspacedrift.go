@@ -0,0 +1,195 @@
+package coderd
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/coder/coder/v2/coderd/database"
+	"github.com/coder/coder/v2/coderd/driftcheck"
+	"github.com/coder/coder/v2/coderd/httpapi"
+	"github.com/coder/coder/v2/coderd/httpmw"
+	"github.com/coder/coder/v2/coderd/provisionerdserver"
+	"github.com/coder/coder/v2/coderd/rbac/policy"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+// @Summary Get workspace drift check
+// @Description Returns the latest drift check of the current build of the workspace.
+// @ID get-workspace-drift-check
+// @Security CoderSessionToken
+// @Produce json
+// @Tags Workspaces
+// @Param workspace path string true "Workspace ID" format(uuid)
+// @Success 200 {object} codersdk.WorkspaceDriftCheck
+// @Router /workspaces/{workspace}/drift [get]
+func (api *API) workspaceDriftCheck(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		workspace = httpmw.WorkspaceParam(r)
+	)
+
+	build, err := api.Database.GetLatestWorkspaceBuildByWorkspaceID(ctx, workspace.ID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace build.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	check, err := api.Database.GetLatestWorkspaceDriftCheckByWorkspaceID(ctx, workspace.ID)
+	if httpapi.Is404Error(err) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace drift check.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	// Checks of previous builds were reconciled by the build that replaced
+	// them.
+	if check.WorkspaceBuildID != build.ID {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	api.writeWorkspaceDriftCheck(rw, r, http.StatusOK, check)
+}
+
+// @Summary Check workspace for drift
+// @Description Queues a plan of the current build of a running workspace, to find resources changed outside of Coder.
+// @ID check-workspace-for-drift
+// @Security CoderSessionToken
+// @Produce json
+// @Tags Workspaces
+// @Param workspace path string true "Workspace ID" format(uuid)
+// @Success 201 {object} codersdk.WorkspaceDriftCheck
+// @Router /workspaces/{workspace}/drift [post]
+func (api *API) postWorkspaceDriftCheck(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		workspace = httpmw.WorkspaceParam(r)
+	)
+
+	if !api.Authorize(r, policy.ActionUpdate, workspace) {
+		httpapi.Forbidden(rw)
+		return
+	}
+
+	build, err := api.Database.GetLatestWorkspaceBuildByWorkspaceID(ctx, workspace.ID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace build.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	buildJob, err := api.Database.GetProvisionerJobByID(ctx, build.JobID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace build job.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	if build.Transition != database.WorkspaceTransitionStart ||
+		buildJob.JobStatus != database.ProvisionerJobStatusSucceeded {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Only running workspaces can be checked for drift.",
+		})
+		return
+	}
+
+	latest, err := api.Database.GetLatestWorkspaceDriftCheckByWorkspaceID(ctx, workspace.ID)
+	if err != nil && !httpapi.Is404Error(err) {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace drift check.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	if err == nil {
+		job, err := api.Database.GetProvisionerJobByID(ctx, latest.JobID)
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+				Message: "Internal error fetching drift check job.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+		if !job.CompletedAt.Valid {
+			httpapi.Write(ctx, rw, http.StatusConflict, codersdk.Response{
+				Message: "The workspace is already being checked for drift.",
+			})
+			return
+		}
+	}
+
+	check, _, err := driftcheck.Enqueue(ctx, api.Logger, api.Database, api.Pubsub, workspace.ID, build.ID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error queuing drift check.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	api.writeWorkspaceDriftCheck(rw, r, http.StatusCreated, check)
+}
+
+func (api *API) writeWorkspaceDriftCheck(rw http.ResponseWriter, r *http.Request, status int, check database.WorkspaceDriftCheck) {
+	ctx := r.Context()
+
+	jobs, err := api.Database.GetProvisionerJobsByIDsWithQueuePosition(ctx, database.GetProvisionerJobsByIDsWithQueuePositionParams{
+		IDs:             []uuid.UUID{check.JobID},
+		StaleIntervalMS: provisionerdserver.StaleInterval.Milliseconds(),
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching drift check job.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	if len(jobs) == 0 {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Drift check job not found.",
+		})
+		return
+	}
+
+	converted, err := convertWorkspaceDriftCheck(check, jobs[0])
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error reading drifted resources.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	httpapi.Write(ctx, rw, status, converted)
+}
+
+func convertWorkspaceDriftCheck(check database.WorkspaceDriftCheck, job database.GetProvisionerJobsByIDsWithQueuePositionRow) (codersdk.WorkspaceDriftCheck, error) {
+	converted := codersdk.WorkspaceDriftCheck{
+		ID:               check.ID,
+		WorkspaceID:      check.WorkspaceID,
+		WorkspaceBuildID: check.WorkspaceBuildID,
+		Job:              convertProvisionerJob(job),
+		CreatedAt:        check.CreatedAt,
+		Resources:        []codersdk.WorkspaceDriftedResource{},
+	}
+	if check.CompletedAt.Valid {
+		converted.CompletedAt = &check.CompletedAt.Time
+	}
+	if check.DriftedResources.Valid {
+		if err := json.Unmarshal(check.DriftedResources.RawMessage, &converted.Resources); err != nil {
+			return codersdk.WorkspaceDriftCheck{}, err
+		}
+	}
+	converted.Drifted = len(converted.Resources) > 0
+	return converted, nil
+}
@@ -0,0 +1,478 @@
+package coderd
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"github.com/coder/coder/v2/coderd/database"
+	"github.com/coder/coder/v2/coderd/database/dbtime"
+	"github.com/coder/coder/v2/coderd/httpapi"
+	"github.com/coder/coder/v2/coderd/httpmw"
+	"github.com/coder/coder/v2/coderd/workspacesnapshots"
+	"github.com/coder/coder/v2/codersdk"
+	"github.com/coder/coder/v2/codersdk/agentsdk"
+)
+
+// DefaultWorkspaceSnapshotMaxSize is the largest snapshot archive accepted
+// from an agent when no limit is configured.
+const DefaultWorkspaceSnapshotMaxSize = 1 << 30
+
+// @Summary Upload workspace agent snapshot
+// @ID upload-workspace-agent-snapshot
+// @Security CoderSessionToken
+// @Accept application/gzip
+// @Produce json
+// @Tags Agents
+// @Param trigger query string true "Snapshot trigger" Enums(stop,schedule)
+// @Param file_count query int true "Number of files in the snapshot"
+// @Param path query []string true "Snapshotted paths" collectionFormat(multi)
+// @Param parent_id query string false "Parent snapshot ID" format(uuid)
+// @Success 201 {object} codersdk.WorkspaceSnapshot
+// @Router /workspaceagents/me/snapshots [post]
+func (api *API) postWorkspaceAgentSnapshot(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx            = r.Context()
+		workspaceAgent = httpmw.WorkspaceAgent(r)
+		build          = httpmw.LatestBuild(r)
+	)
+
+	if contentType := r.Header.Get("Content-Type"); contentType != agentsdk.SnapshotContentType {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: fmt.Sprintf("Unsupported content type header %q.", contentType),
+		})
+		return
+	}
+
+	query := r.URL.Query()
+	trigger := database.WorkspaceSnapshotTrigger(query.Get("trigger"))
+	if !trigger.Valid() {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: fmt.Sprintf("Invalid snapshot trigger %q.", trigger),
+		})
+		return
+	}
+	fileCount, err := strconv.ParseInt(query.Get("file_count"), 10, 32)
+	if err != nil || fileCount < 0 {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Invalid file count.",
+		})
+		return
+	}
+	paths := query["path"]
+	if len(paths) == 0 {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "At least one snapshot path is required.",
+		})
+		return
+	}
+
+	workspace, err := api.Database.GetWorkspaceByID(ctx, build.WorkspaceID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	var parentID uuid.NullUUID
+	if raw := query.Get("parent_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: "Invalid parent snapshot ID.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+		parent, err := api.Database.GetWorkspaceSnapshotByID(ctx, id)
+		if err != nil && !httpapi.Is404Error(err) {
+			httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+				Message: "Internal error fetching parent snapshot.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+		if err != nil || parent.WorkspaceID != workspace.ID {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: "Parent snapshot does not exist.",
+			})
+			return
+		}
+		parentID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
+	r.Body = http.MaxBytesReader(rw, r.Body, api.WorkspaceSnapshotMaxSize)
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Failed to read snapshot from request.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	id := uuid.New()
+	key, err := api.WorkspaceSnapshotStore.Put(ctx, workspace, id, data)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error storing snapshot.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	snapshot, err := api.Database.InsertWorkspaceSnapshot(ctx, database.InsertWorkspaceSnapshotParams{
+		ID:          id,
+		WorkspaceID: workspace.ID,
+		AgentName:   workspaceAgent.Name,
+		BuildNumber: build.BuildNumber,
+		ParentID:    parentID,
+		Trigger:     trigger,
+		Storage:     api.WorkspaceSnapshotStore.Storage(),
+		ObjectKey:   key,
+		SizeBytes:   int64(len(data)),
+		FileCount:   int32(fileCount),
+		Paths:       paths,
+		CreatedAt:   dbtime.Now(),
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error saving snapshot.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusCreated, convertWorkspaceSnapshot(snapshot))
+}
+
+// @Summary Get workspace agent pending snapshot restore
+// @ID get-workspace-agent-pending-snapshot-restore
+// @Security CoderSessionToken
+// @Produce json
+// @Tags Agents
+// @Success 200 {object} agentsdk.PendingSnapshotRestore
+// @Router /workspaceagents/me/snapshots/restore [get]
+func (api *API) workspaceAgentPendingSnapshotRestore(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx            = r.Context()
+		workspaceAgent = httpmw.WorkspaceAgent(r)
+		build          = httpmw.LatestBuild(r)
+	)
+
+	restore, err := api.Database.GetWorkspaceSnapshotRestoreByWorkspaceID(ctx, build.WorkspaceID)
+	if httpapi.Is404Error(err) {
+		httpapi.Write(ctx, rw, http.StatusOK, agentsdk.PendingSnapshotRestore{Snapshots: []codersdk.WorkspaceSnapshot{}})
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching snapshot restore.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	chain, err := api.Database.GetWorkspaceSnapshotChain(ctx, restore.SnapshotID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching snapshots.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	// The restore is meant for the agent of a build started after it was
+	// requested, and only for the agent that took the snapshot.
+	if build.BuildNumber <= restore.AfterBuildNumber || len(chain) == 0 || chain[len(chain)-1].AgentName != workspaceAgent.Name {
+		httpapi.Write(ctx, rw, http.StatusOK, agentsdk.PendingSnapshotRestore{Snapshots: []codersdk.WorkspaceSnapshot{}})
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, agentsdk.PendingSnapshotRestore{
+		Snapshots: convertWorkspaceSnapshots(chain),
+	})
+}
+
+// @Summary Download workspace agent snapshot archive
+// @ID download-workspace-agent-snapshot-archive
+// @Security CoderSessionToken
+// @Produce application/gzip
+// @Tags Agents
+// @Param snapshot path string true "Snapshot ID" format(uuid)
+// @Success 200
+// @Router /workspaceagents/me/snapshots/{snapshot}/archive [get]
+func (api *API) workspaceAgentSnapshotArchive(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx   = r.Context()
+		build = httpmw.LatestBuild(r)
+	)
+
+	snapshotID, ok := httpmw.ParseUUIDParam(rw, r, "snapshot")
+	if !ok {
+		return
+	}
+	snapshot, err := api.Database.GetWorkspaceSnapshotByID(ctx, snapshotID)
+	if httpapi.Is404Error(err) || (err == nil && snapshot.WorkspaceID != build.WorkspaceID) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching snapshot.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	store, err := api.workspaceSnapshotStoreFor(snapshot.Storage)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Snapshot storage is unavailable.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	archive, err := store.Open(ctx, snapshot.ObjectKey)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error opening snapshot archive.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	defer archive.Close()
+
+	rw.Header().Set("Content-Type", agentsdk.SnapshotContentType)
+	rw.Header().Set("Content-Length", strconv.FormatInt(snapshot.SizeBytes, 10))
+	rw.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(rw, archive); err != nil {
+		api.Logger.Warn(ctx, "stream snapshot archive", slog.F("snapshot_id", snapshot.ID), slog.Error(err))
+	}
+}
+
+// @Summary Complete workspace agent snapshot restore
+// @ID complete-workspace-agent-snapshot-restore
+// @Security CoderSessionToken
+// @Accept json
+// @Tags Agents
+// @Param request body agentsdk.CompleteSnapshotRestoreRequest true "Restore result"
+// @Success 204
+// @Router /workspaceagents/me/snapshots/restore/complete [post]
+func (api *API) postWorkspaceAgentSnapshotRestoreComplete(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx   = r.Context()
+		build = httpmw.LatestBuild(r)
+	)
+
+	var req agentsdk.CompleteSnapshotRestoreRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+
+	err := api.Database.InTx(func(tx database.Store) error {
+		restore, err := tx.GetWorkspaceSnapshotRestoreByWorkspaceID(ctx, build.WorkspaceID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return xerrors.Errorf("get snapshot restore: %w", err)
+		}
+		// Another restore may have been requested in the meantime.
+		if restore.SnapshotID != req.SnapshotID {
+			return nil
+		}
+		return tx.DeleteWorkspaceSnapshotRestore(ctx, build.WorkspaceID)
+	}, nil)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error completing snapshot restore.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	if req.Error != "" {
+		api.Logger.Warn(ctx, "workspace agent failed to restore snapshot",
+			slog.F("workspace_id", build.WorkspaceID),
+			slog.F("snapshot_id", req.SnapshotID),
+			slog.F("error", req.Error),
+		)
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Get workspace snapshots
+// @ID get-workspace-snapshots
+// @Security CoderSessionToken
+// @Produce json
+// @Tags Workspaces
+// @Param workspace path string true "Workspace ID" format(uuid)
+// @Success 200 {array} codersdk.WorkspaceSnapshot
+// @Router /workspaces/{workspace}/snapshots [get]
+func (api *API) workspaceSnapshots(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		workspace = httpmw.WorkspaceParam(r)
+	)
+
+	snapshots, err := api.Database.GetWorkspaceSnapshotsByWorkspaceID(ctx, workspace.ID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace snapshots.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, convertWorkspaceSnapshots(snapshots))
+}
+
+// @Summary Restore workspace snapshot
+// @Description The snapshot is restored by the workspace agent of the next build.
+// @ID restore-workspace-snapshot
+// @Security CoderSessionToken
+// @Produce json
+// @Tags Workspaces
+// @Param workspace path string true "Workspace ID" format(uuid)
+// @Param snapshot path string true "Snapshot ID" format(uuid)
+// @Success 201 {object} codersdk.WorkspaceSnapshotRestore
+// @Router /workspaces/{workspace}/snapshots/{snapshot}/restore [post]
+func (api *API) postWorkspaceSnapshotRestore(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		workspace = httpmw.WorkspaceParam(r)
+		apiKey    = httpmw.APIKey(r)
+	)
+
+	snapshotID, ok := httpmw.ParseUUIDParam(rw, r, "snapshot")
+	if !ok {
+		return
+	}
+	snapshot, err := api.Database.GetWorkspaceSnapshotByID(ctx, snapshotID)
+	if httpapi.Is404Error(err) || (err == nil && snapshot.WorkspaceID != workspace.ID) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching snapshot.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	build, err := api.Database.GetLatestWorkspaceBuildByWorkspaceID(ctx, workspace.ID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching latest workspace build.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	restore, err := api.Database.UpsertWorkspaceSnapshotRestore(ctx, database.UpsertWorkspaceSnapshotRestoreParams{
+		WorkspaceID:      workspace.ID,
+		SnapshotID:       snapshot.ID,
+		AfterBuildNumber: build.BuildNumber,
+		RequestedBy:      apiKey.UserID,
+		RequestedAt:      dbtime.Now(),
+	})
+	if httpapi.IsUnauthorizedError(err) {
+		httpapi.Forbidden(rw)
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error scheduling snapshot restore.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusCreated, codersdk.WorkspaceSnapshotRestore{
+		WorkspaceID:      restore.WorkspaceID,
+		SnapshotID:       restore.SnapshotID,
+		AfterBuildNumber: restore.AfterBuildNumber,
+		RequestedBy:      restore.RequestedBy,
+		RequestedAt:      restore.RequestedAt,
+	})
+}
+
+// @Summary Cancel workspace snapshot restore
+// @ID cancel-workspace-snapshot-restore
+// @Security CoderSessionToken
+// @Tags Workspaces
+// @Param workspace path string true "Workspace ID" format(uuid)
+// @Success 204
+// @Router /workspaces/{workspace}/snapshots/restore [delete]
+func (api *API) deleteWorkspaceSnapshotRestore(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx       = r.Context()
+		workspace = httpmw.WorkspaceParam(r)
+	)
+
+	err := api.Database.DeleteWorkspaceSnapshotRestore(ctx, workspace.ID)
+	if httpapi.IsUnauthorizedError(err) {
+		httpapi.Forbidden(rw)
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error canceling snapshot restore.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// workspaceSnapshotStoreFor returns the store holding archives of the given
+// storage. Archives in the database remain readable after the deployment
+// switches to another backend.
+func (api *API) workspaceSnapshotStoreFor(storage database.WorkspaceSnapshotStorage) (workspacesnapshots.Store, error) {
+	if api.WorkspaceSnapshotStore.Storage() == storage {
+		return api.WorkspaceSnapshotStore, nil
+	}
+	if storage == database.WorkspaceSnapshotStorageDatabase {
+		return workspacesnapshots.NewDatabaseStore(api.Database), nil
+	}
+	return nil, xerrors.Errorf("snapshot storage %q is not configured", storage)
+}
+
+func convertWorkspaceSnapshots(snapshots []database.WorkspaceSnapshot) []codersdk.WorkspaceSnapshot {
+	converted := make([]codersdk.WorkspaceSnapshot, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		converted = append(converted, convertWorkspaceSnapshot(snapshot))
+	}
+	return converted
+}
+
+func convertWorkspaceSnapshot(snapshot database.WorkspaceSnapshot) codersdk.WorkspaceSnapshot {
+	converted := codersdk.WorkspaceSnapshot{
+		ID:          snapshot.ID,
+		WorkspaceID: snapshot.WorkspaceID,
+		AgentName:   snapshot.AgentName,
+		BuildNumber: snapshot.BuildNumber,
+		Trigger:     codersdk.WorkspaceSnapshotTrigger(snapshot.Trigger),
+		Storage:     codersdk.WorkspaceSnapshotStorage(snapshot.Storage),
+		SizeBytes:   snapshot.SizeBytes,
+		FileCount:   snapshot.FileCount,
+		Paths:       snapshot.Paths,
+		CreatedAt:   snapshot.CreatedAt,
+	}
+	if snapshot.ParentID.Valid {
+		converted.ParentID = &snapshot.ParentID.UUID
+	}
+	return converted
+}
@@ -0,0 +1,181 @@
+package workspacesnapshots
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/v2/coderd/database"
+	"github.com/coder/coder/v2/codersdk/agentsdk"
+)
+
+// emptyPayloadHash is the SHA-256 of an empty request body.
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// S3Options configure an S3 compatible Store.
+type S3Options struct {
+	Bucket string
+	Region string
+	// Endpoint is the URL of an S3 compatible service, e.g. MinIO. Objects
+	// are addressed with path-style URLs when set. Amazon S3 is used with
+	// virtual-hosted-style URLs otherwise.
+	Endpoint *url.URL
+	// Prefix is prepended to object keys.
+	Prefix string
+	// AccessKeyID and SecretAccessKey are optional. The default AWS
+	// credential chain is used when they are empty.
+	AccessKeyID     string
+	SecretAccessKey string
+	HTTPClient      *http.Client
+}
+
+type s3Store struct {
+	opts        S3Options
+	credentials aws.CredentialsProvider
+	signer      *v4.Signer
+}
+
+// NewS3Store returns a Store that keeps archives in an S3 compatible
+// bucket. Requests are signed with AWS Signature Version 4.
+func NewS3Store(ctx context.Context, opts S3Options) (Store, error) {
+	if opts.Bucket == "" {
+		return nil, xerrors.New("bucket is required")
+	}
+	if opts.Region == "" {
+		return nil, xerrors.New("region is required")
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = http.DefaultClient
+	}
+
+	var credentials aws.CredentialsProvider
+	if opts.AccessKeyID != "" || opts.SecretAccessKey != "" {
+		static := aws.Credentials{
+			AccessKeyID:     opts.AccessKeyID,
+			SecretAccessKey: opts.SecretAccessKey,
+			Source:          "CoderSnapshotS3Options",
+		}
+		credentials = aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+			return static, nil
+		})
+	} else {
+		cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(opts.Region))
+		if err != nil {
+			return nil, xerrors.Errorf("load AWS config: %w", err)
+		}
+		if cfg.Credentials == nil {
+			return nil, xerrors.New("no AWS credentials found")
+		}
+		credentials = cfg.Credentials
+	}
+
+	return &s3Store{
+		opts:        opts,
+		credentials: credentials,
+		signer:      v4.NewSigner(),
+	}, nil
+}
+
+func (*s3Store) Storage() database.WorkspaceSnapshotStorage {
+	return database.WorkspaceSnapshotStorageS3
+}
+
+func (s *s3Store) Put(ctx context.Context, workspace database.Workspace, snapshotID uuid.UUID, data []byte) (string, error) {
+	key := path.Join(strings.Trim(s.opts.Prefix, "/"), workspace.ID.String(), snapshotID.String()+".tar.gz")
+	hash := sha256.Sum256(data)
+	req, err := s.request(ctx, http.MethodPut, key, bytes.NewReader(data), hex.EncodeToString(hash[:]))
+	if err != nil {
+		return "", err
+	}
+	req.ContentLength = int64(len(data))
+	req.Header.Set("Content-Type", agentsdk.SnapshotContentType)
+	res, err := s.do(ctx, req)
+	if err != nil {
+		return "", xerrors.Errorf("put object %q: %w", key, err)
+	}
+	_ = res.Body.Close()
+	return key, nil
+}
+
+func (s *s3Store) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, err := s.request(ctx, http.MethodGet, key, nil, emptyPayloadHash)
+	if err != nil {
+		return nil, err
+	}
+	res, err := s.do(ctx, req)
+	if err != nil {
+		return nil, xerrors.Errorf("get object %q: %w", key, err)
+	}
+	return res.Body, nil
+}
+
+func (s *s3Store) Delete(ctx context.Context, key string) error {
+	req, err := s.request(ctx, http.MethodDelete, key, nil, emptyPayloadHash)
+	if err != nil {
+		return err
+	}
+	res, err := s.do(ctx, req)
+	if err != nil {
+		return xerrors.Errorf("delete object %q: %w", key, err)
+	}
+	_ = res.Body.Close()
+	return nil
+}
+
+func (s *s3Store) request(ctx context.Context, method, key string, body io.Reader, payloadHash string) (*http.Request, error) {
+	var u url.URL
+	if s.opts.Endpoint != nil {
+		u = *s.opts.Endpoint
+		u.Path = path.Join("/", u.Path, s.opts.Bucket, key)
+	} else {
+		u = url.URL{
+			Scheme: "https",
+			Host:   fmt.Sprintf("%s.s3.%s.amazonaws.com", s.opts.Bucket, s.opts.Region),
+			Path:   "/" + key,
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, xerrors.Errorf("create request: %w", err)
+	}
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	return req, nil
+}
+
+// do signs and sends req. The response body must be closed by the caller
+// when no error is returned.
+func (s *s3Store) do(ctx context.Context, req *http.Request) (*http.Response, error) {
+	creds, err := s.credentials.Retrieve(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("retrieve credentials: %w", err)
+	}
+	err = s.signer.SignHTTP(ctx, creds, req, req.Header.Get("X-Amz-Content-Sha256"), "s3", s.opts.Region, time.Now())
+	if err != nil {
+		return nil, xerrors.Errorf("sign request: %w", err)
+	}
+	res, err := s.opts.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	// DELETE responds with 204 No Content.
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusNoContent {
+		defer res.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 4<<10))
+		return nil, xerrors.Errorf("unexpected status %s: %s", res.Status, strings.TrimSpace(string(body)))
+	}
+	return res, nil
+}
@@ -0,0 +1,101 @@
+// Package workspacesnapshots stores the archives of workspace snapshots.
+package workspacesnapshots
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"io"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/v2/coderd/database"
+	"github.com/coder/coder/v2/coderd/database/dbauthz"
+	"github.com/coder/coder/v2/coderd/database/dbtime"
+	"github.com/coder/coder/v2/codersdk/agentsdk"
+)
+
+// Store persists snapshot archives.
+type Store interface {
+	// Storage identifies the backend in the database.
+	Storage() database.WorkspaceSnapshotStorage
+	// Put stores the archive of a snapshot and returns the key to open it
+	// with.
+	Put(ctx context.Context, workspace database.Workspace, snapshotID uuid.UUID, data []byte) (string, error)
+	// Open returns the archive stored under key.
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the archive stored under key, after its snapshot has
+	// been deleted.
+	Delete(ctx context.Context, key string) error
+}
+
+type databaseStore struct {
+	db database.Store
+}
+
+// NewDatabaseStore returns a Store that keeps archives in the files table.
+// It is always available, so that snapshots remain readable when the
+// deployment switches to another backend.
+func NewDatabaseStore(db database.Store) Store {
+	return &databaseStore{db: db}
+}
+
+func (*databaseStore) Storage() database.WorkspaceSnapshotStorage {
+	return database.WorkspaceSnapshotStorageDatabase
+}
+
+func (s *databaseStore) Put(ctx context.Context, workspace database.Workspace, _ uuid.UUID, data []byte) (string, error) {
+	// The workspace agent is not allowed to create files, the caller has
+	// already been authorized to snapshot the workspace.
+	//nolint:gocritic // Snapshot archives are owned by the workspace owner.
+	ctx = dbauthz.AsSystemRestricted(ctx)
+
+	hashBytes := sha256.Sum256(data)
+	hash := hex.EncodeToString(hashBytes[:])
+	file, err := s.db.GetFileByHashAndCreator(ctx, database.GetFileByHashAndCreatorParams{
+		Hash:      hash,
+		CreatedBy: workspace.OwnerID,
+	})
+	if err == nil {
+		return file.ID.String(), nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return "", xerrors.Errorf("get file by hash: %w", err)
+	}
+	file, err = s.db.InsertFile(ctx, database.InsertFileParams{
+		ID:        uuid.New(),
+		Hash:      hash,
+		CreatedBy: workspace.OwnerID,
+		CreatedAt: dbtime.Now(),
+		Mimetype:  agentsdk.SnapshotContentType,
+		Data:      data,
+	})
+	if err != nil {
+		return "", xerrors.Errorf("insert file: %w", err)
+	}
+	return file.ID.String(), nil
+}
+
+func (s *databaseStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	id, err := uuid.Parse(key)
+	if err != nil {
+		return nil, xerrors.Errorf("parse file ID: %w", err)
+	}
+	//nolint:gocritic // The caller has already been authorized to read the snapshot.
+	file, err := s.db.GetFileByID(dbauthz.AsSystemRestricted(ctx), id)
+	if err != nil {
+		return nil, xerrors.Errorf("get file: %w", err)
+	}
+	return io.NopCloser(bytes.NewReader(file.Data)), nil
+}
+
+// Delete does nothing, as archives in the files table may be shared by
+// snapshots with identical contents. They are deleted by the query which
+// deletes the last snapshot using them.
+func (*databaseStore) Delete(context.Context, string) error {
+	return nil
+}
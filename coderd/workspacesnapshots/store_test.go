@@ -0,0 +1,118 @@
+package workspacesnapshots_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/coderd/database"
+	"github.com/coder/coder/v2/coderd/database/dbmem"
+	"github.com/coder/coder/v2/coderd/workspacesnapshots"
+	"github.com/coder/coder/v2/testutil"
+)
+
+func TestDatabaseStore(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Context(t, testutil.WaitShort)
+	store := workspacesnapshots.NewDatabaseStore(dbmem.New())
+	require.Equal(t, database.WorkspaceSnapshotStorageDatabase, store.Storage())
+
+	workspace := database.Workspace{ID: uuid.New(), OwnerID: uuid.New()}
+	key, err := store.Put(ctx, workspace, uuid.New(), []byte("archive"))
+	require.NoError(t, err)
+	// Identical archives are only stored once.
+	again, err := store.Put(ctx, workspace, uuid.New(), []byte("archive"))
+	require.NoError(t, err)
+	require.Equal(t, key, again)
+
+	requireArchive(ctx, t, store, key, "archive")
+}
+
+func TestS3Store(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu      sync.Mutex
+		objects = map[string][]byte{}
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=access/") {
+			http.Error(rw, "unsigned request", http.StatusForbidden)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case http.MethodPut:
+			data, err := io.ReadAll(r.Body)
+			if err != nil {
+				http.Error(rw, err.Error(), http.StatusBadRequest)
+				return
+			}
+			objects[r.URL.Path] = data
+		case http.MethodGet:
+			data, ok := objects[r.URL.Path]
+			if !ok {
+				http.Error(rw, "NoSuchKey", http.StatusNotFound)
+				return
+			}
+			_, _ = rw.Write(data)
+		case http.MethodDelete:
+			delete(objects, r.URL.Path)
+			rw.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	endpoint, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	ctx := testutil.Context(t, testutil.WaitShort)
+	store, err := workspacesnapshots.NewS3Store(ctx, workspacesnapshots.S3Options{
+		Bucket:          "snapshots",
+		Region:          "us-east-1",
+		Endpoint:        endpoint,
+		Prefix:          "/coder/",
+		AccessKeyID:     "access",
+		SecretAccessKey: "secret",
+	})
+	require.NoError(t, err)
+	require.Equal(t, database.WorkspaceSnapshotStorageS3, store.Storage())
+
+	workspace := database.Workspace{ID: uuid.New()}
+	snapshotID := uuid.New()
+	key, err := store.Put(ctx, workspace, snapshotID, []byte("archive"))
+	require.NoError(t, err)
+	require.Equal(t, "coder/"+workspace.ID.String()+"/"+snapshotID.String()+".tar.gz", key)
+	require.Contains(t, objects, "/snapshots/"+key)
+
+	requireArchive(ctx, t, store, key, "archive")
+
+	_, err = store.Open(ctx, "missing")
+	require.ErrorContains(t, err, "NoSuchKey")
+
+	err = store.Delete(ctx, key)
+	require.NoError(t, err)
+	mu.Lock()
+	require.NotContains(t, objects, "/snapshots/"+key)
+	mu.Unlock()
+}
+
+func requireArchive(ctx context.Context, t *testing.T, store workspacesnapshots.Store, key, want string) {
+	t.Helper()
+
+	rc, err := store.Open(ctx, key)
+	require.NoError(t, err)
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	require.Equal(t, want, string(data))
+}
@@ -0,0 +1,110 @@
+package coderd_test
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/coderd/coderdtest"
+	"github.com/coder/coder/v2/coderd/database"
+	"github.com/coder/coder/v2/coderd/database/dbfake"
+	"github.com/coder/coder/v2/codersdk"
+	"github.com/coder/coder/v2/codersdk/agentsdk"
+	"github.com/coder/coder/v2/testutil"
+)
+
+func TestWorkspaceSnapshots(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Context(t, testutil.WaitLong)
+	ownerClient, db := coderdtest.NewWithDatabase(t, nil)
+	owner := coderdtest.CreateFirstUser(t, ownerClient)
+	client, user := coderdtest.CreateAnotherUser(t, ownerClient, owner.OrganizationID)
+	r := dbfake.WorkspaceBuild(t, db, database.WorkspaceTable{
+		OrganizationID: owner.OrganizationID,
+		OwnerID:        user.ID,
+	}).WithAgent().Do()
+
+	agentClient := agentsdk.New(client.URL)
+	agentClient.SetSessionToken(r.AgentToken)
+
+	full, err := agentClient.UploadSnapshot(ctx, agentsdk.UploadSnapshotRequest{
+		Trigger:   codersdk.WorkspaceSnapshotTriggerStop,
+		FileCount: 2,
+		Paths:     []string{"/home/coder"},
+	}, bytes.NewReader([]byte("full")))
+	require.NoError(t, err)
+	require.Equal(t, r.Workspace.ID, full.WorkspaceID)
+	require.Equal(t, codersdk.WorkspaceSnapshotStorageDatabase, full.Storage)
+	require.Nil(t, full.ParentID)
+
+	incremental, err := agentClient.UploadSnapshot(ctx, agentsdk.UploadSnapshotRequest{
+		Trigger:   codersdk.WorkspaceSnapshotTriggerSchedule,
+		ParentID:  &full.ID,
+		FileCount: 3,
+		Paths:     []string{"/home/coder"},
+	}, bytes.NewReader([]byte("incremental")))
+	require.NoError(t, err)
+	require.Equal(t, &full.ID, incremental.ParentID)
+
+	snapshots, err := client.WorkspaceSnapshots(ctx, r.Workspace.ID)
+	require.NoError(t, err)
+	require.Len(t, snapshots, 2)
+	require.Equal(t, incremental.ID, snapshots[0].ID)
+
+	// Nothing to restore yet.
+	pending, err := agentClient.PendingSnapshotRestore(ctx)
+	require.NoError(t, err)
+	require.Empty(t, pending.Snapshots)
+
+	_, err = client.RestoreWorkspaceSnapshot(ctx, r.Workspace.ID, incremental.ID)
+	require.NoError(t, err)
+
+	// The restore is meant for the agent of the next build.
+	pending, err = agentClient.PendingSnapshotRestore(ctx)
+	require.NoError(t, err)
+	require.Empty(t, pending.Snapshots)
+
+	next := dbfake.WorkspaceBuild(t, db, r.Workspace).Seed(database.WorkspaceBuild{
+		BuildNumber: r.Build.BuildNumber + 1,
+	}).WithAgent().Do()
+	agentClient = agentsdk.New(client.URL)
+	agentClient.SetSessionToken(next.AgentToken)
+
+	pending, err = agentClient.PendingSnapshotRestore(ctx)
+	require.NoError(t, err)
+	require.Len(t, pending.Snapshots, 2)
+	require.Equal(t, full.ID, pending.Snapshots[0].ID)
+	require.Equal(t, incremental.ID, pending.Snapshots[1].ID)
+
+	archive, err := agentClient.DownloadSnapshot(ctx, incremental.ID)
+	require.NoError(t, err)
+	data, err := io.ReadAll(archive)
+	_ = archive.Close()
+	require.NoError(t, err)
+	require.Equal(t, "incremental", string(data))
+
+	err = agentClient.CompleteSnapshotRestore(ctx, agentsdk.CompleteSnapshotRestoreRequest{SnapshotID: incremental.ID})
+	require.NoError(t, err)
+	pending, err = agentClient.PendingSnapshotRestore(ctx)
+	require.NoError(t, err)
+	require.Empty(t, pending.Snapshots)
+
+	// Snapshots of other workspaces cannot be restored.
+	other := dbfake.WorkspaceBuild(t, db, database.WorkspaceTable{
+		OrganizationID: owner.OrganizationID,
+		OwnerID:        user.ID,
+	}).WithAgent().Do()
+	_, err = client.RestoreWorkspaceSnapshot(ctx, other.Workspace.ID, incremental.ID)
+	var apiErr *codersdk.Error
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+
+	// A pending restore can be canceled.
+	_, err = client.RestoreWorkspaceSnapshot(ctx, r.Workspace.ID, full.ID)
+	require.NoError(t, err)
+	require.NoError(t, client.CancelWorkspaceSnapshotRestore(ctx, r.Workspace.ID))
+}
@@ -376,6 +376,8 @@ func (b *Builder) buildTx(authFunc func(action policy.Action, object rbac.Object
 			Valid:      true,
 			RawMessage: traceMetadataRaw,
 		},
+		Priority:   b.jobPriority(),
+		TemplateID: uuid.NullUUID{UUID: template.ID, Valid: true},
 	})
 	if err != nil {
 		return nil, nil, nil, BuildError{http.StatusInternalServerError, "insert provisioner job", err}
@@ -512,6 +514,20 @@ func (b *Builder) buildTx(authFunc func(action policy.Action, object rbac.Object
 	return &workspaceBuild, &provisionerJob, provisionerDaemons, nil
 }
 
+// jobPriority returns the priority class of the provisioner job of the build.
+// Builds started by users are waited on, so they go before builds started by
+// the lifecycle executor and prebuilds.
+func (b *Builder) jobPriority() database.ProvisionerJobPriority {
+	switch {
+	case b.initiator == database.PrebuildsSystemUserID:
+		return database.ProvisionerJobPriorityPrebuild
+	case b.reason == database.BuildReasonInitiator:
+		return database.ProvisionerJobPriorityInteractive
+	default:
+		return database.ProvisionerJobPriorityScheduled
+	}
+}
+
 func (b *Builder) getTemplate() (*database.Template, error) {
 	if b.template != nil {
 		return b.template, nil
@@ -993,7 +1009,14 @@ func (b *Builder) getProvisionerTags() (map[string]string, error) {
 		}
 		tags[workspaceTag.Key] = str
 	}
-	return tags, nil
+
+	// The provisioner version pinned by the template applies to every build,
+	// including builds of versions imported before it was pinned.
+	template, err := b.getTemplate()
+	if err != nil {
+		return nil, BuildError{http.StatusInternalServerError, "failed to fetch template", err}
+	}
+	return provisionersdk.WithProvisionerVersion(tags, string(template.Provisioner), template.ProvisionerVersion), nil
 }
 
 func (b *Builder) getTemplateVersionWorkspaceTags() ([]database.TemplateVersionWorkspaceTag, error) {
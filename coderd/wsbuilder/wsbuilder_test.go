@@ -391,6 +391,63 @@ func TestWorkspaceBuildWithTags(t *testing.T) {
 	req.NoError(err)
 }
 
+func TestWorkspaceBuildWithProvisionerVersion(t *testing.T) {
+	t.Parallel()
+
+	asrt := assert.New(t)
+	req := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mDB := expectDB(t,
+		// Inputs
+		func(mTx *dbmock.MockStore) {
+			mTx.EXPECT().GetTemplateByID(gomock.Any(), templateID).
+				Times(1).
+				Return(database.Template{
+					ID:                      templateID,
+					OrganizationID:          orgID,
+					Provisioner:             database.ProvisionerTypeTofu,
+					ActiveVersionID:         activeVersionID,
+					UseClassicParameterFlow: true,
+					ProvisionerVersion:      "1.9.1",
+				}, nil)
+		},
+		withInactiveVersion(nil),
+		withLastBuildFound,
+		withTemplateVersionVariables(inactiveVersionID, nil),
+		withRichParameters(nil),
+		withParameterSchemas(inactiveJobID, nil),
+		withWorkspaceTags(inactiveVersionID, nil),
+		withProvisionerDaemons([]database.GetEligibleProvisionerDaemonsByProvisionerJobIDsRow{}),
+
+		// Outputs
+		expectProvisionerJob(func(job database.InsertProvisionerJobParams) {
+			asrt.Equal(database.ProvisionerTypeTofu, job.Provisioner)
+			asrt.Equal(database.StringMap{
+				"scope":   "user",
+				"version": "inactive",
+				"owner":   userID.String(),
+
+				"provisioner_version.tofu.1.9.1": "true",
+			}, job.Tags)
+		}),
+		withInTx,
+		expectBuild(func(_ database.InsertWorkspaceBuildParams) {}),
+		expectBuildParameters(func(_ database.InsertWorkspaceBuildParametersParams) {
+		}),
+		withBuild,
+	)
+	fc := files.New(prometheus.NewRegistry(), &coderdtest.FakeAuthorizer{})
+
+	ws := database.Workspace{ID: workspaceID, TemplateID: templateID, OwnerID: userID}
+	uut := wsbuilder.New(ws, database.WorkspaceTransitionStart)
+	// nolint: dogsled
+	_, _, _, err := uut.Build(ctx, mDB, fc, nil, audit.WorkspaceBuildBaggage{})
+	req.NoError(err)
+}
+
 func TestWorkspaceBuildWithRichParameters(t *testing.T) {
 	t.Parallel()
 
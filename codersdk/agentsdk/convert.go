@@ -43,6 +43,10 @@ func ManifestFromProto(manifest *proto.Manifest) (Manifest, error) {
 	if err != nil {
 		return Manifest{}, xerrors.Errorf("error converting workspace agent devcontainers: %w", err)
 	}
+	portShareRules, err := PortShareRulesFromProto(manifest.PortShareRules)
+	if err != nil {
+		return Manifest{}, xerrors.Errorf("error converting port share rules: %w", err)
+	}
 	return Manifest{
 		ParentID:                 parentID,
 		AgentID:                  agentID,
@@ -62,6 +66,7 @@ func ManifestFromProto(manifest *proto.Manifest) (Manifest, error) {
 		DisableDirectConnections: manifest.DisableDirectConnections,
 		Metadata:                 MetadataDescriptionsFromProto(manifest.Metadata),
 		Devcontainers:            devcontainers,
+		PortShareRules:           portShareRules,
 	}, nil
 }
 
@@ -70,6 +75,10 @@ func ProtoFromManifest(manifest Manifest) (*proto.Manifest, error) {
 	if err != nil {
 		return nil, xerrors.Errorf("convert workspace apps: %w", err)
 	}
+	portShareRules, err := ProtoFromPortShareRules(manifest.PortShareRules)
+	if err != nil {
+		return nil, xerrors.Errorf("convert port share rules: %w", err)
+	}
 	return &proto.Manifest{
 		ParentId:      manifest.ParentID[:],
 		AgentId:       manifest.AgentID[:],
@@ -90,6 +99,7 @@ func ProtoFromManifest(manifest Manifest) (*proto.Manifest, error) {
 		Apps:                     apps,
 		Metadata:                 ProtoFromMetadataDescriptions(manifest.Metadata),
 		Devcontainers:            ProtoFromDevcontainers(manifest.Devcontainers),
+		PortShareRules:           portShareRules,
 	}, nil
 }
 
@@ -483,3 +493,63 @@ func ProtoFromDevcontainer(dc codersdk.WorkspaceAgentDevcontainer) *proto.Worksp
 		ConfigPath:      dc.ConfigPath,
 	}
 }
+
+func PortShareRulesFromProto(prules []*proto.PortShareRule) ([]codersdk.TemplatePortShareRule, error) {
+	ret := make([]codersdk.TemplatePortShareRule, len(prules))
+	for i, prule := range prules {
+		rule, err := PortShareRuleFromProto(prule)
+		if err != nil {
+			return nil, xerrors.Errorf("parse port share rule %v: %w", i, err)
+		}
+		ret[i] = rule
+	}
+	return ret, nil
+}
+
+func PortShareRuleFromProto(prule *proto.PortShareRule) (codersdk.TemplatePortShareRule, error) {
+	shareLevel := codersdk.WorkspaceAgentPortShareLevel(strings.ToLower(prule.ShareLevel.String()))
+	if !shareLevel.ValidPortShareLevel() {
+		return codersdk.TemplatePortShareRule{}, xerrors.Errorf("unknown port share level: %v (%q)", prule.ShareLevel, prule.ShareLevel.String())
+	}
+	protocol := codersdk.WorkspaceAgentPortShareProtocol(strings.ToLower(prule.Protocol.String()))
+	if !protocol.ValidPortProtocol() {
+		return codersdk.TemplatePortShareRule{}, xerrors.Errorf("unknown port share protocol: %v (%q)", prule.Protocol, prule.Protocol.String())
+	}
+	return codersdk.TemplatePortShareRule{
+		PortStart:  prule.PortStart,
+		PortEnd:    prule.PortEnd,
+		ShareLevel: shareLevel,
+		Protocol:   protocol,
+		Label:      prule.Label,
+	}, nil
+}
+
+func ProtoFromPortShareRules(rules []codersdk.TemplatePortShareRule) ([]*proto.PortShareRule, error) {
+	ret := make([]*proto.PortShareRule, len(rules))
+	for i, rule := range rules {
+		prule, err := ProtoFromPortShareRule(rule)
+		if err != nil {
+			return nil, xerrors.Errorf("convert port share rule %v: %w", i, err)
+		}
+		ret[i] = prule
+	}
+	return ret, nil
+}
+
+func ProtoFromPortShareRule(rule codersdk.TemplatePortShareRule) (*proto.PortShareRule, error) {
+	shareLevel, ok := proto.WorkspaceApp_SharingLevel_value[strings.ToUpper(string(rule.ShareLevel))]
+	if !ok {
+		return nil, xerrors.Errorf("unknown port share level %s", rule.ShareLevel)
+	}
+	protocol, ok := proto.PortShareRule_Protocol_value[strings.ToUpper(string(rule.Protocol))]
+	if !ok {
+		return nil, xerrors.Errorf("unknown port share protocol %s", rule.Protocol)
+	}
+	return &proto.PortShareRule{
+		PortStart:  rule.PortStart,
+		PortEnd:    rule.PortEnd,
+		ShareLevel: proto.WorkspaceApp_SharingLevel(shareLevel),
+		Protocol:   proto.PortShareRule_Protocol(protocol),
+		Label:      rule.Label,
+	}, nil
+}
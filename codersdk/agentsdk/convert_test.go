@@ -138,6 +138,21 @@ func TestManifest(t *testing.T) {
 				ConfigPath:      "/home/coder/coder/.devcontainer/devcontainer.json",
 			},
 		},
+		PortShareRules: []codersdk.TemplatePortShareRule{
+			{
+				PortStart:  3000,
+				PortEnd:    3999,
+				ShareLevel: codersdk.WorkspaceAgentPortShareLevelOrganization,
+				Protocol:   codersdk.WorkspaceAgentPortShareProtocolHTTP,
+			},
+			{
+				PortStart:  8080,
+				PortEnd:    8080,
+				ShareLevel: codersdk.WorkspaceAgentPortShareLevelPublic,
+				Protocol:   codersdk.WorkspaceAgentPortShareProtocolHTTPS,
+				Label:      "docs",
+			},
+		},
 	}
 	p, err := agentsdk.ProtoFromManifest(manifest)
 	require.NoError(t, err)
@@ -162,6 +177,7 @@ func TestManifest(t *testing.T) {
 	require.Equal(t, manifest.Metadata, back.Metadata)
 	require.Equal(t, manifest.Scripts, back.Scripts)
 	require.Equal(t, manifest.Devcontainers, back.Devcontainers)
+	require.Equal(t, manifest.PortShareRules, back.PortShareRules)
 }
 
 func TestSubsystems(t *testing.T) {
@@ -0,0 +1,114 @@
+package agentsdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+
+	"github.com/coder/coder/v2/codersdk"
+)
+
+// SnapshotContentType is the content type of snapshot archives, which are
+// gzip compressed tarballs.
+const SnapshotContentType = "application/gzip"
+
+// UploadSnapshotRequest describes a snapshot archive uploaded by the agent.
+type UploadSnapshotRequest struct {
+	Trigger codersdk.WorkspaceSnapshotTrigger
+	// ParentID is the snapshot the archive is incremental to, or nil for a
+	// full snapshot.
+	ParentID  *uuid.UUID
+	FileCount int32
+	Paths     []string
+}
+
+// UploadSnapshot uploads a snapshot archive of the workspace.
+func (c *Client) UploadSnapshot(ctx context.Context, req UploadSnapshotRequest, archive io.Reader) (codersdk.WorkspaceSnapshot, error) {
+	opts := []codersdk.RequestOption{
+		codersdk.WithQueryParam("trigger", string(req.Trigger)),
+		codersdk.WithQueryParam("file_count", strconv.Itoa(int(req.FileCount))),
+		func(r *http.Request) {
+			r.Header.Set("Content-Type", SnapshotContentType)
+			q := r.URL.Query()
+			for _, p := range req.Paths {
+				q.Add("path", p)
+			}
+			r.URL.RawQuery = q.Encode()
+		},
+	}
+	if req.ParentID != nil {
+		opts = append(opts, codersdk.WithQueryParam("parent_id", req.ParentID.String()))
+	}
+	res, err := c.SDK.Request(ctx, http.MethodPost, "/api/v2/workspaceagents/me/snapshots", archive, opts...)
+	if err != nil {
+		return codersdk.WorkspaceSnapshot{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusCreated {
+		return codersdk.WorkspaceSnapshot{}, codersdk.ReadBodyAsError(res)
+	}
+	var snapshot codersdk.WorkspaceSnapshot
+	return snapshot, json.NewDecoder(res.Body).Decode(&snapshot)
+}
+
+// PendingSnapshotRestore lists the snapshots the agent must restore on
+// startup.
+type PendingSnapshotRestore struct {
+	// Snapshots is empty when there is nothing to restore. Otherwise it
+	// holds the snapshot to restore and every snapshot it is incremental
+	// to, oldest first.
+	Snapshots []codersdk.WorkspaceSnapshot `json:"snapshots"`
+}
+
+// PendingSnapshotRestore returns the snapshots the agent must restore.
+func (c *Client) PendingSnapshotRestore(ctx context.Context) (PendingSnapshotRestore, error) {
+	res, err := c.SDK.Request(ctx, http.MethodGet, "/api/v2/workspaceagents/me/snapshots/restore", nil)
+	if err != nil {
+		return PendingSnapshotRestore{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return PendingSnapshotRestore{}, codersdk.ReadBodyAsError(res)
+	}
+	var restore PendingSnapshotRestore
+	return restore, json.NewDecoder(res.Body).Decode(&restore)
+}
+
+// DownloadSnapshot returns the archive of a snapshot of the workspace. The
+// caller must close the returned reader.
+func (c *Client) DownloadSnapshot(ctx context.Context, id uuid.UUID) (io.ReadCloser, error) {
+	res, err := c.SDK.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/workspaceagents/me/snapshots/%s/archive", id), nil)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+		return nil, codersdk.ReadBodyAsError(res)
+	}
+	return res.Body, nil
+}
+
+type CompleteSnapshotRestoreRequest struct {
+	SnapshotID uuid.UUID `json:"snapshot_id"`
+	// Error is set when the restore failed.
+	Error string `json:"error,omitempty"`
+}
+
+// CompleteSnapshotRestore reports the outcome of a pending restore. The
+// restore is not attempted again, even if it failed.
+func (c *Client) CompleteSnapshotRestore(ctx context.Context, req CompleteSnapshotRestoreRequest) error {
+	res, err := c.SDK.Request(ctx, http.MethodPost, "/api/v2/workspaceagents/me/snapshots/restore/complete", req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusNoContent {
+		return codersdk.ReadBodyAsError(res)
+	}
+	return nil
+}
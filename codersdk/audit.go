@@ -3,6 +3,7 @@ package codersdk
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/netip"
 	"strings"
@@ -208,6 +209,74 @@ type CreateTestAuditLogRequest struct {
 	RequestID        uuid.UUID       `json:"request_id,omitempty" format:"uuid"`
 }
 
+type AuditLogVerifyRequest struct {
+	From time.Time `json:"from" format:"date-time"`
+	To   time.Time `json:"to" format:"date-time"`
+}
+
+type AuditLogVerificationProblemKind string
+
+const (
+	// AuditLogVerificationProblemGap is a missing range of the hash chain.
+	AuditLogVerificationProblemGap AuditLogVerificationProblemKind = "gap"
+	// AuditLogVerificationProblemBrokenLink is an entry of the hash chain
+	// which doesn't follow the previous entry.
+	AuditLogVerificationProblemBrokenLink AuditLogVerificationProblemKind = "broken_link"
+	// AuditLogVerificationProblemDeleted is an audit log which was deleted
+	// after it was chained.
+	AuditLogVerificationProblemDeleted AuditLogVerificationProblemKind = "deleted"
+	// AuditLogVerificationProblemModified is an audit log which doesn't match
+	// its hash.
+	AuditLogVerificationProblemModified AuditLogVerificationProblemKind = "modified"
+	// AuditLogVerificationProblemUnchained is an audit log which isn't part of
+	// the hash chain.
+	AuditLogVerificationProblemUnchained AuditLogVerificationProblemKind = "unchained"
+	// AuditLogVerificationProblemInvalidCheckpoint is a checkpoint with an
+	// invalid signature, or which doesn't match the hash chain.
+	AuditLogVerificationProblemInvalidCheckpoint AuditLogVerificationProblemKind = "invalid_checkpoint"
+	// AuditLogVerificationProblemTruncated is a hash chain which ends before
+	// the latest checkpoint.
+	AuditLogVerificationProblemTruncated AuditLogVerificationProblemKind = "truncated"
+)
+
+type AuditLogVerificationProblem struct {
+	Kind AuditLogVerificationProblemKind `json:"kind" enums:"gap,broken_link,deleted,modified,unchained,invalid_checkpoint,truncated"`
+	// Sequence is the position in the hash chain, if any.
+	Sequence   int64     `json:"sequence,omitempty"`
+	AuditLogID uuid.UUID `json:"audit_log_id,omitempty" format:"uuid"`
+	Detail     string    `json:"detail"`
+}
+
+// AuditLogVerification is the result of verifying the hash chain of the
+// audit logs in a time range.
+type AuditLogVerification struct {
+	From time.Time `json:"from" format:"date-time"`
+	To   time.Time `json:"to" format:"date-time"`
+	// Entries is the number of verified entries of the hash chain.
+	Entries int64 `json:"entries"`
+	// Checkpoints is the number of verified signed checkpoints.
+	Checkpoints int64 `json:"checkpoints"`
+	// Archived is the number of entries whose audit log was archived by its
+	// retention. Only their links in the chain are verified.
+	Archived int64                         `json:"archived"`
+	Problems []AuditLogVerificationProblem `json:"problems"`
+	// ProblemsTruncated is set when more problems were found than listed.
+	ProblemsTruncated bool `json:"problems_truncated"`
+}
+
+// AuditLogArchive is a compressed file expired audit logs were archived to
+// before they were deleted.
+type AuditLogArchive struct {
+	ID            uuid.UUID `json:"id" format:"uuid"`
+	CreatedAt     time.Time `json:"created_at" format:"date-time"`
+	FileName      string    `json:"file_name"`
+	OldestTime    time.Time `json:"oldest_time" format:"date-time"`
+	NewestTime    time.Time `json:"newest_time" format:"date-time"`
+	AuditLogCount int32     `json:"audit_log_count"`
+	// RestoredAt is set while the audit logs of the archive are restored.
+	RestoredAt *time.Time `json:"restored_at,omitempty" format:"date-time"`
+}
+
 // AuditLogs retrieves audit logs from the given page.
 func (c *Client) AuditLogs(ctx context.Context, req AuditLogsRequest) (AuditLogResponse, error) {
 	res, err := c.Request(ctx, http.MethodGet, "/api/v2/audit", nil, req.Pagination.asRequestOption(), func(r *http.Request) {
@@ -252,3 +321,63 @@ func (c *Client) CreateTestAuditLog(ctx context.Context, req CreateTestAuditLogR
 
 	return nil
 }
+
+// VerifyAuditLogs verifies that the audit logs in a time range were not
+// modified or deleted.
+func (c *Client) VerifyAuditLogs(ctx context.Context, req AuditLogVerifyRequest) (AuditLogVerification, error) {
+	res, err := c.Request(ctx, http.MethodGet, "/api/v2/audit/verify", nil, func(r *http.Request) {
+		q := r.URL.Query()
+		if !req.From.IsZero() {
+			q.Set("from", req.From.Format(time.RFC3339Nano))
+		}
+		if !req.To.IsZero() {
+			q.Set("to", req.To.Format(time.RFC3339Nano))
+		}
+		r.URL.RawQuery = q.Encode()
+	})
+	if err != nil {
+		return AuditLogVerification{}, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return AuditLogVerification{}, ReadBodyAsError(res)
+	}
+
+	var verification AuditLogVerification
+	return verification, json.NewDecoder(res.Body).Decode(&verification)
+}
+
+// AuditLogArchives lists the archives of expired audit logs, newest first.
+func (c *Client) AuditLogArchives(ctx context.Context) ([]AuditLogArchive, error) {
+	res, err := c.Request(ctx, http.MethodGet, "/api/v2/audit/archives", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, ReadBodyAsError(res)
+	}
+
+	var archives []AuditLogArchive
+	return archives, json.NewDecoder(res.Body).Decode(&archives)
+}
+
+// RestoreAuditLogArchive inserts the audit logs of an archive again, for
+// investigations. They are deleted again after the restore duration of the
+// deployment.
+func (c *Client) RestoreAuditLogArchive(ctx context.Context, id uuid.UUID) (AuditLogArchive, error) {
+	res, err := c.Request(ctx, http.MethodPost, fmt.Sprintf("/api/v2/audit/archives/%s/restore", id), nil)
+	if err != nil {
+		return AuditLogArchive{}, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return AuditLogArchive{}, ReadBodyAsError(res)
+	}
+
+	var archive AuditLogArchive
+	return archive, json.NewDecoder(res.Body).Decode(&archive)
+}
@@ -0,0 +1,87 @@
+package codersdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// AutostopWindow is the time of day workspaces are stopped at on a day of the
+// week, in the timezone of the quiet hours schedule of the workspace owner.
+type AutostopWindow struct {
+	Weekday string `json:"weekday" enums:"monday,tuesday,wednesday,thursday,friday,saturday,sunday"`
+	// Time is the time of day workspaces are stopped at.
+	Time string `json:"time"` // HH:mm (24-hour)
+}
+
+// AutostopWindows are the times of day workspaces are stopped at, which can
+// differ per day of the week. Workspaces are not stopped on days without a
+// window.
+type AutostopWindows struct {
+	Windows []AutostopWindow `json:"windows"`
+}
+
+// TemplateAutostopWindows returns the autostop windows of a template. This
+// endpoint only exists in enterprise editions.
+func (c *Client) TemplateAutostopWindows(ctx context.Context, templateID uuid.UUID) (AutostopWindows, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/templates/%s/autostop-windows", templateID), nil)
+	if err != nil {
+		return AutostopWindows{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return AutostopWindows{}, ReadBodyAsError(res)
+	}
+	var resp AutostopWindows
+	return resp, json.NewDecoder(res.Body).Decode(&resp)
+}
+
+// UpdateTemplateAutostopWindows replaces the autostop windows of a template,
+// and updates the deadlines of its running workspaces. This endpoint only
+// exists in enterprise editions.
+func (c *Client) UpdateTemplateAutostopWindows(ctx context.Context, templateID uuid.UUID, req AutostopWindows) (AutostopWindows, error) {
+	res, err := c.Request(ctx, http.MethodPut, fmt.Sprintf("/api/v2/templates/%s/autostop-windows", templateID), req)
+	if err != nil {
+		return AutostopWindows{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return AutostopWindows{}, ReadBodyAsError(res)
+	}
+	var resp AutostopWindows
+	return resp, json.NewDecoder(res.Body).Decode(&resp)
+}
+
+// UserAutostopWindows returns the autostop windows of a user. They replace
+// the windows of templates which allow users to set their own autostop. This
+// endpoint only exists in enterprise editions.
+func (c *Client) UserAutostopWindows(ctx context.Context, userIdent string) (AutostopWindows, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/users/%s/autostop-windows", userIdent), nil)
+	if err != nil {
+		return AutostopWindows{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return AutostopWindows{}, ReadBodyAsError(res)
+	}
+	var resp AutostopWindows
+	return resp, json.NewDecoder(res.Body).Decode(&resp)
+}
+
+// UpdateUserAutostopWindows replaces the autostop windows of a user. This
+// endpoint only exists in enterprise editions.
+func (c *Client) UpdateUserAutostopWindows(ctx context.Context, userIdent string, req AutostopWindows) (AutostopWindows, error) {
+	res, err := c.Request(ctx, http.MethodPut, fmt.Sprintf("/api/v2/users/%s/autostop-windows", userIdent), req)
+	if err != nil {
+		return AutostopWindows{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return AutostopWindows{}, ReadBodyAsError(res)
+	}
+	var resp AutostopWindows
+	return resp, json.NewDecoder(res.Body).Decode(&resp)
+}
@@ -399,6 +399,10 @@ type DeploymentValues struct {
 	WorkspaceHostnameSuffix         serpent.String                       `json:"workspace_hostname_suffix,omitempty" typescript:",notnull"`
 	Prebuilds                       PrebuildsConfig                      `json:"workspace_prebuilds,omitempty" typescript:",notnull"`
 	HideAITasks                     serpent.Bool                         `json:"hide_ai_tasks,omitempty" typescript:",notnull"`
+	WorkspaceSnapshots              WorkspaceSnapshotsConfig             `json:"workspace_snapshots,omitempty" typescript:",notnull"`
+	AuditLogStreaming               AuditLogStreamingConfig              `json:"audit_log_streaming,omitempty" typescript:",notnull"`
+	AuditLogRetention               AuditLogRetentionConfig              `json:"audit_log_retention,omitempty" typescript:",notnull"`
+	WorkspaceStateHistory           WorkspaceStateHistoryConfig          `json:"workspace_state_history,omitempty" typescript:",notnull"`
 
 	Config      serpent.YAMLConfigPath `json:"config,omitempty" typescript:",notnull"`
 	WriteConfig serpent.Bool           `json:"write_config,omitempty" typescript:",notnull"`
@@ -652,10 +656,21 @@ type ProvisionerConfig struct {
 	// Daemons is the number of built-in terraform provisioners.
 	Daemons             serpent.Int64       `json:"daemons" typescript:",notnull"`
 	DaemonTypes         serpent.StringArray `json:"daemon_types" typescript:",notnull"`
+	DaemonVersions      serpent.StringArray `json:"daemon_versions" typescript:",notnull"`
 	DaemonPollInterval  serpent.Duration    `json:"daemon_poll_interval" typescript:",notnull"`
 	DaemonPollJitter    serpent.Duration    `json:"daemon_poll_jitter" typescript:",notnull"`
 	ForceCancelInterval serpent.Duration    `json:"force_cancel_interval" typescript:",notnull"`
 	DaemonPSK           serpent.String      `json:"daemon_psk" typescript:",notnull"`
+	ProviderMirror      serpent.Bool        `json:"provider_mirror" typescript:",notnull"`
+	// ExternalCommand is the executable the built-in external provisioners
+	// invoke for each stage of a job.
+	ExternalCommand serpent.String `json:"external_command" typescript:",notnull"`
+	// ScalerEnable serves the KEDA external scaler of the provisioner
+	// daemons on ScalerAddress.
+	ScalerEnable serpent.Bool `json:"scaler_enable" typescript:",notnull"`
+	// ScalerAddress is the address the KEDA external scaler of the
+	// provisioner daemons listens on.
+	ScalerAddress serpent.String `json:"scaler_address" typescript:",notnull"`
 }
 
 type RateLimitConfig struct {
@@ -722,7 +737,7 @@ type NotificationsConfig struct {
 	// How often to query the database for queued notifications.
 	FetchInterval serpent.Duration `json:"fetch_interval"`
 
-	// Which delivery method to use (available options: 'smtp', 'webhook').
+	// Which delivery method to use (available options: 'smtp', 'webhook', 'slack', 'matrix', 'teams').
 	Method serpent.String `json:"method"`
 	// How long to wait while a notification is being sent before giving up.
 	DispatchTimeout serpent.Duration `json:"dispatch_timeout"`
@@ -732,11 +747,18 @@ type NotificationsConfig struct {
 	Webhook NotificationsWebhookConfig `json:"webhook" typescript:",notnull"`
 	// Inbox settings.
 	Inbox NotificationsInboxConfig `json:"inbox" typescript:",notnull"`
+	// Slack settings.
+	Slack NotificationsSlackConfig `json:"slack" typescript:",notnull"`
+	// Matrix settings.
+	Matrix NotificationsMatrixConfig `json:"matrix" typescript:",notnull"`
+	// Microsoft Teams settings.
+	Teams NotificationsTeamsConfig `json:"teams" typescript:",notnull"`
 }
 
-// Are either of the notification methods enabled?
+// Are any of the notification methods enabled?
 func (n *NotificationsConfig) Enabled() bool {
-	return n.SMTP.Smarthost != "" || n.Webhook.Endpoint != serpent.URL{}
+	return n.SMTP.Smarthost != "" || n.Webhook.Endpoint != serpent.URL{} ||
+		n.Slack.BotToken != "" || n.Matrix.AccessToken != "" || n.Teams.WebhookURL != serpent.URL{}
 }
 
 type NotificationsInboxConfig struct {
@@ -798,6 +820,29 @@ type NotificationsWebhookConfig struct {
 	Endpoint serpent.URL `json:"endpoint" typescript:",notnull"`
 }
 
+type NotificationsSlackConfig struct {
+	// The token of the Slack app posting messages, which needs the chat:write scope.
+	BotToken serpent.String `json:"bot_token" typescript:",notnull"`
+	// The channel messages are posted to for users who have not linked a Slack account.
+	Channel serpent.String `json:"channel" typescript:",notnull"`
+	// The base URL of the Slack Web API.
+	APIURL serpent.URL `json:"api_url" typescript:",notnull"`
+}
+
+type NotificationsMatrixConfig struct {
+	// The homeserver of the Matrix account posting messages.
+	Homeserver serpent.URL `json:"homeserver" typescript:",notnull"`
+	// The access token of the Matrix account posting messages.
+	AccessToken serpent.String `json:"access_token" typescript:",notnull"`
+	// The room messages are posted to for users who have not linked a Matrix account.
+	Room serpent.String `json:"room" typescript:",notnull"`
+}
+
+type NotificationsTeamsConfig struct {
+	// The URL of a Teams workflow that posts Adaptive Cards to a channel.
+	WebhookURL serpent.URL `json:"webhook_url" typescript:",notnull"`
+}
+
 type PrebuildsConfig struct {
 	// ReconciliationInterval defines how often the workspace prebuilds state should be reconciled.
 	ReconciliationInterval serpent.Duration `json:"reconciliation_interval" typescript:",notnull"`
@@ -817,6 +862,98 @@ type PrebuildsConfig struct {
 	FailureHardLimit serpent.Int64 `json:"failure_hard_limit" typescript:"failure_hard_limit"`
 }
 
+// WorkspaceSnapshotsConfig configures where the snapshot archives uploaded by
+// workspace agents are stored.
+type WorkspaceSnapshotsConfig struct {
+	// Storage is either "database" or "s3".
+	Storage serpent.String `json:"storage" typescript:",notnull"`
+	// MaxSize is the largest archive accepted from an agent, in bytes.
+	MaxSize serpent.Int64 `json:"max_size" typescript:",notnull"`
+	// KeepChains is the number of full snapshots kept per workspace agent,
+	// along with the snapshots incremental to them. Zero keeps every
+	// snapshot.
+	KeepChains serpent.Int64              `json:"keep_chains" typescript:",notnull"`
+	S3         WorkspaceSnapshotsS3Config `json:"s3" typescript:",notnull"`
+}
+
+type WorkspaceSnapshotsS3Config struct {
+	Bucket serpent.String `json:"bucket" typescript:",notnull"`
+	Region serpent.String `json:"region" typescript:",notnull"`
+	// Endpoint is the URL of an S3 compatible service. Amazon S3 is used
+	// when empty.
+	Endpoint        serpent.URL    `json:"endpoint" typescript:",notnull"`
+	Prefix          serpent.String `json:"prefix" typescript:",notnull"`
+	AccessKeyID     serpent.String `json:"access_key_id" typescript:",notnull"`
+	SecretAccessKey serpent.String `json:"secret_access_key" typescript:",notnull"`
+}
+
+// AuditLogStreamingConfig configures the sinks audit logs are streamed to.
+// Sinks without an address are disabled.
+type AuditLogStreamingConfig struct {
+	Syslog AuditLogStreamingSyslogConfig `json:"syslog" typescript:",notnull"`
+	OTLP   AuditLogStreamingOTLPConfig   `json:"otlp" typescript:",notnull"`
+	S3     AuditLogStreamingS3Config     `json:"s3" typescript:",notnull"`
+}
+
+type AuditLogStreamingSyslogConfig struct {
+	// Address is the URL of the syslog server, with the tls, tcp or udp
+	// scheme.
+	Address serpent.URL `json:"address" typescript:",notnull"`
+	// CAFile verifies the certificate of the server when using TLS. The
+	// system roots are used when empty.
+	CAFile   serpent.String `json:"ca_file" typescript:",notnull"`
+	CertFile serpent.String `json:"cert_file" typescript:",notnull"`
+	KeyFile  serpent.String `json:"key_file" typescript:",notnull"`
+}
+
+type AuditLogStreamingOTLPConfig struct {
+	// Endpoint is the OTLP/HTTP logs endpoint, e.g.
+	// http://collector:4318/v1/logs.
+	Endpoint serpent.URL `json:"endpoint" typescript:",notnull"`
+	// Headers are sent with every request, as key=value pairs.
+	Headers serpent.StringArray `json:"headers" typescript:",notnull"`
+}
+
+type AuditLogStreamingS3Config struct {
+	Bucket serpent.String `json:"bucket" typescript:",notnull"`
+	Region serpent.String `json:"region" typescript:",notnull"`
+	// Endpoint is the URL of an S3 compatible service. Amazon S3 is used
+	// when empty.
+	Endpoint        serpent.URL    `json:"endpoint" typescript:",notnull"`
+	Prefix          serpent.String `json:"prefix" typescript:",notnull"`
+	AccessKeyID     serpent.String `json:"access_key_id" typescript:",notnull"`
+	SecretAccessKey serpent.String `json:"secret_access_key" typescript:",notnull"`
+	// Interval is how often a batch of audit logs is written.
+	Interval serpent.Duration `json:"interval" typescript:",notnull"`
+}
+
+// AuditLogRetentionConfig configures how long audit logs are kept. Expired
+// audit logs are archived to compressed files in the database before they are
+// deleted.
+type AuditLogRetentionConfig struct {
+	// Default is the retention of audit logs no rule matches. Zero keeps
+	// them forever.
+	Default serpent.Duration `json:"default" typescript:",notnull"`
+	// Rules are [resource_type:]action=duration retentions, where the
+	// action may be * to match every action of a resource type. The most
+	// specific rule matching an audit log applies.
+	Rules serpent.StringArray `json:"rules" typescript:",notnull"`
+	// RestoreDuration is how long audit logs restored from an archive are
+	// kept before they are deleted again.
+	RestoreDuration serpent.Duration `json:"restore_duration" typescript:",notnull"`
+}
+
+// WorkspaceStateHistoryConfig configures how many Terraform states of earlier
+// builds are kept per workspace. The newest state of a workspace is always
+// kept.
+type WorkspaceStateHistoryConfig struct {
+	// Builds is the number of states kept per workspace. Zero keeps any
+	// number of states.
+	Builds serpent.Int64 `json:"builds" typescript:",notnull"`
+	// MaxAge is how long states are kept. Zero keeps them forever.
+	MaxAge serpent.Duration `json:"max_age" typescript:",notnull"`
+}
+
 const (
 	annotationFormatDuration = "format_duration"
 	annotationEnterpriseKey  = "enterprise"
@@ -1047,11 +1184,67 @@ func (c *DeploymentValues) Options() serpent.OptionSet {
 			Parent: &deploymentGroupNotifications,
 			YAML:   "webhook",
 		}
+		deploymentGroupNotificationsSlack = serpent.Group{
+			Name:   "Slack",
+			Parent: &deploymentGroupNotifications,
+			YAML:   "slack",
+		}
+		deploymentGroupNotificationsMatrix = serpent.Group{
+			Name:   "Matrix",
+			Parent: &deploymentGroupNotifications,
+			YAML:   "matrix",
+		}
+		deploymentGroupNotificationsTeams = serpent.Group{
+			Name:        "Microsoft Teams",
+			Parent:      &deploymentGroupNotifications,
+			Description: "Teams notifications are posted to a channel and mention the user, as incoming webhooks cannot send direct messages.",
+			YAML:        "teams",
+		}
 		deploymentGroupPrebuilds = serpent.Group{
 			Name:        "Workspace Prebuilds",
 			YAML:        "workspace_prebuilds",
 			Description: "Configure how workspace prebuilds behave.",
 		}
+		deploymentGroupWorkspaceSnapshots = serpent.Group{
+			Name:        "Workspace Snapshots",
+			YAML:        "workspace_snapshots",
+			Description: "Configure where workspace agents store snapshots of workspace directories.",
+		}
+		deploymentGroupWorkspaceSnapshotsS3 = serpent.Group{
+			Name:   "S3",
+			Parent: &deploymentGroupWorkspaceSnapshots,
+			YAML:   "s3",
+		}
+		deploymentGroupAuditLogStreaming = serpent.Group{
+			Name:        "Audit Log Streaming",
+			YAML:        "audit_log_streaming",
+			Description: "Stream audit logs to external systems, such as a SIEM. Delivery is at-least-once, audit logs are retried until the sink accepts them.",
+		}
+		deploymentGroupAuditLogStreamingSyslog = serpent.Group{
+			Name:   "Syslog",
+			Parent: &deploymentGroupAuditLogStreaming,
+			YAML:   "syslog",
+		}
+		deploymentGroupAuditLogStreamingOTLP = serpent.Group{
+			Name:   "OpenTelemetry",
+			Parent: &deploymentGroupAuditLogStreaming,
+			YAML:   "otlp",
+		}
+		deploymentGroupAuditLogStreamingS3 = serpent.Group{
+			Name:   "S3",
+			Parent: &deploymentGroupAuditLogStreaming,
+			YAML:   "s3",
+		}
+		deploymentGroupAuditLogRetention = serpent.Group{
+			Name:        "Audit Log Retention",
+			YAML:        "audit_log_retention",
+			Description: "Archive and delete audit logs once they outlive their retention. Archives can be restored for investigations.",
+		}
+		deploymentGroupWorkspaceStateHistory = serpent.Group{
+			Name:        "Workspace State History",
+			YAML:        "workspace_state_history",
+			Description: "Keep the Terraform states of earlier workspace builds to browse, diff and roll back to.",
+		}
 		deploymentGroupInbox = serpent.Group{
 			Name:   "Inbox",
 			Parent: &deploymentGroupNotifications,
@@ -2121,7 +2314,7 @@ func (c *DeploymentValues) Options() serpent.OptionSet {
 			Name: "Provisioner Daemon Types",
 			Description: fmt.Sprintf("The supported job types for the built-in provisioners. By default, this is only the terraform type. Supported types: %s.",
 				strings.Join([]string{
-					string(ProvisionerTypeTerraform), string(ProvisionerTypeEcho),
+					string(ProvisionerTypeTerraform), string(ProvisionerTypeTofu), string(ProvisionerTypeExternal), string(ProvisionerTypeEcho),
 				}, ",")),
 			Flag:    "provisioner-types",
 			Env:     "CODER_PROVISIONER_TYPES",
@@ -2143,6 +2336,28 @@ func (c *DeploymentValues) Options() serpent.OptionSet {
 			Group: &deploymentGroupProvisioning,
 			YAML:  "daemonTypes",
 		},
+		{
+			Name:        "Provisioner Daemon Versions",
+			Description: "Additional versions of provisioner binaries for the built-in provisioners to install and advertise, e.g. tofu@1.9.1. Jobs of templates that pin a version are only acquired by provisioners with that version installed.",
+			Flag:        "provisioner-versions",
+			Env:         "CODER_PROVISIONER_VERSIONS",
+			Hidden:      true,
+			Value: serpent.Validate(&c.Provisioner.DaemonVersions, func(values *serpent.StringArray) error {
+				if values == nil {
+					return nil
+				}
+
+				for _, value := range *values {
+					if _, err := ParseProvisionerBinaryVersion(value); err != nil {
+						return err
+					}
+				}
+
+				return nil
+			}),
+			Group: &deploymentGroupProvisioning,
+			YAML:  "daemonVersions",
+		},
 		{
 			Name:        "Poll Interval",
 			Description: "Deprecated and ignored.",
@@ -2185,6 +2400,45 @@ func (c *DeploymentValues) Options() serpent.OptionSet {
 			Group:       &deploymentGroupProvisioning,
 			Annotations: serpent.Annotations{}.Mark(annotationSecretKey, "true"),
 		},
+		{
+			Name:        "Provider Mirror",
+			Description: "Mirror the Terraform providers installed by template imports and serve them to provisioner daemons, so that workspace builds do not download providers and modules again. Requires an HTTPS access URL.",
+			Flag:        "provisioner-provider-mirror",
+			Env:         "CODER_PROVISIONER_PROVIDER_MIRROR",
+			Default:     "false",
+			Value:       &c.Provisioner.ProviderMirror,
+			Group:       &deploymentGroupProvisioning,
+			YAML:        "providerMirror",
+		},
+		{
+			Name:        "Provisioner External Command",
+			Description: "The executable the built-in external provisioners invoke for each stage of a job. Relative paths are resolved against the template directory, so that templates can ship their own executable.",
+			Flag:        "provisioner-external-command",
+			Env:         "CODER_PROVISIONER_EXTERNAL_COMMAND",
+			Default:     "./coder-provisioner",
+			Value:       &c.Provisioner.ExternalCommand,
+			Group:       &deploymentGroupProvisioning,
+			YAML:        "externalCommand",
+		},
+		{
+			Name:        "Provisioner Scaler Enable",
+			Description: "Serve the KEDA external scaler, which scales provisioner daemons to the jobs they can run, on the address defined by provisioner scaler address.",
+			Flag:        "provisioner-scaler-enable",
+			Env:         "CODER_PROVISIONER_SCALER_ENABLE",
+			Value:       &c.Provisioner.ScalerEnable,
+			Group:       &deploymentGroupProvisioning,
+			YAML:        "scalerEnable",
+		},
+		{
+			Name:        "Provisioner Scaler Address",
+			Description: "The bind address of the KEDA external scaler. The scaler is unauthenticated and exposes the provisioner job queue, so only bind it to an address that KEDA alone can reach.",
+			Flag:        "provisioner-scaler-address",
+			Env:         "CODER_PROVISIONER_SCALER_ADDRESS",
+			Default:     "127.0.0.1:9090",
+			Value:       &c.Provisioner.ScalerAddress,
+			Group:       &deploymentGroupProvisioning,
+			YAML:        "scalerAddress",
+		},
 		// RateLimit settings
 		{
 			Name:        "Disable All Rate Limits",
@@ -2790,7 +3044,7 @@ Write out the current server config as YAML to stdout.`,
 		// Notifications Options
 		{
 			Name:        "Notifications: Method",
-			Description: "Which delivery method to use (available options: 'smtp', 'webhook').",
+			Description: "Which delivery method to use (available options: 'smtp', 'webhook', 'slack', 'matrix', 'teams').",
 			Flag:        "notifications-method",
 			Env:         "CODER_NOTIFICATIONS_METHOD",
 			Value:       &c.Notifications.Method,
@@ -2958,6 +3212,71 @@ Write out the current server config as YAML to stdout.`,
 			Group:       &deploymentGroupNotificationsWebhook,
 			YAML:        "endpoint",
 		},
+		{
+			Name:        "Notifications: Slack: Bot Token",
+			Description: "The bot token of the Slack app that posts notifications. The app needs the chat:write scope.",
+			Flag:        "notifications-slack-bot-token",
+			Env:         "CODER_NOTIFICATIONS_SLACK_BOT_TOKEN",
+			Annotations: serpent.Annotations{}.Mark(annotationSecretKey, "true"),
+			Value:       &c.Notifications.Slack.BotToken,
+			Group:       &deploymentGroupNotificationsSlack,
+		},
+		{
+			Name:        "Notifications: Slack: Channel",
+			Description: "The channel ID or name notifications are posted to for users who have not linked a Slack account. Users who have linked an account receive direct messages.",
+			Flag:        "notifications-slack-channel",
+			Env:         "CODER_NOTIFICATIONS_SLACK_CHANNEL",
+			Value:       &c.Notifications.Slack.Channel,
+			Group:       &deploymentGroupNotificationsSlack,
+			YAML:        "channel",
+		},
+		{
+			Name:        "Notifications: Slack: API URL",
+			Description: "The base URL of the Slack Web API.",
+			Flag:        "notifications-slack-api-url",
+			Env:         "CODER_NOTIFICATIONS_SLACK_API_URL",
+			Default:     "https://slack.com/api",
+			Value:       &c.Notifications.Slack.APIURL,
+			Group:       &deploymentGroupNotificationsSlack,
+			YAML:        "apiURL",
+			Hidden:      true,
+		},
+		{
+			Name:        "Notifications: Matrix: Homeserver",
+			Description: "The URL of the homeserver of the Matrix account that posts notifications.",
+			Flag:        "notifications-matrix-homeserver",
+			Env:         "CODER_NOTIFICATIONS_MATRIX_HOMESERVER",
+			Value:       &c.Notifications.Matrix.Homeserver,
+			Group:       &deploymentGroupNotificationsMatrix,
+			YAML:        "homeserver",
+		},
+		{
+			Name:        "Notifications: Matrix: Access Token",
+			Description: "The access token of the Matrix account that posts notifications.",
+			Flag:        "notifications-matrix-access-token",
+			Env:         "CODER_NOTIFICATIONS_MATRIX_ACCESS_TOKEN",
+			Annotations: serpent.Annotations{}.Mark(annotationSecretKey, "true"),
+			Value:       &c.Notifications.Matrix.AccessToken,
+			Group:       &deploymentGroupNotificationsMatrix,
+		},
+		{
+			Name:        "Notifications: Matrix: Room",
+			Description: "The room ID notifications are posted to for users who have not linked a Matrix account. Users who have linked an account receive direct messages.",
+			Flag:        "notifications-matrix-room",
+			Env:         "CODER_NOTIFICATIONS_MATRIX_ROOM",
+			Value:       &c.Notifications.Matrix.Room,
+			Group:       &deploymentGroupNotificationsMatrix,
+			YAML:        "room",
+		},
+		{
+			Name:        "Notifications: Microsoft Teams: Webhook URL",
+			Description: "The URL of a Teams workflow that posts Adaptive Cards to a channel, such as one created from the \"Post to a channel when a webhook request is received\" template.",
+			Flag:        "notifications-teams-webhook-url",
+			Env:         "CODER_NOTIFICATIONS_TEAMS_WEBHOOK_URL",
+			Value:       &c.Notifications.Teams.WebhookURL,
+			Group:       &deploymentGroupNotificationsTeams,
+			YAML:        "webhookURL",
+		},
 		{
 			Name:        "Notifications: Inbox: Enabled",
 			Description: "Enable Coder Inbox.",
@@ -3106,6 +3425,273 @@ Write out the current server config as YAML to stdout.`,
 			YAML:        "failure_hard_limit",
 			Hidden:      true,
 		},
+		{
+			Name:        "Workspace Snapshots: Storage",
+			Description: "Where workspace snapshot archives are stored, either \"database\" or \"s3\".",
+			Flag:        "workspace-snapshots-storage",
+			Env:         "CODER_WORKSPACE_SNAPSHOTS_STORAGE",
+			Value:       &c.WorkspaceSnapshots.Storage,
+			Default:     "database",
+			Group:       &deploymentGroupWorkspaceSnapshots,
+			YAML:        "storage",
+		},
+		{
+			Name:        "Workspace Snapshots: Max Size",
+			Description: "The largest snapshot archive accepted from a workspace agent, in bytes.",
+			Flag:        "workspace-snapshots-max-size",
+			Env:         "CODER_WORKSPACE_SNAPSHOTS_MAX_SIZE",
+			Value:       &c.WorkspaceSnapshots.MaxSize,
+			Default:     "1073741824",
+			Group:       &deploymentGroupWorkspaceSnapshots,
+			YAML:        "max_size",
+		},
+		{
+			Name:        "Workspace Snapshots: Keep Chains",
+			Description: "The number of full snapshots kept per workspace agent, with the incremental snapshots taken after each of them. Older snapshots are deleted. Every snapshot is kept when zero.",
+			Flag:        "workspace-snapshots-keep-chains",
+			Env:         "CODER_WORKSPACE_SNAPSHOTS_KEEP_CHAINS",
+			Value:       &c.WorkspaceSnapshots.KeepChains,
+			Default:     "3",
+			Group:       &deploymentGroupWorkspaceSnapshots,
+			YAML:        "keep_chains",
+		},
+		{
+			Name:        "Workspace Snapshots: S3 Bucket",
+			Description: "The bucket that holds snapshot archives when the storage is \"s3\".",
+			Flag:        "workspace-snapshots-s3-bucket",
+			Env:         "CODER_WORKSPACE_SNAPSHOTS_S3_BUCKET",
+			Value:       &c.WorkspaceSnapshots.S3.Bucket,
+			Group:       &deploymentGroupWorkspaceSnapshotsS3,
+			YAML:        "bucket",
+		},
+		{
+			Name:        "Workspace Snapshots: S3 Region",
+			Description: "The region of the snapshot bucket.",
+			Flag:        "workspace-snapshots-s3-region",
+			Env:         "CODER_WORKSPACE_SNAPSHOTS_S3_REGION",
+			Value:       &c.WorkspaceSnapshots.S3.Region,
+			Default:     "us-east-1",
+			Group:       &deploymentGroupWorkspaceSnapshotsS3,
+			YAML:        "region",
+		},
+		{
+			Name:        "Workspace Snapshots: S3 Endpoint",
+			Description: "The URL of an S3 compatible service, e.g. MinIO. Amazon S3 is used when unset.",
+			Flag:        "workspace-snapshots-s3-endpoint",
+			Env:         "CODER_WORKSPACE_SNAPSHOTS_S3_ENDPOINT",
+			Value:       &c.WorkspaceSnapshots.S3.Endpoint,
+			Group:       &deploymentGroupWorkspaceSnapshotsS3,
+			YAML:        "endpoint",
+		},
+		{
+			Name:        "Workspace Snapshots: S3 Prefix",
+			Description: "A prefix for the keys of snapshot archives in the bucket.",
+			Flag:        "workspace-snapshots-s3-prefix",
+			Env:         "CODER_WORKSPACE_SNAPSHOTS_S3_PREFIX",
+			Value:       &c.WorkspaceSnapshots.S3.Prefix,
+			Group:       &deploymentGroupWorkspaceSnapshotsS3,
+			YAML:        "prefix",
+		},
+		{
+			Name:        "Workspace Snapshots: S3 Access Key ID",
+			Description: "The access key ID for the snapshot bucket. The default AWS credential chain is used when unset.",
+			Flag:        "workspace-snapshots-s3-access-key-id",
+			Env:         "CODER_WORKSPACE_SNAPSHOTS_S3_ACCESS_KEY_ID",
+			Value:       &c.WorkspaceSnapshots.S3.AccessKeyID,
+			Group:       &deploymentGroupWorkspaceSnapshotsS3,
+			YAML:        "access_key_id",
+		},
+		{
+			Name:        "Workspace Snapshots: S3 Secret Access Key",
+			Description: "The secret access key for the snapshot bucket.",
+			Flag:        "workspace-snapshots-s3-secret-access-key",
+			Env:         "CODER_WORKSPACE_SNAPSHOTS_S3_SECRET_ACCESS_KEY",
+			Value:       &c.WorkspaceSnapshots.S3.SecretAccessKey,
+			Group:       &deploymentGroupWorkspaceSnapshotsS3,
+			Annotations: serpent.Annotations{}.Mark(annotationSecretKey, "true"),
+		},
+		{
+			Name:        "Audit Log Streaming: Syslog Address",
+			Description: "The URL of a syslog server audit logs are streamed to as RFC 5424 messages, e.g. tls://siem.example.com:6514. The tls, tcp and udp schemes are supported.",
+			Flag:        "audit-log-streaming-syslog-address",
+			Env:         "CODER_AUDIT_LOG_STREAMING_SYSLOG_ADDRESS",
+			Value:       &c.AuditLogStreaming.Syslog.Address,
+			Group:       &deploymentGroupAuditLogStreamingSyslog,
+			YAML:        "address",
+			Annotations: serpent.Annotations{}.Mark(annotationEnterpriseKey, "true"),
+		},
+		{
+			Name:        "Audit Log Streaming: Syslog CA File",
+			Description: "A PEM file of the certificate authorities which verify the syslog server. The system roots are used when unset.",
+			Flag:        "audit-log-streaming-syslog-ca-file",
+			Env:         "CODER_AUDIT_LOG_STREAMING_SYSLOG_CA_FILE",
+			Value:       &c.AuditLogStreaming.Syslog.CAFile,
+			Group:       &deploymentGroupAuditLogStreamingSyslog,
+			YAML:        "caFile",
+			Annotations: serpent.Annotations{}.Mark(annotationEnterpriseKey, "true"),
+		},
+		{
+			Name:        "Audit Log Streaming: Syslog Cert File",
+			Description: "A PEM client certificate presented to the syslog server.",
+			Flag:        "audit-log-streaming-syslog-cert-file",
+			Env:         "CODER_AUDIT_LOG_STREAMING_SYSLOG_CERT_FILE",
+			Value:       &c.AuditLogStreaming.Syslog.CertFile,
+			Group:       &deploymentGroupAuditLogStreamingSyslog,
+			YAML:        "certFile",
+			Annotations: serpent.Annotations{}.Mark(annotationEnterpriseKey, "true"),
+		},
+		{
+			Name:        "Audit Log Streaming: Syslog Key File",
+			Description: "The PEM private key of the syslog client certificate.",
+			Flag:        "audit-log-streaming-syslog-key-file",
+			Env:         "CODER_AUDIT_LOG_STREAMING_SYSLOG_KEY_FILE",
+			Value:       &c.AuditLogStreaming.Syslog.KeyFile,
+			Group:       &deploymentGroupAuditLogStreamingSyslog,
+			YAML:        "keyFile",
+			Annotations: serpent.Annotations{}.Mark(annotationEnterpriseKey, "true"),
+		},
+		{
+			Name:        "Audit Log Streaming: OTLP Endpoint",
+			Description: "The OTLP/HTTP logs endpoint audit logs are streamed to, e.g. http://collector:4318/v1/logs.",
+			Flag:        "audit-log-streaming-otlp-endpoint",
+			Env:         "CODER_AUDIT_LOG_STREAMING_OTLP_ENDPOINT",
+			Value:       &c.AuditLogStreaming.OTLP.Endpoint,
+			Group:       &deploymentGroupAuditLogStreamingOTLP,
+			YAML:        "endpoint",
+			Annotations: serpent.Annotations{}.Mark(annotationEnterpriseKey, "true"),
+		},
+		{
+			Name:        "Audit Log Streaming: OTLP Headers",
+			Description: "Headers sent to the OTLP endpoint, as key=value pairs, e.g. for authentication.",
+			Flag:        "audit-log-streaming-otlp-headers",
+			Env:         "CODER_AUDIT_LOG_STREAMING_OTLP_HEADERS",
+			Value:       &c.AuditLogStreaming.OTLP.Headers,
+			Group:       &deploymentGroupAuditLogStreamingOTLP,
+			Annotations: serpent.Annotations{}.Mark(annotationEnterpriseKey, "true").Mark(annotationSecretKey, "true"),
+		},
+		{
+			Name:        "Audit Log Streaming: S3 Bucket",
+			Description: "The bucket batches of audit logs are written to as newline-delimited JSON objects.",
+			Flag:        "audit-log-streaming-s3-bucket",
+			Env:         "CODER_AUDIT_LOG_STREAMING_S3_BUCKET",
+			Value:       &c.AuditLogStreaming.S3.Bucket,
+			Group:       &deploymentGroupAuditLogStreamingS3,
+			YAML:        "bucket",
+			Annotations: serpent.Annotations{}.Mark(annotationEnterpriseKey, "true"),
+		},
+		{
+			Name:        "Audit Log Streaming: S3 Region",
+			Description: "The region of the audit log bucket.",
+			Flag:        "audit-log-streaming-s3-region",
+			Env:         "CODER_AUDIT_LOG_STREAMING_S3_REGION",
+			Value:       &c.AuditLogStreaming.S3.Region,
+			Default:     "us-east-1",
+			Group:       &deploymentGroupAuditLogStreamingS3,
+			YAML:        "region",
+			Annotations: serpent.Annotations{}.Mark(annotationEnterpriseKey, "true"),
+		},
+		{
+			Name:        "Audit Log Streaming: S3 Endpoint",
+			Description: "The URL of an S3 compatible service, e.g. MinIO. Amazon S3 is used when unset.",
+			Flag:        "audit-log-streaming-s3-endpoint",
+			Env:         "CODER_AUDIT_LOG_STREAMING_S3_ENDPOINT",
+			Value:       &c.AuditLogStreaming.S3.Endpoint,
+			Group:       &deploymentGroupAuditLogStreamingS3,
+			YAML:        "endpoint",
+			Annotations: serpent.Annotations{}.Mark(annotationEnterpriseKey, "true"),
+		},
+		{
+			Name:        "Audit Log Streaming: S3 Prefix",
+			Description: "A prefix for the keys of audit log objects in the bucket.",
+			Flag:        "audit-log-streaming-s3-prefix",
+			Env:         "CODER_AUDIT_LOG_STREAMING_S3_PREFIX",
+			Value:       &c.AuditLogStreaming.S3.Prefix,
+			Group:       &deploymentGroupAuditLogStreamingS3,
+			YAML:        "prefix",
+			Annotations: serpent.Annotations{}.Mark(annotationEnterpriseKey, "true"),
+		},
+		{
+			Name:        "Audit Log Streaming: S3 Access Key ID",
+			Description: "The access key ID for the audit log bucket. The default AWS credential chain is used when unset.",
+			Flag:        "audit-log-streaming-s3-access-key-id",
+			Env:         "CODER_AUDIT_LOG_STREAMING_S3_ACCESS_KEY_ID",
+			Value:       &c.AuditLogStreaming.S3.AccessKeyID,
+			Group:       &deploymentGroupAuditLogStreamingS3,
+			YAML:        "access_key_id",
+			Annotations: serpent.Annotations{}.Mark(annotationEnterpriseKey, "true"),
+		},
+		{
+			Name:        "Audit Log Streaming: S3 Secret Access Key",
+			Description: "The secret access key for the audit log bucket.",
+			Flag:        "audit-log-streaming-s3-secret-access-key",
+			Env:         "CODER_AUDIT_LOG_STREAMING_S3_SECRET_ACCESS_KEY",
+			Value:       &c.AuditLogStreaming.S3.SecretAccessKey,
+			Group:       &deploymentGroupAuditLogStreamingS3,
+			Annotations: serpent.Annotations{}.Mark(annotationEnterpriseKey, "true").Mark(annotationSecretKey, "true"),
+		},
+		{
+			Name:        "Audit Log Streaming: S3 Interval",
+			Description: "How often a batch of audit logs is written to the bucket.",
+			Flag:        "audit-log-streaming-s3-interval",
+			Env:         "CODER_AUDIT_LOG_STREAMING_S3_INTERVAL",
+			Value:       &c.AuditLogStreaming.S3.Interval,
+			Default:     "5m",
+			Group:       &deploymentGroupAuditLogStreamingS3,
+			YAML:        "interval",
+			Annotations: serpent.Annotations{}.Mark(annotationEnterpriseKey, "true").Mark(annotationFormatDuration, "true"),
+		},
+		{
+			Name:        "Audit Log Retention: Default",
+			Description: "How long audit logs are kept when no retention rule matches them. Audit logs are kept forever when zero.",
+			Flag:        "audit-log-retention",
+			Env:         "CODER_AUDIT_LOG_RETENTION",
+			Value:       &c.AuditLogRetention.Default,
+			Default:     "0",
+			Group:       &deploymentGroupAuditLogRetention,
+			YAML:        "default",
+			Annotations: serpent.Annotations{}.Mark(annotationFormatDuration, "true"),
+		},
+		{
+			Name:        "Audit Log Retention: Rules",
+			Description: "Retention of audit logs by action, as [resource_type:]action=duration, e.g. connect=720h or organization_member:*=61320h. The most specific rule applies, and a duration of zero keeps matching audit logs forever.",
+			Flag:        "audit-log-retention-rules",
+			Env:         "CODER_AUDIT_LOG_RETENTION_RULES",
+			Value:       &c.AuditLogRetention.Rules,
+			Group:       &deploymentGroupAuditLogRetention,
+			YAML:        "rules",
+		},
+		{
+			Name:        "Audit Log Retention: Restore Duration",
+			Description: "How long audit logs restored from an archive are kept before they are deleted again.",
+			Flag:        "audit-log-restore-duration",
+			Env:         "CODER_AUDIT_LOG_RESTORE_DURATION",
+			Value:       &c.AuditLogRetention.RestoreDuration,
+			Default:     "168h",
+			Group:       &deploymentGroupAuditLogRetention,
+			YAML:        "restore_duration",
+			Annotations: serpent.Annotations{}.Mark(annotationFormatDuration, "true"),
+		},
+		{
+			Name:        "Workspace State History: Builds",
+			Description: "The number of Terraform states kept per workspace, including the current one. States of any number of builds are kept when zero.",
+			Flag:        "workspace-state-history-builds",
+			Env:         "CODER_WORKSPACE_STATE_HISTORY_BUILDS",
+			Value:       &c.WorkspaceStateHistory.Builds,
+			Default:     "25",
+			Group:       &deploymentGroupWorkspaceStateHistory,
+			YAML:        "builds",
+		},
+		{
+			Name:        "Workspace State History: Max Age",
+			Description: "How long the Terraform states of earlier builds are kept. The current state of a workspace is always kept. States are kept forever when zero.",
+			Flag:        "workspace-state-history-max-age",
+			Env:         "CODER_WORKSPACE_STATE_HISTORY_MAX_AGE",
+			Value:       &c.WorkspaceStateHistory.MaxAge,
+			Default:     "0",
+			Group:       &deploymentGroupWorkspaceStateHistory,
+			YAML:        "max_age",
+			Annotations: serpent.Annotations{}.Mark(annotationFormatDuration, "true"),
+		},
 		{
 			Name:        "Hide AI Tasks",
 			Description: "Hide AI tasks from the dashboard.",
@@ -3573,6 +4159,7 @@ const (
 	CryptoKeyFeatureWorkspaceAppsToken CryptoKeyFeature = "workspace_apps_token"
 	CryptoKeyFeatureOIDCConvert        CryptoKeyFeature = "oidc_convert"
 	CryptoKeyFeatureTailnetResume      CryptoKeyFeature = "tailnet_resume"
+	CryptoKeyFeatureAuditLogCheckpoint CryptoKeyFeature = "audit_log_checkpoint"
 )
 
 type CryptoKey struct {
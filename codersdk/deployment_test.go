@@ -84,6 +84,21 @@ func TestDeploymentValues_HighlyConfigurable(t *testing.T) {
 		"Notifications: Email Auth: Password": {
 			yaml: true,
 		},
+		"Workspace Snapshots: S3 Secret Access Key": {
+			yaml: true,
+		},
+		"Notifications: Slack: Bot Token": {
+			yaml: true,
+		},
+		"Notifications: Matrix: Access Token": {
+			yaml: true,
+		},
+		"Audit Log Streaming: OTLP Headers": {
+			yaml: true,
+		},
+		"Audit Log Streaming: S3 Secret Access Key": {
+			yaml: true,
+		},
 	}
 
 	set := (&codersdk.DeploymentValues{}).Options()
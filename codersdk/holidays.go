@@ -0,0 +1,73 @@
+package codersdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/google/uuid"
+)
+
+// OrganizationHoliday is a day on which workspaces of the organization are not
+// autostarted.
+type OrganizationHoliday struct {
+	// Calendar is the name of the calendar the holiday was imported from.
+	Calendar string `json:"calendar"`
+	// Date is the day of the holiday, in the timezone of the autostart
+	// schedule of each workspace.
+	Date string `json:"date"` // YYYY-MM-DD
+	Name string `json:"name"`
+}
+
+type ImportHolidayCalendarRequest struct {
+	// ICalendar is the content of an iCalendar (.ics) file. Each day of each
+	// event is a holiday.
+	ICalendar string `json:"icalendar" validate:"required"`
+}
+
+// OrganizationHolidays returns the upcoming holidays of an organization.
+// This endpoint only exists in enterprise editions.
+func (c *Client) OrganizationHolidays(ctx context.Context, organizationID uuid.UUID) ([]OrganizationHoliday, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/organizations/%s/holidays", organizationID), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, ReadBodyAsError(res)
+	}
+	var resp []OrganizationHoliday
+	return resp, json.NewDecoder(res.Body).Decode(&resp)
+}
+
+// ImportOrganizationHolidayCalendar imports the holidays of an iCalendar file
+// into an organization, replacing the holidays previously imported under the
+// same calendar name. This endpoint only exists in enterprise editions.
+func (c *Client) ImportOrganizationHolidayCalendar(ctx context.Context, organizationID uuid.UUID, calendar string, req ImportHolidayCalendarRequest) ([]OrganizationHoliday, error) {
+	res, err := c.Request(ctx, http.MethodPut, fmt.Sprintf("/api/v2/organizations/%s/holidays/%s", organizationID, url.PathEscape(calendar)), req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, ReadBodyAsError(res)
+	}
+	var resp []OrganizationHoliday
+	return resp, json.NewDecoder(res.Body).Decode(&resp)
+}
+
+// DeleteOrganizationHolidayCalendar removes the holidays of a calendar from
+// an organization. This endpoint only exists in enterprise editions.
+func (c *Client) DeleteOrganizationHolidayCalendar(ctx context.Context, organizationID uuid.UUID, calendar string) error {
+	res, err := c.Request(ctx, http.MethodDelete, fmt.Sprintf("/api/v2/organizations/%s/holidays/%s", organizationID, url.PathEscape(calendar)), nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusNoContent {
+		return ReadBodyAsError(res)
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"strings"
@@ -313,3 +314,101 @@ func (c *Client) GetUserStatusCounts(ctx context.Context, req GetUserStatusCount
 	var result GetUserStatusCountsResponse
 	return result, json.NewDecoder(resp.Body).Decode(&result)
 }
+
+// CostInsightsGroupBy is the dimension the cost of workspaces is rolled up by.
+type CostInsightsGroupBy string
+
+const (
+	CostInsightsGroupByUser         CostInsightsGroupBy = "user"
+	CostInsightsGroupByGroup        CostInsightsGroupBy = "group"
+	CostInsightsGroupByOrganization CostInsightsGroupBy = "organization"
+	CostInsightsGroupByTemplate     CostInsightsGroupBy = "template"
+)
+
+// CostInsightsResponse is the response from the cost insights endpoint.
+type CostInsightsResponse struct {
+	Report CostInsightsReport `json:"report"`
+}
+
+// CostInsightsReport is the cost of workspaces within a time range, from the
+// hourly cost of their resources while running. Costs have no currency, they
+// are in the unit the templates annotate their resources with.
+type CostInsightsReport struct {
+	StartTime   time.Time           `json:"start_time" format:"date-time"`
+	EndTime     time.Time           `json:"end_time" format:"date-time"`
+	TemplateIDs []uuid.UUID         `json:"template_ids" format:"uuid"`
+	GroupBy     CostInsightsGroupBy `json:"group_by" enums:"user,group,organization,template"`
+	// Entries are sorted by cost, from the most expensive. Users can be in
+	// many groups, so the costs of groups can add up to more than the total.
+	Entries []CostInsightsEntry `json:"entries"`
+}
+
+// CostInsightsEntry is the uptime and cost of the workspaces of a user, group,
+// organization or template.
+type CostInsightsEntry struct {
+	ID   uuid.UUID `json:"id" format:"uuid"`
+	Name string    `json:"name"`
+	// OrganizationName is the organization of groups and templates, as their
+	// names are only unique within an organization.
+	OrganizationName string  `json:"organization_name,omitempty"`
+	UsageSeconds     int64   `json:"usage_seconds" example:"3600"`
+	Cost             float64 `json:"cost" example:"1.25"`
+}
+
+type CostInsightsRequest struct {
+	StartTime   time.Time           `json:"start_time" format:"date-time"`
+	EndTime     time.Time           `json:"end_time" format:"date-time"`
+	TemplateIDs []uuid.UUID         `json:"template_ids" format:"uuid"`
+	GroupBy     CostInsightsGroupBy `json:"group_by"`
+}
+
+func (req CostInsightsRequest) query(format string) url.Values {
+	qp := url.Values{}
+	qp.Add("start_time", req.StartTime.Format(insightsTimeLayout))
+	qp.Add("end_time", req.EndTime.Format(insightsTimeLayout))
+	if len(req.TemplateIDs) > 0 {
+		var templateIDs []string
+		for _, id := range req.TemplateIDs {
+			templateIDs = append(templateIDs, id.String())
+		}
+		qp.Add("template_ids", strings.Join(templateIDs, ","))
+	}
+	if req.GroupBy != "" {
+		qp.Add("group_by", string(req.GroupBy))
+	}
+	if format != "" {
+		qp.Add("format", format)
+	}
+	return qp
+}
+
+func (c *Client) CostInsights(ctx context.Context, req CostInsightsRequest) (CostInsightsResponse, error) {
+	reqURL := fmt.Sprintf("/api/v2/insights/costs?%s", req.query("").Encode())
+	resp, err := c.Request(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return CostInsightsResponse{}, xerrors.Errorf("make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return CostInsightsResponse{}, ReadBodyAsError(resp)
+	}
+	var result CostInsightsResponse
+	return result, json.NewDecoder(resp.Body).Decode(&result)
+}
+
+// CostInsightsCSV returns the cost insights report as a CSV file, for
+// chargeback in spreadsheets and billing systems.
+func (c *Client) CostInsightsCSV(ctx context.Context, req CostInsightsRequest) ([]byte, error) {
+	reqURL := fmt.Sprintf("/api/v2/insights/costs?%s", req.query("csv").Encode())
+	resp, err := c.Request(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, xerrors.Errorf("make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, ReadBodyAsError(resp)
+	}
+	return io.ReadAll(resp.Body)
+}
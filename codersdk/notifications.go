@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"regexp"
 	"time"
 
 	"github.com/google/uuid"
@@ -26,6 +27,9 @@ type NotificationTemplate struct {
 	Method           string    `json:"method"`
 	Kind             string    `json:"kind"`
 	EnabledByDefault bool      `json:"enabled_by_default"`
+	// Critical notifications are never batched into digests or deferred by
+	// quiet hours.
+	Critical bool `json:"critical"`
 }
 
 type NotificationMethodsResponse struct {
@@ -280,3 +284,315 @@ func (c *Client) PostTestWebpushMessage(ctx context.Context) error {
 	}
 	return nil
 }
+
+// ChatNotificationMethod is a notification method that delivers messages to
+// a chat platform.
+type ChatNotificationMethod string
+
+const (
+	ChatNotificationMethodSlack  ChatNotificationMethod = "slack"
+	ChatNotificationMethodMatrix ChatNotificationMethod = "matrix"
+	ChatNotificationMethodTeams  ChatNotificationMethod = "teams"
+)
+
+var ChatNotificationMethods = []ChatNotificationMethod{
+	ChatNotificationMethodSlack,
+	ChatNotificationMethodMatrix,
+	ChatNotificationMethodTeams,
+}
+
+var (
+	slackMemberIDRegex      = regexp.MustCompile(`^[UW][A-Z0-9]{2,}$`)
+	matrixUserIDRegex       = regexp.MustCompile(`^@[a-z0-9._=\-/+]+:[A-Za-z0-9.\-]+(:[0-9]{1,5})?$`)
+	teamsUserPrincipalRegex = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+)
+
+// ValidateIdentity checks that identity is formatted as an account on the
+// chat platform. It does not prove the user owns the account, see
+// VerifyUserChatIdentity.
+func (m ChatNotificationMethod) ValidateIdentity(identity string) error {
+	switch m {
+	case ChatNotificationMethodSlack:
+		if !slackMemberIDRegex.MatchString(identity) {
+			return xerrors.Errorf("%q is not a Slack member ID, e.g. U012AB3CD", identity)
+		}
+	case ChatNotificationMethodMatrix:
+		if !matrixUserIDRegex.MatchString(identity) {
+			return xerrors.Errorf("%q is not a Matrix user ID, e.g. @alice:example.com", identity)
+		}
+	case ChatNotificationMethodTeams:
+		if !teamsUserPrincipalRegex.MatchString(identity) {
+			return xerrors.Errorf("%q is not a Microsoft Teams user principal name, e.g. alice@example.com", identity)
+		}
+	default:
+		return xerrors.Errorf("%q is not a chat notification method", m)
+	}
+	return nil
+}
+
+// UserChatIdentity is a chat account a user linked to receive notifications
+// as direct messages.
+type UserChatIdentity struct {
+	Method   ChatNotificationMethod `json:"method" table:"method,default_sort" enums:"slack,matrix,teams"`
+	Identity string                 `json:"identity" table:"identity"`
+	// Verified is true once the user proved they own the identity.
+	// Notifications are only sent to verified identities.
+	Verified  bool      `json:"verified" table:"verified"`
+	UpdatedAt time.Time `json:"updated_at" table:"updated at" format:"date-time"`
+}
+
+type UpdateUserChatIdentityRequest struct {
+	// Identity is the Slack member ID, Matrix user ID or Microsoft Teams
+	// user principal name of the user.
+	Identity string `json:"identity" validate:"required"`
+}
+
+type VerifyUserChatIdentityRequest struct {
+	// Code is the one-time code sent to the identity in a direct message.
+	Code string `json:"code" validate:"required"`
+}
+
+// UserChatIdentities returns the chat accounts the user linked.
+func (c *Client) UserChatIdentities(ctx context.Context, user string) ([]UserChatIdentity, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/users/%s/notifications/chat-identities", user), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, ReadBodyAsError(res)
+	}
+	var identities []UserChatIdentity
+	return identities, json.NewDecoder(res.Body).Decode(&identities)
+}
+
+// UpdateUserChatIdentity links a chat account to the user, replacing the
+// account previously linked for the method. Slack and Matrix accounts are
+// sent a code in a direct message that must be passed to
+// VerifyUserChatIdentity. Microsoft Teams accounts must match the email of
+// the user's OIDC account and are verified immediately.
+func (c *Client) UpdateUserChatIdentity(ctx context.Context, user string, method ChatNotificationMethod, req UpdateUserChatIdentityRequest) (UserChatIdentity, error) {
+	res, err := c.Request(ctx, http.MethodPut, fmt.Sprintf("/api/v2/users/%s/notifications/chat-identities/%s", user, method), req)
+	if err != nil {
+		return UserChatIdentity{}, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return UserChatIdentity{}, ReadBodyAsError(res)
+	}
+	var identity UserChatIdentity
+	return identity, json.NewDecoder(res.Body).Decode(&identity)
+}
+
+// VerifyUserChatIdentity proves the user owns the chat account they linked
+// with the code sent to it.
+func (c *Client) VerifyUserChatIdentity(ctx context.Context, user string, method ChatNotificationMethod, req VerifyUserChatIdentityRequest) (UserChatIdentity, error) {
+	res, err := c.Request(ctx, http.MethodPost, fmt.Sprintf("/api/v2/users/%s/notifications/chat-identities/%s/verify", user, method), req)
+	if err != nil {
+		return UserChatIdentity{}, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return UserChatIdentity{}, ReadBodyAsError(res)
+	}
+	var identity UserChatIdentity
+	return identity, json.NewDecoder(res.Body).Decode(&identity)
+}
+
+// DeleteUserChatIdentity unlinks the chat account of the user.
+func (c *Client) DeleteUserChatIdentity(ctx context.Context, user string, method ChatNotificationMethod) error {
+	res, err := c.Request(ctx, http.MethodDelete, fmt.Sprintf("/api/v2/users/%s/notifications/chat-identities/%s", user, method), nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusNoContent {
+		return ReadBodyAsError(res)
+	}
+	return nil
+}
+
+// NotificationDigestInterval is how often batched notifications are
+// summarized in a digest.
+type NotificationDigestInterval string
+
+const (
+	NotificationDigestIntervalHourly NotificationDigestInterval = "hourly"
+	NotificationDigestIntervalDaily  NotificationDigestInterval = "daily"
+)
+
+// NotificationQuietHours is a daily window, in the user's timezone, during
+// which non-critical notifications are held back. The window wraps past
+// midnight when End is before Start.
+type NotificationQuietHours struct {
+	// Start is the time of day at which quiet hours start, as HH:MM.
+	Start string `json:"start" validate:"required"`
+	// End is the time of day at which quiet hours end, as HH:MM.
+	End string `json:"end" validate:"required"`
+}
+
+// NotificationDeliverySettings controls when a user receives notifications.
+type NotificationDeliverySettings struct {
+	// Timezone is an IANA timezone name, e.g. Europe/Berlin. It defaults to
+	// UTC.
+	Timezone   string                  `json:"timezone"`
+	QuietHours *NotificationQuietHours `json:"quiet_hours,omitempty"`
+	// DailyDigestTime is the time of day at which daily digests are
+	// delivered, as HH:MM. It defaults to 09:00.
+	DailyDigestTime string `json:"daily_digest_time"`
+	// Digests maps notification template IDs to the interval at which they
+	// are batched. Templates which are not listed are delivered immediately.
+	Digests map[string]NotificationDigestInterval `json:"digests"`
+}
+
+// UserNotificationDeliverySettings returns the digest and quiet hours
+// settings of the user.
+func (c *Client) UserNotificationDeliverySettings(ctx context.Context, user string) (NotificationDeliverySettings, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/users/%s/notifications/delivery", user), nil)
+	if err != nil {
+		return NotificationDeliverySettings{}, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return NotificationDeliverySettings{}, ReadBodyAsError(res)
+	}
+	var settings NotificationDeliverySettings
+	return settings, json.NewDecoder(res.Body).Decode(&settings)
+}
+
+// UpdateUserNotificationDeliverySettings replaces the digest and quiet hours
+// settings of the user.
+func (c *Client) UpdateUserNotificationDeliverySettings(ctx context.Context, user string, req NotificationDeliverySettings) (NotificationDeliverySettings, error) {
+	res, err := c.Request(ctx, http.MethodPut, fmt.Sprintf("/api/v2/users/%s/notifications/delivery", user), req)
+	if err != nil {
+		return NotificationDeliverySettings{}, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return NotificationDeliverySettings{}, ReadBodyAsError(res)
+	}
+	var settings NotificationDeliverySettings
+	return settings, json.NewDecoder(res.Body).Decode(&settings)
+}
+
+// NotificationRuleSource is the kind of event a notification rule matches.
+type NotificationRuleSource string
+
+const (
+	NotificationRuleSourceAuditLog       NotificationRuleSource = "audit_log"
+	NotificationRuleSourceWorkspaceBuild NotificationRuleSource = "workspace_build"
+)
+
+// NotificationRule notifies its creator when the number of audit log entries
+// or completed workspace builds matching a search query reaches a threshold.
+type NotificationRule struct {
+	ID             uuid.UUID              `json:"id" format:"uuid"`
+	OrganizationID uuid.UUID              `json:"organization_id" format:"uuid"`
+	UserID         uuid.UUID              `json:"user_id" format:"uuid"`
+	Name           string                 `json:"name"`
+	Source         NotificationRuleSource `json:"source" enums:"audit_log,workspace_build"`
+	// Query uses the audit log search syntax for audit log rules, and the
+	// template, workspace, owner, transition, status and build_reason keys
+	// for workspace build rules.
+	Query string `json:"query"`
+	// Method is the dispatch method notifications are sent with. It defaults
+	// to the method of the notification template.
+	Method string `json:"method,omitempty"`
+	// Threshold is the number of matching events which trigger the rule.
+	Threshold int32 `json:"threshold"`
+	// WindowSeconds is the period over which matching events are counted.
+	// A rule is not triggered again until its window passed. When zero, the
+	// events since the previous evaluation, about a minute ago, are counted.
+	WindowSeconds   int32      `json:"window_seconds"`
+	Enabled         bool       `json:"enabled"`
+	CreatedAt       time.Time  `json:"created_at" format:"date-time"`
+	UpdatedAt       time.Time  `json:"updated_at" format:"date-time"`
+	LastTriggeredAt *time.Time `json:"last_triggered_at,omitempty" format:"date-time"`
+}
+
+type CreateNotificationRuleRequest struct {
+	Name          string                 `json:"name" validate:"required"`
+	Source        NotificationRuleSource `json:"source" validate:"required" enums:"audit_log,workspace_build"`
+	Query         string                 `json:"query"`
+	Method        string                 `json:"method,omitempty"`
+	Threshold     int32                  `json:"threshold,omitempty"`
+	WindowSeconds int32                  `json:"window_seconds,omitempty"`
+	Disabled      bool                   `json:"disabled,omitempty"`
+}
+
+// UpdateNotificationRuleRequest replaces the settings of a rule. The source
+// of a rule cannot be changed.
+type UpdateNotificationRuleRequest struct {
+	Name          string `json:"name" validate:"required"`
+	Query         string `json:"query"`
+	Method        string `json:"method,omitempty"`
+	Threshold     int32  `json:"threshold,omitempty"`
+	WindowSeconds int32  `json:"window_seconds,omitempty"`
+	Enabled       bool   `json:"enabled"`
+}
+
+// NotificationRules returns the notification rules of an organization.
+func (c *Client) NotificationRules(ctx context.Context, organizationID uuid.UUID) ([]NotificationRule, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/organizations/%s/notifications/rules", organizationID), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, ReadBodyAsError(res)
+	}
+	var rules []NotificationRule
+	return rules, json.NewDecoder(res.Body).Decode(&rules)
+}
+
+// CreateNotificationRule creates a notification rule in an organization,
+// which notifies the authenticated user.
+func (c *Client) CreateNotificationRule(ctx context.Context, organizationID uuid.UUID, req CreateNotificationRuleRequest) (NotificationRule, error) {
+	res, err := c.Request(ctx, http.MethodPost, fmt.Sprintf("/api/v2/organizations/%s/notifications/rules", organizationID), req)
+	if err != nil {
+		return NotificationRule{}, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusCreated {
+		return NotificationRule{}, ReadBodyAsError(res)
+	}
+	var rule NotificationRule
+	return rule, json.NewDecoder(res.Body).Decode(&rule)
+}
+
+// UpdateNotificationRule replaces the settings of a notification rule.
+func (c *Client) UpdateNotificationRule(ctx context.Context, organizationID, ruleID uuid.UUID, req UpdateNotificationRuleRequest) (NotificationRule, error) {
+	res, err := c.Request(ctx, http.MethodPut, fmt.Sprintf("/api/v2/organizations/%s/notifications/rules/%s", organizationID, ruleID), req)
+	if err != nil {
+		return NotificationRule{}, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return NotificationRule{}, ReadBodyAsError(res)
+	}
+	var rule NotificationRule
+	return rule, json.NewDecoder(res.Body).Decode(&rule)
+}
+
+// DeleteNotificationRule deletes a notification rule.
+func (c *Client) DeleteNotificationRule(ctx context.Context, organizationID, ruleID uuid.UUID) error {
+	res, err := c.Request(ctx, http.MethodDelete, fmt.Sprintf("/api/v2/organizations/%s/notifications/rules/%s", organizationID, ruleID), nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusNoContent {
+		return ReadBodyAsError(res)
+	}
+	return nil
+}
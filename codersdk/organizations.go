@@ -28,13 +28,15 @@ type ProvisionerType string
 const (
 	ProvisionerTypeEcho      ProvisionerType = "echo"
 	ProvisionerTypeTerraform ProvisionerType = "terraform"
+	ProvisionerTypeTofu      ProvisionerType = "tofu"
+	ProvisionerTypeExternal  ProvisionerType = "external"
 )
 
 // ProvisionerTypeValid accepts string or ProvisionerType for easier usage.
 // Will validate the enum is in the set.
 func ProvisionerTypeValid[T ProvisionerType | string](pt T) error {
 	switch string(pt) {
-	case string(ProvisionerTypeEcho), string(ProvisionerTypeTerraform):
+	case string(ProvisionerTypeEcho), string(ProvisionerTypeTerraform), string(ProvisionerTypeTofu), string(ProvisionerTypeExternal):
 		return nil
 	default:
 		return xerrors.Errorf("provisioner type '%s' is not supported", pt)
@@ -115,7 +117,7 @@ type CreateTemplateVersionRequest struct {
 	StorageMethod   ProvisionerStorageMethod `json:"storage_method" validate:"oneof=file,required" enums:"file"`
 	FileID          uuid.UUID                `json:"file_id,omitempty" validate:"required_without=ExampleID" format:"uuid"`
 	ExampleID       string                   `json:"example_id,omitempty" validate:"required_without=FileID"`
-	Provisioner     ProvisionerType          `json:"provisioner" validate:"oneof=terraform echo,required"`
+	Provisioner     ProvisionerType          `json:"provisioner" validate:"oneof=terraform tofu external echo,required"`
 	ProvisionerTags map[string]string        `json:"tags"`
 
 	UserVariableValues []VariableValue `json:"user_variable_values,omitempty"`
@@ -381,6 +383,36 @@ func (c *Client) OrganizationProvisionerDaemons(ctx context.Context, organizatio
 	return daemons, json.NewDecoder(res.Body).Decode(&daemons)
 }
 
+// OrganizationProvisionerQueue returns the autoscaling signals of the
+// provisioner daemons of an organization, by set of tags. If tags are given,
+// only the stats of daemons started with them are returned.
+func (c *Client) OrganizationProvisionerQueue(ctx context.Context, organizationID uuid.UUID, tags map[string]string) ([]ProvisionerQueueStats, error) {
+	qp := url.Values{}
+	if len(tags) > 0 {
+		tagsRaw, err := json.Marshal(tags)
+		if err != nil {
+			return nil, xerrors.Errorf("marshal tags: %w", err)
+		}
+		qp.Add("tags", string(tagsRaw))
+	}
+
+	res, err := c.Request(ctx, http.MethodGet,
+		fmt.Sprintf("/api/v2/organizations/%s/provisionerdaemons/queue?%s", organizationID.String(), qp.Encode()),
+		nil,
+	)
+	if err != nil {
+		return nil, xerrors.Errorf("execute request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, ReadBodyAsError(res)
+	}
+
+	var stats []ProvisionerQueueStats
+	return stats, json.NewDecoder(res.Body).Decode(&stats)
+}
+
 type OrganizationProvisionerJobsOptions struct {
 	Limit  int
 	IDs    []uuid.UUID
@@ -0,0 +1,109 @@
+package codersdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PlanPolicy is a Rego policy evaluated against the Terraform plans of
+// workspace builds of an organization before they are applied. Messages of
+// its `deny` rules fail the build, and messages of its `warn` rules are
+// written to the build logs.
+type PlanPolicy struct {
+	ID             uuid.UUID `json:"id" format:"uuid" table:"id"`
+	OrganizationID uuid.UUID `json:"organization_id" format:"uuid" table:"organization id"`
+	Name           string    `json:"name" table:"name,default_sort"`
+	Description    string    `json:"description" table:"description"`
+	// Module is the Rego source of the policy. The JSON plan of the build
+	// is its input.
+	Module    string    `json:"module"`
+	CreatedAt time.Time `json:"created_at" format:"date-time" table:"created at"`
+	UpdatedAt time.Time `json:"updated_at" format:"date-time" table:"updated at"`
+}
+
+type CreatePlanPolicyRequest struct {
+	Name        string `json:"name" validate:"required,plan_policy_name"`
+	Description string `json:"description,omitempty" validate:"lt=256"`
+	Module      string `json:"module" validate:"required"`
+}
+
+type UpdatePlanPolicyRequest struct {
+	Description *string `json:"description,omitempty" validate:"omitempty,lt=256"`
+	Module      *string `json:"module,omitempty"`
+}
+
+// PlanPolicies returns the plan policies of an organization.
+func (c *Client) PlanPolicies(ctx context.Context, organizationID uuid.UUID) ([]PlanPolicy, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/organizations/%s/plan-policies", organizationID), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, ReadBodyAsError(res)
+	}
+	var resp []PlanPolicy
+	return resp, json.NewDecoder(res.Body).Decode(&resp)
+}
+
+// PlanPolicy returns a plan policy of an organization by name.
+func (c *Client) PlanPolicy(ctx context.Context, organizationID uuid.UUID, name string) (PlanPolicy, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/organizations/%s/plan-policies/%s", organizationID, url.PathEscape(name)), nil)
+	if err != nil {
+		return PlanPolicy{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return PlanPolicy{}, ReadBodyAsError(res)
+	}
+	var resp PlanPolicy
+	return resp, json.NewDecoder(res.Body).Decode(&resp)
+}
+
+// CreatePlanPolicy creates a plan policy in an organization. The policy
+// applies to builds acquired after it is created.
+func (c *Client) CreatePlanPolicy(ctx context.Context, organizationID uuid.UUID, req CreatePlanPolicyRequest) (PlanPolicy, error) {
+	res, err := c.Request(ctx, http.MethodPost, fmt.Sprintf("/api/v2/organizations/%s/plan-policies", organizationID), req)
+	if err != nil {
+		return PlanPolicy{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusCreated {
+		return PlanPolicy{}, ReadBodyAsError(res)
+	}
+	var resp PlanPolicy
+	return resp, json.NewDecoder(res.Body).Decode(&resp)
+}
+
+// UpdatePlanPolicy updates a plan policy of an organization.
+func (c *Client) UpdatePlanPolicy(ctx context.Context, organizationID uuid.UUID, name string, req UpdatePlanPolicyRequest) (PlanPolicy, error) {
+	res, err := c.Request(ctx, http.MethodPatch, fmt.Sprintf("/api/v2/organizations/%s/plan-policies/%s", organizationID, url.PathEscape(name)), req)
+	if err != nil {
+		return PlanPolicy{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return PlanPolicy{}, ReadBodyAsError(res)
+	}
+	var resp PlanPolicy
+	return resp, json.NewDecoder(res.Body).Decode(&resp)
+}
+
+// DeletePlanPolicy deletes a plan policy of an organization.
+func (c *Client) DeletePlanPolicy(ctx context.Context, organizationID uuid.UUID, name string) error {
+	res, err := c.Request(ctx, http.MethodDelete, fmt.Sprintf("/api/v2/organizations/%s/plan-policies/%s", organizationID, url.PathEscape(name)), nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusNoContent {
+		return ReadBodyAsError(res)
+	}
+	return nil
+}
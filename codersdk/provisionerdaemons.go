@@ -14,6 +14,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/hashicorp/yamux"
 	"golang.org/x/exp/maps"
+	"golang.org/x/mod/semver"
 	"golang.org/x/xerrors"
 
 	"github.com/coder/coder/v2/buildinfo"
@@ -93,6 +94,43 @@ type MatchedProvisioners struct {
 	MostRecentlySeen NullTime `json:"most_recently_seen,omitempty" format:"date-time"`
 }
 
+// ProvisionerQueueStats are the autoscaling signals of the provisioner
+// daemons with a set of tags. There are stats for the tags of every online
+// daemon and pending job.
+type ProvisionerQueueStats struct {
+	OrganizationID uuid.UUID         `json:"organization_id" format:"uuid"`
+	Tags           map[string]string `json:"tags"`
+	// PendingJobs is the number of pending jobs that daemons with the tags
+	// can acquire.
+	PendingJobs int64 `json:"pending_jobs"`
+	// OldestPendingJobAgeMS is how long the oldest of the pending jobs has
+	// been waiting.
+	OldestPendingJobAgeMS int64 `json:"oldest_pending_job_age_ms"`
+	// RunningJobs is the number of jobs running on daemons with the tags.
+	RunningJobs int64 `json:"running_jobs"`
+	// Daemons is the number of online daemons with the tags, of which
+	// BusyDaemons are running a job.
+	Daemons     int64 `json:"daemons"`
+	BusyDaemons int64 `json:"busy_daemons"`
+	// Utilization is the fraction of the online daemons that are busy.
+	Utilization float64 `json:"utilization"`
+	// WaitTime holds percentiles of how long the jobs that daemons with the
+	// tags can acquire waited to start, over the last hour.
+	WaitTime ProvisionerQueueWaitTime `json:"wait_time"`
+}
+
+type ProvisionerQueueWaitTime struct {
+	P50MS int64 `json:"p50_ms"`
+	P95MS int64 `json:"p95_ms"`
+	P99MS int64 `json:"p99_ms"`
+}
+
+// DesiredDaemons returns the number of daemons with the tags needed to run
+// the pending and running jobs, with each daemon running a single job.
+func (s ProvisionerQueueStats) DesiredDaemons() int64 {
+	return s.PendingJobs + s.RunningJobs
+}
+
 // ProvisionerJobStatus represents the at-time state of a job.
 type ProvisionerJobStatus string
 
@@ -152,6 +190,27 @@ const (
 	ProvisionerJobTypeTemplateVersionImport ProvisionerJobType = "template_version_import"
 	ProvisionerJobTypeWorkspaceBuild        ProvisionerJobType = "workspace_build"
 	ProvisionerJobTypeTemplateVersionDryRun ProvisionerJobType = "template_version_dry_run"
+	ProvisionerJobTypeWorkspaceDriftCheck   ProvisionerJobType = "workspace_drift_check"
+)
+
+// ProvisionerJobPriority is the priority class of a job. Pending jobs of a
+// higher priority are acquired first.
+type ProvisionerJobPriority string
+
+const (
+	// ProvisionerJobPriorityInteractive is for jobs users wait on, like
+	// workspace builds they started.
+	ProvisionerJobPriorityInteractive ProvisionerJobPriority = "interactive"
+	// ProvisionerJobPriorityScheduled is for builds of the lifecycle
+	// executor, like autostart and autostop.
+	ProvisionerJobPriorityScheduled ProvisionerJobPriority = "scheduled"
+	// ProvisionerJobPriorityPrebuild is for builds of prebuilt workspaces.
+	ProvisionerJobPriorityPrebuild ProvisionerJobPriority = "prebuild"
+	// ProvisionerJobPriorityTemplateImport is for template version imports.
+	ProvisionerJobPriorityTemplateImport ProvisionerJobPriority = "template_import"
+	// ProvisionerJobPriorityDriftCheck is for scheduled drift checks of
+	// running workspaces.
+	ProvisionerJobPriorityDriftCheck ProvisionerJobPriority = "drift_check"
 )
 
 // JobErrorCode defines the error code returned by job runner.
@@ -159,6 +218,9 @@ type JobErrorCode string
 
 const (
 	RequiredTemplateVariables JobErrorCode = "REQUIRED_TEMPLATE_VARIABLES"
+	// PlanPolicyViolation is returned when the plan of a workspace build is
+	// denied by a plan policy of the organization.
+	PlanPolicyViolation JobErrorCode = "PLAN_POLICY_VIOLATION"
 )
 
 // JobIsMissingParameterErrorCode returns whether the error is a missing parameter error.
@@ -168,26 +230,33 @@ func JobIsMissingParameterErrorCode(code JobErrorCode) bool {
 }
 
 // ProvisionerJob describes the job executed by the provisioning daemon.
+//
+// EstimatedWaitMillis approximates how long a pending job waits to be
+// acquired: its queue position times the average duration of the jobs of the
+// last day, divided by the online provisioners that can acquire it. It doesn't
+// account for jobs that are already running.
 type ProvisionerJob struct {
-	ID               uuid.UUID              `json:"id" format:"uuid" table:"id"`
-	CreatedAt        time.Time              `json:"created_at" format:"date-time" table:"created at"`
-	StartedAt        *time.Time             `json:"started_at,omitempty" format:"date-time" table:"started at"`
-	CompletedAt      *time.Time             `json:"completed_at,omitempty" format:"date-time" table:"completed at"`
-	CanceledAt       *time.Time             `json:"canceled_at,omitempty" format:"date-time" table:"canceled at"`
-	Error            string                 `json:"error,omitempty" table:"error"`
-	ErrorCode        JobErrorCode           `json:"error_code,omitempty" enums:"REQUIRED_TEMPLATE_VARIABLES" table:"error code"`
-	Status           ProvisionerJobStatus   `json:"status" enums:"pending,running,succeeded,canceling,canceled,failed" table:"status"`
-	WorkerID         *uuid.UUID             `json:"worker_id,omitempty" format:"uuid" table:"worker id"`
-	WorkerName       string                 `json:"worker_name,omitempty" table:"worker name"`
-	FileID           uuid.UUID              `json:"file_id" format:"uuid" table:"file id"`
-	Tags             map[string]string      `json:"tags" table:"tags"`
-	QueuePosition    int                    `json:"queue_position" table:"queue position"`
-	QueueSize        int                    `json:"queue_size" table:"queue size"`
-	OrganizationID   uuid.UUID              `json:"organization_id" format:"uuid" table:"organization id"`
-	Input            ProvisionerJobInput    `json:"input" table:"input,recursive_inline"`
-	Type             ProvisionerJobType     `json:"type" table:"type"`
-	AvailableWorkers []uuid.UUID            `json:"available_workers,omitempty" format:"uuid" table:"available workers"`
-	Metadata         ProvisionerJobMetadata `json:"metadata" table:"metadata,recursive_inline"`
+	ID                  uuid.UUID              `json:"id" format:"uuid" table:"id"`
+	CreatedAt           time.Time              `json:"created_at" format:"date-time" table:"created at"`
+	StartedAt           *time.Time             `json:"started_at,omitempty" format:"date-time" table:"started at"`
+	CompletedAt         *time.Time             `json:"completed_at,omitempty" format:"date-time" table:"completed at"`
+	CanceledAt          *time.Time             `json:"canceled_at,omitempty" format:"date-time" table:"canceled at"`
+	Error               string                 `json:"error,omitempty" table:"error"`
+	ErrorCode           JobErrorCode           `json:"error_code,omitempty" enums:"REQUIRED_TEMPLATE_VARIABLES,PLAN_POLICY_VIOLATION" table:"error code"`
+	Status              ProvisionerJobStatus   `json:"status" enums:"pending,running,succeeded,canceling,canceled,failed" table:"status"`
+	WorkerID            *uuid.UUID             `json:"worker_id,omitempty" format:"uuid" table:"worker id"`
+	WorkerName          string                 `json:"worker_name,omitempty" table:"worker name"`
+	FileID              uuid.UUID              `json:"file_id" format:"uuid" table:"file id"`
+	Tags                map[string]string      `json:"tags" table:"tags"`
+	QueuePosition       int                    `json:"queue_position" table:"queue position"`
+	QueueSize           int                    `json:"queue_size" table:"queue size"`
+	EstimatedWaitMillis int64                  `json:"estimated_wait_ms,omitempty" table:"estimated wait ms"`
+	Priority            ProvisionerJobPriority `json:"priority" enums:"interactive,scheduled,prebuild,template_import,drift_check" table:"priority"`
+	OrganizationID      uuid.UUID              `json:"organization_id" format:"uuid" table:"organization id"`
+	Input               ProvisionerJobInput    `json:"input" table:"input,recursive_inline"`
+	Type                ProvisionerJobType     `json:"type" table:"type"`
+	AvailableWorkers    []uuid.UUID            `json:"available_workers,omitempty" format:"uuid" table:"available workers"`
+	Metadata            ProvisionerJobMetadata `json:"metadata" table:"metadata,recursive_inline"`
 }
 
 // ProvisionerJobLog represents the provisioner log entry annotated with source and level.
@@ -255,6 +324,55 @@ type ServeProvisionerDaemonRequest struct {
 	PreSharedKey string `json:"pre_shared_key"`
 	// ProvisionerKey is an authentication key to use on the API instead of the normal session token from the client.
 	ProvisionerKey string `json:"provisioner_key"`
+	// ProvisionerVersions are the versions of provisioner binaries installed
+	// on the daemon. Jobs of templates that pin one of them can be acquired
+	// by the daemon.
+	ProvisionerVersions []ProvisionerBinaryVersion `json:"provisioner_versions"`
+}
+
+// ProvisionerBinaryVersion is a version of the binary of a provisioner type,
+// written as "<provisioner>@<version>", e.g. "tofu@1.9.1".
+// @typescript-ignore ProvisionerBinaryVersion
+type ProvisionerBinaryVersion struct {
+	Provisioner ProvisionerType `json:"provisioner"`
+	Version     string          `json:"version"`
+}
+
+func (v ProvisionerBinaryVersion) String() string {
+	return fmt.Sprintf("%s@%s", v.Provisioner, v.Version)
+}
+
+// ParseProvisionerBinaryVersion parses a version of the binary of a
+// provisioner type written as "<provisioner>@<version>".
+func ParseProvisionerBinaryVersion(s string) (ProvisionerBinaryVersion, error) {
+	provisioner, version, ok := strings.Cut(s, "@")
+	if !ok {
+		return ProvisionerBinaryVersion{}, xerrors.Errorf("provisioner version %q must be of the form <provisioner>@<version>", s)
+	}
+	switch ProvisionerType(provisioner) {
+	case ProvisionerTypeTerraform, ProvisionerTypeTofu:
+	default:
+		return ProvisionerBinaryVersion{}, xerrors.Errorf("provisioner %q does not support versions", provisioner)
+	}
+	version, err := CanonicalProvisionerVersion(version)
+	if err != nil {
+		return ProvisionerBinaryVersion{}, err
+	}
+	return ProvisionerBinaryVersion{
+		Provisioner: ProvisionerType(provisioner),
+		Version:     version,
+	}, nil
+}
+
+// CanonicalProvisionerVersion validates a version of a provisioner binary
+// and returns it in the form used by provisioner tags, e.g. "v1.9" becomes
+// "1.9.0".
+func CanonicalProvisionerVersion(version string) (string, error) {
+	canonical := semver.Canonical("v" + strings.TrimPrefix(version, "v"))
+	if canonical == "" {
+		return "", xerrors.Errorf("provisioner version %q is not a valid semantic version", version)
+	}
+	return strings.TrimPrefix(canonical, "v"), nil
 }
 
 // ServeProvisionerDaemon returns the gRPC service for a provisioner daemon
@@ -281,6 +399,9 @@ func (c *Client) ServeProvisionerDaemon(ctx context.Context, req ServeProvisione
 	for key, value := range req.Tags {
 		query.Add("tag", fmt.Sprintf("%s=%s", key, value))
 	}
+	for _, version := range req.ProvisionerVersions {
+		query.Add("provisioner_version", version.String())
+	}
 	serverURL.RawQuery = query.Encode()
 	httpClient := &http.Client{
 		Transport: c.HTTPClient.Transport,
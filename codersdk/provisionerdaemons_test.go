@@ -0,0 +1,40 @@
+package codersdk_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/codersdk"
+)
+
+func TestParseProvisionerBinaryVersion(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		input string
+		want  codersdk.ProvisionerBinaryVersion
+		err   string
+	}{
+		{input: "tofu@1.9.1", want: codersdk.ProvisionerBinaryVersion{Provisioner: codersdk.ProvisionerTypeTofu, Version: "1.9.1"}},
+		{input: "terraform@v1.12", want: codersdk.ProvisionerBinaryVersion{Provisioner: codersdk.ProvisionerTypeTerraform, Version: "1.12.0"}},
+		{input: "tofu@1.10.0-rc1", want: codersdk.ProvisionerBinaryVersion{Provisioner: codersdk.ProvisionerTypeTofu, Version: "1.10.0-rc1"}},
+		{input: "tofu", err: "must be of the form"},
+		{input: "echo@1.0.0", err: "does not support versions"},
+		{input: "tofu@latest", err: "not a valid semantic version"},
+		{input: "tofu@", err: "not a valid semantic version"},
+	} {
+		t.Run(tc.input, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := codersdk.ParseProvisionerBinaryVersion(tc.input)
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+			require.Equal(t, string(tc.want.Provisioner)+"@"+tc.want.Version, got.String())
+		})
+	}
+}
@@ -24,7 +24,7 @@ type Template struct {
 	OrganizationIcon        string          `json:"organization_icon"`
 	Name                    string          `json:"name"`
 	DisplayName             string          `json:"display_name"`
-	Provisioner             ProvisionerType `json:"provisioner" enums:"terraform"`
+	Provisioner             ProvisionerType `json:"provisioner" enums:"terraform,tofu"`
 	ActiveVersionID         uuid.UUID       `json:"active_version_id" format:"uuid"`
 	// ActiveUserCount is set to -1 when loading.
 	ActiveUserCount    int                    `json:"active_user_count"`
@@ -63,6 +63,17 @@ type Template struct {
 	MaxPortShareLevel    WorkspaceAgentPortShareLevel `json:"max_port_share_level"`
 
 	UseClassicParameterFlow bool `json:"use_classic_parameter_flow"`
+
+	// DriftCheckIntervalMillis is how often running workspaces of the
+	// template are checked for resources changed outside of Coder. Zero
+	// disables drift checks.
+	DriftCheckIntervalMillis int64 `json:"drift_check_interval_ms"`
+
+	// ProvisionerVersion is the version of the provisioner binary that jobs
+	// of the template run with. Jobs are only acquired by provisioner
+	// daemons that have this version installed. Empty uses the default
+	// version of each daemon.
+	ProvisionerVersion string `json:"provisioner_version"`
 }
 
 // WeekdaysToBitmap converts a list of weekdays to a bitmap in accordance with
@@ -258,6 +269,15 @@ type UpdateTemplateMeta struct {
 	// made the default.
 	// An "opt-out" is present in case the new feature breaks some existing templates.
 	UseClassicParameterFlow *bool `json:"use_classic_parameter_flow,omitempty"`
+	// DriftCheckIntervalMillis sets how often running workspaces are checked
+	// for resources changed outside of Coder. Set to zero to disable drift
+	// checks. The current value is kept when omitted.
+	DriftCheckIntervalMillis *int64 `json:"drift_check_interval_ms,omitempty"`
+	// ProvisionerVersion pins the version of the provisioner binary that
+	// jobs of the template run with, e.g. "1.9.1". Set to an empty string to
+	// use the default version of the provisioner daemons. The current value
+	// is kept when omitted.
+	ProvisionerVersion *string `json:"provisioner_version,omitempty"`
 }
 
 type TemplateExample struct {
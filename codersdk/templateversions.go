@@ -193,6 +193,10 @@ type CreateTemplateVersionDryRunRequest struct {
 	WorkspaceName       string                    `json:"workspace_name"`
 	RichParameterValues []WorkspaceBuildParameter `json:"rich_parameter_values"`
 	UserVariableValues  []VariableValue           `json:"user_variable_values,omitempty"`
+	// WorkspaceID plans the dry-run against the state of an existing
+	// workspace, to preview the resource changes of updating the workspace to
+	// the template version. See TemplateVersionDryRunResourceChanges.
+	WorkspaceID uuid.UUID `json:"workspace_id,omitempty" format:"uuid"`
 }
 
 // CreateTemplateVersionDryRun begins a dry-run provisioner job against the
@@ -0,0 +1,216 @@
+package codersdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WorkspaceAgentHTTPCaptureRedactedHeaders are always redacted from
+// captured requests and responses.
+var WorkspaceAgentHTTPCaptureRedactedHeaders = []string{
+	"Authorization",
+	"Cookie",
+	"Proxy-Authorization",
+	"Set-Cookie",
+	SessionTokenHeader,
+}
+
+// WorkspaceAgentHTTPCaptureRedacted replaces the values of redacted headers.
+const WorkspaceAgentHTTPCaptureRedacted = "[REDACTED]"
+
+type WorkspaceAgentHTTPCaptureRequest struct {
+	// Port is the port of the app in the workspace.
+	Port uint16 `json:"port"`
+	// DurationMillis is how long requests are recorded for. Defaults to
+	// five minutes.
+	DurationMillis int64 `json:"duration_ms,omitempty"`
+	// IncludeBodies records request and response bodies, up to
+	// MaxBodyBytes each.
+	IncludeBodies bool  `json:"include_bodies,omitempty"`
+	MaxBodyBytes  int64 `json:"max_body_bytes,omitempty"`
+	// MaxEntries is the number of requests kept. The oldest requests are
+	// dropped once it is reached.
+	MaxEntries int `json:"max_entries,omitempty"`
+	// RedactHeaders are redacted in addition to
+	// WorkspaceAgentHTTPCaptureRedactedHeaders.
+	RedactHeaders []string `json:"redact_headers,omitempty"`
+}
+
+// WorkspaceAgentHTTPCapture describes the recording of requests to a port.
+type WorkspaceAgentHTTPCapture struct {
+	Port          uint16     `json:"port"`
+	Active        bool       `json:"active"`
+	StartedAt     time.Time  `json:"started_at" format:"date-time"`
+	EndsAt        time.Time  `json:"ends_at" format:"date-time"`
+	StoppedAt     *time.Time `json:"stopped_at,omitempty" format:"date-time"`
+	IncludeBodies bool       `json:"include_bodies"`
+	MaxBodyBytes  int64      `json:"max_body_bytes"`
+	MaxEntries    int        `json:"max_entries"`
+	RedactHeaders []string   `json:"redact_headers"`
+	// Entries is the number of recorded requests, Dropped the number of
+	// requests that were dropped to stay within MaxEntries.
+	Entries int `json:"entries"`
+	Dropped int `json:"dropped"`
+	// OpaqueConnections counts connections that were passed to the app
+	// without being recorded, e.g. TLS connections.
+	OpaqueConnections int `json:"opaque_connections"`
+}
+
+// HAR is an HTTP Archive, see http://www.softwareishard.com/blog/har-12-spec/.
+type HAR struct {
+	Log HARLog `json:"log"`
+}
+
+type HARLog struct {
+	Version string     `json:"version"`
+	Creator HARCreator `json:"creator"`
+	Entries []HAREntry `json:"entries"`
+	Comment string     `json:"comment,omitempty"`
+}
+
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type HAREntry struct {
+	StartedDateTime time.Time `json:"startedDateTime" format:"date-time"`
+	// Time is the total duration of the request in milliseconds.
+	Time     float64     `json:"time"`
+	Request  HARRequest  `json:"request"`
+	Response HARResponse `json:"response"`
+	Cache    HARCache    `json:"cache"`
+	Timings  HARTimings  `json:"timings"`
+	Comment  string      `json:"comment,omitempty"`
+}
+
+type HARRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	QueryString []HARNameValue `json:"queryString"`
+	PostData    *HARPostData   `json:"postData,omitempty"`
+	HeadersSize int64          `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type HARResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	Content     HARContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int64          `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type HARPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+type HARContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	// Encoding is "base64" when Text holds binary content.
+	Encoding string `json:"encoding,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+type HARCache struct{}
+
+// HARTimings are in milliseconds.
+type HARTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// WorkspaceAgentHTTPCaptures lists the HTTP captures of a workspace agent.
+func (c *Client) WorkspaceAgentHTTPCaptures(ctx context.Context, agentID uuid.UUID) ([]WorkspaceAgentHTTPCapture, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/workspaceagents/%s/http-captures", agentID), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, ReadBodyAsError(res)
+	}
+	var captures []WorkspaceAgentHTTPCapture
+	return captures, json.NewDecoder(res.Body).Decode(&captures)
+}
+
+// StartWorkspaceAgentHTTPCapture starts recording the requests to a port
+// of the workspace. A previous capture of the port is discarded.
+func (c *Client) StartWorkspaceAgentHTTPCapture(ctx context.Context, agentID uuid.UUID, req WorkspaceAgentHTTPCaptureRequest) (WorkspaceAgentHTTPCapture, error) {
+	res, err := c.Request(ctx, http.MethodPost, fmt.Sprintf("/api/v2/workspaceagents/%s/http-captures", agentID), req)
+	if err != nil {
+		return WorkspaceAgentHTTPCapture{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusCreated {
+		return WorkspaceAgentHTTPCapture{}, ReadBodyAsError(res)
+	}
+	var capture WorkspaceAgentHTTPCapture
+	return capture, json.NewDecoder(res.Body).Decode(&capture)
+}
+
+// StopWorkspaceAgentHTTPCapture stops recording the requests to a port. The
+// recorded requests are kept.
+func (c *Client) StopWorkspaceAgentHTTPCapture(ctx context.Context, agentID uuid.UUID, port uint16) (WorkspaceAgentHTTPCapture, error) {
+	res, err := c.Request(ctx, http.MethodPost, fmt.Sprintf("/api/v2/workspaceagents/%s/http-captures/%d/stop", agentID, port), nil)
+	if err != nil {
+		return WorkspaceAgentHTTPCapture{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return WorkspaceAgentHTTPCapture{}, ReadBodyAsError(res)
+	}
+	var capture WorkspaceAgentHTTPCapture
+	return capture, json.NewDecoder(res.Body).Decode(&capture)
+}
+
+// DeleteWorkspaceAgentHTTPCapture stops recording the requests to a port
+// and discards the recorded requests.
+func (c *Client) DeleteWorkspaceAgentHTTPCapture(ctx context.Context, agentID uuid.UUID, port uint16) error {
+	res, err := c.Request(ctx, http.MethodDelete, fmt.Sprintf("/api/v2/workspaceagents/%s/http-captures/%d", agentID, port), nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusNoContent {
+		return ReadBodyAsError(res)
+	}
+	return nil
+}
+
+// WorkspaceAgentHTTPCaptureHAR returns the requests recorded for a port as
+// an HTTP Archive.
+func (c *Client) WorkspaceAgentHTTPCaptureHAR(ctx context.Context, agentID uuid.UUID, port uint16) (HAR, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/workspaceagents/%s/http-captures/%d/har", agentID, port), nil)
+	if err != nil {
+		return HAR{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return HAR{}, ReadBodyAsError(res)
+	}
+	var har HAR
+	return har, json.NewDecoder(res.Body).Decode(&har)
+}
@@ -38,13 +38,39 @@ type (
 		Port        int32                           `json:"port"`
 		ShareLevel  WorkspaceAgentPortShareLevel    `json:"share_level" enums:"owner,authenticated,organization,public"`
 		Protocol    WorkspaceAgentPortShareProtocol `json:"protocol" enums:"http,https"`
+		// AutoShared is true when the port share was created by a template
+		// port share rule.
+		AutoShared bool `json:"auto_shared"`
+		// Label is the label of the template port share rule that created
+		// the port share, if any.
+		Label string `json:"label"`
 	}
 	DeleteWorkspaceAgentPortShareRequest struct {
 		AgentName string `json:"agent_name"`
 		Port      int32  `json:"port"`
 	}
+	// TemplatePortShareRule automatically shares listening ports within
+	// [PortStart, PortEnd] of workspaces created from the template.
+	TemplatePortShareRule struct {
+		PortStart  int32                           `json:"port_start"`
+		PortEnd    int32                           `json:"port_end"`
+		ShareLevel WorkspaceAgentPortShareLevel    `json:"share_level" enums:"authenticated,organization,public"`
+		Protocol   WorkspaceAgentPortShareProtocol `json:"protocol" enums:"http,https"`
+		Label      string                          `json:"label,omitempty"`
+	}
+	TemplatePortShareRules struct {
+		Rules []TemplatePortShareRule `json:"rules"`
+	}
+	UpdateTemplatePortShareRulesRequest struct {
+		Rules []TemplatePortShareRule `json:"rules"`
+	}
 )
 
+// Matches returns true if the port is within the range of the rule.
+func (r TemplatePortShareRule) Matches(port int32) bool {
+	return port >= r.PortStart && port <= r.PortEnd
+}
+
 func (l WorkspaceAgentPortShareLevel) ValidMaxLevel() bool {
 	return l == WorkspaceAgentPortShareLevelOwner ||
 		l == WorkspaceAgentPortShareLevelAuthenticated ||
@@ -146,3 +172,32 @@ func (c *Client) DeleteWorkspaceAgentPortShare(ctx context.Context, workspaceID
 	}
 	return nil
 }
+
+func (c *Client) TemplatePortShareRules(ctx context.Context, templateID uuid.UUID) (TemplatePortShareRules, error) {
+	var rules TemplatePortShareRules
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/templates/%s/port-share-rules", templateID), nil)
+	if err != nil {
+		return rules, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return rules, ReadBodyAsError(res)
+	}
+
+	return rules, json.NewDecoder(res.Body).Decode(&rules)
+}
+
+// UpdateTemplatePortShareRules replaces the port share rules of a template.
+func (c *Client) UpdateTemplatePortShareRules(ctx context.Context, templateID uuid.UUID, req UpdateTemplatePortShareRulesRequest) (TemplatePortShareRules, error) {
+	var rules TemplatePortShareRules
+	res, err := c.Request(ctx, http.MethodPut, fmt.Sprintf("/api/v2/templates/%s/port-share-rules", templateID), req)
+	if err != nil {
+		return rules, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return rules, ReadBodyAsError(res)
+	}
+
+	return rules, json.NewDecoder(res.Body).Decode(&rules)
+}
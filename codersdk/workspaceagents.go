@@ -7,6 +7,7 @@ import (
 	"io"
 	"net/http"
 	"net/http/cookiejar"
+	"strconv"
 	"strings"
 	"time"
 
@@ -393,6 +394,99 @@ func (c *Client) WorkspaceAgentListeningPorts(ctx context.Context, agentID uuid.
 	return listeningPorts, json.NewDecoder(res.Body).Decode(&listeningPorts)
 }
 
+// WorkspaceAgentProcessSort is the order in which processes are listed.
+type WorkspaceAgentProcessSort string
+
+const (
+	WorkspaceAgentProcessSortCPU    WorkspaceAgentProcessSort = "cpu"
+	WorkspaceAgentProcessSortMemory WorkspaceAgentProcessSort = "memory"
+	WorkspaceAgentProcessSortPID    WorkspaceAgentProcessSort = "pid"
+)
+
+// Valid returns whether the sort order is known.
+func (s WorkspaceAgentProcessSort) Valid() bool {
+	switch s {
+	case WorkspaceAgentProcessSortCPU, WorkspaceAgentProcessSortMemory, WorkspaceAgentProcessSortPID:
+		return true
+	default:
+		return false
+	}
+}
+
+type WorkspaceAgentProcessesResponse struct {
+	Processes []WorkspaceAgentProcess `json:"processes"`
+	// Warnings contains any processes that could not be fully inspected,
+	// usually because they belong to another user.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// WorkspaceAgentProcess is a process running in the workspace agent's
+// process namespace.
+type WorkspaceAgentProcess struct {
+	PID      int32  `json:"pid"`
+	PPID     int32  `json:"ppid"`
+	Name     string `json:"name"`
+	Cmdline  string `json:"cmdline"`
+	Username string `json:"username"`
+	Status   string `json:"status"`
+	// CPUPercent is the CPU usage sampled over a short interval, relative to
+	// a single core. It may exceed 100 for multi-threaded processes.
+	CPUPercent    float64   `json:"cpu_percent"`
+	MemoryRSS     uint64    `json:"memory_rss"`
+	MemoryPercent float32   `json:"memory_percent"`
+	CreatedAt     time.Time `json:"created_at" format:"date-time"`
+	// Ports are the TCP ports the process is listening on.
+	Ports []uint16 `json:"ports"`
+}
+
+// WorkspaceAgentProcessesOptions filters and orders the processes returned by
+// WorkspaceAgentProcesses.
+// @typescript-ignore WorkspaceAgentProcessesOptions
+type WorkspaceAgentProcessesOptions struct {
+	Sort WorkspaceAgentProcessSort
+	// Limit is the maximum number of processes to return. Zero means no limit.
+	Limit int
+}
+
+// WorkspaceAgentProcesses returns the processes running inside the workspace
+// agent's process namespace.
+func (c *Client) WorkspaceAgentProcesses(ctx context.Context, agentID uuid.UUID, opts WorkspaceAgentProcessesOptions) (WorkspaceAgentProcessesResponse, error) {
+	reqOpts := []RequestOption{WithQueryParam("sort", string(opts.Sort))}
+	if opts.Limit > 0 {
+		reqOpts = append(reqOpts, WithQueryParam("limit", strconv.Itoa(opts.Limit)))
+	}
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/workspaceagents/%s/processes", agentID), nil, reqOpts...)
+	if err != nil {
+		return WorkspaceAgentProcessesResponse{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return WorkspaceAgentProcessesResponse{}, ReadBodyAsError(res)
+	}
+	var resp WorkspaceAgentProcessesResponse
+	return resp, json.NewDecoder(res.Body).Decode(&resp)
+}
+
+type WorkspaceAgentSignalProcessRequest struct {
+	// Signal is the name of the signal to send, e.g. "TERM" or "KILL". The
+	// "SIG" prefix is optional.
+	Signal string `json:"signal"`
+}
+
+// WorkspaceAgentSignalProcess sends a signal to a process running inside the
+// workspace agent's process namespace.
+func (c *Client) WorkspaceAgentSignalProcess(ctx context.Context, agentID uuid.UUID, pid int32, req WorkspaceAgentSignalProcessRequest) error {
+	res, err := c.Request(ctx, http.MethodPost, fmt.Sprintf("/api/v2/workspaceagents/%s/processes/%d/signal", agentID, pid), req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return ReadBodyAsError(res)
+	}
+	return nil
+}
+
 // WorkspaceAgentDevcontainerStatus is the status of a devcontainer.
 type WorkspaceAgentDevcontainerStatus string
 
@@ -401,6 +495,8 @@ const (
 	WorkspaceAgentDevcontainerStatusRunning  WorkspaceAgentDevcontainerStatus = "running"
 	WorkspaceAgentDevcontainerStatusStopped  WorkspaceAgentDevcontainerStatus = "stopped"
 	WorkspaceAgentDevcontainerStatusStarting WorkspaceAgentDevcontainerStatus = "starting"
+	WorkspaceAgentDevcontainerStatusStopping WorkspaceAgentDevcontainerStatus = "stopping"
+	WorkspaceAgentDevcontainerStatusDeleting WorkspaceAgentDevcontainerStatus = "deleting"
 	WorkspaceAgentDevcontainerStatusError    WorkspaceAgentDevcontainerStatus = "error"
 )
 
@@ -417,10 +513,26 @@ type WorkspaceAgentDevcontainer struct {
 	Dirty     bool                             `json:"dirty"`
 	Container *WorkspaceAgentContainer         `json:"container,omitempty"`
 	Agent     *WorkspaceAgentDevcontainerAgent `json:"agent,omitempty"`
+	// Features are the devcontainer features applied to the container.
+	Features []WorkspaceAgentDevcontainerFeature `json:"features,omitempty"`
 
 	Error string `json:"error,omitempty"`
 }
 
+// WorkspaceAgentDevcontainerFeature is a devcontainer feature that was
+// applied when the devcontainer was built.
+type WorkspaceAgentDevcontainerFeature struct {
+	// ID is the feature reference, e.g.
+	// "ghcr.io/devcontainers/features/go:1".
+	ID string `json:"id"`
+}
+
+// WorkspaceAgentDevcontainerLogsResponse contains the output of the most
+// recent build of a devcontainer.
+type WorkspaceAgentDevcontainerLogsResponse struct {
+	Logs []WorkspaceAgentLog `json:"logs"`
+}
+
 // WorkspaceAgentDevcontainerAgent represents the sub agent for a
 // devcontainer.
 type WorkspaceAgentDevcontainerAgent struct {
@@ -537,6 +649,117 @@ func (c *Client) WorkspaceAgentRecreateDevcontainer(ctx context.Context, agentID
 	return m, nil
 }
 
+// WorkspaceAgentRebuildDevcontainer recreates the devcontainer with the
+// given ID, rebuilding its image without using the build cache.
+func (c *Client) WorkspaceAgentRebuildDevcontainer(ctx context.Context, agentID uuid.UUID, devcontainerID string) (Response, error) {
+	return c.workspaceAgentDevcontainerAction(ctx, http.MethodPost, fmt.Sprintf("/api/v2/workspaceagents/%s/containers/devcontainers/%s/recreate?no_cache=true", agentID, devcontainerID), http.StatusAccepted)
+}
+
+// WorkspaceAgentStopDevcontainer stops the container backing the
+// devcontainer with the given ID.
+func (c *Client) WorkspaceAgentStopDevcontainer(ctx context.Context, agentID uuid.UUID, devcontainerID string) (Response, error) {
+	return c.workspaceAgentDevcontainerAction(ctx, http.MethodPost, fmt.Sprintf("/api/v2/workspaceagents/%s/containers/devcontainers/%s/stop", agentID, devcontainerID), http.StatusOK)
+}
+
+// WorkspaceAgentStartDevcontainer starts the stopped container backing
+// the devcontainer with the given ID.
+func (c *Client) WorkspaceAgentStartDevcontainer(ctx context.Context, agentID uuid.UUID, devcontainerID string) (Response, error) {
+	return c.workspaceAgentDevcontainerAction(ctx, http.MethodPost, fmt.Sprintf("/api/v2/workspaceagents/%s/containers/devcontainers/%s/start", agentID, devcontainerID), http.StatusOK)
+}
+
+// WorkspaceAgentDeleteDevcontainer removes the container backing the
+// devcontainer with the given ID.
+func (c *Client) WorkspaceAgentDeleteDevcontainer(ctx context.Context, agentID uuid.UUID, devcontainerID string) (Response, error) {
+	return c.workspaceAgentDevcontainerAction(ctx, http.MethodDelete, fmt.Sprintf("/api/v2/workspaceagents/%s/containers/devcontainers/%s", agentID, devcontainerID), http.StatusOK)
+}
+
+func (c *Client) workspaceAgentDevcontainerAction(ctx context.Context, method, path string, wantStatus int) (Response, error) {
+	res, err := c.Request(ctx, method, path, nil)
+	if err != nil {
+		return Response{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != wantStatus {
+		return Response{}, ReadBodyAsError(res)
+	}
+	var m Response
+	if err := json.NewDecoder(res.Body).Decode(&m); err != nil {
+		return Response{}, xerrors.Errorf("decode response body: %w", err)
+	}
+	return m, nil
+}
+
+// WorkspaceAgentDevcontainerLogs returns the output of the most recent
+// build of the devcontainer with the given ID. Only logs with an ID
+// greater than after are returned.
+func (c *Client) WorkspaceAgentDevcontainerLogs(ctx context.Context, agentID uuid.UUID, devcontainerID string, after int64) (WorkspaceAgentDevcontainerLogsResponse, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/workspaceagents/%s/containers/devcontainers/%s/logs?after=%d", agentID, devcontainerID, after), nil)
+	if err != nil {
+		return WorkspaceAgentDevcontainerLogsResponse{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return WorkspaceAgentDevcontainerLogsResponse{}, ReadBodyAsError(res)
+	}
+	var resp WorkspaceAgentDevcontainerLogsResponse
+	return resp, json.NewDecoder(res.Body).Decode(&resp)
+}
+
+// WorkspaceAgentDevcontainerLogsOptions controls which devcontainer build
+// logs are returned by WorkspaceAgentDevcontainerLogsAfter.
+// @typescript-ignore WorkspaceAgentDevcontainerLogsOptions
+type WorkspaceAgentDevcontainerLogsOptions struct {
+	// After only returns logs with an ID greater than this one.
+	After int64
+	// Follow streams the logs until the devcontainer is no longer being
+	// built.
+	Follow bool
+}
+
+// WorkspaceAgentDevcontainerLogsAfter returns the output of the most
+// recent build of the devcontainer with the given ID.
+func (c *Client) WorkspaceAgentDevcontainerLogsAfter(ctx context.Context, agentID uuid.UUID, devcontainerID string, opts WorkspaceAgentDevcontainerLogsOptions) (<-chan []WorkspaceAgentLog, io.Closer, error) {
+	if !opts.Follow {
+		resp, err := c.WorkspaceAgentDevcontainerLogs(ctx, agentID, devcontainerID, opts.After)
+		if err != nil {
+			return nil, nil, err
+		}
+		ch := make(chan []WorkspaceAgentLog, 1)
+		ch <- resp.Logs
+		close(ch)
+		return ch, closeFunc(func() error { return nil }), nil
+	}
+
+	reqURL, err := c.URL.Parse(fmt.Sprintf("/api/v2/workspaceagents/%s/containers/devcontainers/%s/logs?follow&after=%d", agentID, devcontainerID, opts.After))
+	if err != nil {
+		return nil, nil, err
+	}
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, nil, xerrors.Errorf("create cookie jar: %w", err)
+	}
+	jar.SetCookies(reqURL, []*http.Cookie{{
+		Name:  SessionTokenCookie,
+		Value: c.SessionToken(),
+	}})
+	httpClient := &http.Client{
+		Jar:       jar,
+		Transport: c.HTTPClient.Transport,
+	}
+	conn, res, err := websocket.Dial(ctx, reqURL.String(), &websocket.DialOptions{
+		HTTPClient:      httpClient,
+		CompressionMode: websocket.CompressionDisabled,
+	})
+	if err != nil {
+		if res == nil {
+			return nil, nil, err
+		}
+		return nil, nil, ReadBodyAsError(res)
+	}
+	d := wsjson.NewDecoder[[]WorkspaceAgentLog](conn, websocket.MessageText, c.logger)
+	return d.Chan(), d, nil
+}
+
 //nolint:revive // Follow is a control flag on the server as well.
 func (c *Client) WorkspaceAgentLogsAfter(ctx context.Context, agentID uuid.UUID, after int64, follow bool) (<-chan []WorkspaceAgentLog, io.Closer, error) {
 	var queryParams []string
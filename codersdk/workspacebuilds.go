@@ -154,6 +154,33 @@ func (c *Client) WorkspaceBuildState(ctx context.Context, build uuid.UUID) ([]by
 	return io.ReadAll(res.Body)
 }
 
+// WorkspaceBuildState describes the Terraform state a workspace build left
+// behind. The state itself is fetched with WorkspaceBuildState.
+type WorkspaceBuildState struct {
+	WorkspaceBuildID uuid.UUID           `json:"workspace_build_id" format:"uuid"`
+	BuildNumber      int32               `json:"build_number"`
+	Transition       WorkspaceTransition `json:"transition" enums:"start,stop,delete"`
+	// Hash is the hex-encoded SHA256 hash of the state.
+	Hash      string    `json:"hash"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at" format:"date-time"`
+}
+
+// WorkspaceBuildStates returns the Terraform states kept for the builds of a
+// workspace, newest first.
+func (c *Client) WorkspaceBuildStates(ctx context.Context, workspace uuid.UUID) ([]WorkspaceBuildState, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/workspaces/%s/states", workspace), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, ReadBodyAsError(res)
+	}
+	var states []WorkspaceBuildState
+	return states, json.NewDecoder(res.Body).Decode(&states)
+}
+
 func (c *Client) WorkspaceBuildByUsernameAndWorkspaceNameAndBuildNumber(ctx context.Context, username string, workspaceName string, buildNumber string) (WorkspaceBuild, error) {
 	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/users/%s/workspace/%s/builds/%s", username, workspaceName, buildNumber), nil)
 	if err != nil {
@@ -0,0 +1,85 @@
+package codersdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WorkspaceDriftAction is how a resource changed outside of Coder.
+type WorkspaceDriftAction string
+
+const (
+	WorkspaceDriftActionChanged WorkspaceDriftAction = "changed"
+	WorkspaceDriftActionDeleted WorkspaceDriftAction = "deleted"
+)
+
+// WorkspaceDriftedAttribute is an attribute of a resource whose value differs
+// between the state of the last build and the real infrastructure.
+type WorkspaceDriftedAttribute struct {
+	// Path is the dot separated path of the attribute, e.g. "labels.owner".
+	Path string `json:"path"`
+	// Before and After are omitted for sensitive attributes.
+	Before    any  `json:"before,omitempty"`
+	After     any  `json:"after,omitempty"`
+	Sensitive bool `json:"sensitive,omitempty"`
+}
+
+// WorkspaceDriftedResource is a resource that changed outside of Coder.
+type WorkspaceDriftedResource struct {
+	Address    string                      `json:"address"`
+	Type       string                      `json:"type"`
+	Name       string                      `json:"name"`
+	ModulePath string                      `json:"module_path,omitempty"`
+	Action     WorkspaceDriftAction        `json:"action" enums:"changed,deleted"`
+	Attributes []WorkspaceDriftedAttribute `json:"attributes"`
+}
+
+// WorkspaceDriftCheck is a plan of the latest build of a running workspace,
+// which compares its resources against the real infrastructure.
+type WorkspaceDriftCheck struct {
+	ID               uuid.UUID      `json:"id" format:"uuid"`
+	WorkspaceID      uuid.UUID      `json:"workspace_id" format:"uuid"`
+	WorkspaceBuildID uuid.UUID      `json:"workspace_build_id" format:"uuid"`
+	Job              ProvisionerJob `json:"job"`
+	CreatedAt        time.Time      `json:"created_at" format:"date-time"`
+	CompletedAt      *time.Time     `json:"completed_at,omitempty" format:"date-time"`
+	// Drifted is true when the check completed and found at least one
+	// resource that changed outside of Coder.
+	Drifted   bool                       `json:"drifted"`
+	Resources []WorkspaceDriftedResource `json:"resources"`
+}
+
+// WorkspaceDriftCheck returns the latest drift check of the current build of
+// a workspace.
+func (c *Client) WorkspaceDriftCheck(ctx context.Context, workspaceID uuid.UUID) (WorkspaceDriftCheck, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/workspaces/%s/drift", workspaceID), nil)
+	if err != nil {
+		return WorkspaceDriftCheck{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return WorkspaceDriftCheck{}, ReadBodyAsError(res)
+	}
+	var check WorkspaceDriftCheck
+	return check, json.NewDecoder(res.Body).Decode(&check)
+}
+
+// CheckWorkspaceDrift queues a drift check of the current build of a running
+// workspace. The check completes asynchronously.
+func (c *Client) CheckWorkspaceDrift(ctx context.Context, workspaceID uuid.UUID) (WorkspaceDriftCheck, error) {
+	res, err := c.Request(ctx, http.MethodPost, fmt.Sprintf("/api/v2/workspaces/%s/drift", workspaceID), nil)
+	if err != nil {
+		return WorkspaceDriftCheck{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusCreated {
+		return WorkspaceDriftCheck{}, ReadBodyAsError(res)
+	}
+	var check WorkspaceDriftCheck
+	return check, json.NewDecoder(res.Body).Decode(&check)
+}
@@ -1,6 +1,7 @@
 package workspacesdk
 
 import (
+	"bytes"
 	"context"
 	"encoding/binary"
 	"encoding/json"
@@ -9,6 +10,7 @@ import (
 	"net"
 	"net/http"
 	"net/netip"
+	"net/url"
 	"strconv"
 	"time"
 
@@ -20,10 +22,13 @@ import (
 	"tailscale.com/ipn/ipnstate"
 	"tailscale.com/net/speedtest"
 
+	"cdr.dev/slog"
 	"github.com/coder/coder/v2/coderd/tracing"
 	"github.com/coder/coder/v2/codersdk"
 	"github.com/coder/coder/v2/codersdk/healthsdk"
+	"github.com/coder/coder/v2/codersdk/wsjson"
 	"github.com/coder/coder/v2/tailnet"
+	"github.com/coder/websocket"
 )
 
 // NewAgentConn creates a new WorkspaceAgentConn. `conn` may be unique
@@ -277,6 +282,143 @@ func (c *AgentConn) ListeningPorts(ctx context.Context) (codersdk.WorkspaceAgent
 	return resp, json.NewDecoder(res.Body).Decode(&resp)
 }
 
+// ListProcesses lists the processes running in the workspace.
+func (c *AgentConn) ListProcesses(ctx context.Context, opts codersdk.WorkspaceAgentProcessesOptions) (codersdk.WorkspaceAgentProcessesResponse, error) {
+	ctx, span := tracing.StartSpan(ctx)
+	defer span.End()
+	q := url.Values{}
+	if opts.Sort != "" {
+		q.Set("sort", string(opts.Sort))
+	}
+	if opts.Limit > 0 {
+		q.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	path := "/api/v0/processes"
+	if len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+	res, err := c.apiRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return codersdk.WorkspaceAgentProcessesResponse{}, xerrors.Errorf("do request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return codersdk.WorkspaceAgentProcessesResponse{}, codersdk.ReadBodyAsError(res)
+	}
+
+	var resp codersdk.WorkspaceAgentProcessesResponse
+	return resp, json.NewDecoder(res.Body).Decode(&resp)
+}
+
+// SignalProcess sends a signal to a process running in the workspace.
+func (c *AgentConn) SignalProcess(ctx context.Context, pid int32, req codersdk.WorkspaceAgentSignalProcessRequest) error {
+	ctx, span := tracing.StartSpan(ctx)
+	defer span.End()
+	body, err := json.Marshal(req)
+	if err != nil {
+		return xerrors.Errorf("marshal request: %w", err)
+	}
+	res, err := c.apiRequest(ctx, http.MethodPost, fmt.Sprintf("/api/v0/processes/%d/signal", pid), bytes.NewReader(body))
+	if err != nil {
+		return xerrors.Errorf("do request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return codersdk.ReadBodyAsError(res)
+	}
+	return nil
+}
+
+// HTTPCaptures lists the HTTP captures of the workspace agent.
+func (c *AgentConn) HTTPCaptures(ctx context.Context) ([]codersdk.WorkspaceAgentHTTPCapture, error) {
+	ctx, span := tracing.StartSpan(ctx)
+	defer span.End()
+	res, err := c.apiRequest(ctx, http.MethodGet, "/api/v0/http-captures", nil)
+	if err != nil {
+		return nil, xerrors.Errorf("do request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, codersdk.ReadBodyAsError(res)
+	}
+
+	var captures []codersdk.WorkspaceAgentHTTPCapture
+	return captures, json.NewDecoder(res.Body).Decode(&captures)
+}
+
+// StartHTTPCapture starts recording the requests to a port of the workspace.
+func (c *AgentConn) StartHTTPCapture(ctx context.Context, req codersdk.WorkspaceAgentHTTPCaptureRequest) (codersdk.WorkspaceAgentHTTPCapture, error) {
+	ctx, span := tracing.StartSpan(ctx)
+	defer span.End()
+	body, err := json.Marshal(req)
+	if err != nil {
+		return codersdk.WorkspaceAgentHTTPCapture{}, xerrors.Errorf("marshal request: %w", err)
+	}
+	res, err := c.apiRequest(ctx, http.MethodPost, "/api/v0/http-captures", bytes.NewReader(body))
+	if err != nil {
+		return codersdk.WorkspaceAgentHTTPCapture{}, xerrors.Errorf("do request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusCreated {
+		return codersdk.WorkspaceAgentHTTPCapture{}, codersdk.ReadBodyAsError(res)
+	}
+
+	var capture codersdk.WorkspaceAgentHTTPCapture
+	return capture, json.NewDecoder(res.Body).Decode(&capture)
+}
+
+// StopHTTPCapture stops recording the requests to a port and keeps the
+// recorded requests.
+func (c *AgentConn) StopHTTPCapture(ctx context.Context, port uint16) (codersdk.WorkspaceAgentHTTPCapture, error) {
+	ctx, span := tracing.StartSpan(ctx)
+	defer span.End()
+	res, err := c.apiRequest(ctx, http.MethodPost, fmt.Sprintf("/api/v0/http-captures/%d/stop", port), nil)
+	if err != nil {
+		return codersdk.WorkspaceAgentHTTPCapture{}, xerrors.Errorf("do request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return codersdk.WorkspaceAgentHTTPCapture{}, codersdk.ReadBodyAsError(res)
+	}
+
+	var capture codersdk.WorkspaceAgentHTTPCapture
+	return capture, json.NewDecoder(res.Body).Decode(&capture)
+}
+
+// DeleteHTTPCapture stops recording the requests to a port and discards the
+// recorded requests.
+func (c *AgentConn) DeleteHTTPCapture(ctx context.Context, port uint16) error {
+	ctx, span := tracing.StartSpan(ctx)
+	defer span.End()
+	res, err := c.apiRequest(ctx, http.MethodDelete, fmt.Sprintf("/api/v0/http-captures/%d", port), nil)
+	if err != nil {
+		return xerrors.Errorf("do request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusNoContent {
+		return codersdk.ReadBodyAsError(res)
+	}
+	return nil
+}
+
+// HTTPCaptureHAR returns the requests recorded for a port as an HTTP
+// Archive.
+func (c *AgentConn) HTTPCaptureHAR(ctx context.Context, port uint16) (codersdk.HAR, error) {
+	ctx, span := tracing.StartSpan(ctx)
+	defer span.End()
+	res, err := c.apiRequest(ctx, http.MethodGet, fmt.Sprintf("/api/v0/http-captures/%d/har", port), nil)
+	if err != nil {
+		return codersdk.HAR{}, xerrors.Errorf("do request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return codersdk.HAR{}, codersdk.ReadBodyAsError(res)
+	}
+
+	var har codersdk.HAR
+	return har, json.NewDecoder(res.Body).Decode(&har)
+}
+
 // Netcheck returns a network check report from the workspace agent.
 func (c *AgentConn) Netcheck(ctx context.Context) (healthsdk.AgentNetcheckReport, error) {
 	ctx, span := tracing.StartSpan(ctx)
@@ -407,6 +549,93 @@ func (c *AgentConn) RecreateDevcontainer(ctx context.Context, devcontainerID str
 	return m, nil
 }
 
+// RebuildDevcontainer recreates a devcontainer with the given container,
+// rebuilding its image without using the build cache.
+func (c *AgentConn) RebuildDevcontainer(ctx context.Context, devcontainerID string) (codersdk.Response, error) {
+	ctx, span := tracing.StartSpan(ctx)
+	defer span.End()
+	return c.devcontainerAction(ctx, http.MethodPost, "/api/v0/containers/devcontainers/"+devcontainerID+"/recreate?no_cache=true", http.StatusAccepted)
+}
+
+// StopDevcontainer stops the container backing a devcontainer.
+func (c *AgentConn) StopDevcontainer(ctx context.Context, devcontainerID string) (codersdk.Response, error) {
+	ctx, span := tracing.StartSpan(ctx)
+	defer span.End()
+	return c.devcontainerAction(ctx, http.MethodPost, "/api/v0/containers/devcontainers/"+devcontainerID+"/stop", http.StatusOK)
+}
+
+// StartDevcontainer starts the stopped container backing a devcontainer.
+func (c *AgentConn) StartDevcontainer(ctx context.Context, devcontainerID string) (codersdk.Response, error) {
+	ctx, span := tracing.StartSpan(ctx)
+	defer span.End()
+	return c.devcontainerAction(ctx, http.MethodPost, "/api/v0/containers/devcontainers/"+devcontainerID+"/start", http.StatusOK)
+}
+
+// DeleteDevcontainer removes the container backing a devcontainer.
+func (c *AgentConn) DeleteDevcontainer(ctx context.Context, devcontainerID string) (codersdk.Response, error) {
+	ctx, span := tracing.StartSpan(ctx)
+	defer span.End()
+	return c.devcontainerAction(ctx, http.MethodDelete, "/api/v0/containers/devcontainers/"+devcontainerID, http.StatusOK)
+}
+
+func (c *AgentConn) devcontainerAction(ctx context.Context, method, path string, wantStatus int) (codersdk.Response, error) {
+	res, err := c.apiRequest(ctx, method, path, nil)
+	if err != nil {
+		return codersdk.Response{}, xerrors.Errorf("do request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != wantStatus {
+		return codersdk.Response{}, codersdk.ReadBodyAsError(res)
+	}
+	var m codersdk.Response
+	if err := json.NewDecoder(res.Body).Decode(&m); err != nil {
+		return codersdk.Response{}, xerrors.Errorf("decode response body: %w", err)
+	}
+	return m, nil
+}
+
+// DevcontainerLogs returns the output of the most recent build of a
+// devcontainer, starting after the given log ID.
+func (c *AgentConn) DevcontainerLogs(ctx context.Context, devcontainerID string, after int64) (codersdk.WorkspaceAgentDevcontainerLogsResponse, error) {
+	ctx, span := tracing.StartSpan(ctx)
+	defer span.End()
+	res, err := c.apiRequest(ctx, http.MethodGet, fmt.Sprintf("/api/v0/containers/devcontainers/%s/logs?after=%d", devcontainerID, after), nil)
+	if err != nil {
+		return codersdk.WorkspaceAgentDevcontainerLogsResponse{}, xerrors.Errorf("do request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return codersdk.WorkspaceAgentDevcontainerLogsResponse{}, codersdk.ReadBodyAsError(res)
+	}
+	var resp codersdk.WorkspaceAgentDevcontainerLogsResponse
+	return resp, json.NewDecoder(res.Body).Decode(&resp)
+}
+
+// WatchDevcontainerLogs streams the output of the most recent build of a
+// devcontainer, starting after the given log ID. The returned channel is
+// closed once the devcontainer is no longer being built and all of its
+// build logs have been sent.
+func (c *AgentConn) WatchDevcontainerLogs(ctx context.Context, devcontainerID string, after int64) (<-chan []codersdk.WorkspaceAgentLog, io.Closer, error) {
+	ctx, span := tracing.StartSpan(ctx)
+	defer span.End()
+
+	host := net.JoinHostPort(c.agentAddress().String(), strconv.Itoa(AgentHTTPAPIServerPort))
+	url := fmt.Sprintf("http://%s/api/v0/containers/devcontainers/%s/logs?follow&after=%d", host, devcontainerID, after)
+
+	conn, res, err := websocket.Dial(ctx, url, &websocket.DialOptions{
+		HTTPClient:      c.apiClient(),
+		CompressionMode: websocket.CompressionDisabled,
+	})
+	if err != nil {
+		if res == nil {
+			return nil, nil, xerrors.Errorf("dial devcontainer logs: %w", err)
+		}
+		return nil, nil, codersdk.ReadBodyAsError(res)
+	}
+	d := wsjson.NewDecoder[[]codersdk.WorkspaceAgentLog](conn, websocket.MessageText, slog.Logger{})
+	return d.Chan(), d, nil
+}
+
 // apiRequest makes a request to the workspace agent's HTTP API server.
 func (c *AgentConn) apiRequest(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
 	ctx, span := tracing.StartSpan(ctx)
@@ -0,0 +1,102 @@
+package codersdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WorkspaceSnapshotTrigger is the event that caused the agent to take a
+// snapshot.
+type WorkspaceSnapshotTrigger string
+
+const (
+	WorkspaceSnapshotTriggerStop     WorkspaceSnapshotTrigger = "stop"
+	WorkspaceSnapshotTriggerSchedule WorkspaceSnapshotTrigger = "schedule"
+)
+
+// WorkspaceSnapshotStorage is the backend that holds the snapshot archive.
+type WorkspaceSnapshotStorage string
+
+const (
+	WorkspaceSnapshotStorageDatabase WorkspaceSnapshotStorage = "database"
+	WorkspaceSnapshotStorageS3       WorkspaceSnapshotStorage = "s3"
+)
+
+// WorkspaceSnapshot is a compressed archive of directories in a workspace,
+// taken by the workspace agent.
+type WorkspaceSnapshot struct {
+	ID          uuid.UUID `json:"id" format:"uuid"`
+	WorkspaceID uuid.UUID `json:"workspace_id" format:"uuid"`
+	AgentName   string    `json:"agent_name"`
+	BuildNumber int32     `json:"build_number"`
+	// ParentID is set when the snapshot only contains the changes since
+	// the parent snapshot. Restoring it also restores every ancestor.
+	ParentID  *uuid.UUID               `json:"parent_id,omitempty" format:"uuid"`
+	Trigger   WorkspaceSnapshotTrigger `json:"trigger" enums:"stop,schedule"`
+	Storage   WorkspaceSnapshotStorage `json:"storage" enums:"database,s3"`
+	SizeBytes int64                    `json:"size_bytes"`
+	// FileCount is the number of files in the snapshotted directories,
+	// including files unchanged since the parent snapshot.
+	FileCount int32     `json:"file_count"`
+	Paths     []string  `json:"paths"`
+	CreatedAt time.Time `json:"created_at" format:"date-time"`
+}
+
+// WorkspaceSnapshotRestore is a pending restore of a snapshot. The agent
+// restores the snapshot when it starts in the first build after
+// AfterBuildNumber.
+type WorkspaceSnapshotRestore struct {
+	WorkspaceID      uuid.UUID `json:"workspace_id" format:"uuid"`
+	SnapshotID       uuid.UUID `json:"snapshot_id" format:"uuid"`
+	AfterBuildNumber int32     `json:"after_build_number"`
+	RequestedBy      uuid.UUID `json:"requested_by" format:"uuid"`
+	RequestedAt      time.Time `json:"requested_at" format:"date-time"`
+}
+
+// WorkspaceSnapshots returns the snapshots of a workspace, newest first.
+func (c *Client) WorkspaceSnapshots(ctx context.Context, workspaceID uuid.UUID) ([]WorkspaceSnapshot, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/workspaces/%s/snapshots", workspaceID), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, ReadBodyAsError(res)
+	}
+	var snapshots []WorkspaceSnapshot
+	return snapshots, json.NewDecoder(res.Body).Decode(&snapshots)
+}
+
+// RestoreWorkspaceSnapshot schedules a snapshot to be restored by the agent
+// of the next workspace build. It does not start a build.
+func (c *Client) RestoreWorkspaceSnapshot(ctx context.Context, workspaceID, snapshotID uuid.UUID) (WorkspaceSnapshotRestore, error) {
+	res, err := c.Request(ctx, http.MethodPost, fmt.Sprintf("/api/v2/workspaces/%s/snapshots/%s/restore", workspaceID, snapshotID), nil)
+	if err != nil {
+		return WorkspaceSnapshotRestore{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusCreated {
+		return WorkspaceSnapshotRestore{}, ReadBodyAsError(res)
+	}
+	var restore WorkspaceSnapshotRestore
+	return restore, json.NewDecoder(res.Body).Decode(&restore)
+}
+
+// CancelWorkspaceSnapshotRestore cancels the pending snapshot restore of a
+// workspace, if any.
+func (c *Client) CancelWorkspaceSnapshotRestore(ctx context.Context, workspaceID uuid.UUID) error {
+	res, err := c.Request(ctx, http.MethodDelete, fmt.Sprintf("/api/v2/workspaces/%s/snapshots/restore", workspaceID), nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusNoContent {
+		return ReadBodyAsError(res)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package codersdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WorkspaceResourceChangeAction is what updating a workspace does to one of
+// its resources.
+type WorkspaceResourceChangeAction string
+
+const (
+	WorkspaceResourceChangeActionCreate  WorkspaceResourceChangeAction = "create"
+	WorkspaceResourceChangeActionUpdate  WorkspaceResourceChangeAction = "update"
+	WorkspaceResourceChangeActionReplace WorkspaceResourceChangeAction = "replace"
+	WorkspaceResourceChangeActionDelete  WorkspaceResourceChangeAction = "delete"
+)
+
+// WorkspaceResourceChange is a resource that updating a workspace changes.
+type WorkspaceResourceChange struct {
+	Address    string                        `json:"address"`
+	Type       string                        `json:"type"`
+	Name       string                        `json:"name"`
+	ModulePath string                        `json:"module_path,omitempty"`
+	Action     WorkspaceResourceChangeAction `json:"action" enums:"create,update,replace,delete"`
+	// ReplacePaths are the attributes whose change forces the resource to be
+	// replaced.
+	ReplacePaths []string `json:"replace_paths,omitempty"`
+	// Persistent is true for resources that outlive a stop of the workspace,
+	// like the volume of a home directory. Replacing or deleting them loses
+	// their data.
+	Persistent bool `json:"persistent"`
+}
+
+// Destructive reports whether the change destroys data of a persistent
+// resource.
+func (c WorkspaceResourceChange) Destructive() bool {
+	return c.Persistent && (c.Action == WorkspaceResourceChangeActionReplace || c.Action == WorkspaceResourceChangeActionDelete)
+}
+
+// WorkspaceUpdatePreview is the outcome of a template version dry-run planned
+// against the state of a workspace.
+type WorkspaceUpdatePreview struct {
+	WorkspaceID uuid.UUID  `json:"workspace_id" format:"uuid"`
+	CreatedAt   time.Time  `json:"created_at" format:"date-time"`
+	CompletedAt *time.Time `json:"completed_at,omitempty" format:"date-time"`
+	// Changes are empty until the dry-run completes.
+	Changes []WorkspaceResourceChange `json:"changes"`
+}
+
+// TemplateVersionDryRunResourceChanges returns the resource changes of a
+// template version dry-run that previewed a workspace update.
+func (c *Client) TemplateVersionDryRunResourceChanges(ctx context.Context, version, job uuid.UUID) (WorkspaceUpdatePreview, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/templateversions/%s/dry-run/%s/resource-changes", version, job), nil)
+	if err != nil {
+		return WorkspaceUpdatePreview{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return WorkspaceUpdatePreview{}, ReadBodyAsError(res)
+	}
+	var preview WorkspaceUpdatePreview
+	return preview, json.NewDecoder(res.Body).Decode(&preview)
+}
@@ -0,0 +1,203 @@
+package backends
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/v2/buildinfo"
+)
+
+const (
+	otlpInterval = 5 * time.Second
+	otlpTimeout  = 30 * time.Second
+
+	// Severity numbers of the OpenTelemetry log data model.
+	otlpSeverityInfo = 9
+	otlpSeverityWarn = 13
+)
+
+// OTLPOptions configure an OpenTelemetry logs StreamSink.
+type OTLPOptions struct {
+	// Endpoint is the OTLP/HTTP logs endpoint, e.g.
+	// http://collector:4318/v1/logs.
+	Endpoint *url.URL
+	// Headers are sent with every request.
+	Headers    http.Header
+	HTTPClient *http.Client
+}
+
+type otlpSink struct {
+	opts OTLPOptions
+}
+
+// NewOTLP returns a StreamSink which exports audit logs as OpenTelemetry log
+// records over OTLP/HTTP, using the JSON encoding.
+func NewOTLP(opts OTLPOptions) (StreamSink, error) {
+	if opts.Endpoint == nil || opts.Endpoint.Host == "" {
+		return nil, xerrors.New("endpoint is required")
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{Timeout: otlpTimeout}
+	}
+	return &otlpSink{opts: opts}, nil
+}
+
+// ParseOTLPHeaders parses headers formatted as key=value pairs.
+func ParseOTLPHeaders(pairs []string) (http.Header, error) {
+	headers := http.Header{}
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, xerrors.Errorf("invalid header %q, expected key=value", pair)
+		}
+		headers.Add(strings.TrimSpace(key), strings.TrimSpace(value))
+	}
+	return headers, nil
+}
+
+func (*otlpSink) Name() string {
+	return "otlp"
+}
+
+func (*otlpSink) Interval() time.Duration {
+	return otlpInterval
+}
+
+func (s *otlpSink) Send(ctx context.Context, events []StreamEvent) error {
+	records := make([]otlpLogRecord, 0, len(events))
+	for _, event := range events {
+		record, err := otlpRecord(event)
+		if err != nil {
+			return err
+		}
+		records = append(records, record)
+	}
+	body, err := json.Marshal(otlpExportLogsRequest{
+		ResourceLogs: []otlpResourceLogs{{
+			Resource: otlpResource{Attributes: []otlpKeyValue{
+				otlpString("service.name", "coderd"),
+				otlpString("service.version", buildinfo.Version()),
+			}},
+			ScopeLogs: []otlpScopeLogs{{
+				Scope:      otlpScope{Name: "github.com/coder/coder/v2/enterprise/audit"},
+				LogRecords: records,
+			}},
+		}},
+	})
+	if err != nil {
+		return xerrors.Errorf("marshal logs: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.opts.Endpoint.String(), bytes.NewReader(body))
+	if err != nil {
+		return xerrors.Errorf("create request: %w", err)
+	}
+	for key, values := range s.opts.Headers {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := s.opts.HTTPClient.Do(req)
+	if err != nil {
+		return xerrors.Errorf("export logs: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 4<<10))
+		return xerrors.Errorf("export logs: unexpected status %s: %s", res.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+func (*otlpSink) Close() error {
+	return nil
+}
+
+func otlpRecord(event StreamEvent) (otlpLogRecord, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return otlpLogRecord{}, xerrors.Errorf("marshal audit log: %w", err)
+	}
+	record := otlpLogRecord{
+		TimeUnixNano:   strconv.FormatInt(event.Time.UnixNano(), 10),
+		SeverityNumber: otlpSeverityInfo,
+		SeverityText:   "INFO",
+		Body:           otlpAnyValue{StringValue: string(data)},
+		Attributes: []otlpKeyValue{
+			otlpString("event.name", "coder.audit_log"),
+			otlpString("coder.audit.id", event.ID.String()),
+			otlpString("coder.audit.action", event.Action),
+			otlpString("coder.audit.resource_type", event.ResourceType),
+			otlpString("coder.audit.resource_id", event.ResourceID.String()),
+			otlpString("coder.audit.resource_target", event.ResourceTarget),
+			otlpString("coder.audit.organization_id", event.OrganizationID.String()),
+			otlpString("enduser.id", event.UserID.String()),
+			otlpString("http.response.status_code", strconv.Itoa(int(event.StatusCode))),
+		},
+	}
+	if event.StatusCode >= 400 {
+		record.SeverityNumber = otlpSeverityWarn
+		record.SeverityText = "WARN"
+	}
+	if event.Username != "" {
+		record.Attributes = append(record.Attributes, otlpString("coder.audit.username", event.Username))
+	}
+	if event.IP != "" {
+		record.Attributes = append(record.Attributes, otlpString("client.address", event.IP))
+	}
+	return record, nil
+}
+
+func otlpString(key, value string) otlpKeyValue {
+	return otlpKeyValue{Key: key, Value: otlpAnyValue{StringValue: value}}
+}
+
+// The types below are the subset of the OTLP logs protocol used by the sink,
+// in its JSON encoding.
+
+type otlpExportLogsRequest struct {
+	ResourceLogs []otlpResourceLogs `json:"resourceLogs"`
+}
+
+type otlpResourceLogs struct {
+	Resource  otlpResource    `json:"resource"`
+	ScopeLogs []otlpScopeLogs `json:"scopeLogs"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeLogs struct {
+	Scope      otlpScope       `json:"scope"`
+	LogRecords []otlpLogRecord `json:"logRecords"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpLogRecord struct {
+	TimeUnixNano   string         `json:"timeUnixNano"`
+	SeverityNumber int            `json:"severityNumber"`
+	SeverityText   string         `json:"severityText"`
+	Body           otlpAnyValue   `json:"body"`
+	Attributes     []otlpKeyValue `json:"attributes"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue string `json:"stringValue"`
+}
@@ -0,0 +1,90 @@
+package backends_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/enterprise/audit/backends"
+	"github.com/coder/coder/v2/testutil"
+)
+
+func TestOTLP(t *testing.T) {
+	t.Parallel()
+
+	type logRecord struct {
+		TimeUnixNano   string `json:"timeUnixNano"`
+		SeverityNumber int    `json:"severityNumber"`
+		Body           struct {
+			StringValue string `json:"stringValue"`
+		} `json:"body"`
+	}
+	type exportRequest struct {
+		ResourceLogs []struct {
+			ScopeLogs []struct {
+				LogRecords []logRecord `json:"logRecords"`
+			} `json:"scopeLogs"`
+		} `json:"resourceLogs"`
+	}
+
+	ctx := testutil.Context(t, testutil.WaitShort)
+	requests := make(chan exportRequest, 1)
+	var fail atomic.Bool
+	fail.Store(true)
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(rw, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if fail.Load() {
+			http.Error(rw, "overloaded", http.StatusServiceUnavailable)
+			return
+		}
+		var req exportRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		requests <- req
+		_, _ = rw.Write([]byte("{}"))
+	}))
+	defer srv.Close()
+
+	endpoint, err := url.Parse(srv.URL + "/v1/logs")
+	require.NoError(t, err)
+	headers, err := backends.ParseOTLPHeaders([]string{"Authorization=Bearer secret"})
+	require.NoError(t, err)
+	sink, err := backends.NewOTLP(backends.OTLPOptions{Endpoint: endpoint, Headers: headers})
+	require.NoError(t, err)
+	defer sink.Close()
+
+	events := []backends.StreamEvent{
+		streamEventFixture("create", 201),
+		streamEventFixture("delete", 403),
+	}
+	// Rejected exports are reported, so they are retried.
+	err = sink.Send(ctx, events)
+	require.ErrorContains(t, err, "overloaded")
+
+	fail.Store(false)
+	err = sink.Send(ctx, events)
+	require.NoError(t, err)
+
+	req := testutil.TryReceive(ctx, t, requests)
+	require.Len(t, req.ResourceLogs, 1)
+	require.Len(t, req.ResourceLogs[0].ScopeLogs, 1)
+	records := req.ResourceLogs[0].ScopeLogs[0].LogRecords
+	require.Len(t, records, 2)
+	require.Equal(t, "1704164645000000000", records[0].TimeUnixNano)
+	require.Equal(t, 9, records[0].SeverityNumber)
+	require.Equal(t, 13, records[1].SeverityNumber)
+	var event backends.StreamEvent
+	err = json.Unmarshal([]byte(records[0].Body.StringValue), &event)
+	require.NoError(t, err)
+	require.Equal(t, events[0].ID, event.ID)
+}
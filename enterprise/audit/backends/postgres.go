@@ -30,8 +30,10 @@ func (b *postgresBackend) Decision() audit.FilterDecision {
 	return audit.FilterDecisionExport
 }
 
+// Export inserts the audit log. It is appended to the hash chain in the
+// background, see audit.NewChainer.
 func (b *postgresBackend) Export(ctx context.Context, alog database.AuditLog, _ audit.BackendDetails) error {
-	_, err := b.db.InsertAuditLog(ctx, database.InsertAuditLogParams(alog))
+	_, err := b.db.InsertAuditLog(ctx, alog.InsertAuditLogParams())
 	if err != nil {
 		return xerrors.Errorf("insert audit log: %w", err)
 	}
@@ -0,0 +1,170 @@
+package backends
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"golang.org/x/xerrors"
+)
+
+// S3Options configure an S3 compatible StreamSink.
+type S3Options struct {
+	Bucket string
+	Region string
+	// Endpoint is the URL of an S3 compatible service, e.g. MinIO. Objects
+	// are addressed with path-style URLs when set. Amazon S3 is used with
+	// virtual-hosted-style URLs otherwise.
+	Endpoint *url.URL
+	// Prefix is prepended to object keys.
+	Prefix string
+	// AccessKeyID and SecretAccessKey are optional. The default AWS
+	// credential chain is used when they are empty.
+	AccessKeyID     string
+	SecretAccessKey string
+	// Interval is how often a batch of audit logs is written.
+	Interval   time.Duration
+	HTTPClient *http.Client
+}
+
+type s3Sink struct {
+	opts        S3Options
+	credentials aws.CredentialsProvider
+	signer      *v4.Signer
+}
+
+// NewS3 returns a StreamSink which writes batches of audit logs to an S3
+// compatible bucket as newline-delimited JSON objects. Objects are keyed by
+// the date and the first audit log of the batch, so a retried batch replaces
+// the object of the failed attempt.
+func NewS3(ctx context.Context, opts S3Options) (StreamSink, error) {
+	if opts.Bucket == "" {
+		return nil, xerrors.New("bucket is required")
+	}
+	if opts.Region == "" {
+		return nil, xerrors.New("region is required")
+	}
+	if opts.Interval <= 0 {
+		return nil, xerrors.New("interval must be positive")
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = http.DefaultClient
+	}
+
+	var credentials aws.CredentialsProvider
+	if opts.AccessKeyID != "" || opts.SecretAccessKey != "" {
+		static := aws.Credentials{
+			AccessKeyID:     opts.AccessKeyID,
+			SecretAccessKey: opts.SecretAccessKey,
+			Source:          "CoderAuditLogStreamingS3Options",
+		}
+		credentials = aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+			return static, nil
+		})
+	} else {
+		cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(opts.Region))
+		if err != nil {
+			return nil, xerrors.Errorf("load AWS config: %w", err)
+		}
+		if cfg.Credentials == nil {
+			return nil, xerrors.New("no AWS credentials found")
+		}
+		credentials = cfg.Credentials
+	}
+
+	return &s3Sink{
+		opts:        opts,
+		credentials: credentials,
+		signer:      v4.NewSigner(),
+	}, nil
+}
+
+func (*s3Sink) Name() string {
+	return "s3"
+}
+
+func (s *s3Sink) Interval() time.Duration {
+	return s.opts.Interval
+}
+
+func (s *s3Sink) Send(ctx context.Context, events []StreamEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, event := range events {
+		if err := enc.Encode(event); err != nil {
+			return xerrors.Errorf("marshal audit log: %w", err)
+		}
+	}
+
+	first := events[0]
+	key := path.Join(
+		strings.Trim(s.opts.Prefix, "/"),
+		first.Time.Format("2006/01/02"),
+		fmt.Sprintf("%s-%s.ndjson", first.Time.Format("20060102T150405.000000000Z"), first.ID),
+	)
+	if err := s.put(ctx, key, body.Bytes()); err != nil {
+		return xerrors.Errorf("put object %q: %w", key, err)
+	}
+	return nil
+}
+
+func (*s3Sink) Close() error {
+	return nil
+}
+
+func (s *s3Sink) put(ctx context.Context, key string, data []byte) error {
+	var u url.URL
+	if s.opts.Endpoint != nil {
+		u = *s.opts.Endpoint
+		u.Path = path.Join("/", u.Path, s.opts.Bucket, key)
+	} else {
+		u = url.URL{
+			Scheme: "https",
+			Host:   fmt.Sprintf("%s.s3.%s.amazonaws.com", s.opts.Bucket, s.opts.Region),
+			Path:   "/" + key,
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.String(), bytes.NewReader(data))
+	if err != nil {
+		return xerrors.Errorf("create request: %w", err)
+	}
+	hash := sha256.Sum256(data)
+	payloadHash := hex.EncodeToString(hash[:])
+	req.ContentLength = int64(len(data))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	creds, err := s.credentials.Retrieve(ctx)
+	if err != nil {
+		return xerrors.Errorf("retrieve credentials: %w", err)
+	}
+	err = s.signer.SignHTTP(ctx, creds, req, payloadHash, "s3", s.opts.Region, time.Now())
+	if err != nil {
+		return xerrors.Errorf("sign request: %w", err)
+	}
+	res, err := s.opts.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 4<<10))
+		return xerrors.Errorf("unexpected status %s: %s", res.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
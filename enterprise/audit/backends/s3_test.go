@@ -0,0 +1,94 @@
+package backends_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/enterprise/audit/backends"
+	"github.com/coder/coder/v2/testutil"
+)
+
+func TestS3(t *testing.T) {
+	t.Parallel()
+
+	// A MinIO-style server, which addresses objects with path-style URLs.
+	var (
+		mu      sync.Mutex
+		objects = map[string][]byte{}
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=access/") {
+			http.Error(rw, "unsigned request", http.StatusForbidden)
+			return
+		}
+		if r.Method != http.MethodPut {
+			http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		objects[r.URL.Path] = data
+	}))
+	defer srv.Close()
+
+	ctx := testutil.Context(t, testutil.WaitShort)
+	endpoint, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+	sink, err := backends.NewS3(ctx, backends.S3Options{
+		Bucket:          "audit",
+		Region:          "us-east-1",
+		Endpoint:        endpoint,
+		Prefix:          "/coder/",
+		AccessKeyID:     "access",
+		SecretAccessKey: "secret",
+		Interval:        time.Minute,
+	})
+	require.NoError(t, err)
+	defer sink.Close()
+	require.Equal(t, time.Minute, sink.Interval())
+
+	events := []backends.StreamEvent{
+		streamEventFixture("create", 201),
+		streamEventFixture("delete", 200),
+	}
+	err = sink.Send(ctx, events)
+	require.NoError(t, err)
+	// A retried batch replaces the object of the previous attempt.
+	err = sink.Send(ctx, events)
+	require.NoError(t, err)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, objects, 1)
+	key := "/audit/coder/2024/01/02/20240102T030405.000000000Z-" + events[0].ID.String() + ".ndjson"
+	data, ok := objects[key]
+	require.True(t, ok, "object %q not found", key)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	var got []backends.StreamEvent
+	for scanner.Scan() {
+		var event backends.StreamEvent
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+		got = append(got, event)
+	}
+	require.NoError(t, scanner.Err())
+	require.Len(t, got, 2)
+	require.Equal(t, events[0].ID, got[0].ID)
+	require.Equal(t, events[1].ID, got[1].ID)
+}
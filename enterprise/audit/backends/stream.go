@@ -0,0 +1,242 @@
+package backends
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"github.com/coder/quartz"
+
+	"github.com/coder/coder/v2/coderd/database"
+	"github.com/coder/coder/v2/coderd/database/dbauthz"
+	"github.com/coder/coder/v2/coderd/database/dbtime"
+)
+
+const (
+	// streamBatchSize is the maximum number of audit logs sent to a sink at
+	// once.
+	streamBatchSize = 500
+
+	// streamLeaseDuration is how long a replica owns a sink after it last
+	// advanced the cursor. Another replica takes over once it expires.
+	streamLeaseDuration = time.Minute
+
+	// streamSendTimeout bounds a single delivery to a sink, so the lease
+	// can't expire while a batch is in flight.
+	streamSendTimeout = 30 * time.Second
+)
+
+// StreamEvent is an audit log as it is delivered to streaming sinks.
+type StreamEvent struct {
+	ID               uuid.UUID       `json:"id"`
+	Time             time.Time       `json:"time"`
+	OrganizationID   uuid.UUID       `json:"organization_id"`
+	OrganizationName string          `json:"organization_name,omitempty"`
+	UserID           uuid.UUID       `json:"user_id"`
+	Username         string          `json:"username,omitempty"`
+	UserEmail        string          `json:"user_email,omitempty"`
+	IP               string          `json:"ip,omitempty"`
+	UserAgent        string          `json:"user_agent,omitempty"`
+	ResourceType     string          `json:"resource_type"`
+	ResourceID       uuid.UUID       `json:"resource_id"`
+	ResourceTarget   string          `json:"resource_target"`
+	Action           string          `json:"action"`
+	Diff             json.RawMessage `json:"diff,omitempty"`
+	StatusCode       int32           `json:"status_code"`
+	AdditionalFields json.RawMessage `json:"additional_fields,omitempty"`
+	RequestID        uuid.UUID       `json:"request_id"`
+}
+
+func streamEvent(row database.GetAuditLogsForExportRow) StreamEvent {
+	alog := row.AuditLog
+	event := StreamEvent{
+		ID:               alog.ID,
+		Time:             alog.Time.UTC(),
+		OrganizationID:   alog.OrganizationID,
+		OrganizationName: row.OrganizationName,
+		UserID:           alog.UserID,
+		Username:         row.UserUsername,
+		UserEmail:        row.UserEmail,
+		UserAgent:        alog.UserAgent.String,
+		ResourceType:     string(alog.ResourceType),
+		ResourceID:       alog.ResourceID,
+		ResourceTarget:   alog.ResourceTarget,
+		Action:           string(alog.Action),
+		StatusCode:       alog.StatusCode,
+		RequestID:        alog.RequestID,
+	}
+	if alog.Ip.Valid {
+		event.IP = alog.Ip.IPNet.IP.String()
+	}
+	if json.Valid(alog.Diff) {
+		event.Diff = alog.Diff
+	}
+	if json.Valid(alog.AdditionalFields) {
+		event.AdditionalFields = alog.AdditionalFields
+	}
+	return event
+}
+
+// StreamSink receives the audit logs of a Streamer.
+type StreamSink interface {
+	// Name identifies the durable cursor of the sink. It must not change
+	// between restarts.
+	Name() string
+	// Interval is how often pending audit logs are sent to the sink.
+	Interval() time.Duration
+	// Send delivers audit logs, oldest first. The audit logs are sent again
+	// when an error is returned, so sinks must tolerate duplicates.
+	Send(ctx context.Context, events []StreamEvent) error
+	io.Closer
+}
+
+// NewStreamer delivers the audit logs stored in the database to the sinks.
+// Delivery is at-least-once: the position of each sink is stored in the
+// database once the sink accepted the audit logs, so they are retried after a
+// failure or a restart. A sink starts with the audit logs created after it was
+// first configured.
+//
+// Audit logs are delivered in the order of the transactions which inserted
+// them, rather than by time, so an audit log committed late is never skipped.
+// Only one replica delivers to a sink at a time: it holds a lease on the
+// cursor of the sink, which is extended every time the cursor advances.
+func NewStreamer(ctx context.Context, logger slog.Logger, db database.Store, clk quartz.Clock, sinks ...StreamSink) io.Closer {
+	ctx, cancelFunc := context.WithCancel(ctx)
+	//nolint:gocritic // The system streams the audit logs of every organization.
+	ctx = dbauthz.AsSystemRestricted(ctx)
+
+	s := &streamer{
+		cancel: cancelFunc,
+		sinks:  sinks,
+	}
+	replicaID := uuid.New()
+	for _, sink := range sinks {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			stream(ctx, logger.With(slog.F("sink", sink.Name())), db, clk, replicaID, sink)
+		}()
+	}
+	return s
+}
+
+type streamer struct {
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	sinks  []StreamSink
+}
+
+func (s *streamer) Close() error {
+	s.cancel()
+	s.wg.Wait()
+	var errs []error
+	for _, sink := range s.sinks {
+		if err := sink.Close(); err != nil {
+			errs = append(errs, xerrors.Errorf("close %s: %w", sink.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func stream(ctx context.Context, logger slog.Logger, db database.Store, clk quartz.Clock, replicaID uuid.UUID, sink StreamSink) {
+	interval := sink.Interval()
+	tags := []string{"audit", "stream", sink.Name()}
+	ticker := clk.NewTicker(interval, tags...)
+	defer ticker.Stop()
+	ticker.Stop()
+	doTick := func(now time.Time) {
+		defer ticker.Reset(interval, tags...)
+		if err := deliver(ctx, db, clk, replicaID, sink, now); err != nil {
+			logger.Warn(ctx, "failed to stream audit logs, they will be retried", slog.Error(err))
+		}
+	}
+
+	// Force an initial tick.
+	doTick(dbtime.Time(clk.Now()).UTC())
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Debug(ctx, "closing audit log stream")
+			return
+		case tick := <-ticker.C:
+			ticker.Stop()
+
+			doTick(dbtime.Time(tick).UTC())
+		}
+	}
+}
+
+// deliver sends the audit logs after the cursor of the sink, in batches, if
+// the replica holds the lease on the cursor. The cursor advances after every
+// accepted batch. No transaction is held while the sink is sending.
+func deliver(ctx context.Context, db database.Store, clk quartz.Clock, replicaID uuid.UUID, sink StreamSink, now time.Time) error {
+	cursor, err := db.AcquireAuditLogExportCursor(ctx, database.AcquireAuditLogExportCursorParams{
+		Sink:           sink.Name(),
+		Now:            now,
+		LeasedBy:       replicaID,
+		LeaseExpiresAt: now.Add(streamLeaseDuration),
+	})
+	if xerrors.Is(err, sql.ErrNoRows) {
+		// Another replica delivers to the sink.
+		return nil
+	}
+	if err != nil {
+		return xerrors.Errorf("acquire cursor: %w", err)
+	}
+
+	for {
+		rows, err := db.GetAuditLogsForExport(ctx, database.GetAuditLogsForExportParams{
+			AfterXactID: cursor.LastXactID,
+			AfterID:     cursor.LastID,
+			LimitOpt:    streamBatchSize,
+		})
+		if err != nil {
+			return xerrors.Errorf("get audit logs: %w", err)
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+
+		events := make([]StreamEvent, 0, len(rows))
+		for _, row := range rows {
+			events = append(events, streamEvent(row))
+		}
+		sendCtx, cancel := context.WithTimeout(ctx, streamSendTimeout)
+		err = sink.Send(sendCtx, events)
+		cancel()
+		if err != nil {
+			return xerrors.Errorf("send: %w", err)
+		}
+
+		last := rows[len(rows)-1].AuditLog
+		cursor.LastXactID, cursor.LastID = last.XactID.Int64, last.ID
+		updatedAt := dbtime.Time(clk.Now()).UTC()
+		updated, err := db.UpdateAuditLogExportCursor(ctx, database.UpdateAuditLogExportCursorParams{
+			LastXactID:     cursor.LastXactID,
+			LastID:         cursor.LastID,
+			UpdatedAt:      updatedAt,
+			LeaseExpiresAt: updatedAt.Add(streamLeaseDuration),
+			Sink:           sink.Name(),
+			LeasedBy:       replicaID,
+		})
+		if err != nil {
+			return xerrors.Errorf("update cursor: %w", err)
+		}
+		if updated == 0 {
+			// The lease expired and another replica took over. It sends
+			// the batch again.
+			return xerrors.New("lost the lease on the cursor")
+		}
+		if len(rows) < streamBatchSize {
+			return nil
+		}
+	}
+}
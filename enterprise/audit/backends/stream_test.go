@@ -0,0 +1,176 @@
+package backends_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog/sloggers/slogtest"
+	"github.com/coder/quartz"
+
+	"github.com/coder/coder/v2/coderd/database"
+	"github.com/coder/coder/v2/coderd/database/dbgen"
+	"github.com/coder/coder/v2/coderd/database/dbmem"
+	"github.com/coder/coder/v2/coderd/database/dbtime"
+	"github.com/coder/coder/v2/enterprise/audit/backends"
+	"github.com/coder/coder/v2/testutil"
+)
+
+func TestStreamer(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Context(t, testutil.WaitShort)
+	logger := slogtest.Make(t, &slogtest.Options{IgnoreErrors: true})
+	db := dbmem.New()
+	clk := quartz.NewMock(t)
+	sink := &fakeStreamSink{}
+
+	// An audit log created before the sink was configured is not streamed.
+	_ = dbgen.AuditLog(t, db, database.AuditLog{Time: dbtime.Time(clk.Now()).Add(-time.Hour)})
+
+	resetTrap := clk.Trap().TickerReset("audit", "stream", sink.Name())
+	defer resetTrap.Close()
+	streamer := backends.NewStreamer(ctx, logger, db, clk, sink)
+	defer streamer.Close()
+	resetTrap.MustWait(ctx).MustRelease(ctx)
+
+	user := dbgen.User(t, db, database.User{Username: "alice"})
+	first := dbgen.AuditLog(t, db, database.AuditLog{Time: dbtime.Time(clk.Now()), UserID: user.ID})
+	second := dbgen.AuditLog(t, db, database.AuditLog{Time: dbtime.Time(clk.Now()).Add(time.Millisecond)})
+	tick := func() {
+		clk.Advance(sink.Interval()).MustWait(ctx)
+		resetTrap.MustWait(ctx).MustRelease(ctx)
+	}
+
+	// When: the sink is unavailable
+	sink.setErr(xerrors.New("unavailable"))
+	tick()
+
+	// Then: the audit logs are not acknowledged
+	require.Equal(t, 1, sink.attempts())
+	require.Empty(t, sink.delivered())
+	cursor, err := db.GetAuditLogExportCursor(ctx, sink.Name())
+	require.NoError(t, err)
+	require.Equal(t, uuid.Nil, cursor.LastID)
+
+	// When: the sink is available again
+	sink.setErr(nil)
+	tick()
+
+	// Then: the audit logs are delivered in order
+	events := sink.delivered()
+	require.Len(t, events, 2)
+	require.Equal(t, first.ID, events[0].ID)
+	require.Equal(t, "alice", events[0].Username)
+	require.Equal(t, second.ID, events[1].ID)
+	cursor, err = db.GetAuditLogExportCursor(ctx, sink.Name())
+	require.NoError(t, err)
+	require.Equal(t, second.ID, cursor.LastID)
+
+	// When: there are no new audit logs
+	tick()
+
+	// Then: nothing is sent again
+	require.Equal(t, 2, sink.attempts())
+
+	// When: an audit log timestamped before the delivered ones is inserted
+	late := dbgen.AuditLog(t, db, database.AuditLog{Time: dbtime.Time(clk.Now()).Add(-time.Minute)})
+	tick()
+
+	// Then: it is delivered all the same
+	events = sink.delivered()
+	require.Len(t, events, 3)
+	require.Equal(t, late.ID, events[2].ID)
+}
+
+func TestStreamerLease(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Context(t, testutil.WaitShort)
+	logger := slogtest.Make(t, &slogtest.Options{IgnoreErrors: true})
+	db := dbmem.New()
+	clk := quartz.NewMock(t)
+	sink := &fakeStreamSink{}
+
+	// Given: another replica holds the lease on the cursor of the sink
+	now := dbtime.Time(clk.Now()).UTC()
+	_, err := db.AcquireAuditLogExportCursor(ctx, database.AcquireAuditLogExportCursorParams{
+		Sink:           sink.Name(),
+		Now:            now,
+		LeasedBy:       uuid.New(),
+		LeaseExpiresAt: now.Add(time.Minute),
+	})
+	require.NoError(t, err)
+	_ = dbgen.AuditLog(t, db, database.AuditLog{Time: now})
+
+	resetTrap := clk.Trap().TickerReset("audit", "stream", sink.Name())
+	defer resetTrap.Close()
+	streamer := backends.NewStreamer(ctx, logger, db, clk, sink)
+	defer streamer.Close()
+	resetTrap.MustWait(ctx).MustRelease(ctx)
+
+	// Then: the audit log is not sent
+	require.Zero(t, sink.attempts())
+
+	// When: the lease expires
+	for range 13 {
+		clk.Advance(sink.Interval()).MustWait(ctx)
+		resetTrap.MustWait(ctx).MustRelease(ctx)
+	}
+
+	// Then: the replica takes over the sink
+	require.Len(t, sink.delivered(), 1)
+}
+
+type fakeStreamSink struct {
+	mu     sync.Mutex
+	err    error
+	tries  int
+	events []backends.StreamEvent
+}
+
+func (*fakeStreamSink) Name() string {
+	return "fake"
+}
+
+func (*fakeStreamSink) Interval() time.Duration {
+	return 5 * time.Second
+}
+
+func (s *fakeStreamSink) Send(_ context.Context, events []backends.StreamEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tries++
+	if s.err != nil {
+		return s.err
+	}
+	s.events = append(s.events, events...)
+	return nil
+}
+
+func (*fakeStreamSink) Close() error {
+	return nil
+}
+
+func (s *fakeStreamSink) setErr(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+func (s *fakeStreamSink) attempts() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tries
+}
+
+func (s *fakeStreamSink) delivered() []backends.StreamEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]backends.StreamEvent(nil), s.events...)
+}
@@ -0,0 +1,218 @@
+package backends
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+const (
+	// syslogFacilityLogAudit is the "log audit" facility of RFC 5424.
+	syslogFacilityLogAudit = 13
+	syslogSeverityWarning  = 4
+	syslogSeverityNotice   = 5
+
+	syslogAppName = "coder"
+	// syslogInterval is short, as syslog is meant to receive audit logs as
+	// they happen.
+	syslogInterval = 5 * time.Second
+	syslogTimeout  = 10 * time.Second
+)
+
+// SyslogOptions configure a syslog StreamSink.
+type SyslogOptions struct {
+	// Address is the URL of the syslog server. The tls and tcp schemes frame
+	// messages with octet counting (RFC 5425 and RFC 6587). The udp scheme
+	// sends one message per datagram.
+	Address *url.URL
+	// TLSConfig is used with the tls scheme.
+	TLSConfig *tls.Config
+	// Hostname is the HOSTNAME of messages. The hostname of the machine is
+	// used when empty.
+	Hostname string
+}
+
+// SyslogTLSConfig loads the certificate authorities and the client
+// certificate of a syslog server from PEM files. All files are optional.
+func SyslogTLSConfig(caFile, certFile, keyFile string) (*tls.Config, error) {
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+	if caFile != "" {
+		data, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, xerrors.Errorf("read CA file: %w", err)
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(data) {
+			return nil, xerrors.Errorf("no certificates found in CA file %q", caFile)
+		}
+	}
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, xerrors.Errorf("load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+type syslogSink struct {
+	opts SyslogOptions
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// NewSyslog returns a StreamSink which sends audit logs to a syslog server
+// as RFC 5424 messages. The message of each audit log is its StreamEvent as
+// JSON.
+func NewSyslog(opts SyslogOptions) (StreamSink, error) {
+	if opts.Address == nil || opts.Address.Host == "" {
+		return nil, xerrors.New("address is required")
+	}
+	switch opts.Address.Scheme {
+	case "tls", "tcp", "udp":
+	default:
+		return nil, xerrors.Errorf("unsupported scheme %q, use tls, tcp or udp", opts.Address.Scheme)
+	}
+	if opts.Hostname == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, xerrors.Errorf("get hostname: %w", err)
+		}
+		opts.Hostname = hostname
+	}
+	return &syslogSink{opts: opts}, nil
+}
+
+func (*syslogSink) Name() string {
+	return "syslog"
+}
+
+func (*syslogSink) Interval() time.Duration {
+	return syslogInterval
+}
+
+func (s *syslogSink) Send(ctx context.Context, events []StreamEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		conn, err := s.dial(ctx)
+		if err != nil {
+			return xerrors.Errorf("dial %s: %w", s.opts.Address.Redacted(), err)
+		}
+		s.conn = conn
+	}
+
+	deadline := time.Now().Add(syslogTimeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	_ = s.conn.SetWriteDeadline(deadline)
+
+	for _, event := range events {
+		msg, err := s.message(event)
+		if err != nil {
+			return err
+		}
+		if s.opts.Address.Scheme != "udp" {
+			msg = append([]byte(fmt.Sprintf("%d ", len(msg))), msg...)
+		}
+		if _, err := s.conn.Write(msg); err != nil {
+			// Reconnect on the next attempt, the server may have closed
+			// the connection.
+			_ = s.conn.Close()
+			s.conn = nil
+			return xerrors.Errorf("write message: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *syslogSink) dial(ctx context.Context) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: syslogTimeout}
+	switch s.opts.Address.Scheme {
+	case "tls":
+		config := s.opts.TLSConfig
+		if config == nil {
+			config = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		if config.ServerName == "" {
+			config = config.Clone()
+			config.ServerName = s.opts.Address.Hostname()
+		}
+		tlsDialer := &tls.Dialer{NetDialer: dialer, Config: config}
+		return tlsDialer.DialContext(ctx, "tcp", s.opts.Address.Host)
+	default:
+		return dialer.DialContext(ctx, s.opts.Address.Scheme, s.opts.Address.Host)
+	}
+}
+
+// message formats an audit log as an RFC 5424 message.
+func (s *syslogSink) message(event StreamEvent) ([]byte, error) {
+	severity := syslogSeverityNotice
+	if event.StatusCode >= 400 {
+		severity = syslogSeverityWarning
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		return nil, xerrors.Errorf("marshal audit log: %w", err)
+	}
+
+	var msg bytes.Buffer
+	// <PRI>VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+	_, _ = fmt.Fprintf(&msg, "<%d>1 %s %s %s %d %s - ",
+		syslogFacilityLogAudit*8+severity,
+		event.Time.Format(time.RFC3339Nano),
+		syslogHeaderField(s.opts.Hostname, 255),
+		syslogAppName,
+		os.Getpid(),
+		syslogHeaderField(event.Action, 32),
+	)
+	_, _ = msg.Write(data)
+	return msg.Bytes(), nil
+}
+
+func (s *syslogSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// syslogHeaderField truncates a header field to its maximum length, and
+// replaces characters which are not printable ASCII. Empty fields are
+// written as the NILVALUE.
+func syslogHeaderField(value string, maxLen int) string {
+	if value == "" {
+		return "-"
+	}
+	field := []byte(value)
+	if len(field) > maxLen {
+		field = field[:maxLen]
+	}
+	for i, c := range field {
+		if c < 33 || c > 126 {
+			field[i] = '_'
+		}
+	}
+	return string(field)
+}
@@ -0,0 +1,142 @@
+package backends_test
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/enterprise/audit/backends"
+	"github.com/coder/coder/v2/testutil"
+)
+
+func TestSyslog(t *testing.T) {
+	t.Parallel()
+
+	t.Run("TLS", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := testutil.Context(t, testutil.WaitShort)
+		cert := testutil.GenerateTLSCertificate(t, "localhost")
+		ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+			MinVersion:   tls.VersionTLS12,
+			Certificates: []tls.Certificate{cert},
+		})
+		require.NoError(t, err)
+		defer ln.Close()
+		messages := make(chan string, 2)
+		go func() {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			reader := bufio.NewReader(conn)
+			for {
+				// Messages are framed with octet counting.
+				length, err := reader.ReadString(' ')
+				if err != nil {
+					return
+				}
+				n, err := strconv.Atoi(strings.TrimSpace(length))
+				if err != nil {
+					return
+				}
+				msg := make([]byte, n)
+				if _, err := io.ReadFull(reader, msg); err != nil {
+					return
+				}
+				messages <- string(msg)
+			}
+		}()
+
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		require.NoError(t, err)
+		roots := x509.NewCertPool()
+		roots.AddCert(leaf)
+		sink, err := backends.NewSyslog(backends.SyslogOptions{
+			Address:   &url.URL{Scheme: "tls", Host: ln.Addr().String()},
+			TLSConfig: &tls.Config{MinVersion: tls.VersionTLS12, RootCAs: roots},
+			Hostname:  "coder-0",
+		})
+		require.NoError(t, err)
+		defer sink.Close()
+
+		events := []backends.StreamEvent{
+			streamEventFixture("create", 201),
+			streamEventFixture("delete", 403),
+		}
+		err = sink.Send(ctx, events)
+		require.NoError(t, err)
+
+		msg := testutil.TryReceive(ctx, t, messages)
+		require.True(t, strings.HasPrefix(msg, "<109>1 2024-01-02T03:04:05Z coder-0 coder "), msg)
+		require.Contains(t, msg, " create - {")
+		require.Contains(t, msg, `"id":"`+events[0].ID.String()+`"`)
+		msg = testutil.TryReceive(ctx, t, messages)
+		// Failed requests have the warning severity.
+		require.True(t, strings.HasPrefix(msg, "<108>1 "), msg)
+		require.Contains(t, msg, " delete - {")
+	})
+
+	t.Run("UDP", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := testutil.Context(t, testutil.WaitShort)
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer conn.Close()
+
+		sink, err := backends.NewSyslog(backends.SyslogOptions{
+			Address:  &url.URL{Scheme: "udp", Host: conn.LocalAddr().String()},
+			Hostname: "coder-0",
+		})
+		require.NoError(t, err)
+		defer sink.Close()
+
+		event := streamEventFixture("login", 200)
+		err = sink.Send(ctx, []backends.StreamEvent{event})
+		require.NoError(t, err)
+
+		_ = conn.SetReadDeadline(time.Now().Add(testutil.WaitShort))
+		buf := make([]byte, 64<<10)
+		n, _, err := conn.ReadFrom(buf)
+		require.NoError(t, err)
+		// Datagrams are not framed.
+		require.True(t, strings.HasPrefix(string(buf[:n]), "<109>1 "), string(buf[:n]))
+		require.Contains(t, string(buf[:n]), event.ID.String())
+	})
+
+	t.Run("InvalidScheme", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := backends.NewSyslog(backends.SyslogOptions{
+			Address: &url.URL{Scheme: "http", Host: "localhost:514"},
+		})
+		require.ErrorContains(t, err, "unsupported scheme")
+	})
+}
+
+func streamEventFixture(action string, statusCode int32) backends.StreamEvent {
+	return backends.StreamEvent{
+		ID:             uuid.New(),
+		Time:           time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		UserID:         uuid.New(),
+		Username:       "alice",
+		ResourceType:   "workspace",
+		ResourceID:     uuid.New(),
+		ResourceTarget: "dev",
+		Action:         action,
+		StatusCode:     statusCode,
+		RequestID:      uuid.New(),
+	}
+}
@@ -0,0 +1,343 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/binary"
+	"encoding/json"
+	"hash"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"github.com/coder/quartz"
+
+	"github.com/coder/coder/v2/coderd/cryptokeys"
+	"github.com/coder/coder/v2/coderd/database"
+	"github.com/coder/coder/v2/coderd/database/dbauthz"
+	"github.com/coder/coder/v2/coderd/database/dbtime"
+)
+
+// CheckpointInterval is how often the head of the audit log hash chain is
+// signed.
+const CheckpointInterval = time.Hour
+
+// ChainInterval is how often the audit logs inserted since the head of the
+// hash chain are appended to it.
+const ChainInterval = 10 * time.Second
+
+// chainBatchSize is the number of audit logs read at once when appending to
+// the hash chain.
+const chainBatchSize = 1000
+
+// chainVersion prefixes the hashed representation of an audit log, so the
+// representation can be changed without ambiguity.
+const chainVersion = "coder-audit-log-v1"
+
+// Chain appends the audit logs inserted since the head of the hash chain to
+// it, in the order of the transactions which inserted them. Chaining happens
+// off the insert path, so inserting an audit log never waits for the chain.
+// Only one replica appends to the chain at a time.
+func Chain(ctx context.Context, db database.Store) error {
+	return db.InTx(func(tx database.Store) error {
+		ok, err := tx.TryAcquireLock(ctx, database.LockIDAuditLogHashChain)
+		if err != nil {
+			return xerrors.Errorf("acquire audit log hash chain lock: %w", err)
+		}
+		if !ok {
+			return nil
+		}
+
+		var (
+			sequence    int64
+			previous    = []byte{}
+			afterXactID int64
+			afterID     uuid.UUID
+		)
+		head, err := tx.GetLatestAuditLogHash(ctx)
+		if err != nil && !xerrors.Is(err, sql.ErrNoRows) {
+			return xerrors.Errorf("get latest audit log hash: %w", err)
+		}
+		if err == nil {
+			sequence, previous = head.Sequence, head.Hash
+			afterXactID, afterID = head.AuditLogXactID, head.AuditLogID
+		}
+
+		for {
+			logs, err := tx.GetAuditLogsToChain(ctx, database.GetAuditLogsToChainParams{
+				AfterXactID: afterXactID,
+				AfterID:     afterID,
+				LimitOpt:    chainBatchSize,
+			})
+			if err != nil {
+				return xerrors.Errorf("get audit logs to chain: %w", err)
+			}
+			for _, alog := range logs {
+				sum, err := HashAuditLog(previous, alog)
+				if err != nil {
+					return xerrors.Errorf("hash audit log %s: %w", alog.ID, err)
+				}
+				sequence++
+				err = tx.InsertAuditLogHash(ctx, database.InsertAuditLogHashParams{
+					Sequence:       sequence,
+					AuditLogID:     alog.ID,
+					AuditLogTime:   alog.Time,
+					AuditLogXactID: alog.XactID.Int64,
+					PreviousHash:   previous,
+					Hash:           sum,
+				})
+				if err != nil {
+					return xerrors.Errorf("insert audit log hash: %w", err)
+				}
+				previous = sum
+			}
+			if len(logs) < chainBatchSize {
+				return nil
+			}
+			last := logs[len(logs)-1]
+			afterXactID, afterID = last.XactID.Int64, last.ID
+		}
+	}, nil)
+}
+
+// HashAuditLog returns the SHA-256 of the previous hash of the chain and the
+// audit log. Every field is hashed in a representation which survives a
+// round trip through the database.
+func HashAuditLog(previous []byte, alog database.AuditLog) ([]byte, error) {
+	diff, err := canonicalJSON(alog.Diff)
+	if err != nil {
+		return nil, xerrors.Errorf("diff: %w", err)
+	}
+	additionalFields, err := canonicalJSON(alog.AdditionalFields)
+	if err != nil {
+		return nil, xerrors.Errorf("additional fields: %w", err)
+	}
+	var ip string
+	if alog.Ip.Valid {
+		ip = alog.Ip.IPNet.IP.String()
+	}
+	var userAgent string
+	if alog.UserAgent.Valid {
+		userAgent = "+" + alog.UserAgent.String
+	}
+
+	h := sha256.New()
+	for _, field := range [][]byte{
+		[]byte(chainVersion),
+		previous,
+		alog.ID[:],
+		[]byte(strconv.FormatInt(alog.Time.UnixMicro(), 10)),
+		alog.UserID[:],
+		alog.OrganizationID[:],
+		[]byte(ip),
+		[]byte(userAgent),
+		[]byte(alog.ResourceType),
+		alog.ResourceID[:],
+		[]byte(alog.ResourceTarget),
+		[]byte(alog.Action),
+		diff,
+		[]byte(strconv.FormatInt(int64(alog.StatusCode), 10)),
+		additionalFields,
+		alog.RequestID[:],
+		[]byte(alog.ResourceIcon),
+	} {
+		writeField(h, field)
+	}
+	return h.Sum(nil), nil
+}
+
+// SignCheckpoint returns the signature of the head of the hash chain at a
+// sequence.
+//
+// The HMAC key is stored in the same database as the chain, so a checkpoint
+// only protects the chain from someone who can modify the audit logs but not
+// read the crypto keys. It doesn't protect the chain from a full compromise of
+// the database.
+func SignCheckpoint(key []byte, sequence int64, sum []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	writeField(mac, []byte(chainVersion))
+	writeField(mac, []byte(strconv.FormatInt(sequence, 10)))
+	writeField(mac, sum)
+	return mac.Sum(nil)
+}
+
+// writeField writes a length-prefixed field, so adjacent fields can't be
+// shifted into one another.
+func writeField(h hash.Hash, field []byte) {
+	var length [8]byte
+	binary.BigEndian.PutUint64(length[:], uint64(len(field)))
+	_, _ = h.Write(length[:])
+	_, _ = h.Write(field)
+}
+
+// canonicalJSON re-encodes a JSON document with sorted keys and no
+// whitespace, since the database doesn't preserve either.
+func canonicalJSON(raw json.RawMessage) ([]byte, error) {
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return nil, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+// NewChainer periodically appends the audit logs inserted since the head of
+// the hash chain to it.
+func NewChainer(ctx context.Context, logger slog.Logger, db database.Store, clk quartz.Clock) io.Closer {
+	closed := make(chan struct{})
+
+	ctx, cancelFunc := context.WithCancel(ctx)
+	//nolint:gocritic // The system chains the audit logs of every organization.
+	ctx = dbauthz.AsSystemRestricted(ctx)
+
+	ticker := clk.NewTicker(ChainInterval)
+	ticker.Stop()
+	doTick := func() {
+		defer ticker.Reset(ChainInterval, "audit", "chain")
+		if err := Chain(ctx, db); err != nil {
+			logger.Error(ctx, "failed to append to audit log hash chain", slog.Error(err))
+		}
+	}
+
+	go func() {
+		defer close(closed)
+		defer ticker.Stop()
+		// Force an initial tick.
+		doTick()
+		for {
+			select {
+			case <-ctx.Done():
+				logger.Debug(ctx, "closing audit log chainer")
+				return
+			case <-ticker.C:
+				ticker.Stop()
+
+				doTick()
+			}
+		}
+	}()
+	return &routine{
+		cancel: cancelFunc,
+		closed: closed,
+	}
+}
+
+// NewCheckpointer periodically signs the head of the audit log hash chain
+// with the audit log checkpoint key. A checkpoint prevents the chain from
+// being rewritten up to it by someone without access to the key.
+func NewCheckpointer(ctx context.Context, logger slog.Logger, db database.Store, keys cryptokeys.SigningKeycache, clk quartz.Clock) io.Closer {
+	closed := make(chan struct{})
+
+	ctx, cancelFunc := context.WithCancel(ctx)
+	//nolint:gocritic // The system signs the audit log without direct user input.
+	ctx = dbauthz.AsSystemRestricted(ctx)
+
+	ticker := clk.NewTicker(CheckpointInterval)
+	ticker.Stop()
+	doTick := func(now time.Time) {
+		defer ticker.Reset(CheckpointInterval, "audit", "checkpoint")
+		if err := Checkpoint(ctx, db, keys, now); err != nil {
+			logger.Error(ctx, "failed to checkpoint audit log hash chain", slog.Error(err))
+		}
+	}
+
+	go func() {
+		defer close(closed)
+		defer ticker.Stop()
+		// Force an initial tick.
+		doTick(dbtime.Time(clk.Now()).UTC())
+		for {
+			select {
+			case <-ctx.Done():
+				logger.Debug(ctx, "closing audit log checkpointer")
+				return
+			case tick := <-ticker.C:
+				ticker.Stop()
+
+				doTick(dbtime.Time(tick).UTC())
+			}
+		}
+	}()
+	return &routine{
+		cancel: cancelFunc,
+		closed: closed,
+	}
+}
+
+// routine is a background loop of the audit log hash chain.
+type routine struct {
+	cancel context.CancelFunc
+	closed chan struct{}
+}
+
+func (c *routine) Close() error {
+	c.cancel()
+	<-c.closed
+	return nil
+}
+
+// Checkpoint signs the head of the audit log hash chain, unless it was signed
+// already.
+func Checkpoint(ctx context.Context, db database.Store, keys cryptokeys.SigningKeycache, now time.Time) error {
+	// Start a transaction to grab advisory lock, we don't want to sign the chain at the same time (multiple replicas).
+	return db.InTx(func(tx database.Store) error {
+		ok, err := tx.TryAcquireLock(ctx, database.LockIDAuditLogCheckpoint)
+		if err != nil {
+			return xerrors.Errorf("acquire audit log checkpoint lock: %w", err)
+		}
+		if !ok {
+			return nil
+		}
+
+		head, err := tx.GetLatestAuditLogHash(ctx)
+		if xerrors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return xerrors.Errorf("get latest audit log hash: %w", err)
+		}
+		latest, err := tx.GetLatestAuditLogCheckpoint(ctx)
+		if err != nil && !xerrors.Is(err, sql.ErrNoRows) {
+			return xerrors.Errorf("get latest audit log checkpoint: %w", err)
+		}
+		if err == nil && latest.Sequence >= head.Sequence {
+			return nil
+		}
+
+		id, key, err := keys.SigningKey(ctx)
+		if err != nil {
+			return xerrors.Errorf("get signing key: %w", err)
+		}
+		secret, ok := key.([]byte)
+		if !ok {
+			return xerrors.Errorf("unexpected signing key type %T", key)
+		}
+		keySequence, err := strconv.ParseInt(id, 10, 32)
+		if err != nil {
+			return xerrors.Errorf("parse signing key id %q: %w", id, err)
+		}
+		err = tx.InsertAuditLogCheckpoint(ctx, database.InsertAuditLogCheckpointParams{
+			Sequence: head.Sequence,
+			Hash:     head.Hash,
+			// #nosec G115 - The sequence was parsed with a bit size of 32.
+			KeySequence: int32(keySequence),
+			Signature:   SignCheckpoint(secret, head.Sequence, head.Hash),
+			CreatedAt:   now,
+		})
+		if err != nil {
+			return xerrors.Errorf("insert audit log checkpoint: %w", err)
+		}
+		return nil
+	}, nil)
+}
@@ -0,0 +1,267 @@
+package audit_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/v2/coderd/database"
+	"github.com/coder/coder/v2/coderd/database/dbmem"
+	"github.com/coder/coder/v2/codersdk"
+	"github.com/coder/coder/v2/enterprise/audit"
+	"github.com/coder/coder/v2/enterprise/audit/audittest"
+	"github.com/coder/coder/v2/enterprise/audit/backends"
+	"github.com/coder/coder/v2/testutil"
+)
+
+func TestChain(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Context(t, testutil.WaitShort)
+	db := dbmem.New()
+	now := time.Now()
+
+	// Audit logs are chained in the order they were inserted, even if one
+	// was timestamped before the other.
+	first := exportLog(ctx, t, db, now)
+	second := exportLog(ctx, t, db, now.Add(-time.Minute))
+	err := audit.Chain(ctx, db)
+	require.NoError(t, err)
+	// Chaining again doesn't append anything.
+	err = audit.Chain(ctx, db)
+	require.NoError(t, err)
+
+	hashes, err := db.GetAuditLogHashes(ctx, database.GetAuditLogHashesParams{
+		AfterSequence: 0,
+		LastSequence:  math.MaxInt64,
+	})
+	require.NoError(t, err)
+	require.Len(t, hashes, 2)
+	require.Equal(t, first.ID, hashes[0].AuditLogID)
+	require.Empty(t, hashes[0].PreviousHash)
+	require.Equal(t, second.ID, hashes[1].AuditLogID)
+	require.Equal(t, hashes[0].Hash, hashes[1].PreviousHash)
+
+	// The chain continues after its head.
+	third := exportLog(ctx, t, db, now.Add(-time.Hour))
+	err = audit.Chain(ctx, db)
+	require.NoError(t, err)
+	head, err := db.GetLatestAuditLogHash(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, 3, head.Sequence)
+	require.Equal(t, third.ID, head.AuditLogID)
+	require.Equal(t, hashes[1].Hash, head.PreviousHash)
+}
+
+func TestVerify(t *testing.T) {
+	t.Parallel()
+
+	t.Run("OK", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := testutil.Context(t, testutil.WaitShort)
+		db := dbmem.New()
+		keys := &fakeKeycache{key: []byte("secret")}
+		now := time.Now()
+
+		exportLogs(ctx, t, db, now, 3)
+		err := audit.Checkpoint(ctx, db, keys, now)
+		require.NoError(t, err)
+		// The head of the chain is only signed once.
+		err = audit.Checkpoint(ctx, db, keys, now)
+		require.NoError(t, err)
+		exportLogs(ctx, t, db, now.Add(time.Minute), 1)
+
+		verification, err := audit.Verify(ctx, db, keys, now.Add(-time.Hour), now.Add(time.Hour))
+		require.NoError(t, err)
+		require.Empty(t, verification.Problems)
+		require.EqualValues(t, 4, verification.Entries)
+		require.EqualValues(t, 1, verification.Checkpoints)
+
+		// A time range in the middle of the chain is linked to the entries
+		// around it.
+		verification, err = audit.Verify(ctx, db, keys, now.Add(500*time.Millisecond), now.Add(1500*time.Millisecond))
+		require.NoError(t, err)
+		require.Empty(t, verification.Problems)
+		require.EqualValues(t, 1, verification.Entries)
+	})
+
+	t.Run("Archived", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := testutil.Context(t, testutil.WaitShort)
+		db := dbmem.New()
+		keys := &fakeKeycache{key: []byte("secret")}
+		now := time.Now()
+
+		exportLogs(ctx, t, db, now, 2)
+		first, err := db.GetAuditLogHashes(ctx, database.GetAuditLogHashesParams{
+			AfterSequence: 0,
+			LastSequence:  1,
+			LimitOpt:      1,
+		})
+		require.NoError(t, err)
+		require.Len(t, first, 1)
+
+		// An audit log which was archived by its retention is not deleted.
+		archive, err := db.InsertAuditLogArchive(ctx, database.InsertAuditLogArchiveParams{
+			ID:            uuid.New(),
+			CreatedAt:     now,
+			FileName:      "audit-logs.ndjson.gz",
+			FileID:        uuid.New(),
+			OldestTime:    now,
+			NewestTime:    now,
+			AuditLogCount: 1,
+			Sha256:        []byte("sha256"),
+		})
+		require.NoError(t, err)
+		err = db.InsertAuditLogArchiveEntries(ctx, database.InsertAuditLogArchiveEntriesParams{
+			AuditLogIDs: []uuid.UUID{first[0].AuditLogID},
+			ArchiveID:   archive.ID,
+		})
+		require.NoError(t, err)
+		err = db.DeleteAuditLogsByIDs(ctx, []uuid.UUID{first[0].AuditLogID})
+		require.NoError(t, err)
+
+		verification, err := audit.Verify(ctx, db, keys, now.Add(-time.Hour), now.Add(time.Hour))
+		require.NoError(t, err)
+		require.Empty(t, verification.Problems)
+		require.EqualValues(t, 2, verification.Entries)
+		require.EqualValues(t, 1, verification.Archived)
+	})
+
+	t.Run("Tampered", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := testutil.Context(t, testutil.WaitShort)
+		db := dbmem.New()
+		keys := &fakeKeycache{key: []byte("secret")}
+		now := time.Now()
+
+		exportLogs(ctx, t, db, now, 2)
+		head, err := db.GetLatestAuditLogHash(ctx)
+		require.NoError(t, err)
+
+		// An audit log which was deleted after it was chained.
+		deleted := audittest.RandomLog()
+		deleted.Time = now.Add(2 * time.Second)
+		deleted.XactID = sql.NullInt64{Int64: head.AuditLogXactID, Valid: true}
+		deletedHash, err := audit.HashAuditLog(head.Hash, deleted)
+		require.NoError(t, err)
+		insertHash(ctx, t, db, 3, deleted, head.Hash, deletedHash)
+
+		// An audit log which was modified after it was chained.
+		modified := audittest.RandomLog()
+		modified.Time = now.Add(3 * time.Second)
+		modified, err = db.InsertAuditLog(ctx, modified.InsertAuditLogParams())
+		require.NoError(t, err)
+		tamperedHash := sha256.Sum256([]byte("tampered"))
+		insertHash(ctx, t, db, 4, modified, deletedHash, tamperedHash[:])
+
+		// An audit log which was skipped by the chain.
+		unchained := audittest.RandomLog()
+		unchained.Time = now.Add(5 * time.Second)
+		unchained, err = db.InsertAuditLog(ctx, unchained.InsertAuditLogParams())
+		require.NoError(t, err)
+
+		// Entry 5 was removed from the chain.
+		skipped := audittest.RandomLog()
+		skipped.Time = now.Add(4 * time.Second)
+		skipped, err = db.InsertAuditLog(ctx, skipped.InsertAuditLogParams())
+		require.NoError(t, err)
+		skippedHash, err := audit.HashAuditLog([]byte("unknown"), skipped)
+		require.NoError(t, err)
+		insertHash(ctx, t, db, 6, skipped, []byte("unknown"), skippedHash)
+
+		// An audit log which is not chained yet is not reported.
+		_ = exportLog(ctx, t, db, now.Add(6*time.Second))
+
+		// A checkpoint past the end of the chain, with a forged signature.
+		err = db.InsertAuditLogCheckpoint(ctx, database.InsertAuditLogCheckpointParams{
+			Sequence:    10,
+			Hash:        skippedHash,
+			KeySequence: 1,
+			Signature:   audit.SignCheckpoint([]byte("forged"), 10, skippedHash),
+			CreatedAt:   now,
+		})
+		require.NoError(t, err)
+
+		verification, err := audit.Verify(ctx, db, keys, now.Add(-time.Hour), now.Add(time.Hour))
+		require.NoError(t, err)
+		require.EqualValues(t, 5, verification.Entries)
+		kinds := make(map[codersdk.AuditLogVerificationProblemKind]codersdk.AuditLogVerificationProblem)
+		for _, problem := range verification.Problems {
+			kinds[problem.Kind] = problem
+		}
+		require.Len(t, kinds, 5, verification.Problems)
+		require.Equal(t, deleted.ID, kinds[codersdk.AuditLogVerificationProblemDeleted].AuditLogID)
+		require.Equal(t, modified.ID, kinds[codersdk.AuditLogVerificationProblemModified].AuditLogID)
+		require.EqualValues(t, 5, kinds[codersdk.AuditLogVerificationProblemGap].Sequence)
+		require.Equal(t, unchained.ID, kinds[codersdk.AuditLogVerificationProblemUnchained].AuditLogID)
+		require.EqualValues(t, 7, kinds[codersdk.AuditLogVerificationProblemTruncated].Sequence)
+	})
+}
+
+// exportLogs exports audit logs one second apart through the Postgres
+// backend, and chains them.
+func exportLogs(ctx context.Context, t *testing.T, db database.Store, start time.Time, n int) {
+	t.Helper()
+
+	for i := range n {
+		_ = exportLog(ctx, t, db, start.Add(time.Duration(i)*time.Second))
+	}
+	err := audit.Chain(ctx, db)
+	require.NoError(t, err)
+}
+
+// exportLog exports an audit log through the Postgres backend, without
+// chaining it.
+func exportLog(ctx context.Context, t *testing.T, db database.Store, at time.Time) database.AuditLog {
+	t.Helper()
+
+	alog := audittest.RandomLog()
+	alog.Time = at
+	err := backends.NewPostgres(db, true).Export(ctx, alog, audit.BackendDetails{})
+	require.NoError(t, err)
+	return alog
+}
+
+func insertHash(ctx context.Context, t *testing.T, db database.Store, sequence int64, alog database.AuditLog, previous, sum []byte) {
+	t.Helper()
+
+	err := db.InsertAuditLogHash(ctx, database.InsertAuditLogHashParams{
+		Sequence:       sequence,
+		AuditLogID:     alog.ID,
+		AuditLogTime:   alog.Time,
+		AuditLogXactID: alog.XactID.Int64,
+		PreviousHash:   previous,
+		Hash:           sum,
+	})
+	require.NoError(t, err)
+}
+
+type fakeKeycache struct {
+	key []byte
+}
+
+func (f *fakeKeycache) SigningKey(context.Context) (string, interface{}, error) {
+	return "1", f.key, nil
+}
+
+func (f *fakeKeycache) VerifyingKey(_ context.Context, id string) (interface{}, error) {
+	if id != "1" {
+		return nil, xerrors.Errorf("unknown key %q", id)
+	}
+	return f.key, nil
+}
+
+func (*fakeKeycache) Close() error {
+	return nil
+}
@@ -0,0 +1,396 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"database/sql"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/v2/coderd/cryptokeys"
+	"github.com/coder/coder/v2/coderd/database"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+const (
+	// verifyBatchSize is the number of hash chain entries verified at once.
+	verifyBatchSize = 1000
+	// maxVerificationProblems is the number of problems listed in a
+	// verification.
+	maxVerificationProblems = 1000
+)
+
+// Verify verifies the hash chain of the audit logs in a time range, and the
+// signed checkpoints of the chain. It reports the audit logs which were
+// modified or deleted, and the audit logs which were inserted without being
+// chained. Audit logs which were archived by their retention are counted, not
+// reported.
+func Verify(ctx context.Context, db database.Store, keys cryptokeys.SigningKeycache, from, to time.Time) (codersdk.AuditLogVerification, error) {
+	v := &verifier{
+		db:   db,
+		keys: keys,
+		result: codersdk.AuditLogVerification{
+			From:     from,
+			To:       to,
+			Problems: []codersdk.AuditLogVerificationProblem{},
+		},
+	}
+
+	sequences, err := db.GetAuditLogHashSequenceRange(ctx, database.GetAuditLogHashSequenceRangeParams{
+		FromTime: from,
+		ToTime:   to,
+	})
+	if err != nil {
+		return codersdk.AuditLogVerification{}, xerrors.Errorf("get audit log hash sequence range: %w", err)
+	}
+	if sequences.LastSequence > 0 {
+		if err := v.verifyChain(ctx, sequences.FirstSequence, sequences.LastSequence); err != nil {
+			return codersdk.AuditLogVerification{}, err
+		}
+	}
+	if err := v.verifyHead(ctx); err != nil {
+		return codersdk.AuditLogVerification{}, err
+	}
+	if err := v.verifyUnchained(ctx, from, to); err != nil {
+		return codersdk.AuditLogVerification{}, err
+	}
+	return v.result, nil
+}
+
+type verifier struct {
+	db     database.Store
+	keys   cryptokeys.SigningKeycache
+	result codersdk.AuditLogVerification
+}
+
+func (v *verifier) problem(problem codersdk.AuditLogVerificationProblem) {
+	if len(v.result.Problems) >= maxVerificationProblems {
+		v.result.ProblemsTruncated = true
+		return
+	}
+	v.result.Problems = append(v.result.Problems, problem)
+}
+
+// verifyChain walks the hash chain between two sequences, including the
+// links to the entries right outside of them.
+func (v *verifier) verifyChain(ctx context.Context, first, last int64) error {
+	checkpoints, err := v.db.GetAuditLogCheckpoints(ctx, database.GetAuditLogCheckpointsParams{
+		FirstSequence: first,
+		LastSequence:  last,
+	})
+	if err != nil {
+		return xerrors.Errorf("get audit log checkpoints: %w", err)
+	}
+	checkpointsBySequence := make(map[int64]database.AuditLogCheckpoint, len(checkpoints))
+	for _, checkpoint := range checkpoints {
+		checkpointsBySequence[checkpoint.Sequence] = checkpoint
+	}
+
+	var previous *database.AuditLogHash
+	if first > 1 {
+		prior, err := v.hashAt(ctx, first-1)
+		if err != nil {
+			return err
+		}
+		if prior == nil {
+			v.problem(codersdk.AuditLogVerificationProblem{
+				Kind:     codersdk.AuditLogVerificationProblemGap,
+				Sequence: first - 1,
+				Detail:   fmt.Sprintf("entry %d, before the time range, is missing", first-1),
+			})
+		}
+		previous = prior
+	}
+
+	after := first - 1
+	for after < last {
+		entries, err := v.db.GetAuditLogHashes(ctx, database.GetAuditLogHashesParams{
+			AfterSequence: after,
+			LastSequence:  last,
+			LimitOpt:      verifyBatchSize,
+		})
+		if err != nil {
+			return xerrors.Errorf("get audit log hashes: %w", err)
+		}
+		if len(entries) == 0 {
+			break
+		}
+
+		ids := make([]uuid.UUID, 0, len(entries))
+		for _, entry := range entries {
+			ids = append(ids, entry.AuditLogID)
+		}
+		logs, err := v.db.GetAuditLogsByIDs(ctx, ids)
+		if err != nil {
+			return xerrors.Errorf("get audit logs by ids: %w", err)
+		}
+		logsByID := make(map[uuid.UUID]database.AuditLog, len(logs))
+		for _, alog := range logs {
+			logsByID[alog.ID] = alog
+		}
+		archived, err := v.archived(ctx, ids, logsByID)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			v.verifyLink(previous, entry)
+			v.verifyEntry(entry, logsByID, archived)
+			if checkpoint, ok := checkpointsBySequence[entry.Sequence]; ok {
+				v.verifyCheckpoint(ctx, checkpoint, entry.Hash)
+				delete(checkpointsBySequence, entry.Sequence)
+			}
+			v.result.Entries++
+			previous = &entry
+		}
+		after = entries[len(entries)-1].Sequence
+	}
+
+	// The entry after the time range must follow the last one, otherwise
+	// the end of the time range was removed from the chain.
+	following, err := v.db.GetAuditLogHashes(ctx, database.GetAuditLogHashesParams{
+		AfterSequence: last,
+		LastSequence:  math.MaxInt64,
+		LimitOpt:      1,
+	})
+	if err != nil {
+		return xerrors.Errorf("get audit log hashes: %w", err)
+	}
+	if len(following) > 0 {
+		v.verifyLink(previous, following[0])
+	}
+
+	// The remaining checkpoints are of entries which are missing from the
+	// chain.
+	missing := make([]int64, 0, len(checkpointsBySequence))
+	for sequence := range checkpointsBySequence {
+		missing = append(missing, sequence)
+	}
+	slices.Sort(missing)
+	for _, sequence := range missing {
+		v.problem(codersdk.AuditLogVerificationProblem{
+			Kind:     codersdk.AuditLogVerificationProblemInvalidCheckpoint,
+			Sequence: sequence,
+			Detail:   fmt.Sprintf("the checkpointed entry %d is missing", sequence),
+		})
+	}
+	return nil
+}
+
+// archived returns which of the audit logs missing from a batch were archived.
+func (v *verifier) archived(ctx context.Context, ids []uuid.UUID, logsByID map[uuid.UUID]database.AuditLog) (map[uuid.UUID]struct{}, error) {
+	missing := make([]uuid.UUID, 0)
+	for _, id := range ids {
+		if _, ok := logsByID[id]; !ok {
+			missing = append(missing, id)
+		}
+	}
+	archived := make(map[uuid.UUID]struct{})
+	if len(missing) == 0 {
+		return archived, nil
+	}
+	archivedIDs, err := v.db.GetArchivedAuditLogIDs(ctx, missing)
+	if err != nil {
+		return nil, xerrors.Errorf("get archived audit log ids: %w", err)
+	}
+	for _, id := range archivedIDs {
+		archived[id] = struct{}{}
+	}
+	return archived, nil
+}
+
+// hashAt returns the entry of the hash chain at a sequence, or nil if there
+// is none.
+func (v *verifier) hashAt(ctx context.Context, sequence int64) (*database.AuditLogHash, error) {
+	entries, err := v.db.GetAuditLogHashes(ctx, database.GetAuditLogHashesParams{
+		AfterSequence: sequence - 1,
+		LastSequence:  sequence,
+		LimitOpt:      1,
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("get audit log hash %d: %w", sequence, err)
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	return &entries[0], nil
+}
+
+// verifyLink checks that an entry follows the previous entry of the chain.
+// A nil previous entry is either the start of the chain, or an entry which
+// was reported missing already.
+func (v *verifier) verifyLink(previous *database.AuditLogHash, entry database.AuditLogHash) {
+	switch {
+	case previous == nil && entry.Sequence == 1:
+		if len(entry.PreviousHash) != 0 {
+			v.problem(codersdk.AuditLogVerificationProblem{
+				Kind:       codersdk.AuditLogVerificationProblemBrokenLink,
+				Sequence:   entry.Sequence,
+				AuditLogID: entry.AuditLogID,
+				Detail:     "the first entry of the chain refers to a previous entry",
+			})
+		}
+	case previous == nil:
+	case entry.Sequence != previous.Sequence+1:
+		v.problem(codersdk.AuditLogVerificationProblem{
+			Kind:     codersdk.AuditLogVerificationProblemGap,
+			Sequence: previous.Sequence + 1,
+			Detail:   fmt.Sprintf("entries %d to %d are missing", previous.Sequence+1, entry.Sequence-1),
+		})
+	case !bytes.Equal(entry.PreviousHash, previous.Hash):
+		v.problem(codersdk.AuditLogVerificationProblem{
+			Kind:       codersdk.AuditLogVerificationProblemBrokenLink,
+			Sequence:   entry.Sequence,
+			AuditLogID: entry.AuditLogID,
+			Detail:     fmt.Sprintf("the previous hash doesn't match entry %d", previous.Sequence),
+		})
+	}
+}
+
+// verifyEntry checks that the audit log of an entry exists, unless it was
+// archived, and matches its hash.
+func (v *verifier) verifyEntry(entry database.AuditLogHash, logsByID map[uuid.UUID]database.AuditLog, archived map[uuid.UUID]struct{}) {
+	alog, ok := logsByID[entry.AuditLogID]
+	if !ok {
+		if _, ok := archived[entry.AuditLogID]; ok {
+			v.result.Archived++
+			return
+		}
+		v.problem(codersdk.AuditLogVerificationProblem{
+			Kind:       codersdk.AuditLogVerificationProblemDeleted,
+			Sequence:   entry.Sequence,
+			AuditLogID: entry.AuditLogID,
+			Detail:     fmt.Sprintf("the audit log of %s was deleted", entry.AuditLogTime.Format(time.RFC3339)),
+		})
+		return
+	}
+	sum, err := HashAuditLog(entry.PreviousHash, alog)
+	if err != nil {
+		v.problem(codersdk.AuditLogVerificationProblem{
+			Kind:       codersdk.AuditLogVerificationProblemModified,
+			Sequence:   entry.Sequence,
+			AuditLogID: entry.AuditLogID,
+			Detail:     fmt.Sprintf("hash audit log: %s", err),
+		})
+		return
+	}
+	if !bytes.Equal(sum, entry.Hash) {
+		v.problem(codersdk.AuditLogVerificationProblem{
+			Kind:       codersdk.AuditLogVerificationProblemModified,
+			Sequence:   entry.Sequence,
+			AuditLogID: entry.AuditLogID,
+			Detail:     "the audit log doesn't match its hash",
+		})
+	}
+}
+
+// verifyCheckpoint checks the signature of a checkpoint, and that it matches
+// the hash of its entry in the chain.
+func (v *verifier) verifyCheckpoint(ctx context.Context, checkpoint database.AuditLogCheckpoint, sum []byte) {
+	invalid := func(detail string) {
+		v.problem(codersdk.AuditLogVerificationProblem{
+			Kind:     codersdk.AuditLogVerificationProblemInvalidCheckpoint,
+			Sequence: checkpoint.Sequence,
+			Detail:   detail,
+		})
+	}
+
+	key, err := v.keys.VerifyingKey(ctx, strconv.FormatInt(int64(checkpoint.KeySequence), 10))
+	if err != nil {
+		invalid(fmt.Sprintf("the signing key %d is unavailable: %s", checkpoint.KeySequence, err))
+		return
+	}
+	secret, ok := key.([]byte)
+	if !ok {
+		invalid(fmt.Sprintf("unexpected signing key type %T", key))
+		return
+	}
+	if !hmac.Equal(SignCheckpoint(secret, checkpoint.Sequence, checkpoint.Hash), checkpoint.Signature) {
+		invalid("the signature is invalid")
+		return
+	}
+	if !bytes.Equal(checkpoint.Hash, sum) {
+		invalid("the hash chain doesn't match the checkpoint")
+		return
+	}
+	v.result.Checkpoints++
+}
+
+// verifyHead checks that the chain wasn't truncated before the latest
+// checkpoint.
+func (v *verifier) verifyHead(ctx context.Context) error {
+	checkpoint, err := v.db.GetLatestAuditLogCheckpoint(ctx)
+	if xerrors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return xerrors.Errorf("get latest audit log checkpoint: %w", err)
+	}
+	var headSequence int64
+	head, err := v.db.GetLatestAuditLogHash(ctx)
+	if err != nil && !xerrors.Is(err, sql.ErrNoRows) {
+		return xerrors.Errorf("get latest audit log hash: %w", err)
+	}
+	if err == nil {
+		headSequence = head.Sequence
+	}
+	if headSequence < checkpoint.Sequence {
+		v.problem(codersdk.AuditLogVerificationProblem{
+			Kind:     codersdk.AuditLogVerificationProblemTruncated,
+			Sequence: headSequence + 1,
+			Detail:   fmt.Sprintf("the chain ends at entry %d, before the checkpoint of entry %d", headSequence, checkpoint.Sequence),
+		})
+	}
+	return nil
+}
+
+// verifyUnchained reports the audit logs which were inserted after the chain
+// started, but are not part of it. Audit logs ordered after the head of the
+// chain are not chained yet, so they are not reported.
+func (v *verifier) verifyUnchained(ctx context.Context, from, to time.Time) error {
+	start, err := v.db.GetAuditLogHashes(ctx, database.GetAuditLogHashesParams{
+		AfterSequence: 0,
+		LastSequence:  math.MaxInt64,
+		LimitOpt:      1,
+	})
+	if err != nil {
+		return xerrors.Errorf("get audit log hashes: %w", err)
+	}
+	if len(start) == 0 {
+		// Audit logs from before the chain started are not chained.
+		return nil
+	}
+	if start[0].AuditLogTime.After(from) {
+		from = start[0].AuditLogTime
+	}
+	head, err := v.db.GetLatestAuditLogHash(ctx)
+	if err != nil {
+		return xerrors.Errorf("get latest audit log hash: %w", err)
+	}
+
+	unchained, err := v.db.GetUnchainedAuditLogs(ctx, database.GetUnchainedAuditLogsParams{
+		FromTime:   from,
+		ToTime:     to,
+		HeadXactID: head.AuditLogXactID,
+		HeadID:     head.AuditLogID,
+		LimitOpt:   maxVerificationProblems + 1,
+	})
+	if err != nil {
+		return xerrors.Errorf("get unchained audit logs: %w", err)
+	}
+	for _, alog := range unchained {
+		v.problem(codersdk.AuditLogVerificationProblem{
+			Kind:       codersdk.AuditLogVerificationProblemUnchained,
+			AuditLogID: alog.ID,
+			Detail:     fmt.Sprintf("the audit log of %s is not part of the hash chain", alog.Time.Format(time.RFC3339)),
+		})
+	}
+	return nil
+}
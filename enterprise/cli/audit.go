@@ -0,0 +1,269 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/serpent"
+
+	"github.com/coder/coder/v2/cli"
+	"github.com/coder/coder/v2/cli/cliui"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+func (r *RootCmd) audit() *serpent.Command {
+	cmd := &serpent.Command{
+		Use:   "audit",
+		Short: "Manage audit logs",
+		Handler: func(inv *serpent.Invocation) error {
+			return inv.Command.HelpHandler(inv)
+		},
+		Children: []*serpent.Command{
+			r.auditVerify(),
+			r.auditArchives(),
+		},
+	}
+	return cmd
+}
+
+func (r *RootCmd) auditVerify() *serpent.Command {
+	type problemRow struct {
+		Kind       codersdk.AuditLogVerificationProblemKind `json:"kind" table:"kind"`
+		Sequence   int64                                    `json:"sequence" table:"sequence,default_sort"`
+		AuditLogID string                                   `json:"audit_log_id" table:"audit log id"`
+		Detail     string                                   `json:"detail" table:"detail"`
+	}
+
+	var (
+		from      string
+		to        string
+		client    = new(codersdk.Client)
+		formatter = cliui.NewOutputFormatter(
+			cliui.ChangeFormatterData(
+				cliui.TableFormat([]problemRow{}, []string{"kind", "sequence", "audit log id", "detail"}),
+				func(data any) (any, error) {
+					verification, ok := data.(codersdk.AuditLogVerification)
+					if !ok {
+						return nil, xerrors.Errorf("expected codersdk.AuditLogVerification, got %T", data)
+					}
+					rows := make([]problemRow, 0, len(verification.Problems))
+					for _, problem := range verification.Problems {
+						row := problemRow{
+							Kind:     problem.Kind,
+							Sequence: problem.Sequence,
+							Detail:   problem.Detail,
+						}
+						if problem.AuditLogID != uuid.Nil {
+							row.AuditLogID = problem.AuditLogID.String()
+						}
+						rows = append(rows, row)
+					}
+					return rows, nil
+				},
+			),
+			cliui.JSONFormat(),
+		)
+	)
+	cmd := &serpent.Command{
+		Use:   "verify",
+		Short: "Verify that audit logs were not modified or deleted",
+		Long: "Audit logs are chained with a hash of the previous audit log, and the chain is signed periodically. " +
+			"This checks the chain for gaps and tampering over a time range.\n" + cli.FormatExamples(
+			cli.Example{
+				Description: "Verify the audit logs of the last 30 days.",
+				Command:     "coder audit verify",
+			},
+			cli.Example{
+				Description: "Verify the audit logs of January 2025.",
+				Command:     "coder audit verify --from 2025-01-01 --to 2025-02-01",
+			},
+		),
+		Middleware: serpent.Chain(
+			serpent.RequireNArgs(0),
+			r.InitClient(client),
+		),
+		Options: serpent.OptionSet{
+			{
+				Flag:        "from",
+				Description: "Start of the time range, as a date (2006-01-02) or an RFC 3339 time. Defaults to 30 days before the end of the time range.",
+				Value:       serpent.StringOf(&from),
+			},
+			{
+				Flag:        "to",
+				Description: "End of the time range, as a date (2006-01-02) or an RFC 3339 time. Defaults to now.",
+				Value:       serpent.StringOf(&to),
+			},
+		},
+		Handler: func(inv *serpent.Invocation) error {
+			ctx := inv.Context()
+			var (
+				req codersdk.AuditLogVerifyRequest
+				err error
+			)
+			if req.From, err = parseAuditTime(from); err != nil {
+				return xerrors.Errorf("parse --from: %w", err)
+			}
+			if req.To, err = parseAuditTime(to); err != nil {
+				return xerrors.Errorf("parse --to: %w", err)
+			}
+
+			verification, err := client.VerifyAuditLogs(ctx, req)
+			if err != nil {
+				return xerrors.Errorf("verify audit logs: %w", err)
+			}
+			if len(verification.Problems) > 0 || formatter.FormatID() != "table" {
+				out, err := formatter.Format(ctx, verification)
+				if err != nil {
+					return xerrors.Errorf("format verification: %w", err)
+				}
+				_, _ = fmt.Fprintln(inv.Stdout, out)
+			}
+
+			summary := fmt.Sprintf("Verified %d audit logs and %d checkpoints from %s to %s.",
+				verification.Entries, verification.Checkpoints,
+				verification.From.Format(time.RFC3339), verification.To.Format(time.RFC3339))
+			if verification.Archived > 0 {
+				summary += fmt.Sprintf(" %d of the audit logs were archived.", verification.Archived)
+			}
+			if len(verification.Problems) == 0 {
+				cliui.Infof(inv.Stderr, "%s No problems were found.", summary)
+				return nil
+			}
+			cliui.Warnf(inv.Stderr, "%s", summary)
+			if verification.ProblemsTruncated {
+				return xerrors.Errorf("found more than %d problems, narrow the time range to list them all", len(verification.Problems))
+			}
+			return xerrors.Errorf("found %d problems", len(verification.Problems))
+		},
+	}
+	formatter.AttachOptions(&cmd.Options)
+	return cmd
+}
+
+func (r *RootCmd) auditArchives() *serpent.Command {
+	cmd := &serpent.Command{
+		Use:   "archives",
+		Short: "Manage the archives of expired audit logs",
+		Long:  "Audit logs which outlive their retention are archived to compressed files before they are deleted. Archives can be restored for investigations.",
+		Handler: func(inv *serpent.Invocation) error {
+			return inv.Command.HelpHandler(inv)
+		},
+		Children: []*serpent.Command{
+			r.auditArchivesList(),
+			r.auditArchivesRestore(),
+		},
+	}
+	return cmd
+}
+
+type auditLogArchiveRow struct {
+	codersdk.AuditLogArchive `table:"-"`
+
+	ID         string `json:"-" table:"id"`
+	OldestTime string `json:"-" table:"oldest,default_sort"`
+	NewestTime string `json:"-" table:"newest"`
+	AuditLogs  int32  `json:"-" table:"audit logs"`
+	Restored   string `json:"-" table:"restored"`
+	FileName   string `json:"-" table:"file name"`
+}
+
+func auditLogArchiveRowFromArchive(archive codersdk.AuditLogArchive) auditLogArchiveRow {
+	row := auditLogArchiveRow{
+		AuditLogArchive: archive,
+		ID:              archive.ID.String(),
+		OldestTime:      archive.OldestTime.Format(time.RFC3339),
+		NewestTime:      archive.NewestTime.Format(time.RFC3339),
+		AuditLogs:       archive.AuditLogCount,
+		FileName:        archive.FileName,
+	}
+	if archive.RestoredAt != nil {
+		row.Restored = archive.RestoredAt.Format(time.RFC3339)
+	}
+	return row
+}
+
+func (r *RootCmd) auditArchivesList() *serpent.Command {
+	var (
+		client    = new(codersdk.Client)
+		formatter = cliui.NewOutputFormatter(
+			cliui.TableFormat([]auditLogArchiveRow{}, []string{"id", "oldest", "newest", "audit logs", "restored"}),
+			cliui.JSONFormat(),
+		)
+	)
+	cmd := &serpent.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List the archives of expired audit logs",
+		Middleware: serpent.Chain(
+			serpent.RequireNArgs(0),
+			r.InitClient(client),
+		),
+		Handler: func(inv *serpent.Invocation) error {
+			ctx := inv.Context()
+			archives, err := client.AuditLogArchives(ctx)
+			if err != nil {
+				return xerrors.Errorf("list audit log archives: %w", err)
+			}
+			rows := make([]auditLogArchiveRow, 0, len(archives))
+			for _, archive := range archives {
+				rows = append(rows, auditLogArchiveRowFromArchive(archive))
+			}
+			out, err := formatter.Format(ctx, rows)
+			if err != nil {
+				return xerrors.Errorf("format archives: %w", err)
+			}
+			if out == "" {
+				cliui.Infof(inv.Stderr, "No audit logs were archived.")
+				return nil
+			}
+			_, _ = fmt.Fprintln(inv.Stdout, out)
+			return nil
+		},
+	}
+	formatter.AttachOptions(&cmd.Options)
+	return cmd
+}
+
+func (r *RootCmd) auditArchivesRestore() *serpent.Command {
+	client := new(codersdk.Client)
+	cmd := &serpent.Command{
+		Use:   "restore <archive-id>",
+		Short: "Restore the audit logs of an archive for an investigation",
+		Long:  "The restored audit logs are deleted again after the restore duration of the deployment, and stay in the archive.",
+		Middleware: serpent.Chain(
+			serpent.RequireNArgs(1),
+			r.InitClient(client),
+		),
+		Handler: func(inv *serpent.Invocation) error {
+			ctx := inv.Context()
+			id, err := uuid.Parse(inv.Args[0])
+			if err != nil {
+				return xerrors.Errorf("parse archive id: %w", err)
+			}
+			archive, err := client.RestoreAuditLogArchive(ctx, id)
+			if err != nil {
+				return xerrors.Errorf("restore audit log archive: %w", err)
+			}
+			cliui.Infof(inv.Stderr, "Restored %d audit logs from %s to %s.",
+				archive.AuditLogCount,
+				archive.OldestTime.Format(time.RFC3339), archive.NewestTime.Format(time.RFC3339))
+			return nil
+		},
+	}
+	return cmd
+}
+
+// parseAuditTime parses a date or an RFC 3339 time. An empty value is the
+// zero time, so the server default is used.
+func parseAuditTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, value, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339Nano, value)
+}
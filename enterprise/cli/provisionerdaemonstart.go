@@ -8,10 +8,13 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"path/filepath"
 	"regexp"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/hashicorp/go-version"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -23,9 +26,9 @@ import (
 	"github.com/coder/coder/v2/cli/clilog"
 	"github.com/coder/coder/v2/cli/cliui"
 	"github.com/coder/coder/v2/cli/cliutil"
-	"github.com/coder/coder/v2/coderd/database"
 	"github.com/coder/coder/v2/codersdk"
 	"github.com/coder/coder/v2/codersdk/drpcsdk"
+	"github.com/coder/coder/v2/provisioner/external"
 	"github.com/coder/coder/v2/provisioner/terraform"
 	"github.com/coder/coder/v2/provisionerd"
 	provisionerdproto "github.com/coder/coder/v2/provisionerd/proto"
@@ -49,6 +52,10 @@ func (r *RootCmd) provisionerDaemonStart() *serpent.Command {
 		provisionerKey string
 		verbose        bool
 
+		provisionerTypes    []string
+		provisionerVersions []string
+		externalCommand     string
+
 		prometheusEnable  bool
 		prometheusAddress string
 	)
@@ -173,32 +180,95 @@ func (r *RootCmd) provisionerDaemonStart() *serpent.Command {
 				return err
 			}
 
-			terraformClient, terraformServer := drpcsdk.MemTransportPipe()
-			go func() {
-				<-ctx.Done()
-				_ = terraformClient.Close()
-				_ = terraformServer.Close()
-			}()
+			provisioners := make([]codersdk.ProvisionerType, 0, len(provisionerTypes))
+			for _, pt := range provisionerTypes {
+				switch codersdk.ProvisionerType(pt) {
+				case codersdk.ProvisionerTypeTerraform, codersdk.ProvisionerTypeTofu, codersdk.ProvisionerTypeExternal:
+				default:
+					return xerrors.Errorf("unsupported provisioner type %q, must be one of %q, %q or %q", pt, codersdk.ProvisionerTypeTerraform, codersdk.ProvisionerTypeTofu, codersdk.ProvisionerTypeExternal)
+				}
+				if slices.Contains(provisioners, codersdk.ProvisionerType(pt)) {
+					continue
+				}
+				provisioners = append(provisioners, codersdk.ProvisionerType(pt))
+			}
+			if len(provisioners) == 0 {
+				return xerrors.New("at least one provisioner type must be specified")
+			}
+
+			versions := make([]codersdk.ProvisionerBinaryVersion, 0, len(provisionerVersions))
+			for _, raw := range provisionerVersions {
+				v, err := codersdk.ParseProvisionerBinaryVersion(raw)
+				if err != nil {
+					return err
+				}
+				if !slices.Contains(provisioners, v.Provisioner) {
+					return xerrors.Errorf("provisioner version %q is for provisioner type %q, which this daemon does not run", raw, v.Provisioner)
+				}
+				versions = append(versions, v)
+			}
 
 			errCh := make(chan error, 1)
-			go func() {
-				defer cancel()
-
-				err := terraform.Serve(ctx, &terraform.ServeOptions{
-					ServeOptions: &provisionersdk.ServeOptions{
-						Listener:      terraformServer,
-						Logger:        logger.Named("terraform"),
-						WorkDirectory: tempDir,
-					},
-					CachePath: cacheDir,
-				})
-				if err != nil && !xerrors.Is(err, context.Canceled) {
-					select {
-					case errCh <- err:
-					default:
+			connector := provisionerd.LocalProvisioners{}
+			for _, pt := range provisioners {
+				provisionerClient, provisionerServer := drpcsdk.MemTransportPipe()
+				go func() {
+					<-ctx.Done()
+					_ = provisionerClient.Close()
+					_ = provisionerServer.Close()
+				}()
+				serveOptions := &provisionersdk.ServeOptions{
+					Listener:      provisionerServer,
+					Logger:        logger.Named(string(pt)),
+					WorkDirectory: tempDir,
+				}
+
+				var serve func() error
+				switch pt {
+				case codersdk.ProvisionerTypeExternal:
+					serve = func() error {
+						return external.Serve(ctx, &external.ServeOptions{
+							ServeOptions: serveOptions,
+							Command:      externalCommand,
+						})
+					}
+				default:
+					distribution := terraform.Distribution(pt)
+					var distributionVersions []*version.Version
+					for _, v := range versions {
+						if v.Provisioner == pt {
+							distributionVersions = append(distributionVersions, version.Must(version.NewVersion(v.Version)))
+						}
+					}
+					// Terraform keeps using the root of the cache directory, so
+					// that existing caches remain valid.
+					cachePath := cacheDir
+					if distribution != terraform.DistributionTerraform {
+						cachePath = filepath.Join(cacheDir, string(distribution))
+					}
+					serve = func() error {
+						return terraform.Serve(ctx, &terraform.ServeOptions{
+							ServeOptions: serveOptions,
+							Distribution: distribution,
+							Versions:     distributionVersions,
+							CachePath:    cachePath,
+						})
 					}
 				}
-			}()
+
+				go func() {
+					defer cancel()
+
+					err := serve()
+					if err != nil && !xerrors.Is(err, context.Canceled) {
+						select {
+						case errCh <- err:
+						default:
+						}
+					}
+				}()
+				connector[string(pt)] = proto.NewDRPCProvisionerClient(provisionerClient)
+			}
 
 			var metrics *provisionerd.Metrics
 			if prometheusEnable {
@@ -218,21 +288,17 @@ func (r *RootCmd) provisionerDaemonStart() *serpent.Command {
 				defer closeFunc()
 			}
 
-			logger.Info(ctx, "starting provisioner daemon", slog.F("tags", displayedTags), slog.F("name", name))
+			logger.Info(ctx, "starting provisioner daemon", slog.F("tags", displayedTags), slog.F("name", name), slog.F("provisioners", provisioners), slog.F("provisioner_versions", versions))
 
-			connector := provisionerd.LocalProvisioners{
-				string(database.ProvisionerTypeTerraform): proto.NewDRPCProvisionerClient(terraformClient),
-			}
 			srv := provisionerd.New(func(ctx context.Context) (provisionerdproto.DRPCProvisionerDaemonClient, error) {
 				return client.ServeProvisionerDaemon(ctx, codersdk.ServeProvisionerDaemonRequest{
-					Name: name,
-					Provisioners: []codersdk.ProvisionerType{
-						codersdk.ProvisionerTypeTerraform,
-					},
-					Tags:           tags,
-					PreSharedKey:   preSharedKey,
-					Organization:   orgID,
-					ProvisionerKey: provisionerKey,
+					Name:                name,
+					Provisioners:        provisioners,
+					ProvisionerVersions: versions,
+					Tags:                tags,
+					PreSharedKey:        preSharedKey,
+					Organization:        orgID,
+					ProvisionerKey:      provisionerKey,
 				})
 			}, &provisionerd.Options{
 				Logger:              logger,
@@ -303,6 +369,26 @@ func (r *RootCmd) provisionerDaemonStart() *serpent.Command {
 			Description:   "Tags to filter provisioner jobs by.",
 			Value:         serpent.StringArrayOf(&rawTags),
 		},
+		{
+			Flag:        "type",
+			Env:         "CODER_PROVISIONER_DAEMON_TYPES",
+			Description: "The provisioner types to run jobs of. Supported types are terraform, tofu and external.",
+			Default:     string(codersdk.ProvisionerTypeTerraform),
+			Value:       serpent.StringArrayOf(&provisionerTypes),
+		},
+		{
+			Flag:        "provisioner-version",
+			Env:         "CODER_PROVISIONER_DAEMON_PROVISIONER_VERSIONS",
+			Description: "Additional versions of the provisioner binaries to install and run the jobs of templates pinned to them, e.g. tofu@1.8.0.",
+			Value:       serpent.StringArrayOf(&provisionerVersions),
+		},
+		{
+			Flag:        "external-command",
+			Env:         "CODER_PROVISIONER_DAEMON_EXTERNAL_COMMAND",
+			Description: "The executable the external provisioner invokes for each stage of a job. Relative paths are resolved against the template directory.",
+			Default:     external.DefaultCommand,
+			Value:       serpent.StringOf(&externalCommand),
+		},
 		{
 			Flag:        "poll-interval",
 			Env:         "CODER_PROVISIONERD_POLL_INTERVAL",
@@ -12,6 +12,7 @@ type RootCmd struct {
 func (r *RootCmd) enterpriseOnly() []*serpent.Command {
 	return []*serpent.Command{
 		r.Server(nil),
+		r.audit(),
 		r.workspaceProxy(),
 		r.features(),
 		r.licenses(),
@@ -15,6 +15,7 @@ import (
 	"tailscale.com/types/key"
 
 	"github.com/coder/coder/v2/coderd/database"
+	"github.com/coder/coder/v2/codersdk"
 	"github.com/coder/coder/v2/cryptorand"
 	"github.com/coder/coder/v2/enterprise/audit"
 	"github.com/coder/coder/v2/enterprise/audit/backends"
@@ -115,8 +116,19 @@ func (r *RootCmd) Server(_ func()) *serpent.Command {
 			o.ExternalTokenEncryption = cs
 		}
 
+		sinks, err := auditLogStreamSinks(ctx, options.DeploymentValues.AuditLogStreaming)
+		if err != nil {
+			return nil, nil, xerrors.Errorf("configure audit log streaming: %w", err)
+		}
+		if len(sinks) > 0 {
+			o.AuditLogStreamer = backends.NewStreamer(ctx, options.Logger.Named("audit_log_streaming"), options.Database, quartz.NewReal(), sinks...)
+		}
+
 		api, err := coderd.New(ctx, o)
 		if err != nil {
+			if o.AuditLogStreamer != nil {
+				_ = o.AuditLogStreamer.Close()
+			}
 			return nil, nil, err
 		}
 		return api.AGPL, api, nil
@@ -127,3 +139,57 @@ func (r *RootCmd) Server(_ func()) *serpent.Command {
 	)
 	return cmd
 }
+
+// auditLogStreamSinks returns the audit log streaming sinks which are
+// configured.
+func auditLogStreamSinks(ctx context.Context, cfg codersdk.AuditLogStreamingConfig) ([]backends.StreamSink, error) {
+	var sinks []backends.StreamSink
+	if cfg.Syslog.Address.String() != "" {
+		tlsConfig, err := backends.SyslogTLSConfig(cfg.Syslog.CAFile.Value(), cfg.Syslog.CertFile.Value(), cfg.Syslog.KeyFile.Value())
+		if err != nil {
+			return nil, xerrors.Errorf("syslog: %w", err)
+		}
+		sink, err := backends.NewSyslog(backends.SyslogOptions{
+			Address:   cfg.Syslog.Address.Value(),
+			TLSConfig: tlsConfig,
+		})
+		if err != nil {
+			return nil, xerrors.Errorf("syslog: %w", err)
+		}
+		sinks = append(sinks, sink)
+	}
+	if cfg.OTLP.Endpoint.String() != "" {
+		headers, err := backends.ParseOTLPHeaders(cfg.OTLP.Headers.Value())
+		if err != nil {
+			return nil, xerrors.Errorf("otlp: %w", err)
+		}
+		sink, err := backends.NewOTLP(backends.OTLPOptions{
+			Endpoint: cfg.OTLP.Endpoint.Value(),
+			Headers:  headers,
+		})
+		if err != nil {
+			return nil, xerrors.Errorf("otlp: %w", err)
+		}
+		sinks = append(sinks, sink)
+	}
+	if cfg.S3.Bucket.Value() != "" {
+		var endpoint *url.URL
+		if cfg.S3.Endpoint.String() != "" {
+			endpoint = cfg.S3.Endpoint.Value()
+		}
+		sink, err := backends.NewS3(ctx, backends.S3Options{
+			Bucket:          cfg.S3.Bucket.Value(),
+			Region:          cfg.S3.Region.Value(),
+			Endpoint:        endpoint,
+			Prefix:          cfg.S3.Prefix.Value(),
+			AccessKeyID:     cfg.S3.AccessKeyID.Value(),
+			SecretAccessKey: cfg.S3.SecretAccessKey.Value(),
+			Interval:        cfg.S3.Interval.Value(),
+		})
+		if err != nil {
+			return nil, xerrors.Errorf("s3: %w", err)
+		}
+		sinks = append(sinks, sink)
+	}
+	return sinks, nil
+}
@@ -2,14 +2,18 @@ package coderd_test
 
 import (
 	"context"
+	"net/http"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 
+	"github.com/coder/coder/v2/coderd/coderdtest"
 	"github.com/coder/coder/v2/codersdk"
 	"github.com/coder/coder/v2/enterprise/coderd/coderdenttest"
 	"github.com/coder/coder/v2/enterprise/coderd/license"
+	"github.com/coder/coder/v2/testutil"
 )
 
 func TestEnterpriseAuditLogs(t *testing.T) {
@@ -102,4 +106,57 @@ func TestEnterpriseAuditLogs(t *testing.T) {
 		// OrganizationID is deprecated, but make sure it is empty.
 		require.Equal(t, uuid.Nil, alogs.AuditLogs[0].OrganizationID)
 	})
+
+	t.Run("Verify", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := testutil.Context(t, testutil.WaitShort)
+		client, user := coderdenttest.New(t, &coderdenttest.Options{
+			LicenseOptions: &coderdenttest.LicenseOptions{
+				Features: license.Features{
+					codersdk.FeatureAuditLog: 1,
+				},
+			},
+		})
+
+		verification, err := client.VerifyAuditLogs(ctx, codersdk.AuditLogVerifyRequest{})
+		require.NoError(t, err)
+		require.Empty(t, verification.Problems)
+		require.WithinDuration(t, verification.To.AddDate(0, 0, -30), verification.From, time.Second)
+
+		// The hash chain spans all organizations, so only site-wide auditors
+		// can verify it.
+		memberClient, _ := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+		_, err = memberClient.VerifyAuditLogs(ctx, codersdk.AuditLogVerifyRequest{})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusForbidden, apiErr.StatusCode())
+	})
+
+	t.Run("Archives", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := testutil.Context(t, testutil.WaitShort)
+		client, user := coderdenttest.New(t, &coderdenttest.Options{
+			LicenseOptions: &coderdenttest.LicenseOptions{
+				Features: license.Features{
+					codersdk.FeatureAuditLog: 1,
+				},
+			},
+		})
+
+		archives, err := client.AuditLogArchives(ctx)
+		require.NoError(t, err)
+		require.Empty(t, archives)
+
+		_, err = client.RestoreAuditLogArchive(ctx, uuid.New())
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+
+		memberClient, _ := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+		_, err = memberClient.AuditLogArchives(ctx)
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusForbidden, apiErr.StatusCode())
+	})
 }
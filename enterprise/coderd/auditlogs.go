@@ -0,0 +1,146 @@
+package coderd
+
+import (
+	"net/http"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+
+	"github.com/coder/coder/v2/coderd/database/db2sdk"
+	"github.com/coder/coder/v2/coderd/database/dbpurge"
+	"github.com/coder/coder/v2/coderd/httpapi"
+	"github.com/coder/coder/v2/coderd/httpmw"
+	"github.com/coder/coder/v2/coderd/rbac"
+	"github.com/coder/coder/v2/coderd/rbac/policy"
+	"github.com/coder/coder/v2/codersdk"
+	"github.com/coder/coder/v2/enterprise/audit"
+)
+
+// @Summary Verify audit logs
+// @Description Verifies the hash chain of the audit logs in a time range, and reports
+// @Description the audit logs which were modified or deleted.
+// @ID verify-audit-logs
+// @Security CoderSessionToken
+// @Produce json
+// @Tags Enterprise
+// @Param from query string false "Start of the time range, RFC 3339. Defaults to 30 days ago." format(date-time)
+// @Param to query string false "End of the time range, RFC 3339. Defaults to now." format(date-time)
+// @Success 200 {object} codersdk.AuditLogVerification
+// @Router /audit/verify [get]
+func (api *API) verifyAuditLogs(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	// The hash chain spans all organizations.
+	if !api.Authorize(r, policy.ActionRead, rbac.ResourceAuditLog) {
+		httpapi.Forbidden(rw)
+		return
+	}
+
+	now := time.Now()
+	qp := r.URL.Query()
+	p := httpapi.NewQueryParamParser()
+	to := p.Time3339Nano(qp, now, "to")
+	from := p.Time3339Nano(qp, to.AddDate(0, 0, -30), "from")
+	p.ErrorExcessParams(qp)
+	if len(p.Errors) > 0 {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message:     "Invalid query parameters.",
+			Validations: p.Errors,
+		})
+		return
+	}
+	if from.After(to) {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Invalid time range.",
+			Validations: []codersdk.ValidationError{
+				{Field: "from", Detail: "must not be after to"},
+			},
+		})
+		return
+	}
+
+	verification, err := audit.Verify(ctx, api.Database, api.auditLogCheckpointKeys, from, to)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error verifying audit logs.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, verification)
+}
+
+// @Summary List audit log archives
+// @Description Lists the compressed files expired audit logs were archived to
+// @Description before they were deleted, newest first.
+// @ID list-audit-log-archives
+// @Security CoderSessionToken
+// @Produce json
+// @Tags Enterprise
+// @Success 200 {array} codersdk.AuditLogArchive
+// @Router /audit/archives [get]
+func (api *API) auditLogArchives(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	// Archives span all organizations.
+	if !api.Authorize(r, policy.ActionRead, rbac.ResourceAuditLog) {
+		httpapi.Forbidden(rw)
+		return
+	}
+
+	archives, err := api.Database.GetAuditLogArchives(ctx)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching audit log archives.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, db2sdk.List(archives, db2sdk.AuditLogArchive))
+}
+
+// @Summary Restore audit log archive
+// @Description Inserts the audit logs of an archive again, for investigations.
+// @Description They are deleted again after the restore duration of the deployment.
+// @ID restore-audit-log-archive
+// @Security CoderSessionToken
+// @Produce json
+// @Tags Enterprise
+// @Param archive path string true "Archive ID" format(uuid)
+// @Success 200 {object} codersdk.AuditLogArchive
+// @Router /audit/archives/{archive}/restore [post]
+func (api *API) restoreAuditLogArchive(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if !api.Authorize(r, policy.ActionCreate, rbac.ResourceAuditLog) {
+		httpapi.Forbidden(rw)
+		return
+	}
+	id, ok := httpmw.ParseUUIDParam(rw, r, "archive")
+	if !ok {
+		return
+	}
+
+	archive, err := dbpurge.RestoreAuditLogArchive(ctx, api.Database, id, time.Now())
+	switch {
+	case httpapi.Is404Error(err):
+		httpapi.ResourceNotFound(rw)
+		return
+	case xerrors.Is(err, dbpurge.ErrAuditLogArchiveRestored):
+		httpapi.Write(ctx, rw, http.StatusConflict, codersdk.Response{
+			Message: "The audit logs of this archive are restored already.",
+		})
+		return
+	case err != nil:
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error restoring audit log archive.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	api.Logger.Info(ctx, "restored audit log archive",
+		slog.F("archive_id", archive.ID),
+		slog.F("audit_logs", archive.AuditLogCount),
+		slog.F("user_id", httpmw.APIKey(r).UserID),
+	)
+	httpapi.Write(ctx, rw, http.StatusOK, db2sdk.AuditLogArchive(archive))
+}
@@ -0,0 +1,222 @@
+package coderd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/v2/coderd/database"
+	"github.com/coder/coder/v2/coderd/database/dbauthz"
+	"github.com/coder/coder/v2/coderd/httpapi"
+	"github.com/coder/coder/v2/coderd/httpmw"
+	agplschedule "github.com/coder/coder/v2/coderd/schedule"
+	"github.com/coder/coder/v2/codersdk"
+	"github.com/coder/coder/v2/enterprise/coderd/schedule"
+)
+
+// @Summary Get template autostop windows
+// @ID get-template-autostop-windows
+// @Security CoderSessionToken
+// @Produce json
+// @Tags Enterprise
+// @Param template path string true "Template ID" format(uuid)
+// @Success 200 {object} codersdk.AutostopWindows
+// @Router /templates/{template}/autostop-windows [get]
+func (api *API) templateAutostopWindows(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx      = r.Context()
+		template = httpmw.TemplateParam(r)
+	)
+
+	opts, err := (*api.AGPL.TemplateScheduleStore.Load()).Get(ctx, api.Database, template.ID)
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, convertAutostopWindows(opts.AutostopWindows))
+}
+
+// @Summary Update template autostop windows
+// @Description Replaces the autostop windows of a template, and updates the
+// @Description deadlines of its running workspaces.
+// @ID update-template-autostop-windows
+// @Security CoderSessionToken
+// @Accept json
+// @Produce json
+// @Tags Enterprise
+// @Param template path string true "Template ID" format(uuid)
+// @Param request body codersdk.AutostopWindows true "Autostop windows"
+// @Success 200 {object} codersdk.AutostopWindows
+// @Router /templates/{template}/autostop-windows [put]
+func (api *API) putTemplateAutostopWindows(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx      = r.Context()
+		template = httpmw.TemplateParam(r)
+		req      codersdk.AutostopWindows
+	)
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+	windows, ok := parseAutostopWindows(ctx, rw, req)
+	if !ok {
+		return
+	}
+
+	store, ok := (*api.AGPL.TemplateScheduleStore.Load()).(*schedule.EnterpriseTemplateScheduleStore)
+	if !ok {
+		httpapi.Write(ctx, rw, http.StatusForbidden, codersdk.Response{
+			Message: "Advanced template scheduling is not enabled.",
+		})
+		return
+	}
+	err := store.SetAutostopWindows(ctx, api.Database, template, windows)
+	if dbauthz.IsNotAuthorizedError(err) {
+		httpapi.Forbidden(rw)
+		return
+	}
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, convertAutostopWindows(windows))
+}
+
+// @Summary Get user autostop windows
+// @ID get-user-autostop-windows
+// @Security CoderSessionToken
+// @Produce json
+// @Tags Enterprise
+// @Param user path string true "User ID" format(uuid)
+// @Success 200 {object} codersdk.AutostopWindows
+// @Router /users/{user}/autostop-windows [get]
+func (api *API) userAutostopWindows(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx  = r.Context()
+		user = httpmw.UserParam(r)
+	)
+
+	opts, err := (*api.UserQuietHoursScheduleStore.Load()).Get(ctx, api.Database, user.ID)
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, convertAutostopWindows(opts.AutostopWindows))
+}
+
+// @Summary Update user autostop windows
+// @Description Replaces the autostop windows of a user. They replace the
+// @Description windows of templates which allow users to set their own autostop.
+// @ID update-user-autostop-windows
+// @Security CoderSessionToken
+// @Accept json
+// @Produce json
+// @Tags Enterprise
+// @Param user path string true "User ID" format(uuid)
+// @Param request body codersdk.AutostopWindows true "Autostop windows"
+// @Success 200 {object} codersdk.AutostopWindows
+// @Router /users/{user}/autostop-windows [put]
+func (api *API) putUserAutostopWindows(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx  = r.Context()
+		user = httpmw.UserParam(r)
+		req  codersdk.AutostopWindows
+	)
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+	windows, ok := parseAutostopWindows(ctx, rw, req)
+	if !ok {
+		return
+	}
+
+	// Like the quiet hours schedule, the deadlines of running workspaces are
+	// not updated, so users can't keep their workspaces running forever.
+	weekdays, stopMinutes := windows.Columns()
+	err := api.Database.UpdateUserAutostopWindows(ctx, database.UpdateUserAutostopWindowsParams{
+		UserID:      user.ID,
+		Weekdays:    weekdays,
+		StopMinutes: stopMinutes,
+	})
+	if dbauthz.IsNotAuthorizedError(err) {
+		httpapi.Forbidden(rw)
+		return
+	}
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, convertAutostopWindows(windows))
+}
+
+// parseAutostopWindows converts autostop windows from a request, and writes a
+// bad request response if they are invalid.
+func parseAutostopWindows(ctx context.Context, rw http.ResponseWriter, req codersdk.AutostopWindows) (agplschedule.AutostopWindows, bool) {
+	windows := make(agplschedule.AutostopWindows, len(req.Windows))
+	var validations []codersdk.ValidationError
+	for i, window := range req.Windows {
+		day, err := parseWeekday(window.Weekday)
+		if err != nil {
+			validations = append(validations, codersdk.ValidationError{
+				Field:  fmt.Sprintf("windows[%d].weekday", i),
+				Detail: err.Error(),
+			})
+			continue
+		}
+		if _, ok := windows[day]; ok {
+			validations = append(validations, codersdk.ValidationError{
+				Field:  fmt.Sprintf("windows[%d].weekday", i),
+				Detail: fmt.Sprintf("%s has more than one window", window.Weekday),
+			})
+			continue
+		}
+		stop, err := time.Parse(TimeFormatHHMM, window.Time)
+		if err != nil {
+			validations = append(validations, codersdk.ValidationError{
+				Field:  fmt.Sprintf("windows[%d].time", i),
+				Detail: fmt.Sprintf("time %q must be in HH:MM 24-hour format", window.Time),
+			})
+			continue
+		}
+		windows[day] = time.Duration(stop.Hour())*time.Hour + time.Duration(stop.Minute())*time.Minute
+	}
+	if len(validations) > 0 {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message:     "Invalid autostop windows.",
+			Validations: validations,
+		})
+		return nil, false
+	}
+	return windows, true
+}
+
+func parseWeekday(name string) (time.Weekday, error) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(day.String(), name) {
+			return day, nil
+		}
+	}
+	return 0, xerrors.Errorf("invalid day of the week %q", name)
+}
+
+func convertAutostopWindows(windows agplschedule.AutostopWindows) codersdk.AutostopWindows {
+	resp := codersdk.AutostopWindows{
+		Windows: make([]codersdk.AutostopWindow, 0, len(windows)),
+	}
+	// List the windows from Monday, like the days of the week of the
+	// autostop requirement.
+	for _, day := range agplschedule.DaysOfWeek {
+		stop, ok := windows[day]
+		if !ok {
+			continue
+		}
+		resp.Windows = append(resp.Windows, codersdk.AutostopWindow{
+			Weekday: strings.ToLower(day.String()),
+			Time:    time.Date(0, 1, 1, 0, 0, 0, 0, time.UTC).Add(stop).Format(TimeFormatHHMM),
+		})
+	}
+	return resp
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/ed25519"
 	"fmt"
+	"io"
 	"math"
 	"net/http"
 	"net/url"
@@ -36,6 +37,7 @@ import (
 
 	"github.com/coder/coder/v2/coderd"
 	agplaudit "github.com/coder/coder/v2/coderd/audit"
+	"github.com/coder/coder/v2/coderd/cryptokeys"
 	agpldbauthz "github.com/coder/coder/v2/coderd/database/dbauthz"
 	"github.com/coder/coder/v2/coderd/database/dbtime"
 	"github.com/coder/coder/v2/coderd/healthcheck"
@@ -44,6 +46,7 @@ import (
 	"github.com/coder/coder/v2/coderd/rbac"
 	agplschedule "github.com/coder/coder/v2/coderd/schedule"
 	"github.com/coder/coder/v2/codersdk"
+	"github.com/coder/coder/v2/enterprise/audit"
 	"github.com/coder/coder/v2/enterprise/coderd/dbauthz"
 	"github.com/coder/coder/v2/enterprise/coderd/license"
 	"github.com/coder/coder/v2/enterprise/coderd/prebuilds"
@@ -427,6 +430,25 @@ func New(ctx context.Context, options *Options) (_ *API, err error) {
 			r.Get("/", api.templateACL)
 			r.Patch("/", api.patchTemplateACL)
 		})
+		r.Route("/templates/{template}/autostop-windows", func(r chi.Router) {
+			r.Use(
+				api.autostopRequirementEnabledMW,
+				apiKeyMiddleware,
+				httpmw.ExtractTemplateParam(api.Database),
+			)
+			r.Get("/", api.templateAutostopWindows)
+			r.Put("/", api.putTemplateAutostopWindows)
+		})
+		r.Route("/organizations/{organization}/holidays", func(r chi.Router) {
+			r.Use(
+				api.autostopRequirementEnabledMW,
+				apiKeyMiddleware,
+				httpmw.ExtractOrganizationParam(api.Database),
+			)
+			r.Get("/", api.organizationHolidays)
+			r.Put("/{calendar}", api.putOrganizationHolidayCalendar)
+			r.Delete("/{calendar}", api.deleteOrganizationHolidayCalendar)
+		})
 		r.Route("/groups", func(r chi.Router) {
 			r.Use(
 				api.templateRBACEnabledMW,
@@ -480,6 +502,16 @@ func New(ctx context.Context, options *Options) (_ *API, err error) {
 			r.Get("/", api.userQuietHoursSchedule)
 			r.Put("/", api.putUserQuietHoursSchedule)
 		})
+		r.Route("/users/{user}/autostop-windows", func(r chi.Router) {
+			r.Use(
+				api.autostopRequirementEnabledMW,
+				apiKeyMiddleware,
+				httpmw.ExtractUserParam(options.Database),
+			)
+
+			r.Get("/", api.userAutostopWindows)
+			r.Put("/", api.putUserAutostopWindows)
+		})
 		r.Route("/prebuilds", func(r chi.Router) {
 			r.Use(
 				apiKeyMiddleware,
@@ -495,6 +527,19 @@ func New(ctx context.Context, options *Options) (_ *API, err error) {
 			apiKeyMiddleware,
 			httpmw.ExtractNotificationTemplateParam(options.Database),
 		).Put("/notifications/templates/{notification_template}/method", api.updateNotificationTemplateMethod)
+		// The /audit base route is mounted by the AGPL router as well.
+		r.With(
+			apiKeyMiddleware,
+			api.RequireFeatureMW(codersdk.FeatureAuditLog),
+		).Get("/audit/verify", api.verifyAuditLogs)
+		r.With(
+			apiKeyMiddleware,
+			api.RequireFeatureMW(codersdk.FeatureAuditLog),
+		).Get("/audit/archives", api.auditLogArchives)
+		r.With(
+			apiKeyMiddleware,
+			api.RequireFeatureMW(codersdk.FeatureAuditLog),
+		).Post("/audit/archives/{archive}/restore", api.restoreAuditLogArchive)
 	})
 
 	if len(options.SCIMAPIKey) != 0 {
@@ -582,6 +627,20 @@ func New(ctx context.Context, options *Options) (_ *API, err error) {
 	}
 	api.AGPL.WorkspaceProxiesFetchUpdater.Store(&fetchUpdater)
 
+	// Audit logs are appended to the hash chain in the background, and its
+	// head is signed periodically, so it can't be rewritten by someone
+	// without the signing key.
+	api.auditLogCheckpointKeys, err = cryptokeys.NewSigningCache(ctx,
+		options.Logger.Named("audit_log_checkpoint_keys"),
+		&cryptokeys.DBFetcher{DB: options.Database},
+		codersdk.CryptoKeyFeatureAuditLogCheckpoint,
+	)
+	if err != nil {
+		return nil, xerrors.Errorf("initialize audit log checkpoint keys: %w", err)
+	}
+	api.auditLogChainer = audit.NewChainer(ctx, options.Logger.Named("audit_log_chainer"), options.Database, quartz.NewReal())
+	api.auditLogCheckpointer = audit.NewCheckpointer(ctx, options.Logger.Named("audit_log_checkpointer"), options.Database, api.auditLogCheckpointKeys, quartz.NewReal())
+
 	err = api.PrometheusRegistry.Register(api.licenseMetricsCollector)
 	if err != nil {
 		return nil, xerrors.Errorf("unable to register license metrics collector")
@@ -624,6 +683,10 @@ type Options struct {
 	ProvisionerDaemonPSK string
 
 	CheckInactiveUsersCancelFunc func()
+
+	// AuditLogStreamer streams audit logs to external systems. It is closed
+	// with the API.
+	AuditLogStreamer io.Closer
 }
 
 type API struct {
@@ -646,6 +709,12 @@ type API struct {
 
 	licenseMetricsCollector *license.MetricsCollector
 	tailnetService          *tailnet.ClientService
+
+	// auditLogCheckpointKeys sign and verify the checkpoints of the audit
+	// log hash chain.
+	auditLogCheckpointKeys cryptokeys.SigningKeycache
+	auditLogChainer        io.Closer
+	auditLogCheckpointer   io.Closer
 }
 
 // writeEntitlementWarningsHeader writes the entitlement warnings to the response header
@@ -677,6 +746,19 @@ func (api *API) Close() error {
 		api.Options.CheckInactiveUsersCancelFunc()
 	}
 
+	if api.Options.AuditLogStreamer != nil {
+		_ = api.Options.AuditLogStreamer.Close()
+	}
+	if api.auditLogChainer != nil {
+		_ = api.auditLogChainer.Close()
+	}
+	if api.auditLogCheckpointer != nil {
+		_ = api.auditLogCheckpointer.Close()
+	}
+	if api.auditLogCheckpointKeys != nil {
+		_ = api.auditLogCheckpointKeys.Close()
+	}
+
 	return api.AGPL.Close()
 }
 
@@ -0,0 +1,201 @@
+package coderd
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/coder/coder/v2/coderd/database"
+	"github.com/coder/coder/v2/coderd/database/db2sdk"
+	"github.com/coder/coder/v2/coderd/database/dbauthz"
+	"github.com/coder/coder/v2/coderd/database/dbtime"
+	"github.com/coder/coder/v2/coderd/httpapi"
+	"github.com/coder/coder/v2/coderd/httpmw"
+	agplschedule "github.com/coder/coder/v2/coderd/schedule"
+	"github.com/coder/coder/v2/codersdk"
+)
+
+const (
+	// holidayCalendarYears is how many years ahead recurring holidays are
+	// imported for. Calendars have to be imported again after that.
+	holidayCalendarYears = 2
+	// maxCalendarHolidays bounds the holidays of a single calendar.
+	maxCalendarHolidays = 1000
+)
+
+// @Summary Get organization holidays
+// @Description Returns the upcoming holidays of all calendars of an
+// @Description organization. Workspaces are not autostarted on holidays.
+// @ID get-organization-holidays
+// @Security CoderSessionToken
+// @Produce json
+// @Tags Enterprise
+// @Param organization path string true "Organization ID" format(uuid)
+// @Success 200 {array} codersdk.OrganizationHoliday
+// @Router /organizations/{organization}/holidays [get]
+func (api *API) organizationHolidays(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx          = r.Context()
+		organization = httpmw.OrganizationParam(r)
+	)
+
+	holidays, err := api.Database.GetOrganizationHolidays(ctx, database.GetOrganizationHolidaysParams{
+		OrganizationID: organization.ID,
+		// Include yesterday, as it may still be a holiday in timezones
+		// behind UTC.
+		After: dbtime.Now().AddDate(0, 0, -1),
+	})
+	if dbauthz.IsNotAuthorizedError(err) {
+		httpapi.Forbidden(rw)
+		return
+	}
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, db2sdk.List(holidays, convertOrganizationHoliday))
+}
+
+// @Summary Import organization holiday calendar
+// @Description Imports the holidays of an iCalendar file, replacing the
+// @Description holidays previously imported under the same calendar name.
+// @ID import-organization-holiday-calendar
+// @Security CoderSessionToken
+// @Accept json
+// @Produce json
+// @Tags Enterprise
+// @Param organization path string true "Organization ID" format(uuid)
+// @Param calendar path string true "Calendar name"
+// @Param request body codersdk.ImportHolidayCalendarRequest true "Import holiday calendar request"
+// @Success 200 {array} codersdk.OrganizationHoliday
+// @Router /organizations/{organization}/holidays/{calendar} [put]
+func (api *API) putOrganizationHolidayCalendar(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx          = r.Context()
+		organization = httpmw.OrganizationParam(r)
+		calendar     = chi.URLParam(r, "calendar")
+		req          codersdk.ImportHolidayCalendarRequest
+	)
+	if err := codersdk.NameValid(calendar); err != nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Invalid calendar name.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+
+	now := dbtime.Now()
+	parsed, err := agplschedule.ParseICalendar(strings.NewReader(req.ICalendar), now.AddDate(holidayCalendarYears, 0, 0))
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Invalid iCalendar file.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	// Events may overlap, a day is only stored once per calendar with the name
+	// of the first event on it.
+	params := database.UpdateOrganizationHolidayCalendarParams{
+		OrganizationID: organization.ID,
+		Calendar:       calendar,
+		IDs:            []uuid.UUID{},
+		Dates:          []time.Time{},
+		Names:          []string{},
+		CreatedAt:      now,
+	}
+	seen := make(map[string]struct{}, len(parsed))
+	for _, holiday := range parsed {
+		key := holiday.Date.Format(time.DateOnly)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		params.IDs = append(params.IDs, uuid.New())
+		params.Dates = append(params.Dates, holiday.Date)
+		params.Names = append(params.Names, holiday.Name)
+	}
+	if len(params.IDs) > maxCalendarHolidays {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Too many holidays.",
+			Detail:  fmt.Sprintf("The calendar has %d holidays, the maximum is %d.", len(params.IDs), maxCalendarHolidays),
+		})
+		return
+	}
+
+	err = api.Database.UpdateOrganizationHolidayCalendar(ctx, params)
+	if dbauthz.IsNotAuthorizedError(err) {
+		httpapi.Forbidden(rw)
+		return
+	}
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return
+	}
+
+	holidays, err := api.Database.GetOrganizationHolidays(ctx, database.GetOrganizationHolidaysParams{
+		OrganizationID: organization.ID,
+		After:          now.AddDate(0, 0, -1),
+	})
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return
+	}
+	resp := make([]codersdk.OrganizationHoliday, 0, len(holidays))
+	for _, holiday := range holidays {
+		if holiday.Calendar != calendar {
+			continue
+		}
+		resp = append(resp, convertOrganizationHoliday(holiday))
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, resp)
+}
+
+// @Summary Delete organization holiday calendar
+// @ID delete-organization-holiday-calendar
+// @Security CoderSessionToken
+// @Tags Enterprise
+// @Param organization path string true "Organization ID" format(uuid)
+// @Param calendar path string true "Calendar name"
+// @Success 204
+// @Router /organizations/{organization}/holidays/{calendar} [delete]
+func (api *API) deleteOrganizationHolidayCalendar(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx          = r.Context()
+		organization = httpmw.OrganizationParam(r)
+		calendar     = chi.URLParam(r, "calendar")
+	)
+
+	err := api.Database.UpdateOrganizationHolidayCalendar(ctx, database.UpdateOrganizationHolidayCalendarParams{
+		OrganizationID: organization.ID,
+		Calendar:       calendar,
+		IDs:            []uuid.UUID{},
+		Dates:          []time.Time{},
+		Names:          []string{},
+		CreatedAt:      dbtime.Now(),
+	})
+	if dbauthz.IsNotAuthorizedError(err) {
+		httpapi.Forbidden(rw)
+		return
+	}
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+func convertOrganizationHoliday(holiday database.OrganizationHoliday) codersdk.OrganizationHoliday {
+	return codersdk.OrganizationHoliday{
+		Calendar: holiday.Calendar,
+		Date:     holiday.Date.Format(time.DateOnly),
+		Name:     holiday.Name,
+	}
+}
@@ -185,6 +185,10 @@ func (api *API) provisionerDaemonServe(rw http.ResponseWriter, r *http.Request)
 			provisionersMap[codersdk.ProvisionerTypeEcho] = struct{}{}
 		case string(codersdk.ProvisionerTypeTerraform):
 			provisionersMap[codersdk.ProvisionerTypeTerraform] = struct{}{}
+		case string(codersdk.ProvisionerTypeTofu):
+			provisionersMap[codersdk.ProvisionerTypeTofu] = struct{}{}
+		case string(codersdk.ProvisionerTypeExternal):
+			provisionersMap[codersdk.ProvisionerTypeExternal] = struct{}{}
 		default:
 			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
 				Message: fmt.Sprintf("Unknown provisioner type %q", provisioner),
@@ -193,6 +197,27 @@ func (api *API) provisionerDaemonServe(rw http.ResponseWriter, r *http.Request)
 		}
 	}
 
+	// Provisioner versions are advertised separately from tags, as daemons
+	// authenticated by a provisioner key cannot set tags.
+	versionTags := map[string]string{}
+	for _, raw := range r.URL.Query()["provisioner_version"] {
+		v, err := codersdk.ParseProvisionerBinaryVersion(raw)
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: fmt.Sprintf("Invalid provisioner version %q.", raw),
+				Detail:  err.Error(),
+			})
+			return
+		}
+		if _, ok := provisionersMap[v.Provisioner]; !ok {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: fmt.Sprintf("Provisioner version %q is for a provisioner the daemon does not serve.", raw),
+			})
+			return
+		}
+		versionTags[provisionersdk.ProvisionerVersionTag(string(v.Provisioner), v.Version)] = "true"
+	}
+
 	name := namesgenerator.GetRandomName(10)
 	if vals, ok := r.URL.Query()["name"]; ok && len(vals) > 0 {
 		name = vals[0]
@@ -212,6 +237,9 @@ func (api *API) provisionerDaemonServe(rw http.ResponseWriter, r *http.Request)
 		return
 	}
 	tags = authRes.tags
+	for k, v := range versionTags {
+		tags[k] = v
+	}
 
 	api.Logger.Debug(ctx, "provisioner authorized", slog.F("tags", tags))
 	if err := provisionerdserver.Tags(tags).Valid(); err != nil {
@@ -229,6 +257,10 @@ func (api *API) provisionerDaemonServe(rw http.ResponseWriter, r *http.Request)
 		switch p {
 		case codersdk.ProvisionerTypeTerraform:
 			provisioners = append(provisioners, database.ProvisionerTypeTerraform)
+		case codersdk.ProvisionerTypeTofu:
+			provisioners = append(provisioners, database.ProvisionerTypeTofu)
+		case codersdk.ProvisionerTypeExternal:
+			provisioners = append(provisioners, database.ProvisionerTypeExternal)
 		case codersdk.ProvisionerTypeEcho:
 			provisioners = append(provisioners, database.ProvisionerTypeEcho)
 		}
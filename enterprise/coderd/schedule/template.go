@@ -58,7 +58,7 @@ func (s *EnterpriseTemplateScheduleStore) now() time.Time {
 }
 
 // Get implements agpl.TemplateScheduleStore.
-func (*EnterpriseTemplateScheduleStore) Get(ctx context.Context, db database.Store, templateID uuid.UUID) (agpl.TemplateScheduleOptions, error) {
+func (s *EnterpriseTemplateScheduleStore) Get(ctx context.Context, db database.Store, templateID uuid.UUID) (agpl.TemplateScheduleOptions, error) {
 	ctx, span := tracing.StartSpan(ctx)
 	defer span.End()
 
@@ -84,6 +84,31 @@ func (*EnterpriseTemplateScheduleStore) Get(ctx context.Context, db database.Sto
 		return agpl.TemplateScheduleOptions{}, err
 	}
 
+	windows, err := db.GetTemplateAutostopWindows(ctx, templateID)
+	if err != nil {
+		return agpl.TemplateScheduleOptions{}, xerrors.Errorf("get template autostop windows: %w", err)
+	}
+	autostopWindows := make(agpl.AutostopWindows, len(windows))
+	for _, window := range windows {
+		autostopWindows[time.Weekday(window.Weekday)] = time.Duration(window.StopMinute) * time.Minute
+	}
+
+	// Holidays apply to every workspace of the organization, so they are
+	// read regardless of who reads the template schedule. Start a day early,
+	// as it may still be yesterday in the timezone of a workspace.
+	//nolint:gocritic // Holidays are not sensitive, and are needed to autostart workspaces.
+	holidays, err := db.GetOrganizationHolidays(dbauthz.AsSystemRestricted(ctx), database.GetOrganizationHolidaysParams{
+		OrganizationID: tpl.OrganizationID,
+		After:          s.now().AddDate(0, 0, -1),
+	})
+	if err != nil {
+		return agpl.TemplateScheduleOptions{}, xerrors.Errorf("get organization holidays: %w", err)
+	}
+	organizationHolidays := make(agpl.Holidays, len(holidays))
+	for _, holiday := range holidays {
+		organizationHolidays[holiday.Date.Format(time.DateOnly)] = holiday.Name
+	}
+
 	return agpl.TemplateScheduleOptions{
 		UserAutostartEnabled: tpl.AllowUserAutostart,
 		UserAutostopEnabled:  tpl.AllowUserAutostop,
@@ -97,12 +122,45 @@ func (*EnterpriseTemplateScheduleStore) Get(ctx context.Context, db database.Sto
 		AutostartRequirement: agpl.TemplateAutostartRequirement{
 			DaysOfWeek: tpl.AutostartAllowedDays(),
 		},
+		AutostopWindows:          autostopWindows,
+		Holidays:                 organizationHolidays,
 		FailureTTL:               time.Duration(tpl.FailureTTL),
 		TimeTilDormant:           time.Duration(tpl.TimeTilDormant),
 		TimeTilDormantAutoDelete: time.Duration(tpl.TimeTilDormantAutoDelete),
 	}, nil
 }
 
+// SetAutostopWindows replaces the autostop windows of a template, and
+// recalculates the deadlines of its running workspaces.
+func (s *EnterpriseTemplateScheduleStore) SetAutostopWindows(ctx context.Context, db database.Store, tpl database.Template, windows agpl.AutostopWindows) error {
+	ctx, span := tracing.StartSpan(ctx)
+	defer span.End()
+
+	if err := windows.Verify(); err != nil {
+		return xerrors.Errorf("verify autostop windows: %w", err)
+	}
+
+	weekdays, stopMinutes := windows.Columns()
+	return db.InTx(func(tx database.Store) error {
+		err := tx.UpdateTemplateAutostopWindows(ctx, database.UpdateTemplateAutostopWindowsParams{
+			TemplateID:  tpl.ID,
+			Weekdays:    weekdays,
+			StopMinutes: stopMinutes,
+		})
+		if err != nil {
+			return xerrors.Errorf("update template autostop windows: %w", err)
+		}
+
+		// Recalculate max_deadline and deadline for all running workspace
+		// builds on this template.
+		err = s.updateWorkspaceBuilds(ctx, tx, tpl)
+		if err != nil {
+			return xerrors.Errorf("update workspace builds: %w", err)
+		}
+		return nil
+	}, nil)
+}
+
 // Set implements agpl.TemplateScheduleStore.
 func (s *EnterpriseTemplateScheduleStore) Set(ctx context.Context, db database.Store, tpl database.Template, opts agpl.TemplateScheduleOptions) (database.Template, error) {
 	ctx, span := tracing.StartSpan(ctx)
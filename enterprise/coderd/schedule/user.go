@@ -3,6 +3,7 @@ package schedule
 import (
 	"context"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"golang.org/x/xerrors"
@@ -76,16 +77,29 @@ func (s *enterpriseUserQuietHoursScheduleStore) Get(ctx context.Context, db data
 	ctx, span := tracing.StartSpan(ctx)
 	defer span.End()
 
-	if !s.userCanSet {
-		return s.parseSchedule(ctx, "")
+	rawSchedule := ""
+	if s.userCanSet {
+		user, err := db.GetUserByID(ctx, userID)
+		if err != nil {
+			return agpl.UserQuietHoursScheduleOptions{}, xerrors.Errorf("get user by ID: %w", err)
+		}
+		rawSchedule = user.QuietHoursSchedule
 	}
 
-	user, err := db.GetUserByID(ctx, userID)
+	opts, err := s.parseSchedule(ctx, rawSchedule)
 	if err != nil {
-		return agpl.UserQuietHoursScheduleOptions{}, xerrors.Errorf("get user by ID: %w", err)
+		return opts, err
 	}
 
-	return s.parseSchedule(ctx, user.QuietHoursSchedule)
+	windows, err := db.GetUserAutostopWindows(ctx, userID)
+	if err != nil {
+		return agpl.UserQuietHoursScheduleOptions{}, xerrors.Errorf("get user autostop windows: %w", err)
+	}
+	opts.AutostopWindows = make(agpl.AutostopWindows, len(windows))
+	for _, window := range windows {
+		opts.AutostopWindows[time.Weekday(window.Weekday)] = time.Duration(window.StopMinute) * time.Minute
+	}
+	return opts, nil
 }
 
 func (s *enterpriseUserQuietHoursScheduleStore) Set(ctx context.Context, db database.Store, userID uuid.UUID, rawSchedule string) (agpl.UserQuietHoursScheduleOptions, error) {
@@ -12,7 +12,8 @@ import (
 )
 
 // Rotate rotates the database encryption keys by re-encrypting all user tokens
-// with the first cipher and revoking all other ciphers.
+// and workspace build states with the first cipher and revoking all other
+// ciphers.
 func Rotate(ctx context.Context, log slog.Logger, sqlDB *sql.DB, ciphers []Cipher) error {
 	db := database.New(sqlDB)
 	cryptDB, err := New(ctx, db, ciphers...)
@@ -83,6 +84,13 @@ func Rotate(ctx context.Context, log slog.Logger, sqlDB *sql.DB, ciphers []Ciphe
 		log.Debug(ctx, "encrypted user tokens", slog.F("user_id", uid), slog.F("current", idx+1), slog.F("cipher", ciphers[0].HexDigest()))
 	}
 
+	err = reencryptWorkspaceBuildStates(ctx, log, db, cryptDB, func(keyID sql.NullString) bool {
+		return keyID.String == ciphers[0].HexDigest()
+	})
+	if err != nil {
+		return err
+	}
+
 	// Revoke old keys
 	for _, c := range ciphers[1:] {
 		if err := db.RevokeDBCryptKey(ctx, c.HexDigest()); err != nil {
@@ -94,7 +102,8 @@ func Rotate(ctx context.Context, log slog.Logger, sqlDB *sql.DB, ciphers []Ciphe
 	return nil
 }
 
-// Decrypt decrypts all user tokens and revokes all ciphers.
+// Decrypt decrypts all user tokens and workspace build states and revokes all
+// ciphers.
 func Decrypt(ctx context.Context, log slog.Logger, sqlDB *sql.DB, ciphers []Cipher) error {
 	db := database.New(sqlDB)
 	cdb, err := New(ctx, db, ciphers...)
@@ -173,6 +182,13 @@ func Decrypt(ctx context.Context, log slog.Logger, sqlDB *sql.DB, ciphers []Ciph
 		log.Debug(ctx, "decrypted user tokens", slog.F("user_id", uid), slog.F("current", idx+1), slog.F("cipher", ciphers[0].HexDigest()))
 	}
 
+	err = reencryptWorkspaceBuildStates(ctx, log, db, cryptDB, func(keyID sql.NullString) bool {
+		return !keyID.Valid
+	})
+	if err != nil {
+		return err
+	}
+
 	// Revoke _all_ keys
 	for _, c := range ciphers {
 		if err := db.RevokeDBCryptKey(ctx, c.HexDigest()); err != nil {
@@ -184,6 +200,45 @@ func Decrypt(ctx context.Context, log slog.Logger, sqlDB *sql.DB, ciphers []Ciph
 	return nil
 }
 
+// reencryptWorkspaceBuildStates writes every workspace build state back
+// through cryptDB, which encrypts it with its primary cipher, if any. States
+// for which skip returns true are left as they are.
+func reencryptWorkspaceBuildStates(ctx context.Context, log slog.Logger, db database.Store, cryptDB database.Store, skip func(keyID sql.NullString) bool) error {
+	keyIDs, err := db.GetWorkspaceBuildStateKeyIDs(ctx)
+	if err != nil {
+		return xerrors.Errorf("get workspace build states: %w", err)
+	}
+	log.Info(ctx, "re-encrypting workspace build states", slog.F("state_count", len(keyIDs)))
+	for idx, row := range keyIDs {
+		if skip(row.StateKeyID) {
+			log.Debug(ctx, "skipping workspace build state", slog.F("workspace_build_id", row.WorkspaceBuildID), slog.F("current", idx+1))
+			continue
+		}
+		err := cryptDB.InTx(func(tx database.Store) error {
+			state, err := tx.GetWorkspaceBuildStateByBuildID(ctx, row.WorkspaceBuildID)
+			if err != nil {
+				return xerrors.Errorf("get workspace build state: %w", err)
+			}
+			return tx.UpsertWorkspaceBuildState(ctx, database.UpsertWorkspaceBuildStateParams{
+				WorkspaceBuildID: state.WorkspaceBuildID,
+				WorkspaceID:      state.WorkspaceID,
+				State:            state.State,
+				StateKeyID:       sql.NullString{}, // dbcrypt will update as required
+				Hash:             state.Hash,
+				Size:             state.Size,
+				CreatedAt:        state.CreatedAt,
+			})
+		}, &database.TxOptions{
+			Isolation: sql.LevelRepeatableRead,
+		})
+		if err != nil {
+			return xerrors.Errorf("update workspace build state workspace_build_id=%s: %w", row.WorkspaceBuildID, err)
+		}
+		log.Debug(ctx, "re-encrypted workspace build state", slog.F("workspace_build_id", row.WorkspaceBuildID), slog.F("current", idx+1))
+	}
+	return nil
+}
+
 // nolint: gosec
 const sqlDeleteEncryptedUserTokens = `
 BEGIN;
@@ -193,10 +248,13 @@ DELETE FROM user_links
 DELETE FROM external_auth_links
 	WHERE oauth_access_token_key_id IS NOT NULL
 	OR oauth_refresh_token_key_id IS NOT NULL;
+DELETE FROM workspace_build_states
+	WHERE state_key_id IS NOT NULL;
 COMMIT;
 `
 
-// Delete deletes all user tokens and revokes all ciphers.
+// Delete deletes all user tokens and encrypted workspace build states, and
+// revokes all ciphers.
 // This is a destructive operation and should only be used
 // as a last resort, for example, if the database encryption key has been
 // lost.
@@ -351,6 +351,24 @@ func (db *dbCrypt) GetCryptoKeysByFeature(ctx context.Context, feature database.
 	return keys, nil
 }
 
+func (db *dbCrypt) GetWorkspaceBuildStateByBuildID(ctx context.Context, workspaceBuildID uuid.UUID) (database.WorkspaceBuildState, error) {
+	state, err := db.Store.GetWorkspaceBuildStateByBuildID(ctx, workspaceBuildID)
+	if err != nil {
+		return database.WorkspaceBuildState{}, err
+	}
+	if err := db.decryptBytesField(&state.State, state.StateKeyID); err != nil {
+		return database.WorkspaceBuildState{}, err
+	}
+	return state, nil
+}
+
+func (db *dbCrypt) UpsertWorkspaceBuildState(ctx context.Context, params database.UpsertWorkspaceBuildStateParams) error {
+	if err := db.encryptBytesField(&params.State, &params.StateKeyID); err != nil {
+		return err
+	}
+	return db.Store.UpsertWorkspaceBuildState(ctx, params)
+}
+
 func (db *dbCrypt) encryptField(field *string, digest *sql.NullString) error {
 	// If no cipher is loaded, then we can't encrypt anything!
 	if db.ciphers == nil || db.primaryCipherDigest == "" {
@@ -408,6 +426,32 @@ func (db *dbCrypt) decryptField(field *string, digest sql.NullString) error {
 	return nil
 }
 
+// encryptBytesField encrypts a bytea field the same way as a text field.
+func (db *dbCrypt) encryptBytesField(field *[]byte, digest *sql.NullString) error {
+	if field == nil {
+		return xerrors.Errorf("developer error: encryptBytesField called with nil field")
+	}
+	value := string(*field)
+	if err := db.encryptField(&value, digest); err != nil {
+		return err
+	}
+	*field = []byte(value)
+	return nil
+}
+
+// decryptBytesField decrypts a bytea field encrypted by encryptBytesField.
+func (db *dbCrypt) decryptBytesField(field *[]byte, digest sql.NullString) error {
+	if field == nil {
+		return xerrors.Errorf("developer error: decryptBytesField called with nil field")
+	}
+	value := string(*field)
+	if err := db.decryptField(&value, digest); err != nil {
+		return err
+	}
+	*field = []byte(value)
+	return nil
+}
+
 func (db *dbCrypt) ensureEncryptedWithRetry(ctx context.Context) error {
 	var err error
 	for i := 0; i < 3; i++ {
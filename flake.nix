@@ -160,6 +160,7 @@
             pnpm
             postgresql_16
             proto_gen_go_1_30
+            protoc-gen-go-grpc
             protobuf_23
             ripgrep
             shellcheck
@@ -0,0 +1,474 @@
+package external
+
+import (
+	"encoding/json"
+	"strings"
+
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/v2/provisionersdk/proto"
+)
+
+// Stage is the stage of a provisioner job the executable is invoked for. It
+// is passed as the only argument, and as the stage of the request.
+type Stage string
+
+const (
+	// StageParse describes the template variables and workspace tags of a
+	// template version. It runs when a template version is imported.
+	StageParse Stage = "parse"
+	// StagePlan describes the parameters, external auth providers and
+	// resources that an apply would create, without changing anything.
+	StagePlan Stage = "plan"
+	// StageApply starts, stops or deletes the workspace by the transition of
+	// the request, and describes the resources that exist afterwards.
+	StageApply Stage = "apply"
+)
+
+// Request is written as JSON to the standard input of the executable.
+type Request struct {
+	Stage Stage `json:"stage"`
+	// Transition is start, stop or delete. It is empty for the parse stage.
+	Transition string    `json:"transition,omitempty"`
+	CoderURL   string    `json:"coder_url,omitempty"`
+	Workspace  Workspace `json:"workspace"`
+	Owner      Owner     `json:"owner"`
+	Template   Template  `json:"template"`
+	// Parameters are the values of the workspace parameters of the build.
+	Parameters []Value `json:"parameters"`
+	// PreviousParameters are the values of the previous build.
+	PreviousParameters []Value `json:"previous_parameters"`
+	// Variables are the values of the template variables.
+	Variables    []VariableValue `json:"variables"`
+	ExternalAuth []ExternalAuth  `json:"external_auth"`
+	// State is the state returned by the last apply of the workspace, if any.
+	State json.RawMessage `json:"state,omitempty"`
+	// AgentTokens are the auth tokens of the agents returned by the plan, by
+	// agent name. Agents authenticate to Coder with their token. Tokens are
+	// only set for the apply stage, and remain the same across builds.
+	AgentTokens map[string]string `json:"agent_tokens,omitempty"`
+}
+
+type Workspace struct {
+	ID      string `json:"id,omitempty"`
+	Name    string `json:"name,omitempty"`
+	BuildID string `json:"build_id,omitempty"`
+}
+
+type Owner struct {
+	ID              string   `json:"id,omitempty"`
+	Name            string   `json:"name,omitempty"`
+	FullName        string   `json:"full_name,omitempty"`
+	Email           string   `json:"email,omitempty"`
+	Groups          []string `json:"groups,omitempty"`
+	SSHPublicKey    string   `json:"ssh_public_key,omitempty"`
+	SSHPrivateKey   string   `json:"ssh_private_key,omitempty"`
+	SessionToken    string   `json:"session_token,omitempty"`
+	OIDCAccessToken string   `json:"oidc_access_token,omitempty"`
+	LoginType       string   `json:"login_type,omitempty"`
+}
+
+type Template struct {
+	ID      string `json:"id,omitempty"`
+	Name    string `json:"name,omitempty"`
+	Version string `json:"version,omitempty"`
+}
+
+type Value struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type VariableValue struct {
+	Name      string `json:"name"`
+	Value     string `json:"value"`
+	Sensitive bool   `json:"sensitive"`
+}
+
+type ExternalAuth struct {
+	ID          string `json:"id"`
+	AccessToken string `json:"access_token"`
+}
+
+// Response is read as JSON from the standard output of the executable. Logs
+// are read from its standard error.
+type Response struct {
+	// Error fails the job with the message. The executable may also exit
+	// with a non-zero status.
+	Error string `json:"error,omitempty"`
+
+	// Variables are only read from the parse stage.
+	Variables []Variable `json:"variables,omitempty"`
+
+	Parameters   []Parameter            `json:"parameters,omitempty"`
+	ExternalAuth []ExternalAuthProvider `json:"external_auth,omitempty"`
+	Resources    []Resource             `json:"resources,omitempty"`
+	// State is only read from the apply stage. It is stored by Coder and
+	// passed to the next build of the workspace as is.
+	State json.RawMessage `json:"state,omitempty"`
+}
+
+type Variable struct {
+	Name         string `json:"name"`
+	Description  string `json:"description,omitempty"`
+	Type         string `json:"type,omitempty"`
+	DefaultValue string `json:"default_value,omitempty"`
+	Required     bool   `json:"required,omitempty"`
+	Sensitive    bool   `json:"sensitive,omitempty"`
+}
+
+type Parameter struct {
+	Name                string            `json:"name"`
+	DisplayName         string            `json:"display_name,omitempty"`
+	Description         string            `json:"description,omitempty"`
+	Type                string            `json:"type,omitempty"`
+	Mutable             bool              `json:"mutable,omitempty"`
+	DefaultValue        string            `json:"default_value,omitempty"`
+	Icon                string            `json:"icon,omitempty"`
+	Options             []ParameterOption `json:"options,omitempty"`
+	ValidationRegex     string            `json:"validation_regex,omitempty"`
+	ValidationError     string            `json:"validation_error,omitempty"`
+	ValidationMin       *int32            `json:"validation_min,omitempty"`
+	ValidationMax       *int32            `json:"validation_max,omitempty"`
+	ValidationMonotonic string            `json:"validation_monotonic,omitempty"`
+	Required            bool              `json:"required,omitempty"`
+	Order               int32             `json:"order,omitempty"`
+	Ephemeral           bool              `json:"ephemeral,omitempty"`
+}
+
+type ParameterOption struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Value       string `json:"value"`
+	Icon        string `json:"icon,omitempty"`
+}
+
+type ExternalAuthProvider struct {
+	ID       string `json:"id"`
+	Optional bool   `json:"optional,omitempty"`
+}
+
+type Resource struct {
+	Name         string             `json:"name"`
+	Type         string             `json:"type"`
+	Hide         bool               `json:"hide,omitempty"`
+	Icon         string             `json:"icon,omitempty"`
+	InstanceType string             `json:"instance_type,omitempty"`
+	DailyCost    int32              `json:"daily_cost,omitempty"`
+	Metadata     []ResourceMetadata `json:"metadata,omitempty"`
+	Agents       []Agent            `json:"agents,omitempty"`
+}
+
+type ResourceMetadata struct {
+	Key       string `json:"key"`
+	Value     string `json:"value"`
+	Sensitive bool   `json:"sensitive,omitempty"`
+}
+
+type Agent struct {
+	Name            string            `json:"name"`
+	OperatingSystem string            `json:"operating_system"`
+	Architecture    string            `json:"architecture"`
+	Directory       string            `json:"directory,omitempty"`
+	Env             map[string]string `json:"env,omitempty"`
+	// InstanceID authenticates the agent by the identity of its cloud
+	// instance instead of its token.
+	InstanceID               string   `json:"instance_id,omitempty"`
+	ConnectionTimeoutSeconds int32    `json:"connection_timeout_seconds,omitempty"`
+	TroubleshootingURL       string   `json:"troubleshooting_url,omitempty"`
+	MOTDFile                 string   `json:"motd_file,omitempty"`
+	Order                    int64    `json:"order,omitempty"`
+	Apps                     []App    `json:"apps,omitempty"`
+	Scripts                  []Script `json:"scripts,omitempty"`
+}
+
+type App struct {
+	Slug        string       `json:"slug"`
+	DisplayName string       `json:"display_name,omitempty"`
+	Command     string       `json:"command,omitempty"`
+	URL         string       `json:"url,omitempty"`
+	Icon        string       `json:"icon,omitempty"`
+	Subdomain   bool         `json:"subdomain,omitempty"`
+	External    bool         `json:"external,omitempty"`
+	Hidden      bool         `json:"hidden,omitempty"`
+	Order       int64        `json:"order,omitempty"`
+	Group       string       `json:"group,omitempty"`
+	Share       string       `json:"share,omitempty"`
+	Healthcheck *Healthcheck `json:"healthcheck,omitempty"`
+}
+
+type Healthcheck struct {
+	URL       string `json:"url"`
+	Interval  int32  `json:"interval"`
+	Threshold int32  `json:"threshold"`
+}
+
+type Script struct {
+	DisplayName      string `json:"display_name"`
+	Icon             string `json:"icon,omitempty"`
+	Script           string `json:"script"`
+	Cron             string `json:"cron,omitempty"`
+	RunOnStart       bool   `json:"run_on_start,omitempty"`
+	RunOnStop        bool   `json:"run_on_stop,omitempty"`
+	StartBlocksLogin bool   `json:"start_blocks_login,omitempty"`
+	TimeoutSeconds   int32  `json:"timeout_seconds,omitempty"`
+	LogPath          string `json:"log_path,omitempty"`
+}
+
+// newRequest converts the metadata and values of a plan or apply to the
+// request of the executable.
+func newRequest(stage Stage, metadata *proto.Metadata) Request {
+	owner := Owner{
+		ID:              metadata.GetWorkspaceOwnerId(),
+		Name:            metadata.GetWorkspaceOwner(),
+		FullName:        metadata.GetWorkspaceOwnerName(),
+		Email:           metadata.GetWorkspaceOwnerEmail(),
+		Groups:          metadata.GetWorkspaceOwnerGroups(),
+		SSHPublicKey:    metadata.GetWorkspaceOwnerSshPublicKey(),
+		SSHPrivateKey:   metadata.GetWorkspaceOwnerSshPrivateKey(),
+		SessionToken:    metadata.GetWorkspaceOwnerSessionToken(),
+		OIDCAccessToken: metadata.GetWorkspaceOwnerOidcAccessToken(),
+		LoginType:       metadata.GetWorkspaceOwnerLoginType(),
+	}
+	return Request{
+		Stage:      stage,
+		Transition: transitionName(metadata.GetWorkspaceTransition()),
+		CoderURL:   metadata.GetCoderUrl(),
+		Workspace: Workspace{
+			ID:      metadata.GetWorkspaceId(),
+			Name:    metadata.GetWorkspaceName(),
+			BuildID: metadata.GetWorkspaceBuildId(),
+		},
+		Owner: owner,
+		Template: Template{
+			ID:      metadata.GetTemplateId(),
+			Name:    metadata.GetTemplateName(),
+			Version: metadata.GetTemplateVersion(),
+		},
+		Parameters:         []Value{},
+		PreviousParameters: []Value{},
+		Variables:          []VariableValue{},
+		ExternalAuth:       []ExternalAuth{},
+	}
+}
+
+func transitionName(transition proto.WorkspaceTransition) string {
+	switch transition {
+	case proto.WorkspaceTransition_STOP:
+		return "stop"
+	case proto.WorkspaceTransition_DESTROY:
+		return "delete"
+	default:
+		return "start"
+	}
+}
+
+func convertValues(values []*proto.RichParameterValue) []Value {
+	converted := make([]Value, 0, len(values))
+	for _, value := range values {
+		converted = append(converted, Value{Name: value.Name, Value: value.Value})
+	}
+	return converted
+}
+
+func convertVariableValues(values []*proto.VariableValue) []VariableValue {
+	converted := make([]VariableValue, 0, len(values))
+	for _, value := range values {
+		converted = append(converted, VariableValue{Name: value.Name, Value: value.Value, Sensitive: value.Sensitive})
+	}
+	return converted
+}
+
+func convertExternalAuth(providers []*proto.ExternalAuthProvider) []ExternalAuth {
+	converted := make([]ExternalAuth, 0, len(providers))
+	for _, provider := range providers {
+		converted = append(converted, ExternalAuth{ID: provider.Id, AccessToken: provider.AccessToken})
+	}
+	return converted
+}
+
+func (r Response) protoVariables() []*proto.TemplateVariable {
+	variables := make([]*proto.TemplateVariable, 0, len(r.Variables))
+	for _, variable := range r.Variables {
+		variables = append(variables, &proto.TemplateVariable{
+			Name:         variable.Name,
+			Description:  variable.Description,
+			Type:         variable.Type,
+			DefaultValue: variable.DefaultValue,
+			Required:     variable.Required,
+			Sensitive:    variable.Sensitive,
+		})
+	}
+	return variables
+}
+
+func (r Response) protoParameters() []*proto.RichParameter {
+	parameters := make([]*proto.RichParameter, 0, len(r.Parameters))
+	for _, parameter := range r.Parameters {
+		options := make([]*proto.RichParameterOption, 0, len(parameter.Options))
+		for _, option := range parameter.Options {
+			options = append(options, &proto.RichParameterOption{
+				Name:        option.Name,
+				Description: option.Description,
+				Value:       option.Value,
+				Icon:        option.Icon,
+			})
+		}
+		parameterType := parameter.Type
+		if parameterType == "" {
+			parameterType = "string"
+		}
+		parameters = append(parameters, &proto.RichParameter{
+			Name:                parameter.Name,
+			DisplayName:         parameter.DisplayName,
+			Description:         parameter.Description,
+			Type:                parameterType,
+			Mutable:             parameter.Mutable,
+			DefaultValue:        parameter.DefaultValue,
+			Icon:                parameter.Icon,
+			Options:             options,
+			ValidationRegex:     parameter.ValidationRegex,
+			ValidationError:     parameter.ValidationError,
+			ValidationMin:       parameter.ValidationMin,
+			ValidationMax:       parameter.ValidationMax,
+			ValidationMonotonic: parameter.ValidationMonotonic,
+			Required:            parameter.Required,
+			Order:               parameter.Order,
+			Ephemeral:           parameter.Ephemeral,
+		})
+	}
+	return parameters
+}
+
+func (r Response) protoExternalAuth() []*proto.ExternalAuthProviderResource {
+	providers := make([]*proto.ExternalAuthProviderResource, 0, len(r.ExternalAuth))
+	for _, provider := range r.ExternalAuth {
+		providers = append(providers, &proto.ExternalAuthProviderResource{
+			Id:       provider.ID,
+			Optional: provider.Optional,
+		})
+	}
+	return providers
+}
+
+// protoResources converts the resources of the response. Agents that are
+// not authenticated by their instance are given the token by their name.
+func (r Response) protoResources(agentTokens map[string]string) ([]*proto.Resource, error) {
+	resources := make([]*proto.Resource, 0, len(r.Resources))
+	for _, resource := range r.Resources {
+		if resource.Name == "" || resource.Type == "" {
+			return nil, xerrors.New("resources must have a name and type")
+		}
+		metadata := make([]*proto.Resource_Metadata, 0, len(resource.Metadata))
+		for _, item := range resource.Metadata {
+			metadata = append(metadata, &proto.Resource_Metadata{
+				Key:       item.Key,
+				Value:     item.Value,
+				Sensitive: item.Sensitive,
+			})
+		}
+		agents := make([]*proto.Agent, 0, len(resource.Agents))
+		for _, agent := range resource.Agents {
+			converted, err := agent.proto(agentTokens)
+			if err != nil {
+				return nil, xerrors.Errorf("resource %s.%s: %w", resource.Type, resource.Name, err)
+			}
+			agents = append(agents, converted)
+		}
+		resources = append(resources, &proto.Resource{
+			Name:         resource.Name,
+			Type:         resource.Type,
+			Agents:       agents,
+			Metadata:     metadata,
+			Hide:         resource.Hide,
+			Icon:         resource.Icon,
+			InstanceType: resource.InstanceType,
+			DailyCost:    resource.DailyCost,
+		})
+	}
+	return resources, nil
+}
+
+// agentNames returns the names of the agents of every resource.
+func (r Response) agentNames() []string {
+	var names []string
+	for _, resource := range r.Resources {
+		for _, agent := range resource.Agents {
+			names = append(names, agent.Name)
+		}
+	}
+	return names
+}
+
+func (a Agent) proto(agentTokens map[string]string) (*proto.Agent, error) {
+	if a.Name == "" {
+		return nil, xerrors.New("agents must have a name")
+	}
+	apps := make([]*proto.App, 0, len(a.Apps))
+	for _, app := range a.Apps {
+		sharingLevel, ok := proto.AppSharingLevel_value[strings.ToUpper(app.Share)]
+		if app.Share == "" {
+			sharingLevel, ok = int32(proto.AppSharingLevel_OWNER), true
+		}
+		if !ok {
+			return nil, xerrors.Errorf("app %q: invalid share %q, must be owner, authenticated or public", app.Slug, app.Share)
+		}
+		var healthcheck *proto.Healthcheck
+		if app.Healthcheck != nil {
+			healthcheck = &proto.Healthcheck{
+				Url:       app.Healthcheck.URL,
+				Interval:  app.Healthcheck.Interval,
+				Threshold: app.Healthcheck.Threshold,
+			}
+		}
+		apps = append(apps, &proto.App{
+			Slug:         app.Slug,
+			DisplayName:  app.DisplayName,
+			Command:      app.Command,
+			Url:          app.URL,
+			Icon:         app.Icon,
+			Subdomain:    app.Subdomain,
+			Healthcheck:  healthcheck,
+			SharingLevel: proto.AppSharingLevel(sharingLevel),
+			External:     app.External,
+			Order:        app.Order,
+			Hidden:       app.Hidden,
+			Group:        app.Group,
+		})
+	}
+	scripts := make([]*proto.Script, 0, len(a.Scripts))
+	for _, script := range a.Scripts {
+		scripts = append(scripts, &proto.Script{
+			DisplayName:      script.DisplayName,
+			Icon:             script.Icon,
+			Script:           script.Script,
+			Cron:             script.Cron,
+			StartBlocksLogin: script.StartBlocksLogin,
+			RunOnStart:       script.RunOnStart,
+			RunOnStop:        script.RunOnStop,
+			TimeoutSeconds:   script.TimeoutSeconds,
+			LogPath:          script.LogPath,
+		})
+	}
+
+	agent := &proto.Agent{
+		Name:                     a.Name,
+		Env:                      a.Env,
+		OperatingSystem:          a.OperatingSystem,
+		Architecture:             a.Architecture,
+		Directory:                a.Directory,
+		Apps:                     apps,
+		ConnectionTimeoutSeconds: a.ConnectionTimeoutSeconds,
+		TroubleshootingUrl:       a.TroubleshootingURL,
+		MotdFile:                 a.MOTDFile,
+		Scripts:                  scripts,
+		Order:                    a.Order,
+	}
+	switch {
+	case a.InstanceID != "":
+		agent.Auth = &proto.Agent_InstanceId{InstanceId: a.InstanceID}
+	case agentTokens[a.Name] != "":
+		agent.Auth = &proto.Agent_Token{Token: agentTokens[a.Name]}
+	}
+	return agent, nil
+}
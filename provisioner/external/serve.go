@@ -0,0 +1,325 @@
+// Package external implements a provisioner that invokes an executable for
+// each stage of a job, so that stacks other than Terraform can provision
+// workspaces. The executable reads a Request as JSON from its standard input,
+// writes a Response as JSON to its standard output, and logs to its standard
+// error.
+package external
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+
+	"github.com/coder/coder/v2/coderd/jobreaper"
+	"github.com/coder/coder/v2/provisionersdk"
+	"github.com/coder/coder/v2/provisionersdk/proto"
+)
+
+// DefaultCommand is the executable invoked when none is configured. It is
+// shipped with the template.
+const DefaultCommand = "./coder-provisioner"
+
+// planFile keeps the agents of the plan of a session, so that the apply of
+// the session can pass their tokens.
+const planFile = ".coder-external-plan.json"
+
+type ServeOptions struct {
+	*provisionersdk.ServeOptions
+
+	// Command is the executable invoked with the stage of the job as its
+	// only argument. Relative paths are resolved against the template
+	// directory. Defaults to DefaultCommand.
+	Command string
+
+	// ExitTimeout defines how long we will wait for a running command to
+	// exit after it was interrupted because the job was canceled.
+	//
+	// Default value: 3 minutes (jobreaper.HungJobExitTimeout).
+	ExitTimeout time.Duration
+}
+
+// Serve starts a dRPC server on the provided transport speaking the external
+// provisioner.
+func Serve(ctx context.Context, options *ServeOptions) error {
+	if options.Command == "" {
+		options.Command = DefaultCommand
+	}
+	if options.ExitTimeout == 0 {
+		options.ExitTimeout = jobreaper.HungJobExitTimeout
+	}
+	return provisionersdk.Serve(ctx, &server{
+		command:     options.Command,
+		logger:      options.Logger,
+		exitTimeout: options.ExitTimeout,
+	}, options.ServeOptions)
+}
+
+type server struct {
+	command     string
+	logger      slog.Logger
+	exitTimeout time.Duration
+}
+
+// providerState is the state stored by Coder. It wraps the state of the
+// executable with the tokens of the agents, so that agents keep their token
+// across builds.
+type providerState struct {
+	AgentTokens map[string]string `json:"agent_tokens"`
+	State       json.RawMessage   `json:"state,omitempty"`
+}
+
+func (s *server) Parse(sess *provisionersdk.Session, _ *proto.ParseRequest, canceledOrComplete <-chan struct{}) *proto.ParseComplete {
+	resp, err := s.run(sess, Request{
+		Stage:              StageParse,
+		Parameters:         []Value{},
+		PreviousParameters: []Value{},
+		Variables:          []VariableValue{},
+		ExternalAuth:       []ExternalAuth{},
+	}, canceledOrComplete)
+	if err != nil {
+		return &proto.ParseComplete{Error: err.Error()}
+	}
+	return &proto.ParseComplete{
+		TemplateVariables: resp.protoVariables(),
+	}
+}
+
+func (s *server) Plan(sess *provisionersdk.Session, request *proto.PlanRequest, canceledOrComplete <-chan struct{}) *proto.PlanComplete {
+	state, err := parseState(sess.Config.GetState())
+	if err != nil {
+		return &proto.PlanComplete{Error: err.Error()}
+	}
+
+	req := newRequest(StagePlan, request.GetMetadata())
+	req.Parameters = convertValues(request.GetRichParameterValues())
+	req.PreviousParameters = convertValues(request.GetPreviousParameterValues())
+	req.Variables = convertVariableValues(request.GetVariableValues())
+	req.ExternalAuth = convertExternalAuth(request.GetExternalAuthProviders())
+	req.State = state.State
+	resp, err := s.run(sess, req, canceledOrComplete)
+	if err != nil {
+		return &proto.PlanComplete{Error: err.Error()}
+	}
+	resources, err := resp.protoResources(nil)
+	if err != nil {
+		return &proto.PlanComplete{Error: err.Error()}
+	}
+
+	// Apply only receives the metadata of the build, so the request is kept
+	// for it in the session directory.
+	req.AgentTokens = agentTokens(state.AgentTokens, resp.agentNames())
+	data, err := json.Marshal(req)
+	if err != nil {
+		return &proto.PlanComplete{Error: xerrors.Errorf("marshal plan: %w", err).Error()}
+	}
+	err = os.WriteFile(filepath.Join(sess.WorkDirectory, planFile), data, 0o600)
+	if err != nil {
+		return &proto.PlanComplete{Error: xerrors.Errorf("write plan: %w", err).Error()}
+	}
+
+	return &proto.PlanComplete{
+		Resources:             resources,
+		Parameters:            resp.protoParameters(),
+		ExternalAuthProviders: resp.protoExternalAuth(),
+	}
+}
+
+func (s *server) Apply(sess *provisionersdk.Session, request *proto.ApplyRequest, canceledOrComplete <-chan struct{}) *proto.ApplyComplete {
+	data, err := os.ReadFile(filepath.Join(sess.WorkDirectory, planFile))
+	if err != nil {
+		return &proto.ApplyComplete{Error: xerrors.Errorf("read plan: %w", err).Error()}
+	}
+	var req Request
+	err = json.Unmarshal(data, &req)
+	if err != nil {
+		return &proto.ApplyComplete{Error: xerrors.Errorf("unmarshal plan: %w", err).Error()}
+	}
+	req.Stage = StageApply
+	req.Transition = transitionName(request.GetMetadata().GetWorkspaceTransition())
+
+	resp, runErr := s.run(sess, req, canceledOrComplete)
+	// A failed apply may still have changed resources, so its state is kept
+	// if it returned one.
+	state, err := json.Marshal(providerState{
+		AgentTokens: agentTokens(req.AgentTokens, resp.agentNames()),
+		State:       resp.State,
+	})
+	if err != nil {
+		return &proto.ApplyComplete{Error: xerrors.Errorf("marshal state: %w", err).Error()}
+	}
+	if runErr != nil {
+		if len(resp.State) == 0 {
+			state = nil
+		}
+		return &proto.ApplyComplete{State: state, Error: runErr.Error()}
+	}
+	resources, err := resp.protoResources(req.AgentTokens)
+	if err != nil {
+		return &proto.ApplyComplete{State: state, Error: err.Error()}
+	}
+	return &proto.ApplyComplete{
+		State:                 state,
+		Resources:             resources,
+		Parameters:            resp.protoParameters(),
+		ExternalAuthProviders: resp.protoExternalAuth(),
+	}
+}
+
+func parseState(raw []byte) (providerState, error) {
+	var state providerState
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return state, nil
+	}
+	err := json.Unmarshal(raw, &state)
+	if err != nil {
+		return state, xerrors.Errorf("parse state: %w", err)
+	}
+	return state, nil
+}
+
+// agentTokens returns the tokens of the named agents. Agents keep their
+// existing token, and new agents are given a new one.
+func agentTokens(existing map[string]string, names []string) map[string]string {
+	tokens := make(map[string]string, len(names))
+	for _, name := range names {
+		token, ok := existing[name]
+		if !ok {
+			token = uuid.NewString()
+		}
+		tokens[name] = token
+	}
+	return tokens
+}
+
+// run invokes the executable for the stage of the request in the template
+// directory, and streams its standard error to the logs of the job.
+func (s *server) run(sess *provisionersdk.Session, req Request, canceledOrComplete <-chan struct{}) (Response, error) {
+	ctx, cancel := context.WithCancel(sess.Context())
+	defer cancel()
+	// The kill context is not tied to the session, so that the executable
+	// can exit gracefully when the job is canceled.
+	killCtx, kill := context.WithCancel(context.Background())
+	defer kill()
+	go func() {
+		select {
+		case <-canceledOrComplete:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		t := time.NewTimer(s.exitTimeout)
+		defer t.Stop()
+		select {
+		case <-t.C:
+			kill()
+		case <-killCtx.Done():
+		}
+	}()
+
+	input, err := json.Marshal(req)
+	if err != nil {
+		return Response{}, xerrors.Errorf("marshal request: %w", err)
+	}
+
+	// #nosec
+	cmd := exec.CommandContext(killCtx, s.command, string(req.Stage))
+	cmd.Dir = sess.WorkDirectory
+	cmd.Env = safeEnviron()
+	cmd.Stdin = bytes.NewReader(input)
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	stderr, logsDone := logWriter(sess)
+	cmd.Stderr = stderr
+
+	s.logger.Debug(ctx, "executing external provisioner command",
+		slog.F("command", s.command),
+		slog.F("stage", req.Stage),
+	)
+	err = cmd.Start()
+	if err != nil {
+		_ = stderr.Close()
+		<-logsDone
+		return Response{}, xerrors.Errorf("start %s: %w", s.command, err)
+	}
+	go func() {
+		<-ctx.Done()
+		if runtime.GOOS == "windows" {
+			// Interrupts aren't supported by Windows.
+			_ = cmd.Process.Kill()
+			return
+		}
+		_ = cmd.Process.Signal(os.Interrupt)
+	}()
+	err = cmd.Wait()
+	_ = stderr.Close()
+	<-logsDone
+
+	// The response is returned along with errors, so that the state of a
+	// failed apply is kept.
+	var resp Response
+	decodeErr := json.Unmarshal(stdout.Bytes(), &resp)
+	if resp.Error != "" {
+		return resp, xerrors.New(resp.Error)
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return resp, xerrors.Errorf("%s canceled: %w", req.Stage, err)
+		}
+		return resp, xerrors.Errorf("%s %s: %w", s.command, req.Stage, err)
+	}
+	if decodeErr != nil {
+		return Response{}, xerrors.Errorf("decode response of %s %s: %w", s.command, req.Stage, decodeErr)
+	}
+	return resp, nil
+}
+
+// logWriter returns a writer that logs each line written to it. The
+// returned channel is closed once the writer was closed and all lines were
+// logged.
+func logWriter(sess *provisionersdk.Session) (io.WriteCloser, <-chan struct{}) {
+	r, w := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			if strings.TrimSpace(scanner.Text()) == "" {
+				continue
+			}
+			sess.ProvisionLog(proto.LogLevel_INFO, scanner.Text())
+		}
+		// Drain the pipe if a line was too long to scan, so that the
+		// command does not block on writing to it.
+		_, _ = io.Copy(io.Discard, r)
+	}()
+	return w, done
+}
+
+// safeEnviron returns the environment of the process without CODER_
+// variables, which may contain secrets like the database URL.
+func safeEnviron() []string {
+	env := os.Environ()
+	stripped := make([]string, 0, len(env))
+	for _, e := range env {
+		if strings.HasPrefix(e, "CODER_") {
+			continue
+		}
+		stripped = append(stripped, e)
+	}
+	return stripped
+}
@@ -0,0 +1,202 @@
+package external_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"runtime"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/codersdk/drpcsdk"
+	"github.com/coder/coder/v2/provisioner/external"
+	"github.com/coder/coder/v2/provisionersdk"
+	"github.com/coder/coder/v2/provisionersdk/proto"
+	"github.com/coder/coder/v2/testutil"
+)
+
+const resources = `[{"name":"vm","type":"bespoke_vm","agents":[{"name":"main","operating_system":"linux","architecture":"amd64","apps":[{"slug":"web","url":"http://localhost:8080","share":"authenticated"}]}]}]`
+
+const script = `#!/bin/sh
+cat > request-$1.json
+echo "running $1" >&2
+case "$1" in
+parse)
+	echo '{"variables":[{"name":"region","default_value":"us"}]}'
+	;;
+plan)
+	echo '{"parameters":[{"name":"size","default_value":"small"}],"resources":` + resources + `}'
+	;;
+apply)
+	if grep -q fail request-apply.json; then
+		echo '{"error":"vm failed to boot","state":{"vm_id":"i-123"}}'
+		exit 1
+	fi
+	echo '{"resources":` + resources + `,"state":{"vm_id":"i-123"}}'
+	;;
+esac
+`
+
+func TestExternal(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("the test executable is a shell script")
+	}
+
+	client, server := drpcsdk.MemTransportPipe()
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	t.Cleanup(func() {
+		_ = client.Close()
+		_ = server.Close()
+		cancelFunc()
+	})
+	go func() {
+		err := external.Serve(ctx, &external.ServeOptions{
+			ServeOptions: &provisionersdk.ServeOptions{
+				Listener:      server,
+				WorkDirectory: t.TempDir(),
+			},
+		})
+		assert.NoError(t, err)
+	}()
+	api := proto.NewDRPCProvisionerClient(client)
+
+	t.Run("Parse", func(t *testing.T) {
+		t.Parallel()
+		ctx := testutil.Context(t, testutil.WaitShort)
+
+		sess := newSession(ctx, t, api, nil)
+		err := sess.Send(&proto.Request{Type: &proto.Request_Parse{Parse: &proto.ParseRequest{}}})
+		require.NoError(t, err)
+		logs, resp := readResponse(t, sess)
+		require.Contains(t, logs, "running parse")
+		parse := resp.GetParse()
+		require.Empty(t, parse.Error)
+		require.Len(t, parse.TemplateVariables, 1)
+		require.Equal(t, "region", parse.TemplateVariables[0].Name)
+		require.Equal(t, "us", parse.TemplateVariables[0].DefaultValue)
+	})
+
+	t.Run("Apply", func(t *testing.T) {
+		t.Parallel()
+		ctx := testutil.Context(t, testutil.WaitShort)
+
+		sess := newSession(ctx, t, api, nil)
+		plan := planSession(t, sess, "dev")
+		require.Len(t, plan.Parameters, 1)
+		require.Equal(t, "string", plan.Parameters[0].Type)
+		require.Len(t, plan.Resources, 1)
+		require.Nil(t, plan.Resources[0].Agents[0].Auth)
+
+		apply := applySession(t, sess)
+		require.Empty(t, apply.Error)
+		require.Len(t, apply.Resources, 1)
+		agent := apply.Resources[0].Agents[0]
+		require.Equal(t, "main", agent.Name)
+		require.Equal(t, proto.AppSharingLevel_AUTHENTICATED, agent.Apps[0].SharingLevel)
+		token := agent.GetToken()
+		_, err := uuid.Parse(token)
+		require.NoError(t, err)
+
+		var state struct {
+			AgentTokens map[string]string `json:"agent_tokens"`
+			State       json.RawMessage   `json:"state"`
+		}
+		require.NoError(t, json.Unmarshal(apply.State, &state))
+		require.Equal(t, map[string]string{"main": token}, state.AgentTokens)
+		require.JSONEq(t, `{"vm_id":"i-123"}`, string(state.State))
+
+		// The next build of the workspace keeps the token of the agent.
+		sess = newSession(ctx, t, api, apply.State)
+		planSession(t, sess, "dev")
+		apply = applySession(t, sess)
+		require.Empty(t, apply.Error)
+		require.Equal(t, token, apply.Resources[0].Agents[0].GetToken())
+	})
+
+	t.Run("ApplyFailed", func(t *testing.T) {
+		t.Parallel()
+		ctx := testutil.Context(t, testutil.WaitShort)
+
+		sess := newSession(ctx, t, api, nil)
+		planSession(t, sess, "fail")
+		apply := applySession(t, sess)
+		require.Equal(t, "vm failed to boot", apply.Error)
+		// The state of the failed apply is kept.
+		require.Contains(t, string(apply.State), "i-123")
+	})
+}
+
+func newSession(ctx context.Context, t *testing.T, api proto.DRPCProvisionerClient, state []byte) proto.DRPCProvisioner_SessionClient {
+	t.Helper()
+
+	var buf bytes.Buffer
+	writer := tar.NewWriter(&buf)
+	err := writer.WriteHeader(&tar.Header{
+		Name: "coder-provisioner",
+		Mode: 0o755,
+		Size: int64(len(script)),
+	})
+	require.NoError(t, err)
+	_, err = writer.Write([]byte(script))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	sess, err := api.Session(ctx)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = sess.Close()
+	})
+	err = sess.Send(&proto.Request{Type: &proto.Request_Config{Config: &proto.Config{
+		TemplateSourceArchive: buf.Bytes(),
+		State:                 state,
+	}}})
+	require.NoError(t, err)
+	return sess
+}
+
+func planSession(t *testing.T, sess proto.DRPCProvisioner_SessionClient, workspaceName string) *proto.PlanComplete {
+	t.Helper()
+
+	err := sess.Send(&proto.Request{Type: &proto.Request_Plan{Plan: &proto.PlanRequest{
+		Metadata: &proto.Metadata{WorkspaceName: workspaceName},
+	}}})
+	require.NoError(t, err)
+	logs, resp := readResponse(t, sess)
+	require.Contains(t, logs, "running plan")
+	plan := resp.GetPlan()
+	require.Empty(t, plan.Error)
+	return plan
+}
+
+func applySession(t *testing.T, sess proto.DRPCProvisioner_SessionClient) *proto.ApplyComplete {
+	t.Helper()
+
+	err := sess.Send(&proto.Request{Type: &proto.Request_Apply{Apply: &proto.ApplyRequest{
+		Metadata: &proto.Metadata{},
+	}}})
+	require.NoError(t, err)
+	_, resp := readResponse(t, sess)
+	return resp.GetApply()
+}
+
+// readResponse reads the logs of the session until the response completing
+// the request.
+func readResponse(t *testing.T, sess proto.DRPCProvisioner_SessionClient) ([]string, *proto.Response) {
+	t.Helper()
+
+	var logs []string
+	for {
+		msg, err := sess.Recv()
+		require.NoError(t, err)
+		if log := msg.GetLog(); log != nil {
+			logs = append(logs, log.Output)
+			continue
+		}
+		return logs, msg
+	}
+}
@@ -37,7 +37,10 @@ type executor struct {
 	// cachePath and workdir must not be used by multiple processes at once.
 	cachePath     string
 	cliConfigPath string
-	workdir       string
+	// providerMirrorConfigPath is the CLI config file that installs
+	// providers from the provider mirror, used if no CLI config is set.
+	providerMirrorConfigPath string
+	workdir                  string
 	// used to capture execution times at various stages
 	timings *timingAggregator
 }
@@ -53,6 +56,8 @@ func (e *executor) basicEnv() []string {
 	}
 	if e.cliConfigPath != "" {
 		env = append(env, "TF_CLI_CONFIG_FILE="+e.cliConfigPath)
+	} else if e.providerMirrorConfigPath != "" {
+		env = append(env, "TF_CLI_CONFIG_FILE="+e.providerMirrorConfigPath)
 	}
 	return env
 }
@@ -1,13 +1,22 @@
 package terraform
 
 import (
+	"archive/zip"
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"sync/atomic"
 	"time"
 
+	"github.com/ProtonMail/go-crypto/openpgp"
 	"github.com/gofrs/flock"
 	"github.com/hashicorp/go-version"
 	"github.com/hashicorp/hc-install/product"
@@ -27,14 +36,82 @@ var (
 	minTerraformVersion = version.Must(version.NewVersion("1.1.0"))
 	maxTerraformVersion = version.Must(version.NewVersion("1.12.9")) // use .9 to automatically allow patch releases
 
+	// TofuVersion is the version of OpenTofu used internally when OpenTofu
+	// is not available on the system.
+	TofuVersion = version.Must(version.NewVersion("1.9.1"))
+
+	minTofuVersion = version.Must(version.NewVersion("1.6.0"))
+	maxTofuVersion = version.Must(version.NewVersion("1.9.9"))
+
 	errTerraformMinorVersionMismatch = xerrors.New("Terraform binary minor version mismatch.")
 )
 
+// tofuReleasesURL is where OpenTofu releases are downloaded from.
+const tofuReleasesURL = "https://github.com/opentofu/opentofu/releases/download"
+
+// tofuHTTPClient downloads OpenTofu releases. The timeout bounds the whole
+// download, so a stalled connection can't hold the install lock forever.
+var tofuHTTPClient = &http.Client{Timeout: 5 * time.Minute}
+
+// Distribution is a distribution of the Terraform CLI that templates are
+// provisioned with.
+type Distribution string
+
+const (
+	DistributionTerraform Distribution = "terraform"
+	DistributionTofu      Distribution = "tofu"
+)
+
+// BinaryName returns the file name of the executable of the distribution.
+func (d Distribution) BinaryName() string {
+	if d == DistributionTerraform {
+		return product.Terraform.BinaryName()
+	}
+	if runtime.GOOS == "windows" {
+		return string(d) + ".exe"
+	}
+	return string(d)
+}
+
+// displayName returns the name of the distribution used in messages.
+func (d Distribution) displayName() string {
+	if d == DistributionTofu {
+		return "OpenTofu"
+	}
+	return "Terraform"
+}
+
+// DefaultVersion returns the version of the distribution that is installed
+// when it is not available on the system.
+func (d Distribution) DefaultVersion() *version.Version {
+	if d == DistributionTofu {
+		return TofuVersion
+	}
+	return TerraformVersion
+}
+
+// versionRange returns the oldest supported version of the distribution,
+// and the version from which it is newer than expected.
+func (d Distribution) versionRange() (minVersion, maxVersion *version.Version) {
+	if d == DistributionTofu {
+		return minTofuVersion, maxTofuVersion
+	}
+	return minTerraformVersion, maxTerraformVersion
+}
+
 // Install implements a thread-safe, idempotent Terraform Install
 // operation.
 //
 //nolint:revive // verbose is a control flag that controls the verbosity of the log output.
 func Install(ctx context.Context, log slog.Logger, verbose bool, dir string, wantVersion *version.Version) (string, error) {
+	return InstallDistribution(ctx, log, verbose, dir, DistributionTerraform, wantVersion)
+}
+
+// InstallDistribution implements a thread-safe, idempotent Install operation
+// of a version of a distribution into dir.
+//
+//nolint:revive // verbose is a control flag that controls the verbosity of the log output.
+func InstallDistribution(ctx context.Context, log slog.Logger, verbose bool, dir string, distribution Distribution, wantVersion *version.Version) (string, error) {
 	err := os.MkdirAll(dir, 0o750)
 	if err != nil {
 		return "", err
@@ -51,7 +128,7 @@ func Install(ctx context.Context, log slog.Logger, verbose bool, dir string, wan
 	}
 	defer lock.Close()
 
-	binPath := filepath.Join(dir, product.Terraform.BinaryName())
+	binPath := filepath.Join(dir, distribution.BinaryName())
 
 	hasVersionStr := "nil"
 	hasVersion, err := versionFromBinaryPath(ctx, binPath)
@@ -62,22 +139,15 @@ func Install(ctx context.Context, log slog.Logger, verbose bool, dir string, wan
 		}
 	}
 
-	installer := &releases.ExactVersion{
-		InstallDir: dir,
-		Product:    product.Terraform,
-		Version:    TerraformVersion,
-	}
-	installer.SetLogger(slog.Stdlib(ctx, log, slog.LevelDebug))
-
 	logInstall := log.Debug
 	if verbose {
 		logInstall = log.Info
 	}
 
-	logInstall(ctx, "installing terraform",
+	logInstall(ctx, "installing "+string(distribution),
 		slog.F("prev_version", hasVersionStr),
 		slog.F("dir", dir),
-		slog.F("version", TerraformVersion))
+		slog.F("version", wantVersion))
 
 	prolongedInstall := atomic.Bool{}
 	prolongedInstallCtx, prolongedInstallCancel := context.WithCancel(ctx)
@@ -89,10 +159,10 @@ func Install(ctx context.Context, log slog.Logger, verbose bool, dir string, wan
 			// We always want to log this at the info level.
 			log.Info(
 				prolongedInstallCtx,
-				fmt.Sprintf("terraform installation is taking longer than %d seconds, still in progress", seconds),
+				fmt.Sprintf("%s installation is taking longer than %d seconds, still in progress", distribution, seconds),
 				slog.F("prev_version", hasVersionStr),
 				slog.F("dir", dir),
-				slog.F("version", TerraformVersion),
+				slog.F("version", wantVersion),
 			)
 		case <-prolongedInstallCtx.Done():
 			return
@@ -100,7 +170,23 @@ func Install(ctx context.Context, log slog.Logger, verbose bool, dir string, wan
 	}()
 	defer prolongedInstallCancel()
 
-	path, err := installer.Install(ctx)
+	var path string
+	if distribution == DistributionTofu {
+		var keyRing openpgp.EntityList
+		keyRing, err = openpgp.ReadArmoredKeyRing(strings.NewReader(tofuSigningKey))
+		if err != nil {
+			return "", xerrors.Errorf("read OpenTofu signing key: %w", err)
+		}
+		path, err = installTofu(ctx, tofuHTTPClient, tofuReleasesURL, keyRing, dir, wantVersion)
+	} else {
+		installer := &releases.ExactVersion{
+			InstallDir: dir,
+			Product:    product.Terraform,
+			Version:    wantVersion,
+		}
+		installer.SetLogger(slog.Stdlib(ctx, log, slog.LevelDebug))
+		path, err = installer.Install(ctx)
+	}
 	if err != nil {
 		return "", xerrors.Errorf("install: %w", err)
 	}
@@ -112,8 +198,124 @@ func Install(ctx context.Context, log slog.Logger, verbose bool, dir string, wan
 	}
 
 	if prolongedInstall.Load() {
-		log.Info(ctx, "terraform installation complete")
+		log.Info(ctx, string(distribution)+" installation complete")
 	}
 
 	return path, nil
 }
+
+// installTofu downloads a release of OpenTofu for the current platform,
+// verifies it against the SHA256SUMS of the release, and extracts its binary
+// into dir. The SHA256SUMS must be signed by a key of keyRing, so a release
+// that was tampered with on the mirror can't vouch for itself.
+func installTofu(ctx context.Context, client *http.Client, releasesURL string, keyRing openpgp.KeyRing, dir string, wantVersion *version.Version) (string, error) {
+	releaseURL := fmt.Sprintf("%s/v%s", releasesURL, wantVersion)
+	sumsName := fmt.Sprintf("tofu_%s_SHA256SUMS", wantVersion)
+	sums, err := download(ctx, client, releaseURL+"/"+sumsName)
+	if err != nil {
+		return "", xerrors.Errorf("download checksums: %w", err)
+	}
+	signature, err := download(ctx, client, releaseURL+"/"+sumsName+".gpgsig")
+	if err != nil {
+		return "", xerrors.Errorf("download checksums signature: %w", err)
+	}
+	_, err = openpgp.CheckDetachedSignature(keyRing, bytes.NewReader(sums), bytes.NewReader(signature), nil)
+	if err != nil {
+		return "", xerrors.Errorf("verify signature of %s: %w", sumsName, err)
+	}
+
+	archiveName := fmt.Sprintf("tofu_%s_%s_%s.zip", wantVersion, runtime.GOOS, runtime.GOARCH)
+	wantSum, ok := findChecksum(sums, archiveName)
+	if !ok {
+		return "", xerrors.Errorf("%s is not listed in %s, the platform is not supported", archiveName, sumsName)
+	}
+
+	archive, err := download(ctx, client, releaseURL+"/"+archiveName)
+	if err != nil {
+		return "", xerrors.Errorf("download release: %w", err)
+	}
+	sum := sha256.Sum256(archive)
+	if hex.EncodeToString(sum[:]) != wantSum {
+		return "", xerrors.Errorf("checksum of %s does not match %s", archiveName, sumsName)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return "", xerrors.Errorf("open release: %w", err)
+	}
+	binaryName := DistributionTofu.BinaryName()
+	for _, f := range zr.File {
+		if f.Name != binaryName {
+			continue
+		}
+		binPath := filepath.Join(dir, binaryName)
+		err = extractFile(f, binPath)
+		if err != nil {
+			return "", xerrors.Errorf("extract %s: %w", binaryName, err)
+		}
+		return binPath, nil
+	}
+	return "", xerrors.Errorf("release does not contain %s", binaryName)
+}
+
+// findChecksum returns the checksum of a file listed in the output of
+// sha256sum.
+func findChecksum(sums []byte, name string) (string, bool) {
+	for _, line := range strings.Split(string(sums), "\n") {
+		sum, file, ok := strings.Cut(strings.TrimSpace(line), "  ")
+		if ok && file == name {
+			return sum, true
+		}
+	}
+	return "", false
+}
+
+func download(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, xerrors.Errorf("GET %s: unexpected status %s", url, res.Status)
+	}
+	return io.ReadAll(res.Body)
+}
+
+// extractFile writes a file of an archive to path. It is written to a
+// temporary file first, so that a binary being executed is never
+// overwritten in place.
+func extractFile(f *zip.File, path string) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}()
+	// #nosec G110 -- The archive was verified against the signed checksums.
+	_, err = io.Copy(tmp, rc)
+	if err != nil {
+		return err
+	}
+	err = tmp.Chmod(0o755)
+	if err != nil {
+		return err
+	}
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
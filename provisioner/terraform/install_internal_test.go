@@ -0,0 +1,187 @@
+package terraform
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"github.com/hashicorp/go-version"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/testutil"
+)
+
+func TestInstallTofu(t *testing.T) {
+	t.Parallel()
+
+	v := version.Must(version.NewVersion("1.9.1"))
+	archiveName := fmt.Sprintf("tofu_%s_%s_%s.zip", v, runtime.GOOS, runtime.GOARCH)
+	binary := []byte("#!/bin/sh\necho tofu\n")
+
+	var archive bytes.Buffer
+	zw := zip.NewWriter(&archive)
+	w, err := zw.Create("LICENSE")
+	require.NoError(t, err)
+	_, err = w.Write([]byte("MPL-2.0"))
+	require.NoError(t, err)
+	w, err = zw.Create(DistributionTofu.BinaryName())
+	require.NoError(t, err)
+	_, err = w.Write(binary)
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	config := &packet.Config{Algorithm: packet.PubKeyAlgoEdDSA}
+	releaseKey, err := openpgp.NewEntity("OpenTofu", "", "core@opentofu.org", config)
+	require.NoError(t, err)
+	otherKey, err := openpgp.NewEntity("Other", "", "other@example.com", config)
+	require.NoError(t, err)
+	keyRing := openpgp.EntityList{releaseKey}
+
+	sum := sha256.Sum256(archive.Bytes())
+	checksums := func(checksum string) []byte {
+		return []byte(fmt.Sprintf("%s  tofu_%s_other_arch.zip\n%s  %s\n", hex.EncodeToString(make([]byte, 32)), v, checksum, archiveName))
+	}
+	sign := func(t *testing.T, key *openpgp.Entity, sums []byte) []byte {
+		var signature bytes.Buffer
+		require.NoError(t, openpgp.DetachSign(&signature, key, bytes.NewReader(sums), nil))
+		return signature.Bytes()
+	}
+	serve := func(t *testing.T, sums, signature []byte) string {
+		srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case fmt.Sprintf("/v%s/tofu_%s_SHA256SUMS", v, v):
+				_, _ = rw.Write(sums)
+			case fmt.Sprintf("/v%s/tofu_%s_SHA256SUMS.gpgsig", v, v):
+				_, _ = rw.Write(signature)
+			case fmt.Sprintf("/v%s/%s", v, archiveName):
+				_, _ = rw.Write(archive.Bytes())
+			default:
+				rw.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		t.Cleanup(srv.Close)
+		return srv.URL
+	}
+	requireNotInstalled := func(t *testing.T, dir string) {
+		_, err := os.Stat(filepath.Join(dir, DistributionTofu.BinaryName()))
+		require.ErrorIs(t, err, os.ErrNotExist)
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := testutil.Context(t, testutil.WaitShort)
+		dir := t.TempDir()
+		sums := checksums(hex.EncodeToString(sum[:]))
+		path, err := installTofu(ctx, http.DefaultClient, serve(t, sums, sign(t, releaseKey, sums)), keyRing, dir, v)
+		require.NoError(t, err)
+		require.Equal(t, filepath.Join(dir, DistributionTofu.BinaryName()), path)
+
+		got, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, binary, got)
+		if runtime.GOOS != "windows" {
+			info, err := os.Stat(path)
+			require.NoError(t, err)
+			require.NotZero(t, info.Mode().Perm()&0o100, "binary must be executable")
+		}
+	})
+
+	t.Run("ChecksumMismatch", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := testutil.Context(t, testutil.WaitShort)
+		dir := t.TempDir()
+		sums := checksums(hex.EncodeToString(make([]byte, 32)))
+		_, err := installTofu(ctx, http.DefaultClient, serve(t, sums, sign(t, releaseKey, sums)), keyRing, dir, v)
+		require.ErrorContains(t, err, "does not match")
+		requireNotInstalled(t, dir)
+	})
+
+	t.Run("TamperedChecksums", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := testutil.Context(t, testutil.WaitShort)
+		dir := t.TempDir()
+		signature := sign(t, releaseKey, checksums(hex.EncodeToString(make([]byte, 32))))
+		_, err := installTofu(ctx, http.DefaultClient, serve(t, checksums(hex.EncodeToString(sum[:])), signature), keyRing, dir, v)
+		require.ErrorContains(t, err, "verify signature")
+		requireNotInstalled(t, dir)
+	})
+
+	t.Run("UntrustedKey", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := testutil.Context(t, testutil.WaitShort)
+		dir := t.TempDir()
+		sums := checksums(hex.EncodeToString(sum[:]))
+		_, err := installTofu(ctx, http.DefaultClient, serve(t, sums, sign(t, otherKey, sums)), keyRing, dir, v)
+		require.ErrorContains(t, err, "verify signature")
+		requireNotInstalled(t, dir)
+	})
+
+	t.Run("UnsupportedPlatform", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := testutil.Context(t, testutil.WaitShort)
+		sums := []byte(fmt.Sprintf("%s  tofu_%s_other_arch.zip\n", hex.EncodeToString(sum[:]), v))
+		_, err := installTofu(ctx, http.DefaultClient, serve(t, sums, sign(t, releaseKey, sums)), keyRing, t.TempDir(), v)
+		require.ErrorContains(t, err, "is not listed")
+	})
+
+	t.Run("UnknownVersion", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := testutil.Context(t, testutil.WaitShort)
+		sums := checksums(hex.EncodeToString(sum[:]))
+		_, err := installTofu(ctx, http.DefaultClient, serve(t, sums, sign(t, releaseKey, sums)), keyRing, t.TempDir(), version.Must(version.NewVersion("1.0.0")))
+		require.ErrorContains(t, err, "download checksums")
+	})
+}
+
+func TestTofuSigningKey(t *testing.T) {
+	t.Parallel()
+
+	keyRing, err := openpgp.ReadArmoredKeyRing(strings.NewReader(tofuSigningKey))
+	require.NoError(t, err)
+	require.Len(t, keyRing, 1)
+	// The fingerprint published at https://opentofu.org/docs/intro/install/.
+	require.Equal(t, "E3E6E43D84CB852EADB0051D0C0AF313E5FD9F80", fmt.Sprintf("%X", keyRing[0].PrimaryKey.Fingerprint))
+}
+
+func TestBinaryPathForVersion(t *testing.T) {
+	t.Parallel()
+
+	s := &server{
+		distribution: DistributionTofu,
+		binaryPath:   "/usr/bin/tofu",
+		binaryPaths: map[string]string{
+			"1.8.0": "/cache/versions/tofu/1.8.0/tofu",
+		},
+	}
+
+	path, err := s.binaryPathForVersion("")
+	require.NoError(t, err)
+	require.Equal(t, "/usr/bin/tofu", path)
+
+	path, err = s.binaryPathForVersion("1.8.0")
+	require.NoError(t, err)
+	require.Equal(t, "/cache/versions/tofu/1.8.0/tofu", path)
+
+	_, err = s.binaryPathForVersion("1.9.1")
+	require.ErrorContains(t, err, "OpenTofu 1.9.1 is not installed")
+
+	_, err = s.binaryPathForVersion("latest")
+	require.ErrorContains(t, err, "parse provisioner version")
+}
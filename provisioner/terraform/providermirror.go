@@ -0,0 +1,219 @@
+package terraform
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/mod/sumdb/dirhash"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/v2/coderd/util/xio"
+	"github.com/coder/coder/v2/provisionersdk"
+	"github.com/coder/coder/v2/provisionersdk/proto"
+)
+
+// MaximumProviderArchiveSize limits the size of a provider package added to
+// the provider mirror. Larger providers are installed directly instead.
+const MaximumProviderArchiveSize = 256 * 1024 * 1024 // 256MB
+
+func getProviderMirrorConfigPath(workdir string) string {
+	return filepath.Join(workdir, ".terraform", "provider-mirror.tfrc")
+}
+
+// writeProviderMirrorConfig writes a CLI configuration that installs providers
+// from the provider mirror of coderd. Providers the mirror does not have are
+// installed directly, as without a mirror.
+func writeProviderMirrorConfig(workdir string, metadata *proto.Metadata) (string, error) {
+	mirrorURL, err := url.Parse(metadata.GetProviderMirrorUrl())
+	if err != nil {
+		return "", xerrors.Errorf("parse provider mirror url: %w", err)
+	}
+
+	var config strings.Builder
+	_, _ = fmt.Fprintf(&config, "provider_installation {\n  network_mirror {\n    url = %q\n  }\n  direct {}\n}\n", mirrorURL.String())
+	if token := metadata.GetProviderMirrorToken(); token != "" {
+		_, _ = fmt.Fprintf(&config, "\ncredentials %q {\n  token = %q\n}\n", mirrorURL.Host, token)
+	}
+
+	configPath := getProviderMirrorConfigPath(workdir)
+	err = os.MkdirAll(filepath.Dir(configPath), 0o700)
+	if err != nil {
+		return "", xerrors.Errorf("create config directory: %w", err)
+	}
+	err = os.WriteFile(configPath, []byte(config.String()), 0o600)
+	if err != nil {
+		return "", xerrors.Errorf("write provider mirror config: %w", err)
+	}
+	return configPath, nil
+}
+
+// installCachedModules extracts the modules cached by the template import
+// into the working directory. `terraform init` keeps modules that are already
+// installed, so it only downloads the ones that are missing.
+func installCachedModules(ctx context.Context, workdir string, metadata *proto.Metadata) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, metadata.GetModuleFilesUrl(), nil)
+	if err != nil {
+		return xerrors.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+metadata.GetProviderMirrorToken())
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return xerrors.Errorf("get module files: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return xerrors.Errorf("get module files: unexpected status code %d", res.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(res.Body, MaximumModuleArchiveSize+1))
+	if err != nil {
+		return xerrors.Errorf("read module files: %w", err)
+	}
+	if len(data) > MaximumModuleArchiveSize {
+		return xerrors.Errorf("module files exceed the maximum size of %d bytes", MaximumModuleArchiveSize)
+	}
+	hash := sha256.Sum256(data)
+	if want := path.Base(req.URL.Path); hex.EncodeToString(hash[:]) != want {
+		return xerrors.Errorf("module files hash mismatch, got %x, expected %s", hash, want)
+	}
+
+	err = provisionersdk.Untar(workdir, bytes.NewReader(data))
+	if err != nil {
+		return xerrors.Errorf("extract module files: %w", err)
+	}
+	return nil
+}
+
+// getProviderArchives archives the provider packages installed by
+// `terraform init` for the provider mirror. The packages are laid out as
+// .terraform/providers/HOSTNAME/NAMESPACE/TYPE/VERSION/PLATFORM. Every package
+// is archived to a temporary file and uploaded before the next one, so that
+// they are never held in memory.
+func getProviderArchives(workdir string, upload func(archive io.ReadSeeker) ([]byte, error)) ([]*proto.ProviderArchive, error) {
+	root := filepath.Join(workdir, ".terraform", "providers")
+	if _, err := os.Stat(root); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	var archives []*proto.ProviderArchive
+	packageDirs, err := filepath.Glob(filepath.Join(root, "*", "*", "*", "*", "*"))
+	if err != nil {
+		return nil, xerrors.Errorf("find provider packages: %w", err)
+	}
+	for _, packageDir := range packageDirs {
+		rel, err := filepath.Rel(root, packageDir)
+		if err != nil {
+			return nil, xerrors.Errorf("find provider packages: %w", err)
+		}
+		parts := strings.Split(filepath.ToSlash(rel), "/")
+
+		// Packages are often symlinks into the plugin cache directory.
+		dir, err := filepath.EvalSymlinks(packageDir)
+		if err != nil {
+			return nil, xerrors.Errorf("resolve provider package %q: %w", rel, err)
+		}
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			continue
+		}
+		hash, err := dirhash.HashDir(dir, "", dirhash.Hash1)
+		if err != nil {
+			return nil, xerrors.Errorf("hash provider package %q: %w", rel, err)
+		}
+		dataHash, err := uploadProviderPackage(dir, upload)
+		if err != nil {
+			if xerrors.Is(err, xio.ErrLimitReached) {
+				continue
+			}
+			return nil, xerrors.Errorf("upload provider package %q: %w", rel, err)
+		}
+		archives = append(archives, &proto.ProviderArchive{
+			Hostname:  parts[0],
+			Namespace: parts[1],
+			Type:      parts[2],
+			Version:   parts[3],
+			Platform:  parts[4],
+			Hash:      hash,
+			DataHash:  dataHash,
+		})
+	}
+	return archives, nil
+}
+
+// uploadProviderPackage archives a provider package to a temporary file and
+// uploads it, returning the hash of the uploaded archive.
+func uploadProviderPackage(dir string, upload func(archive io.ReadSeeker) ([]byte, error)) ([]byte, error) {
+	file, err := os.CreateTemp("", "coder-provider-archive-*.zip")
+	if err != nil {
+		return nil, xerrors.Errorf("create archive file: %w", err)
+	}
+	defer func() {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+	}()
+
+	err = zipProviderPackage(file, dir)
+	if err != nil {
+		return nil, err
+	}
+	_, err = file.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, xerrors.Errorf("seek archive file: %w", err)
+	}
+	return upload(file)
+}
+
+// zipProviderPackage archives an unpacked provider package. The archive
+// leaves out modification times, so that archiving the same package twice
+// yields the same bytes and is stored only once.
+func zipProviderPackage(dst io.Writer, dir string) error {
+	w := zip.NewWriter(xio.NewLimitWriter(dst, MaximumProviderArchiveSize))
+
+	err := filepath.WalkDir(dir, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, filePath)
+		if err != nil {
+			return err
+		}
+		header := &zip.FileHeader{
+			Name:   filepath.ToSlash(rel),
+			Method: zip.Deflate,
+		}
+		header.SetMode(info.Mode())
+		fw, err := w.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		file, err := os.Open(filePath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(fw, file)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return w.Close()
+}
@@ -0,0 +1,84 @@
+package terraform
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/provisionersdk/proto"
+)
+
+func TestGetProviderArchives(t *testing.T) {
+	t.Parallel()
+
+	// upload records the uploaded archives by the hash they are referenced by.
+	upload := func(uploaded map[string][]byte) func(io.ReadSeeker) ([]byte, error) {
+		return func(archive io.ReadSeeker) ([]byte, error) {
+			data, err := io.ReadAll(archive)
+			if err != nil {
+				return nil, err
+			}
+			hash := sha256.Sum256(data)
+			uploaded[string(hash[:])] = data
+			return hash[:], nil
+		}
+	}
+
+	t.Run("NoProviders", func(t *testing.T) {
+		t.Parallel()
+
+		uploaded := map[string][]byte{}
+		archives, err := getProviderArchives(t.TempDir(), upload(uploaded))
+		require.NoError(t, err)
+		require.Empty(t, archives)
+		require.Empty(t, uploaded)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		t.Parallel()
+
+		workdir := t.TempDir()
+		packageDir := filepath.Join(workdir, ".terraform", "providers", "registry.terraform.io", "coder", "coder", "2.5.3", "linux_amd64")
+		require.NoError(t, os.MkdirAll(packageDir, 0o755))
+		binary := filepath.Join(packageDir, "terraform-provider-coder_v2.5.3")
+		require.NoError(t, os.WriteFile(binary, []byte("provider"), 0o755))
+
+		uploaded := map[string][]byte{}
+		archives, err := getProviderArchives(workdir, upload(uploaded))
+		require.NoError(t, err)
+		require.Len(t, archives, 1)
+		archive := archives[0]
+		require.Equal(t, "registry.terraform.io", archive.Hostname)
+		require.Equal(t, "coder", archive.Namespace)
+		require.Equal(t, "coder", archive.Type)
+		require.Equal(t, "2.5.3", archive.Version)
+		require.Equal(t, "linux_amd64", archive.Platform)
+		require.True(t, strings.HasPrefix(archive.Hash, "h1:"))
+
+		require.Empty(t, archive.Data)
+		require.Len(t, uploaded, 1)
+		data, ok := uploaded[string(archive.DataHash)]
+		require.True(t, ok)
+		reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		require.NoError(t, err)
+		require.Len(t, reader.File, 1)
+		require.Equal(t, "terraform-provider-coder_v2.5.3", reader.File[0].Name)
+
+		// Archiving the package again must yield the same bytes, so that the
+		// archive is only stored once.
+		later := time.Now().Add(time.Hour)
+		require.NoError(t, os.Chtimes(binary, later, later))
+		again, err := getProviderArchives(workdir, upload(uploaded))
+		require.NoError(t, err)
+		require.Equal(t, []*proto.ProviderArchive{archive}, again)
+		require.Len(t, uploaded, 1)
+	})
+}
@@ -76,7 +76,11 @@ func (s *server) Plan(
 	defer cancel()
 	defer kill()
 
-	e := s.executor(sess.WorkDirectory, database.ProvisionerJobTimingStagePlan)
+	binaryPath, err := s.binaryPathForVersion(sess.Config.GetProvisionerVersion())
+	if err != nil {
+		return provisionersdk.PlanErrorf("%s", err.Error())
+	}
+	e := s.executor(binaryPath, sess.WorkDirectory, database.ProvisionerJobTimingStagePlan)
 	if err := e.checkMinVersion(ctx); err != nil {
 		return provisionersdk.PlanErrorf("%s", err.Error())
 	}
@@ -100,11 +104,15 @@ func (s *server) Plan(
 		}
 	}
 
-	err := CleanStaleTerraformPlugins(sess.Context(), s.cachePath, afero.NewOsFs(), time.Now(), s.logger)
+	err = CleanStaleTerraformPlugins(sess.Context(), s.cachePath, afero.NewOsFs(), time.Now(), s.logger)
 	if err != nil {
 		return provisionersdk.PlanErrorf("unable to clean stale Terraform plugins: %s", err)
 	}
 
+	if request.Metadata.GetProviderMirrorUrl() != "" {
+		s.configureProviderMirror(ctx, sess, e, request.Metadata)
+	}
+
 	s.logger.Debug(ctx, "running initialization")
 
 	// The JSON output of `terraform init` doesn't include discrete fields for capturing timings of each plugin,
@@ -172,9 +180,40 @@ func (s *server) Plan(
 	// Order is irrelevant; this is merely indicative.
 	resp.Timings = append(initTimings.aggregate(), resp.Timings...)
 	resp.Modules = modules
+	if request.CollectProviderArchives {
+		resp.ProviderArchives, err = getProviderArchives(sess.WorkDirectory, sess.UploadProviderArchive)
+		if err != nil {
+			// The provider mirror is a cache, so a template import does not
+			// fail if it cannot be populated.
+			s.logger.Warn(ctx, "failed to archive terraform providers", slog.Error(err))
+		}
+	}
 	return resp
 }
 
+// configureProviderMirror points `terraform init` at the provider mirror and
+// installs the modules cached by the template import. Both are caches, so
+// failures are logged and init downloads from the original sources instead.
+func (s *server) configureProviderMirror(ctx context.Context, sess *provisionersdk.Session, e *executor, metadata *proto.Metadata) {
+	if e.cliConfigPath != "" || os.Getenv("TF_CLI_CONFIG_FILE") != "" {
+		sess.ProvisionLog(proto.LogLevel_WARN, "A Terraform CLI config file is set, so providers are not installed from the provider mirror")
+	} else {
+		configPath, err := writeProviderMirrorConfig(sess.WorkDirectory, metadata)
+		if err != nil {
+			s.logger.Warn(ctx, "failed to configure provider mirror", slog.Error(err))
+		} else {
+			e.providerMirrorConfigPath = configPath
+		}
+	}
+
+	if metadata.GetModuleFilesUrl() != "" {
+		err := installCachedModules(ctx, sess.WorkDirectory, metadata)
+		if err != nil {
+			s.logger.Warn(ctx, "failed to install cached modules", slog.Error(err))
+		}
+	}
+}
+
 func (s *server) Apply(
 	sess *provisionersdk.Session, request *proto.ApplyRequest, canceledOrComplete <-chan struct{},
 ) *proto.ApplyComplete {
@@ -184,7 +223,11 @@ func (s *server) Apply(
 	defer cancel()
 	defer kill()
 
-	e := s.executor(sess.WorkDirectory, database.ProvisionerJobTimingStageApply)
+	binaryPath, err := s.binaryPathForVersion(sess.Config.GetProvisionerVersion())
+	if err != nil {
+		return provisionersdk.ApplyErrorf("%s", err.Error())
+	}
+	e := s.executor(binaryPath, sess.WorkDirectory, database.ProvisionerJobTimingStageApply)
 	if err := e.checkMinVersion(ctx); err != nil {
 		return provisionersdk.ApplyErrorf("%s", err.Error())
 	}
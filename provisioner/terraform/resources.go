@@ -729,6 +729,11 @@ func ConvertState(ctx context.Context, modules []*tfjson.StateModule, rawGraph s
 			resourceIcon[targetLabel] = attrs.Icon
 			resourceCost[targetLabel] = attrs.DailyCost
 			for _, item := range attrs.Items {
+				if item.Key == provisionersdk.HourlyCostMetadataKey && !item.IsNull {
+					if _, err := provisionersdk.ParseHourlyCost(item.Value); err != nil {
+						return nil, xerrors.Errorf("metadata of resource %q: %w", targetLabel, err)
+					}
+				}
 				resourceMetadata[targetLabel] = append(resourceMetadata[targetLabel],
 					&proto.Resource_Metadata{
 						Key:       item.Key,
@@ -23,9 +23,16 @@ import (
 type ServeOptions struct {
 	*provisionersdk.ServeOptions
 
+	// Distribution is the distribution of the Terraform CLI to provision
+	// with. Defaults to Terraform.
+	Distribution Distribution
 	// BinaryPath specifies the "terraform" binary to use.
 	// If omitted, the $PATH will attempt to find it.
 	BinaryPath string
+	// Versions are additional versions of the distribution to install into
+	// the cache. Jobs that request one of them by the provisioner version of
+	// their configuration run with it, and others run with BinaryPath.
+	Versions []*version.Version
 	// CachePath must not be used by multiple processes at once.
 	CachePath string
 	// CliConfigPath is the path to the Terraform CLI config file.
@@ -50,10 +57,10 @@ type systemBinaryDetails struct {
 	version      *version.Version
 }
 
-func systemBinary(ctx context.Context) (*systemBinaryDetails, error) {
-	binaryPath, err := safeexec.LookPath("terraform")
+func systemBinary(ctx context.Context, distribution Distribution) (*systemBinaryDetails, error) {
+	binaryPath, err := safeexec.LookPath(string(distribution))
 	if err != nil {
-		return nil, xerrors.Errorf("Terraform binary not found: %w", err)
+		return nil, xerrors.Errorf("%s binary not found: %w", distribution.displayName(), err)
 	}
 
 	// If the "coder" binary is in the same directory as
@@ -63,13 +70,13 @@ func systemBinary(ctx context.Context) (*systemBinaryDetails, error) {
 	// to execute this properly!
 	absoluteBinary, err := filepath.Abs(binaryPath)
 	if err != nil {
-		return nil, xerrors.Errorf("Terraform binary absolute path not found: %w", err)
+		return nil, xerrors.Errorf("%s binary absolute path not found: %w", distribution.displayName(), err)
 	}
 
 	// Checking the installed version of Terraform.
 	installedVersion, err := versionFromBinaryPath(ctx, absoluteBinary)
 	if err != nil {
-		return nil, xerrors.Errorf("Terraform binary get version failed: %w", err)
+		return nil, xerrors.Errorf("%s binary get version failed: %w", distribution.displayName(), err)
 	}
 
 	details := &systemBinaryDetails{
@@ -77,7 +84,8 @@ func systemBinary(ctx context.Context) (*systemBinaryDetails, error) {
 		version:      installedVersion,
 	}
 
-	if installedVersion.LessThan(minTerraformVersion) {
+	minVersion, _ := distribution.versionRange()
+	if installedVersion.LessThan(minVersion) {
 		return details, errTerraformMinorVersionMismatch
 	}
 
@@ -86,8 +94,12 @@ func systemBinary(ctx context.Context) (*systemBinaryDetails, error) {
 
 // Serve starts a dRPC server on the provided transport speaking Terraform provisioner.
 func Serve(ctx context.Context, options *ServeOptions) error {
+	if options.Distribution == "" {
+		options.Distribution = DistributionTerraform
+	}
+	minVersion, maxVersion := options.Distribution.versionRange()
 	if options.BinaryPath == "" {
-		binaryDetails, err := systemBinary(ctx)
+		binaryDetails, err := systemBinary(ctx, options.Distribution)
 		if err != nil {
 			// This is an early exit to prevent extra execution in case the context is canceled.
 			// It generally happens in unit tests since this method is asynchronous and
@@ -97,14 +109,14 @@ func Serve(ctx context.Context, options *ServeOptions) error {
 			}
 
 			if errors.Is(err, errTerraformMinorVersionMismatch) {
-				options.Logger.Warn(ctx, "installed terraform version too old, will download known good version to cache, or use a previously cached version",
+				options.Logger.Warn(ctx, "installed "+string(options.Distribution)+" version too old, will download known good version to cache, or use a previously cached version",
 					slog.F("installed_version", binaryDetails.version.String()),
-					slog.F("min_version", minTerraformVersion.String()))
+					slog.F("min_version", minVersion.String()))
 			}
 
-			binPath, err := Install(ctx, options.Logger, options.ExternalProvisioner, options.CachePath, TerraformVersion)
+			binPath, err := InstallDistribution(ctx, options.Logger, options.ExternalProvisioner, options.CachePath, options.Distribution, options.Distribution.DefaultVersion())
 			if err != nil {
-				return xerrors.Errorf("install terraform: %w", err)
+				return xerrors.Errorf("install %s: %w", options.Distribution, err)
 			}
 			options.BinaryPath = binPath
 		} else {
@@ -112,21 +124,32 @@ func Serve(ctx context.Context, options *ServeOptions) error {
 			if options.ExternalProvisioner {
 				logVersion = options.Logger.Info
 			}
-			logVersion(ctx, "detected terraform version",
+			logVersion(ctx, "detected "+string(options.Distribution)+" version",
 				slog.F("installed_version", binaryDetails.version.String()),
-				slog.F("min_version", minTerraformVersion.String()),
-				slog.F("max_version", maxTerraformVersion.String()))
+				slog.F("min_version", minVersion.String()),
+				slog.F("max_version", maxVersion.String()))
 			// Warn if the installed version is newer than what we've decided is the max.
 			// We used to ignore it and download our own version but this makes it easier
 			// to test out newer versions of Terraform.
-			if binaryDetails.version.GreaterThanOrEqual(maxTerraformVersion) {
-				options.Logger.Warn(ctx, "installed terraform version newer than expected, you may experience bugs",
+			if binaryDetails.version.GreaterThanOrEqual(maxVersion) {
+				options.Logger.Warn(ctx, "installed "+string(options.Distribution)+" version newer than expected, you may experience bugs",
 					slog.F("installed_version", binaryDetails.version.String()),
-					slog.F("max_version", maxTerraformVersion.String()))
+					slog.F("max_version", maxVersion.String()))
 			}
 			options.BinaryPath = binaryDetails.absolutePath
 		}
 	}
+	// Each additional version is installed into its own directory of the
+	// cache, so that daemons keep every version they advertise.
+	binaryPaths := make(map[string]string, len(options.Versions))
+	for _, v := range options.Versions {
+		dir := filepath.Join(options.CachePath, "versions", string(options.Distribution), v.String())
+		binPath, err := InstallDistribution(ctx, options.Logger, options.ExternalProvisioner, dir, options.Distribution, v)
+		if err != nil {
+			return xerrors.Errorf("install %s %s: %w", options.Distribution, v, err)
+		}
+		binaryPaths[v.String()] = binPath
+	}
 	if options.Tracer == nil {
 		options.Tracer = trace.NewNoopTracerProvider().Tracer("noop")
 	}
@@ -135,7 +158,9 @@ func Serve(ctx context.Context, options *ServeOptions) error {
 	}
 	return provisionersdk.Serve(ctx, &server{
 		execMut:       &sync.Mutex{},
+		distribution:  options.Distribution,
 		binaryPath:    options.BinaryPath,
+		binaryPaths:   binaryPaths,
 		cachePath:     options.CachePath,
 		cliConfigPath: options.CliConfigPath,
 		logger:        options.Logger,
@@ -145,8 +170,11 @@ func Serve(ctx context.Context, options *ServeOptions) error {
 }
 
 type server struct {
-	execMut       *sync.Mutex
-	binaryPath    string
+	execMut      *sync.Mutex
+	distribution Distribution
+	binaryPath   string
+	// binaryPaths are the binaries of the additional versions, by version.
+	binaryPaths   map[string]string
 	cachePath     string
 	cliConfigPath string
 	logger        slog.Logger
@@ -160,11 +188,28 @@ func (s *server) startTrace(ctx context.Context, name string, opts ...trace.Span
 	))...)
 }
 
-func (s *server) executor(workdir string, stage database.ProvisionerJobTimingStage) *executor {
+// binaryPathForVersion returns the binary of the version requested by the
+// configuration of a session, or the default binary if none is requested.
+func (s *server) binaryPathForVersion(requested string) (string, error) {
+	if requested == "" {
+		return s.binaryPath, nil
+	}
+	v, err := version.NewVersion(requested)
+	if err != nil {
+		return "", xerrors.Errorf("parse provisioner version %q: %w", requested, err)
+	}
+	binaryPath, ok := s.binaryPaths[v.String()]
+	if !ok {
+		return "", xerrors.Errorf("%s %s is not installed on this provisioner daemon", s.distribution.displayName(), v)
+	}
+	return binaryPath, nil
+}
+
+func (s *server) executor(binaryPath, workdir string, stage database.ProvisionerJobTimingStage) *executor {
 	return &executor{
 		server:        s,
 		mut:           s.execMut,
-		binaryPath:    s.binaryPath,
+		binaryPath:    binaryPath,
 		cachePath:     s.cachePath,
 		cliConfigPath: s.cliConfigPath,
 		workdir:       workdir,
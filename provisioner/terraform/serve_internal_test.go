@@ -84,7 +84,7 @@ func Test_absoluteBinaryPath(t *testing.T) {
 			}
 
 			ctx := testutil.Context(t, testutil.WaitShort)
-			actualBinaryDetails, actualErr := systemBinary(ctx)
+			actualBinaryDetails, actualErr := systemBinary(ctx, DistributionTerraform)
 
 			if tt.expectedErr == nil {
 				require.NoError(t, actualErr)
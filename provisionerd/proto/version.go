@@ -44,9 +44,34 @@ import "github.com/coder/coder/v2/apiversion"
 //     -> `has_ai_tasks` in `CompleteJob.TemplateImport`
 //     -> `has_ai_tasks` and `ai_tasks` in `PlanComplete`
 //     -> new message types `AITaskSidebarApp` and `AITask`
+//
+// API v1.8:
+//   - Add `workspace_drift_check` to the types of `AcquiredJob`, `FailedJob`
+//     and `CompletedJob`. Workspace drift checks plan a workspace build
+//     without applying it.
+//
+// API v1.9:
+//   - Add `plan_policies` to `AcquiredJob.WorkspaceBuild`. Provisioner daemons
+//     evaluate them against the plan of the build before applying it.
+//
+// API v1.10:
+//   - Add `provisioner_version` to `AcquiredJob` and `provisioner_version` to
+//     `provisioner.Config`. Jobs of templates that pin a version of the
+//     provisioner binary run with that version.
+//
+// API v1.11:
+//   - Add `workspace_update` to `AcquiredJob.TemplateDryRun`, and `plan` and
+//     `resource_replacements` to `CompletedJob.TemplateDryRun`. Dry-runs with
+//     a workspace update plan against the state of the workspace.
+//
+// API v1.12:
+//   - Add `provider_mirror_url`, `provider_mirror_token` and
+//     `module_files_url` to `provisioner.Metadata`, and `provider_archives` to
+//     `CompletedJob.TemplateImport`. Template imports populate the provider
+//     mirror of coderd, and other jobs install providers and modules from it.
 const (
 	CurrentMajor = 1
-	CurrentMinor = 7
+	CurrentMinor = 12
 )
 
 // CurrentVersion is the current provisionerd API version.
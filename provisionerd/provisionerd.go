@@ -418,6 +418,7 @@ func (p *Server) acquireAndRunOne(client proto.DRPCProvisionerDaemonClient) erro
 		runner.Options{
 			Updater:             p,
 			QuotaCommitter:      p,
+			ArchiveUploader:     p,
 			Logger:              p.opts.Logger.Named("runner"),
 			Provisioner:         resp.Client,
 			UpdateInterval:      p.opts.UpdateInterval,
@@ -520,6 +521,24 @@ func (p *Server) FailJob(ctx context.Context, in *proto.FailedJob) error {
 
 // UploadModuleFiles will insert a file into the database of coderd.
 func (p *Server) UploadModuleFiles(ctx context.Context, moduleFiles []byte) error {
+	err := p.uploadFile(ctx, sdkproto.DataUploadType_UPLOAD_TYPE_MODULE_FILES, moduleFiles)
+	if err != nil {
+		return xerrors.Errorf("upload module files: %w", err)
+	}
+	return nil
+}
+
+// UploadProviderArchive will insert a provider package of the provider
+// mirror into the database of coderd.
+func (p *Server) UploadProviderArchive(ctx context.Context, archive []byte) error {
+	err := p.uploadFile(ctx, sdkproto.DataUploadType_UPLOAD_TYPE_PROVIDER_ARCHIVE, archive)
+	if err != nil {
+		return xerrors.Errorf("upload provider archive: %w", err)
+	}
+	return nil
+}
+
+func (p *Server) uploadFile(ctx context.Context, uploadType sdkproto.DataUploadType, data []byte) error {
 	// Send the files separately if the message size is too large.
 	_, err := clientDoWithRetries(ctx, p.client, func(ctx context.Context, client proto.DRPCProvisionerDaemonClient) (*proto.Empty, error) {
 		// Add some timeout to prevent the stream from hanging indefinitely.
@@ -532,7 +551,7 @@ func (p *Server) UploadModuleFiles(ctx context.Context, moduleFiles []byte) erro
 		}
 		defer stream.Close()
 
-		dataUp, chunks := sdkproto.BytesToDataUpload(sdkproto.DataUploadType_UPLOAD_TYPE_MODULE_FILES, moduleFiles)
+		dataUp, chunks := sdkproto.BytesToDataUpload(uploadType, data)
 
 		err = stream.Send(&proto.UploadFileRequest{Type: &proto.UploadFileRequest_DataUpload{DataUpload: dataUp}})
 		if err != nil {
@@ -561,16 +580,12 @@ func (p *Server) UploadModuleFiles(ctx context.Context, moduleFiles []byte) erro
 		}
 		return resp, nil
 	})
-	if err != nil {
-		return xerrors.Errorf("upload module files: %w", err)
-	}
-
-	return nil
+	return err
 }
 
 func (p *Server) CompleteJob(ctx context.Context, in *proto.CompletedJob) error {
-	// If the moduleFiles exceed the max message size, we need to upload them separately.
 	if ti, ok := in.Type.(*proto.CompletedJob_TemplateImport_); ok {
+		// If the moduleFiles exceed the max message size, we need to upload them separately.
 		messageSize := protobuf.Size(in)
 		if messageSize > drpcsdk.MaxMessageSize &&
 			messageSize-len(ti.TemplateImport.ModuleFiles) < drpcsdk.MaxMessageSize {
@@ -22,6 +22,7 @@ import (
 
 	"cdr.dev/slog"
 
+	"github.com/coder/coder/v2/coderd/planpolicy"
 	"github.com/coder/coder/v2/coderd/tracing"
 	"github.com/coder/coder/v2/coderd/util/ptr"
 	"github.com/coder/coder/v2/provisionerd/proto"
@@ -34,11 +35,15 @@ const (
 
 	RequiredTemplateVariablesErrorCode = "REQUIRED_TEMPLATE_VARIABLES"
 	requiredTemplateVariablesErrorText = "required template variables"
+
+	PlanPolicyViolationErrorCode = "PLAN_POLICY_VIOLATION"
+	planPolicyViolationErrorText = "plan violates policies"
 )
 
 var errorCodes = map[string]string{
 	MissingParameterErrorCode:          missingParameterErrorText,
 	RequiredTemplateVariablesErrorCode: requiredTemplateVariablesErrorText,
+	PlanPolicyViolationErrorCode:       planPolicyViolationErrorText,
 }
 
 var errUpdateSkipped = xerrors.New("update skipped; job complete or failed")
@@ -49,6 +54,7 @@ type Runner struct {
 	job                 *proto.AcquiredJob
 	sender              JobUpdater
 	quotaCommitter      QuotaCommitter
+	archiveUploader     ProviderArchiveUploader
 	logger              slog.Logger
 	provisioner         sdkproto.DRPCProvisionerClient
 	lastUpdate          atomic.Pointer[time.Time]
@@ -100,10 +106,14 @@ type JobUpdater interface {
 type QuotaCommitter interface {
 	CommitQuota(ctx context.Context, in *proto.CommitQuotaRequest) (*proto.CommitQuotaResponse, error)
 }
+type ProviderArchiveUploader interface {
+	UploadProviderArchive(ctx context.Context, archive []byte) error
+}
 
 type Options struct {
 	Updater             JobUpdater
 	QuotaCommitter      QuotaCommitter
+	ArchiveUploader     ProviderArchiveUploader
 	Logger              slog.Logger
 	Provisioner         sdkproto.DRPCProvisionerClient
 	UpdateInterval      time.Duration
@@ -143,6 +153,7 @@ func New(
 		job:                 job,
 		sender:              opts.Updater,
 		quotaCommitter:      opts.QuotaCommitter,
+		archiveUploader:     opts.ArchiveUploader,
 		logger:              logger,
 		provisioner:         opts.Provisioner,
 		updateInterval:      opts.UpdateInterval,
@@ -443,6 +454,12 @@ func (r *Runner) do(ctx context.Context) (*proto.CompletedJob, *proto.FailedJob)
 			slog.F("variable_values", redactVariableValues(jobType.WorkspaceBuild.VariableValues)),
 		)
 		return r.runWorkspaceBuild(ctx)
+	case *proto.AcquiredJob_WorkspaceDriftCheck:
+		r.logger.Debug(context.Background(), "acquired job is workspace drift check",
+			slog.F("workspace_name", jobType.WorkspaceDriftCheck.WorkspaceName),
+			slog.F("state_length", len(jobType.WorkspaceDriftCheck.State)),
+		)
+		return r.runWorkspaceDriftCheck(ctx)
 	default:
 		return nil, r.failedJobf("unknown job type %q; ensure your provisioner daemon is up-to-date",
 			reflect.TypeOf(r.job.Type).String())
@@ -450,6 +467,8 @@ func (r *Runner) do(ctx context.Context) (*proto.CompletedJob, *proto.FailedJob)
 }
 
 func (r *Runner) configure(config *sdkproto.Config) *proto.FailedJob {
+	// Every job of a template runs with the provisioner version it pins.
+	config.ProvisionerVersion = r.job.GetProvisionerVersion()
 	err := r.session.Send(&sdkproto.Request{Type: &sdkproto.Request_Config{Config: config}})
 	if err != nil {
 		return r.failedJobf("send config: %s", err)
@@ -556,6 +575,8 @@ func (r *Runner) runTemplateImport(ctx context.Context) (*proto.CompletedJob, *p
 		CoderUrl:             r.job.GetTemplateImport().Metadata.CoderUrl,
 		WorkspaceOwnerGroups: r.job.GetTemplateImport().Metadata.WorkspaceOwnerGroups,
 		WorkspaceTransition:  sdkproto.WorkspaceTransition_START,
+		ProviderMirrorUrl:    r.job.GetTemplateImport().Metadata.ProviderMirrorUrl,
+		ProviderMirrorToken:  r.job.GetTemplateImport().Metadata.ProviderMirrorToken,
 	}, false)
 	if err != nil {
 		return nil, r.failedJobf("template import provision for start: %s", err)
@@ -572,6 +593,8 @@ func (r *Runner) runTemplateImport(ctx context.Context) (*proto.CompletedJob, *p
 		CoderUrl:             r.job.GetTemplateImport().Metadata.CoderUrl,
 		WorkspaceOwnerGroups: r.job.GetTemplateImport().Metadata.WorkspaceOwnerGroups,
 		WorkspaceTransition:  sdkproto.WorkspaceTransition_STOP,
+		ProviderMirrorUrl:    r.job.GetTemplateImport().Metadata.ProviderMirrorUrl,
+		ProviderMirrorToken:  r.job.GetTemplateImport().Metadata.ProviderMirrorToken,
 	}, true, // Modules downloaded on the start provision
 	)
 	if err != nil {
@@ -600,8 +623,9 @@ func (r *Runner) runTemplateImport(ctx context.Context) (*proto.CompletedJob, *p
 				// ModuleFiles are not on the stopProvision. So grab from the startProvision.
 				ModuleFiles: startProvision.ModuleFiles,
 				// ModuleFileHash will be populated if the file is uploaded async
-				ModuleFilesHash: []byte{},
-				HasAiTasks:      startProvision.HasAITasks,
+				ModuleFilesHash:  []byte{},
+				HasAiTasks:       startProvision.HasAITasks,
+				ProviderArchives: startProvision.ProviderArchives,
 			},
 		},
 	}, nil
@@ -666,6 +690,7 @@ type templateImportProvision struct {
 	Plan                  json.RawMessage
 	ModuleFiles           []byte
 	HasAITasks            bool
+	ProviderArchives      []*sdkproto.ProviderArchive
 }
 
 // Performs a dry-run provision when importing a template.
@@ -703,6 +728,9 @@ func (r *Runner) runTemplateImportProvisionWithRichParameters(
 		PreviousParameterValues: make([]*sdkproto.RichParameterValue, 0),
 		VariableValues:          variableValues,
 		OmitModuleFiles:         omitModules,
+		// Providers are installed on the start provision of template imports,
+		// which populate the provider mirror.
+		CollectProviderArchives: r.job.GetTemplateImport() != nil && !omitModules && metadata.GetProviderMirrorUrl() != "",
 	}}})
 	if err != nil {
 		return nil, xerrors.Errorf("start provision: %w", err)
@@ -725,6 +753,11 @@ func (r *Runner) runTemplateImportProvisionWithRichParameters(
 	}()
 
 	var moduleFilesUpload *sdkproto.DataBuilder
+	// providerArchiveUploads are keyed by the hash of their data. Every
+	// archive is uploaded to coderd as soon as it is complete, so that only
+	// one is held in memory at a time.
+	providerArchiveUploads := map[string]*sdkproto.DataBuilder{}
+	uploadedProviderArchives := map[string]struct{}{}
 	for {
 		msg, err := r.session.Recv()
 		if err != nil {
@@ -746,6 +779,13 @@ func (r *Runner) runTemplateImportProvisionWithRichParameters(
 			})
 		case *sdkproto.Response_DataUpload:
 			c := msgType.DataUpload
+			if c.UploadType == sdkproto.DataUploadType_UPLOAD_TYPE_PROVIDER_ARCHIVE {
+				providerArchiveUploads[string(c.DataHash)], err = sdkproto.NewDataBuilder(c)
+				if err != nil {
+					return nil, xerrors.Errorf("create data builder: %w", err)
+				}
+				continue
+			}
 			if c.UploadType != sdkproto.DataUploadType_UPLOAD_TYPE_MODULE_FILES {
 				return nil, xerrors.Errorf("invalid data upload type: %q", c.UploadType)
 			}
@@ -760,6 +800,26 @@ func (r *Runner) runTemplateImportProvisionWithRichParameters(
 			}
 		case *sdkproto.Response_ChunkPiece:
 			c := msgType.ChunkPiece
+			if upload, ok := providerArchiveUploads[string(c.FullDataHash)]; ok {
+				done, err := upload.Add(c)
+				if err != nil {
+					return nil, xerrors.Errorf("provider archive, add chunk piece: %w", err)
+				}
+				if !done {
+					continue
+				}
+				delete(providerArchiveUploads, string(c.FullDataHash))
+				data, err := upload.Complete()
+				if err != nil {
+					return nil, xerrors.Errorf("provider archive, complete upload: %w", err)
+				}
+				err = r.archiveUploader.UploadProviderArchive(ctx, data)
+				if err != nil {
+					return nil, xerrors.Errorf("upload provider archive: %w", err)
+				}
+				uploadedProviderArchives[string(c.FullDataHash)] = struct{}{}
+				continue
+			}
 			if moduleFilesUpload == nil {
 				return nil, xerrors.New("received chunk piece before module files data upload")
 			}
@@ -798,6 +858,14 @@ func (r *Runner) runTemplateImportProvisionWithRichParameters(
 					return nil, xerrors.Errorf("module files hash mismatch, uploaded: %x, expected: %x", moduleFilesUpload.Hash, c.ModuleFilesHash)
 				}
 			}
+			for _, archive := range c.ProviderArchives {
+				if len(archive.DataHash) == 0 {
+					continue
+				}
+				if _, ok := uploadedProviderArchives[string(archive.DataHash)]; !ok {
+					return nil, xerrors.Errorf("provider archive %s/%s/%s %s was not uploaded", archive.Hostname, archive.Namespace, archive.Type, archive.Version)
+				}
+			}
 			return &templateImportProvision{
 				Resources:             c.Resources,
 				Parameters:            c.Parameters,
@@ -807,6 +875,7 @@ func (r *Runner) runTemplateImportProvisionWithRichParameters(
 				Plan:                  c.Plan,
 				ModuleFiles:           moduleFilesData,
 				HasAITasks:            c.HasAiTasks,
+				ProviderArchives:      c.ProviderArchives,
 			}, nil
 		default:
 			return nil, xerrors.Errorf("invalid message type %q received from provisioner",
@@ -828,7 +897,9 @@ func (r *Runner) runTemplateDryRun(ctx context.Context) (*proto.CompletedJob, *p
 	if metadata.WorkspaceName == "" {
 		metadata.WorkspaceName = "dryrun"
 	}
-	metadata.WorkspaceOwner = r.job.UserName
+	if metadata.WorkspaceOwner == "" {
+		metadata.WorkspaceOwner = r.job.UserName
+	}
 	if metadata.WorkspaceOwner == "" {
 		metadata.WorkspaceOwner = "dryrunner"
 	}
@@ -847,6 +918,10 @@ func (r *Runner) runTemplateDryRun(ctx context.Context) (*proto.CompletedJob, *p
 		metadata.WorkspaceOwnerId = id.String()
 	}
 
+	if update := r.job.GetTemplateDryRun().GetWorkspaceUpdate(); update != nil {
+		return r.runWorkspaceUpdateDryRun(ctx, metadata, update)
+	}
+
 	failedJob := r.configure(&sdkproto.Config{
 		TemplateSourceArchive: r.job.GetTemplateSourceArchive(),
 	})
@@ -876,6 +951,66 @@ func (r *Runner) runTemplateDryRun(ctx context.Context) (*proto.CompletedJob, *p
 	}, nil
 }
 
+// runWorkspaceUpdateDryRun plans the template version against the state of an
+// existing workspace, without applying it. Coderd shows the changes in the plan
+// before the workspace is updated to the template version.
+func (r *Runner) runWorkspaceUpdateDryRun(ctx context.Context, metadata *sdkproto.Metadata, update *proto.AcquiredJob_TemplateDryRun_WorkspaceUpdate) (*proto.CompletedJob, *proto.FailedJob) {
+	failedJob := r.configure(&sdkproto.Config{
+		TemplateSourceArchive: r.job.GetTemplateSourceArchive(),
+		State:                 update.State,
+	})
+	if failedJob != nil {
+		return nil, r.asFailedDryRun(failedJob)
+	}
+
+	resp, failed := r.buildWorkspace(ctx, "Previewing workspace update", &sdkproto.Request{
+		Type: &sdkproto.Request_Plan{
+			Plan: &sdkproto.PlanRequest{
+				OmitModuleFiles:         true,
+				Metadata:                metadata,
+				RichParameterValues:     r.job.GetTemplateDryRun().GetRichParameterValues(),
+				PreviousParameterValues: update.PreviousParameterValues,
+				VariableValues:          r.job.GetTemplateDryRun().GetVariableValues(),
+			},
+		},
+	})
+	if failed != nil {
+		return nil, r.asFailedDryRun(failed)
+	}
+	planComplete := resp.GetPlan()
+	if planComplete == nil {
+		return nil, r.failedJobf("invalid message type %T received from provisioner", resp.Type)
+	}
+	if planComplete.Error != "" {
+		return nil, &proto.FailedJob{
+			JobId: r.job.JobId,
+			Error: planComplete.Error,
+		}
+	}
+	r.flushQueuedLogs(ctx)
+
+	return &proto.CompletedJob{
+		JobId: r.job.JobId,
+		Type: &proto.CompletedJob_TemplateDryRun_{
+			TemplateDryRun: &proto.CompletedJob_TemplateDryRun{
+				Resources:            planComplete.Resources,
+				Modules:              planComplete.Modules,
+				Plan:                 planComplete.Plan,
+				ResourceReplacements: planComplete.ResourceReplacements,
+			},
+		},
+	}, nil
+}
+
+// asFailedDryRun marks a failed job as a dry-run, so coderd doesn't treat it
+// as a failed workspace build.
+func (*Runner) asFailedDryRun(failedJob *proto.FailedJob) *proto.FailedJob {
+	failedJob.Type = &proto.FailedJob_TemplateDryRun_{
+		TemplateDryRun: &proto.FailedJob_TemplateDryRun{},
+	}
+	return failedJob
+}
+
 func (r *Runner) buildWorkspace(ctx context.Context, stage string, req *sdkproto.Request) (
 	*sdkproto.Response, *proto.FailedJob,
 ) {
@@ -987,6 +1122,65 @@ func (r *Runner) commitQuota(ctx context.Context, resources []*sdkproto.Resource
 	return nil
 }
 
+// evaluatePlanPolicies evaluates the plan policies of the organization against
+// the plan of the build. Warnings are written to the build logs, and denials
+// fail the build before anything is applied.
+func (r *Runner) evaluatePlanPolicies(ctx context.Context, plan []byte) *proto.FailedJob {
+	build := r.job.GetWorkspaceBuild()
+	if len(build.GetPlanPolicies()) == 0 {
+		return nil
+	}
+	// A policy must never keep a workspace from being deleted.
+	if build.GetMetadata().GetWorkspaceTransition() == sdkproto.WorkspaceTransition_DESTROY {
+		return nil
+	}
+
+	const stage = "Evaluating plan policies"
+
+	logf := func(level sdkproto.LogLevel, format string, args ...any) {
+		r.queueLog(ctx, &proto.Log{
+			Source:    proto.LogSource_PROVISIONER,
+			Level:     level,
+			CreatedAt: time.Now().UnixMilli(),
+			Output:    fmt.Sprintf(format, args...),
+			Stage:     stage,
+		})
+	}
+
+	if len(plan) == 0 {
+		// Policies cannot be enforced, so the build must not be applied.
+		logf(sdkproto.LogLevel_ERROR, "The provisioner did not return a plan to evaluate policies against.")
+		return r.failedWorkspaceBuildf("evaluate plan policies: provisioner returned no plan")
+	}
+
+	policies := make([]planpolicy.Policy, 0, len(build.GetPlanPolicies()))
+	for _, policy := range build.GetPlanPolicies() {
+		policies = append(policies, planpolicy.Policy{
+			Name:   policy.GetName(),
+			Module: policy.GetModule(),
+		})
+	}
+	result, err := planpolicy.Evaluate(ctx, policies, plan)
+	if err != nil {
+		logf(sdkproto.LogLevel_ERROR, "Failed to evaluate plan policies: %s", err)
+		return r.failedWorkspaceBuildf("evaluate plan policies: %s", err)
+	}
+
+	for _, warning := range result.Warnings {
+		logf(sdkproto.LogLevel_WARN, "Warning from policy %s", warning)
+	}
+	if len(result.Denials) == 0 {
+		logf(sdkproto.LogLevel_INFO, "The plan complies with %d policies.", len(policies))
+		return nil
+	}
+	denials := make([]string, 0, len(result.Denials))
+	for _, denial := range result.Denials {
+		logf(sdkproto.LogLevel_ERROR, "Denied by policy %s", denial)
+		denials = append(denials, denial.String())
+	}
+	return r.failedWorkspaceBuildf("%s: %s", planPolicyViolationErrorText, strings.Join(denials, "; "))
+}
+
 func (r *Runner) runWorkspaceBuild(ctx context.Context) (*proto.CompletedJob, *proto.FailedJob) {
 	ctx, span := r.startTrace(ctx, tracing.FuncName())
 	defer span.End()
@@ -1056,6 +1250,11 @@ func (r *Runner) runWorkspaceBuild(ctx context.Context) (*proto.CompletedJob, *p
 		slog.F("resources", resourceNames(planComplete.Resources)),
 	)
 	r.flushQueuedLogs(ctx)
+	failed = r.evaluatePlanPolicies(ctx, planComplete.Plan)
+	r.flushQueuedLogs(ctx)
+	if failed != nil {
+		return nil, failed
+	}
 	if commitQuota {
 		failed = r.commitQuota(ctx, planComplete.Resources)
 		r.flushQueuedLogs(ctx)
@@ -1133,6 +1332,71 @@ func (r *Runner) runWorkspaceBuild(ctx context.Context) (*proto.CompletedJob, *p
 	}, nil
 }
 
+// runWorkspaceDriftCheck plans a workspace build without applying it. Coderd
+// compares the resources in the plan with the state of the build.
+func (r *Runner) runWorkspaceDriftCheck(ctx context.Context) (*proto.CompletedJob, *proto.FailedJob) {
+	ctx, span := r.startTrace(ctx, tracing.FuncName())
+	defer span.End()
+
+	build := r.job.GetWorkspaceDriftCheck()
+	failedJob := r.configure(&sdkproto.Config{
+		TemplateSourceArchive: r.job.GetTemplateSourceArchive(),
+		State:                 build.State,
+		ProvisionerLogLevel:   build.LogLevel,
+	})
+	if failedJob != nil {
+		return nil, r.asFailedDriftCheck(failedJob)
+	}
+
+	resp, failed := r.buildWorkspace(ctx, "Checking for drift", &sdkproto.Request{
+		Type: &sdkproto.Request_Plan{
+			Plan: &sdkproto.PlanRequest{
+				OmitModuleFiles:         true,
+				Metadata:                build.Metadata,
+				RichParameterValues:     build.RichParameterValues,
+				PreviousParameterValues: build.PreviousParameterValues,
+				VariableValues:          build.VariableValues,
+				ExternalAuthProviders:   build.ExternalAuthProviders,
+			},
+		},
+	})
+	if failed != nil {
+		return nil, r.asFailedDriftCheck(failed)
+	}
+	planComplete := resp.GetPlan()
+	if planComplete == nil {
+		return nil, r.asFailedDriftCheck(r.failedJobf("invalid message type %T received from provisioner", resp.Type))
+	}
+	if planComplete.Error != "" {
+		r.logger.Warn(context.Background(), "drift check plan failed",
+			slog.F("error", planComplete.Error),
+		)
+		return nil, r.asFailedDriftCheck(&proto.FailedJob{
+			JobId: r.job.JobId,
+			Error: planComplete.Error,
+		})
+	}
+	r.flushQueuedLogs(ctx)
+
+	return &proto.CompletedJob{
+		JobId: r.job.JobId,
+		Type: &proto.CompletedJob_WorkspaceDriftCheck_{
+			WorkspaceDriftCheck: &proto.CompletedJob_WorkspaceDriftCheck{
+				Plan: planComplete.Plan,
+			},
+		},
+	}, nil
+}
+
+// asFailedDriftCheck marks a failed job as a drift check, so coderd doesn't
+// treat it as a failed workspace build.
+func (*Runner) asFailedDriftCheck(failedJob *proto.FailedJob) *proto.FailedJob {
+	failedJob.Type = &proto.FailedJob_WorkspaceDriftCheck_{
+		WorkspaceDriftCheck: &proto.FailedJob_WorkspaceDriftCheck{},
+	}
+	return failedJob
+}
+
 func resourceNames(rs []*sdkproto.Resource) []string {
 	var sb strings.Builder
 	names := make([]string, 0, len(rs))
@@ -0,0 +1,54 @@
+package provisionersdk
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+// HourlyCostMetadataKey is the key of the "coder_metadata" item which
+// annotates a resource with its cost per hour while the workspace is running,
+// e.g. "0.35". The cost has no currency, templates of a deployment should use
+// the same one.
+const HourlyCostMetadataKey = "hourly_cost"
+
+// hourlyCostMaxDecimals is the precision costs are stored with, in millionths.
+const hourlyCostMaxDecimals = 6
+
+// ParseHourlyCost parses the value of an hourly cost metadata item into
+// millionths of the currency unit. An empty value is no cost.
+func ParseHourlyCost(value string) (int64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+	whole, fraction, _ := strings.Cut(value, ".")
+	if whole == "" {
+		whole = "0"
+	}
+	if len(fraction) > hourlyCostMaxDecimals {
+		return 0, xerrors.Errorf("hourly cost %q has more than %d decimals", value, hourlyCostMaxDecimals)
+	}
+	for _, part := range []string{whole, fraction} {
+		if strings.IndexFunc(part, func(r rune) bool { return r < '0' || r > '9' }) >= 0 {
+			return 0, xerrors.Errorf("hourly cost %q must be a non-negative decimal number", value)
+		}
+	}
+
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || units > math.MaxInt64/1_000_000 {
+		return 0, xerrors.Errorf("hourly cost %q is too large", value)
+	}
+	micros := units * 1_000_000
+	if fraction != "" {
+		fraction += strings.Repeat("0", hourlyCostMaxDecimals-len(fraction))
+		frac, err := strconv.ParseInt(fraction, 10, 64)
+		if err != nil {
+			return 0, xerrors.Errorf("parse hourly cost %q: %w", value, err)
+		}
+		micros += frac
+	}
+	return micros, nil
+}
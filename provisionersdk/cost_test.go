@@ -0,0 +1,43 @@
+package provisionersdk_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/v2/provisionersdk"
+)
+
+func TestParseHourlyCost(t *testing.T) {
+	t.Parallel()
+
+	for _, c := range []struct {
+		value    string
+		expected int64
+		err      bool
+	}{
+		{value: "", expected: 0},
+		{value: "0", expected: 0},
+		{value: "1", expected: 1_000_000},
+		{value: "0.35", expected: 350_000},
+		{value: ".5", expected: 500_000},
+		{value: " 12.000001 ", expected: 12_000_001},
+		{value: "-1", err: true},
+		{value: "1e3", err: true},
+		{value: "$1", err: true},
+		{value: "0.0000001", err: true},
+		{value: "1.2.3", err: true},
+		{value: "99999999999999999999", err: true},
+	} {
+		t.Run(c.value, func(t *testing.T) {
+			t.Parallel()
+			micros, err := provisionersdk.ParseHourlyCost(c.value)
+			if c.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.expected, micros)
+		})
+	}
+}
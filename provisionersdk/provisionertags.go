@@ -1,6 +1,10 @@
 package provisionersdk
 
-import "github.com/google/uuid"
+import (
+	"strings"
+
+	"github.com/google/uuid"
+)
 
 const (
 	TagScope = "scope"
@@ -8,8 +12,57 @@ const (
 
 	ScopeUser         = "user"
 	ScopeOrganization = "organization"
+
+	// TagProvisionerVersionPrefix prefixes the tags that route jobs to the
+	// provisioner daemons that have a version of a provisioner binary
+	// installed, e.g. "provisioner_version.tofu.1.9.1". Daemons carry one
+	// such tag for every version they have installed, and jobs of templates
+	// that pin a version carry the tag of that version.
+	TagProvisionerVersionPrefix = "provisioner_version."
 )
 
+// ProvisionerVersionTag returns the key of the tag for a version of the
+// binary of a provisioner type. Its value is always "true".
+func ProvisionerVersionTag(provisioner, version string) string {
+	return TagProvisionerVersionPrefix + provisioner + "." + version
+}
+
+// IsProvisionerVersionTag returns whether the key of a tag is that of a
+// provisioner binary version. These tags are managed by Coder.
+func IsProvisionerVersionTag(key string) bool {
+	return strings.HasPrefix(key, TagProvisionerVersionPrefix)
+}
+
+// ProvisionerVersionFromTags returns the version of the binary of a
+// provisioner type pinned by the tags of a job. An empty string is
+// returned if the job may run with any version.
+func ProvisionerVersionFromTags(provisioner string, tags map[string]string) string {
+	prefix := ProvisionerVersionTag(provisioner, "")
+	for key, value := range tags {
+		if value == "true" && strings.HasPrefix(key, prefix) {
+			return strings.TrimPrefix(key, prefix)
+		}
+	}
+	return ""
+}
+
+// WithProvisionerVersion returns a copy of the tags of a job with any
+// provisioner version tags replaced by the tag of the given version. No
+// version tag is set if the version is empty.
+func WithProvisionerVersion(tags map[string]string, provisioner, version string) map[string]string {
+	m := make(map[string]string, len(tags)+1)
+	for k, v := range tags {
+		if IsProvisionerVersionTag(k) {
+			continue
+		}
+		m[k] = v
+	}
+	if version != "" {
+		m[ProvisionerVersionTag(provisioner, version)] = "true"
+	}
+	return m
+}
+
 // MutateTags adjusts the "owner" tag dependent on the "scope".
 // If the scope is "user", the "owner" is changed to the user ID.
 // This is for user-scoped provisioner daemons, where users should
@@ -192,3 +192,31 @@ func TestMutateTags(t *testing.T) {
 		})
 	}
 }
+
+func TestWithProvisionerVersion(t *testing.T) {
+	t.Parallel()
+
+	tags := map[string]string{
+		provisionersdk.TagScope:                               provisionersdk.ScopeOrganization,
+		provisionersdk.TagOwner:                               "",
+		provisionersdk.ProvisionerVersionTag("tofu", "1.8.0"): "true",
+	}
+
+	pinned := provisionersdk.WithProvisionerVersion(tags, "tofu", "1.9.1")
+	require.Equal(t, map[string]string{
+		provisionersdk.TagScope:          provisionersdk.ScopeOrganization,
+		provisionersdk.TagOwner:          "",
+		"provisioner_version.tofu.1.9.1": "true",
+	}, pinned)
+	require.Equal(t, "1.9.1", provisionersdk.ProvisionerVersionFromTags("tofu", pinned))
+	require.Empty(t, provisionersdk.ProvisionerVersionFromTags("terraform", pinned))
+	// The tags passed in are not modified.
+	require.Equal(t, "1.8.0", provisionersdk.ProvisionerVersionFromTags("tofu", tags))
+
+	unpinned := provisionersdk.WithProvisionerVersion(tags, "tofu", "")
+	require.Equal(t, map[string]string{
+		provisionersdk.TagScope: provisionersdk.ScopeOrganization,
+		provisionersdk.TagOwner: "",
+	}, unpinned)
+	require.Empty(t, provisionersdk.ProvisionerVersionFromTags("tofu", unpinned))
+}
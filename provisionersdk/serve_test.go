@@ -1,6 +1,7 @@
 package provisionersdk_test
 
 import (
+	"bytes"
 	"context"
 	"net"
 	"testing"
@@ -133,6 +134,68 @@ func TestProvisionerSDK(t *testing.T) {
 			require.NoError(t, err)
 		}
 	})
+
+	t.Run("UploadProviderArchive", func(t *testing.T) {
+		t.Parallel()
+		client, server := drpcsdk.MemTransportPipe()
+		defer client.Close()
+		defer server.Close()
+
+		ctx, cancelFunc := context.WithTimeout(context.Background(), testutil.WaitMedium)
+		defer cancelFunc()
+		// The archive spans several chunks, the last of them partial.
+		archive := bytes.Repeat([]byte("provider"), proto.ChunkSize/4+1)
+		go func() {
+			err := provisionersdk.Serve(ctx, uploadArchiveServer{archive: archive}, &provisionersdk.ServeOptions{
+				Listener:      server,
+				WorkDirectory: t.TempDir(),
+			})
+			assert.NoError(t, err)
+		}()
+
+		api := proto.NewDRPCProvisionerClient(client)
+		s, err := api.Session(ctx)
+		require.NoError(t, err)
+		err = s.Send(&proto.Request{Type: &proto.Request_Config{Config: &proto.Config{}}})
+		require.NoError(t, err)
+		err = s.Send(&proto.Request{Type: &proto.Request_Plan{Plan: &proto.PlanRequest{}}})
+		require.NoError(t, err)
+
+		msg, err := s.Recv()
+		require.NoError(t, err)
+		upload, err := proto.NewDataBuilder(msg.GetDataUpload())
+		require.NoError(t, err)
+		require.Equal(t, proto.DataUploadType_UPLOAD_TYPE_PROVIDER_ARCHIVE, upload.Type)
+		require.EqualValues(t, 3, upload.ChunkCount)
+		for !upload.IsDone() {
+			msg, err = s.Recv()
+			require.NoError(t, err)
+			_, err = upload.Add(msg.GetChunkPiece())
+			require.NoError(t, err)
+		}
+		data, err := upload.Complete()
+		require.NoError(t, err)
+		require.Equal(t, archive, data)
+
+		msg, err = s.Recv()
+		require.NoError(t, err)
+		require.Empty(t, msg.GetPlan().GetError())
+		require.Len(t, msg.GetPlan().GetProviderArchives(), 1)
+		require.Equal(t, upload.Hash, msg.GetPlan().GetProviderArchives()[0].GetDataHash())
+	})
+}
+
+type uploadArchiveServer struct {
+	unimplementedServer
+	archive []byte
+}
+
+func (s uploadArchiveServer) Plan(sess *provisionersdk.Session, _ *proto.PlanRequest, _ <-chan struct{}) *proto.PlanComplete {
+	dataHash, err := sess.UploadProviderArchive(bytes.NewReader(s.archive))
+	if err != nil {
+		return &proto.PlanComplete{Error: err.Error()}
+	}
+	return &proto.PlanComplete{ProviderArchives: []*proto.ProviderArchive{{DataHash: dataHash}}}
 }
 
 type unimplementedServer struct{}
@@ -4,6 +4,7 @@ import (
 	"archive/tar"
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"fmt"
 	"hash/crc32"
 	"io"
@@ -171,24 +172,14 @@ func (s *Session) handleRequests() error {
 				s.Logger.Info(s.Context(), "plan response too large, sending modules as stream",
 					slog.F("size_bytes", len(complete.ModuleFiles)),
 				)
-				dataUp, chunks := proto.BytesToDataUpload(proto.DataUploadType_UPLOAD_TYPE_MODULE_FILES, complete.ModuleFiles)
+				dataHash, err := s.sendDataUpload(proto.DataUploadType_UPLOAD_TYPE_MODULE_FILES, complete.ModuleFiles)
+				if err != nil {
+					complete.Error = err.Error()
+				}
 
 				complete.ModuleFiles = nil // sent over the stream
-				complete.ModuleFilesHash = dataUp.DataHash
+				complete.ModuleFilesHash = dataHash
 				resp.Type = &proto.Response_Plan{Plan: complete}
-
-				err := s.stream.Send(&proto.Response{Type: &proto.Response_DataUpload{DataUpload: dataUp}})
-				if err != nil {
-					complete.Error = fmt.Sprintf("send data upload: %s", err.Error())
-				} else {
-					for i, chunk := range chunks {
-						err := s.stream.Send(&proto.Response{Type: &proto.Response_ChunkPiece{ChunkPiece: chunk}})
-						if err != nil {
-							complete.Error = fmt.Sprintf("send data piece upload %d/%d: %s", i, dataUp.Chunks, err.Error())
-							break
-						}
-					}
-				}
 			}
 
 			if complete.Error == "" {
@@ -233,6 +224,67 @@ func (s *Session) Context() context.Context {
 	return s.stream.Context()
 }
 
+// sendDataUpload streams data to the daemon in chunks, and returns the hash
+// that the complete message should reference it by.
+func (s *Session) sendDataUpload(uploadType proto.DataUploadType, data []byte) ([]byte, error) {
+	dataUp, chunks := proto.BytesToDataUpload(uploadType, data)
+	err := s.stream.Send(&proto.Response{Type: &proto.Response_DataUpload{DataUpload: dataUp}})
+	if err != nil {
+		return dataUp.DataHash, xerrors.Errorf("send data upload: %s", err.Error())
+	}
+	for i, chunk := range chunks {
+		err := s.stream.Send(&proto.Response{Type: &proto.Response_ChunkPiece{ChunkPiece: chunk}})
+		if err != nil {
+			return dataUp.DataHash, xerrors.Errorf("send data piece upload %d/%d: %s", i, dataUp.Chunks, err.Error())
+		}
+	}
+	return dataUp.DataHash, nil
+}
+
+// UploadProviderArchive streams a provider archive to the daemon while the
+// plan is running. Provider archives are far too large for a single message,
+// so they are read in chunks rather than held in memory. It returns the hash
+// the plan response references the archive by.
+func (s *Session) UploadProviderArchive(archive io.ReadSeeker) ([]byte, error) {
+	hasher := sha256.New()
+	size, err := io.Copy(hasher, archive)
+	if err != nil {
+		return nil, xerrors.Errorf("hash provider archive: %w", err)
+	}
+	_, err = archive.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, xerrors.Errorf("seek provider archive: %w", err)
+	}
+
+	dataUp := &proto.DataUpload{
+		UploadType: proto.DataUploadType_UPLOAD_TYPE_PROVIDER_ARCHIVE,
+		DataHash:   hasher.Sum(nil),
+		FileSize:   size,
+		//nolint:gosec // Provider archives are limited far below the int32 range.
+		Chunks: int32((size + proto.ChunkSize - 1) / proto.ChunkSize),
+	}
+	err = s.stream.Send(&proto.Response{Type: &proto.Response_DataUpload{DataUpload: dataUp}})
+	if err != nil {
+		return nil, xerrors.Errorf("send data upload: %s", err.Error())
+	}
+	chunk := make([]byte, proto.ChunkSize)
+	for i := int32(0); i < dataUp.Chunks; i++ {
+		n, err := io.ReadFull(archive, chunk)
+		if err != nil && !xerrors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, xerrors.Errorf("read provider archive: %w", err)
+		}
+		err = s.stream.Send(&proto.Response{Type: &proto.Response_ChunkPiece{ChunkPiece: &proto.ChunkPiece{
+			PieceIndex:   i,
+			Data:         chunk[:n],
+			FullDataHash: dataUp.DataHash,
+		}}})
+		if err != nil {
+			return nil, xerrors.Errorf("send data piece upload %d/%d: %s", i, dataUp.Chunks, err.Error())
+		}
+	}
+	return dataUp.DataHash, nil
+}
+
 func (s *Session) extractArchive() error {
 	ctx := s.Context()
 
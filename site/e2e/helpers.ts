@@ -715,6 +715,7 @@ const createTemplateVersionTar = async (
 			moduleFiles: new Uint8Array(),
 			moduleFilesHash: new Uint8Array(),
 			aiTasks: [],
+			providerArchives: [],
 			...response.plan,
 		} as PlanComplete;
 		response.plan.resources = response.plan.resources?.map(fillResource);
@@ -78,6 +78,11 @@ export enum DataUploadType {
    * parameters.
    */
   UPLOAD_TYPE_MODULE_FILES = 1,
+  /**
+   * UPLOAD_TYPE_PROVIDER_ARCHIVE - UPLOAD_TYPE_PROVIDER_ARCHIVE is used to stream over a provider package
+   * for the provider mirror.
+   */
+  UPLOAD_TYPE_PROVIDER_ARCHIVE = 2,
   UNRECOGNIZED = -1,
 }
 
@@ -350,6 +355,30 @@ export interface Module {
   dir: string;
 }
 
+/**
+ * ProviderArchive is a provider package installed by `terraform init`,
+ * archived in the zip layout of the provider network mirror protocol.
+ */
+export interface ProviderArchive {
+  hostname: string;
+  namespace: string;
+  type: string;
+  version: string;
+  /** platform is the os_arch the provider was built for, e.g. linux_amd64. */
+  platform: string;
+  /**
+   * hash is the "h1:" package hash of the archive contents, as it
+   * appears in dependency lock files.
+   */
+  hash: string;
+  data: Uint8Array;
+  /**
+   * data_hash is the sha256 of data, set when data is sent as a
+   * DataUpload instead.
+   */
+  dataHash: Uint8Array;
+}
+
 export interface Role {
   name: string;
   orgId: string;
@@ -393,6 +422,21 @@ export interface Metadata {
   /** Indicates that a prebuilt workspace is being built. */
   prebuiltWorkspaceBuildStage: PrebuiltWorkspaceBuildStage;
   runningAgentAuthTokens: RunningAgentAuthToken[];
+  /**
+   * provider_mirror_url is the provider network mirror that init should
+   * install providers from, if any.
+   */
+  providerMirrorUrl: string;
+  /**
+   * provider_mirror_token authenticates requests to the provider mirror
+   * for the duration of the job.
+   */
+  providerMirrorToken: string;
+  /**
+   * module_files_url serves the modules cached by the template import, so
+   * that init does not need to download them again.
+   */
+  moduleFilesUrl: string;
 }
 
 /** Config represents execution configuration shared by all subsequent requests in the Session */
@@ -402,6 +446,11 @@ export interface Config {
   /** state is the provisioner state (if any) */
   state: Uint8Array;
   provisionerLogLevel: string;
+  /**
+   * provisioner_version is the version of the provisioner binary to run
+   * with. Empty uses the default version.
+   */
+  provisionerVersion: string;
 }
 
 /** ParseRequest consumes source-code to produce inputs. */
@@ -436,6 +485,11 @@ export interface PlanRequest {
    * behavior of downloading the module files.
    */
   omitModuleFiles: boolean;
+  /**
+   * If true, the provider packages installed by `terraform init` are
+   * returned as provider_archives, to populate the provider mirror.
+   */
+  collectProviderArchives: boolean;
 }
 
 /** PlanComplete indicates a request to plan completed. */
@@ -460,6 +514,7 @@ export interface PlanComplete {
    */
   hasAiTasks: boolean;
   aiTasks: AITask[];
+  providerArchives: ProviderArchive[];
 }
 
 /**
@@ -1157,6 +1212,36 @@ export const Module = {
   },
 };
 
+export const ProviderArchive = {
+  encode(message: ProviderArchive, writer: _m0.Writer = _m0.Writer.create()): _m0.Writer {
+    if (message.hostname !== "") {
+      writer.uint32(10).string(message.hostname);
+    }
+    if (message.namespace !== "") {
+      writer.uint32(18).string(message.namespace);
+    }
+    if (message.type !== "") {
+      writer.uint32(26).string(message.type);
+    }
+    if (message.version !== "") {
+      writer.uint32(34).string(message.version);
+    }
+    if (message.platform !== "") {
+      writer.uint32(42).string(message.platform);
+    }
+    if (message.hash !== "") {
+      writer.uint32(50).string(message.hash);
+    }
+    if (message.data.length !== 0) {
+      writer.uint32(58).bytes(message.data);
+    }
+    if (message.dataHash.length !== 0) {
+      writer.uint32(66).bytes(message.dataHash);
+    }
+    return writer;
+  },
+};
+
 export const Role = {
   encode(message: Role, writer: _m0.Writer = _m0.Writer.create()): _m0.Writer {
     if (message.name !== "") {
@@ -1267,6 +1352,15 @@ export const Metadata = {
     for (const v of message.runningAgentAuthTokens) {
       RunningAgentAuthToken.encode(v!, writer.uint32(170).fork()).ldelim();
     }
+    if (message.providerMirrorUrl !== "") {
+      writer.uint32(178).string(message.providerMirrorUrl);
+    }
+    if (message.providerMirrorToken !== "") {
+      writer.uint32(186).string(message.providerMirrorToken);
+    }
+    if (message.moduleFilesUrl !== "") {
+      writer.uint32(194).string(message.moduleFilesUrl);
+    }
     return writer;
   },
 };
@@ -1282,6 +1376,9 @@ export const Config = {
     if (message.provisionerLogLevel !== "") {
       writer.uint32(26).string(message.provisionerLogLevel);
     }
+    if (message.provisionerVersion !== "") {
+      writer.uint32(34).string(message.provisionerVersion);
+    }
     return writer;
   },
 };
@@ -1342,6 +1439,9 @@ export const PlanRequest = {
     if (message.omitModuleFiles === true) {
       writer.uint32(48).bool(message.omitModuleFiles);
     }
+    if (message.collectProviderArchives === true) {
+      writer.uint32(56).bool(message.collectProviderArchives);
+    }
     return writer;
   },
 };
@@ -1387,6 +1487,9 @@ export const PlanComplete = {
     for (const v of message.aiTasks) {
       AITask.encode(v!, writer.uint32(114).fork()).ldelim();
     }
+    for (const v of message.providerArchives) {
+      ProviderArchive.encode(v!, writer.uint32(122).fork()).ldelim();
+    }
     return writer;
   },
 };
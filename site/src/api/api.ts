@@ -252,6 +252,22 @@ type WatchWorkspaceAgentLogsParams = {
 	after?: number;
 };
 
+export const watchWorkspaceAgentDevcontainerLogs = (
+	agentId: string,
+	devcontainerId: string,
+	params?: WatchWorkspaceAgentLogsParams,
+) => {
+	const searchParams = new URLSearchParams({
+		follow: "true",
+		after: params?.after?.toString() ?? "0",
+	});
+
+	return new OneWayWebSocket<TypesGen.WorkspaceAgentLog[]>({
+		apiRoute: `/api/v2/workspaceagents/${agentId}/containers/devcontainers/${devcontainerId}/logs`,
+		searchParams,
+	});
+};
+
 type WatchBuildLogsByBuildIdOptions = {
 	after?: number;
 	onMessage: (log: TypesGen.ProvisionerJobLog) => void;
@@ -1357,6 +1373,40 @@ class ApiMethods {
 		return response.data;
 	};
 
+	createTemplateVersionDryRun = async (
+		templateVersionId: string,
+		req: TypesGen.CreateTemplateVersionDryRunRequest,
+	): Promise<TypesGen.ProvisionerJob> => {
+		const response = await this.axios.post<TypesGen.ProvisionerJob>(
+			`/api/v2/templateversions/${templateVersionId}/dry-run`,
+			req,
+		);
+
+		return response.data;
+	};
+
+	getTemplateVersionDryRun = async (
+		templateVersionId: string,
+		jobId: string,
+	): Promise<TypesGen.ProvisionerJob> => {
+		const response = await this.axios.get<TypesGen.ProvisionerJob>(
+			`/api/v2/templateversions/${templateVersionId}/dry-run/${jobId}`,
+		);
+
+		return response.data;
+	};
+
+	getTemplateVersionDryRunResourceChanges = async (
+		templateVersionId: string,
+		jobId: string,
+	): Promise<TypesGen.WorkspaceUpdatePreview> => {
+		const response = await this.axios.get<TypesGen.WorkspaceUpdatePreview>(
+			`/api/v2/templateversions/${templateVersionId}/dry-run/${jobId}/resource-changes`,
+		);
+
+		return response.data;
+	};
+
 	createUser = async (
 		user: TypesGen.CreateUserRequestWithOrgs,
 	): Promise<TypesGen.User> => {
@@ -2292,6 +2342,32 @@ class ApiMethods {
 		});
 	};
 
+	/**
+	 * Plans the active version of the template of a workspace against its
+	 * current resources, and returns the changes an update would make to them.
+	 */
+	previewWorkspaceUpdate = async (
+		workspace: TypesGen.Workspace,
+		newBuildParameters: TypesGen.WorkspaceBuildParameter[] = [],
+	): Promise<TypesGen.WorkspaceUpdatePreview> => {
+		const versionId = workspace.template_active_version_id;
+		let job = await this.createTemplateVersionDryRun(versionId, {
+			workspace_name: workspace.name,
+			workspace_id: workspace.id,
+			rich_parameter_values: newBuildParameters,
+		});
+
+		while (!["succeeded", "failed", "canceled"].includes(job.status)) {
+			await delay(1000);
+			job = await this.getTemplateVersionDryRun(versionId, job.id);
+		}
+		if (job.status !== "succeeded") {
+			throw new Error(job.error || "Previewing the workspace update failed.");
+		}
+
+		return this.getTemplateVersionDryRunResourceChanges(versionId, job.id);
+	};
+
 	getWorkspaceResolveAutostart = async (
 		workspaceId: string,
 	): Promise<TypesGen.ResolveAutostartResponse> => {
@@ -2301,6 +2377,35 @@ class ApiMethods {
 		return response.data;
 	};
 
+	/**
+	 * Returns the latest drift check of the current build of a workspace, or
+	 * null if the build has not been checked.
+	 */
+	getWorkspaceDriftCheck = async (
+		workspaceId: string,
+	): Promise<TypesGen.WorkspaceDriftCheck | null> => {
+		try {
+			const response = await this.axios.get<TypesGen.WorkspaceDriftCheck>(
+				`/api/v2/workspaces/${workspaceId}/drift`,
+			);
+			return response.data;
+		} catch (error) {
+			if (isAxiosError(error) && error.response?.status === 404) {
+				return null;
+			}
+			throw error;
+		}
+	};
+
+	checkWorkspaceDrift = async (
+		workspaceId: string,
+	): Promise<TypesGen.WorkspaceDriftCheck> => {
+		const response = await this.axios.post<TypesGen.WorkspaceDriftCheck>(
+			`/api/v2/workspaces/${workspaceId}/drift`,
+		);
+		return response.data;
+	};
+
 	issueReconnectingPTYSignedToken = async (
 		params: TypesGen.IssueReconnectingPTYSignedTokenRequest,
 	): Promise<TypesGen.IssueReconnectingPTYSignedTokenResponse> => {
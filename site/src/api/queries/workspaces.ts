@@ -358,6 +358,38 @@ export const buildLogs = (workspace: Workspace) => {
 	};
 };
 
+export const workspaceDriftCheckKey = (workspaceId: string) => [
+	"workspaces",
+	workspaceId,
+	"drift",
+];
+
+export const workspaceDriftCheck = (workspace: Workspace) => {
+	return {
+		// The check belongs to a build, so a new build refetches it.
+		queryKey: [
+			...workspaceDriftCheckKey(workspace.id),
+			workspace.latest_build.id,
+		],
+		queryFn: () => API.getWorkspaceDriftCheck(workspace.id),
+	};
+};
+
+export const workspaceUpdatePreview = (workspace: Workspace) => {
+	return {
+		// A new active version or build needs a new preview.
+		queryKey: [
+			"workspaces",
+			workspace.id,
+			"updatePreview",
+			workspace.template_active_version_id,
+			workspace.latest_build.id,
+		],
+		queryFn: () => API.previewWorkspaceUpdate(workspace),
+		...disabledRefetchOptions,
+	};
+};
+
 export const agentLogsKey = (agentId: string) => ["agents", agentId, "logs"];
 
 export const agentLogs = (agentId: string) => {
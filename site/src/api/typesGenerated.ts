@@ -188,12 +188,108 @@ export interface AuditLog {
 	readonly user: User | null;
 }
 
+// From codersdk/audit.go
+export interface AuditLogArchive {
+	readonly id: string;
+	readonly created_at: string;
+	readonly file_name: string;
+	readonly oldest_time: string;
+	readonly newest_time: string;
+	readonly audit_log_count: number;
+	readonly restored_at?: string;
+}
+
 // From codersdk/audit.go
 export interface AuditLogResponse {
 	readonly audit_logs: readonly AuditLog[];
 	readonly count: number;
 }
 
+// From codersdk/deployment.go
+export interface AuditLogRetentionConfig {
+	readonly default: number;
+	readonly rules: string;
+	readonly restore_duration: number;
+}
+
+// From codersdk/deployment.go
+export interface AuditLogStreamingConfig {
+	readonly syslog: AuditLogStreamingSyslogConfig;
+	readonly otlp: AuditLogStreamingOTLPConfig;
+	readonly s3: AuditLogStreamingS3Config;
+}
+
+// From codersdk/deployment.go
+export interface AuditLogStreamingOTLPConfig {
+	readonly endpoint: string;
+	readonly headers: string;
+}
+
+// From codersdk/deployment.go
+export interface AuditLogStreamingS3Config {
+	readonly bucket: string;
+	readonly region: string;
+	readonly endpoint: string;
+	readonly prefix: string;
+	readonly access_key_id: string;
+	readonly secret_access_key: string;
+	readonly interval: number;
+}
+
+// From codersdk/deployment.go
+export interface AuditLogStreamingSyslogConfig {
+	readonly address: string;
+	readonly ca_file: string;
+	readonly cert_file: string;
+	readonly key_file: string;
+}
+
+// From codersdk/audit.go
+export interface AuditLogVerification {
+	readonly from: string;
+	readonly to: string;
+	readonly entries: number;
+	readonly checkpoints: number;
+	readonly archived: number;
+	readonly problems: readonly AuditLogVerificationProblem[];
+	readonly problems_truncated: boolean;
+}
+
+// From codersdk/audit.go
+export interface AuditLogVerificationProblem {
+	readonly kind: AuditLogVerificationProblemKind;
+	readonly sequence?: number;
+	readonly audit_log_id?: string;
+	readonly detail: string;
+}
+
+// From codersdk/audit.go
+export type AuditLogVerificationProblemKind =
+	| "broken_link"
+	| "deleted"
+	| "gap"
+	| "invalid_checkpoint"
+	| "modified"
+	| "truncated"
+	| "unchained";
+
+export const AuditLogVerificationProblemKinds: AuditLogVerificationProblemKind[] =
+	[
+		"broken_link",
+		"deleted",
+		"gap",
+		"invalid_checkpoint",
+		"modified",
+		"truncated",
+		"unchained",
+	];
+
+// From codersdk/audit.go
+export interface AuditLogVerifyRequest {
+	readonly from: string;
+	readonly to: string;
+}
+
 // From codersdk/audit.go
 export interface AuditLogsRequest extends Pagination {
 	readonly q?: string;
@@ -240,6 +336,17 @@ export type AutomaticUpdates = "always" | "never";
 
 export const AutomaticUpdateses: AutomaticUpdates[] = ["always", "never"];
 
+// From codersdk/autostopwindows.go
+export interface AutostopWindow {
+	readonly weekday: string;
+	readonly time: string;
+}
+
+// From codersdk/autostopwindows.go
+export interface AutostopWindows {
+	readonly windows: readonly AutostopWindow[];
+}
+
 // From codersdk/deployment.go
 export interface AvailableExperiments {
 	readonly safe: readonly Experiment[];
@@ -299,6 +406,15 @@ export interface ChangePasswordWithOneTimePasscodeRequest {
 	readonly one_time_passcode: string;
 }
 
+// From codersdk/notifications.go
+export type ChatNotificationMethod = "matrix" | "slack" | "teams";
+
+export const ChatNotificationMethods: ChatNotificationMethod[] = [
+	"matrix",
+	"slack",
+	"teams",
+];
+
 // From codersdk/client.go
 export const CoderDesktopTelemetryHeader = "Coder-Desktop-Telemetry";
 
@@ -320,6 +436,47 @@ export interface ConvertLoginRequest {
 	readonly password: string;
 }
 
+// From codersdk/insights.go
+export interface CostInsightsEntry {
+	readonly id: string;
+	readonly name: string;
+	readonly organization_name?: string;
+	readonly usage_seconds: number;
+	readonly cost: number;
+}
+
+// From codersdk/insights.go
+export type CostInsightsGroupBy = "group" | "organization" | "template" | "user";
+
+export const CostInsightsGroupBys: CostInsightsGroupBy[] = [
+	"group",
+	"organization",
+	"template",
+	"user",
+];
+
+// From codersdk/insights.go
+export interface CostInsightsReport {
+	readonly start_time: string;
+	readonly end_time: string;
+	readonly template_ids: readonly string[];
+	readonly group_by: CostInsightsGroupBy;
+	readonly entries: readonly CostInsightsEntry[];
+}
+
+// From codersdk/insights.go
+export interface CostInsightsRequest {
+	readonly start_time: string;
+	readonly end_time: string;
+	readonly template_ids: readonly string[];
+	readonly group_by: CostInsightsGroupBy;
+}
+
+// From codersdk/insights.go
+export interface CostInsightsResponse {
+	readonly report: CostInsightsReport;
+}
+
 // From codersdk/users.go
 export interface CreateFirstUserRequest {
 	readonly email: string;
@@ -355,6 +512,17 @@ export interface CreateGroupRequest {
 	readonly quota_allowance: number;
 }
 
+// From codersdk/notifications.go
+export interface CreateNotificationRuleRequest {
+	readonly name: string;
+	readonly source: NotificationRuleSource;
+	readonly query: string;
+	readonly method?: string;
+	readonly threshold?: number;
+	readonly window_seconds?: number;
+	readonly disabled?: boolean;
+}
+
 // From codersdk/organizations.go
 export interface CreateOrganizationRequest {
 	readonly name: string;
@@ -363,6 +531,13 @@ export interface CreateOrganizationRequest {
 	readonly icon?: string;
 }
 
+// From codersdk/planpolicies.go
+export interface CreatePlanPolicyRequest {
+	readonly name: string;
+	readonly description?: string;
+	readonly module: string;
+}
+
 // From codersdk/provisionerdaemons.go
 export interface CreateProvisionerKeyRequest {
 	readonly name: string;
@@ -402,6 +577,7 @@ export interface CreateTemplateVersionDryRunRequest {
 	readonly workspace_name: string;
 	readonly rich_parameter_values: readonly WorkspaceBuildParameter[];
 	readonly user_variable_values?: readonly VariableValue[];
+	readonly workspace_id?: string;
 }
 
 // From codersdk/organizations.go
@@ -489,12 +665,14 @@ export interface CryptoKey {
 
 // From codersdk/deployment.go
 export type CryptoKeyFeature =
+	| "audit_log_checkpoint"
 	| "oidc_convert"
 	| "tailnet_resume"
 	| "workspace_apps_api_key"
 	| "workspace_apps_token";
 
 export const CryptoKeyFeatures: CryptoKeyFeature[] = [
+	"audit_log_checkpoint",
 	"oidc_convert",
 	"tailnet_resume",
 	"workspace_apps_api_key",
@@ -701,6 +879,10 @@ export interface DeploymentValues {
 	readonly workspace_hostname_suffix?: string;
 	readonly workspace_prebuilds?: PrebuildsConfig;
 	readonly hide_ai_tasks?: boolean;
+	readonly workspace_snapshots?: WorkspaceSnapshotsConfig;
+	readonly audit_log_streaming?: AuditLogStreamingConfig;
+	readonly audit_log_retention?: AuditLogRetentionConfig;
+	readonly workspace_state_history?: WorkspaceStateHistoryConfig;
 	readonly config?: string;
 	readonly write_config?: boolean;
 	readonly address?: string;
@@ -1033,6 +1215,94 @@ export interface GroupSyncSettings {
 	readonly legacy_group_name_mapping?: Record<string, string>;
 }
 
+// From codersdk/workspaceagenthttpcapture.go
+export interface HAR {
+	readonly log: HARLog;
+}
+
+// From codersdk/workspaceagenthttpcapture.go
+export interface HARCache {}
+
+// From codersdk/workspaceagenthttpcapture.go
+export interface HARContent {
+	readonly size: number;
+	readonly mimeType: string;
+	readonly text?: string;
+	readonly encoding?: string;
+	readonly comment?: string;
+}
+
+// From codersdk/workspaceagenthttpcapture.go
+export interface HARCreator {
+	readonly name: string;
+	readonly version: string;
+}
+
+// From codersdk/workspaceagenthttpcapture.go
+export interface HAREntry {
+	readonly startedDateTime: string;
+	readonly time: number;
+	readonly request: HARRequest;
+	readonly response: HARResponse;
+	readonly cache: HARCache;
+	readonly timings: HARTimings;
+	readonly comment?: string;
+}
+
+// From codersdk/workspaceagenthttpcapture.go
+export interface HARLog {
+	readonly version: string;
+	readonly creator: HARCreator;
+	readonly entries: readonly HAREntry[];
+	readonly comment?: string;
+}
+
+// From codersdk/workspaceagenthttpcapture.go
+export interface HARNameValue {
+	readonly name: string;
+	readonly value: string;
+}
+
+// From codersdk/workspaceagenthttpcapture.go
+export interface HARPostData {
+	readonly mimeType: string;
+	readonly text: string;
+	readonly comment?: string;
+}
+
+// From codersdk/workspaceagenthttpcapture.go
+export interface HARRequest {
+	readonly method: string;
+	readonly url: string;
+	readonly httpVersion: string;
+	readonly cookies: readonly HARNameValue[];
+	readonly headers: readonly HARNameValue[];
+	readonly queryString: readonly HARNameValue[];
+	readonly postData?: HARPostData;
+	readonly headersSize: number;
+	readonly bodySize: number;
+}
+
+// From codersdk/workspaceagenthttpcapture.go
+export interface HARResponse {
+	readonly status: number;
+	readonly statusText: string;
+	readonly httpVersion: string;
+	readonly cookies: readonly HARNameValue[];
+	readonly headers: readonly HARNameValue[];
+	readonly content: HARContent;
+	readonly redirectURL: string;
+	readonly headersSize: number;
+	readonly bodySize: number;
+}
+
+// From codersdk/workspaceagenthttpcapture.go
+export interface HARTimings {
+	readonly send: number;
+	readonly wait: number;
+	readonly receive: number;
+}
+
 // From codersdk/deployment.go
 export interface HTTPCookieConfig {
 	readonly secure_auth_cookie?: boolean;
@@ -1157,6 +1427,11 @@ export interface IDPSyncMapping<ResourceIdType extends string | string> {
 	readonly Gets: ResourceIdType;
 }
 
+// From codersdk/holidays.go
+export interface ImportHolidayCalendarRequest {
+	readonly icalendar: string;
+}
+
 // From codersdk/inboxnotification.go
 export interface InboxNotification {
 	readonly id: string;
@@ -1209,9 +1484,14 @@ export interface IssueReconnectingPTYSignedTokenResponse {
 }
 
 // From codersdk/provisionerdaemons.go
-export type JobErrorCode = "REQUIRED_TEMPLATE_VARIABLES";
+export type JobErrorCode =
+	| "PLAN_POLICY_VIOLATION"
+	| "REQUIRED_TEMPLATE_VARIABLES";
 
-export const JobErrorCodes: JobErrorCode[] = ["REQUIRED_TEMPLATE_VARIABLES"];
+export const JobErrorCodes: JobErrorCode[] = [
+	"PLAN_POLICY_VIOLATION",
+	"REQUIRED_TEMPLATE_VARIABLES",
+];
 
 // From codersdk/licenses.go
 export interface License {
@@ -1348,6 +1628,22 @@ export interface NetcheckReport {
 	readonly CaptivePortal: boolean | null;
 }
 
+// From codersdk/notifications.go
+export interface NotificationDeliverySettings {
+	readonly timezone: string;
+	readonly quiet_hours?: NotificationQuietHours;
+	readonly daily_digest_time: string;
+	readonly digests: Record<string, NotificationDigestInterval>;
+}
+
+// From codersdk/notifications.go
+export type NotificationDigestInterval = "daily" | "hourly";
+
+export const NotificationDigestIntervals: NotificationDigestInterval[] = [
+	"daily",
+	"hourly",
+];
+
 // From codersdk/notifications.go
 export interface NotificationMethodsResponse {
 	readonly available: readonly string[];
@@ -1361,6 +1657,37 @@ export interface NotificationPreference {
 	readonly updated_at: string;
 }
 
+// From codersdk/notifications.go
+export interface NotificationQuietHours {
+	readonly start: string;
+	readonly end: string;
+}
+
+// From codersdk/notifications.go
+export interface NotificationRule {
+	readonly id: string;
+	readonly organization_id: string;
+	readonly user_id: string;
+	readonly name: string;
+	readonly source: NotificationRuleSource;
+	readonly query: string;
+	readonly method?: string;
+	readonly threshold: number;
+	readonly window_seconds: number;
+	readonly enabled: boolean;
+	readonly created_at: string;
+	readonly updated_at: string;
+	readonly last_triggered_at?: string;
+}
+
+// From codersdk/notifications.go
+export type NotificationRuleSource = "audit_log" | "workspace_build";
+
+export const NotificationRuleSources: NotificationRuleSource[] = [
+	"audit_log",
+	"workspace_build",
+];
+
 // From codersdk/notifications.go
 export interface NotificationTemplate {
 	readonly id: string;
@@ -1372,6 +1699,7 @@ export interface NotificationTemplate {
 	readonly method: string;
 	readonly kind: string;
 	readonly enabled_by_default: boolean;
+	readonly critical: boolean;
 }
 
 // From codersdk/deployment.go
@@ -1388,6 +1716,9 @@ export interface NotificationsConfig {
 	readonly email: NotificationsEmailConfig;
 	readonly webhook: NotificationsWebhookConfig;
 	readonly inbox: NotificationsInboxConfig;
+	readonly slack: NotificationsSlackConfig;
+	readonly matrix: NotificationsMatrixConfig;
+	readonly teams: NotificationsTeamsConfig;
 }
 
 // From codersdk/deployment.go
@@ -1423,11 +1754,30 @@ export interface NotificationsInboxConfig {
 	readonly enabled: boolean;
 }
 
+// From codersdk/deployment.go
+export interface NotificationsMatrixConfig {
+	readonly homeserver: string;
+	readonly access_token: string;
+	readonly room: string;
+}
+
 // From codersdk/notifications.go
 export interface NotificationsSettings {
 	readonly notifier_paused: boolean;
 }
 
+// From codersdk/deployment.go
+export interface NotificationsSlackConfig {
+	readonly bot_token: string;
+	readonly channel: string;
+	readonly api_url: string;
+}
+
+// From codersdk/deployment.go
+export interface NotificationsTeamsConfig {
+	readonly webhook_url: string;
+}
+
 // From codersdk/deployment.go
 export interface NotificationsWebhookConfig {
 	readonly endpoint: string;
@@ -1604,6 +1954,13 @@ export interface Organization extends MinimalOrganization {
 	readonly is_default: boolean;
 }
 
+// From codersdk/holidays.go
+export interface OrganizationHoliday {
+	readonly calendar: string;
+	readonly date: string;
+	readonly name: string;
+}
+
 // From codersdk/organizations.go
 export interface OrganizationMember {
 	readonly user_id: string;
@@ -1762,6 +2119,17 @@ export interface Permission {
 	readonly action: RBACAction;
 }
 
+// From codersdk/planpolicies.go
+export interface PlanPolicy {
+	readonly id: string;
+	readonly organization_id: string;
+	readonly name: string;
+	readonly description: string;
+	readonly module: string;
+	readonly created_at: string;
+	readonly updated_at: string;
+}
+
 // From codersdk/oauth2.go
 export interface PostOAuth2ProviderAppRequest {
 	readonly name: string;
@@ -1875,10 +2243,15 @@ export interface PrometheusConfig {
 export interface ProvisionerConfig {
 	readonly daemons: number;
 	readonly daemon_types: string;
+	readonly daemon_versions: string;
 	readonly daemon_poll_interval: number;
 	readonly daemon_poll_jitter: number;
 	readonly force_cancel_interval: number;
 	readonly daemon_psk: string;
+	readonly provider_mirror: boolean;
+	readonly external_command: string;
+	readonly scaler_enable: boolean;
+	readonly scaler_address: string;
 }
 
 // From codersdk/provisionerdaemons.go
@@ -1950,6 +2323,8 @@ export interface ProvisionerJob {
 	readonly tags: Record<string, string>;
 	readonly queue_position: number;
 	readonly queue_size: number;
+	readonly estimated_wait_ms?: number;
+	readonly priority: ProvisionerJobPriority;
 	readonly organization_id: string;
 	readonly input: ProvisionerJobInput;
 	readonly type: ProvisionerJobType;
@@ -1985,6 +2360,22 @@ export interface ProvisionerJobMetadata {
 	readonly workspace_name?: string;
 }
 
+// From codersdk/provisionerdaemons.go
+export type ProvisionerJobPriority =
+	| "drift_check"
+	| "interactive"
+	| "prebuild"
+	| "scheduled"
+	| "template_import";
+
+export const ProvisionerJobPriorities: ProvisionerJobPriority[] = [
+	"drift_check",
+	"interactive",
+	"prebuild",
+	"scheduled",
+	"template_import",
+];
+
 // From codersdk/provisionerdaemons.go
 export type ProvisionerJobStatus =
 	| "canceled"
@@ -2009,12 +2400,14 @@ export const ProvisionerJobStatuses: ProvisionerJobStatus[] = [
 export type ProvisionerJobType =
 	| "template_version_dry_run"
 	| "template_version_import"
-	| "workspace_build";
+	| "workspace_build"
+	| "workspace_drift_check";
 
 export const ProvisionerJobTypes: ProvisionerJobType[] = [
 	"template_version_dry_run",
 	"template_version_import",
 	"workspace_build",
+	"workspace_drift_check",
 ];
 
 // From codersdk/provisionerdaemons.go
@@ -2058,6 +2451,26 @@ export type ProvisionerLogLevel = "debug";
 
 export const ProvisionerLogLevels: ProvisionerLogLevel[] = ["debug"];
 
+// From codersdk/provisionerdaemons.go
+export interface ProvisionerQueueStats {
+	readonly organization_id: string;
+	readonly tags: Record<string, string>;
+	readonly pending_jobs: number;
+	readonly oldest_pending_job_age_ms: number;
+	readonly running_jobs: number;
+	readonly daemons: number;
+	readonly busy_daemons: number;
+	readonly utilization: number;
+	readonly wait_time: ProvisionerQueueWaitTime;
+}
+
+// From codersdk/provisionerdaemons.go
+export interface ProvisionerQueueWaitTime {
+	readonly p50_ms: number;
+	readonly p95_ms: number;
+	readonly p99_ms: number;
+}
+
 // From codersdk/organizations.go
 export type ProvisionerStorageMethod = "file";
 
@@ -2075,9 +2488,14 @@ export interface ProvisionerTiming {
 }
 
 // From codersdk/organizations.go
-export type ProvisionerType = "echo" | "terraform";
+export type ProvisionerType = "echo" | "external" | "terraform" | "tofu";
 
-export const ProvisionerTypes: ProvisionerType[] = ["echo", "terraform"];
+export const ProvisionerTypes: ProvisionerType[] = [
+	"echo",
+	"external",
+	"terraform",
+	"tofu",
+];
 
 // From codersdk/workspaceproxy.go
 export interface ProxyHealthReport {
@@ -2623,6 +3041,8 @@ export interface Template {
 	readonly require_active_version: boolean;
 	readonly max_port_share_level: WorkspaceAgentPortShareLevel;
 	readonly use_classic_parameter_flow: boolean;
+	readonly drift_check_interval_ms: number;
+	readonly provisioner_version: string;
 }
 
 // From codersdk/templates.go
@@ -2759,6 +3179,20 @@ export interface TemplateParameterValue {
 	readonly count: number;
 }
 
+// From codersdk/workspaceagentportshare.go
+export interface TemplatePortShareRule {
+	readonly port_start: number;
+	readonly port_end: number;
+	readonly share_level: WorkspaceAgentPortShareLevel;
+	readonly protocol: WorkspaceAgentPortShareProtocol;
+	readonly label?: string;
+}
+
+// From codersdk/workspaceagentportshare.go
+export interface TemplatePortShareRules {
+	readonly rules: readonly TemplatePortShareRule[];
+}
+
 // From codersdk/templates.go
 export type TemplateRole = "admin" | "" | "use";
 
@@ -2948,6 +3382,16 @@ export interface UpdateInboxNotificationReadStatusResponse {
 	readonly unread_count: number;
 }
 
+// From codersdk/notifications.go
+export interface UpdateNotificationRuleRequest {
+	readonly name: string;
+	readonly query: string;
+	readonly method?: string;
+	readonly threshold?: number;
+	readonly window_seconds?: number;
+	readonly enabled: boolean;
+}
+
 // From codersdk/notifications.go
 export interface UpdateNotificationTemplateMethod {
 	readonly method?: string;
@@ -2961,6 +3405,12 @@ export interface UpdateOrganizationRequest {
 	readonly icon?: string;
 }
 
+// From codersdk/planpolicies.go
+export interface UpdatePlanPolicyRequest {
+	readonly description?: string;
+	readonly module?: string;
+}
+
 // From codersdk/users.go
 export interface UpdateRoles {
 	readonly roles: readonly string[];
@@ -2995,6 +3445,13 @@ export interface UpdateTemplateMeta {
 	readonly disable_everyone_group_access: boolean;
 	readonly max_port_share_level?: WorkspaceAgentPortShareLevel;
 	readonly use_classic_parameter_flow?: boolean;
+	readonly drift_check_interval_ms?: number;
+	readonly provisioner_version?: string;
+}
+
+// From codersdk/workspaceagentportshare.go
+export interface UpdateTemplatePortShareRulesRequest {
+	readonly rules: readonly TemplatePortShareRule[];
 }
 
 // From codersdk/users.go
@@ -3003,6 +3460,11 @@ export interface UpdateUserAppearanceSettingsRequest {
 	readonly terminal_font: TerminalFontName;
 }
 
+// From codersdk/notifications.go
+export interface UpdateUserChatIdentityRequest {
+	readonly identity: string;
+}
+
 // From codersdk/notifications.go
 export interface UpdateUserNotificationPreferences {
 	readonly template_disabled_map: Record<string, boolean>;
@@ -3120,6 +3582,14 @@ export interface UserAppearanceSettings {
 	readonly terminal_font: TerminalFontName;
 }
 
+// From codersdk/notifications.go
+export interface UserChatIdentity {
+	readonly method: ChatNotificationMethod;
+	readonly identity: string;
+	readonly verified: boolean;
+	readonly updated_at: string;
+}
+
 // From codersdk/insights.go
 export interface UserLatency {
 	readonly template_ids: readonly string[];
@@ -3229,6 +3699,11 @@ export interface VariableValue {
 	readonly value: string;
 }
 
+// From codersdk/notifications.go
+export interface VerifyUserChatIdentityRequest {
+	readonly code: string;
+}
+
 // From codersdk/notifications.go
 export interface WebpushMessage {
 	readonly icon: string;
@@ -3359,6 +3834,7 @@ export interface WorkspaceAgentDevcontainer {
 	readonly dirty: boolean;
 	readonly container?: WorkspaceAgentContainer;
 	readonly agent?: WorkspaceAgentDevcontainerAgent;
+	readonly features?: readonly WorkspaceAgentDevcontainerFeature[];
 	readonly error?: string;
 }
 
@@ -3369,15 +3845,56 @@ export interface WorkspaceAgentDevcontainerAgent {
 	readonly directory: string;
 }
 
+// From codersdk/workspaceagents.go
+export interface WorkspaceAgentDevcontainerFeature {
+	readonly id: string;
+}
+
+// From codersdk/workspaceagents.go
+export interface WorkspaceAgentDevcontainerLogsResponse {
+	readonly logs: readonly WorkspaceAgentLog[];
+}
+
 // From codersdk/workspaceagents.go
 export type WorkspaceAgentDevcontainerStatus =
+	| "deleting"
 	| "error"
 	| "running"
 	| "starting"
-	| "stopped";
+	| "stopped"
+	| "stopping";
 
 export const WorkspaceAgentDevcontainerStatuses: WorkspaceAgentDevcontainerStatus[] =
-	["error", "running", "starting", "stopped"];
+	["deleting", "error", "running", "starting", "stopped", "stopping"];
+
+// From codersdk/workspaceagenthttpcapture.go
+export interface WorkspaceAgentHTTPCapture {
+	readonly port: number;
+	readonly active: boolean;
+	readonly started_at: string;
+	readonly ends_at: string;
+	readonly stopped_at?: string;
+	readonly include_bodies: boolean;
+	readonly max_body_bytes: number;
+	readonly max_entries: number;
+	readonly redact_headers: readonly string[];
+	readonly entries: number;
+	readonly dropped: number;
+	readonly opaque_connections: number;
+}
+
+// From codersdk/workspaceagenthttpcapture.go
+export const WorkspaceAgentHTTPCaptureRedacted = "[REDACTED]";
+
+// From codersdk/workspaceagenthttpcapture.go
+export interface WorkspaceAgentHTTPCaptureRequest {
+	readonly port: number;
+	readonly duration_ms?: number;
+	readonly include_bodies?: boolean;
+	readonly max_body_bytes?: number;
+	readonly max_entries?: number;
+	readonly redact_headers?: readonly string[];
+}
 
 // From codersdk/workspaceagents.go
 export interface WorkspaceAgentHealth {
@@ -3476,6 +3993,8 @@ export interface WorkspaceAgentPortShare {
 	readonly port: number;
 	readonly share_level: WorkspaceAgentPortShareLevel;
 	readonly protocol: WorkspaceAgentPortShareProtocol;
+	readonly auto_shared: boolean;
+	readonly label: string;
 }
 
 // From codersdk/workspaceagentportshare.go
@@ -3503,6 +4022,36 @@ export interface WorkspaceAgentPortShares {
 	readonly shares: readonly WorkspaceAgentPortShare[];
 }
 
+// From codersdk/workspaceagents.go
+export interface WorkspaceAgentProcess {
+	readonly pid: number;
+	readonly ppid: number;
+	readonly name: string;
+	readonly cmdline: string;
+	readonly username: string;
+	readonly status: string;
+	readonly cpu_percent: number;
+	readonly memory_rss: number;
+	readonly memory_percent: number;
+	readonly created_at: string;
+	readonly ports: readonly number[];
+}
+
+// From codersdk/workspaceagents.go
+export type WorkspaceAgentProcessSort = "cpu" | "memory" | "pid";
+
+export const WorkspaceAgentProcessSorts: WorkspaceAgentProcessSort[] = [
+	"cpu",
+	"memory",
+	"pid",
+];
+
+// From codersdk/workspaceagents.go
+export interface WorkspaceAgentProcessesResponse {
+	readonly processes: readonly WorkspaceAgentProcess[];
+	readonly warnings?: readonly string[];
+}
+
 // From codersdk/workspaceagents.go
 export interface WorkspaceAgentScript {
 	readonly id: string;
@@ -3517,6 +4066,11 @@ export interface WorkspaceAgentScript {
 	readonly display_name: string;
 }
 
+// From codersdk/workspaceagents.go
+export interface WorkspaceAgentSignalProcessRequest {
+	readonly signal: string;
+}
+
 // From codersdk/workspaceagents.go
 export type WorkspaceAgentStartupScriptBehavior = "blocking" | "non-blocking";
 
@@ -3660,6 +4214,16 @@ export interface WorkspaceBuildTimings {
 	readonly agent_connection_timings: readonly AgentConnectionTiming[];
 }
 
+// From codersdk/workspacebuilds.go
+export interface WorkspaceBuildState {
+	readonly workspace_build_id: string;
+	readonly build_number: number;
+	readonly transition: WorkspaceTransition;
+	readonly hash: string;
+	readonly size: number;
+	readonly created_at: string;
+}
+
 // From codersdk/workspaces.go
 export interface WorkspaceBuildsRequest extends Pagination {
 	readonly since?: string;
@@ -3683,6 +4247,46 @@ export interface WorkspaceDeploymentStats {
 	readonly tx_bytes: number;
 }
 
+// From codersdk/workspacedrift.go
+export type WorkspaceDriftAction = "changed" | "deleted";
+
+export const WorkspaceDriftActions: WorkspaceDriftAction[] = [
+	"changed",
+	"deleted",
+];
+
+// From codersdk/workspacedrift.go
+export interface WorkspaceDriftCheck {
+	readonly id: string;
+	readonly workspace_id: string;
+	readonly workspace_build_id: string;
+	readonly job: ProvisionerJob;
+	readonly created_at: string;
+	readonly completed_at?: string;
+	readonly drifted: boolean;
+	readonly resources: readonly WorkspaceDriftedResource[];
+}
+
+// From codersdk/workspacedrift.go
+export interface WorkspaceDriftedAttribute {
+	readonly path: string;
+	// empty interface{} type, falling back to unknown
+	readonly before?: unknown;
+	// empty interface{} type, falling back to unknown
+	readonly after?: unknown;
+	readonly sensitive?: boolean;
+}
+
+// From codersdk/workspacedrift.go
+export interface WorkspaceDriftedResource {
+	readonly address: string;
+	readonly type: string;
+	readonly name: string;
+	readonly module_path?: string;
+	readonly action: WorkspaceDriftAction;
+	readonly attributes: readonly WorkspaceDriftedAttribute[];
+}
+
 // From codersdk/workspaces.go
 export interface WorkspaceFilter {
 	readonly q?: string;
@@ -3750,6 +4354,31 @@ export interface WorkspaceResource {
 	readonly daily_cost: number;
 }
 
+// From codersdk/workspaceupdatepreview.go
+export interface WorkspaceResourceChange {
+	readonly address: string;
+	readonly type: string;
+	readonly name: string;
+	readonly module_path?: string;
+	readonly action: WorkspaceResourceChangeAction;
+	readonly replace_paths?: readonly string[];
+	readonly persistent: boolean;
+}
+
+// From codersdk/workspaceupdatepreview.go
+export type WorkspaceResourceChangeAction =
+	| "create"
+	| "delete"
+	| "replace"
+	| "update";
+
+export const WorkspaceResourceChangeActions: WorkspaceResourceChangeAction[] = [
+	"create",
+	"delete",
+	"replace",
+	"update",
+];
+
 // From codersdk/workspacebuilds.go
 export interface WorkspaceResourceMetadata {
 	readonly key: string;
@@ -3757,6 +4386,70 @@ export interface WorkspaceResourceMetadata {
 	readonly sensitive: boolean;
 }
 
+// From codersdk/workspacesnapshots.go
+export interface WorkspaceSnapshot {
+	readonly id: string;
+	readonly workspace_id: string;
+	readonly agent_name: string;
+	readonly build_number: number;
+	readonly parent_id?: string;
+	readonly trigger: WorkspaceSnapshotTrigger;
+	readonly storage: WorkspaceSnapshotStorage;
+	readonly size_bytes: number;
+	readonly file_count: number;
+	readonly paths: readonly string[];
+	readonly created_at: string;
+}
+
+// From codersdk/workspacesnapshots.go
+export interface WorkspaceSnapshotRestore {
+	readonly workspace_id: string;
+	readonly snapshot_id: string;
+	readonly after_build_number: number;
+	readonly requested_by: string;
+	readonly requested_at: string;
+}
+
+// From codersdk/workspacesnapshots.go
+export type WorkspaceSnapshotStorage = "database" | "s3";
+
+export const WorkspaceSnapshotStorages: WorkspaceSnapshotStorage[] = [
+	"database",
+	"s3",
+];
+
+// From codersdk/workspacesnapshots.go
+export type WorkspaceSnapshotTrigger = "schedule" | "stop";
+
+export const WorkspaceSnapshotTriggers: WorkspaceSnapshotTrigger[] = [
+	"schedule",
+	"stop",
+];
+
+// From codersdk/deployment.go
+export interface WorkspaceSnapshotsConfig {
+	readonly storage: string;
+	readonly max_size: number;
+	readonly keep_chains: number;
+	readonly s3: WorkspaceSnapshotsS3Config;
+}
+
+// From codersdk/deployment.go
+export interface WorkspaceSnapshotsS3Config {
+	readonly bucket: string;
+	readonly region: string;
+	readonly endpoint: string;
+	readonly prefix: string;
+	readonly access_key_id: string;
+	readonly secret_access_key: string;
+}
+
+// From codersdk/deployment.go
+export interface WorkspaceStateHistoryConfig {
+	readonly builds: number;
+	readonly max_age: number;
+}
+
 // From codersdk/workspacebuilds.go
 export type WorkspaceStatus =
 	| "canceled"
@@ -3792,6 +4485,14 @@ export const WorkspaceTransitions: WorkspaceTransition[] = [
 	"stop",
 ];
 
+// From codersdk/workspaceupdatepreview.go
+export interface WorkspaceUpdatePreview {
+	readonly workspace_id: string;
+	readonly created_at: string;
+	readonly completed_at?: string;
+	readonly changes: readonly WorkspaceResourceChange[];
+}
+
 // From codersdk/workspaces.go
 export interface WorkspacesRequest extends Pagination {
 	readonly q?: string;
@@ -8,6 +8,7 @@ import type {
 } from "api/typesGenerated";
 
 import { Button } from "components/Button/Button";
+import { DropdownArrow } from "components/DropdownArrow/DropdownArrow";
 import { displayError } from "components/GlobalSnackbar/utils";
 import { Spinner } from "components/Spinner/Spinner";
 import { Stack } from "components/Stack/Stack";
@@ -22,19 +23,23 @@ import { Container, ExternalLinkIcon } from "lucide-react";
 import { useFeatureVisibility } from "modules/dashboard/useFeatureVisibility";
 import { AppStatuses } from "pages/WorkspacePage/AppStatuses";
 import type { FC } from "react";
-import { useEffect } from "react";
+import { useEffect, useLayoutEffect, useRef, useState } from "react";
 import { useMutation, useQueryClient } from "react-query";
+import AutoSizer from "react-virtualized-auto-sizer";
+import type { FixedSizeList as List } from "react-window";
 import { cn } from "utils/cn";
 import { portForwardURL } from "utils/portForward";
 import { AgentApps, organizeAgentApps } from "./AgentApps/AgentApps";
 import { AgentButton } from "./AgentButton";
 import { AgentLatency } from "./AgentLatency";
+import { AgentLogs } from "./AgentLogs/AgentLogs";
 import { DevcontainerStatus } from "./AgentStatus";
 import { PortForwardButton } from "./PortForwardButton";
 import { AgentSSHButton } from "./SSHButton/SSHButton";
 import { SubAgentOutdatedTooltip } from "./SubAgentOutdatedTooltip";
 import { TerminalLink } from "./TerminalLink/TerminalLink";
 import { VSCodeDevContainerButton } from "./VSCodeDevContainerButton/VSCodeDevContainerButton";
+import { useDevcontainerLogs } from "./useDevcontainerLogs";
 
 type AgentDevcontainerCardProps = {
 	parentAgent: WorkspaceAgent;
@@ -174,6 +179,11 @@ export const AgentDevcontainerCard: FC<AgentDevcontainerCardProps> = ({
 	]);
 
 	const showDevcontainerControls = subAgent && devcontainer.container;
+	// The agent rejects actions while another one is in progress.
+	const devcontainerBusy =
+		devcontainer.status === "starting" ||
+		devcontainer.status === "stopping" ||
+		devcontainer.status === "deleting";
 	const showSubAgentApps =
 		devcontainer.status !== "starting" &&
 		subAgent?.status === "connected" &&
@@ -181,6 +191,19 @@ export const AgentDevcontainerCard: FC<AgentDevcontainerCardProps> = ({
 	const showSubAgentAppsPlaceholders =
 		devcontainer.status === "starting" || subAgent?.status === "connecting";
 
+	// Show the build logs live while the devcontainer is being built.
+	const [showLogs, setShowLogs] = useState(devcontainer.status === "starting");
+	useEffect(() => {
+		if (devcontainer.status === "starting") {
+			setShowLogs(true);
+		}
+	}, [devcontainer.status]);
+	const buildLogs = useDevcontainerLogs(parentAgent, devcontainer, showLogs);
+	const logListRef = useRef<List>(null);
+	useLayoutEffect(() => {
+		logListRef.current?.scrollToItem(buildLogs.length - 1, "end");
+	}, [buildLogs]);
+
 	const handleRebuildDevcontainer = () => {
 		rebuildDevcontainerMutation.mutate();
 	};
@@ -252,13 +275,21 @@ export const AgentDevcontainerCard: FC<AgentDevcontainerCardProps> = ({
 				</div>
 
 				<div className="flex items-center gap-2">
+					<Button
+						variant="subtle"
+						size="sm"
+						onClick={() => setShowLogs((v) => !v)}
+					>
+						<DropdownArrow close={showLogs} margin={false} />
+						Build logs
+					</Button>
 					<Button
 						variant="outline"
 						size="sm"
 						onClick={handleRebuildDevcontainer}
-						disabled={devcontainer.status === "starting"}
+						disabled={devcontainerBusy}
 					>
-						<Spinner loading={devcontainer.status === "starting"} />
+						<Spinner loading={devcontainerBusy} />
 						Rebuild
 					</Button>
 
@@ -288,6 +319,34 @@ export const AgentDevcontainerCard: FC<AgentDevcontainerCardProps> = ({
 				</div>
 			)}
 
+			{showLogs && (
+				<div className="mx-8 mt-4 border border-solid border-border rounded">
+					{buildLogs.length === 0 ? (
+						<div className="p-4 text-xs text-content-secondary">
+							No build logs.
+						</div>
+					) : (
+						<AutoSizer disableHeight>
+							{({ width }) => (
+								<AgentLogs
+									ref={logListRef}
+									height={256}
+									width={width}
+									logs={buildLogs.map((l) => ({
+										id: l.id,
+										level: l.level,
+										output: l.output,
+										sourceId: l.source_id,
+										time: l.created_at,
+									}))}
+									sources={parentAgent.log_sources}
+								/>
+							)}
+						</AutoSizer>
+					)}
+				</div>
+			)}
+
 			{(showSubAgentApps || showSubAgentAppsPlaceholders) && (
 				<div className="flex flex-col gap-8 px-8 pt-4">
 					{subAgent &&
@@ -0,0 +1,57 @@
+import { watchWorkspaceAgentDevcontainerLogs } from "api/api";
+import type {
+	WorkspaceAgent,
+	WorkspaceAgentDevcontainer,
+	WorkspaceAgentLog,
+} from "api/typesGenerated";
+import { displayError } from "components/GlobalSnackbar/utils";
+import { useEffect, useState } from "react";
+
+export function useDevcontainerLogs(
+	parentAgent: WorkspaceAgent,
+	devcontainer: WorkspaceAgentDevcontainer,
+	enabled: boolean,
+): readonly WorkspaceAgentLog[] {
+	const [logs, setLogs] = useState<WorkspaceAgentLog[]>([]);
+	// The agent ends the stream once the devcontainer has been built, so
+	// watch again whenever a new build starts.
+	const building = devcontainer.status === "starting";
+
+	// biome-ignore lint/correctness/useExhaustiveDependencies: building re-opens the stream for each build
+	useEffect(() => {
+		// Only the logs of the most recent build are kept, so always fetch
+		// them from the beginning.
+		setLogs([]);
+		if (!enabled) {
+			return;
+		}
+
+		const socket = watchWorkspaceAgentDevcontainerLogs(
+			parentAgent.id,
+			devcontainer.id,
+			{ after: 0 },
+		);
+		socket.addEventListener("message", (e) => {
+			if (e.parseError) {
+				console.warn("Error parsing devcontainer log: ", e.parseError);
+				return;
+			}
+			setLogs((logs) => [...logs, ...e.parsedMessage]);
+		});
+
+		socket.addEventListener("error", (e) => {
+			console.error("Error in devcontainer log socket: ", e);
+			displayError(
+				"Unable to watch the dev container build logs",
+				"Please try refreshing the browser",
+			);
+			socket.close();
+		});
+
+		return () => {
+			socket.close();
+		};
+	}, [parentAgent.id, devcontainer.id, enabled, building]);
+
+	return logs;
+}
@@ -1,17 +1,23 @@
 import { MissingBuildParameters } from "api/api";
-import { updateWorkspace } from "api/queries/workspaces";
+import {
+	updateWorkspace,
+	workspaceUpdatePreview,
+} from "api/queries/workspaces";
 import type {
 	TemplateVersion,
 	Workspace,
 	WorkspaceBuild,
 	WorkspaceBuildParameter,
+	WorkspaceResourceChange,
 } from "api/typesGenerated";
+import { ErrorAlert } from "components/Alert/ErrorAlert";
 import { ConfirmDialog } from "components/Dialogs/ConfirmDialog/ConfirmDialog";
+import { Loader } from "components/Loader/Loader";
 import { MemoizedInlineMarkdown } from "components/Markdown/Markdown";
 import { UpdateBuildParametersDialog } from "modules/workspaces/WorkspaceMoreActions/UpdateBuildParametersDialog";
 import { UpdateBuildParametersDialogExperimental } from "modules/workspaces/WorkspaceMoreActions/UpdateBuildParametersDialogExperimental";
 import { type FC, useState } from "react";
-import { useMutation, useQueryClient } from "react-query";
+import { useMutation, useQuery, useQueryClient } from "react-query";
 
 type UseWorkspaceUpdateOptions = {
 	workspace: Workspace;
@@ -69,6 +75,7 @@ export const useWorkspaceUpdate = ({
 				open: isConfirmingUpdate,
 				onClose: () => setIsConfirmingUpdate(false),
 				onConfirm: () => confirmUpdate(),
+				workspace,
 				latestVersion,
 			},
 			missingBuildParameters: {
@@ -108,19 +115,32 @@ type UpdateConfirmationDialogProps = {
 	open: boolean;
 	onClose: () => void;
 	onConfirm: () => void;
+	workspace: Workspace;
 	latestVersion?: TemplateVersion;
 };
 
 const UpdateConfirmationDialog: FC<UpdateConfirmationDialogProps> = ({
+	workspace,
 	latestVersion,
 	...dialogProps
 }) => {
+	// The update is previewed against the current resources of the workspace,
+	// so the user can see what it replaces before confirming.
+	const previewQuery = useQuery({
+		...workspaceUpdatePreview(workspace),
+		enabled: dialogProps.open,
+	});
+	const changes = previewQuery.data?.changes ?? [];
+	const isDestructive = changes.some(isDestructiveChange);
+
 	return (
 		<ConfirmDialog
 			{...dialogProps}
+			type={isDestructive ? "delete" : "info"}
 			hideCancel={false}
 			title="Update workspace?"
 			confirmText="Update"
+			confirmLoading={previewQuery.isLoading}
 			description={
 				<div className="flex flex-col gap-2">
 					<p>
@@ -133,12 +153,74 @@ const UpdateConfirmationDialog: FC<UpdateConfirmationDialogProps> = ({
 							{latestVersion.message}
 						</MemoizedInlineMarkdown>
 					)}
+					{previewQuery.isLoading && <Loader size="sm" />}
+					{previewQuery.error && <ErrorAlert error={previewQuery.error} />}
+					{previewQuery.data && (
+						<ResourceChanges changes={changes} isDestructive={isDestructive} />
+					)}
 				</div>
 			}
 		/>
 	);
 };
 
+const isDestructiveChange = (change: WorkspaceResourceChange) =>
+	change.persistent &&
+	(change.action === "replace" || change.action === "delete");
+
+type ResourceChangesProps = {
+	changes: readonly WorkspaceResourceChange[];
+	isDestructive: boolean;
+};
+
+const ResourceChanges: FC<ResourceChangesProps> = ({
+	changes,
+	isDestructive,
+}) => {
+	if (changes.length === 0) {
+		return (
+			<p>The update makes no changes to the resources of the workspace.</p>
+		);
+	}
+
+	return (
+		<>
+			{isDestructive && (
+				<p>
+					The update <strong>replaces or deletes persistent resources</strong>.
+					Their data will be lost.
+				</p>
+			)}
+			<ul className="m-0 p-0 list-none flex flex-col gap-1 text-sm">
+				{changes.map((change) => (
+					<li
+						key={change.address}
+						className={
+							isDestructiveChange(change)
+								? "text-content-destructive"
+								: undefined
+						}
+					>
+						<span className="font-mono">{change.address}</span>{" "}
+						will be {actionLabels[change.action]}
+						{change.replace_paths &&
+							change.replace_paths.length > 0 &&
+							` (${change.replace_paths.join(", ")})`}
+						{change.persistent && " — persistent"}
+					</li>
+				))}
+			</ul>
+		</>
+	);
+};
+
+const actionLabels: Record<WorkspaceResourceChange["action"], string> = {
+	create: "created",
+	update: "updated",
+	replace: "replaced",
+	delete: "deleted",
+};
+
 type MissingBuildParametersDialogProps = {
 	workspace: Workspace;
 	error: unknown;
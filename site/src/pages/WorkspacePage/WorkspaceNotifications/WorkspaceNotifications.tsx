@@ -1,5 +1,6 @@
 import type { Interpolation, Theme } from "@emotion/react";
 import { workspaceResolveAutostart } from "api/queries/workspaceQuota";
+import { workspaceDriftCheck } from "api/queries/workspaces";
 import type {
 	Template,
 	TemplateVersion,
@@ -31,6 +32,7 @@ type WorkspaceNotificationsProps = {
 	onRestartWorkspace: () => void;
 	onUpdateWorkspace: () => void;
 	onActivateWorkspace: () => void;
+	onReconcileWorkspace: () => void;
 	latestVersion?: TemplateVersion;
 };
 
@@ -42,6 +44,7 @@ export const WorkspaceNotifications: FC<WorkspaceNotificationsProps> = ({
 	onRestartWorkspace,
 	onUpdateWorkspace,
 	onActivateWorkspace,
+	onReconcileWorkspace,
 }) => {
 	const notifications: NotificationItem[] = [];
 
@@ -123,6 +126,44 @@ export const WorkspaceNotifications: FC<WorkspaceNotificationsProps> = ({
 		});
 	}
 
+	// Drifted
+	const driftCheckQuery = useQuery({
+		...workspaceDriftCheck(workspace),
+		enabled: template.drift_check_interval_ms > 0,
+	});
+	const driftCheck = driftCheckQuery.data;
+	if (
+		workspace.latest_build.status === "running" &&
+		driftCheck?.drifted &&
+		driftCheck.workspace_build_id === workspace.latest_build.id
+	) {
+		notifications.push({
+			title: "Workspace resources changed outside of Coder",
+			severity: "warning",
+			detail: (
+				<>
+					{driftCheck.resources.length > 1
+						? `${driftCheck.resources.length} resources no longer match`
+						: "1 resource no longer matches"}{" "}
+					the last build:
+					<ul css={{ margin: 0, paddingLeft: 16 }}>
+						{driftCheck.resources.map((resource) => (
+							<li key={resource.address}>
+								<code>{resource.address}</code> was {resource.action}
+							</li>
+						))}
+					</ul>
+					Rebuild the workspace to reconcile it with the template.
+				</>
+			),
+			actions: permissions.updateWorkspace ? (
+				<NotificationActionButton onClick={onReconcileWorkspace}>
+					Reconcile
+				</NotificationActionButton>
+			) : undefined,
+		});
+	}
+
 	// Dormant
 	const { entitlements } = useDashboard();
 	const advancedSchedulingEnabled =
@@ -236,6 +236,12 @@ describe("WorkspacePage", () => {
 			.spyOn(API, "getWorkspaceByOwnerAndName")
 			.mockResolvedValueOnce(MockOutdatedWorkspace);
 
+		jest.spyOn(API, "previewWorkspaceUpdate").mockResolvedValueOnce({
+			workspace_id: MockOutdatedWorkspace.id,
+			created_at: MockWorkspaceBuild.created_at,
+			completed_at: MockWorkspaceBuild.created_at,
+			changes: [],
+		});
 		const updateWorkspaceMock = jest
 			.spyOn(API, "updateWorkspace")
 			.mockResolvedValueOnce(MockWorkspaceBuild);
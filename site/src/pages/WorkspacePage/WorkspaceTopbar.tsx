@@ -220,6 +220,7 @@ export const WorkspaceTopbar: FC<WorkspaceProps> = ({
 						onRestartWorkspace={handleRestart}
 						onUpdateWorkspace={handleUpdate}
 						onActivateWorkspace={handleDormantActivate}
+						onReconcileWorkspace={() => handleStart()}
 					/>
 
 					<WorkspaceStatusIndicator workspace={workspace} />
@@ -675,6 +675,7 @@ export const MockProvisionerJob: TypesGen.ProvisionerJob = {
 	},
 	queue_position: 0,
 	queue_size: 0,
+	priority: "interactive",
 	input: {
 		template_version_id: "test-template-version", // MockTemplateVersion.id
 	},
@@ -827,6 +828,8 @@ export const MockTemplate: TypesGen.Template = {
 	deprecation_message: "",
 	max_port_share_level: "public",
 	use_classic_parameter_flow: true,
+	drift_check_interval_ms: 0,
+	provisioner_version: "",
 };
 
 const MockTemplateVersionFiles: TemplateVersionFiles = {
@@ -3980,6 +3983,8 @@ export const MockSharedPortsResponse: TypesGen.WorkspaceAgentPortShares = {
 			port: 4000,
 			share_level: "authenticated",
 			protocol: "http",
+			auto_shared: false,
+			label: "",
 		},
 		{
 			workspace_id: MockWorkspace.id,
@@ -3987,6 +3992,8 @@ export const MockSharedPortsResponse: TypesGen.WorkspaceAgentPortShares = {
 			port: 4443,
 			share_level: "organization",
 			protocol: "http",
+			auto_shared: false,
+			label: "",
 		},
 		{
 			workspace_id: MockWorkspace.id,
@@ -3994,6 +4001,8 @@ export const MockSharedPortsResponse: TypesGen.WorkspaceAgentPortShares = {
 			port: 65535,
 			share_level: "authenticated",
 			protocol: "https",
+			auto_shared: false,
+			label: "",
 		},
 		{
 			workspace_id: MockWorkspace.id,
@@ -4001,6 +4010,8 @@ export const MockSharedPortsResponse: TypesGen.WorkspaceAgentPortShares = {
 			port: 8081,
 			share_level: "public",
 			protocol: "http",
+			auto_shared: false,
+			label: "",
 		},
 	],
 };
@@ -4225,6 +4236,7 @@ export const MockNotificationTemplates: TypesGen.NotificationTemplate[] = [
 		method: "webhook",
 		kind: "system",
 		enabled_by_default: true,
+		critical: false,
 	},
 	{
 		id: "f517da0b-cdc9-410f-ab89-a86107c420ed",
@@ -4238,6 +4250,7 @@ export const MockNotificationTemplates: TypesGen.NotificationTemplate[] = [
 		method: "smtp",
 		kind: "system",
 		enabled_by_default: true,
+		critical: false,
 	},
 	{
 		id: "f44d9314-ad03-4bc8-95d0-5cad491da6b6",
@@ -4251,6 +4264,7 @@ export const MockNotificationTemplates: TypesGen.NotificationTemplate[] = [
 		method: "",
 		kind: "system",
 		enabled_by_default: true,
+		critical: false,
 	},
 	{
 		id: "4e19c0ac-94e1-4532-9515-d1801aa283b2",
@@ -4264,6 +4278,7 @@ export const MockNotificationTemplates: TypesGen.NotificationTemplate[] = [
 		method: "",
 		kind: "system",
 		enabled_by_default: true,
+		critical: false,
 	},
 	{
 		id: "0ea69165-ec14-4314-91f1-69566ac3c5a0",
@@ -4277,6 +4292,7 @@ export const MockNotificationTemplates: TypesGen.NotificationTemplate[] = [
 		method: "smtp",
 		kind: "system",
 		enabled_by_default: true,
+		critical: false,
 	},
 	{
 		id: "c34a0c09-0704-4cac-bd1c-0c0146811c2b",
@@ -4290,6 +4306,7 @@ export const MockNotificationTemplates: TypesGen.NotificationTemplate[] = [
 		method: "smtp",
 		kind: "system",
 		enabled_by_default: true,
+		critical: false,
 	},
 	{
 		id: "51ce2fdf-c9ca-4be1-8d70-628674f9bc42",
@@ -4303,6 +4320,7 @@ export const MockNotificationTemplates: TypesGen.NotificationTemplate[] = [
 		method: "webhook",
 		kind: "system",
 		enabled_by_default: true,
+		critical: false,
 	},
 ];
 
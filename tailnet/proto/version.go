@@ -56,9 +56,14 @@ import (
 //   - Added support for DeleteSubAgent RPC on the Agent API.
 //   - Added support for ListSubAgents RPC on the Agent API.
 //   - Add ORGANIZATION SharingLevel
+//
+// API v2.7:
+//   - Added `PortShareRules` to the agent manifest.
+//   - Added support for UpdateAutoPortShares RPC on the Agent API.
+//   - Added `TopProcesses` to PushResourcesMonitoringUsageRequest.
 const (
 	CurrentMajor = 2
-	CurrentMinor = 6
+	CurrentMinor = 7
 )
 
 var CurrentVersion = apiversion.New(CurrentMajor, CurrentMinor)